@@ -11,10 +11,15 @@
 
 import 'dart:core' as $core;
 
+import 'package:fixnum/fixnum.dart' as $fixnum;
 import 'package:protobuf/protobuf.dart' as $pb;
 
+import 'agentapi.pbenum.dart';
+
 export 'package:protobuf/protobuf.dart' show GeneratedMessageGenericExtensions;
 
+export 'agentapi.pbenum.dart';
+
 class Empty extends $pb.GeneratedMessage {
   factory Empty() => create();
   Empty._() : super();
@@ -147,58 +152,32 @@ class LandscapeConfig extends $pb.GeneratedMessage {
   void clearConfig() => $_clearField(1);
 }
 
-enum SubscriptionInfo_SubscriptionType {
-  none, 
-  user, 
-  organization, 
-  microsoftStore, 
-  notSet
-}
-
-class SubscriptionInfo extends $pb.GeneratedMessage {
-  factory SubscriptionInfo({
-    $core.String? productId,
-    Empty? none,
-    Empty? user,
-    Empty? organization,
-    Empty? microsoftStore,
+class ProServiceInfo extends $pb.GeneratedMessage {
+  factory ProServiceInfo({
+    $core.Iterable<$core.String>? distros,
+    $core.String? service,
+    $core.bool? enable,
   }) {
     final $result = create();
-    if (productId != null) {
-      $result.productId = productId;
-    }
-    if (none != null) {
-      $result.none = none;
-    }
-    if (user != null) {
-      $result.user = user;
+    if (distros != null) {
+      $result.distros.addAll(distros);
     }
-    if (organization != null) {
-      $result.organization = organization;
+    if (service != null) {
+      $result.service = service;
     }
-    if (microsoftStore != null) {
-      $result.microsoftStore = microsoftStore;
+    if (enable != null) {
+      $result.enable = enable;
     }
     return $result;
   }
-  SubscriptionInfo._() : super();
-  factory SubscriptionInfo.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
-  factory SubscriptionInfo.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+  ProServiceInfo._() : super();
+  factory ProServiceInfo.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ProServiceInfo.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
 
-  static const $core.Map<$core.int, SubscriptionInfo_SubscriptionType> _SubscriptionInfo_SubscriptionTypeByTag = {
-    2 : SubscriptionInfo_SubscriptionType.none,
-    3 : SubscriptionInfo_SubscriptionType.user,
-    4 : SubscriptionInfo_SubscriptionType.organization,
-    5 : SubscriptionInfo_SubscriptionType.microsoftStore,
-    0 : SubscriptionInfo_SubscriptionType.notSet
-  };
-  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'SubscriptionInfo', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
-    ..oo(0, [2, 3, 4, 5])
-    ..aOS(1, _omitFieldNames ? '' : 'productId', protoName: 'productId')
-    ..aOM<Empty>(2, _omitFieldNames ? '' : 'none', subBuilder: Empty.create)
-    ..aOM<Empty>(3, _omitFieldNames ? '' : 'user', subBuilder: Empty.create)
-    ..aOM<Empty>(4, _omitFieldNames ? '' : 'organization', subBuilder: Empty.create)
-    ..aOM<Empty>(5, _omitFieldNames ? '' : 'microsoftStore', protoName: 'microsoftStore', subBuilder: Empty.create)
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ProServiceInfo', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pPS(1, _omitFieldNames ? '' : 'distros')
+    ..aOS(2, _omitFieldNames ? '' : 'service')
+    ..aOB(3, _omitFieldNames ? '' : 'enable')
     ..hasRequiredFields = false
   ;
 
@@ -206,120 +185,71 @@ class SubscriptionInfo extends $pb.GeneratedMessage {
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
   'Will be removed in next major version')
-  SubscriptionInfo clone() => SubscriptionInfo()..mergeFromMessage(this);
+  ProServiceInfo clone() => ProServiceInfo()..mergeFromMessage(this);
   @$core.Deprecated(
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
   'Will be removed in next major version')
-  SubscriptionInfo copyWith(void Function(SubscriptionInfo) updates) => super.copyWith((message) => updates(message as SubscriptionInfo)) as SubscriptionInfo;
+  ProServiceInfo copyWith(void Function(ProServiceInfo) updates) => super.copyWith((message) => updates(message as ProServiceInfo)) as ProServiceInfo;
 
   $pb.BuilderInfo get info_ => _i;
 
   @$core.pragma('dart2js:noInline')
-  static SubscriptionInfo create() => SubscriptionInfo._();
-  SubscriptionInfo createEmptyInstance() => create();
-  static $pb.PbList<SubscriptionInfo> createRepeated() => $pb.PbList<SubscriptionInfo>();
+  static ProServiceInfo create() => ProServiceInfo._();
+  ProServiceInfo createEmptyInstance() => create();
+  static $pb.PbList<ProServiceInfo> createRepeated() => $pb.PbList<ProServiceInfo>();
   @$core.pragma('dart2js:noInline')
-  static SubscriptionInfo getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<SubscriptionInfo>(create);
-  static SubscriptionInfo? _defaultInstance;
-
-  SubscriptionInfo_SubscriptionType whichSubscriptionType() => _SubscriptionInfo_SubscriptionTypeByTag[$_whichOneof(0)]!;
-  void clearSubscriptionType() => $_clearField($_whichOneof(0));
+  static ProServiceInfo getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ProServiceInfo>(create);
+  static ProServiceInfo? _defaultInstance;
 
   @$pb.TagNumber(1)
-  $core.String get productId => $_getSZ(0);
-  @$pb.TagNumber(1)
-  set productId($core.String v) { $_setString(0, v); }
-  @$pb.TagNumber(1)
-  $core.bool hasProductId() => $_has(0);
-  @$pb.TagNumber(1)
-  void clearProductId() => $_clearField(1);
+  $core.List<$core.String> get distros => $_getList(0);
 
   @$pb.TagNumber(2)
-  Empty get none => $_getN(1);
-  @$pb.TagNumber(2)
-  set none(Empty v) { $_setField(2, v); }
+  $core.String get service => $_getSZ(1);
   @$pb.TagNumber(2)
-  $core.bool hasNone() => $_has(1);
+  set service($core.String v) { $_setString(1, v); }
   @$pb.TagNumber(2)
-  void clearNone() => $_clearField(2);
+  $core.bool hasService() => $_has(1);
   @$pb.TagNumber(2)
-  Empty ensureNone() => $_ensure(1);
+  void clearService() => $_clearField(2);
 
   @$pb.TagNumber(3)
-  Empty get user => $_getN(2);
-  @$pb.TagNumber(3)
-  set user(Empty v) { $_setField(3, v); }
+  $core.bool get enable => $_getBF(2);
   @$pb.TagNumber(3)
-  $core.bool hasUser() => $_has(2);
+  set enable($core.bool v) { $_setBool(2, v); }
   @$pb.TagNumber(3)
-  void clearUser() => $_clearField(3);
+  $core.bool hasEnable() => $_has(2);
   @$pb.TagNumber(3)
-  Empty ensureUser() => $_ensure(2);
-
-  @$pb.TagNumber(4)
-  Empty get organization => $_getN(3);
-  @$pb.TagNumber(4)
-  set organization(Empty v) { $_setField(4, v); }
-  @$pb.TagNumber(4)
-  $core.bool hasOrganization() => $_has(3);
-  @$pb.TagNumber(4)
-  void clearOrganization() => $_clearField(4);
-  @$pb.TagNumber(4)
-  Empty ensureOrganization() => $_ensure(3);
-
-  @$pb.TagNumber(5)
-  Empty get microsoftStore => $_getN(4);
-  @$pb.TagNumber(5)
-  set microsoftStore(Empty v) { $_setField(5, v); }
-  @$pb.TagNumber(5)
-  $core.bool hasMicrosoftStore() => $_has(4);
-  @$pb.TagNumber(5)
-  void clearMicrosoftStore() => $_clearField(5);
-  @$pb.TagNumber(5)
-  Empty ensureMicrosoftStore() => $_ensure(4);
-}
-
-enum LandscapeSource_LandscapeSourceType {
-  none, 
-  user, 
-  organization, 
-  notSet
+  void clearEnable() => $_clearField(3);
 }
 
-class LandscapeSource extends $pb.GeneratedMessage {
-  factory LandscapeSource({
-    Empty? none,
-    Empty? user,
-    Empty? organization,
+class UsgProfileInfo extends $pb.GeneratedMessage {
+  factory UsgProfileInfo({
+    $core.Iterable<$core.String>? distros,
+    $core.String? profile,
+    $core.bool? fix,
   }) {
     final $result = create();
-    if (none != null) {
-      $result.none = none;
+    if (distros != null) {
+      $result.distros.addAll(distros);
     }
-    if (user != null) {
-      $result.user = user;
+    if (profile != null) {
+      $result.profile = profile;
     }
-    if (organization != null) {
-      $result.organization = organization;
+    if (fix != null) {
+      $result.fix = fix;
     }
     return $result;
   }
-  LandscapeSource._() : super();
-  factory LandscapeSource.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
-  factory LandscapeSource.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+  UsgProfileInfo._() : super();
+  factory UsgProfileInfo.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory UsgProfileInfo.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
 
-  static const $core.Map<$core.int, LandscapeSource_LandscapeSourceType> _LandscapeSource_LandscapeSourceTypeByTag = {
-    1 : LandscapeSource_LandscapeSourceType.none,
-    2 : LandscapeSource_LandscapeSourceType.user,
-    3 : LandscapeSource_LandscapeSourceType.organization,
-    0 : LandscapeSource_LandscapeSourceType.notSet
-  };
-  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'LandscapeSource', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
-    ..oo(0, [1, 2, 3])
-    ..aOM<Empty>(1, _omitFieldNames ? '' : 'none', subBuilder: Empty.create)
-    ..aOM<Empty>(2, _omitFieldNames ? '' : 'user', subBuilder: Empty.create)
-    ..aOM<Empty>(3, _omitFieldNames ? '' : 'organization', subBuilder: Empty.create)
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'UsgProfileInfo', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pPS(1, _omitFieldNames ? '' : 'distros')
+    ..aOS(2, _omitFieldNames ? '' : 'profile')
+    ..aOB(3, _omitFieldNames ? '' : 'fix')
     ..hasRequiredFields = false
   ;
 
@@ -327,81 +257,76 @@ class LandscapeSource extends $pb.GeneratedMessage {
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
   'Will be removed in next major version')
-  LandscapeSource clone() => LandscapeSource()..mergeFromMessage(this);
+  UsgProfileInfo clone() => UsgProfileInfo()..mergeFromMessage(this);
   @$core.Deprecated(
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
   'Will be removed in next major version')
-  LandscapeSource copyWith(void Function(LandscapeSource) updates) => super.copyWith((message) => updates(message as LandscapeSource)) as LandscapeSource;
+  UsgProfileInfo copyWith(void Function(UsgProfileInfo) updates) => super.copyWith((message) => updates(message as UsgProfileInfo)) as UsgProfileInfo;
 
   $pb.BuilderInfo get info_ => _i;
 
   @$core.pragma('dart2js:noInline')
-  static LandscapeSource create() => LandscapeSource._();
-  LandscapeSource createEmptyInstance() => create();
-  static $pb.PbList<LandscapeSource> createRepeated() => $pb.PbList<LandscapeSource>();
+  static UsgProfileInfo create() => UsgProfileInfo._();
+  UsgProfileInfo createEmptyInstance() => create();
+  static $pb.PbList<UsgProfileInfo> createRepeated() => $pb.PbList<UsgProfileInfo>();
   @$core.pragma('dart2js:noInline')
-  static LandscapeSource getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<LandscapeSource>(create);
-  static LandscapeSource? _defaultInstance;
+  static UsgProfileInfo getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<UsgProfileInfo>(create);
+  static UsgProfileInfo? _defaultInstance;
 
-  LandscapeSource_LandscapeSourceType whichLandscapeSourceType() => _LandscapeSource_LandscapeSourceTypeByTag[$_whichOneof(0)]!;
-  void clearLandscapeSourceType() => $_clearField($_whichOneof(0));
-
-  @$pb.TagNumber(1)
-  Empty get none => $_getN(0);
-  @$pb.TagNumber(1)
-  set none(Empty v) { $_setField(1, v); }
-  @$pb.TagNumber(1)
-  $core.bool hasNone() => $_has(0);
-  @$pb.TagNumber(1)
-  void clearNone() => $_clearField(1);
   @$pb.TagNumber(1)
-  Empty ensureNone() => $_ensure(0);
+  $core.List<$core.String> get distros => $_getList(0);
 
   @$pb.TagNumber(2)
-  Empty get user => $_getN(1);
-  @$pb.TagNumber(2)
-  set user(Empty v) { $_setField(2, v); }
+  $core.String get profile => $_getSZ(1);
   @$pb.TagNumber(2)
-  $core.bool hasUser() => $_has(1);
+  set profile($core.String v) { $_setString(1, v); }
   @$pb.TagNumber(2)
-  void clearUser() => $_clearField(2);
+  $core.bool hasProfile() => $_has(1);
   @$pb.TagNumber(2)
-  Empty ensureUser() => $_ensure(1);
+  void clearProfile() => $_clearField(2);
 
   @$pb.TagNumber(3)
-  Empty get organization => $_getN(2);
-  @$pb.TagNumber(3)
-  set organization(Empty v) { $_setField(3, v); }
+  $core.bool get fix => $_getBF(2);
   @$pb.TagNumber(3)
-  $core.bool hasOrganization() => $_has(2);
+  set fix($core.bool v) { $_setBool(2, v); }
   @$pb.TagNumber(3)
-  void clearOrganization() => $_clearField(3);
+  $core.bool hasFix() => $_has(2);
   @$pb.TagNumber(3)
-  Empty ensureOrganization() => $_ensure(2);
+  void clearFix() => $_clearField(3);
 }
 
-class ConfigSources extends $pb.GeneratedMessage {
-  factory ConfigSources({
-    SubscriptionInfo? proSubscription,
-    LandscapeSource? landscapeSource,
+class ManageUserInfo extends $pb.GeneratedMessage {
+  factory ManageUserInfo({
+    $core.Iterable<$core.String>? distros,
+    $core.String? name,
+    $core.Iterable<$core.String>? groups,
+    $core.bool? setDefault,
   }) {
     final $result = create();
-    if (proSubscription != null) {
-      $result.proSubscription = proSubscription;
+    if (distros != null) {
+      $result.distros.addAll(distros);
     }
-    if (landscapeSource != null) {
-      $result.landscapeSource = landscapeSource;
+    if (name != null) {
+      $result.name = name;
+    }
+    if (groups != null) {
+      $result.groups.addAll(groups);
+    }
+    if (setDefault != null) {
+      $result.setDefault = setDefault;
     }
     return $result;
   }
-  ConfigSources._() : super();
-  factory ConfigSources.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
-  factory ConfigSources.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+  ManageUserInfo._() : super();
+  factory ManageUserInfo.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ManageUserInfo.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
 
-  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ConfigSources', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
-    ..aOM<SubscriptionInfo>(1, _omitFieldNames ? '' : 'proSubscription', protoName: 'proSubscription', subBuilder: SubscriptionInfo.create)
-    ..aOM<LandscapeSource>(2, _omitFieldNames ? '' : 'landscapeSource', protoName: 'landscapeSource', subBuilder: LandscapeSource.create)
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ManageUserInfo', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pPS(1, _omitFieldNames ? '' : 'distros')
+    ..aOS(2, _omitFieldNames ? '' : 'name')
+    ..pPS(3, _omitFieldNames ? '' : 'groups')
+    ..aOB(4, _omitFieldNames ? '' : 'setDefault')
     ..hasRequiredFields = false
   ;
 
@@ -409,87 +334,93 @@ class ConfigSources extends $pb.GeneratedMessage {
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
   'Will be removed in next major version')
-  ConfigSources clone() => ConfigSources()..mergeFromMessage(this);
+  ManageUserInfo clone() => ManageUserInfo()..mergeFromMessage(this);
   @$core.Deprecated(
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
   'Will be removed in next major version')
-  ConfigSources copyWith(void Function(ConfigSources) updates) => super.copyWith((message) => updates(message as ConfigSources)) as ConfigSources;
+  ManageUserInfo copyWith(void Function(ManageUserInfo) updates) => super.copyWith((message) => updates(message as ManageUserInfo)) as ManageUserInfo;
 
   $pb.BuilderInfo get info_ => _i;
 
   @$core.pragma('dart2js:noInline')
-  static ConfigSources create() => ConfigSources._();
-  ConfigSources createEmptyInstance() => create();
-  static $pb.PbList<ConfigSources> createRepeated() => $pb.PbList<ConfigSources>();
+  static ManageUserInfo create() => ManageUserInfo._();
+  ManageUserInfo createEmptyInstance() => create();
+  static $pb.PbList<ManageUserInfo> createRepeated() => $pb.PbList<ManageUserInfo>();
   @$core.pragma('dart2js:noInline')
-  static ConfigSources getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ConfigSources>(create);
-  static ConfigSources? _defaultInstance;
+  static ManageUserInfo getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ManageUserInfo>(create);
+  static ManageUserInfo? _defaultInstance;
 
   @$pb.TagNumber(1)
-  SubscriptionInfo get proSubscription => $_getN(0);
-  @$pb.TagNumber(1)
-  set proSubscription(SubscriptionInfo v) { $_setField(1, v); }
-  @$pb.TagNumber(1)
-  $core.bool hasProSubscription() => $_has(0);
-  @$pb.TagNumber(1)
-  void clearProSubscription() => $_clearField(1);
-  @$pb.TagNumber(1)
-  SubscriptionInfo ensureProSubscription() => $_ensure(0);
+  $core.List<$core.String> get distros => $_getList(0);
 
   @$pb.TagNumber(2)
-  LandscapeSource get landscapeSource => $_getN(1);
-  @$pb.TagNumber(2)
-  set landscapeSource(LandscapeSource v) { $_setField(2, v); }
+  $core.String get name => $_getSZ(1);
   @$pb.TagNumber(2)
-  $core.bool hasLandscapeSource() => $_has(1);
+  set name($core.String v) { $_setString(1, v); }
   @$pb.TagNumber(2)
-  void clearLandscapeSource() => $_clearField(2);
+  $core.bool hasName() => $_has(1);
   @$pb.TagNumber(2)
-  LandscapeSource ensureLandscapeSource() => $_ensure(1);
+  void clearName() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.List<$core.String> get groups => $_getList(2);
+
+  @$pb.TagNumber(4)
+  $core.bool get setDefault => $_getBF(3);
+  @$pb.TagNumber(4)
+  set setDefault($core.bool v) { $_setBool(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasSetDefault() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearSetDefault() => $_clearField(4);
 }
 
-class DistroInfo extends $pb.GeneratedMessage {
-  factory DistroInfo({
-    $core.String? wslName,
-    $core.String? id,
-    $core.String? versionId,
-    $core.String? prettyName,
-    $core.bool? proAttached,
-    $core.String? hostname,
+enum ManageDistroRequest_Operation {
+  setSparse, 
+  move, 
+  setVersion, 
+  notSet
+}
+
+class ManageDistroRequest extends $pb.GeneratedMessage {
+  factory ManageDistroRequest({
+    $core.Iterable<$core.String>? distros,
+    $core.bool? setSparse,
+    $core.String? move,
+    $core.int? setVersion,
   }) {
     final $result = create();
-    if (wslName != null) {
-      $result.wslName = wslName;
-    }
-    if (id != null) {
-      $result.id = id;
-    }
-    if (versionId != null) {
-      $result.versionId = versionId;
+    if (distros != null) {
+      $result.distros.addAll(distros);
     }
-    if (prettyName != null) {
-      $result.prettyName = prettyName;
+    if (setSparse != null) {
+      $result.setSparse = setSparse;
     }
-    if (proAttached != null) {
-      $result.proAttached = proAttached;
+    if (move != null) {
+      $result.move = move;
     }
-    if (hostname != null) {
-      $result.hostname = hostname;
+    if (setVersion != null) {
+      $result.setVersion = setVersion;
     }
     return $result;
   }
-  DistroInfo._() : super();
-  factory DistroInfo.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
-  factory DistroInfo.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+  ManageDistroRequest._() : super();
+  factory ManageDistroRequest.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ManageDistroRequest.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
 
-  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'DistroInfo', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
-    ..aOS(1, _omitFieldNames ? '' : 'wslName')
-    ..aOS(2, _omitFieldNames ? '' : 'id')
-    ..aOS(3, _omitFieldNames ? '' : 'versionId')
-    ..aOS(4, _omitFieldNames ? '' : 'prettyName')
-    ..aOB(5, _omitFieldNames ? '' : 'proAttached')
-    ..aOS(6, _omitFieldNames ? '' : 'hostname')
+  static const $core.Map<$core.int, ManageDistroRequest_Operation> _ManageDistroRequest_OperationByTag = {
+    2 : ManageDistroRequest_Operation.setSparse,
+    3 : ManageDistroRequest_Operation.move,
+    4 : ManageDistroRequest_Operation.setVersion,
+    0 : ManageDistroRequest_Operation.notSet
+  };
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ManageDistroRequest', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..oo(0, [2, 3, 4])
+    ..pPS(1, _omitFieldNames ? '' : 'distros')
+    ..aOB(2, _omitFieldNames ? '' : 'setSparse')
+    ..aOS(3, _omitFieldNames ? '' : 'move')
+    ..a<$core.int>(4, _omitFieldNames ? '' : 'setVersion', $pb.PbFieldType.O3)
     ..hasRequiredFields = false
   ;
 
@@ -497,94 +428,4791 @@ class DistroInfo extends $pb.GeneratedMessage {
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
   'Will be removed in next major version')
-  DistroInfo clone() => DistroInfo()..mergeFromMessage(this);
+  ManageDistroRequest clone() => ManageDistroRequest()..mergeFromMessage(this);
   @$core.Deprecated(
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
   'Will be removed in next major version')
-  DistroInfo copyWith(void Function(DistroInfo) updates) => super.copyWith((message) => updates(message as DistroInfo)) as DistroInfo;
+  ManageDistroRequest copyWith(void Function(ManageDistroRequest) updates) => super.copyWith((message) => updates(message as ManageDistroRequest)) as ManageDistroRequest;
 
   $pb.BuilderInfo get info_ => _i;
 
   @$core.pragma('dart2js:noInline')
-  static DistroInfo create() => DistroInfo._();
-  DistroInfo createEmptyInstance() => create();
-  static $pb.PbList<DistroInfo> createRepeated() => $pb.PbList<DistroInfo>();
+  static ManageDistroRequest create() => ManageDistroRequest._();
+  ManageDistroRequest createEmptyInstance() => create();
+  static $pb.PbList<ManageDistroRequest> createRepeated() => $pb.PbList<ManageDistroRequest>();
   @$core.pragma('dart2js:noInline')
-  static DistroInfo getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<DistroInfo>(create);
-  static DistroInfo? _defaultInstance;
+  static ManageDistroRequest getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ManageDistroRequest>(create);
+  static ManageDistroRequest? _defaultInstance;
+
+  ManageDistroRequest_Operation whichOperation() => _ManageDistroRequest_OperationByTag[$_whichOneof(0)]!;
+  void clearOperation() => $_clearField($_whichOneof(0));
 
   @$pb.TagNumber(1)
-  $core.String get wslName => $_getSZ(0);
-  @$pb.TagNumber(1)
-  set wslName($core.String v) { $_setString(0, v); }
-  @$pb.TagNumber(1)
-  $core.bool hasWslName() => $_has(0);
-  @$pb.TagNumber(1)
-  void clearWslName() => $_clearField(1);
+  $core.List<$core.String> get distros => $_getList(0);
+
+  @$pb.TagNumber(2)
+  $core.bool get setSparse => $_getBF(1);
+  @$pb.TagNumber(2)
+  set setSparse($core.bool v) { $_setBool(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasSetSparse() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearSetSparse() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get move => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set move($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasMove() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearMove() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.int get setVersion => $_getIZ(3);
+  @$pb.TagNumber(4)
+  set setVersion($core.int v) { $_setSignedInt32(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasSetVersion() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearSetVersion() => $_clearField(4);
+}
+
+class GetEventsRequest extends $pb.GeneratedMessage {
+  factory GetEventsRequest({
+    $core.String? sinceToken,
+  }) {
+    final $result = create();
+    if (sinceToken != null) {
+      $result.sinceToken = sinceToken;
+    }
+    return $result;
+  }
+  GetEventsRequest._() : super();
+  factory GetEventsRequest.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory GetEventsRequest.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'GetEventsRequest', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'sinceToken')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  GetEventsRequest clone() => GetEventsRequest()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  GetEventsRequest copyWith(void Function(GetEventsRequest) updates) => super.copyWith((message) => updates(message as GetEventsRequest)) as GetEventsRequest;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static GetEventsRequest create() => GetEventsRequest._();
+  GetEventsRequest createEmptyInstance() => create();
+  static $pb.PbList<GetEventsRequest> createRepeated() => $pb.PbList<GetEventsRequest>();
+  @$core.pragma('dart2js:noInline')
+  static GetEventsRequest getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<GetEventsRequest>(create);
+  static GetEventsRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get sinceToken => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set sinceToken($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasSinceToken() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearSinceToken() => $_clearField(1);
+}
+
+class Events extends $pb.GeneratedMessage {
+  factory Events({
+    $core.Iterable<AgentEvent>? events,
+    $core.String? nextToken,
+    $core.bool? truncated,
+  }) {
+    final $result = create();
+    if (events != null) {
+      $result.events.addAll(events);
+    }
+    if (nextToken != null) {
+      $result.nextToken = nextToken;
+    }
+    if (truncated != null) {
+      $result.truncated = truncated;
+    }
+    return $result;
+  }
+  Events._() : super();
+  factory Events.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory Events.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'Events', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pc<AgentEvent>(1, _omitFieldNames ? '' : 'events', $pb.PbFieldType.PM, subBuilder: AgentEvent.create)
+    ..aOS(2, _omitFieldNames ? '' : 'nextToken')
+    ..aOB(3, _omitFieldNames ? '' : 'truncated')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  Events clone() => Events()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  Events copyWith(void Function(Events) updates) => super.copyWith((message) => updates(message as Events)) as Events;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static Events create() => Events._();
+  Events createEmptyInstance() => create();
+  static $pb.PbList<Events> createRepeated() => $pb.PbList<Events>();
+  @$core.pragma('dart2js:noInline')
+  static Events getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<Events>(create);
+  static Events? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.List<AgentEvent> get events => $_getList(0);
+
+  @$pb.TagNumber(2)
+  $core.String get nextToken => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set nextToken($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasNextToken() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearNextToken() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.bool get truncated => $_getBF(2);
+  @$pb.TagNumber(3)
+  set truncated($core.bool v) { $_setBool(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasTruncated() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearTruncated() => $_clearField(3);
+}
+
+class AgentEvent extends $pb.GeneratedMessage {
+  factory AgentEvent({
+    AgentEventType? type,
+    $core.String? time,
+    $core.String? distro,
+    $core.String? message,
+    TaskResult? result,
+  }) {
+    final $result = create();
+    if (type != null) {
+      $result.type = type;
+    }
+    if (time != null) {
+      $result.time = time;
+    }
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    if (message != null) {
+      $result.message = message;
+    }
+    if (result != null) {
+      $result.result = result;
+    }
+    return $result;
+  }
+  AgentEvent._() : super();
+  factory AgentEvent.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory AgentEvent.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'AgentEvent', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..e<AgentEventType>(1, _omitFieldNames ? '' : 'type', $pb.PbFieldType.OE, defaultOrMaker: AgentEventType.AGENT_EVENT_UNSPECIFIED, valueOf: AgentEventType.valueOf, enumValues: AgentEventType.values)
+    ..aOS(2, _omitFieldNames ? '' : 'time')
+    ..aOS(3, _omitFieldNames ? '' : 'distro')
+    ..aOS(4, _omitFieldNames ? '' : 'message')
+    ..aOM<TaskResult>(5, _omitFieldNames ? '' : 'result', subBuilder: TaskResult.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  AgentEvent clone() => AgentEvent()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  AgentEvent copyWith(void Function(AgentEvent) updates) => super.copyWith((message) => updates(message as AgentEvent)) as AgentEvent;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static AgentEvent create() => AgentEvent._();
+  AgentEvent createEmptyInstance() => create();
+  static $pb.PbList<AgentEvent> createRepeated() => $pb.PbList<AgentEvent>();
+  @$core.pragma('dart2js:noInline')
+  static AgentEvent getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<AgentEvent>(create);
+  static AgentEvent? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  AgentEventType get type => $_getN(0);
+  @$pb.TagNumber(1)
+  set type(AgentEventType v) { $_setField(1, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasType() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearType() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get time => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set time($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasTime() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearTime() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get distro => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set distro($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasDistro() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearDistro() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get message => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set message($core.String v) { $_setString(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasMessage() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearMessage() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  TaskResult get result => $_getN(4);
+  @$pb.TagNumber(5)
+  set result(TaskResult v) { $_setField(5, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasResult() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearResult() => $_clearField(5);
+  @$pb.TagNumber(5)
+  TaskResult ensureResult() => $_ensure(4);
+}
+
+class ConsentRequest extends $pb.GeneratedMessage {
+  factory ConsentRequest({
+    $core.String? id,
+    $core.String? distro,
+    $core.String? prompt,
+    $core.String? deadline,
+  }) {
+    final $result = create();
+    if (id != null) {
+      $result.id = id;
+    }
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    if (prompt != null) {
+      $result.prompt = prompt;
+    }
+    if (deadline != null) {
+      $result.deadline = deadline;
+    }
+    return $result;
+  }
+  ConsentRequest._() : super();
+  factory ConsentRequest.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ConsentRequest.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ConsentRequest', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'id')
+    ..aOS(2, _omitFieldNames ? '' : 'distro')
+    ..aOS(3, _omitFieldNames ? '' : 'prompt')
+    ..aOS(4, _omitFieldNames ? '' : 'deadline')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ConsentRequest clone() => ConsentRequest()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ConsentRequest copyWith(void Function(ConsentRequest) updates) => super.copyWith((message) => updates(message as ConsentRequest)) as ConsentRequest;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ConsentRequest create() => ConsentRequest._();
+  ConsentRequest createEmptyInstance() => create();
+  static $pb.PbList<ConsentRequest> createRepeated() => $pb.PbList<ConsentRequest>();
+  @$core.pragma('dart2js:noInline')
+  static ConsentRequest getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ConsentRequest>(create);
+  static ConsentRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get id => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set id($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasId() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearId() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get distro => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set distro($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasDistro() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearDistro() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get prompt => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set prompt($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasPrompt() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearPrompt() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get deadline => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set deadline($core.String v) { $_setString(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasDeadline() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearDeadline() => $_clearField(4);
+}
+
+class ConsentAnswer extends $pb.GeneratedMessage {
+  factory ConsentAnswer({
+    $core.String? id,
+    $core.bool? granted,
+  }) {
+    final $result = create();
+    if (id != null) {
+      $result.id = id;
+    }
+    if (granted != null) {
+      $result.granted = granted;
+    }
+    return $result;
+  }
+  ConsentAnswer._() : super();
+  factory ConsentAnswer.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ConsentAnswer.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ConsentAnswer', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'id')
+    ..aOB(2, _omitFieldNames ? '' : 'granted')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ConsentAnswer clone() => ConsentAnswer()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ConsentAnswer copyWith(void Function(ConsentAnswer) updates) => super.copyWith((message) => updates(message as ConsentAnswer)) as ConsentAnswer;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ConsentAnswer create() => ConsentAnswer._();
+  ConsentAnswer createEmptyInstance() => create();
+  static $pb.PbList<ConsentAnswer> createRepeated() => $pb.PbList<ConsentAnswer>();
+  @$core.pragma('dart2js:noInline')
+  static ConsentAnswer getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ConsentAnswer>(create);
+  static ConsentAnswer? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get id => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set id($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasId() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearId() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.bool get granted => $_getBF(1);
+  @$pb.TagNumber(2)
+  set granted($core.bool v) { $_setBool(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasGranted() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearGranted() => $_clearField(2);
+}
+
+class UsgReportRequest extends $pb.GeneratedMessage {
+  factory UsgReportRequest({
+    $core.String? distro,
+    $core.String? profile,
+  }) {
+    final $result = create();
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    if (profile != null) {
+      $result.profile = profile;
+    }
+    return $result;
+  }
+  UsgReportRequest._() : super();
+  factory UsgReportRequest.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory UsgReportRequest.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'UsgReportRequest', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'distro')
+    ..aOS(2, _omitFieldNames ? '' : 'profile')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  UsgReportRequest clone() => UsgReportRequest()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  UsgReportRequest copyWith(void Function(UsgReportRequest) updates) => super.copyWith((message) => updates(message as UsgReportRequest)) as UsgReportRequest;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static UsgReportRequest create() => UsgReportRequest._();
+  UsgReportRequest createEmptyInstance() => create();
+  static $pb.PbList<UsgReportRequest> createRepeated() => $pb.PbList<UsgReportRequest>();
+  @$core.pragma('dart2js:noInline')
+  static UsgReportRequest getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<UsgReportRequest>(create);
+  static UsgReportRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get distro => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set distro($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasDistro() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearDistro() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get profile => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set profile($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasProfile() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearProfile() => $_clearField(2);
+}
+
+class UsgReport extends $pb.GeneratedMessage {
+  factory UsgReport({
+    $core.String? distro,
+    $core.String? profile,
+    $core.String? timestamp,
+    $core.List<$core.int>? report,
+  }) {
+    final $result = create();
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    if (profile != null) {
+      $result.profile = profile;
+    }
+    if (timestamp != null) {
+      $result.timestamp = timestamp;
+    }
+    if (report != null) {
+      $result.report = report;
+    }
+    return $result;
+  }
+  UsgReport._() : super();
+  factory UsgReport.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory UsgReport.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'UsgReport', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'distro')
+    ..aOS(2, _omitFieldNames ? '' : 'profile')
+    ..aOS(3, _omitFieldNames ? '' : 'timestamp')
+    ..a<$core.List<$core.int>>(4, _omitFieldNames ? '' : 'report', $pb.PbFieldType.OY)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  UsgReport clone() => UsgReport()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  UsgReport copyWith(void Function(UsgReport) updates) => super.copyWith((message) => updates(message as UsgReport)) as UsgReport;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static UsgReport create() => UsgReport._();
+  UsgReport createEmptyInstance() => create();
+  static $pb.PbList<UsgReport> createRepeated() => $pb.PbList<UsgReport>();
+  @$core.pragma('dart2js:noInline')
+  static UsgReport getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<UsgReport>(create);
+  static UsgReport? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get distro => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set distro($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasDistro() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearDistro() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get profile => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set profile($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasProfile() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearProfile() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get timestamp => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set timestamp($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasTimestamp() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearTimestamp() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.List<$core.int> get report => $_getN(3);
+  @$pb.TagNumber(4)
+  set report($core.List<$core.int> v) { $_setBytes(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasReport() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearReport() => $_clearField(4);
+}
+
+class TailLogRequest extends $pb.GeneratedMessage {
+  factory TailLogRequest({
+    $core.String? distro,
+    $core.int? lines,
+    $core.String? priority,
+    $core.bool? includeProClient,
+    $core.bool? follow,
+  }) {
+    final $result = create();
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    if (lines != null) {
+      $result.lines = lines;
+    }
+    if (priority != null) {
+      $result.priority = priority;
+    }
+    if (includeProClient != null) {
+      $result.includeProClient = includeProClient;
+    }
+    if (follow != null) {
+      $result.follow = follow;
+    }
+    return $result;
+  }
+  TailLogRequest._() : super();
+  factory TailLogRequest.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory TailLogRequest.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'TailLogRequest', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'distro')
+    ..a<$core.int>(2, _omitFieldNames ? '' : 'lines', $pb.PbFieldType.O3)
+    ..aOS(3, _omitFieldNames ? '' : 'priority')
+    ..aOB(4, _omitFieldNames ? '' : 'includeProClient')
+    ..aOB(5, _omitFieldNames ? '' : 'follow')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  TailLogRequest clone() => TailLogRequest()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  TailLogRequest copyWith(void Function(TailLogRequest) updates) => super.copyWith((message) => updates(message as TailLogRequest)) as TailLogRequest;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TailLogRequest create() => TailLogRequest._();
+  TailLogRequest createEmptyInstance() => create();
+  static $pb.PbList<TailLogRequest> createRepeated() => $pb.PbList<TailLogRequest>();
+  @$core.pragma('dart2js:noInline')
+  static TailLogRequest getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<TailLogRequest>(create);
+  static TailLogRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get distro => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set distro($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasDistro() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearDistro() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.int get lines => $_getIZ(1);
+  @$pb.TagNumber(2)
+  set lines($core.int v) { $_setSignedInt32(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasLines() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearLines() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get priority => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set priority($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasPriority() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearPriority() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.bool get includeProClient => $_getBF(3);
+  @$pb.TagNumber(4)
+  set includeProClient($core.bool v) { $_setBool(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasIncludeProClient() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearIncludeProClient() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.bool get follow => $_getBF(4);
+  @$pb.TagNumber(5)
+  set follow($core.bool v) { $_setBool(4, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasFollow() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearFollow() => $_clearField(5);
+}
+
+class LogLine extends $pb.GeneratedMessage {
+  factory LogLine({
+    $core.String? line,
+  }) {
+    final $result = create();
+    if (line != null) {
+      $result.line = line;
+    }
+    return $result;
+  }
+  LogLine._() : super();
+  factory LogLine.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory LogLine.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'LogLine', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'line')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  LogLine clone() => LogLine()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  LogLine copyWith(void Function(LogLine) updates) => super.copyWith((message) => updates(message as LogLine)) as LogLine;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static LogLine create() => LogLine._();
+  LogLine createEmptyInstance() => create();
+  static $pb.PbList<LogLine> createRepeated() => $pb.PbList<LogLine>();
+  @$core.pragma('dart2js:noInline')
+  static LogLine getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<LogLine>(create);
+  static LogLine? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get line => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set line($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasLine() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearLine() => $_clearField(1);
+}
+
+class WatchTasksRequest extends $pb.GeneratedMessage {
+  factory WatchTasksRequest({
+    $core.String? distro,
+  }) {
+    final $result = create();
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    return $result;
+  }
+  WatchTasksRequest._() : super();
+  factory WatchTasksRequest.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory WatchTasksRequest.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'WatchTasksRequest', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'distro')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  WatchTasksRequest clone() => WatchTasksRequest()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  WatchTasksRequest copyWith(void Function(WatchTasksRequest) updates) => super.copyWith((message) => updates(message as WatchTasksRequest)) as WatchTasksRequest;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static WatchTasksRequest create() => WatchTasksRequest._();
+  WatchTasksRequest createEmptyInstance() => create();
+  static $pb.PbList<WatchTasksRequest> createRepeated() => $pb.PbList<WatchTasksRequest>();
+  @$core.pragma('dart2js:noInline')
+  static WatchTasksRequest getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<WatchTasksRequest>(create);
+  static WatchTasksRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get distro => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set distro($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasDistro() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearDistro() => $_clearField(1);
+}
+
+class TaskEvent extends $pb.GeneratedMessage {
+  factory TaskEvent({
+    TaskEventType? type,
+    $core.String? task,
+    $core.int? progress,
+    $core.String? reason,
+    TaskResult? result,
+    $core.String? step,
+  }) {
+    final $result = create();
+    if (type != null) {
+      $result.type = type;
+    }
+    if (task != null) {
+      $result.task = task;
+    }
+    if (progress != null) {
+      $result.progress = progress;
+    }
+    if (reason != null) {
+      $result.reason = reason;
+    }
+    if (result != null) {
+      $result.result = result;
+    }
+    if (step != null) {
+      $result.step = step;
+    }
+    return $result;
+  }
+  TaskEvent._() : super();
+  factory TaskEvent.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory TaskEvent.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'TaskEvent', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..e<TaskEventType>(1, _omitFieldNames ? '' : 'type', $pb.PbFieldType.OE, defaultOrMaker: TaskEventType.TASK_EVENT_UNSPECIFIED, valueOf: TaskEventType.valueOf, enumValues: TaskEventType.values)
+    ..aOS(2, _omitFieldNames ? '' : 'task')
+    ..a<$core.int>(3, _omitFieldNames ? '' : 'progress', $pb.PbFieldType.OU3)
+    ..aOS(4, _omitFieldNames ? '' : 'reason')
+    ..aOM<TaskResult>(5, _omitFieldNames ? '' : 'result', subBuilder: TaskResult.create)
+    ..aOS(6, _omitFieldNames ? '' : 'step')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  TaskEvent clone() => TaskEvent()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  TaskEvent copyWith(void Function(TaskEvent) updates) => super.copyWith((message) => updates(message as TaskEvent)) as TaskEvent;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TaskEvent create() => TaskEvent._();
+  TaskEvent createEmptyInstance() => create();
+  static $pb.PbList<TaskEvent> createRepeated() => $pb.PbList<TaskEvent>();
+  @$core.pragma('dart2js:noInline')
+  static TaskEvent getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<TaskEvent>(create);
+  static TaskEvent? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  TaskEventType get type => $_getN(0);
+  @$pb.TagNumber(1)
+  set type(TaskEventType v) { $_setField(1, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasType() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearType() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get task => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set task($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasTask() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearTask() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.int get progress => $_getIZ(2);
+  @$pb.TagNumber(3)
+  set progress($core.int v) { $_setUnsignedInt32(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasProgress() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearProgress() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get reason => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set reason($core.String v) { $_setString(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasReason() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearReason() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  TaskResult get result => $_getN(4);
+  @$pb.TagNumber(5)
+  set result(TaskResult v) { $_setField(5, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasResult() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearResult() => $_clearField(5);
+  @$pb.TagNumber(5)
+  TaskResult ensureResult() => $_ensure(4);
+
+  @$pb.TagNumber(6)
+  $core.String get step => $_getSZ(5);
+  @$pb.TagNumber(6)
+  set step($core.String v) { $_setString(5, v); }
+  @$pb.TagNumber(6)
+  $core.bool hasStep() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearStep() => $_clearField(6);
+}
+
+class TaskResult extends $pb.GeneratedMessage {
+  factory TaskResult({
+    $core.Iterable<TaskStep>? steps,
+  }) {
+    final $result = create();
+    if (steps != null) {
+      $result.steps.addAll(steps);
+    }
+    return $result;
+  }
+  TaskResult._() : super();
+  factory TaskResult.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory TaskResult.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'TaskResult', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pc<TaskStep>(1, _omitFieldNames ? '' : 'steps', $pb.PbFieldType.PM, subBuilder: TaskStep.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  TaskResult clone() => TaskResult()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  TaskResult copyWith(void Function(TaskResult) updates) => super.copyWith((message) => updates(message as TaskResult)) as TaskResult;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TaskResult create() => TaskResult._();
+  TaskResult createEmptyInstance() => create();
+  static $pb.PbList<TaskResult> createRepeated() => $pb.PbList<TaskResult>();
+  @$core.pragma('dart2js:noInline')
+  static TaskResult getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<TaskResult>(create);
+  static TaskResult? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.List<TaskStep> get steps => $_getList(0);
+}
+
+class TaskStep extends $pb.GeneratedMessage {
+  factory TaskStep({
+    $core.String? name,
+    TaskStepStatus? status,
+    $core.String? message,
+  }) {
+    final $result = create();
+    if (name != null) {
+      $result.name = name;
+    }
+    if (status != null) {
+      $result.status = status;
+    }
+    if (message != null) {
+      $result.message = message;
+    }
+    return $result;
+  }
+  TaskStep._() : super();
+  factory TaskStep.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory TaskStep.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'TaskStep', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'name')
+    ..e<TaskStepStatus>(2, _omitFieldNames ? '' : 'status', $pb.PbFieldType.OE, defaultOrMaker: TaskStepStatus.TASK_STEP_UNSPECIFIED, valueOf: TaskStepStatus.valueOf, enumValues: TaskStepStatus.values)
+    ..aOS(3, _omitFieldNames ? '' : 'message')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  TaskStep clone() => TaskStep()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  TaskStep copyWith(void Function(TaskStep) updates) => super.copyWith((message) => updates(message as TaskStep)) as TaskStep;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TaskStep create() => TaskStep._();
+  TaskStep createEmptyInstance() => create();
+  static $pb.PbList<TaskStep> createRepeated() => $pb.PbList<TaskStep>();
+  @$core.pragma('dart2js:noInline')
+  static TaskStep getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<TaskStep>(create);
+  static TaskStep? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get name => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set name($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  TaskStepStatus get status => $_getN(1);
+  @$pb.TagNumber(2)
+  set status(TaskStepStatus v) { $_setField(2, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasStatus() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearStatus() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get message => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set message($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasMessage() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearMessage() => $_clearField(3);
+}
+
+class NotificationSettings extends $pb.GeneratedMessage {
+  factory NotificationSettings({
+    $core.String? frequency,
+  }) {
+    final $result = create();
+    if (frequency != null) {
+      $result.frequency = frequency;
+    }
+    return $result;
+  }
+  NotificationSettings._() : super();
+  factory NotificationSettings.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory NotificationSettings.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'NotificationSettings', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'frequency')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  NotificationSettings clone() => NotificationSettings()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  NotificationSettings copyWith(void Function(NotificationSettings) updates) => super.copyWith((message) => updates(message as NotificationSettings)) as NotificationSettings;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static NotificationSettings create() => NotificationSettings._();
+  NotificationSettings createEmptyInstance() => create();
+  static $pb.PbList<NotificationSettings> createRepeated() => $pb.PbList<NotificationSettings>();
+  @$core.pragma('dart2js:noInline')
+  static NotificationSettings getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<NotificationSettings>(create);
+  static NotificationSettings? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get frequency => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set frequency($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasFrequency() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearFrequency() => $_clearField(1);
+}
+
+class Latencies extends $pb.GeneratedMessage {
+  factory Latencies({
+    $core.Iterable<DistroLatency>? distros,
+  }) {
+    final $result = create();
+    if (distros != null) {
+      $result.distros.addAll(distros);
+    }
+    return $result;
+  }
+  Latencies._() : super();
+  factory Latencies.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory Latencies.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'Latencies', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pc<DistroLatency>(1, _omitFieldNames ? '' : 'distros', $pb.PbFieldType.PM, subBuilder: DistroLatency.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  Latencies clone() => Latencies()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  Latencies copyWith(void Function(Latencies) updates) => super.copyWith((message) => updates(message as Latencies)) as Latencies;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static Latencies create() => Latencies._();
+  Latencies createEmptyInstance() => create();
+  static $pb.PbList<Latencies> createRepeated() => $pb.PbList<Latencies>();
+  @$core.pragma('dart2js:noInline')
+  static Latencies getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<Latencies>(create);
+  static Latencies? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.List<DistroLatency> get distros => $_getList(0);
+}
+
+class DistroLatency extends $pb.GeneratedMessage {
+  factory DistroLatency({
+    $core.String? distro,
+    $core.double? lastMs,
+    $core.double? minMs,
+    $core.double? maxMs,
+    $core.double? meanMs,
+    $core.int? samples,
+    $core.int? failures,
+    $core.String? lastProbe,
+    $core.String? lastError,
+  }) {
+    final $result = create();
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    if (lastMs != null) {
+      $result.lastMs = lastMs;
+    }
+    if (minMs != null) {
+      $result.minMs = minMs;
+    }
+    if (maxMs != null) {
+      $result.maxMs = maxMs;
+    }
+    if (meanMs != null) {
+      $result.meanMs = meanMs;
+    }
+    if (samples != null) {
+      $result.samples = samples;
+    }
+    if (failures != null) {
+      $result.failures = failures;
+    }
+    if (lastProbe != null) {
+      $result.lastProbe = lastProbe;
+    }
+    if (lastError != null) {
+      $result.lastError = lastError;
+    }
+    return $result;
+  }
+  DistroLatency._() : super();
+  factory DistroLatency.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory DistroLatency.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'DistroLatency', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'distro')
+    ..a<$core.double>(2, _omitFieldNames ? '' : 'lastMs', $pb.PbFieldType.OD)
+    ..a<$core.double>(3, _omitFieldNames ? '' : 'minMs', $pb.PbFieldType.OD)
+    ..a<$core.double>(4, _omitFieldNames ? '' : 'maxMs', $pb.PbFieldType.OD)
+    ..a<$core.double>(5, _omitFieldNames ? '' : 'meanMs', $pb.PbFieldType.OD)
+    ..a<$core.int>(6, _omitFieldNames ? '' : 'samples', $pb.PbFieldType.O3)
+    ..a<$core.int>(7, _omitFieldNames ? '' : 'failures', $pb.PbFieldType.O3)
+    ..aOS(8, _omitFieldNames ? '' : 'lastProbe')
+    ..aOS(9, _omitFieldNames ? '' : 'lastError')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  DistroLatency clone() => DistroLatency()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  DistroLatency copyWith(void Function(DistroLatency) updates) => super.copyWith((message) => updates(message as DistroLatency)) as DistroLatency;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroLatency create() => DistroLatency._();
+  DistroLatency createEmptyInstance() => create();
+  static $pb.PbList<DistroLatency> createRepeated() => $pb.PbList<DistroLatency>();
+  @$core.pragma('dart2js:noInline')
+  static DistroLatency getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<DistroLatency>(create);
+  static DistroLatency? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get distro => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set distro($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasDistro() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearDistro() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.double get lastMs => $_getN(1);
+  @$pb.TagNumber(2)
+  set lastMs($core.double v) { $_setDouble(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasLastMs() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearLastMs() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.double get minMs => $_getN(2);
+  @$pb.TagNumber(3)
+  set minMs($core.double v) { $_setDouble(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasMinMs() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearMinMs() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.double get maxMs => $_getN(3);
+  @$pb.TagNumber(4)
+  set maxMs($core.double v) { $_setDouble(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasMaxMs() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearMaxMs() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.double get meanMs => $_getN(4);
+  @$pb.TagNumber(5)
+  set meanMs($core.double v) { $_setDouble(4, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasMeanMs() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearMeanMs() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.int get samples => $_getIZ(5);
+  @$pb.TagNumber(6)
+  set samples($core.int v) { $_setSignedInt32(5, v); }
+  @$pb.TagNumber(6)
+  $core.bool hasSamples() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearSamples() => $_clearField(6);
+
+  @$pb.TagNumber(7)
+  $core.int get failures => $_getIZ(6);
+  @$pb.TagNumber(7)
+  set failures($core.int v) { $_setSignedInt32(6, v); }
+  @$pb.TagNumber(7)
+  $core.bool hasFailures() => $_has(6);
+  @$pb.TagNumber(7)
+  void clearFailures() => $_clearField(7);
+
+  @$pb.TagNumber(8)
+  $core.String get lastProbe => $_getSZ(7);
+  @$pb.TagNumber(8)
+  set lastProbe($core.String v) { $_setString(7, v); }
+  @$pb.TagNumber(8)
+  $core.bool hasLastProbe() => $_has(7);
+  @$pb.TagNumber(8)
+  void clearLastProbe() => $_clearField(8);
+
+  @$pb.TagNumber(9)
+  $core.String get lastError => $_getSZ(8);
+  @$pb.TagNumber(9)
+  set lastError($core.String v) { $_setString(8, v); }
+  @$pb.TagNumber(9)
+  $core.bool hasLastError() => $_has(8);
+  @$pb.TagNumber(9)
+  void clearLastError() => $_clearField(9);
+}
+
+class Bandwidth extends $pb.GeneratedMessage {
+  factory Bandwidth({
+    $core.Iterable<DistroBandwidth>? distros,
+  }) {
+    final $result = create();
+    if (distros != null) {
+      $result.distros.addAll(distros);
+    }
+    return $result;
+  }
+  Bandwidth._() : super();
+  factory Bandwidth.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory Bandwidth.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'Bandwidth', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pc<DistroBandwidth>(1, _omitFieldNames ? '' : 'distros', $pb.PbFieldType.PM, subBuilder: DistroBandwidth.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  Bandwidth clone() => Bandwidth()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  Bandwidth copyWith(void Function(Bandwidth) updates) => super.copyWith((message) => updates(message as Bandwidth)) as Bandwidth;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static Bandwidth create() => Bandwidth._();
+  Bandwidth createEmptyInstance() => create();
+  static $pb.PbList<Bandwidth> createRepeated() => $pb.PbList<Bandwidth>();
+  @$core.pragma('dart2js:noInline')
+  static Bandwidth getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<Bandwidth>(create);
+  static Bandwidth? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.List<DistroBandwidth> get distros => $_getList(0);
+}
+
+class DistroBandwidth extends $pb.GeneratedMessage {
+  factory DistroBandwidth({
+    $core.String? distro,
+    $core.Iterable<TransferUsage>? transfers,
+    $fixnum.Int64? todayBytes,
+    $fixnum.Int64? dailyCapBytes,
+  }) {
+    final $result = create();
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    if (transfers != null) {
+      $result.transfers.addAll(transfers);
+    }
+    if (todayBytes != null) {
+      $result.todayBytes = todayBytes;
+    }
+    if (dailyCapBytes != null) {
+      $result.dailyCapBytes = dailyCapBytes;
+    }
+    return $result;
+  }
+  DistroBandwidth._() : super();
+  factory DistroBandwidth.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory DistroBandwidth.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'DistroBandwidth', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'distro')
+    ..pc<TransferUsage>(2, _omitFieldNames ? '' : 'transfers', $pb.PbFieldType.PM, subBuilder: TransferUsage.create)
+    ..a<$fixnum.Int64>(3, _omitFieldNames ? '' : 'todayBytes', $pb.PbFieldType.OU6, defaultOrMaker: $fixnum.Int64.ZERO)
+    ..a<$fixnum.Int64>(4, _omitFieldNames ? '' : 'dailyCapBytes', $pb.PbFieldType.OU6, defaultOrMaker: $fixnum.Int64.ZERO)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  DistroBandwidth clone() => DistroBandwidth()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  DistroBandwidth copyWith(void Function(DistroBandwidth) updates) => super.copyWith((message) => updates(message as DistroBandwidth)) as DistroBandwidth;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroBandwidth create() => DistroBandwidth._();
+  DistroBandwidth createEmptyInstance() => create();
+  static $pb.PbList<DistroBandwidth> createRepeated() => $pb.PbList<DistroBandwidth>();
+  @$core.pragma('dart2js:noInline')
+  static DistroBandwidth getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<DistroBandwidth>(create);
+  static DistroBandwidth? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get distro => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set distro($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasDistro() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearDistro() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.List<TransferUsage> get transfers => $_getList(1);
+
+  @$pb.TagNumber(3)
+  $fixnum.Int64 get todayBytes => $_getI64(2);
+  @$pb.TagNumber(3)
+  set todayBytes($fixnum.Int64 v) { $_setInt64(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasTodayBytes() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearTodayBytes() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $fixnum.Int64 get dailyCapBytes => $_getI64(3);
+  @$pb.TagNumber(4)
+  set dailyCapBytes($fixnum.Int64 v) { $_setInt64(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasDailyCapBytes() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearDailyCapBytes() => $_clearField(4);
+}
+
+class TransferUsage extends $pb.GeneratedMessage {
+  factory TransferUsage({
+    $core.String? kind,
+    $fixnum.Int64? todayBytes,
+    $fixnum.Int64? totalBytes,
+  }) {
+    final $result = create();
+    if (kind != null) {
+      $result.kind = kind;
+    }
+    if (todayBytes != null) {
+      $result.todayBytes = todayBytes;
+    }
+    if (totalBytes != null) {
+      $result.totalBytes = totalBytes;
+    }
+    return $result;
+  }
+  TransferUsage._() : super();
+  factory TransferUsage.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory TransferUsage.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'TransferUsage', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'kind')
+    ..a<$fixnum.Int64>(2, _omitFieldNames ? '' : 'todayBytes', $pb.PbFieldType.OU6, defaultOrMaker: $fixnum.Int64.ZERO)
+    ..a<$fixnum.Int64>(3, _omitFieldNames ? '' : 'totalBytes', $pb.PbFieldType.OU6, defaultOrMaker: $fixnum.Int64.ZERO)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  TransferUsage clone() => TransferUsage()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  TransferUsage copyWith(void Function(TransferUsage) updates) => super.copyWith((message) => updates(message as TransferUsage)) as TransferUsage;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TransferUsage create() => TransferUsage._();
+  TransferUsage createEmptyInstance() => create();
+  static $pb.PbList<TransferUsage> createRepeated() => $pb.PbList<TransferUsage>();
+  @$core.pragma('dart2js:noInline')
+  static TransferUsage getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<TransferUsage>(create);
+  static TransferUsage? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get kind => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set kind($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasKind() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearKind() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $fixnum.Int64 get todayBytes => $_getI64(1);
+  @$pb.TagNumber(2)
+  set todayBytes($fixnum.Int64 v) { $_setInt64(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasTodayBytes() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearTodayBytes() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $fixnum.Int64 get totalBytes => $_getI64(2);
+  @$pb.TagNumber(3)
+  set totalBytes($fixnum.Int64 v) { $_setInt64(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasTotalBytes() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearTotalBytes() => $_clearField(3);
+}
+
+class ComplianceReport extends $pb.GeneratedMessage {
+  factory ComplianceReport({
+    $core.int? total,
+    $core.int? fullyPatched,
+    $core.int? pendingSecurity,
+    $core.int? pendingEsm,
+    $core.int? unknown,
+    $core.Iterable<DistroCompliance>? distros,
+  }) {
+    final $result = create();
+    if (total != null) {
+      $result.total = total;
+    }
+    if (fullyPatched != null) {
+      $result.fullyPatched = fullyPatched;
+    }
+    if (pendingSecurity != null) {
+      $result.pendingSecurity = pendingSecurity;
+    }
+    if (pendingEsm != null) {
+      $result.pendingEsm = pendingEsm;
+    }
+    if (unknown != null) {
+      $result.unknown = unknown;
+    }
+    if (distros != null) {
+      $result.distros.addAll(distros);
+    }
+    return $result;
+  }
+  ComplianceReport._() : super();
+  factory ComplianceReport.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ComplianceReport.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ComplianceReport', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..a<$core.int>(1, _omitFieldNames ? '' : 'total', $pb.PbFieldType.O3)
+    ..a<$core.int>(2, _omitFieldNames ? '' : 'fullyPatched', $pb.PbFieldType.O3)
+    ..a<$core.int>(3, _omitFieldNames ? '' : 'pendingSecurity', $pb.PbFieldType.O3)
+    ..a<$core.int>(4, _omitFieldNames ? '' : 'pendingEsm', $pb.PbFieldType.O3)
+    ..a<$core.int>(5, _omitFieldNames ? '' : 'unknown', $pb.PbFieldType.O3)
+    ..pc<DistroCompliance>(6, _omitFieldNames ? '' : 'distros', $pb.PbFieldType.PM, subBuilder: DistroCompliance.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ComplianceReport clone() => ComplianceReport()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ComplianceReport copyWith(void Function(ComplianceReport) updates) => super.copyWith((message) => updates(message as ComplianceReport)) as ComplianceReport;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ComplianceReport create() => ComplianceReport._();
+  ComplianceReport createEmptyInstance() => create();
+  static $pb.PbList<ComplianceReport> createRepeated() => $pb.PbList<ComplianceReport>();
+  @$core.pragma('dart2js:noInline')
+  static ComplianceReport getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ComplianceReport>(create);
+  static ComplianceReport? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.int get total => $_getIZ(0);
+  @$pb.TagNumber(1)
+  set total($core.int v) { $_setSignedInt32(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasTotal() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearTotal() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.int get fullyPatched => $_getIZ(1);
+  @$pb.TagNumber(2)
+  set fullyPatched($core.int v) { $_setSignedInt32(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasFullyPatched() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearFullyPatched() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.int get pendingSecurity => $_getIZ(2);
+  @$pb.TagNumber(3)
+  set pendingSecurity($core.int v) { $_setSignedInt32(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasPendingSecurity() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearPendingSecurity() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.int get pendingEsm => $_getIZ(3);
+  @$pb.TagNumber(4)
+  set pendingEsm($core.int v) { $_setSignedInt32(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasPendingEsm() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearPendingEsm() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.int get unknown => $_getIZ(4);
+  @$pb.TagNumber(5)
+  set unknown($core.int v) { $_setSignedInt32(4, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasUnknown() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearUnknown() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.List<DistroCompliance> get distros => $_getList(5);
+}
+
+class DistroCompliance extends $pb.GeneratedMessage {
+  factory DistroCompliance({
+    $core.String? distro,
+    SecurityStatus? status,
+  }) {
+    final $result = create();
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    if (status != null) {
+      $result.status = status;
+    }
+    return $result;
+  }
+  DistroCompliance._() : super();
+  factory DistroCompliance.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory DistroCompliance.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'DistroCompliance', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'distro')
+    ..aOM<SecurityStatus>(2, _omitFieldNames ? '' : 'status', subBuilder: SecurityStatus.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  DistroCompliance clone() => DistroCompliance()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  DistroCompliance copyWith(void Function(DistroCompliance) updates) => super.copyWith((message) => updates(message as DistroCompliance)) as DistroCompliance;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroCompliance create() => DistroCompliance._();
+  DistroCompliance createEmptyInstance() => create();
+  static $pb.PbList<DistroCompliance> createRepeated() => $pb.PbList<DistroCompliance>();
+  @$core.pragma('dart2js:noInline')
+  static DistroCompliance getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<DistroCompliance>(create);
+  static DistroCompliance? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get distro => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set distro($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasDistro() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearDistro() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  SecurityStatus get status => $_getN(1);
+  @$pb.TagNumber(2)
+  set status(SecurityStatus v) { $_setField(2, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasStatus() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearStatus() => $_clearField(2);
+  @$pb.TagNumber(2)
+  SecurityStatus ensureStatus() => $_ensure(1);
+}
+
+class Summary extends $pb.GeneratedMessage {
+  factory Summary({
+    SubscriptionInfo? subscription,
+    $core.int? distros,
+    $core.int? connected,
+    $core.int? pendingUpdates,
+    $core.int? errors,
+    WslInfo? wsl,
+    ContractsHealth? contracts,
+  }) {
+    final $result = create();
+    if (subscription != null) {
+      $result.subscription = subscription;
+    }
+    if (distros != null) {
+      $result.distros = distros;
+    }
+    if (connected != null) {
+      $result.connected = connected;
+    }
+    if (pendingUpdates != null) {
+      $result.pendingUpdates = pendingUpdates;
+    }
+    if (errors != null) {
+      $result.errors = errors;
+    }
+    if (wsl != null) {
+      $result.wsl = wsl;
+    }
+    if (contracts != null) {
+      $result.contracts = contracts;
+    }
+    return $result;
+  }
+  Summary._() : super();
+  factory Summary.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory Summary.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'Summary', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOM<SubscriptionInfo>(1, _omitFieldNames ? '' : 'subscription', subBuilder: SubscriptionInfo.create)
+    ..a<$core.int>(2, _omitFieldNames ? '' : 'distros', $pb.PbFieldType.O3)
+    ..a<$core.int>(3, _omitFieldNames ? '' : 'connected', $pb.PbFieldType.O3)
+    ..a<$core.int>(4, _omitFieldNames ? '' : 'pendingUpdates', $pb.PbFieldType.O3)
+    ..a<$core.int>(5, _omitFieldNames ? '' : 'errors', $pb.PbFieldType.O3)
+    ..aOM<WslInfo>(6, _omitFieldNames ? '' : 'wsl', subBuilder: WslInfo.create)
+    ..aOM<ContractsHealth>(7, _omitFieldNames ? '' : 'contracts', subBuilder: ContractsHealth.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  Summary clone() => Summary()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  Summary copyWith(void Function(Summary) updates) => super.copyWith((message) => updates(message as Summary)) as Summary;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static Summary create() => Summary._();
+  Summary createEmptyInstance() => create();
+  static $pb.PbList<Summary> createRepeated() => $pb.PbList<Summary>();
+  @$core.pragma('dart2js:noInline')
+  static Summary getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<Summary>(create);
+  static Summary? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  SubscriptionInfo get subscription => $_getN(0);
+  @$pb.TagNumber(1)
+  set subscription(SubscriptionInfo v) { $_setField(1, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasSubscription() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearSubscription() => $_clearField(1);
+  @$pb.TagNumber(1)
+  SubscriptionInfo ensureSubscription() => $_ensure(0);
+
+  @$pb.TagNumber(2)
+  $core.int get distros => $_getIZ(1);
+  @$pb.TagNumber(2)
+  set distros($core.int v) { $_setSignedInt32(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasDistros() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearDistros() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.int get connected => $_getIZ(2);
+  @$pb.TagNumber(3)
+  set connected($core.int v) { $_setSignedInt32(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasConnected() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearConnected() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.int get pendingUpdates => $_getIZ(3);
+  @$pb.TagNumber(4)
+  set pendingUpdates($core.int v) { $_setSignedInt32(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasPendingUpdates() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearPendingUpdates() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.int get errors => $_getIZ(4);
+  @$pb.TagNumber(5)
+  set errors($core.int v) { $_setSignedInt32(4, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasErrors() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearErrors() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  WslInfo get wsl => $_getN(5);
+  @$pb.TagNumber(6)
+  set wsl(WslInfo v) { $_setField(6, v); }
+  @$pb.TagNumber(6)
+  $core.bool hasWsl() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearWsl() => $_clearField(6);
+  @$pb.TagNumber(6)
+  WslInfo ensureWsl() => $_ensure(5);
+
+  @$pb.TagNumber(7)
+  ContractsHealth get contracts => $_getN(6);
+  @$pb.TagNumber(7)
+  set contracts(ContractsHealth v) { $_setField(7, v); }
+  @$pb.TagNumber(7)
+  $core.bool hasContracts() => $_has(6);
+  @$pb.TagNumber(7)
+  void clearContracts() => $_clearField(7);
+  @$pb.TagNumber(7)
+  ContractsHealth ensureContracts() => $_ensure(6);
+}
+
+class ContractsHealth extends $pb.GeneratedMessage {
+  factory ContractsHealth({
+    $core.String? lastContact,
+    $core.String? lastTokenRefresh,
+    $core.String? lastEntitlementSync,
+    $core.String? lastError,
+    $core.String? lastErrorTime,
+  }) {
+    final $result = create();
+    if (lastContact != null) {
+      $result.lastContact = lastContact;
+    }
+    if (lastTokenRefresh != null) {
+      $result.lastTokenRefresh = lastTokenRefresh;
+    }
+    if (lastEntitlementSync != null) {
+      $result.lastEntitlementSync = lastEntitlementSync;
+    }
+    if (lastError != null) {
+      $result.lastError = lastError;
+    }
+    if (lastErrorTime != null) {
+      $result.lastErrorTime = lastErrorTime;
+    }
+    return $result;
+  }
+  ContractsHealth._() : super();
+  factory ContractsHealth.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ContractsHealth.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ContractsHealth', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'lastContact')
+    ..aOS(2, _omitFieldNames ? '' : 'lastTokenRefresh')
+    ..aOS(3, _omitFieldNames ? '' : 'lastEntitlementSync')
+    ..aOS(4, _omitFieldNames ? '' : 'lastError')
+    ..aOS(5, _omitFieldNames ? '' : 'lastErrorTime')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ContractsHealth clone() => ContractsHealth()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ContractsHealth copyWith(void Function(ContractsHealth) updates) => super.copyWith((message) => updates(message as ContractsHealth)) as ContractsHealth;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ContractsHealth create() => ContractsHealth._();
+  ContractsHealth createEmptyInstance() => create();
+  static $pb.PbList<ContractsHealth> createRepeated() => $pb.PbList<ContractsHealth>();
+  @$core.pragma('dart2js:noInline')
+  static ContractsHealth getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ContractsHealth>(create);
+  static ContractsHealth? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get lastContact => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set lastContact($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasLastContact() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearLastContact() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get lastTokenRefresh => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set lastTokenRefresh($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasLastTokenRefresh() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearLastTokenRefresh() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get lastEntitlementSync => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set lastEntitlementSync($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasLastEntitlementSync() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearLastEntitlementSync() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get lastError => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set lastError($core.String v) { $_setString(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasLastError() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearLastError() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.String get lastErrorTime => $_getSZ(4);
+  @$pb.TagNumber(5)
+  set lastErrorTime($core.String v) { $_setString(4, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasLastErrorTime() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearLastErrorTime() => $_clearField(5);
+}
+
+class Inventory extends $pb.GeneratedMessage {
+  factory Inventory({
+    $core.Iterable<InventoryRecord>? records,
+  }) {
+    final $result = create();
+    if (records != null) {
+      $result.records.addAll(records);
+    }
+    return $result;
+  }
+  Inventory._() : super();
+  factory Inventory.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory Inventory.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'Inventory', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pc<InventoryRecord>(1, _omitFieldNames ? '' : 'records', $pb.PbFieldType.PM, subBuilder: InventoryRecord.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  Inventory clone() => Inventory()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  Inventory copyWith(void Function(Inventory) updates) => super.copyWith((message) => updates(message as Inventory)) as Inventory;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static Inventory create() => Inventory._();
+  Inventory createEmptyInstance() => create();
+  static $pb.PbList<Inventory> createRepeated() => $pb.PbList<Inventory>();
+  @$core.pragma('dart2js:noInline')
+  static Inventory getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<Inventory>(create);
+  static Inventory? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.List<InventoryRecord> get records => $_getList(0);
+}
+
+class InventoryRecord extends $pb.GeneratedMessage {
+  factory InventoryRecord({
+    $core.String? machine,
+    $core.String? distro,
+    $core.String? hostname,
+    $core.String? distroId,
+    $core.String? ubuntuVersion,
+    $core.String? prettyName,
+    $core.bool? proAttached,
+    $core.Iterable<$core.String>? proServices,
+    $core.String? proExpires,
+    $core.String? lastSeen,
+    $core.String? landscapeId,
+    $core.bool? landscapeManaged,
+    $core.int? pendingTasks,
+    $core.bool? running,
+    $core.String? unsupportedReason,
+  }) {
+    final $result = create();
+    if (machine != null) {
+      $result.machine = machine;
+    }
+    if (distro != null) {
+      $result.distro = distro;
+    }
+    if (hostname != null) {
+      $result.hostname = hostname;
+    }
+    if (distroId != null) {
+      $result.distroId = distroId;
+    }
+    if (ubuntuVersion != null) {
+      $result.ubuntuVersion = ubuntuVersion;
+    }
+    if (prettyName != null) {
+      $result.prettyName = prettyName;
+    }
+    if (proAttached != null) {
+      $result.proAttached = proAttached;
+    }
+    if (proServices != null) {
+      $result.proServices.addAll(proServices);
+    }
+    if (proExpires != null) {
+      $result.proExpires = proExpires;
+    }
+    if (lastSeen != null) {
+      $result.lastSeen = lastSeen;
+    }
+    if (landscapeId != null) {
+      $result.landscapeId = landscapeId;
+    }
+    if (landscapeManaged != null) {
+      $result.landscapeManaged = landscapeManaged;
+    }
+    if (pendingTasks != null) {
+      $result.pendingTasks = pendingTasks;
+    }
+    if (running != null) {
+      $result.running = running;
+    }
+    if (unsupportedReason != null) {
+      $result.unsupportedReason = unsupportedReason;
+    }
+    return $result;
+  }
+  InventoryRecord._() : super();
+  factory InventoryRecord.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory InventoryRecord.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'InventoryRecord', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'machine')
+    ..aOS(2, _omitFieldNames ? '' : 'distro')
+    ..aOS(3, _omitFieldNames ? '' : 'hostname')
+    ..aOS(4, _omitFieldNames ? '' : 'distroId')
+    ..aOS(5, _omitFieldNames ? '' : 'ubuntuVersion')
+    ..aOS(6, _omitFieldNames ? '' : 'prettyName')
+    ..aOB(7, _omitFieldNames ? '' : 'proAttached')
+    ..pPS(8, _omitFieldNames ? '' : 'proServices')
+    ..aOS(9, _omitFieldNames ? '' : 'proExpires')
+    ..aOS(10, _omitFieldNames ? '' : 'lastSeen')
+    ..aOS(11, _omitFieldNames ? '' : 'landscapeId')
+    ..aOB(12, _omitFieldNames ? '' : 'landscapeManaged')
+    ..a<$core.int>(13, _omitFieldNames ? '' : 'pendingTasks', $pb.PbFieldType.O3)
+    ..aOB(14, _omitFieldNames ? '' : 'running')
+    ..aOS(15, _omitFieldNames ? '' : 'unsupportedReason')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  InventoryRecord clone() => InventoryRecord()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  InventoryRecord copyWith(void Function(InventoryRecord) updates) => super.copyWith((message) => updates(message as InventoryRecord)) as InventoryRecord;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static InventoryRecord create() => InventoryRecord._();
+  InventoryRecord createEmptyInstance() => create();
+  static $pb.PbList<InventoryRecord> createRepeated() => $pb.PbList<InventoryRecord>();
+  @$core.pragma('dart2js:noInline')
+  static InventoryRecord getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<InventoryRecord>(create);
+  static InventoryRecord? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get machine => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set machine($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasMachine() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearMachine() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get distro => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set distro($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasDistro() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearDistro() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get hostname => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set hostname($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasHostname() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearHostname() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get distroId => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set distroId($core.String v) { $_setString(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasDistroId() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearDistroId() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.String get ubuntuVersion => $_getSZ(4);
+  @$pb.TagNumber(5)
+  set ubuntuVersion($core.String v) { $_setString(4, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasUbuntuVersion() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearUbuntuVersion() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.String get prettyName => $_getSZ(5);
+  @$pb.TagNumber(6)
+  set prettyName($core.String v) { $_setString(5, v); }
+  @$pb.TagNumber(6)
+  $core.bool hasPrettyName() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearPrettyName() => $_clearField(6);
+
+  @$pb.TagNumber(7)
+  $core.bool get proAttached => $_getBF(6);
+  @$pb.TagNumber(7)
+  set proAttached($core.bool v) { $_setBool(6, v); }
+  @$pb.TagNumber(7)
+  $core.bool hasProAttached() => $_has(6);
+  @$pb.TagNumber(7)
+  void clearProAttached() => $_clearField(7);
+
+  @$pb.TagNumber(8)
+  $core.List<$core.String> get proServices => $_getList(7);
+
+  @$pb.TagNumber(9)
+  $core.String get proExpires => $_getSZ(8);
+  @$pb.TagNumber(9)
+  set proExpires($core.String v) { $_setString(8, v); }
+  @$pb.TagNumber(9)
+  $core.bool hasProExpires() => $_has(8);
+  @$pb.TagNumber(9)
+  void clearProExpires() => $_clearField(9);
+
+  @$pb.TagNumber(10)
+  $core.String get lastSeen => $_getSZ(9);
+  @$pb.TagNumber(10)
+  set lastSeen($core.String v) { $_setString(9, v); }
+  @$pb.TagNumber(10)
+  $core.bool hasLastSeen() => $_has(9);
+  @$pb.TagNumber(10)
+  void clearLastSeen() => $_clearField(10);
+
+  @$pb.TagNumber(11)
+  $core.String get landscapeId => $_getSZ(10);
+  @$pb.TagNumber(11)
+  set landscapeId($core.String v) { $_setString(10, v); }
+  @$pb.TagNumber(11)
+  $core.bool hasLandscapeId() => $_has(10);
+  @$pb.TagNumber(11)
+  void clearLandscapeId() => $_clearField(11);
+
+  @$pb.TagNumber(12)
+  $core.bool get landscapeManaged => $_getBF(11);
+  @$pb.TagNumber(12)
+  set landscapeManaged($core.bool v) { $_setBool(11, v); }
+  @$pb.TagNumber(12)
+  $core.bool hasLandscapeManaged() => $_has(11);
+  @$pb.TagNumber(12)
+  void clearLandscapeManaged() => $_clearField(12);
+
+  @$pb.TagNumber(13)
+  $core.int get pendingTasks => $_getIZ(12);
+  @$pb.TagNumber(13)
+  set pendingTasks($core.int v) { $_setSignedInt32(12, v); }
+  @$pb.TagNumber(13)
+  $core.bool hasPendingTasks() => $_has(12);
+  @$pb.TagNumber(13)
+  void clearPendingTasks() => $_clearField(13);
+
+  @$pb.TagNumber(14)
+  $core.bool get running => $_getBF(13);
+  @$pb.TagNumber(14)
+  set running($core.bool v) { $_setBool(13, v); }
+  @$pb.TagNumber(14)
+  $core.bool hasRunning() => $_has(13);
+  @$pb.TagNumber(14)
+  void clearRunning() => $_clearField(14);
+
+  @$pb.TagNumber(15)
+  $core.String get unsupportedReason => $_getSZ(14);
+  @$pb.TagNumber(15)
+  set unsupportedReason($core.String v) { $_setString(14, v); }
+  @$pb.TagNumber(15)
+  $core.bool hasUnsupportedReason() => $_has(14);
+  @$pb.TagNumber(15)
+  void clearUnsupportedReason() => $_clearField(15);
+}
+
+class ProvisionRequest extends $pb.GeneratedMessage {
+  factory ProvisionRequest({
+    $core.String? distroName,
+    $core.String? rootfsUrl,
+    $core.String? installDir,
+  }) {
+    final $result = create();
+    if (distroName != null) {
+      $result.distroName = distroName;
+    }
+    if (rootfsUrl != null) {
+      $result.rootfsUrl = rootfsUrl;
+    }
+    if (installDir != null) {
+      $result.installDir = installDir;
+    }
+    return $result;
+  }
+  ProvisionRequest._() : super();
+  factory ProvisionRequest.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ProvisionRequest.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ProvisionRequest', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'distroName')
+    ..aOS(2, _omitFieldNames ? '' : 'rootfsUrl')
+    ..aOS(3, _omitFieldNames ? '' : 'installDir')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ProvisionRequest clone() => ProvisionRequest()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ProvisionRequest copyWith(void Function(ProvisionRequest) updates) => super.copyWith((message) => updates(message as ProvisionRequest)) as ProvisionRequest;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ProvisionRequest create() => ProvisionRequest._();
+  ProvisionRequest createEmptyInstance() => create();
+  static $pb.PbList<ProvisionRequest> createRepeated() => $pb.PbList<ProvisionRequest>();
+  @$core.pragma('dart2js:noInline')
+  static ProvisionRequest getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ProvisionRequest>(create);
+  static ProvisionRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get distroName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set distroName($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasDistroName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearDistroName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get rootfsUrl => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set rootfsUrl($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasRootfsUrl() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearRootfsUrl() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get installDir => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set installDir($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasInstallDir() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearInstallDir() => $_clearField(3);
+}
+
+class ProvisionProgress extends $pb.GeneratedMessage {
+  factory ProvisionProgress({
+    ProvisionStage? stage,
+    $fixnum.Int64? done,
+    $fixnum.Int64? total,
+  }) {
+    final $result = create();
+    if (stage != null) {
+      $result.stage = stage;
+    }
+    if (done != null) {
+      $result.done = done;
+    }
+    if (total != null) {
+      $result.total = total;
+    }
+    return $result;
+  }
+  ProvisionProgress._() : super();
+  factory ProvisionProgress.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ProvisionProgress.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ProvisionProgress', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..e<ProvisionStage>(1, _omitFieldNames ? '' : 'stage', $pb.PbFieldType.OE, defaultOrMaker: ProvisionStage.PROVISION_STAGE_UNSPECIFIED, valueOf: ProvisionStage.valueOf, enumValues: ProvisionStage.values)
+    ..aInt64(2, _omitFieldNames ? '' : 'done')
+    ..aInt64(3, _omitFieldNames ? '' : 'total')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ProvisionProgress clone() => ProvisionProgress()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ProvisionProgress copyWith(void Function(ProvisionProgress) updates) => super.copyWith((message) => updates(message as ProvisionProgress)) as ProvisionProgress;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ProvisionProgress create() => ProvisionProgress._();
+  ProvisionProgress createEmptyInstance() => create();
+  static $pb.PbList<ProvisionProgress> createRepeated() => $pb.PbList<ProvisionProgress>();
+  @$core.pragma('dart2js:noInline')
+  static ProvisionProgress getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ProvisionProgress>(create);
+  static ProvisionProgress? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  ProvisionStage get stage => $_getN(0);
+  @$pb.TagNumber(1)
+  set stage(ProvisionStage v) { $_setField(1, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasStage() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearStage() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $fixnum.Int64 get done => $_getI64(1);
+  @$pb.TagNumber(2)
+  set done($fixnum.Int64 v) { $_setInt64(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasDone() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearDone() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $fixnum.Int64 get total => $_getI64(2);
+  @$pb.TagNumber(3)
+  set total($fixnum.Int64 v) { $_setInt64(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasTotal() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearTotal() => $_clearField(3);
+}
+
+class WslInfo extends $pb.GeneratedMessage {
+  factory WslInfo({
+    $core.String? version,
+    $core.String? kernelVersion,
+    $core.String? channel,
+    $core.Iterable<$core.String>? degraded,
+    $core.Iterable<DistroOperation>? operations,
+  }) {
+    final $result = create();
+    if (version != null) {
+      $result.version = version;
+    }
+    if (kernelVersion != null) {
+      $result.kernelVersion = kernelVersion;
+    }
+    if (channel != null) {
+      $result.channel = channel;
+    }
+    if (degraded != null) {
+      $result.degraded.addAll(degraded);
+    }
+    if (operations != null) {
+      $result.operations.addAll(operations);
+    }
+    return $result;
+  }
+  WslInfo._() : super();
+  factory WslInfo.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory WslInfo.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'WslInfo', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'version')
+    ..aOS(2, _omitFieldNames ? '' : 'kernelVersion')
+    ..aOS(3, _omitFieldNames ? '' : 'channel')
+    ..pPS(4, _omitFieldNames ? '' : 'degraded')
+    ..pc<DistroOperation>(5, _omitFieldNames ? '' : 'operations', $pb.PbFieldType.KE, valueOf: DistroOperation.valueOf, enumValues: DistroOperation.values, defaultEnumValue: DistroOperation.DISTRO_OPERATION_UNSPECIFIED)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  WslInfo clone() => WslInfo()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  WslInfo copyWith(void Function(WslInfo) updates) => super.copyWith((message) => updates(message as WslInfo)) as WslInfo;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static WslInfo create() => WslInfo._();
+  WslInfo createEmptyInstance() => create();
+  static $pb.PbList<WslInfo> createRepeated() => $pb.PbList<WslInfo>();
+  @$core.pragma('dart2js:noInline')
+  static WslInfo getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<WslInfo>(create);
+  static WslInfo? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get version => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set version($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasVersion() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearVersion() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get kernelVersion => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set kernelVersion($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasKernelVersion() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearKernelVersion() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get channel => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set channel($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasChannel() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearChannel() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.List<$core.String> get degraded => $_getList(3);
+
+  @$pb.TagNumber(5)
+  $core.List<DistroOperation> get operations => $_getList(4);
+}
+
+class WslConfig extends $pb.GeneratedMessage {
+  factory WslConfig({
+    $core.String? memory,
+    $core.String? networkingMode,
+    $fixnum.Int64? vmIdleTimeout,
+    $core.Iterable<$core.String>? problems,
+  }) {
+    final $result = create();
+    if (memory != null) {
+      $result.memory = memory;
+    }
+    if (networkingMode != null) {
+      $result.networkingMode = networkingMode;
+    }
+    if (vmIdleTimeout != null) {
+      $result.vmIdleTimeout = vmIdleTimeout;
+    }
+    if (problems != null) {
+      $result.problems.addAll(problems);
+    }
+    return $result;
+  }
+  WslConfig._() : super();
+  factory WslConfig.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory WslConfig.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'WslConfig', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'memory')
+    ..aOS(2, _omitFieldNames ? '' : 'networkingMode')
+    ..aInt64(3, _omitFieldNames ? '' : 'vmIdleTimeout')
+    ..pPS(4, _omitFieldNames ? '' : 'problems')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  WslConfig clone() => WslConfig()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  WslConfig copyWith(void Function(WslConfig) updates) => super.copyWith((message) => updates(message as WslConfig)) as WslConfig;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static WslConfig create() => WslConfig._();
+  WslConfig createEmptyInstance() => create();
+  static $pb.PbList<WslConfig> createRepeated() => $pb.PbList<WslConfig>();
+  @$core.pragma('dart2js:noInline')
+  static WslConfig getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<WslConfig>(create);
+  static WslConfig? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get memory => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set memory($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasMemory() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearMemory() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get networkingMode => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set networkingMode($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasNetworkingMode() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearNetworkingMode() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $fixnum.Int64 get vmIdleTimeout => $_getI64(2);
+  @$pb.TagNumber(3)
+  set vmIdleTimeout($fixnum.Int64 v) { $_setInt64(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasVmIdleTimeout() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearVmIdleTimeout() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.List<$core.String> get problems => $_getList(3);
+}
+
+enum SubscriptionInfo_SubscriptionType {
+  none, 
+  user, 
+  organization, 
+  microsoftStore, 
+  notSet
+}
+
+class SubscriptionInfo extends $pb.GeneratedMessage {
+  factory SubscriptionInfo({
+    $core.String? productId,
+    Empty? none,
+    Empty? user,
+    Empty? organization,
+    Empty? microsoftStore,
+  }) {
+    final $result = create();
+    if (productId != null) {
+      $result.productId = productId;
+    }
+    if (none != null) {
+      $result.none = none;
+    }
+    if (user != null) {
+      $result.user = user;
+    }
+    if (organization != null) {
+      $result.organization = organization;
+    }
+    if (microsoftStore != null) {
+      $result.microsoftStore = microsoftStore;
+    }
+    return $result;
+  }
+  SubscriptionInfo._() : super();
+  factory SubscriptionInfo.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory SubscriptionInfo.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static const $core.Map<$core.int, SubscriptionInfo_SubscriptionType> _SubscriptionInfo_SubscriptionTypeByTag = {
+    2 : SubscriptionInfo_SubscriptionType.none,
+    3 : SubscriptionInfo_SubscriptionType.user,
+    4 : SubscriptionInfo_SubscriptionType.organization,
+    5 : SubscriptionInfo_SubscriptionType.microsoftStore,
+    0 : SubscriptionInfo_SubscriptionType.notSet
+  };
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'SubscriptionInfo', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..oo(0, [2, 3, 4, 5])
+    ..aOS(1, _omitFieldNames ? '' : 'productId', protoName: 'productId')
+    ..aOM<Empty>(2, _omitFieldNames ? '' : 'none', subBuilder: Empty.create)
+    ..aOM<Empty>(3, _omitFieldNames ? '' : 'user', subBuilder: Empty.create)
+    ..aOM<Empty>(4, _omitFieldNames ? '' : 'organization', subBuilder: Empty.create)
+    ..aOM<Empty>(5, _omitFieldNames ? '' : 'microsoftStore', protoName: 'microsoftStore', subBuilder: Empty.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  SubscriptionInfo clone() => SubscriptionInfo()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  SubscriptionInfo copyWith(void Function(SubscriptionInfo) updates) => super.copyWith((message) => updates(message as SubscriptionInfo)) as SubscriptionInfo;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static SubscriptionInfo create() => SubscriptionInfo._();
+  SubscriptionInfo createEmptyInstance() => create();
+  static $pb.PbList<SubscriptionInfo> createRepeated() => $pb.PbList<SubscriptionInfo>();
+  @$core.pragma('dart2js:noInline')
+  static SubscriptionInfo getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<SubscriptionInfo>(create);
+  static SubscriptionInfo? _defaultInstance;
+
+  SubscriptionInfo_SubscriptionType whichSubscriptionType() => _SubscriptionInfo_SubscriptionTypeByTag[$_whichOneof(0)]!;
+  void clearSubscriptionType() => $_clearField($_whichOneof(0));
+
+  @$pb.TagNumber(1)
+  $core.String get productId => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set productId($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasProductId() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearProductId() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  Empty get none => $_getN(1);
+  @$pb.TagNumber(2)
+  set none(Empty v) { $_setField(2, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasNone() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearNone() => $_clearField(2);
+  @$pb.TagNumber(2)
+  Empty ensureNone() => $_ensure(1);
+
+  @$pb.TagNumber(3)
+  Empty get user => $_getN(2);
+  @$pb.TagNumber(3)
+  set user(Empty v) { $_setField(3, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasUser() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearUser() => $_clearField(3);
+  @$pb.TagNumber(3)
+  Empty ensureUser() => $_ensure(2);
+
+  @$pb.TagNumber(4)
+  Empty get organization => $_getN(3);
+  @$pb.TagNumber(4)
+  set organization(Empty v) { $_setField(4, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasOrganization() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearOrganization() => $_clearField(4);
+  @$pb.TagNumber(4)
+  Empty ensureOrganization() => $_ensure(3);
+
+  @$pb.TagNumber(5)
+  Empty get microsoftStore => $_getN(4);
+  @$pb.TagNumber(5)
+  set microsoftStore(Empty v) { $_setField(5, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasMicrosoftStore() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearMicrosoftStore() => $_clearField(5);
+  @$pb.TagNumber(5)
+  Empty ensureMicrosoftStore() => $_ensure(4);
+}
+
+class SubscriptionDetails extends $pb.GeneratedMessage {
+  factory SubscriptionDetails({
+    $core.Iterable<Entitlement>? entitlements,
+    $core.String? supportLevel,
+    $core.String? expires,
+  }) {
+    final $result = create();
+    if (entitlements != null) {
+      $result.entitlements.addAll(entitlements);
+    }
+    if (supportLevel != null) {
+      $result.supportLevel = supportLevel;
+    }
+    if (expires != null) {
+      $result.expires = expires;
+    }
+    return $result;
+  }
+  SubscriptionDetails._() : super();
+  factory SubscriptionDetails.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory SubscriptionDetails.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'SubscriptionDetails', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pc<Entitlement>(1, _omitFieldNames ? '' : 'entitlements', $pb.PbFieldType.PM, subBuilder: Entitlement.create)
+    ..aOS(2, _omitFieldNames ? '' : 'supportLevel')
+    ..aOS(3, _omitFieldNames ? '' : 'expires')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  SubscriptionDetails clone() => SubscriptionDetails()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  SubscriptionDetails copyWith(void Function(SubscriptionDetails) updates) => super.copyWith((message) => updates(message as SubscriptionDetails)) as SubscriptionDetails;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static SubscriptionDetails create() => SubscriptionDetails._();
+  SubscriptionDetails createEmptyInstance() => create();
+  static $pb.PbList<SubscriptionDetails> createRepeated() => $pb.PbList<SubscriptionDetails>();
+  @$core.pragma('dart2js:noInline')
+  static SubscriptionDetails getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<SubscriptionDetails>(create);
+  static SubscriptionDetails? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.List<Entitlement> get entitlements => $_getList(0);
+
+  @$pb.TagNumber(2)
+  $core.String get supportLevel => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set supportLevel($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasSupportLevel() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearSupportLevel() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get expires => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set expires($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasExpires() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearExpires() => $_clearField(3);
+}
+
+class Entitlement extends $pb.GeneratedMessage {
+  factory Entitlement({
+    $core.String? name,
+    $core.bool? entitled,
+  }) {
+    final $result = create();
+    if (name != null) {
+      $result.name = name;
+    }
+    if (entitled != null) {
+      $result.entitled = entitled;
+    }
+    return $result;
+  }
+  Entitlement._() : super();
+  factory Entitlement.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory Entitlement.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'Entitlement', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'name')
+    ..aOB(2, _omitFieldNames ? '' : 'entitled')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  Entitlement clone() => Entitlement()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  Entitlement copyWith(void Function(Entitlement) updates) => super.copyWith((message) => updates(message as Entitlement)) as Entitlement;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static Entitlement create() => Entitlement._();
+  Entitlement createEmptyInstance() => create();
+  static $pb.PbList<Entitlement> createRepeated() => $pb.PbList<Entitlement>();
+  @$core.pragma('dart2js:noInline')
+  static Entitlement getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<Entitlement>(create);
+  static Entitlement? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get name => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set name($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.bool get entitled => $_getBF(1);
+  @$pb.TagNumber(2)
+  set entitled($core.bool v) { $_setBool(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasEntitled() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearEntitled() => $_clearField(2);
+}
+
+enum LandscapeSource_LandscapeSourceType {
+  none, 
+  user, 
+  organization, 
+  notSet
+}
+
+class LandscapeSource extends $pb.GeneratedMessage {
+  factory LandscapeSource({
+    Empty? none,
+    Empty? user,
+    Empty? organization,
+  }) {
+    final $result = create();
+    if (none != null) {
+      $result.none = none;
+    }
+    if (user != null) {
+      $result.user = user;
+    }
+    if (organization != null) {
+      $result.organization = organization;
+    }
+    return $result;
+  }
+  LandscapeSource._() : super();
+  factory LandscapeSource.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory LandscapeSource.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static const $core.Map<$core.int, LandscapeSource_LandscapeSourceType> _LandscapeSource_LandscapeSourceTypeByTag = {
+    1 : LandscapeSource_LandscapeSourceType.none,
+    2 : LandscapeSource_LandscapeSourceType.user,
+    3 : LandscapeSource_LandscapeSourceType.organization,
+    0 : LandscapeSource_LandscapeSourceType.notSet
+  };
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'LandscapeSource', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..oo(0, [1, 2, 3])
+    ..aOM<Empty>(1, _omitFieldNames ? '' : 'none', subBuilder: Empty.create)
+    ..aOM<Empty>(2, _omitFieldNames ? '' : 'user', subBuilder: Empty.create)
+    ..aOM<Empty>(3, _omitFieldNames ? '' : 'organization', subBuilder: Empty.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  LandscapeSource clone() => LandscapeSource()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  LandscapeSource copyWith(void Function(LandscapeSource) updates) => super.copyWith((message) => updates(message as LandscapeSource)) as LandscapeSource;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static LandscapeSource create() => LandscapeSource._();
+  LandscapeSource createEmptyInstance() => create();
+  static $pb.PbList<LandscapeSource> createRepeated() => $pb.PbList<LandscapeSource>();
+  @$core.pragma('dart2js:noInline')
+  static LandscapeSource getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<LandscapeSource>(create);
+  static LandscapeSource? _defaultInstance;
+
+  LandscapeSource_LandscapeSourceType whichLandscapeSourceType() => _LandscapeSource_LandscapeSourceTypeByTag[$_whichOneof(0)]!;
+  void clearLandscapeSourceType() => $_clearField($_whichOneof(0));
+
+  @$pb.TagNumber(1)
+  Empty get none => $_getN(0);
+  @$pb.TagNumber(1)
+  set none(Empty v) { $_setField(1, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasNone() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearNone() => $_clearField(1);
+  @$pb.TagNumber(1)
+  Empty ensureNone() => $_ensure(0);
+
+  @$pb.TagNumber(2)
+  Empty get user => $_getN(1);
+  @$pb.TagNumber(2)
+  set user(Empty v) { $_setField(2, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasUser() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearUser() => $_clearField(2);
+  @$pb.TagNumber(2)
+  Empty ensureUser() => $_ensure(1);
+
+  @$pb.TagNumber(3)
+  Empty get organization => $_getN(2);
+  @$pb.TagNumber(3)
+  set organization(Empty v) { $_setField(3, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasOrganization() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearOrganization() => $_clearField(3);
+  @$pb.TagNumber(3)
+  Empty ensureOrganization() => $_ensure(2);
+}
+
+class ConfigSources extends $pb.GeneratedMessage {
+  factory ConfigSources({
+    SubscriptionInfo? proSubscription,
+    LandscapeSource? landscapeSource,
+  }) {
+    final $result = create();
+    if (proSubscription != null) {
+      $result.proSubscription = proSubscription;
+    }
+    if (landscapeSource != null) {
+      $result.landscapeSource = landscapeSource;
+    }
+    return $result;
+  }
+  ConfigSources._() : super();
+  factory ConfigSources.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ConfigSources.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ConfigSources', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOM<SubscriptionInfo>(1, _omitFieldNames ? '' : 'proSubscription', protoName: 'proSubscription', subBuilder: SubscriptionInfo.create)
+    ..aOM<LandscapeSource>(2, _omitFieldNames ? '' : 'landscapeSource', protoName: 'landscapeSource', subBuilder: LandscapeSource.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ConfigSources clone() => ConfigSources()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ConfigSources copyWith(void Function(ConfigSources) updates) => super.copyWith((message) => updates(message as ConfigSources)) as ConfigSources;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ConfigSources create() => ConfigSources._();
+  ConfigSources createEmptyInstance() => create();
+  static $pb.PbList<ConfigSources> createRepeated() => $pb.PbList<ConfigSources>();
+  @$core.pragma('dart2js:noInline')
+  static ConfigSources getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ConfigSources>(create);
+  static ConfigSources? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  SubscriptionInfo get proSubscription => $_getN(0);
+  @$pb.TagNumber(1)
+  set proSubscription(SubscriptionInfo v) { $_setField(1, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasProSubscription() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearProSubscription() => $_clearField(1);
+  @$pb.TagNumber(1)
+  SubscriptionInfo ensureProSubscription() => $_ensure(0);
+
+  @$pb.TagNumber(2)
+  LandscapeSource get landscapeSource => $_getN(1);
+  @$pb.TagNumber(2)
+  set landscapeSource(LandscapeSource v) { $_setField(2, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasLandscapeSource() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearLandscapeSource() => $_clearField(2);
+  @$pb.TagNumber(2)
+  LandscapeSource ensureLandscapeSource() => $_ensure(1);
+}
+
+enum DistroMessage_Data {
+  handshake, 
+  info, 
+  notSet
+}
+
+class DistroMessage extends $pb.GeneratedMessage {
+  factory DistroMessage({
+    Handshake? handshake,
+    DistroInfo? info,
+  }) {
+    final $result = create();
+    if (handshake != null) {
+      $result.handshake = handshake;
+    }
+    if (info != null) {
+      $result.info = info;
+    }
+    return $result;
+  }
+  DistroMessage._() : super();
+  factory DistroMessage.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory DistroMessage.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static const $core.Map<$core.int, DistroMessage_Data> _DistroMessage_DataByTag = {
+    1 : DistroMessage_Data.handshake,
+    2 : DistroMessage_Data.info,
+    0 : DistroMessage_Data.notSet
+  };
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'DistroMessage', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..oo(0, [1, 2])
+    ..aOM<Handshake>(1, _omitFieldNames ? '' : 'handshake', subBuilder: Handshake.create)
+    ..aOM<DistroInfo>(2, _omitFieldNames ? '' : 'info', subBuilder: DistroInfo.create)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  DistroMessage clone() => DistroMessage()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  DistroMessage copyWith(void Function(DistroMessage) updates) => super.copyWith((message) => updates(message as DistroMessage)) as DistroMessage;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroMessage create() => DistroMessage._();
+  DistroMessage createEmptyInstance() => create();
+  static $pb.PbList<DistroMessage> createRepeated() => $pb.PbList<DistroMessage>();
+  @$core.pragma('dart2js:noInline')
+  static DistroMessage getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<DistroMessage>(create);
+  static DistroMessage? _defaultInstance;
+
+  DistroMessage_Data whichData() => _DistroMessage_DataByTag[$_whichOneof(0)]!;
+  void clearData() => $_clearField($_whichOneof(0));
+
+  @$pb.TagNumber(1)
+  Handshake get handshake => $_getN(0);
+  @$pb.TagNumber(1)
+  set handshake(Handshake v) { $_setField(1, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasHandshake() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearHandshake() => $_clearField(1);
+  @$pb.TagNumber(1)
+  Handshake ensureHandshake() => $_ensure(0);
+
+  @$pb.TagNumber(2)
+  DistroInfo get info => $_getN(1);
+  @$pb.TagNumber(2)
+  set info(DistroInfo v) { $_setField(2, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasInfo() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearInfo() => $_clearField(2);
+  @$pb.TagNumber(2)
+  DistroInfo ensureInfo() => $_ensure(1);
+}
+
+class Handshake extends $pb.GeneratedMessage {
+  factory Handshake({
+    $core.int? protocolVersion,
+    $core.String? wslName,
+    $core.Iterable<Capability>? capabilities,
+    $core.String? serviceVersion,
+  }) {
+    final $result = create();
+    if (protocolVersion != null) {
+      $result.protocolVersion = protocolVersion;
+    }
+    if (wslName != null) {
+      $result.wslName = wslName;
+    }
+    if (capabilities != null) {
+      $result.capabilities.addAll(capabilities);
+    }
+    if (serviceVersion != null) {
+      $result.serviceVersion = serviceVersion;
+    }
+    return $result;
+  }
+  Handshake._() : super();
+  factory Handshake.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory Handshake.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'Handshake', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..a<$core.int>(1, _omitFieldNames ? '' : 'protocolVersion', $pb.PbFieldType.OU3)
+    ..aOS(2, _omitFieldNames ? '' : 'wslName')
+    ..pc<Capability>(3, _omitFieldNames ? '' : 'capabilities', $pb.PbFieldType.KE, valueOf: Capability.valueOf, enumValues: Capability.values, defaultEnumValue: Capability.CAPABILITY_UNSPECIFIED)
+    ..aOS(4, _omitFieldNames ? '' : 'serviceVersion')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  Handshake clone() => Handshake()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  Handshake copyWith(void Function(Handshake) updates) => super.copyWith((message) => updates(message as Handshake)) as Handshake;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static Handshake create() => Handshake._();
+  Handshake createEmptyInstance() => create();
+  static $pb.PbList<Handshake> createRepeated() => $pb.PbList<Handshake>();
+  @$core.pragma('dart2js:noInline')
+  static Handshake getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<Handshake>(create);
+  static Handshake? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.int get protocolVersion => $_getIZ(0);
+  @$pb.TagNumber(1)
+  set protocolVersion($core.int v) { $_setUnsignedInt32(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasProtocolVersion() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearProtocolVersion() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get wslName => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set wslName($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasWslName() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearWslName() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.List<Capability> get capabilities => $_getList(2);
+
+  @$pb.TagNumber(4)
+  $core.String get serviceVersion => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set serviceVersion($core.String v) { $_setString(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasServiceVersion() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearServiceVersion() => $_clearField(4);
+}
+
+class HandshakeAck extends $pb.GeneratedMessage {
+  factory HandshakeAck({
+    $core.int? protocolVersion,
+    $core.Iterable<Capability>? capabilities,
+    DistroSettings? settings,
+    $fixnum.Int64? agentTimeUnixMilli,
+  }) {
+    final $result = create();
+    if (protocolVersion != null) {
+      $result.protocolVersion = protocolVersion;
+    }
+    if (capabilities != null) {
+      $result.capabilities.addAll(capabilities);
+    }
+    if (settings != null) {
+      $result.settings = settings;
+    }
+    if (agentTimeUnixMilli != null) {
+      $result.agentTimeUnixMilli = agentTimeUnixMilli;
+    }
+    return $result;
+  }
+  HandshakeAck._() : super();
+  factory HandshakeAck.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory HandshakeAck.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'HandshakeAck', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..a<$core.int>(1, _omitFieldNames ? '' : 'protocolVersion', $pb.PbFieldType.OU3)
+    ..pc<Capability>(2, _omitFieldNames ? '' : 'capabilities', $pb.PbFieldType.KE, valueOf: Capability.valueOf, enumValues: Capability.values, defaultEnumValue: Capability.CAPABILITY_UNSPECIFIED)
+    ..aOM<DistroSettings>(3, _omitFieldNames ? '' : 'settings', subBuilder: DistroSettings.create)
+    ..aInt64(4, _omitFieldNames ? '' : 'agentTimeUnixMilli')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  HandshakeAck clone() => HandshakeAck()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  HandshakeAck copyWith(void Function(HandshakeAck) updates) => super.copyWith((message) => updates(message as HandshakeAck)) as HandshakeAck;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static HandshakeAck create() => HandshakeAck._();
+  HandshakeAck createEmptyInstance() => create();
+  static $pb.PbList<HandshakeAck> createRepeated() => $pb.PbList<HandshakeAck>();
+  @$core.pragma('dart2js:noInline')
+  static HandshakeAck getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<HandshakeAck>(create);
+  static HandshakeAck? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.int get protocolVersion => $_getIZ(0);
+  @$pb.TagNumber(1)
+  set protocolVersion($core.int v) { $_setUnsignedInt32(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasProtocolVersion() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearProtocolVersion() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.List<Capability> get capabilities => $_getList(1);
+
+  @$pb.TagNumber(3)
+  DistroSettings get settings => $_getN(2);
+  @$pb.TagNumber(3)
+  set settings(DistroSettings v) { $_setField(3, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasSettings() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearSettings() => $_clearField(3);
+  @$pb.TagNumber(3)
+  DistroSettings ensureSettings() => $_ensure(2);
+
+  @$pb.TagNumber(4)
+  $fixnum.Int64 get agentTimeUnixMilli => $_getI64(3);
+  @$pb.TagNumber(4)
+  set agentTimeUnixMilli($fixnum.Int64 v) { $_setInt64(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasAgentTimeUnixMilli() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearAgentTimeUnixMilli() => $_clearField(4);
+}
+
+class DistroSettings extends $pb.GeneratedMessage {
+  factory DistroSettings({
+    $core.String? configHash,
+    $core.int? pendingTasks,
+    $core.int? refreshIntervalSeconds,
+  }) {
+    final $result = create();
+    if (configHash != null) {
+      $result.configHash = configHash;
+    }
+    if (pendingTasks != null) {
+      $result.pendingTasks = pendingTasks;
+    }
+    if (refreshIntervalSeconds != null) {
+      $result.refreshIntervalSeconds = refreshIntervalSeconds;
+    }
+    return $result;
+  }
+  DistroSettings._() : super();
+  factory DistroSettings.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory DistroSettings.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'DistroSettings', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'configHash')
+    ..a<$core.int>(2, _omitFieldNames ? '' : 'pendingTasks', $pb.PbFieldType.O3)
+    ..a<$core.int>(3, _omitFieldNames ? '' : 'refreshIntervalSeconds', $pb.PbFieldType.OU3)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  DistroSettings clone() => DistroSettings()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  DistroSettings copyWith(void Function(DistroSettings) updates) => super.copyWith((message) => updates(message as DistroSettings)) as DistroSettings;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroSettings create() => DistroSettings._();
+  DistroSettings createEmptyInstance() => create();
+  static $pb.PbList<DistroSettings> createRepeated() => $pb.PbList<DistroSettings>();
+  @$core.pragma('dart2js:noInline')
+  static DistroSettings getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<DistroSettings>(create);
+  static DistroSettings? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get configHash => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set configHash($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasConfigHash() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearConfigHash() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.int get pendingTasks => $_getIZ(1);
+  @$pb.TagNumber(2)
+  set pendingTasks($core.int v) { $_setSignedInt32(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasPendingTasks() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearPendingTasks() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.int get refreshIntervalSeconds => $_getIZ(2);
+  @$pb.TagNumber(3)
+  set refreshIntervalSeconds($core.int v) { $_setUnsignedInt32(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasRefreshIntervalSeconds() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearRefreshIntervalSeconds() => $_clearField(3);
+}
+
+class DistroInfo extends $pb.GeneratedMessage {
+  factory DistroInfo({
+    $core.String? wslName,
+    $core.String? id,
+    $core.String? versionId,
+    $core.String? prettyName,
+    $core.bool? proAttached,
+    $core.String? hostname,
+    $core.Iterable<$core.String>? proServices,
+    SecurityStatus? securityStatus,
+    $core.String? proExpires,
+    $core.String? proSupportLevel,
+    $core.String? unsupportedReason,
+    $core.int? wslVersion,
+  }) {
+    final $result = create();
+    if (wslName != null) {
+      $result.wslName = wslName;
+    }
+    if (id != null) {
+      $result.id = id;
+    }
+    if (versionId != null) {
+      $result.versionId = versionId;
+    }
+    if (prettyName != null) {
+      $result.prettyName = prettyName;
+    }
+    if (proAttached != null) {
+      $result.proAttached = proAttached;
+    }
+    if (hostname != null) {
+      $result.hostname = hostname;
+    }
+    if (proServices != null) {
+      $result.proServices.addAll(proServices);
+    }
+    if (securityStatus != null) {
+      $result.securityStatus = securityStatus;
+    }
+    if (proExpires != null) {
+      $result.proExpires = proExpires;
+    }
+    if (proSupportLevel != null) {
+      $result.proSupportLevel = proSupportLevel;
+    }
+    if (unsupportedReason != null) {
+      $result.unsupportedReason = unsupportedReason;
+    }
+    if (wslVersion != null) {
+      $result.wslVersion = wslVersion;
+    }
+    return $result;
+  }
+  DistroInfo._() : super();
+  factory DistroInfo.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory DistroInfo.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'DistroInfo', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..aOS(2, _omitFieldNames ? '' : 'id')
+    ..aOS(3, _omitFieldNames ? '' : 'versionId')
+    ..aOS(4, _omitFieldNames ? '' : 'prettyName')
+    ..aOB(5, _omitFieldNames ? '' : 'proAttached')
+    ..aOS(6, _omitFieldNames ? '' : 'hostname')
+    ..pPS(7, _omitFieldNames ? '' : 'proServices')
+    ..aOM<SecurityStatus>(8, _omitFieldNames ? '' : 'securityStatus', subBuilder: SecurityStatus.create)
+    ..aOS(9, _omitFieldNames ? '' : 'proExpires')
+    ..aOS(10, _omitFieldNames ? '' : 'proSupportLevel')
+    ..aOS(11, _omitFieldNames ? '' : 'unsupportedReason')
+    ..a<$core.int>(12, _omitFieldNames ? '' : 'wslVersion', $pb.PbFieldType.O3)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  DistroInfo clone() => DistroInfo()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  DistroInfo copyWith(void Function(DistroInfo) updates) => super.copyWith((message) => updates(message as DistroInfo)) as DistroInfo;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroInfo create() => DistroInfo._();
+  DistroInfo createEmptyInstance() => create();
+  static $pb.PbList<DistroInfo> createRepeated() => $pb.PbList<DistroInfo>();
+  @$core.pragma('dart2js:noInline')
+  static DistroInfo getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<DistroInfo>(create);
+  static DistroInfo? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
 
   @$pb.TagNumber(2)
   $core.String get id => $_getSZ(1);
   @$pb.TagNumber(2)
-  set id($core.String v) { $_setString(1, v); }
+  set id($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasId() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearId() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get versionId => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set versionId($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasVersionId() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearVersionId() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get prettyName => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set prettyName($core.String v) { $_setString(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasPrettyName() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearPrettyName() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.bool get proAttached => $_getBF(4);
+  @$pb.TagNumber(5)
+  set proAttached($core.bool v) { $_setBool(4, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasProAttached() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearProAttached() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.String get hostname => $_getSZ(5);
+  @$pb.TagNumber(6)
+  set hostname($core.String v) { $_setString(5, v); }
+  @$pb.TagNumber(6)
+  $core.bool hasHostname() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearHostname() => $_clearField(6);
+
+  @$pb.TagNumber(7)
+  $core.List<$core.String> get proServices => $_getList(6);
+
+  @$pb.TagNumber(8)
+  SecurityStatus get securityStatus => $_getN(7);
+  @$pb.TagNumber(8)
+  set securityStatus(SecurityStatus v) { $_setField(8, v); }
+  @$pb.TagNumber(8)
+  $core.bool hasSecurityStatus() => $_has(7);
+  @$pb.TagNumber(8)
+  void clearSecurityStatus() => $_clearField(8);
+  @$pb.TagNumber(8)
+  SecurityStatus ensureSecurityStatus() => $_ensure(7);
+
+  @$pb.TagNumber(9)
+  $core.String get proExpires => $_getSZ(8);
+  @$pb.TagNumber(9)
+  set proExpires($core.String v) { $_setString(8, v); }
+  @$pb.TagNumber(9)
+  $core.bool hasProExpires() => $_has(8);
+  @$pb.TagNumber(9)
+  void clearProExpires() => $_clearField(9);
+
+  @$pb.TagNumber(10)
+  $core.String get proSupportLevel => $_getSZ(9);
+  @$pb.TagNumber(10)
+  set proSupportLevel($core.String v) { $_setString(9, v); }
+  @$pb.TagNumber(10)
+  $core.bool hasProSupportLevel() => $_has(9);
+  @$pb.TagNumber(10)
+  void clearProSupportLevel() => $_clearField(10);
+
+  @$pb.TagNumber(11)
+  $core.String get unsupportedReason => $_getSZ(10);
+  @$pb.TagNumber(11)
+  set unsupportedReason($core.String v) { $_setString(10, v); }
+  @$pb.TagNumber(11)
+  $core.bool hasUnsupportedReason() => $_has(10);
+  @$pb.TagNumber(11)
+  void clearUnsupportedReason() => $_clearField(11);
+
+  @$pb.TagNumber(12)
+  $core.int get wslVersion => $_getIZ(11);
+  @$pb.TagNumber(12)
+  set wslVersion($core.int v) { $_setSignedInt32(11, v); }
+  @$pb.TagNumber(12)
+  $core.bool hasWslVersion() => $_has(11);
+  @$pb.TagNumber(12)
+  void clearWslVersion() => $_clearField(12);
+}
+
+class SecurityStatus extends $pb.GeneratedMessage {
+  factory SecurityStatus({
+    $core.int? standardUpdates,
+    $core.int? esmUpdates,
+  }) {
+    final $result = create();
+    if (standardUpdates != null) {
+      $result.standardUpdates = standardUpdates;
+    }
+    if (esmUpdates != null) {
+      $result.esmUpdates = esmUpdates;
+    }
+    return $result;
+  }
+  SecurityStatus._() : super();
+  factory SecurityStatus.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory SecurityStatus.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'SecurityStatus', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..a<$core.int>(1, _omitFieldNames ? '' : 'standardUpdates', $pb.PbFieldType.O3)
+    ..a<$core.int>(2, _omitFieldNames ? '' : 'esmUpdates', $pb.PbFieldType.O3)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  SecurityStatus clone() => SecurityStatus()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  SecurityStatus copyWith(void Function(SecurityStatus) updates) => super.copyWith((message) => updates(message as SecurityStatus)) as SecurityStatus;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static SecurityStatus create() => SecurityStatus._();
+  SecurityStatus createEmptyInstance() => create();
+  static $pb.PbList<SecurityStatus> createRepeated() => $pb.PbList<SecurityStatus>();
+  @$core.pragma('dart2js:noInline')
+  static SecurityStatus getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<SecurityStatus>(create);
+  static SecurityStatus? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.int get standardUpdates => $_getIZ(0);
+  @$pb.TagNumber(1)
+  set standardUpdates($core.int v) { $_setSignedInt32(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasStandardUpdates() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearStandardUpdates() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.int get esmUpdates => $_getIZ(1);
+  @$pb.TagNumber(2)
+  set esmUpdates($core.int v) { $_setSignedInt32(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasEsmUpdates() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearEsmUpdates() => $_clearField(2);
+}
+
+class ProAttachCmd extends $pb.GeneratedMessage {
+  factory ProAttachCmd({
+    $core.String? token,
+  }) {
+    final $result = create();
+    if (token != null) {
+      $result.token = token;
+    }
+    return $result;
+  }
+  ProAttachCmd._() : super();
+  factory ProAttachCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ProAttachCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ProAttachCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'token')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ProAttachCmd clone() => ProAttachCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ProAttachCmd copyWith(void Function(ProAttachCmd) updates) => super.copyWith((message) => updates(message as ProAttachCmd)) as ProAttachCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ProAttachCmd create() => ProAttachCmd._();
+  ProAttachCmd createEmptyInstance() => create();
+  static $pb.PbList<ProAttachCmd> createRepeated() => $pb.PbList<ProAttachCmd>();
+  @$core.pragma('dart2js:noInline')
+  static ProAttachCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ProAttachCmd>(create);
+  static ProAttachCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get token => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set token($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasToken() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearToken() => $_clearField(1);
+}
+
+class LandscapeConfigCmd extends $pb.GeneratedMessage {
+  factory LandscapeConfigCmd({
+    $core.String? config,
+  }) {
+    final $result = create();
+    if (config != null) {
+      $result.config = config;
+    }
+    return $result;
+  }
+  LandscapeConfigCmd._() : super();
+  factory LandscapeConfigCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory LandscapeConfigCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'LandscapeConfigCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'config')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  LandscapeConfigCmd clone() => LandscapeConfigCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  LandscapeConfigCmd copyWith(void Function(LandscapeConfigCmd) updates) => super.copyWith((message) => updates(message as LandscapeConfigCmd)) as LandscapeConfigCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static LandscapeConfigCmd create() => LandscapeConfigCmd._();
+  LandscapeConfigCmd createEmptyInstance() => create();
+  static $pb.PbList<LandscapeConfigCmd> createRepeated() => $pb.PbList<LandscapeConfigCmd>();
+  @$core.pragma('dart2js:noInline')
+  static LandscapeConfigCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<LandscapeConfigCmd>(create);
+  static LandscapeConfigCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get config => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set config($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasConfig() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearConfig() => $_clearField(1);
+}
+
+enum Command_Cmd {
+  proService, 
+  usg, 
+  serviceUpgrade, 
+  preempt, 
+  manageUser, 
+  patching, 
+  proxy, 
+  proStatus, 
+  snapd, 
+  dns, 
+  notSet
+}
+
+class Command extends $pb.GeneratedMessage {
+  factory Command({
+    ProServiceCmd? proService,
+    UsgCmd? usg,
+    ServiceUpgradeCmd? serviceUpgrade,
+    PreemptCmd? preempt,
+    ManageUserCmd? manageUser,
+    PatchingCmd? patching,
+    ProxyCmd? proxy,
+    ProStatusCmd? proStatus,
+    SnapdCmd? snapd,
+    DnsCmd? dns,
+    $core.int? id,
+  }) {
+    final $result = create();
+    if (proService != null) {
+      $result.proService = proService;
+    }
+    if (usg != null) {
+      $result.usg = usg;
+    }
+    if (serviceUpgrade != null) {
+      $result.serviceUpgrade = serviceUpgrade;
+    }
+    if (preempt != null) {
+      $result.preempt = preempt;
+    }
+    if (manageUser != null) {
+      $result.manageUser = manageUser;
+    }
+    if (patching != null) {
+      $result.patching = patching;
+    }
+    if (proxy != null) {
+      $result.proxy = proxy;
+    }
+    if (proStatus != null) {
+      $result.proStatus = proStatus;
+    }
+    if (snapd != null) {
+      $result.snapd = snapd;
+    }
+    if (dns != null) {
+      $result.dns = dns;
+    }
+    if (id != null) {
+      $result.id = id;
+    }
+    return $result;
+  }
+  Command._() : super();
+  factory Command.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory Command.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static const $core.Map<$core.int, Command_Cmd> _Command_CmdByTag = {
+    1 : Command_Cmd.proService,
+    2 : Command_Cmd.usg,
+    3 : Command_Cmd.serviceUpgrade,
+    6 : Command_Cmd.preempt,
+    7 : Command_Cmd.manageUser,
+    8 : Command_Cmd.patching,
+    9 : Command_Cmd.proxy,
+    11 : Command_Cmd.proStatus,
+    12 : Command_Cmd.snapd,
+    13 : Command_Cmd.dns,
+    0 : Command_Cmd.notSet
+  };
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'Command', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..oo(0, [1, 2, 3, 6, 7, 8, 9, 11, 12, 13])
+    ..aOM<ProServiceCmd>(1, _omitFieldNames ? '' : 'proService', subBuilder: ProServiceCmd.create)
+    ..aOM<UsgCmd>(2, _omitFieldNames ? '' : 'usg', subBuilder: UsgCmd.create)
+    ..aOM<ServiceUpgradeCmd>(3, _omitFieldNames ? '' : 'serviceUpgrade', subBuilder: ServiceUpgradeCmd.create)
+    ..aOM<PreemptCmd>(6, _omitFieldNames ? '' : 'preempt', subBuilder: PreemptCmd.create)
+    ..aOM<ManageUserCmd>(7, _omitFieldNames ? '' : 'manageUser', subBuilder: ManageUserCmd.create)
+    ..aOM<PatchingCmd>(8, _omitFieldNames ? '' : 'patching', subBuilder: PatchingCmd.create)
+    ..aOM<ProxyCmd>(9, _omitFieldNames ? '' : 'proxy', subBuilder: ProxyCmd.create)
+    ..aOM<ProStatusCmd>(11, _omitFieldNames ? '' : 'proStatus', subBuilder: ProStatusCmd.create)
+    ..aOM<SnapdCmd>(12, _omitFieldNames ? '' : 'snapd', subBuilder: SnapdCmd.create)
+    ..aOM<DnsCmd>(13, _omitFieldNames ? '' : 'dns', subBuilder: DnsCmd.create)
+    ..a<$core.int>(10, _omitFieldNames ? '' : 'id', $pb.PbFieldType.OU3)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  Command clone() => Command()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  Command copyWith(void Function(Command) updates) => super.copyWith((message) => updates(message as Command)) as Command;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static Command create() => Command._();
+  Command createEmptyInstance() => create();
+  static $pb.PbList<Command> createRepeated() => $pb.PbList<Command>();
+  @$core.pragma('dart2js:noInline')
+  static Command getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<Command>(create);
+  static Command? _defaultInstance;
+
+  Command_Cmd whichCmd() => _Command_CmdByTag[$_whichOneof(0)]!;
+  void clearCmd() => $_clearField($_whichOneof(0));
+
+  @$pb.TagNumber(1)
+  ProServiceCmd get proService => $_getN(0);
+  @$pb.TagNumber(1)
+  set proService(ProServiceCmd v) { $_setField(1, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasProService() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearProService() => $_clearField(1);
+  @$pb.TagNumber(1)
+  ProServiceCmd ensureProService() => $_ensure(0);
+
+  @$pb.TagNumber(2)
+  UsgCmd get usg => $_getN(1);
+  @$pb.TagNumber(2)
+  set usg(UsgCmd v) { $_setField(2, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasUsg() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearUsg() => $_clearField(2);
+  @$pb.TagNumber(2)
+  UsgCmd ensureUsg() => $_ensure(1);
+
+  @$pb.TagNumber(3)
+  ServiceUpgradeCmd get serviceUpgrade => $_getN(2);
+  @$pb.TagNumber(3)
+  set serviceUpgrade(ServiceUpgradeCmd v) { $_setField(3, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasServiceUpgrade() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearServiceUpgrade() => $_clearField(3);
+  @$pb.TagNumber(3)
+  ServiceUpgradeCmd ensureServiceUpgrade() => $_ensure(2);
+
+  @$pb.TagNumber(6)
+  PreemptCmd get preempt => $_getN(3);
+  @$pb.TagNumber(6)
+  set preempt(PreemptCmd v) { $_setField(6, v); }
+  @$pb.TagNumber(6)
+  $core.bool hasPreempt() => $_has(3);
+  @$pb.TagNumber(6)
+  void clearPreempt() => $_clearField(6);
+  @$pb.TagNumber(6)
+  PreemptCmd ensurePreempt() => $_ensure(3);
+
+  @$pb.TagNumber(7)
+  ManageUserCmd get manageUser => $_getN(4);
+  @$pb.TagNumber(7)
+  set manageUser(ManageUserCmd v) { $_setField(7, v); }
+  @$pb.TagNumber(7)
+  $core.bool hasManageUser() => $_has(4);
+  @$pb.TagNumber(7)
+  void clearManageUser() => $_clearField(7);
+  @$pb.TagNumber(7)
+  ManageUserCmd ensureManageUser() => $_ensure(4);
+
+  @$pb.TagNumber(8)
+  PatchingCmd get patching => $_getN(5);
+  @$pb.TagNumber(8)
+  set patching(PatchingCmd v) { $_setField(8, v); }
+  @$pb.TagNumber(8)
+  $core.bool hasPatching() => $_has(5);
+  @$pb.TagNumber(8)
+  void clearPatching() => $_clearField(8);
+  @$pb.TagNumber(8)
+  PatchingCmd ensurePatching() => $_ensure(5);
+
+  @$pb.TagNumber(9)
+  ProxyCmd get proxy => $_getN(6);
+  @$pb.TagNumber(9)
+  set proxy(ProxyCmd v) { $_setField(9, v); }
+  @$pb.TagNumber(9)
+  $core.bool hasProxy() => $_has(6);
+  @$pb.TagNumber(9)
+  void clearProxy() => $_clearField(9);
+  @$pb.TagNumber(9)
+  ProxyCmd ensureProxy() => $_ensure(6);
+
+  @$pb.TagNumber(11)
+  ProStatusCmd get proStatus => $_getN(7);
+  @$pb.TagNumber(11)
+  set proStatus(ProStatusCmd v) { $_setField(11, v); }
+  @$pb.TagNumber(11)
+  $core.bool hasProStatus() => $_has(7);
+  @$pb.TagNumber(11)
+  void clearProStatus() => $_clearField(11);
+  @$pb.TagNumber(11)
+  ProStatusCmd ensureProStatus() => $_ensure(7);
+
+  @$pb.TagNumber(12)
+  SnapdCmd get snapd => $_getN(8);
+  @$pb.TagNumber(12)
+  set snapd(SnapdCmd v) { $_setField(12, v); }
+  @$pb.TagNumber(12)
+  $core.bool hasSnapd() => $_has(8);
+  @$pb.TagNumber(12)
+  void clearSnapd() => $_clearField(12);
+  @$pb.TagNumber(12)
+  SnapdCmd ensureSnapd() => $_ensure(8);
+
+  @$pb.TagNumber(13)
+  DnsCmd get dns => $_getN(9);
+  @$pb.TagNumber(13)
+  set dns(DnsCmd v) { $_setField(13, v); }
+  @$pb.TagNumber(13)
+  $core.bool hasDns() => $_has(9);
+  @$pb.TagNumber(13)
+  void clearDns() => $_clearField(13);
+  @$pb.TagNumber(13)
+  DnsCmd ensureDns() => $_ensure(9);
+
+  @$pb.TagNumber(10)
+  $core.int get id => $_getIZ(10);
+  @$pb.TagNumber(10)
+  set id($core.int v) { $_setUnsignedInt32(10, v); }
+  @$pb.TagNumber(10)
+  $core.bool hasId() => $_has(10);
+  @$pb.TagNumber(10)
+  void clearId() => $_clearField(10);
+}
+
+class ProServiceCmd extends $pb.GeneratedMessage {
+  factory ProServiceCmd({
+    $core.String? service,
+    $core.bool? enable,
+  }) {
+    final $result = create();
+    if (service != null) {
+      $result.service = service;
+    }
+    if (enable != null) {
+      $result.enable = enable;
+    }
+    return $result;
+  }
+  ProServiceCmd._() : super();
+  factory ProServiceCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ProServiceCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ProServiceCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'service')
+    ..aOB(2, _omitFieldNames ? '' : 'enable')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ProServiceCmd clone() => ProServiceCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ProServiceCmd copyWith(void Function(ProServiceCmd) updates) => super.copyWith((message) => updates(message as ProServiceCmd)) as ProServiceCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ProServiceCmd create() => ProServiceCmd._();
+  ProServiceCmd createEmptyInstance() => create();
+  static $pb.PbList<ProServiceCmd> createRepeated() => $pb.PbList<ProServiceCmd>();
+  @$core.pragma('dart2js:noInline')
+  static ProServiceCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ProServiceCmd>(create);
+  static ProServiceCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get service => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set service($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasService() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearService() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.bool get enable => $_getBF(1);
+  @$pb.TagNumber(2)
+  set enable($core.bool v) { $_setBool(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasEnable() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearEnable() => $_clearField(2);
+}
+
+class UsgCmd extends $pb.GeneratedMessage {
+  factory UsgCmd({
+    $core.String? profile,
+    $core.bool? fix,
+  }) {
+    final $result = create();
+    if (profile != null) {
+      $result.profile = profile;
+    }
+    if (fix != null) {
+      $result.fix = fix;
+    }
+    return $result;
+  }
+  UsgCmd._() : super();
+  factory UsgCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory UsgCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'UsgCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'profile')
+    ..aOB(2, _omitFieldNames ? '' : 'fix')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  UsgCmd clone() => UsgCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  UsgCmd copyWith(void Function(UsgCmd) updates) => super.copyWith((message) => updates(message as UsgCmd)) as UsgCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static UsgCmd create() => UsgCmd._();
+  UsgCmd createEmptyInstance() => create();
+  static $pb.PbList<UsgCmd> createRepeated() => $pb.PbList<UsgCmd>();
+  @$core.pragma('dart2js:noInline')
+  static UsgCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<UsgCmd>(create);
+  static UsgCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get profile => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set profile($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasProfile() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearProfile() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.bool get fix => $_getBF(1);
+  @$pb.TagNumber(2)
+  set fix($core.bool v) { $_setBool(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasFix() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearFix() => $_clearField(2);
+}
+
+class ServiceUpgradeCmd extends $pb.GeneratedMessage {
+  factory ServiceUpgradeCmd({
+    $core.String? channel,
+    $core.String? source,
+    $core.String? checksum,
+  }) {
+    final $result = create();
+    if (channel != null) {
+      $result.channel = channel;
+    }
+    if (source != null) {
+      $result.source = source;
+    }
+    if (checksum != null) {
+      $result.checksum = checksum;
+    }
+    return $result;
+  }
+  ServiceUpgradeCmd._() : super();
+  factory ServiceUpgradeCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ServiceUpgradeCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ServiceUpgradeCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'channel')
+    ..aOS(2, _omitFieldNames ? '' : 'source')
+    ..aOS(3, _omitFieldNames ? '' : 'checksum')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ServiceUpgradeCmd clone() => ServiceUpgradeCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ServiceUpgradeCmd copyWith(void Function(ServiceUpgradeCmd) updates) => super.copyWith((message) => updates(message as ServiceUpgradeCmd)) as ServiceUpgradeCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ServiceUpgradeCmd create() => ServiceUpgradeCmd._();
+  ServiceUpgradeCmd createEmptyInstance() => create();
+  static $pb.PbList<ServiceUpgradeCmd> createRepeated() => $pb.PbList<ServiceUpgradeCmd>();
+  @$core.pragma('dart2js:noInline')
+  static ServiceUpgradeCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ServiceUpgradeCmd>(create);
+  static ServiceUpgradeCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get channel => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set channel($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasChannel() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearChannel() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get source => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set source($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasSource() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearSource() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get checksum => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set checksum($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasChecksum() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearChecksum() => $_clearField(3);
+}
+
+class TailLogCmd extends $pb.GeneratedMessage {
+  factory TailLogCmd({
+    $core.int? lines,
+    $core.String? priority,
+    $core.bool? includeProClient,
+    $core.int? id,
+    $core.bool? follow,
+    $core.bool? cancel,
+  }) {
+    final $result = create();
+    if (lines != null) {
+      $result.lines = lines;
+    }
+    if (priority != null) {
+      $result.priority = priority;
+    }
+    if (includeProClient != null) {
+      $result.includeProClient = includeProClient;
+    }
+    if (id != null) {
+      $result.id = id;
+    }
+    if (follow != null) {
+      $result.follow = follow;
+    }
+    if (cancel != null) {
+      $result.cancel = cancel;
+    }
+    return $result;
+  }
+  TailLogCmd._() : super();
+  factory TailLogCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory TailLogCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'TailLogCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..a<$core.int>(1, _omitFieldNames ? '' : 'lines', $pb.PbFieldType.O3)
+    ..aOS(2, _omitFieldNames ? '' : 'priority')
+    ..aOB(3, _omitFieldNames ? '' : 'includeProClient')
+    ..a<$core.int>(4, _omitFieldNames ? '' : 'id', $pb.PbFieldType.OU3)
+    ..aOB(5, _omitFieldNames ? '' : 'follow')
+    ..aOB(6, _omitFieldNames ? '' : 'cancel')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  TailLogCmd clone() => TailLogCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  TailLogCmd copyWith(void Function(TailLogCmd) updates) => super.copyWith((message) => updates(message as TailLogCmd)) as TailLogCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TailLogCmd create() => TailLogCmd._();
+  TailLogCmd createEmptyInstance() => create();
+  static $pb.PbList<TailLogCmd> createRepeated() => $pb.PbList<TailLogCmd>();
+  @$core.pragma('dart2js:noInline')
+  static TailLogCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<TailLogCmd>(create);
+  static TailLogCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.int get lines => $_getIZ(0);
+  @$pb.TagNumber(1)
+  set lines($core.int v) { $_setSignedInt32(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasLines() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearLines() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get priority => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set priority($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasPriority() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearPriority() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.bool get includeProClient => $_getBF(2);
+  @$pb.TagNumber(3)
+  set includeProClient($core.bool v) { $_setBool(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasIncludeProClient() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearIncludeProClient() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.int get id => $_getIZ(3);
+  @$pb.TagNumber(4)
+  set id($core.int v) { $_setUnsignedInt32(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasId() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearId() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.bool get follow => $_getBF(4);
+  @$pb.TagNumber(5)
+  set follow($core.bool v) { $_setBool(4, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasFollow() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearFollow() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.bool get cancel => $_getBF(5);
+  @$pb.TagNumber(6)
+  set cancel($core.bool v) { $_setBool(5, v); }
+  @$pb.TagNumber(6)
+  $core.bool hasCancel() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearCancel() => $_clearField(6);
+}
+
+class LogMessage extends $pb.GeneratedMessage {
+  factory LogMessage({
+    $core.String? wslName,
+    $core.int? id,
+    $core.String? line,
+    $core.bool? done,
+    $core.String? error,
+  }) {
+    final $result = create();
+    if (wslName != null) {
+      $result.wslName = wslName;
+    }
+    if (id != null) {
+      $result.id = id;
+    }
+    if (line != null) {
+      $result.line = line;
+    }
+    if (done != null) {
+      $result.done = done;
+    }
+    if (error != null) {
+      $result.error = error;
+    }
+    return $result;
+  }
+  LogMessage._() : super();
+  factory LogMessage.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory LogMessage.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'LogMessage', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..a<$core.int>(2, _omitFieldNames ? '' : 'id', $pb.PbFieldType.OU3)
+    ..aOS(3, _omitFieldNames ? '' : 'line')
+    ..aOB(4, _omitFieldNames ? '' : 'done')
+    ..aOS(5, _omitFieldNames ? '' : 'error')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  LogMessage clone() => LogMessage()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  LogMessage copyWith(void Function(LogMessage) updates) => super.copyWith((message) => updates(message as LogMessage)) as LogMessage;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static LogMessage create() => LogMessage._();
+  LogMessage createEmptyInstance() => create();
+  static $pb.PbList<LogMessage> createRepeated() => $pb.PbList<LogMessage>();
+  @$core.pragma('dart2js:noInline')
+  static LogMessage getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<LogMessage>(create);
+  static LogMessage? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.int get id => $_getIZ(1);
+  @$pb.TagNumber(2)
+  set id($core.int v) { $_setUnsignedInt32(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasId() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearId() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get line => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set line($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasLine() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearLine() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.bool get done => $_getBF(3);
+  @$pb.TagNumber(4)
+  set done($core.bool v) { $_setBool(3, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasDone() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearDone() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.String get error => $_getSZ(4);
+  @$pb.TagNumber(5)
+  set error($core.String v) { $_setString(4, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasError() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearError() => $_clearField(5);
+}
+
+class PingCmd extends $pb.GeneratedMessage {
+  factory PingCmd({
+    $core.List<$core.int>? payload,
+    $core.int? id,
+  }) {
+    final $result = create();
+    if (payload != null) {
+      $result.payload = payload;
+    }
+    if (id != null) {
+      $result.id = id;
+    }
+    return $result;
+  }
+  PingCmd._() : super();
+  factory PingCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory PingCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'PingCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..a<$core.List<$core.int>>(1, _omitFieldNames ? '' : 'payload', $pb.PbFieldType.OY)
+    ..a<$core.int>(2, _omitFieldNames ? '' : 'id', $pb.PbFieldType.OU3)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  PingCmd clone() => PingCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  PingCmd copyWith(void Function(PingCmd) updates) => super.copyWith((message) => updates(message as PingCmd)) as PingCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static PingCmd create() => PingCmd._();
+  PingCmd createEmptyInstance() => create();
+  static $pb.PbList<PingCmd> createRepeated() => $pb.PbList<PingCmd>();
+  @$core.pragma('dart2js:noInline')
+  static PingCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<PingCmd>(create);
+  static PingCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.List<$core.int> get payload => $_getN(0);
+  @$pb.TagNumber(1)
+  set payload($core.List<$core.int> v) { $_setBytes(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasPayload() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearPayload() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.int get id => $_getIZ(1);
+  @$pb.TagNumber(2)
+  set id($core.int v) { $_setUnsignedInt32(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasId() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearId() => $_clearField(2);
+}
+
+class PingReply extends $pb.GeneratedMessage {
+  factory PingReply({
+    $core.String? wslName,
+    $core.int? id,
+    $core.List<$core.int>? payload,
+  }) {
+    final $result = create();
+    if (wslName != null) {
+      $result.wslName = wslName;
+    }
+    if (id != null) {
+      $result.id = id;
+    }
+    if (payload != null) {
+      $result.payload = payload;
+    }
+    return $result;
+  }
+  PingReply._() : super();
+  factory PingReply.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory PingReply.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'PingReply', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..a<$core.int>(2, _omitFieldNames ? '' : 'id', $pb.PbFieldType.OU3)
+    ..a<$core.List<$core.int>>(3, _omitFieldNames ? '' : 'payload', $pb.PbFieldType.OY)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  PingReply clone() => PingReply()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  PingReply copyWith(void Function(PingReply) updates) => super.copyWith((message) => updates(message as PingReply)) as PingReply;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static PingReply create() => PingReply._();
+  PingReply createEmptyInstance() => create();
+  static $pb.PbList<PingReply> createRepeated() => $pb.PbList<PingReply>();
+  @$core.pragma('dart2js:noInline')
+  static PingReply getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<PingReply>(create);
+  static PingReply? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.int get id => $_getIZ(1);
+  @$pb.TagNumber(2)
+  set id($core.int v) { $_setUnsignedInt32(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasId() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearId() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.List<$core.int> get payload => $_getN(2);
+  @$pb.TagNumber(3)
+  set payload($core.List<$core.int> v) { $_setBytes(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasPayload() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearPayload() => $_clearField(3);
+}
+
+class PreemptCmd extends $pb.GeneratedMessage {
+  factory PreemptCmd({
+    $core.int? id,
+  }) {
+    final $result = create();
+    if (id != null) {
+      $result.id = id;
+    }
+    return $result;
+  }
+  PreemptCmd._() : super();
+  factory PreemptCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory PreemptCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'PreemptCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..a<$core.int>(1, _omitFieldNames ? '' : 'id', $pb.PbFieldType.OU3)
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  PreemptCmd clone() => PreemptCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  PreemptCmd copyWith(void Function(PreemptCmd) updates) => super.copyWith((message) => updates(message as PreemptCmd)) as PreemptCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static PreemptCmd create() => PreemptCmd._();
+  PreemptCmd createEmptyInstance() => create();
+  static $pb.PbList<PreemptCmd> createRepeated() => $pb.PbList<PreemptCmd>();
+  @$core.pragma('dart2js:noInline')
+  static PreemptCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<PreemptCmd>(create);
+  static PreemptCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.int get id => $_getIZ(0);
+  @$pb.TagNumber(1)
+  set id($core.int v) { $_setUnsignedInt32(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasId() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearId() => $_clearField(1);
+}
+
+class ManageUserCmd extends $pb.GeneratedMessage {
+  factory ManageUserCmd({
+    $core.String? name,
+    $core.Iterable<$core.String>? groups,
+    $core.bool? setDefault,
+  }) {
+    final $result = create();
+    if (name != null) {
+      $result.name = name;
+    }
+    if (groups != null) {
+      $result.groups.addAll(groups);
+    }
+    if (setDefault != null) {
+      $result.setDefault = setDefault;
+    }
+    return $result;
+  }
+  ManageUserCmd._() : super();
+  factory ManageUserCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ManageUserCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ManageUserCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'name')
+    ..pPS(2, _omitFieldNames ? '' : 'groups')
+    ..aOB(3, _omitFieldNames ? '' : 'setDefault')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  ManageUserCmd clone() => ManageUserCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  ManageUserCmd copyWith(void Function(ManageUserCmd) updates) => super.copyWith((message) => updates(message as ManageUserCmd)) as ManageUserCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ManageUserCmd create() => ManageUserCmd._();
+  ManageUserCmd createEmptyInstance() => create();
+  static $pb.PbList<ManageUserCmd> createRepeated() => $pb.PbList<ManageUserCmd>();
+  @$core.pragma('dart2js:noInline')
+  static ManageUserCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ManageUserCmd>(create);
+  static ManageUserCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get name => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set name($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.List<$core.String> get groups => $_getList(1);
+
+  @$pb.TagNumber(3)
+  $core.bool get setDefault => $_getBF(2);
+  @$pb.TagNumber(3)
+  set setDefault($core.bool v) { $_setBool(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasSetDefault() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearSetDefault() => $_clearField(3);
+}
+
+class PatchingCmd extends $pb.GeneratedMessage {
+  factory PatchingCmd({
+    $core.String? level,
+  }) {
+    final $result = create();
+    if (level != null) {
+      $result.level = level;
+    }
+    return $result;
+  }
+  PatchingCmd._() : super();
+  factory PatchingCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory PatchingCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'PatchingCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'level')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  PatchingCmd clone() => PatchingCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  PatchingCmd copyWith(void Function(PatchingCmd) updates) => super.copyWith((message) => updates(message as PatchingCmd)) as PatchingCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static PatchingCmd create() => PatchingCmd._();
+  PatchingCmd createEmptyInstance() => create();
+  static $pb.PbList<PatchingCmd> createRepeated() => $pb.PbList<PatchingCmd>();
+  @$core.pragma('dart2js:noInline')
+  static PatchingCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<PatchingCmd>(create);
+  static PatchingCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get level => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set level($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasLevel() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearLevel() => $_clearField(1);
+}
+
+class SnapdCmd extends $pb.GeneratedMessage {
+  factory SnapdCmd({
+    $core.String? http,
+    $core.String? https,
+    $core.String? storeProxy,
+    $core.String? storeId,
+  }) {
+    final $result = create();
+    if (http != null) {
+      $result.http = http;
+    }
+    if (https != null) {
+      $result.https = https;
+    }
+    if (storeProxy != null) {
+      $result.storeProxy = storeProxy;
+    }
+    if (storeId != null) {
+      $result.storeId = storeId;
+    }
+    return $result;
+  }
+  SnapdCmd._() : super();
+  factory SnapdCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory SnapdCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'SnapdCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'http')
+    ..aOS(2, _omitFieldNames ? '' : 'https')
+    ..aOS(3, _omitFieldNames ? '' : 'storeProxy')
+    ..aOS(4, _omitFieldNames ? '' : 'storeId')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  SnapdCmd clone() => SnapdCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  SnapdCmd copyWith(void Function(SnapdCmd) updates) => super.copyWith((message) => updates(message as SnapdCmd)) as SnapdCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static SnapdCmd create() => SnapdCmd._();
+  SnapdCmd createEmptyInstance() => create();
+  static $pb.PbList<SnapdCmd> createRepeated() => $pb.PbList<SnapdCmd>();
+  @$core.pragma('dart2js:noInline')
+  static SnapdCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<SnapdCmd>(create);
+  static SnapdCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get http => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set http($core.String v) { $_setString(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasHttp() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearHttp() => $_clearField(1);
+
   @$pb.TagNumber(2)
-  $core.bool hasId() => $_has(1);
+  $core.String get https => $_getSZ(1);
   @$pb.TagNumber(2)
-  void clearId() => $_clearField(2);
+  set https($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasHttps() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearHttps() => $_clearField(2);
 
   @$pb.TagNumber(3)
-  $core.String get versionId => $_getSZ(2);
+  $core.String get storeProxy => $_getSZ(2);
   @$pb.TagNumber(3)
-  set versionId($core.String v) { $_setString(2, v); }
+  set storeProxy($core.String v) { $_setString(2, v); }
   @$pb.TagNumber(3)
-  $core.bool hasVersionId() => $_has(2);
+  $core.bool hasStoreProxy() => $_has(2);
   @$pb.TagNumber(3)
-  void clearVersionId() => $_clearField(3);
+  void clearStoreProxy() => $_clearField(3);
 
   @$pb.TagNumber(4)
-  $core.String get prettyName => $_getSZ(3);
+  $core.String get storeId => $_getSZ(3);
   @$pb.TagNumber(4)
-  set prettyName($core.String v) { $_setString(3, v); }
+  set storeId($core.String v) { $_setString(3, v); }
   @$pb.TagNumber(4)
-  $core.bool hasPrettyName() => $_has(3);
+  $core.bool hasStoreId() => $_has(3);
   @$pb.TagNumber(4)
-  void clearPrettyName() => $_clearField(4);
-
-  @$pb.TagNumber(5)
-  $core.bool get proAttached => $_getBF(4);
-  @$pb.TagNumber(5)
-  set proAttached($core.bool v) { $_setBool(4, v); }
-  @$pb.TagNumber(5)
-  $core.bool hasProAttached() => $_has(4);
-  @$pb.TagNumber(5)
-  void clearProAttached() => $_clearField(5);
-
-  @$pb.TagNumber(6)
-  $core.String get hostname => $_getSZ(5);
-  @$pb.TagNumber(6)
-  set hostname($core.String v) { $_setString(5, v); }
-  @$pb.TagNumber(6)
-  $core.bool hasHostname() => $_has(5);
-  @$pb.TagNumber(6)
-  void clearHostname() => $_clearField(6);
+  void clearStoreId() => $_clearField(4);
 }
 
-class ProAttachCmd extends $pb.GeneratedMessage {
-  factory ProAttachCmd({
-    $core.String? token,
+class ProStatusCmd extends $pb.GeneratedMessage {
+  factory ProStatusCmd({
+    $core.bool? attached,
   }) {
     final $result = create();
-    if (token != null) {
-      $result.token = token;
+    if (attached != null) {
+      $result.attached = attached;
     }
     return $result;
   }
-  ProAttachCmd._() : super();
-  factory ProAttachCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
-  factory ProAttachCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+  ProStatusCmd._() : super();
+  factory ProStatusCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ProStatusCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
 
-  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ProAttachCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
-    ..aOS(1, _omitFieldNames ? '' : 'token')
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ProStatusCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOB(1, _omitFieldNames ? '' : 'attached')
     ..hasRequiredFields = false
   ;
 
@@ -592,49 +5220,59 @@ class ProAttachCmd extends $pb.GeneratedMessage {
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
   'Will be removed in next major version')
-  ProAttachCmd clone() => ProAttachCmd()..mergeFromMessage(this);
+  ProStatusCmd clone() => ProStatusCmd()..mergeFromMessage(this);
   @$core.Deprecated(
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
   'Will be removed in next major version')
-  ProAttachCmd copyWith(void Function(ProAttachCmd) updates) => super.copyWith((message) => updates(message as ProAttachCmd)) as ProAttachCmd;
+  ProStatusCmd copyWith(void Function(ProStatusCmd) updates) => super.copyWith((message) => updates(message as ProStatusCmd)) as ProStatusCmd;
 
   $pb.BuilderInfo get info_ => _i;
 
   @$core.pragma('dart2js:noInline')
-  static ProAttachCmd create() => ProAttachCmd._();
-  ProAttachCmd createEmptyInstance() => create();
-  static $pb.PbList<ProAttachCmd> createRepeated() => $pb.PbList<ProAttachCmd>();
+  static ProStatusCmd create() => ProStatusCmd._();
+  ProStatusCmd createEmptyInstance() => create();
+  static $pb.PbList<ProStatusCmd> createRepeated() => $pb.PbList<ProStatusCmd>();
   @$core.pragma('dart2js:noInline')
-  static ProAttachCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ProAttachCmd>(create);
-  static ProAttachCmd? _defaultInstance;
+  static ProStatusCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ProStatusCmd>(create);
+  static ProStatusCmd? _defaultInstance;
 
   @$pb.TagNumber(1)
-  $core.String get token => $_getSZ(0);
+  $core.bool get attached => $_getBF(0);
   @$pb.TagNumber(1)
-  set token($core.String v) { $_setString(0, v); }
+  set attached($core.bool v) { $_setBool(0, v); }
   @$pb.TagNumber(1)
-  $core.bool hasToken() => $_has(0);
+  $core.bool hasAttached() => $_has(0);
   @$pb.TagNumber(1)
-  void clearToken() => $_clearField(1);
+  void clearAttached() => $_clearField(1);
 }
 
-class LandscapeConfigCmd extends $pb.GeneratedMessage {
-  factory LandscapeConfigCmd({
-    $core.String? config,
+class ProxyCmd extends $pb.GeneratedMessage {
+  factory ProxyCmd({
+    $core.String? http,
+    $core.String? https,
+    $core.String? noProxy,
   }) {
     final $result = create();
-    if (config != null) {
-      $result.config = config;
+    if (http != null) {
+      $result.http = http;
+    }
+    if (https != null) {
+      $result.https = https;
+    }
+    if (noProxy != null) {
+      $result.noProxy = noProxy;
     }
     return $result;
   }
-  LandscapeConfigCmd._() : super();
-  factory LandscapeConfigCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
-  factory LandscapeConfigCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+  ProxyCmd._() : super();
+  factory ProxyCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory ProxyCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
 
-  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'LandscapeConfigCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
-    ..aOS(1, _omitFieldNames ? '' : 'config')
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'ProxyCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'http')
+    ..aOS(2, _omitFieldNames ? '' : 'https')
+    ..aOS(3, _omitFieldNames ? '' : 'noProxy')
     ..hasRequiredFields = false
   ;
 
@@ -642,36 +5280,115 @@ class LandscapeConfigCmd extends $pb.GeneratedMessage {
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
   'Will be removed in next major version')
-  LandscapeConfigCmd clone() => LandscapeConfigCmd()..mergeFromMessage(this);
+  ProxyCmd clone() => ProxyCmd()..mergeFromMessage(this);
   @$core.Deprecated(
   'Using this can add significant overhead to your binary. '
   'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
   'Will be removed in next major version')
-  LandscapeConfigCmd copyWith(void Function(LandscapeConfigCmd) updates) => super.copyWith((message) => updates(message as LandscapeConfigCmd)) as LandscapeConfigCmd;
+  ProxyCmd copyWith(void Function(ProxyCmd) updates) => super.copyWith((message) => updates(message as ProxyCmd)) as ProxyCmd;
 
   $pb.BuilderInfo get info_ => _i;
 
   @$core.pragma('dart2js:noInline')
-  static LandscapeConfigCmd create() => LandscapeConfigCmd._();
-  LandscapeConfigCmd createEmptyInstance() => create();
-  static $pb.PbList<LandscapeConfigCmd> createRepeated() => $pb.PbList<LandscapeConfigCmd>();
+  static ProxyCmd create() => ProxyCmd._();
+  ProxyCmd createEmptyInstance() => create();
+  static $pb.PbList<ProxyCmd> createRepeated() => $pb.PbList<ProxyCmd>();
   @$core.pragma('dart2js:noInline')
-  static LandscapeConfigCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<LandscapeConfigCmd>(create);
-  static LandscapeConfigCmd? _defaultInstance;
+  static ProxyCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<ProxyCmd>(create);
+  static ProxyCmd? _defaultInstance;
 
   @$pb.TagNumber(1)
-  $core.String get config => $_getSZ(0);
+  $core.String get http => $_getSZ(0);
   @$pb.TagNumber(1)
-  set config($core.String v) { $_setString(0, v); }
+  set http($core.String v) { $_setString(0, v); }
   @$pb.TagNumber(1)
-  $core.bool hasConfig() => $_has(0);
+  $core.bool hasHttp() => $_has(0);
   @$pb.TagNumber(1)
-  void clearConfig() => $_clearField(1);
+  void clearHttp() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get https => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set https($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasHttps() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearHttps() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get noProxy => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set noProxy($core.String v) { $_setString(2, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasNoProxy() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearNoProxy() => $_clearField(3);
+}
+
+class DnsCmd extends $pb.GeneratedMessage {
+  factory DnsCmd({
+    $core.Iterable<$core.String>? nameservers,
+    $core.Iterable<$core.String>? searchDomains,
+    $core.Iterable<$core.String>? hosts,
+  }) {
+    final $result = create();
+    if (nameservers != null) {
+      $result.nameservers.addAll(nameservers);
+    }
+    if (searchDomains != null) {
+      $result.searchDomains.addAll(searchDomains);
+    }
+    if (hosts != null) {
+      $result.hosts.addAll(hosts);
+    }
+    return $result;
+  }
+  DnsCmd._() : super();
+  factory DnsCmd.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory DnsCmd.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'DnsCmd', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..pPS(1, _omitFieldNames ? '' : 'nameservers')
+    ..pPS(2, _omitFieldNames ? '' : 'searchDomains')
+    ..pPS(3, _omitFieldNames ? '' : 'hosts')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  DnsCmd clone() => DnsCmd()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  DnsCmd copyWith(void Function(DnsCmd) updates) => super.copyWith((message) => updates(message as DnsCmd)) as DnsCmd;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DnsCmd create() => DnsCmd._();
+  DnsCmd createEmptyInstance() => create();
+  static $pb.PbList<DnsCmd> createRepeated() => $pb.PbList<DnsCmd>();
+  @$core.pragma('dart2js:noInline')
+  static DnsCmd getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<DnsCmd>(create);
+  static DnsCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.List<$core.String> get nameservers => $_getList(0);
+
+  @$pb.TagNumber(2)
+  $core.List<$core.String> get searchDomains => $_getList(1);
+
+  @$pb.TagNumber(3)
+  $core.List<$core.String> get hosts => $_getList(2);
 }
 
 enum MSG_Data {
   wslName, 
   result, 
+  progress, 
   notSet
 }
 
@@ -679,6 +5396,10 @@ class MSG extends $pb.GeneratedMessage {
   factory MSG({
     $core.String? wslName,
     $core.String? result,
+    TaskProgress? progress,
+    $core.List<$core.int>? output,
+    $core.bool? preempted,
+    $core.bool? more,
   }) {
     final $result = create();
     if (wslName != null) {
@@ -687,6 +5408,18 @@ class MSG extends $pb.GeneratedMessage {
     if (result != null) {
       $result.result = result;
     }
+    if (progress != null) {
+      $result.progress = progress;
+    }
+    if (output != null) {
+      $result.output = output;
+    }
+    if (preempted != null) {
+      $result.preempted = preempted;
+    }
+    if (more != null) {
+      $result.more = more;
+    }
     return $result;
   }
   MSG._() : super();
@@ -696,12 +5429,17 @@ class MSG extends $pb.GeneratedMessage {
   static const $core.Map<$core.int, MSG_Data> _MSG_DataByTag = {
     1 : MSG_Data.wslName,
     2 : MSG_Data.result,
+    6 : MSG_Data.progress,
     0 : MSG_Data.notSet
   };
   static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'MSG', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
-    ..oo(0, [1, 2])
+    ..oo(0, [1, 2, 6])
     ..aOS(1, _omitFieldNames ? '' : 'wslName')
     ..aOS(2, _omitFieldNames ? '' : 'result')
+    ..aOM<TaskProgress>(6, _omitFieldNames ? '' : 'progress', subBuilder: TaskProgress.create)
+    ..a<$core.List<$core.int>>(3, _omitFieldNames ? '' : 'output', $pb.PbFieldType.OY)
+    ..aOB(4, _omitFieldNames ? '' : 'preempted')
+    ..aOB(5, _omitFieldNames ? '' : 'more')
     ..hasRequiredFields = false
   ;
 
@@ -746,6 +5484,108 @@ class MSG extends $pb.GeneratedMessage {
   $core.bool hasResult() => $_has(1);
   @$pb.TagNumber(2)
   void clearResult() => $_clearField(2);
+
+  @$pb.TagNumber(6)
+  TaskProgress get progress => $_getN(2);
+  @$pb.TagNumber(6)
+  set progress(TaskProgress v) { $_setField(6, v); }
+  @$pb.TagNumber(6)
+  $core.bool hasProgress() => $_has(2);
+  @$pb.TagNumber(6)
+  void clearProgress() => $_clearField(6);
+  @$pb.TagNumber(6)
+  TaskProgress ensureProgress() => $_ensure(2);
+
+  @$pb.TagNumber(3)
+  $core.List<$core.int> get output => $_getN(3);
+  @$pb.TagNumber(3)
+  set output($core.List<$core.int> v) { $_setBytes(3, v); }
+  @$pb.TagNumber(3)
+  $core.bool hasOutput() => $_has(3);
+  @$pb.TagNumber(3)
+  void clearOutput() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.bool get preempted => $_getBF(4);
+  @$pb.TagNumber(4)
+  set preempted($core.bool v) { $_setBool(4, v); }
+  @$pb.TagNumber(4)
+  $core.bool hasPreempted() => $_has(4);
+  @$pb.TagNumber(4)
+  void clearPreempted() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.bool get more => $_getBF(5);
+  @$pb.TagNumber(5)
+  set more($core.bool v) { $_setBool(5, v); }
+  @$pb.TagNumber(5)
+  $core.bool hasMore() => $_has(5);
+  @$pb.TagNumber(5)
+  void clearMore() => $_clearField(5);
+}
+
+class TaskProgress extends $pb.GeneratedMessage {
+  factory TaskProgress({
+    $core.int? percent,
+    $core.String? step,
+  }) {
+    final $result = create();
+    if (percent != null) {
+      $result.percent = percent;
+    }
+    if (step != null) {
+      $result.step = step;
+    }
+    return $result;
+  }
+  TaskProgress._() : super();
+  factory TaskProgress.fromBuffer($core.List<$core.int> i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromBuffer(i, r);
+  factory TaskProgress.fromJson($core.String i, [$pb.ExtensionRegistry r = $pb.ExtensionRegistry.EMPTY]) => create()..mergeFromJson(i, r);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(_omitMessageNames ? '' : 'TaskProgress', package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'), createEmptyInstance: create)
+    ..a<$core.int>(1, _omitFieldNames ? '' : 'percent', $pb.PbFieldType.OU3)
+    ..aOS(2, _omitFieldNames ? '' : 'step')
+    ..hasRequiredFields = false
+  ;
+
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.deepCopy] instead. '
+  'Will be removed in next major version')
+  TaskProgress clone() => TaskProgress()..mergeFromMessage(this);
+  @$core.Deprecated(
+  'Using this can add significant overhead to your binary. '
+  'Use [GeneratedMessageGenericExtensions.rebuild] instead. '
+  'Will be removed in next major version')
+  TaskProgress copyWith(void Function(TaskProgress) updates) => super.copyWith((message) => updates(message as TaskProgress)) as TaskProgress;
+
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TaskProgress create() => TaskProgress._();
+  TaskProgress createEmptyInstance() => create();
+  static $pb.PbList<TaskProgress> createRepeated() => $pb.PbList<TaskProgress>();
+  @$core.pragma('dart2js:noInline')
+  static TaskProgress getDefault() => _defaultInstance ??= $pb.GeneratedMessage.$_defaultFor<TaskProgress>(create);
+  static TaskProgress? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.int get percent => $_getIZ(0);
+  @$pb.TagNumber(1)
+  set percent($core.int v) { $_setUnsignedInt32(0, v); }
+  @$pb.TagNumber(1)
+  $core.bool hasPercent() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearPercent() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get step => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set step($core.String v) { $_setString(1, v); }
+  @$pb.TagNumber(2)
+  $core.bool hasStep() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearStep() => $_clearField(2);
 }
 
 
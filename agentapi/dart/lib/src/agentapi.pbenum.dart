@@ -9,3 +9,145 @@
 // ignore_for_file: non_constant_identifier_names, prefer_final_fields
 // ignore_for_file: unnecessary_import, unnecessary_this, unused_import
 
+import 'dart:core' as $core;
+
+import 'package:protobuf/protobuf.dart' as $pb;
+
+class AgentEventType extends $pb.ProtobufEnum {
+  static const AgentEventType AGENT_EVENT_UNSPECIFIED = AgentEventType._(0, _omitEnumNames ? '' : 'AGENT_EVENT_UNSPECIFIED');
+  static const AgentEventType AGENT_EVENT_DISTRO_ADDED = AgentEventType._(1, _omitEnumNames ? '' : 'AGENT_EVENT_DISTRO_ADDED');
+  static const AgentEventType AGENT_EVENT_DISTRO_REMOVED = AgentEventType._(2, _omitEnumNames ? '' : 'AGENT_EVENT_DISTRO_REMOVED');
+  static const AgentEventType AGENT_EVENT_DISTRO_STAGE_CHANGED = AgentEventType._(3, _omitEnumNames ? '' : 'AGENT_EVENT_DISTRO_STAGE_CHANGED');
+  static const AgentEventType AGENT_EVENT_TASK_FAILED = AgentEventType._(4, _omitEnumNames ? '' : 'AGENT_EVENT_TASK_FAILED');
+  static const AgentEventType AGENT_EVENT_DISTRO_RENAMED = AgentEventType._(5, _omitEnumNames ? '' : 'AGENT_EVENT_DISTRO_RENAMED');
+  static const AgentEventType AGENT_EVENT_TASK_COMPLETED = AgentEventType._(6, _omitEnumNames ? '' : 'AGENT_EVENT_TASK_COMPLETED');
+
+  static const $core.List<AgentEventType> values = <AgentEventType> [
+    AGENT_EVENT_UNSPECIFIED,
+    AGENT_EVENT_DISTRO_ADDED,
+    AGENT_EVENT_DISTRO_REMOVED,
+    AGENT_EVENT_DISTRO_STAGE_CHANGED,
+    AGENT_EVENT_TASK_FAILED,
+    AGENT_EVENT_DISTRO_RENAMED,
+    AGENT_EVENT_TASK_COMPLETED,
+  ];
+
+  static final $core.Map<$core.int, AgentEventType> _byValue = $pb.ProtobufEnum.initByValue(values);
+  static AgentEventType? valueOf($core.int value) => _byValue[value];
+
+  const AgentEventType._($core.int v, $core.String n) : super(v, n);
+}
+
+class TaskEventType extends $pb.ProtobufEnum {
+  static const TaskEventType TASK_EVENT_UNSPECIFIED = TaskEventType._(0, _omitEnumNames ? '' : 'TASK_EVENT_UNSPECIFIED');
+  static const TaskEventType TASK_EVENT_QUEUED = TaskEventType._(1, _omitEnumNames ? '' : 'TASK_EVENT_QUEUED');
+  static const TaskEventType TASK_EVENT_STARTED = TaskEventType._(2, _omitEnumNames ? '' : 'TASK_EVENT_STARTED');
+  static const TaskEventType TASK_EVENT_PROGRESS = TaskEventType._(3, _omitEnumNames ? '' : 'TASK_EVENT_PROGRESS');
+  static const TaskEventType TASK_EVENT_COMPLETED = TaskEventType._(4, _omitEnumNames ? '' : 'TASK_EVENT_COMPLETED');
+  static const TaskEventType TASK_EVENT_FAILED = TaskEventType._(5, _omitEnumNames ? '' : 'TASK_EVENT_FAILED');
+  static const TaskEventType TASK_EVENT_INTERRUPTED = TaskEventType._(6, _omitEnumNames ? '' : 'TASK_EVENT_INTERRUPTED');
+
+  static const $core.List<TaskEventType> values = <TaskEventType> [
+    TASK_EVENT_UNSPECIFIED,
+    TASK_EVENT_QUEUED,
+    TASK_EVENT_STARTED,
+    TASK_EVENT_PROGRESS,
+    TASK_EVENT_COMPLETED,
+    TASK_EVENT_FAILED,
+    TASK_EVENT_INTERRUPTED,
+  ];
+
+  static final $core.Map<$core.int, TaskEventType> _byValue = $pb.ProtobufEnum.initByValue(values);
+  static TaskEventType? valueOf($core.int value) => _byValue[value];
+
+  const TaskEventType._($core.int v, $core.String n) : super(v, n);
+}
+
+class TaskStepStatus extends $pb.ProtobufEnum {
+  static const TaskStepStatus TASK_STEP_UNSPECIFIED = TaskStepStatus._(0, _omitEnumNames ? '' : 'TASK_STEP_UNSPECIFIED');
+  static const TaskStepStatus TASK_STEP_SUCCEEDED = TaskStepStatus._(1, _omitEnumNames ? '' : 'TASK_STEP_SUCCEEDED');
+  static const TaskStepStatus TASK_STEP_FAILED = TaskStepStatus._(2, _omitEnumNames ? '' : 'TASK_STEP_FAILED');
+  static const TaskStepStatus TASK_STEP_SKIPPED = TaskStepStatus._(3, _omitEnumNames ? '' : 'TASK_STEP_SKIPPED');
+
+  static const $core.List<TaskStepStatus> values = <TaskStepStatus> [
+    TASK_STEP_UNSPECIFIED,
+    TASK_STEP_SUCCEEDED,
+    TASK_STEP_FAILED,
+    TASK_STEP_SKIPPED,
+  ];
+
+  static final $core.Map<$core.int, TaskStepStatus> _byValue = $pb.ProtobufEnum.initByValue(values);
+  static TaskStepStatus? valueOf($core.int value) => _byValue[value];
+
+  const TaskStepStatus._($core.int v, $core.String n) : super(v, n);
+}
+
+class ProvisionStage extends $pb.ProtobufEnum {
+  static const ProvisionStage PROVISION_STAGE_UNSPECIFIED = ProvisionStage._(0, _omitEnumNames ? '' : 'PROVISION_STAGE_UNSPECIFIED');
+  static const ProvisionStage PROVISION_STAGE_DOWNLOADING = ProvisionStage._(1, _omitEnumNames ? '' : 'PROVISION_STAGE_DOWNLOADING');
+  static const ProvisionStage PROVISION_STAGE_IMPORTING = ProvisionStage._(2, _omitEnumNames ? '' : 'PROVISION_STAGE_IMPORTING');
+  static const ProvisionStage PROVISION_STAGE_CONFIGURING = ProvisionStage._(3, _omitEnumNames ? '' : 'PROVISION_STAGE_CONFIGURING');
+  static const ProvisionStage PROVISION_STAGE_DONE = ProvisionStage._(4, _omitEnumNames ? '' : 'PROVISION_STAGE_DONE');
+
+  static const $core.List<ProvisionStage> values = <ProvisionStage> [
+    PROVISION_STAGE_UNSPECIFIED,
+    PROVISION_STAGE_DOWNLOADING,
+    PROVISION_STAGE_IMPORTING,
+    PROVISION_STAGE_CONFIGURING,
+    PROVISION_STAGE_DONE,
+  ];
+
+  static final $core.Map<$core.int, ProvisionStage> _byValue = $pb.ProtobufEnum.initByValue(values);
+  static ProvisionStage? valueOf($core.int value) => _byValue[value];
+
+  const ProvisionStage._($core.int v, $core.String n) : super(v, n);
+}
+
+class DistroOperation extends $pb.ProtobufEnum {
+  static const DistroOperation DISTRO_OPERATION_UNSPECIFIED = DistroOperation._(0, _omitEnumNames ? '' : 'DISTRO_OPERATION_UNSPECIFIED');
+  static const DistroOperation DISTRO_OPERATION_SET_SPARSE = DistroOperation._(1, _omitEnumNames ? '' : 'DISTRO_OPERATION_SET_SPARSE');
+  static const DistroOperation DISTRO_OPERATION_MOVE = DistroOperation._(2, _omitEnumNames ? '' : 'DISTRO_OPERATION_MOVE');
+  static const DistroOperation DISTRO_OPERATION_SET_VERSION = DistroOperation._(3, _omitEnumNames ? '' : 'DISTRO_OPERATION_SET_VERSION');
+
+  static const $core.List<DistroOperation> values = <DistroOperation> [
+    DISTRO_OPERATION_UNSPECIFIED,
+    DISTRO_OPERATION_SET_SPARSE,
+    DISTRO_OPERATION_MOVE,
+    DISTRO_OPERATION_SET_VERSION,
+  ];
+
+  static final $core.Map<$core.int, DistroOperation> _byValue = $pb.ProtobufEnum.initByValue(values);
+  static DistroOperation? valueOf($core.int value) => _byValue[value];
+
+  const DistroOperation._($core.int v, $core.String n) : super(v, n);
+}
+
+class Capability extends $pb.ProtobufEnum {
+  static const Capability CAPABILITY_UNSPECIFIED = Capability._(0, _omitEnumNames ? '' : 'CAPABILITY_UNSPECIFIED');
+  static const Capability CAPABILITY_EXEC = Capability._(1, _omitEnumNames ? '' : 'CAPABILITY_EXEC');
+  static const Capability CAPABILITY_FILE_PUSH = Capability._(2, _omitEnumNames ? '' : 'CAPABILITY_FILE_PUSH');
+  static const Capability CAPABILITY_LOGS = Capability._(3, _omitEnumNames ? '' : 'CAPABILITY_LOGS');
+  static const Capability CAPABILITY_INFO_ACK = Capability._(4, _omitEnumNames ? '' : 'CAPABILITY_INFO_ACK');
+  static const Capability CAPABILITY_PING = Capability._(5, _omitEnumNames ? '' : 'CAPABILITY_PING');
+  static const Capability CAPABILITY_COMPRESSION = Capability._(6, _omitEnumNames ? '' : 'CAPABILITY_COMPRESSION');
+  static const Capability CAPABILITY_PROGRESS = Capability._(7, _omitEnumNames ? '' : 'CAPABILITY_PROGRESS');
+
+  static const $core.List<Capability> values = <Capability> [
+    CAPABILITY_UNSPECIFIED,
+    CAPABILITY_EXEC,
+    CAPABILITY_FILE_PUSH,
+    CAPABILITY_LOGS,
+    CAPABILITY_INFO_ACK,
+    CAPABILITY_PING,
+    CAPABILITY_COMPRESSION,
+    CAPABILITY_PROGRESS,
+  ];
+
+  static final $core.Map<$core.int, Capability> _byValue = $pb.ProtobufEnum.initByValue(values);
+  static Capability? valueOf($core.int value) => _byValue[value];
+
+  const Capability._($core.int v, $core.String n) : super(v, n);
+}
+
+
+const _omitEnumNames = $core.bool.fromEnvironment('protobuf.omit_enum_names');
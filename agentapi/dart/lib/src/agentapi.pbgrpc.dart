@@ -41,6 +41,94 @@ class UIClient extends $grpc.Client {
       '/agentapi.UI/NotifyPurchase',
       ($0.Empty value) => value.writeToBuffer(),
       ($core.List<$core.int> value) => $0.SubscriptionInfo.fromBuffer(value));
+  static final _$applyProService = $grpc.ClientMethod<$0.ProServiceInfo, $0.Empty>(
+      '/agentapi.UI/ApplyProService',
+      ($0.ProServiceInfo value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Empty.fromBuffer(value));
+  static final _$applyUsgProfile = $grpc.ClientMethod<$0.UsgProfileInfo, $0.Empty>(
+      '/agentapi.UI/ApplyUsgProfile',
+      ($0.UsgProfileInfo value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Empty.fromBuffer(value));
+  static final _$getUsgReport = $grpc.ClientMethod<$0.UsgReportRequest, $0.UsgReport>(
+      '/agentapi.UI/GetUsgReport',
+      ($0.UsgReportRequest value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.UsgReport.fromBuffer(value));
+  static final _$getComplianceReport = $grpc.ClientMethod<$0.Empty, $0.ComplianceReport>(
+      '/agentapi.UI/GetComplianceReport',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.ComplianceReport.fromBuffer(value));
+  static final _$tailLog = $grpc.ClientMethod<$0.TailLogRequest, $0.LogLine>(
+      '/agentapi.UI/TailLog',
+      ($0.TailLogRequest value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.LogLine.fromBuffer(value));
+  static final _$getNotificationSettings = $grpc.ClientMethod<$0.Empty, $0.NotificationSettings>(
+      '/agentapi.UI/GetNotificationSettings',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.NotificationSettings.fromBuffer(value));
+  static final _$setNotificationSettings = $grpc.ClientMethod<$0.NotificationSettings, $0.Empty>(
+      '/agentapi.UI/SetNotificationSettings',
+      ($0.NotificationSettings value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Empty.fromBuffer(value));
+  static final _$getLatencies = $grpc.ClientMethod<$0.Empty, $0.Latencies>(
+      '/agentapi.UI/GetLatencies',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Latencies.fromBuffer(value));
+  static final _$getSubscriptionDetails = $grpc.ClientMethod<$0.Empty, $0.SubscriptionDetails>(
+      '/agentapi.UI/GetSubscriptionDetails',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.SubscriptionDetails.fromBuffer(value));
+  static final _$watchTasks = $grpc.ClientMethod<$0.WatchTasksRequest, $0.TaskEvent>(
+      '/agentapi.UI/WatchTasks',
+      ($0.WatchTasksRequest value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.TaskEvent.fromBuffer(value));
+  static final _$manageUser = $grpc.ClientMethod<$0.ManageUserInfo, $0.Empty>(
+      '/agentapi.UI/ManageUser',
+      ($0.ManageUserInfo value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Empty.fromBuffer(value));
+  static final _$manageDistro = $grpc.ClientMethod<$0.ManageDistroRequest, $0.Empty>(
+      '/agentapi.UI/ManageDistro',
+      ($0.ManageDistroRequest value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Empty.fromBuffer(value));
+  static final _$getEvents = $grpc.ClientMethod<$0.GetEventsRequest, $0.Events>(
+      '/agentapi.UI/GetEvents',
+      ($0.GetEventsRequest value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Events.fromBuffer(value));
+  static final _$watchConsent = $grpc.ClientMethod<$0.Empty, $0.ConsentRequest>(
+      '/agentapi.UI/WatchConsent',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.ConsentRequest.fromBuffer(value));
+  static final _$answerConsent = $grpc.ClientMethod<$0.ConsentAnswer, $0.Empty>(
+      '/agentapi.UI/AnswerConsent',
+      ($0.ConsentAnswer value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Empty.fromBuffer(value));
+  static final _$getSummary = $grpc.ClientMethod<$0.Empty, $0.Summary>(
+      '/agentapi.UI/GetSummary',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Summary.fromBuffer(value));
+  static final _$watchSummary = $grpc.ClientMethod<$0.Empty, $0.Summary>(
+      '/agentapi.UI/WatchSummary',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Summary.fromBuffer(value));
+  static final _$getInventory = $grpc.ClientMethod<$0.Empty, $0.Inventory>(
+      '/agentapi.UI/GetInventory',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Inventory.fromBuffer(value));
+  static final _$provisionDistro = $grpc.ClientMethod<$0.ProvisionRequest, $0.ProvisionProgress>(
+      '/agentapi.UI/ProvisionDistro',
+      ($0.ProvisionRequest value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.ProvisionProgress.fromBuffer(value));
+  static final _$getWslConfig = $grpc.ClientMethod<$0.Empty, $0.WslConfig>(
+      '/agentapi.UI/GetWslConfig',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.WslConfig.fromBuffer(value));
+  static final _$setWslConfig = $grpc.ClientMethod<$0.WslConfig, $0.WslConfig>(
+      '/agentapi.UI/SetWslConfig',
+      ($0.WslConfig value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.WslConfig.fromBuffer(value));
+  static final _$getBandwidth = $grpc.ClientMethod<$0.Empty, $0.Bandwidth>(
+      '/agentapi.UI/GetBandwidth',
+      ($0.Empty value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Bandwidth.fromBuffer(value));
 
   UIClient($grpc.ClientChannel channel,
       {$grpc.CallOptions? options,
@@ -67,6 +155,94 @@ class UIClient extends $grpc.Client {
   $grpc.ResponseFuture<$0.SubscriptionInfo> notifyPurchase($0.Empty request, {$grpc.CallOptions? options}) {
     return $createUnaryCall(_$notifyPurchase, request, options: options);
   }
+
+  $grpc.ResponseFuture<$0.Empty> applyProService($0.ProServiceInfo request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$applyProService, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Empty> applyUsgProfile($0.UsgProfileInfo request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$applyUsgProfile, request, options: options);
+  }
+
+  $grpc.ResponseStream<$0.UsgReport> getUsgReport($0.UsgReportRequest request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$getUsgReport, $async.Stream.fromIterable([request]), options: options);
+  }
+
+  $grpc.ResponseFuture<$0.ComplianceReport> getComplianceReport($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$getComplianceReport, request, options: options);
+  }
+
+  $grpc.ResponseStream<$0.LogLine> tailLog($0.TailLogRequest request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$tailLog, $async.Stream.fromIterable([request]), options: options);
+  }
+
+  $grpc.ResponseFuture<$0.NotificationSettings> getNotificationSettings($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$getNotificationSettings, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Empty> setNotificationSettings($0.NotificationSettings request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$setNotificationSettings, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Latencies> getLatencies($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$getLatencies, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.SubscriptionDetails> getSubscriptionDetails($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$getSubscriptionDetails, request, options: options);
+  }
+
+  $grpc.ResponseStream<$0.TaskEvent> watchTasks($0.WatchTasksRequest request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$watchTasks, $async.Stream.fromIterable([request]), options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Empty> manageUser($0.ManageUserInfo request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$manageUser, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Empty> manageDistro($0.ManageDistroRequest request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$manageDistro, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Events> getEvents($0.GetEventsRequest request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$getEvents, request, options: options);
+  }
+
+  $grpc.ResponseStream<$0.ConsentRequest> watchConsent($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$watchConsent, $async.Stream.fromIterable([request]), options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Empty> answerConsent($0.ConsentAnswer request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$answerConsent, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Summary> getSummary($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$getSummary, request, options: options);
+  }
+
+  $grpc.ResponseStream<$0.Summary> watchSummary($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$watchSummary, $async.Stream.fromIterable([request]), options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Inventory> getInventory($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$getInventory, request, options: options);
+  }
+
+  $grpc.ResponseStream<$0.ProvisionProgress> provisionDistro($0.ProvisionRequest request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$provisionDistro, $async.Stream.fromIterable([request]), options: options);
+  }
+
+  $grpc.ResponseFuture<$0.WslConfig> getWslConfig($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$getWslConfig, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.WslConfig> setWslConfig($0.WslConfig request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$setWslConfig, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Bandwidth> getBandwidth($0.Empty request, {$grpc.CallOptions? options}) {
+    return $createUnaryCall(_$getBandwidth, request, options: options);
+  }
 }
 
 @$pb.GrpcServiceName('agentapi.UI')
@@ -109,6 +285,160 @@ abstract class UIServiceBase extends $grpc.Service {
         false,
         ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
         ($0.SubscriptionInfo value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.ProServiceInfo, $0.Empty>(
+        'ApplyProService',
+        applyProService_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.ProServiceInfo.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.UsgProfileInfo, $0.Empty>(
+        'ApplyUsgProfile',
+        applyUsgProfile_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.UsgProfileInfo.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.UsgReportRequest, $0.UsgReport>(
+        'GetUsgReport',
+        getUsgReport_Pre,
+        false,
+        true,
+        ($core.List<$core.int> value) => $0.UsgReportRequest.fromBuffer(value),
+        ($0.UsgReport value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.ComplianceReport>(
+        'GetComplianceReport',
+        getComplianceReport_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.ComplianceReport value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.TailLogRequest, $0.LogLine>(
+        'TailLog',
+        tailLog_Pre,
+        false,
+        true,
+        ($core.List<$core.int> value) => $0.TailLogRequest.fromBuffer(value),
+        ($0.LogLine value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.NotificationSettings>(
+        'GetNotificationSettings',
+        getNotificationSettings_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.NotificationSettings value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.NotificationSettings, $0.Empty>(
+        'SetNotificationSettings',
+        setNotificationSettings_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.NotificationSettings.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.Latencies>(
+        'GetLatencies',
+        getLatencies_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.Latencies value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.SubscriptionDetails>(
+        'GetSubscriptionDetails',
+        getSubscriptionDetails_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.SubscriptionDetails value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.WatchTasksRequest, $0.TaskEvent>(
+        'WatchTasks',
+        watchTasks_Pre,
+        false,
+        true,
+        ($core.List<$core.int> value) => $0.WatchTasksRequest.fromBuffer(value),
+        ($0.TaskEvent value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.ManageUserInfo, $0.Empty>(
+        'ManageUser',
+        manageUser_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.ManageUserInfo.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.ManageDistroRequest, $0.Empty>(
+        'ManageDistro',
+        manageDistro_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.ManageDistroRequest.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.GetEventsRequest, $0.Events>(
+        'GetEvents',
+        getEvents_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.GetEventsRequest.fromBuffer(value),
+        ($0.Events value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.ConsentRequest>(
+        'WatchConsent',
+        watchConsent_Pre,
+        false,
+        true,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.ConsentRequest value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.ConsentAnswer, $0.Empty>(
+        'AnswerConsent',
+        answerConsent_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.ConsentAnswer.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.Summary>(
+        'GetSummary',
+        getSummary_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.Summary value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.Summary>(
+        'WatchSummary',
+        watchSummary_Pre,
+        false,
+        true,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.Summary value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.Inventory>(
+        'GetInventory',
+        getInventory_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.Inventory value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.ProvisionRequest, $0.ProvisionProgress>(
+        'ProvisionDistro',
+        provisionDistro_Pre,
+        false,
+        true,
+        ($core.List<$core.int> value) => $0.ProvisionRequest.fromBuffer(value),
+        ($0.ProvisionProgress value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.WslConfig>(
+        'GetWslConfig',
+        getWslConfig_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.WslConfig value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.WslConfig, $0.WslConfig>(
+        'SetWslConfig',
+        setWslConfig_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.WslConfig.fromBuffer(value),
+        ($0.WslConfig value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.Bandwidth>(
+        'GetBandwidth',
+        getBandwidth_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.Bandwidth value) => value.writeToBuffer()));
   }
 
   $async.Future<$0.SubscriptionInfo> applyProToken_Pre($grpc.ServiceCall $call, $async.Future<$0.ProAttachInfo> $request) async {
@@ -131,11 +461,121 @@ abstract class UIServiceBase extends $grpc.Service {
     return notifyPurchase($call, await $request);
   }
 
+  $async.Future<$0.Empty> applyProService_Pre($grpc.ServiceCall $call, $async.Future<$0.ProServiceInfo> $request) async {
+    return applyProService($call, await $request);
+  }
+
+  $async.Future<$0.Empty> applyUsgProfile_Pre($grpc.ServiceCall $call, $async.Future<$0.UsgProfileInfo> $request) async {
+    return applyUsgProfile($call, await $request);
+  }
+
+  $async.Stream<$0.UsgReport> getUsgReport_Pre($grpc.ServiceCall $call, $async.Future<$0.UsgReportRequest> $request) async* {
+    yield* getUsgReport($call, await $request);
+  }
+
+  $async.Future<$0.ComplianceReport> getComplianceReport_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getComplianceReport($call, await $request);
+  }
+
+  $async.Stream<$0.LogLine> tailLog_Pre($grpc.ServiceCall $call, $async.Future<$0.TailLogRequest> $request) async* {
+    yield* tailLog($call, await $request);
+  }
+
+  $async.Future<$0.NotificationSettings> getNotificationSettings_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getNotificationSettings($call, await $request);
+  }
+
+  $async.Future<$0.Empty> setNotificationSettings_Pre($grpc.ServiceCall $call, $async.Future<$0.NotificationSettings> $request) async {
+    return setNotificationSettings($call, await $request);
+  }
+
+  $async.Future<$0.Latencies> getLatencies_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getLatencies($call, await $request);
+  }
+
+  $async.Future<$0.SubscriptionDetails> getSubscriptionDetails_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getSubscriptionDetails($call, await $request);
+  }
+
+  $async.Stream<$0.TaskEvent> watchTasks_Pre($grpc.ServiceCall $call, $async.Future<$0.WatchTasksRequest> $request) async* {
+    yield* watchTasks($call, await $request);
+  }
+
+  $async.Future<$0.Empty> manageUser_Pre($grpc.ServiceCall $call, $async.Future<$0.ManageUserInfo> $request) async {
+    return manageUser($call, await $request);
+  }
+
+  $async.Future<$0.Empty> manageDistro_Pre($grpc.ServiceCall $call, $async.Future<$0.ManageDistroRequest> $request) async {
+    return manageDistro($call, await $request);
+  }
+
+  $async.Future<$0.Events> getEvents_Pre($grpc.ServiceCall $call, $async.Future<$0.GetEventsRequest> $request) async {
+    return getEvents($call, await $request);
+  }
+
+  $async.Stream<$0.ConsentRequest> watchConsent_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async* {
+    yield* watchConsent($call, await $request);
+  }
+
+  $async.Future<$0.Empty> answerConsent_Pre($grpc.ServiceCall $call, $async.Future<$0.ConsentAnswer> $request) async {
+    return answerConsent($call, await $request);
+  }
+
+  $async.Future<$0.Summary> getSummary_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getSummary($call, await $request);
+  }
+
+  $async.Stream<$0.Summary> watchSummary_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async* {
+    yield* watchSummary($call, await $request);
+  }
+
+  $async.Future<$0.Inventory> getInventory_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getInventory($call, await $request);
+  }
+
+  $async.Stream<$0.ProvisionProgress> provisionDistro_Pre($grpc.ServiceCall $call, $async.Future<$0.ProvisionRequest> $request) async* {
+    yield* provisionDistro($call, await $request);
+  }
+
+  $async.Future<$0.WslConfig> getWslConfig_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getWslConfig($call, await $request);
+  }
+
+  $async.Future<$0.WslConfig> setWslConfig_Pre($grpc.ServiceCall $call, $async.Future<$0.WslConfig> $request) async {
+    return setWslConfig($call, await $request);
+  }
+
+  $async.Future<$0.Bandwidth> getBandwidth_Pre($grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getBandwidth($call, await $request);
+  }
+
   $async.Future<$0.SubscriptionInfo> applyProToken($grpc.ServiceCall call, $0.ProAttachInfo request);
   $async.Future<$0.LandscapeSource> applyLandscapeConfig($grpc.ServiceCall call, $0.LandscapeConfig request);
   $async.Future<$0.Empty> ping($grpc.ServiceCall call, $0.Empty request);
   $async.Future<$0.ConfigSources> getConfigSources($grpc.ServiceCall call, $0.Empty request);
   $async.Future<$0.SubscriptionInfo> notifyPurchase($grpc.ServiceCall call, $0.Empty request);
+  $async.Future<$0.Empty> applyProService($grpc.ServiceCall call, $0.ProServiceInfo request);
+  $async.Future<$0.Empty> applyUsgProfile($grpc.ServiceCall call, $0.UsgProfileInfo request);
+  $async.Stream<$0.UsgReport> getUsgReport($grpc.ServiceCall call, $0.UsgReportRequest request);
+  $async.Future<$0.ComplianceReport> getComplianceReport($grpc.ServiceCall call, $0.Empty request);
+  $async.Stream<$0.LogLine> tailLog($grpc.ServiceCall call, $0.TailLogRequest request);
+  $async.Future<$0.NotificationSettings> getNotificationSettings($grpc.ServiceCall call, $0.Empty request);
+  $async.Future<$0.Empty> setNotificationSettings($grpc.ServiceCall call, $0.NotificationSettings request);
+  $async.Future<$0.Latencies> getLatencies($grpc.ServiceCall call, $0.Empty request);
+  $async.Future<$0.SubscriptionDetails> getSubscriptionDetails($grpc.ServiceCall call, $0.Empty request);
+  $async.Stream<$0.TaskEvent> watchTasks($grpc.ServiceCall call, $0.WatchTasksRequest request);
+  $async.Future<$0.Empty> manageUser($grpc.ServiceCall call, $0.ManageUserInfo request);
+  $async.Future<$0.Empty> manageDistro($grpc.ServiceCall call, $0.ManageDistroRequest request);
+  $async.Future<$0.Events> getEvents($grpc.ServiceCall call, $0.GetEventsRequest request);
+  $async.Stream<$0.ConsentRequest> watchConsent($grpc.ServiceCall call, $0.Empty request);
+  $async.Future<$0.Empty> answerConsent($grpc.ServiceCall call, $0.ConsentAnswer request);
+  $async.Future<$0.Summary> getSummary($grpc.ServiceCall call, $0.Empty request);
+  $async.Stream<$0.Summary> watchSummary($grpc.ServiceCall call, $0.Empty request);
+  $async.Future<$0.Inventory> getInventory($grpc.ServiceCall call, $0.Empty request);
+  $async.Stream<$0.ProvisionProgress> provisionDistro($grpc.ServiceCall call, $0.ProvisionRequest request);
+  $async.Future<$0.WslConfig> getWslConfig($grpc.ServiceCall call, $0.Empty request);
+  $async.Future<$0.WslConfig> setWslConfig($grpc.ServiceCall call, $0.WslConfig request);
+  $async.Future<$0.Bandwidth> getBandwidth($grpc.ServiceCall call, $0.Empty request);
 }
 @$pb.GrpcServiceName('agentapi.WSLInstance')
 class WSLInstanceClient extends $grpc.Client {
@@ -143,6 +583,10 @@ class WSLInstanceClient extends $grpc.Client {
       '/agentapi.WSLInstance/Connected',
       ($0.DistroInfo value) => value.writeToBuffer(),
       ($core.List<$core.int> value) => $0.Empty.fromBuffer(value));
+  static final _$session = $grpc.ClientMethod<$0.DistroMessage, $0.HandshakeAck>(
+      '/agentapi.WSLInstance/Session',
+      ($0.DistroMessage value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.HandshakeAck.fromBuffer(value));
   static final _$proAttachmentCommands = $grpc.ClientMethod<$0.MSG, $0.ProAttachCmd>(
       '/agentapi.WSLInstance/ProAttachmentCommands',
       ($0.MSG value) => value.writeToBuffer(),
@@ -151,6 +595,18 @@ class WSLInstanceClient extends $grpc.Client {
       '/agentapi.WSLInstance/LandscapeConfigCommands',
       ($0.MSG value) => value.writeToBuffer(),
       ($core.List<$core.int> value) => $0.LandscapeConfigCmd.fromBuffer(value));
+  static final _$commands = $grpc.ClientMethod<$0.MSG, $0.Command>(
+      '/agentapi.WSLInstance/Commands',
+      ($0.MSG value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.Command.fromBuffer(value));
+  static final _$tailLog = $grpc.ClientMethod<$0.LogMessage, $0.TailLogCmd>(
+      '/agentapi.WSLInstance/TailLog',
+      ($0.LogMessage value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.TailLogCmd.fromBuffer(value));
+  static final _$ping = $grpc.ClientMethod<$0.PingReply, $0.PingCmd>(
+      '/agentapi.WSLInstance/Ping',
+      ($0.PingReply value) => value.writeToBuffer(),
+      ($core.List<$core.int> value) => $0.PingCmd.fromBuffer(value));
 
   WSLInstanceClient($grpc.ClientChannel channel,
       {$grpc.CallOptions? options,
@@ -162,6 +618,10 @@ class WSLInstanceClient extends $grpc.Client {
     return $createStreamingCall(_$connected, request, options: options).single;
   }
 
+  $grpc.ResponseStream<$0.HandshakeAck> session($async.Stream<$0.DistroMessage> request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$session, request, options: options);
+  }
+
   $grpc.ResponseStream<$0.ProAttachCmd> proAttachmentCommands($async.Stream<$0.MSG> request, {$grpc.CallOptions? options}) {
     return $createStreamingCall(_$proAttachmentCommands, request, options: options);
   }
@@ -169,6 +629,18 @@ class WSLInstanceClient extends $grpc.Client {
   $grpc.ResponseStream<$0.LandscapeConfigCmd> landscapeConfigCommands($async.Stream<$0.MSG> request, {$grpc.CallOptions? options}) {
     return $createStreamingCall(_$landscapeConfigCommands, request, options: options);
   }
+
+  $grpc.ResponseStream<$0.Command> commands($async.Stream<$0.MSG> request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$commands, request, options: options);
+  }
+
+  $grpc.ResponseStream<$0.TailLogCmd> tailLog($async.Stream<$0.LogMessage> request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$tailLog, request, options: options);
+  }
+
+  $grpc.ResponseStream<$0.PingCmd> ping($async.Stream<$0.PingReply> request, {$grpc.CallOptions? options}) {
+    return $createStreamingCall(_$ping, request, options: options);
+  }
 }
 
 @$pb.GrpcServiceName('agentapi.WSLInstance')
@@ -183,6 +655,13 @@ abstract class WSLInstanceServiceBase extends $grpc.Service {
         false,
         ($core.List<$core.int> value) => $0.DistroInfo.fromBuffer(value),
         ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.DistroMessage, $0.HandshakeAck>(
+        'Session',
+        session,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.DistroMessage.fromBuffer(value),
+        ($0.HandshakeAck value) => value.writeToBuffer()));
     $addMethod($grpc.ServiceMethod<$0.MSG, $0.ProAttachCmd>(
         'ProAttachmentCommands',
         proAttachmentCommands,
@@ -197,9 +676,34 @@ abstract class WSLInstanceServiceBase extends $grpc.Service {
         true,
         ($core.List<$core.int> value) => $0.MSG.fromBuffer(value),
         ($0.LandscapeConfigCmd value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.MSG, $0.Command>(
+        'Commands',
+        commands,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.MSG.fromBuffer(value),
+        ($0.Command value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.LogMessage, $0.TailLogCmd>(
+        'TailLog',
+        tailLog,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.LogMessage.fromBuffer(value),
+        ($0.TailLogCmd value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.PingReply, $0.PingCmd>(
+        'Ping',
+        ping,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.PingReply.fromBuffer(value),
+        ($0.PingCmd value) => value.writeToBuffer()));
   }
 
   $async.Future<$0.Empty> connected($grpc.ServiceCall call, $async.Stream<$0.DistroInfo> request);
+  $async.Stream<$0.HandshakeAck> session($grpc.ServiceCall call, $async.Stream<$0.DistroMessage> request);
   $async.Stream<$0.ProAttachCmd> proAttachmentCommands($grpc.ServiceCall call, $async.Stream<$0.MSG> request);
   $async.Stream<$0.LandscapeConfigCmd> landscapeConfigCommands($grpc.ServiceCall call, $async.Stream<$0.MSG> request);
+  $async.Stream<$0.Command> commands($grpc.ServiceCall call, $async.Stream<$0.MSG> request);
+  $async.Stream<$0.TailLogCmd> tailLog($grpc.ServiceCall call, $async.Stream<$0.LogMessage> request);
+  $async.Stream<$0.PingCmd> ping($grpc.ServiceCall call, $async.Stream<$0.PingReply> request);
 }
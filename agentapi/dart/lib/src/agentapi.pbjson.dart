@@ -13,6 +13,124 @@ import 'dart:convert' as $convert;
 import 'dart:core' as $core;
 import 'dart:typed_data' as $typed_data;
 
+@$core.Deprecated('Use agentEventTypeDescriptor instead')
+const AgentEventType$json = {
+  '1': 'AgentEventType',
+  '2': [
+    {'1': 'AGENT_EVENT_UNSPECIFIED', '2': 0},
+    {'1': 'AGENT_EVENT_DISTRO_ADDED', '2': 1},
+    {'1': 'AGENT_EVENT_DISTRO_REMOVED', '2': 2},
+    {'1': 'AGENT_EVENT_DISTRO_STAGE_CHANGED', '2': 3},
+    {'1': 'AGENT_EVENT_TASK_FAILED', '2': 4},
+    {'1': 'AGENT_EVENT_DISTRO_RENAMED', '2': 5},
+    {'1': 'AGENT_EVENT_TASK_COMPLETED', '2': 6},
+  ],
+};
+
+/// Descriptor for `AgentEventType`. Decode as a `google.protobuf.EnumDescriptorProto`.
+final $typed_data.Uint8List agentEventTypeDescriptor = $convert.base64Decode(
+    'Cg5BZ2VudEV2ZW50VHlwZRIbChdBR0VOVF9FVkVOVF9VTlNQRUNJRklFRBAAEhwKGEFHRU5UX0'
+    'VWRU5UX0RJU1RST19BRERFRBABEh4KGkFHRU5UX0VWRU5UX0RJU1RST19SRU1PVkVEEAISJAog'
+    'QUdFTlRfRVZFTlRfRElTVFJPX1NUQUdFX0NIQU5HRUQQAxIbChdBR0VOVF9FVkVOVF9UQVNLX0'
+    'ZBSUxFRBAEEh4KGkFHRU5UX0VWRU5UX0RJU1RST19SRU5BTUVEEAUSHgoaQUdFTlRfRVZFTlRf'
+    'VEFTS19DT01QTEVURUQQBg==');
+
+@$core.Deprecated('Use taskEventTypeDescriptor instead')
+const TaskEventType$json = {
+  '1': 'TaskEventType',
+  '2': [
+    {'1': 'TASK_EVENT_UNSPECIFIED', '2': 0},
+    {'1': 'TASK_EVENT_QUEUED', '2': 1},
+    {'1': 'TASK_EVENT_STARTED', '2': 2},
+    {'1': 'TASK_EVENT_PROGRESS', '2': 3},
+    {'1': 'TASK_EVENT_COMPLETED', '2': 4},
+    {'1': 'TASK_EVENT_FAILED', '2': 5},
+    {'1': 'TASK_EVENT_INTERRUPTED', '2': 6},
+  ],
+};
+
+/// Descriptor for `TaskEventType`. Decode as a `google.protobuf.EnumDescriptorProto`.
+final $typed_data.Uint8List taskEventTypeDescriptor = $convert.base64Decode(
+    'Cg1UYXNrRXZlbnRUeXBlEhoKFlRBU0tfRVZFTlRfVU5TUEVDSUZJRUQQABIVChFUQVNLX0VWRU'
+    '5UX1FVRVVFRBABEhYKElRBU0tfRVZFTlRfU1RBUlRFRBACEhcKE1RBU0tfRVZFTlRfUFJPR1JF'
+    'U1MQAxIYChRUQVNLX0VWRU5UX0NPTVBMRVRFRBAEEhUKEVRBU0tfRVZFTlRfRkFJTEVEEAUSGg'
+    'oWVEFTS19FVkVOVF9JTlRFUlJVUFRFRBAG');
+
+@$core.Deprecated('Use taskStepStatusDescriptor instead')
+const TaskStepStatus$json = {
+  '1': 'TaskStepStatus',
+  '2': [
+    {'1': 'TASK_STEP_UNSPECIFIED', '2': 0},
+    {'1': 'TASK_STEP_SUCCEEDED', '2': 1},
+    {'1': 'TASK_STEP_FAILED', '2': 2},
+    {'1': 'TASK_STEP_SKIPPED', '2': 3},
+  ],
+};
+
+/// Descriptor for `TaskStepStatus`. Decode as a `google.protobuf.EnumDescriptorProto`.
+final $typed_data.Uint8List taskStepStatusDescriptor = $convert.base64Decode(
+    'Cg5UYXNrU3RlcFN0YXR1cxIZChVUQVNLX1NURVBfVU5TUEVDSUZJRUQQABIXChNUQVNLX1NURV'
+    'BfU1VDQ0VFREVEEAESFAoQVEFTS19TVEVQX0ZBSUxFRBACEhUKEVRBU0tfU1RFUF9TS0lQUEVE'
+    'EAM=');
+
+@$core.Deprecated('Use provisionStageDescriptor instead')
+const ProvisionStage$json = {
+  '1': 'ProvisionStage',
+  '2': [
+    {'1': 'PROVISION_STAGE_UNSPECIFIED', '2': 0},
+    {'1': 'PROVISION_STAGE_DOWNLOADING', '2': 1},
+    {'1': 'PROVISION_STAGE_IMPORTING', '2': 2},
+    {'1': 'PROVISION_STAGE_CONFIGURING', '2': 3},
+    {'1': 'PROVISION_STAGE_DONE', '2': 4},
+  ],
+};
+
+/// Descriptor for `ProvisionStage`. Decode as a `google.protobuf.EnumDescriptorProto`.
+final $typed_data.Uint8List provisionStageDescriptor = $convert.base64Decode(
+    'Cg5Qcm92aXNpb25TdGFnZRIfChtQUk9WSVNJT05fU1RBR0VfVU5TUEVDSUZJRUQQABIfChtQUk'
+    '9WSVNJT05fU1RBR0VfRE9XTkxPQURJTkcQARIdChlQUk9WSVNJT05fU1RBR0VfSU1QT1JUSU5H'
+    'EAISHwobUFJPVklTSU9OX1NUQUdFX0NPTkZJR1VSSU5HEAMSGAoUUFJPVklTSU9OX1NUQUdFX0'
+    'RPTkUQBA==');
+
+@$core.Deprecated('Use distroOperationDescriptor instead')
+const DistroOperation$json = {
+  '1': 'DistroOperation',
+  '2': [
+    {'1': 'DISTRO_OPERATION_UNSPECIFIED', '2': 0},
+    {'1': 'DISTRO_OPERATION_SET_SPARSE', '2': 1},
+    {'1': 'DISTRO_OPERATION_MOVE', '2': 2},
+    {'1': 'DISTRO_OPERATION_SET_VERSION', '2': 3},
+  ],
+};
+
+/// Descriptor for `DistroOperation`. Decode as a `google.protobuf.EnumDescriptorProto`.
+final $typed_data.Uint8List distroOperationDescriptor = $convert.base64Decode(
+    'Cg9EaXN0cm9PcGVyYXRpb24SIAocRElTVFJPX09QRVJBVElPTl9VTlNQRUNJRklFRBAAEh8KG0'
+    'RJU1RST19PUEVSQVRJT05fU0VUX1NQQVJTRRABEhkKFURJU1RST19PUEVSQVRJT05fTU9WRRAC'
+    'EiAKHERJU1RST19PUEVSQVRJT05fU0VUX1ZFUlNJT04QAw==');
+
+@$core.Deprecated('Use capabilityDescriptor instead')
+const Capability$json = {
+  '1': 'Capability',
+  '2': [
+    {'1': 'CAPABILITY_UNSPECIFIED', '2': 0},
+    {'1': 'CAPABILITY_EXEC', '2': 1},
+    {'1': 'CAPABILITY_FILE_PUSH', '2': 2},
+    {'1': 'CAPABILITY_LOGS', '2': 3},
+    {'1': 'CAPABILITY_INFO_ACK', '2': 4},
+    {'1': 'CAPABILITY_PING', '2': 5},
+    {'1': 'CAPABILITY_COMPRESSION', '2': 6},
+    {'1': 'CAPABILITY_PROGRESS', '2': 7},
+  ],
+};
+
+/// Descriptor for `Capability`. Decode as a `google.protobuf.EnumDescriptorProto`.
+final $typed_data.Uint8List capabilityDescriptor = $convert.base64Decode(
+    'CgpDYXBhYmlsaXR5EhoKFkNBUEFCSUxJVFlfVU5TUEVDSUZJRUQQABITCg9DQVBBQklMSVRZX0'
+    'VYRUMQARIYChRDQVBBQklMSVRZX0ZJTEVfUFVTSBACEhMKD0NBUEFCSUxJVFlfTE9HUxADEhcK'
+    'E0NBUEFCSUxJVFlfSU5GT19BQ0sQBBITCg9DQVBBQklMSVRZX1BJTkcQBRIaChZDQVBBQklMSV'
+    'RZX0NPTVBSRVNTSU9OEAYSFwoTQ0FQQUJJTElUWV9QUk9HUkVTUxAH');
+
 @$core.Deprecated('Use emptyDescriptor instead')
 const Empty$json = {
   '1': 'Empty',
@@ -46,6 +164,557 @@ const LandscapeConfig$json = {
 final $typed_data.Uint8List landscapeConfigDescriptor = $convert.base64Decode(
     'Cg9MYW5kc2NhcGVDb25maWcSFgoGY29uZmlnGAEgASgJUgZjb25maWc=');
 
+@$core.Deprecated('Use proServiceInfoDescriptor instead')
+const ProServiceInfo$json = {
+  '1': 'ProServiceInfo',
+  '2': [
+    {'1': 'distros', '3': 1, '4': 3, '5': 9, '10': 'distros'},
+    {'1': 'service', '3': 2, '4': 1, '5': 9, '10': 'service'},
+    {'1': 'enable', '3': 3, '4': 1, '5': 8, '10': 'enable'},
+  ],
+};
+
+/// Descriptor for `ProServiceInfo`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List proServiceInfoDescriptor = $convert.base64Decode(
+    'Cg5Qcm9TZXJ2aWNlSW5mbxIYCgdkaXN0cm9zGAEgAygJUgdkaXN0cm9zEhgKB3NlcnZpY2UYAi'
+    'ABKAlSB3NlcnZpY2USFgoGZW5hYmxlGAMgASgIUgZlbmFibGU=');
+
+@$core.Deprecated('Use usgProfileInfoDescriptor instead')
+const UsgProfileInfo$json = {
+  '1': 'UsgProfileInfo',
+  '2': [
+    {'1': 'distros', '3': 1, '4': 3, '5': 9, '10': 'distros'},
+    {'1': 'profile', '3': 2, '4': 1, '5': 9, '10': 'profile'},
+    {'1': 'fix', '3': 3, '4': 1, '5': 8, '10': 'fix'},
+  ],
+};
+
+/// Descriptor for `UsgProfileInfo`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List usgProfileInfoDescriptor = $convert.base64Decode(
+    'Cg5Vc2dQcm9maWxlSW5mbxIYCgdkaXN0cm9zGAEgAygJUgdkaXN0cm9zEhgKB3Byb2ZpbGUYAi'
+    'ABKAlSB3Byb2ZpbGUSEAoDZml4GAMgASgIUgNmaXg=');
+
+@$core.Deprecated('Use manageUserInfoDescriptor instead')
+const ManageUserInfo$json = {
+  '1': 'ManageUserInfo',
+  '2': [
+    {'1': 'distros', '3': 1, '4': 3, '5': 9, '10': 'distros'},
+    {'1': 'name', '3': 2, '4': 1, '5': 9, '10': 'name'},
+    {'1': 'groups', '3': 3, '4': 3, '5': 9, '10': 'groups'},
+    {'1': 'set_default', '3': 4, '4': 1, '5': 8, '10': 'setDefault'},
+  ],
+};
+
+/// Descriptor for `ManageUserInfo`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List manageUserInfoDescriptor = $convert.base64Decode(
+    'Cg5NYW5hZ2VVc2VySW5mbxIYCgdkaXN0cm9zGAEgAygJUgdkaXN0cm9zEhIKBG5hbWUYAiABKA'
+    'lSBG5hbWUSFgoGZ3JvdXBzGAMgAygJUgZncm91cHMSHwoLc2V0X2RlZmF1bHQYBCABKAhSCnNl'
+    'dERlZmF1bHQ=');
+
+@$core.Deprecated('Use manageDistroRequestDescriptor instead')
+const ManageDistroRequest$json = {
+  '1': 'ManageDistroRequest',
+  '2': [
+    {'1': 'distros', '3': 1, '4': 3, '5': 9, '10': 'distros'},
+    {'1': 'set_sparse', '3': 2, '4': 1, '5': 8, '9': 0, '10': 'setSparse'},
+    {'1': 'move', '3': 3, '4': 1, '5': 9, '9': 0, '10': 'move'},
+    {'1': 'set_version', '3': 4, '4': 1, '5': 5, '9': 0, '10': 'setVersion'},
+  ],
+  '8': [
+    {'1': 'operation'},
+  ],
+};
+
+/// Descriptor for `ManageDistroRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List manageDistroRequestDescriptor = $convert.base64Decode(
+    'ChNNYW5hZ2VEaXN0cm9SZXF1ZXN0EhgKB2Rpc3Ryb3MYASADKAlSB2Rpc3Ryb3MSHwoKc2V0X3'
+    'NwYXJzZRgCIAEoCEgAUglzZXRTcGFyc2USFAoEbW92ZRgDIAEoCUgAUgRtb3ZlEiEKC3NldF92'
+    'ZXJzaW9uGAQgASgFSABSCnNldFZlcnNpb25CCwoJb3BlcmF0aW9u');
+
+@$core.Deprecated('Use getEventsRequestDescriptor instead')
+const GetEventsRequest$json = {
+  '1': 'GetEventsRequest',
+  '2': [
+    {'1': 'since_token', '3': 1, '4': 1, '5': 9, '10': 'sinceToken'},
+  ],
+};
+
+/// Descriptor for `GetEventsRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List getEventsRequestDescriptor = $convert.base64Decode(
+    'ChBHZXRFdmVudHNSZXF1ZXN0Eh8KC3NpbmNlX3Rva2VuGAEgASgJUgpzaW5jZVRva2Vu');
+
+@$core.Deprecated('Use eventsDescriptor instead')
+const Events$json = {
+  '1': 'Events',
+  '2': [
+    {'1': 'events', '3': 1, '4': 3, '5': 11, '6': '.agentapi.AgentEvent', '10': 'events'},
+    {'1': 'next_token', '3': 2, '4': 1, '5': 9, '10': 'nextToken'},
+    {'1': 'truncated', '3': 3, '4': 1, '5': 8, '10': 'truncated'},
+  ],
+};
+
+/// Descriptor for `Events`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List eventsDescriptor = $convert.base64Decode(
+    'CgZFdmVudHMSLAoGZXZlbnRzGAEgAygLMhQuYWdlbnRhcGkuQWdlbnRFdmVudFIGZXZlbnRzEh'
+    '0KCm5leHRfdG9rZW4YAiABKAlSCW5leHRUb2tlbhIcCgl0cnVuY2F0ZWQYAyABKAhSCXRydW5j'
+    'YXRlZA==');
+
+@$core.Deprecated('Use agentEventDescriptor instead')
+const AgentEvent$json = {
+  '1': 'AgentEvent',
+  '2': [
+    {'1': 'type', '3': 1, '4': 1, '5': 14, '6': '.agentapi.AgentEventType', '10': 'type'},
+    {'1': 'time', '3': 2, '4': 1, '5': 9, '10': 'time'},
+    {'1': 'distro', '3': 3, '4': 1, '5': 9, '10': 'distro'},
+    {'1': 'message', '3': 4, '4': 1, '5': 9, '10': 'message'},
+    {'1': 'result', '3': 5, '4': 1, '5': 11, '6': '.agentapi.TaskResult', '10': 'result'},
+  ],
+};
+
+/// Descriptor for `AgentEvent`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List agentEventDescriptor = $convert.base64Decode(
+    'CgpBZ2VudEV2ZW50EiwKBHR5cGUYASABKA4yGC5hZ2VudGFwaS5BZ2VudEV2ZW50VHlwZVIEdH'
+    'lwZRISCgR0aW1lGAIgASgJUgR0aW1lEhYKBmRpc3RybxgDIAEoCVIGZGlzdHJvEhgKB21lc3Nh'
+    'Z2UYBCABKAlSB21lc3NhZ2USLAoGcmVzdWx0GAUgASgLMhQuYWdlbnRhcGkuVGFza1Jlc3VsdF'
+    'IGcmVzdWx0');
+
+@$core.Deprecated('Use consentRequestDescriptor instead')
+const ConsentRequest$json = {
+  '1': 'ConsentRequest',
+  '2': [
+    {'1': 'id', '3': 1, '4': 1, '5': 9, '10': 'id'},
+    {'1': 'distro', '3': 2, '4': 1, '5': 9, '10': 'distro'},
+    {'1': 'prompt', '3': 3, '4': 1, '5': 9, '10': 'prompt'},
+    {'1': 'deadline', '3': 4, '4': 1, '5': 9, '10': 'deadline'},
+  ],
+};
+
+/// Descriptor for `ConsentRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List consentRequestDescriptor = $convert.base64Decode(
+    'Cg5Db25zZW50UmVxdWVzdBIOCgJpZBgBIAEoCVICaWQSFgoGZGlzdHJvGAIgASgJUgZkaXN0cm'
+    '8SFgoGcHJvbXB0GAMgASgJUgZwcm9tcHQSGgoIZGVhZGxpbmUYBCABKAlSCGRlYWRsaW5l');
+
+@$core.Deprecated('Use consentAnswerDescriptor instead')
+const ConsentAnswer$json = {
+  '1': 'ConsentAnswer',
+  '2': [
+    {'1': 'id', '3': 1, '4': 1, '5': 9, '10': 'id'},
+    {'1': 'granted', '3': 2, '4': 1, '5': 8, '10': 'granted'},
+  ],
+};
+
+/// Descriptor for `ConsentAnswer`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List consentAnswerDescriptor = $convert.base64Decode(
+    'Cg1Db25zZW50QW5zd2VyEg4KAmlkGAEgASgJUgJpZBIYCgdncmFudGVkGAIgASgIUgdncmFudG'
+    'Vk');
+
+@$core.Deprecated('Use usgReportRequestDescriptor instead')
+const UsgReportRequest$json = {
+  '1': 'UsgReportRequest',
+  '2': [
+    {'1': 'distro', '3': 1, '4': 1, '5': 9, '10': 'distro'},
+    {'1': 'profile', '3': 2, '4': 1, '5': 9, '10': 'profile'},
+  ],
+};
+
+/// Descriptor for `UsgReportRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List usgReportRequestDescriptor = $convert.base64Decode(
+    'ChBVc2dSZXBvcnRSZXF1ZXN0EhYKBmRpc3RybxgBIAEoCVIGZGlzdHJvEhgKB3Byb2ZpbGUYAi'
+    'ABKAlSB3Byb2ZpbGU=');
+
+@$core.Deprecated('Use usgReportDescriptor instead')
+const UsgReport$json = {
+  '1': 'UsgReport',
+  '2': [
+    {'1': 'distro', '3': 1, '4': 1, '5': 9, '10': 'distro'},
+    {'1': 'profile', '3': 2, '4': 1, '5': 9, '10': 'profile'},
+    {'1': 'timestamp', '3': 3, '4': 1, '5': 9, '10': 'timestamp'},
+    {'1': 'report', '3': 4, '4': 1, '5': 12, '10': 'report'},
+  ],
+};
+
+/// Descriptor for `UsgReport`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List usgReportDescriptor = $convert.base64Decode(
+    'CglVc2dSZXBvcnQSFgoGZGlzdHJvGAEgASgJUgZkaXN0cm8SGAoHcHJvZmlsZRgCIAEoCVIHcH'
+    'JvZmlsZRIcCgl0aW1lc3RhbXAYAyABKAlSCXRpbWVzdGFtcBIWCgZyZXBvcnQYBCABKAxSBnJl'
+    'cG9ydA==');
+
+@$core.Deprecated('Use tailLogRequestDescriptor instead')
+const TailLogRequest$json = {
+  '1': 'TailLogRequest',
+  '2': [
+    {'1': 'distro', '3': 1, '4': 1, '5': 9, '10': 'distro'},
+    {'1': 'lines', '3': 2, '4': 1, '5': 5, '10': 'lines'},
+    {'1': 'priority', '3': 3, '4': 1, '5': 9, '10': 'priority'},
+    {'1': 'include_pro_client', '3': 4, '4': 1, '5': 8, '10': 'includeProClient'},
+    {'1': 'follow', '3': 5, '4': 1, '5': 8, '10': 'follow'},
+  ],
+};
+
+/// Descriptor for `TailLogRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List tailLogRequestDescriptor = $convert.base64Decode(
+    'Cg5UYWlsTG9nUmVxdWVzdBIWCgZkaXN0cm8YASABKAlSBmRpc3RybxIUCgVsaW5lcxgCIAEoBV'
+    'IFbGluZXMSGgoIcHJpb3JpdHkYAyABKAlSCHByaW9yaXR5EiwKEmluY2x1ZGVfcHJvX2NsaWVu'
+    'dBgEIAEoCFIQaW5jbHVkZVByb0NsaWVudBIWCgZmb2xsb3cYBSABKAhSBmZvbGxvdw==');
+
+@$core.Deprecated('Use logLineDescriptor instead')
+const LogLine$json = {
+  '1': 'LogLine',
+  '2': [
+    {'1': 'line', '3': 1, '4': 1, '5': 9, '10': 'line'},
+  ],
+};
+
+/// Descriptor for `LogLine`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List logLineDescriptor = $convert.base64Decode(
+    'CgdMb2dMaW5lEhIKBGxpbmUYASABKAlSBGxpbmU=');
+
+@$core.Deprecated('Use watchTasksRequestDescriptor instead')
+const WatchTasksRequest$json = {
+  '1': 'WatchTasksRequest',
+  '2': [
+    {'1': 'distro', '3': 1, '4': 1, '5': 9, '10': 'distro'},
+  ],
+};
+
+/// Descriptor for `WatchTasksRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List watchTasksRequestDescriptor = $convert.base64Decode(
+    'ChFXYXRjaFRhc2tzUmVxdWVzdBIWCgZkaXN0cm8YASABKAlSBmRpc3Rybw==');
+
+@$core.Deprecated('Use taskEventDescriptor instead')
+const TaskEvent$json = {
+  '1': 'TaskEvent',
+  '2': [
+    {'1': 'type', '3': 1, '4': 1, '5': 14, '6': '.agentapi.TaskEventType', '10': 'type'},
+    {'1': 'task', '3': 2, '4': 1, '5': 9, '10': 'task'},
+    {'1': 'progress', '3': 3, '4': 1, '5': 13, '10': 'progress'},
+    {'1': 'reason', '3': 4, '4': 1, '5': 9, '10': 'reason'},
+    {'1': 'result', '3': 5, '4': 1, '5': 11, '6': '.agentapi.TaskResult', '10': 'result'},
+    {'1': 'step', '3': 6, '4': 1, '5': 9, '10': 'step'},
+  ],
+};
+
+/// Descriptor for `TaskEvent`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List taskEventDescriptor = $convert.base64Decode(
+    'CglUYXNrRXZlbnQSKwoEdHlwZRgBIAEoDjIXLmFnZW50YXBpLlRhc2tFdmVudFR5cGVSBHR5cG'
+    'USEgoEdGFzaxgCIAEoCVIEdGFzaxIaCghwcm9ncmVzcxgDIAEoDVIIcHJvZ3Jlc3MSFgoGcmVh'
+    'c29uGAQgASgJUgZyZWFzb24SLAoGcmVzdWx0GAUgASgLMhQuYWdlbnRhcGkuVGFza1Jlc3VsdF'
+    'IGcmVzdWx0EhIKBHN0ZXAYBiABKAlSBHN0ZXA=');
+
+@$core.Deprecated('Use taskResultDescriptor instead')
+const TaskResult$json = {
+  '1': 'TaskResult',
+  '2': [
+    {'1': 'steps', '3': 1, '4': 3, '5': 11, '6': '.agentapi.TaskStep', '10': 'steps'},
+  ],
+};
+
+/// Descriptor for `TaskResult`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List taskResultDescriptor = $convert.base64Decode(
+    'CgpUYXNrUmVzdWx0EigKBXN0ZXBzGAEgAygLMhIuYWdlbnRhcGkuVGFza1N0ZXBSBXN0ZXBz');
+
+@$core.Deprecated('Use taskStepDescriptor instead')
+const TaskStep$json = {
+  '1': 'TaskStep',
+  '2': [
+    {'1': 'name', '3': 1, '4': 1, '5': 9, '10': 'name'},
+    {'1': 'status', '3': 2, '4': 1, '5': 14, '6': '.agentapi.TaskStepStatus', '10': 'status'},
+    {'1': 'message', '3': 3, '4': 1, '5': 9, '10': 'message'},
+  ],
+};
+
+/// Descriptor for `TaskStep`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List taskStepDescriptor = $convert.base64Decode(
+    'CghUYXNrU3RlcBISCgRuYW1lGAEgASgJUgRuYW1lEjAKBnN0YXR1cxgCIAEoDjIYLmFnZW50YX'
+    'BpLlRhc2tTdGVwU3RhdHVzUgZzdGF0dXMSGAoHbWVzc2FnZRgDIAEoCVIHbWVzc2FnZQ==');
+
+@$core.Deprecated('Use notificationSettingsDescriptor instead')
+const NotificationSettings$json = {
+  '1': 'NotificationSettings',
+  '2': [
+    {'1': 'frequency', '3': 1, '4': 1, '5': 9, '10': 'frequency'},
+  ],
+};
+
+/// Descriptor for `NotificationSettings`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List notificationSettingsDescriptor = $convert.base64Decode(
+    'ChROb3RpZmljYXRpb25TZXR0aW5ncxIcCglmcmVxdWVuY3kYASABKAlSCWZyZXF1ZW5jeQ==');
+
+@$core.Deprecated('Use latenciesDescriptor instead')
+const Latencies$json = {
+  '1': 'Latencies',
+  '2': [
+    {'1': 'distros', '3': 1, '4': 3, '5': 11, '6': '.agentapi.DistroLatency', '10': 'distros'},
+  ],
+};
+
+/// Descriptor for `Latencies`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List latenciesDescriptor = $convert.base64Decode(
+    'CglMYXRlbmNpZXMSMQoHZGlzdHJvcxgBIAMoCzIXLmFnZW50YXBpLkRpc3Ryb0xhdGVuY3lSB2'
+    'Rpc3Ryb3M=');
+
+@$core.Deprecated('Use distroLatencyDescriptor instead')
+const DistroLatency$json = {
+  '1': 'DistroLatency',
+  '2': [
+    {'1': 'distro', '3': 1, '4': 1, '5': 9, '10': 'distro'},
+    {'1': 'last_ms', '3': 2, '4': 1, '5': 1, '10': 'lastMs'},
+    {'1': 'min_ms', '3': 3, '4': 1, '5': 1, '10': 'minMs'},
+    {'1': 'max_ms', '3': 4, '4': 1, '5': 1, '10': 'maxMs'},
+    {'1': 'mean_ms', '3': 5, '4': 1, '5': 1, '10': 'meanMs'},
+    {'1': 'samples', '3': 6, '4': 1, '5': 5, '10': 'samples'},
+    {'1': 'failures', '3': 7, '4': 1, '5': 5, '10': 'failures'},
+    {'1': 'last_probe', '3': 8, '4': 1, '5': 9, '10': 'lastProbe'},
+    {'1': 'last_error', '3': 9, '4': 1, '5': 9, '10': 'lastError'},
+  ],
+};
+
+/// Descriptor for `DistroLatency`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List distroLatencyDescriptor = $convert.base64Decode(
+    'Cg1EaXN0cm9MYXRlbmN5EhYKBmRpc3RybxgBIAEoCVIGZGlzdHJvEhcKB2xhc3RfbXMYAiABKA'
+    'FSBmxhc3RNcxIVCgZtaW5fbXMYAyABKAFSBW1pbk1zEhUKBm1heF9tcxgEIAEoAVIFbWF4TXMS'
+    'FwoHbWVhbl9tcxgFIAEoAVIGbWVhbk1zEhgKB3NhbXBsZXMYBiABKAVSB3NhbXBsZXMSGgoIZm'
+    'FpbHVyZXMYByABKAVSCGZhaWx1cmVzEh0KCmxhc3RfcHJvYmUYCCABKAlSCWxhc3RQcm9iZRId'
+    'CgpsYXN0X2Vycm9yGAkgASgJUglsYXN0RXJyb3I=');
+
+@$core.Deprecated('Use bandwidthDescriptor instead')
+const Bandwidth$json = {
+  '1': 'Bandwidth',
+  '2': [
+    {'1': 'distros', '3': 1, '4': 3, '5': 11, '6': '.agentapi.DistroBandwidth', '10': 'distros'},
+  ],
+};
+
+/// Descriptor for `Bandwidth`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List bandwidthDescriptor = $convert.base64Decode(
+    'CglCYW5kd2lkdGgSMwoHZGlzdHJvcxgBIAMoCzIZLmFnZW50YXBpLkRpc3Ryb0JhbmR3aWR0aF'
+    'IHZGlzdHJvcw==');
+
+@$core.Deprecated('Use distroBandwidthDescriptor instead')
+const DistroBandwidth$json = {
+  '1': 'DistroBandwidth',
+  '2': [
+    {'1': 'distro', '3': 1, '4': 1, '5': 9, '10': 'distro'},
+    {'1': 'transfers', '3': 2, '4': 3, '5': 11, '6': '.agentapi.TransferUsage', '10': 'transfers'},
+    {'1': 'today_bytes', '3': 3, '4': 1, '5': 4, '10': 'todayBytes'},
+    {'1': 'daily_cap_bytes', '3': 4, '4': 1, '5': 4, '10': 'dailyCapBytes'},
+  ],
+};
+
+/// Descriptor for `DistroBandwidth`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List distroBandwidthDescriptor = $convert.base64Decode(
+    'Cg9EaXN0cm9CYW5kd2lkdGgSFgoGZGlzdHJvGAEgASgJUgZkaXN0cm8SNQoJdHJhbnNmZXJzGA'
+    'IgAygLMhcuYWdlbnRhcGkuVHJhbnNmZXJVc2FnZVIJdHJhbnNmZXJzEh8KC3RvZGF5X2J5dGVz'
+    'GAMgASgEUgp0b2RheUJ5dGVzEiYKD2RhaWx5X2NhcF9ieXRlcxgEIAEoBFINZGFpbHlDYXBCeX'
+    'Rlcw==');
+
+@$core.Deprecated('Use transferUsageDescriptor instead')
+const TransferUsage$json = {
+  '1': 'TransferUsage',
+  '2': [
+    {'1': 'kind', '3': 1, '4': 1, '5': 9, '10': 'kind'},
+    {'1': 'today_bytes', '3': 2, '4': 1, '5': 4, '10': 'todayBytes'},
+    {'1': 'total_bytes', '3': 3, '4': 1, '5': 4, '10': 'totalBytes'},
+  ],
+};
+
+/// Descriptor for `TransferUsage`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List transferUsageDescriptor = $convert.base64Decode(
+    'Cg1UcmFuc2ZlclVzYWdlEhIKBGtpbmQYASABKAlSBGtpbmQSHwoLdG9kYXlfYnl0ZXMYAiABKA'
+    'RSCnRvZGF5Qnl0ZXMSHwoLdG90YWxfYnl0ZXMYAyABKARSCnRvdGFsQnl0ZXM=');
+
+@$core.Deprecated('Use complianceReportDescriptor instead')
+const ComplianceReport$json = {
+  '1': 'ComplianceReport',
+  '2': [
+    {'1': 'total', '3': 1, '4': 1, '5': 5, '10': 'total'},
+    {'1': 'fully_patched', '3': 2, '4': 1, '5': 5, '10': 'fullyPatched'},
+    {'1': 'pending_security', '3': 3, '4': 1, '5': 5, '10': 'pendingSecurity'},
+    {'1': 'pending_esm', '3': 4, '4': 1, '5': 5, '10': 'pendingEsm'},
+    {'1': 'unknown', '3': 5, '4': 1, '5': 5, '10': 'unknown'},
+    {'1': 'distros', '3': 6, '4': 3, '5': 11, '6': '.agentapi.DistroCompliance', '10': 'distros'},
+  ],
+};
+
+/// Descriptor for `ComplianceReport`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List complianceReportDescriptor = $convert.base64Decode(
+    'ChBDb21wbGlhbmNlUmVwb3J0EhQKBXRvdGFsGAEgASgFUgV0b3RhbBIjCg1mdWxseV9wYXRjaG'
+    'VkGAIgASgFUgxmdWxseVBhdGNoZWQSKQoQcGVuZGluZ19zZWN1cml0eRgDIAEoBVIPcGVuZGlu'
+    'Z1NlY3VyaXR5Eh8KC3BlbmRpbmdfZXNtGAQgASgFUgpwZW5kaW5nRXNtEhgKB3Vua25vd24YBS'
+    'ABKAVSB3Vua25vd24SNAoHZGlzdHJvcxgGIAMoCzIaLmFnZW50YXBpLkRpc3Ryb0NvbXBsaWFu'
+    'Y2VSB2Rpc3Ryb3M=');
+
+@$core.Deprecated('Use distroComplianceDescriptor instead')
+const DistroCompliance$json = {
+  '1': 'DistroCompliance',
+  '2': [
+    {'1': 'distro', '3': 1, '4': 1, '5': 9, '10': 'distro'},
+    {'1': 'status', '3': 2, '4': 1, '5': 11, '6': '.agentapi.SecurityStatus', '10': 'status'},
+  ],
+};
+
+/// Descriptor for `DistroCompliance`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List distroComplianceDescriptor = $convert.base64Decode(
+    'ChBEaXN0cm9Db21wbGlhbmNlEhYKBmRpc3RybxgBIAEoCVIGZGlzdHJvEjAKBnN0YXR1cxgCIA'
+    'EoCzIYLmFnZW50YXBpLlNlY3VyaXR5U3RhdHVzUgZzdGF0dXM=');
+
+@$core.Deprecated('Use summaryDescriptor instead')
+const Summary$json = {
+  '1': 'Summary',
+  '2': [
+    {'1': 'subscription', '3': 1, '4': 1, '5': 11, '6': '.agentapi.SubscriptionInfo', '10': 'subscription'},
+    {'1': 'distros', '3': 2, '4': 1, '5': 5, '10': 'distros'},
+    {'1': 'connected', '3': 3, '4': 1, '5': 5, '10': 'connected'},
+    {'1': 'pending_updates', '3': 4, '4': 1, '5': 5, '10': 'pendingUpdates'},
+    {'1': 'errors', '3': 5, '4': 1, '5': 5, '10': 'errors'},
+    {'1': 'wsl', '3': 6, '4': 1, '5': 11, '6': '.agentapi.WslInfo', '10': 'wsl'},
+    {'1': 'contracts', '3': 7, '4': 1, '5': 11, '6': '.agentapi.ContractsHealth', '10': 'contracts'},
+  ],
+};
+
+/// Descriptor for `Summary`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List summaryDescriptor = $convert.base64Decode(
+    'CgdTdW1tYXJ5Ej4KDHN1YnNjcmlwdGlvbhgBIAEoCzIaLmFnZW50YXBpLlN1YnNjcmlwdGlvbk'
+    'luZm9SDHN1YnNjcmlwdGlvbhIYCgdkaXN0cm9zGAIgASgFUgdkaXN0cm9zEhwKCWNvbm5lY3Rl'
+    'ZBgDIAEoBVIJY29ubmVjdGVkEicKD3BlbmRpbmdfdXBkYXRlcxgEIAEoBVIOcGVuZGluZ1VwZG'
+    'F0ZXMSFgoGZXJyb3JzGAUgASgFUgZlcnJvcnMSIwoDd3NsGAYgASgLMhEuYWdlbnRhcGkuV3Ns'
+    'SW5mb1IDd3NsEjcKCWNvbnRyYWN0cxgHIAEoCzIZLmFnZW50YXBpLkNvbnRyYWN0c0hlYWx0aF'
+    'IJY29udHJhY3Rz');
+
+@$core.Deprecated('Use contractsHealthDescriptor instead')
+const ContractsHealth$json = {
+  '1': 'ContractsHealth',
+  '2': [
+    {'1': 'last_contact', '3': 1, '4': 1, '5': 9, '10': 'lastContact'},
+    {'1': 'last_token_refresh', '3': 2, '4': 1, '5': 9, '10': 'lastTokenRefresh'},
+    {'1': 'last_entitlement_sync', '3': 3, '4': 1, '5': 9, '10': 'lastEntitlementSync'},
+    {'1': 'last_error', '3': 4, '4': 1, '5': 9, '10': 'lastError'},
+    {'1': 'last_error_time', '3': 5, '4': 1, '5': 9, '10': 'lastErrorTime'},
+  ],
+};
+
+/// Descriptor for `ContractsHealth`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List contractsHealthDescriptor = $convert.base64Decode(
+    'Cg9Db250cmFjdHNIZWFsdGgSIQoMbGFzdF9jb250YWN0GAEgASgJUgtsYXN0Q29udGFjdBIsCh'
+    'JsYXN0X3Rva2VuX3JlZnJlc2gYAiABKAlSEGxhc3RUb2tlblJlZnJlc2gSMgoVbGFzdF9lbnRp'
+    'dGxlbWVudF9zeW5jGAMgASgJUhNsYXN0RW50aXRsZW1lbnRTeW5jEh0KCmxhc3RfZXJyb3IYBC'
+    'ABKAlSCWxhc3RFcnJvchImCg9sYXN0X2Vycm9yX3RpbWUYBSABKAlSDWxhc3RFcnJvclRpbWU=');
+
+@$core.Deprecated('Use inventoryDescriptor instead')
+const Inventory$json = {
+  '1': 'Inventory',
+  '2': [
+    {'1': 'records', '3': 1, '4': 3, '5': 11, '6': '.agentapi.InventoryRecord', '10': 'records'},
+  ],
+};
+
+/// Descriptor for `Inventory`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List inventoryDescriptor = $convert.base64Decode(
+    'CglJbnZlbnRvcnkSMwoHcmVjb3JkcxgBIAMoCzIZLmFnZW50YXBpLkludmVudG9yeVJlY29yZF'
+    'IHcmVjb3Jkcw==');
+
+@$core.Deprecated('Use inventoryRecordDescriptor instead')
+const InventoryRecord$json = {
+  '1': 'InventoryRecord',
+  '2': [
+    {'1': 'machine', '3': 1, '4': 1, '5': 9, '10': 'machine'},
+    {'1': 'distro', '3': 2, '4': 1, '5': 9, '10': 'distro'},
+    {'1': 'hostname', '3': 3, '4': 1, '5': 9, '10': 'hostname'},
+    {'1': 'distro_id', '3': 4, '4': 1, '5': 9, '10': 'distroId'},
+    {'1': 'ubuntu_version', '3': 5, '4': 1, '5': 9, '10': 'ubuntuVersion'},
+    {'1': 'pretty_name', '3': 6, '4': 1, '5': 9, '10': 'prettyName'},
+    {'1': 'pro_attached', '3': 7, '4': 1, '5': 8, '10': 'proAttached'},
+    {'1': 'pro_services', '3': 8, '4': 3, '5': 9, '10': 'proServices'},
+    {'1': 'pro_expires', '3': 9, '4': 1, '5': 9, '10': 'proExpires'},
+    {'1': 'last_seen', '3': 10, '4': 1, '5': 9, '10': 'lastSeen'},
+    {'1': 'landscape_id', '3': 11, '4': 1, '5': 9, '10': 'landscapeId'},
+    {'1': 'landscape_managed', '3': 12, '4': 1, '5': 8, '10': 'landscapeManaged'},
+    {'1': 'pending_tasks', '3': 13, '4': 1, '5': 5, '10': 'pendingTasks'},
+    {'1': 'running', '3': 14, '4': 1, '5': 8, '10': 'running'},
+    {'1': 'unsupported_reason', '3': 15, '4': 1, '5': 9, '10': 'unsupportedReason'},
+  ],
+};
+
+/// Descriptor for `InventoryRecord`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List inventoryRecordDescriptor = $convert.base64Decode(
+    'Cg9JbnZlbnRvcnlSZWNvcmQSGAoHbWFjaGluZRgBIAEoCVIHbWFjaGluZRIWCgZkaXN0cm8YAi'
+    'ABKAlSBmRpc3RybxIaCghob3N0bmFtZRgDIAEoCVIIaG9zdG5hbWUSGwoJZGlzdHJvX2lkGAQg'
+    'ASgJUghkaXN0cm9JZBIlCg51YnVudHVfdmVyc2lvbhgFIAEoCVINdWJ1bnR1VmVyc2lvbhIfCg'
+    'twcmV0dHlfbmFtZRgGIAEoCVIKcHJldHR5TmFtZRIhCgxwcm9fYXR0YWNoZWQYByABKAhSC3By'
+    'b0F0dGFjaGVkEiEKDHByb19zZXJ2aWNlcxgIIAMoCVILcHJvU2VydmljZXMSHwoLcHJvX2V4cG'
+    'lyZXMYCSABKAlSCnByb0V4cGlyZXMSGwoJbGFzdF9zZWVuGAogASgJUghsYXN0U2VlbhIhCgxs'
+    'YW5kc2NhcGVfaWQYCyABKAlSC2xhbmRzY2FwZUlkEisKEWxhbmRzY2FwZV9tYW5hZ2VkGAwgAS'
+    'gIUhBsYW5kc2NhcGVNYW5hZ2VkEiMKDXBlbmRpbmdfdGFza3MYDSABKAVSDHBlbmRpbmdUYXNr'
+    'cxIYCgdydW5uaW5nGA4gASgIUgdydW5uaW5nEi0KEnVuc3VwcG9ydGVkX3JlYXNvbhgPIAEoCV'
+    'IRdW5zdXBwb3J0ZWRSZWFzb24=');
+
+@$core.Deprecated('Use provisionRequestDescriptor instead')
+const ProvisionRequest$json = {
+  '1': 'ProvisionRequest',
+  '2': [
+    {'1': 'distro_name', '3': 1, '4': 1, '5': 9, '10': 'distroName'},
+    {'1': 'rootfs_url', '3': 2, '4': 1, '5': 9, '10': 'rootfsUrl'},
+    {'1': 'install_dir', '3': 3, '4': 1, '5': 9, '10': 'installDir'},
+  ],
+};
+
+/// Descriptor for `ProvisionRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List provisionRequestDescriptor = $convert.base64Decode(
+    'ChBQcm92aXNpb25SZXF1ZXN0Eh8KC2Rpc3Ryb19uYW1lGAEgASgJUgpkaXN0cm9OYW1lEh0KCn'
+    'Jvb3Rmc191cmwYAiABKAlSCXJvb3Rmc1VybBIfCgtpbnN0YWxsX2RpchgDIAEoCVIKaW5zdGFs'
+    'bERpcg==');
+
+@$core.Deprecated('Use provisionProgressDescriptor instead')
+const ProvisionProgress$json = {
+  '1': 'ProvisionProgress',
+  '2': [
+    {'1': 'stage', '3': 1, '4': 1, '5': 14, '6': '.agentapi.ProvisionStage', '10': 'stage'},
+    {'1': 'done', '3': 2, '4': 1, '5': 3, '10': 'done'},
+    {'1': 'total', '3': 3, '4': 1, '5': 3, '10': 'total'},
+  ],
+};
+
+/// Descriptor for `ProvisionProgress`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List provisionProgressDescriptor = $convert.base64Decode(
+    'ChFQcm92aXNpb25Qcm9ncmVzcxIuCgVzdGFnZRgBIAEoDjIYLmFnZW50YXBpLlByb3Zpc2lvbl'
+    'N0YWdlUgVzdGFnZRISCgRkb25lGAIgASgDUgRkb25lEhQKBXRvdGFsGAMgASgDUgV0b3RhbA==');
+
+@$core.Deprecated('Use wslInfoDescriptor instead')
+const WslInfo$json = {
+  '1': 'WslInfo',
+  '2': [
+    {'1': 'version', '3': 1, '4': 1, '5': 9, '10': 'version'},
+    {'1': 'kernel_version', '3': 2, '4': 1, '5': 9, '10': 'kernelVersion'},
+    {'1': 'channel', '3': 3, '4': 1, '5': 9, '10': 'channel'},
+    {'1': 'degraded', '3': 4, '4': 3, '5': 9, '10': 'degraded'},
+    {'1': 'operations', '3': 5, '4': 3, '5': 14, '6': '.agentapi.DistroOperation', '10': 'operations'},
+  ],
+};
+
+/// Descriptor for `WslInfo`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List wslInfoDescriptor = $convert.base64Decode(
+    'CgdXc2xJbmZvEhgKB3ZlcnNpb24YASABKAlSB3ZlcnNpb24SJQoOa2VybmVsX3ZlcnNpb24YAi'
+    'ABKAlSDWtlcm5lbFZlcnNpb24SGAoHY2hhbm5lbBgDIAEoCVIHY2hhbm5lbBIaCghkZWdyYWRl'
+    'ZBgEIAMoCVIIZGVncmFkZWQSOQoKb3BlcmF0aW9ucxgFIAMoDjIZLmFnZW50YXBpLkRpc3Ryb0'
+    '9wZXJhdGlvblIKb3BlcmF0aW9ucw==');
+
+@$core.Deprecated('Use wslConfigDescriptor instead')
+const WslConfig$json = {
+  '1': 'WslConfig',
+  '2': [
+    {'1': 'memory', '3': 1, '4': 1, '5': 9, '10': 'memory'},
+    {'1': 'networking_mode', '3': 2, '4': 1, '5': 9, '10': 'networkingMode'},
+    {'1': 'vm_idle_timeout', '3': 3, '4': 1, '5': 3, '10': 'vmIdleTimeout'},
+    {'1': 'problems', '3': 4, '4': 3, '5': 9, '10': 'problems'},
+  ],
+};
+
+/// Descriptor for `WslConfig`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List wslConfigDescriptor = $convert.base64Decode(
+    'CglXc2xDb25maWcSFgoGbWVtb3J5GAEgASgJUgZtZW1vcnkSJwoPbmV0d29ya2luZ19tb2RlGA'
+    'IgASgJUg5uZXR3b3JraW5nTW9kZRImCg92bV9pZGxlX3RpbWVvdXQYAyABKANSDXZtSWRsZVRp'
+    'bWVvdXQSGgoIcHJvYmxlbXMYBCADKAlSCHByb2JsZW1z');
+
 @$core.Deprecated('Use subscriptionInfoDescriptor instead')
 const SubscriptionInfo$json = {
   '1': 'SubscriptionInfo',
@@ -69,6 +738,36 @@ final $typed_data.Uint8List subscriptionInfoDescriptor = $convert.base64Decode(
     'BSDG9yZ2FuaXphdGlvbhI5Cg5taWNyb3NvZnRTdG9yZRgFIAEoCzIPLmFnZW50YXBpLkVtcHR5'
     'SABSDm1pY3Jvc29mdFN0b3JlQhIKEHN1YnNjcmlwdGlvblR5cGU=');
 
+@$core.Deprecated('Use subscriptionDetailsDescriptor instead')
+const SubscriptionDetails$json = {
+  '1': 'SubscriptionDetails',
+  '2': [
+    {'1': 'entitlements', '3': 1, '4': 3, '5': 11, '6': '.agentapi.Entitlement', '10': 'entitlements'},
+    {'1': 'support_level', '3': 2, '4': 1, '5': 9, '10': 'supportLevel'},
+    {'1': 'expires', '3': 3, '4': 1, '5': 9, '10': 'expires'},
+  ],
+};
+
+/// Descriptor for `SubscriptionDetails`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List subscriptionDetailsDescriptor = $convert.base64Decode(
+    'ChNTdWJzY3JpcHRpb25EZXRhaWxzEjkKDGVudGl0bGVtZW50cxgBIAMoCzIVLmFnZW50YXBpLk'
+    'VudGl0bGVtZW50UgxlbnRpdGxlbWVudHMSIwoNc3VwcG9ydF9sZXZlbBgCIAEoCVIMc3VwcG9y'
+    'dExldmVsEhgKB2V4cGlyZXMYAyABKAlSB2V4cGlyZXM=');
+
+@$core.Deprecated('Use entitlementDescriptor instead')
+const Entitlement$json = {
+  '1': 'Entitlement',
+  '2': [
+    {'1': 'name', '3': 1, '4': 1, '5': 9, '10': 'name'},
+    {'1': 'entitled', '3': 2, '4': 1, '5': 8, '10': 'entitled'},
+  ],
+};
+
+/// Descriptor for `Entitlement`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List entitlementDescriptor = $convert.base64Decode(
+    'CgtFbnRpdGxlbWVudBISCgRuYW1lGAEgASgJUgRuYW1lEhoKCGVudGl0bGVkGAIgASgIUghlbn'
+    'RpdGxlZA==');
+
 @$core.Deprecated('Use landscapeSourceDescriptor instead')
 const LandscapeSource$json = {
   '1': 'LandscapeSource',
@@ -104,6 +803,76 @@ final $typed_data.Uint8List configSourcesDescriptor = $convert.base64Decode(
     'NjcmlwdGlvbkluZm9SD3Byb1N1YnNjcmlwdGlvbhJDCg9sYW5kc2NhcGVTb3VyY2UYAiABKAsy'
     'GS5hZ2VudGFwaS5MYW5kc2NhcGVTb3VyY2VSD2xhbmRzY2FwZVNvdXJjZQ==');
 
+@$core.Deprecated('Use distroMessageDescriptor instead')
+const DistroMessage$json = {
+  '1': 'DistroMessage',
+  '2': [
+    {'1': 'handshake', '3': 1, '4': 1, '5': 11, '6': '.agentapi.Handshake', '9': 0, '10': 'handshake'},
+    {'1': 'info', '3': 2, '4': 1, '5': 11, '6': '.agentapi.DistroInfo', '9': 0, '10': 'info'},
+  ],
+  '8': [
+    {'1': 'data'},
+  ],
+};
+
+/// Descriptor for `DistroMessage`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List distroMessageDescriptor = $convert.base64Decode(
+    'Cg1EaXN0cm9NZXNzYWdlEjMKCWhhbmRzaGFrZRgBIAEoCzITLmFnZW50YXBpLkhhbmRzaGFrZU'
+    'gAUgloYW5kc2hha2USKgoEaW5mbxgCIAEoCzIULmFnZW50YXBpLkRpc3Ryb0luZm9IAFIEaW5m'
+    'b0IGCgRkYXRh');
+
+@$core.Deprecated('Use handshakeDescriptor instead')
+const Handshake$json = {
+  '1': 'Handshake',
+  '2': [
+    {'1': 'protocol_version', '3': 1, '4': 1, '5': 13, '10': 'protocolVersion'},
+    {'1': 'wsl_name', '3': 2, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'capabilities', '3': 3, '4': 3, '5': 14, '6': '.agentapi.Capability', '10': 'capabilities'},
+    {'1': 'service_version', '3': 4, '4': 1, '5': 9, '10': 'serviceVersion'},
+  ],
+};
+
+/// Descriptor for `Handshake`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List handshakeDescriptor = $convert.base64Decode(
+    'CglIYW5kc2hha2USKQoQcHJvdG9jb2xfdmVyc2lvbhgBIAEoDVIPcHJvdG9jb2xWZXJzaW9uEh'
+    'kKCHdzbF9uYW1lGAIgASgJUgd3c2xOYW1lEjgKDGNhcGFiaWxpdGllcxgDIAMoDjIULmFnZW50'
+    'YXBpLkNhcGFiaWxpdHlSDGNhcGFiaWxpdGllcxInCg9zZXJ2aWNlX3ZlcnNpb24YBCABKAlSDn'
+    'NlcnZpY2VWZXJzaW9u');
+
+@$core.Deprecated('Use handshakeAckDescriptor instead')
+const HandshakeAck$json = {
+  '1': 'HandshakeAck',
+  '2': [
+    {'1': 'protocol_version', '3': 1, '4': 1, '5': 13, '10': 'protocolVersion'},
+    {'1': 'capabilities', '3': 2, '4': 3, '5': 14, '6': '.agentapi.Capability', '10': 'capabilities'},
+    {'1': 'settings', '3': 3, '4': 1, '5': 11, '6': '.agentapi.DistroSettings', '10': 'settings'},
+    {'1': 'agent_time_unix_milli', '3': 4, '4': 1, '5': 3, '10': 'agentTimeUnixMilli'},
+  ],
+};
+
+/// Descriptor for `HandshakeAck`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List handshakeAckDescriptor = $convert.base64Decode(
+    'CgxIYW5kc2hha2VBY2sSKQoQcHJvdG9jb2xfdmVyc2lvbhgBIAEoDVIPcHJvdG9jb2xWZXJzaW'
+    '9uEjgKDGNhcGFiaWxpdGllcxgCIAMoDjIULmFnZW50YXBpLkNhcGFiaWxpdHlSDGNhcGFiaWxp'
+    'dGllcxI0CghzZXR0aW5ncxgDIAEoCzIYLmFnZW50YXBpLkRpc3Ryb1NldHRpbmdzUghzZXR0aW'
+    '5ncxIxChVhZ2VudF90aW1lX3VuaXhfbWlsbGkYBCABKANSEmFnZW50VGltZVVuaXhNaWxsaQ==');
+
+@$core.Deprecated('Use distroSettingsDescriptor instead')
+const DistroSettings$json = {
+  '1': 'DistroSettings',
+  '2': [
+    {'1': 'config_hash', '3': 1, '4': 1, '5': 9, '10': 'configHash'},
+    {'1': 'pending_tasks', '3': 2, '4': 1, '5': 5, '10': 'pendingTasks'},
+    {'1': 'refresh_interval_seconds', '3': 3, '4': 1, '5': 13, '10': 'refreshIntervalSeconds'},
+  ],
+};
+
+/// Descriptor for `DistroSettings`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List distroSettingsDescriptor = $convert.base64Decode(
+    'Cg5EaXN0cm9TZXR0aW5ncxIfCgtjb25maWdfaGFzaBgBIAEoCVIKY29uZmlnSGFzaBIjCg1wZW'
+    '5kaW5nX3Rhc2tzGAIgASgFUgxwZW5kaW5nVGFza3MSOAoYcmVmcmVzaF9pbnRlcnZhbF9zZWNv'
+    'bmRzGAMgASgNUhZyZWZyZXNoSW50ZXJ2YWxTZWNvbmRz');
+
 @$core.Deprecated('Use distroInfoDescriptor instead')
 const DistroInfo$json = {
   '1': 'DistroInfo',
@@ -114,6 +883,12 @@ const DistroInfo$json = {
     {'1': 'pretty_name', '3': 4, '4': 1, '5': 9, '10': 'prettyName'},
     {'1': 'pro_attached', '3': 5, '4': 1, '5': 8, '10': 'proAttached'},
     {'1': 'hostname', '3': 6, '4': 1, '5': 9, '10': 'hostname'},
+    {'1': 'pro_services', '3': 7, '4': 3, '5': 9, '10': 'proServices'},
+    {'1': 'security_status', '3': 8, '4': 1, '5': 11, '6': '.agentapi.SecurityStatus', '10': 'securityStatus'},
+    {'1': 'pro_expires', '3': 9, '4': 1, '5': 9, '10': 'proExpires'},
+    {'1': 'pro_support_level', '3': 10, '4': 1, '5': 9, '10': 'proSupportLevel'},
+    {'1': 'unsupported_reason', '3': 11, '4': 1, '5': 9, '10': 'unsupportedReason'},
+    {'1': 'wsl_version', '3': 12, '4': 1, '5': 5, '10': 'wslVersion'},
   ],
 };
 
@@ -122,7 +897,25 @@ final $typed_data.Uint8List distroInfoDescriptor = $convert.base64Decode(
     'CgpEaXN0cm9JbmZvEhkKCHdzbF9uYW1lGAEgASgJUgd3c2xOYW1lEg4KAmlkGAIgASgJUgJpZB'
     'IdCgp2ZXJzaW9uX2lkGAMgASgJUgl2ZXJzaW9uSWQSHwoLcHJldHR5X25hbWUYBCABKAlSCnBy'
     'ZXR0eU5hbWUSIQoMcHJvX2F0dGFjaGVkGAUgASgIUgtwcm9BdHRhY2hlZBIaCghob3N0bmFtZR'
-    'gGIAEoCVIIaG9zdG5hbWU=');
+    'gGIAEoCVIIaG9zdG5hbWUSIQoMcHJvX3NlcnZpY2VzGAcgAygJUgtwcm9TZXJ2aWNlcxJBCg9z'
+    'ZWN1cml0eV9zdGF0dXMYCCABKAsyGC5hZ2VudGFwaS5TZWN1cml0eVN0YXR1c1IOc2VjdXJpdH'
+    'lTdGF0dXMSHwoLcHJvX2V4cGlyZXMYCSABKAlSCnByb0V4cGlyZXMSKgoRcHJvX3N1cHBvcnRf'
+    'bGV2ZWwYCiABKAlSD3Byb1N1cHBvcnRMZXZlbBItChJ1bnN1cHBvcnRlZF9yZWFzb24YCyABKA'
+    'lSEXVuc3VwcG9ydGVkUmVhc29uEh8KC3dzbF92ZXJzaW9uGAwgASgFUgp3c2xWZXJzaW9u');
+
+@$core.Deprecated('Use securityStatusDescriptor instead')
+const SecurityStatus$json = {
+  '1': 'SecurityStatus',
+  '2': [
+    {'1': 'standard_updates', '3': 1, '4': 1, '5': 5, '10': 'standardUpdates'},
+    {'1': 'esm_updates', '3': 2, '4': 1, '5': 5, '10': 'esmUpdates'},
+  ],
+};
+
+/// Descriptor for `SecurityStatus`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List securityStatusDescriptor = $convert.base64Decode(
+    'Cg5TZWN1cml0eVN0YXR1cxIpChBzdGFuZGFyZF91cGRhdGVzGAEgASgFUg9zdGFuZGFyZFVwZG'
+    'F0ZXMSHwoLZXNtX3VwZGF0ZXMYAiABKAVSCmVzbVVwZGF0ZXM=');
 
 @$core.Deprecated('Use proAttachCmdDescriptor instead')
 const ProAttachCmd$json = {
@@ -148,12 +941,261 @@ const LandscapeConfigCmd$json = {
 final $typed_data.Uint8List landscapeConfigCmdDescriptor = $convert.base64Decode(
     'ChJMYW5kc2NhcGVDb25maWdDbWQSFgoGY29uZmlnGAEgASgJUgZjb25maWc=');
 
+@$core.Deprecated('Use commandDescriptor instead')
+const Command$json = {
+  '1': 'Command',
+  '2': [
+    {'1': 'pro_service', '3': 1, '4': 1, '5': 11, '6': '.agentapi.ProServiceCmd', '9': 0, '10': 'proService'},
+    {'1': 'usg', '3': 2, '4': 1, '5': 11, '6': '.agentapi.UsgCmd', '9': 0, '10': 'usg'},
+    {'1': 'service_upgrade', '3': 3, '4': 1, '5': 11, '6': '.agentapi.ServiceUpgradeCmd', '9': 0, '10': 'serviceUpgrade'},
+    {'1': 'preempt', '3': 6, '4': 1, '5': 11, '6': '.agentapi.PreemptCmd', '9': 0, '10': 'preempt'},
+    {'1': 'manage_user', '3': 7, '4': 1, '5': 11, '6': '.agentapi.ManageUserCmd', '9': 0, '10': 'manageUser'},
+    {'1': 'patching', '3': 8, '4': 1, '5': 11, '6': '.agentapi.PatchingCmd', '9': 0, '10': 'patching'},
+    {'1': 'proxy', '3': 9, '4': 1, '5': 11, '6': '.agentapi.ProxyCmd', '9': 0, '10': 'proxy'},
+    {'1': 'pro_status', '3': 11, '4': 1, '5': 11, '6': '.agentapi.ProStatusCmd', '9': 0, '10': 'proStatus'},
+    {'1': 'snapd', '3': 12, '4': 1, '5': 11, '6': '.agentapi.SnapdCmd', '9': 0, '10': 'snapd'},
+    {'1': 'dns', '3': 13, '4': 1, '5': 11, '6': '.agentapi.DnsCmd', '9': 0, '10': 'dns'},
+    {'1': 'id', '3': 10, '4': 1, '5': 13, '10': 'id'},
+  ],
+  '8': [
+    {'1': 'cmd'},
+  ],
+  '9': [
+    {'1': 4, '2': 5},
+    {'1': 5, '2': 6},
+  ],
+};
+
+/// Descriptor for `Command`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List commandDescriptor = $convert.base64Decode(
+    'CgdDb21tYW5kEjoKC3Byb19zZXJ2aWNlGAEgASgLMhcuYWdlbnRhcGkuUHJvU2VydmljZUNtZE'
+    'gAUgpwcm9TZXJ2aWNlEiQKA3VzZxgCIAEoCzIQLmFnZW50YXBpLlVzZ0NtZEgAUgN1c2cSRgoP'
+    'c2VydmljZV91cGdyYWRlGAMgASgLMhsuYWdlbnRhcGkuU2VydmljZVVwZ3JhZGVDbWRIAFIOc2'
+    'VydmljZVVwZ3JhZGUSMAoHcHJlZW1wdBgGIAEoCzIULmFnZW50YXBpLlByZWVtcHRDbWRIAFIH'
+    'cHJlZW1wdBI6CgttYW5hZ2VfdXNlchgHIAEoCzIXLmFnZW50YXBpLk1hbmFnZVVzZXJDbWRIAF'
+    'IKbWFuYWdlVXNlchIzCghwYXRjaGluZxgIIAEoCzIVLmFnZW50YXBpLlBhdGNoaW5nQ21kSABS'
+    'CHBhdGNoaW5nEioKBXByb3h5GAkgASgLMhIuYWdlbnRhcGkuUHJveHlDbWRIAFIFcHJveHkSNw'
+    'oKcHJvX3N0YXR1cxgLIAEoCzIWLmFnZW50YXBpLlByb1N0YXR1c0NtZEgAUglwcm9TdGF0dXMS'
+    'KgoFc25hcGQYDCABKAsyEi5hZ2VudGFwaS5TbmFwZENtZEgAUgVzbmFwZBIkCgNkbnMYDSABKA'
+    'syEC5hZ2VudGFwaS5EbnNDbWRIAFIDZG5zEg4KAmlkGAogASgNUgJpZEIFCgNjbWRKBAgEEAVK'
+    'BAgFEAY=');
+
+@$core.Deprecated('Use proServiceCmdDescriptor instead')
+const ProServiceCmd$json = {
+  '1': 'ProServiceCmd',
+  '2': [
+    {'1': 'service', '3': 1, '4': 1, '5': 9, '10': 'service'},
+    {'1': 'enable', '3': 2, '4': 1, '5': 8, '10': 'enable'},
+  ],
+};
+
+/// Descriptor for `ProServiceCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List proServiceCmdDescriptor = $convert.base64Decode(
+    'Cg1Qcm9TZXJ2aWNlQ21kEhgKB3NlcnZpY2UYASABKAlSB3NlcnZpY2USFgoGZW5hYmxlGAIgAS'
+    'gIUgZlbmFibGU=');
+
+@$core.Deprecated('Use usgCmdDescriptor instead')
+const UsgCmd$json = {
+  '1': 'UsgCmd',
+  '2': [
+    {'1': 'profile', '3': 1, '4': 1, '5': 9, '10': 'profile'},
+    {'1': 'fix', '3': 2, '4': 1, '5': 8, '10': 'fix'},
+  ],
+};
+
+/// Descriptor for `UsgCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List usgCmdDescriptor = $convert.base64Decode(
+    'CgZVc2dDbWQSGAoHcHJvZmlsZRgBIAEoCVIHcHJvZmlsZRIQCgNmaXgYAiABKAhSA2ZpeA==');
+
+@$core.Deprecated('Use serviceUpgradeCmdDescriptor instead')
+const ServiceUpgradeCmd$json = {
+  '1': 'ServiceUpgradeCmd',
+  '2': [
+    {'1': 'channel', '3': 1, '4': 1, '5': 9, '10': 'channel'},
+    {'1': 'source', '3': 2, '4': 1, '5': 9, '10': 'source'},
+    {'1': 'checksum', '3': 3, '4': 1, '5': 9, '10': 'checksum'},
+  ],
+};
+
+/// Descriptor for `ServiceUpgradeCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List serviceUpgradeCmdDescriptor = $convert.base64Decode(
+    'ChFTZXJ2aWNlVXBncmFkZUNtZBIYCgdjaGFubmVsGAEgASgJUgdjaGFubmVsEhYKBnNvdXJjZR'
+    'gCIAEoCVIGc291cmNlEhoKCGNoZWNrc3VtGAMgASgJUghjaGVja3N1bQ==');
+
+@$core.Deprecated('Use tailLogCmdDescriptor instead')
+const TailLogCmd$json = {
+  '1': 'TailLogCmd',
+  '2': [
+    {'1': 'lines', '3': 1, '4': 1, '5': 5, '10': 'lines'},
+    {'1': 'priority', '3': 2, '4': 1, '5': 9, '10': 'priority'},
+    {'1': 'include_pro_client', '3': 3, '4': 1, '5': 8, '10': 'includeProClient'},
+    {'1': 'id', '3': 4, '4': 1, '5': 13, '10': 'id'},
+    {'1': 'follow', '3': 5, '4': 1, '5': 8, '10': 'follow'},
+    {'1': 'cancel', '3': 6, '4': 1, '5': 8, '10': 'cancel'},
+  ],
+};
+
+/// Descriptor for `TailLogCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List tailLogCmdDescriptor = $convert.base64Decode(
+    'CgpUYWlsTG9nQ21kEhQKBWxpbmVzGAEgASgFUgVsaW5lcxIaCghwcmlvcml0eRgCIAEoCVIIcH'
+    'Jpb3JpdHkSLAoSaW5jbHVkZV9wcm9fY2xpZW50GAMgASgIUhBpbmNsdWRlUHJvQ2xpZW50Eg4K'
+    'AmlkGAQgASgNUgJpZBIWCgZmb2xsb3cYBSABKAhSBmZvbGxvdxIWCgZjYW5jZWwYBiABKAhSBm'
+    'NhbmNlbA==');
+
+@$core.Deprecated('Use logMessageDescriptor instead')
+const LogMessage$json = {
+  '1': 'LogMessage',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'id', '3': 2, '4': 1, '5': 13, '10': 'id'},
+    {'1': 'line', '3': 3, '4': 1, '5': 9, '10': 'line'},
+    {'1': 'done', '3': 4, '4': 1, '5': 8, '10': 'done'},
+    {'1': 'error', '3': 5, '4': 1, '5': 9, '10': 'error'},
+  ],
+};
+
+/// Descriptor for `LogMessage`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List logMessageDescriptor = $convert.base64Decode(
+    'CgpMb2dNZXNzYWdlEhkKCHdzbF9uYW1lGAEgASgJUgd3c2xOYW1lEg4KAmlkGAIgASgNUgJpZB'
+    'ISCgRsaW5lGAMgASgJUgRsaW5lEhIKBGRvbmUYBCABKAhSBGRvbmUSFAoFZXJyb3IYBSABKAlS'
+    'BWVycm9y');
+
+@$core.Deprecated('Use pingCmdDescriptor instead')
+const PingCmd$json = {
+  '1': 'PingCmd',
+  '2': [
+    {'1': 'payload', '3': 1, '4': 1, '5': 12, '10': 'payload'},
+    {'1': 'id', '3': 2, '4': 1, '5': 13, '10': 'id'},
+  ],
+};
+
+/// Descriptor for `PingCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List pingCmdDescriptor = $convert.base64Decode(
+    'CgdQaW5nQ21kEhgKB3BheWxvYWQYASABKAxSB3BheWxvYWQSDgoCaWQYAiABKA1SAmlk');
+
+@$core.Deprecated('Use pingReplyDescriptor instead')
+const PingReply$json = {
+  '1': 'PingReply',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'id', '3': 2, '4': 1, '5': 13, '10': 'id'},
+    {'1': 'payload', '3': 3, '4': 1, '5': 12, '10': 'payload'},
+  ],
+};
+
+/// Descriptor for `PingReply`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List pingReplyDescriptor = $convert.base64Decode(
+    'CglQaW5nUmVwbHkSGQoId3NsX25hbWUYASABKAlSB3dzbE5hbWUSDgoCaWQYAiABKA1SAmlkEh'
+    'gKB3BheWxvYWQYAyABKAxSB3BheWxvYWQ=');
+
+@$core.Deprecated('Use preemptCmdDescriptor instead')
+const PreemptCmd$json = {
+  '1': 'PreemptCmd',
+  '2': [
+    {'1': 'id', '3': 1, '4': 1, '5': 13, '10': 'id'},
+  ],
+};
+
+/// Descriptor for `PreemptCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List preemptCmdDescriptor = $convert.base64Decode(
+    'CgpQcmVlbXB0Q21kEg4KAmlkGAEgASgNUgJpZA==');
+
+@$core.Deprecated('Use manageUserCmdDescriptor instead')
+const ManageUserCmd$json = {
+  '1': 'ManageUserCmd',
+  '2': [
+    {'1': 'name', '3': 1, '4': 1, '5': 9, '10': 'name'},
+    {'1': 'groups', '3': 2, '4': 3, '5': 9, '10': 'groups'},
+    {'1': 'set_default', '3': 3, '4': 1, '5': 8, '10': 'setDefault'},
+  ],
+};
+
+/// Descriptor for `ManageUserCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List manageUserCmdDescriptor = $convert.base64Decode(
+    'Cg1NYW5hZ2VVc2VyQ21kEhIKBG5hbWUYASABKAlSBG5hbWUSFgoGZ3JvdXBzGAIgAygJUgZncm'
+    '91cHMSHwoLc2V0X2RlZmF1bHQYAyABKAhSCnNldERlZmF1bHQ=');
+
+@$core.Deprecated('Use patchingCmdDescriptor instead')
+const PatchingCmd$json = {
+  '1': 'PatchingCmd',
+  '2': [
+    {'1': 'level', '3': 1, '4': 1, '5': 9, '10': 'level'},
+  ],
+};
+
+/// Descriptor for `PatchingCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List patchingCmdDescriptor = $convert.base64Decode(
+    'CgtQYXRjaGluZ0NtZBIUCgVsZXZlbBgBIAEoCVIFbGV2ZWw=');
+
+@$core.Deprecated('Use snapdCmdDescriptor instead')
+const SnapdCmd$json = {
+  '1': 'SnapdCmd',
+  '2': [
+    {'1': 'http', '3': 1, '4': 1, '5': 9, '10': 'http'},
+    {'1': 'https', '3': 2, '4': 1, '5': 9, '10': 'https'},
+    {'1': 'store_proxy', '3': 3, '4': 1, '5': 9, '10': 'storeProxy'},
+    {'1': 'store_id', '3': 4, '4': 1, '5': 9, '10': 'storeId'},
+  ],
+};
+
+/// Descriptor for `SnapdCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List snapdCmdDescriptor = $convert.base64Decode(
+    'CghTbmFwZENtZBISCgRodHRwGAEgASgJUgRodHRwEhQKBWh0dHBzGAIgASgJUgVodHRwcxIfCg'
+    'tzdG9yZV9wcm94eRgDIAEoCVIKc3RvcmVQcm94eRIZCghzdG9yZV9pZBgEIAEoCVIHc3RvcmVJ'
+    'ZA==');
+
+@$core.Deprecated('Use proStatusCmdDescriptor instead')
+const ProStatusCmd$json = {
+  '1': 'ProStatusCmd',
+  '2': [
+    {'1': 'attached', '3': 1, '4': 1, '5': 8, '10': 'attached'},
+  ],
+};
+
+/// Descriptor for `ProStatusCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List proStatusCmdDescriptor = $convert.base64Decode(
+    'CgxQcm9TdGF0dXNDbWQSGgoIYXR0YWNoZWQYASABKAhSCGF0dGFjaGVk');
+
+@$core.Deprecated('Use proxyCmdDescriptor instead')
+const ProxyCmd$json = {
+  '1': 'ProxyCmd',
+  '2': [
+    {'1': 'http', '3': 1, '4': 1, '5': 9, '10': 'http'},
+    {'1': 'https', '3': 2, '4': 1, '5': 9, '10': 'https'},
+    {'1': 'no_proxy', '3': 3, '4': 1, '5': 9, '10': 'noProxy'},
+  ],
+};
+
+/// Descriptor for `ProxyCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List proxyCmdDescriptor = $convert.base64Decode(
+    'CghQcm94eUNtZBISCgRodHRwGAEgASgJUgRodHRwEhQKBWh0dHBzGAIgASgJUgVodHRwcxIZCg'
+    'hub19wcm94eRgDIAEoCVIHbm9Qcm94eQ==');
+
+@$core.Deprecated('Use dnsCmdDescriptor instead')
+const DnsCmd$json = {
+  '1': 'DnsCmd',
+  '2': [
+    {'1': 'nameservers', '3': 1, '4': 3, '5': 9, '10': 'nameservers'},
+    {'1': 'search_domains', '3': 2, '4': 3, '5': 9, '10': 'searchDomains'},
+    {'1': 'hosts', '3': 3, '4': 3, '5': 9, '10': 'hosts'},
+  ],
+};
+
+/// Descriptor for `DnsCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List dnsCmdDescriptor = $convert.base64Decode(
+    'CgZEbnNDbWQSIAoLbmFtZXNlcnZlcnMYASADKAlSC25hbWVzZXJ2ZXJzEiUKDnNlYXJjaF9kb2'
+    '1haW5zGAIgAygJUg1zZWFyY2hEb21haW5zEhQKBWhvc3RzGAMgAygJUgVob3N0cw==');
+
 @$core.Deprecated('Use mSGDescriptor instead')
 const MSG$json = {
   '1': 'MSG',
   '2': [
     {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '9': 0, '10': 'wslName'},
     {'1': 'result', '3': 2, '4': 1, '5': 9, '9': 0, '10': 'result'},
+    {'1': 'progress', '3': 6, '4': 1, '5': 11, '6': '.agentapi.TaskProgress', '9': 0, '10': 'progress'},
+    {'1': 'output', '3': 3, '4': 1, '5': 12, '10': 'output'},
+    {'1': 'preempted', '3': 4, '4': 1, '5': 8, '10': 'preempted'},
+    {'1': 'more', '3': 5, '4': 1, '5': 8, '10': 'more'},
   ],
   '8': [
     {'1': 'data'},
@@ -163,5 +1205,21 @@ const MSG$json = {
 /// Descriptor for `MSG`. Decode as a `google.protobuf.DescriptorProto`.
 final $typed_data.Uint8List mSGDescriptor = $convert.base64Decode(
     'CgNNU0cSGwoId3NsX25hbWUYASABKAlIAFIHd3NsTmFtZRIYCgZyZXN1bHQYAiABKAlIAFIGcm'
-    'VzdWx0QgYKBGRhdGE=');
+    'VzdWx0EjQKCHByb2dyZXNzGAYgASgLMhYuYWdlbnRhcGkuVGFza1Byb2dyZXNzSABSCHByb2dy'
+    'ZXNzEhYKBm91dHB1dBgDIAEoDFIGb3V0cHV0EhwKCXByZWVtcHRlZBgEIAEoCFIJcHJlZW1wdG'
+    'VkEhIKBG1vcmUYBSABKAhSBG1vcmVCBgoEZGF0YQ==');
+
+@$core.Deprecated('Use taskProgressDescriptor instead')
+const TaskProgress$json = {
+  '1': 'TaskProgress',
+  '2': [
+    {'1': 'percent', '3': 1, '4': 1, '5': 13, '10': 'percent'},
+    {'1': 'step', '3': 2, '4': 1, '5': 9, '10': 'step'},
+  ],
+};
+
+/// Descriptor for `TaskProgress`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List taskProgressDescriptor = $convert.base64Decode(
+    'CgxUYXNrUHJvZ3Jlc3MSGAoHcGVyY2VudBgBIAEoDVIHcGVyY2VudBISCgRzdGVwGAIgASgJUg'
+    'RzdGVw');
 
@@ -21,6 +21,351 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type AgentEventType int32
+
+const (
+	AgentEventType_AGENT_EVENT_UNSPECIFIED          AgentEventType = 0
+	AgentEventType_AGENT_EVENT_DISTRO_ADDED         AgentEventType = 1
+	AgentEventType_AGENT_EVENT_DISTRO_REMOVED       AgentEventType = 2
+	AgentEventType_AGENT_EVENT_DISTRO_STAGE_CHANGED AgentEventType = 3 // The distro moved to a different stage of its lifecycle, e.g. it got Pro-attached.
+	AgentEventType_AGENT_EVENT_TASK_FAILED          AgentEventType = 4
+	AgentEventType_AGENT_EVENT_DISTRO_RENAMED       AgentEventType = 5 // Recorded under the new name of the distro. The message is its previous name.
+	AgentEventType_AGENT_EVENT_TASK_COMPLETED       AgentEventType = 6 // Only recorded for tasks that report their steps, so that their result is known.
+)
+
+// Enum value maps for AgentEventType.
+var (
+	AgentEventType_name = map[int32]string{
+		0: "AGENT_EVENT_UNSPECIFIED",
+		1: "AGENT_EVENT_DISTRO_ADDED",
+		2: "AGENT_EVENT_DISTRO_REMOVED",
+		3: "AGENT_EVENT_DISTRO_STAGE_CHANGED",
+		4: "AGENT_EVENT_TASK_FAILED",
+		5: "AGENT_EVENT_DISTRO_RENAMED",
+		6: "AGENT_EVENT_TASK_COMPLETED",
+	}
+	AgentEventType_value = map[string]int32{
+		"AGENT_EVENT_UNSPECIFIED":          0,
+		"AGENT_EVENT_DISTRO_ADDED":         1,
+		"AGENT_EVENT_DISTRO_REMOVED":       2,
+		"AGENT_EVENT_DISTRO_STAGE_CHANGED": 3,
+		"AGENT_EVENT_TASK_FAILED":          4,
+		"AGENT_EVENT_DISTRO_RENAMED":       5,
+		"AGENT_EVENT_TASK_COMPLETED":       6,
+	}
+)
+
+func (x AgentEventType) Enum() *AgentEventType {
+	p := new(AgentEventType)
+	*p = x
+	return p
+}
+
+func (x AgentEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AgentEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_agentapi_proto_enumTypes[0].Descriptor()
+}
+
+func (AgentEventType) Type() protoreflect.EnumType {
+	return &file_agentapi_proto_enumTypes[0]
+}
+
+func (x AgentEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AgentEventType.Descriptor instead.
+func (AgentEventType) EnumDescriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{0}
+}
+
+type TaskEventType int32
+
+const (
+	TaskEventType_TASK_EVENT_UNSPECIFIED TaskEventType = 0
+	TaskEventType_TASK_EVENT_QUEUED      TaskEventType = 1 // The task was submitted and waits for its turn.
+	TaskEventType_TASK_EVENT_STARTED     TaskEventType = 2
+	TaskEventType_TASK_EVENT_PROGRESS    TaskEventType = 3
+	TaskEventType_TASK_EVENT_COMPLETED   TaskEventType = 4
+	TaskEventType_TASK_EVENT_FAILED      TaskEventType = 5
+	TaskEventType_TASK_EVENT_INTERRUPTED TaskEventType = 6 // The distro disconnected mid-task: the task is queued again to resume once it reconnects.
+)
+
+// Enum value maps for TaskEventType.
+var (
+	TaskEventType_name = map[int32]string{
+		0: "TASK_EVENT_UNSPECIFIED",
+		1: "TASK_EVENT_QUEUED",
+		2: "TASK_EVENT_STARTED",
+		3: "TASK_EVENT_PROGRESS",
+		4: "TASK_EVENT_COMPLETED",
+		5: "TASK_EVENT_FAILED",
+		6: "TASK_EVENT_INTERRUPTED",
+	}
+	TaskEventType_value = map[string]int32{
+		"TASK_EVENT_UNSPECIFIED": 0,
+		"TASK_EVENT_QUEUED":      1,
+		"TASK_EVENT_STARTED":     2,
+		"TASK_EVENT_PROGRESS":    3,
+		"TASK_EVENT_COMPLETED":   4,
+		"TASK_EVENT_FAILED":      5,
+		"TASK_EVENT_INTERRUPTED": 6,
+	}
+)
+
+func (x TaskEventType) Enum() *TaskEventType {
+	p := new(TaskEventType)
+	*p = x
+	return p
+}
+
+func (x TaskEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TaskEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_agentapi_proto_enumTypes[1].Descriptor()
+}
+
+func (TaskEventType) Type() protoreflect.EnumType {
+	return &file_agentapi_proto_enumTypes[1]
+}
+
+func (x TaskEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TaskEventType.Descriptor instead.
+func (TaskEventType) EnumDescriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{1}
+}
+
+type TaskStepStatus int32
+
+const (
+	TaskStepStatus_TASK_STEP_UNSPECIFIED TaskStepStatus = 0
+	TaskStepStatus_TASK_STEP_SUCCEEDED   TaskStepStatus = 1
+	TaskStepStatus_TASK_STEP_FAILED      TaskStepStatus = 2
+	TaskStepStatus_TASK_STEP_SKIPPED     TaskStepStatus = 3 // The step did not run, e.g. because an earlier step it depends on failed.
+)
+
+// Enum value maps for TaskStepStatus.
+var (
+	TaskStepStatus_name = map[int32]string{
+		0: "TASK_STEP_UNSPECIFIED",
+		1: "TASK_STEP_SUCCEEDED",
+		2: "TASK_STEP_FAILED",
+		3: "TASK_STEP_SKIPPED",
+	}
+	TaskStepStatus_value = map[string]int32{
+		"TASK_STEP_UNSPECIFIED": 0,
+		"TASK_STEP_SUCCEEDED":   1,
+		"TASK_STEP_FAILED":      2,
+		"TASK_STEP_SKIPPED":     3,
+	}
+)
+
+func (x TaskStepStatus) Enum() *TaskStepStatus {
+	p := new(TaskStepStatus)
+	*p = x
+	return p
+}
+
+func (x TaskStepStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TaskStepStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_agentapi_proto_enumTypes[2].Descriptor()
+}
+
+func (TaskStepStatus) Type() protoreflect.EnumType {
+	return &file_agentapi_proto_enumTypes[2]
+}
+
+func (x TaskStepStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TaskStepStatus.Descriptor instead.
+func (TaskStepStatus) EnumDescriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{2}
+}
+
+type ProvisionStage int32
+
+const (
+	ProvisionStage_PROVISION_STAGE_UNSPECIFIED ProvisionStage = 0
+	ProvisionStage_PROVISION_STAGE_DOWNLOADING ProvisionStage = 1 // The image is downloaded and verified on the fly.
+	ProvisionStage_PROVISION_STAGE_IMPORTING   ProvisionStage = 2 // The image is imported into WSL.
+	ProvisionStage_PROVISION_STAGE_CONFIGURING ProvisionStage = 3 // The distro boots for the first time, until cloud-init is done with it.
+	ProvisionStage_PROVISION_STAGE_DONE        ProvisionStage = 4
+)
+
+// Enum value maps for ProvisionStage.
+var (
+	ProvisionStage_name = map[int32]string{
+		0: "PROVISION_STAGE_UNSPECIFIED",
+		1: "PROVISION_STAGE_DOWNLOADING",
+		2: "PROVISION_STAGE_IMPORTING",
+		3: "PROVISION_STAGE_CONFIGURING",
+		4: "PROVISION_STAGE_DONE",
+	}
+	ProvisionStage_value = map[string]int32{
+		"PROVISION_STAGE_UNSPECIFIED": 0,
+		"PROVISION_STAGE_DOWNLOADING": 1,
+		"PROVISION_STAGE_IMPORTING":   2,
+		"PROVISION_STAGE_CONFIGURING": 3,
+		"PROVISION_STAGE_DONE":        4,
+	}
+)
+
+func (x ProvisionStage) Enum() *ProvisionStage {
+	p := new(ProvisionStage)
+	*p = x
+	return p
+}
+
+func (x ProvisionStage) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProvisionStage) Descriptor() protoreflect.EnumDescriptor {
+	return file_agentapi_proto_enumTypes[3].Descriptor()
+}
+
+func (ProvisionStage) Type() protoreflect.EnumType {
+	return &file_agentapi_proto_enumTypes[3]
+}
+
+func (x ProvisionStage) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProvisionStage.Descriptor instead.
+func (ProvisionStage) EnumDescriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{3}
+}
+
+type DistroOperation int32
+
+const (
+	DistroOperation_DISTRO_OPERATION_UNSPECIFIED DistroOperation = 0
+	DistroOperation_DISTRO_OPERATION_SET_SPARSE  DistroOperation = 1 // Requires WSL 2.0.0 or newer.
+	DistroOperation_DISTRO_OPERATION_MOVE        DistroOperation = 2 // Requires WSL 2.3.11 or newer.
+	DistroOperation_DISTRO_OPERATION_SET_VERSION DistroOperation = 3
+)
+
+// Enum value maps for DistroOperation.
+var (
+	DistroOperation_name = map[int32]string{
+		0: "DISTRO_OPERATION_UNSPECIFIED",
+		1: "DISTRO_OPERATION_SET_SPARSE",
+		2: "DISTRO_OPERATION_MOVE",
+		3: "DISTRO_OPERATION_SET_VERSION",
+	}
+	DistroOperation_value = map[string]int32{
+		"DISTRO_OPERATION_UNSPECIFIED": 0,
+		"DISTRO_OPERATION_SET_SPARSE":  1,
+		"DISTRO_OPERATION_MOVE":        2,
+		"DISTRO_OPERATION_SET_VERSION": 3,
+	}
+)
+
+func (x DistroOperation) Enum() *DistroOperation {
+	p := new(DistroOperation)
+	*p = x
+	return p
+}
+
+func (x DistroOperation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DistroOperation) Descriptor() protoreflect.EnumDescriptor {
+	return file_agentapi_proto_enumTypes[4].Descriptor()
+}
+
+func (DistroOperation) Type() protoreflect.EnumType {
+	return &file_agentapi_proto_enumTypes[4]
+}
+
+func (x DistroOperation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DistroOperation.Descriptor instead.
+func (DistroOperation) EnumDescriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{4}
+}
+
+type Capability int32
+
+const (
+	Capability_CAPABILITY_UNSPECIFIED Capability = 0
+	Capability_CAPABILITY_EXEC        Capability = 1 // Running arbitrary commands in the distro.
+	Capability_CAPABILITY_FILE_PUSH   Capability = 2 // Copying files from Windows into the distro.
+	Capability_CAPABILITY_LOGS        Capability = 3 // Streaming the logs of the WSL Pro service (the TailLog stream).
+	Capability_CAPABILITY_INFO_ACK    Capability = 4 // Acknowledging every DistroInfo with the DistroSettings of the distro.
+	Capability_CAPABILITY_PING        Capability = 5 // Echoing pings to measure the round-trip time (the Ping stream).
+	Capability_CAPABILITY_COMPRESSION Capability = 6 // Compressing the large messages of the streams opened after the handshake.
+	Capability_CAPABILITY_PROGRESS    Capability = 7 // Reporting the progress of long commands in MSG messages sent ahead of their result.
+)
+
+// Enum value maps for Capability.
+var (
+	Capability_name = map[int32]string{
+		0: "CAPABILITY_UNSPECIFIED",
+		1: "CAPABILITY_EXEC",
+		2: "CAPABILITY_FILE_PUSH",
+		3: "CAPABILITY_LOGS",
+		4: "CAPABILITY_INFO_ACK",
+		5: "CAPABILITY_PING",
+		6: "CAPABILITY_COMPRESSION",
+		7: "CAPABILITY_PROGRESS",
+	}
+	Capability_value = map[string]int32{
+		"CAPABILITY_UNSPECIFIED": 0,
+		"CAPABILITY_EXEC":        1,
+		"CAPABILITY_FILE_PUSH":   2,
+		"CAPABILITY_LOGS":        3,
+		"CAPABILITY_INFO_ACK":    4,
+		"CAPABILITY_PING":        5,
+		"CAPABILITY_COMPRESSION": 6,
+		"CAPABILITY_PROGRESS":    7,
+	}
+)
+
+func (x Capability) Enum() *Capability {
+	p := new(Capability)
+	*p = x
+	return p
+}
+
+func (x Capability) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Capability) Descriptor() protoreflect.EnumDescriptor {
+	return file_agentapi_proto_enumTypes[5].Descriptor()
+}
+
+func (Capability) Type() protoreflect.EnumType {
+	return &file_agentapi_proto_enumTypes[5]
+}
+
+func (x Capability) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Capability.Descriptor instead.
+func (Capability) EnumDescriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{5}
+}
+
 type Empty struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -145,34 +490,29 @@ func (x *LandscapeConfig) GetConfig() string {
 	return ""
 }
 
-type SubscriptionInfo struct {
-	state     protoimpl.MessageState `protogen:"open.v1"`
-	ProductId string                 `protobuf:"bytes,1,opt,name=productId,proto3" json:"productId,omitempty"` // The ID of the Ubuntu Pro for WSL product on the Microsoft Store.
-	// Types that are valid to be assigned to SubscriptionType:
-	//
-	//	*SubscriptionInfo_None
-	//	*SubscriptionInfo_User
-	//	*SubscriptionInfo_Organization
-	//	*SubscriptionInfo_MicrosoftStore
-	SubscriptionType isSubscriptionInfo_SubscriptionType `protobuf_oneof:"subscriptionType"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+type ProServiceInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distros       []string               `protobuf:"bytes,1,rep,name=distros,proto3" json:"distros,omitempty"` // The WSL names of the distros to act upon.
+	Service       string                 `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"` // The Ubuntu Pro service to act upon (e.g. esm-apps, usg).
+	Enable        bool                   `protobuf:"varint,3,opt,name=enable,proto3" json:"enable,omitempty"`  // Whether to enable or disable the service.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SubscriptionInfo) Reset() {
-	*x = SubscriptionInfo{}
+func (x *ProServiceInfo) Reset() {
+	*x = ProServiceInfo{}
 	mi := &file_agentapi_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SubscriptionInfo) String() string {
+func (x *ProServiceInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SubscriptionInfo) ProtoMessage() {}
+func (*ProServiceInfo) ProtoMessage() {}
 
-func (x *SubscriptionInfo) ProtoReflect() protoreflect.Message {
+func (x *ProServiceInfo) ProtoReflect() protoreflect.Message {
 	mi := &file_agentapi_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -184,116 +524,117 @@ func (x *SubscriptionInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SubscriptionInfo.ProtoReflect.Descriptor instead.
-func (*SubscriptionInfo) Descriptor() ([]byte, []int) {
+// Deprecated: Use ProServiceInfo.ProtoReflect.Descriptor instead.
+func (*ProServiceInfo) Descriptor() ([]byte, []int) {
 	return file_agentapi_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *SubscriptionInfo) GetProductId() string {
+func (x *ProServiceInfo) GetDistros() []string {
 	if x != nil {
-		return x.ProductId
+		return x.Distros
 	}
-	return ""
+	return nil
 }
 
-func (x *SubscriptionInfo) GetSubscriptionType() isSubscriptionInfo_SubscriptionType {
+func (x *ProServiceInfo) GetService() string {
 	if x != nil {
-		return x.SubscriptionType
+		return x.Service
 	}
-	return nil
+	return ""
 }
 
-func (x *SubscriptionInfo) GetNone() *Empty {
+func (x *ProServiceInfo) GetEnable() bool {
 	if x != nil {
-		if x, ok := x.SubscriptionType.(*SubscriptionInfo_None); ok {
-			return x.None
-		}
+		return x.Enable
 	}
-	return nil
+	return false
 }
 
-func (x *SubscriptionInfo) GetUser() *Empty {
-	if x != nil {
-		if x, ok := x.SubscriptionType.(*SubscriptionInfo_User); ok {
-			return x.User
-		}
-	}
-	return nil
+type UsgProfileInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distros       []string               `protobuf:"bytes,1,rep,name=distros,proto3" json:"distros,omitempty"` // The WSL names of the distros to act upon.
+	Profile       string                 `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"` // The USG profile to apply (e.g. cis_level1_server, disa_stig).
+	Fix           bool                   `protobuf:"varint,3,opt,name=fix,proto3" json:"fix,omitempty"`        // Whether to remediate with `usg fix` before auditing.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SubscriptionInfo) GetOrganization() *Empty {
-	if x != nil {
-		if x, ok := x.SubscriptionType.(*SubscriptionInfo_Organization); ok {
-			return x.Organization
-		}
-	}
-	return nil
+func (x *UsgProfileInfo) Reset() {
+	*x = UsgProfileInfo{}
+	mi := &file_agentapi_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *SubscriptionInfo) GetMicrosoftStore() *Empty {
+func (x *UsgProfileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsgProfileInfo) ProtoMessage() {}
+
+func (x *UsgProfileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[4]
 	if x != nil {
-		if x, ok := x.SubscriptionType.(*SubscriptionInfo_MicrosoftStore); ok {
-			return x.MicrosoftStore
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
+		return ms
 	}
-	return nil
-}
-
-type isSubscriptionInfo_SubscriptionType interface {
-	isSubscriptionInfo_SubscriptionType()
+	return mi.MessageOf(x)
 }
 
-type SubscriptionInfo_None struct {
-	None *Empty `protobuf:"bytes,2,opt,name=none,proto3,oneof"` // There is no active subscription.
+// Deprecated: Use UsgProfileInfo.ProtoReflect.Descriptor instead.
+func (*UsgProfileInfo) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{4}
 }
 
-type SubscriptionInfo_User struct {
-	User *Empty `protobuf:"bytes,3,opt,name=user,proto3,oneof"` // The subscription is managed by the user with a pro token from the GUI or the registry.
+func (x *UsgProfileInfo) GetDistros() []string {
+	if x != nil {
+		return x.Distros
+	}
+	return nil
 }
 
-type SubscriptionInfo_Organization struct {
-	Organization *Empty `protobuf:"bytes,4,opt,name=organization,proto3,oneof"` // The subscription is managed by the sysadmin with a pro token from the registry.
+func (x *UsgProfileInfo) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
 }
 
-type SubscriptionInfo_MicrosoftStore struct {
-	MicrosoftStore *Empty `protobuf:"bytes,5,opt,name=microsoftStore,proto3,oneof"` // The subscription is managed via the Microsoft store.
+func (x *UsgProfileInfo) GetFix() bool {
+	if x != nil {
+		return x.Fix
+	}
+	return false
 }
 
-func (*SubscriptionInfo_None) isSubscriptionInfo_SubscriptionType() {}
-
-func (*SubscriptionInfo_User) isSubscriptionInfo_SubscriptionType() {}
-
-func (*SubscriptionInfo_Organization) isSubscriptionInfo_SubscriptionType() {}
-
-func (*SubscriptionInfo_MicrosoftStore) isSubscriptionInfo_SubscriptionType() {}
-
-type LandscapeSource struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Types that are valid to be assigned to LandscapeSourceType:
-	//
-	//	*LandscapeSource_None
-	//	*LandscapeSource_User
-	//	*LandscapeSource_Organization
-	LandscapeSourceType isLandscapeSource_LandscapeSourceType `protobuf_oneof:"landscapeSourceType"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+type ManageUserInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distros       []string               `protobuf:"bytes,1,rep,name=distros,proto3" json:"distros,omitempty"`                          // The WSL names of the distros to act upon.
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                                // The name of the user, created if it does not exist.
+	Groups        []string               `protobuf:"bytes,3,rep,name=groups,proto3" json:"groups,omitempty"`                            // Supplementary groups the user is added to. They must exist in the distro.
+	SetDefault    bool                   `protobuf:"varint,4,opt,name=set_default,json=setDefault,proto3" json:"set_default,omitempty"` // Make it the default user of the distro.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LandscapeSource) Reset() {
-	*x = LandscapeSource{}
-	mi := &file_agentapi_proto_msgTypes[4]
+func (x *ManageUserInfo) Reset() {
+	*x = ManageUserInfo{}
+	mi := &file_agentapi_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LandscapeSource) String() string {
+func (x *ManageUserInfo) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LandscapeSource) ProtoMessage() {}
+func (*ManageUserInfo) ProtoMessage() {}
 
-func (x *LandscapeSource) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[4]
+func (x *ManageUserInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -304,90 +645,3782 @@ func (x *LandscapeSource) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LandscapeSource.ProtoReflect.Descriptor instead.
-func (*LandscapeSource) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use ManageUserInfo.ProtoReflect.Descriptor instead.
+func (*ManageUserInfo) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ManageUserInfo) GetDistros() []string {
+	if x != nil {
+		return x.Distros
+	}
+	return nil
+}
+
+func (x *ManageUserInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ManageUserInfo) GetGroups() []string {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *ManageUserInfo) GetSetDefault() bool {
+	if x != nil {
+		return x.SetDefault
+	}
+	return false
+}
+
+// ManageDistroRequest is an operation on the distros as a whole, such as reclaiming the disk space they freed. It
+// runs on the host with wsl.exe once each distro is stopped. WslInfo lists the operations the host WSL supports.
+type ManageDistroRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Distros []string               `protobuf:"bytes,1,rep,name=distros,proto3" json:"distros,omitempty"` // The WSL names of the distros to act upon.
+	// Types that are valid to be assigned to Operation:
+	//
+	//	*ManageDistroRequest_SetSparse
+	//	*ManageDistroRequest_Move
+	//	*ManageDistroRequest_SetVersion
+	Operation     isManageDistroRequest_Operation `protobuf_oneof:"operation"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ManageDistroRequest) Reset() {
+	*x = ManageDistroRequest{}
+	mi := &file_agentapi_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManageDistroRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManageDistroRequest) ProtoMessage() {}
+
+func (x *ManageDistroRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ManageDistroRequest.ProtoReflect.Descriptor instead.
+func (*ManageDistroRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ManageDistroRequest) GetDistros() []string {
+	if x != nil {
+		return x.Distros
+	}
+	return nil
+}
+
+func (x *ManageDistroRequest) GetOperation() isManageDistroRequest_Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return nil
+}
+
+func (x *ManageDistroRequest) GetSetSparse() bool {
+	if x != nil {
+		if x, ok := x.Operation.(*ManageDistroRequest_SetSparse); ok {
+			return x.SetSparse
+		}
+	}
+	return false
+}
+
+func (x *ManageDistroRequest) GetMove() string {
+	if x != nil {
+		if x, ok := x.Operation.(*ManageDistroRequest_Move); ok {
+			return x.Move
+		}
+	}
+	return ""
+}
+
+func (x *ManageDistroRequest) GetSetVersion() int32 {
+	if x != nil {
+		if x, ok := x.Operation.(*ManageDistroRequest_SetVersion); ok {
+			return x.SetVersion
+		}
+	}
+	return 0
+}
+
+type isManageDistroRequest_Operation interface {
+	isManageDistroRequest_Operation()
+}
+
+type ManageDistroRequest_SetSparse struct {
+	SetSparse bool `protobuf:"varint,2,opt,name=set_sparse,json=setSparse,proto3,oneof"` // Whether the VHD of the distros is sparse, so that WSL gives back the disk space they free.
+}
+
+type ManageDistroRequest_Move struct {
+	Move string `protobuf:"bytes,3,opt,name=move,proto3,oneof"` // Directory of the host to move the VHD of the distro into. Only one distro can be moved at a time.
+}
+
+type ManageDistroRequest_SetVersion struct {
+	SetVersion int32 `protobuf:"varint,4,opt,name=set_version,json=setVersion,proto3,oneof"` // Version of WSL the distros run with: 1 or 2.
+}
+
+func (*ManageDistroRequest_SetSparse) isManageDistroRequest_Operation() {}
+
+func (*ManageDistroRequest_Move) isManageDistroRequest_Operation() {}
+
+func (*ManageDistroRequest_SetVersion) isManageDistroRequest_Operation() {}
+
+type GetEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SinceToken    string                 `protobuf:"bytes,1,opt,name=since_token,json=sinceToken,proto3" json:"since_token,omitempty"` // The next_token of a previous response. Empty to get all the journaled events.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventsRequest) Reset() {
+	*x = GetEventsRequest{}
+	mi := &file_agentapi_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventsRequest) ProtoMessage() {}
+
+func (x *GetEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventsRequest.ProtoReflect.Descriptor instead.
+func (*GetEventsRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetEventsRequest) GetSinceToken() string {
+	if x != nil {
+		return x.SinceToken
+	}
+	return ""
+}
+
+type Events struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*AgentEvent          `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`                        // The events that happened after since_token, oldest first.
+	NextToken     string                 `protobuf:"bytes,2,opt,name=next_token,json=nextToken,proto3" json:"next_token,omitempty"` // The token to pass in the next request to get the events that happen after these.
+	Truncated     bool                   `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"`                 // Some events after since_token are no longer in the journal: the full state must be fetched again.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Events) Reset() {
+	*x = Events{}
+	mi := &file_agentapi_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Events) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Events) ProtoMessage() {}
+
+func (x *Events) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Events.ProtoReflect.Descriptor instead.
+func (*Events) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Events) GetEvents() []*AgentEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Events) GetNextToken() string {
+	if x != nil {
+		return x.NextToken
+	}
+	return ""
+}
+
+func (x *Events) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type AgentEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          AgentEventType         `protobuf:"varint,1,opt,name=type,proto3,enum=agentapi.AgentEventType" json:"type,omitempty"`
+	Time          string                 `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`       // When the event happened, in RFC3339 format.
+	Distro        string                 `protobuf:"bytes,3,opt,name=distro,proto3" json:"distro,omitempty"`   // The distro the event is about.
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"` // Human-readable details, such as the new stage of the distro or why a task failed.
+	Result        *TaskResult            `protobuf:"bytes,5,opt,name=result,proto3" json:"result,omitempty"`   // The outcome of each step of the failed task, only set for AGENT_EVENT_TASK_FAILED.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentEvent) Reset() {
+	*x = AgentEvent{}
+	mi := &file_agentapi_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentEvent) ProtoMessage() {}
+
+func (x *AgentEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentEvent.ProtoReflect.Descriptor instead.
+func (*AgentEvent) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AgentEvent) GetType() AgentEventType {
+	if x != nil {
+		return x.Type
+	}
+	return AgentEventType_AGENT_EVENT_UNSPECIFIED
+}
+
+func (x *AgentEvent) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *AgentEvent) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *AgentEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AgentEvent) GetResult() *TaskResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type ConsentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`             // The ID to answer the request with.
+	Distro        string                 `protobuf:"bytes,2,opt,name=distro,proto3" json:"distro,omitempty"`     // The distro the task asking for consent would act upon. Empty for changes to the host, e.g. to .wslconfig.
+	Prompt        string                 `protobuf:"bytes,3,opt,name=prompt,proto3" json:"prompt,omitempty"`     // Human-readable description of what the task would do.
+	Deadline      string                 `protobuf:"bytes,4,opt,name=deadline,proto3" json:"deadline,omitempty"` // When the request is resolved by the timeout policy if nobody answers, in RFC3339 format.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsentRequest) Reset() {
+	*x = ConsentRequest{}
+	mi := &file_agentapi_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsentRequest) ProtoMessage() {}
+
+func (x *ConsentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsentRequest.ProtoReflect.Descriptor instead.
+func (*ConsentRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ConsentRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ConsentRequest) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *ConsentRequest) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *ConsentRequest) GetDeadline() string {
+	if x != nil {
+		return x.Deadline
+	}
+	return ""
+}
+
+type ConsentAnswer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // The ID of the request being answered.
+	Granted       bool                   `protobuf:"varint,2,opt,name=granted,proto3" json:"granted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsentAnswer) Reset() {
+	*x = ConsentAnswer{}
+	mi := &file_agentapi_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsentAnswer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsentAnswer) ProtoMessage() {}
+
+func (x *ConsentAnswer) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsentAnswer.ProtoReflect.Descriptor instead.
+func (*ConsentAnswer) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ConsentAnswer) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ConsentAnswer) GetGranted() bool {
+	if x != nil {
+		return x.Granted
+	}
+	return false
+}
+
+type UsgReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distro        string                 `protobuf:"bytes,1,opt,name=distro,proto3" json:"distro,omitempty"`
+	Profile       string                 `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsgReportRequest) Reset() {
+	*x = UsgReportRequest{}
+	mi := &file_agentapi_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsgReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsgReportRequest) ProtoMessage() {}
+
+func (x *UsgReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsgReportRequest.ProtoReflect.Descriptor instead.
+func (*UsgReportRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UsgReportRequest) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *UsgReportRequest) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+type UsgReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distro        string                 `protobuf:"bytes,1,opt,name=distro,proto3" json:"distro,omitempty"`
+	Profile       string                 `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+	Timestamp     string                 `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // When the report was received, in RFC3339 format.
+	Report        []byte                 `protobuf:"bytes,4,opt,name=report,proto3" json:"report,omitempty"`       // A chunk of the HTML audit report produced by `usg audit`. The report is the concatenation of the chunks of the stream.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsgReport) Reset() {
+	*x = UsgReport{}
+	mi := &file_agentapi_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsgReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsgReport) ProtoMessage() {}
+
+func (x *UsgReport) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsgReport.ProtoReflect.Descriptor instead.
+func (*UsgReport) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UsgReport) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *UsgReport) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *UsgReport) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *UsgReport) GetReport() []byte {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+type TailLogRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Distro           string                 `protobuf:"bytes,1,opt,name=distro,proto3" json:"distro,omitempty"`
+	Lines            int32                  `protobuf:"varint,2,opt,name=lines,proto3" json:"lines,omitempty"`                                                 // Number of recent lines to return. Defaults to 100 when unset.
+	Priority         string                 `protobuf:"bytes,3,opt,name=priority,proto3" json:"priority,omitempty"`                                            // Only return lines of this journal priority or more severe (e.g. err, warning). All if unset.
+	IncludeProClient bool                   `protobuf:"varint,4,opt,name=include_pro_client,json=includeProClient,proto3" json:"include_pro_client,omitempty"` // Whether to include the logs of the Ubuntu Pro client (ubuntu-advantage).
+	Follow           bool                   `protobuf:"varint,5,opt,name=follow,proto3" json:"follow,omitempty"`                                               // Keep streaming new lines until the call is cancelled.
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *TailLogRequest) Reset() {
+	*x = TailLogRequest{}
+	mi := &file_agentapi_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TailLogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailLogRequest) ProtoMessage() {}
+
+func (x *TailLogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailLogRequest.ProtoReflect.Descriptor instead.
+func (*TailLogRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TailLogRequest) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *TailLogRequest) GetLines() int32 {
+	if x != nil {
+		return x.Lines
+	}
+	return 0
+}
+
+func (x *TailLogRequest) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+func (x *TailLogRequest) GetIncludeProClient() bool {
+	if x != nil {
+		return x.IncludeProClient
+	}
+	return false
+}
+
+func (x *TailLogRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+type LogLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          string                 `protobuf:"bytes,1,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogLine) Reset() {
+	*x = LogLine{}
+	mi := &file_agentapi_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogLine) ProtoMessage() {}
+
+func (x *LogLine) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogLine.ProtoReflect.Descriptor instead.
+func (*LogLine) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *LogLine) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+type WatchTasksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distro        string                 `protobuf:"bytes,1,opt,name=distro,proto3" json:"distro,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchTasksRequest) Reset() {
+	*x = WatchTasksRequest{}
+	mi := &file_agentapi_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchTasksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchTasksRequest) ProtoMessage() {}
+
+func (x *WatchTasksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchTasksRequest.ProtoReflect.Descriptor instead.
+func (*WatchTasksRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WatchTasksRequest) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+type TaskEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          TaskEventType          `protobuf:"varint,1,opt,name=type,proto3,enum=agentapi.TaskEventType" json:"type,omitempty"`
+	Task          string                 `protobuf:"bytes,2,opt,name=task,proto3" json:"task,omitempty"`          // Human-readable description of the task.
+	Progress      uint32                 `protobuf:"varint,3,opt,name=progress,proto3" json:"progress,omitempty"` // Percentage of completion, only set for TASK_EVENT_PROGRESS.
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`      // Why the task failed or was interrupted, only set for TASK_EVENT_FAILED and TASK_EVENT_INTERRUPTED.
+	Result        *TaskResult            `protobuf:"bytes,5,opt,name=result,proto3" json:"result,omitempty"`      // The outcome of each step of the task, only set for TASK_EVENT_COMPLETED and TASK_EVENT_FAILED.
+	Step          string                 `protobuf:"bytes,6,opt,name=step,proto3" json:"step,omitempty"`          // What the task is doing, e.g. "Installing wsl-pro-service". Only set for TASK_EVENT_PROGRESS, and only by tasks that tell.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskEvent) Reset() {
+	*x = TaskEvent{}
+	mi := &file_agentapi_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskEvent) ProtoMessage() {}
+
+func (x *TaskEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskEvent.ProtoReflect.Descriptor instead.
+func (*TaskEvent) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TaskEvent) GetType() TaskEventType {
+	if x != nil {
+		return x.Type
+	}
+	return TaskEventType_TASK_EVENT_UNSPECIFIED
+}
+
+func (x *TaskEvent) GetTask() string {
+	if x != nil {
+		return x.Task
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetProgress() uint32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *TaskEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *TaskEvent) GetResult() *TaskResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *TaskEvent) GetStep() string {
+	if x != nil {
+		return x.Step
+	}
+	return ""
+}
+
+// TaskResult is the outcome of each step of a multi-step task, so that a partial success can be told
+// apart from a task that failed altogether.
+type TaskResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Steps         []*TaskStep            `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"` // In the order they ran. Empty for tasks that do not report their steps.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskResult) Reset() {
+	*x = TaskResult{}
+	mi := &file_agentapi_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskResult) ProtoMessage() {}
+
+func (x *TaskResult) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskResult.ProtoReflect.Descriptor instead.
+func (*TaskResult) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TaskResult) GetSteps() []*TaskStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+type TaskStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // Human-readable name of the step, e.g. "audit".
+	Status        TaskStepStatus         `protobuf:"varint,2,opt,name=status,proto3,enum=agentapi.TaskStepStatus" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // Why the step failed or was skipped.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskStep) Reset() {
+	*x = TaskStep{}
+	mi := &file_agentapi_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskStep) ProtoMessage() {}
+
+func (x *TaskStep) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskStep.ProtoReflect.Descriptor instead.
+func (*TaskStep) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *TaskStep) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TaskStep) GetStatus() TaskStepStatus {
+	if x != nil {
+		return x.Status
+	}
+	return TaskStepStatus_TASK_STEP_UNSPECIFIED
+}
+
+func (x *TaskStep) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type NotificationSettings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Frequency     string                 `protobuf:"bytes,1,opt,name=frequency,proto3" json:"frequency,omitempty"` // How often to summarize low priority notifications: immediate, hourly, daily or never.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NotificationSettings) Reset() {
+	*x = NotificationSettings{}
+	mi := &file_agentapi_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NotificationSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotificationSettings) ProtoMessage() {}
+
+func (x *NotificationSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotificationSettings.ProtoReflect.Descriptor instead.
+func (*NotificationSettings) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *NotificationSettings) GetFrequency() string {
+	if x != nil {
+		return x.Frequency
+	}
+	return ""
+}
+
+type Latencies struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distros       []*DistroLatency       `protobuf:"bytes,1,rep,name=distros,proto3" json:"distros,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Latencies) Reset() {
+	*x = Latencies{}
+	mi := &file_agentapi_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Latencies) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Latencies) ProtoMessage() {}
+
+func (x *Latencies) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Latencies.ProtoReflect.Descriptor instead.
+func (*Latencies) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Latencies) GetDistros() []*DistroLatency {
+	if x != nil {
+		return x.Distros
+	}
+	return nil
+}
+
+type DistroLatency struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distro        string                 `protobuf:"bytes,1,opt,name=distro,proto3" json:"distro,omitempty"`
+	LastMs        float64                `protobuf:"fixed64,2,opt,name=last_ms,json=lastMs,proto3" json:"last_ms,omitempty"`        // Round-trip time of the latest successful ping, in milliseconds.
+	MinMs         float64                `protobuf:"fixed64,3,opt,name=min_ms,json=minMs,proto3" json:"min_ms,omitempty"`           // Shortest round-trip time, in milliseconds.
+	MaxMs         float64                `protobuf:"fixed64,4,opt,name=max_ms,json=maxMs,proto3" json:"max_ms,omitempty"`           // Longest round-trip time, in milliseconds.
+	MeanMs        float64                `protobuf:"fixed64,5,opt,name=mean_ms,json=meanMs,proto3" json:"mean_ms,omitempty"`        // Average round-trip time, in milliseconds.
+	Samples       int32                  `protobuf:"varint,6,opt,name=samples,proto3" json:"samples,omitempty"`                     // Number of successful pings.
+	Failures      int32                  `protobuf:"varint,7,opt,name=failures,proto3" json:"failures,omitempty"`                   // Number of failed pings.
+	LastProbe     string                 `protobuf:"bytes,8,opt,name=last_probe,json=lastProbe,proto3" json:"last_probe,omitempty"` // When the distro was last pinged, in RFC3339 format. Unset if never pinged.
+	LastError     string                 `protobuf:"bytes,9,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"` // The error of the latest ping, if it failed.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DistroLatency) Reset() {
+	*x = DistroLatency{}
+	mi := &file_agentapi_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroLatency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroLatency) ProtoMessage() {}
+
+func (x *DistroLatency) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroLatency.ProtoReflect.Descriptor instead.
+func (*DistroLatency) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DistroLatency) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *DistroLatency) GetLastMs() float64 {
+	if x != nil {
+		return x.LastMs
+	}
+	return 0
+}
+
+func (x *DistroLatency) GetMinMs() float64 {
+	if x != nil {
+		return x.MinMs
+	}
+	return 0
+}
+
+func (x *DistroLatency) GetMaxMs() float64 {
+	if x != nil {
+		return x.MaxMs
+	}
+	return 0
+}
+
+func (x *DistroLatency) GetMeanMs() float64 {
+	if x != nil {
+		return x.MeanMs
+	}
+	return 0
+}
+
+func (x *DistroLatency) GetSamples() int32 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *DistroLatency) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *DistroLatency) GetLastProbe() string {
+	if x != nil {
+		return x.LastProbe
+	}
+	return ""
+}
+
+func (x *DistroLatency) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+type Bandwidth struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distros       []*DistroBandwidth     `protobuf:"bytes,1,rep,name=distros,proto3" json:"distros,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bandwidth) Reset() {
+	*x = Bandwidth{}
+	mi := &file_agentapi_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bandwidth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bandwidth) ProtoMessage() {}
+
+func (x *Bandwidth) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bandwidth.ProtoReflect.Descriptor instead.
+func (*Bandwidth) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Bandwidth) GetDistros() []*DistroBandwidth {
+	if x != nil {
+		return x.Distros
+	}
+	return nil
+}
+
+type DistroBandwidth struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distro        string                 `protobuf:"bytes,1,opt,name=distro,proto3" json:"distro,omitempty"`
+	Transfers     []*TransferUsage       `protobuf:"bytes,2,rep,name=transfers,proto3" json:"transfers,omitempty"`                                 // One per kind of data transferred: files, debs and logs.
+	TodayBytes    uint64                 `protobuf:"varint,3,opt,name=today_bytes,json=todayBytes,proto3" json:"today_bytes,omitempty"`            // Bytes transferred since midnight, local time, all kinds together.
+	DailyCapBytes uint64                 `protobuf:"varint,4,opt,name=daily_cap_bytes,json=dailyCapBytes,proto3" json:"daily_cap_bytes,omitempty"` // Bytes the distro may transfer per day. Zero means no cap.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DistroBandwidth) Reset() {
+	*x = DistroBandwidth{}
+	mi := &file_agentapi_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroBandwidth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroBandwidth) ProtoMessage() {}
+
+func (x *DistroBandwidth) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroBandwidth.ProtoReflect.Descriptor instead.
+func (*DistroBandwidth) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *DistroBandwidth) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *DistroBandwidth) GetTransfers() []*TransferUsage {
+	if x != nil {
+		return x.Transfers
+	}
+	return nil
+}
+
+func (x *DistroBandwidth) GetTodayBytes() uint64 {
+	if x != nil {
+		return x.TodayBytes
+	}
+	return 0
+}
+
+func (x *DistroBandwidth) GetDailyCapBytes() uint64 {
+	if x != nil {
+		return x.DailyCapBytes
+	}
+	return 0
+}
+
+type TransferUsage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`                                // One of files, debs or logs.
+	TodayBytes    uint64                 `protobuf:"varint,2,opt,name=today_bytes,json=todayBytes,proto3" json:"today_bytes,omitempty"` // Bytes transferred since midnight, local time.
+	TotalBytes    uint64                 `protobuf:"varint,3,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"` // Bytes transferred since the agent started.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferUsage) Reset() {
+	*x = TransferUsage{}
+	mi := &file_agentapi_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferUsage) ProtoMessage() {}
+
+func (x *TransferUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferUsage.ProtoReflect.Descriptor instead.
+func (*TransferUsage) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *TransferUsage) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *TransferUsage) GetTodayBytes() uint64 {
+	if x != nil {
+		return x.TodayBytes
+	}
+	return 0
+}
+
+func (x *TransferUsage) GetTotalBytes() uint64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+type ComplianceReport struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Total           int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`                                            // Number of distros known to the agent.
+	FullyPatched    int32                  `protobuf:"varint,2,opt,name=fully_patched,json=fullyPatched,proto3" json:"fully_patched,omitempty"`          // Distros with no pending security updates.
+	PendingSecurity int32                  `protobuf:"varint,3,opt,name=pending_security,json=pendingSecurity,proto3" json:"pending_security,omitempty"` // Distros with pending standard security updates.
+	PendingEsm      int32                  `protobuf:"varint,4,opt,name=pending_esm,json=pendingEsm,proto3" json:"pending_esm,omitempty"`                // Distros with pending ESM updates.
+	Unknown         int32                  `protobuf:"varint,5,opt,name=unknown,proto3" json:"unknown,omitempty"`                                        // Distros that never reported their security status.
+	Distros         []*DistroCompliance    `protobuf:"bytes,6,rep,name=distros,proto3" json:"distros,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ComplianceReport) Reset() {
+	*x = ComplianceReport{}
+	mi := &file_agentapi_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComplianceReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComplianceReport) ProtoMessage() {}
+
+func (x *ComplianceReport) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComplianceReport.ProtoReflect.Descriptor instead.
+func (*ComplianceReport) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ComplianceReport) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ComplianceReport) GetFullyPatched() int32 {
+	if x != nil {
+		return x.FullyPatched
+	}
+	return 0
+}
+
+func (x *ComplianceReport) GetPendingSecurity() int32 {
+	if x != nil {
+		return x.PendingSecurity
+	}
+	return 0
+}
+
+func (x *ComplianceReport) GetPendingEsm() int32 {
+	if x != nil {
+		return x.PendingEsm
+	}
+	return 0
+}
+
+func (x *ComplianceReport) GetUnknown() int32 {
+	if x != nil {
+		return x.Unknown
+	}
+	return 0
+}
+
+func (x *ComplianceReport) GetDistros() []*DistroCompliance {
+	if x != nil {
+		return x.Distros
+	}
+	return nil
+}
+
+type DistroCompliance struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distro        string                 `protobuf:"bytes,1,opt,name=distro,proto3" json:"distro,omitempty"`
+	Status        *SecurityStatus        `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // Unset if the distro never reported its security status.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DistroCompliance) Reset() {
+	*x = DistroCompliance{}
+	mi := &file_agentapi_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroCompliance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroCompliance) ProtoMessage() {}
+
+func (x *DistroCompliance) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroCompliance.ProtoReflect.Descriptor instead.
+func (*DistroCompliance) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *DistroCompliance) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *DistroCompliance) GetStatus() *SecurityStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type Summary struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Subscription   *SubscriptionInfo      `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	Distros        int32                  `protobuf:"varint,2,opt,name=distros,proto3" json:"distros,omitempty"`                                     // Number of distros known to the agent.
+	Connected      int32                  `protobuf:"varint,3,opt,name=connected,proto3" json:"connected,omitempty"`                                 // Distros whose WSL Pro service is connected to the agent.
+	PendingUpdates int32                  `protobuf:"varint,4,opt,name=pending_updates,json=pendingUpdates,proto3" json:"pending_updates,omitempty"` // Distros with pending security updates, standard or ESM.
+	Errors         int32                  `protobuf:"varint,5,opt,name=errors,proto3" json:"errors,omitempty"`                                       // Distros in a degraded state, e.g. because their last task failed.
+	Wsl            *WslInfo               `protobuf:"bytes,6,opt,name=wsl,proto3" json:"wsl,omitempty"`                                              // The WSL installed on the host.
+	Contracts      *ContractsHealth       `protobuf:"bytes,7,opt,name=contracts,proto3" json:"contracts,omitempty"`                                  // Health of the synchronisation of the subscription with the contract server.
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Summary) Reset() {
+	*x = Summary{}
+	mi := &file_agentapi_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Summary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Summary) ProtoMessage() {}
+
+func (x *Summary) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Summary.ProtoReflect.Descriptor instead.
+func (*Summary) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *Summary) GetSubscription() *SubscriptionInfo {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+func (x *Summary) GetDistros() int32 {
+	if x != nil {
+		return x.Distros
+	}
+	return 0
+}
+
+func (x *Summary) GetConnected() int32 {
+	if x != nil {
+		return x.Connected
+	}
+	return 0
+}
+
+func (x *Summary) GetPendingUpdates() int32 {
+	if x != nil {
+		return x.PendingUpdates
+	}
+	return 0
+}
+
+func (x *Summary) GetErrors() int32 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *Summary) GetWsl() *WslInfo {
+	if x != nil {
+		return x.Wsl
+	}
+	return nil
+}
+
+func (x *Summary) GetContracts() *ContractsHealth {
+	if x != nil {
+		return x.Contracts
+	}
+	return nil
+}
+
+// ContractsHealth tells whether the subscription is synchronised with the contract server. Times are RFC3339,
+// and empty if it never happened.
+type ContractsHealth struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	LastContact         string                 `protobuf:"bytes,1,opt,name=last_contact,json=lastContact,proto3" json:"last_contact,omitempty"`                           // Last successful interaction with the contract server.
+	LastTokenRefresh    string                 `protobuf:"bytes,2,opt,name=last_token_refresh,json=lastTokenRefresh,proto3" json:"last_token_refresh,omitempty"`          // Last Pro token obtained for the Microsoft Store subscription.
+	LastEntitlementSync string                 `protobuf:"bytes,3,opt,name=last_entitlement_sync,json=lastEntitlementSync,proto3" json:"last_entitlement_sync,omitempty"` // Last time the entitlements of the subscription were fetched.
+	LastError           string                 `protobuf:"bytes,4,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`                                 // Error of the last interaction that failed since the last successful one, if any.
+	LastErrorTime       string                 `protobuf:"bytes,5,opt,name=last_error_time,json=lastErrorTime,proto3" json:"last_error_time,omitempty"`                   // Time of that failed interaction.
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ContractsHealth) Reset() {
+	*x = ContractsHealth{}
+	mi := &file_agentapi_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContractsHealth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContractsHealth) ProtoMessage() {}
+
+func (x *ContractsHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContractsHealth.ProtoReflect.Descriptor instead.
+func (*ContractsHealth) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ContractsHealth) GetLastContact() string {
+	if x != nil {
+		return x.LastContact
+	}
+	return ""
+}
+
+func (x *ContractsHealth) GetLastTokenRefresh() string {
+	if x != nil {
+		return x.LastTokenRefresh
+	}
+	return ""
+}
+
+func (x *ContractsHealth) GetLastEntitlementSync() string {
+	if x != nil {
+		return x.LastEntitlementSync
+	}
+	return ""
+}
+
+func (x *ContractsHealth) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *ContractsHealth) GetLastErrorTime() string {
+	if x != nil {
+		return x.LastErrorTime
+	}
+	return ""
+}
+
+// Inventory of the distros of the machine, for asset management tools.
+type Inventory struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*InventoryRecord     `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Inventory) Reset() {
+	*x = Inventory{}
+	mi := &file_agentapi_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Inventory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Inventory) ProtoMessage() {}
+
+func (x *Inventory) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Inventory.ProtoReflect.Descriptor instead.
+func (*Inventory) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *Inventory) GetRecords() []*InventoryRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type InventoryRecord struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Machine           string                 `protobuf:"bytes,1,opt,name=machine,proto3" json:"machine,omitempty"`   // Hostname of the Windows machine.
+	Distro            string                 `protobuf:"bytes,2,opt,name=distro,proto3" json:"distro,omitempty"`     // Name of the distro in WSL.
+	Hostname          string                 `protobuf:"bytes,3,opt,name=hostname,proto3" json:"hostname,omitempty"` // Hostname of the distro.
+	DistroId          string                 `protobuf:"bytes,4,opt,name=distro_id,json=distroId,proto3" json:"distro_id,omitempty"`
+	UbuntuVersion     string                 `protobuf:"bytes,5,opt,name=ubuntu_version,json=ubuntuVersion,proto3" json:"ubuntu_version,omitempty"`
+	PrettyName        string                 `protobuf:"bytes,6,opt,name=pretty_name,json=prettyName,proto3" json:"pretty_name,omitempty"`
+	ProAttached       bool                   `protobuf:"varint,7,opt,name=pro_attached,json=proAttached,proto3" json:"pro_attached,omitempty"`
+	ProServices       []string               `protobuf:"bytes,8,rep,name=pro_services,json=proServices,proto3" json:"pro_services,omitempty"`
+	ProExpires        string                 `protobuf:"bytes,9,opt,name=pro_expires,json=proExpires,proto3" json:"pro_expires,omitempty"`
+	LastSeen          string                 `protobuf:"bytes,10,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`          // RFC3339 time the WSL Pro service of the distro was last heard from. Empty if never.
+	LandscapeId       string                 `protobuf:"bytes,11,opt,name=landscape_id,json=landscapeId,proto3" json:"landscape_id,omitempty"` // UID Landscape assigned to the machine. Empty if not registered.
+	LandscapeManaged  bool                   `protobuf:"varint,12,opt,name=landscape_managed,json=landscapeManaged,proto3" json:"landscape_managed,omitempty"`
+	PendingTasks      int32                  `protobuf:"varint,13,opt,name=pending_tasks,json=pendingTasks,proto3" json:"pending_tasks,omitempty"`               // Tasks queued for the distro, including the deferred ones.
+	Running           bool                   `protobuf:"varint,14,opt,name=running,proto3" json:"running,omitempty"`                                             // Whether the distro is running in WSL, as last observed by the agent.
+	UnsupportedReason string                 `protobuf:"bytes,15,opt,name=unsupported_reason,json=unsupportedReason,proto3" json:"unsupported_reason,omitempty"` // Why the Ubuntu Pro client cannot run in the distro, so that it is not attached. Empty if it can.
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *InventoryRecord) Reset() {
+	*x = InventoryRecord{}
+	mi := &file_agentapi_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryRecord) ProtoMessage() {}
+
+func (x *InventoryRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryRecord.ProtoReflect.Descriptor instead.
+func (*InventoryRecord) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *InventoryRecord) GetMachine() string {
+	if x != nil {
+		return x.Machine
+	}
+	return ""
+}
+
+func (x *InventoryRecord) GetDistro() string {
+	if x != nil {
+		return x.Distro
+	}
+	return ""
+}
+
+func (x *InventoryRecord) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *InventoryRecord) GetDistroId() string {
+	if x != nil {
+		return x.DistroId
+	}
+	return ""
+}
+
+func (x *InventoryRecord) GetUbuntuVersion() string {
+	if x != nil {
+		return x.UbuntuVersion
+	}
+	return ""
+}
+
+func (x *InventoryRecord) GetPrettyName() string {
+	if x != nil {
+		return x.PrettyName
+	}
+	return ""
+}
+
+func (x *InventoryRecord) GetProAttached() bool {
+	if x != nil {
+		return x.ProAttached
+	}
+	return false
+}
+
+func (x *InventoryRecord) GetProServices() []string {
+	if x != nil {
+		return x.ProServices
+	}
+	return nil
+}
+
+func (x *InventoryRecord) GetProExpires() string {
+	if x != nil {
+		return x.ProExpires
+	}
+	return ""
+}
+
+func (x *InventoryRecord) GetLastSeen() string {
+	if x != nil {
+		return x.LastSeen
+	}
+	return ""
+}
+
+func (x *InventoryRecord) GetLandscapeId() string {
+	if x != nil {
+		return x.LandscapeId
+	}
+	return ""
+}
+
+func (x *InventoryRecord) GetLandscapeManaged() bool {
+	if x != nil {
+		return x.LandscapeManaged
+	}
+	return false
+}
+
+func (x *InventoryRecord) GetPendingTasks() int32 {
+	if x != nil {
+		return x.PendingTasks
+	}
+	return 0
+}
+
+func (x *InventoryRecord) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *InventoryRecord) GetUnsupportedReason() string {
+	if x != nil {
+		return x.UnsupportedReason
+	}
+	return ""
+}
+
+type ProvisionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DistroName    string                 `protobuf:"bytes,1,opt,name=distro_name,json=distroName,proto3" json:"distro_name,omitempty"` // The name of the new distro. The names of the distros of the Microsoft Store are reserved.
+	RootfsUrl     string                 `protobuf:"bytes,2,opt,name=rootfs_url,json=rootfsUrl,proto3" json:"rootfs_url,omitempty"`    // The URL of the image, with a SHA256SUMS file next to it to verify it against.
+	InstallDir    string                 `protobuf:"bytes,3,opt,name=install_dir,json=installDir,proto3" json:"install_dir,omitempty"` // The directory to keep the virtual disk of the distro in. Defaults to %USERPROFILE%\WSL\<distro_name>.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProvisionRequest) Reset() {
+	*x = ProvisionRequest{}
+	mi := &file_agentapi_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProvisionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProvisionRequest) ProtoMessage() {}
+
+func (x *ProvisionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProvisionRequest.ProtoReflect.Descriptor instead.
+func (*ProvisionRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ProvisionRequest) GetDistroName() string {
+	if x != nil {
+		return x.DistroName
+	}
+	return ""
+}
+
+func (x *ProvisionRequest) GetRootfsUrl() string {
+	if x != nil {
+		return x.RootfsUrl
+	}
+	return ""
+}
+
+func (x *ProvisionRequest) GetInstallDir() string {
+	if x != nil {
+		return x.InstallDir
+	}
+	return ""
+}
+
+// ProvisionProgress is streamed as the distro is provisioned. The stream ends once the stage is done, or with the
+// error that stopped the provisioning.
+type ProvisionProgress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stage         ProvisionStage         `protobuf:"varint,1,opt,name=stage,proto3,enum=agentapi.ProvisionStage" json:"stage,omitempty"`
+	Done          int64                  `protobuf:"varint,2,opt,name=done,proto3" json:"done,omitempty"`   // Bytes of the image downloaded so far, only set for PROVISION_STAGE_DOWNLOADING.
+	Total         int64                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"` // Size of the image, only set for PROVISION_STAGE_DOWNLOADING. Zero if unknown.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProvisionProgress) Reset() {
+	*x = ProvisionProgress{}
+	mi := &file_agentapi_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProvisionProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProvisionProgress) ProtoMessage() {}
+
+func (x *ProvisionProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProvisionProgress.ProtoReflect.Descriptor instead.
+func (*ProvisionProgress) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ProvisionProgress) GetStage() ProvisionStage {
+	if x != nil {
+		return x.Stage
+	}
+	return ProvisionStage_PROVISION_STAGE_UNSPECIFIED
+}
+
+func (x *ProvisionProgress) GetDone() int64 {
+	if x != nil {
+		return x.Done
+	}
+	return 0
+}
+
+func (x *ProvisionProgress) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type WslInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`                                             // Version of the WSL package. Empty if unknown, e.g. with the inbox WSL.
+	KernelVersion string                 `protobuf:"bytes,2,opt,name=kernel_version,json=kernelVersion,proto3" json:"kernel_version,omitempty"`            // Version of the WSL kernel. Empty if unknown.
+	Channel       string                 `protobuf:"bytes,3,opt,name=channel,proto3" json:"channel,omitempty"`                                             // Release channel of WSL: Store or Inbox. Empty if unknown.
+	Degraded      []string               `protobuf:"bytes,4,rep,name=degraded,proto3" json:"degraded,omitempty"`                                           // Features unavailable with this WSL and how the agent copes without them.
+	Operations    []DistroOperation      `protobuf:"varint,5,rep,packed,name=operations,proto3,enum=agentapi.DistroOperation" json:"operations,omitempty"` // Operations of ManageDistroRequest this WSL supports.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WslInfo) Reset() {
+	*x = WslInfo{}
+	mi := &file_agentapi_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WslInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WslInfo) ProtoMessage() {}
+
+func (x *WslInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WslInfo.ProtoReflect.Descriptor instead.
+func (*WslInfo) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *WslInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *WslInfo) GetKernelVersion() string {
+	if x != nil {
+		return x.KernelVersion
+	}
+	return ""
+}
+
+func (x *WslInfo) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *WslInfo) GetDegraded() []string {
+	if x != nil {
+		return x.Degraded
+	}
+	return nil
+}
+
+func (x *WslInfo) GetOperations() []DistroOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+// WslConfig holds the settings of %USERPROFILE%\.wslconfig relevant to Pro workloads. They are shared by all the
+// distros, and only apply once WSL restarts, e.g. after `wsl --shutdown`.
+type WslConfig struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Memory         string                 `protobuf:"bytes,1,opt,name=memory,proto3" json:"memory,omitempty"`                                       // Memory the WSL VM can use, e.g. 8GB. Empty for the default: half of the memory of the host.
+	NetworkingMode string                 `protobuf:"bytes,2,opt,name=networking_mode,json=networkingMode,proto3" json:"networking_mode,omitempty"` // NAT, mirrored, virtioproxy, bridged or none. Empty for the default: NAT.
+	VmIdleTimeout  int64                  `protobuf:"varint,3,opt,name=vm_idle_timeout,json=vmIdleTimeout,proto3" json:"vm_idle_timeout,omitempty"` // Milliseconds the WSL VM stays up once idle. -1 keeps it up, zero for the default: one minute.
+	Problems       []string               `protobuf:"bytes,4,rep,name=problems,proto3" json:"problems,omitempty"`                                   // Why the settings are invalid or fall short of what Pro workloads need. Ignored when setting them.
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WslConfig) Reset() {
+	*x = WslConfig{}
+	mi := &file_agentapi_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WslConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WslConfig) ProtoMessage() {}
+
+func (x *WslConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WslConfig.ProtoReflect.Descriptor instead.
+func (*WslConfig) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *WslConfig) GetMemory() string {
+	if x != nil {
+		return x.Memory
+	}
+	return ""
+}
+
+func (x *WslConfig) GetNetworkingMode() string {
+	if x != nil {
+		return x.NetworkingMode
+	}
+	return ""
+}
+
+func (x *WslConfig) GetVmIdleTimeout() int64 {
+	if x != nil {
+		return x.VmIdleTimeout
+	}
+	return 0
+}
+
+func (x *WslConfig) GetProblems() []string {
+	if x != nil {
+		return x.Problems
+	}
+	return nil
+}
+
+type SubscriptionInfo struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProductId string                 `protobuf:"bytes,1,opt,name=productId,proto3" json:"productId,omitempty"` // The ID of the Ubuntu Pro for WSL product on the Microsoft Store.
+	// Types that are valid to be assigned to SubscriptionType:
+	//
+	//	*SubscriptionInfo_None
+	//	*SubscriptionInfo_User
+	//	*SubscriptionInfo_Organization
+	//	*SubscriptionInfo_MicrosoftStore
+	SubscriptionType isSubscriptionInfo_SubscriptionType `protobuf_oneof:"subscriptionType"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SubscriptionInfo) Reset() {
+	*x = SubscriptionInfo{}
+	mi := &file_agentapi_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscriptionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionInfo) ProtoMessage() {}
+
+func (x *SubscriptionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionInfo.ProtoReflect.Descriptor instead.
+func (*SubscriptionInfo) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *SubscriptionInfo) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *SubscriptionInfo) GetSubscriptionType() isSubscriptionInfo_SubscriptionType {
+	if x != nil {
+		return x.SubscriptionType
+	}
+	return nil
+}
+
+func (x *SubscriptionInfo) GetNone() *Empty {
+	if x != nil {
+		if x, ok := x.SubscriptionType.(*SubscriptionInfo_None); ok {
+			return x.None
+		}
+	}
+	return nil
+}
+
+func (x *SubscriptionInfo) GetUser() *Empty {
+	if x != nil {
+		if x, ok := x.SubscriptionType.(*SubscriptionInfo_User); ok {
+			return x.User
+		}
+	}
+	return nil
+}
+
+func (x *SubscriptionInfo) GetOrganization() *Empty {
+	if x != nil {
+		if x, ok := x.SubscriptionType.(*SubscriptionInfo_Organization); ok {
+			return x.Organization
+		}
+	}
+	return nil
+}
+
+func (x *SubscriptionInfo) GetMicrosoftStore() *Empty {
+	if x != nil {
+		if x, ok := x.SubscriptionType.(*SubscriptionInfo_MicrosoftStore); ok {
+			return x.MicrosoftStore
+		}
+	}
+	return nil
+}
+
+type isSubscriptionInfo_SubscriptionType interface {
+	isSubscriptionInfo_SubscriptionType()
+}
+
+type SubscriptionInfo_None struct {
+	None *Empty `protobuf:"bytes,2,opt,name=none,proto3,oneof"` // There is no active subscription.
+}
+
+type SubscriptionInfo_User struct {
+	User *Empty `protobuf:"bytes,3,opt,name=user,proto3,oneof"` // The subscription is managed by the user with a pro token from the GUI or the registry.
+}
+
+type SubscriptionInfo_Organization struct {
+	Organization *Empty `protobuf:"bytes,4,opt,name=organization,proto3,oneof"` // The subscription is managed by the sysadmin with a pro token from the registry.
+}
+
+type SubscriptionInfo_MicrosoftStore struct {
+	MicrosoftStore *Empty `protobuf:"bytes,5,opt,name=microsoftStore,proto3,oneof"` // The subscription is managed via the Microsoft store.
+}
+
+func (*SubscriptionInfo_None) isSubscriptionInfo_SubscriptionType() {}
+
+func (*SubscriptionInfo_User) isSubscriptionInfo_SubscriptionType() {}
+
+func (*SubscriptionInfo_Organization) isSubscriptionInfo_SubscriptionType() {}
+
+func (*SubscriptionInfo_MicrosoftStore) isSubscriptionInfo_SubscriptionType() {}
+
+// SubscriptionDetails describe the contract of the subscription, as the contract server reports it. When it
+// cannot be reached, they are gathered from the distros attached to the subscription, as reported by their
+// Ubuntu Pro client, so they are empty until a distro is attached.
+type SubscriptionDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entitlements  []*Entitlement         `protobuf:"bytes,1,rep,name=entitlements,proto3" json:"entitlements,omitempty"`                     // Empty if unknown.
+	SupportLevel  string                 `protobuf:"bytes,2,opt,name=support_level,json=supportLevel,proto3" json:"support_level,omitempty"` // Empty if the contract does not include support.
+	Expires       string                 `protobuf:"bytes,3,opt,name=expires,proto3" json:"expires,omitempty"`                               // RFC3339 date of expiry of the contract. Empty if no distro is attached.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscriptionDetails) Reset() {
+	*x = SubscriptionDetails{}
+	mi := &file_agentapi_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscriptionDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionDetails) ProtoMessage() {}
+
+func (x *SubscriptionDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionDetails.ProtoReflect.Descriptor instead.
+func (*SubscriptionDetails) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *SubscriptionDetails) GetEntitlements() []*Entitlement {
+	if x != nil {
+		return x.Entitlements
+	}
+	return nil
+}
+
+func (x *SubscriptionDetails) GetSupportLevel() string {
+	if x != nil {
+		return x.SupportLevel
+	}
+	return ""
+}
+
+func (x *SubscriptionDetails) GetExpires() string {
+	if x != nil {
+		return x.Expires
+	}
+	return ""
+}
+
+type Entitlement struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The name of the service, e.g. esm-infra.
+	Entitled      bool                   `protobuf:"varint,2,opt,name=entitled,proto3" json:"entitled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Entitlement) Reset() {
+	*x = Entitlement{}
+	mi := &file_agentapi_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Entitlement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entitlement) ProtoMessage() {}
+
+func (x *Entitlement) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entitlement.ProtoReflect.Descriptor instead.
+func (*Entitlement) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *Entitlement) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Entitlement) GetEntitled() bool {
+	if x != nil {
+		return x.Entitled
+	}
+	return false
+}
+
+type LandscapeSource struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to LandscapeSourceType:
+	//
+	//	*LandscapeSource_None
+	//	*LandscapeSource_User
+	//	*LandscapeSource_Organization
+	LandscapeSourceType isLandscapeSource_LandscapeSourceType `protobuf_oneof:"landscapeSourceType"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *LandscapeSource) Reset() {
+	*x = LandscapeSource{}
+	mi := &file_agentapi_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LandscapeSource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LandscapeSource) ProtoMessage() {}
+
+func (x *LandscapeSource) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LandscapeSource.ProtoReflect.Descriptor instead.
+func (*LandscapeSource) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *LandscapeSource) GetLandscapeSourceType() isLandscapeSource_LandscapeSourceType {
+	if x != nil {
+		return x.LandscapeSourceType
+	}
+	return nil
+}
+
+func (x *LandscapeSource) GetNone() *Empty {
+	if x != nil {
+		if x, ok := x.LandscapeSourceType.(*LandscapeSource_None); ok {
+			return x.None
+		}
+	}
+	return nil
+}
+
+func (x *LandscapeSource) GetUser() *Empty {
+	if x != nil {
+		if x, ok := x.LandscapeSourceType.(*LandscapeSource_User); ok {
+			return x.User
+		}
+	}
+	return nil
+}
+
+func (x *LandscapeSource) GetOrganization() *Empty {
+	if x != nil {
+		if x, ok := x.LandscapeSourceType.(*LandscapeSource_Organization); ok {
+			return x.Organization
+		}
+	}
+	return nil
+}
+
+type isLandscapeSource_LandscapeSourceType interface {
+	isLandscapeSource_LandscapeSourceType()
+}
+
+type LandscapeSource_None struct {
+	None *Empty `protobuf:"bytes,1,opt,name=none,proto3,oneof"` // There is no active Landscape config data.
+}
+
+type LandscapeSource_User struct {
+	User *Empty `protobuf:"bytes,2,opt,name=user,proto3,oneof"` // The Landscape config is managed by the user, set via the GUI.
+}
+
+type LandscapeSource_Organization struct {
+	Organization *Empty `protobuf:"bytes,3,opt,name=organization,proto3,oneof"` // The Landscape config is managedby the sysadmin, set via the registry.
+}
+
+func (*LandscapeSource_None) isLandscapeSource_LandscapeSourceType() {}
+
+func (*LandscapeSource_User) isLandscapeSource_LandscapeSourceType() {}
+
+func (*LandscapeSource_Organization) isLandscapeSource_LandscapeSourceType() {}
+
+type ConfigSources struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ProSubscription *SubscriptionInfo      `protobuf:"bytes,1,opt,name=proSubscription,proto3" json:"proSubscription,omitempty"`
+	LandscapeSource *LandscapeSource       `protobuf:"bytes,2,opt,name=landscapeSource,proto3" json:"landscapeSource,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ConfigSources) Reset() {
+	*x = ConfigSources{}
+	mi := &file_agentapi_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigSources) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigSources) ProtoMessage() {}
+
+func (x *ConfigSources) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigSources.ProtoReflect.Descriptor instead.
+func (*ConfigSources) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ConfigSources) GetProSubscription() *SubscriptionInfo {
+	if x != nil {
+		return x.ProSubscription
+	}
+	return nil
+}
+
+func (x *ConfigSources) GetLandscapeSource() *LandscapeSource {
+	if x != nil {
+		return x.LandscapeSource
+	}
+	return nil
+}
+
+type DistroMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Data:
+	//
+	//	*DistroMessage_Handshake
+	//	*DistroMessage_Info
+	Data          isDistroMessage_Data `protobuf_oneof:"data"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DistroMessage) Reset() {
+	*x = DistroMessage{}
+	mi := &file_agentapi_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroMessage) ProtoMessage() {}
+
+func (x *DistroMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroMessage.ProtoReflect.Descriptor instead.
+func (*DistroMessage) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *DistroMessage) GetData() isDistroMessage_Data {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DistroMessage) GetHandshake() *Handshake {
+	if x != nil {
+		if x, ok := x.Data.(*DistroMessage_Handshake); ok {
+			return x.Handshake
+		}
+	}
+	return nil
+}
+
+func (x *DistroMessage) GetInfo() *DistroInfo {
+	if x != nil {
+		if x, ok := x.Data.(*DistroMessage_Info); ok {
+			return x.Info
+		}
+	}
+	return nil
+}
+
+type isDistroMessage_Data interface {
+	isDistroMessage_Data()
+}
+
+type DistroMessage_Handshake struct {
+	Handshake *Handshake `protobuf:"bytes,1,opt,name=handshake,proto3,oneof"` // Must be the first message of the stream, and only that one.
+}
+
+type DistroMessage_Info struct {
+	Info *DistroInfo `protobuf:"bytes,2,opt,name=info,proto3,oneof"`
+}
+
+func (*DistroMessage_Handshake) isDistroMessage_Data() {}
+
+func (*DistroMessage_Info) isDistroMessage_Data() {}
+
+type Handshake struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ProtocolVersion uint32                 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	WslName         string                 `protobuf:"bytes,2,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Capabilities    []Capability           `protobuf:"varint,3,rep,packed,name=capabilities,proto3,enum=agentapi.Capability" json:"capabilities,omitempty"` // Features the WSL Pro service supports.
+	ServiceVersion  string                 `protobuf:"bytes,4,opt,name=service_version,json=serviceVersion,proto3" json:"service_version,omitempty"`        // The version of the wsl-pro-service package. Empty for versions that predate this field.
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Handshake) Reset() {
+	*x = Handshake{}
+	mi := &file_agentapi_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Handshake) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Handshake) ProtoMessage() {}
+
+func (x *Handshake) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Handshake.ProtoReflect.Descriptor instead.
+func (*Handshake) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *Handshake) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *Handshake) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *Handshake) GetCapabilities() []Capability {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *Handshake) GetServiceVersion() string {
+	if x != nil {
+		return x.ServiceVersion
+	}
+	return ""
+}
+
+type HandshakeAck struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ProtocolVersion    uint32                 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	Capabilities       []Capability           `protobuf:"varint,2,rep,packed,name=capabilities,proto3,enum=agentapi.Capability" json:"capabilities,omitempty"`           // Features supported by both ends, which may be used.
+	Settings           *DistroSettings        `protobuf:"bytes,3,opt,name=settings,proto3" json:"settings,omitempty"`                                                    // Only set in the acknowledgements of DistroInfo messages.
+	AgentTimeUnixMilli int64                  `protobuf:"varint,4,opt,name=agent_time_unix_milli,json=agentTimeUnixMilli,proto3" json:"agent_time_unix_milli,omitempty"` // Wall clock of the agent when sending it, to detect clock skew. Zero for agents that predate this field.
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *HandshakeAck) Reset() {
+	*x = HandshakeAck{}
+	mi := &file_agentapi_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HandshakeAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HandshakeAck) ProtoMessage() {}
+
+func (x *HandshakeAck) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HandshakeAck.ProtoReflect.Descriptor instead.
+func (*HandshakeAck) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *HandshakeAck) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *HandshakeAck) GetCapabilities() []Capability {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *HandshakeAck) GetSettings() *DistroSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+func (x *HandshakeAck) GetAgentTimeUnixMilli() int64 {
+	if x != nil {
+		return x.AgentTimeUnixMilli
+	}
+	return 0
+}
+
+// DistroSettings are the settings the agent propagates to a distro in answer to its DistroInfo updates.
+type DistroSettings struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	ConfigHash             string                 `protobuf:"bytes,1,opt,name=config_hash,json=configHash,proto3" json:"config_hash,omitempty"`                                        // Changes every time the configuration the agent wants the distro to have changes. Empty if unknown.
+	PendingTasks           int32                  `protobuf:"varint,2,opt,name=pending_tasks,json=pendingTasks,proto3" json:"pending_tasks,omitempty"`                                 // Tasks queued for the distro, including the deferred ones.
+	RefreshIntervalSeconds uint32                 `protobuf:"varint,3,opt,name=refresh_interval_seconds,json=refreshIntervalSeconds,proto3" json:"refresh_interval_seconds,omitempty"` // How often the agent wants a new DistroInfo. Zero means only when something changes.
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *DistroSettings) Reset() {
+	*x = DistroSettings{}
+	mi := &file_agentapi_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroSettings) ProtoMessage() {}
+
+func (x *DistroSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroSettings.ProtoReflect.Descriptor instead.
+func (*DistroSettings) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *DistroSettings) GetConfigHash() string {
+	if x != nil {
+		return x.ConfigHash
+	}
+	return ""
+}
+
+func (x *DistroSettings) GetPendingTasks() int32 {
+	if x != nil {
+		return x.PendingTasks
+	}
+	return 0
+}
+
+func (x *DistroSettings) GetRefreshIntervalSeconds() uint32 {
+	if x != nil {
+		return x.RefreshIntervalSeconds
+	}
+	return 0
+}
+
+type DistroInfo struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	WslName           string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Id                string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	VersionId         string                 `protobuf:"bytes,3,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
+	PrettyName        string                 `protobuf:"bytes,4,opt,name=pretty_name,json=prettyName,proto3" json:"pretty_name,omitempty"`
+	ProAttached       bool                   `protobuf:"varint,5,opt,name=pro_attached,json=proAttached,proto3" json:"pro_attached,omitempty"`
+	Hostname          string                 `protobuf:"bytes,6,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	ProServices       []string               `protobuf:"bytes,7,rep,name=pro_services,json=proServices,proto3" json:"pro_services,omitempty"`                    // Ubuntu Pro services the distro is entitled to.
+	SecurityStatus    *SecurityStatus        `protobuf:"bytes,8,opt,name=security_status,json=securityStatus,proto3" json:"security_status,omitempty"`           // Unset if the security status could not be obtained.
+	ProExpires        string                 `protobuf:"bytes,9,opt,name=pro_expires,json=proExpires,proto3" json:"pro_expires,omitempty"`                       // RFC3339 date of expiry of the contract the distro is attached to. Empty if not attached.
+	ProSupportLevel   string                 `protobuf:"bytes,10,opt,name=pro_support_level,json=proSupportLevel,proto3" json:"pro_support_level,omitempty"`     // Support level of that contract. Empty if not attached or without support.
+	UnsupportedReason string                 `protobuf:"bytes,11,opt,name=unsupported_reason,json=unsupportedReason,proto3" json:"unsupported_reason,omitempty"` // Why the Ubuntu Pro client cannot run in the distro, e.g. on minimal images. Empty if it can.
+	WslVersion        int32                  `protobuf:"varint,12,opt,name=wsl_version,json=wslVersion,proto3" json:"wsl_version,omitempty"`                     // Version of WSL the distro runs on: 1 or 2. Zero if it could not be detected.
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *DistroInfo) Reset() {
+	*x = DistroInfo{}
+	mi := &file_agentapi_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroInfo) ProtoMessage() {}
+
+func (x *DistroInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroInfo.ProtoReflect.Descriptor instead.
+func (*DistroInfo) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *DistroInfo) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetPrettyName() string {
+	if x != nil {
+		return x.PrettyName
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetProAttached() bool {
+	if x != nil {
+		return x.ProAttached
+	}
+	return false
+}
+
+func (x *DistroInfo) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetProServices() []string {
+	if x != nil {
+		return x.ProServices
+	}
+	return nil
+}
+
+func (x *DistroInfo) GetSecurityStatus() *SecurityStatus {
+	if x != nil {
+		return x.SecurityStatus
+	}
+	return nil
+}
+
+func (x *DistroInfo) GetProExpires() string {
+	if x != nil {
+		return x.ProExpires
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetProSupportLevel() string {
+	if x != nil {
+		return x.ProSupportLevel
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetUnsupportedReason() string {
+	if x != nil {
+		return x.UnsupportedReason
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetWslVersion() int32 {
+	if x != nil {
+		return x.WslVersion
+	}
+	return 0
+}
+
+type SecurityStatus struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	StandardUpdates int32                  `protobuf:"varint,1,opt,name=standard_updates,json=standardUpdates,proto3" json:"standard_updates,omitempty"` // Pending security updates from the Ubuntu archive.
+	EsmUpdates      int32                  `protobuf:"varint,2,opt,name=esm_updates,json=esmUpdates,proto3" json:"esm_updates,omitempty"`                // Pending security updates from ESM Infra and ESM Apps.
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SecurityStatus) Reset() {
+	*x = SecurityStatus{}
+	mi := &file_agentapi_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SecurityStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SecurityStatus) ProtoMessage() {}
+
+func (x *SecurityStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SecurityStatus.ProtoReflect.Descriptor instead.
+func (*SecurityStatus) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *SecurityStatus) GetStandardUpdates() int32 {
+	if x != nil {
+		return x.StandardUpdates
+	}
+	return 0
+}
+
+func (x *SecurityStatus) GetEsmUpdates() int32 {
+	if x != nil {
+		return x.EsmUpdates
+	}
+	return 0
+}
+
+type ProAttachCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProAttachCmd) Reset() {
+	*x = ProAttachCmd{}
+	mi := &file_agentapi_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProAttachCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProAttachCmd) ProtoMessage() {}
+
+func (x *ProAttachCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProAttachCmd.ProtoReflect.Descriptor instead.
+func (*ProAttachCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ProAttachCmd) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type LandscapeConfigCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Config        string                 `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LandscapeConfigCmd) Reset() {
+	*x = LandscapeConfigCmd{}
+	mi := &file_agentapi_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LandscapeConfigCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LandscapeConfigCmd) ProtoMessage() {}
+
+func (x *LandscapeConfigCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LandscapeConfigCmd.ProtoReflect.Descriptor instead.
+func (*LandscapeConfigCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *LandscapeConfigCmd) GetConfig() string {
+	if x != nil {
+		return x.Config
+	}
+	return ""
+}
+
+type Command struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Cmd:
+	//
+	//	*Command_ProService
+	//	*Command_Usg
+	//	*Command_ServiceUpgrade
+	//	*Command_Preempt
+	//	*Command_ManageUser
+	//	*Command_Patching
+	//	*Command_Proxy
+	//	*Command_ProStatus
+	//	*Command_Snapd
+	//	*Command_Dns
+	Cmd           isCommand_Cmd `protobuf_oneof:"cmd"`
+	Id            uint32        `protobuf:"varint,10,opt,name=id,proto3" json:"id,omitempty"` // Identifies the command, so that preemptions only stop the command they target.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Command) Reset() {
+	*x = Command{}
+	mi := &file_agentapi_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Command) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Command) ProtoMessage() {}
+
+func (x *Command) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Command.ProtoReflect.Descriptor instead.
+func (*Command) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *Command) GetCmd() isCommand_Cmd {
+	if x != nil {
+		return x.Cmd
+	}
+	return nil
+}
+
+func (x *Command) GetProService() *ProServiceCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_ProService); ok {
+			return x.ProService
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetUsg() *UsgCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_Usg); ok {
+			return x.Usg
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetServiceUpgrade() *ServiceUpgradeCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_ServiceUpgrade); ok {
+			return x.ServiceUpgrade
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetPreempt() *PreemptCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_Preempt); ok {
+			return x.Preempt
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetManageUser() *ManageUserCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_ManageUser); ok {
+			return x.ManageUser
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetPatching() *PatchingCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_Patching); ok {
+			return x.Patching
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetProxy() *ProxyCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_Proxy); ok {
+			return x.Proxy
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetProStatus() *ProStatusCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_ProStatus); ok {
+			return x.ProStatus
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetSnapd() *SnapdCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_Snapd); ok {
+			return x.Snapd
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetDns() *DnsCmd {
+	if x != nil {
+		if x, ok := x.Cmd.(*Command_Dns); ok {
+			return x.Dns
+		}
+	}
+	return nil
+}
+
+func (x *Command) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type isCommand_Cmd interface {
+	isCommand_Cmd()
+}
+
+type Command_ProService struct {
+	ProService *ProServiceCmd `protobuf:"bytes,1,opt,name=pro_service,json=proService,proto3,oneof"` // Enable or disable an Ubuntu Pro service.
+}
+
+type Command_Usg struct {
+	Usg *UsgCmd `protobuf:"bytes,2,opt,name=usg,proto3,oneof"` // Audit (and optionally fix) a USG profile.
+}
+
+type Command_ServiceUpgrade struct {
+	ServiceUpgrade *ServiceUpgradeCmd `protobuf:"bytes,3,opt,name=service_upgrade,json=serviceUpgrade,proto3,oneof"` // Install or upgrade wsl-pro-service from a channel.
+}
+
+type Command_Preempt struct {
+	Preempt *PreemptCmd `protobuf:"bytes,6,opt,name=preempt,proto3,oneof"` // Stop the command with its id at its next safe point. It gets no reply of its own.
+}
+
+type Command_ManageUser struct {
+	ManageUser *ManageUserCmd `protobuf:"bytes,7,opt,name=manage_user,json=manageUser,proto3,oneof"` // Create a user if needed, add it to groups and optionally make it the default user.
+}
+
+type Command_Patching struct {
+	Patching *PatchingCmd `protobuf:"bytes,8,opt,name=patching,proto3,oneof"` // Configure which pockets unattended-upgrades installs updates from.
+}
+
+type Command_Proxy struct {
+	Proxy *ProxyCmd `protobuf:"bytes,9,opt,name=proxy,proto3,oneof"` // Configure the proxy of apt, login sessions and systemd services. No proxies stop managing it.
+}
+
+type Command_ProStatus struct {
+	ProStatus *ProStatusCmd `protobuf:"bytes,11,opt,name=pro_status,json=proStatus,proto3,oneof"` // Check that the distro is attached to Ubuntu Pro, or detached from it.
+}
+
+type Command_Snapd struct {
+	Snapd *SnapdCmd `protobuf:"bytes,12,opt,name=snapd,proto3,oneof"` // Configure the proxy and the store proxy of snapd. Empty settings stop managing them.
+}
+
+type Command_Dns struct {
+	Dns *DnsCmd `protobuf:"bytes,13,opt,name=dns,proto3,oneof"` // Configure the nameservers, search domains and host entries. Empty settings stop managing them.
+}
+
+func (*Command_ProService) isCommand_Cmd() {}
+
+func (*Command_Usg) isCommand_Cmd() {}
+
+func (*Command_ServiceUpgrade) isCommand_Cmd() {}
+
+func (*Command_Preempt) isCommand_Cmd() {}
+
+func (*Command_ManageUser) isCommand_Cmd() {}
+
+func (*Command_Patching) isCommand_Cmd() {}
+
+func (*Command_Proxy) isCommand_Cmd() {}
+
+func (*Command_ProStatus) isCommand_Cmd() {}
+
+func (*Command_Snapd) isCommand_Cmd() {}
+
+func (*Command_Dns) isCommand_Cmd() {}
+
+type ProServiceCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Service       string                 `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Enable        bool                   `protobuf:"varint,2,opt,name=enable,proto3" json:"enable,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProServiceCmd) Reset() {
+	*x = ProServiceCmd{}
+	mi := &file_agentapi_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProServiceCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProServiceCmd) ProtoMessage() {}
+
+func (x *ProServiceCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProServiceCmd.ProtoReflect.Descriptor instead.
+func (*ProServiceCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *ProServiceCmd) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *ProServiceCmd) GetEnable() bool {
+	if x != nil {
+		return x.Enable
+	}
+	return false
+}
+
+type UsgCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Profile       string                 `protobuf:"bytes,1,opt,name=profile,proto3" json:"profile,omitempty"`
+	Fix           bool                   `protobuf:"varint,2,opt,name=fix,proto3" json:"fix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsgCmd) Reset() {
+	*x = UsgCmd{}
+	mi := &file_agentapi_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsgCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsgCmd) ProtoMessage() {}
+
+func (x *UsgCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsgCmd.ProtoReflect.Descriptor instead.
+func (*UsgCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *UsgCmd) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *UsgCmd) GetFix() bool {
+	if x != nil {
+		return x.Fix
+	}
+	return false
+}
+
+type ServiceUpgradeCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`   // One of stable, beta or local.
+	Source        string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`     // The PPA for the beta channel, or the Windows path to the deb for the local channel (signed in <source>.sig).
+	Checksum      string                 `protobuf:"bytes,3,opt,name=checksum,proto3" json:"checksum,omitempty"` // The SHA-256 checksum of the deb for the local channel.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LandscapeSource) GetLandscapeSourceType() isLandscapeSource_LandscapeSourceType {
+func (x *ServiceUpgradeCmd) Reset() {
+	*x = ServiceUpgradeCmd{}
+	mi := &file_agentapi_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceUpgradeCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceUpgradeCmd) ProtoMessage() {}
+
+func (x *ServiceUpgradeCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[52]
 	if x != nil {
-		return x.LandscapeSourceType
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceUpgradeCmd.ProtoReflect.Descriptor instead.
+func (*ServiceUpgradeCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *ServiceUpgradeCmd) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *ServiceUpgradeCmd) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ServiceUpgradeCmd) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+type TailLogCmd struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Lines            int32                  `protobuf:"varint,1,opt,name=lines,proto3" json:"lines,omitempty"`
+	Priority         string                 `protobuf:"bytes,2,opt,name=priority,proto3" json:"priority,omitempty"`
+	IncludeProClient bool                   `protobuf:"varint,3,opt,name=include_pro_client,json=includeProClient,proto3" json:"include_pro_client,omitempty"`
+	Id               uint32                 `protobuf:"varint,4,opt,name=id,proto3" json:"id,omitempty"`         // Identifies the request in the LogMessages that answer it.
+	Follow           bool                   `protobuf:"varint,5,opt,name=follow,proto3" json:"follow,omitempty"` // Keep streaming new lines until the request is cancelled.
+	Cancel           bool                   `protobuf:"varint,6,opt,name=cancel,proto3" json:"cancel,omitempty"` // Stop streaming the lines of the request with this id. Other fields are ignored.
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *TailLogCmd) Reset() {
+	*x = TailLogCmd{}
+	mi := &file_agentapi_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TailLogCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailLogCmd) ProtoMessage() {}
+
+func (x *TailLogCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailLogCmd.ProtoReflect.Descriptor instead.
+func (*TailLogCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *TailLogCmd) GetLines() int32 {
+	if x != nil {
+		return x.Lines
+	}
+	return 0
+}
+
+func (x *TailLogCmd) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+func (x *TailLogCmd) GetIncludeProClient() bool {
+	if x != nil {
+		return x.IncludeProClient
+	}
+	return false
+}
+
+func (x *TailLogCmd) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TailLogCmd) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+func (x *TailLogCmd) GetCancel() bool {
+	if x != nil {
+		return x.Cancel
+	}
+	return false
+}
+
+type LogMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"` // Used during handshake to identify the WSL instance.
+	Id            uint32                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`                         // The id of the TailLogCmd being answered.
+	Line          string                 `protobuf:"bytes,3,opt,name=line,proto3" json:"line,omitempty"`
+	Done          bool                   `protobuf:"varint,4,opt,name=done,proto3" json:"done,omitempty"`  // No more lines will be sent for this request.
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"` // Why the request stopped before completion, if it did. Only set along with done.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogMessage) Reset() {
+	*x = LogMessage{}
+	mi := &file_agentapi_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogMessage) ProtoMessage() {}
+
+func (x *LogMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogMessage.ProtoReflect.Descriptor instead.
+func (*LogMessage) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *LogMessage) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *LogMessage) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *LogMessage) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+func (x *LogMessage) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *LogMessage) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type PingCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payload       []byte                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	Id            uint32                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"` // Identifies the ping in the PingReply that answers it.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingCmd) Reset() {
+	*x = PingCmd{}
+	mi := &file_agentapi_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingCmd) ProtoMessage() {}
+
+func (x *PingCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingCmd.ProtoReflect.Descriptor instead.
+func (*PingCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *PingCmd) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *PingCmd) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type PingReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"` // Used during handshake to identify the WSL instance.
+	Id            uint32                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`                         // The id of the PingCmd being answered.
+	Payload       []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`                // The payload of the PingCmd, unchanged.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingReply) Reset() {
+	*x = PingReply{}
+	mi := &file_agentapi_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingReply) ProtoMessage() {}
+
+func (x *PingReply) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingReply.ProtoReflect.Descriptor instead.
+func (*PingReply) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *PingReply) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *PingReply) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PingReply) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
 	}
 	return nil
 }
 
-func (x *LandscapeSource) GetNone() *Empty {
+type PreemptCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"` // The id of the command to preempt. Preemptions of commands no longer in progress are ignored.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreemptCmd) Reset() {
+	*x = PreemptCmd{}
+	mi := &file_agentapi_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreemptCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreemptCmd) ProtoMessage() {}
+
+func (x *PreemptCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreemptCmd.ProtoReflect.Descriptor instead.
+func (*PreemptCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *PreemptCmd) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ManageUserCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Groups        []string               `protobuf:"bytes,2,rep,name=groups,proto3" json:"groups,omitempty"`
+	SetDefault    bool                   `protobuf:"varint,3,opt,name=set_default,json=setDefault,proto3" json:"set_default,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ManageUserCmd) Reset() {
+	*x = ManageUserCmd{}
+	mi := &file_agentapi_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManageUserCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManageUserCmd) ProtoMessage() {}
+
+func (x *ManageUserCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[58]
 	if x != nil {
-		if x, ok := x.LandscapeSourceType.(*LandscapeSource_None); ok {
-			return x.None
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *LandscapeSource) GetUser() *Empty {
+// Deprecated: Use ManageUserCmd.ProtoReflect.Descriptor instead.
+func (*ManageUserCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *ManageUserCmd) GetName() string {
 	if x != nil {
-		if x, ok := x.LandscapeSourceType.(*LandscapeSource_User); ok {
-			return x.User
-		}
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-func (x *LandscapeSource) GetOrganization() *Empty {
+func (x *ManageUserCmd) GetGroups() []string {
 	if x != nil {
-		if x, ok := x.LandscapeSourceType.(*LandscapeSource_Organization); ok {
-			return x.Organization
-		}
+		return x.Groups
 	}
 	return nil
 }
 
-type isLandscapeSource_LandscapeSourceType interface {
-	isLandscapeSource_LandscapeSourceType()
-}
-
-type LandscapeSource_None struct {
-	None *Empty `protobuf:"bytes,1,opt,name=none,proto3,oneof"` // There is no active Landscape config data.
-}
-
-type LandscapeSource_User struct {
-	User *Empty `protobuf:"bytes,2,opt,name=user,proto3,oneof"` // The Landscape config is managed by the user, set via the GUI.
-}
-
-type LandscapeSource_Organization struct {
-	Organization *Empty `protobuf:"bytes,3,opt,name=organization,proto3,oneof"` // The Landscape config is managedby the sysadmin, set via the registry.
+func (x *ManageUserCmd) GetSetDefault() bool {
+	if x != nil {
+		return x.SetDefault
+	}
+	return false
 }
 
-func (*LandscapeSource_None) isLandscapeSource_LandscapeSourceType() {}
-
-func (*LandscapeSource_User) isLandscapeSource_LandscapeSourceType() {}
-
-func (*LandscapeSource_Organization) isLandscapeSource_LandscapeSourceType() {}
-
-type ConfigSources struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	ProSubscription *SubscriptionInfo      `protobuf:"bytes,1,opt,name=proSubscription,proto3" json:"proSubscription,omitempty"`
-	LandscapeSource *LandscapeSource       `protobuf:"bytes,2,opt,name=landscapeSource,proto3" json:"landscapeSource,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type PatchingCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         string                 `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"` // One of security-only, security+updates or all. Empty stops managing unattended-upgrades.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConfigSources) Reset() {
-	*x = ConfigSources{}
-	mi := &file_agentapi_proto_msgTypes[5]
+func (x *PatchingCmd) Reset() {
+	*x = PatchingCmd{}
+	mi := &file_agentapi_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConfigSources) String() string {
+func (x *PatchingCmd) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConfigSources) ProtoMessage() {}
+func (*PatchingCmd) ProtoMessage() {}
 
-func (x *ConfigSources) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[5]
+func (x *PatchingCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -398,52 +4431,43 @@ func (x *ConfigSources) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConfigSources.ProtoReflect.Descriptor instead.
-func (*ConfigSources) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{5}
-}
-
-func (x *ConfigSources) GetProSubscription() *SubscriptionInfo {
-	if x != nil {
-		return x.ProSubscription
-	}
-	return nil
+// Deprecated: Use PatchingCmd.ProtoReflect.Descriptor instead.
+func (*PatchingCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{59}
 }
 
-func (x *ConfigSources) GetLandscapeSource() *LandscapeSource {
+func (x *PatchingCmd) GetLevel() string {
 	if x != nil {
-		return x.LandscapeSource
+		return x.Level
 	}
-	return nil
+	return ""
 }
 
-type DistroInfo struct {
+type SnapdCmd struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
-	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
-	VersionId     string                 `protobuf:"bytes,3,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
-	PrettyName    string                 `protobuf:"bytes,4,opt,name=pretty_name,json=prettyName,proto3" json:"pretty_name,omitempty"`
-	ProAttached   bool                   `protobuf:"varint,5,opt,name=pro_attached,json=proAttached,proto3" json:"pro_attached,omitempty"`
-	Hostname      string                 `protobuf:"bytes,6,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Http          string                 `protobuf:"bytes,1,opt,name=http,proto3" json:"http,omitempty"`                               // The proxy snapd sends HTTP requests through.
+	Https         string                 `protobuf:"bytes,2,opt,name=https,proto3" json:"https,omitempty"`                             // The proxy snapd sends HTTPS requests through.
+	StoreProxy    string                 `protobuf:"bytes,3,opt,name=store_proxy,json=storeProxy,proto3" json:"store_proxy,omitempty"` // The URL of the enterprise snap store proxy, which the store assertions are fetched from.
+	StoreId       string                 `protobuf:"bytes,4,opt,name=store_id,json=storeId,proto3" json:"store_id,omitempty"`          // The ID of the store of the enterprise snap store proxy.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DistroInfo) Reset() {
-	*x = DistroInfo{}
-	mi := &file_agentapi_proto_msgTypes[6]
+func (x *SnapdCmd) Reset() {
+	*x = SnapdCmd{}
+	mi := &file_agentapi_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DistroInfo) String() string {
+func (x *SnapdCmd) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DistroInfo) ProtoMessage() {}
+func (*SnapdCmd) ProtoMessage() {}
 
-func (x *DistroInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[6]
+func (x *SnapdCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -454,75 +4478,107 @@ func (x *DistroInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DistroInfo.ProtoReflect.Descriptor instead.
-func (*DistroInfo) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use SnapdCmd.ProtoReflect.Descriptor instead.
+func (*SnapdCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{60}
 }
 
-func (x *DistroInfo) GetWslName() string {
+func (x *SnapdCmd) GetHttp() string {
 	if x != nil {
-		return x.WslName
+		return x.Http
 	}
 	return ""
 }
 
-func (x *DistroInfo) GetId() string {
+func (x *SnapdCmd) GetHttps() string {
 	if x != nil {
-		return x.Id
+		return x.Https
 	}
 	return ""
 }
 
-func (x *DistroInfo) GetVersionId() string {
+func (x *SnapdCmd) GetStoreProxy() string {
 	if x != nil {
-		return x.VersionId
+		return x.StoreProxy
 	}
 	return ""
 }
 
-func (x *DistroInfo) GetPrettyName() string {
+func (x *SnapdCmd) GetStoreId() string {
 	if x != nil {
-		return x.PrettyName
+		return x.StoreId
 	}
 	return ""
 }
 
-func (x *DistroInfo) GetProAttached() bool {
+type ProStatusCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attached      bool                   `protobuf:"varint,1,opt,name=attached,proto3" json:"attached,omitempty"` // Whether the distro is expected to be attached. The command fails otherwise.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProStatusCmd) Reset() {
+	*x = ProStatusCmd{}
+	mi := &file_agentapi_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProStatusCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProStatusCmd) ProtoMessage() {}
+
+func (x *ProStatusCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[61]
 	if x != nil {
-		return x.ProAttached
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *DistroInfo) GetHostname() string {
+// Deprecated: Use ProStatusCmd.ProtoReflect.Descriptor instead.
+func (*ProStatusCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *ProStatusCmd) GetAttached() bool {
 	if x != nil {
-		return x.Hostname
+		return x.Attached
 	}
-	return ""
+	return false
 }
 
-type ProAttachCmd struct {
+type ProxyCmd struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Http          string                 `protobuf:"bytes,1,opt,name=http,proto3" json:"http,omitempty"`                      // The proxy for HTTP requests, e.g. http://proxy.example.com:3128.
+	Https         string                 `protobuf:"bytes,2,opt,name=https,proto3" json:"https,omitempty"`                    // The proxy for HTTPS requests.
+	NoProxy       string                 `protobuf:"bytes,3,opt,name=no_proxy,json=noProxy,proto3" json:"no_proxy,omitempty"` // Comma-separated list of hosts and domains reached without proxy.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProAttachCmd) Reset() {
-	*x = ProAttachCmd{}
-	mi := &file_agentapi_proto_msgTypes[7]
+func (x *ProxyCmd) Reset() {
+	*x = ProxyCmd{}
+	mi := &file_agentapi_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProAttachCmd) String() string {
+func (x *ProxyCmd) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProAttachCmd) ProtoMessage() {}
+func (*ProxyCmd) ProtoMessage() {}
 
-func (x *ProAttachCmd) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[7]
+func (x *ProxyCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -533,40 +4589,56 @@ func (x *ProAttachCmd) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProAttachCmd.ProtoReflect.Descriptor instead.
-func (*ProAttachCmd) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use ProxyCmd.ProtoReflect.Descriptor instead.
+func (*ProxyCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{62}
 }
 
-func (x *ProAttachCmd) GetToken() string {
+func (x *ProxyCmd) GetHttp() string {
 	if x != nil {
-		return x.Token
+		return x.Http
 	}
 	return ""
 }
 
-type LandscapeConfigCmd struct {
+func (x *ProxyCmd) GetHttps() string {
+	if x != nil {
+		return x.Https
+	}
+	return ""
+}
+
+func (x *ProxyCmd) GetNoProxy() string {
+	if x != nil {
+		return x.NoProxy
+	}
+	return ""
+}
+
+type DnsCmd struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Config        string                 `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	Nameservers   []string               `protobuf:"bytes,1,rep,name=nameservers,proto3" json:"nameservers,omitempty"`                          // IP addresses of the nameservers, which replace the ones WSL generates in resolv.conf.
+	SearchDomains []string               `protobuf:"bytes,2,rep,name=search_domains,json=searchDomains,proto3" json:"search_domains,omitempty"` // Domains appended to the unqualified host names. Only used along with nameservers.
+	Hosts         []string               `protobuf:"bytes,3,rep,name=hosts,proto3" json:"hosts,omitempty"`                                      // Lines of /etc/hosts, each an IP address followed by one or more host names.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LandscapeConfigCmd) Reset() {
-	*x = LandscapeConfigCmd{}
-	mi := &file_agentapi_proto_msgTypes[8]
+func (x *DnsCmd) Reset() {
+	*x = DnsCmd{}
+	mi := &file_agentapi_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LandscapeConfigCmd) String() string {
+func (x *DnsCmd) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LandscapeConfigCmd) ProtoMessage() {}
+func (*DnsCmd) ProtoMessage() {}
 
-func (x *LandscapeConfigCmd) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[8]
+func (x *DnsCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -577,16 +4649,30 @@ func (x *LandscapeConfigCmd) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LandscapeConfigCmd.ProtoReflect.Descriptor instead.
-func (*LandscapeConfigCmd) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use DnsCmd.ProtoReflect.Descriptor instead.
+func (*DnsCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{63}
 }
 
-func (x *LandscapeConfigCmd) GetConfig() string {
+func (x *DnsCmd) GetNameservers() []string {
 	if x != nil {
-		return x.Config
+		return x.Nameservers
 	}
-	return ""
+	return nil
+}
+
+func (x *DnsCmd) GetSearchDomains() []string {
+	if x != nil {
+		return x.SearchDomains
+	}
+	return nil
+}
+
+func (x *DnsCmd) GetHosts() []string {
+	if x != nil {
+		return x.Hosts
+	}
+	return nil
 }
 
 type MSG struct {
@@ -595,14 +4681,18 @@ type MSG struct {
 	//
 	//	*MSG_WslName
 	//	*MSG_Result
+	//	*MSG_Progress
 	Data          isMSG_Data `protobuf_oneof:"data"`
+	Output        []byte     `protobuf:"bytes,3,opt,name=output,proto3" json:"output,omitempty"`        // Command-specific payload sent along with the result (e.g. a report).
+	Preempted     bool       `protobuf:"varint,4,opt,name=preempted,proto3" json:"preempted,omitempty"` // The command stopped at a safe point before completion, and can be sent again to resume it.
+	More          bool       `protobuf:"varint,5,opt,name=more,proto3" json:"more,omitempty"`           // The output continues in the next message. Only the last message of a result carries no more output.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *MSG) Reset() {
 	*x = MSG{}
-	mi := &file_agentapi_proto_msgTypes[9]
+	mi := &file_agentapi_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -614,7 +4704,7 @@ func (x *MSG) String() string {
 func (*MSG) ProtoMessage() {}
 
 func (x *MSG) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[9]
+	mi := &file_agentapi_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -627,7 +4717,7 @@ func (x *MSG) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MSG.ProtoReflect.Descriptor instead.
 func (*MSG) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{9}
+	return file_agentapi_proto_rawDescGZIP(), []int{64}
 }
 
 func (x *MSG) GetData() isMSG_Data {
@@ -655,6 +4745,36 @@ func (x *MSG) GetResult() string {
 	return ""
 }
 
+func (x *MSG) GetProgress() *TaskProgress {
+	if x != nil {
+		if x, ok := x.Data.(*MSG_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *MSG) GetOutput() []byte {
+	if x != nil {
+		return x.Output
+	}
+	return nil
+}
+
+func (x *MSG) GetPreempted() bool {
+	if x != nil {
+		return x.Preempted
+	}
+	return false
+}
+
+func (x *MSG) GetMore() bool {
+	if x != nil {
+		return x.More
+	}
+	return false
+}
+
 type isMSG_Data interface {
 	isMSG_Data()
 }
@@ -667,10 +4787,68 @@ type MSG_Result struct {
 	Result string `protobuf:"bytes,2,opt,name=result,proto3,oneof"` // Used in response to a command
 }
 
+type MSG_Progress struct {
+	Progress *TaskProgress `protobuf:"bytes,6,opt,name=progress,proto3,oneof"` // How far the command in progress is. Only sent if CAPABILITY_PROGRESS was negotiated, and followed by more messages answering the same command.
+}
+
 func (*MSG_WslName) isMSG_Data() {}
 
 func (*MSG_Result) isMSG_Data() {}
 
+func (*MSG_Progress) isMSG_Data() {}
+
+type TaskProgress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Percent       uint32                 `protobuf:"varint,1,opt,name=percent,proto3" json:"percent,omitempty"` // Percentage of completion of the command, from 0 to 100.
+	Step          string                 `protobuf:"bytes,2,opt,name=step,proto3" json:"step,omitempty"`        // What the command is doing, e.g. "Refreshing the package index".
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskProgress) Reset() {
+	*x = TaskProgress{}
+	mi := &file_agentapi_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskProgress) ProtoMessage() {}
+
+func (x *TaskProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskProgress.ProtoReflect.Descriptor instead.
+func (*TaskProgress) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *TaskProgress) GetPercent() uint32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *TaskProgress) GetStep() string {
+	if x != nil {
+		return x.Step
+	}
+	return ""
+}
+
 var File_agentapi_proto protoreflect.FileDescriptor
 
 const file_agentapi_proto_rawDesc = "" +
@@ -680,14 +4858,199 @@ const file_agentapi_proto_rawDesc = "" +
 	"\rProAttachInfo\x12\x14\n" +
 	"\x05token\x18\x01 \x01(\tR\x05token\")\n" +
 	"\x0fLandscapeConfig\x12\x16\n" +
-	"\x06config\x18\x01 \x01(\tR\x06config\"\x84\x02\n" +
+	"\x06config\x18\x01 \x01(\tR\x06config\"\\\n" +
+	"\x0eProServiceInfo\x12\x18\n" +
+	"\adistros\x18\x01 \x03(\tR\adistros\x12\x18\n" +
+	"\aservice\x18\x02 \x01(\tR\aservice\x12\x16\n" +
+	"\x06enable\x18\x03 \x01(\bR\x06enable\"V\n" +
+	"\x0eUsgProfileInfo\x12\x18\n" +
+	"\adistros\x18\x01 \x03(\tR\adistros\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\x12\x10\n" +
+	"\x03fix\x18\x03 \x01(\bR\x03fix\"w\n" +
+	"\x0eManageUserInfo\x12\x18\n" +
+	"\adistros\x18\x01 \x03(\tR\adistros\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06groups\x18\x03 \x03(\tR\x06groups\x12\x1f\n" +
+	"\vset_default\x18\x04 \x01(\bR\n" +
+	"setDefault\"\x96\x01\n" +
+	"\x13ManageDistroRequest\x12\x18\n" +
+	"\adistros\x18\x01 \x03(\tR\adistros\x12\x1f\n" +
+	"\n" +
+	"set_sparse\x18\x02 \x01(\bH\x00R\tsetSparse\x12\x14\n" +
+	"\x04move\x18\x03 \x01(\tH\x00R\x04move\x12!\n" +
+	"\vset_version\x18\x04 \x01(\x05H\x00R\n" +
+	"setVersionB\v\n" +
+	"\toperation\"3\n" +
+	"\x10GetEventsRequest\x12\x1f\n" +
+	"\vsince_token\x18\x01 \x01(\tR\n" +
+	"sinceToken\"s\n" +
+	"\x06Events\x12,\n" +
+	"\x06events\x18\x01 \x03(\v2\x14.agentapi.AgentEventR\x06events\x12\x1d\n" +
+	"\n" +
+	"next_token\x18\x02 \x01(\tR\tnextToken\x12\x1c\n" +
+	"\ttruncated\x18\x03 \x01(\bR\ttruncated\"\xae\x01\n" +
+	"\n" +
+	"AgentEvent\x12,\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x18.agentapi.AgentEventTypeR\x04type\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\tR\x04time\x12\x16\n" +
+	"\x06distro\x18\x03 \x01(\tR\x06distro\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12,\n" +
+	"\x06result\x18\x05 \x01(\v2\x14.agentapi.TaskResultR\x06result\"l\n" +
+	"\x0eConsentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06distro\x18\x02 \x01(\tR\x06distro\x12\x16\n" +
+	"\x06prompt\x18\x03 \x01(\tR\x06prompt\x12\x1a\n" +
+	"\bdeadline\x18\x04 \x01(\tR\bdeadline\"9\n" +
+	"\rConsentAnswer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\agranted\x18\x02 \x01(\bR\agranted\"D\n" +
+	"\x10UsgReportRequest\x12\x16\n" +
+	"\x06distro\x18\x01 \x01(\tR\x06distro\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\"s\n" +
+	"\tUsgReport\x12\x16\n" +
+	"\x06distro\x18\x01 \x01(\tR\x06distro\x12\x18\n" +
+	"\aprofile\x18\x02 \x01(\tR\aprofile\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\tR\ttimestamp\x12\x16\n" +
+	"\x06report\x18\x04 \x01(\fR\x06report\"\xa0\x01\n" +
+	"\x0eTailLogRequest\x12\x16\n" +
+	"\x06distro\x18\x01 \x01(\tR\x06distro\x12\x14\n" +
+	"\x05lines\x18\x02 \x01(\x05R\x05lines\x12\x1a\n" +
+	"\bpriority\x18\x03 \x01(\tR\bpriority\x12,\n" +
+	"\x12include_pro_client\x18\x04 \x01(\bR\x10includeProClient\x12\x16\n" +
+	"\x06follow\x18\x05 \x01(\bR\x06follow\"\x1d\n" +
+	"\aLogLine\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\tR\x04line\"+\n" +
+	"\x11WatchTasksRequest\x12\x16\n" +
+	"\x06distro\x18\x01 \x01(\tR\x06distro\"\xc2\x01\n" +
+	"\tTaskEvent\x12+\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x17.agentapi.TaskEventTypeR\x04type\x12\x12\n" +
+	"\x04task\x18\x02 \x01(\tR\x04task\x12\x1a\n" +
+	"\bprogress\x18\x03 \x01(\rR\bprogress\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12,\n" +
+	"\x06result\x18\x05 \x01(\v2\x14.agentapi.TaskResultR\x06result\x12\x12\n" +
+	"\x04step\x18\x06 \x01(\tR\x04step\"6\n" +
+	"\n" +
+	"TaskResult\x12(\n" +
+	"\x05steps\x18\x01 \x03(\v2\x12.agentapi.TaskStepR\x05steps\"j\n" +
+	"\bTaskStep\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x120\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x18.agentapi.TaskStepStatusR\x06status\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"4\n" +
+	"\x14NotificationSettings\x12\x1c\n" +
+	"\tfrequency\x18\x01 \x01(\tR\tfrequency\">\n" +
+	"\tLatencies\x121\n" +
+	"\adistros\x18\x01 \x03(\v2\x17.agentapi.DistroLatencyR\adistros\"\xfb\x01\n" +
+	"\rDistroLatency\x12\x16\n" +
+	"\x06distro\x18\x01 \x01(\tR\x06distro\x12\x17\n" +
+	"\alast_ms\x18\x02 \x01(\x01R\x06lastMs\x12\x15\n" +
+	"\x06min_ms\x18\x03 \x01(\x01R\x05minMs\x12\x15\n" +
+	"\x06max_ms\x18\x04 \x01(\x01R\x05maxMs\x12\x17\n" +
+	"\amean_ms\x18\x05 \x01(\x01R\x06meanMs\x12\x18\n" +
+	"\asamples\x18\x06 \x01(\x05R\asamples\x12\x1a\n" +
+	"\bfailures\x18\a \x01(\x05R\bfailures\x12\x1d\n" +
+	"\n" +
+	"last_probe\x18\b \x01(\tR\tlastProbe\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\t \x01(\tR\tlastError\"@\n" +
+	"\tBandwidth\x123\n" +
+	"\adistros\x18\x01 \x03(\v2\x19.agentapi.DistroBandwidthR\adistros\"\xa9\x01\n" +
+	"\x0fDistroBandwidth\x12\x16\n" +
+	"\x06distro\x18\x01 \x01(\tR\x06distro\x125\n" +
+	"\ttransfers\x18\x02 \x03(\v2\x17.agentapi.TransferUsageR\ttransfers\x12\x1f\n" +
+	"\vtoday_bytes\x18\x03 \x01(\x04R\n" +
+	"todayBytes\x12&\n" +
+	"\x0fdaily_cap_bytes\x18\x04 \x01(\x04R\rdailyCapBytes\"e\n" +
+	"\rTransferUsage\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x1f\n" +
+	"\vtoday_bytes\x18\x02 \x01(\x04R\n" +
+	"todayBytes\x12\x1f\n" +
+	"\vtotal_bytes\x18\x03 \x01(\x04R\n" +
+	"totalBytes\"\xe9\x01\n" +
+	"\x10ComplianceReport\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12#\n" +
+	"\rfully_patched\x18\x02 \x01(\x05R\ffullyPatched\x12)\n" +
+	"\x10pending_security\x18\x03 \x01(\x05R\x0fpendingSecurity\x12\x1f\n" +
+	"\vpending_esm\x18\x04 \x01(\x05R\n" +
+	"pendingEsm\x12\x18\n" +
+	"\aunknown\x18\x05 \x01(\x05R\aunknown\x124\n" +
+	"\adistros\x18\x06 \x03(\v2\x1a.agentapi.DistroComplianceR\adistros\"\\\n" +
+	"\x10DistroCompliance\x12\x16\n" +
+	"\x06distro\x18\x01 \x01(\tR\x06distro\x120\n" +
+	"\x06status\x18\x02 \x01(\v2\x18.agentapi.SecurityStatusR\x06status\"\xa0\x02\n" +
+	"\aSummary\x12>\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x1a.agentapi.SubscriptionInfoR\fsubscription\x12\x18\n" +
+	"\adistros\x18\x02 \x01(\x05R\adistros\x12\x1c\n" +
+	"\tconnected\x18\x03 \x01(\x05R\tconnected\x12'\n" +
+	"\x0fpending_updates\x18\x04 \x01(\x05R\x0ependingUpdates\x12\x16\n" +
+	"\x06errors\x18\x05 \x01(\x05R\x06errors\x12#\n" +
+	"\x03wsl\x18\x06 \x01(\v2\x11.agentapi.WslInfoR\x03wsl\x127\n" +
+	"\tcontracts\x18\a \x01(\v2\x19.agentapi.ContractsHealthR\tcontracts\"\xdd\x01\n" +
+	"\x0fContractsHealth\x12!\n" +
+	"\flast_contact\x18\x01 \x01(\tR\vlastContact\x12,\n" +
+	"\x12last_token_refresh\x18\x02 \x01(\tR\x10lastTokenRefresh\x122\n" +
+	"\x15last_entitlement_sync\x18\x03 \x01(\tR\x13lastEntitlementSync\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x04 \x01(\tR\tlastError\x12&\n" +
+	"\x0flast_error_time\x18\x05 \x01(\tR\rlastErrorTime\"@\n" +
+	"\tInventory\x123\n" +
+	"\arecords\x18\x01 \x03(\v2\x19.agentapi.InventoryRecordR\arecords\"\x86\x04\n" +
+	"\x0fInventoryRecord\x12\x18\n" +
+	"\amachine\x18\x01 \x01(\tR\amachine\x12\x16\n" +
+	"\x06distro\x18\x02 \x01(\tR\x06distro\x12\x1a\n" +
+	"\bhostname\x18\x03 \x01(\tR\bhostname\x12\x1b\n" +
+	"\tdistro_id\x18\x04 \x01(\tR\bdistroId\x12%\n" +
+	"\x0eubuntu_version\x18\x05 \x01(\tR\rubuntuVersion\x12\x1f\n" +
+	"\vpretty_name\x18\x06 \x01(\tR\n" +
+	"prettyName\x12!\n" +
+	"\fpro_attached\x18\a \x01(\bR\vproAttached\x12!\n" +
+	"\fpro_services\x18\b \x03(\tR\vproServices\x12\x1f\n" +
+	"\vpro_expires\x18\t \x01(\tR\n" +
+	"proExpires\x12\x1b\n" +
+	"\tlast_seen\x18\n" +
+	" \x01(\tR\blastSeen\x12!\n" +
+	"\flandscape_id\x18\v \x01(\tR\vlandscapeId\x12+\n" +
+	"\x11landscape_managed\x18\f \x01(\bR\x10landscapeManaged\x12#\n" +
+	"\rpending_tasks\x18\r \x01(\x05R\fpendingTasks\x12\x18\n" +
+	"\arunning\x18\x0e \x01(\bR\arunning\x12-\n" +
+	"\x12unsupported_reason\x18\x0f \x01(\tR\x11unsupportedReason\"s\n" +
+	"\x10ProvisionRequest\x12\x1f\n" +
+	"\vdistro_name\x18\x01 \x01(\tR\n" +
+	"distroName\x12\x1d\n" +
+	"\n" +
+	"rootfs_url\x18\x02 \x01(\tR\trootfsUrl\x12\x1f\n" +
+	"\vinstall_dir\x18\x03 \x01(\tR\n" +
+	"installDir\"m\n" +
+	"\x11ProvisionProgress\x12.\n" +
+	"\x05stage\x18\x01 \x01(\x0e2\x18.agentapi.ProvisionStageR\x05stage\x12\x12\n" +
+	"\x04done\x18\x02 \x01(\x03R\x04done\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x03R\x05total\"\xbb\x01\n" +
+	"\aWslInfo\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12%\n" +
+	"\x0ekernel_version\x18\x02 \x01(\tR\rkernelVersion\x12\x18\n" +
+	"\achannel\x18\x03 \x01(\tR\achannel\x12\x1a\n" +
+	"\bdegraded\x18\x04 \x03(\tR\bdegraded\x129\n" +
+	"\n" +
+	"operations\x18\x05 \x03(\x0e2\x19.agentapi.DistroOperationR\n" +
+	"operations\"\x90\x01\n" +
+	"\tWslConfig\x12\x16\n" +
+	"\x06memory\x18\x01 \x01(\tR\x06memory\x12'\n" +
+	"\x0fnetworking_mode\x18\x02 \x01(\tR\x0enetworkingMode\x12&\n" +
+	"\x0fvm_idle_timeout\x18\x03 \x01(\x03R\rvmIdleTimeout\x12\x1a\n" +
+	"\bproblems\x18\x04 \x03(\tR\bproblems\"\x84\x02\n" +
 	"\x10SubscriptionInfo\x12\x1c\n" +
 	"\tproductId\x18\x01 \x01(\tR\tproductId\x12%\n" +
 	"\x04none\x18\x02 \x01(\v2\x0f.agentapi.EmptyH\x00R\x04none\x12%\n" +
 	"\x04user\x18\x03 \x01(\v2\x0f.agentapi.EmptyH\x00R\x04user\x125\n" +
 	"\forganization\x18\x04 \x01(\v2\x0f.agentapi.EmptyH\x00R\forganization\x129\n" +
 	"\x0emicrosoftStore\x18\x05 \x01(\v2\x0f.agentapi.EmptyH\x00R\x0emicrosoftStoreB\x12\n" +
-	"\x10subscriptionType\"\xad\x01\n" +
+	"\x10subscriptionType\"\x8f\x01\n" +
+	"\x13SubscriptionDetails\x129\n" +
+	"\fentitlements\x18\x01 \x03(\v2\x15.agentapi.EntitlementR\fentitlements\x12#\n" +
+	"\rsupport_level\x18\x02 \x01(\tR\fsupportLevel\x12\x18\n" +
+	"\aexpires\x18\x03 \x01(\tR\aexpires\"=\n" +
+	"\vEntitlement\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bentitled\x18\x02 \x01(\bR\bentitled\"\xad\x01\n" +
 	"\x0fLandscapeSource\x12%\n" +
 	"\x04none\x18\x01 \x01(\v2\x0f.agentapi.EmptyH\x00R\x04none\x12%\n" +
 	"\x04user\x18\x02 \x01(\v2\x0f.agentapi.EmptyH\x00R\x04user\x125\n" +
@@ -695,7 +5058,26 @@ const file_agentapi_proto_rawDesc = "" +
 	"\x13landscapeSourceType\"\x9a\x01\n" +
 	"\rConfigSources\x12D\n" +
 	"\x0fproSubscription\x18\x01 \x01(\v2\x1a.agentapi.SubscriptionInfoR\x0fproSubscription\x12C\n" +
-	"\x0flandscapeSource\x18\x02 \x01(\v2\x19.agentapi.LandscapeSourceR\x0flandscapeSource\"\xb6\x01\n" +
+	"\x0flandscapeSource\x18\x02 \x01(\v2\x19.agentapi.LandscapeSourceR\x0flandscapeSource\"x\n" +
+	"\rDistroMessage\x123\n" +
+	"\thandshake\x18\x01 \x01(\v2\x13.agentapi.HandshakeH\x00R\thandshake\x12*\n" +
+	"\x04info\x18\x02 \x01(\v2\x14.agentapi.DistroInfoH\x00R\x04infoB\x06\n" +
+	"\x04data\"\xb4\x01\n" +
+	"\tHandshake\x12)\n" +
+	"\x10protocol_version\x18\x01 \x01(\rR\x0fprotocolVersion\x12\x19\n" +
+	"\bwsl_name\x18\x02 \x01(\tR\awslName\x128\n" +
+	"\fcapabilities\x18\x03 \x03(\x0e2\x14.agentapi.CapabilityR\fcapabilities\x12'\n" +
+	"\x0fservice_version\x18\x04 \x01(\tR\x0eserviceVersion\"\xdc\x01\n" +
+	"\fHandshakeAck\x12)\n" +
+	"\x10protocol_version\x18\x01 \x01(\rR\x0fprotocolVersion\x128\n" +
+	"\fcapabilities\x18\x02 \x03(\x0e2\x14.agentapi.CapabilityR\fcapabilities\x124\n" +
+	"\bsettings\x18\x03 \x01(\v2\x18.agentapi.DistroSettingsR\bsettings\x121\n" +
+	"\x15agent_time_unix_milli\x18\x04 \x01(\x03R\x12agentTimeUnixMilli\"\x90\x01\n" +
+	"\x0eDistroSettings\x12\x1f\n" +
+	"\vconfig_hash\x18\x01 \x01(\tR\n" +
+	"configHash\x12#\n" +
+	"\rpending_tasks\x18\x02 \x01(\x05R\fpendingTasks\x128\n" +
+	"\x18refresh_interval_seconds\x18\x03 \x01(\rR\x16refreshIntervalSeconds\"\xb9\x03\n" +
 	"\n" +
 	"DistroInfo\x12\x19\n" +
 	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x0e\n" +
@@ -705,25 +5087,191 @@ const file_agentapi_proto_rawDesc = "" +
 	"\vpretty_name\x18\x04 \x01(\tR\n" +
 	"prettyName\x12!\n" +
 	"\fpro_attached\x18\x05 \x01(\bR\vproAttached\x12\x1a\n" +
-	"\bhostname\x18\x06 \x01(\tR\bhostname\"$\n" +
+	"\bhostname\x18\x06 \x01(\tR\bhostname\x12!\n" +
+	"\fpro_services\x18\a \x03(\tR\vproServices\x12A\n" +
+	"\x0fsecurity_status\x18\b \x01(\v2\x18.agentapi.SecurityStatusR\x0esecurityStatus\x12\x1f\n" +
+	"\vpro_expires\x18\t \x01(\tR\n" +
+	"proExpires\x12*\n" +
+	"\x11pro_support_level\x18\n" +
+	" \x01(\tR\x0fproSupportLevel\x12-\n" +
+	"\x12unsupported_reason\x18\v \x01(\tR\x11unsupportedReason\x12\x1f\n" +
+	"\vwsl_version\x18\f \x01(\x05R\n" +
+	"wslVersion\"\\\n" +
+	"\x0eSecurityStatus\x12)\n" +
+	"\x10standard_updates\x18\x01 \x01(\x05R\x0fstandardUpdates\x12\x1f\n" +
+	"\vesm_updates\x18\x02 \x01(\x05R\n" +
+	"esmUpdates\"$\n" +
 	"\fProAttachCmd\x12\x14\n" +
 	"\x05token\x18\x01 \x01(\tR\x05token\",\n" +
 	"\x12LandscapeConfigCmd\x12\x16\n" +
-	"\x06config\x18\x01 \x01(\tR\x06config\"D\n" +
+	"\x06config\x18\x01 \x01(\tR\x06config\"\xb0\x04\n" +
+	"\aCommand\x12:\n" +
+	"\vpro_service\x18\x01 \x01(\v2\x17.agentapi.ProServiceCmdH\x00R\n" +
+	"proService\x12$\n" +
+	"\x03usg\x18\x02 \x01(\v2\x10.agentapi.UsgCmdH\x00R\x03usg\x12F\n" +
+	"\x0fservice_upgrade\x18\x03 \x01(\v2\x1b.agentapi.ServiceUpgradeCmdH\x00R\x0eserviceUpgrade\x120\n" +
+	"\apreempt\x18\x06 \x01(\v2\x14.agentapi.PreemptCmdH\x00R\apreempt\x12:\n" +
+	"\vmanage_user\x18\a \x01(\v2\x17.agentapi.ManageUserCmdH\x00R\n" +
+	"manageUser\x123\n" +
+	"\bpatching\x18\b \x01(\v2\x15.agentapi.PatchingCmdH\x00R\bpatching\x12*\n" +
+	"\x05proxy\x18\t \x01(\v2\x12.agentapi.ProxyCmdH\x00R\x05proxy\x127\n" +
+	"\n" +
+	"pro_status\x18\v \x01(\v2\x16.agentapi.ProStatusCmdH\x00R\tproStatus\x12*\n" +
+	"\x05snapd\x18\f \x01(\v2\x12.agentapi.SnapdCmdH\x00R\x05snapd\x12$\n" +
+	"\x03dns\x18\r \x01(\v2\x10.agentapi.DnsCmdH\x00R\x03dns\x12\x0e\n" +
+	"\x02id\x18\n" +
+	" \x01(\rR\x02idB\x05\n" +
+	"\x03cmdJ\x04\b\x04\x10\x05J\x04\b\x05\x10\x06\"A\n" +
+	"\rProServiceCmd\x12\x18\n" +
+	"\aservice\x18\x01 \x01(\tR\aservice\x12\x16\n" +
+	"\x06enable\x18\x02 \x01(\bR\x06enable\"4\n" +
+	"\x06UsgCmd\x12\x18\n" +
+	"\aprofile\x18\x01 \x01(\tR\aprofile\x12\x10\n" +
+	"\x03fix\x18\x02 \x01(\bR\x03fix\"a\n" +
+	"\x11ServiceUpgradeCmd\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x1a\n" +
+	"\bchecksum\x18\x03 \x01(\tR\bchecksum\"\xac\x01\n" +
+	"\n" +
+	"TailLogCmd\x12\x14\n" +
+	"\x05lines\x18\x01 \x01(\x05R\x05lines\x12\x1a\n" +
+	"\bpriority\x18\x02 \x01(\tR\bpriority\x12,\n" +
+	"\x12include_pro_client\x18\x03 \x01(\bR\x10includeProClient\x12\x0e\n" +
+	"\x02id\x18\x04 \x01(\rR\x02id\x12\x16\n" +
+	"\x06follow\x18\x05 \x01(\bR\x06follow\x12\x16\n" +
+	"\x06cancel\x18\x06 \x01(\bR\x06cancel\"u\n" +
+	"\n" +
+	"LogMessage\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\rR\x02id\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\tR\x04line\x12\x12\n" +
+	"\x04done\x18\x04 \x01(\bR\x04done\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"3\n" +
+	"\aPingCmd\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\fR\apayload\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\rR\x02id\"P\n" +
+	"\tPingReply\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\rR\x02id\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\"\x1c\n" +
+	"\n" +
+	"PreemptCmd\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\"\\\n" +
+	"\rManageUserCmd\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06groups\x18\x02 \x03(\tR\x06groups\x12\x1f\n" +
+	"\vset_default\x18\x03 \x01(\bR\n" +
+	"setDefault\"#\n" +
+	"\vPatchingCmd\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\"p\n" +
+	"\bSnapdCmd\x12\x12\n" +
+	"\x04http\x18\x01 \x01(\tR\x04http\x12\x14\n" +
+	"\x05https\x18\x02 \x01(\tR\x05https\x12\x1f\n" +
+	"\vstore_proxy\x18\x03 \x01(\tR\n" +
+	"storeProxy\x12\x19\n" +
+	"\bstore_id\x18\x04 \x01(\tR\astoreId\"*\n" +
+	"\fProStatusCmd\x12\x1a\n" +
+	"\battached\x18\x01 \x01(\bR\battached\"O\n" +
+	"\bProxyCmd\x12\x12\n" +
+	"\x04http\x18\x01 \x01(\tR\x04http\x12\x14\n" +
+	"\x05https\x18\x02 \x01(\tR\x05https\x12\x19\n" +
+	"\bno_proxy\x18\x03 \x01(\tR\anoProxy\"g\n" +
+	"\x06DnsCmd\x12 \n" +
+	"\vnameservers\x18\x01 \x03(\tR\vnameservers\x12%\n" +
+	"\x0esearch_domains\x18\x02 \x03(\tR\rsearchDomains\x12\x14\n" +
+	"\x05hosts\x18\x03 \x03(\tR\x05hosts\"\xc4\x01\n" +
 	"\x03MSG\x12\x1b\n" +
 	"\bwsl_name\x18\x01 \x01(\tH\x00R\awslName\x12\x18\n" +
-	"\x06result\x18\x02 \x01(\tH\x00R\x06resultB\x06\n" +
-	"\x04data2\xc9\x02\n" +
+	"\x06result\x18\x02 \x01(\tH\x00R\x06result\x124\n" +
+	"\bprogress\x18\x06 \x01(\v2\x16.agentapi.TaskProgressH\x00R\bprogress\x12\x16\n" +
+	"\x06output\x18\x03 \x01(\fR\x06output\x12\x1c\n" +
+	"\tpreempted\x18\x04 \x01(\bR\tpreempted\x12\x12\n" +
+	"\x04more\x18\x05 \x01(\bR\x04moreB\x06\n" +
+	"\x04data\"<\n" +
+	"\fTaskProgress\x12\x18\n" +
+	"\apercent\x18\x01 \x01(\rR\apercent\x12\x12\n" +
+	"\x04step\x18\x02 \x01(\tR\x04step*\xee\x01\n" +
+	"\x0eAgentEventType\x12\x1b\n" +
+	"\x17AGENT_EVENT_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18AGENT_EVENT_DISTRO_ADDED\x10\x01\x12\x1e\n" +
+	"\x1aAGENT_EVENT_DISTRO_REMOVED\x10\x02\x12$\n" +
+	" AGENT_EVENT_DISTRO_STAGE_CHANGED\x10\x03\x12\x1b\n" +
+	"\x17AGENT_EVENT_TASK_FAILED\x10\x04\x12\x1e\n" +
+	"\x1aAGENT_EVENT_DISTRO_RENAMED\x10\x05\x12\x1e\n" +
+	"\x1aAGENT_EVENT_TASK_COMPLETED\x10\x06*\xc0\x01\n" +
+	"\rTaskEventType\x12\x1a\n" +
+	"\x16TASK_EVENT_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11TASK_EVENT_QUEUED\x10\x01\x12\x16\n" +
+	"\x12TASK_EVENT_STARTED\x10\x02\x12\x17\n" +
+	"\x13TASK_EVENT_PROGRESS\x10\x03\x12\x18\n" +
+	"\x14TASK_EVENT_COMPLETED\x10\x04\x12\x15\n" +
+	"\x11TASK_EVENT_FAILED\x10\x05\x12\x1a\n" +
+	"\x16TASK_EVENT_INTERRUPTED\x10\x06*q\n" +
+	"\x0eTaskStepStatus\x12\x19\n" +
+	"\x15TASK_STEP_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13TASK_STEP_SUCCEEDED\x10\x01\x12\x14\n" +
+	"\x10TASK_STEP_FAILED\x10\x02\x12\x15\n" +
+	"\x11TASK_STEP_SKIPPED\x10\x03*\xac\x01\n" +
+	"\x0eProvisionStage\x12\x1f\n" +
+	"\x1bPROVISION_STAGE_UNSPECIFIED\x10\x00\x12\x1f\n" +
+	"\x1bPROVISION_STAGE_DOWNLOADING\x10\x01\x12\x1d\n" +
+	"\x19PROVISION_STAGE_IMPORTING\x10\x02\x12\x1f\n" +
+	"\x1bPROVISION_STAGE_CONFIGURING\x10\x03\x12\x18\n" +
+	"\x14PROVISION_STAGE_DONE\x10\x04*\x91\x01\n" +
+	"\x0fDistroOperation\x12 \n" +
+	"\x1cDISTRO_OPERATION_UNSPECIFIED\x10\x00\x12\x1f\n" +
+	"\x1bDISTRO_OPERATION_SET_SPARSE\x10\x01\x12\x19\n" +
+	"\x15DISTRO_OPERATION_MOVE\x10\x02\x12 \n" +
+	"\x1cDISTRO_OPERATION_SET_VERSION\x10\x03*\xcf\x01\n" +
+	"\n" +
+	"Capability\x12\x1a\n" +
+	"\x16CAPABILITY_UNSPECIFIED\x10\x00\x12\x13\n" +
+	"\x0fCAPABILITY_EXEC\x10\x01\x12\x18\n" +
+	"\x14CAPABILITY_FILE_PUSH\x10\x02\x12\x13\n" +
+	"\x0fCAPABILITY_LOGS\x10\x03\x12\x17\n" +
+	"\x13CAPABILITY_INFO_ACK\x10\x04\x12\x13\n" +
+	"\x0fCAPABILITY_PING\x10\x05\x12\x1a\n" +
+	"\x16CAPABILITY_COMPRESSION\x10\x06\x12\x17\n" +
+	"\x13CAPABILITY_PROGRESS\x10\a2\xca\r\n" +
 	"\x02UI\x12F\n" +
 	"\rApplyProToken\x12\x17.agentapi.ProAttachInfo\x1a\x1a.agentapi.SubscriptionInfo\"\x00\x12N\n" +
 	"\x14ApplyLandscapeConfig\x12\x19.agentapi.LandscapeConfig\x1a\x19.agentapi.LandscapeSource\"\x00\x12*\n" +
 	"\x04Ping\x12\x0f.agentapi.Empty\x1a\x0f.agentapi.Empty\"\x00\x12>\n" +
 	"\x10GetConfigSources\x12\x0f.agentapi.Empty\x1a\x17.agentapi.ConfigSources\"\x00\x12?\n" +
-	"\x0eNotifyPurchase\x12\x0f.agentapi.Empty\x1a\x1a.agentapi.SubscriptionInfo\"\x002\xd9\x01\n" +
+	"\x0eNotifyPurchase\x12\x0f.agentapi.Empty\x1a\x1a.agentapi.SubscriptionInfo\"\x00\x12>\n" +
+	"\x0fApplyProService\x12\x18.agentapi.ProServiceInfo\x1a\x0f.agentapi.Empty\"\x00\x12>\n" +
+	"\x0fApplyUsgProfile\x12\x18.agentapi.UsgProfileInfo\x1a\x0f.agentapi.Empty\"\x00\x12C\n" +
+	"\fGetUsgReport\x12\x1a.agentapi.UsgReportRequest\x1a\x13.agentapi.UsgReport\"\x000\x01\x12D\n" +
+	"\x13GetComplianceReport\x12\x0f.agentapi.Empty\x1a\x1a.agentapi.ComplianceReport\"\x00\x12:\n" +
+	"\aTailLog\x12\x18.agentapi.TailLogRequest\x1a\x11.agentapi.LogLine\"\x000\x01\x12L\n" +
+	"\x17GetNotificationSettings\x12\x0f.agentapi.Empty\x1a\x1e.agentapi.NotificationSettings\"\x00\x12L\n" +
+	"\x17SetNotificationSettings\x12\x1e.agentapi.NotificationSettings\x1a\x0f.agentapi.Empty\"\x00\x126\n" +
+	"\fGetLatencies\x12\x0f.agentapi.Empty\x1a\x13.agentapi.Latencies\"\x00\x12J\n" +
+	"\x16GetSubscriptionDetails\x12\x0f.agentapi.Empty\x1a\x1d.agentapi.SubscriptionDetails\"\x00\x12B\n" +
+	"\n" +
+	"WatchTasks\x12\x1b.agentapi.WatchTasksRequest\x1a\x13.agentapi.TaskEvent\"\x000\x01\x129\n" +
+	"\n" +
+	"ManageUser\x12\x18.agentapi.ManageUserInfo\x1a\x0f.agentapi.Empty\"\x00\x12@\n" +
+	"\fManageDistro\x12\x1d.agentapi.ManageDistroRequest\x1a\x0f.agentapi.Empty\"\x00\x12;\n" +
+	"\tGetEvents\x12\x1a.agentapi.GetEventsRequest\x1a\x10.agentapi.Events\"\x00\x12=\n" +
+	"\fWatchConsent\x12\x0f.agentapi.Empty\x1a\x18.agentapi.ConsentRequest\"\x000\x01\x12;\n" +
+	"\rAnswerConsent\x12\x17.agentapi.ConsentAnswer\x1a\x0f.agentapi.Empty\"\x00\x122\n" +
+	"\n" +
+	"GetSummary\x12\x0f.agentapi.Empty\x1a\x11.agentapi.Summary\"\x00\x126\n" +
+	"\fWatchSummary\x12\x0f.agentapi.Empty\x1a\x11.agentapi.Summary\"\x000\x01\x126\n" +
+	"\fGetInventory\x12\x0f.agentapi.Empty\x1a\x13.agentapi.Inventory\"\x00\x12N\n" +
+	"\x0fProvisionDistro\x12\x1a.agentapi.ProvisionRequest\x1a\x1b.agentapi.ProvisionProgress\"\x000\x01\x126\n" +
+	"\fGetWslConfig\x12\x0f.agentapi.Empty\x1a\x13.agentapi.WslConfig\"\x00\x12:\n" +
+	"\fSetWslConfig\x12\x13.agentapi.WslConfig\x1a\x13.agentapi.WslConfig\"\x00\x126\n" +
+	"\fGetBandwidth\x12\x0f.agentapi.Empty\x1a\x13.agentapi.Bandwidth\"\x002\xc2\x03\n" +
 	"\vWSLInstance\x126\n" +
-	"\tConnected\x12\x14.agentapi.DistroInfo\x1a\x0f.agentapi.Empty\"\x00(\x01\x12D\n" +
+	"\tConnected\x12\x14.agentapi.DistroInfo\x1a\x0f.agentapi.Empty\"\x00(\x01\x12@\n" +
+	"\aSession\x12\x17.agentapi.DistroMessage\x1a\x16.agentapi.HandshakeAck\"\x00(\x010\x01\x12D\n" +
 	"\x15ProAttachmentCommands\x12\r.agentapi.MSG\x1a\x16.agentapi.ProAttachCmd\"\x00(\x010\x01\x12L\n" +
-	"\x17LandscapeConfigCommands\x12\r.agentapi.MSG\x1a\x1c.agentapi.LandscapeConfigCmd\"\x00(\x010\x01B2Z0github.com/canonical/ubuntu-pro-for-wsl/agentapib\x06proto3"
+	"\x17LandscapeConfigCommands\x12\r.agentapi.MSG\x1a\x1c.agentapi.LandscapeConfigCmd\"\x00(\x010\x01\x122\n" +
+	"\bCommands\x12\r.agentapi.MSG\x1a\x11.agentapi.Command\"\x00(\x010\x01\x12;\n" +
+	"\aTailLog\x12\x14.agentapi.LogMessage\x1a\x14.agentapi.TailLogCmd\"\x00(\x010\x01\x124\n" +
+	"\x04Ping\x12\x13.agentapi.PingReply\x1a\x11.agentapi.PingCmd\"\x00(\x010\x01B2Z0github.com/canonical/ubuntu-pro-for-wsl/agentapib\x06proto3"
 
 var (
 	file_agentapi_proto_rawDescOnce sync.Once
@@ -737,50 +5285,201 @@ func file_agentapi_proto_rawDescGZIP() []byte {
 	return file_agentapi_proto_rawDescData
 }
 
-var file_agentapi_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_agentapi_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_agentapi_proto_msgTypes = make([]protoimpl.MessageInfo, 66)
 var file_agentapi_proto_goTypes = []any{
-	(*Empty)(nil),              // 0: agentapi.Empty
-	(*ProAttachInfo)(nil),      // 1: agentapi.ProAttachInfo
-	(*LandscapeConfig)(nil),    // 2: agentapi.LandscapeConfig
-	(*SubscriptionInfo)(nil),   // 3: agentapi.SubscriptionInfo
-	(*LandscapeSource)(nil),    // 4: agentapi.LandscapeSource
-	(*ConfigSources)(nil),      // 5: agentapi.ConfigSources
-	(*DistroInfo)(nil),         // 6: agentapi.DistroInfo
-	(*ProAttachCmd)(nil),       // 7: agentapi.ProAttachCmd
-	(*LandscapeConfigCmd)(nil), // 8: agentapi.LandscapeConfigCmd
-	(*MSG)(nil),                // 9: agentapi.MSG
+	(AgentEventType)(0),          // 0: agentapi.AgentEventType
+	(TaskEventType)(0),           // 1: agentapi.TaskEventType
+	(TaskStepStatus)(0),          // 2: agentapi.TaskStepStatus
+	(ProvisionStage)(0),          // 3: agentapi.ProvisionStage
+	(DistroOperation)(0),         // 4: agentapi.DistroOperation
+	(Capability)(0),              // 5: agentapi.Capability
+	(*Empty)(nil),                // 6: agentapi.Empty
+	(*ProAttachInfo)(nil),        // 7: agentapi.ProAttachInfo
+	(*LandscapeConfig)(nil),      // 8: agentapi.LandscapeConfig
+	(*ProServiceInfo)(nil),       // 9: agentapi.ProServiceInfo
+	(*UsgProfileInfo)(nil),       // 10: agentapi.UsgProfileInfo
+	(*ManageUserInfo)(nil),       // 11: agentapi.ManageUserInfo
+	(*ManageDistroRequest)(nil),  // 12: agentapi.ManageDistroRequest
+	(*GetEventsRequest)(nil),     // 13: agentapi.GetEventsRequest
+	(*Events)(nil),               // 14: agentapi.Events
+	(*AgentEvent)(nil),           // 15: agentapi.AgentEvent
+	(*ConsentRequest)(nil),       // 16: agentapi.ConsentRequest
+	(*ConsentAnswer)(nil),        // 17: agentapi.ConsentAnswer
+	(*UsgReportRequest)(nil),     // 18: agentapi.UsgReportRequest
+	(*UsgReport)(nil),            // 19: agentapi.UsgReport
+	(*TailLogRequest)(nil),       // 20: agentapi.TailLogRequest
+	(*LogLine)(nil),              // 21: agentapi.LogLine
+	(*WatchTasksRequest)(nil),    // 22: agentapi.WatchTasksRequest
+	(*TaskEvent)(nil),            // 23: agentapi.TaskEvent
+	(*TaskResult)(nil),           // 24: agentapi.TaskResult
+	(*TaskStep)(nil),             // 25: agentapi.TaskStep
+	(*NotificationSettings)(nil), // 26: agentapi.NotificationSettings
+	(*Latencies)(nil),            // 27: agentapi.Latencies
+	(*DistroLatency)(nil),        // 28: agentapi.DistroLatency
+	(*Bandwidth)(nil),            // 29: agentapi.Bandwidth
+	(*DistroBandwidth)(nil),      // 30: agentapi.DistroBandwidth
+	(*TransferUsage)(nil),        // 31: agentapi.TransferUsage
+	(*ComplianceReport)(nil),     // 32: agentapi.ComplianceReport
+	(*DistroCompliance)(nil),     // 33: agentapi.DistroCompliance
+	(*Summary)(nil),              // 34: agentapi.Summary
+	(*ContractsHealth)(nil),      // 35: agentapi.ContractsHealth
+	(*Inventory)(nil),            // 36: agentapi.Inventory
+	(*InventoryRecord)(nil),      // 37: agentapi.InventoryRecord
+	(*ProvisionRequest)(nil),     // 38: agentapi.ProvisionRequest
+	(*ProvisionProgress)(nil),    // 39: agentapi.ProvisionProgress
+	(*WslInfo)(nil),              // 40: agentapi.WslInfo
+	(*WslConfig)(nil),            // 41: agentapi.WslConfig
+	(*SubscriptionInfo)(nil),     // 42: agentapi.SubscriptionInfo
+	(*SubscriptionDetails)(nil),  // 43: agentapi.SubscriptionDetails
+	(*Entitlement)(nil),          // 44: agentapi.Entitlement
+	(*LandscapeSource)(nil),      // 45: agentapi.LandscapeSource
+	(*ConfigSources)(nil),        // 46: agentapi.ConfigSources
+	(*DistroMessage)(nil),        // 47: agentapi.DistroMessage
+	(*Handshake)(nil),            // 48: agentapi.Handshake
+	(*HandshakeAck)(nil),         // 49: agentapi.HandshakeAck
+	(*DistroSettings)(nil),       // 50: agentapi.DistroSettings
+	(*DistroInfo)(nil),           // 51: agentapi.DistroInfo
+	(*SecurityStatus)(nil),       // 52: agentapi.SecurityStatus
+	(*ProAttachCmd)(nil),         // 53: agentapi.ProAttachCmd
+	(*LandscapeConfigCmd)(nil),   // 54: agentapi.LandscapeConfigCmd
+	(*Command)(nil),              // 55: agentapi.Command
+	(*ProServiceCmd)(nil),        // 56: agentapi.ProServiceCmd
+	(*UsgCmd)(nil),               // 57: agentapi.UsgCmd
+	(*ServiceUpgradeCmd)(nil),    // 58: agentapi.ServiceUpgradeCmd
+	(*TailLogCmd)(nil),           // 59: agentapi.TailLogCmd
+	(*LogMessage)(nil),           // 60: agentapi.LogMessage
+	(*PingCmd)(nil),              // 61: agentapi.PingCmd
+	(*PingReply)(nil),            // 62: agentapi.PingReply
+	(*PreemptCmd)(nil),           // 63: agentapi.PreemptCmd
+	(*ManageUserCmd)(nil),        // 64: agentapi.ManageUserCmd
+	(*PatchingCmd)(nil),          // 65: agentapi.PatchingCmd
+	(*SnapdCmd)(nil),             // 66: agentapi.SnapdCmd
+	(*ProStatusCmd)(nil),         // 67: agentapi.ProStatusCmd
+	(*ProxyCmd)(nil),             // 68: agentapi.ProxyCmd
+	(*DnsCmd)(nil),               // 69: agentapi.DnsCmd
+	(*MSG)(nil),                  // 70: agentapi.MSG
+	(*TaskProgress)(nil),         // 71: agentapi.TaskProgress
 }
 var file_agentapi_proto_depIdxs = []int32{
-	0,  // 0: agentapi.SubscriptionInfo.none:type_name -> agentapi.Empty
-	0,  // 1: agentapi.SubscriptionInfo.user:type_name -> agentapi.Empty
-	0,  // 2: agentapi.SubscriptionInfo.organization:type_name -> agentapi.Empty
-	0,  // 3: agentapi.SubscriptionInfo.microsoftStore:type_name -> agentapi.Empty
-	0,  // 4: agentapi.LandscapeSource.none:type_name -> agentapi.Empty
-	0,  // 5: agentapi.LandscapeSource.user:type_name -> agentapi.Empty
-	0,  // 6: agentapi.LandscapeSource.organization:type_name -> agentapi.Empty
-	3,  // 7: agentapi.ConfigSources.proSubscription:type_name -> agentapi.SubscriptionInfo
-	4,  // 8: agentapi.ConfigSources.landscapeSource:type_name -> agentapi.LandscapeSource
-	1,  // 9: agentapi.UI.ApplyProToken:input_type -> agentapi.ProAttachInfo
-	2,  // 10: agentapi.UI.ApplyLandscapeConfig:input_type -> agentapi.LandscapeConfig
-	0,  // 11: agentapi.UI.Ping:input_type -> agentapi.Empty
-	0,  // 12: agentapi.UI.GetConfigSources:input_type -> agentapi.Empty
-	0,  // 13: agentapi.UI.NotifyPurchase:input_type -> agentapi.Empty
-	6,  // 14: agentapi.WSLInstance.Connected:input_type -> agentapi.DistroInfo
-	9,  // 15: agentapi.WSLInstance.ProAttachmentCommands:input_type -> agentapi.MSG
-	9,  // 16: agentapi.WSLInstance.LandscapeConfigCommands:input_type -> agentapi.MSG
-	3,  // 17: agentapi.UI.ApplyProToken:output_type -> agentapi.SubscriptionInfo
-	4,  // 18: agentapi.UI.ApplyLandscapeConfig:output_type -> agentapi.LandscapeSource
-	0,  // 19: agentapi.UI.Ping:output_type -> agentapi.Empty
-	5,  // 20: agentapi.UI.GetConfigSources:output_type -> agentapi.ConfigSources
-	3,  // 21: agentapi.UI.NotifyPurchase:output_type -> agentapi.SubscriptionInfo
-	0,  // 22: agentapi.WSLInstance.Connected:output_type -> agentapi.Empty
-	7,  // 23: agentapi.WSLInstance.ProAttachmentCommands:output_type -> agentapi.ProAttachCmd
-	8,  // 24: agentapi.WSLInstance.LandscapeConfigCommands:output_type -> agentapi.LandscapeConfigCmd
-	17, // [17:25] is the sub-list for method output_type
-	9,  // [9:17] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	15, // 0: agentapi.Events.events:type_name -> agentapi.AgentEvent
+	0,  // 1: agentapi.AgentEvent.type:type_name -> agentapi.AgentEventType
+	24, // 2: agentapi.AgentEvent.result:type_name -> agentapi.TaskResult
+	1,  // 3: agentapi.TaskEvent.type:type_name -> agentapi.TaskEventType
+	24, // 4: agentapi.TaskEvent.result:type_name -> agentapi.TaskResult
+	25, // 5: agentapi.TaskResult.steps:type_name -> agentapi.TaskStep
+	2,  // 6: agentapi.TaskStep.status:type_name -> agentapi.TaskStepStatus
+	28, // 7: agentapi.Latencies.distros:type_name -> agentapi.DistroLatency
+	30, // 8: agentapi.Bandwidth.distros:type_name -> agentapi.DistroBandwidth
+	31, // 9: agentapi.DistroBandwidth.transfers:type_name -> agentapi.TransferUsage
+	33, // 10: agentapi.ComplianceReport.distros:type_name -> agentapi.DistroCompliance
+	52, // 11: agentapi.DistroCompliance.status:type_name -> agentapi.SecurityStatus
+	42, // 12: agentapi.Summary.subscription:type_name -> agentapi.SubscriptionInfo
+	40, // 13: agentapi.Summary.wsl:type_name -> agentapi.WslInfo
+	35, // 14: agentapi.Summary.contracts:type_name -> agentapi.ContractsHealth
+	37, // 15: agentapi.Inventory.records:type_name -> agentapi.InventoryRecord
+	3,  // 16: agentapi.ProvisionProgress.stage:type_name -> agentapi.ProvisionStage
+	4,  // 17: agentapi.WslInfo.operations:type_name -> agentapi.DistroOperation
+	6,  // 18: agentapi.SubscriptionInfo.none:type_name -> agentapi.Empty
+	6,  // 19: agentapi.SubscriptionInfo.user:type_name -> agentapi.Empty
+	6,  // 20: agentapi.SubscriptionInfo.organization:type_name -> agentapi.Empty
+	6,  // 21: agentapi.SubscriptionInfo.microsoftStore:type_name -> agentapi.Empty
+	44, // 22: agentapi.SubscriptionDetails.entitlements:type_name -> agentapi.Entitlement
+	6,  // 23: agentapi.LandscapeSource.none:type_name -> agentapi.Empty
+	6,  // 24: agentapi.LandscapeSource.user:type_name -> agentapi.Empty
+	6,  // 25: agentapi.LandscapeSource.organization:type_name -> agentapi.Empty
+	42, // 26: agentapi.ConfigSources.proSubscription:type_name -> agentapi.SubscriptionInfo
+	45, // 27: agentapi.ConfigSources.landscapeSource:type_name -> agentapi.LandscapeSource
+	48, // 28: agentapi.DistroMessage.handshake:type_name -> agentapi.Handshake
+	51, // 29: agentapi.DistroMessage.info:type_name -> agentapi.DistroInfo
+	5,  // 30: agentapi.Handshake.capabilities:type_name -> agentapi.Capability
+	5,  // 31: agentapi.HandshakeAck.capabilities:type_name -> agentapi.Capability
+	50, // 32: agentapi.HandshakeAck.settings:type_name -> agentapi.DistroSettings
+	52, // 33: agentapi.DistroInfo.security_status:type_name -> agentapi.SecurityStatus
+	56, // 34: agentapi.Command.pro_service:type_name -> agentapi.ProServiceCmd
+	57, // 35: agentapi.Command.usg:type_name -> agentapi.UsgCmd
+	58, // 36: agentapi.Command.service_upgrade:type_name -> agentapi.ServiceUpgradeCmd
+	63, // 37: agentapi.Command.preempt:type_name -> agentapi.PreemptCmd
+	64, // 38: agentapi.Command.manage_user:type_name -> agentapi.ManageUserCmd
+	65, // 39: agentapi.Command.patching:type_name -> agentapi.PatchingCmd
+	68, // 40: agentapi.Command.proxy:type_name -> agentapi.ProxyCmd
+	67, // 41: agentapi.Command.pro_status:type_name -> agentapi.ProStatusCmd
+	66, // 42: agentapi.Command.snapd:type_name -> agentapi.SnapdCmd
+	69, // 43: agentapi.Command.dns:type_name -> agentapi.DnsCmd
+	71, // 44: agentapi.MSG.progress:type_name -> agentapi.TaskProgress
+	7,  // 45: agentapi.UI.ApplyProToken:input_type -> agentapi.ProAttachInfo
+	8,  // 46: agentapi.UI.ApplyLandscapeConfig:input_type -> agentapi.LandscapeConfig
+	6,  // 47: agentapi.UI.Ping:input_type -> agentapi.Empty
+	6,  // 48: agentapi.UI.GetConfigSources:input_type -> agentapi.Empty
+	6,  // 49: agentapi.UI.NotifyPurchase:input_type -> agentapi.Empty
+	9,  // 50: agentapi.UI.ApplyProService:input_type -> agentapi.ProServiceInfo
+	10, // 51: agentapi.UI.ApplyUsgProfile:input_type -> agentapi.UsgProfileInfo
+	18, // 52: agentapi.UI.GetUsgReport:input_type -> agentapi.UsgReportRequest
+	6,  // 53: agentapi.UI.GetComplianceReport:input_type -> agentapi.Empty
+	20, // 54: agentapi.UI.TailLog:input_type -> agentapi.TailLogRequest
+	6,  // 55: agentapi.UI.GetNotificationSettings:input_type -> agentapi.Empty
+	26, // 56: agentapi.UI.SetNotificationSettings:input_type -> agentapi.NotificationSettings
+	6,  // 57: agentapi.UI.GetLatencies:input_type -> agentapi.Empty
+	6,  // 58: agentapi.UI.GetSubscriptionDetails:input_type -> agentapi.Empty
+	22, // 59: agentapi.UI.WatchTasks:input_type -> agentapi.WatchTasksRequest
+	11, // 60: agentapi.UI.ManageUser:input_type -> agentapi.ManageUserInfo
+	12, // 61: agentapi.UI.ManageDistro:input_type -> agentapi.ManageDistroRequest
+	13, // 62: agentapi.UI.GetEvents:input_type -> agentapi.GetEventsRequest
+	6,  // 63: agentapi.UI.WatchConsent:input_type -> agentapi.Empty
+	17, // 64: agentapi.UI.AnswerConsent:input_type -> agentapi.ConsentAnswer
+	6,  // 65: agentapi.UI.GetSummary:input_type -> agentapi.Empty
+	6,  // 66: agentapi.UI.WatchSummary:input_type -> agentapi.Empty
+	6,  // 67: agentapi.UI.GetInventory:input_type -> agentapi.Empty
+	38, // 68: agentapi.UI.ProvisionDistro:input_type -> agentapi.ProvisionRequest
+	6,  // 69: agentapi.UI.GetWslConfig:input_type -> agentapi.Empty
+	41, // 70: agentapi.UI.SetWslConfig:input_type -> agentapi.WslConfig
+	6,  // 71: agentapi.UI.GetBandwidth:input_type -> agentapi.Empty
+	51, // 72: agentapi.WSLInstance.Connected:input_type -> agentapi.DistroInfo
+	47, // 73: agentapi.WSLInstance.Session:input_type -> agentapi.DistroMessage
+	70, // 74: agentapi.WSLInstance.ProAttachmentCommands:input_type -> agentapi.MSG
+	70, // 75: agentapi.WSLInstance.LandscapeConfigCommands:input_type -> agentapi.MSG
+	70, // 76: agentapi.WSLInstance.Commands:input_type -> agentapi.MSG
+	60, // 77: agentapi.WSLInstance.TailLog:input_type -> agentapi.LogMessage
+	62, // 78: agentapi.WSLInstance.Ping:input_type -> agentapi.PingReply
+	42, // 79: agentapi.UI.ApplyProToken:output_type -> agentapi.SubscriptionInfo
+	45, // 80: agentapi.UI.ApplyLandscapeConfig:output_type -> agentapi.LandscapeSource
+	6,  // 81: agentapi.UI.Ping:output_type -> agentapi.Empty
+	46, // 82: agentapi.UI.GetConfigSources:output_type -> agentapi.ConfigSources
+	42, // 83: agentapi.UI.NotifyPurchase:output_type -> agentapi.SubscriptionInfo
+	6,  // 84: agentapi.UI.ApplyProService:output_type -> agentapi.Empty
+	6,  // 85: agentapi.UI.ApplyUsgProfile:output_type -> agentapi.Empty
+	19, // 86: agentapi.UI.GetUsgReport:output_type -> agentapi.UsgReport
+	32, // 87: agentapi.UI.GetComplianceReport:output_type -> agentapi.ComplianceReport
+	21, // 88: agentapi.UI.TailLog:output_type -> agentapi.LogLine
+	26, // 89: agentapi.UI.GetNotificationSettings:output_type -> agentapi.NotificationSettings
+	6,  // 90: agentapi.UI.SetNotificationSettings:output_type -> agentapi.Empty
+	27, // 91: agentapi.UI.GetLatencies:output_type -> agentapi.Latencies
+	43, // 92: agentapi.UI.GetSubscriptionDetails:output_type -> agentapi.SubscriptionDetails
+	23, // 93: agentapi.UI.WatchTasks:output_type -> agentapi.TaskEvent
+	6,  // 94: agentapi.UI.ManageUser:output_type -> agentapi.Empty
+	6,  // 95: agentapi.UI.ManageDistro:output_type -> agentapi.Empty
+	14, // 96: agentapi.UI.GetEvents:output_type -> agentapi.Events
+	16, // 97: agentapi.UI.WatchConsent:output_type -> agentapi.ConsentRequest
+	6,  // 98: agentapi.UI.AnswerConsent:output_type -> agentapi.Empty
+	34, // 99: agentapi.UI.GetSummary:output_type -> agentapi.Summary
+	34, // 100: agentapi.UI.WatchSummary:output_type -> agentapi.Summary
+	36, // 101: agentapi.UI.GetInventory:output_type -> agentapi.Inventory
+	39, // 102: agentapi.UI.ProvisionDistro:output_type -> agentapi.ProvisionProgress
+	41, // 103: agentapi.UI.GetWslConfig:output_type -> agentapi.WslConfig
+	41, // 104: agentapi.UI.SetWslConfig:output_type -> agentapi.WslConfig
+	29, // 105: agentapi.UI.GetBandwidth:output_type -> agentapi.Bandwidth
+	6,  // 106: agentapi.WSLInstance.Connected:output_type -> agentapi.Empty
+	49, // 107: agentapi.WSLInstance.Session:output_type -> agentapi.HandshakeAck
+	53, // 108: agentapi.WSLInstance.ProAttachmentCommands:output_type -> agentapi.ProAttachCmd
+	54, // 109: agentapi.WSLInstance.LandscapeConfigCommands:output_type -> agentapi.LandscapeConfigCmd
+	55, // 110: agentapi.WSLInstance.Commands:output_type -> agentapi.Command
+	59, // 111: agentapi.WSLInstance.TailLog:output_type -> agentapi.TailLogCmd
+	61, // 112: agentapi.WSLInstance.Ping:output_type -> agentapi.PingCmd
+	79, // [79:113] is the sub-list for method output_type
+	45, // [45:79] is the sub-list for method input_type
+	45, // [45:45] is the sub-list for extension type_name
+	45, // [45:45] is the sub-list for extension extendee
+	0,  // [0:45] is the sub-list for field type_name
 }
 
 func init() { file_agentapi_proto_init() }
@@ -788,33 +5487,56 @@ func file_agentapi_proto_init() {
 	if File_agentapi_proto != nil {
 		return
 	}
-	file_agentapi_proto_msgTypes[3].OneofWrappers = []any{
+	file_agentapi_proto_msgTypes[6].OneofWrappers = []any{
+		(*ManageDistroRequest_SetSparse)(nil),
+		(*ManageDistroRequest_Move)(nil),
+		(*ManageDistroRequest_SetVersion)(nil),
+	}
+	file_agentapi_proto_msgTypes[36].OneofWrappers = []any{
 		(*SubscriptionInfo_None)(nil),
 		(*SubscriptionInfo_User)(nil),
 		(*SubscriptionInfo_Organization)(nil),
 		(*SubscriptionInfo_MicrosoftStore)(nil),
 	}
-	file_agentapi_proto_msgTypes[4].OneofWrappers = []any{
+	file_agentapi_proto_msgTypes[39].OneofWrappers = []any{
 		(*LandscapeSource_None)(nil),
 		(*LandscapeSource_User)(nil),
 		(*LandscapeSource_Organization)(nil),
 	}
-	file_agentapi_proto_msgTypes[9].OneofWrappers = []any{
+	file_agentapi_proto_msgTypes[41].OneofWrappers = []any{
+		(*DistroMessage_Handshake)(nil),
+		(*DistroMessage_Info)(nil),
+	}
+	file_agentapi_proto_msgTypes[49].OneofWrappers = []any{
+		(*Command_ProService)(nil),
+		(*Command_Usg)(nil),
+		(*Command_ServiceUpgrade)(nil),
+		(*Command_Preempt)(nil),
+		(*Command_ManageUser)(nil),
+		(*Command_Patching)(nil),
+		(*Command_Proxy)(nil),
+		(*Command_ProStatus)(nil),
+		(*Command_Snapd)(nil),
+		(*Command_Dns)(nil),
+	}
+	file_agentapi_proto_msgTypes[64].OneofWrappers = []any{
 		(*MSG_WslName)(nil),
 		(*MSG_Result)(nil),
+		(*MSG_Progress)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_agentapi_proto_rawDesc), len(file_agentapi_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   10,
+			NumEnums:      6,
+			NumMessages:   66,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
 		GoTypes:           file_agentapi_proto_goTypes,
 		DependencyIndexes: file_agentapi_proto_depIdxs,
+		EnumInfos:         file_agentapi_proto_enumTypes,
 		MessageInfos:      file_agentapi_proto_msgTypes,
 	}.Build()
 	File_agentapi_proto = out.File
@@ -19,11 +19,33 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	UI_ApplyProToken_FullMethodName        = "/agentapi.UI/ApplyProToken"
-	UI_ApplyLandscapeConfig_FullMethodName = "/agentapi.UI/ApplyLandscapeConfig"
-	UI_Ping_FullMethodName                 = "/agentapi.UI/Ping"
-	UI_GetConfigSources_FullMethodName     = "/agentapi.UI/GetConfigSources"
-	UI_NotifyPurchase_FullMethodName       = "/agentapi.UI/NotifyPurchase"
+	UI_ApplyProToken_FullMethodName           = "/agentapi.UI/ApplyProToken"
+	UI_ApplyLandscapeConfig_FullMethodName    = "/agentapi.UI/ApplyLandscapeConfig"
+	UI_Ping_FullMethodName                    = "/agentapi.UI/Ping"
+	UI_GetConfigSources_FullMethodName        = "/agentapi.UI/GetConfigSources"
+	UI_NotifyPurchase_FullMethodName          = "/agentapi.UI/NotifyPurchase"
+	UI_ApplyProService_FullMethodName         = "/agentapi.UI/ApplyProService"
+	UI_ApplyUsgProfile_FullMethodName         = "/agentapi.UI/ApplyUsgProfile"
+	UI_GetUsgReport_FullMethodName            = "/agentapi.UI/GetUsgReport"
+	UI_GetComplianceReport_FullMethodName     = "/agentapi.UI/GetComplianceReport"
+	UI_TailLog_FullMethodName                 = "/agentapi.UI/TailLog"
+	UI_GetNotificationSettings_FullMethodName = "/agentapi.UI/GetNotificationSettings"
+	UI_SetNotificationSettings_FullMethodName = "/agentapi.UI/SetNotificationSettings"
+	UI_GetLatencies_FullMethodName            = "/agentapi.UI/GetLatencies"
+	UI_GetSubscriptionDetails_FullMethodName  = "/agentapi.UI/GetSubscriptionDetails"
+	UI_WatchTasks_FullMethodName              = "/agentapi.UI/WatchTasks"
+	UI_ManageUser_FullMethodName              = "/agentapi.UI/ManageUser"
+	UI_ManageDistro_FullMethodName            = "/agentapi.UI/ManageDistro"
+	UI_GetEvents_FullMethodName               = "/agentapi.UI/GetEvents"
+	UI_WatchConsent_FullMethodName            = "/agentapi.UI/WatchConsent"
+	UI_AnswerConsent_FullMethodName           = "/agentapi.UI/AnswerConsent"
+	UI_GetSummary_FullMethodName              = "/agentapi.UI/GetSummary"
+	UI_WatchSummary_FullMethodName            = "/agentapi.UI/WatchSummary"
+	UI_GetInventory_FullMethodName            = "/agentapi.UI/GetInventory"
+	UI_ProvisionDistro_FullMethodName         = "/agentapi.UI/ProvisionDistro"
+	UI_GetWslConfig_FullMethodName            = "/agentapi.UI/GetWslConfig"
+	UI_SetWslConfig_FullMethodName            = "/agentapi.UI/SetWslConfig"
+	UI_GetBandwidth_FullMethodName            = "/agentapi.UI/GetBandwidth"
 )
 
 // UIClient is the client API for UI service.
@@ -35,6 +57,28 @@ type UIClient interface {
 	Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
 	GetConfigSources(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ConfigSources, error)
 	NotifyPurchase(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SubscriptionInfo, error)
+	ApplyProService(ctx context.Context, in *ProServiceInfo, opts ...grpc.CallOption) (*Empty, error)
+	ApplyUsgProfile(ctx context.Context, in *UsgProfileInfo, opts ...grpc.CallOption) (*Empty, error)
+	GetUsgReport(ctx context.Context, in *UsgReportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UsgReport], error)
+	GetComplianceReport(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ComplianceReport, error)
+	TailLog(ctx context.Context, in *TailLogRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogLine], error)
+	GetNotificationSettings(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NotificationSettings, error)
+	SetNotificationSettings(ctx context.Context, in *NotificationSettings, opts ...grpc.CallOption) (*Empty, error)
+	GetLatencies(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Latencies, error)
+	GetSubscriptionDetails(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SubscriptionDetails, error)
+	WatchTasks(ctx context.Context, in *WatchTasksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskEvent], error)
+	ManageUser(ctx context.Context, in *ManageUserInfo, opts ...grpc.CallOption) (*Empty, error)
+	ManageDistro(ctx context.Context, in *ManageDistroRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetEvents(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*Events, error)
+	WatchConsent(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConsentRequest], error)
+	AnswerConsent(ctx context.Context, in *ConsentAnswer, opts ...grpc.CallOption) (*Empty, error)
+	GetSummary(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Summary, error)
+	WatchSummary(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Summary], error)
+	GetInventory(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Inventory, error)
+	ProvisionDistro(ctx context.Context, in *ProvisionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProvisionProgress], error)
+	GetWslConfig(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*WslConfig, error)
+	SetWslConfig(ctx context.Context, in *WslConfig, opts ...grpc.CallOption) (*WslConfig, error)
+	GetBandwidth(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Bandwidth, error)
 }
 
 type uIClient struct {
@@ -95,6 +139,280 @@ func (c *uIClient) NotifyPurchase(ctx context.Context, in *Empty, opts ...grpc.C
 	return out, nil
 }
 
+func (c *uIClient) ApplyProService(ctx context.Context, in *ProServiceInfo, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_ApplyProService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) ApplyUsgProfile(ctx context.Context, in *UsgProfileInfo, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_ApplyUsgProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetUsgReport(ctx context.Context, in *UsgReportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UsgReport], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UI_ServiceDesc.Streams[0], UI_GetUsgReport_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UsgReportRequest, UsgReport]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_GetUsgReportClient = grpc.ServerStreamingClient[UsgReport]
+
+func (c *uIClient) GetComplianceReport(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ComplianceReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ComplianceReport)
+	err := c.cc.Invoke(ctx, UI_GetComplianceReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) TailLog(ctx context.Context, in *TailLogRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogLine], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UI_ServiceDesc.Streams[1], UI_TailLog_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TailLogRequest, LogLine]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_TailLogClient = grpc.ServerStreamingClient[LogLine]
+
+func (c *uIClient) GetNotificationSettings(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NotificationSettings, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NotificationSettings)
+	err := c.cc.Invoke(ctx, UI_GetNotificationSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) SetNotificationSettings(ctx context.Context, in *NotificationSettings, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_SetNotificationSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetLatencies(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Latencies, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Latencies)
+	err := c.cc.Invoke(ctx, UI_GetLatencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetSubscriptionDetails(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SubscriptionDetails, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubscriptionDetails)
+	err := c.cc.Invoke(ctx, UI_GetSubscriptionDetails_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) WatchTasks(ctx context.Context, in *WatchTasksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UI_ServiceDesc.Streams[2], UI_WatchTasks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchTasksRequest, TaskEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_WatchTasksClient = grpc.ServerStreamingClient[TaskEvent]
+
+func (c *uIClient) ManageUser(ctx context.Context, in *ManageUserInfo, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_ManageUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) ManageDistro(ctx context.Context, in *ManageDistroRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_ManageDistro_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetEvents(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*Events, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Events)
+	err := c.cc.Invoke(ctx, UI_GetEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) WatchConsent(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConsentRequest], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UI_ServiceDesc.Streams[3], UI_WatchConsent_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Empty, ConsentRequest]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_WatchConsentClient = grpc.ServerStreamingClient[ConsentRequest]
+
+func (c *uIClient) AnswerConsent(ctx context.Context, in *ConsentAnswer, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_AnswerConsent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetSummary(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Summary, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Summary)
+	err := c.cc.Invoke(ctx, UI_GetSummary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) WatchSummary(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Summary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UI_ServiceDesc.Streams[4], UI_WatchSummary_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Empty, Summary]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_WatchSummaryClient = grpc.ServerStreamingClient[Summary]
+
+func (c *uIClient) GetInventory(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Inventory, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Inventory)
+	err := c.cc.Invoke(ctx, UI_GetInventory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) ProvisionDistro(ctx context.Context, in *ProvisionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProvisionProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UI_ServiceDesc.Streams[5], UI_ProvisionDistro_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ProvisionRequest, ProvisionProgress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_ProvisionDistroClient = grpc.ServerStreamingClient[ProvisionProgress]
+
+func (c *uIClient) GetWslConfig(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*WslConfig, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WslConfig)
+	err := c.cc.Invoke(ctx, UI_GetWslConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) SetWslConfig(ctx context.Context, in *WslConfig, opts ...grpc.CallOption) (*WslConfig, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WslConfig)
+	err := c.cc.Invoke(ctx, UI_SetWslConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetBandwidth(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Bandwidth, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Bandwidth)
+	err := c.cc.Invoke(ctx, UI_GetBandwidth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UIServer is the server API for UI service.
 // All implementations must embed UnimplementedUIServer
 // for forward compatibility.
@@ -104,138 +422,580 @@ type UIServer interface {
 	Ping(context.Context, *Empty) (*Empty, error)
 	GetConfigSources(context.Context, *Empty) (*ConfigSources, error)
 	NotifyPurchase(context.Context, *Empty) (*SubscriptionInfo, error)
+	ApplyProService(context.Context, *ProServiceInfo) (*Empty, error)
+	ApplyUsgProfile(context.Context, *UsgProfileInfo) (*Empty, error)
+	GetUsgReport(*UsgReportRequest, grpc.ServerStreamingServer[UsgReport]) error
+	GetComplianceReport(context.Context, *Empty) (*ComplianceReport, error)
+	TailLog(*TailLogRequest, grpc.ServerStreamingServer[LogLine]) error
+	GetNotificationSettings(context.Context, *Empty) (*NotificationSettings, error)
+	SetNotificationSettings(context.Context, *NotificationSettings) (*Empty, error)
+	GetLatencies(context.Context, *Empty) (*Latencies, error)
+	GetSubscriptionDetails(context.Context, *Empty) (*SubscriptionDetails, error)
+	WatchTasks(*WatchTasksRequest, grpc.ServerStreamingServer[TaskEvent]) error
+	ManageUser(context.Context, *ManageUserInfo) (*Empty, error)
+	ManageDistro(context.Context, *ManageDistroRequest) (*Empty, error)
+	GetEvents(context.Context, *GetEventsRequest) (*Events, error)
+	WatchConsent(*Empty, grpc.ServerStreamingServer[ConsentRequest]) error
+	AnswerConsent(context.Context, *ConsentAnswer) (*Empty, error)
+	GetSummary(context.Context, *Empty) (*Summary, error)
+	WatchSummary(*Empty, grpc.ServerStreamingServer[Summary]) error
+	GetInventory(context.Context, *Empty) (*Inventory, error)
+	ProvisionDistro(*ProvisionRequest, grpc.ServerStreamingServer[ProvisionProgress]) error
+	GetWslConfig(context.Context, *Empty) (*WslConfig, error)
+	SetWslConfig(context.Context, *WslConfig) (*WslConfig, error)
+	GetBandwidth(context.Context, *Empty) (*Bandwidth, error)
+	mustEmbedUnimplementedUIServer()
+}
+
+// UnimplementedUIServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUIServer struct{}
+
+func (UnimplementedUIServer) ApplyProToken(context.Context, *ProAttachInfo) (*SubscriptionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApplyProToken not implemented")
+}
+func (UnimplementedUIServer) ApplyLandscapeConfig(context.Context, *LandscapeConfig) (*LandscapeSource, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApplyLandscapeConfig not implemented")
+}
+func (UnimplementedUIServer) Ping(context.Context, *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedUIServer) GetConfigSources(context.Context, *Empty) (*ConfigSources, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfigSources not implemented")
+}
+func (UnimplementedUIServer) NotifyPurchase(context.Context, *Empty) (*SubscriptionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NotifyPurchase not implemented")
+}
+func (UnimplementedUIServer) ApplyProService(context.Context, *ProServiceInfo) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApplyProService not implemented")
+}
+func (UnimplementedUIServer) ApplyUsgProfile(context.Context, *UsgProfileInfo) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApplyUsgProfile not implemented")
+}
+func (UnimplementedUIServer) GetUsgReport(*UsgReportRequest, grpc.ServerStreamingServer[UsgReport]) error {
+	return status.Errorf(codes.Unimplemented, "method GetUsgReport not implemented")
+}
+func (UnimplementedUIServer) GetComplianceReport(context.Context, *Empty) (*ComplianceReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetComplianceReport not implemented")
+}
+func (UnimplementedUIServer) TailLog(*TailLogRequest, grpc.ServerStreamingServer[LogLine]) error {
+	return status.Errorf(codes.Unimplemented, "method TailLog not implemented")
+}
+func (UnimplementedUIServer) GetNotificationSettings(context.Context, *Empty) (*NotificationSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotificationSettings not implemented")
+}
+func (UnimplementedUIServer) SetNotificationSettings(context.Context, *NotificationSettings) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetNotificationSettings not implemented")
+}
+func (UnimplementedUIServer) GetLatencies(context.Context, *Empty) (*Latencies, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatencies not implemented")
+}
+func (UnimplementedUIServer) GetSubscriptionDetails(context.Context, *Empty) (*SubscriptionDetails, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSubscriptionDetails not implemented")
+}
+func (UnimplementedUIServer) WatchTasks(*WatchTasksRequest, grpc.ServerStreamingServer[TaskEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTasks not implemented")
+}
+func (UnimplementedUIServer) ManageUser(context.Context, *ManageUserInfo) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ManageUser not implemented")
+}
+func (UnimplementedUIServer) ManageDistro(context.Context, *ManageDistroRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ManageDistro not implemented")
+}
+func (UnimplementedUIServer) GetEvents(context.Context, *GetEventsRequest) (*Events, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEvents not implemented")
+}
+func (UnimplementedUIServer) WatchConsent(*Empty, grpc.ServerStreamingServer[ConsentRequest]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchConsent not implemented")
+}
+func (UnimplementedUIServer) AnswerConsent(context.Context, *ConsentAnswer) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnswerConsent not implemented")
+}
+func (UnimplementedUIServer) GetSummary(context.Context, *Empty) (*Summary, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSummary not implemented")
+}
+func (UnimplementedUIServer) WatchSummary(*Empty, grpc.ServerStreamingServer[Summary]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchSummary not implemented")
+}
+func (UnimplementedUIServer) GetInventory(context.Context, *Empty) (*Inventory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInventory not implemented")
+}
+func (UnimplementedUIServer) ProvisionDistro(*ProvisionRequest, grpc.ServerStreamingServer[ProvisionProgress]) error {
+	return status.Errorf(codes.Unimplemented, "method ProvisionDistro not implemented")
+}
+func (UnimplementedUIServer) GetWslConfig(context.Context, *Empty) (*WslConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWslConfig not implemented")
+}
+func (UnimplementedUIServer) SetWslConfig(context.Context, *WslConfig) (*WslConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWslConfig not implemented")
+}
+func (UnimplementedUIServer) GetBandwidth(context.Context, *Empty) (*Bandwidth, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBandwidth not implemented")
+}
+func (UnimplementedUIServer) mustEmbedUnimplementedUIServer() {}
+func (UnimplementedUIServer) testEmbeddedByValue()            {}
+
+// UnsafeUIServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UIServer will
+// result in compilation errors.
+type UnsafeUIServer interface {
 	mustEmbedUnimplementedUIServer()
 }
 
-// UnimplementedUIServer must be embedded to have
-// forward compatible implementations.
-//
-// NOTE: this should be embedded by value instead of pointer to avoid a nil
-// pointer dereference when methods are called.
-type UnimplementedUIServer struct{}
-
-func (UnimplementedUIServer) ApplyProToken(context.Context, *ProAttachInfo) (*SubscriptionInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ApplyProToken not implemented")
-}
-func (UnimplementedUIServer) ApplyLandscapeConfig(context.Context, *LandscapeConfig) (*LandscapeSource, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ApplyLandscapeConfig not implemented")
+func RegisterUIServer(s grpc.ServiceRegistrar, srv UIServer) {
+	// If the following call pancis, it indicates UnimplementedUIServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&UI_ServiceDesc, srv)
+}
+
+func _UI_ApplyProToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProAttachInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).ApplyProToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_ApplyProToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).ApplyProToken(ctx, req.(*ProAttachInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_ApplyLandscapeConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LandscapeConfig)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).ApplyLandscapeConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_ApplyLandscapeConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).ApplyLandscapeConfig(ctx, req.(*LandscapeConfig))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).Ping(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetConfigSources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetConfigSources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetConfigSources_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetConfigSources(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_NotifyPurchase_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).NotifyPurchase(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_NotifyPurchase_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).NotifyPurchase(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_ApplyProService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProServiceInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).ApplyProService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_ApplyProService_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).ApplyProService(ctx, req.(*ProServiceInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_ApplyUsgProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UsgProfileInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).ApplyUsgProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_ApplyUsgProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).ApplyUsgProfile(ctx, req.(*UsgProfileInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetUsgReport_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(UsgReportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UIServer).GetUsgReport(m, &grpc.GenericServerStream[UsgReportRequest, UsgReport]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_GetUsgReportServer = grpc.ServerStreamingServer[UsgReport]
+
+func _UI_GetComplianceReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetComplianceReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetComplianceReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetComplianceReport(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_TailLog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailLogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UIServer).TailLog(m, &grpc.GenericServerStream[TailLogRequest, LogLine]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_TailLogServer = grpc.ServerStreamingServer[LogLine]
+
+func _UI_GetNotificationSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetNotificationSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetNotificationSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetNotificationSettings(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_SetNotificationSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NotificationSettings)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).SetNotificationSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_SetNotificationSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).SetNotificationSettings(ctx, req.(*NotificationSettings))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetLatencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetLatencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetLatencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetLatencies(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetSubscriptionDetails_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetSubscriptionDetails(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetSubscriptionDetails_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetSubscriptionDetails(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedUIServer) Ping(context.Context, *Empty) (*Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+
+func _UI_WatchTasks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchTasksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UIServer).WatchTasks(m, &grpc.GenericServerStream[WatchTasksRequest, TaskEvent]{ServerStream: stream})
 }
-func (UnimplementedUIServer) GetConfigSources(context.Context, *Empty) (*ConfigSources, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetConfigSources not implemented")
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_WatchTasksServer = grpc.ServerStreamingServer[TaskEvent]
+
+func _UI_ManageUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ManageUserInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).ManageUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_ManageUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).ManageUser(ctx, req.(*ManageUserInfo))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedUIServer) NotifyPurchase(context.Context, *Empty) (*SubscriptionInfo, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method NotifyPurchase not implemented")
+
+func _UI_ManageDistro_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ManageDistroRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).ManageDistro(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_ManageDistro_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).ManageDistro(ctx, req.(*ManageDistroRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedUIServer) mustEmbedUnimplementedUIServer() {}
-func (UnimplementedUIServer) testEmbeddedByValue()            {}
 
-// UnsafeUIServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to UIServer will
-// result in compilation errors.
-type UnsafeUIServer interface {
-	mustEmbedUnimplementedUIServer()
+func _UI_GetEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetEvents(ctx, req.(*GetEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterUIServer(s grpc.ServiceRegistrar, srv UIServer) {
-	// If the following call pancis, it indicates UnimplementedUIServer was
-	// embedded by pointer and is nil.  This will cause panics if an
-	// unimplemented method is ever invoked, so we test this at initialization
-	// time to prevent it from happening at runtime later due to I/O.
-	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
-		t.testEmbeddedByValue()
+func _UI_WatchConsent_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
 	}
-	s.RegisterService(&UI_ServiceDesc, srv)
+	return srv.(UIServer).WatchConsent(m, &grpc.GenericServerStream[Empty, ConsentRequest]{ServerStream: stream})
 }
 
-func _UI_ApplyProToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ProAttachInfo)
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_WatchConsentServer = grpc.ServerStreamingServer[ConsentRequest]
+
+func _UI_AnswerConsent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConsentAnswer)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(UIServer).ApplyProToken(ctx, in)
+		return srv.(UIServer).AnswerConsent(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: UI_ApplyProToken_FullMethodName,
+		FullMethod: UI_AnswerConsent_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UIServer).ApplyProToken(ctx, req.(*ProAttachInfo))
+		return srv.(UIServer).AnswerConsent(ctx, req.(*ConsentAnswer))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _UI_ApplyLandscapeConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LandscapeConfig)
+func _UI_GetSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(UIServer).ApplyLandscapeConfig(ctx, in)
+		return srv.(UIServer).GetSummary(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: UI_ApplyLandscapeConfig_FullMethodName,
+		FullMethod: UI_GetSummary_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UIServer).ApplyLandscapeConfig(ctx, req.(*LandscapeConfig))
+		return srv.(UIServer).GetSummary(ctx, req.(*Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _UI_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _UI_WatchSummary_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UIServer).WatchSummary(m, &grpc.GenericServerStream[Empty, Summary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_WatchSummaryServer = grpc.ServerStreamingServer[Summary]
+
+func _UI_GetInventory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(UIServer).Ping(ctx, in)
+		return srv.(UIServer).GetInventory(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: UI_Ping_FullMethodName,
+		FullMethod: UI_GetInventory_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UIServer).Ping(ctx, req.(*Empty))
+		return srv.(UIServer).GetInventory(ctx, req.(*Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _UI_GetConfigSources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _UI_ProvisionDistro_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ProvisionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UIServer).ProvisionDistro(m, &grpc.GenericServerStream[ProvisionRequest, ProvisionProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UI_ProvisionDistroServer = grpc.ServerStreamingServer[ProvisionProgress]
+
+func _UI_GetWslConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(UIServer).GetConfigSources(ctx, in)
+		return srv.(UIServer).GetWslConfig(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: UI_GetConfigSources_FullMethodName,
+		FullMethod: UI_GetWslConfig_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UIServer).GetConfigSources(ctx, req.(*Empty))
+		return srv.(UIServer).GetWslConfig(ctx, req.(*Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _UI_NotifyPurchase_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _UI_SetWslConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WslConfig)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).SetWslConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_SetWslConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).SetWslConfig(ctx, req.(*WslConfig))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetBandwidth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(UIServer).NotifyPurchase(ctx, in)
+		return srv.(UIServer).GetBandwidth(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: UI_NotifyPurchase_FullMethodName,
+		FullMethod: UI_GetBandwidth_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UIServer).NotifyPurchase(ctx, req.(*Empty))
+		return srv.(UIServer).GetBandwidth(ctx, req.(*Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -267,25 +1027,142 @@ var UI_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "NotifyPurchase",
 			Handler:    _UI_NotifyPurchase_Handler,
 		},
+		{
+			MethodName: "ApplyProService",
+			Handler:    _UI_ApplyProService_Handler,
+		},
+		{
+			MethodName: "ApplyUsgProfile",
+			Handler:    _UI_ApplyUsgProfile_Handler,
+		},
+		{
+			MethodName: "GetComplianceReport",
+			Handler:    _UI_GetComplianceReport_Handler,
+		},
+		{
+			MethodName: "GetNotificationSettings",
+			Handler:    _UI_GetNotificationSettings_Handler,
+		},
+		{
+			MethodName: "SetNotificationSettings",
+			Handler:    _UI_SetNotificationSettings_Handler,
+		},
+		{
+			MethodName: "GetLatencies",
+			Handler:    _UI_GetLatencies_Handler,
+		},
+		{
+			MethodName: "GetSubscriptionDetails",
+			Handler:    _UI_GetSubscriptionDetails_Handler,
+		},
+		{
+			MethodName: "ManageUser",
+			Handler:    _UI_ManageUser_Handler,
+		},
+		{
+			MethodName: "ManageDistro",
+			Handler:    _UI_ManageDistro_Handler,
+		},
+		{
+			MethodName: "GetEvents",
+			Handler:    _UI_GetEvents_Handler,
+		},
+		{
+			MethodName: "AnswerConsent",
+			Handler:    _UI_AnswerConsent_Handler,
+		},
+		{
+			MethodName: "GetSummary",
+			Handler:    _UI_GetSummary_Handler,
+		},
+		{
+			MethodName: "GetInventory",
+			Handler:    _UI_GetInventory_Handler,
+		},
+		{
+			MethodName: "GetWslConfig",
+			Handler:    _UI_GetWslConfig_Handler,
+		},
+		{
+			MethodName: "SetWslConfig",
+			Handler:    _UI_SetWslConfig_Handler,
+		},
+		{
+			MethodName: "GetBandwidth",
+			Handler:    _UI_GetBandwidth_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetUsgReport",
+			Handler:       _UI_GetUsgReport_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "TailLog",
+			Handler:       _UI_TailLog_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchTasks",
+			Handler:       _UI_WatchTasks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchConsent",
+			Handler:       _UI_WatchConsent_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchSummary",
+			Handler:       _UI_WatchSummary_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ProvisionDistro",
+			Handler:       _UI_ProvisionDistro_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "agentapi.proto",
 }
 
 const (
 	WSLInstance_Connected_FullMethodName               = "/agentapi.WSLInstance/Connected"
+	WSLInstance_Session_FullMethodName                 = "/agentapi.WSLInstance/Session"
 	WSLInstance_ProAttachmentCommands_FullMethodName   = "/agentapi.WSLInstance/ProAttachmentCommands"
 	WSLInstance_LandscapeConfigCommands_FullMethodName = "/agentapi.WSLInstance/LandscapeConfigCommands"
+	WSLInstance_Commands_FullMethodName                = "/agentapi.WSLInstance/Commands"
+	WSLInstance_TailLog_FullMethodName                 = "/agentapi.WSLInstance/TailLog"
+	WSLInstance_Ping_FullMethodName                    = "/agentapi.WSLInstance/Ping"
 )
 
 // WSLInstanceClient is the client API for WSLInstance service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type WSLInstanceClient interface {
+	// Connected is the stream of the WSL Pro services that predate the handshake, made of bare DistroInfo
+	// messages. The agent rejects it with FAILED_PRECONDITION, so that those services are told to upgrade.
 	Connected(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[DistroInfo, Empty], error)
+	// Session starts with a Handshake, answered with a single HandshakeAck, and then streams
+	// DistroInfo updates for the lifetime of the WSL Pro service. If CAPABILITY_INFO_ACK was negotiated,
+	// every DistroInfo is answered with another HandshakeAck carrying only the up-to-date DistroSettings.
+	// Agents that predate the handshake answer it with UNIMPLEMENTED.
+	Session(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[DistroMessage, HandshakeAck], error)
 	// Reverse unary calls
 	ProAttachmentCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, ProAttachCmd], error)
 	LandscapeConfigCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, LandscapeConfigCmd], error)
+	// Commands, TailLog and Ping are opened after the handshake, so that they compress their large messages
+	// with the "up4w-gzip" compressor if CAPABILITY_COMPRESSION was negotiated.
+	Commands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, Command], error)
+	// TailLog starts with a LogMessage carrying only the WSL name, and is only opened if CAPABILITY_LOGS was
+	// negotiated. Every TailLogCmd is answered with the journal lines it requests as they are read, tagged with
+	// its id, so that several requests are streamed at once. The last LogMessage of a request is marked done.
+	TailLog(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LogMessage, TailLogCmd], error)
+	// Ping starts with a PingReply carrying only the WSL name, and is only opened if CAPABILITY_PING was
+	// negotiated. Every PingCmd is echoed back right away in a PingReply with the same id and payload, so
+	// that the round-trip time does not depend on the commands in progress.
+	Ping(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[PingReply, PingCmd], error)
 }
 
 type wSLInstanceClient struct {
@@ -309,9 +1186,22 @@ func (c *wSLInstanceClient) Connected(ctx context.Context, opts ...grpc.CallOpti
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type WSLInstance_ConnectedClient = grpc.ClientStreamingClient[DistroInfo, Empty]
 
+func (c *wSLInstanceClient) Session(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[DistroMessage, HandshakeAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[1], WSLInstance_Session_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DistroMessage, HandshakeAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_SessionClient = grpc.BidiStreamingClient[DistroMessage, HandshakeAck]
+
 func (c *wSLInstanceClient) ProAttachmentCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, ProAttachCmd], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[1], WSLInstance_ProAttachmentCommands_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[2], WSLInstance_ProAttachmentCommands_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -324,7 +1214,7 @@ type WSLInstance_ProAttachmentCommandsClient = grpc.BidiStreamingClient[MSG, Pro
 
 func (c *wSLInstanceClient) LandscapeConfigCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, LandscapeConfigCmd], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[2], WSLInstance_LandscapeConfigCommands_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[3], WSLInstance_LandscapeConfigCommands_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -335,14 +1225,71 @@ func (c *wSLInstanceClient) LandscapeConfigCommands(ctx context.Context, opts ..
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type WSLInstance_LandscapeConfigCommandsClient = grpc.BidiStreamingClient[MSG, LandscapeConfigCmd]
 
+func (c *wSLInstanceClient) Commands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, Command], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[4], WSLInstance_Commands_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MSG, Command]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_CommandsClient = grpc.BidiStreamingClient[MSG, Command]
+
+func (c *wSLInstanceClient) TailLog(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LogMessage, TailLogCmd], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[5], WSLInstance_TailLog_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LogMessage, TailLogCmd]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_TailLogClient = grpc.BidiStreamingClient[LogMessage, TailLogCmd]
+
+func (c *wSLInstanceClient) Ping(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[PingReply, PingCmd], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[6], WSLInstance_Ping_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PingReply, PingCmd]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_PingClient = grpc.BidiStreamingClient[PingReply, PingCmd]
+
 // WSLInstanceServer is the server API for WSLInstance service.
 // All implementations must embed UnimplementedWSLInstanceServer
 // for forward compatibility.
 type WSLInstanceServer interface {
+	// Connected is the stream of the WSL Pro services that predate the handshake, made of bare DistroInfo
+	// messages. The agent rejects it with FAILED_PRECONDITION, so that those services are told to upgrade.
 	Connected(grpc.ClientStreamingServer[DistroInfo, Empty]) error
+	// Session starts with a Handshake, answered with a single HandshakeAck, and then streams
+	// DistroInfo updates for the lifetime of the WSL Pro service. If CAPABILITY_INFO_ACK was negotiated,
+	// every DistroInfo is answered with another HandshakeAck carrying only the up-to-date DistroSettings.
+	// Agents that predate the handshake answer it with UNIMPLEMENTED.
+	Session(grpc.BidiStreamingServer[DistroMessage, HandshakeAck]) error
 	// Reverse unary calls
 	ProAttachmentCommands(grpc.BidiStreamingServer[MSG, ProAttachCmd]) error
 	LandscapeConfigCommands(grpc.BidiStreamingServer[MSG, LandscapeConfigCmd]) error
+	// Commands, TailLog and Ping are opened after the handshake, so that they compress their large messages
+	// with the "up4w-gzip" compressor if CAPABILITY_COMPRESSION was negotiated.
+	Commands(grpc.BidiStreamingServer[MSG, Command]) error
+	// TailLog starts with a LogMessage carrying only the WSL name, and is only opened if CAPABILITY_LOGS was
+	// negotiated. Every TailLogCmd is answered with the journal lines it requests as they are read, tagged with
+	// its id, so that several requests are streamed at once. The last LogMessage of a request is marked done.
+	TailLog(grpc.BidiStreamingServer[LogMessage, TailLogCmd]) error
+	// Ping starts with a PingReply carrying only the WSL name, and is only opened if CAPABILITY_PING was
+	// negotiated. Every PingCmd is echoed back right away in a PingReply with the same id and payload, so
+	// that the round-trip time does not depend on the commands in progress.
+	Ping(grpc.BidiStreamingServer[PingReply, PingCmd]) error
 	mustEmbedUnimplementedWSLInstanceServer()
 }
 
@@ -356,12 +1303,24 @@ type UnimplementedWSLInstanceServer struct{}
 func (UnimplementedWSLInstanceServer) Connected(grpc.ClientStreamingServer[DistroInfo, Empty]) error {
 	return status.Errorf(codes.Unimplemented, "method Connected not implemented")
 }
+func (UnimplementedWSLInstanceServer) Session(grpc.BidiStreamingServer[DistroMessage, HandshakeAck]) error {
+	return status.Errorf(codes.Unimplemented, "method Session not implemented")
+}
 func (UnimplementedWSLInstanceServer) ProAttachmentCommands(grpc.BidiStreamingServer[MSG, ProAttachCmd]) error {
 	return status.Errorf(codes.Unimplemented, "method ProAttachmentCommands not implemented")
 }
 func (UnimplementedWSLInstanceServer) LandscapeConfigCommands(grpc.BidiStreamingServer[MSG, LandscapeConfigCmd]) error {
 	return status.Errorf(codes.Unimplemented, "method LandscapeConfigCommands not implemented")
 }
+func (UnimplementedWSLInstanceServer) Commands(grpc.BidiStreamingServer[MSG, Command]) error {
+	return status.Errorf(codes.Unimplemented, "method Commands not implemented")
+}
+func (UnimplementedWSLInstanceServer) TailLog(grpc.BidiStreamingServer[LogMessage, TailLogCmd]) error {
+	return status.Errorf(codes.Unimplemented, "method TailLog not implemented")
+}
+func (UnimplementedWSLInstanceServer) Ping(grpc.BidiStreamingServer[PingReply, PingCmd]) error {
+	return status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
 func (UnimplementedWSLInstanceServer) mustEmbedUnimplementedWSLInstanceServer() {}
 func (UnimplementedWSLInstanceServer) testEmbeddedByValue()                     {}
 
@@ -390,6 +1349,13 @@ func _WSLInstance_Connected_Handler(srv interface{}, stream grpc.ServerStream) e
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type WSLInstance_ConnectedServer = grpc.ClientStreamingServer[DistroInfo, Empty]
 
+func _WSLInstance_Session_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).Session(&grpc.GenericServerStream[DistroMessage, HandshakeAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_SessionServer = grpc.BidiStreamingServer[DistroMessage, HandshakeAck]
+
 func _WSLInstance_ProAttachmentCommands_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(WSLInstanceServer).ProAttachmentCommands(&grpc.GenericServerStream[MSG, ProAttachCmd]{ServerStream: stream})
 }
@@ -404,6 +1370,27 @@ func _WSLInstance_LandscapeConfigCommands_Handler(srv interface{}, stream grpc.S
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type WSLInstance_LandscapeConfigCommandsServer = grpc.BidiStreamingServer[MSG, LandscapeConfigCmd]
 
+func _WSLInstance_Commands_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).Commands(&grpc.GenericServerStream[MSG, Command]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_CommandsServer = grpc.BidiStreamingServer[MSG, Command]
+
+func _WSLInstance_TailLog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).TailLog(&grpc.GenericServerStream[LogMessage, TailLogCmd]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_TailLogServer = grpc.BidiStreamingServer[LogMessage, TailLogCmd]
+
+func _WSLInstance_Ping_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).Ping(&grpc.GenericServerStream[PingReply, PingCmd]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_PingServer = grpc.BidiStreamingServer[PingReply, PingCmd]
+
 // WSLInstance_ServiceDesc is the grpc.ServiceDesc for WSLInstance service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -417,6 +1404,12 @@ var WSLInstance_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _WSLInstance_Connected_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Session",
+			Handler:       _WSLInstance_Session_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 		{
 			StreamName:    "ProAttachmentCommands",
 			Handler:       _WSLInstance_ProAttachmentCommands_Handler,
@@ -429,6 +1422,24 @@ var WSLInstance_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Commands",
+			Handler:       _WSLInstance_Commands_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "TailLog",
+			Handler:       _WSLInstance_TailLog_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Ping",
+			Handler:       _WSLInstance_Ping_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "agentapi.proto",
 }
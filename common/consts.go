@@ -13,9 +13,20 @@ const (
 	//  ${env:UserProfile}/{UserProfileDir}
 	UserProfileDir = ".ubuntupro"
 
+	// StatusDir is the relative path name used to store the address and certificates of the read-only status API of
+	// the agent, with the same layout as UserProfileDir. It has an access control list of its own, so that third-party
+	// tools can be granted access to the status of the agent without getting control over it.
+	//  ${env:UserProfile}/{StatusDir}
+	StatusDir = ".ubuntupro-status"
+
 	// ListeningPortFileName corresponds to the base name of the file hosting the addressing of our GRPC server.
 	ListeningPortFileName = ".address"
 
+	// NamedPipeFileName corresponds to the base name of the file hosting the path of the named pipe our GRPC server
+	// is also served on, so that the command line clients of the agent, like simulate-distro, need not go through
+	// loopback TCP. The GUI keeps connecting to the address in ListeningPortFileName.
+	NamedPipeFileName = ".pipe"
+
 	// MsStoreProductID is the ID of the product in the Microsoft Store
 	//
 	// TODO: Replace with real product ID.
@@ -33,12 +44,20 @@ const (
 	// AgentCertFilePrefix is the file name prefix to identify the certificate/key pair of the agent in the PEM format.
 	AgentCertFilePrefix = "agent"
 
-	// ClientsCertFilePrefix is the file name prefix to identify the certificate/key pair of the clients (GUI and all WSL instances) in the PEM format.
+	// ClientsCertFilePrefix is the file name prefix to identify the certificate/key pair of the GUI in the PEM format.
 	ClientsCertFilePrefix = "client"
 
+	// WSLProServiceCertFilePrefix is the file name prefix to identify the certificate/key pair of the WSL Pro service
+	// running in the WSL instances in the PEM format.
+	WSLProServiceCertFilePrefix = "wsl-pro-service"
+
 	// CertificateSuffix is the file name suffix to the (public) certificate in the PEM format.
 	CertificateSuffix = "_cert.pem"
 
 	// KeySuffix is the file name suffix to the private key in the PEM format.
 	KeySuffix = "_key.pem"
+
+	// WSLInstanceProtocolVersion is the version of the protocol spoken between the agent and the WSL Pro service,
+	// exchanged in the handshake of the Session stream. It must be bumped on every incompatible change.
+	WSLInstanceProtocolVersion = 1
 )
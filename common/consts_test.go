@@ -0,0 +1,123 @@
+package common_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/stretchr/testify/require"
+)
+
+// repoRoot is the root of the repository, relative to this package.
+const repoRoot = ".."
+
+// sharedNames are the constants of this package naming what the components share, by value. Declaring them again
+// elsewhere lets the copies diverge, so that one side looks for the files where the other does not write them.
+var sharedNames = map[string]string{
+	common.LocalAppDataDir:       "LocalAppDataDir",
+	common.UserProfileDir:        "UserProfileDir",
+	common.StatusDir:             "StatusDir",
+	common.ListeningPortFileName: "ListeningPortFileName",
+	common.NamedPipeFileName:     "NamedPipeFileName",
+	common.RootCACertFileName:    "RootCACertFileName",
+}
+
+// TestSharedNamesAreNotRedeclared works like a vet check: it fails if a Go file of any module, tests aside, declares
+// a string constant or variable with the value of one of the shared names instead of using it from this package.
+func TestSharedNamesAreNotRedeclared(t *testing.T) {
+	t.Parallel()
+
+	var redeclared []string
+	err := filepath.WalkDir(repoRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			switch name := d.Name(); {
+			case p == repoRoot:
+				return nil
+			case p == filepath.Join(repoRoot, "common"), p == filepath.Join(repoRoot, "gui"):
+				return filepath.SkipDir
+			case name == "testdata", name == "vendor", strings.HasPrefix(name, "."):
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(p, ".go") || strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+
+		found, err := redeclaredNames(p)
+		if err != nil {
+			return err
+		}
+		redeclared = append(redeclared, found...)
+		return nil
+	})
+	require.NoError(t, err, "Setup: could not walk the repository")
+
+	require.Empty(t, redeclared, "Shared names should be used from the common package rather than redeclared")
+}
+
+// TestGUIAddressFile checks that the GUI looks for the address of the agent where the agent writes it, since the
+// Dart code cannot use the constants of this package.
+func TestGUIAddressFile(t *testing.T) {
+	t.Parallel()
+
+	out, err := os.ReadFile(filepath.Join(repoRoot, "gui", "packages", "ubuntupro", "lib", "constants.dart"))
+	require.NoError(t, err, "Setup: could not read the constants of the GUI")
+
+	m := regexp.MustCompile(`const kAddrFileName = '([^']*)';`).FindSubmatch(out)
+	require.NotNil(t, m, "The GUI should declare the address file in kAddrFileName")
+
+	want := path.Join(common.UserProfileDir, common.ListeningPortFileName)
+	require.Equal(t, want, string(m[1]), "The GUI should look for the address file in the public directory of the agent")
+}
+
+// redeclaredNames returns the positions and names of the constants and variables of the file whose value is a
+// shared name.
+func redeclaredNames(p string) ([]string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, p, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+
+		for i, v := range spec.Values {
+			lit, ok := v.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING || i >= len(spec.Names) {
+				continue
+			}
+
+			value, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				continue
+			}
+
+			if shared, ok := sharedNames[value]; ok {
+				found = append(found, fset.Position(lit.Pos()).String()+": "+spec.Names[i].Name+" redeclares common."+shared)
+			}
+		}
+		return true
+	})
+
+	return found, nil
+}
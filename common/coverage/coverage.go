@@ -0,0 +1,95 @@
+// Package coverage helps collecting the coverage profiles of the binaries built with -cover, which
+// end-to-end tests use to measure how much of the code they exercise.
+package coverage
+
+import (
+	"context"
+	"os"
+	"runtime/coverage"
+	"sync"
+	"time"
+)
+
+// FlushIntervalEnv is the environment variable overriding how often the coverage counters are written, as
+// a duration such as "5s". Zero disables the periodic writes, leaving only the ones on start and stop.
+const FlushIntervalEnv = "UP4W_COVERAGE_FLUSH_INTERVAL"
+
+// defaultFlushInterval is how often the coverage counters are written by default.
+const defaultFlushInterval = time.Minute
+
+// Start writes the coverage counters of the binary into $GOCOVERDIR, and then periodically (see
+// FlushIntervalEnv) until the context is cancelled or stop is called, so that they are not lost when the
+// process is killed instead of exiting (e.g. the agent being stopped with Stop-Process, or WSL shutting
+// down the distro). Calling stop on shutdown writes them one last time, and releases the resources.
+//
+// It does nothing if GOCOVERDIR is not set or the binary was not built with -cover -covermode=atomic,
+// in which case the counters are only written when the process exits.
+func Start(ctx context.Context) (stop func()) {
+	dir := os.Getenv("GOCOVERDIR")
+	if dir == "" {
+		return func() {}
+	}
+
+	if err := flush(dir); err != nil {
+		return func() {}
+	}
+
+	interval := flushInterval()
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		if interval <= 0 {
+			<-ctx.Done()
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = flush(dir)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+			_ = flush(dir)
+		})
+	}
+}
+
+// flushInterval returns the interval set in FlushIntervalEnv, or the default one if it is not set or invalid.
+func flushInterval() time.Duration {
+	v := os.Getenv(FlushIntervalEnv)
+	if v == "" {
+		return defaultFlushInterval
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return defaultFlushInterval
+	}
+
+	return d
+}
+
+// flush writes the counters into dir and resets them, so that every file holds the increments since
+// the previous one and merging them adds up to the real counts.
+func flush(dir string) error {
+	if err := coverage.WriteCountersDir(dir); err != nil {
+		return err
+	}
+	return coverage.ClearCounters()
+}
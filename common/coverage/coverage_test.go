@@ -0,0 +1,144 @@
+package coverage_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/coverage"
+	"github.com/stretchr/testify/require"
+)
+
+// Test binaries cannot write their counters on demand, even when built with -cover, so only the
+// cases in which Start does nothing can be exercised in process. See TestStartInstrumented for the rest.
+func TestStart(t *testing.T) {
+	testCases := map[string]struct {
+		noCoverDir bool
+	}{
+		"No-op without GOCOVERDIR":                  {noCoverDir: true},
+		"No-op when the counters cannot be written": {},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			dir := t.TempDir()
+			if tc.noCoverDir {
+				t.Setenv("GOCOVERDIR", "")
+			} else {
+				t.Setenv("GOCOVERDIR", dir)
+			}
+
+			stop := coverage.Start(ctx)
+			stop()
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err, "Setup: could not read the coverage directory")
+			require.Empty(t, entries, "Start should not write anything into the coverage directory")
+		})
+	}
+}
+
+func TestStartInstrumented(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("Skipping test that builds an instrumented binary in short mode")
+	}
+
+	bin := filepath.Join(t.TempDir(), "flusher")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+
+	//nolint:gosec // The arguments are controlled by the test.
+	out, err := exec.Command("go", "build", "-cover", "-covermode=atomic", "-o", bin, "./testdata/flusher").CombinedOutput()
+	require.NoError(t, err, "Setup: could not build the instrumented binary: %s", out)
+
+	testCases := map[string]struct {
+		interval string
+		stop     bool
+
+		wantCounters int
+	}{
+		"Success writing the counters periodically":  {interval: "100ms", wantCounters: 3},
+		"Success writing the counters when stopped":  {interval: "0", stop: true, wantCounters: 2},
+		"Success writing the counters only on start": {interval: "0", wantCounters: 1},
+		"Success with an invalid interval":           {interval: "not a duration", wantCounters: 1},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			var args []string
+			if tc.stop {
+				args = append(args, "stop")
+			}
+
+			//nolint:gosec // The arguments are controlled by the test.
+			cmd := exec.Command(bin, args...)
+			cmd.Env = append(os.Environ(), "GOCOVERDIR="+dir, coverage.FlushIntervalEnv+"="+tc.interval)
+			require.NoError(t, cmd.Start(), "Setup: could not start the instrumented binary")
+
+			// The process is killed, so that only the counters written by Start are found.
+			defer func() {
+				_ = cmd.Process.Kill()
+				_ = cmd.Wait()
+			}()
+
+			require.Eventually(t, func() bool {
+				return len(counters(t, dir)) >= tc.wantCounters
+			}, 20*time.Second, 50*time.Millisecond, "Start should have written the coverage counters %d times", tc.wantCounters)
+
+			if tc.interval == "0" {
+				time.Sleep(500 * time.Millisecond)
+				require.Len(t, counters(t, dir), tc.wantCounters, "Start should not write the counters periodically when the interval is zero")
+			}
+
+			require.FileExists(t, filepath.Join(dir, metaFile(t, dir)), "The coverage metadata should have been written along the counters")
+		})
+	}
+}
+
+// counters returns the names of the files with coverage counters in dir.
+func counters(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err, "could not read the coverage directory")
+
+	var names []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "covcounters.") {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+// metaFile returns the name of the file with the coverage metadata in dir.
+func metaFile(t *testing.T, dir string) string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err, "could not read the coverage directory")
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "covmeta.") {
+			return e.Name()
+		}
+	}
+
+	require.Fail(t, "no coverage metadata file was written")
+	return ""
+}
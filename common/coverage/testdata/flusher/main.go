@@ -0,0 +1,21 @@
+// Package main starts writing its coverage counters like the agent and the WSL Pro service do, stopping
+// right away if its first argument is "stop", and then waits to be killed.
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/coverage"
+)
+
+func main() {
+	stop := coverage.Start(context.Background())
+
+	if len(os.Args) > 1 && os.Args[1] == "stop" {
+		stop()
+	}
+
+	time.Sleep(time.Hour)
+}
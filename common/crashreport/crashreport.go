@@ -0,0 +1,243 @@
+// Package crashreport writes a structured report of the panics of the long-running goroutines, so that the crashes
+// happening in the field can be diagnosed from the stack, the version and the events that led to them.
+//
+// A process installs its Reporter once at startup and every long-running goroutine defers Recover with the name of
+// the component it belongs to. The Reporter is also a logrus hook, which keeps the most recent log entries in a ring
+// buffer to attach them to the reports.
+package crashreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	// FilePrefix is the prefix of the file names of the crash reports.
+	FilePrefix = "crash-"
+
+	// defaultEvents is how many recent events are attached to the reports by default.
+	defaultEvents = 50
+
+	// uploadTimeout is how long uploading a report may take. The process is about to die, so it cannot wait long.
+	uploadTimeout = 10 * time.Second
+)
+
+// Report is the content of a crash report, written as JSON.
+type Report struct {
+	Component string    `json:"component"`
+	Version   string    `json:"version"`
+	Time      time.Time `json:"time"`
+	Panic     string    `json:"panic"`
+	Stack     string    `json:"stack"`
+	Events    []Event   `json:"events"`
+}
+
+// Event is a log entry that happened before the crash.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+// Reporter writes the crash reports of a process into a directory and keeps the recent events to attach to them.
+// It is safe for concurrent use.
+type Reporter struct {
+	dir     string
+	version string
+	upload  func(context.Context, []byte) error
+
+	// events is a ring buffer of the most recent events, where next is the index of the oldest one once it is full.
+	events []Event
+	next   int
+	full   bool
+	mu     sync.Mutex
+}
+
+type options struct {
+	events    int
+	uploadURL string
+}
+
+// Option is an optional argument for New.
+type Option func(*options)
+
+// WithEvents sets how many recent events are attached to the reports.
+func WithEvents(n int) Option {
+	return func(o *options) {
+		o.events = n
+	}
+}
+
+// WithUploadURL makes the reporter post the reports to url on top of writing them to disk. Reports are only ever
+// uploaded if this option is set with a non-empty url.
+func WithUploadURL(url string) Option {
+	return func(o *options) {
+		o.uploadURL = url
+	}
+}
+
+// New creates a reporter writing the reports of the given version of the process into dir.
+func New(dir, version string, args ...Option) *Reporter {
+	opts := options{events: defaultEvents}
+	for _, f := range args {
+		f(&opts)
+	}
+
+	r := &Reporter{
+		dir:     dir,
+		version: version,
+		events:  make([]Event, max(opts.events, 1)),
+	}
+
+	if opts.uploadURL != "" {
+		r.upload = func(ctx context.Context, body []byte) error {
+			return post(ctx, opts.uploadURL, body)
+		}
+	}
+
+	return r
+}
+
+// Levels implements logrus.Hook. Debug entries are left out so that they do not push the meaningful ones out of the
+// ring buffer.
+func (r *Reporter) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel, logrus.InfoLevel}
+}
+
+// Fire implements logrus.Hook by adding the entry to the recent events.
+func (r *Reporter) Fire(entry *logrus.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[r.next] = Event{Time: entry.Time, Level: entry.Level.String(), Message: entry.Message}
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.full = true
+	}
+	return nil
+}
+
+// recentEvents returns a copy of the recent events, from the oldest to the newest.
+func (r *Reporter) recentEvents() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]Event{}, r.events[:r.next]...)
+	}
+	return append(append([]Event{}, r.events[r.next:]...), r.events[:r.next]...)
+}
+
+// Write writes the report of the panic p of component, with its stack, and uploads it if the reporter has an
+// upload URL. It returns the path of the report.
+func (r *Reporter) Write(component string, p any, stack []byte) (path string, err error) {
+	defer decorate.OnError(&err, "could not write crash report of %s", component)
+
+	report := Report{
+		Component: component,
+		Version:   r.version,
+		Time:      time.Now(),
+		Panic:     fmt.Sprint(p),
+		Stack:     string(stack),
+		Events:    r.recentEvents(),
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(r.dir, 0700); err != nil {
+		return "", err
+	}
+
+	path = filepath.Join(r.dir, fmt.Sprintf("%s%s-%s.json", FilePrefix, component, report.Time.Format("20060102-150405.000000000")))
+	if err := os.WriteFile(path, out, 0600); err != nil {
+		return "", err
+	}
+
+	if r.upload != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+		defer cancel()
+		if err := r.upload(ctx, out); err != nil {
+			return path, fmt.Errorf("written to %s but could not upload it: %v", path, err)
+		}
+	}
+
+	return path, nil
+}
+
+// post sends the report in body to url.
+func post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %s", res.Status)
+	}
+	return nil
+}
+
+var (
+	installed   *Reporter
+	installedMu sync.RWMutex
+)
+
+// Install makes r the reporter of the process used by Recover and hooks it to the standard logger of logrus.
+// It is meant to be called once at startup. A nil reporter stops writing reports.
+func Install(r *Reporter) {
+	installedMu.Lock()
+	defer installedMu.Unlock()
+
+	installed = r
+	if r != nil {
+		logrus.AddHook(r)
+	}
+}
+
+// Recover must be deferred at the top of the long-running goroutines of component. On panic, it writes a crash report
+// with the reporter installed, if any, and panics again so that the process still crashes instead of carrying on
+// without the goroutine.
+func Recover(component string) {
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	// The stack is captured here, as panicking again replaces it with the one of this function.
+	stack := debug.Stack()
+
+	installedMu.RLock()
+	r := installed
+	installedMu.RUnlock()
+
+	if r != nil {
+		if path, err := r.Write(component, p, stack); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Crash report written to %s\n", path)
+		}
+	}
+
+	panic(p)
+}
@@ -0,0 +1,116 @@
+package crashreport_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		events     int
+		upload     bool
+		uploadCode int
+		breakDir   bool
+
+		wantEvents []string
+		wantErr    bool
+	}{
+		"Success with no events":                    {wantEvents: []string{}},
+		"Success with fewer events than capacity":   {events: 2, wantEvents: []string{"event 0", "event 1"}},
+		"Success keeps only the most recent events": {events: 5, wantEvents: []string{"event 2", "event 3", "event 4"}},
+		"Success uploading the report":              {upload: true, uploadCode: http.StatusOK, wantEvents: []string{}},
+
+		"Error when the directory cannot be created": {breakDir: true, wantErr: true},
+		"Error when the upload is refused":           {upload: true, uploadCode: http.StatusForbidden, wantEvents: []string{}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := filepath.Join(t.TempDir(), "crashes")
+			if tc.breakDir {
+				require.NoError(t, os.WriteFile(dir, []byte{}, 0600), "Setup: could not write the file that should break the directory")
+			}
+
+			var args []crashreport.Option
+			args = append(args, crashreport.WithEvents(3))
+
+			uploaded := make(chan []byte, 1)
+			if tc.upload {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, _ := io.ReadAll(r.Body)
+					uploaded <- body
+					w.WriteHeader(tc.uploadCode)
+				}))
+				t.Cleanup(server.Close)
+				args = append(args, crashreport.WithUploadURL(server.URL))
+			}
+
+			r := crashreport.New(dir, "1.2.3", args...)
+			for i := range tc.events {
+				require.NoError(t, r.Fire(&logrus.Entry{Time: time.Now(), Level: logrus.InfoLevel, Message: fmt.Sprintf("event %d", i)}), "Setup: Fire should not fail")
+			}
+
+			path, err := r.Write("test component", "boom", []byte("the stack"))
+			if tc.wantErr {
+				require.Error(t, err, "Write should have failed")
+				return
+			}
+			require.NoError(t, err, "Write should not fail")
+
+			out, err := os.ReadFile(path)
+			require.NoError(t, err, "Could not read the crash report")
+
+			var got crashreport.Report
+			require.NoError(t, json.Unmarshal(out, &got), "The crash report should be valid JSON")
+			require.Equal(t, "test component", got.Component, "The report should name the component")
+			require.Equal(t, "1.2.3", got.Version, "The report should have the version")
+			require.Equal(t, "boom", got.Panic, "The report should have the value of the panic")
+			require.Equal(t, "the stack", got.Stack, "The report should have the stack")
+
+			messages := []string{}
+			for _, e := range got.Events {
+				messages = append(messages, e.Message)
+			}
+			require.Equal(t, tc.wantEvents, messages, "The report should have the recent events, from the oldest to the newest")
+
+			if tc.upload {
+				require.JSONEq(t, string(out), string(<-uploaded), "The uploaded report should be the one written to disk")
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // Install changes the reporter of the whole process.
+func TestRecover(t *testing.T) {
+	dir := t.TempDir()
+	crashreport.Install(crashreport.New(dir, "1.2.3"))
+	t.Cleanup(func() { crashreport.Install(nil) })
+
+	require.PanicsWithValue(t, "boom", func() {
+		defer crashreport.Recover("test component")
+		panic("boom")
+	}, "Recover should panic again with the same value")
+
+	reports, err := filepath.Glob(filepath.Join(dir, crashreport.FilePrefix+"*.json"))
+	require.NoError(t, err, "Could not list the crash reports")
+	require.Len(t, reports, 1, "Recover should have written a crash report")
+
+	require.NotPanics(t, func() {
+		defer crashreport.Recover("test component")
+	}, "Recover should not panic when there is no panic")
+}
@@ -0,0 +1,122 @@
+// Package compression compresses the large messages between the agent and the WSL Pro services, such as
+// log lines, pushed files and diagnostics, while leaving the small ones as they are.
+//
+// Importing the package registers the compressor with gRPC. A stream may only use it once both ends
+// agreed to it in the handshake, with CAPABILITY_COMPRESSION.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// Name is the name the compressor is registered with, to pass to grpc.UseCompressor.
+const Name = "up4w-gzip"
+
+// DefaultThreshold is the size in bytes of the smallest message that is compressed by default. Smaller messages
+// gain too little from it to be worth the CPU time.
+const DefaultThreshold = 1024
+
+// The first byte of every message tells how the rest of it is encoded.
+const (
+	stored     byte = 0
+	compressed byte = 1
+)
+
+var threshold atomic.Int64
+
+func init() {
+	threshold.Store(DefaultThreshold)
+	encoding.RegisterCompressor(&compressor{})
+}
+
+// SetThreshold sets the size in bytes of the smallest message that is compressed. Zero or less restores
+// DefaultThreshold. It applies to the messages sent from then on.
+func SetThreshold(n int) {
+	if n <= 0 {
+		n = DefaultThreshold
+	}
+	threshold.Store(int64(n))
+}
+
+// Threshold returns the size in bytes of the smallest message that is compressed.
+func Threshold() int {
+	return int(threshold.Load())
+}
+
+// compressor is a gzip compressor that only compresses the messages of at least the threshold size.
+type compressor struct {
+	writers sync.Pool
+}
+
+// Name returns the name the compressor is registered with.
+func (c *compressor) Name() string {
+	return Name
+}
+
+// Compress returns a writer that buffers the message, and writes it to w, compressed or not depending on
+// its size, when closed.
+func (c *compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &messageWriter{compressor: c, dst: w}, nil
+}
+
+// Decompress returns a reader of the message in r.
+func (c *compressor) Decompress(r io.Reader) (io.Reader, error) {
+	var header [1]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("could not read the header of the message: %v", err)
+	}
+
+	switch header[0] {
+	case stored:
+		return r, nil
+	case compressed:
+		return gzip.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unknown encoding of the message: %d", header[0])
+	}
+}
+
+// messageWriter buffers a message until it is closed.
+type messageWriter struct {
+	compressor *compressor
+	dst        io.Writer
+	buf        bytes.Buffer
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Close writes the message, compressed if it is at least as large as the threshold.
+func (w *messageWriter) Close() (err error) {
+	if w.buf.Len() < Threshold() {
+		if _, err := w.dst.Write([]byte{stored}); err != nil {
+			return err
+		}
+		_, err := w.dst.Write(w.buf.Bytes())
+		return err
+	}
+
+	if _, err := w.dst.Write([]byte{compressed}); err != nil {
+		return err
+	}
+
+	gz, ok := w.compressor.writers.Get().(*gzip.Writer)
+	if !ok {
+		gz = gzip.NewWriter(w.dst)
+	} else {
+		gz.Reset(w.dst)
+	}
+	defer w.compressor.writers.Put(gz)
+
+	_, err = gz.Write(w.buf.Bytes())
+	return errors.Join(err, gz.Close())
+}
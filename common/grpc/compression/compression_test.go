@@ -0,0 +1,86 @@
+package compression_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/grpc/compression"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestCompression(t *testing.T) {
+	testCases := map[string]struct {
+		size      int
+		threshold int
+
+		wantCompressed bool
+	}{
+		"Success storing an empty message":                       {},
+		"Success storing a message below the threshold":          {size: compression.DefaultThreshold - 1},
+		"Success compressing a message at the threshold":         {size: compression.DefaultThreshold, wantCompressed: true},
+		"Success compressing a large message":                    {size: 1 << 20, wantCompressed: true},
+		"Success storing a message below a custom threshold":     {size: 2048, threshold: 4096},
+		"Success compressing a message above a custom threshold": {size: 512, threshold: 256, wantCompressed: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// The threshold is global, so the test cases cannot run in parallel.
+			compression.SetThreshold(tc.threshold)
+			defer compression.SetThreshold(0)
+
+			c := encoding.GetCompressor(compression.Name)
+			require.NotNil(t, c, "The compressor should be registered with gRPC")
+
+			msg := []byte(strings.Repeat("a log line that repeats itself\n", tc.size/31+1)[:tc.size])
+
+			var wire bytes.Buffer
+			w, err := c.Compress(&wire)
+			require.NoError(t, err, "Compress should return no error")
+			_, err = w.Write(msg)
+			require.NoError(t, err, "Writing the message should return no error")
+			require.NoError(t, w.Close(), "Closing the writer should return no error")
+
+			if tc.wantCompressed {
+				require.Less(t, wire.Len(), len(msg), "The message should have been compressed")
+			} else {
+				require.Equal(t, len(msg)+1, wire.Len(), "The message should have been stored as is, after the header")
+			}
+
+			r, err := c.Decompress(&wire)
+			require.NoError(t, err, "Decompress should return no error")
+			got, err := io.ReadAll(r)
+			require.NoError(t, err, "Reading the message should return no error")
+			require.Equal(t, msg, got, "The message should be the same after a round trip")
+		})
+	}
+}
+
+func TestDecompressErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		wire []byte
+	}{
+		"Error when the message is empty":              {wire: []byte{}},
+		"Error when the encoding is unknown":           {wire: []byte{42, 'a'}},
+		"Error when the compressed message is corrupt": {wire: []byte{1, 'n', 'o', 't', ' ', 'g', 'z', 'i', 'p'}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := encoding.GetCompressor(compression.Name)
+
+			r, err := c.Decompress(bytes.NewReader(tc.wire))
+			if err == nil {
+				_, err = io.ReadAll(r)
+			}
+			require.Error(t, err, "Decompressing an invalid message should return an error")
+		})
+	}
+}
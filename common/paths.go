@@ -0,0 +1,36 @@
+package common
+
+import "path/filepath"
+
+// The directories and files the agent shares with the other components are found the same way on both sides: the
+// agent from %UserProfile% and %LocalAppData%, and the WSL Pro service from where the Windows user profile is
+// mounted in the distro. These helpers keep their naming in one place.
+
+// PublicDir returns the directory, inside the user profile directory home, where the agent shares the data the
+// other components need, such as its address and certificates.
+func PublicDir(home string) string {
+	return filepath.Join(home, UserProfileDir)
+}
+
+// StatusPublicDir returns the directory, inside the user profile directory home, where the agent shares the
+// address and certificates of its read-only status API.
+func StatusPublicDir(home string) string {
+	return filepath.Join(home, StatusDir)
+}
+
+// PrivateDir returns the directory, inside the local application data directory localAppData, where the agent
+// keeps its private data by default.
+func PrivateDir(localAppData string) string {
+	return filepath.Join(localAppData, LocalAppDataDir)
+}
+
+// AddressFile returns the path of the file, inside a public directory, hosting the address of the GRPC server
+// of the agent.
+func AddressFile(publicDir string) string {
+	return filepath.Join(publicDir, ListeningPortFileName)
+}
+
+// CertificatesPath returns the path of the directory, inside a public directory, where the certificates are stored.
+func CertificatesPath(publicDir string) string {
+	return filepath.Join(publicDir, CertificatesDir)
+}
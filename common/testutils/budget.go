@@ -0,0 +1,33 @@
+package testutils
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// PerformanceBudgetsEnv is the environment variable used to indicate go test that the
+	// performance budgets should be enforced.
+	PerformanceBudgetsEnv = `TESTS_PERFORMANCE_BUDGETS`
+)
+
+// RequirePerformanceBudget runs the benchmark and fails the test if an operation takes longer than
+// the budget on average. It is skipped unless PerformanceBudgetsEnv is set, as timings are only
+// meaningful without the race detector, coverage, or other tests running in parallel.
+func RequirePerformanceBudget(t *testing.T, budget time.Duration, benchmark func(*testing.B)) {
+	t.Helper()
+
+	if os.Getenv(PerformanceBudgetsEnv) == "" {
+		t.Skipf("Performance budgets are only enforced with %s set", PerformanceBudgetsEnv)
+	}
+
+	res := testing.Benchmark(benchmark)
+	require.NotZero(t, res.N, "The benchmark failed or was skipped")
+
+	got := time.Duration(res.NsPerOp())
+	t.Logf("%s per operation over %d operations (budget: %s)", got, res.N, budget)
+	require.LessOrEqual(t, got, budget, "An operation took longer than its performance budget")
+}
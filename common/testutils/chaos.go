@@ -0,0 +1,205 @@
+package testutils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Chaos injects network faults into connections, so that tests can simulate network partitions between
+// the agent and the WSL Pro service. Faults only affect what is sent by the side that accepted (see Listen)
+// or dialed (see Dial) the connection. The zero value injects no faults.
+type Chaos struct {
+	delay       time.Duration
+	partitioned bool
+	conns       map[*chaosConn]struct{}
+
+	mu sync.Mutex
+}
+
+// Delay holds back every write of the agent for d before sending it. Zero disables the delay.
+func (c *Chaos) Delay(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.delay = d
+}
+
+// Truncate cuts the current connections after n more bytes have been written to them: the message in
+// flight is sent incomplete, and then the connection is reset.
+func (c *Chaos) Truncate(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for conn := range c.conns {
+		conn.setBudget(n)
+	}
+}
+
+// Reset abruptly resets the current connections, as if the network dropped mid-stream.
+func (c *Chaos) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resetAll()
+}
+
+// Partition resets the current connections and drops the new ones until Heal is called.
+func (c *Chaos) Partition() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.partitioned = true
+	c.resetAll()
+}
+
+// Heal stops injecting faults into new writes and connections.
+func (c *Chaos) Heal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.delay = 0
+	c.partitioned = false
+	for conn := range c.conns {
+		conn.setBudget(-1)
+	}
+}
+
+// resetAll resets all connections. The caller must hold the lock.
+func (c *Chaos) resetAll() {
+	for conn := range c.conns {
+		conn.reset()
+	}
+	c.conns = nil
+}
+
+func (c *Chaos) getDelay() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.delay
+}
+
+// track registers a new connection, unless the network is partitioned.
+func (c *Chaos) track(conn *chaosConn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.partitioned {
+		return false
+	}
+
+	if c.conns == nil {
+		c.conns = make(map[*chaosConn]struct{})
+	}
+	c.conns[conn] = struct{}{}
+	return true
+}
+
+func (c *Chaos) untrack(conn *chaosConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.conns, conn)
+}
+
+// Listen wraps the listener so that the connections it accepts are subject to chaos.
+func (c *Chaos) Listen(lis net.Listener) net.Listener {
+	return &chaosListener{Listener: lis, chaos: c}
+}
+
+// Dial connects to the address so that the connection is subject to chaos. It is meant to be used
+// with grpc.WithContextDialer. Dialing fails while the network is partitioned.
+func (c *Chaos) Dial(ctx context.Context, addr string) (net.Conn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cc := &chaosConn{Conn: conn, chaos: c, budget: -1}
+	if !c.track(cc) {
+		cc.reset()
+		return nil, errors.New("chaos: network partitioned")
+	}
+
+	return cc, nil
+}
+
+type chaosListener struct {
+	net.Listener
+	chaos *Chaos
+}
+
+func (l *chaosListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		cc := &chaosConn{Conn: conn, chaos: l.chaos, budget: -1}
+		if !l.chaos.track(cc) {
+			// Partitioned: the connection never makes it through.
+			cc.reset()
+			continue
+		}
+
+		return cc, nil
+	}
+}
+
+// chaosConn is a connection whose writes can be delayed and truncated.
+type chaosConn struct {
+	net.Conn
+	chaos *Chaos
+
+	// budget is the number of bytes that can still be written before resetting the connection. Negative means unlimited.
+	budget int
+	mu     sync.Mutex
+}
+
+func (c *chaosConn) setBudget(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.budget = n
+}
+
+func (c *chaosConn) Write(b []byte) (int, error) {
+	if d := c.chaos.getDelay(); d > 0 {
+		time.Sleep(d)
+	}
+
+	c.mu.Lock()
+	budget := c.budget
+	if budget >= 0 && budget < len(b) {
+		c.budget = 0
+	} else if budget >= 0 {
+		c.budget -= len(b)
+	}
+	c.mu.Unlock()
+
+	if budget < 0 || budget >= len(b) {
+		return c.Conn.Write(b)
+	}
+
+	n, _ := c.Conn.Write(b[:budget])
+	c.chaos.untrack(c)
+	c.reset()
+	return n, errors.New("chaos: connection truncated")
+}
+
+func (c *chaosConn) Close() error {
+	c.chaos.untrack(c)
+	return c.Conn.Close()
+}
+
+// reset closes the connection sending a TCP reset rather than a graceful shutdown.
+func (c *chaosConn) reset() {
+	if tcp, ok := c.Conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	_ = c.Conn.Close()
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -19,6 +20,20 @@ func Obfuscate(contents string) string {
 	return contents[0:endsToReveal] + strings.Repeat("*", asterisksLength) + contents[asterisksLength+endsToReveal:]
 }
 
+// RedactURL returns the URL with its user information, such as the credentials of a proxy, hidden so that
+// it is suitable for logging. URLs that cannot be parsed are hidden altogether, as they may contain credentials too.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid URL>"
+	}
+
+	if u.User != nil {
+		u.User = url.User("REDACTED")
+	}
+	return u.String()
+}
+
 // WSLLauncher translates the name of an Ubuntu WSL distro into the base path for its launcher.
 func WSLLauncher(distroName string) (string, error) {
 	r := strings.NewReplacer(
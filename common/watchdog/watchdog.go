@@ -0,0 +1,169 @@
+// Package watchdog detects the goroutines that stop making progress, such as those of a stream stuck on a
+// deadlock, so that a supervisor can tear down and rebuild what they belong to instead of leaving it as a
+// zombie that looks alive but does nothing.
+//
+// Every goroutine watched has a Heart. It is only expected to beat while it is busy: between Start and Done,
+// it must Beat at least once per timeout, or it is considered stalled. Goroutines waiting for their peer,
+// which can legitimately take forever, are left out of Start/Done sections.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Watchdog watches the hearts of a group of goroutines. It is safe for concurrent use.
+type Watchdog struct {
+	timeout time.Duration
+
+	hearts []*Heart
+	mu     sync.Mutex
+}
+
+// New creates a watchdog that considers stalled the hearts busy for longer than timeout without beating.
+func New(timeout time.Duration) *Watchdog {
+	return &Watchdog{timeout: timeout}
+}
+
+// Heart is the heartbeat of a goroutine. Its methods are no-ops on a nil Heart, so that goroutines can be
+// run without being watched.
+type Heart struct {
+	name string
+
+	// busy is the number of Start/Done sections in progress, as a heart can be shared by goroutines that
+	// play the same role, e.g. all the writers of a stream.
+	busy int
+	last time.Time
+	mu   sync.Mutex
+}
+
+// Heart registers a new heart with the name of the goroutine it belongs to, as it appears in the diagnostics.
+// It is not busy yet. It is a no-op returning nil on a nil watchdog.
+func (w *Watchdog) Heart(name string) *Heart {
+	if w == nil {
+		return nil
+	}
+
+	h := &Heart{name: name}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.hearts = append(w.hearts, h)
+	return h
+}
+
+// Start marks the beginning of some work that must beat at least once per timeout until Done is called.
+func (h *Heart) Start() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.busy++
+	h.last = time.Now()
+}
+
+// Beat records progress in the work in progress.
+func (h *Heart) Beat() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.last = time.Now()
+}
+
+// Done marks the end of some work started with Start.
+func (h *Heart) Done() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.busy > 0 {
+		h.busy--
+	}
+	h.last = time.Now()
+}
+
+// stalledFor returns how long the heart has been busy without beating, or 0 if it is not busy.
+func (h *Heart) stalledFor(now time.Time) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.busy == 0 {
+		return 0
+	}
+	return now.Sub(h.last)
+}
+
+// Stalled returns the description of the hearts that have been busy for longer than the timeout without
+// beating, sorted by name.
+func (w *Watchdog) Stalled() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	var stalled []string
+	for _, h := range w.hearts {
+		if d := h.stalledFor(now); d > w.timeout {
+			stalled = append(stalled, fmt.Sprintf("%s (no heartbeat for %s)", h.name, d.Truncate(time.Second)))
+		}
+	}
+
+	slices.Sort(stalled)
+	return stalled
+}
+
+// Run checks the hearts several times per timeout, until the context is cancelled, in which case it returns
+// nil, or some of them stall, in which case it returns a StallError with the diagnostics.
+func (w *Watchdog) Run(ctx context.Context) error {
+	t := time.NewTicker(w.timeout / 4)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+
+		if stalled := w.Stalled(); len(stalled) > 0 {
+			return StallError{Stalled: stalled, Goroutines: goroutines()}
+		}
+	}
+}
+
+// StallError is the diagnostics of the goroutines that stalled.
+type StallError struct {
+	// Stalled describes the goroutines that stalled.
+	Stalled []string
+
+	// Goroutines is the stack trace of every goroutine of the process when they were found stalled.
+	Goroutines string
+}
+
+func (err StallError) Error() string {
+	return fmt.Sprintf("stalled: %s", strings.Join(err.Stalled, ", "))
+}
+
+// goroutines returns the stack trace of every goroutine of the process.
+func goroutines() string {
+	var b strings.Builder
+	if err := pprof.Lookup("goroutine").WriteTo(&b, 2); err != nil {
+		return fmt.Sprintf("could not dump goroutines: %v", err)
+	}
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package watchdog_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/watchdog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStalled(t *testing.T) {
+	t.Parallel()
+
+	const timeout = 50 * time.Millisecond
+
+	testCases := map[string]struct {
+		start int
+		done  int
+		beat  bool
+
+		wantStalled bool
+	}{
+		"Idle hearts never stall":                  {},
+		"Busy hearts that beat do not stall":       {start: 1, beat: true},
+		"Hearts done with their work do not stall": {start: 1, done: 1},
+
+		"Busy hearts that do not beat stall":                         {start: 1, wantStalled: true},
+		"Shared hearts stall while any of their users is still busy": {start: 2, done: 1, wantStalled: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := watchdog.New(timeout)
+			h := w.Heart("stream reader")
+			idle := w.Heart("stream writer")
+			idle.Start()
+			idle.Done()
+
+			for range tc.start {
+				h.Start()
+			}
+			for range tc.done {
+				h.Done()
+			}
+
+			deadline := time.Now().Add(2 * timeout)
+			for time.Now().Before(deadline) {
+				if tc.beat {
+					h.Beat()
+				}
+				time.Sleep(timeout / 10)
+			}
+
+			got := w.Stalled()
+			if !tc.wantStalled {
+				require.Empty(t, got, "No heart should have stalled")
+				return
+			}
+			require.Len(t, got, 1, "Only the busy heart should have stalled")
+			require.Contains(t, got[0], "stream reader", "The stalled heart should be described by its name")
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stall bool
+
+		wantErr bool
+	}{
+		"Success returning when the context is cancelled": {},
+
+		"Error when a heart stalls": {stall: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			w := watchdog.New(50 * time.Millisecond)
+			if tc.stall {
+				w.Heart("stream dispatcher").Start()
+			}
+
+			err := w.Run(ctx)
+			if !tc.wantErr {
+				require.NoError(t, err, "Run should return no error when the context is cancelled")
+				return
+			}
+
+			require.Error(t, err, "Run should return an error when a heart stalls")
+			require.NoError(t, ctx.Err(), "Run should return as soon as a heart stalls")
+
+			var stall watchdog.StallError
+			require.True(t, errors.As(err, &stall), "Run should return a StallError")
+			require.Len(t, stall.Stalled, 1, "The stalled heart should be reported")
+			require.Contains(t, stall.Stalled[0], "stream dispatcher", "The stalled heart should be described by its name")
+			require.Contains(t, stall.Goroutines, "TestRun", "The diagnostics should contain the stack of every goroutine")
+		})
+	}
+}
+
+func TestNilHeart(t *testing.T) {
+	t.Parallel()
+
+	var w *watchdog.Watchdog
+	h := w.Heart("unwatched")
+	require.Nil(t, h, "A nil watchdog should return nil hearts")
+
+	require.NotPanics(t, func() {
+		h.Start()
+		h.Beat()
+		h.Done()
+	}, "The methods of a nil heart should be no-ops")
+}
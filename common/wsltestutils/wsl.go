@@ -22,7 +22,7 @@ const (
 )
 
 // NonRegisteredDistro generates a random distroName and GUID but does not register them.
-func NonRegisteredDistro(t *testing.T) (distroName string, GUID string) {
+func NonRegisteredDistro(t testing.TB) (distroName string, GUID string) {
 	t.Helper()
 
 	distroName = RandomDistroName(t)
@@ -35,7 +35,7 @@ func NonRegisteredDistro(t *testing.T) (distroName string, GUID string) {
 }
 
 // RandomDistroName generates a distroName that is not registered guaranteed not to collide with the reserved distro IDs (such as Ubuntu-XX.YY or Ubuntu-Preview).
-func RandomDistroName(t *testing.T) (name string) {
+func RandomDistroName(t testing.TB) (name string) {
 	t.Helper()
 
 	p := regexp.MustCompile(`[^a-zA-Z0-9_\-\.]+`)
@@ -52,7 +52,7 @@ func RandomDistroName(t *testing.T) (name string) {
 // - Unregistered.
 //
 //nolint:revive // The context is better after the testing.T
-func DistroState(t *testing.T, ctx context.Context, distroName string) string {
+func DistroState(t testing.TB, ctx context.Context, distroName string) string {
 	t.Helper()
 
 	d := wsl.NewDistro(ctx, distroName)
@@ -63,7 +63,7 @@ func DistroState(t *testing.T, ctx context.Context, distroName string) string {
 
 // requireIsTestDistro requires a distroName to match those generated by the testutils.
 // It is intended to protect other distros in the machine.
-func requireIsTestDistro(t *testing.T, distroName string) {
+func requireIsTestDistro(t testing.TB, distroName string) {
 	t.Helper()
 
 	if !strings.HasPrefix(distroName, testDistroPrefix) {
@@ -74,7 +74,7 @@ func requireIsTestDistro(t *testing.T, distroName string) {
 // RegisterDistro registers a distro and returns its randomly-generated name and its GUID.
 //
 //nolint:revive // The context is better after the testing.T
-func RegisterDistro(t *testing.T, ctx context.Context, realDistro bool) (distroName string, GUID string) {
+func RegisterDistro(t testing.TB, ctx context.Context, realDistro bool) (distroName string, GUID string) {
 	t.Helper()
 
 	distroName = RandomDistroName(t)
@@ -85,7 +85,7 @@ func RegisterDistro(t *testing.T, ctx context.Context, realDistro bool) (distroN
 // UnregisterDistro unregisters a WSL distro. Errors are ignored.
 //
 //nolint:revive // The context is better after the testing.T
-func UnregisterDistro(t *testing.T, ctx context.Context, distroName string) {
+func UnregisterDistro(t testing.TB, ctx context.Context, distroName string) {
 	t.Helper()
 
 	requireIsTestDistro(t, distroName)
@@ -97,7 +97,7 @@ func UnregisterDistro(t *testing.T, ctx context.Context, distroName string) {
 // ReregisterDistro unregister, then registers the same distro again.
 //
 //nolint:revive // The context is better after the testing.T
-func ReregisterDistro(t *testing.T, ctx context.Context, distroName string, realDistro bool) (GUID string) {
+func ReregisterDistro(t testing.TB, ctx context.Context, distroName string, realDistro bool) (GUID string) {
 	t.Helper()
 
 	UnregisterDistro(t, ctx, distroName)
@@ -108,7 +108,7 @@ func ReregisterDistro(t *testing.T, ctx context.Context, distroName string, real
 // Wrapper for `wsl -t distro`.
 //
 //nolint:revive // The context is better after the testing.T
-func TerminateDistro(t *testing.T, ctx context.Context, distroName string) {
+func TerminateDistro(t testing.TB, ctx context.Context, distroName string) {
 	t.Helper()
 
 	requireIsTestDistro(t, distroName)
@@ -118,7 +118,7 @@ func TerminateDistro(t *testing.T, ctx context.Context, distroName string) {
 }
 
 //nolint:revive // The context is better after the testing.T
-func registerDistro(t *testing.T, ctx context.Context, distroName string, realDistro bool) (GUID string) {
+func registerDistro(t testing.TB, ctx context.Context, distroName string, realDistro bool) (GUID string) {
 	t.Helper()
 
 	if !wsl.MockAvailable() {
@@ -18,7 +18,7 @@ import (
 // If the rootfs is an empty string, an empty tarball will be used.
 //
 //nolint:revive // The context is better after the testing.T
-func PowershellImportDistro(t *testing.T, ctx context.Context, distroName string, rootFsPath string) (GUID string) {
+func PowershellImportDistro(t testing.TB, ctx context.Context, distroName string, rootFsPath string) (GUID string) {
 	t.Helper()
 	tmpDir := t.TempDir()
 
@@ -59,7 +59,7 @@ func PowershellImportDistro(t *testing.T, ctx context.Context, distroName string
 
 // powershellOutputf runs the command (with any printf-style directives and args). It fails if the
 // return value of the command is non-zero. Otherwise, it returns its combined stdout and stderr.
-func powershellOutputf(t *testing.T, command string, args ...any) string {
+func powershellOutputf(t testing.TB, command string, args ...any) string {
 	t.Helper()
 
 	cmd := fmt.Sprintf(command, args...)
@@ -1,6 +1,11 @@
 // Package contractsapi exports some constants defining the Contracts Server backend REST API
+//
+// There is no endpoint to report seats: instances are counted by the contract server when their
+// own Ubuntu Pro client attaches them, so the agent never reports its instances on their behalf.
 package contractsapi
 
+import "time"
+
 const (
 	// Version is the current Contracts Server REST API version.
 	Version = "/v1"
@@ -9,11 +14,17 @@ const (
 	TokenPath = "/token"
 	// SubscriptionPath is the path where clients should POST the user JWT to notify the CS backend of changes in the current user subscription.
 	SubscriptionPath = "/subscription"
+	// ContractPath is the path where clients should GET the contract of a Pro token, passed as a bearer token.
+	ContractPath = "/contract"
 
 	// TokenMaxSize is a safe token response size - tests with the real MS APIs suggested that those tokens will stay in between 1.2kB to 1.7kB.
 	// Our Pro Token is much, much smaller.
 	TokenMaxSize = 4096
 
+	// ContractMaxSize is a safe contract response size. Contracts list every entitlement with its
+	// directives and obligations, so they are much bigger than tokens.
+	ContractMaxSize = 256 * 1024
+
 	//nolint:gosec // G101 false positive, this is not a credential
 	// ADTokenKey is the JSON key of the response payload of the /token endpoint.
 	ADTokenKey = "azure_ad_token"
@@ -45,3 +56,34 @@ type SyncUserSubscriptionsResponseItem struct {
 type SyncUserSubscriptionsResponse struct {
 	SubscriptionEntitlements map[string]SyncUserSubscriptionsResponseItem `json:"subscriptionEntitlements"`
 }
+
+// ContractResponse is the structure for json response for /v1/contract, the endpoint the Ubuntu Pro
+// client queries to preview the contract of a token before attaching (see `pro status --simulate-with-token`).
+//
+// Only the fields relevant to Ubuntu Pro for WSL are decoded. Must keep in sync with
+// https://github.com/canonical/ubuntu-pro-client/blob/main/uaclient/contract.py
+type ContractResponse struct {
+	ContractInfo ContractInfo `json:"contractInfo"`
+}
+
+// ContractInfo describes a contract and the entitlements it grants.
+type ContractInfo struct {
+	ID                   string        `json:"id"`
+	Name                 string        `json:"name"`
+	EffectiveFrom        time.Time     `json:"effectiveFrom"`
+	EffectiveTo          time.Time     `json:"effectiveTo"`
+	ResourceEntitlements []Entitlement `json:"resourceEntitlements"`
+}
+
+// Entitlement is an individual service (e.g. esm-infra, livepatch, support) of a contract.
+type Entitlement struct {
+	Type        string      `json:"type"`
+	Entitled    bool        `json:"entitled"`
+	Affordances Affordances `json:"affordances"`
+}
+
+// Affordances are the entitlement-specific details of what the contract grants.
+type Affordances struct {
+	// SupportLevel is only set for the support entitlement.
+	SupportLevel string `json:"supportLevel,omitempty"`
+}
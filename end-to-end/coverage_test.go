@@ -0,0 +1,138 @@
+package endtoend_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/coverage"
+	"github.com/ubuntu/gowsl"
+)
+
+const (
+	// distroCoverageDir is where the WSL Pro Service writes its coverage profiles inside the distros.
+	distroCoverageDir = "/var/lib/wsl-pro-service-coverage"
+
+	// distroCoverageDropIn is the systemd drop-in that points the WSL Pro Service to distroCoverageDir.
+	distroCoverageDropIn = "/etc/systemd/system/wsl-pro.service.d/coverage.conf"
+)
+
+// coverDir is the absolute path where coverage profiles are collected. Empty if coverage is not enabled.
+var coverDir string
+
+// setupCoverage makes coverDir absolute, and creates it.
+func setupCoverage(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("could not make coverage directory path absolute: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "windows-agent"), 0700); err != nil {
+		return fmt.Errorf("could not create coverage directory: %v", err)
+	}
+
+	coverDir = dir
+	return nil
+}
+
+// coverageFlushInterval is how often the binaries write their coverage counters during the tests, which kill
+// the agent and shut the distros down often, instead of letting the binaries exit.
+const coverageFlushInterval = "5s"
+
+// agentCoverageEnviron returns the environment overrides for the agent to write its coverage profiles.
+func agentCoverageEnviron() []string {
+	if coverDir == "" {
+		return nil
+	}
+	return []string{
+		"GOCOVERDIR=" + filepath.Join(coverDir, "windows-agent"),
+		coverage.FlushIntervalEnv + "=" + coverageFlushInterval,
+	}
+}
+
+// enableDistroCoverage configures the WSL Pro Service in the distro to write its coverage profiles.
+func enableDistroCoverage(ctx context.Context, d gowsl.Distro) error {
+	if coverDir == "" {
+		return nil
+	}
+
+	cmd := fmt.Sprintf(`bash -ec "mkdir -p %s $(dirname %s) && printf '[Service]\nEnvironment=GOCOVERDIR=%s %s=%s\n' > %s"`,
+		distroCoverageDir, distroCoverageDropIn, distroCoverageDir, coverage.FlushIntervalEnv, coverageFlushInterval, distroCoverageDropIn)
+
+	if out, err := d.Command(ctx, cmd).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not enable coverage of wsl-pro-service: %v. %s", err, out)
+	}
+
+	return nil
+}
+
+// collectDistroCoverage stops the WSL Pro Service in the distro, so that it writes its last coverage
+// counters, and copies its coverage profiles out of the distro.
+//
+//nolint:revive // testing.T must precede the context
+func collectDistroCoverage(t *testing.T, ctx context.Context, d gowsl.Distro) {
+	t.Helper()
+
+	if coverDir == "" {
+		return
+	}
+
+	dst := filepath.Join(coverDir, "wsl-pro-service", d.Name())
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		t.Logf("Cleanup: could not create coverage directory for distro %q: %v", d.Name(), err)
+		return
+	}
+
+	cmd := fmt.Sprintf(`bash -ec "systemctl stop wsl-pro.service && cp -r %s/. $(wslpath -ua '%s')"`, distroCoverageDir, dst)
+	if out, err := d.Command(ctx, cmd).CombinedOutput(); err != nil {
+		t.Logf("Cleanup: could not collect coverage of wsl-pro-service in distro %q: %v. %s", d.Name(), err, out)
+	}
+}
+
+// mergeCoverage merges the coverage profiles collected from the agent and the distros into a single
+// profile in coverDir, and writes a text report that can be used with "go tool cover".
+func mergeCoverage(ctx context.Context) error {
+	if coverDir == "" {
+		return nil
+	}
+
+	inputs := []string{filepath.Join(coverDir, "windows-agent")}
+
+	distros, err := filepath.Glob(filepath.Join(coverDir, "wsl-pro-service", "*"))
+	if err != nil {
+		return fmt.Errorf("could not find the coverage of wsl-pro-service: %v", err)
+	}
+	inputs = append(inputs, distros...)
+
+	merged := filepath.Join(coverDir, "merged")
+	if err := os.MkdirAll(merged, 0700); err != nil {
+		return fmt.Errorf("could not create directory for the merged coverage: %v", err)
+	}
+
+	in := "-i=" + strings.Join(inputs, ",")
+	if out, err := exec.CommandContext(ctx, "go", "tool", "covdata", "merge", in, "-o="+merged).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not merge coverage profiles: %v. %s", err, out)
+	}
+
+	profile := filepath.Join(coverDir, "coverage.out")
+	if out, err := exec.CommandContext(ctx, "go", "tool", "covdata", "textfmt", "-i="+merged, "-o="+profile).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not write coverage profile: %v. %s", err, out)
+	}
+
+	out, err := exec.CommandContext(ctx, "go", "tool", "covdata", "percent", "-i="+merged).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not compute coverage: %v. %s", err, out)
+	}
+
+	log.Printf("Coverage profile written to %s:\n%s", profile, out)
+	return nil
+}
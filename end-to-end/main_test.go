@@ -49,6 +49,12 @@ const (
 	//
 	prebuiltPath = "UP4W_TEST_BUILD_PATH"
 
+	// coverageDirEnv is an env variable that, if set, builds the project with coverage instrumentation,
+	// and collects the coverage profiles of the agent and of the WSL Pro Service into that directory.
+	// They are merged into ${coverageDirEnv}/coverage.out after the tests. Prebuilt projects must have
+	// been built with coverage instrumentation as well (see tools/build).
+	coverageDirEnv = "UP4W_TEST_COVERAGE_DIR"
+
 	// referenceDistro is the WSL distro that will be used to generate the test image.
 	referenceDistro = "Ubuntu-Preview"
 
@@ -78,6 +84,10 @@ func TestMain(m *testing.M) {
 		log.Fatalf("Setup: %v\n", err)
 	}
 
+	if err := setupCoverage(os.Getenv(coverageDirEnv)); err != nil {
+		log.Fatalf("Setup: %v\n", err)
+	}
+
 	buildPath := os.Getenv(prebuiltPath)
 	if buildPath == "" {
 		path, err := buildProject(ctx)
@@ -103,6 +113,10 @@ func TestMain(m *testing.M) {
 
 	m.Run()
 
+	if err := mergeCoverage(ctx); err != nil {
+		log.Printf("Cleanup: coverage: %v\n", err)
+	}
+
 	if err := cleanupRegistry(); err != nil {
 		log.Printf("Cleanup: registry: %v\n", err)
 	}
@@ -160,13 +174,22 @@ func buildProject(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("could not create directory for Ubuntu Pro for WSL MSIX artifacts")
 	}
 
+	var coverageFlag string
+	if coverDir != "" {
+		coverageFlag = "-Coverage"
+	}
+
 	jobs := map[string]*exec.Cmd{
-		"Build Windows Agent":   powershellf(ctx, `..\tools\build\build-appx.ps1 -Mode end_to_end_tests -OutputDir %q`, winPath),
+		"Build Windows Agent":   powershellf(ctx, `..\tools\build\build-appx.ps1 -Mode end_to_end_tests -OutputDir %q %s`, winPath, coverageFlag),
 		"Build Wsl Pro Service": powershellf(ctx, `..\tools\build\build-deb.ps1 -OutputDir %q`, debPath),
 	}
 
 	results := make(chan error)
 	for jobName, cmd := range jobs {
+		if coverDir != "" {
+			cmd.Env = append(cmd.Environ(), "UP4W_COVERAGE=1")
+		}
+
 		go func() {
 			log.Printf("Started job: %s\n", jobName)
 
@@ -389,6 +412,11 @@ func generateTestImage(ctx context.Context, sourceDistro string) (path string, c
 
 	log.Printf("Setup: Installed wsl-pro-service into %q\n", sourceDistro)
 
+	if err := enableDistroCoverage(ctx, d); err != nil {
+		defer cleanup()
+		return "", nil, err
+	}
+
 	if err := wsl.Shutdown(ctx); err != nil {
 		defer cleanup()
 		return "", nil, fmt.Errorf("could not shut down WSL: %v", err)
@@ -63,6 +63,10 @@ func registerFromTestImage(t *testing.T, ctx context.Context) string {
 	defer t.Logf("Registered distro %q", distroName)
 
 	_ = wsltestutils.PowershellImportDistro(t, ctx, distroName, testImagePath)
+
+	// Registered after the import so that it runs before the distro is unregistered.
+	t.Cleanup(func() { collectDistroCoverage(t, ctx, gowsl.NewDistro(ctx, distroName)) })
+
 	return distroName
 }
 
@@ -88,6 +92,7 @@ func startAgent(t *testing.T, ctx context.Context, arg string, environ ...string
 	cmd.Stdout = &buff
 	cmd.Stderr = &buff
 
+	environ = append(environ, agentCoverageEnviron()...)
 	if environ != nil {
 		cmd.Env = append(cmd.Environ(), environ...)
 	}
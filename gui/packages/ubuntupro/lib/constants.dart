@@ -1,4 +1,6 @@
 /// The name of the file where the Agent's drop its service connection information.
+/// The named pipe the agent also serves on is only meant for its command line
+/// clients: the GUI keeps connecting through TCP.
 const kAddrFileName = '.ubuntupro/.address';
 
 /// Default window width.
@@ -81,6 +81,12 @@ class AgentApiClient {
   /// It's expected that an updated SubscriptionInfo will be returned.
   Future<SubscriptionInfo> notifyPurchase() => _client.notifyPurchase(Empty());
 
+  /// Streams the lifecycle events of the tasks of the [distro] as they happen, including the progress of
+  /// the long ones (e.g. upgrades, Landscape enrollment), so that it can be shown live.
+  /// Subscribing while a task reports progress starts with how far it is.
+  Stream<TaskEvent> watchTasks(String distro) =>
+      _client.watchTasks(WatchTasksRequest(distro: distro));
+
   Stream<ConnectionEvent> get onConnectionChanged =>
       mapGRPCConnectionEvents(_channel.onConnectionStateChanged);
 }
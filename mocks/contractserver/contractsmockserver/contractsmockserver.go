@@ -3,10 +3,17 @@
 package contractsmockserver
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"path"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	"github.com/canonical/ubuntu-pro-for-wsl/contractsapi"
@@ -20,18 +27,32 @@ const (
 	//nolint:gosec // G101 false positive, this is not a credential
 	// DefaultProToken is the value returned by default to the POST /susbcription request, encoded in a JSON object.
 	DefaultProToken = "CHx_ProToken"
+	// DefaultSupportLevel is the support level of the contract returned by the GET /contract request.
+	DefaultSupportLevel = "essential"
 )
 
+// DefaultContractExpiry is the expiry date of the contract returned by the GET /contract request.
+var DefaultContractExpiry = time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 // Server is a mock of the contract server, where its behaviour can be modified.
 type Server struct {
 	restserver.ServerBase
 	settings Settings
+
+	// exchanges counts the requests to the /susbcription endpoint.
+	exchanges atomic.Int64
 }
 
 // Settings contains the parameters for the Server.
 type Settings struct {
 	Token        restserver.Endpoint
 	Subscription restserver.Endpoint
+	// Contract responds with the contract of the Pro token in its OnSuccess value, and rejects any other token.
+	Contract restserver.Endpoint
+
+	// CacheControl is the Cache-Control header of the responses of the GET endpoints. Regardless of it,
+	// these responses carry an ETag, and requests whose If-None-Match matches it get a 304 Not Modified.
+	CacheControl string
 }
 
 // Unmarshal tricks the type system so marshalling YAML will just work when called from the restserver.Settings interface.
@@ -45,6 +66,7 @@ func DefaultSettings() Settings {
 	return Settings{
 		Token:        restserver.Endpoint{OnSuccess: restserver.Response{Value: DefaultADToken, Status: http.StatusOK}},
 		Subscription: restserver.Endpoint{OnSuccess: restserver.Response{Value: DefaultProToken, Status: http.StatusOK}},
+		Contract:     restserver.Endpoint{OnSuccess: restserver.Response{Value: DefaultProToken, Status: http.StatusOK}},
 	}
 }
 
@@ -60,11 +82,21 @@ func NewServer(s Settings) *Server {
 	if !s.Subscription.Disabled {
 		mux.HandleFunc(path.Join(contractsapi.Version, contractsapi.SubscriptionPath), sv.handleSubscription)
 	}
+
+	if !s.Contract.Disabled {
+		mux.HandleFunc(path.Join(contractsapi.Version, contractsapi.ContractPath), sv.handleContract)
+	}
 	sv.Mux = mux
 
 	return sv
 }
 
+// Exchanges returns how many requests to exchange a JWT for a Pro token the /susbcription endpoint received,
+// successful or not.
+func (s *Server) Exchanges() int {
+	return int(s.exchanges.Load())
+}
+
 // handleToken implements the /token endpoint.
 func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
 	if err := s.ValidateRequest(w, r, http.MethodGet, s.settings.Token); err != nil {
@@ -72,15 +104,13 @@ func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := fmt.Fprintf(w, `{%q: %q}`, contractsapi.ADTokenKey, s.settings.Token.OnSuccess.Value); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "failed to write the response: %v", err)
-		return
-	}
+	s.serveCacheable(w, r, []byte(fmt.Sprintf(`{%q: %q}`, contractsapi.ADTokenKey, s.settings.Token.OnSuccess.Value)))
 }
 
 // handleSubscription implements the /susbcription endpoint.
 func (s *Server) handleSubscription(w http.ResponseWriter, r *http.Request) {
+	s.exchanges.Add(1)
+
 	if err := s.ValidateRequest(w, r, http.MethodPost, s.settings.Subscription); err != nil {
 		fmt.Fprintf(w, "%v", err)
 		return
@@ -117,3 +147,64 @@ func (s *Server) handleSubscription(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// handleContract implements the /contract endpoint.
+func (s *Server) handleContract(w http.ResponseWriter, r *http.Request) {
+	if err := s.ValidateRequest(w, r, http.MethodGet, s.settings.Contract); err != nil {
+		fmt.Fprintf(w, "%v", err)
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token != s.settings.Contract.OnSuccess.Value {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintln(w, "Unauthorized")
+		return
+	}
+
+	resp := contractsapi.ContractResponse{
+		ContractInfo: contractsapi.ContractInfo{
+			ID:            "cAbCdEf",
+			Name:          "Ubuntu Pro for WSL",
+			EffectiveFrom: DefaultContractExpiry.AddDate(-1, 0, 0),
+			EffectiveTo:   DefaultContractExpiry,
+			ResourceEntitlements: []contractsapi.Entitlement{
+				{Type: "esm-infra", Entitled: true},
+				{Type: "esm-apps", Entitled: true},
+				{Type: "livepatch", Entitled: true},
+				{Type: "fips", Entitled: false},
+				{Type: "support", Entitled: true, Affordances: contractsapi.Affordances{SupportLevel: DefaultSupportLevel}},
+			},
+		},
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(resp); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "failed to encode the response: %v", err)
+		return
+	}
+
+	s.serveCacheable(w, r, body.Bytes())
+}
+
+// serveCacheable writes the body alongside its validator, or a 304 Not Modified if the client has it already.
+func (s *Server) serveCacheable(w http.ResponseWriter, r *http.Request, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := fmt.Sprintf("%q", hex.EncodeToString(sum[:8]))
+
+	w.Header().Set("ETag", etag)
+	if s.settings.CacheControl != "" {
+		w.Header().Set("Cache-Control", s.settings.CacheControl)
+	}
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+	if _, err := w.Write(body); err != nil {
+		slog.Error("failed to write the response", "error", err)
+	}
+}
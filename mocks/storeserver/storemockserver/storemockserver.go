@@ -88,6 +88,27 @@ const (
 	PurchaseStatusKey = "status"
 )
 
+// LicenseState is the state of the license of a product, which drives how the server reports and sells it.
+type LicenseState string
+
+// License states.
+const (
+	// LicenseNotPurchased means that the user does not own the product. Purchasing it activates the license.
+	LicenseNotPurchased LicenseState = "NotPurchased"
+
+	// LicensePending means that the purchase went through but the Store did not grant the license yet: the product
+	// is not in the user collection and purchasing it again succeeds without granting it. Use SetLicense to
+	// complete it.
+	LicensePending LicenseState = "Pending"
+
+	// LicenseActive means that the user owns the product until its expiration date.
+	LicenseActive LicenseState = "Active"
+
+	// LicenseExpired means that the user owned the product but its expiration date passed. Purchasing it renews
+	// the license.
+	LicenseExpired LicenseState = "Expired"
+)
+
 // Settings contains the parameters for the Server.
 type Settings struct {
 	AllAuthenticatedUsers restserver.Endpoint
@@ -120,6 +141,32 @@ type Product struct {
 	IsInUserCollection bool
 	ProductKind        string
 	ExpirationDate     time.Time
+
+	// License is the state of the license of the product. If empty, IsInUserCollection and ExpirationDate are
+	// reported as they are set. Otherwise, a zero ExpirationDate is reported as one year from now for active
+	// licenses, and as yesterday for expired ones.
+	License LicenseState
+}
+
+// licensed returns the product as the Store reports it according to its license.
+func (p Product) licensed() Product {
+	switch p.License {
+	case LicenseNotPurchased, LicensePending:
+		p.IsInUserCollection = false
+		p.ExpirationDate = time.Time{}
+	case LicenseActive:
+		p.IsInUserCollection = true
+		if p.ExpirationDate.IsZero() {
+			p.ExpirationDate = oneYearFromNow()
+		}
+	case LicenseExpired:
+		p.IsInUserCollection = true
+		if p.ExpirationDate.IsZero() || p.ExpirationDate.After(time.Now()) {
+			p.ExpirationDate = time.Now().AddDate(0, 0, -1)
+		}
+	}
+
+	return p
 }
 
 // DefaultSettings returns the default set of Settings for the server.
@@ -163,6 +210,46 @@ func NewServer(s Settings) *Server {
 	return sv
 }
 
+// SetLicense changes the license of the product with the given ID, so that tests can script what happens to it
+// while the server runs, such as completing a pending purchase, renewing or expiring a subscription. The
+// expiration date is only relevant to active and expired licenses: if it is zero, an active license expires one
+// year from now and an expired one expired yesterday.
+func (s *Server) SetLicense(id string, state LicenseState, expiration time.Time) error {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	for i, p := range s.settings.AllProducts {
+		if p.StoreID != id {
+			continue
+		}
+
+		if state == LicenseActive && expiration.IsZero() {
+			expiration = oneYearFromNow()
+		}
+
+		s.settings.AllProducts[i].License = state
+		s.settings.AllProducts[i].ExpirationDate = expiration
+		s.settings.AllProducts[i].IsInUserCollection = state == LicenseActive || state == LicenseExpired
+		return nil
+	}
+
+	return fmt.Errorf("product %s does not exist", id)
+}
+
+// Product returns the product with the given ID as the server reports it.
+func (s *Server) Product(id string) (Product, bool) {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+
+	for _, p := range s.settings.AllProducts {
+		if p.StoreID == id {
+			return p.licensed(), true
+		}
+	}
+
+	return Product{}, false
+}
+
 // Generates a request handler function by chaining calls to the server request validation routine and the actual handler.
 func (s *Server) generateHandler(endpoint restserver.Endpoint, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -228,7 +315,7 @@ func (s *Server) handleGetProducts(w http.ResponseWriter, r *http.Request) {
 
 	for _, p := range s.settings.AllProducts {
 		if slices.Contains(kinds, p.ProductKind) && slices.Contains(ids, p.StoreID) {
-			productsFound = append(productsFound, p)
+			productsFound = append(productsFound, p.licensed())
 		}
 	}
 
@@ -281,16 +368,29 @@ func (s *Server) handlePurchase(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		if p.IsInUserCollection {
+		switch p.License {
+		case LicensePending:
+			slog.Info(fmt.Sprintf("%s: product %q purchased but its license is pending", PurchasePath, id))
+			fmt.Fprintf(w, `{%q:%q}`, PurchaseStatusKey, SucceededResult)
+			return
+		case LicenseExpired:
+			slog.Info(fmt.Sprintf("%s: renewing expired product %q", PurchasePath, id))
+		case "":
+			if !p.IsInUserCollection {
+				break
+			}
+			fallthrough
+		case LicenseActive:
 			slog.Info(fmt.Sprintf("%s: product %q already in user collection", PurchasePath, id))
 			fmt.Fprintf(w, `{%q:%q}`, PurchaseStatusKey, AlreadyPurchasedResult)
 			return
 		}
 
-		year, month, day := time.Now().Date()
-
-		s.settings.AllProducts[i].ExpirationDate = time.Date(year+1, month, day, 1, 1, 1, 1, time.Local) // one year from now.
+		s.settings.AllProducts[i].ExpirationDate = oneYearFromNow()
 		s.settings.AllProducts[i].IsInUserCollection = true
+		if p.License != "" {
+			s.settings.AllProducts[i].License = LicenseActive
+		}
 		fmt.Fprintf(w, `{%q:%q}`, PurchaseStatusKey, SucceededResult)
 		return
 	}
@@ -298,3 +398,9 @@ func (s *Server) handlePurchase(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusBadRequest)
 	fmt.Fprintf(w, "product %s does not exist", id)
 }
+
+// oneYearFromNow returns the expiration date of a subscription purchased now.
+func oneYearFromNow() time.Time {
+	year, month, day := time.Now().Date()
+	return time.Date(year+1, month, day, 1, 1, 1, 1, time.Local)
+}
@@ -0,0 +1,162 @@
+package storemockserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/mocks/storeserver/storemockserver"
+	"github.com/stretchr/testify/require"
+)
+
+const productID = "A_SUBSCRIPTION"
+
+func TestLicense(t *testing.T) {
+	t.Parallel()
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	nextMonth := time.Now().AddDate(0, 1, 0)
+
+	testCases := map[string]struct {
+		license      storemockserver.LicenseState
+		inCollection bool
+		expiration   time.Time
+
+		wantPurchaseStatus   string
+		wantInCollection     bool
+		wantInCollectionNext bool
+		wantExpired          bool
+	}{
+		"Success purchasing a product with no license":                       {wantPurchaseStatus: storemockserver.SucceededResult, wantInCollectionNext: true},
+		"Success purchasing a product not purchased":                         {license: storemockserver.LicenseNotPurchased, inCollection: true, wantPurchaseStatus: storemockserver.SucceededResult, wantInCollectionNext: true},
+		"Success purchasing a product whose license is pending":              {license: storemockserver.LicensePending, wantPurchaseStatus: storemockserver.SucceededResult},
+		"Success renewing an expired product":                                {license: storemockserver.LicenseExpired, expiration: yesterday, wantPurchaseStatus: storemockserver.SucceededResult, wantInCollection: true, wantInCollectionNext: true, wantExpired: true},
+		"Success reporting an expired product that expires later as expired": {license: storemockserver.LicenseExpired, expiration: nextMonth, wantPurchaseStatus: storemockserver.SucceededResult, wantInCollection: true, wantInCollectionNext: true, wantExpired: true},
+
+		"Error when purchasing an active product":       {license: storemockserver.LicenseActive, expiration: nextMonth, wantPurchaseStatus: storemockserver.AlreadyPurchasedResult, wantInCollection: true, wantInCollectionNext: true},
+		"Error when purchasing a product in collection": {inCollection: true, wantPurchaseStatus: storemockserver.AlreadyPurchasedResult, wantInCollection: true, wantInCollectionNext: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			settings := storemockserver.DefaultSettings()
+			settings.AllProducts = []storemockserver.Product{{
+				StoreID:            productID,
+				ProductKind:        "Durable",
+				IsInUserCollection: tc.inCollection,
+				ExpirationDate:     tc.expiration,
+				License:            tc.license,
+			}}
+			address := serve(t, settings)
+
+			p := getProduct(t, address)
+			require.Equal(t, tc.wantInCollection, p.IsInUserCollection, "Unexpected user collection status before the purchase")
+			if tc.wantExpired {
+				require.True(t, p.ExpirationDate.Before(time.Now()), "An expired product should expire in the past")
+			}
+
+			require.Equal(t, tc.wantPurchaseStatus, purchase(t, address), "Unexpected purchase status")
+
+			p = getProduct(t, address)
+			require.Equal(t, tc.wantInCollectionNext, p.IsInUserCollection, "Unexpected user collection status after the purchase")
+			if tc.wantInCollectionNext && tc.wantPurchaseStatus == storemockserver.SucceededResult {
+				require.True(t, p.ExpirationDate.After(time.Now()), "A purchased product should expire in the future")
+			}
+		})
+	}
+}
+
+func TestSetLicense(t *testing.T) {
+	t.Parallel()
+
+	settings := storemockserver.DefaultSettings()
+	settings.AllProducts = []storemockserver.Product{{StoreID: productID, ProductKind: "Durable", License: storemockserver.LicensePending}}
+	server := storemockserver.NewServer(settings)
+	err := server.Serve(context.Background(), "localhost:0")
+	require.NoError(t, err, "Setup: Serve should return no error")
+	//nolint:errcheck // Nothing we can do about it
+	t.Cleanup(func() { server.Stop() })
+
+	require.Equal(t, storemockserver.SucceededResult, purchase(t, server.Address()), "Purchasing a pending product should succeed")
+	require.False(t, getProduct(t, server.Address()).IsInUserCollection, "A pending product should not be in the user collection")
+
+	// The Store grants the license.
+	err = server.SetLicense(productID, storemockserver.LicenseActive, time.Time{})
+	require.NoError(t, err, "SetLicense should return no error")
+	p := getProduct(t, server.Address())
+	require.True(t, p.IsInUserCollection, "An active product should be in the user collection")
+	require.True(t, p.ExpirationDate.After(time.Now()), "An active product should expire in the future")
+
+	// The subscription expires.
+	err = server.SetLicense(productID, storemockserver.LicenseExpired, time.Time{})
+	require.NoError(t, err, "SetLicense should return no error")
+	p = getProduct(t, server.Address())
+	require.True(t, p.ExpirationDate.Before(time.Now()), "An expired product should expire in the past")
+
+	got, ok := server.Product(productID)
+	require.True(t, ok, "Product should find the product")
+	require.Equal(t, storemockserver.LicenseExpired, got.License, "Product should report the license")
+
+	err = server.SetLicense("nonexistent", storemockserver.LicenseActive, time.Time{})
+	require.Error(t, err, "SetLicense should return an error for a product that does not exist")
+}
+
+// serve starts a server with the given settings and returns its address.
+func serve(t *testing.T, settings storemockserver.Settings) string {
+	t.Helper()
+
+	server := storemockserver.NewServer(settings)
+	err := server.Serve(context.Background(), "localhost:0")
+	require.NoError(t, err, "Setup: Serve should return no error")
+	//nolint:errcheck // Nothing we can do about it
+	t.Cleanup(func() { server.Stop() })
+
+	return server.Address()
+}
+
+// getProduct returns the test product as the server reports it.
+func getProduct(t *testing.T, address string) storemockserver.Product {
+	t.Helper()
+
+	q := url.Values{storemockserver.ProductIDsParam: {productID}, storemockserver.ProductKindsParam: {"Durable"}}
+
+	var resp struct {
+		Products []storemockserver.Product `json:"products"`
+	}
+	get(t, address, storemockserver.ProductPath, q, &resp)
+	require.Len(t, resp.Products, 1, "The server should report the product")
+
+	return resp.Products[0]
+}
+
+// purchase purchases the test product and returns the purchase status.
+func purchase(t *testing.T, address string) string {
+	t.Helper()
+
+	var resp map[string]string
+	get(t, address, storemockserver.PurchasePath, url.Values{storemockserver.ProductIDParam: {productID}}, &resp)
+
+	return resp[storemockserver.PurchaseStatusKey]
+}
+
+// get sends a GET request to the endpoint and decodes its JSON response into out.
+func get(t *testing.T, address, path string, query url.Values, out any) {
+	t.Helper()
+
+	u := url.URL{Scheme: "http", Host: address, Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, u.String(), nil)
+	require.NoError(t, err, "Setup: could not create request")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "GET %s should return no error", path)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode, "GET %s should succeed", path)
+	err = json.NewDecoder(resp.Body).Decode(out)
+	require.NoError(t, err, "GET %s should return valid JSON", path)
+}
@@ -8,7 +8,10 @@ param (
     [string]$mode,
 
     [Parameter(Mandatory = $false, HelpMessage = "A directory were the MSIX and the certificate will be copied to")]
-    [string]$OutputDir
+    [string]$OutputDir,
+
+    [Parameter(Mandatory = $false, HelpMessage = "Build the agent so that it writes coverage profiles into GOCOVERDIR")]
+    [switch]$Coverage
 )
 
 function Start-VsDevShell {
@@ -134,6 +137,10 @@ If ($mode -eq 'end_to_end_tests') {
     $env:UP4W_TEST_WITH_MS_STORE_MOCK = 1
 }
 
+If ($Coverage) {
+    $env:UP4W_COVERAGE = 1
+}
+
 msbuild.exe                                                                              `
     .\msix\msix.sln                                                                      `
     -target:Build                                                                        `
@@ -13,4 +13,5 @@ sudo DEBIAN_FRONTEND=noninteractive apt install -y devscripts
 sudo DEBIAN_FRONTEND=noninteractive apt -y build-dep .
 
 # Build
-DEB_BUILD_OPTIONS=nocheck debuild
+# Set UP4W_COVERAGE to build a binary that writes coverage profiles, for the end-to-end tests.
+DEB_BUILD_OPTIONS=nocheck debuild --preserve-envvar=UP4W_COVERAGE
@@ -7,18 +7,28 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/user"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/grpc/compression"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consent"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/daemon"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/eventlog"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/registrywatcher"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/retention"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/sandbox"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslversion"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -41,11 +51,49 @@ type App struct {
 	daemon      *daemon.Daemon
 	proServices *proservices.Manager
 
+	// live holds what changes when the configuration file does.
+	live *liveConfig
+
 	ready chan struct{}
 }
 
 type daemonConfig struct {
 	Verbosity int
+
+	// DataDir overrides the directory where private data goes.
+	DataDir string
+
+	// Retention overrides the default policy on how much of the agent's data is kept on disk.
+	Retention retention.Policy
+
+	// Consent overrides the default policy on the requests of tasks for user confirmation that nobody answers.
+	Consent consent.Policy
+
+	// CrashReportsURL opts in to uploading the crash reports there. They are only written to disk if it is empty.
+	CrashReportsURL string
+
+	// EventLogLevel is the lowest level of the log entries mirrored to the Windows Event Log, "warning" by default.
+	// Setting it to "none" stops mirroring them.
+	EventLogLevel string
+
+	// MetricsAddress is where the metrics of the agent are served to Prometheus, such as "127.0.0.1:9464".
+	// They are not served if it is empty.
+	MetricsAddress string
+
+	// CompressionThreshold is the size in bytes of the smallest message compressed on the streams with the distros,
+	// when they support compression. Zero keeps the default.
+	CompressionThreshold int
+
+	// ContractsURL overrides the URL of the contract server.
+	ContractsURL string
+
+	// Proxy is the proxy the agent reaches the contract server through, instead of the proxy of the registry or,
+	// if it has none, that of the system.
+	Proxy contracts.Proxy
+
+	// Defaults are the settings of the registry, such as the proxies or the provisioning of the distros, used when
+	// the registry leaves them empty. Its keys are the names of the registry values in lowercase.
+	Defaults config.RegistryData
 }
 
 type options struct {
@@ -56,13 +104,16 @@ type options struct {
 	privateDir string
 
 	registry registrywatcher.Registry
+
+	// noSandbox makes the agent perform the calls to the contract server and the Microsoft Store itself.
+	noSandbox bool
 }
 
 type option func(*options)
 
 // New registers commands and return a new App.
 func New(o ...option) *App {
-	a := App{ready: make(chan struct{})}
+	a := App{ready: make(chan struct{}), live: &liveConfig{}}
 	a.rootCmd = cobra.Command{
 		Use:   fmt.Sprintf("%s COMMAND", cmdName()),
 		Short: i18n.G("Ubuntu Pro for WSL agent"),
@@ -83,6 +134,7 @@ func New(o ...option) *App {
 			}
 
 			setVerboseMode(a.config.Verbosity)
+			a.live.config = a.config
 
 			return nil
 		},
@@ -109,6 +161,18 @@ func New(o ...option) *App {
 			}
 			defer cleanup()
 
+			cleanup, err = a.setUpEventLog(ctx)
+			if err != nil {
+				log.Warningf(ctx, "could not mirror the logs to the Event Log: %v", err)
+			}
+			defer cleanup()
+
+			cleanup, err = a.setUpMetrics(ctx)
+			if err != nil {
+				log.Warningf(ctx, "could not serve the metrics: %v", err)
+			}
+			defer cleanup()
+
 			return a.serve(ctx, opt)
 		},
 		// We display usage error ourselves
@@ -122,6 +186,16 @@ func New(o ...option) *App {
 	// subcommands
 	a.installVersion()
 	a.installClean()
+	a.installCompliance(o...)
+	a.installConfig(o...)
+	a.installDB(o...)
+	a.installFeedback(o...)
+	a.installInventory(o...)
+	a.installOperations(o...)
+	a.installStatus(o...)
+	a.installTUI(o...)
+	a.installSimulate(o...)
+	a.installSandbox()
 
 	return &a
 }
@@ -144,22 +218,67 @@ func (a *App) serve(ctx context.Context, opt options) error {
 
 	log.Debugf(ctx, "Agent private directory: %s", privateDir)
 
-	proservices, err := proservices.New(ctx,
-		publicDir,
-		privateDir,
+	// Crash reports are error records: they are pruned by the janitor and bundled in the feedback reports.
+	crashreport.Install(crashreport.New(filepath.Join(privateDir, consts.ErrorRecordsDir), consts.Version, crashreport.WithUploadURL(a.config.CrashReportsURL)))
+	compression.SetThreshold(a.config.CompressionThreshold)
+
+	wslInfo := wslversion.Detect(ctx)
+	log.Infof(ctx, "WSL version: %q, kernel: %q, channel: %q", wslInfo.Version, wslInfo.KernelVersion, wslInfo.Channel)
+	for _, msg := range wslInfo.Degraded() {
+		log.Warningf(ctx, "Degraded mode: %s", msg)
+	}
+
+	args := []proservices.Option{
 		proservices.WithRegistry(opt.registry),
-	)
+		proservices.WithRetention(a.config.Retention),
+		proservices.WithConsentPolicy(a.config.Consent),
+		proservices.WithWslInfo(wslInfo),
+		proservices.WithProxyNotifier(a.live.setOrgProxy),
+	}
+
+	var s *sandbox.Supervisor
+	if !opt.noSandbox {
+		s, err = a.newSandbox(ctx, privateDir)
+		if err != nil {
+			close(a.ready)
+			return err
+		}
+		defer s.Stop()
+		args = append(args, proservices.WithOutbound(s))
+	}
+
+	proservices, err := proservices.New(ctx, publicDir, privateDir, args...)
 	if err != nil {
 		close(a.ready)
 		return err
 	}
 	a.proServices = &proservices
 
+	a.live.serve(privateDir, s, a.proServices)
+	a.watchConfig(ctx)
+
 	a.daemon = daemon.New(ctx, proservices.RegisterGRPCServices, publicDir)
 
 	close(a.ready)
 
-	return a.daemon.Serve(ctx)
+	serveArgs := []daemon.Option{daemon.WithWslInfo(wslInfo), daemon.WithStatusAPI(proservices.StatusDir(), proservices.RegisterStatusGRPCServices)}
+	if name, err := namedPipeName(); err != nil {
+		log.Warningf(ctx, "Not serving on a named pipe: %v", err)
+	} else {
+		serveArgs = append(serveArgs, daemon.WithNamedPipe(name))
+	}
+
+	return a.daemon.Serve(ctx, serveArgs...)
+}
+
+// namedPipeName returns the name of the named pipe the agent serves its command line clients on. It is unique to the
+// user, so that the agents of the different users of the machine do not compete for it.
+func namedPipeName() (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("could not get the current user: %v", err)
+	}
+	return consts.NamedPipePrefix + u.Uid, nil
 }
 
 // Run executes the command and associated process. It returns an error on syntax/usage error.
@@ -172,14 +291,22 @@ func (a App) UsageError() bool {
 	return !a.rootCmd.SilenceUsage
 }
 
-// Quit gracefully shutdown the service.
+// Quit gracefully shutdown the service. The tasks are handed off first, as the agent cannot tell an update of its
+// MSIX package, which stops it the same way, from any other request to stop: the tasks in progress have a grace
+// period to finish, and the next start knows whether it follows an update.
 func (a *App) Quit() {
 	a.WaitReady()
 	if a.daemon == nil {
 		return
 	}
-	a.daemon.Quit(context.Background(), false)
-	a.proServices.Stop(context.Background())
+
+	ctx := context.Background()
+	// The distros must still be connected for the tasks in progress to finish.
+	if err := a.proServices.HandOff(ctx); err != nil {
+		log.Warning(ctx, err)
+	}
+	a.daemon.Quit(ctx, false)
+	a.proServices.Stop(ctx)
 }
 
 // WaitReady signals when the daemon is ready
@@ -192,6 +319,16 @@ func (a *App) WaitReady() {
 	a.daemon.WaitReady()
 }
 
+// Ready returns a channel that is closed once the agent accepts connections from its clients. It blocks until the
+// daemon is created, and the channel is never closed if the agent fails to start.
+func (a *App) Ready() <-chan struct{} {
+	<-a.ready
+	if a.daemon == nil {
+		return nil
+	}
+	return a.daemon.Ready()
+}
+
 // RootCmd returns a copy of the root command for the app. Shouldn't be in general necessary apart when running generators.
 func (a App) RootCmd() cobra.Command {
 	return a.rootCmd
@@ -216,7 +353,7 @@ func (a *App) publicDir(opts options) (string, error) {
 			return "", errors.New("could not create public dir: %UserProfile% is not set")
 		}
 
-		opts.publicDir = filepath.Join(homeDir, common.UserProfileDir)
+		opts.publicDir = common.PublicDir(homeDir)
 	}
 
 	if err := os.MkdirAll(opts.publicDir, 0700); err != nil {
@@ -227,14 +364,15 @@ func (a *App) publicDir(opts options) (string, error) {
 }
 
 // privateDir creates a directory to store private data in, with the option of overriding the path.
+// See defaultPrivateDir for the path used when it is not overridden.
 func (a *App) privateDir(opts options) (string, error) {
 	if opts.privateDir == "" {
-		localAppData := os.Getenv("LocalAppData")
-		if localAppData == "" {
-			return "", errors.New("could not create private dir: %LocalAppData% is not set")
+		dir, err := a.defaultPrivateDir(context.Background())
+		if err != nil {
+			return "", err
 		}
 
-		opts.privateDir = filepath.Join(localAppData, common.LocalAppDataDir)
+		opts.privateDir = dir
 	}
 
 	if err := os.MkdirAll(opts.privateDir, 0700); err != nil {
@@ -256,10 +394,10 @@ func (a *App) setUpLogger(ctx context.Context) (func(), error) {
 		return noop, err
 	}
 
-	logFile := filepath.Join(publicDir, "log")
+	logFile := filepath.Join(publicDir, consts.LogFileName)
 
 	// Move old log file
-	oldLogFile := filepath.Join(publicDir, "log.old")
+	oldLogFile := logFile + ".old"
 	err = os.Rename(logFile, oldLogFile)
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		log.Warningf(ctx, "Could not archive previous log file: %v", err)
@@ -281,6 +419,47 @@ func (a *App) setUpLogger(ctx context.Context) (func(), error) {
 	return func() { _ = f.Close() }, nil
 }
 
+// setUpEventLog mirrors the log entries at or above the configured level to the Windows Event Log.
+func (a *App) setUpEventLog(ctx context.Context) (func(), error) {
+	noop := func() {}
+
+	if a.config.EventLogLevel == "none" {
+		return noop, nil
+	}
+
+	level := logrus.WarnLevel
+	if a.config.EventLogLevel != "" {
+		l, err := logrus.ParseLevel(a.config.EventLogLevel)
+		if err != nil {
+			return noop, fmt.Errorf("invalid Event Log level: %v", err)
+		}
+		level = l
+	}
+
+	h, err := eventlog.New(ctx, level)
+	if err != nil {
+		return noop, err
+	}
+	logrus.AddHook(h)
+
+	return func() { _ = h.Close() }, nil
+}
+
+// setUpMetrics serves the metrics of the agent on the configured address, if any. The address can be changed in the
+// configuration file while the agent runs.
+func (a *App) setUpMetrics(ctx context.Context) (func(), error) {
+	a.live.mu.Lock()
+	defer a.live.mu.Unlock()
+
+	cleanup := func() {
+		a.live.mu.Lock()
+		defer a.live.mu.Unlock()
+		a.live.stopMetrics()
+	}
+
+	return cleanup, a.live.serveMetrics(ctx)
+}
+
 // ensureSingleInstance creates a lock file to ensure that only one instance of the agent is running.
 // It returns a cleanup function to release that file or an error if the lock file could not be flushed to disk.
 func (a *App) ensureSingleInstance(opt options) (cleanup func(), err error) {
@@ -32,6 +32,7 @@ func (a *App) installClean() {
 			return errors.Join(
 				cleanLocation("LocalAppData", common.LocalAppDataDir),
 				cleanLocation("UserProfile", common.UserProfileDir),
+				cleanLocation("UserProfile", common.StatusDir),
 			)
 		},
 	}
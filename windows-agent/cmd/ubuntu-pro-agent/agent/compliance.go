@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/compliance"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) installCompliance(o ...option) {
+	cmd := &cobra.Command{
+		Use:   "compliance",
+		Short: i18n.G("Prints the security patch compliance of all distros and exits"),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			privateDir, err := a.privateDir(opt)
+			if err != nil {
+				return err
+			}
+
+			// Reading the database directly allows this command to run alongside the agent.
+			props, err := database.ReadProperties(privateDir)
+			if err != nil {
+				return err
+			}
+
+			return printComplianceReport(os.Stdout, compliance.NewReport(props))
+		},
+	}
+	a.rootCmd.AddCommand(cmd)
+}
+
+// printComplianceReport writes a human-readable version of the report into w.
+func printComplianceReport(w io.Writer, r compliance.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Distros:"), r.Total)
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Fully patched:"), r.FullyPatched)
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Pending security updates:"), r.PendingSecurity)
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Pending ESM updates:"), r.PendingESM)
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Unknown status:"), r.Unknown)
+
+	if len(r.Distros) == 0 {
+		return tw.Flush()
+	}
+
+	fmt.Fprintf(tw, "\n%s\t%s\t%s\n", i18n.G("DISTRO"), i18n.G("SECURITY UPDATES"), i18n.G("ESM UPDATES"))
+	for _, d := range r.Distros {
+		standard, esm := i18n.G("unknown"), i18n.G("unknown")
+		if d.Security.Known {
+			standard, esm = strconv.Itoa(d.Security.StandardUpdates), strconv.Itoa(d.Security.ESMUpdates)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Name, standard, esm)
+	}
+
+	return tw.Flush()
+}
@@ -2,14 +2,23 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/registrywatcher"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/registrywatcher/registry"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -34,6 +43,10 @@ func initViperConfig(name string, cmd *cobra.Command, vip *viper.Viper) (err err
 		vip.AddConfigPath("./")
 		vip.AddConfigPath("$HOME")
 		vip.AddConfigPath(filepath.Join("$HOME", common.UserProfileDir))
+		// The machine-wide configuration, which administrators can deploy to all users.
+		if programData := os.Getenv("ProgramData"); programData != "" {
+			vip.AddConfigPath(filepath.Join(programData, "Ubuntu Pro for WSL"))
+		}
 	}
 
 	// Load the config
@@ -52,6 +65,11 @@ func initViperConfig(name string, cmd *cobra.Command, vip *viper.Viper) (err err
 	vip.SetEnvPrefix("UP4W")
 	vip.AutomaticEnv()
 
+	// Keys without a flag must be bound explicitly to be read from the environment.
+	if err := vip.BindEnv("datadir"); err != nil {
+		return fmt.Errorf("could not bind data directory to the environment: %v", err)
+	}
+
 	return nil
 }
 
@@ -85,3 +103,120 @@ func setVerboseMode(level int) {
 	}
 	log.SetReportCaller(reportCaller)
 }
+
+func (a *App) installConfig(o ...option) {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: i18n.G("Inspects the configuration of the agent"),
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(a.configShowCmd(o...))
+	a.rootCmd.AddCommand(cmd)
+}
+
+func (a *App) configShowCmd(o ...option) *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: i18n.G("Prints the effective configuration of the agent and exits"),
+		Long: i18n.G(`Prints the effective configuration of the agent and exits.
+
+Every setting is printed along with where it is taken from: its built-in default, the configuration file of
+the agent, the Ubuntu Pro registry key of the current user (HKCU), the GUI or the Microsoft Store. Settings
+enforced by the organization, through the registry or the configuration file, are locked: the user cannot change
+them. Tokens and credentials are hidden.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			var write func(io.Writer, []config.Setting) error
+			switch format {
+			case "text":
+				write = printSettings
+			case "json":
+				write = writeSettingsJSON
+			default:
+				return fmt.Errorf(i18n.G("unknown format %q: use text or json"), format)
+			}
+
+			privateDir, err := a.privateDir(opt)
+			if err != nil {
+				return err
+			}
+
+			var reg registrywatcher.Registry = registry.Windows{}
+			if opt.registry != nil {
+				reg = opt.registry
+			}
+
+			data, err := registrywatcher.ReadRegistry(reg)
+			if err != nil {
+				return err
+			}
+
+			var settings []config.Setting
+			err = readConfig(privateDir, func(c *config.Config) (err error) {
+				settings, err = c.Effective(data, a.config.Defaults)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			return write(os.Stdout, settings)
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "text", i18n.G("format of the configuration: text or json"))
+
+	return cmd
+}
+
+// printSettings writes a human-readable table of the settings into w.
+func printSettings(w io.Writer, settings []config.Setting) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", i18n.G("KEY"), i18n.G("VALUE"), i18n.G("SOURCE"), i18n.G("LOCKED"))
+	for _, s := range settings {
+		value := s.Value
+		if value == "" {
+			value = "-"
+		} else if strings.ContainsAny(value, "\t\n") {
+			value = strconv.Quote(value)
+		}
+
+		locked := i18n.G("no")
+		if s.Locked {
+			locked = i18n.G("yes")
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Key, value, s.Origin, locked)
+	}
+
+	return tw.Flush()
+}
+
+// settingJSON is a setting as written by writeSettingsJSON.
+type settingJSON struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Locked bool   `json:"locked"`
+}
+
+// writeSettingsJSON writes the settings into w as JSON.
+func writeSettingsJSON(w io.Writer, settings []config.Setting) error {
+	out := make([]settingJSON, 0, len(settings))
+	for _, s := range settings {
+		out = append(out, settingJSON{Key: s.Key, Value: s.Value, Source: string(s.Origin), Locked: s.Locked})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
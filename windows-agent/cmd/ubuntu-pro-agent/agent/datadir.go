@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// defaultPrivateDir returns the directory where private data goes when it is not overridden by an option.
+// This is the data directory from the configuration, unless it is on a roaming profile or a network share,
+// where file locking is unreliable. The fallback is the agent's directory under %LocalAppData%.
+func (a *App) defaultPrivateDir(ctx context.Context) (string, error) {
+	localAppData := os.Getenv("LocalAppData")
+
+	if dir := a.config.DataDir; dir != "" {
+		roaming := isRoamingPath(dir)
+		if !roaming && !filepath.IsAbs(dir) {
+			return "", fmt.Errorf("could not create private dir: data directory %s is not an absolute path", dir)
+		}
+
+		if !roaming {
+			return dir, nil
+		}
+
+		if localAppData == "" {
+			return "", fmt.Errorf("could not create private dir: data directory %s is on a roaming profile or a network share, and %%LocalAppData%% is not set", dir)
+		}
+
+		log.Warningf(ctx, "Data directory %s is on a roaming profile or a network share, where file locking is unreliable: using %%LocalAppData%% instead", dir)
+	}
+
+	if localAppData == "" {
+		return "", errors.New("could not create private dir: %LocalAppData% is not set")
+	}
+
+	dir := common.PrivateDir(localAppData)
+	if isRoamingPath(dir) {
+		log.Warningf(ctx, "Private directory %s is on a roaming profile or a network share, where file locking is unreliable: the database may get corrupted. Set a local data directory in the configuration to avoid it.", dir)
+	}
+
+	return dir, nil
+}
+
+// isRoamingPath returns true if the path is on a network share or in the roaming part of the user profile.
+func isRoamingPath(path string) bool {
+	// UNC paths, such as \\server\share\dir.
+	if strings.HasPrefix(path, `\\`) || strings.HasPrefix(path, `//`) {
+		return true
+	}
+
+	if appData := os.Getenv("AppData"); appData != "" && isWithin(path, appData) {
+		return true
+	}
+
+	return isRemoteDrive(path)
+}
+
+// isWithin returns true if path is dir or any of its descendants. The comparison is case-insensitive,
+// as paths are on Windows.
+func isWithin(path, dir string) bool {
+	path = strings.ToLower(filepath.Clean(path))
+	dir = strings.ToLower(filepath.Clean(dir))
+
+	if path == dir {
+		return true
+	}
+
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
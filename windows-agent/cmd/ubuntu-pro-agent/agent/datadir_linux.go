@@ -0,0 +1,6 @@
+package agent
+
+// isRemoteDrive returns true if the path is on a mapped network drive. There are no drives outside of Windows.
+func isRemoteDrive(string) bool {
+	return false
+}
@@ -0,0 +1,22 @@
+package agent
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// isRemoteDrive returns true if the path is on a mapped network drive.
+func isRemoteDrive(path string) bool {
+	volume := filepath.VolumeName(path)
+	if volume == "" {
+		return false
+	}
+
+	root, err := windows.UTF16PtrFromString(volume + `\`)
+	if err != nil {
+		return false
+	}
+
+	return windows.GetDriveType(root) == windows.DRIVE_REMOTE
+}
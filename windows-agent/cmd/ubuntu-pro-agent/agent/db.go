@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) installDB(o ...option) {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: i18n.G("Exports or imports the database of distros and their task queues"),
+		Long: i18n.G(`Exports or imports the database of distros and their task queues.
+
+The export is a JSON document with the record of every distro and its queued tasks, to attach to a bug
+report or to move the agent to another machine.`),
+		Args: cobra.NoArgs,
+	}
+
+	cmd.AddCommand(a.dbExportCmd(o...), a.dbImportCmd(o...))
+	a.rootCmd.AddCommand(cmd)
+}
+
+func (a *App) dbExportCmd(o ...option) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: i18n.G("Exports the database of distros and their task queues as JSON and exits"),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			privateDir, err := a.privateDir(opt)
+			if err != nil {
+				return err
+			}
+
+			// Reading a snapshot of the database allows this command to run alongside the agent.
+			if output == "" {
+				return database.ExportTo(privateDir, os.Stdout)
+			}
+
+			f, err := os.OpenFile(output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				err = errors.Join(err, f.Close())
+			}()
+
+			return database.ExportTo(privateDir, f)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", i18n.G("file to write the export into (default: the standard output)"))
+
+	return cmd
+}
+
+func (a *App) dbImportCmd(o ...option) *cobra.Command {
+	return &cobra.Command{
+		Use:   "import FILE",
+		Short: i18n.G("Replaces the database of distros and their task queues with an export and exits"),
+		Long: i18n.G(`Replaces the database of distros and their task queues with an export and exits.
+
+The agent must be stopped. Distros are matched by name to those registered in WSL, so that the export can
+come from another machine. Those that are not registered are skipped. Use "-" to read the standard input.`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			privateDir, err := a.privateDir(opt)
+			if err != nil {
+				return err
+			}
+
+			var in io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+
+			imported, skipped, err := database.ImportFrom(context.Background(), privateDir, in)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf(i18n.G("Imported %d distros\n"), len(imported))
+			for _, name := range imported {
+				fmt.Printf("  %s\n", name)
+			}
+			if len(skipped) == 0 {
+				return nil
+			}
+
+			fmt.Printf(i18n.G("Skipped %d distros not registered in WSL\n"), len(skipped))
+			for _, name := range skipped {
+				fmt.Printf("  %s\n", name)
+			}
+			return nil
+		},
+	}
+}
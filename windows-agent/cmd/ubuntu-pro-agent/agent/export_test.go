@@ -25,6 +25,14 @@ func WithRegistry(r registrywatcher.Registry) func(*options) {
 	}
 }
 
+// WithoutSandbox makes the agent perform the network-facing operations itself, as the test binary cannot
+// act as the sandboxed process.
+func WithoutSandbox() func(*options) {
+	return func(o *options) {
+		o.noSandbox = true
+	}
+}
+
 // NewForTesting creates a new App with overridden paths for the service and daemon caches.
 func NewForTesting(t *testing.T, publicDir, privateDir string) *App {
 	t.Helper()
@@ -37,7 +45,7 @@ func NewForTesting(t *testing.T, publicDir, privateDir string) *App {
 		privateDir = t.TempDir()
 	}
 
-	return New(WithPrivateDir(privateDir), WithPublicDir(publicDir), WithRegistry(registry.NewMock()))
+	return New(WithPrivateDir(privateDir), WithPublicDir(publicDir), WithRegistry(registry.NewMock()), WithoutSandbox())
 }
 
 // DaemonConfig exports the internal daemonConfig struct for test purposes.
@@ -48,5 +56,13 @@ func (a App) Config() DaemonConfig {
 	return a.config
 }
 
+// PrivateDir creates a directory to store private data in.
+func (a *App) PrivateDir() (string, error) {
+	return a.privateDir(options{})
+}
+
 // CreateLockFile tries to create or open an empty file with given name with exclusive access.
 var CreateLockFile = createLockFile
+
+// RunDashboard draws the live dashboard of the agent c talks to into out, until ctx is cancelled.
+var RunDashboard = runDashboard
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/feedback"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) installFeedback(o ...option) {
+	var outputDir string
+	var noOpen bool
+
+	cmd := &cobra.Command{
+		Use:   "feedback",
+		Short: i18n.G("Packages the agent's diagnostics for a Feedback Hub report, opens Feedback Hub and exits"),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			publicDir, err := a.publicDir(opt)
+			if err != nil {
+				return err
+			}
+
+			privateDir, err := a.privateDir(opt)
+			if err != nil {
+				return err
+			}
+
+			// The public directory is hidden, so the bundle goes where users can find it to attach it.
+			if outputDir == "" {
+				outputDir = os.Getenv("UserProfile")
+				if outputDir == "" {
+					return errors.New("could not find where to write the diagnostics: %UserProfile% is not set, use --output")
+				}
+			}
+
+			ctx := context.Background()
+			now := time.Now()
+
+			path := filepath.Join(outputDir, fmt.Sprintf("ubuntu-pro-for-wsl-feedback-%s.zip", now.Format("20060102-150405")))
+			if err := writeFeedbackBundle(ctx, path, publicDir, privateDir, now); err != nil {
+				return err
+			}
+
+			fmt.Printf(i18n.G("Diagnostics written to %s. Attach them to your report in Feedback Hub.\n"), path)
+
+			if noOpen {
+				return nil
+			}
+			return feedback.Open(ctx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output", "o", "", i18n.G("directory to write the diagnostics to (default: the user's profile directory)"))
+	cmd.Flags().BoolVar(&noOpen, "no-open", false, i18n.G("do not open Feedback Hub"))
+
+	a.rootCmd.AddCommand(cmd)
+}
+
+// writeFeedbackBundle writes the diagnostics bundle into a new file at path.
+func writeFeedbackBundle(ctx context.Context, path, publicDir, privateDir string, now time.Time) (err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("could not create feedback bundle: %v", err)
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("could not write feedback bundle: %v", cerr)
+		}
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	meta := feedback.Metadata{
+		Product:  "Ubuntu Pro for WSL",
+		Version:  consts.Version,
+		Platform: fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		Created:  now.UTC(),
+	}
+
+	return feedback.WriteBundle(ctx, f, publicDir, privateDir, meta)
+}
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/inventory"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) installInventory(o ...option) {
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: i18n.G("Exports the inventory of all distros for asset management tools and exits"),
+		Long: i18n.G(`Exports the inventory of all distros for asset management tools and exits.
+
+Every distro is exported as a record with the name of the machine, the name of the distro, its Ubuntu
+version, its Pro status, the last time its WSL Pro service was heard from and the Landscape ID of the machine.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			var write func(io.Writer, []inventory.Record) error
+			switch format {
+			case "csv":
+				write = inventory.WriteCSV
+			case "json":
+				write = inventory.WriteJSON
+			default:
+				return fmt.Errorf(i18n.G("unknown format %q: use csv or json"), format)
+			}
+
+			privateDir, err := a.privateDir(opt)
+			if err != nil {
+				return err
+			}
+
+			// Reading the database directly allows this command to run alongside the agent.
+			props, err := database.ReadProperties(privateDir)
+			if err != nil {
+				return err
+			}
+
+			landscapeID, err := readLandscapeUID(privateDir)
+			if err != nil {
+				return err
+			}
+
+			machine, err := os.Hostname()
+			if err != nil {
+				return fmt.Errorf(i18n.G("could not get the hostname: %v"), err)
+			}
+
+			records := inventory.NewRecords(inventory.Host{Machine: machine, LandscapeID: landscapeID}, props)
+
+			if output == "" {
+				return write(os.Stdout, records)
+			}
+
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				err = errors.Join(err, f.Close())
+			}()
+
+			return write(f, records)
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "csv", i18n.G("format of the export: csv or json"))
+	cmd.Flags().StringVarP(&output, "output", "o", "", i18n.G("file to write the export into (default: the standard output)"))
+
+	a.rootCmd.AddCommand(cmd)
+}
+
+// readLandscapeUID reads the UID Landscape assigned to the machine from the configuration of the agent in
+// privateDir.
+func readLandscapeUID(privateDir string) (uid string, err error) {
+	err = readConfig(privateDir, func(c *config.Config) (err error) {
+		uid, err = c.LandscapeAgentUID()
+		return err
+	})
+	return uid, err
+}
+
+// readConfig calls read with the configuration of the agent in privateDir. Like the database, it is read from a
+// snapshot of the store, so that the agent can be running.
+func readConfig(privateDir string, read func(*config.Config) error) error {
+	snapshot, err := store.Snapshot(filepath.Join(privateDir, consts.StoreFileName))
+	if err != nil {
+		return err
+	}
+
+	var opts []config.Option
+	if snapshot != nil {
+		defer snapshot.Close()
+		opts = append(opts, config.WithStore(snapshot), config.WithSecrets(secrets.New(snapshot)))
+	}
+
+	return read(config.New(context.Background(), privateDir, opts...))
+}
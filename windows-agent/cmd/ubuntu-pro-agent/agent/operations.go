@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/operations"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) installOperations(o ...option) {
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "operations [ID]",
+		Short: i18n.G("Lists the operations submitted to several distros at once, or exports one of them, and exits"),
+		Long: i18n.G(`Lists the operations submitted to several distros at once, or exports one of them, and exits.
+
+Operations are tasks submitted to several distros at once, such as attaching all of them to Ubuntu Pro or
+enabling a Pro service in a selection of them. Without an ID, the operations are listed along with how many
+distros they succeeded and failed in. With the ID of an operation, its outcome in every distro is exported,
+with the time it finished there, as a record of the change.`),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			var write func(io.Writer, operations.Operation) error
+			switch format {
+			case "csv":
+				write = operations.WriteCSV
+			case "json":
+				write = operations.WriteJSON
+			default:
+				return fmt.Errorf(i18n.G("unknown format %q: use csv or json"), format)
+			}
+
+			privateDir, err := a.privateDir(opt)
+			if err != nil {
+				return err
+			}
+
+			// Reading a snapshot of the store allows this command to run alongside the agent.
+			snapshot, err := store.Snapshot(filepath.Join(privateDir, consts.StoreFileName))
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				var ops []operations.Operation
+				if snapshot != nil {
+					defer snapshot.Close()
+					if ops, err = operations.Stored(snapshot); err != nil {
+						return err
+					}
+				}
+				return printOperations(os.Stdout, ops)
+			}
+
+			if snapshot == nil {
+				return fmt.Errorf(i18n.G("no operation %s: the agent never ran"), args[0])
+			}
+			defer snapshot.Close()
+
+			op, err := operations.Get(snapshot, args[0])
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				return write(os.Stdout, op)
+			}
+
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				err = errors.Join(err, f.Close())
+			}()
+
+			return write(f, op)
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "csv", i18n.G("format of the export of an operation: csv or json"))
+	cmd.Flags().StringVarP(&output, "output", "o", "", i18n.G("file to write the export into (default: the standard output)"))
+
+	a.rootCmd.AddCommand(cmd)
+}
+
+// printOperations writes a human-readable list of the operations into w, newest first.
+func printOperations(w io.Writer, ops []operations.Operation) error {
+	if len(ops) == 0 {
+		fmt.Fprintln(w, i18n.G("No operations recorded."))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", i18n.G("ID"), i18n.G("KIND"), i18n.G("DESCRIPTION"), i18n.G("STARTED"),
+		i18n.G("DISTROS"), i18n.G("SUCCEEDED"), i18n.G("FAILED"), i18n.G("PENDING"))
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\n", op.ID, op.Kind, op.Description, op.Started, len(op.Outcomes),
+			op.Count(operations.StatusSucceeded), op.Count(operations.StatusFailed), op.Count(operations.StatusPending))
+	}
+
+	return tw.Flush()
+}
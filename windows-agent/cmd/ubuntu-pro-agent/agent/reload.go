@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/metrics"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/sandbox"
+	"github.com/fsnotify/fsnotify"
+)
+
+// liveConfig applies the changes of the configuration file to the running agent, so that they take effect without
+// restarting it. The other settings are only read on startup.
+type liveConfig struct {
+	mu     sync.Mutex
+	config daemonConfig
+
+	metrics *metrics.Server
+
+	// sandbox and privateDir are set once the agent serves, with the sandboxed process if there is one.
+	sandbox    *sandbox.Supervisor
+	privateDir string
+
+	// orgProxy is the proxy of the registry, used by the sandboxed process when the configuration has none.
+	orgProxy contracts.Proxy
+
+	proServices *proservices.Manager
+}
+
+// watchConfig reloads the configuration file, if there is one, whenever it changes. A configuration file created
+// after the agent started is only read on the next start.
+func (a *App) watchConfig(ctx context.Context) {
+	if a.viper.ConfigFileUsed() == "" {
+		return
+	}
+
+	a.viper.OnConfigChange(func(e fsnotify.Event) {
+		var config daemonConfig
+		if err := a.viper.Unmarshal(&config); err != nil {
+			log.Warningf(ctx, "Ignoring the changes of the configuration file %s: %v", e.Name, err)
+			return
+		}
+
+		log.Infof(ctx, "Configuration file %s changed", e.Name)
+		a.live.apply(ctx, config)
+	})
+	a.viper.WatchConfig()
+}
+
+// serve sets the components of the running agent the configuration is applied to, and applies the defaults of
+// the settings of the registry.
+func (l *liveConfig) serve(privateDir string, s *sandbox.Supervisor, m *proservices.Manager) {
+	l.mu.Lock()
+	l.privateDir = privateDir
+	l.sandbox = s
+	l.proServices = m
+	defaults := l.config.Defaults
+
+	// The proxy of the registry may have been read before the sandboxed process was set.
+	if l.sandbox != nil {
+		l.sandbox.SetArgs(sandboxArgs(l.privateDir, l.config, l.orgProxy)...)
+	}
+	l.mu.Unlock()
+
+	// The defaults are pushed to the configuration, which notifies the changes of the proxy: l.mu must not be held.
+	m.SetDefaultSettings(defaults)
+}
+
+// setOrgProxy sets the proxy of the registry, which the sandboxed process uses when the configuration has none.
+func (l *liveConfig) setOrgProxy(ctx context.Context, proxy config.ProxySettings) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.orgProxy = contracts.Proxy{HTTP: proxy.HTTP, HTTPS: proxy.HTTPS, NoProxy: proxy.NoProxy}
+	if l.sandbox != nil {
+		l.sandbox.SetArgs(sandboxArgs(l.privateDir, l.config, l.orgProxy)...)
+	}
+}
+
+// apply applies the settings of config that changed since the last time.
+func (l *liveConfig) apply(ctx context.Context, config daemonConfig) {
+	l.mu.Lock()
+	old := l.config
+	l.config = config
+
+	// The defaults are pushed to the configuration, which notifies the changes of the proxy: l.mu must not be held.
+	if l.proServices != nil && config.Defaults != old.Defaults {
+		defer l.proServices.SetDefaultSettings(config.Defaults)
+	}
+	defer l.mu.Unlock()
+
+	if config.Verbosity != old.Verbosity {
+		setVerboseMode(config.Verbosity)
+	}
+
+	if config.MetricsAddress != old.MetricsAddress {
+		if err := l.serveMetrics(ctx); err != nil {
+			log.Warningf(ctx, "could not serve the metrics: %v", err)
+		}
+	}
+
+	// The sandboxed process is restarted with the new settings, if any of its arguments changed.
+	if l.sandbox != nil {
+		l.sandbox.SetArgs(sandboxArgs(l.privateDir, config, l.orgProxy)...)
+	}
+}
+
+// serveMetrics serves the metrics on the configured address, if any, instead of the previous one.
+// l.mu must be held.
+func (l *liveConfig) serveMetrics(ctx context.Context) error {
+	l.stopMetrics()
+
+	if l.config.MetricsAddress == "" {
+		return nil
+	}
+
+	s, err := metrics.Listen(ctx, l.config.MetricsAddress, metrics.Default)
+	if err != nil {
+		return err
+	}
+	l.metrics = s
+
+	return nil
+}
+
+// stopMetrics stops serving the metrics. l.mu must be held.
+func (l *liveConfig) stopMetrics() {
+	if l.metrics == nil {
+		return
+	}
+	_ = l.metrics.Close()
+	l.metrics = nil
+}
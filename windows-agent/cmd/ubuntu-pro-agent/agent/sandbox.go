@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contractclient"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/sandbox"
+	"github.com/spf13/cobra"
+)
+
+// sandboxCmd is the hidden command that runs the network-facing operations of the agent in a child process.
+const sandboxCmd = "sandbox"
+
+func (a *App) installSandbox() {
+	var cachePath, contractsURL string
+	var proxy contracts.Proxy
+
+	cmd := &cobra.Command{
+		Use:    sandboxCmd,
+		Short:  i18n.G("Serves the agent's network-facing operations over the standard input and output"),
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			var opts []contracts.Option
+			if cachePath != "" {
+				cache, err := contractclient.OpenCache(cachePath)
+				if err != nil {
+					log.Warningf(ctx, "Sandbox: %v", err)
+				}
+				opts = append(opts, contracts.WithCache(cache))
+			}
+
+			if contractsURL != "" {
+				u, err := url.Parse(contractsURL)
+				if err != nil {
+					return fmt.Errorf("invalid contract server URL: %v", err)
+				}
+				opts = append(opts, contracts.WithProURL(u))
+			}
+
+			if proxy != (contracts.Proxy{}) {
+				opts = append(opts, contracts.WithProxy(proxy))
+			}
+
+			return sandbox.Serve(ctx, sandbox.Stdio(), opts...)
+		},
+	}
+	cmd.Flags().StringVar(&cachePath, "cache", "", i18n.G("file where the responses of the contract server are cached"))
+	cmd.Flags().StringVar(&contractsURL, "contracts-url", "", i18n.G("URL of the contract server, instead of the default one"))
+	cmd.Flags().StringVar(&proxy.HTTP, "http-proxy", "", i18n.G("proxy for HTTP requests, instead of that of the system"))
+	cmd.Flags().StringVar(&proxy.HTTPS, "https-proxy", "", i18n.G("proxy for HTTPS requests, instead of that of the system"))
+	cmd.Flags().StringVar(&proxy.NoProxy, "no-proxy", "", i18n.G("comma-separated list of hosts reached without proxy"))
+	a.rootCmd.AddCommand(cmd)
+}
+
+// newSandbox returns the supervisor of the child process that performs the calls to the contract server and the
+// Microsoft Store, which runs this same executable. Its crashes are recorded in the private directory.
+func (a *App) newSandbox(ctx context.Context, privateDir string) (*sandbox.Supervisor, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("could not find the agent executable: %v", err)
+	}
+
+	s := sandbox.New(ctx, exe, sandboxArgs(privateDir, a.config, contracts.Proxy{})...)
+	s.RecordCrashesIn(filepath.Join(privateDir, consts.ErrorRecordsDir))
+	s.SetWritableDir(filepath.Join(privateDir, consts.SandboxDir))
+
+	return s, nil
+}
+
+// sandboxArgs returns the arguments of the sandboxed process. Its verbosity and contract server match the
+// configuration of the agent, and the responses of the contract server are cached in the sandbox directory, the only
+// one it can write to, so that they survive its restarts. It reaches the contract server through the proxy of the
+// configuration of the agent or, if there is none, through that of the organisation, such as the one of the registry.
+// It falls back to the proxy of the system when both are empty.
+func sandboxArgs(privateDir string, config daemonConfig, orgProxy contracts.Proxy) []string {
+	args := []string{sandboxCmd, "--cache", filepath.Join(privateDir, consts.SandboxDir, consts.ContractCacheFileName)}
+	if v := config.Verbosity; v > 0 {
+		args = append(args, "-"+strings.Repeat("v", v))
+	}
+	if config.ContractsURL != "" {
+		args = append(args, "--contracts-url", config.ContractsURL)
+	}
+
+	proxy := config.Proxy
+	if proxy == (contracts.Proxy{}) {
+		proxy = orgProxy
+	}
+	if proxy.HTTP != "" {
+		args = append(args, "--http-proxy", proxy.HTTP)
+	}
+	if proxy.HTTPS != "" {
+		args = append(args, "--https-proxy", proxy.HTTPS)
+	}
+	if proxy.NoProxy != "" {
+		args = append(args, "--no-proxy", proxy.NoProxy)
+	}
+
+	return args
+}
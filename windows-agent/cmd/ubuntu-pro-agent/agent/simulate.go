@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/simulator"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) installSimulate(o ...option) {
+	var name, scriptPath string
+
+	cmd := &cobra.Command{
+		Use:   "simulate-distro",
+		Short: i18n.G("Connects to the running agent as the WSL Pro service of a distro, until interrupted"),
+		Long: i18n.G(`Connects to the running agent as the WSL Pro service of a distro, until interrupted.
+
+This is a developer tool to test the agent and the GUI without installing Ubuntu. The agent only accepts
+distros registered in WSL, so the name must be the one of a registered distro whose WSL Pro service is
+not running, such as a distro of any other flavour.
+
+What the distro reports and how it answers the commands of the agent is described by a YAML script, e.g.:
+
+  info:
+    pretty_name: Ubuntu 24.04 LTS
+    pro_attached: true
+  commands:
+    pro_attach:
+      delay: 10s
+      error: "invalid token"
+    usg:
+      output: "<html>report</html>"`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			if name == "" {
+				return errors.New(i18n.G("the name of the distro to simulate is required"))
+			}
+
+			script := simulator.DefaultScript()
+			if scriptPath != "" {
+				s, err := simulator.LoadScript(scriptPath)
+				if err != nil {
+					return err
+				}
+				script = s
+			}
+
+			publicDir, err := a.publicDir(opt)
+			if err != nil {
+				return err
+			}
+
+			conn, err := simulator.Dial(publicDir, common.WSLProServiceCertFilePrefix)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			return simulator.New(name, script).Run(ctx, conn)
+		},
+	}
+
+	cmd.Flags().StringVarP(&name, "name", "n", "", i18n.G("name of the registered distro to simulate"))
+	cmd.Flags().StringVarP(&scriptPath, "script", "s", "", i18n.G("YAML script describing the simulated distro (default: an unattached Ubuntu distro succeeding at everything)"))
+
+	a.rootCmd.AddCommand(cmd)
+}
@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/simulator"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// statusTimeout is how long the status command waits for the running agent to answer.
+const statusTimeout = 10 * time.Second
+
+// agentStatus is the status of the running agent, as printed by the status command.
+type agentStatus struct {
+	// Subscription is where the Ubuntu Pro subscription comes from: none, user, organization or microsoft-store.
+	Subscription string         `json:"subscription"`
+	Distros      []distroStatus `json:"distros"`
+
+	// PendingTasks is the number of tasks queued for all the distros.
+	PendingTasks int `json:"pending_tasks"`
+
+	// Contracts tells whether the subscription is syncing with the contract server.
+	Contracts contractsStatus `json:"contracts"`
+}
+
+// contractsStatus is the health of the synchronisation with the contract server. Times are in RFC 3339
+// format, and empty if the interaction never happened.
+type contractsStatus struct {
+	LastContact         string `json:"last_contact"`
+	LastTokenRefresh    string `json:"last_token_refresh"`
+	LastEntitlementSync string `json:"last_entitlement_sync"`
+
+	// LastError is the error of the last interaction that failed since the last successful one, if any.
+	LastError     string `json:"last_error,omitempty"`
+	LastErrorTime string `json:"last_error_time,omitempty"`
+}
+
+// distroStatus is the status of a distro known to the running agent.
+type distroStatus struct {
+	Name         string `json:"name"`
+	Running      bool   `json:"running"`
+	ProAttached  bool   `json:"pro_attached"`
+	PendingTasks int    `json:"pending_tasks"`
+}
+
+func (a *App) installStatus(o ...option) {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: i18n.G("Prints the status of the running agent and exits"),
+		Long: i18n.G(`Prints the status of the running agent and exits.
+
+The status is made of the source of the Ubuntu Pro subscription, of when it was last synchronised with the
+contract server, and of the distros known to the agent with whether they are running, whether they are attached
+to Ubuntu Pro and how many tasks are queued for them. It is read through the read-only status API, so the agent must be running.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			var write func(io.Writer, agentStatus) error
+			switch format {
+			case "text":
+				write = printStatus
+			case "json":
+				write = writeStatusJSON
+			default:
+				return fmt.Errorf(i18n.G("unknown format %q: use text or json"), format)
+			}
+
+			publicDir, err := a.publicDir(opt)
+			if err != nil {
+				return err
+			}
+
+			conn, err := dialStatusAPI(publicDir)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), statusTimeout)
+			defer cancel()
+
+			s, err := fetchStatus(ctx, agentapi.NewUIClient(conn))
+			if err != nil {
+				return err
+			}
+
+			return write(os.Stdout, s)
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "text", i18n.G("format of the status: text or json"))
+
+	a.rootCmd.AddCommand(cmd)
+}
+
+// dialStatusAPI connects to the read-only status API of the running agent. It is published next to publicDir,
+// with the same layout.
+func dialStatusAPI(publicDir string) (*grpc.ClientConn, error) {
+	conn, err := simulator.Dial(common.StatusPublicDir(filepath.Dir(publicDir)), common.ClientsCertFilePrefix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(i18n.G("%v: is the agent running?"), err)
+	} else if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// fetchStatus asks the running agent for its status.
+func fetchStatus(ctx context.Context, c agentapi.UIClient) (s agentStatus, err error) {
+	summary, err := c.GetSummary(ctx, &agentapi.Empty{})
+	if err != nil {
+		return s, fmt.Errorf(i18n.G("could not get the summary of the agent: %v"), err)
+	}
+
+	inventory, err := c.GetInventory(ctx, &agentapi.Empty{})
+	if err != nil {
+		return s, fmt.Errorf(i18n.G("could not get the distros of the agent: %v"), err)
+	}
+
+	s.Subscription = subscriptionSource(summary.GetSubscription())
+	s.Contracts = contractsStatus{
+		LastContact:         summary.GetContracts().GetLastContact(),
+		LastTokenRefresh:    summary.GetContracts().GetLastTokenRefresh(),
+		LastEntitlementSync: summary.GetContracts().GetLastEntitlementSync(),
+		LastError:           summary.GetContracts().GetLastError(),
+		LastErrorTime:       summary.GetContracts().GetLastErrorTime(),
+	}
+	s.Distros = make([]distroStatus, 0, len(inventory.GetRecords()))
+	for _, r := range inventory.GetRecords() {
+		s.Distros = append(s.Distros, distroStatus{
+			Name:         r.GetDistro(),
+			Running:      r.GetRunning(),
+			ProAttached:  r.GetProAttached(),
+			PendingTasks: int(r.GetPendingTasks()),
+		})
+		s.PendingTasks += int(r.GetPendingTasks())
+	}
+
+	return s, nil
+}
+
+// subscriptionSource returns where the subscription comes from, as printed by the status command.
+func subscriptionSource(info *agentapi.SubscriptionInfo) string {
+	switch info.GetSubscriptionType().(type) {
+	case *agentapi.SubscriptionInfo_User:
+		return "user"
+	case *agentapi.SubscriptionInfo_Organization:
+		return "organization"
+	case *agentapi.SubscriptionInfo_MicrosoftStore:
+		return "microsoft-store"
+	default:
+		return "none"
+	}
+}
+
+// printStatus writes a human-readable version of the status into w.
+func printStatus(w io.Writer, s agentStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "%s\t%s\n", i18n.G("Subscription:"), s.Subscription)
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Distros:"), len(s.Distros))
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Pending tasks:"), s.PendingTasks)
+
+	never := func(t string) string {
+		if t == "" {
+			return i18n.G("never")
+		}
+		return t
+	}
+	fmt.Fprintf(tw, "%s\t%s\n", i18n.G("Last contact with the contract server:"), never(s.Contracts.LastContact))
+	fmt.Fprintf(tw, "%s\t%s\n", i18n.G("Last token refresh:"), never(s.Contracts.LastTokenRefresh))
+	fmt.Fprintf(tw, "%s\t%s\n", i18n.G("Last entitlement sync:"), never(s.Contracts.LastEntitlementSync))
+	if s.Contracts.LastError != "" {
+		fmt.Fprintf(tw, "%s\t%s (%s)\n", i18n.G("Last error with the contract server:"), s.Contracts.LastError, s.Contracts.LastErrorTime)
+	}
+
+	if len(s.Distros) == 0 {
+		return tw.Flush()
+	}
+
+	yesNo := func(b bool) string {
+		if b {
+			return i18n.G("yes")
+		}
+		return i18n.G("no")
+	}
+
+	fmt.Fprintf(tw, "\n%s\t%s\t%s\t%s\n", i18n.G("DISTRO"), i18n.G("RUNNING"), i18n.G("PRO ATTACHED"), i18n.G("PENDING TASKS"))
+	for _, d := range s.Distros {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", d.Name, yesNo(d.Running), yesNo(d.ProAttached), d.PendingTasks)
+	}
+
+	return tw.Flush()
+}
+
+// writeStatusJSON writes the status into w as indented JSON, for scripting.
+func writeStatusJSON(w io.Writer, s agentStatus) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf(i18n.G("could not write the status: %v"), err)
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// eventsPollInterval is how often the dashboard asks for the events journaled by the agent, which are not streamed.
+	eventsPollInterval = 2 * time.Second
+
+	// dashboardMaxEvents is how many of the most recent events the dashboard shows.
+	dashboardMaxEvents = 10
+
+	// clearScreen moves the cursor to the top left corner of the terminal and clears it.
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+func (a *App) installTUI(o ...option) {
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: i18n.G("Shows a live dashboard of the running agent in the terminal"),
+		Long: i18n.G(`Shows a live dashboard of the running agent in the terminal, until interrupted with Ctrl+C.
+
+The dashboard shows the summary of the agent, the distros it knows with whether they are running, whether they
+are attached to Ubuntu Pro, how many tasks are queued for them and the last thing their current task did, and the
+most recent events of the agent. It is fed by the streams of the read-only status API, so the agent must be running.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			publicDir, err := a.publicDir(opt)
+			if err != nil {
+				return err
+			}
+
+			conn, err := dialStatusAPI(publicDir)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			enableTerminalSequences()
+
+			return runDashboard(ctx, agentapi.NewUIClient(conn), os.Stdout)
+		},
+	}
+
+	a.rootCmd.AddCommand(cmd)
+}
+
+// dashboard is the state of the live dashboard. In the manner of the Elm architecture, the streams of the agent
+// only produce messages, which update the dashboard, and the dashboard is drawn again after each of them.
+type dashboard struct {
+	summary *agentapi.Summary
+	records []*agentapi.InventoryRecord
+
+	// tasks is the last event of the tasks of every distro.
+	tasks map[string]*agentapi.TaskEvent
+
+	// events are the most recent events of the agent, oldest first.
+	events []*agentapi.AgentEvent
+
+	// err is the last error talking to the agent that did not stop the dashboard.
+	err error
+}
+
+// summaryMsg is sent every time the summary of the agent changes, along with the distros at that time.
+type summaryMsg struct {
+	summary   *agentapi.Summary
+	inventory *agentapi.Inventory
+}
+
+// taskMsg is sent for every event of the tasks of a distro.
+type taskMsg struct {
+	distro string
+	event  *agentapi.TaskEvent
+}
+
+// taskWatchEndedMsg is sent when the tasks of a distro are no longer watched, e.g. because it was removed.
+type taskWatchEndedMsg struct {
+	distro string
+}
+
+// eventsMsg is sent with the events journaled since the last poll.
+type eventsMsg struct {
+	events []*agentapi.AgentEvent
+
+	// truncated is true if some events were missed, so that the ones shown so far are out of date.
+	truncated bool
+}
+
+// errMsg is sent when talking to the agent failed, without stopping the dashboard.
+type errMsg struct {
+	err error
+}
+
+// update applies msg to the dashboard.
+func (d *dashboard) update(msg any) {
+	switch m := msg.(type) {
+	case summaryMsg:
+		d.summary = m.summary
+		d.records = m.inventory.GetRecords()
+		d.err = nil
+	case taskMsg:
+		if d.tasks == nil {
+			d.tasks = make(map[string]*agentapi.TaskEvent)
+		}
+		d.tasks[m.distro] = m.event
+	case taskWatchEndedMsg:
+		delete(d.tasks, m.distro)
+	case eventsMsg:
+		if m.truncated {
+			d.events = nil
+		}
+		d.events = append(d.events, m.events...)
+		if len(d.events) > dashboardMaxEvents {
+			d.events = d.events[len(d.events)-dashboardMaxEvents:]
+		}
+	case errMsg:
+		d.err = m.err
+	}
+}
+
+// view writes the dashboard into w.
+func (d dashboard) view(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "%s\n\n", i18n.G("Ubuntu Pro for WSL agent (press Ctrl+C to quit)"))
+
+	if d.summary == nil {
+		fmt.Fprintln(tw, i18n.G("Waiting for the agent…"))
+		return tw.Flush()
+	}
+
+	fmt.Fprintf(tw, "%s\t%s\n", i18n.G("Subscription:"), subscriptionSource(d.summary.GetSubscription()))
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Distros:"), d.summary.GetDistros())
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Connected:"), d.summary.GetConnected())
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Pending updates:"), d.summary.GetPendingUpdates())
+	fmt.Fprintf(tw, "%s\t%d\n", i18n.G("Errors:"), d.summary.GetErrors())
+
+	lastContact := d.summary.GetContracts().GetLastContact()
+	if lastContact == "" {
+		lastContact = i18n.G("never")
+	}
+	fmt.Fprintf(tw, "%s\t%s\n", i18n.G("Last contact with the contract server:"), lastContact)
+
+	if len(d.records) > 0 {
+		yesNo := func(b bool) string {
+			if b {
+				return i18n.G("yes")
+			}
+			return i18n.G("no")
+		}
+
+		fmt.Fprintf(tw, "\n%s\t%s\t%s\t%s\t%s\n", i18n.G("DISTRO"), i18n.G("RUNNING"), i18n.G("PRO ATTACHED"), i18n.G("PENDING TASKS"), i18n.G("LAST TASK"))
+		for _, r := range d.records {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", r.GetDistro(), yesNo(r.GetRunning()), yesNo(r.GetProAttached()), r.GetPendingTasks(), taskState(d.tasks[r.GetDistro()]))
+		}
+	}
+
+	if len(d.events) > 0 {
+		fmt.Fprintf(tw, "\n%s\t%s\t%s\t%s\n", i18n.G("TIME"), i18n.G("DISTRO"), i18n.G("EVENT"), i18n.G("DETAILS"))
+		for i := len(d.events) - 1; i >= 0; i-- {
+			ev := d.events[i]
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ev.GetTime(), ev.GetDistro(), eventKind(ev.GetType()), ev.GetMessage())
+		}
+	}
+
+	if d.err != nil {
+		fmt.Fprintf(tw, "\n%s\t%v\n", i18n.G("Error:"), d.err)
+	}
+
+	return tw.Flush()
+}
+
+// taskState returns a short description of the last event of the tasks of a distro.
+func taskState(ev *agentapi.TaskEvent) string {
+	if ev == nil {
+		return "-"
+	}
+
+	switch ev.GetType() {
+	case agentapi.TaskEventType_TASK_EVENT_QUEUED:
+		return fmt.Sprintf(i18n.G("%s: queued"), ev.GetTask())
+	case agentapi.TaskEventType_TASK_EVENT_STARTED:
+		return fmt.Sprintf(i18n.G("%s: started"), ev.GetTask())
+	case agentapi.TaskEventType_TASK_EVENT_PROGRESS:
+		return fmt.Sprintf(i18n.G("%s: %d%%"), ev.GetTask(), ev.GetProgress())
+	case agentapi.TaskEventType_TASK_EVENT_COMPLETED:
+		return fmt.Sprintf(i18n.G("%s: completed"), ev.GetTask())
+	case agentapi.TaskEventType_TASK_EVENT_FAILED:
+		return fmt.Sprintf(i18n.G("%s: failed: %s"), ev.GetTask(), ev.GetReason())
+	case agentapi.TaskEventType_TASK_EVENT_INTERRUPTED:
+		return fmt.Sprintf(i18n.G("%s: interrupted: %s"), ev.GetTask(), ev.GetReason())
+	default:
+		return ev.GetTask()
+	}
+}
+
+// eventKind returns a short name for the type of an event of the agent.
+func eventKind(t agentapi.AgentEventType) string {
+	name, ok := strings.CutPrefix(t.String(), "AGENT_EVENT_")
+	if !ok {
+		return t.String()
+	}
+	return strings.ToLower(strings.ReplaceAll(name, "_", " "))
+}
+
+// runDashboard draws the live dashboard of the agent c talks to into out, until ctx is cancelled or the agent
+// stops.
+func runDashboard(ctx context.Context, c agentapi.UIClient, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs := make(chan any)
+	send := func(msg any) {
+		select {
+		case <-ctx.Done():
+		case msgs <- msg:
+		}
+	}
+
+	// The summary stream tells when the agent goes away, so that the dashboard stops with it.
+	summaryErr := make(chan error, 1)
+	go func() {
+		summaryErr <- watchSummary(ctx, c, send)
+	}()
+	go pollEvents(ctx, c, send)
+
+	var d dashboard
+	watched := make(map[string]bool)
+
+	for {
+		if _, err := io.WriteString(out, clearScreen); err != nil {
+			return err
+		}
+		if err := d.view(out); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-summaryErr:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		case msg := <-msgs:
+			d.update(msg)
+
+			switch m := msg.(type) {
+			case summaryMsg:
+				// Watching the tasks of the distros as they appear.
+				for _, r := range m.inventory.GetRecords() {
+					if watched[r.GetDistro()] {
+						continue
+					}
+					watched[r.GetDistro()] = true
+					go watchTasks(ctx, c, r.GetDistro(), send)
+				}
+			case taskWatchEndedMsg:
+				delete(watched, m.distro)
+			}
+		}
+	}
+}
+
+// watchSummary sends a summaryMsg every time the summary of the agent changes, until ctx is cancelled or the
+// stream breaks.
+func watchSummary(ctx context.Context, c agentapi.UIClient, send func(any)) error {
+	stream, err := c.WatchSummary(ctx, &agentapi.Empty{})
+	if err != nil {
+		return fmt.Errorf(i18n.G("could not watch the summary of the agent: %v"), err)
+	}
+
+	for {
+		summary, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return errors.New(i18n.G("the agent stopped"))
+		} else if err != nil {
+			return fmt.Errorf(i18n.G("could not watch the summary of the agent: %v"), err)
+		}
+
+		inventory, err := c.GetInventory(ctx, &agentapi.Empty{})
+		if err != nil {
+			send(errMsg{fmt.Errorf(i18n.G("could not get the distros of the agent: %v"), err)})
+			continue
+		}
+
+		send(summaryMsg{summary: summary, inventory: inventory})
+	}
+}
+
+// watchTasks sends a taskMsg for every event of the tasks of distro, and a taskWatchEndedMsg once the stream
+// ends, so that it can be watched again if the distro comes back.
+func watchTasks(ctx context.Context, c agentapi.UIClient, distro string, send func(any)) {
+	defer send(taskWatchEndedMsg{distro: distro})
+
+	stream, err := c.WatchTasks(ctx, &agentapi.WatchTasksRequest{Distro: distro})
+	if err != nil {
+		return
+	}
+
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			// The distro was removed, or the agent stopped, which the summary stream reports.
+			return
+		}
+		send(taskMsg{distro: distro, event: ev})
+	}
+}
+
+// pollEvents sends the events journaled by the agent since the last poll, every eventsPollInterval, until ctx is
+// cancelled.
+func pollEvents(ctx context.Context, c agentapi.UIClient, send func(any)) {
+	var token string
+	for {
+		events, err := c.GetEvents(ctx, &agentapi.GetEventsRequest{SinceToken: token})
+		if err != nil && ctx.Err() == nil {
+			send(errMsg{fmt.Errorf(i18n.G("could not get the events of the agent: %v"), err)})
+		} else if err == nil {
+			token = events.GetNextToken()
+			if len(events.GetEvents()) > 0 || events.GetTruncated() {
+				send(eventsMsg{events: events.GetEvents(), truncated: events.GetTruncated()})
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsPollInterval):
+		}
+	}
+}
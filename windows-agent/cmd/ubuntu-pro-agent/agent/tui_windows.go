@@ -0,0 +1,19 @@
+package agent
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableTerminalSequences makes the console interpret the escape sequences the dashboard is drawn with, which
+// older consoles print as is unless told otherwise. Failing to do so is not fatal: the dashboard is only messier.
+func enableTerminalSequences() {
+	h := windows.Handle(os.Stdout.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return
+	}
+	_ = windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+}
@@ -0,0 +1,211 @@
+// Package bandwidth accounts for the data the agent transfers to and from each distro, so that the organization
+// can cap it per day on metered or constrained networks.
+package bandwidth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/metrics"
+)
+
+// Kind is the kind of data transferred.
+type Kind string
+
+const (
+	// KindFiles are the files transferred between Windows and the distro, such as the reports of USG audits.
+	KindFiles Kind = "files"
+
+	// KindDebs are the packages the distro installs from Windows, such as the local deb of wsl-pro-service.
+	KindDebs Kind = "debs"
+
+	// KindLogs are the lines of the journal streamed from the distro.
+	KindLogs Kind = "logs"
+)
+
+// Kinds are all the kinds of data transferred, in the order they are reported.
+var Kinds = []Kind{KindFiles, KindDebs, KindLogs}
+
+// ErrCapReached is the error of the transfers refused because they would exceed the daily cap of their distro.
+var ErrCapReached = errors.New("the daily transfer cap of the distro is reached")
+
+// CapReachedError is the error Reserve returns for the transfers that would exceed the daily cap of their distro.
+// It wraps ErrCapReached, and tells when the cap resets so that the transfer can be attempted again.
+type CapReachedError struct {
+	Kind     Kind
+	Needed   uint64
+	Today    uint64
+	DailyCap uint64
+
+	// Reset is when what the distro transferred today starts over: the next midnight, local time.
+	Reset time.Time
+}
+
+func (e CapReachedError) Error() string {
+	return fmt.Sprintf("%v: %d of %d bytes transferred today, %d more needed for the %s", ErrCapReached, e.Today, e.DailyCap, e.Needed, e.Kind)
+}
+
+func (e CapReachedError) Unwrap() error {
+	return ErrCapReached
+}
+
+var (
+	transferred = metrics.Default.Counter("bytes_transferred_total", "Bytes the agent transferred to and from the distros since it started, by distro and kind of transfer.", "distro", "kind")
+	refused     = metrics.Default.Counter("transfers_refused_total", "Number of transfers refused because their distro reached its daily cap, by distro and kind of transfer.", "distro", "kind")
+)
+
+// Usage is the data transferred to and from a distro, in bytes, by kind.
+type Usage struct {
+	// Today is what was transferred since midnight, local time. The daily cap applies to it.
+	Today map[Kind]uint64
+
+	// Total is what was transferred since the agent started.
+	Total map[Kind]uint64
+
+	// DailyCap is how much the distro may transfer per day. Zero means no cap.
+	DailyCap uint64
+}
+
+// TodayTotal returns what was transferred today, all kinds together.
+func (u Usage) TodayTotal() uint64 {
+	var total uint64
+	for _, n := range u.Today {
+		total += n
+	}
+	return total
+}
+
+// Meter keeps the usage of every distro, and refuses the transfers that would exceed the daily cap. It is not
+// stored: the usage starts over every time the agent starts.
+type Meter struct {
+	dailyCap uint64
+
+	usage map[string]*distroUsage
+	mu    sync.Mutex
+
+	// now returns the current time, overridden in tests.
+	now func() time.Time
+}
+
+// distroUsage is the usage of a distro, along with the day its Today counts.
+type distroUsage struct {
+	Usage
+	day time.Time
+}
+
+// New creates a meter without cap.
+func New() *Meter {
+	return &Meter{
+		usage: make(map[string]*distroUsage),
+		now:   time.Now,
+	}
+}
+
+// SetDailyCap sets how many bytes each distro may transfer per day. Zero removes the cap.
+func (m *Meter) SetDailyCap(bytes uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dailyCap = bytes
+}
+
+// Reserve accounts for n bytes about to be transferred to or from the distro, unless they would exceed its daily
+// cap. The error is then a CapReachedError, and nothing is accounted for. Reserving zero bytes only checks that the
+// cap is not reached yet, for the transfers whose size is only known once done (see Record).
+//
+// The bytes reserved are accounted for even if the transfer fails, as it may have transferred some of them.
+func (m *Meter) Reserve(distro string, kind Kind, n uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := m.usageOf(distro)
+	if m.dailyCap > 0 {
+		today := u.TodayTotal()
+		if today >= m.dailyCap || n > m.dailyCap-today {
+			refused.Inc(distro, string(kind))
+			return CapReachedError{Kind: kind, Needed: n, Today: today, DailyCap: m.dailyCap, Reset: u.day.AddDate(0, 0, 1)}
+		}
+	}
+
+	u.add(distro, kind, n)
+	return nil
+}
+
+// Record accounts for n bytes transferred to or from the distro, whatever its daily cap: they were transferred
+// already.
+func (m *Meter) Record(distro string, kind Kind, n uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.usageOf(distro).add(distro, kind, n)
+}
+
+// Usage returns what the distro transferred.
+func (m *Meter) Usage(distro string) Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := m.usageOf(distro)
+	return Usage{
+		Today:    maps.Clone(u.Today),
+		Total:    maps.Clone(u.Total),
+		DailyCap: m.dailyCap,
+	}
+}
+
+// Forget drops the usage of the distro, e.g. once it is removed from the database.
+func (m *Meter) Forget(distro string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.usage, distro)
+}
+
+// usageOf returns the usage of the distro, with what it transferred today reset if the day changed since the
+// last transfer. The meter must be locked.
+func (m *Meter) usageOf(distro string) *distroUsage {
+	now := m.now()
+	y, mo, d := now.Date()
+	day := time.Date(y, mo, d, 0, 0, 0, 0, now.Location())
+
+	u, ok := m.usage[distro]
+	if !ok {
+		u = &distroUsage{Usage: Usage{Today: make(map[Kind]uint64), Total: make(map[Kind]uint64)}, day: day}
+		m.usage[distro] = u
+	}
+
+	if !u.day.Equal(day) {
+		u.Today = make(map[Kind]uint64)
+		u.day = day
+	}
+
+	return u
+}
+
+// add accounts for n bytes of the given kind.
+func (u *distroUsage) add(distro string, kind Kind, n uint64) {
+	if n == 0 {
+		return
+	}
+	u.Today[kind] += n
+	u.Total[kind] += n
+	transferred.Add(float64(n), distro, string(kind))
+}
+
+// meterKey is the context key under which the meter is stored.
+type meterKey struct{}
+
+// WithMeter returns a context carrying the meter, so that the distros created with it account for their transfers.
+func WithMeter(ctx context.Context, m *Meter) context.Context {
+	return context.WithValue(ctx, meterKey{}, m)
+}
+
+// FromContext returns the meter stored in the context, if any.
+func FromContext(ctx context.Context) (*Meter, bool) {
+	m, ok := ctx.Value(meterKey{}).(*Meter)
+	return m, ok && m != nil
+}
@@ -0,0 +1,146 @@
+package bandwidth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserve(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		dailyCap  uint64
+		preRecord uint64
+		reserve   uint64
+
+		wantToday uint64
+		wantErr   bool
+	}{
+		"Success without cap":                          {preRecord: 1000, reserve: 500, wantToday: 1500},
+		"Success below the cap":                        {dailyCap: 2000, preRecord: 1000, reserve: 500, wantToday: 1500},
+		"Success reaching the cap exactly":             {dailyCap: 1500, preRecord: 1000, reserve: 500, wantToday: 1500},
+		"Success checking the cap by reserving zero":   {dailyCap: 1500, preRecord: 1000, wantToday: 1000},
+		"Success reserving zero bytes without any use": {dailyCap: 1500},
+
+		"Error when the reservation exceeds the cap":      {dailyCap: 1200, preRecord: 1000, reserve: 500, wantToday: 1000, wantErr: true},
+		"Error when the cap is reached already":           {dailyCap: 1000, preRecord: 1000, wantToday: 1000, wantErr: true},
+		"Error when records went over the cap":            {dailyCap: 1000, preRecord: 1500, reserve: 1, wantToday: 1500, wantErr: true},
+		"Error when a single reservation exceeds the cap": {dailyCap: 1000, reserve: 1001, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := bandwidth.New()
+			m.SetDailyCap(tc.dailyCap)
+			m.Record("Ubuntu", bandwidth.KindLogs, tc.preRecord)
+
+			err := m.Reserve("Ubuntu", bandwidth.KindDebs, tc.reserve)
+			if tc.wantErr {
+				require.ErrorIs(t, err, bandwidth.ErrCapReached, "Reserve should return an error wrapping ErrCapReached")
+			} else {
+				require.NoError(t, err, "Reserve should return no error")
+			}
+
+			u := m.Usage("Ubuntu")
+			require.Equal(t, tc.wantToday, u.TodayTotal(), "Unexpected bytes transferred today")
+			require.Equal(t, tc.dailyCap, u.DailyCap, "Usage should report the daily cap")
+			require.Zero(t, m.Usage("Debian").TodayTotal(), "Other distros should not be affected")
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.March, 10, 23, 0, 0, 0, time.Local)
+	m := bandwidth.New()
+	m.SetNow(func() time.Time { return now })
+	m.SetDailyCap(1000)
+
+	m.Record("Ubuntu", bandwidth.KindFiles, 300)
+	require.NoError(t, m.Reserve("Ubuntu", bandwidth.KindDebs, 700), "Setup: Reserve should return no error")
+
+	u := m.Usage("Ubuntu")
+	require.Equal(t, map[bandwidth.Kind]uint64{bandwidth.KindFiles: 300, bandwidth.KindDebs: 700}, u.Today, "Unexpected usage today")
+	require.Equal(t, u.Today, u.Total, "The total should match the usage today on the first day")
+	var capErr bandwidth.CapReachedError
+	require.ErrorAs(t, m.Reserve("Ubuntu", bandwidth.KindLogs, 1), &capErr, "Reserve should refuse transfers once the cap is reached")
+	require.Equal(t, time.Date(2024, time.March, 11, 0, 0, 0, 0, time.Local), capErr.Reset, "The cap should reset at the next midnight")
+
+	// Changing the usage returned should not change that of the meter.
+	u.Today[bandwidth.KindFiles] = 0
+	require.Equal(t, uint64(300), m.Usage("Ubuntu").Today[bandwidth.KindFiles], "Usage should return a copy")
+
+	// The usage of today starts over the next day.
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, m.Reserve("Ubuntu", bandwidth.KindLogs, 100), "Reserve should accept transfers the next day")
+
+	u = m.Usage("Ubuntu")
+	require.Equal(t, map[bandwidth.Kind]uint64{bandwidth.KindLogs: 100}, u.Today, "Unexpected usage the next day")
+	require.Equal(t, map[bandwidth.Kind]uint64{bandwidth.KindFiles: 300, bandwidth.KindDebs: 700, bandwidth.KindLogs: 100}, u.Total, "The total should accumulate across days")
+
+	// Removing the cap lets any transfer through.
+	m.SetDailyCap(0)
+	require.NoError(t, m.Reserve("Ubuntu", bandwidth.KindDebs, 1<<40), "Reserve should accept any transfer without cap")
+
+	m.Forget("Ubuntu")
+	require.Empty(t, m.Usage("Ubuntu").Total, "Forget should drop the usage of the distro")
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	_, ok := bandwidth.FromContext(context.Background())
+	require.False(t, ok, "FromContext should find no meter in a context without one")
+
+	m := bandwidth.New()
+	got, ok := bandwidth.FromContext(bandwidth.WithMeter(context.Background(), m))
+	require.True(t, ok, "FromContext should find the meter")
+	require.Same(t, m, got, "FromContext should return the meter stored in the context")
+}
+
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		size string
+
+		want    uint64
+		wantErr bool
+	}{
+		"Success with an empty size":        {want: 0},
+		"Success with plain bytes":          {size: "1048576", want: 1048576},
+		"Success with bytes as unit":        {size: "512B", want: 512},
+		"Success with a decimal unit":       {size: "500MB", want: 500_000_000},
+		"Success with a binary unit":        {size: "2GiB", want: 2 << 30},
+		"Success with a fractional size":    {size: "1.5KB", want: 1500},
+		"Success with spaces and lowercase": {size: " 3 mib ", want: 3 << 20},
+		"Success with the largest unit":     {size: "1TiB", want: 1 << 40},
+
+		"Error when the unit is unknown":     {size: "10 parsecs", wantErr: true},
+		"Error when there is no number":      {size: "MB", wantErr: true},
+		"Error when the number is malformed": {size: "1.2.3GB", wantErr: true},
+		"Error when the size is negative":    {size: "-5MB", wantErr: true},
+		"Error when the size is too large":   {size: "100000000TB", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := bandwidth.ParseSize(tc.size)
+			if tc.wantErr {
+				require.Error(t, err, "ParseSize should return an error")
+				return
+			}
+			require.NoError(t, err, "ParseSize should return no error")
+			require.Equal(t, tc.want, got, "Unexpected size")
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package bandwidth
+
+import "time"
+
+// SetNow overrides the clock of the meter, so that tests can move to the next day.
+func (m *Meter) SetNow(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = now
+}
@@ -0,0 +1,54 @@
+package bandwidth
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the multipliers of the units accepted by ParseSize, matched case-insensitively. Decimal units
+// are powers of 1000 and binary ones powers of 1024.
+var sizeUnits = map[string]uint64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+}
+
+// ParseSize parses an amount of bytes such as "500MB", "2 GiB" or "1048576". An empty string is zero.
+func ParseSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	number, unit := s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+
+	mult, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q in size %q, expected B, KB, MB, GB, TB, KiB, MiB, GiB or TiB", s[i:], s)
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %v", s, err)
+	}
+
+	bytes := n * float64(mult)
+	if bytes >= math.MaxUint64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+
+	return uint64(bytes), nil
+}
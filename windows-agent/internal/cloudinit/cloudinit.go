@@ -22,6 +22,7 @@ import (
 type Config interface {
 	Subscription() (string, config.Source, error)
 	LandscapeClientConfig() (string, config.Source, error)
+	DistroGroupsOverrideAll() (subscription, landscape bool, err error)
 }
 
 // CloudInit contains necessary data to drop cloud-init user data files for WSL's data source to pick them up.
@@ -140,15 +141,46 @@ func (c CloudInit) RemoveDistroData(distroName string) (err error) {
 	return nil
 }
 
+// RenameDistroData moves the cloud-init user data of a distro so that it is used by its new name.
+//
+// No error is returned if the data did not exist.
+func (c CloudInit) RenameDistroData(oldName, newName string) (err error) {
+	defer decorate.OnError(&err, "could not rename distro-specific cloud-init file")
+
+	oldPath := filepath.Join(c.dataDir, oldName+".user-data")
+	newPath := filepath.Join(c.dataDir, newName+".user-data")
+
+	err = os.Rename(oldPath, newPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
 func marshalConfig(conf Config) ([]byte, error) {
 	contents := make(map[string]interface{})
 
-	if err := ubuntuProModule(conf, contents); err != nil {
+	// The agent data applies to every distro, and cloud-init runs before the agent can tell which distro group
+	// a distro belongs to. It holds the global settings, which ungrouped distros fall back to, while grouped
+	// distros get theirs from the agent once they connect. A setting is only left out when distro groups
+	// override it for every distro, as no distro would fall back to it.
+	groupSubscription, groupLandscape, err := conf.DistroGroupsOverrideAll()
+	if err != nil {
 		return nil, err
 	}
 
-	if err := landscapeModule(conf, contents); err != nil {
-		return nil, err
+	if !groupSubscription {
+		if err := ubuntuProModule(conf, contents); err != nil {
+			return nil, err
+		}
+	}
+
+	if !groupLandscape {
+		if err := landscapeModule(conf, contents); err != nil {
+			return nil, err
+		}
 	}
 
 	// If there is no config to write, then let's not write an empty object with comments to avoid confusing cloud-init.
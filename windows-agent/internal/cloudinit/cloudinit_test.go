@@ -92,9 +92,14 @@ hostagent_uid = landscapeUID1234
 		skipLandscapeConf bool
 		skipHostAgentUID  bool
 
+		// Distro groups overriding the global settings for every distro
+		groupSubscription bool
+		groupLandscape    bool
+
 		// Break marshalling
 		breakSubscription bool
 		breakLandscape    bool
+		breakGroups       bool
 
 		// Landscape parsing
 		landscapeNoClientSection bool
@@ -108,16 +113,20 @@ hostagent_uid = landscapeUID1234
 
 		wantAgentYamlAsDir bool
 	}{
-		"Success":                            {},
-		"Without hostagent UID":              {skipHostAgentUID: true},
-		"Without pro token":                  {skipProToken: true},
-		"Without Landscape":                  {skipLandscapeConf: true},
-		"Without Landscape [client] section": {landscapeNoClientSection: true},
-		"With empty contents":                {skipProToken: true, skipLandscapeConf: true},
+		"Success":                                     {},
+		"Without hostagent UID":                       {skipHostAgentUID: true},
+		"Without pro token":                           {skipProToken: true},
+		"Without Landscape":                           {skipLandscapeConf: true},
+		"Without Landscape [client] section":          {landscapeNoClientSection: true},
+		"With empty contents":                         {skipProToken: true, skipLandscapeConf: true},
+		"With distro groups overriding the pro token": {groupSubscription: true},
+		"With distro groups overriding Landscape":     {groupLandscape: true},
+		"With distro groups overriding everything":    {groupSubscription: true, groupLandscape: true},
 
 		"Error to remove existing agent.yaml":   {skipProToken: true, skipLandscapeConf: true, breakRemovingFile: true, wantAgentYamlAsDir: true},
 		"Error obtaining pro token":             {breakSubscription: true},
 		"Error obtaining Landscape config":      {breakLandscape: true},
+		"Error obtaining distro groups":         {breakGroups: true},
 		"Error with erroneous Landscape config": {badLandscape: true},
 
 		"Error when the datadir cannot be created":   {breakDir: true},
@@ -147,6 +156,9 @@ hostagent_uid = landscapeUID1234
 			// Test overriding the file: New() created the agent.yaml file
 			conf.subcriptionErr = tc.breakSubscription
 			conf.landscapeErr = tc.breakLandscape
+			conf.groupsErr = tc.breakGroups
+			conf.groupSubscription = tc.groupSubscription
+			conf.groupLandscape = tc.groupLandscape
 
 			conf.proToken = "NEW_PRO_TOKEN"
 			if tc.skipProToken {
@@ -216,6 +228,75 @@ hostagent_uid = landscapeUID1234
 	}
 }
 
+func TestUpdateWithDistroGroups(t *testing.T) {
+	t.Parallel()
+
+	const teamA = `
+- name: team-a
+  pattern: Ubuntu-TeamA-*
+  ubuntu_pro_token: TEAM_A_TOKEN
+  landscape_config: "[client]\nurl=www.example.com/team-a"
+`
+
+	const everyoneElse = `
+- name: everyone-else
+  pattern: "*"
+  ubuntu_pro_token: EVERYONE_ELSE_TOKEN
+`
+
+	testCases := map[string]struct {
+		groups string
+
+		wantSubscription bool
+		wantLandscape    bool
+	}{
+		"Success with no distro groups":                       {wantSubscription: true, wantLandscape: true},
+		"Success with a mix of grouped and ungrouped distros": {groups: teamA, wantSubscription: true, wantLandscape: true},
+		"Success with every distro grouped":                   {groups: teamA + everyoneElse, wantLandscape: true},
+		"Success with a catch-all distro group listed first":  {groups: everyoneElse + teamA, wantLandscape: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			publicDir := t.TempDir()
+			path := filepath.Join(publicDir, ".cloud-init", "agent.yaml")
+
+			conf := config.New(ctx, t.TempDir())
+			err := conf.UpdateRegistryData(ctx, config.RegistryData{
+				UbuntuProToken:  "GLOBAL_TOKEN",
+				LandscapeConfig: "[client]\nurl=www.example.com/global",
+				DistroGroups:    tc.groups,
+			}, nil)
+			require.NoError(t, err, "Setup: UpdateRegistryData should not have failed")
+
+			_, err = cloudinit.New(ctx, conf, publicDir)
+			require.NoError(t, err, "Setup: cloudinit.New should return no error")
+
+			got, err := os.ReadFile(path)
+			require.NoError(t, err, "There should be no error reading the cloud-init agent file")
+
+			// Ungrouped distros fall back to the global settings, grouped ones get theirs once they connect.
+			if tc.wantSubscription {
+				require.Contains(t, string(got), "GLOBAL_TOKEN", "Agent data should contain the global Pro token")
+			} else {
+				require.NotContains(t, string(got), "ubuntu_pro", "Agent data should not contain a Pro token")
+			}
+			require.NotContains(t, string(got), "TEAM_A_TOKEN", "Agent data should not contain the Pro token of a distro group")
+			require.NotContains(t, string(got), "EVERYONE_ELSE_TOKEN", "Agent data should not contain the Pro token of a distro group")
+
+			if tc.wantLandscape {
+				require.Contains(t, string(got), "www.example.com/global", "Agent data should contain the global Landscape config")
+			} else {
+				require.NotContains(t, string(got), "landscape", "Agent data should not contain a Landscape config")
+			}
+			require.NotContains(t, string(got), "www.example.com/team-a", "Agent data should not contain the Landscape config of a distro group")
+		})
+	}
+}
+
 func TestWriteDistroData(t *testing.T) {
 	t.Parallel()
 
@@ -366,12 +447,77 @@ func TestRemoveDistroData(t *testing.T) {
 	}
 }
 
+func TestRenameDistroData(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		fileDoesNotExist bool
+		dirDoesNotExist  bool
+		newFileIsDir     bool
+
+		wantErr bool
+	}{
+		"Success":                                  {},
+		"Success when the file did not exist":      {fileDoesNotExist: true},
+		"Success when the directory did not exist": {dirDoesNotExist: true},
+
+		"Error when file cannot be renamed": {newFileIsDir: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			publicDir := t.TempDir()
+			dir := filepath.Join(publicDir, ".cloud-init")
+			oldPath := filepath.Join(dir, "CoolDistro.user-data")
+			newPath := filepath.Join(dir, "CoolerDistro.user-data")
+
+			ci, err := cloudinit.New(ctx, &mockConfig{}, publicDir)
+			require.NoError(t, err, "Setup: cloud-init New should return no errors")
+
+			if !tc.dirDoesNotExist {
+				require.NoError(t, os.MkdirAll(dir, 0700), "Setup: could not set up directory")
+				if !tc.fileDoesNotExist {
+					require.NoError(t, os.WriteFile(oldPath, []byte("hello, world!"), 0600), "Setup: could not write distro data")
+				}
+				if tc.newFileIsDir {
+					// The data cannot be moved over a non-empty directory.
+					require.NoError(t, os.MkdirAll(filepath.Join(newPath, "child"), 0700), "Setup: could not set up blocking directory")
+				}
+			}
+
+			err = ci.RenameDistroData("CoolDistro", "CoolerDistro")
+			if tc.wantErr {
+				require.Error(t, err, "RenameDistroData should return an error")
+				require.FileExists(t, oldPath, "RenameDistroData should not have removed the distro cloud-init data file")
+				return
+			}
+			require.NoError(t, err, "RenameDistroData should return no errors")
+			require.NoFileExists(t, oldPath, "RenameDistroData should have moved the distro cloud-init data file")
+
+			if tc.fileDoesNotExist || tc.dirDoesNotExist {
+				require.NoFileExists(t, newPath, "RenameDistroData should not create distro cloud-init data out of nothing")
+				return
+			}
+			out, err := os.ReadFile(newPath)
+			require.NoError(t, err, "RenameDistroData should have moved the data to the new name")
+			require.Equal(t, "hello, world!", string(out), "Mismatch in the moved distro cloud-init data")
+		})
+	}
+}
+
 type mockConfig struct {
 	proToken       string
 	subcriptionErr bool
 
 	landscapeConf string
 	landscapeErr  bool
+
+	groupSubscription bool
+	groupLandscape    bool
+	groupsErr         bool
 }
 
 func (c mockConfig) Subscription() (string, config.Source, error) {
@@ -397,3 +543,11 @@ func (c mockConfig) LandscapeClientConfig() (string, config.Source, error) {
 
 	return c.landscapeConf, config.SourceUser, nil
 }
+
+func (c mockConfig) DistroGroupsOverrideAll() (bool, bool, error) {
+	if c.groupsErr {
+		return false, false, errors.New("could not get distro groups: mock error")
+	}
+
+	return c.groupSubscription, c.groupLandscape, nil
+}
@@ -0,0 +1,76 @@
+// Package compliance aggregates the security status reported by each distro into
+// machine-level compliance metrics.
+package compliance
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+)
+
+// DistroStatus is the security status of a single distro.
+type DistroStatus struct {
+	Name     string
+	Security distro.SecurityStatus
+}
+
+// FullyPatched returns true if the distro reported no pending security updates.
+func (s DistroStatus) FullyPatched() bool {
+	return s.Security.Known && s.Security.StandardUpdates == 0 && s.Security.ESMUpdates == 0
+}
+
+// Report contains the compliance metrics of all distros in the machine.
+type Report struct {
+	// Total is the number of distros in the report.
+	Total int
+
+	// FullyPatched is the number of distros with no pending security updates.
+	FullyPatched int
+
+	// PendingSecurity is the number of distros with pending security updates from the Ubuntu archive.
+	PendingSecurity int
+
+	// PendingESM is the number of distros with pending security updates from ESM.
+	PendingESM int
+
+	// Unknown is the number of distros that never reported their security status.
+	Unknown int
+
+	// Distros contains the status of every distro, sorted by name.
+	Distros []DistroStatus
+}
+
+// NewReport aggregates the security status of the distros with the given properties, indexed by name.
+func NewReport(props map[string]distro.Properties) Report {
+	r := Report{
+		Total:   len(props),
+		Distros: make([]DistroStatus, 0, len(props)),
+	}
+
+	for name, p := range props {
+		s := DistroStatus{Name: name, Security: p.Security}
+		r.Distros = append(r.Distros, s)
+
+		if !s.Security.Known {
+			r.Unknown++
+			continue
+		}
+
+		if s.FullyPatched() {
+			r.FullyPatched++
+		}
+		if s.Security.StandardUpdates > 0 {
+			r.PendingSecurity++
+		}
+		if s.Security.ESMUpdates > 0 {
+			r.PendingESM++
+		}
+	}
+
+	sort.Slice(r.Distros, func(i, j int) bool {
+		return strings.ToLower(r.Distros[i].Name) < strings.ToLower(r.Distros[j].Name)
+	})
+
+	return r
+}
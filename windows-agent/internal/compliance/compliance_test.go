@@ -0,0 +1,69 @@
+package compliance_test
+
+import (
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/compliance"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+
+	patched := distro.Properties{Security: distro.SecurityStatus{Known: true}}
+	pendingSecurity := distro.Properties{Security: distro.SecurityStatus{Known: true, StandardUpdates: 3}}
+	pendingESM := distro.Properties{Security: distro.SecurityStatus{Known: true, ESMUpdates: 2}}
+	pendingBoth := distro.Properties{Security: distro.SecurityStatus{Known: true, StandardUpdates: 1, ESMUpdates: 1}}
+	unknown := distro.Properties{}
+
+	testCases := map[string]struct {
+		props map[string]distro.Properties
+
+		want      compliance.Report
+		wantNames []string
+	}{
+		"Empty report with no distros": {want: compliance.Report{}},
+
+		"Only fully patched distros": {
+			props:     map[string]distro.Properties{"b": patched, "a": patched},
+			want:      compliance.Report{Total: 2, FullyPatched: 2},
+			wantNames: []string{"a", "b"},
+		},
+		"Mixed statuses": {
+			props: map[string]distro.Properties{
+				"Patched": patched, "security": pendingSecurity, "esm": pendingESM, "both": pendingBoth, "Unknown": unknown,
+			},
+			want:      compliance.Report{Total: 5, FullyPatched: 1, PendingSecurity: 2, PendingESM: 2, Unknown: 1},
+			wantNames: []string{"both", "esm", "Patched", "security", "Unknown"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := compliance.NewReport(tc.props)
+
+			var names []string
+			for _, d := range got.Distros {
+				names = append(names, d.Name)
+				require.Equal(t, tc.props[d.Name].Security, d.Security, "Mismatched security status for distro %q", d.Name)
+			}
+			require.Equal(t, tc.wantNames, names, "Distros should be sorted by name")
+
+			got.Distros = nil
+			require.Equal(t, tc.want, got, "Mismatched compliance metrics")
+		})
+	}
+}
+
+func TestCounterValues(t *testing.T) {
+	t.Parallel()
+
+	r := compliance.Report{Total: 5, FullyPatched: 1, PendingSecurity: 2, PendingESM: 3, Unknown: 4}
+
+	// The identifiers are those of the counters in compliance.man.
+	want := map[uint32]uint32{1: 5, 2: 1, 3: 2, 4: 3, 5: 4}
+	require.Equal(t, want, compliance.CounterValues(r), "Mismatched performance counter values")
+}
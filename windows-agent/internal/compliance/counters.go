@@ -0,0 +1,21 @@
+package compliance
+
+// Identifiers of the counters in the counter set. They must match those in compliance.man.
+const (
+	counterDistros uint32 = iota + 1
+	counterFullyPatched
+	counterPendingSecurity
+	counterPendingESM
+	counterUnknown
+)
+
+// counterValues returns the value of every counter for the report, indexed by counter identifier.
+func counterValues(r Report) map[uint32]uint32 {
+	return map[uint32]uint32{
+		counterDistros:         uint32(r.Total),
+		counterFullyPatched:    uint32(r.FullyPatched),
+		counterPendingSecurity: uint32(r.PendingSecurity),
+		counterPendingESM:      uint32(r.PendingESM),
+		counterUnknown:         uint32(r.Unknown),
+	}
+}
@@ -0,0 +1,147 @@
+package compliance
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/ubuntu/decorate"
+	"golang.org/x/sys/windows"
+)
+
+// The counter set is published through the Performance Counters (PerfLib V2) API. Consumers such as
+// Performance Monitor only find it once compliance.man is registered with `lodctr /m:compliance.man`.
+// The MSIX package cannot register it, so that is a manual step (see docs/reference/performance_counters.md).
+var (
+	advapi32 = windows.NewLazySystemDLL("advapi32.dll")
+
+	perfStartProvider      = advapi32.NewProc("PerfStartProvider")
+	perfStopProvider       = advapi32.NewProc("PerfStopProvider")
+	perfSetCounterSetInfo  = advapi32.NewProc("PerfSetCounterSetInfo")
+	perfCreateInstance     = advapi32.NewProc("PerfCreateInstance")
+	perfDeleteInstance     = advapi32.NewProc("PerfDeleteInstance")
+	perfSetULongCounterVal = advapi32.NewProc("PerfSetULongCounterValue")
+)
+
+var (
+	// providerGUID and counterSetGUID must match those in compliance.man.
+	providerGUID   = windows.GUID{Data1: 0x5c3c7a3e, Data2: 0x4f7b, Data3: 0x4d4e, Data4: [8]byte{0x9b, 0x8f, 0x1f, 0x6a, 0x2c, 0x9d, 0x7e, 0x01}}
+	counterSetGUID = windows.GUID{Data1: 0x5c3c7a3e, Data2: 0x4f7b, Data3: 0x4d4e, Data4: [8]byte{0x9b, 0x8f, 0x1f, 0x6a, 0x2c, 0x9d, 0x7e, 0x02}}
+)
+
+const (
+	numCounters = 5
+
+	// counterSize is the size in bytes of every counter. It is that of the values returned by counterValues, so
+	// that any value passed to PerfSetULongCounterValue fits in its counter without being truncated.
+	counterSize = uint32(unsafe.Sizeof(uint32(0)))
+
+	perfCounterSetSingleInstance = 0          // PERF_COUNTERSET_SINGLE_INSTANCE
+	perfCounterRawCount          = 0x00010000 // PERF_COUNTER_RAWCOUNT
+	perfDetailNovice             = 100        // PERF_DETAIL_NOVICE
+)
+
+// perfCounterSetTemplate mirrors a PERF_COUNTERSET_INFO followed by its PERF_COUNTER_INFO array.
+type perfCounterSetTemplate struct {
+	counterSetGUID windows.GUID
+	providerGUID   windows.GUID
+	numCounters    uint32
+	instanceType   uint32
+
+	counters [numCounters]perfCounterInfo
+}
+
+// perfCounterInfo mirrors PERF_COUNTER_INFO.
+type perfCounterInfo struct {
+	counterID   uint32
+	counterType uint32
+	attrib      uint64
+	size        uint32
+	detailLevel uint32
+	scale       int32
+	offset      uint32
+}
+
+// Counters publishes the compliance metrics as Windows performance counters.
+type Counters struct {
+	provider windows.Handle
+	instance uintptr
+}
+
+// NewCounters registers the counter set of the compliance metrics. Call Close to release resources.
+func NewCounters() (c *Counters, err error) {
+	defer decorate.OnError(&err, "could not publish performance counters")
+
+	c = &Counters{}
+
+	//nolint:gosec // No other way of calling a DLL proc.
+	if r, _, _ := perfStartProvider.Call(uintptr(unsafe.Pointer(&providerGUID)), 0, uintptr(unsafe.Pointer(&c.provider))); r != 0 {
+		return nil, fmt.Errorf("PerfStartProvider: %w", syscall.Errno(r))
+	}
+	defer func() {
+		if err != nil {
+			c.Close()
+		}
+	}()
+
+	template := perfCounterSetTemplate{
+		counterSetGUID: counterSetGUID,
+		providerGUID:   providerGUID,
+		numCounters:    numCounters,
+		instanceType:   perfCounterSetSingleInstance,
+	}
+	for i := range template.counters {
+		template.counters[i] = perfCounterInfo{
+			counterID:   uint32(i) + counterDistros,
+			counterType: perfCounterRawCount,
+			size:        counterSize,
+			detailLevel: perfDetailNovice,
+			offset:      uint32(i) * counterSize,
+		}
+	}
+
+	//nolint:gosec // No other way of calling a DLL proc.
+	if r, _, _ := perfSetCounterSetInfo.Call(uintptr(c.provider), uintptr(unsafe.Pointer(&template)), unsafe.Sizeof(template)); r != 0 {
+		return nil, fmt.Errorf("PerfSetCounterSetInfo: %w", syscall.Errno(r))
+	}
+
+	name, err := windows.UTF16PtrFromString("_Total")
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec // No other way of calling a DLL proc.
+	instance, _, e := perfCreateInstance.Call(uintptr(c.provider), uintptr(unsafe.Pointer(&counterSetGUID)), uintptr(unsafe.Pointer(name)), 0)
+	if instance == 0 {
+		return nil, fmt.Errorf("PerfCreateInstance: %w", e)
+	}
+	c.instance = instance
+
+	return c, nil
+}
+
+// Publish sets the counters to the metrics of the report.
+func (c *Counters) Publish(r Report) (err error) {
+	defer decorate.OnError(&err, "could not update performance counters")
+
+	for id, value := range counterValues(r) {
+		if r, _, _ := perfSetULongCounterVal.Call(uintptr(c.provider), c.instance, uintptr(id), uintptr(value)); r != 0 {
+			err = errors.Join(err, fmt.Errorf("counter %d: %w", id, syscall.Errno(r)))
+		}
+	}
+
+	return err
+}
+
+// Close stops publishing the counters.
+func (c *Counters) Close() {
+	if c.instance != 0 {
+		_, _, _ = perfDeleteInstance.Call(uintptr(c.provider), c.instance)
+		c.instance = 0
+	}
+	if c.provider != 0 {
+		_, _, _ = perfStopProvider.Call(uintptr(c.provider))
+		c.provider = 0
+	}
+}
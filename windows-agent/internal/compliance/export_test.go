@@ -0,0 +1,4 @@
+package compliance
+
+// CounterValues returns the value of every performance counter for the report, indexed by counter identifier.
+var CounterValues = counterValues
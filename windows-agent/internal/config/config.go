@@ -3,17 +3,31 @@
 package config
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha512"
 	"encoding/base64"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/debversion"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/maintenance"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/secrets"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/offline"
 	"github.com/ubuntu/decorate"
 	"gopkg.in/ini.v1"
 )
@@ -27,12 +41,26 @@ type Config struct {
 	// disk backing
 	storagePath string
 
+	// store is where the configuration is kept if the config is created WithStore, instead of the file at storagePath.
+	store *store.Store
+
+	// secrets is where the Ubuntu Pro tokens are kept if the config is created WithSecrets, instead of in plaintext.
+	secrets *secrets.Secrets
+
 	// Sync
 	mu *sync.Mutex
 
 	// observers are notified after any configuration changes.
-	notifyLandscape LandscapeNotifier
-	notifyUbuntuPro UbuntuProNotifier
+	notifyLandscape     LandscapeNotifier
+	notifyUbuntuPro     UbuntuProNotifier
+	notifyUpdateChannel UpdateChannelNotifier
+	notifyNotifications NotificationsNotifier
+	notifyPatching      PatchingNotifier
+	notifyProxy         ProxyNotifier
+	notifyMinVersion    MinimumServiceVersionNotifier
+	notifySnap          SnapNotifier
+	notifyDNS           DNSNotifier
+	notifyTransferCap   TransferCapNotifier
 }
 
 // UbuntuProNotifier is a function that is called when the Ubuntu Pro subscription changes.
@@ -41,23 +69,97 @@ type UbuntuProNotifier func(ctx context.Context, token string)
 // LandscapeNotifier is a function that is called when the Landscape configuration changes.
 type LandscapeNotifier func(ctx context.Context, config, uid string)
 
+// UpdateChannelNotifier is a function that is called when the wsl-pro-service update channel changes.
+type UpdateChannelNotifier func(ctx context.Context, channel UpdateChannel)
+
+// NotificationsNotifier is a function that is called when the notification frequency changes.
+type NotificationsNotifier func(ctx context.Context, frequency string)
+
+// PatchingNotifier is a function that is called when the patching level of any distro may have changed.
+// The level is the global one: use PatchingLevelFor to find that of each distro.
+type PatchingNotifier func(ctx context.Context, level PatchingLevel)
+
+// ProxyNotifier is a function that is called when the proxy settings change.
+type ProxyNotifier func(ctx context.Context, proxy ProxySettings)
+
+// MinimumServiceVersionNotifier is a function that is called when the minimum wsl-pro-service version changes.
+type MinimumServiceVersionNotifier func(ctx context.Context, version string)
+
+// SnapNotifier is a function that is called when the snap store proxy settings change.
+type SnapNotifier func(ctx context.Context, snap SnapSettings)
+
+// DNSNotifier is a function that is called when the DNS settings change.
+type DNSNotifier func(ctx context.Context, dns DNSSettings)
+
+// TransferCapNotifier is a function that is called when the daily transfer cap of the distros changes.
+type TransferCapNotifier func(ctx context.Context, bytes uint64)
+
 // configState contains the actual configuration data.
 //
 // Its methods must be public for proper YAML (un)marshalling.
 type configState struct {
-	Subscription subscription
-	Landscape    landscapeConf
+	Subscription   subscription
+	Landscape      landscapeConf
+	ServiceUpdates updateChannelConf
+	Notifications  notificationsConf
+	DistroGroups   distroGroupsConf
+	Patching       patchingConf
+	Attach         attachConf
+	Proxy          proxyConf
+	Snap           snapConf
+	DNS            dnsConf
+	Transfers      transferConf
+}
+
+type options struct {
+	store   *store.Store
+	secrets *secrets.Secrets
+}
+
+// Option is an optional argument for New.
+type Option func(*options)
+
+// WithStore keeps the configuration in the embedded store s instead of in a file in the cache path.
+// The configuration that older versions left in that file is migrated into the store on first load.
+func WithStore(s *store.Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
+}
+
+// WithSecrets keeps the Ubuntu Pro tokens of the user and of the Microsoft Store encrypted in sec, instead of in
+// plaintext along with the rest of the configuration. The tokens that older versions left in plaintext are moved
+// into sec on first load.
+func WithSecrets(sec *secrets.Secrets) Option {
+	return func(o *options) {
+		o.secrets = sec
+	}
 }
 
 // New creates and initializes a new Config object.
-func New(ctx context.Context, cachePath string) (m *Config) {
+func New(ctx context.Context, cachePath string, args ...Option) (m *Config) {
+	var opts options
+	for _, f := range args {
+		f(&opts)
+	}
+
 	m = &Config{
 		storagePath: filepath.Join(cachePath, "config"),
+		store:       opts.store,
+		secrets:     opts.secrets,
 		mu:          &sync.Mutex{},
 
 		// No-ops to avoid nil checks
-		notifyUbuntuPro: func(ctx context.Context, token string) {},
-		notifyLandscape: func(ctx context.Context, config, uid string) {},
+		notifyUbuntuPro:     func(ctx context.Context, token string) {},
+		notifyLandscape:     func(ctx context.Context, config, uid string) {},
+		notifyUpdateChannel: func(ctx context.Context, channel UpdateChannel) {},
+		notifyNotifications: func(ctx context.Context, frequency string) {},
+		notifyPatching:      func(ctx context.Context, level PatchingLevel) {},
+		notifyProxy:         func(ctx context.Context, proxy ProxySettings) {},
+		notifyMinVersion:    func(ctx context.Context, version string) {},
+		notifySnap:          func(ctx context.Context, snap SnapSettings) {},
+		notifyDNS:           func(ctx context.Context, dns DNSSettings) {},
+		notifyTransferCap:   func(ctx context.Context, bytes uint64) {},
 	}
 
 	return m
@@ -79,6 +181,70 @@ func (c *Config) SetUbuntuProNotifier(notify UbuntuProNotifier) {
 	c.notifyUbuntuPro = notify
 }
 
+// SetUpdateChannelNotifier sets the function to be called when the wsl-pro-service update channel changes.
+func (c *Config) SetUpdateChannelNotifier(notify UpdateChannelNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifyUpdateChannel = notify
+}
+
+// SetNotificationsNotifier sets the function to be called when the notification frequency changes.
+func (c *Config) SetNotificationsNotifier(notify NotificationsNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifyNotifications = notify
+}
+
+// SetPatchingNotifier sets the function to be called when the patching level of any distro may have changed.
+func (c *Config) SetPatchingNotifier(notify PatchingNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifyPatching = notify
+}
+
+// SetProxyNotifier sets the function to be called when the proxy settings change.
+func (c *Config) SetProxyNotifier(notify ProxyNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifyProxy = notify
+}
+
+// SetMinimumServiceVersionNotifier sets the function to be called when the minimum wsl-pro-service version changes.
+func (c *Config) SetMinimumServiceVersionNotifier(notify MinimumServiceVersionNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifyMinVersion = notify
+}
+
+// SetSnapNotifier sets the function to be called when the snap store proxy settings change.
+func (c *Config) SetSnapNotifier(notify SnapNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifySnap = notify
+}
+
+// SetDNSNotifier sets the function to be called when the DNS settings change.
+func (c *Config) SetDNSNotifier(notify DNSNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifyDNS = notify
+}
+
+// SetTransferCapNotifier sets the function to be called when the daily transfer cap of the distros changes.
+func (c *Config) SetTransferCapNotifier(notify TransferCapNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifyTransferCap = notify
+}
+
 // Subscription returns the ProToken and the method it was acquired with (if any).
 func (c *Config) Subscription() (token string, source Source, err error) {
 	s, err := c.get()
@@ -102,6 +268,250 @@ func (c *Config) LandscapeClientConfig() (string, Source, error) {
 	return conf, src, nil
 }
 
+// SubscriptionFor returns the Ubuntu Pro token for the named distro: the one of the distro group it
+// belongs to, if that group has any, or the global subscription otherwise.
+func (c *Config) SubscriptionFor(distroName string) (string, Source, error) {
+	s, err := c.get()
+	if err != nil {
+		return "", SourceNone, fmt.Errorf("config: could not get Ubuntu Pro subscription for distro %q: %v", distroName, err)
+	}
+
+	if g, ok := s.DistroGroups.groupOf(distroName); ok && g.UbuntuProToken != "" {
+		return g.UbuntuProToken, SourceDistroGroup, nil
+	}
+
+	token, source := s.Subscription.resolve()
+	return token, source, nil
+}
+
+// LandscapeClientConfigFor returns the complete Landscape client configuration for the named distro:
+// the one of the distro group it belongs to, if that group has any, or the global one otherwise.
+func (c *Config) LandscapeClientConfigFor(distroName string) (string, Source, error) {
+	s, err := c.get()
+	if err != nil {
+		return "", SourceNone, fmt.Errorf("config: could not get Landscape configuration for distro %q: %v", distroName, err)
+	}
+
+	if g, ok := s.DistroGroups.groupOf(distroName); ok && g.LandscapeConfig != "" {
+		conf, err := completeLandscapeConfig(g.LandscapeConfig, s.Landscape.UID)
+		if err != nil {
+			return "", SourceNone, fmt.Errorf("config: could not complete Landscape configuration of distro group %q: %v", g.Name, err)
+		}
+		return conf, SourceDistroGroup, nil
+	}
+
+	conf, src := s.Landscape.resolve()
+	return conf, src, nil
+}
+
+// DistroGroupsOverrideAll reports whether distro groups override the global Ubuntu Pro token for every
+// distro, and whether they override the global Landscape configuration for every distro. That is only the
+// case when a group matches any distro name and neither it nor any group before it falls back to the global
+// setting, as distros belong to the first group they match.
+func (c *Config) DistroGroupsOverrideAll() (subscription, landscape bool, err error) {
+	s, err := c.get()
+	if err != nil {
+		return false, false, fmt.Errorf("config: could not get distro groups: %v", err)
+	}
+
+	subscription, landscape = true, true
+	for _, g := range s.DistroGroups.OrgGroups {
+		subscription = subscription && g.UbuntuProToken != ""
+		landscape = landscape && g.LandscapeConfig != ""
+
+		if g.matchesAll() {
+			return subscription, landscape, nil
+		}
+	}
+
+	// Some distros belong to no group.
+	return false, false, nil
+}
+
+// UpdateChannel returns the channel from which wsl-pro-service is provisioned into the distros.
+// It can only be set via the registry.
+func (c *Config) UpdateChannel() (UpdateChannel, error) {
+	s, err := c.get()
+	if err != nil {
+		return UpdateChannel{}, fmt.Errorf("config: could not get update channel: %v", err)
+	}
+
+	return s.ServiceUpdates.OrgChannel, nil
+}
+
+// PendingServiceUpgrade returns the update channel if the upgrade from it has not been distributed yet,
+// and an empty channel otherwise.
+func (c *Config) PendingServiceUpgrade() (UpdateChannel, error) {
+	s, err := c.get()
+	if err != nil {
+		return UpdateChannel{}, fmt.Errorf("config: could not get pending service upgrade: %v", err)
+	}
+
+	if !s.ServiceUpdates.Pending {
+		return UpdateChannel{}, nil
+	}
+	return s.ServiceUpdates.OrgChannel, nil
+}
+
+// SetServiceUpgradeDistributed records that the upgrade from the channel was distributed. It does nothing
+// if the channel was replaced in the meantime, as the upgrade from the new one is still pending.
+func (c *Config) SetServiceUpgradeDistributed(channel UpdateChannel) (err error) {
+	defer decorate.OnError(&err, "config: could not record the distribution of the service upgrade")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.load(); err != nil {
+		return err
+	}
+
+	checksum := c.ServiceUpdates.Checksum
+	if !c.ServiceUpdates.Pending || hasChanged(channel.String(), &checksum) {
+		return nil
+	}
+
+	c.ServiceUpdates.Pending = false
+	return c.dump()
+}
+
+// MinimumServiceVersion returns the oldest version of wsl-pro-service that the agent manages. An empty
+// string means that any version is accepted. It can only be set via the registry.
+func (c *Config) MinimumServiceVersion() (string, error) {
+	s, err := c.get()
+	if err != nil {
+		return "", fmt.Errorf("config: could not get minimum service version: %v", err)
+	}
+
+	return s.ServiceUpdates.OrgMinimumVersion, nil
+}
+
+// MaintenanceWindows returns the maintenance windows during which wsl-pro-service may be upgraded
+// automatically. No windows means that it may be upgraded at any time outside of the user's active
+// hours. They can only be set via the registry.
+func (c *Config) MaintenanceWindows() ([]maintenance.Window, error) {
+	s, err := c.get()
+	if err != nil {
+		return nil, fmt.Errorf("config: could not get maintenance windows: %v", err)
+	}
+
+	return s.ServiceUpdates.OrgMaintenanceWindows, nil
+}
+
+// DailyTransferCap returns how many bytes the agent may transfer to and from each distro per day. Zero means
+// no cap. It can only be set via the registry.
+func (c *Config) DailyTransferCap() (uint64, error) {
+	s, err := c.get()
+	if err != nil {
+		return 0, fmt.Errorf("config: could not get daily transfer cap: %v", err)
+	}
+
+	return s.Transfers.OrgDailyCap, nil
+}
+
+// PatchingLevelFor returns the patching level for the named distro: the one of the distro group it belongs
+// to, if that group has any, or the global one otherwise. An empty level means that patching is not managed.
+// It can only be set via the registry.
+func (c *Config) PatchingLevelFor(distroName string) (PatchingLevel, Source, error) {
+	s, err := c.get()
+	if err != nil {
+		return "", SourceNone, fmt.Errorf("config: could not get patching level for distro %q: %v", distroName, err)
+	}
+
+	if g, ok := s.DistroGroups.groupOf(distroName); ok && g.PatchingLevel != "" {
+		return g.PatchingLevel, SourceDistroGroup, nil
+	}
+
+	if s.Patching.OrgLevel != "" {
+		return s.Patching.OrgLevel, SourceRegistry, nil
+	}
+
+	return "", SourceNone, nil
+}
+
+// AttachPolicy returns when the distros are attached to Ubuntu Pro. It defaults to AttachImmediately, and
+// can only be set via the registry.
+func (c *Config) AttachPolicy() (AttachPolicy, error) {
+	s, err := c.get()
+	if err != nil {
+		return "", fmt.Errorf("config: could not get attach policy: %v", err)
+	}
+
+	if s.Attach.OrgPolicy == "" {
+		return AttachImmediately, nil
+	}
+	return s.Attach.OrgPolicy, nil
+}
+
+// ProxySettings returns the proxy settings of the distros. Empty settings mean that the proxy is not managed.
+// They can only be set via the registry.
+func (c *Config) ProxySettings() (ProxySettings, Source, error) {
+	s, err := c.get()
+	if err != nil {
+		return ProxySettings{}, SourceNone, fmt.Errorf("config: could not get proxy settings: %v", err)
+	}
+
+	if s.Proxy.OrgSettings == (ProxySettings{}) {
+		return ProxySettings{}, SourceNone, nil
+	}
+
+	return s.Proxy.OrgSettings, SourceRegistry, nil
+}
+
+// SnapSettings returns the enterprise snap store proxy of the distros. Empty settings mean that the distros
+// use the global snap store. They can only be set via the registry.
+func (c *Config) SnapSettings() (SnapSettings, Source, error) {
+	s, err := c.get()
+	if err != nil {
+		return SnapSettings{}, SourceNone, fmt.Errorf("config: could not get snap store proxy settings: %v", err)
+	}
+
+	if s.Snap.OrgSettings == (SnapSettings{}) {
+		return SnapSettings{}, SourceNone, nil
+	}
+
+	return s.Snap.OrgSettings, SourceRegistry, nil
+}
+
+// DNSSettings returns the nameservers, search domains and host entries of the distros. Empty settings mean that
+// WSL keeps generating resolv.conf and /etc/hosts on its own. They can only be set via the registry.
+func (c *Config) DNSSettings() (DNSSettings, Source, error) {
+	s, err := c.get()
+	if err != nil {
+		return DNSSettings{}, SourceNone, fmt.Errorf("config: could not get DNS settings: %v", err)
+	}
+
+	if s.DNS.OrgSettings.empty() {
+		return DNSSettings{}, SourceNone, nil
+	}
+
+	return s.DNS.OrgSettings, SourceRegistry, nil
+}
+
+// NotificationFrequency returns how often the user wants to be shown the summary of low priority notifications.
+// An empty string means that the user did not choose any.
+func (c *Config) NotificationFrequency() (string, error) {
+	s, err := c.get()
+	if err != nil {
+		return "", fmt.Errorf("config: could not get notification frequency: %v", err)
+	}
+
+	return s.Notifications.Frequency, nil
+}
+
+// SetNotificationFrequency overwrites how often the user wants to be shown the summary of low priority notifications.
+func (c *Config) SetNotificationFrequency(ctx context.Context, frequency string) error {
+	isNew, err := c.set(&c.Notifications.Frequency, frequency)
+	if err != nil {
+		return fmt.Errorf("config: could not set notification frequency: %v", err)
+	}
+
+	if isNew {
+		c.notifyNotifications(ctx, frequency)
+	}
+
+	return nil
+}
+
 // SetUserSubscription overwrites the value of the user-provided Ubuntu Pro token.
 func (c *Config) SetUserSubscription(ctx context.Context, proToken string) (err error) {
 	defer decorate.OnError(&err, "config: could not set user-provided Ubuntu Pro subscription")
@@ -297,6 +707,70 @@ func (c *Config) LandscapeAgentUID() (string, error) {
 // RegistryData contains the data that the Ubuntu Pro registry key can provide.
 type RegistryData struct {
 	UbuntuProToken, LandscapeConfig string
+	UpdateChannel                   UpdateChannel
+
+	// MinimumServiceVersion is the oldest version of wsl-pro-service that the agent manages.
+	MinimumServiceVersion string
+
+	// MaintenanceWindows is the list of maintenance windows during which wsl-pro-service may be upgraded.
+	MaintenanceWindows string
+
+	// UbuntuProTokenFile is the path to an offline token file, used when UbuntuProToken is empty.
+	UbuntuProTokenFile string
+
+	// LandscapeConfigFile is the path to a Landscape client configuration file, used when LandscapeConfig is empty.
+	LandscapeConfigFile string
+
+	// PatchingLevel is which pockets unattended-upgrades installs updates from: security-only, security+updates or all.
+	PatchingLevel string
+
+	// AttachPolicy is when the distros are attached to Ubuntu Pro: immediately or on first use.
+	AttachPolicy string
+
+	// HTTPProxy is the proxy for HTTP requests of the distros, e.g. http://proxy.example.com:3128.
+	HTTPProxy string
+
+	// HTTPSProxy is the proxy for HTTPS requests of the distros.
+	HTTPSProxy string
+
+	// NoProxy is a comma-separated list of hosts and domains the distros reach without proxy.
+	NoProxy string
+
+	// SnapStoreProxy is the URL of the enterprise snap store proxy of the distros, e.g. http://snaps.example.com.
+	SnapStoreProxy string
+
+	// SnapStoreID is the ID of the store of the enterprise snap store proxy.
+	SnapStoreID string
+
+	// Nameservers is a comma-separated list of the IP addresses of the nameservers of the distros, which replace
+	// the ones WSL generates in resolv.conf.
+	Nameservers string
+
+	// SearchDomains is a comma-separated list of the domains the distros append to unqualified host names.
+	SearchDomains string
+
+	// HostEntries are the lines added to /etc/hosts in the distros, each an IP address followed by host names.
+	HostEntries string
+
+	// DistroGroups is a YAML list of distro groups, each with its own Ubuntu Pro token, Landscape configuration
+	// and patching level.
+	DistroGroups string
+
+	// DailyTransferCap is how much data the agent may transfer to and from each distro per day, e.g. 500MB.
+	DailyTransferCap string
+}
+
+// WithDefaults returns the data with the settings it leaves empty taken from defaults, such as those of the
+// configuration file of the agent. The update channel is taken as a whole, as its fields only make sense together.
+func (data RegistryData) WithDefaults(defaults RegistryData) RegistryData {
+	v := reflect.ValueOf(&data).Elem()
+	d := reflect.ValueOf(defaults)
+	for i := range v.NumField() {
+		if v.Field(i).IsZero() {
+			v.Field(i).Set(d.Field(i))
+		}
+	}
+	return data
 }
 
 // UpdateRegistryData takes in data from the registry and applies it as necessary.
@@ -319,19 +793,27 @@ func (c *Config) UpdateRegistryData(ctx context.Context, data RegistryData, db *
 	}
 
 	// Ubuntu Pro subscription
-	c.configState.Subscription.Organization = data.UbuntuProToken
-	if hasChanged(data.UbuntuProToken, &c.configState.Subscription.Checksum) {
-		log.Debug(ctx, "Config: new Ubuntu Pro subscription received from the registry")
+	tokenFile := data.UbuntuProTokenFile
+	if data.UbuntuProToken != "" && tokenFile != "" {
+		log.Warning(ctx, "Config: ignoring offline Ubuntu Pro token file from registry: a token is already provided")
+		tokenFile = ""
+	}
 
-		// We must resolve the subscription in case a lower priority token becomes active
-		resolv, _ := c.configState.Subscription.resolve()
-		afterUnlock = append(afterUnlock, func() {
-			c.notifyUbuntuPro(ctx, resolv)
-		})
+	if notify, _, _ := c.setOrganizationToken(ctx, data.UbuntuProToken, tokenFile); notify != nil {
+		afterUnlock = append(afterUnlock, notify)
 	}
 
 	// Landscape configuration
-	conf, err := completeLandscapeConfig(data.LandscapeConfig, c.Landscape.UID)
+	landscapeConf := data.LandscapeConfig
+	if landscapeConf == "" && data.LandscapeConfigFile != "" {
+		if landscapeConf, err = readLandscapeConfigFile(data.LandscapeConfigFile); err != nil {
+			log.Errorf(ctx, "Config: %v", err)
+		}
+	} else if data.LandscapeConfigFile != "" {
+		log.Warning(ctx, "Config: ignoring Landscape configuration file from registry: a configuration is already provided")
+	}
+
+	conf, err := completeLandscapeConfig(landscapeConf, c.Landscape.UID)
 	if err != nil {
 		log.Errorf(ctx, "Config: removing Landscape configuration from registry: %v", err)
 	}
@@ -346,6 +828,164 @@ func (c *Config) UpdateRegistryData(ctx context.Context, data RegistryData, db *
 		})
 	}
 
+	// Patching level
+	patching := PatchingLevel(strings.ToLower(strings.TrimSpace(data.PatchingLevel)))
+	if err := patching.validate(); err != nil {
+		log.Errorf(ctx, "Config: ignoring patching level from registry: %v", err)
+		patching = ""
+	}
+	c.Patching.OrgLevel = patching
+	if hasChanged(string(patching), &c.Patching.Checksum) {
+		log.Debug(ctx, "Config: new patching level received from the registry")
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyPatching(ctx, patching)
+		})
+	}
+
+	// Attach policy
+	attach := AttachPolicy(strings.ToLower(strings.TrimSpace(data.AttachPolicy)))
+	if err := attach.validate(); err != nil {
+		log.Errorf(ctx, "Config: ignoring attach policy from registry: %v", err)
+		attach = ""
+	}
+	c.Attach.OrgPolicy = attach
+	if hasChanged(string(attach), &c.Attach.Checksum) {
+		log.Debug(ctx, "Config: new attach policy received from the registry")
+
+		// The distros held back by the previous policy may have to be attached now
+		token, _ := c.configState.Subscription.resolve()
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyUbuntuPro(ctx, token)
+		})
+	}
+
+	// Proxy settings
+	proxy := ProxySettings{
+		HTTP:    strings.TrimSpace(data.HTTPProxy),
+		HTTPS:   strings.TrimSpace(data.HTTPSProxy),
+		NoProxy: strings.TrimSpace(data.NoProxy),
+	}
+	if err := proxy.validate(); err != nil {
+		log.Errorf(ctx, "Config: ignoring proxy settings from registry: %v", err)
+		proxy = ProxySettings{}
+	}
+	c.Proxy.OrgSettings = proxy
+	if hasChanged(proxy.checksumInput(), &c.Proxy.Checksum) {
+		log.Debug(ctx, "Config: new proxy settings received from the registry")
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyProxy(ctx, proxy)
+		})
+	}
+
+	// Snap store proxy settings
+	snap := SnapSettings{
+		StoreProxy: strings.TrimSpace(data.SnapStoreProxy),
+		StoreID:    strings.TrimSpace(data.SnapStoreID),
+	}
+	if err := snap.validate(); err != nil {
+		log.Errorf(ctx, "Config: ignoring snap store proxy settings from registry: %v", err)
+		snap = SnapSettings{}
+	}
+	c.Snap.OrgSettings = snap
+	if hasChanged(snap.checksumInput(), &c.Snap.Checksum) {
+		log.Debug(ctx, "Config: new snap store proxy settings received from the registry")
+		afterUnlock = append(afterUnlock, func() {
+			c.notifySnap(ctx, snap)
+		})
+	}
+
+	// DNS settings
+	dns := DNSSettings{
+		Nameservers:   splitList(data.Nameservers),
+		SearchDomains: splitList(data.SearchDomains),
+		Hosts:         splitHostEntries(data.HostEntries),
+	}
+	if err := dns.validate(); err != nil {
+		log.Errorf(ctx, "Config: ignoring DNS settings from registry: %v", err)
+		dns = DNSSettings{}
+	}
+	c.DNS.OrgSettings = dns
+	if hasChanged(dns.checksumInput(), &c.DNS.Checksum) {
+		log.Debug(ctx, "Config: new DNS settings received from the registry")
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyDNS(ctx, dns)
+		})
+	}
+
+	// Distro groups
+	rawGroups := data.DistroGroups
+	groups, err := parseDistroGroups(rawGroups)
+	if err != nil {
+		log.Errorf(ctx, "Config: ignoring distro groups from registry: %v", err)
+		rawGroups, groups = "", nil
+	}
+	c.DistroGroups.OrgGroups = groups
+	if hasChanged(rawGroups, &c.DistroGroups.Checksum) {
+		log.Debug(ctx, "Config: new distro groups received from the registry")
+
+		// Every distro must be sent its settings again, as they may have moved to another group
+		token, _ := c.configState.Subscription.resolve()
+		landscapeConf, _ := c.Landscape.resolve()
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyUbuntuPro(ctx, token)
+			c.notifyLandscape(ctx, landscapeConf, c.Landscape.UID)
+			c.notifyPatching(ctx, patching)
+		})
+	}
+
+	// wsl-pro-service update channel
+	channel := data.UpdateChannel
+	if err := channel.validate(); err != nil {
+		log.Errorf(ctx, "Config: ignoring update channel from registry: %v", err)
+		channel = UpdateChannel{}
+	}
+	c.ServiceUpdates.OrgChannel = channel
+	if hasChanged(channel.String(), &c.ServiceUpdates.Checksum) {
+		log.Debug(ctx, "Config: new update channel received from the registry")
+		c.ServiceUpdates.Pending = channel.Channel != ""
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyUpdateChannel(ctx, channel)
+		})
+	}
+
+	// Minimum wsl-pro-service version
+	minVersion := data.MinimumServiceVersion
+	if minVersion != "" {
+		if err := debversion.Validate(minVersion); err != nil {
+			log.Errorf(ctx, "Config: ignoring minimum service version from registry: %v", err)
+			minVersion = ""
+		}
+	}
+	if minVersion != c.ServiceUpdates.OrgMinimumVersion {
+		log.Debug(ctx, "Config: new minimum service version received from the registry")
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyMinVersion(ctx, minVersion)
+		})
+	}
+	c.ServiceUpdates.OrgMinimumVersion = minVersion
+
+	// Maintenance windows
+	windows, err := maintenance.ParseWindows(data.MaintenanceWindows)
+	if err != nil {
+		log.Errorf(ctx, "Config: ignoring maintenance windows from registry: %v", err)
+		windows = nil
+	}
+	c.ServiceUpdates.OrgMaintenanceWindows = windows
+
+	// Daily transfer cap
+	transferCap, err := bandwidth.ParseSize(data.DailyTransferCap)
+	if err != nil {
+		log.Errorf(ctx, "Config: ignoring daily transfer cap from registry: %v", err)
+		transferCap = 0
+	}
+	if transferCap != c.Transfers.OrgDailyCap {
+		log.Debug(ctx, "Config: new daily transfer cap received from the registry")
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyTransferCap(ctx, transferCap)
+		})
+	}
+	c.Transfers.OrgDailyCap = transferCap
+
 	if err := c.dump(); err != nil {
 		return err
 	}
@@ -353,6 +993,94 @@ func (c *Config) UpdateRegistryData(ctx context.Context, data RegistryData, db *
 	return nil
 }
 
+// RefreshOfflineToken reads the offline Ubuntu Pro token file provided by the registry again, in case it was
+// renewed or has expired, and returns its contents. It returns a zero Token if no such file is configured.
+// The token is only withdrawn if the file is no longer valid or has expired: if it cannot be read, the
+// error is returned and the last token read is kept.
+func (c *Config) RefreshOfflineToken(ctx context.Context) (tok offline.Token, err error) {
+	defer decorate.OnError(&err, "config: could not refresh offline Ubuntu Pro token")
+
+	var notify func()
+	defer func() {
+		// We must perform the notification outside the lock to avoid deadlocks
+		if notify != nil {
+			notify()
+		}
+	}()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.load(); err != nil {
+		return tok, err
+	}
+
+	path := c.configState.Subscription.OrgTokenFile
+	if path == "" {
+		return tok, nil
+	}
+
+	notify, tok, err = c.setOrganizationToken(ctx, "", path)
+
+	if err := c.dump(); err != nil {
+		return tok, err
+	}
+
+	return tok, err
+}
+
+// OfflineSubscription returns true if the organization-provided subscription comes from an offline token
+// file, in which case the contract server must not be contacted.
+func (c *Config) OfflineSubscription() (bool, error) {
+	s, err := c.get()
+	if err != nil {
+		return false, fmt.Errorf("config: could not get Ubuntu Pro subscription: %v", err)
+	}
+
+	return s.Subscription.OrgTokenFile != "", nil
+}
+
+// setOrganizationToken sets the organization-provided token, reading it from the offline token file if
+// registryToken is empty. It returns the function to notify the change, if any, which must be called
+// after releasing the lock, and the contents of the offline token file, if it was read.
+//
+// Invalid or expired token files withdraw the token. Errors reading the file may be transient, so the
+// token read from the same file last time is kept in that case.
+// The caller must hold the lock, and is responsible for dumping the config.
+func (c *Config) setOrganizationToken(ctx context.Context, registryToken, tokenFile string) (notify func(), tok offline.Token, err error) {
+	token := registryToken
+	if token == "" && tokenFile != "" {
+		tok, err = offline.Read(tokenFile)
+		switch {
+		case errors.Is(err, offline.ErrInvalid):
+			log.Errorf(ctx, "Config: %v", err)
+		case err != nil && tokenFile == c.configState.Subscription.OrgTokenFile:
+			log.Warningf(ctx, "Config: keeping the last offline Ubuntu Pro token read: %v", err)
+			token = c.configState.Subscription.Organization
+		case err != nil:
+			log.Errorf(ctx, "Config: %v", err)
+		case tok.Expired(time.Now()):
+			log.Errorf(ctx, "Config: offline Ubuntu Pro token in %q expired on %s", tokenFile, tok.Expires.Format(time.DateOnly))
+		default:
+			token = tok.Token
+		}
+	}
+
+	c.configState.Subscription.OrgTokenFile = tokenFile
+	c.configState.Subscription.Organization = token
+	if !hasChanged(token, &c.configState.Subscription.Checksum) {
+		return nil, tok, err
+	}
+
+	log.Debug(ctx, "Config: new Ubuntu Pro subscription received from the registry")
+
+	// We must resolve the subscription in case a lower priority token becomes active
+	resolv, _ := c.configState.Subscription.resolve()
+	return func() {
+		c.notifyUbuntuPro(ctx, resolv)
+	}, tok, err
+}
+
 // hasChanged detects if the current value is different from the last time it was used.
 // If the value has changed, the checksum will be updated.
 func hasChanged(newValue string, checksum *string) bool {
@@ -373,6 +1101,7 @@ func hasChanged(newValue string, checksum *string) bool {
 // completeLandscapeConfig completes the Landscape configuration by adding the hostagent_uid field to the client section,
 // making it ready for consumption by the Landscape client inside the distro instances.
 func completeLandscapeConfig(landscapeConf, hostAgentUID string) (string, error) {
+	landscapeConf = normalizeLandscapeConfig(landscapeConf)
 	if landscapeConf == "" {
 		return "", nil
 	}
@@ -406,6 +1135,64 @@ func completeLandscapeConfig(landscapeConf, hostAgentUID string) (string, error)
 	return b.String(), nil
 }
 
+// normalizeLandscapeConfig undoes the alterations that multi-line values suffer on their way to the agent:
+// Windows line endings, byte order marks, and the NUL characters and trailing whitespace left behind by the
+// tools that write REG_MULTI_SZ values. A configuration made only of whitespace is considered empty.
+func normalizeLandscapeConfig(landscapeConf string) string {
+	landscapeConf = strings.TrimPrefix(landscapeConf, "\uFEFF")
+	landscapeConf = strings.ReplaceAll(landscapeConf, "\x00", "")
+	landscapeConf = strings.ReplaceAll(landscapeConf, "\r\n", "\n")
+	landscapeConf = strings.ReplaceAll(landscapeConf, "\r", "\n")
+
+	lines := strings.Split(landscapeConf, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRightFunc(lines[i], unicode.IsSpace)
+	}
+
+	landscapeConf = strings.Trim(strings.Join(lines, "\n"), "\n")
+	if landscapeConf == "" {
+		return ""
+	}
+
+	return landscapeConf + "\n"
+}
+
+// readLandscapeConfigFile reads a Landscape client configuration file. Files encoded in UTF-16, as written by
+// some Windows editors, are converted to UTF-8.
+func readLandscapeConfigFile(path string) (landscapeConf string, err error) {
+	defer decorate.OnError(&err, "could not read Landscape configuration file %q", path)
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(out, []byte{0xFF, 0xFE}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(out, []byte{0xFE, 0xFF}):
+		order = binary.BigEndian
+	default:
+		if !utf8.Valid(out) {
+			return "", errors.New("file is not encoded in UTF-8 nor UTF-16")
+		}
+		return string(out), nil
+	}
+
+	out = out[2:]
+	if len(out)%2 != 0 {
+		return "", errors.New("truncated UTF-16 file")
+	}
+
+	u16 := make([]uint16, len(out)/2)
+	for i := range u16 {
+		u16[i] = order.Uint16(out[2*i:])
+	}
+
+	return string(utf16.Decode(u16)), nil
+}
+
 // addKeyValuePair adds a key-value pair to an ini section. If the key already exists and override is true, the value will be updated.
 func addKeyValuePair(section *ini.Section, key, value string, override bool) error {
 	k, err := section.GetKey(key)
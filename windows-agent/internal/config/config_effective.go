@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+)
+
+// Origin is the layer of the configuration a setting is taken from.
+type Origin string
+
+// config Origin types, from the lowest precedence to the highest.
+const (
+	// OriginDefault -> the setting is set nowhere, so its built-in default applies.
+	OriginDefault Origin = "default"
+
+	// OriginFile -> the setting is set in the configuration file of the agent.
+	OriginFile Origin = "file"
+
+	// OriginGUI -> the setting was introduced by the user.
+	OriginGUI Origin = "GUI"
+
+	// OriginMicrosoftStore -> the setting was acquired via the Microsoft Store.
+	OriginMicrosoftStore Origin = "Microsoft Store"
+
+	// OriginRegistry -> the setting is set in the Ubuntu Pro registry key of the current user.
+	OriginRegistry Origin = "HKCU"
+)
+
+// Setting is a setting of the effective configuration, along with where it is taken from.
+type Setting struct {
+	// Key is the name of the setting, which is the name of its field in RegistryData for those that the
+	// organization can set.
+	Key string
+
+	// Value is the value of the setting, with the tokens and credentials hidden. It is empty if the setting is unset.
+	Value string
+
+	Origin Origin
+
+	// Locked is true if the organization enforces the setting, so that the user cannot change it.
+	Locked bool
+}
+
+// Effective returns every setting of the configuration as the agent applies it, sorted by key. The settings the
+// organization can set are taken from registry or, if it leaves them empty, from file, the defaults that the
+// configuration file of the agent sets for them, as the registry watcher does. The others are the ones the user
+// set from the GUI. Unlike the running agent, it does not read the files the settings may point to.
+func (c *Config) Effective(registry, file RegistryData) ([]Setting, error) {
+	s, err := c.get()
+	if err != nil {
+		return nil, fmt.Errorf("config: could not get effective configuration: %v", err)
+	}
+
+	var settings []Setting
+	reg := reflect.ValueOf(registry)
+	def := reflect.ValueOf(file)
+	for i := range reg.NumField() {
+		setting := Setting{Key: reg.Type().Field(i).Name, Origin: OriginDefault}
+
+		switch {
+		case !reg.Field(i).IsZero():
+			setting.Value, setting.Origin, setting.Locked = displayValue(setting.Key, reg.Field(i).Interface()), OriginRegistry, true
+		case !def.Field(i).IsZero():
+			setting.Value, setting.Origin, setting.Locked = displayValue(setting.Key, def.Field(i).Interface()), OriginFile, true
+		}
+
+		if !setting.Locked {
+			setting.Value, setting.Origin = s.userSetting(setting.Key)
+		}
+
+		settings = append(settings, setting)
+	}
+
+	frequency, origin := s.userSetting("NotificationFrequency")
+	settings = append(settings, Setting{Key: "NotificationFrequency", Value: frequency, Origin: origin})
+
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings, nil
+}
+
+// userSetting returns the value the user set for the setting with the given key, or its built-in default.
+func (s configState) userSetting(key string) (string, Origin) {
+	switch key {
+	case "UbuntuProToken":
+		if s.Subscription.Store != "" {
+			return displayValue(key, s.Subscription.Store), OriginMicrosoftStore
+		}
+		if s.Subscription.User != "" {
+			return displayValue(key, s.Subscription.User), OriginGUI
+		}
+	case "LandscapeConfig":
+		if s.Landscape.UserConfig != "" {
+			return displayValue(key, s.Landscape.UserConfig), OriginGUI
+		}
+	case "NotificationFrequency":
+		if s.Notifications.Frequency != "" {
+			return s.Notifications.Frequency, OriginGUI
+		}
+	case "AttachPolicy":
+		return string(AttachImmediately), OriginDefault
+	}
+
+	return "", OriginDefault
+}
+
+// displayValue returns the value of the setting with the given key as it is displayed, with its tokens and
+// credentials hidden.
+func displayValue(key string, value any) string {
+	switch v := value.(type) {
+	case UpdateChannel:
+		var fields []string
+		for _, f := range []string{v.Channel, v.Source, v.Checksum} {
+			if f != "" {
+				fields = append(fields, f)
+			}
+		}
+		return strings.Join(fields, " ")
+	case string:
+		switch key {
+		case "UbuntuProToken":
+			return common.Obfuscate(v)
+		case "HTTPProxy", "HTTPSProxy", "SnapStoreProxy":
+			return common.RedactURL(v)
+		case "LandscapeConfig", "DistroGroups":
+			// They embed registration keys and tokens.
+			return "<redacted>"
+		}
+		return v
+	}
+
+	return fmt.Sprint(value)
+}
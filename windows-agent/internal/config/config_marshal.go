@@ -6,6 +6,8 @@ import (
 	"io/fs"
 	"os"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/secrets"
 	"github.com/ubuntu/decorate"
 	"gopkg.in/yaml.v3"
 )
@@ -15,11 +17,9 @@ func (c *Config) load() (err error) {
 
 	var s configState
 
-	out, err := os.ReadFile(c.storagePath)
-	if errors.Is(err, fs.ErrNotExist) {
-		out = []byte{}
-	} else if err != nil {
-		return fmt.Errorf("could not read config file: %v", err)
+	out, err := c.read()
+	if err != nil {
+		return err
 	}
 
 	if err := yaml.Unmarshal(out, &s); err != nil {
@@ -28,27 +28,140 @@ func (c *Config) load() (err error) {
 
 	// Registry data must not be overridden
 	tokenOrg := c.configState.Subscription.Organization
+	tokenFileOrg := c.configState.Subscription.OrgTokenFile
 	landscapeOrg := c.configState.Landscape.OrgConfig
+	channelOrg := c.configState.ServiceUpdates.OrgChannel
+	minVersionOrg := c.configState.ServiceUpdates.OrgMinimumVersion
+	windowsOrg := c.configState.ServiceUpdates.OrgMaintenanceWindows
+	groupsOrg := c.configState.DistroGroups.OrgGroups
+	patchingOrg := c.configState.Patching.OrgLevel
+	attachOrg := c.configState.Attach.OrgPolicy
+	proxyOrg := c.configState.Proxy.OrgSettings
+	snapOrg := c.configState.Snap.OrgSettings
+	dnsOrg := c.configState.DNS.OrgSettings
+	transferCapOrg := c.configState.Transfers.OrgDailyCap
 
 	c.configState = s
 
 	c.configState.Subscription.Organization = tokenOrg
+	c.configState.Subscription.OrgTokenFile = tokenFileOrg
 	c.configState.Landscape.OrgConfig = landscapeOrg
+	c.configState.ServiceUpdates.OrgChannel = channelOrg
+	c.configState.ServiceUpdates.OrgMinimumVersion = minVersionOrg
+	c.configState.ServiceUpdates.OrgMaintenanceWindows = windowsOrg
+	c.configState.DistroGroups.OrgGroups = groupsOrg
+	c.configState.Patching.OrgLevel = patchingOrg
+	c.configState.Attach.OrgPolicy = attachOrg
+	c.configState.Proxy.OrgSettings = proxyOrg
+	c.configState.Snap.OrgSettings = snapOrg
+	c.configState.DNS.OrgSettings = dnsOrg
+	c.configState.Transfers.OrgDailyCap = transferCapOrg
+
+	if c.secrets != nil {
+		return c.loadSecrets()
+	}
+
+	return nil
+}
+
+// loadSecrets reads the Ubuntu Pro tokens out of the secrets. Those that older versions left in plaintext in the
+// configuration are moved into the secrets instead.
+func (c *Config) loadSecrets() error {
+	var migrate bool
+	for name, token := range c.secretTokens() {
+		if *token != "" {
+			migrate = true
+			continue
+		}
+
+		v, err := c.secrets.Get(name)
+		if err != nil {
+			return err
+		}
+		*token = v
+	}
 
+	if migrate {
+		return c.dump()
+	}
 	return nil
 }
 
+// secretTokens returns the fields of the Ubuntu Pro tokens kept in the secrets, by name of the secret.
+func (c *Config) secretTokens() map[string]*string {
+	return map[string]*string{
+		secrets.UserProToken:  &c.configState.Subscription.User,
+		secrets.StoreProToken: &c.configState.Subscription.Store,
+	}
+}
+
 func (c *Config) dump() (err error) {
 	defer decorate.OnError(&err, "could not store config to disk")
 
-	out, err := yaml.Marshal(c.configState)
+	state := c.configState
+	if c.secrets != nil {
+		for name, token := range c.secretTokens() {
+			if err := c.secrets.Set(name, *token); err != nil {
+				return err
+			}
+		}
+		state.Subscription.User = ""
+		state.Subscription.Store = ""
+	}
+
+	out, err := yaml.Marshal(state)
 	if err != nil {
 		return fmt.Errorf("could not marshal config: %v", err)
 	}
 
+	if c.store != nil {
+		err := c.store.Update(func(tx *store.Tx) error {
+			return tx.Put(store.StateBucket, storeKey, out)
+		})
+		if err != nil {
+			return fmt.Errorf("could not write config to the store: %v", err)
+		}
+
+		// The file of older versions is stale now.
+		if err := os.Remove(c.storagePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("could not remove the config file migrated into the store: %v", err)
+		}
+		return nil
+	}
+
 	if err := os.WriteFile(c.storagePath, out, 0600); err != nil {
 		return fmt.Errorf("could not write config file: %v", err)
 	}
 
 	return nil
 }
+
+// storeKey is the key of the configuration in the state bucket of the store.
+const storeKey = "config"
+
+// read returns the serialized configuration. In the store, the file of older versions is used
+// until the configuration is first dumped, which removes it.
+func (c *Config) read() ([]byte, error) {
+	if c.store != nil {
+		var out []byte
+		err := c.store.View(func(tx *store.Tx) (err error) {
+			out, err = tx.Get(store.StateBucket, storeKey)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not read config from the store: %v", err)
+		}
+		if out != nil {
+			return out, nil
+		}
+	}
+
+	out, err := os.ReadFile(c.storagePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []byte{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read config file: %v", err)
+	}
+
+	return out, nil
+}
@@ -1,5 +1,18 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/maintenance"
+)
+
 // Source indicates the method a configuration parameter was acquired.
 type Source int
 
@@ -16,6 +29,9 @@ const (
 
 	// SourceRegistry -> the data was obtained from the registry.
 	SourceRegistry
+
+	// SourceDistroGroup -> the data was obtained from the registry, for the distro group a distro belongs to.
+	SourceDistroGroup
 )
 
 type subscription struct {
@@ -23,6 +39,9 @@ type subscription struct {
 	Store        string
 	Organization string `yaml:"-"`
 	Checksum     string
+
+	// OrgTokenFile is the path to the offline token file the Organization token is read from, if any.
+	OrgTokenFile string `yaml:"-"`
 }
 
 func (s subscription) resolve() (string, Source) {
@@ -60,3 +79,359 @@ func (p landscapeConf) resolve() (string, Source) {
 
 	return "", SourceNone
 }
+
+// PatchingLevel is which package pockets unattended-upgrades installs updates from in the distros.
+type PatchingLevel string
+
+const (
+	// PatchingSecurityOnly installs security updates only, including those from Ubuntu Pro (ESM).
+	PatchingSecurityOnly PatchingLevel = "security-only"
+
+	// PatchingSecurityAndUpdates installs security updates and stable release updates, including those from Ubuntu Pro (ESM).
+	PatchingSecurityAndUpdates PatchingLevel = "security+updates"
+
+	// PatchingAll installs updates from every pocket, backports included.
+	PatchingAll PatchingLevel = "all"
+)
+
+// validate checks that the patching level is known. Empty is valid: it means that patching is not managed.
+func (p PatchingLevel) validate() error {
+	switch p {
+	case "", PatchingSecurityOnly, PatchingSecurityAndUpdates, PatchingAll:
+		return nil
+	}
+	return fmt.Errorf("unknown patching level %q, expected %q, %q or %q", string(p), PatchingSecurityOnly, PatchingSecurityAndUpdates, PatchingAll)
+}
+
+type patchingConf struct {
+	OrgLevel PatchingLevel `yaml:"-"`
+
+	Checksum string
+}
+
+// AttachPolicy is when the distros are attached to Ubuntu Pro, each consuming a seat of the subscription.
+type AttachPolicy string
+
+const (
+	// AttachImmediately attaches every distro as soon as there is a subscription.
+	AttachImmediately AttachPolicy = "immediately"
+
+	// AttachOnFirstUse holds back the attachment of the distros until they are first launched by the user, so
+	// that dormant distros consume no seat. Distros attached already stay attached.
+	AttachOnFirstUse AttachPolicy = "first-use"
+)
+
+// validate checks that the attach policy is known. Empty is valid: it means AttachImmediately.
+func (p AttachPolicy) validate() error {
+	switch p {
+	case "", AttachImmediately, AttachOnFirstUse:
+		return nil
+	}
+	return fmt.Errorf("unknown attach policy %q, expected %q or %q", string(p), AttachImmediately, AttachOnFirstUse)
+}
+
+type attachConf struct {
+	OrgPolicy AttachPolicy `yaml:"-"`
+
+	Checksum string
+}
+
+type transferConf struct {
+	// OrgDailyCap is how many bytes the agent may transfer to and from each distro per day. Zero means no cap.
+	OrgDailyCap uint64 `yaml:"-"`
+}
+
+// ProxySettings is the proxy the distros reach the network through.
+type ProxySettings struct {
+	// HTTP is the proxy for HTTP requests, e.g. http://proxy.example.com:3128.
+	HTTP string
+
+	// HTTPS is the proxy for HTTPS requests.
+	HTTPS string
+
+	// NoProxy is a comma-separated list of hosts and domains reached without proxy.
+	NoProxy string
+}
+
+// validate checks that the proxies are HTTP(S) URLs and that the list of exceptions is well-formed.
+// Empty settings are valid: they mean that the proxy is not managed.
+func (p ProxySettings) validate() error {
+	for _, proxy := range []string{p.HTTP, p.HTTPS} {
+		if proxy == "" {
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy %q: %v", common.RedactURL(proxy), err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid proxy %q: expected an http:// or https:// URL", common.RedactURL(proxy))
+		}
+	}
+
+	if strings.ContainsAny(p.HTTP+p.HTTPS+p.NoProxy, "\"'\\ \t\r\n") {
+		return errors.New("proxy settings must not contain quotes, backslashes nor whitespace")
+	}
+
+	return nil
+}
+
+// String returns a representation of the proxy settings for logs, with the credentials of the proxies hidden.
+func (p ProxySettings) String() string {
+	return fmt.Sprintf("http: %q, https: %q, no_proxy: %q", common.RedactURL(p.HTTP), common.RedactURL(p.HTTPS), p.NoProxy)
+}
+
+// checksumInput returns the full representation of the proxy settings that their checksum is computed from.
+// It is empty for empty settings.
+func (p ProxySettings) checksumInput() string {
+	if p == (ProxySettings{}) {
+		return ""
+	}
+	return p.HTTP + "\n" + p.HTTPS + "\n" + p.NoProxy
+}
+
+type proxyConf struct {
+	OrgSettings ProxySettings `yaml:"-"`
+
+	Checksum string
+}
+
+// SnapSettings is the enterprise snap store proxy the distros install snaps from.
+type SnapSettings struct {
+	// StoreProxy is the URL of the snap store proxy, e.g. http://snaps.example.com.
+	StoreProxy string
+
+	// StoreID is the ID of the store of the snap store proxy.
+	StoreID string
+}
+
+// validate checks that the store proxy is an HTTP(S) URL and that both settings are set or unset together.
+// Empty settings are valid: they mean that the distros use the global snap store.
+func (s SnapSettings) validate() error {
+	if s == (SnapSettings{}) {
+		return nil
+	}
+
+	if s.StoreProxy == "" || s.StoreID == "" {
+		return errors.New("both the snap store proxy and its store ID must be set")
+	}
+
+	u, err := url.Parse(s.StoreProxy)
+	if err != nil {
+		return fmt.Errorf("invalid snap store proxy %q: %v", s.StoreProxy, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid snap store proxy %q: expected an http:// or https:// URL", s.StoreProxy)
+	}
+
+	if strings.ContainsAny(s.StoreID, "=\"'\\ \t\r\n") {
+		return fmt.Errorf("invalid snap store ID %q: equal signs, quotes, backslashes and whitespace are not allowed", s.StoreID)
+	}
+
+	return nil
+}
+
+// checksumInput returns the full representation of the snap settings that their checksum is computed from.
+// It is empty for empty settings.
+func (s SnapSettings) checksumInput() string {
+	if s == (SnapSettings{}) {
+		return ""
+	}
+	return s.StoreProxy + "\n" + s.StoreID
+}
+
+type snapConf struct {
+	OrgSettings SnapSettings `yaml:"-"`
+
+	Checksum string
+}
+
+// DNSSettings is how the distros resolve host names, instead of relying only on what WSL generates.
+type DNSSettings struct {
+	// Nameservers are the IP addresses of the nameservers, which replace the ones WSL generates in resolv.conf.
+	Nameservers []string
+
+	// SearchDomains are the domains appended to unqualified host names. They require nameservers, as they are
+	// written in the same resolv.conf.
+	SearchDomains []string
+
+	// Hosts are the lines added to /etc/hosts, each an IP address followed by one or more host names.
+	Hosts []string
+}
+
+// empty returns true if the DNS settings are not managed.
+func (d DNSSettings) empty() bool {
+	return len(d.Nameservers) == 0 && len(d.SearchDomains) == 0 && len(d.Hosts) == 0
+}
+
+// validate checks that the nameservers and the host entries start with IP addresses and that the names are free of
+// characters that resolv.conf and /etc/hosts would misread. Empty settings are valid: they mean that the DNS is not
+// managed.
+func (d DNSSettings) validate() error {
+	for _, ns := range d.Nameservers {
+		if _, err := netip.ParseAddr(ns); err != nil {
+			return fmt.Errorf("invalid nameserver %q: expected an IP address", ns)
+		}
+	}
+
+	if len(d.SearchDomains) > 0 && len(d.Nameservers) == 0 {
+		return errors.New("search domains require nameservers")
+	}
+	for _, domain := range d.SearchDomains {
+		if !hostNameRegex.MatchString(domain) {
+			return fmt.Errorf("invalid search domain %q", domain)
+		}
+	}
+
+	for _, entry := range d.Hosts {
+		fields := strings.Fields(entry)
+		if len(fields) < 2 {
+			return fmt.Errorf("invalid host entry %q: expected an IP address followed by host names", entry)
+		}
+		if _, err := netip.ParseAddr(fields[0]); err != nil {
+			return fmt.Errorf("invalid host entry %q: %q is not an IP address", entry, fields[0])
+		}
+		for _, name := range fields[1:] {
+			if !hostNameRegex.MatchString(name) {
+				return fmt.Errorf("invalid host entry %q: %q is not a host name", entry, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checksumInput returns the full representation of the DNS settings that their checksum is computed from.
+// It is empty for empty settings.
+func (d DNSSettings) checksumInput() string {
+	if d.empty() {
+		return ""
+	}
+	return strings.Join(d.Nameservers, ",") + "\n" + strings.Join(d.SearchDomains, ",") + "\n" + strings.Join(d.Hosts, "\n")
+}
+
+// hostNameRegex matches the host names and domains, made of dot-separated labels of letters, digits, hyphens and
+// underscores.
+var hostNameRegex = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_-]*[A-Za-z0-9_])?(\.[A-Za-z0-9_]([A-Za-z0-9_-]*[A-Za-z0-9_])?)*\.?$`)
+
+// splitList splits a comma- or whitespace-separated list, dropping the empty items. It returns nil for an empty list.
+func splitList(list string) []string {
+	items := strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
+
+// splitHostEntries splits the lines of host entries, normalising the whitespace and dropping the empty lines and
+// the comments.
+func splitHostEntries(entries string) []string {
+	var lines []string
+	for _, line := range strings.Split(entries, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines = append(lines, strings.Join(fields, " "))
+		}
+	}
+	return lines
+}
+
+type dnsConf struct {
+	OrgSettings DNSSettings `yaml:"-"`
+
+	Checksum string
+}
+
+// Update channels from which wsl-pro-service can be provisioned into the distros.
+const (
+	// ChannelStable provisions the package from the distro's own archive.
+	ChannelStable = "stable"
+
+	// ChannelBeta provisions the package from a PPA with pre-release builds.
+	ChannelBeta = "beta"
+
+	// ChannelLocal provisions the package from a deb file in the Windows filesystem.
+	ChannelLocal = "local"
+)
+
+// UpdateChannel is the source from which wsl-pro-service is provisioned into the distros.
+type UpdateChannel struct {
+	// Channel is one of ChannelStable, ChannelBeta or ChannelLocal. Empty means no channel is configured.
+	Channel string
+
+	// Source is the PPA for ChannelBeta (e.g. ppa:owner/name), or the Windows path to the deb for ChannelLocal.
+	// A local deb must be accompanied by its detached signature, at the same path with the .sig extension.
+	Source string
+
+	// Checksum is the SHA-256 checksum of the deb. Required for ChannelLocal, to detect copies corrupted on their way
+	// into the distro. It does not prove the deb authentic: the distros check its signature for that.
+	Checksum string
+}
+
+var (
+	ppaRegex    = regexp.MustCompile(`^ppa:[a-z0-9][a-z0-9+.-]*/[a-z0-9][a-z0-9+.-]*$`)
+	sha256Regex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+)
+
+// validate checks that the update channel is well-formed.
+func (u UpdateChannel) validate() error {
+	switch u.Channel {
+	case "":
+		if u.Source != "" || u.Checksum != "" {
+			return errors.New("source and checksum require a channel")
+		}
+	case ChannelStable:
+		if u.Source != "" {
+			return errors.New("the stable channel does not accept a source")
+		}
+	case ChannelBeta:
+		if !ppaRegex.MatchString(u.Source) {
+			return fmt.Errorf("the beta channel requires a PPA as source, got %q", u.Source)
+		}
+	case ChannelLocal:
+		if !strings.HasSuffix(u.Source, ".deb") {
+			return fmt.Errorf("the local channel requires the path to a deb as source, got %q", u.Source)
+		}
+		if !sha256Regex.MatchString(u.Checksum) {
+			return errors.New("the local channel requires the SHA-256 checksum of the deb")
+		}
+	default:
+		return fmt.Errorf("unknown channel %q", u.Channel)
+	}
+
+	return nil
+}
+
+// String returns a canonical representation of the update channel, used to detect changes.
+func (u UpdateChannel) String() string {
+	if u.Channel == "" {
+		return ""
+	}
+	return strings.Join([]string{u.Channel, u.Source, strings.ToLower(u.Checksum)}, "\n")
+}
+
+type updateChannelConf struct {
+	OrgChannel UpdateChannel `yaml:"-"`
+
+	// OrgMinimumVersion is the oldest version of wsl-pro-service the agent manages. Empty means any version.
+	OrgMinimumVersion string `yaml:"-"`
+
+	// OrgMaintenanceWindows are the windows during which wsl-pro-service may be upgraded. None means any time
+	// outside of the user's active hours.
+	OrgMaintenanceWindows []maintenance.Window `yaml:"-"`
+
+	Checksum string
+
+	// Pending is whether the upgrade from the channel matching Checksum has yet to be distributed. It is
+	// persisted so that an upgrade held back until the maintenance window survives restarts.
+	Pending bool
+}
+
+// notificationsConf contains the user's notification preferences.
+type notificationsConf struct {
+	Frequency string
+}
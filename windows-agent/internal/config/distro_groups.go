@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DistroGroup maps a set of distros to their own Ubuntu Pro token, Landscape configuration and patching
+// level, so that organizations can use different contracts and policies for different teams. Empty
+// settings fall back to the global ones.
+type DistroGroup struct {
+	Name string `yaml:"name"`
+
+	// Distros is the explicit list of distro names in the group.
+	Distros []string `yaml:"distros"`
+
+	// Pattern is a glob pattern (e.g. "Ubuntu-Team*") matching the names of the distros in the group.
+	Pattern string `yaml:"pattern"`
+
+	UbuntuProToken  string `yaml:"ubuntu_pro_token"`
+	LandscapeConfig string `yaml:"landscape_config"`
+
+	// PatchingLevel overrides the global patching level for the distros in the group.
+	PatchingLevel PatchingLevel `yaml:"patching_level"`
+}
+
+// Matches returns true if the distro belongs to the group. Distro names are case-insensitive.
+func (g DistroGroup) Matches(distroName string) bool {
+	distroName = strings.ToLower(distroName)
+
+	for _, d := range g.Distros {
+		if strings.ToLower(d) == distroName {
+			return true
+		}
+	}
+
+	if g.Pattern == "" {
+		return false
+	}
+
+	match, err := path.Match(strings.ToLower(g.Pattern), distroName)
+	return err == nil && match
+}
+
+// matchesAll returns true if the group pattern matches any distro name.
+func (g DistroGroup) matchesAll() bool {
+	return g.Pattern != "" && strings.Trim(g.Pattern, "*") == ""
+}
+
+// validate checks that the distro group is well-formed.
+func (g DistroGroup) validate() error {
+	if g.Name == "" {
+		return errors.New("distro group has no name")
+	}
+
+	if len(g.Distros) == 0 && g.Pattern == "" {
+		return fmt.Errorf("distro group %q matches no distros: it needs a list of distros or a pattern", g.Name)
+	}
+
+	if _, err := path.Match(g.Pattern, ""); err != nil {
+		return fmt.Errorf("distro group %q has an invalid pattern %q: %v", g.Name, g.Pattern, err)
+	}
+
+	if err := g.PatchingLevel.validate(); err != nil {
+		return fmt.Errorf("distro group %q: %v", g.Name, err)
+	}
+
+	if g.UbuntuProToken == "" && g.LandscapeConfig == "" && g.PatchingLevel == "" {
+		return fmt.Errorf("distro group %q has no Ubuntu Pro token, Landscape configuration nor patching level", g.Name)
+	}
+
+	return nil
+}
+
+// parseDistroGroups parses the YAML list of distro groups provided by the registry.
+func parseDistroGroups(data string) ([]DistroGroup, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	var groups []DistroGroup
+	if err := yaml.Unmarshal([]byte(data), &groups); err != nil {
+		return nil, fmt.Errorf("could not parse distro groups: %v", err)
+	}
+
+	names := make(map[string]struct{})
+	for _, g := range groups {
+		if err := g.validate(); err != nil {
+			return nil, err
+		}
+
+		if _, ok := names[g.Name]; ok {
+			return nil, fmt.Errorf("distro group %q is defined more than once", g.Name)
+		}
+		names[g.Name] = struct{}{}
+	}
+
+	return groups, nil
+}
+
+type distroGroupsConf struct {
+	OrgGroups []DistroGroup `yaml:"-"`
+
+	Checksum string
+}
+
+// groupOf returns the first distro group the distro belongs to.
+func (d distroGroupsConf) groupOf(distroName string) (DistroGroup, bool) {
+	for _, g := range d.OrgGroups {
+		if g.Matches(distroName) {
+			return g, true
+		}
+	}
+	return DistroGroup{}, false
+}
@@ -0,0 +1,164 @@
+// Package consent routes the requests of tasks for user confirmation (e.g. before making changes
+// that are hard to undo) to the GUI, and their answers back to the tasks.
+package consent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/google/uuid"
+)
+
+// Policy states what happens to requests nobody answers.
+type Policy struct {
+	// Timeout is how long to wait for an answer. Zero means the default timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// GrantOnTimeout makes unanswered requests be granted rather than denied.
+	GrantOnTimeout bool `mapstructure:"grant_on_timeout"`
+}
+
+// DefaultPolicy is the policy used when none is configured: unanswered requests are denied after a while.
+var DefaultPolicy = Policy{
+	Timeout: 5 * time.Minute,
+}
+
+// Request is a pending request for consent.
+type Request struct {
+	ID       string
+	Distro   string
+	Prompt   string
+	Deadline time.Time
+}
+
+// watcherBufferSize is the number of requests buffered for each watcher. Requests for watchers
+// that fall behind are dropped, and they will time out if nobody else answers them.
+const watcherBufferSize = 16
+
+// Broker keeps track of the pending requests for consent, and notifies its watchers about them.
+type Broker struct {
+	policy Policy
+
+	pending  map[string]pendingRequest
+	watchers map[chan Request]struct{}
+	mu       sync.Mutex
+}
+
+type pendingRequest struct {
+	Request
+	answer chan bool
+}
+
+// New creates a broker that applies the policy to the requests nobody answers.
+func New(policy Policy) *Broker {
+	if policy.Timeout == 0 {
+		policy.Timeout = DefaultPolicy.Timeout
+	}
+
+	return &Broker{
+		policy:   policy,
+		pending:  make(map[string]pendingRequest),
+		watchers: make(map[chan Request]struct{}),
+	}
+}
+
+// Ask requests consent to go ahead with what the prompt describes on the distro, and blocks until
+// the request is answered, it times out, or the context is cancelled. Timeouts are resolved according
+// to the policy.
+func (b *Broker) Ask(ctx context.Context, distro, prompt string) (granted bool, err error) {
+	req := pendingRequest{
+		Request: Request{
+			ID:       uuid.NewString(),
+			Distro:   distro,
+			Prompt:   prompt,
+			Deadline: time.Now().Add(b.policy.Timeout),
+		},
+		answer: make(chan bool, 1),
+	}
+
+	b.mu.Lock()
+	b.pending[req.ID] = req
+	for ch := range b.watchers {
+		select {
+		case ch <- req.Request:
+		default:
+			log.Warningf(ctx, "Consent: dropped request for a watcher that is falling behind")
+		}
+	}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.pending, req.ID)
+	}()
+
+	log.Infof(ctx, "Consent: distro %q: waiting for consent to %s", distro, prompt)
+
+	select {
+	case granted := <-req.answer:
+		return granted, nil
+	case <-time.After(b.policy.Timeout):
+		log.Warningf(ctx, "Consent: distro %q: request timed out, granted by policy: %t", distro, b.policy.GrantOnTimeout)
+		return b.policy.GrantOnTimeout, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// Answer grants or denies the pending request with the given ID.
+func (b *Broker) Answer(id string, granted bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	req, ok := b.pending[id]
+	if !ok {
+		return fmt.Errorf("no pending request for consent with ID %q", id)
+	}
+	delete(b.pending, id)
+
+	req.answer <- granted
+	return nil
+}
+
+// Watch returns a channel that receives the pending requests, and the new ones as they are made.
+// The channel is closed when the context is cancelled.
+func (b *Broker) Watch(ctx context.Context) <-chan Request {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Request, max(watcherBufferSize, len(b.pending)))
+	for _, req := range b.pending {
+		ch <- req.Request
+	}
+	b.watchers[ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.watchers, ch)
+		close(ch)
+	}()
+
+	return ch
+}
+
+// brokerKey is the context key under which the broker is stored.
+type brokerKey struct{}
+
+// WithBroker returns a context carrying the broker, so that the distros created with it can request consent.
+func WithBroker(ctx context.Context, b *Broker) context.Context {
+	return context.WithValue(ctx, brokerKey{}, b)
+}
+
+// FromContext returns the broker stored in the context, if any.
+func FromContext(ctx context.Context) (*Broker, bool) {
+	b, ok := ctx.Value(brokerKey{}).(*Broker)
+	return b, ok && b != nil
+}
@@ -0,0 +1,154 @@
+package consent_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsk(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		answer         *bool
+		grantOnTimeout bool
+		cancelCtx      bool
+		badID          bool
+
+		want          bool
+		wantErr       bool
+		wantAnswerErr bool
+	}{
+		"Success when the user grants consent":    {answer: ptr(true), want: true},
+		"Success when the user denies consent":    {answer: ptr(false), want: false},
+		"Success denying by policy on timeout":    {},
+		"Success granting by policy on timeout":   {grantOnTimeout: true, want: true},
+		"Success ignoring answers to unknown IDs": {answer: ptr(true), badID: true, wantAnswerErr: true},
+
+		"Error when the context is cancelled": {cancelCtx: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			b := consent.New(consent.Policy{Timeout: 2 * time.Second, GrantOnTimeout: tc.grantOnTimeout})
+			requests := b.Watch(ctx)
+
+			type result struct {
+				granted bool
+				err     error
+			}
+			done := make(chan result, 1)
+			go func() {
+				granted, err := b.Ask(ctx, "mock-distro", "mock prompt")
+				done <- result{granted, err}
+			}()
+
+			var req consent.Request
+			select {
+			case req = <-requests:
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "Watcher should have received the request")
+			}
+			require.Equal(t, "mock-distro", req.Distro, "Mismatched distro in the request")
+			require.Equal(t, "mock prompt", req.Prompt, "Mismatched prompt in the request")
+			require.WithinDuration(t, time.Now().Add(2*time.Second), req.Deadline, time.Second, "Deadline should follow the policy")
+
+			if tc.cancelCtx {
+				cancel()
+			}
+
+			if tc.answer != nil {
+				id := req.ID
+				if tc.badID {
+					id = "not-a-request"
+				}
+				err := b.Answer(id, *tc.answer)
+				if tc.wantAnswerErr {
+					require.Error(t, err, "Answer should return an error")
+				} else {
+					require.NoError(t, err, "Answer should return no error")
+				}
+			}
+
+			var got result
+			select {
+			case got = <-done:
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "Ask should have returned")
+			}
+
+			if tc.wantErr {
+				require.Error(t, got.err, "Ask should return an error")
+				return
+			}
+			require.NoError(t, got.err, "Ask should return no error")
+			require.Equal(t, tc.want, got.granted, "Mismatched consent")
+
+			require.Error(t, b.Answer(req.ID, true), "Answering a request that is no longer pending should return an error")
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := consent.New(consent.Policy{})
+
+	go func() {
+		//nolint:errcheck // The request is never answered
+		b.Ask(ctx, "mock-distro", "mock prompt")
+	}()
+
+	// A watcher that arrives late still gets the pending request.
+	var pending []consent.Request
+	require.Eventually(t, func() bool {
+		watchCtx, cancelWatch := context.WithCancel(ctx)
+		defer cancelWatch()
+
+		select {
+		case req := <-b.Watch(watchCtx):
+			pending = append(pending, req)
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 100*time.Millisecond, "Late watchers should receive the pending requests")
+	require.Equal(t, "mock prompt", pending[0].Prompt, "Mismatched pending request")
+
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	requests := b.Watch(watchCtx)
+	<-requests
+
+	cancelWatch()
+	require.Eventually(t, func() bool {
+		_, ok := <-requests
+		return !ok
+	}, 5*time.Second, 100*time.Millisecond, "Channel should be closed when the watch is cancelled")
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	_, ok := consent.FromContext(context.Background())
+	require.False(t, ok, "There should be no broker in an empty context")
+
+	b := consent.New(consent.Policy{})
+	got, ok := consent.FromContext(consent.WithBroker(context.Background(), b))
+	require.True(t, ok, "There should be a broker in the context")
+	require.Same(t, b, got, "Mismatched broker in the context")
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
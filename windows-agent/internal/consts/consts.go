@@ -1,4 +1,5 @@
-// Package consts defines the constants used by the project
+// Package consts defines the constants used by the agent. The names it shares with the other components, such as
+// the files of its public directory, are in the common module instead, so that they cannot diverge.
 package consts
 
 import log "github.com/sirupsen/logrus"
@@ -9,4 +10,45 @@ const (
 
 	// DatabaseFileName corresponds to the base name of the file containing the database.
 	DatabaseFileName = "distros.db"
+
+	// StoreFileName is the base name of the file, inside the private directory, of the embedded store where the
+	// database, the task queues of the distros, the journal of events and the configuration are kept. It replaces
+	// the database file, the task files, the journal file and the config file.
+	StoreFileName = "store.db"
+
+	// DatabaseBackupsDir is the name of the directory, inside the private directory, where backups of the database are stored.
+	DatabaseBackupsDir = "db-backups"
+
+	// ErrorRecordsDir is the name of the directory, inside the private directory, where records of errors (e.g. the crashes of the sandboxed process) are stored.
+	ErrorRecordsDir = "errors"
+
+	// SandboxDir is the name of the directory, inside the private directory, where the sandboxed process keeps its
+	// data, so that it survives the process being restarted.
+	SandboxDir = "sandbox"
+
+	// ContractCacheFileName is the base name of the file, inside the sandbox directory, where the responses of the
+	// contract server are cached.
+	ContractCacheFileName = "contract-cache.json"
+
+	// ContractHealthFileName is the base name of the file, inside the private directory, where the times of the
+	// last interactions with the contract server are kept.
+	ContractHealthFileName = "contract-health.json"
+
+	// LogFileName is the base name of the agent's log file, inside the public directory.
+	LogFileName = "log"
+
+	// JournalFileName is the base name of the file, inside the private directory, where older versions stored the
+	// journal of events. It is also the name of the journal in feedback bundles.
+	JournalFileName = "events.journal"
+
+	// HandOffFileName is the base name of the file, inside the private directory, that the agent writes once it
+	// handed its tasks off to the next version, so that the next version knows it starts after an update.
+	HandOffFileName = "handoff.json"
+
+	// UsgReportsDir is the name of the directory, inside the private directory, where USG audit reports are stored.
+	UsgReportsDir = "usg-reports"
+
+	// NamedPipePrefix is the prefix of the name of the named pipe the agent serves its command line clients on,
+	// followed by the ID of the user running it.
+	NamedPipePrefix = "ubuntu-pro-agent-"
 )
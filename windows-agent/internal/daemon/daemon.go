@@ -9,11 +9,16 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/daemon/netmonitoring"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/namedpipe"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/peercred"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslversion"
 	"github.com/ubuntu/decorate"
 	"google.golang.org/grpc"
 )
@@ -21,13 +26,22 @@ import (
 // GRPCServiceRegisterer is a function that the daemon will call everytime we want to build a new GRPC object.
 type GRPCServiceRegisterer func(ctx context.Context, isWslNetAvailable bool) *grpc.Server
 
+// StatusServiceRegisterer is a function that the daemon will call everytime we want to build a new GRPC object
+// serving the read-only status API.
+type StatusServiceRegisterer func(ctx context.Context) *grpc.Server
+
 // Daemon is a daemon for windows agents with grpc support.
 type Daemon struct {
 	listeningPortFilePath string
+	namedPipeFilePath     string
 
 	// serving signals that Serve has been called once. This channel is closed when Serve is called.
 	serving chan struct{}
 
+	// ready signals that the daemon accepts connections. This channel is closed once it first does.
+	ready     chan struct{}
+	readyOnce sync.Once
+
 	// quit allows other goroutines to signal to stop the daemon while still running. It's intentionally never closed so clients can call Quit() safely.
 	quit chan quitRequest
 
@@ -44,13 +58,13 @@ type Daemon struct {
 func New(ctx context.Context, registerGRPCServices GRPCServiceRegisterer, addrDir string) *Daemon {
 	log.Debug(ctx, "Building new daemon")
 
-	listeningPortFilePath := filepath.Join(addrDir, common.ListeningPortFileName)
-
 	return &Daemon{
-		listeningPortFilePath: listeningPortFilePath,
+		listeningPortFilePath: common.AddressFile(addrDir),
+		namedPipeFilePath:     filepath.Join(addrDir, common.NamedPipeFileName),
 		registerer:            registerGRPCServices,
 		quit:                  make(chan quitRequest, 1),
 		serving:               make(chan struct{}),
+		ready:                 make(chan struct{}),
 		stopped:               make(chan struct{}, 1),
 	}
 }
@@ -60,6 +74,10 @@ type options struct {
 	wslCmdEnv             []string
 	getAdaptersAddresses  getAdaptersAddressesFunc
 	netMonitoringProvider netmonitoring.DevicesAPIProvider
+	wslInfo               wslversion.Info
+	statusRegisterer      StatusServiceRegisterer
+	statusDir             string
+	namedPipe             string
 }
 
 var defaultOptions = options{
@@ -73,9 +91,48 @@ func (d *Daemon) WaitReady() {
 	<-d.serving
 }
 
+// Ready returns a channel that is closed once the daemon accepts connections and its address file is written, so
+// that clients can reach it, instead of guessing how long it takes. It stays closed while the daemon restarts, and
+// is never closed if the daemon fails to serve.
+func (d *Daemon) Ready() <-chan struct{} {
+	return d.ready
+}
+
 // Option represents an optional function to override getWslIP default values.
 type Option func(*options)
 
+// WithWslInfo sets the information about the host WSL, so that features it does not support are not used.
+// By default the WSL version is unknown and every feature is attempted.
+func WithWslInfo(info wslversion.Info) Option {
+	return func(o *options) {
+		o.wslInfo = info
+	}
+}
+
+// WithStatusAPI serves the read-only status API built by registerer on a second tcp socket, only listening on
+// localhost, whose address is written in statusDir rather than next to the one of the main socket, so that its
+// clients need no access to the latter. By default, only the main socket is served.
+func WithStatusAPI(statusDir string, registerer StatusServiceRegisterer) Option {
+	return func(o *options) {
+		o.statusDir = statusDir
+		o.statusRegisterer = registerer
+	}
+}
+
+// statusListeningPortFilePath is the path of the file hosting the address of the status API, if it is served.
+func (o options) statusListeningPortFilePath() string {
+	return common.AddressFile(o.statusDir)
+}
+
+// WithNamedPipe also serves the main GRPC server on the named pipe with the given name, only reachable by the user
+// running the agent, whose path is written next to the address of the main socket. The command line clients of the
+// agent use it rather than loopback TCP. By default, there is no named pipe.
+func WithNamedPipe(name string) Option {
+	return func(o *options) {
+		o.namedPipe = name
+	}
+}
+
 // Serve listens on a tcp socket and starts serving GRPC requests on it.
 // Before serving, it writes a file on disk on which port it's listening on for client
 // to be able to reach our server.
@@ -121,6 +178,14 @@ func (d *Daemon) tryServingOnce(ctx context.Context, opts options) error {
 		if err := os.Remove(d.listeningPortFilePath); err != nil {
 			log.Warningf(ctx, "Daemon: could not remove address file: %v", err)
 		}
+		if opts.statusRegisterer != nil {
+			if err := os.Remove(opts.statusListeningPortFilePath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Warningf(ctx, "Daemon: could not remove status address file: %v", err)
+			}
+		}
+		if err := os.Remove(d.namedPipeFilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warningf(ctx, "Daemon: could not remove named pipe file: %v", err)
+		}
 		d.stopped <- struct{}{}
 	}()
 
@@ -271,13 +336,18 @@ func (d *Daemon) serve(ctx context.Context, opts options) (<-chan error, stopFun
 			d.netSubs = n
 		}
 
+		// Listening on IPv4 only, as that's what WSL can reach. Port 0 lets the OS pick a free one.
 		var cfg net.ListenConfig
-		lis, err = cfg.Listen(ctx, "tcp", fmt.Sprintf("%s:0", wslIP))
+		lis, err = cfg.Listen(ctx, "tcp4", net.JoinHostPort(wslIP.String(), "0"))
 		if err != nil {
 			return fmt.Errorf("can't listen: %v", err)
 		}
 
-		addr := lis.Addr().String()
+		addr, err := publishedAddress(lis.Addr(), wslIP)
+		if err != nil {
+			_ = lis.Close()
+			return err
+		}
 
 		// Write a file on disk to signal selected ports to clients.
 		// We write it here to signal error when calling service.Start().
@@ -303,6 +373,7 @@ func (d *Daemon) serve(ctx context.Context, opts options) (<-chan error, stopFun
 	grpcServer := d.registerer(ctx, wslNetAvailable)
 
 	go func() {
+		defer crashreport.Recover("daemon")
 		// If we get here, we're the only writer to this channel, thus we are responsible for closing it.
 		defer close(errCh)
 		err := grpcServer.Serve(lis)
@@ -313,7 +384,97 @@ func (d *Daemon) serve(ctx context.Context, opts options) (<-chan error, stopFun
 		errCh <- err
 	}()
 
-	return errCh, newStopFunc(grpcServer)
+	// The named pipe is an extra as well: stopping the server stops serving it too.
+	if opts.namedPipe != "" {
+		if err := d.serveNamedPipe(ctx, grpcServer, opts.namedPipe); err != nil {
+			log.Warningf(ctx, "Daemon: not serving on a named pipe: %v", err)
+		}
+	}
+
+	stop := newStopFunc(grpcServer)
+	if opts.statusRegisterer == nil {
+		d.setReady()
+		return errCh, stop
+	}
+
+	// The status API is an extra: the agent keeps serving its main socket without it.
+	statusServer, err := serveStatus(ctx, opts)
+	d.setReady()
+	if err != nil {
+		log.Warningf(ctx, "Daemon: not serving the status API: %v", err)
+		return errCh, stop
+	}
+
+	stopStatus := newStopFunc(statusServer)
+	return errCh, func(ctx context.Context, force bool) {
+		stopStatus(ctx, force)
+		stop(ctx, force)
+	}
+}
+
+// setReady signals that the daemon accepts connections, unless it already did.
+func (d *Daemon) setReady() {
+	d.readyOnce.Do(func() { close(d.ready) })
+}
+
+// serveStatus starts serving the status API on localhost, and writes its address file. Its serving errors are only
+// logged, as they do not affect the main socket.
+func serveStatus(ctx context.Context, opts options) (server *grpc.Server, err error) {
+	defer decorate.OnError(&err, "could not serve the status API")
+
+	// Only processes of the host can reach the status API.
+	var cfg net.ListenConfig
+	lis, err := cfg.Listen(ctx, "tcp4", net.JoinHostPort(net.IPv4(127, 0, 0, 1).String(), "0"))
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %v", err)
+	}
+
+	addr := lis.Addr().String()
+	if err := os.WriteFile(opts.statusListeningPortFilePath(), []byte(addr), 0600); err != nil {
+		_ = lis.Close()
+		return nil, err
+	}
+	log.Infof(ctx, "Daemon: serving the status API on %s", addr)
+
+	server = opts.statusRegisterer(ctx)
+	go func() {
+		defer crashreport.Recover("daemon")
+		if err := server.Serve(lis); err != nil {
+			log.Warningf(ctx, "Daemon: status API serve error: %v", err)
+		}
+	}()
+
+	return server, nil
+}
+
+// serveNamedPipe starts serving the server on the named pipe with the given name as well, and writes its path file.
+// Its serving errors are only logged, as they do not affect the main socket.
+func (d *Daemon) serveNamedPipe(ctx context.Context, server *grpc.Server, name string) (err error) {
+	defer decorate.OnError(&err, "could not serve on named pipe %q", name)
+
+	path := namedpipe.Path(name)
+	lis, err := namedpipe.Listen(path)
+	if err != nil {
+		return err
+	}
+	// The clients of the named pipe are identified when they connect, so that only the user running the agent and
+	// the administrators can change its state.
+	lis = peercred.WrapListener(lis)
+
+	if err := os.WriteFile(d.namedPipeFilePath, []byte(path), 0600); err != nil {
+		_ = lis.Close()
+		return err
+	}
+	log.Infof(ctx, "Daemon: serving gRPC requests on named pipe %s", path)
+
+	go func() {
+		defer crashreport.Recover("daemon")
+		if err := server.Serve(lis); err != nil {
+			log.Warningf(ctx, "Daemon: named pipe serve error: %v", err)
+		}
+	}()
+
+	return nil
 }
 
 type stopFunc func(ctx context.Context, force bool)
@@ -3,10 +3,13 @@ package daemon_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand/v2"
 	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,6 +18,8 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/daemon/daemontestutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/daemon/netmonitoring"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/daemon/testdata/grpctestservice"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/namedpipe"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslversion"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -71,9 +76,10 @@ func TestStartQuit(t *testing.T) {
 				require.NoError(t, err, "Setup: failed to create pre-existing port file")
 			}
 
+			svc := &testGRPCService{}
 			registerer := func(context.Context, bool) *grpc.Server {
 				server := grpc.NewServer()
-				grpctestservice.RegisterTestServiceServer(server, testGRPCService{})
+				grpctestservice.RegisterTestServiceServer(server, svc)
 				return server
 			}
 
@@ -97,7 +103,7 @@ func TestStartQuit(t *testing.T) {
 					return string(addrContents) != "# Old port file"
 				}, 5*time.Second, 100*time.Millisecond, "Pre-existing address file should be overwritten after dameon.New()")
 			} else {
-				daemontestutils.RequireWaitPathExists(t, addrPath, "Serve should create an address file")
+				requireReady(t, d)
 				addrContents, err = os.ReadFile(addrPath)
 				require.NoError(t, err, "Address file should be readable")
 			}
@@ -112,7 +118,7 @@ func TestStartQuit(t *testing.T) {
 			require.NoError(t, err, "Port should be valid")
 
 			// We start a connection but don't close it yet, so as to test graceful vs. forceful Quit
-			closeHangingConn := grpcPersistentCall(t, address)
+			closeHangingConn := grpcPersistentCall(t, svc, address)
 			defer closeHangingConn()
 
 			// Now we know the GRPC server has started serving.
@@ -158,6 +164,184 @@ func TestStartQuit(t *testing.T) {
 	}
 }
 
+func TestServeStatusAPI(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		noStatusAPI         bool
+		breakStatusAddrFile bool
+
+		wantStatusAPI bool
+	}{
+		"Success serving the status API on localhost":                          {wantStatusAPI: true},
+		"Success serving without the status API":                               {noStatusAPI: true},
+		"Success serving the main socket when the status API cannot be served": {breakStatusAddrFile: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			addrDir := t.TempDir()
+			statusDir := t.TempDir()
+
+			statusAddrPath := filepath.Join(statusDir, common.ListeningPortFileName)
+			if tc.breakStatusAddrFile {
+				require.NoError(t, os.MkdirAll(filepath.Join(statusAddrPath, "child"), 0700), "Setup: could not create a directory in place of the status address file")
+			}
+
+			svc := &testGRPCService{}
+			registerer := func(context.Context, bool) *grpc.Server {
+				server := grpc.NewServer()
+				grpctestservice.RegisterTestServiceServer(server, svc)
+				return server
+			}
+
+			var opts []daemon.Option
+			if !tc.noStatusAPI {
+				opts = append(opts, daemon.WithStatusAPI(statusDir, func(context.Context) *grpc.Server {
+					server := grpc.NewServer()
+					grpctestservice.RegisterTestServiceServer(server, svc)
+					return server
+				}))
+			}
+
+			d := daemon.New(ctx, registerer, addrDir)
+			serveErr := make(chan error)
+			go func() {
+				serveErr <- d.Serve(ctx, opts...)
+				close(serveErr)
+			}()
+
+			addrPath := filepath.Join(addrDir, common.ListeningPortFileName)
+			requireReady(t, d)
+			addr, err := os.ReadFile(addrPath)
+			require.NoError(t, err, "Address file should be readable")
+			drop := grpcPersistentCall(t, svc, string(addr))
+			require.Equal(t, codes.Canceled, drop(), "The main socket should be served")
+
+			if !tc.wantStatusAPI {
+				if !tc.breakStatusAddrFile {
+					require.NoFileExists(t, statusAddrPath, "Serve should not create a status address file without the status API")
+				}
+				d.Quit(ctx, false)
+				require.NoError(t, <-serveErr, "Serve should return no error when stopped normally")
+				return
+			}
+
+			daemontestutils.RequireWaitPathExists(t, statusAddrPath, "Serve should create a status address file")
+			statusAddr, err := os.ReadFile(statusAddrPath)
+			require.NoError(t, err, "Status address file should be readable")
+
+			host, _, err := net.SplitHostPort(string(statusAddr))
+			require.NoError(t, err, "Status address should be valid")
+			require.Equal(t, "127.0.0.1", host, "The status API should only listen on localhost")
+
+			drop = grpcPersistentCall(t, svc, string(statusAddr))
+			require.Equal(t, codes.Canceled, drop(), "The status API should be served")
+
+			d.Quit(ctx, false)
+			require.NoError(t, <-serveErr, "Serve should return no error when stopped normally")
+			requireCannotDialGRPC(t, string(statusAddr), "No new connection to the status API should be allowed when the daemon is no longer running")
+			daemontestutils.RequireWaitPathDoesNotExist(t, statusAddrPath, "Status address file should have been removed after quitting the server")
+		})
+	}
+}
+
+func TestServeNamedPipe(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		noNamedPipe   bool
+		pipeTaken     bool
+		breakPipeFile bool
+
+		wantNamedPipe bool
+	}{
+		"Success serving on a named pipe":                                            {wantNamedPipe: true},
+		"Success serving without a named pipe":                                       {noNamedPipe: true},
+		"Success serving the main socket when the named pipe is already taken":       {pipeTaken: true},
+		"Success serving the main socket when the named pipe file cannot be written": {breakPipeFile: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			addrDir := t.TempDir()
+
+			// Short and unique, as the named pipe stands in for a unix socket on Linux.
+			pipeName := fmt.Sprintf("up4w-test-%d", rand.Uint64())
+
+			pipeFilePath := filepath.Join(addrDir, common.NamedPipeFileName)
+			if tc.breakPipeFile {
+				require.NoError(t, os.MkdirAll(filepath.Join(pipeFilePath, "child"), 0700), "Setup: could not create a directory in place of the named pipe file")
+			}
+			if tc.pipeTaken {
+				lis, err := namedpipe.Listen(namedpipe.Path(pipeName))
+				require.NoError(t, err, "Setup: could not take the named pipe")
+				defer lis.Close()
+			}
+
+			svc := &testGRPCService{}
+			registerer := func(context.Context, bool) *grpc.Server {
+				server := grpc.NewServer()
+				grpctestservice.RegisterTestServiceServer(server, svc)
+				return server
+			}
+
+			var opts []daemon.Option
+			if !tc.noNamedPipe {
+				opts = append(opts, daemon.WithNamedPipe(pipeName))
+			}
+
+			d := daemon.New(ctx, registerer, addrDir)
+			serveErr := make(chan error)
+			go func() {
+				serveErr <- d.Serve(ctx, opts...)
+				close(serveErr)
+			}()
+
+			addrPath := filepath.Join(addrDir, common.ListeningPortFileName)
+			requireReady(t, d)
+			addr, err := os.ReadFile(addrPath)
+			require.NoError(t, err, "Address file should be readable")
+			drop := grpcPersistentCall(t, svc, string(addr))
+			require.Equal(t, codes.Canceled, drop(), "The main socket should be served")
+
+			if !tc.wantNamedPipe {
+				if !tc.breakPipeFile {
+					require.NoFileExists(t, pipeFilePath, "Serve should not create a named pipe file without serving on the named pipe")
+				}
+				d.Quit(ctx, false)
+				require.NoError(t, <-serveErr, "Serve should return no error when stopped normally")
+				return
+			}
+
+			daemontestutils.RequireWaitPathExists(t, pipeFilePath, "Serve should create a named pipe file")
+			pipePath, err := os.ReadFile(pipeFilePath)
+			require.NoError(t, err, "Named pipe file should be readable")
+			require.Equal(t, namedpipe.Path(pipeName), string(pipePath), "Named pipe file should contain the path of the named pipe")
+
+			dialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return namedpipe.Dial(ctx, string(pipePath))
+			})
+			drop = grpcPersistentCall(t, svc, "passthrough:///pipe", dialer)
+			require.Equal(t, codes.Canceled, drop(), "The named pipe should be served")
+
+			d.Quit(ctx, false)
+			require.NoError(t, <-serveErr, "Serve should return no error when stopped normally")
+			_, err = namedpipe.Dial(ctx, string(pipePath))
+			require.Error(t, err, "No new connection to the named pipe should be allowed when the daemon is no longer running")
+			daemontestutils.RequireWaitPathDoesNotExist(t, pipeFilePath, "Named pipe file should have been removed after quitting the server")
+		})
+	}
+}
+
 func TestCanServeOnlyOnce(t *testing.T) {
 	t.Parallel()
 
@@ -179,9 +363,10 @@ func TestCanServeOnlyOnce(t *testing.T) {
 			defer cancel()
 			addrDir := t.TempDir()
 
+			svc := &testGRPCService{}
 			registerer := func(context.Context, bool) *grpc.Server {
 				server := grpc.NewServer()
-				grpctestservice.RegisterTestServiceServer(server, testGRPCService{})
+				grpctestservice.RegisterTestServiceServer(server, svc)
 				return server
 			}
 
@@ -229,6 +414,7 @@ func TestServeWSLIP(t *testing.T) {
 
 	testcases := map[string]struct {
 		netmode      string
+		wslVersion   string
 		withAdapters daemontestutils.MockIPAdaptersState
 		subscribeErr error
 
@@ -237,6 +423,8 @@ func TestServeWSLIP(t *testing.T) {
 		"Success":                       {withAdapters: daemontestutils.MultipleHyperVAdaptersInList},
 		"With a single Hyper-V Adapter": {withAdapters: daemontestutils.SingleHyperVAdapterInList},
 		"With mirrored networking mode": {netmode: "mirrored", withAdapters: daemontestutils.MultipleHyperVAdaptersInList},
+		"With mirrored networking mode on a WSL supporting it":                  {netmode: "mirrored", wslVersion: "2.3.24.0", withAdapters: daemontestutils.MultipleHyperVAdaptersInList},
+		"With NAT assumed on a WSL too old to support mirrored networking":      {netmode: "mirrored", wslVersion: "1.2.5.0", withAdapters: daemontestutils.MultipleHyperVAdaptersInList},
 		"With no access to the system distro but net mode is the default (NAT)": {netmode: "error", withAdapters: daemontestutils.MultipleHyperVAdaptersInList},
 
 		"When the networking mode is unknown":            {netmode: "unknown"},
@@ -244,6 +432,8 @@ func TestServeWSLIP(t *testing.T) {
 		"When listing adapters requires too much memory": {withAdapters: daemontestutils.RequiresTooMuchMem},
 		"When there is no Hyper-V adapter the list":      {withAdapters: daemontestutils.NoHyperVAdapterInList},
 		"When retrieving adapters information fails":     {withAdapters: daemontestutils.MockError},
+		"When the WSL adapter has an unspecified IP":     {withAdapters: daemontestutils.UnspecifiedIPWSLAdapterInList},
+		"When the WSL adapter only has an IPv6 address":  {withAdapters: daemontestutils.IPv6OnlyWSLAdapterInList},
 
 		"Error when the WSL IP cannot be found and monitoring network fails": {withAdapters: daemontestutils.NoHyperVAdapterInList, subscribeErr: errors.New("mock error"), wantErr: true},
 	}
@@ -269,6 +459,7 @@ func TestServeWSLIP(t *testing.T) {
 			serveErr := make(chan error)
 			go func() {
 				serveErr <- d.Serve(ctx, daemon.WithWslNetworkingMode(tc.netmode), daemon.WithMockedGetAdapterAddresses(mock),
+					daemon.WithWslInfo(wslversion.Info{Version: tc.wslVersion}),
 					daemon.WithNetDevicesAPIProvider(
 						func() (netmonitoring.DevicesAPI, error) {
 							if tc.subscribeErr != nil {
@@ -285,6 +476,28 @@ func TestServeWSLIP(t *testing.T) {
 				return
 			}
 
+			// The published address must be one WSL can reach: the WSL adapter IPv4, or the loopback
+			// when it is unknown (mirrored networking or while waiting for the adapter to show up).
+			wantIP := mock.WSLAdapterIP()
+			mirrored := tc.netmode == "mirrored" && tc.wslVersion != "1.2.5.0"
+			if mirrored || tc.netmode == "unknown" || wantIP == nil {
+				wantIP = net.IPv4(127, 0, 0, 1)
+			}
+
+			addrPath := filepath.Join(addrDir, common.ListeningPortFileName)
+			daemontestutils.RequireWaitPathExists(t, addrPath, "Serve should have written the address file")
+			addr, err := os.ReadFile(addrPath)
+			require.NoError(t, err, "Address file should be readable")
+
+			host, port, err := net.SplitHostPort(string(addr))
+			require.NoError(t, err, "Address file should contain a host:port address")
+			require.NotEqual(t, "0", port, "Published port should be the one picked by the OS")
+			require.Equal(t, wantIP.String(), host, "Published address should be the WSL-reachable IPv4")
+
+			conn, err := net.DialTimeout("tcp", string(addr), 5*time.Second)
+			require.NoError(t, err, "Published address should be reachable")
+			conn.Close()
+
 			serverStopped := make(chan struct{})
 			go func() {
 				time.Sleep(500 * time.Millisecond)
@@ -293,7 +506,7 @@ func TestServeWSLIP(t *testing.T) {
 			}()
 			<-serverStopped
 
-			err := <-serveErr
+			err = <-serveErr
 			if err != nil && strings.Contains(err.Error(), grpc.ErrServerStopped.Error()) {
 				// We stopped the server manually, so we expect this error, although it's possible that there is not even an error at this point.
 				err = nil
@@ -319,9 +532,10 @@ func TestAddingWSLAdapterRestarts(t *testing.T) {
 	defer cancel()
 	addrDir := t.TempDir()
 
+	svc := &testGRPCService{}
 	registerer := func(context.Context, bool) *grpc.Server {
 		server := grpc.NewServer()
-		grpctestservice.RegisterTestServiceServer(server, testGRPCService{})
+		grpctestservice.RegisterTestServiceServer(server, svc)
 		return server
 	}
 
@@ -342,7 +556,7 @@ func TestAddingWSLAdapterRestarts(t *testing.T) {
 
 	addrPath := filepath.Join(addrDir, common.ListeningPortFileName)
 
-	daemontestutils.RequireWaitPathExists(t, addrPath, "Serve should create an address file")
+	requireReady(t, d)
 	addrSt, err := os.Stat(addrPath)
 	require.NoError(t, err, "Address file should be readable")
 
@@ -472,30 +686,86 @@ func TestWaitReady(t *testing.T) {
 	}
 }
 
+func TestReady(t *testing.T) {
+	t.Parallel()
+
+	registerer := func(context.Context, bool) *grpc.Server {
+		return grpc.NewServer()
+	}
+
+	testcases := map[string]struct {
+		skipServe bool
+
+		wantBlock bool
+	}{
+		"Success when serving": {},
+
+		"Block when not serving": {skipServe: true, wantBlock: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			addrDir := t.TempDir()
+
+			d := daemon.New(ctx, registerer, addrDir)
+			if !tc.skipServe {
+				serveErr := make(chan error, 1)
+				go func() { serveErr <- d.Serve(ctx) }()
+				defer func() {
+					d.Quit(ctx, false)
+					<-serveErr
+				}()
+			}
+
+			select {
+			case <-d.Ready():
+				require.False(t, tc.wantBlock, "Ready should not be closed as the daemon does not serve")
+				require.FileExists(t, filepath.Join(addrDir, common.ListeningPortFileName), "The address file should be written once the daemon is ready")
+			case <-time.After(time.Second):
+				require.True(t, tc.wantBlock, "Ready should have been closed as the daemon serves")
+			}
+		})
+	}
+}
+
+// requireReady waits for the daemon to accept connections.
+func requireReady(t *testing.T, d *daemon.Daemon) {
+	t.Helper()
+
+	select {
+	case <-d.Ready():
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Daemon should have been ready to accept connections")
+	}
+}
+
 // grpcPersistentCall will create a persistent GRPC connection to the server.
 // It will return immediately. drop() should be called to ends the connection from
 // the client side. It returns the GRPC error code if any.
-func grpcPersistentCall(t *testing.T, addr string) (drop func() codes.Code) {
+func grpcPersistentCall(t *testing.T, svc *testGRPCService, addr string, opts ...grpc.DialOption) (drop func() codes.Code) {
 	t.Helper()
 
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(addr, opts...)
 	require.NoErrorf(t, err, "Could not create a GRPC client.")
 
 	c := grpctestservice.NewTestServiceClient(conn)
 	ctx, cancel := context.WithCancel(context.Background())
 
-	started := make(chan struct{})
+	wantCalls := svc.calls.Load() + 1
 	errch := make(chan error)
 	go func() {
-		close(started)
 		_, err = c.Blocking(ctx, new(grpctestservice.Empty))
 		errch <- err
 		close(errch)
 	}()
 
-	<-started
-	// Wait for the call being initiated.
-	time.Sleep(100 * time.Millisecond)
+	// Wait for the call to reach the server.
+	require.Eventually(t, func() bool { return svc.calls.Load() >= wantCalls }, 5*time.Second, time.Millisecond, "The call should have reached the server")
 
 	return func() codes.Code {
 		// Give some slack for the client if we aborted the server.
@@ -528,12 +798,15 @@ func requireCannotDialGRPC(t *testing.T, addr string, msg string) {
 	require.Contains(t, validStates, conn.GetState(), "unexpected state after dialing. Expected any of %q but got %q", validStates, conn.GetState())
 }
 
-// Our mock GRPC service.
+// Our mock GRPC service. It counts its calls, so that tests know when they reached it.
 type testGRPCService struct {
 	grpctestservice.UnimplementedTestServiceServer
+
+	calls atomic.Int32
 }
 
-func (testGRPCService) Blocking(ctx context.Context, e *grpctestservice.Empty) (*grpctestservice.Empty, error) {
+func (s *testGRPCService) Blocking(ctx context.Context, e *grpctestservice.Empty) (*grpctestservice.Empty, error) {
+	s.calls.Add(1)
 	<-ctx.Done()
 	return &grpctestservice.Empty{}, nil
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"math"
 	"net"
+	"strings"
 	"unsafe"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common/testdetection"
@@ -30,6 +31,12 @@ const (
 
 	// MultipleHyperVAdaptersInList is a state that causes the GetAdaptersAddresses to return a list with multiple Hyper-V adapters, one of which is the WSL one.
 	MultipleHyperVAdaptersInList
+
+	// UnspecifiedIPWSLAdapterInList is a state that causes the GetAdaptersAddresses to return a list where the WSL adapter has the unspecified address 0.0.0.0.
+	UnspecifiedIPWSLAdapterInList
+
+	// IPv6OnlyWSLAdapterInList is a state that causes the GetAdaptersAddresses to return a list where the WSL adapter only has an IPv6 address.
+	IPv6OnlyWSLAdapterInList
 )
 
 // NewHostIPConfigMock initializes a mockIPConfig object with the state provided so it can be used instead of the real GetAdaptersAddresses Win32 API.
@@ -59,7 +66,7 @@ func NewHostIPConfigMock(state MockIPAdaptersState) MockIPConfig {
 	// prefer not to listen on public interfaces if possible.
 	localIP := getLocalPrivateIPv4()
 	if localIP == nil {
-		localIP = net.IPv4(0, 0, 0, 0)
+		localIP = net.IPv4(127, 0, 0, 1)
 	}
 
 	switch m.state {
@@ -88,11 +95,42 @@ func NewHostIPConfigMock(state MockIPAdaptersState) MockIPConfig {
 				ip:           localIP,
 			},
 		)
+	case UnspecifiedIPWSLAdapterInList:
+		m.addrs = append(
+			adaptersList,
+			MockIPAddrsTemplate{
+				friendlyName: "Ethernet adapter vEthernet (WSL)",
+				desc:         "Hyper-V Virtual Ethernet Adapter",
+				ip:           net.IPv4zero,
+			},
+		)
+	case IPv6OnlyWSLAdapterInList:
+		m.addrs = append(
+			adaptersList,
+			MockIPAddrsTemplate{
+				friendlyName: "Ethernet adapter vEthernet (WSL)",
+				desc:         "Hyper-V Virtual Ethernet Adapter",
+				ip:           net.ParseIP("fe80::1"),
+			},
+		)
 	}
 
 	return m
 }
 
+// WSLAdapterIP returns the IPv4 address of the WSL adapter in the mocked list, or nil if there is no usable one.
+func (m MockIPConfig) WSLAdapterIP() net.IP {
+	for _, a := range m.addrs {
+		if !strings.Contains(a.friendlyName, "vEthernet (WSL") {
+			continue
+		}
+		if ip := a.ip.To4(); ip != nil && !ip.IsUnspecified() {
+			return ip
+		}
+	}
+	return nil
+}
+
 // GetAdaptersAddresses is a mock implementation of the GetAdaptersAddresses Win32 API, based on the state of the mockIPConfig object.
 func (m *MockIPConfig) GetAdaptersAddresses(_, _ uint32, _ uintptr, adapterAddresses *IPAdapterAddresses, sizePointer *uint32) (errcode error) {
 	testdetection.MustBeTesting()
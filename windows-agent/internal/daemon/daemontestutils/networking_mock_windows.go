@@ -17,33 +17,40 @@ func fillFromTemplate(template *MockIPAddrsTemplate, a, next *IPAdapterAddresses
 	a.FriendlyName = windows.StringToUTF16Ptr(template.friendlyName)
 	a.Description = windows.StringToUTF16Ptr(template.desc)
 
-	ip := ipToRawSockaddrAny(template.ip)
+	ip, length := ipToRawSockaddrAny(template.ip)
 
 	a.FirstUnicastAddress = &windows.IpAdapterUnicastAddress{
 		Address: windows.SocketAddress{
 			Sockaddr:       ip,
-			SockaddrLength: int32(unsafe.Sizeof(windows.RawSockaddrInet4{})),
+			SockaddrLength: length,
 		},
 	}
 
 	a.Next = (*windows.IpAdapterAddresses)(next)
 }
 
-// ipToRawSockaddrAny is a helper function that converts an assumed valid net.IP (IPv4) to *syscall.RawSockaddrAny.
-func ipToRawSockaddrAny(ip net.IP) *syscall.RawSockaddrAny {
+// ipToRawSockaddrAny is a helper function that converts a net.IP to *syscall.RawSockaddrAny, alongside the length of the underlying sockaddr.
+// A nil IP produces an empty sockaddr.
+func ipToRawSockaddrAny(ip net.IP) (*syscall.RawSockaddrAny, int32) {
 	testdetection.MustBeTesting()
 
-	ip4 := ip.To4()
-	if ip4 == nil {
-		return nil
+	if ip4 := ip.To4(); ip4 != nil {
+		sa := new(syscall.RawSockaddrInet4)
+		sa.Family = syscall.AF_INET
+		copy(sa.Addr[:], ip4) // ip4 is already a 4-byte slice
+
+		//nolint:gosec // Unsafe is required to manipulate pointers at the Win32 API level, only used in tests.
+		return (*syscall.RawSockaddrAny)(unsafe.Pointer(sa)), int32(unsafe.Sizeof(*sa))
 	}
 
-	sa := new(syscall.RawSockaddrInet4)
-	sa.Family = syscall.AF_INET
-	copy(sa.Addr[:], ip4) // ip4 is already a 4-byte slice
+	sa := new(syscall.RawSockaddrInet6)
+	if ip16 := ip.To16(); ip16 != nil {
+		sa.Family = syscall.AF_INET6
+		copy(sa.Addr[:], ip16)
+	}
 
 	//nolint:gosec // Unsafe is required to manipulate pointers at the Win32 API level, only used in tests.
-	return (*syscall.RawSockaddrAny)(unsafe.Pointer(sa))
+	return (*syscall.RawSockaddrAny)(unsafe.Pointer(sa)), int32(unsafe.Sizeof(*sa))
 }
 
 // IPAdapterAddresses is a type alias for windows.IpAdapterAddresses.
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"context"
+	"net"
 	"os"
 	"path/filepath"
 	"testing"
@@ -127,3 +128,41 @@ func TestRestart(t *testing.T) {
 type testGRPCService struct {
 	grpctestservice.UnimplementedTestServiceServer
 }
+
+func TestPublishedAddress(t *testing.T) {
+	t.Parallel()
+
+	wslIP := net.IPv4(172, 22, 16, 1)
+
+	testCases := map[string]struct {
+		lis   net.Addr
+		wslIP net.IP
+
+		want    string
+		wantErr bool
+	}{
+		"Publishes the listener IPv4 as is":                 {lis: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5}, wslIP: wslIP, want: "127.0.0.1:5"},
+		"Publishes the WSL IP for an unspecified IPv4":      {lis: &net.TCPAddr{IP: net.IPv4zero, Port: 1234}, wslIP: wslIP, want: "172.22.16.1:1234"},
+		"Publishes the WSL IP for an unspecified IPv6":      {lis: &net.TCPAddr{IP: net.IPv6unspecified, Port: 1234}, wslIP: wslIP, want: "172.22.16.1:1234"},
+		"Publishes the WSL IP for an IPv6 listener address": {lis: &net.TCPAddr{IP: net.ParseIP("fe80::1"), Port: 1234}, wslIP: wslIP, want: "172.22.16.1:1234"},
+
+		"Error when the address is unspecified and there is no WSL IP":        {lis: &net.TCPAddr{IP: net.IPv4zero, Port: 1234}, wantErr: true},
+		"Error when the address is unspecified and the WSL IP is unspecified": {lis: &net.TCPAddr{IP: net.IPv4zero, Port: 1234}, wslIP: net.IPv4zero, wantErr: true},
+		"Error when the address is unspecified and the WSL IP is not an IPv4": {lis: &net.TCPAddr{IP: net.IPv4zero, Port: 1234}, wslIP: net.ParseIP("fe80::1"), wantErr: true},
+		"Error when the listener address is not a TCP one":                    {lis: &net.UnixAddr{Name: "socket", Net: "unix"}, wslIP: wslIP, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := publishedAddress(tc.lis, tc.wslIP)
+			if tc.wantErr {
+				require.Error(t, err, "publishedAddress should return an error")
+				return
+			}
+			require.NoError(t, err, "publishedAddress should return no error")
+			require.Equal(t, tc.want, got, "Mismatched published address")
+		})
+	}
+}
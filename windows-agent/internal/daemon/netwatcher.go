@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/daemon/netmonitoring"
 	"github.com/google/uuid"
@@ -55,6 +56,7 @@ func subscribe(ctx context.Context, callback NewAdapterCallback, opts options) (
 	}
 
 	go func() {
+		defer crashreport.Recover("daemon")
 		defer close(n.err)
 
 		err := n.start()
@@ -9,9 +9,11 @@ import (
 	"net"
 	"os/exec"
 	"reflect"
+	"strconv"
 	"strings"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslversion"
 	"github.com/ubuntu/decorate"
 )
 
@@ -21,8 +23,12 @@ type getAdaptersAddressesFunc func(family uint32, flags uint32, reserved uintptr
 func getWslIP(ctx context.Context, opts options) (ip net.IP, err error) {
 	defer decorate.OnError(&err, "could not determine WSL IP address: ")
 
-	mode, err := networkingMode(ctx, opts.wslCmd, opts.wslCmdEnv)
-	if err != nil {
+	var mode string
+	if opts.wslInfo.Known() && !opts.wslInfo.Supports(wslversion.MirroredNetworking) {
+		// There is no point in asking: wslinfo is not available and the network cannot be mirrored.
+		log.Infof(ctx, "WSL %s does not support mirrored networking (requires %s): assuming NAT", opts.wslInfo.Version, wslversion.MirroredNetworking.MinVersion())
+		mode = "nat"
+	} else if mode, err = networkingMode(ctx, opts.wslCmd, opts.wslCmdEnv); err != nil {
 		// NAT is assumed because it's the default networking mode for WSL as of 2024.
 		log.Warningf(ctx, "could not determine if WSL network is mirrored (assuming NAT): %v", err)
 		mode = "nat"
@@ -59,12 +65,39 @@ func findWslAdapterIP(opts options) (net.IP, error) {
 			continue
 		}
 
-		return node.ip(), nil
+		// WSL can only reach the agent over IPv4, and an unspecified address (0.0.0.0) is not reachable at all.
+		ip := node.ipv4()
+		if ip == nil || ip.IsUnspecified() {
+			continue
+		}
+
+		return ip, nil
 	}
 
 	return nil, fmt.Errorf("could not find WSL adapter")
 }
 
+// publishedAddress returns the address that clients in WSL must dial to reach the listener,
+// which is the WSL IP unless the listener is bound to a specific IPv4 address already.
+// Unspecified addresses (0.0.0.0 or [::]) are never published, as WSL cannot reach them.
+func publishedAddress(lis net.Addr, wslIP net.IP) (string, error) {
+	tcpAddr, ok := lis.(*net.TCPAddr)
+	if !ok {
+		return "", fmt.Errorf("unexpected listener address type %T", lis)
+	}
+
+	ip := tcpAddr.IP.To4()
+	if ip == nil || ip.IsUnspecified() {
+		ip = wslIP.To4()
+	}
+
+	if ip == nil || ip.IsUnspecified() {
+		return "", fmt.Errorf("could not find a reachable IPv4 address to publish for %s", lis)
+	}
+
+	return net.JoinHostPort(ip.String(), strconv.Itoa(tcpAddr.Port)), nil
+}
+
 // networkingMode detects whether the WSL network is mirrored or not.
 func networkingMode(ctx context.Context, wslCmd, cmdEnv []string) (string, error) {
 	// It does so by launching the system distribution (wsl --system).
@@ -26,8 +26,8 @@ func (a *ipAdapterAddresses) description() string {
 	return a.Description
 }
 
-func (a *ipAdapterAddresses) ip() net.IP {
-	return a.FirstUnicastAddress
+func (a *ipAdapterAddresses) ipv4() net.IP {
+	return a.FirstUnicastAddress.To4()
 }
 
 // getWindowsAdaptersAddresses is a fake wrapper that panics if invoked, which only exists to satisfy setting `defaultOptions` in networking.go with a Linux "implementation".
@@ -26,8 +26,14 @@ func (a *ipAdapterAddresses) description() string {
 	return windows.UTF16PtrToString(a.Description)
 }
 
-func (a *ipAdapterAddresses) ip() net.IP {
-	return a.FirstUnicastAddress.Address.IP()
+// ipv4 returns the first IPv4 unicast address of the adapter, or nil if it has none.
+func (a *ipAdapterAddresses) ipv4() net.IP {
+	for u := a.FirstUnicastAddress; u != nil; u = u.Next {
+		if ip := u.Address.IP().To4(); ip != nil {
+			return ip
+		}
+	}
+	return nil
 }
 
 // ERROR_BUFFER_OVERFLOW is defined as a constant here so we can redefine it in tests on Linux.
@@ -0,0 +1,142 @@
+// Package debversion compares Debian package versions, following the same rules as dpkg.
+package debversion
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a parsed Debian version: [epoch:]upstream[-revision].
+type version struct {
+	epoch    int
+	upstream string
+	revision string
+}
+
+func parse(v string) (ver version, err error) {
+	s := strings.TrimSpace(v)
+	if s == "" {
+		return ver, errors.New("empty version")
+	}
+
+	if e, rest, found := strings.Cut(s, ":"); found {
+		ver.epoch, err = strconv.Atoi(e)
+		if err != nil || ver.epoch < 0 {
+			return ver, fmt.Errorf("invalid epoch in version %q", v)
+		}
+		s = rest
+	}
+
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		ver.revision = s[i+1:]
+		s = s[:i]
+	}
+	ver.upstream = s
+
+	if ver.upstream == "" || !isDigit(ver.upstream[0]) {
+		return ver, fmt.Errorf("version %q does not start with a digit", v)
+	}
+
+	for _, c := range ver.upstream + ver.revision {
+		if !isDigit(byte(c)) && !isLetter(byte(c)) && !strings.ContainsRune(".+~-:", c) {
+			return ver, fmt.Errorf("invalid character %q in version %q", c, v)
+		}
+	}
+
+	return ver, nil
+}
+
+// Validate returns an error if v is not a well-formed Debian version.
+func Validate(v string) error {
+	_, err := parse(v)
+	return err
+}
+
+// Compare returns a negative number if a is older than b, a positive one if it is newer, and zero if
+// both are the same version.
+func Compare(a, b string) (int, error) {
+	va, err := parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if va.epoch != vb.epoch {
+		return va.epoch - vb.epoch, nil
+	}
+	if c := compareParts(va.upstream, vb.upstream); c != 0 {
+		return c, nil
+	}
+	return compareParts(va.revision, vb.revision), nil
+}
+
+// compareParts compares the upstream versions or revisions of two versions: alternating runs of
+// non-digits, compared character by character, and runs of digits, compared numerically.
+func compareParts(a, b string) int {
+	for a != "" || b != "" {
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			if c := order(a) - order(b); c != 0 {
+				return c
+			}
+			a, b = advance(a), advance(b)
+		}
+
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+
+		diff := 0
+		for a != "" && isDigit(a[0]) && b != "" && isDigit(b[0]) {
+			if diff == 0 {
+				diff = int(a[0]) - int(b[0])
+			}
+			a, b = a[1:], b[1:]
+		}
+
+		// The longest run of digits is the largest number.
+		if a != "" && isDigit(a[0]) {
+			return 1
+		}
+		if b != "" && isDigit(b[0]) {
+			return -1
+		}
+		if diff != 0 {
+			return diff
+		}
+	}
+	return 0
+}
+
+// order is the weight of the first character of s in a run of non-digits: the tilde sorts before
+// anything, even the end of the run, and letters sort before other characters.
+func order(s string) int {
+	if s == "" || isDigit(s[0]) {
+		return 0
+	}
+	switch c := s[0]; {
+	case c == '~':
+		return -1
+	case isLetter(c):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+func advance(s string) string {
+	if s == "" || isDigit(s[0]) {
+		return s
+	}
+	return s[1:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
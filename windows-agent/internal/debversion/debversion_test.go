@@ -0,0 +1,70 @@
+package debversion_test
+
+import (
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/debversion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		a, b string
+
+		want    int
+		wantErr bool
+	}{
+		"Equal versions":                        {a: "1.2.3", b: "1.2.3", want: 0},
+		"Equal versions with leading zeros":     {a: "1.02", b: "1.2", want: 0},
+		"Older patch version":                   {a: "1.2.3", b: "1.2.4", want: -1},
+		"Newer version with more digits":        {a: "1.10", b: "1.9", want: 1},
+		"Newer version with more components":    {a: "1.2.1", b: "1.2", want: 1},
+		"Tilde sorts before the release":        {a: "1.0~rc1", b: "1.0", want: -1},
+		"Letters sort before other characters":  {a: "1.0a", b: "1.0+", want: -1},
+		"Plus sorts after the release":          {a: "1.0+24.04", b: "1.0", want: 1},
+		"Epoch takes precedence":                {a: "1:0.1", b: "2.0", want: 1},
+		"Revision breaks ties":                  {a: "1.0-2", b: "1.0-10", want: -1},
+		"Hyphens in the upstream version":       {a: "1.0-rc-1", b: "1.0-rc-2", want: -1},
+		"Ubuntu-style version against a native": {a: "0.1.4~24.04", b: "0.1.4", want: -1},
+
+		"Error when the first version is empty":        {a: "", b: "1.0", wantErr: true},
+		"Error when the second version is a dev build": {a: "1.0", b: "Dev", wantErr: true},
+		"Error when the epoch is not a number":         {a: "a:1.0", b: "1.0", wantErr: true},
+		"Error when the version has invalid chars":     {a: "1.0_1", b: "1.0", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := debversion.Compare(tc.a, tc.b)
+			if tc.wantErr {
+				require.Error(t, err, "Compare should return an error")
+				require.Error(t, debversion.Validate(pick(tc.a, tc.b)), "Validate should reject the malformed version")
+				return
+			}
+			require.NoError(t, err, "Compare should return no error")
+
+			switch {
+			case tc.want < 0:
+				require.Negative(t, got, "%q should be older than %q", tc.a, tc.b)
+			case tc.want > 0:
+				require.Positive(t, got, "%q should be newer than %q", tc.a, tc.b)
+			default:
+				require.Zero(t, got, "%q should be the same version as %q", tc.a, tc.b)
+			}
+
+			require.NoError(t, debversion.Validate(tc.a), "Validate should accept the version")
+		})
+	}
+}
+
+// pick returns whichever of the versions is malformed.
+func pick(a, b string) string {
+	if debversion.Validate(a) != nil {
+		return a
+	}
+	return b
+}
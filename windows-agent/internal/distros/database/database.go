@@ -7,34 +7,51 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/google/uuid"
 	"github.com/ubuntu/decorate"
-	"gopkg.in/yaml.v3"
+	wsl "github.com/ubuntu/gowsl"
 )
 
-const (
-	timeBetweenGC = time.Hour
-)
+const timeBetweenGC = time.Hour
+
+// timeBetweenStateRefresh is how often the live state of the distros is refreshed from WSL.
+const timeBetweenStateRefresh = 10 * time.Second
 
 // DistroDB is a thread-safe single-table database of WSL distribution instances. This
-// database is held in memory and backed in disk. Any write on the database will be instanly
-// followed up by a write-to-disk.
+// database is held in memory and backed in storage, which is a file on disk unless the
+// database is created WithMemoryStorage. Any write on the database will be instanly
+// followed up by a write to storage.
 type DistroDB struct {
 	distros map[string]*distro.Distro
 	mu      sync.RWMutex
 
 	scheduleTrigger chan struct{}
 
+	// dumpRequests is signalled when the database must be dumped, but the caller cannot do it itself.
+	dumpRequests chan struct{}
+
+	// storageDir is where the task queues of the distros are stored. Empty if they are kept in memory.
 	storageDir string
+	storage    storage
+
+	// store is where the database and the task queues are kept if the database is created WithStore.
+	// It belongs to the caller of New.
+	store *store.Store
 
 	ctx       context.Context
 	cancelCtx func()
@@ -45,6 +62,80 @@ type DistroDB struct {
 	distroStartMu sync.Mutex
 
 	onCleanup []func(string)
+	onRename  []func(context.Context, *distro.Distro, string)
+
+	// discover is whether the Ubuntu distros registered in WSL are added to the database when it is reconciled,
+	// instead of only once their WSL Pro service connects.
+	discover bool
+
+	// distroAddedNotifier is called every time a new distro is added to the database.
+	distroAddedNotifier func(context.Context, *distro.Distro)
+
+	// watchers are signalled every time the distros in the database change. They have their own lock,
+	// as distros signal them with their own locks held.
+	watchers   map[chan struct{}]struct{}
+	watchersMu sync.Mutex
+
+	// hooks are called when the distros in the database or their properties change.
+	hooks eventHooks
+
+	// recordVersion is the version of the schema the records are written with. It is the latest one this version
+	// of the agent knows, unless a later version of the agent wrote a newer one, which is kept so that the
+	// migrations it went through are not run again.
+	recordVersion int
+}
+
+type options struct {
+	onCleanup []func(string)
+	onRename  []func(context.Context, *distro.Distro, string)
+	inMemory  bool
+	store     *store.Store
+	discover  bool
+}
+
+// Option is an optional argument for New.
+type Option func(*options)
+
+// WithCleanup adds a function to be called with the name of every distro removed from the database
+// during a cleanup.
+func WithCleanup(f func(distroName string)) Option {
+	return func(o *options) {
+		o.onCleanup = append(o.onCleanup, f)
+	}
+}
+
+// WithRename adds a function to be called every time a distro in the database is found to have been
+// renamed, with the renamed distro and its previous name. It is called once the database is unlocked.
+func WithRename(f func(ctx context.Context, d *distro.Distro, oldName string)) Option {
+	return func(o *options) {
+		o.onRename = append(o.onRename, f)
+	}
+}
+
+// WithDiscovery adds the Ubuntu distros registered in WSL to the database every time it is reconciled with WSL,
+// so that they are known before their WSL Pro service first connects, e.g. right after they are imported.
+func WithDiscovery() Option {
+	return func(o *options) {
+		o.discover = true
+	}
+}
+
+// WithMemoryStorage keeps the database and the task queues of its distros in memory only: nothing is
+// read from nor written to disk, and storageDir is ignored. The contents are lost when the database
+// is closed.
+func WithMemoryStorage() Option {
+	return func(o *options) {
+		o.inMemory = true
+	}
+}
+
+// WithStore keeps the database and the task queues of its distros in the embedded store s, so that they
+// are always consistent with each other, instead of in a file each inside storageDir. The files left behind
+// there by older versions are migrated into the store and removed. The store is not closed with the database.
+func WithStore(s *store.Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
 }
 
 // New creates a database and populates it with data in the file located
@@ -55,10 +146,15 @@ type DistroDB struct {
 // Creating multiple databases with the same disk backing will result in
 // undefined behaviour.
 //
-// Every certain amount of times, the database wil purge all distros that
-// are no longer registered or that have been marked as unreachable. This
-// cleanup can be triggered on demmand with TriggerCleanup.
-func New(ctx context.Context, storageDir string, onCleanup ...func(string)) (db *DistroDB, err error) {
+// Every certain amount of times, and every time the distros registered in WSL
+// change, the database is reconciled with WSL: it purges all distros that are
+// no longer registered or that have been marked as unreachable and, if created
+// WithDiscovery, adds the Ubuntu distros it did not know about. This
+// reconciliation can be triggered on demmand with TriggerCleanup.
+//
+// The live state of the distros (running, stopped, etc.) is refreshed from WSL
+// every few seconds as well.
+func New(ctx context.Context, storageDir string, args ...Option) (db *DistroDB, err error) {
 	defer decorate.OnError(&err, "could not initialize database")
 
 	select {
@@ -67,32 +163,72 @@ func New(ctx context.Context, storageDir string, onCleanup ...func(string)) (db
 	default:
 	}
 
+	var opts options
+	for _, f := range args {
+		f(&opts)
+	}
+
+	var st storage = fileStorage{dir: storageDir}
+	switch {
+	case opts.inMemory:
+		storageDir = ""
+		st = &memoryStorage{}
+	case opts.store != nil:
+		if err := migrateFiles(ctx, opts.store, storageDir); err != nil {
+			return nil, err
+		}
+		st = storeStorage{store: opts.store}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 
 	db = &DistroDB{
 		storageDir:      storageDir,
+		storage:         st,
+		store:           opts.store,
 		scheduleTrigger: make(chan struct{}),
+		dumpRequests:    make(chan struct{}, 1),
 		ctx:             ctx,
 		cancelCtx:       cancel,
-		onCleanup:       onCleanup,
+		onCleanup:       opts.onCleanup,
+		onRename:        opts.onRename,
+		discover:        opts.discover,
+		watchers:        make(map[chan struct{}]struct{}),
+		hooks:           eventHooks{wake: make(chan struct{}, 1)},
 	}
 
 	if err := db.load(ctx); err != nil {
 		return nil, err
 	}
 
+	go db.runHooks()
+	go db.refreshStates(ctx)
+	go db.dumpOnRequest(ctx)
+
+	registrations := watchRegistrations(ctx)
+
 	go func() {
+		defer crashreport.Recover("database")
 		for {
 			select {
 			case <-db.ctx.Done():
 				return
 			case <-time.After(timeBetweenGC):
 			case <-db.scheduleTrigger:
+			case <-registrations:
+				log.Debug(ctx, "Database: the registered distros changed")
 			}
 
 			if err := db.cleanup(ctx); err != nil {
 				log.Errorf(ctx, "Database: failed to clean up potentially unused distros: %v", err)
 			}
+
+			if !db.discover {
+				continue
+			}
+			if err := db.discoverDistros(ctx); err != nil {
+				log.Errorf(ctx, "Database: failed to discover new distros: %v", err)
+			}
 		}
 	}()
 
@@ -130,9 +266,38 @@ func (db *DistroDB) GetAll() (all []*distro.Distro) {
 	return all
 }
 
+// Filter returns the distros in the database whose properties match, sorted by name, so that callers need not
+// go through all of them, e.g. the unattached distros of a release with:
+//
+//	db.Filter(func(p distro.Properties) bool { return !p.ProAttached && p.VersionID == "22.04" })
+func (db *DistroDB) Filter(match func(distro.Properties) bool) (distros []*distro.Distro) {
+	if db.stopped() {
+		panic("Filter: database already stopped")
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, d := range db.distros {
+		if match(d.Properties()) {
+			distros = append(distros, d)
+		}
+	}
+
+	slices.SortFunc(distros, func(a, b *distro.Distro) int { return strings.Compare(a.Name(), b.Name()) })
+	return distros
+}
+
+// Lookup returns the distros in the database whose property has the value, sorted by name, e.g. the distros
+// attached to Ubuntu Pro with Lookup(ProAttached, true). The value must be of the type the property returns.
+func (db *DistroDB) Lookup(property Property, value any) []*distro.Distro {
+	return db.Filter(func(p distro.Properties) bool { return property(p) == value })
+}
+
 // GetDistroAndUpdateProperties fetches a distro from the database, guranteeing that the
 // returned distro is valid, is in the database, and matches the given properties. If needed:
 // * A pre-existing distro with the same name may be removed from the database.
+// * A pre-existing distro with the same GUID but a different name may be renamed.
 // * An existing distro in the database may have their properties updated.
 // * A new distro may be added to the database.
 func (db *DistroDB) GetDistroAndUpdateProperties(ctx context.Context, name string, props distro.Properties) (*distro.Distro, error) {
@@ -140,22 +305,43 @@ func (db *DistroDB) GetDistroAndUpdateProperties(ctx context.Context, name strin
 		panic("GetDistroAndUpdateProperties: database already stopped")
 	}
 
+	var afterUnlock []func()
+	defer func() {
+		for _, f := range afterUnlock {
+			f()
+		}
+	}()
+
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
 	normalizedName := strings.ToLower(name)
 	d, found := db.distros[normalizedName]
 
+	// Name not in database, GUID in database: the distro was renamed.
+	if !found {
+		if old := db.renamedFrom(name); old != nil {
+			d, notify, err := db.rename(ctx, old, name, props)
+			if err != nil {
+				return nil, err
+			}
+			afterUnlock = append(afterUnlock, notify)
+			return d, db.dump()
+		}
+	}
+
 	// Name not in database: create a new distro and returns it
 	if !found {
 		log.Debugf(ctx, "Database: cache miss, creating %q and adding it to the database", name)
 
-		d, err := distro.New(db.ctx, name, props, db.storageDir, &db.distroStartMu)
+		d, err := distro.New(db.ctx, name, props, db.storageDir, &db.distroStartMu, db.distroArgs()...)
 		if err != nil {
 			return nil, err
 		}
 		db.distros[normalizedName] = d
+		// The distro must have its record before the notifier submits any task to it.
 		err = db.dump()
+		db.notifyDistroAdded(ctx, d)
 		return d, err
 	}
 
@@ -167,13 +353,16 @@ func (db *DistroDB) GetDistroAndUpdateProperties(ctx context.Context, name strin
 
 		go d.Cleanup(ctx)
 		delete(db.distros, normalizedName)
+		db.notifyEvent(Event{Kind: DistroRemoved, Distro: d})
 
-		d, err := distro.New(db.ctx, name, props, db.storageDir, &db.distroStartMu)
+		d, err := distro.New(db.ctx, name, props, db.storageDir, &db.distroStartMu, db.distroArgs()...)
 		if err != nil {
 			return nil, err
 		}
 		db.distros[normalizedName] = d
+		// The distro must have its record before the notifier submits any task to it.
 		err = db.dump()
+		db.notifyDistroAdded(ctx, d)
 		return d, err
 	}
 
@@ -188,7 +377,63 @@ func (db *DistroDB) GetDistroAndUpdateProperties(ctx context.Context, name strin
 	return d, err
 }
 
-// Dump stores the current database state to disk, overriding old dumps.
+// SetDistroAddedNotifier sets a function to be called every time a new distro is added to the database,
+// including distros that replace one with the same name. Distros loaded from disk are not notified.
+// The notifier is called with the database locked, so it must not block nor use the database.
+func (db *DistroDB) SetDistroAddedNotifier(f func(context.Context, *distro.Distro)) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.distroAddedNotifier = f
+}
+
+// notifyDistroAdded calls the distro added notifier, if any. The caller must hold the lock.
+func (db *DistroDB) notifyDistroAdded(ctx context.Context, d *distro.Distro) {
+	if db.distroAddedNotifier != nil {
+		db.distroAddedNotifier(ctx, d)
+	}
+	db.notifyEvent(Event{Kind: DistroAdded, Distro: d})
+	db.notifyChange()
+}
+
+// Watch returns a channel that receives a value every time a distro is added to or removed from the
+// database, or the properties, the lifecycle stage or the live state of one of them change. Changes that happen before
+// the previous one is received are coalesced. The channel is closed when the context is cancelled.
+func (db *DistroDB) Watch(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	db.watchersMu.Lock()
+	db.watchers[ch] = struct{}{}
+	db.watchersMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		db.watchersMu.Lock()
+		defer db.watchersMu.Unlock()
+
+		delete(db.watchers, ch)
+		close(ch)
+	}()
+
+	return ch
+}
+
+// notifyChange signals every watcher without blocking.
+func (db *DistroDB) notifyChange() {
+	db.watchersMu.Lock()
+	defer db.watchersMu.Unlock()
+
+	for ch := range db.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// A change is pending already.
+		}
+	}
+}
+
+// Dump stores the current database state, overriding old dumps.
 // Next time we start the agent, the database will be loaded from this dump.
 func (db *DistroDB) Dump() error {
 	if db.stopped() {
@@ -201,8 +446,8 @@ func (db *DistroDB) Dump() error {
 	return db.dump()
 }
 
-// TriggerCleanup forces the database cleanup loop to skip its current delay and
-// call autoCleanup immediately. It is blocking until the cleanup starts.
+// TriggerCleanup forces the database reconciliation loop to skip its current delay and
+// reconcile the database with WSL immediately. It is blocking until the cleanup starts.
 func (db *DistroDB) TriggerCleanup() {
 	if db.stopped() {
 		panic("TriggerCleanup: database already stopped")
@@ -213,71 +458,477 @@ func (db *DistroDB) TriggerCleanup() {
 
 // cleanup removes any distro that no longer exists or has been reset from the database.
 func (db *DistroDB) cleanup(ctx context.Context) error {
+	var afterUnlock []func()
+	defer func() {
+		for _, f := range afterUnlock {
+			f()
+		}
+	}()
+
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	var registered map[string]string
+	var renamed []*distro.Distro
 	var needsDBDump bool
 	for name, d := range db.distros {
 		if d.IsValid() {
 			continue
 		}
 
-		log.Infof(ctx, "Database: distro %q became invalid, cleaning up.", d.Name())
-		for _, f := range db.onCleanup {
-			if f != nil {
-				f(name)
-			}
+		if registered == nil {
+			registered = registeredNames(db.ctx)
 		}
-		go d.Cleanup(ctx)
-		delete(db.distros, name)
+
+		// The distro is still registered under another name: it is followed once the distros that are
+		// gone are removed, as one of them may have had the new name.
+		if _, ok := registered[d.GUID()]; ok {
+			renamed = append(renamed, d)
+			continue
+		}
+
+		db.remove(ctx, name, d)
+		needsDBDump = true
+	}
+
+	for _, d := range renamed {
 		needsDBDump = true
+
+		_, notify, err := db.rename(ctx, d, registered[d.GUID()], d.Properties())
+		if err != nil {
+			log.Warningf(ctx, "Database: %v", err)
+			if name := strings.ToLower(d.Name()); db.distros[name] == d {
+				db.remove(ctx, name, d)
+			}
+			continue
+		}
+		afterUnlock = append(afterUnlock, notify)
 	}
+
+	// Storage that went unused for long is left for the retention policy to decide.
+	if _, err := db.cleanupTaskStorage(ctx, 0); err != nil {
+		log.Warningf(ctx, "Database: %v", err)
+	}
+
 	if needsDBDump {
 		return db.dump()
 	}
 	return nil
 }
 
-// load reads the database from disk.
-func (db *DistroDB) load(ctx context.Context) (err error) {
-	defer decorate.OnError(&err, "failed to load database from disk")
+// discoverDistros adds the registered Ubuntu distros that are not in the database yet. Their properties are
+// empty until their WSL Pro service connects and reports them.
+func (db *DistroDB) discoverDistros(ctx context.Context) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
 
-	// Read raw database from disk
-	out, err := os.ReadFile(filepath.Join(db.storageDir, consts.DatabaseFileName))
-	if errors.Is(err, fs.ErrNotExist) {
-		db.distros = make(map[string]*distro.Distro)
+	known := make(map[string]bool, len(db.distros))
+	for _, d := range db.distros {
+		known[d.GUID()] = true
+	}
+
+	var added []*distro.Distro
+	for guid, name := range registeredNames(db.ctx) {
+		if known[guid] || !isUbuntu(name) {
+			continue
+		}
+		if _, taken := db.distros[strings.ToLower(name)]; taken {
+			// The distro under that name is gone and is cleaned up with the next reconciliation.
+			continue
+		}
+
+		id, err := uuid.Parse(guid)
+		if err != nil {
+			continue
+		}
+
+		log.Infof(ctx, "Database: discovered distro %q", name)
+
+		d, err := distro.New(db.ctx, name, distro.Properties{}, db.storageDir, &db.distroStartMu, append(db.distroArgs(), distro.WithGUID(id))...)
+		if err != nil {
+			log.Warningf(ctx, "Database: could not add discovered distro: %v", err)
+			continue
+		}
+		db.distros[strings.ToLower(name)] = d
+		added = append(added, d)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	// The distros must have their record before the notifier submits any task to them.
+	err := db.dump()
+	for _, d := range added {
+		db.notifyDistroAdded(ctx, d)
+	}
+	return err
+}
+
+// refreshStates refreshes the live state of every distro in the database periodically, until the database is closed.
+func (db *DistroDB) refreshStates(ctx context.Context) {
+	defer crashreport.Recover("database")
+
+	for {
+		// Not using GetAll, which panics once the database is closed.
+		db.mu.RLock()
+		distros := slices.Collect(maps.Values(db.distros))
+		db.mu.RUnlock()
+
+		for _, d := range distros {
+			if d.RefreshState() {
+				log.Debugf(ctx, "Database: distro %q is now %s", d.Name(), d.LiveState())
+			}
+		}
+
+		select {
+		case <-db.ctx.Done():
+			return
+		case <-time.After(timeBetweenStateRefresh):
+		}
+	}
+}
+
+// requestDump asks for the database to be dumped without waiting for it, e.g. from the goroutine processing the
+// tasks of a distro, which the database may be waiting for with its lock held.
+func (db *DistroDB) requestDump() {
+	select {
+	case db.dumpRequests <- struct{}{}:
+	default:
+		// A dump is pending already.
+	}
+}
+
+// dumpOnRequest dumps the database every time it is requested with requestDump, until the database is closed.
+func (db *DistroDB) dumpOnRequest(ctx context.Context) {
+	defer crashreport.Recover("database")
+
+	for {
+		select {
+		case <-db.ctx.Done():
+			return
+		case <-db.dumpRequests:
+		}
+
+		db.mu.Lock()
+		// Close dumps the database itself once it is stopped.
+		if db.stopped() {
+			db.mu.Unlock()
+			return
+		}
+		err := db.dump()
+		db.mu.Unlock()
+
+		if err != nil {
+			log.Warningf(ctx, "Database: %v", err)
+		}
+	}
+}
+
+// isUbuntu returns whether a distro is an Ubuntu one judging by the name it is registered with, as the Ubuntu
+// applications name them, e.g. Ubuntu, Ubuntu-24.04 or Ubuntu-Preview. Its release is only known once its
+// WSL Pro service reports it.
+func isUbuntu(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), "ubuntu")
+}
+
+// remove stops and removes an invalid distro from the database. The caller must hold the database lock
+// and dump the database afterwards.
+func (db *DistroDB) remove(ctx context.Context, name string, d *distro.Distro) {
+	log.Infof(ctx, "Database: distro %q became invalid, cleaning up.", d.Name())
+	for _, f := range db.onCleanup {
+		if f != nil {
+			f(name)
+		}
+	}
+	go d.Cleanup(ctx)
+	delete(db.distros, name)
+	db.notifyEvent(Event{Kind: DistroRemoved, Distro: d})
+	db.notifyChange()
+}
+
+// renamedFrom returns the invalid distro in the database that is registered with the same GUID as the
+// named distro, or nil if there is none. The caller must hold the database lock.
+func (db *DistroDB) renamedFrom(name string) *distro.Distro {
+	registered := wsl.NewDistro(db.ctx, name)
+	guid, err := registered.GUID()
+	if err != nil {
 		return nil
 	}
+
+	for _, d := range db.distros {
+		if d.GUID() != guid.String() || strings.EqualFold(d.Name(), name) {
+			continue
+		}
+		if d.IsValid() {
+			// The old name is still registered with the same GUID: it is not a rename.
+			return nil
+		}
+		return d
+	}
+
+	return nil
+}
+
+// registeredNames returns the name of every registered distro, indexed by GUID. Names keep the case they
+// are registered with, so they must be lowered to look them up in the database.
+func registeredNames(ctx context.Context) map[string]string {
+	registered, err := wsl.RegisteredDistros(ctx)
 	if err != nil {
+		log.Warningf(ctx, "Database: could not list registered distros: %v", err)
+		return map[string]string{}
+	}
+
+	names := make(map[string]string, len(registered))
+	for _, d := range registered {
+		guid, err := d.GUID()
+		if err != nil {
+			continue
+		}
+		names[guid.String()] = d.Name()
+	}
+
+	return names
+}
+
+// rename replaces a distro whose name is no longer registered with one under its new name and the same
+// GUID, keeping its task storage. A distro already in the database under the new name is never replaced.
+// The caller must hold the database lock, dump the database afterwards, and call notify once the lock is
+// released so that the rename callbacks are called with the new distro.
+func (db *DistroDB) rename(ctx context.Context, old *distro.Distro, newName string, props distro.Properties) (d *distro.Distro, notify func(), err error) {
+	oldName := old.Name()
+	defer decorate.OnError(&err, "could not rename distro %q to %q", oldName, newName)
+
+	if _, taken := db.distros[strings.ToLower(newName)]; taken {
+		return nil, nil, errors.New("a distro with the new name is already in the database")
+	}
+
+	guid, err := uuid.Parse(old.GUID())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Infof(ctx, "Database: distro %q was renamed to %q", oldName, newName)
+
+	// The old worker must be stopped so that it does not write to its storage any more.
+	old.Cleanup(ctx)
+	delete(db.distros, strings.ToLower(oldName))
+
+	if db.store != nil {
+		// The queue moves along with the record, so that it is never left without one.
+		records := append(db.records(), serializableDistro{Name: newName, GUID: guid.String(), Properties: props, TaskHistory: old.TaskHistory()})
+		err = db.store.Update(func(tx *store.Tx) error {
+			if err := worker.RenameStoredTasks(tx, oldName, newName); err != nil {
+				return err
+			}
+			return saveRecords(tx, records)
+		})
+	} else {
+		err = worker.RenameStorage(db.storageDir, oldName, newName)
+	}
+	if err != nil {
+		log.Warningf(ctx, "Database: %v", err)
+	}
+
+	d, err = distro.New(db.ctx, newName, props, db.storageDir, &db.distroStartMu, append(db.distroArgs(), distro.WithGUID(guid), distro.WithTaskHistory(old.TaskHistory()))...)
+	if err != nil {
+		return nil, nil, err
+	}
+	db.distros[strings.ToLower(newName)] = d
+	db.notifyEvent(Event{Kind: DistroRenamed, Distro: d, OldName: oldName})
+	db.notifyChange()
+
+	notify = func() {
+		for _, f := range db.onRename {
+			if f != nil {
+				f(ctx, d, oldName)
+			}
+		}
+	}
+
+	return d, notify, nil
+}
+
+// Backup writes a copy of the database and the task queues of its distros to path. Databases kept in
+// memory have nothing to back up, so nothing is written.
+func (db *DistroDB) Backup(path string) (err error) {
+	defer decorate.OnError(&err, "could not back up database")
+
+	if db.stopped() {
+		return errors.New("database already stopped")
+	}
+
+	if db.store != nil {
+		return db.store.Backup(path)
+	}
+
+	if db.storageDir == "" {
+		return nil
+	}
+
+	// Holding the lock so that the file is not replaced while it is read.
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	data, err := os.ReadFile(filepath.Join(db.storageDir, consts.DatabaseFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
 		return err
 	}
 
-	// Parse database into intermediate objects
-	distros := make([]serializableDistro, 0)
-	err = yaml.Unmarshal(out, &distros)
+	return os.WriteFile(path, data, 0600)
+}
+
+// CleanupTaskStorage removes the task storage of distros that are no longer in the database,
+// as well as any that has not been used during the retention period. A non-positive retention
+// only removes the former. It returns the number of bytes reclaimed.
+func (db *DistroDB) CleanupTaskStorage(ctx context.Context, retention time.Duration) (reclaimed int64, err error) {
+	if db.stopped() {
+		return 0, errors.New("could not clean up task storage: database already stopped")
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.cleanupTaskStorage(ctx, retention)
+}
+
+// cleanupTaskStorage is the version of CleanupTaskStorage that does not lock.
+// The caller must hold the database lock.
+func (db *DistroDB) cleanupTaskStorage(ctx context.Context, retention time.Duration) (reclaimed int64, err error) {
+	if db.storageDir == "" {
+		// Task queues are kept in memory: there is nothing to clean up.
+		return 0, nil
+	}
+
+	isKnown := func(name string) bool {
+		_, ok := db.distros[strings.ToLower(name)]
+		return ok
+	}
+
+	if db.store != nil {
+		reclaimed, err = worker.CleanupStoredTasks(ctx, db.store, isKnown, retention)
+	} else {
+		reclaimed, err = worker.CleanupStorage(ctx, db.storageDir, isKnown, retention)
+	}
+	if reclaimed > 0 {
+		log.Infof(ctx, "Database: removed unused task storage, reclaiming %d bytes", reclaimed)
+	}
+
+	return reclaimed, err
+}
+
+// load reads the database from its storage.
+func (db *DistroDB) load(ctx context.Context) (err error) {
+	defer decorate.OnError(&err, "failed to load database")
+
+	distros, rejected, err := db.storage.load()
 	if err != nil {
-		return fmt.Errorf("could not unmarshal: %v", err)
+		return err
 	}
 
 	// Initializing distros into database
 	db.distros = make(map[string]*distro.Distro, len(distros))
+	db.recordVersion = recordVersion()
 	for _, inert := range distros {
-		d, err := inert.newDistro(ctx, db.storageDir, &db.distroStartMu)
-		if err != nil {
-			log.Warningf(ctx, "Database: read invalid distro from database: %#+v", inert)
+		db.recordVersion = max(db.recordVersion, inert.Version)
+
+		if err := inert.validate(); err != nil {
+			rejected = append(rejected, rejectDistro(inert, err))
+			continue
+		}
+
+		key := strings.ToLower(inert.Name)
+		if _, ok := db.distros[key]; ok {
+			rejected = append(rejected, rejectDistro(inert, fmt.Errorf("distro %q is already in the database", inert.Name)))
 			continue
 		}
-		db.distros[strings.ToLower(d.Name())] = d
+
+		d, err := inert.newDistro(ctx, db.storageDir, &db.distroStartMu, db.distroArgs()...)
+		var notValid *distro.NotValidError
+		if errors.As(err, &notValid) {
+			rejected = append(rejected, rejectDistro(inert, err))
+			continue
+		} else if err != nil {
+			log.Warningf(ctx, "Database: could not load distro %q: %v", inert.Name, err)
+			continue
+		}
+		db.distros[key] = d
+	}
+
+	if len(rejected) == 0 {
+		log.Infof(ctx, "Database: loaded %d distros", len(db.distros))
+		return nil
+	}
+
+	log.Warningf(ctx, "Database: loaded %d distros and rejected %d invalid records", len(db.distros), len(rejected))
+	quarantineRecords(ctx, db.storageDir, rejected)
+
+	// The rejected records are dropped from the storage, so that they are not rejected again on the next load.
+	if err := db.dump(); err != nil {
+		log.Warningf(ctx, "Database: could not drop the rejected records: %v", err)
 	}
 
 	return nil
 }
 
-// dump writes the database contents into the file inside db.storageDir.
+// distroArgs returns the options to create the distros of the database with.
+func (db *DistroDB) distroArgs() []distro.Option {
+	args := []distro.Option{
+		distro.WithOnChange(db.notifyChange),
+		distro.WithOnPropertiesChange(db.notifyPropertiesChange),
+		distro.WithOnTaskHistoryChange(db.requestDump),
+	}
+	if db.store != nil {
+		args = append(args, distro.WithStore(db.store, hasRecord))
+	}
+	return args
+}
+
+// ReadProperties reads the properties of every distro in the database stored in storageDir,
+// indexed by distro name. Unlike New, it does not validate the distros against WSL nor
+// write to disk, so it is safe to use while another process owns the database.
+// The database is read from the embedded store if there is one, and from the database file otherwise.
+func ReadProperties(storageDir string) (props map[string]distro.Properties, err error) {
+	defer decorate.OnError(&err, "failed to read database from disk")
+
+	var st storage = fileStorage{dir: storageDir}
+
+	// Another process may have the store open: a snapshot of it is read instead.
+	snapshot, err := store.Snapshot(filepath.Join(storageDir, consts.StoreFileName))
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		defer snapshot.Close()
+		st = storeStorage{store: snapshot}
+	}
+
+	distros, _, err := st.load()
+	if err != nil {
+		return nil, err
+	}
+
+	props = make(map[string]distro.Properties, len(distros))
+	for _, inert := range distros {
+		props[inert.Name] = inert.Properties
+	}
+
+	return props, nil
+}
+
+// dump writes the database contents into its storage.
 // The dump is deterministic, with distros always sorted alphabetically.
 func (db *DistroDB) dump() (err error) {
-	defer decorate.OnError(&err, "failed to dump database to disk")
+	defer decorate.OnError(&err, "failed to dump database")
+
+	return db.storage.save(db.records())
+}
 
+// records returns the intermediate easy-to-marshall objects of the distros in the database, sorted
+// alphabetically. The caller must hold the database lock.
+func (db *DistroDB) records() []serializableDistro {
 	// Sort distros case-independently.
 	normalizedNames := make([]string, 0, len(db.distros))
 	for n := range db.distros {
@@ -285,31 +936,14 @@ func (db *DistroDB) dump() (err error) {
 	}
 	sort.Strings(normalizedNames)
 
-	// Create intermediate easy-to-marshall objects
 	distros := make([]serializableDistro, 0, len(db.distros))
 	for _, n := range normalizedNames {
-		distros = append(distros, newSerializableDistro(db.distros[n]))
-	}
-
-	// Generate dump
-	out, err := yaml.Marshal(distros)
-	if err != nil {
-		return fmt.Errorf("could not marshal: %v", err)
-	}
-
-	// Write dump
-	storagePath := filepath.Join(db.storageDir, consts.DatabaseFileName)
-	err = os.WriteFile(storagePath+".new", out, 0600)
-	if err != nil {
-		return err
-	}
-
-	err = os.Rename(storagePath+".new", storagePath)
-	if err != nil {
-		return err
+		r := newSerializableDistro(db.distros[n])
+		r.Version = max(r.Version, db.recordVersion)
+		distros = append(distros, r)
 	}
 
-	return nil
+	return distros
 }
 
 func (db *DistroDB) stopped() bool {
@@ -322,7 +956,7 @@ func (db *DistroDB) stopped() bool {
 }
 
 // Close frees up resources allocated to database maintenance and
-// ensures the database contents are written to file.
+// ensures the database contents are written to storage.
 func (db *DistroDB) Close(ctx context.Context) {
 	db.once.Do(func() {
 		db.cancelCtx()
@@ -340,6 +974,30 @@ func (db *DistroDB) Close(ctx context.Context) {
 	})
 }
 
+// HandOff stops processing the tasks of all distros ahead of the agent being updated, giving those in progress the
+// grace period to finish. It returns the number of tasks left in the queues for the next version to resume.
+func (db *DistroDB) HandOff(ctx context.Context, grace time.Duration) (pending int) {
+	db.mu.RLock()
+	distros := slices.Collect(maps.Values(db.distros))
+	db.mu.RUnlock()
+
+	// The tasks in progress may need the database: it is not locked meanwhile.
+	var wg sync.WaitGroup
+	for _, d := range distros {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.HandOff(ctx, grace)
+		}()
+	}
+	wg.Wait()
+
+	for _, d := range distros {
+		pending += d.PendingTasks()
+	}
+	return pending
+}
+
 // cleanupAllDistros signals all distro task processing goroutines to stop
 // and blocks until all of them have done so.
 func (db *DistroDB) cleanupAllDistros(ctx context.Context) {
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"text/template"
@@ -18,6 +20,9 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
 	"github.com/stretchr/testify/require"
 	wsl "github.com/ubuntu/gowsl"
 	wslmock "github.com/ubuntu/gowsl/mock"
@@ -46,15 +51,20 @@ func TestNew(t *testing.T) {
 
 	testCases := map[string]struct {
 		dirState dbDirState
+		backups  []dbDirState
 
 		wantDistros []string
 		wantErr     bool
 	}{
-		"Success on no pre-exisiting database file": {dirState: emptyDbDir, wantDistros: []string{}},
-		"Success at loading distro from database":   {dirState: goodDbFile, wantDistros: []string{distro}},
+		"Success on no pre-exisiting database file":              {dirState: emptyDbDir, wantDistros: []string{}},
+		"Success at loading distro from database":                {dirState: goodDbFile, wantDistros: []string{distro}},
+		"Success ignoring the backups of a valid database file":  {dirState: goodDbFile, backups: []dbDirState{badDbFileContents}, wantDistros: []string{distro}},
+		"Success falling back to the newest valid backup":        {dirState: badDbFileContents, backups: []dbDirState{badDbFileContents, goodDbFile}, wantDistros: []string{distro}},
+		"Success falling back to a backup of an unreadable file": {dirState: badDbFile, backups: []dbDirState{goodDbFile}, wantDistros: []string{distro}},
 
 		"Error with syntax error in database file":             {dirState: badDbFileContents, wantErr: true},
 		"Error due to database file exists but cannot be read": {dirState: badDbFile, wantErr: true},
+		"Error when no backup is valid":                        {dirState: badDbFileContents, backups: []dbDirState{badDbFileContents, badDbFileContents}, wantErr: true},
 	}
 
 	for name, tc := range testCases {
@@ -73,6 +83,19 @@ func TestNew(t *testing.T) {
 				databaseFromTemplate(t, dbDir, distroID{distro, guid})
 			}
 
+			for i, state := range tc.backups {
+				contents := []byte("\tThis is not\nvalid yaml")
+				if state == goodDbFile {
+					tmpDir := t.TempDir()
+					databaseFromTemplate(t, tmpDir, distroID{distro, guid})
+					var err error
+					contents, err = os.ReadFile(filepath.Join(tmpDir, consts.DatabaseFileName))
+					require.NoError(t, err, "Setup: could not read database file generated from template")
+				}
+				backup := filepath.Join(dbDir, fmt.Sprintf("%s.bak.%d", consts.DatabaseFileName, i+1))
+				require.NoError(t, os.WriteFile(backup, contents, 0600), "Setup: could not write database backup")
+			}
+
 			db, err := database.New(ctx, dbDir)
 			if err == nil {
 				defer db.Close(ctx)
@@ -90,6 +113,141 @@ func TestNew(t *testing.T) {
 	}
 }
 
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestRejectRecords(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, guid := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		withStore bool
+
+		wantRejected int
+	}{
+		"Success rejecting the invalid records of the database file": {wantRejected: 4},
+		// The store keeps a single record per name, so the duplicate replaces the first record when migrated.
+		"Success rejecting the invalid records of the store": {withStore: true, wantRejected: 3},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dbDir := t.TempDir()
+			databaseFromTemplate(t, dbDir, distroID{distroName, guid})
+			rejectedFile := filepath.Join(dbDir, consts.DatabaseFileName+".rejected")
+
+			var args []database.Option
+			if tc.withStore {
+				args = append(args, database.WithStore(openStore(t, dbDir)))
+			}
+
+			db, err := database.New(ctx, dbDir, args...)
+			require.NoError(t, err, "New() should not fail because of invalid records")
+			require.ElementsMatch(t, []string{distroName}, db.DistroNames(), "Only the valid records should have been loaded")
+			db.Close(ctx)
+
+			rejected := readRejectedRecords(t, rejectedFile)
+			require.Len(t, rejected, tc.wantRejected, "The invalid records should have been quarantined")
+			for _, r := range rejected {
+				require.NotEmpty(t, r["reason"], "Rejected records should say why they were rejected")
+				require.NotEmpty(t, r["record"], "Rejected records should keep their contents")
+			}
+
+			// The rejected records must have been dropped, so that they are not rejected again.
+			db, err = database.New(ctx, dbDir, args...)
+			require.NoError(t, err, "New() should return no error when loading the database again")
+			defer db.Close(ctx)
+
+			require.ElementsMatch(t, []string{distroName}, db.DistroNames(), "The valid records should have been kept")
+			require.Len(t, readRejectedRecords(t, rejectedFile), tc.wantRejected, "No record should have been rejected again")
+		})
+	}
+}
+
+// readRejectedRecords returns every record quarantined into the file of rejected records at path.
+func readRejectedRecords(t *testing.T, path string) (rejected []map[string]any) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err, "Could not open the file of rejected records")
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	for {
+		var doc []map[string]any
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return rejected
+		}
+		require.NoError(t, err, "Could not parse the file of rejected records")
+		rejected = append(rejected, doc...)
+	}
+}
+
+func TestReadProperties(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		dirState dbDirState
+
+		want    map[string]distro.Properties
+		wantErr bool
+	}{
+		"Success on no pre-exisiting database file": {dirState: emptyDbDir, want: map[string]distro.Properties{}},
+		"Success reading properties from database": {dirState: goodDbFile, want: map[string]distro.Properties{
+			"PatchedDistro": {
+				DistroID:    "Ubuntu",
+				VersionID:   "22.04",
+				PrettyName:  "Ubuntu 22.04 LTS (Jammy Jellyfish)",
+				ProAttached: true,
+				Hostname:    "PatchedMachine",
+				Security:    distro.SecurityStatus{Known: true},
+			},
+			"UnknownDistro": {
+				DistroID:   "Ubuntu",
+				VersionID:  "24.04",
+				PrettyName: "Ubuntu 24.04 LTS (Noble Numbat)",
+				Hostname:   "UnknownMachine",
+			},
+		}},
+
+		"Error with syntax error in database file":             {dirState: badDbFileContents, wantErr: true},
+		"Error due to database file exists but cannot be read": {dirState: badDbFile, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dbDir := t.TempDir()
+			switch tc.dirState {
+			case badDbFile:
+				err := os.MkdirAll(filepath.Join(dbDir, consts.DatabaseFileName), 0600)
+				require.NoError(t, err, "Setup: could not create folder where database file is supposed to go")
+			case badDbFileContents:
+				err := os.WriteFile(filepath.Join(dbDir, consts.DatabaseFileName), []byte("\tThis is not\nvalid yaml"), 0600)
+				require.NoError(t, err, "Setup: could not write wrong database file")
+			case goodDbFile:
+				databaseFromTemplate(t, dbDir)
+			}
+
+			got, err := database.ReadProperties(dbDir)
+			if tc.wantErr {
+				require.Error(t, err, "ReadProperties() should have returned an error")
+				return
+			}
+			require.NoError(t, err, "ReadProperties() should have returned no error")
+
+			require.Equal(t, tc.want, got, "ReadProperties() returned unexpected properties")
+		})
+	}
+}
+
 //nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
 func TestDatabaseGetAll(t *testing.T) {
 	ctx := context.Background()
@@ -209,6 +367,78 @@ func TestDatabaseGetAfterClose(t *testing.T) {
 	db.Close(ctx)
 
 	require.Panics(t, func() { db.Get(wsltestutils.RandomDistroName(t)) }, "Database Get should panic when used after Close.")
+	require.Panics(t, func() { db.Filter(func(distro.Properties) bool { return true }) }, "Database Filter should panic when used after Close.")
+}
+
+func TestDatabaseFilter(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	db, err := database.New(ctx, "", database.WithMemoryStorage())
+	require.NoError(t, err, "Setup: New() should return no error")
+
+	// Must use Cleanup. If we use defer, it'll run before the subtests are launched.
+	t.Cleanup(func() { db.Close(ctx) })
+
+	jammy, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	jammyAttached, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	noble, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	for name, props := range map[string]distro.Properties{
+		jammy:         {DistroID: "ubuntu", VersionID: "22.04"},
+		jammyAttached: {DistroID: "ubuntu", VersionID: "22.04", ProAttached: true},
+		noble:         {DistroID: "ubuntu", VersionID: "24.04", ProAttached: true},
+	} {
+		_, err := db.GetDistroAndUpdateProperties(ctx, name, props)
+		require.NoError(t, err, "Setup: could not add %q to database", name)
+	}
+
+	testCases := map[string]struct {
+		filter func() []*distro.Distro
+
+		want []string
+	}{
+		"Success filtering by several properties": {
+			filter: func() []*distro.Distro {
+				return db.Filter(func(p distro.Properties) bool { return !p.ProAttached && p.VersionID == "22.04" })
+			},
+			want: []string{jammy},
+		},
+		"Success looking up by distro ID": {
+			filter: func() []*distro.Distro { return db.Lookup(database.DistroID, "ubuntu") },
+			want:   []string{jammy, jammyAttached, noble},
+		},
+		"Success looking up by version ID": {
+			filter: func() []*distro.Distro { return db.Lookup(database.VersionID, "22.04") },
+			want:   []string{jammy, jammyAttached},
+		},
+		"Success looking up by Pro attachment": {
+			filter: func() []*distro.Distro { return db.Lookup(database.ProAttached, true) },
+			want:   []string{jammyAttached, noble},
+		},
+
+		"No distro when none matches":                   {filter: func() []*distro.Distro { return db.Lookup(database.VersionID, "20.04") }},
+		"No distro when the value has another type":     {filter: func() []*distro.Distro { return db.Lookup(database.ProAttached, "true") }},
+		"No distro when the filter matches no property": {filter: func() []*distro.Distro { return db.Filter(func(distro.Properties) bool { return false }) }},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, d := range tc.filter() {
+				got = append(got, d.Name())
+			}
+
+			want := slices.Clone(tc.want)
+			slices.Sort(want)
+			require.Equal(t, want, got, "The distros should be those that match, sorted by name")
+		})
+	}
 }
 
 //nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
@@ -310,6 +540,174 @@ func TestDatabaseDump(t *testing.T) {
 	}
 }
 
+func TestDatabaseDumpRotatesBackups(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	dbDir := t.TempDir()
+	dbFile := filepath.Join(dbDir, consts.DatabaseFileName)
+
+	db, err := database.New(ctx, dbDir)
+	require.NoError(t, err, "Setup: empty database should be created without issue")
+	defer db.Close(ctx)
+
+	backup := func(n int) string {
+		return fmt.Sprintf("%s.bak.%d", dbFile, n)
+	}
+
+	require.NoError(t, db.Dump(), "Dump should return no error")
+	require.NoFileExists(t, backup(1), "There should be no backup of a database file that did not exist")
+
+	for i := range 5 {
+		// Marking the current database file so that its backup can be told apart from the others.
+		require.NoError(t, os.WriteFile(dbFile, []byte(fmt.Sprintf("# version %d\n[]\n", i)), 0600), "Setup: could not mark database file")
+		require.NoError(t, db.Dump(), "Dump should return no error")
+	}
+
+	for n, version := range []int{4, 3, 2} {
+		got, err := os.ReadFile(backup(n + 1))
+		require.NoError(t, err, "Backup %d should exist", n+1)
+		require.Equal(t, fmt.Sprintf("# version %d\n[]\n", version), string(got), "Backup %d should be the database file from %d dumps ago", n+1, n+1)
+	}
+	require.NoFileExists(t, backup(4), "Only the newest backups should be kept")
+	require.NoFileExists(t, dbFile+".new", "The temporary database file should have replaced the database file")
+
+	dump, err := os.ReadFile(dbFile)
+	require.NoError(t, err, "The database dump should be readable after calling Dump()")
+	require.Empty(t, newStructuredDump(t, dump).data, "Database dump should contain no distros")
+}
+
+func TestMemoryStorage(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	// A database file that cannot be parsed proves that the storage directory is not read.
+	dbDir := t.TempDir()
+	dbFile := filepath.Join(dbDir, consts.DatabaseFileName)
+	err := os.WriteFile(dbFile, []byte("\tThis is not\nvalid yaml"), 0600)
+	require.NoError(t, err, "Setup: could not write wrong database file")
+
+	db, err := database.New(ctx, dbDir, database.WithMemoryStorage())
+	require.NoError(t, err, "New() should ignore the storage directory when the database is in memory")
+	defer db.Close(ctx)
+
+	require.Empty(t, db.DistroNames(), "A new in-memory database should be empty")
+
+	_, err = db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{Hostname: "testMachine"})
+	require.NoError(t, err, "GetDistroAndUpdateProperties should return no error")
+	require.ElementsMatch(t, []string{distroName}, db.DistroNames(), "The distro should have been added to the database")
+
+	err = db.Dump()
+	require.NoError(t, err, "Dump() should return no error when the database is in memory")
+
+	d, ok := db.Get(distroName)
+	require.True(t, ok, "The distro should still be in the database after a dump")
+	require.Equal(t, "testMachine", d.Properties().Hostname, "The distro properties should have been kept")
+
+	db.Close(ctx)
+
+	entries, err := os.ReadDir(dbDir)
+	require.NoError(t, err, "Could not read the storage directory")
+	require.Len(t, entries, 1, "Nothing should have been written to the storage directory")
+
+	out, err := os.ReadFile(dbFile)
+	require.NoError(t, err, "Could not read the database file")
+	require.Equal(t, "\tThis is not\nvalid yaml", string(out), "The database file should not have been modified")
+}
+
+func TestStoreStorage(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, guid := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		badDbFile bool
+	}{
+		"Success migrating the files of older versions":        {},
+		"Success quarantining a database file that is corrupt": {badDbFile: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dbDir := t.TempDir()
+			dbFile := filepath.Join(dbDir, consts.DatabaseFileName)
+			databaseFromTemplate(t, dbDir, distroID{distroName, guid})
+			if tc.badDbFile {
+				err := os.WriteFile(dbFile, []byte("\tThis is not\nvalid yaml"), 0600)
+				require.NoError(t, err, "Setup: could not write wrong database file")
+			}
+
+			taskFiles := []string{
+				filepath.Join(dbDir, distroName+".tasks"),
+				filepath.Join(dbDir, "orphan.tasks"),
+			}
+			for _, path := range taskFiles {
+				err := os.WriteFile(path, []byte("[]"), 0600)
+				require.NoError(t, err, "Setup: could not write task file")
+			}
+
+			st := openStore(t, dbDir)
+			db, err := database.New(ctx, dbDir, database.WithStore(st))
+			require.NoError(t, err, "New() should return no error")
+			defer db.Close(ctx)
+
+			for _, path := range taskFiles {
+				require.NoFileExists(t, path, "Task files should have been renamed after being migrated")
+				require.FileExists(t, path+".migrated", "Task files should have been kept after being migrated")
+			}
+
+			if tc.badDbFile {
+				require.FileExists(t, dbFile+".corrupt", "The corrupt database file should have been quarantined")
+				require.Empty(t, db.DistroNames(), "No distro should be loaded from a corrupt database file")
+				return
+			}
+			require.NoFileExists(t, dbFile, "The database file should have been renamed after being migrated")
+			require.FileExists(t, dbFile+".migrated", "The database file should have been kept after being migrated")
+			require.ElementsMatch(t, []string{distroName}, db.DistroNames(), "The distros in the database file should have been migrated")
+
+			_, err = db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{Hostname: "testMachine"})
+			require.NoError(t, err, "GetDistroAndUpdateProperties should return no error")
+
+			// The store is open, so the properties must be read from the copy it publishes.
+			require.Eventually(t, func() bool {
+				props, err := database.ReadProperties(dbDir)
+				return err == nil && props[distroName].Hostname == "testMachine"
+			}, 5*time.Second, 100*time.Millisecond, "ReadProperties should read the properties in the store while it is open")
+
+			reclaimed, err := db.CleanupTaskStorage(ctx, 0)
+			require.NoError(t, err, "CleanupTaskStorage should return no error")
+			require.Zero(t, reclaimed, "The tasks of the distro not in the database should have been dropped when migrating")
+
+			db.Close(ctx)
+			require.NoError(t, st.Close(), "Could not close the store")
+
+			st = openStore(t, dbDir)
+			db, err = database.New(ctx, dbDir, database.WithStore(st))
+			require.NoError(t, err, "New() should return no error when reopening the store")
+			defer db.Close(ctx)
+
+			d, ok := db.Get(distroName)
+			require.True(t, ok, "The distro should still be in the database after reopening the store")
+			require.Equal(t, "testMachine", d.Properties().Hostname, "The distro properties should have been kept in the store")
+			require.NoFileExists(t, dbFile, "No database file should be written when using the store")
+		})
+	}
+}
+
 func TestGetDistroAndUpdateProperties(t *testing.T) {
 	ctx := context.Background()
 	if wsl.MockAvailable() {
@@ -401,6 +799,9 @@ func TestGetDistroAndUpdateProperties(t *testing.T) {
 			require.NoError(t, err, "Setup: New() should return no error")
 			defer db.Close(ctx)
 
+			var added []string
+			db.SetDistroAddedNotifier(func(_ context.Context, d *distro.Distro) { added = append(added, d.Name()) })
+
 			if tc.distroName == reRegisteredDistro {
 				guids[reRegisteredDistro] = wsltestutils.ReregisterDistro(t, ctx, reRegisteredDistro, false)
 			}
@@ -430,6 +831,12 @@ func TestGetDistroAndUpdateProperties(t *testing.T) {
 			require.Equal(t, guids[tc.distroName], d.GUID(), "GetDistroAndUpdateProperties should return a GUID that matches the requested distro's")
 			require.Equal(t, tc.props, d.Properties(), "GetDistroAndUpdateProperties should return the same properties as requested")
 
+			if tc.want == missedAndAdded || tc.want == hitUnregisteredDistro {
+				require.Equal(t, []string{tc.distroName}, added, "GetDistroAndUpdateProperties should notify that the distro was added")
+			} else {
+				require.Empty(t, added, "GetDistroAndUpdateProperties should not notify about distros already in the database")
+			}
+
 			// Ensure writing one distro does not modify another
 			if tc.distroName != distroInDB {
 				d, ok := db.Get(distroInDB)
@@ -501,6 +908,10 @@ func TestDatabaseCleanup(t *testing.T) {
 
 			databaseFromTemplate(t, dbDir, distros...)
 
+			orphanTasks := filepath.Join(dbDir, "orphan.tasks")
+			err := os.WriteFile(orphanTasks, []byte("[]"), 0600)
+			require.NoError(t, err, "Setup: could not write task storage of a distro not in the database")
+
 			var cleanupCalled atomic.Bool
 			var cleanupFunc func(string)
 			if tc.cleanupFunc {
@@ -509,7 +920,7 @@ func TestDatabaseCleanup(t *testing.T) {
 				}
 			}
 
-			db, err := database.New(ctx, dbDir, cleanupFunc)
+			db, err := database.New(ctx, dbDir, database.WithCleanup(cleanupFunc))
 			require.NoError(t, err, "Setup: New() should have returned no error")
 			defer db.Close(ctx)
 
@@ -548,6 +959,7 @@ func TestDatabaseCleanup(t *testing.T) {
 			}
 
 			require.Equal(t, tc.wantCleanup, cleanupCalled.Load(), "Cleanup callback state mismatch")
+			require.NoFileExists(t, orphanTasks, "Task storage of distros not in the database should have been removed")
 
 			require.ElementsMatch(t, tc.wantDistros, db.DistroNames(), "Database contents after cleanup do not match expectations")
 
@@ -558,71 +970,969 @@ func TestDatabaseCleanup(t *testing.T) {
 	}
 }
 
-// fileModTime returns the ModTime of the provided path. If the path
-// does not exist, the time is reported as Unix 0.
-func fileModTime(t *testing.T, path string) time.Time {
-	t.Helper()
+func TestDistroRename(t *testing.T) {
+	if !wsl.MockAvailable() {
+		t.Skip("This test can only run with the mock")
+	}
+	t.Parallel()
 
-	info, err := os.Stat(path)
-	if errors.Is(err, fs.ErrNotExist) {
-		return time.Unix(0, 0)
+	testCases := map[string]struct {
+		detectOnCleanup bool
+		newNameTaken    bool
+	}{
+		"Rename is detected when the distro connects": {},
+		"Rename is detected during cleanup":           {detectOnCleanup: true},
+
+		"Rename replaces a distro that is gone under the new name": {detectOnCleanup: true, newNameTaken: true},
 	}
 
-	require.NoError(t, err, "Could not Stat file %q", path)
-	return info.ModTime()
-}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-// distroID is a convenience struct to package a distro's identifying data.
-// Used to deanonymize fixtures.
-type distroID struct {
-	Name string
-	GUID string
-}
+			m := wslmock.New()
+			ctx := wsl.WithMock(context.Background(), m)
 
-// databaseFromTemplate creates a yaml database file in the specified directory.
-// The template must be in {TestFamilyPath}/database_template.yaml and it'll be
-// instantiated in {dest}/database.yaml.
-func databaseFromTemplate(t *testing.T, dest string, distros ...distroID) {
-	t.Helper()
+			oldName, guid := wsltestutils.RegisterDistro(t, ctx, false)
+			newName := wsltestutils.RandomDistroName(t)
 
-	in, err := os.ReadFile(filepath.Join(testutils.TestFamilyPath(t), "database_template.yaml"))
-	require.NoError(t, err, "Setup: could not read database template")
+			dbDir := t.TempDir()
+			databaseFromTemplate(t, dbDir, distroID{oldName, guid})
+
+			// The distro's worker creates its storage lazily, so we write it ourselves.
+			oldTasks := filepath.Join(dbDir, oldName+".tasks")
+			err := os.WriteFile(oldTasks, []byte("[]"), 0600)
+			require.NoError(t, err, "Setup: could not write task storage")
+
+			renamedFrom := make(chan string, 1)
+			var db *database.DistroDB
+			db, err = database.New(ctx, dbDir, database.WithRename(func(_ context.Context, d *distro.Distro, oldName string) {
+				require.Equal(t, guid, d.GUID(), "Rename callback should receive the renamed distro")
+				// The database is no longer locked, so the callback can use it.
+				_, ok := db.Get(d.Name())
+				require.True(t, ok, "Rename callback should find the renamed distro in the database")
+				renamedFrom <- oldName
+			}))
+			require.NoError(t, err, "Setup: New() should have returned no error")
+			defer db.Close(ctx)
 
-	tmpl := template.Must(template.New(t.Name()).Parse(string(in)))
+			if tc.newNameTaken {
+				// A distro that is gone had the new name, with a different case.
+				gone, _ := wsltestutils.RegisterDistro(t, ctx, false)
+				_, err := db.GetDistroAndUpdateProperties(ctx, gone, distro.Properties{})
+				require.NoError(t, err, "Setup: could not add %q to the database", gone)
 
-	f, err := os.Create(filepath.Join(dest, consts.DatabaseFileName))
-	require.NoError(t, err, "Setup: could not create database file")
-	defer f.Close()
+				goneDistro := wsl.NewDistro(ctx, gone)
+				err = goneDistro.Unregister()
+				require.NoError(t, err, "Setup: could not unregister %q", gone)
+				newName = strings.ToUpper(gone)
+			}
 
-	err = tmpl.Execute(f, distros)
-	require.NoError(t, err, "Setup: could not execute template database file")
-}
+			renameMockDistro(t, m, guid, newName)
 
-// structuredDump is a convenience struct used to parse the database dump and make
-// assertions on it with better accuracy that just a strings.Contains.
-type structuredDump struct {
-	data []database.SerializableDistro
-}
+			var d *distro.Distro
+			if tc.detectOnCleanup {
+				db.TriggerCleanup()
+				require.Eventually(t, func() bool {
+					var ok bool
+					d, ok = db.Get(newName)
+					return ok && d.GUID() == guid
+				}, 5*time.Second, 100*time.Millisecond, "Renamed distro should have been found under its new name after a cleanup")
+			} else {
+				d, err = db.GetDistroAndUpdateProperties(ctx, newName, distro.Properties{Hostname: "RenamedMachine"})
+				require.NoError(t, err, "GetDistroAndUpdateProperties should return no error")
+				require.Equal(t, newName, d.Name(), "Renamed distro should have the new name")
+				require.Equal(t, "RenamedMachine", d.Properties().Hostname, "Renamed distro should have the latest properties")
+			}
 
-// newStructuredDump takes a database dump and parses it to generate a structuredDump.
-func newStructuredDump(t *testing.T, rawDump []byte) structuredDump {
-	t.Helper()
+			require.True(t, strings.EqualFold(newName, d.Name()), "Renamed distro should have the new name")
+			require.Equal(t, guid, d.GUID(), "Renamed distro should keep its GUID")
 
-	var data []database.SerializableDistro
+			_, ok := db.Get(oldName)
+			require.False(t, ok, "The old name of the distro should no longer be in the database")
+			require.Len(t, db.GetAll(), 1, "The renamed distro should not be duplicated")
 
-	err := yaml.Unmarshal(rawDump, &data)
-	require.NoError(t, err, "In an attempt to parse a database dump: Unmarshal failed for dump:\n%s", rawDump)
+			select {
+			case got := <-renamedFrom:
+				require.Equal(t, oldName, got, "Rename callback should receive the old name")
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "Rename callback should have been called")
+			}
 
-	return structuredDump{data: data}
+			require.NoFileExists(t, oldTasks, "Task storage should no longer belong to the old name")
+			require.FileExists(t, filepath.Join(dbDir, d.Name()+".tasks"), "Task storage should have moved to the new name")
+
+			require.Eventually(t, func() bool {
+				out, err := os.ReadFile(filepath.Join(dbDir, consts.DatabaseFileName))
+				return err == nil && strings.Contains(strings.ToLower(string(out)), strings.ToLower(newName))
+			}, 5*time.Second, 100*time.Millisecond, "Database dump should contain the new name")
+		})
+	}
 }
 
-// anonymise takes a structured dump and removes all dynamically-generated information,
-// leaving behind only information that is invariant across test runs.
-func (sd *structuredDump) anonymise(t *testing.T) {
-	t.Helper()
+func TestDistroDiscovery(t *testing.T) {
+	if !wsl.MockAvailable() {
+		t.Skip("This test can only run with the mock")
+	}
+	t.Parallel()
+
+	testCases := map[string]struct {
+		noDiscovery  bool
+		alreadyKnown bool
+
+		wantDiscovered bool
+	}{
+		"Success discovering a registered Ubuntu distro":   {wantDiscovered: true},
+		"Success keeping the properties of a known distro": {alreadyKnown: true},
+
+		"Success ignoring registered distros without discovery": {noDiscovery: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := wsl.WithMock(context.Background(), wslmock.New())
+
+			ubuntu, guid := registerMockUbuntuDistro(t, ctx)
+			other, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+			var opts []database.Option
+			if !tc.noDiscovery {
+				opts = append(opts, database.WithDiscovery())
+			}
+
+			db, err := database.New(ctx, t.TempDir(), opts...)
+			require.NoError(t, err, "Setup: New() should have returned no error")
+			defer db.Close(ctx)
+
+			if tc.alreadyKnown {
+				_, err := db.GetDistroAndUpdateProperties(ctx, ubuntu, distro.Properties{Hostname: "KnownMachine"})
+				require.NoError(t, err, "Setup: could not add %q to the database", ubuntu)
+			}
+
+			var added atomic.Int32
+			db.SetDistroAddedNotifier(func(context.Context, *distro.Distro) { added.Add(1) })
+
+			db.TriggerCleanup()
+
+			if tc.wantDiscovered {
+				require.Eventually(t, func() bool {
+					_, ok := db.Get(ubuntu)
+					return ok
+				}, 5*time.Second, 100*time.Millisecond, "The Ubuntu distro should have been discovered")
+				d, _ := db.Get(ubuntu)
+				require.Equal(t, guid, d.GUID(), "The discovered distro should have the GUID it is registered with")
+				require.Equal(t, int32(1), added.Load(), "The discovered distro should have been notified as added")
+			} else {
+				// Only the next reconciliation is sure to be finished.
+				db.TriggerCleanup()
+			}
+
+			_, ok := db.Get(other)
+			require.False(t, ok, "Distros that are not Ubuntu should not be discovered")
+
+			d, ok := db.Get(ubuntu)
+			if tc.alreadyKnown {
+				require.True(t, ok, "The known distro should remain in the database")
+				require.Equal(t, "KnownMachine", d.Properties().Hostname, "The known distro should keep its properties")
+			}
+			if tc.noDiscovery {
+				require.False(t, ok, "The Ubuntu distro should not be discovered without discovery")
+			}
+			if !tc.wantDiscovered {
+				require.Zero(t, added.Load(), "No distro should have been notified as added")
+			}
+		})
+	}
+}
+
+func TestLiveState(t *testing.T) {
+	if !wsl.MockAvailable() {
+		t.Skip("This test can only run with the mock")
+	}
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stopped bool
+
+		want wsl.State
+	}{
+		"Success refreshing the state of a running distro": {want: wsl.Running},
+		"Success refreshing the state of a stopped distro": {stopped: true, want: wsl.Stopped},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := wsl.WithMock(context.Background(), wslmock.New())
+			dir := t.TempDir()
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+			// The state of the distros in the database is refreshed right after it is loaded.
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: New() should have returned no error")
+			_, err = db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to the database", distroName)
+			db.Close(ctx)
+
+			gowslDistro := wsl.NewDistro(ctx, distroName)
+			out, err := gowslDistro.Command(ctx, "exit 0").CombinedOutput()
+			require.NoError(t, err, "Setup: could not start WSL distro (%v): %s", err, string(out))
+			if tc.stopped {
+				err := gowslDistro.Terminate()
+				require.NoError(t, err, "Setup: could not terminate WSL distro")
+			}
+
+			db, err = database.New(ctx, dir)
+			require.NoError(t, err, "Setup: New() should have returned no error")
+			defer db.Close(ctx)
+
+			d, ok := db.Get(distroName)
+			require.True(t, ok, "Setup: the distro should have been loaded from the database")
+
+			require.Eventually(t, func() bool {
+				return d.LiveState() == tc.want
+			}, 5*time.Second, 100*time.Millisecond, "The live state of the distro should have been refreshed")
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		change func(t *testing.T, db *database.DistroDB, d *distro.Distro)
+
+		wantSignal bool
+	}{
+		"Signals a new distro": {wantSignal: true, change: func(t *testing.T, db *database.DistroDB, _ *distro.Distro) {
+			t.Helper()
+			other, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			_, err := db.GetDistroAndUpdateProperties(ctx, other, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", other)
+		}},
+		"Signals new properties": {wantSignal: true, change: func(t *testing.T, db *database.DistroDB, d *distro.Distro) {
+			t.Helper()
+			_, err := db.GetDistroAndUpdateProperties(ctx, d.Name(), distro.Properties{Hostname: "changed"})
+			require.NoError(t, err, "Setup: could not update the properties of %q", d.Name())
+		}},
+		"Signals a removed distro": {wantSignal: true, change: func(t *testing.T, db *database.DistroDB, d *distro.Distro) {
+			t.Helper()
+			d.Invalidate(ctx)
+			db.TriggerCleanup()
+		}},
+
+		"No signal when the properties do not change": {change: func(t *testing.T, db *database.DistroDB, d *distro.Distro) {
+			t.Helper()
+			_, err := db.GetDistroAndUpdateProperties(ctx, d.Name(), distro.Properties{})
+			require.NoError(t, err, "Setup: could not update the properties of %q", d.Name())
+		}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: database creation should not fail")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+
+			watchCtx, cancel := context.WithCancel(ctx)
+			changes := db.Watch(watchCtx)
+
+			tc.change(t, db, d)
+
+			if tc.wantSignal {
+				select {
+				case <-changes:
+				case <-time.After(5 * time.Second):
+					require.Fail(t, "The watcher should have been signalled")
+				}
+			} else {
+				require.Never(t, func() bool { return len(changes) > 0 }, time.Second, 100*time.Millisecond, "The watcher should not have been signalled")
+			}
+
+			cancel()
+			require.Eventually(t, func() bool {
+				_, open := <-changes
+				return !open
+			}, 5*time.Second, 100*time.Millisecond, "The channel should be closed once the context is cancelled")
+		})
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	otherName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		change func(t *testing.T, db *database.DistroDB, d *distro.Distro)
+
+		wantEvents []database.EventKind
+	}{
+		"Success reporting a new distro": {wantEvents: []database.EventKind{database.DistroAdded}, change: func(t *testing.T, db *database.DistroDB, _ *distro.Distro) {
+			t.Helper()
+			_, err := db.GetDistroAndUpdateProperties(ctx, otherName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", otherName)
+		}},
+		"Success reporting new properties": {wantEvents: []database.EventKind{database.PropertiesChanged}, change: func(t *testing.T, db *database.DistroDB, d *distro.Distro) {
+			t.Helper()
+			_, err := db.GetDistroAndUpdateProperties(ctx, d.Name(), distro.Properties{Hostname: "changed"})
+			require.NoError(t, err, "Setup: could not update the properties of %q", d.Name())
+		}},
+		"Success reporting a removed distro": {wantEvents: []database.EventKind{database.DistroRemoved}, change: func(t *testing.T, db *database.DistroDB, d *distro.Distro) {
+			t.Helper()
+			d.Invalidate(ctx)
+			db.TriggerCleanup()
+		}},
+		"Success reporting the changes in the order they happened": {
+			wantEvents: []database.EventKind{database.DistroAdded, database.PropertiesChanged, database.PropertiesChanged},
+			change: func(t *testing.T, db *database.DistroDB, _ *distro.Distro) {
+				t.Helper()
+				for _, p := range []distro.Properties{{}, {ProAttached: true}, {}} {
+					_, err := db.GetDistroAndUpdateProperties(ctx, otherName, p)
+					require.NoError(t, err, "Setup: could not update %q in the database", otherName)
+				}
+			},
+		},
+
+		"No event when the properties do not change": {change: func(t *testing.T, db *database.DistroDB, d *distro.Distro) {
+			t.Helper()
+			_, err := db.GetDistroAndUpdateProperties(ctx, d.Name(), distro.Properties{})
+			require.NoError(t, err, "Setup: could not update the properties of %q", d.Name())
+		}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: database creation should not fail")
+			defer db.Close(ctx)
+
+			// Changes before subscribing are not reported.
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+
+			var mu sync.Mutex
+			var got []database.Event
+			db.Subscribe(func(ev database.Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				got = append(got, ev)
+			})
+
+			tc.change(t, db, d)
+
+			kinds := func() (k []database.EventKind) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, ev := range got {
+					k = append(k, ev.Kind)
+				}
+				return k
+			}
+
+			require.Eventually(t, func() bool {
+				return len(kinds()) == len(tc.wantEvents)
+			}, 5*time.Second, 10*time.Millisecond, "The subscriber should have been called once per change")
+
+			// Leave time for any extra call.
+			time.Sleep(100 * time.Millisecond)
+			require.Equal(t, tc.wantEvents, kinds(), "Unexpected events reported to the subscriber")
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, ev := range got {
+				require.NotNil(t, ev.Distro, "Events should report the distro that changed")
+				if ev.Kind == database.PropertiesChanged {
+					require.NotEqual(t, ev.Old, ev.New, "Property changes should report the old and new properties")
+				}
+			}
+		})
+	}
+}
+
+func TestOnPropertyChange(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		props []distro.Properties
+
+		wantAttached []bool
+		wantVersions []string
+	}{
+		"Success calling the hooks of the properties that changed": {
+			props:        []distro.Properties{{ProAttached: true}, {ProAttached: true, VersionID: "24.04"}},
+			wantAttached: []bool{true},
+			wantVersions: []string{"24.04"},
+		},
+		"Success calling the hooks in the order of the changes": {
+			props:        []distro.Properties{{ProAttached: true}, {}, {ProAttached: true}},
+			wantAttached: []bool{true, false, true},
+		},
+
+		"No call when other properties change":      {props: []distro.Properties{{Hostname: "changed"}}},
+		"No call when the properties do not change": {props: []distro.Properties{{}}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: database creation should not fail")
+			defer db.Close(ctx)
+
+			// New distros are not reported.
+			_, err = db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+
+			var mu sync.Mutex
+			var gotAttached []bool
+			var gotVersions []string
+
+			db.OnPropertyChange(database.ProAttached, func(_ context.Context, c database.PropertyChange) {
+				mu.Lock()
+				defer mu.Unlock()
+				gotAttached = append(gotAttached, c.New.ProAttached)
+			})
+			db.OnPropertyChange(database.VersionID, func(_ context.Context, c database.PropertyChange) {
+				mu.Lock()
+				defer mu.Unlock()
+				gotVersions = append(gotVersions, c.New.VersionID)
+			})
+
+			for _, p := range tc.props {
+				_, err := db.GetDistroAndUpdateProperties(ctx, distroName, p)
+				require.NoError(t, err, "Setup: could not update the properties of %q", distroName)
+			}
+
+			got := func() ([]bool, []string) {
+				mu.Lock()
+				defer mu.Unlock()
+				return slices.Clone(gotAttached), slices.Clone(gotVersions)
+			}
+
+			require.Eventually(t, func() bool {
+				attached, versions := got()
+				return len(attached) == len(tc.wantAttached) && len(versions) == len(tc.wantVersions)
+			}, 5*time.Second, 10*time.Millisecond, "The hooks should have been called once per change")
+
+			// Leave time for any extra call.
+			time.Sleep(100 * time.Millisecond)
+			attached, versions := got()
+			require.Equal(t, tc.wantAttached, nilIfEmpty(attached), "Unexpected calls to the hook on ProAttached")
+			require.Equal(t, tc.wantVersions, nilIfEmpty(versions), "Unexpected calls to the hook on VersionID")
+		})
+	}
+}
+
+// nilIfEmpty returns nil for empty slices, so that they compare equal to unset expectations.
+func nilIfEmpty[T any](s []T) []T {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+func TestCleanupTaskStorage(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, guid := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		retention time.Duration
+		closeDB   bool
+
+		wantStaleRemoved bool
+		wantErr          bool
+	}{
+		"Success removing only orphaned storage without retention": {},
+		"Success removing stale storage past the retention":        {retention: time.Hour, wantStaleRemoved: true},
+		"Success keeping storage within the retention":             {retention: 72 * time.Hour},
+
+		"Error when the database is closed": {closeDB: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dbDir := t.TempDir()
+			databaseFromTemplate(t, dbDir, distroID{distroName, guid})
+
+			orphanTasks := filepath.Join(dbDir, "orphan.tasks")
+			err := os.WriteFile(orphanTasks, []byte("[]"), 0600)
+			require.NoError(t, err, "Setup: could not write task storage of a distro not in the database")
+
+			db, err := database.New(ctx, dbDir)
+			require.NoError(t, err, "Setup: New() should have returned no error")
+			defer db.Close(ctx)
+
+			// The distro's worker creates its storage lazily, so we write it ourselves.
+			staleTasks := filepath.Join(dbDir, distroName+".tasks")
+			err = os.WriteFile(staleTasks, []byte("[]"), 0600)
+			require.NoError(t, err, "Setup: could not write task storage")
+			old := time.Now().Add(-48 * time.Hour)
+			err = os.Chtimes(staleTasks, old, old)
+			require.NoError(t, err, "Setup: could not age task storage")
+
+			if tc.closeDB {
+				db.Close(ctx)
+			}
+
+			_, err = db.CleanupTaskStorage(ctx, tc.retention)
+			if tc.wantErr {
+				require.Error(t, err, "CleanupTaskStorage should return an error")
+				return
+			}
+			require.NoError(t, err, "CleanupTaskStorage should return no error")
+
+			require.NoFileExists(t, orphanTasks, "Task storage of distros not in the database should have been removed")
+			if tc.wantStaleRemoved {
+				require.NoFileExists(t, staleTasks, "Task storage unused for longer than the retention should have been removed")
+			} else {
+				require.FileExists(t, staleTasks, "Task storage within the retention should have been kept")
+			}
+		})
+	}
+}
+
+func TestBackup(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, guid := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		withStore bool
+		inMemory  bool
+		closeDB   bool
+
+		wantNoBackup bool
+		wantErr      bool
+	}{
+		"Success backing up the store":              {withStore: true},
+		"Success backing up the database file":      {},
+		"Success with nothing to back up in memory": {inMemory: true, wantNoBackup: true},
+
+		"Error when the database is closed": {closeDB: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dbDir := t.TempDir()
+			databaseFromTemplate(t, dbDir, distroID{distroName, guid})
+
+			var opts []database.Option
+			if tc.withStore {
+				opts = append(opts, database.WithStore(openStore(t, dbDir)))
+			}
+			if tc.inMemory {
+				opts = append(opts, database.WithMemoryStorage())
+			}
+
+			db, err := database.New(ctx, dbDir, opts...)
+			require.NoError(t, err, "Setup: New() should have returned no error")
+			defer db.Close(ctx)
+
+			if tc.closeDB {
+				db.Close(ctx)
+			}
+
+			path := filepath.Join(t.TempDir(), "backup")
+			err = db.Backup(path)
+			if tc.wantErr {
+				require.Error(t, err, "Backup should return an error")
+				return
+			}
+			require.NoError(t, err, "Backup should return no error")
+
+			if tc.wantNoBackup {
+				require.NoFileExists(t, path, "No backup should have been written")
+				return
+			}
+
+			if !tc.withStore {
+				want, err := os.ReadFile(filepath.Join(dbDir, consts.DatabaseFileName))
+				require.NoError(t, err, "Could not read the database file")
+				got, err := os.ReadFile(path)
+				require.NoError(t, err, "Could not read the backup")
+				require.Equal(t, string(want), string(got), "The backup should be a copy of the database file")
+				return
+			}
+
+			// The backup is taken while the store is open, and can be opened on its own.
+			backup, err := store.Open(ctx, path)
+			require.NoError(t, err, "The backup should be a valid store")
+			defer backup.Close()
+
+			record, err := backup.Get(store.DistrosBucket, strings.ToLower(distroName))
+			require.NoError(t, err, "Could not read the backup")
+			require.NotNil(t, record, "The backup should contain the distros in the database")
+		})
+	}
+}
+
+func TestRetainUnknownProperties(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, guid := wsltestutils.RegisterDistro(t, ctx, false)
+
+	dbDir := t.TempDir()
+	dbFile := filepath.Join(dbDir, consts.DatabaseFileName)
+	databaseFromTemplate(t, dbDir, distroID{distroName, guid})
+
+	db, err := database.New(ctx, dbDir)
+	require.NoError(t, err, "Setup: New() should have returned no error")
+	defer db.Close(ctx)
+
+	requireUnknownProperties := func(msg string) {
+		t.Helper()
+
+		out, err := os.ReadFile(dbFile)
+		require.NoError(t, err, "Could not read database file")
+
+		var dump []struct {
+			Properties map[string]any
+		}
+		err = yaml.Unmarshal(out, &dump)
+		require.NoError(t, err, "Could not parse database file")
+		require.Len(t, dump, 1, "Database should contain a single distro")
+
+		props := dump[0].Properties
+		require.Equal(t, 2, props["kernelversion"], msg)
+		require.Equal(t, map[string]any{"nested": "value"}, props["futureproperty"], msg)
+	}
+
+	err = db.Dump()
+	require.NoError(t, err, "Dump should return no error")
+	requireUnknownProperties("Unknown properties should be retained after loading and dumping the database")
+
+	props := distro.Properties{
+		DistroID:   "Ubuntu",
+		VersionID:  "22.04",
+		PrettyName: "Ubuntu 22.04 LTS (Jammy Jellyfish)",
+		Hostname:   "NewTestMachine",
+	}
+	_, err = db.GetDistroAndUpdateProperties(ctx, distroName, props)
+	require.NoError(t, err, "GetDistroAndUpdateProperties should return no error")
+	requireUnknownProperties("Unknown properties should be retained after updating the known ones")
+
+	d, ok := db.Get(distroName)
+	require.True(t, ok, "Distro should be in the database")
+	require.Equal(t, "NewTestMachine", d.Properties().Hostname, "Known properties should have been updated")
+}
+
+func TestTaskHistory(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	dbDir := t.TempDir()
+
+	db, err := database.New(ctx, dbDir)
+	require.NoError(t, err, "Setup: New() should have returned no error")
+
+	d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+	require.NoError(t, err, "Setup: could not add %q to the database", distroName)
+
+	finished := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	record := worker.TaskRecord{
+		Task:     "Pro attachment",
+		Type:     "*tasks.ProAttachment",
+		Started:  finished.Add(-time.Minute),
+		Finished: finished,
+		Error:    "could not attach",
+		Steps:    []task.Step{{Name: "attach", Status: task.StepFailed, Message: "could not attach"}},
+	}
+	d.AppendTaskHistory(record)
+
+	// The database is dumped in the background, as tasks finish in the goroutine of their distro.
+	require.Eventually(t, func() bool {
+		out, err := os.ReadFile(filepath.Join(dbDir, consts.DatabaseFileName))
+		return err == nil && strings.Contains(string(out), "could not attach")
+	}, 5*time.Second, 100*time.Millisecond, "The task history should have been stored in the database")
+
+	db.Close(ctx)
+
+	db, err = database.New(ctx, dbDir)
+	require.NoError(t, err, "New() should have returned no error")
+	defer db.Close(ctx)
+
+	d, ok := db.Get(distroName)
+	require.True(t, ok, "The distro should have been loaded from the database")
+	require.Equal(t, []worker.TaskRecord{record}, d.TaskHistory(), "The task history should have been loaded from the database")
+}
+
+// benchmarkDistros is the number of distros in the databases of the benchmarks, as in a large fleet.
+const benchmarkDistros = 1000
+
+func TestPerformanceBudgets(t *testing.T) {
+	testCases := map[string]struct {
+		benchmark func(*testing.B)
+		budget    time.Duration
+	}{
+		"Dumping a database of 1k distros":              {benchmark: BenchmarkDatabaseDump, budget: time.Second},
+		"Dumping a database of 1k distros to the store": {benchmark: BenchmarkDatabaseDumpToStore, budget: time.Second},
+		"Loading a database of 1k distros":              {benchmark: BenchmarkDatabaseLoad, budget: 10 * time.Second},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			testutils.RequirePerformanceBudget(t, tc.budget, tc.benchmark)
+		})
+	}
+}
+
+func BenchmarkDatabaseDump(b *testing.B) {
+	dir := b.TempDir()
+	ctx := benchmarkDatabaseFile(b, dir)
+
+	db, err := database.New(ctx, dir)
+	require.NoError(b, err, "Setup: could not load the database")
+
+	b.ResetTimer()
+	for range b.N {
+		require.NoError(b, db.Dump(), "Dump should return no error")
+	}
+
+	b.StopTimer()
+	db.Close(ctx)
+}
+
+func BenchmarkDatabaseDumpToStore(b *testing.B) {
+	dir := b.TempDir()
+	ctx := benchmarkDatabaseFile(b, dir)
+
+	st := openStore(b, dir)
+	db, err := database.New(ctx, dir, database.WithStore(st))
+	require.NoError(b, err, "Setup: could not migrate the database into the store")
+
+	// Only one distro changes at a time, as when it reports new properties.
+	distros := db.GetAll()
+
+	b.ResetTimer()
+	for i := range b.N {
+		b.StopTimer()
+		distros[i%len(distros)].SetLastSeen(time.Now())
+		b.StartTimer()
+
+		require.NoError(b, db.Dump(), "Dump should return no error")
+	}
+
+	b.StopTimer()
+	db.Close(ctx)
+}
+
+func BenchmarkDatabaseLoad(b *testing.B) {
+	dir := b.TempDir()
+	ctx := benchmarkDatabaseFile(b, dir)
+
+	b.ResetTimer()
+	for range b.N {
+		db, err := database.New(ctx, dir)
+		require.NoError(b, err, "New should return no error")
+
+		b.StopTimer()
+		require.Len(b, db.GetAll(), benchmarkDistros, "All the distros should have been loaded")
+		db.Close(ctx)
+		b.StartTimer()
+	}
+}
+
+// benchmarkDatabaseFile registers benchmarkDistros distros and writes a database file with all of them in dir.
+// It returns the context to use the mock registry with.
+func benchmarkDatabaseFile(b *testing.B, dir string) context.Context {
+	b.Helper()
+
+	if !wsl.MockAvailable() {
+		b.Skip("This benchmark registers too many distros to run without the WSL mock")
+	}
+	ctx := wsl.WithMock(context.Background(), wslmock.New())
+
+	// The file is written directly, as adding the distros one at a time would dump the database every time.
+	records := make([]database.SerializableDistro, 0, benchmarkDistros)
+	for range benchmarkDistros {
+		name, guid := wsltestutils.RegisterDistro(b, ctx, false)
+		records = append(records, database.SerializableDistro{
+			Version: database.RecordVersion(),
+			Name:    name,
+			GUID:    guid,
+			Properties: distro.Properties{
+				DistroID:    "ubuntu",
+				VersionID:   "24.04",
+				PrettyName:  "Ubuntu 24.04 LTS",
+				Hostname:    name,
+				ProAttached: true,
+				ProServices: []string{"esm-apps", "esm-infra", "livepatch"},
+				LastSeen:    time.Now(),
+			},
+		})
+	}
+
+	out, err := yaml.Marshal(records)
+	require.NoError(b, err, "Setup: could not marshal the database")
+	require.NoError(b, os.WriteFile(filepath.Join(dir, consts.DatabaseFileName), out, 0600), "Setup: could not write the database")
+
+	return ctx
+}
+
+// openStore opens the store in dir, which is closed when the test ends.
+func openStore(t testing.TB, dir string) *store.Store {
+	t.Helper()
+
+	s, err := store.Open(context.Background(), filepath.Join(dir, consts.StoreFileName))
+	require.NoError(t, err, "Setup: could not open the store")
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+// renameMockDistro changes the name a distro is registered under in the GoWSL mock, keeping its GUID.
+func renameMockDistro(t *testing.T, m *wslmock.Backend, guid, newName string) {
+	t.Helper()
+
+	k, err := m.OpenLxssRegistry(fmt.Sprintf("{%s}", guid))
+	require.NoError(t, err, "Setup: could not open the registry key of the distro")
+	defer k.Close()
+
+	key, ok := k.(*wslmock.RegistryKey)
+	require.True(t, ok, "Setup: unexpected registry key type %T", k)
+	key.Data["DistributionName"] = newName
+}
+
+// fileModTime returns the ModTime of the provided path. If the path
+// does not exist, the time is reported as Unix 0.
+func fileModTime(t *testing.T, path string) time.Time {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return time.Unix(0, 0)
+	}
+
+	require.NoError(t, err, "Could not Stat file %q", path)
+	return info.ModTime()
+}
+
+// distroID is a convenience struct to package a distro's identifying data.
+// Used to deanonymize fixtures.
+type distroID struct {
+	Name string
+	GUID string
+}
+
+// databaseFromTemplate creates a yaml database file in the specified directory.
+// The template must be in {TestFamilyPath}/database_template.yaml and it'll be
+// instantiated in {dest}/database.yaml.
+func databaseFromTemplate(t *testing.T, dest string, distros ...distroID) {
+	t.Helper()
+
+	in, err := os.ReadFile(filepath.Join(testutils.TestFamilyPath(t), "database_template.yaml"))
+	require.NoError(t, err, "Setup: could not read database template")
+
+	tmpl := template.Must(template.New(t.Name()).Parse(string(in)))
+
+	f, err := os.Create(filepath.Join(dest, consts.DatabaseFileName))
+	require.NoError(t, err, "Setup: could not create database file")
+	defer f.Close()
+
+	err = tmpl.Execute(f, distros)
+	require.NoError(t, err, "Setup: could not execute template database file")
+}
+
+// structuredDump is a convenience struct used to parse the database dump and make
+// assertions on it with better accuracy that just a strings.Contains.
+type structuredDump struct {
+	data []database.SerializableDistro
+}
+
+// newStructuredDump takes a database dump and parses it to generate a structuredDump.
+func newStructuredDump(t *testing.T, rawDump []byte) structuredDump {
+	t.Helper()
+
+	var data []database.SerializableDistro
+
+	err := yaml.Unmarshal(rawDump, &data)
+	require.NoError(t, err, "In an attempt to parse a database dump: Unmarshal failed for dump:\n%s", rawDump)
+
+	return structuredDump{data: data}
+}
+
+// anonymise takes a structured dump and removes all dynamically-generated information,
+// leaving behind only information that is invariant across test runs.
+func (sd *structuredDump) anonymise(t *testing.T) {
+	t.Helper()
 
 	for i := range sd.data {
 		sd.data[i].Name = fmt.Sprintf("%%DISTRONAME%d%%", i)
 		sd.data[i].GUID = fmt.Sprintf("%%GUID%d%%", i)
 	}
 }
+
+// registerMockUbuntuDistro registers a distro with a name like those of the Ubuntu applications in the mock,
+// and returns its name and GUID.
+//
+//nolint:revive // The context is better after the testing.T
+func registerMockUbuntuDistro(t *testing.T, ctx context.Context) (name, guid string) {
+	t.Helper()
+
+	name = "Ubuntu-" + wsltestutils.RandomDistroName(t)
+
+	rootfs := filepath.Join(t.TempDir(), "empty.tar.gz")
+	err := os.WriteFile(rootfs, []byte{}, 0600)
+	require.NoError(t, err, "Setup: could not write empty fake rootfs")
+
+	d := wsl.NewDistro(ctx, name)
+	err = d.Register(rootfs)
+	require.NoError(t, err, "Setup: could not register %q", name)
+	t.Cleanup(func() { _ = d.Unregister() })
+
+	id, err := d.GUID()
+	require.NoError(t, err, "Setup: could not get the GUID of %q", name)
+
+	return name, id.String()
+}
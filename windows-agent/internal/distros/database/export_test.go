@@ -2,13 +2,30 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"gopkg.in/yaml.v3"
 )
 
 type SerializableDistro = serializableDistro
 
+// RecordVersion is recordVersion, made accessible to tests.
+var RecordVersion = recordVersion
+
+// RenameProperty is renameProperty, made accessible to tests.
+var RenameProperty = renameProperty
+
+// MigrateRecord runs the given migrations on the record, as migrateRecord does with recordMigrations.
+func MigrateRecord(record *yaml.Node, migrations ...func(*yaml.Node) error) error {
+	var m []recordMigration
+	for i, f := range migrations {
+		m = append(m, recordMigration{description: fmt.Sprintf("test migration %d", i+1), run: f})
+	}
+	return migrateRecord(record, m)
+}
+
 // NewDistro is a wrapper around newDistro so as to make it accessible to tests.
 func (in SerializableDistro) NewDistro(ctx context.Context, storageDir string, startupMu *sync.Mutex) (*distro.Distro, error) {
 	return in.newDistro(ctx, storageDir, startupMu)
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+)
+
+// Property picks one of the properties of a distro, so that hooks can be registered on its changes and distros
+// looked up by it. The value it returns must be comparable.
+type Property func(distro.Properties) any
+
+// Properties of the distros that hooks can be registered on and distros looked up by.
+var (
+	// DistroID is the ID of the distribution of the distro, such as ubuntu.
+	DistroID Property = func(p distro.Properties) any { return p.DistroID }
+
+	// ProAttached is whether the distro is attached to Ubuntu Pro.
+	ProAttached Property = func(p distro.Properties) any { return p.ProAttached }
+
+	// VersionID is the version of the release of the distro, such as 24.04.
+	VersionID Property = func(p distro.Properties) any { return p.VersionID }
+
+	// ServiceVersion is the version of the WSL Pro service of the distro.
+	ServiceVersion Property = func(p distro.Properties) any { return p.ServiceVersion }
+
+	// Used is whether the distro was ever launched by the user.
+	Used Property = func(p distro.Properties) any { return !p.FirstUsed.IsZero() }
+)
+
+// PropertyChange is a change of the properties reported by a distro.
+type PropertyChange struct {
+	Distro *distro.Distro
+	Old    distro.Properties
+	New    distro.Properties
+}
+
+// EventKind is the kind of change to the database an Event reports.
+type EventKind int
+
+const (
+	// DistroAdded is reported when a distro is registered in the database, including when it replaces one
+	// with the same name.
+	DistroAdded EventKind = iota
+
+	// DistroRemoved is reported when a distro is removed from the database, because it was unregistered or
+	// replaced by one with the same name.
+	DistroRemoved
+
+	// DistroRenamed is reported when a distro in the database is found to have been renamed.
+	DistroRenamed
+
+	// PropertiesChanged is reported when the properties reported by a distro change.
+	PropertiesChanged
+)
+
+// String returns the name of the kind of event, for the logs.
+func (k EventKind) String() string {
+	switch k {
+	case DistroAdded:
+		return "added"
+	case DistroRemoved:
+		return "removed"
+	case DistroRenamed:
+		return "renamed"
+	case PropertiesChanged:
+		return "properties changed"
+	default:
+		return fmt.Sprintf("unknown event kind %d", int(k))
+	}
+}
+
+// Event is a change to the distros in the database, as reported to the subscribers.
+type Event struct {
+	Kind   EventKind
+	Distro *distro.Distro
+
+	// OldName is the name the distro had before it was renamed. It is only set for DistroRenamed.
+	OldName string
+
+	// Old and New are the properties of the distro before and after they changed. They are only set for
+	// PropertiesChanged.
+	Old distro.Properties
+	New distro.Properties
+}
+
+// propertyHook is a hook registered with OnPropertyChange.
+type propertyHook struct {
+	property Property
+	run      func(context.Context, PropertyChange)
+}
+
+// eventHooks calls the subscribers and the property hooks on the changes to the database, in the order they
+// happened, from a goroutine of their own so that they can use the database and the distros.
+type eventHooks struct {
+	mu            sync.Mutex
+	subscribers   []func(Event)
+	propertyHooks []propertyHook
+	pending       []Event
+
+	// wake is signalled when events are pending.
+	wake chan struct{}
+}
+
+// Subscribe registers f to be called every time a distro is added to or removed from the database, is
+// renamed, or has its properties change, instead of polling the database. Subscribers are called one at a
+// time, in the order the changes happened and then the order they were registered, without any lock held,
+// until the database is closed. Changes that happen before a subscriber is registered are not reported to
+// it, nor are the distros loaded from disk.
+func (db *DistroDB) Subscribe(f func(Event)) {
+	db.hooks.mu.Lock()
+	defer db.hooks.mu.Unlock()
+
+	db.hooks.subscribers = append(db.hooks.subscribers, f)
+}
+
+// OnPropertyChange registers f to be called every time the property of a distro changes, instead of polling
+// the database. Hooks are called one at a time, in the order the changes happened and then the order they were
+// registered, without any lock held. Changes that happen before a hook is registered are not reported to it,
+// nor are those of the distros added to the database or loaded from disk.
+func (db *DistroDB) OnPropertyChange(property Property, f func(ctx context.Context, change PropertyChange)) {
+	db.hooks.mu.Lock()
+	defer db.hooks.mu.Unlock()
+
+	db.hooks.propertyHooks = append(db.hooks.propertyHooks, propertyHook{property: property, run: f})
+}
+
+// notifyPropertiesChange queues the change of the properties of the distro for the hooks interested in it.
+// It does not block, as it is called with the distro locked.
+func (db *DistroDB) notifyPropertiesChange(d *distro.Distro, old, new distro.Properties) {
+	db.notifyEvent(Event{Kind: PropertiesChanged, Distro: d, Old: old, New: new})
+}
+
+// notifyEvent queues the event for the subscribers and the hooks. It does not block, as it is called with
+// the database or the distro locked.
+func (db *DistroDB) notifyEvent(ev Event) {
+	h := &db.hooks
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subscribers) == 0 && len(h.propertyHooks) == 0 {
+		return
+	}
+	h.pending = append(h.pending, ev)
+
+	select {
+	case h.wake <- struct{}{}:
+	default:
+		// The hooks are already due to run.
+	}
+}
+
+// runHooks calls the subscribers and the hooks on the pending events until the database is closed.
+func (db *DistroDB) runHooks() {
+	defer crashreport.Recover("database")
+
+	h := &db.hooks
+	for {
+		select {
+		case <-db.ctx.Done():
+			return
+		case <-h.wake:
+		}
+
+		h.mu.Lock()
+		events := h.pending
+		subscribers := h.subscribers
+		propertyHooks := h.propertyHooks
+		h.pending = nil
+		h.mu.Unlock()
+
+		for _, ev := range events {
+			for _, f := range subscribers {
+				if db.ctx.Err() != nil {
+					return
+				}
+				f(ev)
+			}
+
+			if ev.Kind != PropertiesChanged {
+				continue
+			}
+			change := PropertyChange{Distro: ev.Distro, Old: ev.Old, New: ev.New}
+			for _, hook := range propertyHooks {
+				if db.ctx.Err() != nil {
+					return
+				}
+				if hook.property(change.Old) == hook.property(change.New) {
+					continue
+				}
+				hook.run(db.ctx, change)
+			}
+		}
+	}
+}
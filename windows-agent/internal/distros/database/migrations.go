@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"gopkg.in/yaml.v3"
+)
+
+// recordMigration upgrades a distro record from one version of its schema to the next. It works on the YAML of
+// the record rather than on serializableDistro, so that fields that were renamed or removed can still be read.
+type recordMigration struct {
+	// description tells what the migration does, for the logs.
+	description string
+
+	// run migrates the record, a YAML mapping with the fields of serializableDistro.
+	run func(record *yaml.Node) error
+}
+
+// recordMigrations are the migrations of the schema of the distro records, in order. The version of a record is
+// the number of migrations it went through. Fields added to distro.Properties need no migration, as they are
+// zero in older records, but renaming or changing the type of one does. The first migration marks the records
+// written before they had a version.
+var recordMigrations = []recordMigration{
+	{description: "start versioning the records", run: func(*yaml.Node) error { return nil }},
+}
+
+// versionKey is the key of the version of the schema in a distro record.
+const versionKey = "version"
+
+// recordVersion returns the version of the schema of the records this version of the agent writes.
+func recordVersion() int {
+	return len(recordMigrations)
+}
+
+// migrateRecord runs the migrations the record did not go through yet, in order, and sets its version
+// accordingly. Records written by a later version of the agent, with a schema newer than the migrations, are
+// left untouched.
+func migrateRecord(record *yaml.Node, migrations []recordMigration) error {
+	if record.Kind != yaml.MappingNode {
+		return fmt.Errorf("distro record is not a mapping, but a YAML node of kind %d", record.Kind)
+	}
+
+	version := 0
+	value := mappingValue(record, versionKey)
+	if value != nil {
+		v, err := strconv.Atoi(value.Value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %v", versionKey, value.Value, err)
+		}
+		version = v
+	}
+
+	if version > len(migrations) {
+		log.Warningf(context.Background(), "Database: distro record with version %d, newer than this version of the agent knows (%d)", version, len(migrations))
+		return nil
+	}
+	if version == len(migrations) {
+		return nil
+	}
+
+	for i, m := range migrations[version:] {
+		if err := m.run(record); err != nil {
+			return fmt.Errorf("could not migrate distro record to version %d (%s): %v", version+i+1, m.description, err)
+		}
+	}
+
+	newVersion := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(len(migrations))}
+	if value != nil {
+		*value = *newVersion
+		return nil
+	}
+	record.Content = append(record.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: versionKey}, newVersion)
+
+	return nil
+}
+
+// renameProperty returns a migration step that renames a property of the distro record, if it has it. A property
+// already set under the new name is kept, and the old one dropped.
+func renameProperty(from, to string) func(record *yaml.Node) error {
+	return func(record *yaml.Node) error {
+		props := mappingValue(record, "properties")
+		if props == nil {
+			return nil
+		}
+		if props.Kind != yaml.MappingNode {
+			return fmt.Errorf("properties are not a mapping, but a YAML node of kind %d", props.Kind)
+		}
+
+		keep := mappingValue(props, to) != nil
+		for i := 0; i+1 < len(props.Content); i += 2 {
+			if props.Content[i].Value != from {
+				continue
+			}
+			if keep {
+				props.Content = append(props.Content[:i], props.Content[i+2:]...)
+				return nil
+			}
+			props.Content[i].Value = to
+			return nil
+		}
+
+		return nil
+	}
+}
+
+// mappingValue returns the value of key in the YAML mapping, or nil if it does not have it.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrateRecord(t *testing.T) {
+	t.Parallel()
+
+	const oldRecord = `name: Ubuntu
+guid: '{12345678-1234-1234-1234-123456789abc}'
+properties:
+    distroid: ubuntu
+    proexpiry: "2030-01-01T00:00:00Z"
+    unknownfield: kept
+`
+
+	mustNotRun := func(*yaml.Node) error { return errors.New("this migration should not run") }
+	failing := func(*yaml.Node) error { return errors.New("mock error") }
+
+	testCases := map[string]struct {
+		record     string
+		migrations []func(*yaml.Node) error
+
+		wantErr bool
+	}{
+		"Success migrating a record without a version": {record: oldRecord, migrations: []func(*yaml.Node) error{database.RenameProperty("proexpiry", "proexpires")}},
+		"Success running only the migrations the record did not go through": {
+			record:     "version: 1\n" + oldRecord,
+			migrations: []func(*yaml.Node) error{mustNotRun, database.RenameProperty("proexpiry", "proexpires")},
+		},
+		"Success keeping the property already renamed": {
+			record:     oldRecord + "    proexpires: \"2040-01-01T00:00:00Z\"\n",
+			migrations: []func(*yaml.Node) error{database.RenameProperty("proexpiry", "proexpires")},
+		},
+		"Success renaming a property the record does not have":       {record: oldRecord, migrations: []func(*yaml.Node) error{database.RenameProperty("missing", "found")}},
+		"Success renaming a property of a record without properties": {record: "name: Ubuntu\n", migrations: []func(*yaml.Node) error{database.RenameProperty("proexpiry", "proexpires")}},
+		"Success with a record up to date":                           {record: "version: 1\n" + oldRecord, migrations: []func(*yaml.Node) error{mustNotRun}},
+		"Success leaving a record of a later version untouched":      {record: "version: 5\n" + oldRecord, migrations: []func(*yaml.Node) error{mustNotRun}},
+
+		"Error when the version is not a number":      {record: "version: one\n" + oldRecord, migrations: []func(*yaml.Node) error{mustNotRun}, wantErr: true},
+		"Error when the record is not a mapping":      {record: "- name: Ubuntu\n", migrations: []func(*yaml.Node) error{mustNotRun}, wantErr: true},
+		"Error when the properties are not a mapping": {record: "properties: [a, b]\n", migrations: []func(*yaml.Node) error{database.RenameProperty("a", "b")}, wantErr: true},
+		"Error when a migration fails":                {record: oldRecord, migrations: []func(*yaml.Node) error{failing}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var doc yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(tc.record), &doc), "Setup: could not parse the record")
+			record := doc.Content[0]
+
+			err := database.MigrateRecord(record, tc.migrations...)
+			if tc.wantErr {
+				require.Error(t, err, "MigrateRecord should return an error")
+				return
+			}
+			require.NoError(t, err, "MigrateRecord should return no error")
+
+			out, err := yaml.Marshal(record)
+			require.NoError(t, err, "Setup: could not marshal the migrated record")
+
+			want := testutils.LoadWithUpdateFromGolden(t, string(out))
+			require.Equal(t, want, string(out), "MigrateRecord should have migrated the record and set its version")
+		})
+	}
+}
+
+func TestUnmarshalMigratesRecords(t *testing.T) {
+	t.Parallel()
+
+	// A database file written before the records had a version.
+	const file = `- name: Ubuntu
+  guid: '{12345678-1234-1234-1234-123456789abc}'
+  properties:
+    distroid: ubuntu
+    proattached: true
+`
+
+	var got []database.SerializableDistro
+	require.NoError(t, yaml.Unmarshal([]byte(file), &got), "Unmarshal should return no error")
+
+	require.Len(t, got, 1, "Unmarshal should return the record of the file")
+	require.Equal(t, database.RecordVersion(), got[0].Version, "The record should have been migrated to the latest version")
+	require.Equal(t, "Ubuntu", got[0].Name, "The migration should not have lost the name of the distro")
+	require.True(t, got[0].Properties.ProAttached, "The migration should not have lost the properties of the distro")
+}
@@ -0,0 +1,11 @@
+//go:build gowslmock
+
+package database
+
+import "context"
+
+// watchRegistrations returns nil: the mocked WSL has no registry to watch, so tests reconcile the database
+// with TriggerCleanup.
+func watchRegistrations(context.Context) <-chan struct{} {
+	return nil
+}
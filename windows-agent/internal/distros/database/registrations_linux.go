@@ -0,0 +1,10 @@
+//go:build !gowslmock
+
+package database
+
+import "context"
+
+// watchRegistrations returns nil, as there is no WSL to watch on Linux.
+func watchRegistrations(context.Context) <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,57 @@
+//go:build !gowslmock
+
+package database
+
+import (
+	"context"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// lxssPath is the registry key, under HKEY_CURRENT_USER, where WSL registers the distros.
+const lxssPath = `Software\Microsoft\Windows\CurrentVersion\Lxss`
+
+// watchRegistrations returns a channel that receives a value every time the distros registered in WSL may
+// have changed, i.e. a distro is imported, unregistered or renamed. Changes that happen before the previous
+// one is received are coalesced. It returns nil if WSL cannot be watched, e.g. because it is not installed.
+func watchRegistrations(ctx context.Context) <-chan struct{} {
+	k, err := registry.OpenKey(registry.CURRENT_USER, lxssPath, registry.NOTIFY)
+	if err != nil {
+		log.Warningf(ctx, "Database: not watching the registered distros: %v", err)
+		return nil
+	}
+
+	ch := make(chan struct{}, 1)
+
+	// Closing the key cancels the wait for changes.
+	go func() {
+		<-ctx.Done()
+		_ = k.Close()
+	}()
+
+	go func() {
+		defer crashreport.Recover("database")
+		for {
+			// Waits synchronously for subkeys to be added or deleted, or for values to be set, in the whole tree.
+			err := windows.RegNotifyChangeKeyValue(windows.Handle(k), true, windows.REG_NOTIFY_CHANGE_NAME|windows.REG_NOTIFY_CHANGE_LAST_SET, windows.Handle(0), false)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Warningf(ctx, "Database: stopped watching the registered distros: %v", err)
+				return
+			}
+
+			select {
+			case ch <- struct{}{}:
+			default:
+				// A change is pending already.
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"gopkg.in/yaml.v3"
+)
+
+// rejectedSuffix is appended to the name of the database file to get the name of the file where the records
+// that cannot be loaded are quarantined.
+const rejectedSuffix = ".rejected"
+
+// rejectedRecord is a record of the database that could not be loaded, kept as it was stored so that it can
+// be recovered by hand.
+type rejectedRecord struct {
+	Reason   string
+	Rejected time.Time
+	Record   string
+}
+
+// rejectNode returns the rejected record for the YAML node of a record.
+func rejectNode(node *yaml.Node, reason error) rejectedRecord {
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		out = []byte(node.Value)
+	}
+	return rejectBytes(out, reason)
+}
+
+// rejectDistro returns the rejected record for a record that was parsed but is not valid.
+func rejectDistro(d serializableDistro, reason error) rejectedRecord {
+	out, err := yaml.Marshal(d)
+	if err != nil {
+		out = []byte(fmt.Sprintf("%#+v", d))
+	}
+	return rejectBytes(out, reason)
+}
+
+// rejectBytes returns the rejected record for the stored contents of a record.
+func rejectBytes(out []byte, reason error) rejectedRecord {
+	return rejectedRecord{
+		Reason:   reason.Error(),
+		Rejected: time.Now().UTC().Truncate(time.Second),
+		Record:   string(out),
+	}
+}
+
+// quarantineRecords logs the rejected records and appends them to the file of rejected records in storageDir,
+// as a YAML document per call. They are only logged if storageDir is empty. Failing to quarantine them is
+// logged rather than returned, as it is no reason not to load the rest of the database.
+func quarantineRecords(ctx context.Context, storageDir string, rejected []rejectedRecord) {
+	if len(rejected) == 0 {
+		return
+	}
+
+	for _, r := range rejected {
+		log.Warningf(ctx, "Database: rejected record: %s", r.Reason)
+	}
+
+	if storageDir == "" {
+		return
+	}
+
+	path := filepath.Join(storageDir, consts.DatabaseFileName+rejectedSuffix)
+	if err := appendRecords(path, rejected); err != nil {
+		log.Warningf(ctx, "Database: could not quarantine the rejected records into %q: %v", path, err)
+		return
+	}
+
+	log.Warningf(ctx, "Database: quarantined %d rejected records into %q", len(rejected), path)
+}
+
+// appendRecords appends the rejected records to the file at path as a new YAML document.
+func appendRecords(path string, rejected []rejectedRecord) (err error) {
+	out, err := yaml.Marshal(rejected)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %v", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	if _, err := f.Write(append([]byte("---\n"), out...)); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
@@ -2,10 +2,14 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
 	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
 )
 
 // serializableDistro is an helper struct for marshalling and unmarshalling into and
@@ -14,27 +18,58 @@ import (
 // It contains all the persistent information in plain data structures,
 // with none of the short-term information or functionality.
 type serializableDistro struct {
-	Name string
-	GUID string
-	distro.Properties
+	// Version is the version of the schema of the record. See recordMigrations.
+	Version int
+
+	Name       string
+	GUID       string
+	Properties distro.Properties
+
+	// TaskHistory is the outcome of the last tasks of the distro that finished, oldest first.
+	TaskHistory []worker.TaskRecord `yaml:",omitempty"`
+}
+
+// plainSerializableDistro has the same fields as serializableDistro, without its YAML methods.
+type plainSerializableDistro serializableDistro
+
+// UnmarshalYAML migrates the record to the current version of its schema before unmarshalling it, so that
+// records written by older versions of the agent lose none of their data.
+func (in *serializableDistro) UnmarshalYAML(node *yaml.Node) error {
+	if err := migrateRecord(node, recordMigrations); err != nil {
+		return err
+	}
+	return node.Decode((*plainSerializableDistro)(in))
+}
+
+// validate checks that the record identifies a distro, with a name and a well-formed GUID.
+func (in serializableDistro) validate() error {
+	if in.Name == "" {
+		return errors.New("record has no distro name")
+	}
+	if _, err := uuid.Parse(in.GUID); err != nil {
+		return fmt.Errorf("distro %q has an invalid GUID %q: %v", in.Name, in.GUID, err)
+	}
+	return nil
 }
 
-// newDistro calls distro.New with the name, GUID and properties specified
+// newDistro calls distro.New with the name, GUID, properties and task history specified
 // in its inert counterpart.
-func (in serializableDistro) newDistro(ctx context.Context, storageDir string, startupMu *sync.Mutex) (*distro.Distro, error) {
+func (in serializableDistro) newDistro(ctx context.Context, storageDir string, startupMu *sync.Mutex, args ...distro.Option) (*distro.Distro, error) {
 	GUID, err := uuid.Parse(in.GUID)
 	if err != nil {
 		return nil, err
 	}
-	return distro.New(ctx, in.Name, in.Properties, storageDir, startupMu, distro.WithGUID(GUID))
+	return distro.New(ctx, in.Name, in.Properties, storageDir, startupMu, append(args, distro.WithGUID(GUID), distro.WithTaskHistory(in.TaskHistory))...)
 }
 
 // newSerializableDistro takes the information in distro.Distro relevant to the database
 // and stores it the helper object.
 func newSerializableDistro(d *distro.Distro) serializableDistro {
 	return serializableDistro{
-		Name:       d.Name(),
-		GUID:       d.GUID(),
-		Properties: d.Properties(),
+		Version:     recordVersion(),
+		Name:        d.Name(),
+		GUID:        d.GUID(),
+		Properties:  d.Properties(),
+		TaskHistory: d.TaskHistory(),
 	}
 }
@@ -19,8 +19,9 @@ func TestSerializableDistroMarshallUnmarshall(t *testing.T) {
 
 	testCases := map[string]database.SerializableDistro{
 		"Normal case": {
-			Name: "Ubuntu",
-			GUID: "{12345678-1234-1234-1234-123456789abc}",
+			Version: database.RecordVersion(),
+			Name:    "Ubuntu",
+			GUID:    "{12345678-1234-1234-1234-123456789abc}",
 			Properties: distro.Properties{
 				DistroID:    "Ubuntu",
 				VersionID:   "98.04",
@@ -30,8 +31,9 @@ func TestSerializableDistroMarshallUnmarshall(t *testing.T) {
 			},
 		},
 		"Escaped characters": {
-			Name: "Ubuntu",
-			GUID: "{12345678-1234-1234-1234-123456789abc}",
+			Version: database.RecordVersion(),
+			Name:    "Ubuntu",
+			GUID:    "{12345678-1234-1234-1234-123456789abc}",
 			Properties: distro.Properties{
 				DistroID:    "Ubuntu",
 				VersionID:   "122.04",
@@ -41,8 +43,9 @@ func TestSerializableDistroMarshallUnmarshall(t *testing.T) {
 			},
 		},
 		"Control characters": {
-			Name: "Ubuntu",
-			GUID: "{12345678-1234-1234-1234-123456789abc}",
+			Version: database.RecordVersion(),
+			Name:    "Ubuntu",
+			GUID:    "{12345678-1234-1234-1234-123456789abc}",
 			Properties: distro.Properties{
 				DistroID:    "Ubuntu",
 				VersionID:   "122.04",
@@ -0,0 +1,378 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/ubuntu/decorate"
+	"gopkg.in/yaml.v3"
+)
+
+// storage is where the database persists its contents between runs.
+type storage interface {
+	// load returns the distros that were last saved, and the records that could not be parsed. Storage
+	// that was never saved to is empty.
+	load() ([]serializableDistro, []rejectedRecord, error)
+
+	// save replaces the contents of the storage with the given distros.
+	save(distros []serializableDistro) error
+}
+
+// fileStorage stores the database as a YAML file inside a directory. It is the default storage.
+type fileStorage struct {
+	dir string
+}
+
+// fileBackups is how many previous versions of the database file are kept, as backupPath(1) for the newest
+// to backupPath(fileBackups) for the oldest.
+const fileBackups = 3
+
+// backupPath returns the path of the nth newest backup of the database file.
+func (s fileStorage) backupPath(n int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s.bak.%d", consts.DatabaseFileName, n))
+}
+
+// load reads and parses the database file into intermediate objects.
+// A missing file is interpreted as an empty database. A file that cannot be read or parsed is
+// replaced by the newest backup that can, if any. Records that cannot be parsed in a file that can
+// are rejected on their own.
+func (s fileStorage) load() ([]serializableDistro, []rejectedRecord, error) {
+	distros, rejected, err := readDatabaseFile(filepath.Join(s.dir, consts.DatabaseFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err == nil {
+		return distros, rejected, nil
+	}
+
+	for n := 1; n <= fileBackups; n++ {
+		backup, rejected, backupErr := readDatabaseFile(s.backupPath(n))
+		if backupErr != nil {
+			continue
+		}
+		log.Warningf(context.Background(), "Database: falling back to backup %q: %v", s.backupPath(n), err)
+		return backup, rejected, nil
+	}
+
+	return nil, nil, err
+}
+
+// readDatabaseFile reads and parses the database file at path. Each record is parsed on its own, so that
+// those that cannot be are rejected without the rest of the file.
+func readDatabaseFile(path string) ([]serializableDistro, []rejectedRecord, error) {
+	out, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		return nil, nil, fmt.Errorf("could not unmarshal: %v", err)
+	}
+
+	// An empty file has no content.
+	var nodes []*yaml.Node
+	if len(doc.Content) > 0 {
+		if doc.Content[0].Kind != yaml.SequenceNode {
+			return nil, nil, errors.New("could not unmarshal: the database is not a list of distros")
+		}
+		nodes = doc.Content[0].Content
+	}
+
+	distros := make([]serializableDistro, 0, len(nodes))
+	var rejected []rejectedRecord
+	for i, node := range nodes {
+		var d serializableDistro
+		if err := node.Decode(&d); err != nil {
+			rejected = append(rejected, rejectNode(node, fmt.Errorf("could not unmarshal record %d: %v", i, err)))
+			continue
+		}
+		distros = append(distros, d)
+	}
+
+	return distros, rejected, nil
+}
+
+// save writes the database file, replacing the previous one only once the new one is complete and
+// flushed to disk, so that a crash cannot leave it half-written. The previous file is kept as the
+// newest backup, and the oldest backup beyond fileBackups is dropped.
+func (s fileStorage) save(distros []serializableDistro) error {
+	// Generate dump
+	out, err := yaml.Marshal(distros)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %v", err)
+	}
+
+	storagePath := filepath.Join(s.dir, consts.DatabaseFileName)
+	if err := writeSynced(storagePath+".new", out); err != nil {
+		return err
+	}
+
+	if err := s.rotateBackups(); err != nil {
+		// Losing a backup is no reason not to save the database.
+		log.Warningf(context.Background(), "Database: could not back up the previous database file: %v", err)
+	}
+
+	return os.Rename(storagePath+".new", storagePath)
+}
+
+// rotateBackups shifts the backups of the database file by one, dropping the oldest one, and copies
+// the current database file into the newest backup. Copying rather than renaming it means there is always
+// a database file, even if the agent stops before the new one replaces it.
+func (s fileStorage) rotateBackups() error {
+	current, err := os.ReadFile(filepath.Join(s.dir, consts.DatabaseFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for n := fileBackups - 1; n > 0; n-- {
+		err := os.Rename(s.backupPath(n), s.backupPath(n+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return writeSynced(s.backupPath(1), current)
+}
+
+// writeSynced writes data into the file at path and flushes it to disk.
+func writeSynced(path string, data []byte) (err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// memoryStorage keeps the database in memory only, so it is lost when the agent stops.
+// It is not thread-safe: the database serializes the calls.
+type memoryStorage struct {
+	distros []serializableDistro
+}
+
+func (s *memoryStorage) load() ([]serializableDistro, []rejectedRecord, error) {
+	return append([]serializableDistro(nil), s.distros...), nil, nil
+}
+
+func (s *memoryStorage) save(distros []serializableDistro) error {
+	s.distros = append([]serializableDistro(nil), distros...)
+	return nil
+}
+
+// quarantineSuffix is appended to the name of a database file that cannot be migrated into the store.
+const quarantineSuffix = ".corrupt"
+
+// migratedSuffix is appended to the name of the files of older versions once their contents are in the
+// store, so that they are not migrated again but can still be recovered.
+const migratedSuffix = ".migrated"
+
+// storeStorage keeps the database in the embedded store, with a record per distro.
+type storeStorage struct {
+	store *store.Store
+}
+
+// load reads every distro record in the store. They are sorted by key, which is the lowercase name.
+func (s storeStorage) load() (distros []serializableDistro, rejected []rejectedRecord, err error) {
+	err = s.store.View(func(tx *store.Tx) error {
+		distros, rejected, err = loadRecords(tx)
+		return err
+	})
+	return distros, rejected, err
+}
+
+// save replaces the distro records in the store in a single transaction, so that the database is never
+// left half-written.
+func (s storeStorage) save(distros []serializableDistro) error {
+	return s.store.Update(func(tx *store.Tx) error {
+		return saveRecords(tx, distros)
+	})
+}
+
+// loadRecords reads every distro record in the store. Records that cannot be parsed are rejected.
+func loadRecords(tx *store.Tx) ([]serializableDistro, []rejectedRecord, error) {
+	keys, err := tx.Keys(store.DistrosBucket)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	distros := make([]serializableDistro, 0, len(keys))
+	var rejected []rejectedRecord
+	for _, k := range keys {
+		out, err := tx.Get(store.DistrosBucket, k)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var d serializableDistro
+		if err := yaml.Unmarshal(out, &d); err != nil {
+			rejected = append(rejected, rejectBytes(out, fmt.Errorf("could not unmarshal distro %q: %v", k, err)))
+			continue
+		}
+		distros = append(distros, d)
+	}
+
+	return distros, rejected, nil
+}
+
+// saveRecords replaces the distro records in the store, and drops the task queues of the distros left
+// without a record, so that every queue in the store belongs to a distro in the database. Only the records
+// that changed are written, so that a change to one distro does not rewrite the whole database.
+func saveRecords(tx *store.Tx, distros []serializableDistro) error {
+	keep := make(map[string]bool, len(distros))
+	for _, d := range distros {
+		out, err := yaml.Marshal(d)
+		if err != nil {
+			return fmt.Errorf("could not marshal distro %q: %v", d.Name, err)
+		}
+
+		key := strings.ToLower(d.Name)
+		keep[key] = true
+
+		current, err := tx.Get(store.DistrosBucket, key)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(current, out) {
+			continue
+		}
+
+		if err := tx.Put(store.DistrosBucket, key, out); err != nil {
+			return err
+		}
+	}
+
+	keys, err := tx.Keys(store.DistrosBucket)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if keep[k] {
+			continue
+		}
+		if err := tx.Delete(store.DistrosBucket, k); err != nil {
+			return err
+		}
+	}
+
+	return worker.DropStoredTasks(tx, func(distroName string) bool {
+		return !keep[strings.ToLower(distroName)]
+	})
+}
+
+// hasRecord returns true if the store has a record of the distro with the given name and GUID. Records
+// of another distro that had the same name do not count.
+func hasRecord(tx *store.Tx, name, guid string) (bool, error) {
+	out, err := tx.Get(store.DistrosBucket, strings.ToLower(name))
+	if err != nil || out == nil {
+		return false, err
+	}
+
+	var d serializableDistro
+	if err := yaml.Unmarshal(out, &d); err != nil {
+		return false, fmt.Errorf("could not unmarshal distro %q: %v", name, err)
+	}
+
+	return strings.EqualFold(d.GUID, guid), nil
+}
+
+// migrateFiles moves the database file and the task files in storageDir into the store, in a single
+// transaction. The files are only renamed with migratedSuffix once their contents are safely in the
+// store, so that they are kept around in case the migration must be undone. Records already
+// in the store are newer than the files, so they are never overwritten. A database file that cannot be
+// parsed is quarantined next to where it was, so that it neither stops the agent nor is lost.
+func migrateFiles(ctx context.Context, s *store.Store, storageDir string) (err error) {
+	defer decorate.OnError(&err, "could not migrate the database files into the store")
+
+	dbPath := filepath.Join(storageDir, consts.DatabaseFileName)
+	files := fileStorage{dir: storageDir}
+
+	distros, rejected, err := files.load()
+	if err != nil {
+		log.Warningf(ctx, "Database: quarantining %q: %v", dbPath, err)
+		if err := os.Rename(dbPath, dbPath+quarantineSuffix); err != nil {
+			return fmt.Errorf("could not quarantine %q: %v", dbPath, err)
+		}
+		distros = nil
+	}
+
+	var legacy []string
+	var migrated bool
+	err = s.Update(func(tx *store.Tx) error {
+		if _, err := os.Stat(dbPath); err == nil {
+			current, err := tx.Keys(store.DistrosBucket)
+			if err != nil {
+				return err
+			}
+
+			if len(current) == 0 {
+				if err := saveRecords(tx, distros); err != nil {
+					return err
+				}
+				migrated = true
+			}
+			legacy = append(legacy, dbPath)
+		}
+
+		if _, err := os.Stat(dbPath + ".new"); err == nil {
+			legacy = append(legacy, dbPath+".new")
+		}
+
+		for n := 1; n <= fileBackups; n++ {
+			if _, err := os.Stat(files.backupPath(n)); err == nil {
+				legacy = append(legacy, files.backupPath(n))
+			}
+		}
+
+		tasks, err := worker.ImportTaskFiles(tx, storageDir)
+		if err != nil {
+			return err
+		}
+		legacy = append(legacy, tasks...)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if migrated {
+		// The records that could not be migrated are quarantined like those that cannot be loaded.
+		quarantineRecords(ctx, storageDir, rejected)
+	}
+
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	for _, path := range legacy {
+		if err := os.Rename(path, path+migratedSuffix); err != nil {
+			log.Warningf(ctx, "Database: could not rename %q after migrating it into the store: %v", path, err)
+		}
+	}
+
+	log.Infof(ctx, "Database: migrated %d files into the store", len(legacy))
+	return nil
+}
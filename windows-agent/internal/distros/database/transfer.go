@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/ubuntu/decorate"
+	"gopkg.in/yaml.v3"
+)
+
+// exportFormat is the version of the format of the exports. It only changes when older versions of the agent
+// could not import them: the records carry the version of their own schema.
+const exportFormat = 1
+
+// Export is the database and the task queues of its distros, in a stable JSON format meant for support and for
+// moving the agent to another machine. The records and the task queues are kept as they are stored, so that
+// importing them goes through the same migrations as loading them.
+type Export struct {
+	Format   int              `json:"format"`
+	Exported time.Time        `json:"exported"`
+	Distros  []ExportedDistro `json:"distros"`
+}
+
+// ExportedDistro is the record of a distro in an Export, with its task queue.
+type ExportedDistro struct {
+	Record any `json:"record"`
+	Tasks  any `json:"tasks,omitempty"`
+}
+
+// ExportTo writes the database stored in storageDir and the task queues of its distros into w as JSON. Like
+// ReadProperties, it reads a snapshot of the store, so that it is safe to use while the agent is running.
+func ExportTo(storageDir string, w io.Writer) (err error) {
+	defer decorate.OnError(&err, "could not export the database")
+
+	var st storage = fileStorage{dir: storageDir}
+	readTasks := func(name string) ([]byte, error) { return worker.ReadTaskFile(storageDir, name) }
+
+	snapshot, err := store.Snapshot(filepath.Join(storageDir, consts.StoreFileName))
+	if err != nil {
+		return err
+	}
+	if snapshot != nil {
+		defer snapshot.Close()
+		st = storeStorage{store: snapshot}
+		readTasks = func(name string) ([]byte, error) { return snapshot.Get(store.TasksBucket, name) }
+	}
+
+	distros, _, err := st.load()
+	if err != nil {
+		return err
+	}
+
+	out := Export{
+		Format:   exportFormat,
+		Exported: time.Now().UTC().Truncate(time.Second),
+		Distros:  make([]ExportedDistro, 0, len(distros)),
+	}
+
+	for _, d := range distros {
+		var e ExportedDistro
+		if e.Record, err = yamlToAny(d); err != nil {
+			return fmt.Errorf("distro %q: %v", d.Name, err)
+		}
+
+		tasks, err := readTasks(d.Name)
+		if err != nil {
+			return fmt.Errorf("distro %q: could not read tasks: %v", d.Name, err)
+		}
+		if tasks != nil {
+			if err := yaml.Unmarshal(tasks, &e.Tasks); err != nil {
+				return fmt.Errorf("distro %q: could not parse tasks: %v", d.Name, err)
+			}
+		}
+
+		out.Distros = append(out.Distros, e)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// ImportFrom replaces the database stored in storageDir and the task queues of its distros with the export read
+// from r, in a single transaction. The agent must not be running. Distros are matched by name to those registered
+// in WSL, whose GUID they take, so that the export can come from another machine. It returns the names of the
+// distros that were imported, and of those that were skipped because they are not registered.
+func ImportFrom(ctx context.Context, storageDir string, r io.Reader) (imported, skipped []string, err error) {
+	defer decorate.OnError(&err, "could not import the database")
+
+	var in Export
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, nil, fmt.Errorf("could not parse export: %v", err)
+	}
+	if in.Format != exportFormat {
+		return nil, nil, fmt.Errorf("unsupported export format %d: expected %d", in.Format, exportFormat)
+	}
+
+	guids := make(map[string]string)
+	for guid, name := range registeredNames(ctx) {
+		guids[strings.ToLower(name)] = guid
+	}
+
+	distros := make([]serializableDistro, 0, len(in.Distros))
+	tasks := make(map[string][]byte)
+	seen := make(map[string]bool)
+	for i, e := range in.Distros {
+		var d serializableDistro
+		if err := anyToYAML(e.Record, &d); err != nil {
+			return nil, nil, fmt.Errorf("record %d: %v", i, err)
+		}
+
+		key := strings.ToLower(d.Name)
+		if d.Name == "" || seen[key] {
+			return nil, nil, fmt.Errorf("record %d: missing or duplicate distro name %q", i, d.Name)
+		}
+		seen[key] = true
+
+		guid, ok := guids[key]
+		if !ok {
+			log.Warningf(ctx, "Database: skipping the import of distro %q: it is not registered", d.Name)
+			skipped = append(skipped, d.Name)
+			continue
+		}
+		d.GUID = guid
+		distros = append(distros, d)
+		imported = append(imported, d.Name)
+
+		if e.Tasks == nil {
+			continue
+		}
+		if tasks[d.Name], err = yaml.Marshal(e.Tasks); err != nil {
+			return nil, nil, fmt.Errorf("distro %q: could not marshal tasks: %v", d.Name, err)
+		}
+	}
+
+	s, err := store.Open(ctx, filepath.Join(storageDir, consts.StoreFileName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v (is the agent running?)", err)
+	}
+	defer s.Close()
+
+	err = s.Update(func(tx *store.Tx) error {
+		// Queues of the distros the export has no tasks for must not survive the import.
+		if err := worker.DropStoredTasks(tx, func(string) bool { return true }); err != nil {
+			return err
+		}
+
+		if err := saveRecords(tx, distros); err != nil {
+			return err
+		}
+
+		for name, t := range tasks {
+			if err := worker.PutStoredTasks(tx, name, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return imported, skipped, nil
+}
+
+// yamlToAny converts v into plain maps and slices as it is marshalled into YAML, so that it can be marshalled
+// into JSON with the same keys.
+func yamlToAny(v any) (out any, err error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal: %v", err)
+	}
+	if err := yaml.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("could not unmarshal: %v", err)
+	}
+	return out, nil
+}
+
+// anyToYAML is the inverse of yamlToAny: it unmarshals the plain maps and slices in v into out as YAML.
+func anyToYAML(v any, out any) error {
+	if v == nil {
+		return errors.New("empty record")
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %v", err)
+	}
+	if err := yaml.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("could not unmarshal: %v", err)
+	}
+	return nil
+}
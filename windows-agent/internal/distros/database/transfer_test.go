@@ -0,0 +1,140 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	wsl "github.com/ubuntu/gowsl"
+	wslmock "github.com/ubuntu/gowsl/mock"
+	"gopkg.in/yaml.v3"
+)
+
+// exportedTasks is a task queue as the worker stores it.
+const exportedTasks = `- type: tasks.ProAttachment
+  task:
+    token: secret
+`
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, guid := wsltestutils.RegisterDistro(t, ctx, false)
+	unregistered := distroID{Name: "NotRegistered", GUID: "{12345678-1234-1234-1234-123456789ABC}"}
+
+	testCases := map[string]struct {
+		fromStore   bool
+		otherGUID   bool
+		storeInUse  bool
+		badExport   string
+		wrongFormat bool
+
+		wantErr bool
+	}{
+		"Success moving the database files":               {},
+		"Success moving the database store":               {fromStore: true},
+		"Success moving the database to another machine":  {otherGUID: true},
+		"Error when the export cannot be parsed":          {badExport: "This is not JSON", wantErr: true},
+		"Error when the export has an unknown format":     {wrongFormat: true, wantErr: true},
+		"Error when a record has no name":                 {badExport: `{"format": 1, "distros": [{"record": {"guid": "1234"}}]}`, wantErr: true},
+		"Error when the store is in use by another agent": {storeInUse: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srcDir := t.TempDir()
+			databaseFromTemplate(t, srcDir, distroID{distroName, guid}, unregistered)
+
+			if tc.fromStore {
+				// Creating the database migrates the database file into the store. The tasks are stored afterwards,
+				// as their type is not registered in this test for the worker to load them.
+				st := openStore(t, srcDir)
+				db, err := database.New(ctx, srcDir, database.WithStore(st))
+				require.NoError(t, err, "Setup: New() should return no error")
+				db.Close(ctx)
+				require.NoError(t, st.Put(store.TasksBucket, distroName, []byte(exportedTasks)), "Setup: could not store tasks")
+				require.NoError(t, st.Close(), "Setup: could not close the store")
+			} else {
+				err := os.WriteFile(filepath.Join(srcDir, distroName+".tasks"), []byte(exportedTasks), 0600)
+				require.NoError(t, err, "Setup: could not write task file")
+			}
+
+			var buf bytes.Buffer
+			err := database.ExportTo(srcDir, &buf)
+			require.NoError(t, err, "ExportTo should return no error")
+
+			var export database.Export
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &export), "The export should be valid JSON")
+			// Loading the database into the store drops the distro that is not registered.
+			wantSkipped := []string{unregistered.Name}
+			if tc.fromStore {
+				wantSkipped = nil
+			}
+			require.Len(t, export.Distros, 1+len(wantSkipped), "Every distro should have been exported")
+
+			if tc.otherGUID {
+				record, ok := export.Distros[0].Record.(map[string]any)
+				require.True(t, ok, "Setup: unexpected type of record %T", export.Distros[0].Record)
+				record["guid"] = uuid.New().String()
+			}
+			if tc.wrongFormat {
+				export.Format = 1000
+			}
+			out, err := json.Marshal(export)
+			require.NoError(t, err, "Setup: could not marshal the export again")
+			if tc.badExport != "" {
+				out = []byte(tc.badExport)
+			}
+
+			dstDir := t.TempDir()
+			if tc.storeInUse {
+				openStore(t, dstDir)
+			}
+
+			imported, skipped, err := database.ImportFrom(ctx, dstDir, bytes.NewReader(out))
+			if tc.wantErr {
+				require.Error(t, err, "ImportFrom should return an error")
+				return
+			}
+			require.NoError(t, err, "ImportFrom should return no error")
+			require.Equal(t, []string{distroName}, imported, "Only the registered distro should have been imported")
+			require.Equal(t, wantSkipped, skipped, "The distro that is not registered should have been skipped")
+
+			props, err := database.ReadProperties(dstDir)
+			require.NoError(t, err, "ReadProperties should read the imported database")
+			require.Len(t, props, 1, "Only the registered distro should be in the imported database")
+			require.Equal(t, "SuperTestMachine", props[distroName].Hostname, "The properties should have been imported")
+			require.True(t, props[distroName].ProAttached, "The properties should have been imported")
+			require.False(t, props[distroName].LastSeen.IsZero(), "The properties should have been imported")
+
+			st := openStore(t, dstDir)
+
+			record, err := st.Get(store.DistrosBucket, strings.ToLower(distroName))
+			require.NoError(t, err, "Could not read the imported record")
+			require.Contains(t, strings.ToLower(string(record)), strings.ToLower(strings.Trim(guid, "{}")), "The distro should have taken the GUID it is registered with")
+
+			tasks, err := st.Get(store.TasksBucket, distroName)
+			require.NoError(t, err, "Could not read the imported tasks")
+			var want, got any
+			require.NoError(t, yaml.Unmarshal([]byte(exportedTasks), &want), "Setup: could not parse the exported tasks")
+			require.NoError(t, yaml.Unmarshal(tasks, &got), "The imported tasks should be valid YAML")
+			require.Equal(t, want, got, "The tasks should have been imported as they were stored")
+		})
+	}
+}
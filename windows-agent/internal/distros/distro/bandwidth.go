@@ -0,0 +1,36 @@
+package distro
+
+import (
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
+)
+
+// ReserveTransfer accounts for n bytes about to be transferred to or from the distro, through the meter the
+// distro was created with. The error wraps bandwidth.ErrCapReached if they would exceed the daily cap of the
+// distro. Without a meter, transfers are neither accounted for nor capped.
+func (d *Distro) ReserveTransfer(kind bandwidth.Kind, n uint64) error {
+	m, ok := bandwidth.FromContext(d.ctx)
+	if !ok {
+		return nil
+	}
+	return m.Reserve(d.Name(), kind, n)
+}
+
+// RecordTransfer accounts for n bytes transferred to or from the distro, through the meter the distro was created
+// with, whatever its daily cap.
+func (d *Distro) RecordTransfer(kind bandwidth.Kind, n uint64) {
+	m, ok := bandwidth.FromContext(d.ctx)
+	if !ok {
+		return
+	}
+	m.Record(d.Name(), kind, n)
+}
+
+// Bandwidth returns what the distro transferred, as accounted for by the meter the distro was created with. It
+// is empty without a meter.
+func (d *Distro) Bandwidth() bandwidth.Usage {
+	m, ok := bandwidth.FromContext(d.ctx)
+	if !ok {
+		return bandwidth.Usage{}
+	}
+	return m.Usage(d.Name())
+}
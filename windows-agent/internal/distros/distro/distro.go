@@ -6,10 +6,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
-	"sync/atomic"
+	"time"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consent"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
 	"github.com/google/uuid"
@@ -28,11 +31,32 @@ type Distro struct {
 	properties   Properties
 	propertiesMu sync.RWMutex
 
-	// invalidated is an internal value if distro can't be contacted through GRPC
-	invalidated atomic.Bool
+	// lifecycle tracks the stage of the distro. Once unregistered, the distro can't be contacted through GRPC.
+	lifecycle *lifecycle
+
+	// latency contains the round-trip times to the WSL-Pro-Service. It is not stored in the database.
+	latency   Latency
+	latencyMu sync.RWMutex
+
+	// liveState is the state of the distro in WSL as last observed. It is not stored in the database.
+	liveState   wsl.State
+	liveStateMu sync.RWMutex
+
+	// taskHistory is the outcome of the last tasks that finished, oldest first. It is stored in the database.
+	taskHistory   []worker.TaskRecord
+	taskHistoryMu sync.RWMutex
 
 	worker       workerInterface
 	stateManager *stateManager
+
+	// onChange is called every time the properties, the lifecycle stage or the live state of the distro change.
+	onChange func()
+
+	// onPropertiesChange is called every time SetProperties changes the properties of the distro.
+	onPropertiesChange func(d *Distro, old, new Properties)
+
+	// onTaskHistoryChange is called every time a task is added to the history.
+	onTaskHistoryChange func()
 }
 
 // workerInterface is an interface that is implements the task processing worker. It is intended
@@ -42,11 +66,23 @@ type workerInterface interface {
 	Connection() worker.Connection
 	SetConnection(worker.Connection)
 	SubmitTasks(...task.Task) error
+	SubmitAll([]task.Task) error
+	SubmitTask(task.Task) (worker.TaskID, error)
+	Cancel(worker.TaskID) error
 	SubmitDeferredTasks(...task.Task) error
 	EnqueueDeferredTasks()
+	PendingTasks() int
+	WatchTasks(context.Context) <-chan worker.Event
+	HandOff(context.Context, time.Duration)
 	Stop(context.Context)
 }
 
+// maxTaskHistory is the number of tasks kept in the history of a distro. Older ones are dropped.
+const maxTaskHistory = 20
+
+// stateUnknown is the live state of a distro until it is first observed. It is the zero value of wsl.State.
+const stateUnknown wsl.State = 0
+
 // NotValidError is a type returned when the (distroName, GUID) combination is not in the registry.
 type NotValidError struct{}
 
@@ -58,6 +94,12 @@ type options struct {
 	guid                  uuid.UUID
 	taskProcessingContext context.Context
 	newWorkerFunc         func(context.Context, *Distro, string) (workerInterface, error)
+	store                 *store.Store
+	owned                 func(tx *store.Tx, name, guid string) (bool, error)
+	onChange              func()
+	onPropertiesChange    func(d *Distro, old, new Properties)
+	onTaskHistoryChange   func()
+	taskHistory           []worker.TaskRecord
 }
 
 // Option is an optional argument for distro.New.
@@ -71,6 +113,51 @@ func WithGUID(guid uuid.UUID) Option {
 	}
 }
 
+// WithStore is an optional parameter for distro.New that stores the task queue of the distro in s
+// instead of in a file inside the storage directory. The queue is only written in transactions where
+// owned returns true for the name and GUID of the distro.
+func WithStore(s *store.Store, owned func(tx *store.Tx, name, guid string) (bool, error)) Option {
+	return func(o *options) {
+		o.store = s
+		o.owned = owned
+	}
+}
+
+// WithOnChange is an optional parameter for distro.New that sets a function to be called every time
+// the properties, the lifecycle stage or the live state of the distro change. It is called with the
+// distro locked, so it must not block nor use the distro.
+func WithOnChange(f func()) Option {
+	return func(o *options) {
+		o.onChange = f
+	}
+}
+
+// WithOnPropertiesChange is an optional parameter for distro.New that sets a function to be called every time
+// SetProperties changes the properties of the distro, with their values before and after. It is called with the
+// distro locked, so it must not block nor use the distro.
+func WithOnPropertiesChange(f func(d *Distro, old, new Properties)) Option {
+	return func(o *options) {
+		o.onPropertiesChange = f
+	}
+}
+
+// WithOnTaskHistoryChange is an optional parameter for distro.New that sets a function to be called every time
+// a task is added to the history of the distro, e.g. to store it. It is called from the goroutine processing the
+// tasks, without the distro locked, so it must not block.
+func WithOnTaskHistoryChange(f func()) Option {
+	return func(o *options) {
+		o.onTaskHistoryChange = f
+	}
+}
+
+// WithTaskHistory is an optional parameter for distro.New that sets the history of the tasks of the distro, as
+// it was stored. Only the last tasks are kept.
+func WithTaskHistory(history []worker.TaskRecord) Option {
+	return func(o *options) {
+		o.taskHistory = history
+	}
+}
+
 // New creates a new Distro object after searching for a distro with the given name.
 //
 //   - If identity.Name is not registered, a DistroDoesNotExist error is returned.
@@ -87,9 +174,20 @@ func New(ctx context.Context, name string, props Properties, storageDir string,
 	opts := options{
 		guid:                  nilGUID,
 		taskProcessingContext: context.Background(),
-		newWorkerFunc: func(ctx context.Context, d *Distro, dir string) (workerInterface, error) {
-			return worker.New(ctx, d, dir)
-		},
+		onChange:              func() {},
+		onPropertiesChange:    func(*Distro, Properties, Properties) {},
+		onTaskHistoryChange:   func() {},
+	}
+	opts.newWorkerFunc = func(ctx context.Context, d *Distro, dir string) (workerInterface, error) {
+		var args []worker.Option
+		if opts.store != nil {
+			var owned func(tx *store.Tx) (bool, error)
+			if opts.owned != nil {
+				owned = func(tx *store.Tx) (bool, error) { return opts.owned(tx, d.Name(), d.GUID()) }
+			}
+			args = append(args, worker.WithStore(opts.store, owned))
+		}
+		return worker.New(ctx, d, dir, args...)
 	}
 
 	for _, f := range args {
@@ -125,10 +223,15 @@ func New(ctx context.Context, name string, props Properties, storageDir string,
 	distro = &Distro{
 		identity:   id,
 		properties: props,
+		lifecycle:  newLifecycle(name, opts.onChange),
 		stateManager: &stateManager{
 			distroIdentity: id,
 			startupMu:      startupMu,
 		},
+		onChange:            opts.onChange,
+		onPropertiesChange:  opts.onPropertiesChange,
+		onTaskHistoryChange: opts.onTaskHistoryChange,
+		taskHistory:         lastTasks(slices.Clone(opts.taskHistory)),
 	}
 
 	distro.worker, err = opts.newWorkerFunc(opts.taskProcessingContext, distro, storageDir)
@@ -162,15 +265,24 @@ func (d *Distro) Properties() Properties {
 }
 
 // SetProperties sets the specified properties, and returns true if the set properties are
-// different from the original ones.
+// different from the original ones. Properties unknown to this version of the agent are kept,
+// and so are those the distro does not report.
 func (d *Distro) SetProperties(p Properties) bool {
 	d.propertiesMu.Lock()
 	defer d.propertiesMu.Unlock()
 
-	if d.properties == p {
+	p.unknown = d.properties.unknown
+	p.LandscapeManaged = d.properties.LandscapeManaged
+	p.LastSeen = d.properties.LastSeen
+	p.FirstUsed = d.properties.FirstUsed
+	if d.properties.equals(p) {
 		return false
 	}
+	old := d.properties
 	d.properties = p
+	d.lifecycle.update(d.ctx, p, func(*lifecycle) {})
+	d.onChange()
+	d.onPropertiesChange(d, old, p)
 	return true
 }
 
@@ -192,11 +304,15 @@ func (d *Distro) Connection() (worker.Connection, error) {
 	return d.worker.Connection(), nil
 }
 
-// SetConnection removes the connection associated with the distro.
+// SetConnection sets the connection associated with the distro, or removes it if conn is nil.
+// The distro moves to the Registered stage when the connection is removed, and to one of the
+// connected stages otherwise.
 func (d *Distro) SetConnection(conn worker.Connection) error {
 	// Allowing IsValid check to be bypassed when resetting the connection
 	if conn == nil {
 		d.worker.SetConnection(nil)
+		//nolint:errcheck // Disconnecting an unregistered distro is a no-op.
+		d.lifecycle.connect(d.ctx, false, d.Properties())
 		return nil
 	}
 
@@ -204,7 +320,144 @@ func (d *Distro) SetConnection(conn worker.Connection) error {
 		return &NotValidError{}
 	}
 	d.worker.SetConnection(conn)
-	return nil
+	d.setLiveState(wsl.Running)
+	return d.lifecycle.connect(d.ctx, true, d.Properties())
+}
+
+// SetManaged records whether the distro has been configured to be managed by Landscape, and returns
+// true if that changed its properties.
+func (d *Distro) SetManaged(ctx context.Context, managed bool) bool {
+	d.propertiesMu.Lock()
+	defer d.propertiesMu.Unlock()
+
+	if d.properties.LandscapeManaged == managed {
+		return false
+	}
+	d.properties.LandscapeManaged = managed
+	d.lifecycle.update(ctx, d.properties, func(*lifecycle) {})
+	return true
+}
+
+// SetLastSeen records the last time the WSL Pro service of the distro was heard from, with a precision of a
+// second, and returns true if that changed its properties.
+func (d *Distro) SetLastSeen(t time.Time) bool {
+	d.propertiesMu.Lock()
+	defer d.propertiesMu.Unlock()
+
+	t = t.UTC().Truncate(time.Second)
+	if d.properties.LastSeen.Equal(t) {
+		return false
+	}
+	d.properties.LastSeen = t
+	d.onChange()
+	return true
+}
+
+// SetFirstUsed records the first time the distro was launched by the user, with a precision of a second, and
+// returns true if that changed its properties. Later uses leave it unchanged.
+func (d *Distro) SetFirstUsed(t time.Time) bool {
+	d.propertiesMu.Lock()
+	defer d.propertiesMu.Unlock()
+
+	if !d.properties.FirstUsed.IsZero() {
+		return false
+	}
+	old := d.properties
+	d.properties.FirstUsed = t.UTC().Truncate(time.Second)
+	d.onChange()
+	d.onPropertiesChange(d, old, d.properties)
+	return true
+}
+
+// SetDegraded marks a connected distro as Degraded for the given reason, regardless of the outcome of its
+// tasks. It lasts until it is called with a nil reason or the connection is reset with SetConnection.
+func (d *Distro) SetDegraded(ctx context.Context, reason error) {
+	if reason != nil {
+		log.Warningf(ctx, "Distro %q: degraded: %v", d.Name(), reason)
+	}
+	d.lifecycle.update(ctx, d.Properties(), func(l *lifecycle) { l.reason = reason })
+}
+
+// RecordTaskResult records the outcome of a task of the distro: a connected distro is Degraded while
+// its last tasks failed, and recovers once one succeeds.
+func (d *Distro) RecordTaskResult(ctx context.Context, taskErr error) {
+	d.lifecycle.update(ctx, d.Properties(), func(l *lifecycle) { l.taskDone(taskErr) })
+}
+
+// AppendTaskHistory adds a task that finished to the history of the tasks of the distro, dropping the oldest one
+// if the history is full.
+func (d *Distro) AppendTaskHistory(r worker.TaskRecord) {
+	d.taskHistoryMu.Lock()
+	d.taskHistory = lastTasks(append(d.taskHistory, r))
+	d.taskHistoryMu.Unlock()
+
+	d.onTaskHistoryChange()
+}
+
+// TaskHistory returns the outcome of the last tasks of the distro that finished, oldest first.
+func (d *Distro) TaskHistory() []worker.TaskRecord {
+	d.taskHistoryMu.RLock()
+	defer d.taskHistoryMu.RUnlock()
+
+	return slices.Clone(d.taskHistory)
+}
+
+// lastTasks returns the last maxTaskHistory tasks of the history.
+func lastTasks(history []worker.TaskRecord) []worker.TaskRecord {
+	if len(history) <= maxTaskHistory {
+		return history
+	}
+	return slices.Clone(history[len(history)-maxTaskHistory:])
+}
+
+// Refuse marks the distro as having a WSL Pro service that cannot be managed for the given reason. The distro
+// is Degraded without going through the connected stages, as it is not given the connection, so no tasks are
+// sent to it. It lasts until the connection is reset with SetConnection.
+func (d *Distro) Refuse(ctx context.Context, reason error) error {
+	if !d.IsValid() {
+		return &NotValidError{}
+	}
+
+	log.Warningf(ctx, "Distro %q: refusing to manage it: %v", d.Name(), reason)
+	return d.lifecycle.refuse(d.ctx, reason)
+}
+
+// DegradedReason returns why the distro is in the Degraded stage, or an empty string if it is not.
+func (d *Distro) DegradedReason() string {
+	return d.lifecycle.degradedReason()
+}
+
+// UnsupportedReason returns why the Ubuntu Pro client cannot run in the distro, or an empty string if it can,
+// as last reported by the distro.
+func (d *Distro) UnsupportedReason() string {
+	return d.Properties().UnsupportedReason
+}
+
+// WslVersion returns the version of WSL the distro runs on, 1 or 2, as last reported by the distro. It is zero
+// if the distro never reported it.
+func (d *Distro) WslVersion() int {
+	return d.Properties().WslVersion
+}
+
+// RequestConsent asks the user to confirm what the prompt describes, through the consent broker the
+// distro was created with. Without a broker there is nobody to ask, so consent is never granted.
+func (d *Distro) RequestConsent(ctx context.Context, prompt string) (granted bool, err error) {
+	broker, ok := consent.FromContext(d.ctx)
+	if !ok {
+		return false, errors.New("there is no way to ask the user for consent")
+	}
+	return broker.Ask(ctx, d.Name(), prompt)
+}
+
+// Lifecycle returns the stage of its lifecycle the distro is in.
+func (d *Distro) Lifecycle() Lifecycle {
+	return d.lifecycle.get()
+}
+
+// WatchLifecycle returns a channel that receives an event every time the distro moves to a different
+// stage of its lifecycle. The channel is closed when the context is cancelled or the distro is cleaned up.
+func (d *Distro) WatchLifecycle(ctx context.Context) <-chan LifecycleEvent {
+	return d.lifecycle.watch(ctx)
 }
 
 // SubmitTasks enqueues one or more task on our current worker list.
@@ -216,6 +469,30 @@ func (d *Distro) SubmitTasks(tasks ...task.Task) (err error) {
 	return d.worker.SubmitTasks(tasks...)
 }
 
+// SubmitAll enqueues the tasks on our current worker list, all or nothing.
+// See Worker.SubmitAll for details.
+func (d *Distro) SubmitAll(tasks []task.Task) (err error) {
+	if !d.IsValid() {
+		return &NotValidError{}
+	}
+	return d.worker.SubmitAll(tasks)
+}
+
+// SubmitTask enqueues a task on our current worker list, and returns an ID to cancel it with.
+// See Worker.SubmitTask for details.
+func (d *Distro) SubmitTask(t task.Task) (id worker.TaskID, err error) {
+	if !d.IsValid() {
+		return 0, &NotValidError{}
+	}
+	return d.worker.SubmitTask(t)
+}
+
+// CancelTask aborts a task submitted with SubmitTask, whether it is queued or in progress.
+// See Worker.Cancel for details.
+func (d *Distro) CancelTask(id worker.TaskID) error {
+	return d.worker.Cancel(id)
+}
+
 // SubmitDeferredTasks enqueues one or more task on our current worker list.
 // See Worker.SubmitDeferredTasks for details.
 func (d *Distro) SubmitDeferredTasks(tasks ...task.Task) (err error) {
@@ -231,6 +508,29 @@ func (d *Distro) EnqueueDeferredTasks() {
 	d.worker.EnqueueDeferredTasks()
 }
 
+// PendingTasks returns the number of tasks waiting to be processed, including the deferred ones.
+func (d *Distro) PendingTasks() int {
+	return d.worker.PendingTasks()
+}
+
+// WatchTasks returns a channel that receives the lifecycle events of the tasks of this distro.
+// See Worker.WatchTasks for details.
+func (d *Distro) WatchTasks(ctx context.Context) (<-chan worker.Event, error) {
+	if !d.IsValid() {
+		return nil, &NotValidError{}
+	}
+	return d.worker.WatchTasks(ctx), nil
+}
+
+// HandOff stops processing the tasks of the distro ahead of the agent being updated, and stores those not completed
+// within the grace period for the next version to resume. See Worker.HandOff for details.
+func (d *Distro) HandOff(ctx context.Context, grace time.Duration) {
+	if d == nil {
+		return
+	}
+	d.worker.HandOff(ctx, grace)
+}
+
 // Cleanup releases all resources associated with the distro.
 func (d *Distro) Cleanup(ctx context.Context) {
 	if d == nil {
@@ -238,22 +538,22 @@ func (d *Distro) Cleanup(ctx context.Context) {
 	}
 	d.worker.Stop(ctx)
 	d.stateManager.reset()
+	d.lifecycle.removeAllWatchers()
 }
 
-// Invalidate sets the invalid flag to true. The state of this flag can be read with IsValid.
-// This is irreversible, once the flag is true there is no way of setting it bag to false.
+// Invalidate moves the distro to the Unregistered stage, which can be checked with IsValid.
+// This is irreversible, once unregistered there is no way of moving the distro to another stage.
 func (d *Distro) Invalidate(ctx context.Context) {
-	updated := d.invalidated.CompareAndSwap(false, true)
-	if updated {
+	if d.lifecycle.unregister(ctx) {
 		log.Infof(ctx, "distro %q: marked as no longer valid", d.Name())
 	}
 }
 
-// IsValid checks the registry to see if the distro is valid. If it is not, an internal flag
-// is set and all subsequent calls will return false automatically. This flag may also be set
-// directly via Invalidate.
+// IsValid checks the registry to see if the distro is valid. If it is not, the distro is
+// moved to the Unregistered stage and all subsequent calls will return false automatically.
+// The distro may also be unregistered directly via Invalidate.
 func (d *Distro) IsValid() bool {
-	if d.invalidated.Load() {
+	if d.lifecycle.get() == Unregistered {
 		return false
 	}
 
@@ -270,6 +570,50 @@ func (d *Distro) State() (s wsl.State, err error) {
 	return d.stateManager.state()
 }
 
+// LiveState returns the state of the WSL distro as last observed, either by RefreshState or by the agent waking
+// the distro up or its WSL Pro service connecting. Unlike State, it does not query WSL, so it may be outdated.
+// It is the zero wsl.State until the state is first observed.
+func (d *Distro) LiveState() wsl.State {
+	d.liveStateMu.RLock()
+	defer d.liveStateMu.RUnlock()
+
+	return d.liveState
+}
+
+// RefreshState queries the state of the WSL distro and records it as its live state, and returns true if that
+// changed it. The live state is left as it is if the state cannot be queried.
+func (d *Distro) RefreshState() bool {
+	// The WSL Pro service only runs while the distro does, which spares querying WSL.
+	if d.worker.Connection() != nil {
+		return d.setLiveState(wsl.Running)
+	}
+
+	s, err := d.State()
+	if err != nil {
+		return false
+	}
+	return d.setLiveState(s)
+}
+
+// setLiveState records the state of the WSL distro, and returns true if it changed.
+func (d *Distro) setLiveState(s wsl.State) bool {
+	d.liveStateMu.Lock()
+	defer d.liveStateMu.Unlock()
+
+	if d.liveState == s {
+		return false
+	}
+	old := d.liveState
+	d.liveState = s
+
+	// A distro whose state is unknown is as good as not running, so that first observing it only changes anything
+	// if it runs.
+	if old != stateUnknown || s == wsl.Running {
+		d.onChange()
+	}
+	return true
+}
+
 // LockAwake ensures that the distro will stay awake until ReleaseAwake is called.
 // ReleaseAwake must be called the same amount of times for the distro to be
 // allowed to stop.
@@ -280,7 +624,18 @@ func (d *Distro) LockAwake() error {
 	if !d.IsValid() {
 		return &NotValidError{}
 	}
-	return d.stateManager.lock(d.ctx)
+	if err := d.stateManager.lock(d.ctx); err != nil {
+		return err
+	}
+	d.setLiveState(wsl.Running)
+	return nil
+}
+
+// KeptAwake returns true if the agent is keeping the distro awake, i.e. it was started by the agent rather
+// than by the user unless the user launched it before. It waits for the distro to be woken up if it is
+// being woken up.
+func (d *Distro) KeptAwake() bool {
+	return d.stateManager.locked()
 }
 
 // ReleaseAwake undoes the last call to LockAwake. If this was the last call, the
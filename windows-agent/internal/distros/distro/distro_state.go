@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro/touchdistro"
 	wsl "github.com/ubuntu/gowsl"
@@ -97,6 +98,15 @@ func (m *stateManager) release() error {
 	return nil
 }
 
+// locked returns true if the internal counter is above zero. As lock holds the mutex while waking the distro
+// up, it waits for the distro to be awake if it is being woken up.
+func (m *stateManager) locked() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.refcount > 0
+}
+
 // reset returns the count back to zero. Equivalent to unlocking all standing locks.
 func (m *stateManager) reset() {
 	m.mu.Lock()
@@ -128,6 +138,7 @@ func (m *stateManager) keepAwake(ctx context.Context) (err error) {
 
 	// Keep distro awake
 	go func() {
+		defer crashreport.Recover("distro")
 		for {
 			select {
 			case <-ctx.Done():
@@ -3,13 +3,18 @@ package distro_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"testing"
 	"time"
 
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consent"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
@@ -208,6 +213,13 @@ func TestSetProperties(t *testing.T) {
 		VersionID:   "100.04",
 		PrettyName:  "Ubuntu 100.04.0 LTS",
 		ProAttached: true,
+		ProServices: []string{"esm-apps", "esm-infra"},
+		Security:    distro.SecurityStatus{Known: true, StandardUpdates: 1},
+
+		ServiceVersion:   "1.2.3",
+		LandscapeManaged: true,
+		LastSeen:         time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		FirstUsed:        time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
 	}
 
 	props2 := distro.Properties{
@@ -218,12 +230,24 @@ func TestSetProperties(t *testing.T) {
 	}
 
 	testCases := map[string]struct {
-		sameProps bool
+		sameProps         bool
+		differentServices bool
+		differentSecurity bool
+		differentVersion  bool
+		notManaged        bool
+		notSeen           bool
+		notUsed           bool
 
 		want bool
 	}{
-		"Return true when setting a new set of properties":     {want: true},
-		"Return false when setting the same set of properties": {sameProps: true, want: false},
+		"Return true when setting a new set of properties":                {want: true},
+		"Return true when only the Pro services change":                   {sameProps: true, differentServices: true, want: true},
+		"Return true when only the security status changes":               {sameProps: true, differentSecurity: true, want: true},
+		"Return true when only the service version changes":               {sameProps: true, differentVersion: true, want: true},
+		"Return false when setting the same set of properties":            {sameProps: true, want: false},
+		"Return false when only the Landscape management is not reported": {sameProps: true, notManaged: true, want: false},
+		"Return false when only the last time seen is not reported":       {sameProps: true, notSeen: true, want: false},
+		"Return false when only the first use is not reported":            {sameProps: true, notUsed: true, want: false},
 	}
 
 	for name, tc := range testCases {
@@ -241,10 +265,174 @@ func TestSetProperties(t *testing.T) {
 			p := props2
 			if tc.sameProps {
 				p = props1
+				p.ProServices = slices.Clone(props1.ProServices)
+			}
+			if tc.differentServices {
+				p.ProServices = []string{"esm-apps"}
+			}
+			if tc.differentSecurity {
+				p.Security.ESMUpdates = 3
+			}
+			if tc.differentVersion {
+				p.ServiceVersion = "1.2.4"
+			}
+			if tc.notManaged {
+				p.LandscapeManaged = false
+			}
+			if tc.notSeen {
+				p.LastSeen = time.Time{}
+			}
+			if tc.notUsed {
+				p.FirstUsed = time.Time{}
 			}
 
 			got := d.SetProperties(p)
 			require.Equal(t, tc.want, got, "Unexpected return value from SetProperties")
+			require.True(t, d.Properties().LandscapeManaged, "SetProperties should keep whether the distro is managed by Landscape")
+			require.Equal(t, props1.LastSeen, d.Properties().LastSeen, "SetProperties should keep the last time the distro was seen")
+			require.Equal(t, props1.FirstUsed, d.Properties().FirstUsed, "SetProperties should keep the first time the distro was used")
+		})
+	}
+}
+
+func TestSetLastSeen(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	seen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := map[string]struct {
+		at time.Time
+
+		want bool
+	}{
+		"Return true when the distro is seen at a new time":                 {at: seen.Add(time.Minute), want: true},
+		"Return false when the distro is seen at the same time":             {at: seen, want: false},
+		"Return false when the distro is seen again within the same second": {at: seen.Add(500 * time.Millisecond), want: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			dname, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			d, err := distro.New(ctx, dname, distro.Properties{LastSeen: seen}, t.TempDir(), startupMutex())
+			require.NoError(t, err, "Setup: distro New should return no errors")
+
+			got := d.SetLastSeen(tc.at)
+			require.Equal(t, tc.want, got, "Unexpected return value from SetLastSeen")
+			require.Equal(t, tc.at.Truncate(time.Second), d.Properties().LastSeen, "SetLastSeen should record the time with a precision of a second")
+		})
+	}
+}
+
+func TestTaskHistory(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	stored := []worker.TaskRecord{{Task: "Stored task", Type: "*tasks.ProAttachment"}}
+
+	testCases := map[string]struct {
+		stored   []worker.TaskRecord
+		appended int
+
+		wantFirst string
+		wantLen   int
+	}{
+		"Success with no history":                           {},
+		"Success loading the stored history":                {stored: stored, wantFirst: "Stored task", wantLen: 1},
+		"Success appending tasks to the stored history":     {stored: stored, appended: 2, wantFirst: "Stored task", wantLen: 3},
+		"Success dropping the oldest tasks when it is full": {stored: stored, appended: distro.MaxTaskHistory + 1, wantFirst: "Task 1", wantLen: distro.MaxTaskHistory},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			var changes int
+			dname, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			d, err := distro.New(ctx, dname, distro.Properties{}, t.TempDir(), startupMutex(),
+				distro.WithTaskHistory(tc.stored), distro.WithOnTaskHistoryChange(func() { changes++ }))
+			require.NoError(t, err, "Setup: distro New should return no errors")
+
+			for i := range tc.appended {
+				d.AppendTaskHistory(worker.TaskRecord{Task: fmt.Sprintf("Task %d", i)})
+			}
+
+			history := d.TaskHistory()
+			require.Len(t, history, tc.wantLen, "Unexpected number of tasks in the history")
+			require.Equal(t, tc.appended, changes, "The callback should be called for every task added to the history")
+			if tc.wantLen == 0 {
+				return
+			}
+
+			require.Equal(t, tc.wantFirst, history[0].Task, "Unexpected oldest task in the history")
+			if tc.appended > 0 {
+				require.Equal(t, fmt.Sprintf("Task %d", tc.appended-1), history[len(history)-1].Task, "The last task appended should be the newest in the history")
+			}
+		})
+	}
+}
+
+func TestSetFirstUsed(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	used := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := map[string]struct {
+		usedBefore bool
+
+		want bool
+	}{
+		"Return true when the distro is used for the first time": {want: true},
+
+		"Return false when the distro was used before": {usedBefore: true, want: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			var props distro.Properties
+			wantUsed := used.Add(time.Hour)
+			if tc.usedBefore {
+				props.FirstUsed = used
+				wantUsed = used
+			}
+
+			var changes []distro.Properties
+			onChange := func(_ *distro.Distro, _, new distro.Properties) { changes = append(changes, new) }
+
+			dname, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			d, err := distro.New(ctx, dname, props, t.TempDir(), startupMutex(), distro.WithOnPropertiesChange(onChange))
+			require.NoError(t, err, "Setup: distro New should return no errors")
+
+			got := d.SetFirstUsed(used.Add(time.Hour + 500*time.Millisecond))
+			require.Equal(t, tc.want, got, "Unexpected return value from SetFirstUsed")
+			require.Equal(t, wantUsed, d.Properties().FirstUsed, "SetFirstUsed should only record the first use, with a precision of a second")
+
+			if !tc.want {
+				require.Empty(t, changes, "SetFirstUsed should not report a change of the properties when they did not change")
+				return
+			}
+			require.Len(t, changes, 1, "SetFirstUsed should report the change of the properties")
+			require.Equal(t, wantUsed, changes[0].FirstUsed, "SetFirstUsed should report the new properties")
 		})
 	}
 }
@@ -334,6 +522,8 @@ func TestLockReleaseAwake(t *testing.T) {
 				return
 			}
 			require.NoErrorf(t, err, "LockAwake should have returned no error")
+			require.True(t, d.KeptAwake(), "The distro should be kept awake after calling LockAwake")
+			require.Equal(t, wsl.Running, d.LiveState(), "The distro should be known to be running after calling LockAwake")
 
 			require.Eventually(t, func() bool {
 				return wsltestutils.DistroState(t, ctx, distroName) == "Running"
@@ -412,6 +602,8 @@ func TestLockReleaseAwake(t *testing.T) {
 				return state == wsl.Stopped
 			}, wslSleepDelay+2*time.Second, time.Second, "distro should have stopped after calling ReleaseAwake due to inactivity.")
 
+			require.False(t, d.KeptAwake(), "The distro should not be kept awake once released")
+
 			// Try one more ReleaseAwake than needed
 			err = d.ReleaseAwake()
 			require.Error(t, err, "ReleaseAwake should return and error when called more times than LockAwake")
@@ -519,14 +711,22 @@ func TestState(t *testing.T) {
 				require.NoError(t, err, "Setup: could not unregister: %v", err)
 			}
 
+			var unknown wsl.State
+			require.Equal(t, unknown, d.LiveState(), "The live state should be unknown until it is refreshed")
+
 			got, err := d.State()
 			if tc.wantErr {
 				require.Error(t, err, "expected distro.State to return an error")
+				require.False(t, d.RefreshState(), "RefreshState should not change the live state when the state cannot be queried")
+				require.Equal(t, unknown, d.LiveState(), "The live state should be left unknown when the state cannot be queried")
 				return
 			}
 
 			require.NoError(t, err, "expected distro.State to return no error")
 			require.Equal(t, tc.want, got, "Mismatch between expected and reported states")
+
+			require.True(t, d.RefreshState(), "RefreshState should report the change of the live state")
+			require.Equal(t, tc.want, d.LiveState(), "Mismatch between expected and live states")
 		})
 	}
 }
@@ -616,6 +816,224 @@ func TestInvalidateIdempotent(t *testing.T) {
 	require.False(t, (*w).stopCalled, "worker Stop should not be called in subsequent invalidations")
 }
 
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	const failed = "3 tasks failed in a row, the last one with: mock error"
+
+	testCases := map[string]struct {
+		proAttached bool
+		managed     bool
+		steps       []string
+
+		want       distro.Lifecycle
+		wantEvents []distro.Lifecycle
+		wantReason string
+	}{
+		"Starts as registered": {want: distro.Registered},
+
+		"Connecting moves to connected":                          {steps: []string{"connect"}, want: distro.Connected, wantEvents: []distro.Lifecycle{distro.Connected}},
+		"Connecting a Pro-attached distro moves to provisioned":  {proAttached: true, steps: []string{"connect"}, want: distro.Provisioned, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned}},
+		"Connecting a distro stored as managed moves to managed": {proAttached: true, managed: true, steps: []string{"connect"}, want: distro.Managed, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned, distro.Managed}},
+		"Attaching to Pro moves to provisioned":                  {steps: []string{"connect", "attach"}, want: distro.Provisioned, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned}},
+		"Detaching from Pro moves back to connected":             {proAttached: true, steps: []string{"connect", "detach"}, want: distro.Connected, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned, distro.Connected}},
+		"Configuring Landscape moves to managed":                 {steps: []string{"connect", "attach", "manage"}, want: distro.Managed, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned, distro.Managed}},
+		"Configuring Landscape without Pro remains connected":    {steps: []string{"connect", "manage"}, want: distro.Connected, wantEvents: []distro.Lifecycle{distro.Connected}},
+		"Attaching a distro configured for Landscape manages it": {steps: []string{"connect", "manage", "attach"}, want: distro.Managed, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned, distro.Managed}},
+		"Removing the Landscape config moves back":               {steps: []string{"connect", "attach", "manage", "unmanage"}, want: distro.Provisioned, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned, distro.Managed, distro.Provisioned}},
+		"Detaching a managed distro moves back to connected":     {steps: []string{"connect", "attach", "manage", "detach"}, want: distro.Connected, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned, distro.Managed, distro.Provisioned, distro.Connected}},
+		"A failed task does not degrade the distro":              {steps: []string{"connect", "fail", "fail"}, want: distro.Connected, wantEvents: []distro.Lifecycle{distro.Connected}},
+		"Consecutive failed tasks move to degraded":              {steps: []string{"connect", "fail", "fail", "fail"}, want: distro.Degraded, wantEvents: []distro.Lifecycle{distro.Connected, distro.Degraded}, wantReason: failed},
+		"A successful task resets the failed tasks":              {steps: []string{"connect", "fail", "fail", "succeed", "fail"}, want: distro.Connected, wantEvents: []distro.Lifecycle{distro.Connected}},
+		"A successful task recovers from degraded":               {proAttached: true, steps: []string{"connect", "fail", "fail", "fail", "succeed"}, want: distro.Provisioned, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned, distro.Degraded, distro.Provisioned}},
+		"Degrading moves to degraded":                            {steps: []string{"connect", "degrade"}, want: distro.Degraded, wantEvents: []distro.Lifecycle{distro.Connected, distro.Degraded}, wantReason: "mock degradation"},
+		"A successful task does not recover from degrading":      {steps: []string{"connect", "degrade", "succeed"}, want: distro.Degraded, wantEvents: []distro.Lifecycle{distro.Connected, distro.Degraded}, wantReason: "mock degradation"},
+		"Clearing the degradation recovers":                      {steps: []string{"connect", "degrade", "recover"}, want: distro.Connected, wantEvents: []distro.Lifecycle{distro.Connected, distro.Degraded, distro.Connected}},
+		"Disconnecting moves back to registered":                 {steps: []string{"connect", "degrade", "disconnect"}, want: distro.Registered, wantEvents: []distro.Lifecycle{distro.Connected, distro.Degraded, distro.Registered}},
+		"Reconnecting after failures is not degraded":            {steps: []string{"connect", "fail", "fail", "fail", "disconnect", "connect", "fail"}, want: distro.Connected, wantEvents: []distro.Lifecycle{distro.Connected, distro.Degraded, distro.Registered, distro.Connected}},
+		"Facts recorded while disconnected apply on connect":     {steps: []string{"attach", "manage", "connect"}, want: distro.Managed, wantEvents: []distro.Lifecycle{distro.Connected, distro.Provisioned, distro.Managed}},
+		"Refusing to manage moves to degraded":                   {steps: []string{"refuse"}, want: distro.Degraded, wantEvents: []distro.Lifecycle{distro.Degraded}, wantReason: "mock refusal"},
+		"Refusing to manage never moves to connected":            {proAttached: true, steps: []string{"refuse", "attach"}, want: distro.Degraded, wantEvents: []distro.Lifecycle{distro.Degraded}, wantReason: "mock refusal"},
+		"Disconnecting a refused distro moves back":              {steps: []string{"refuse", "disconnect"}, want: distro.Registered, wantEvents: []distro.Lifecycle{distro.Degraded, distro.Registered}},
+		"Invalidating moves to unregistered":                     {steps: []string{"connect", "invalidate"}, want: distro.Unregistered, wantEvents: []distro.Lifecycle{distro.Connected, distro.Unregistered}},
+
+		"Unregistered is terminal": {steps: []string{"invalidate", "connect", "attach", "manage", "fail", "disconnect"}, want: distro.Unregistered, wantEvents: []distro.Lifecycle{distro.Unregistered}},
+	}
+
+	for name, tc := range testCases {
+		distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			inj, _ := mockWorkerInjector(false)
+			d, err := distro.New(ctx, distroName, distro.Properties{ProAttached: tc.proAttached, LandscapeManaged: tc.managed}, t.TempDir(), &globalStartupMu, inj)
+			require.NoError(t, err, "Setup: distro New should return no error")
+
+			events := d.WatchLifecycle(ctx)
+
+			for _, step := range tc.steps {
+				switch step {
+				case "connect":
+					_ = d.SetConnection(&mockConnection{})
+				case "disconnect":
+					_ = d.SetConnection(nil)
+				case "attach", "detach":
+					d.SetProperties(distro.Properties{ProAttached: step == "attach"})
+				case "manage", "unmanage":
+					d.SetManaged(ctx, step == "manage")
+				case "fail":
+					d.RecordTaskResult(ctx, errors.New("mock error"))
+				case "succeed":
+					d.RecordTaskResult(ctx, nil)
+				case "degrade":
+					d.SetDegraded(ctx, errors.New("mock degradation"))
+				case "recover":
+					d.SetDegraded(ctx, nil)
+				case "refuse":
+					_ = d.Refuse(ctx, errors.New("mock refusal"))
+				case "invalidate":
+					d.Invalidate(ctx)
+				default:
+					require.Failf(t, "Setup: unknown step", "%q", step)
+				}
+			}
+
+			require.Equal(t, tc.want, d.Lifecycle(), "Distro should be in the expected stage of its lifecycle")
+			require.Equal(t, tc.wantReason, d.DegradedReason(), "Distro should report why it is degraded, and only then")
+
+			// Cleaning up closes the watcher, so that the events can be drained.
+			d.Cleanup(ctx)
+
+			var got []distro.Lifecycle
+			from := distro.Registered
+			for ev := range events {
+				require.Equal(t, from, ev.From, "Lifecycle event should start at the stage the previous one ended at")
+				got = append(got, ev.To)
+				from = ev.To
+			}
+			require.Equal(t, tc.wantEvents, got, "Lifecycle events should match the transitions")
+		})
+	}
+}
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestRequestConsent(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	testCases := map[string]struct {
+		noBroker bool
+
+		wantErr bool
+	}{
+		"Success asking the user through the broker": {},
+
+		"Error when there is no broker to ask the user through": {noBroker: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			broker := consent.New(consent.Policy{Timeout: time.Minute})
+			distroCtx := ctx
+			if !tc.noBroker {
+				distroCtx = consent.WithBroker(ctx, broker)
+			}
+
+			inj, _ := mockWorkerInjector(false)
+			d, err := distro.New(distroCtx, distroName, distro.Properties{}, t.TempDir(), &globalStartupMu, inj)
+			require.NoError(t, err, "Setup: distro New should return no error")
+			defer d.Cleanup(ctx)
+
+			requests := broker.Watch(ctx)
+			go func() {
+				req := <-requests
+				//nolint:errcheck // The test fails below if the answer is not relayed
+				broker.Answer(req.ID, req.Distro == distroName)
+			}()
+
+			granted, err := d.RequestConsent(ctx, "mock prompt")
+			if tc.wantErr {
+				require.Error(t, err, "RequestConsent should return an error")
+				require.False(t, granted, "RequestConsent should not grant consent when it fails")
+				return
+			}
+			require.NoError(t, err, "RequestConsent should return no error")
+			require.True(t, granted, "RequestConsent should relay the answer to the request made for the distro")
+		})
+	}
+}
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestTransfers(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	testCases := map[string]struct {
+		noMeter bool
+
+		wantToday uint64
+		wantErr   bool
+	}{
+		"Success accounting and capping the transfers through the meter": {wantToday: 1000, wantErr: true},
+		"Success neither accounting nor capping without meter":           {noMeter: true},
+	}
+
+	for name, tc := range testCases {
+		distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			meter := bandwidth.New()
+			meter.SetDailyCap(1000)
+			distroCtx := ctx
+			if !tc.noMeter {
+				distroCtx = bandwidth.WithMeter(ctx, meter)
+			}
+
+			inj, _ := mockWorkerInjector(false)
+			d, err := distro.New(distroCtx, distroName, distro.Properties{}, t.TempDir(), &globalStartupMu, inj)
+			require.NoError(t, err, "Setup: distro New should return no error")
+			defer d.Cleanup(ctx)
+
+			d.RecordTransfer(bandwidth.KindFiles, 400)
+			require.NoError(t, d.ReserveTransfer(bandwidth.KindDebs, 600), "ReserveTransfer should return no error below the cap")
+
+			err = d.ReserveTransfer(bandwidth.KindLogs, 1)
+			if tc.wantErr {
+				require.ErrorIs(t, err, bandwidth.ErrCapReached, "ReserveTransfer should refuse transfers over the cap")
+			} else {
+				require.NoError(t, err, "ReserveTransfer should return no error")
+			}
+
+			require.Equal(t, tc.wantToday, d.Bandwidth().TodayTotal(), "Unexpected bytes transferred today")
+			require.Equal(t, tc.wantToday, meter.Usage(distroName).TodayTotal(), "The transfers should be accounted for under the name of the distro")
+		})
+	}
+}
+
 //nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
 func TestWorkerWrappers(t *testing.T) {
 	ctx := context.Background()
@@ -649,6 +1067,12 @@ func TestWorkerWrappers(t *testing.T) {
 		"SubmitTasks succeeds with arguments":  {function: "SubmitTasks", wantWorkerCalled: true},
 		"SubmitTasks errors on invalid distro": {function: "SubmitTasks", invalidDistro: true, wantErr: true},
 
+		"SubmitAll succeeds":                 {function: "SubmitAll", wantWorkerCalled: true},
+		"SubmitAll errors on invalid distro": {function: "SubmitAll", invalidDistro: true, wantErr: true},
+
+		"WatchTasks succeeds":                 {function: "WatchTasks", wantWorkerCalled: true},
+		"WatchTasks errors on invalid distro": {function: "WatchTasks", invalidDistro: true, wantErr: true},
+
 		"Stop succeeds":                 {function: "Stop", wantWorkerCalled: true},
 		"Stop errors on invalid distro": {function: "Stop", invalidDistro: true, wantWorkerCalled: true},
 	}
@@ -691,6 +1115,14 @@ func TestWorkerWrappers(t *testing.T) {
 				err = d.SubmitTasks(t...)
 				funcCalled = worker.submitTasksCalled
 
+			case "SubmitAll":
+				err = d.SubmitAll(make([]task.Task, 5))
+				funcCalled = worker.submitTasksCalled
+
+			case "WatchTasks":
+				_, err = d.WatchTasks(ctx)
+				funcCalled = worker.watchTasksCalled
+
 			case "Stop":
 				d.Cleanup(context.Background())
 				funcCalled = worker.stopCalled
@@ -714,6 +1146,86 @@ func TestWorkerWrappers(t *testing.T) {
 	}
 }
 
+func TestPing(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		notConnected  bool
+		invalidDistro bool
+		connErr       bool
+		badEcho       bool
+		timeout       bool
+
+		wantErr bool
+	}{
+		"Success": {},
+
+		"Error when the distro is not connected": {notConnected: true, wantErr: true},
+		"Error when the distro is not valid":     {invalidDistro: true, wantErr: true},
+		"Error when the ping fails":              {connErr: true, wantErr: true},
+		"Error when the payload is not echoed":   {badEcho: true, wantErr: true},
+		"Error when the ping times out":          {timeout: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), startupMutex())
+			require.NoError(t, err, "Setup: distro New should return no error")
+			defer d.Cleanup(context.Background())
+
+			if !tc.notConnected {
+				err = d.SetConnection(&pingConnection{err: tc.connErr, badEcho: tc.badEcho, block: tc.timeout})
+				require.NoError(t, err, "Setup: could not set the connection")
+			}
+
+			if tc.invalidDistro {
+				d.Invalidate(ctx)
+			}
+
+			// Ping twice to check the statistics are accumulated.
+			for range 2 {
+				ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+				_, err = d.Ping(ctx)
+				cancel()
+				if tc.wantErr {
+					require.Error(t, err, "Ping should have returned an error")
+				} else {
+					require.NoError(t, err, "Ping should have returned no error")
+				}
+			}
+
+			l := d.Latency()
+			if tc.notConnected || tc.invalidDistro {
+				require.Zero(t, l, "Latency should not have been recorded when the ping could not be sent")
+				return
+			}
+
+			require.False(t, l.LastProbe.IsZero(), "The time of the latest ping should have been recorded")
+			if tc.wantErr {
+				require.Error(t, l.LastErr, "The error of the latest ping should have been recorded")
+				require.Equal(t, 2, l.Failures, "Failed pings should have been counted")
+				require.Zero(t, l.Samples, "Failed pings should not count as samples")
+				return
+			}
+
+			require.NoError(t, l.LastErr, "No error should have been recorded")
+			require.Equal(t, 2, l.Samples, "Successful pings should have been counted")
+			require.Zero(t, l.Failures, "No failures should have been counted")
+			require.Positive(t, l.Last, "The latest round-trip time should have been recorded")
+			require.LessOrEqual(t, l.Min, l.Mean, "The minimum round-trip time should not exceed the mean")
+			require.LessOrEqual(t, l.Mean, l.Max, "The mean round-trip time should not exceed the maximum")
+		})
+	}
+}
+
 func TestUninstall(t *testing.T) {
 	if wsl.MockAvailable() {
 		t.Parallel()
@@ -774,6 +1286,7 @@ type mockWorker struct {
 	connectionCalled    bool
 	setConnectionCalled bool
 	submitTasksCalled   bool
+	watchTasksCalled    bool
 	stopCalled          bool
 }
 
@@ -814,6 +1327,20 @@ func (w *mockWorker) SubmitTasks(...task.Task) error {
 	return nil
 }
 
+func (w *mockWorker) SubmitAll([]task.Task) error {
+	w.submitTasksCalled = true
+	return nil
+}
+
+func (w *mockWorker) SubmitTask(task.Task) (worker.TaskID, error) {
+	w.submitTasksCalled = true
+	return 1, nil
+}
+
+func (w *mockWorker) Cancel(worker.TaskID) error {
+	return nil
+}
+
 func (w *mockWorker) SubmitDeferredTasks(...task.Task) error {
 	return nil
 }
@@ -822,6 +1349,17 @@ func (w *mockWorker) EnqueueDeferredTasks() {
 	panic("Not implemented")
 }
 
+func (w *mockWorker) PendingTasks() int {
+	return 0
+}
+
+func (w *mockWorker) WatchTasks(context.Context) <-chan worker.Event {
+	w.watchTasksCalled = true
+	return nil
+}
+
+func (w *mockWorker) HandOff(context.Context, time.Duration) {}
+
 func (w *mockWorker) Stop(context.Context) {
 	w.stopCalled = true
 }
@@ -836,5 +1374,51 @@ func (c *mockConnection) SendLandscapeConfig(lpeConfig string) error {
 	return nil
 }
 
+func (c *mockConnection) SendCommand(cmd *agentapi.Command) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *mockConnection) InLane(context.Context, task.Lane) task.Connection {
+	return c
+}
+
+func (c *mockConnection) Preempt(task.Lane) error {
+	return nil
+}
+
+func (c *mockConnection) TailLog(ctx context.Context, cmd *agentapi.TailLogCmd, send func(string) error) error {
+	return nil
+}
+
+func (c *mockConnection) Ping(ctx context.Context, payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
 func (c *mockConnection) Close() {
 }
+
+// pingConnection is a connection that echoes the payload of ping commands.
+type pingConnection struct {
+	mockConnection
+
+	err     bool
+	badEcho bool
+	// block makes pings wait until they are cancelled.
+	block bool
+}
+
+func (c *pingConnection) Ping(ctx context.Context, payload []byte) ([]byte, error) {
+	time.Sleep(time.Millisecond)
+
+	if c.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if c.err {
+		return nil, errors.New("mock error")
+	}
+	if c.badEcho {
+		return []byte("not the payload"), nil
+	}
+	return payload, nil
+}
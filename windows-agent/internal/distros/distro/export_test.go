@@ -29,3 +29,6 @@ type Identity = identity
 func (d *Distro) GetIdentity() *Identity {
 	return &d.identity
 }
+
+// MaxTaskHistory is the number of tasks kept in the history of a distro.
+const MaxTaskHistory = maxTaskHistory
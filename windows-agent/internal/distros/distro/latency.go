@@ -0,0 +1,90 @@
+package distro
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Latency contains the round-trip times measured when pinging the WSL-Pro-Service of a distro.
+type Latency struct {
+	Last time.Duration
+	Min  time.Duration
+	Max  time.Duration
+	Mean time.Duration
+
+	// Samples is the number of successful pings, and Failures the number of failed ones.
+	Samples  int
+	Failures int
+
+	// LastProbe is when the latest ping was sent, and LastErr its error if it failed.
+	LastProbe time.Time
+	LastErr   error
+}
+
+// record updates the latency with the result of a ping sent at the given time.
+func (l *Latency) record(sent time.Time, rtt time.Duration, err error) {
+	l.LastProbe = sent
+	l.LastErr = err
+
+	if err != nil {
+		l.Failures++
+		return
+	}
+
+	if l.Samples == 0 || rtt < l.Min {
+		l.Min = rtt
+	}
+	if rtt > l.Max {
+		l.Max = rtt
+	}
+
+	l.Last = rtt
+	l.Mean += (rtt - l.Mean) / time.Duration(l.Samples+1)
+	l.Samples++
+}
+
+// Ping measures the round-trip time of a message echoed by the WSL-Pro-Service of the distro,
+// and records it into the distro's latency. The distro must be connected. Pings that are not answered
+// before the context is done are recorded as failures.
+func (d *Distro) Ping(ctx context.Context) (rtt time.Duration, err error) {
+	conn, err := d.Connection()
+	if err != nil {
+		return 0, err
+	}
+	if conn == nil {
+		return 0, errors.New("distro is not connected")
+	}
+
+	payload := []byte(uuid.NewString())
+
+	sent := time.Now()
+	out, err := conn.Ping(ctx, payload)
+	rtt = time.Since(sent)
+
+	if err == nil && !bytes.Equal(out, payload) {
+		err = fmt.Errorf("ping payload mismatch: sent %q, received %q", payload, out)
+	}
+
+	d.latencyMu.Lock()
+	defer d.latencyMu.Unlock()
+	d.latency.record(sent, rtt, err)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return rtt, nil
+}
+
+// Latency returns the round-trip times measured by Ping.
+func (d *Distro) Latency() Latency {
+	d.latencyMu.RLock()
+	defer d.latencyMu.RUnlock()
+
+	return d.latency
+}
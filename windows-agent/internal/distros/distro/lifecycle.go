@@ -0,0 +1,332 @@
+package distro
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// Lifecycle is the stage of its lifecycle a distro is in, as seen from the agent.
+type Lifecycle int
+
+const (
+	// Unregistered is the final stage: the distro no longer exists or it cannot be contacted anymore.
+	Unregistered Lifecycle = iota
+	// Registered is the stage of a distro that exists but has no connection to its WSL Pro service.
+	Registered
+	// Connected is the stage of a distro whose WSL Pro service is connected to the agent.
+	Connected
+	// Provisioned is the stage of a connected distro that is Pro-attached.
+	Provisioned
+	// Managed is the stage of a provisioned distro that has been configured to be managed by Landscape.
+	Managed
+	// Degraded is the stage of a connected distro whose last tasks failed, or of a distro whose WSL Pro service
+	// is connected but cannot be managed, and so is refused the connection.
+	Degraded
+)
+
+func (l Lifecycle) String() string {
+	switch l {
+	case Unregistered:
+		return "unregistered"
+	case Registered:
+		return "registered"
+	case Connected:
+		return "connected"
+	case Provisioned:
+		return "provisioned"
+	case Managed:
+		return "managed"
+	case Degraded:
+		return "degraded"
+	}
+	return fmt.Sprintf("unknown lifecycle stage %d", int(l))
+}
+
+// connected returns true for the stages in which the WSL Pro service of the distro is connected to the agent,
+// whether it was given the connection or refused it.
+func (l Lifecycle) connected() bool {
+	return l >= Connected
+}
+
+// transitions are the allowed moves between adjacent stages: a distro connects before being provisioned,
+// and is provisioned before being managed. Any connected distro can degrade, recover and disconnect, and
+// any distro can be unregistered, which is terminal. A distro refused the connection degrades without ever
+// being connected. Moves between stages that are not adjacent go through the stages in between.
+var transitions = map[Lifecycle][]Lifecycle{
+	Registered:  {Connected, Degraded, Unregistered},
+	Connected:   {Provisioned, Degraded, Registered, Unregistered},
+	Provisioned: {Connected, Managed, Degraded, Registered, Unregistered},
+	Managed:     {Provisioned, Degraded, Registered, Unregistered},
+	Degraded:    {Connected, Provisioned, Managed, Registered, Unregistered},
+}
+
+// path returns the shortest sequence of allowed moves from one stage to another, excluding the first
+// stage. Among equally short ones, it prefers the moves listed first. Degraded is only ever a target,
+// never a stage to go through. It returns nil if the target cannot be reached.
+func path(from, to Lifecycle) []Lifecycle {
+	prev := map[Lifecycle]Lifecycle{from: from}
+	queue := []Lifecycle{from}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+
+		if s == to {
+			var p []Lifecycle
+			for ; s != from; s = prev[s] {
+				p = append([]Lifecycle{s}, p...)
+			}
+			return p
+		}
+
+		for _, next := range transitions[s] {
+			if next == Degraded && to != Degraded {
+				continue
+			}
+			if _, seen := prev[next]; !seen {
+				prev[next] = s
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// degradedAfterFailures is the number of consecutive tasks that must fail for the distro to be degraded,
+// so that a one-off failure does not flag the distro.
+const degradedAfterFailures = 3
+
+// LifecycleEvent is emitted every time a distro moves to a different stage.
+type LifecycleEvent struct {
+	From Lifecycle
+	To   Lifecycle
+}
+
+// lifecycleBufferSize is the number of events buffered for each watcher. Events for watchers
+// that fall behind are dropped, so that they never stall the distro.
+const lifecycleBufferSize = 16
+
+// lifecycle is the state machine that tracks the stage of a distro. Whether a connected distro is provisioned
+// or managed is taken from its properties, which are persisted. What it tracks itself only lasts as long as the
+// connection does.
+type lifecycle struct {
+	distroName string
+
+	stage Lifecycle
+
+	// reason is why the distro cannot be managed, nil if it can.
+	reason error
+	// taskErr is the error of the last task, and failures the number of consecutive tasks that failed.
+	taskErr  error
+	failures int
+
+	watchers map[chan LifecycleEvent]struct{}
+	// onChange is called after every move to a different stage.
+	onChange func()
+	mu       sync.Mutex
+}
+
+func newLifecycle(distroName string, onChange func()) *lifecycle {
+	return &lifecycle{
+		distroName: distroName,
+		stage:      Registered,
+		onChange:   onChange,
+	}
+}
+
+// get returns the current stage.
+func (l *lifecycle) get() Lifecycle {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.stage
+}
+
+// update applies f to the lifecycle, and then moves to the stage derived from it and from the properties of
+// the distro. Once unregistered, the lifecycle cannot be updated anymore.
+func (l *lifecycle) update(ctx context.Context, props Properties, f func(*lifecycle)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stage == Unregistered {
+		return
+	}
+
+	f(l)
+
+	if !l.stage.connected() {
+		return
+	}
+
+	if err := l.transition(ctx, l.connectedStage(props)); err != nil {
+		log.Warningf(ctx, "Distro %q: %v", l.distroName, err)
+	}
+}
+
+// taskDone records the outcome of a task.
+func (l *lifecycle) taskDone(taskErr error) {
+	l.taskErr = taskErr
+	if taskErr == nil {
+		l.failures = 0
+		return
+	}
+	l.failures++
+}
+
+// degradedErr returns why the distro is degraded, nil if it is not. The caller must hold the lock.
+func (l *lifecycle) degradedErr() error {
+	if l.reason != nil {
+		return l.reason
+	}
+	if l.failures >= degradedAfterFailures {
+		return fmt.Errorf("%d tasks failed in a row, the last one with: %v", l.failures, l.taskErr)
+	}
+	return nil
+}
+
+// connectedStage derives the stage of a connected distro, in order of precedence. Landscape only manages
+// Pro-attached distros. The caller must hold the lock.
+func (l *lifecycle) connectedStage(props Properties) Lifecycle {
+	switch {
+	case l.degradedErr() != nil:
+		return Degraded
+	case props.ProAttached && props.LandscapeManaged:
+		return Managed
+	case props.ProAttached:
+		return Provisioned
+	default:
+		return Connected
+	}
+}
+
+// degradedReason returns why the distro is degraded, or an empty string if it is not.
+func (l *lifecycle) degradedReason() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stage != Degraded {
+		return ""
+	}
+	return l.degradedErr().Error()
+}
+
+// connect moves the distro to the stage it has when connected or disconnected.
+func (l *lifecycle) connect(ctx context.Context, connected bool, props Properties) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !connected {
+		// Why the distro was degraded does not outlive the connection it happened in.
+		l.reason = nil
+		l.taskErr = nil
+		l.failures = 0
+		return l.transition(ctx, Registered)
+	}
+
+	return l.transition(ctx, l.connectedStage(props))
+}
+
+// refuse moves a distro whose WSL Pro service cannot be managed straight to the Degraded stage, as it is not
+// given the connection. It lasts until the distro is disconnected.
+func (l *lifecycle) refuse(ctx context.Context, reason error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stage == Unregistered {
+		return fmt.Errorf("illegal lifecycle transition from %s to %s", l.stage, Degraded)
+	}
+
+	l.reason = reason
+	return l.transition(ctx, Degraded)
+}
+
+// unregister moves the distro to the terminal stage. It returns false if it was already there.
+func (l *lifecycle) unregister(ctx context.Context) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stage == Unregistered {
+		return false
+	}
+
+	// Every stage can move to Unregistered.
+	_ = l.transition(ctx, Unregistered)
+	return true
+}
+
+// transition moves to the target stage through the allowed moves, and notifies the watchers of each of them.
+// Moving to the current stage is a no-op. The caller must hold the lock.
+func (l *lifecycle) transition(ctx context.Context, to Lifecycle) error {
+	if l.stage == to {
+		return nil
+	}
+
+	moves := path(l.stage, to)
+	if moves == nil {
+		return fmt.Errorf("illegal lifecycle transition from %s to %s", l.stage, to)
+	}
+
+	for _, next := range moves {
+		from := l.stage
+		l.stage = next
+		log.Debugf(ctx, "Distro %q: lifecycle moved from %s to %s", l.distroName, from, next)
+
+		ev := LifecycleEvent{From: from, To: next}
+		for ch := range l.watchers {
+			select {
+			case ch <- ev:
+			default:
+				log.Warningf(ctx, "Distro %q: dropped lifecycle event for a watcher that is falling behind", l.distroName)
+			}
+		}
+	}
+
+	l.onChange()
+	return nil
+}
+
+// watch returns a channel that receives the lifecycle events. It is closed when the context
+// is cancelled or when all watchers are removed.
+func (l *lifecycle) watch(ctx context.Context) <-chan LifecycleEvent {
+	ch := make(chan LifecycleEvent, lifecycleBufferSize)
+
+	l.mu.Lock()
+	if l.watchers == nil {
+		l.watchers = make(map[chan LifecycleEvent]struct{})
+	}
+	l.watchers[ch] = struct{}{}
+	l.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		l.removeWatcher(ch)
+	}()
+
+	return ch
+}
+
+// removeWatcher stops sending events to the channel and closes it, unless it was removed already.
+func (l *lifecycle) removeWatcher(ch chan LifecycleEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.watchers[ch]; !ok {
+		return
+	}
+
+	delete(l.watchers, ch)
+	close(ch)
+}
+
+// removeAllWatchers closes all the channels.
+func (l *lifecycle) removeAllWatchers() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ch := range l.watchers {
+		close(ch)
+	}
+	l.watchers = nil
+}
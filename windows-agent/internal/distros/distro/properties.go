@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/unknownfields"
 	"github.com/google/uuid"
 	wsl "github.com/ubuntu/gowsl"
+	"gopkg.in/yaml.v3"
 )
 
 // identity contains persistent and uniquely identifying information about the distro.
@@ -30,6 +34,84 @@ type Properties struct {
 
 	// Ubuntu Pro
 	ProAttached bool
+	ProServices []string `yaml:",omitempty"`
+	// ProExpires is the RFC3339 date of expiry of the contract the distro is attached to, if known.
+	ProExpires string `yaml:",omitempty"`
+	// ProSupportLevel is the support level of that contract, empty if it includes no support.
+	ProSupportLevel string `yaml:",omitempty"`
+	// UnsupportedReason is why the Ubuntu Pro client cannot run in the distro, e.g. on minimal images, empty if
+	// it can. Such distros are not attached.
+	UnsupportedReason string `yaml:",omitempty"`
+
+	// WslVersion is the version of WSL the distro runs on, 1 or 2, zero if it did not report it. Distros on WSL 1
+	// lack systemd and a virtual disk, so the features that need them are disabled.
+	WslVersion int `yaml:",omitempty"`
+
+	// Security
+	Security SecurityStatus `yaml:",omitempty"`
+
+	// ServiceVersion is the version of the WSL Pro service, empty if it did not report it.
+	ServiceVersion string `yaml:",omitempty"`
+
+	// LandscapeManaged is true once the agent has configured Landscape in the distro. Unlike the rest of the
+	// properties, it is not reported by the distro.
+	LandscapeManaged bool `yaml:",omitempty"`
+
+	// LastSeen is the last time the WSL Pro service of the distro connected, reported its info or disconnected,
+	// zero if it never did. Like LandscapeManaged, it is not reported by the distro.
+	LastSeen time.Time `yaml:",omitempty"`
+
+	// FirstUsed is the first time the WSL Pro service of the distro connected while the agent was not keeping
+	// it awake, i.e. the distro was launched by the user, zero if it never did. Like LandscapeManaged, it is not
+	// reported by the distro.
+	FirstUsed time.Time `yaml:",omitempty"`
+
+	// unknown contains the fields written by newer versions of the agent, so that they are not lost
+	// when storing the properties again.
+	unknown unknownfields.Fields
+}
+
+// plainProperties has the same fields as Properties, without its YAML methods.
+type plainProperties Properties
+
+// MarshalYAML marshals the properties, including the unknown fields read from YAML.
+func (p Properties) MarshalYAML() (any, error) {
+	return unknownfields.Encode(plainProperties(p), p.unknown)
+}
+
+// UnmarshalYAML unmarshals the properties, retaining the fields this version of the agent does not know about.
+func (p *Properties) UnmarshalYAML(node *yaml.Node) (err error) {
+	p.unknown, err = unknownfields.Decode(node, (*plainProperties)(p))
+	return err
+}
+
+// SecurityStatus summarises the pending security updates of a distro.
+type SecurityStatus struct {
+	// Known is false if the distro never reported its security status.
+	Known bool
+
+	StandardUpdates int
+	ESMUpdates      int
+}
+
+// equals compares two sets of properties. Properties cannot be compared with == because of
+// the slices it contains.
+func (p Properties) equals(other Properties) bool {
+	return p.DistroID == other.DistroID &&
+		p.VersionID == other.VersionID &&
+		p.PrettyName == other.PrettyName &&
+		p.Hostname == other.Hostname &&
+		p.ProAttached == other.ProAttached &&
+		slices.Equal(p.ProServices, other.ProServices) &&
+		p.ProExpires == other.ProExpires &&
+		p.ProSupportLevel == other.ProSupportLevel &&
+		p.UnsupportedReason == other.UnsupportedReason &&
+		p.WslVersion == other.WslVersion &&
+		p.Security == other.Security &&
+		p.ServiceVersion == other.ServiceVersion &&
+		p.LandscapeManaged == other.LandscapeManaged &&
+		p.LastSeen.Equal(other.LastSeen) &&
+		p.FirstUsed.Equal(other.FirstUsed)
 }
 
 // isValid checks that the properties against the registry.
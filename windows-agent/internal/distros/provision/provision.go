@@ -0,0 +1,342 @@
+// Package provision installs distros out of Ubuntu WSL images downloaded from the web, such as the official ones
+// of cloud-images.ubuntu.com, verifying them against the checksums published alongside them.
+package provision
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro/touchdistro"
+	"github.com/ubuntu/decorate"
+	"github.com/ubuntu/gowsl"
+)
+
+// Stage is a stage of the provisioning of a distro.
+type Stage int
+
+const (
+	// StageDownloading is the download of the image, which is verified on the fly.
+	StageDownloading Stage = iota + 1
+
+	// StageImporting is the import of the image into WSL.
+	StageImporting
+
+	// StageConfiguring is the first boot of the distro, until cloud-init is done with it.
+	StageConfiguring
+
+	// StageDone is reached once the distro is installed.
+	StageDone
+)
+
+// Progress is the progress of the provisioning of a distro.
+type Progress struct {
+	Stage Stage
+
+	// Done and Total are the bytes of the image downloaded so far and its size, only set while downloading.
+	// Total is zero if the size is unknown.
+	Done, Total int64
+}
+
+// progressStep is how much of the image must be downloaded between two reports of progress, as a fraction of
+// its size. Images of unknown size are reported every progressStepUnknown bytes instead.
+const (
+	progressStep        = 100
+	progressStepUnknown = 8 << 20
+)
+
+type options struct {
+	installDir       string
+	downloadDir      string
+	optionalChecksum bool
+	progress         func(Progress)
+}
+
+// Option is an optional argument for Install.
+type Option func(*options)
+
+// WithInstallDir keeps the virtual disk of the distro in dir. It defaults to %USERPROFILE%\WSL\<name>.
+func WithInstallDir(dir string) Option {
+	return func(o *options) {
+		o.installDir = dir
+	}
+}
+
+// WithDownloadDir downloads the image into a subdirectory of dir, which is removed once the distro is installed.
+// It defaults to the temporary directory of the user.
+func WithDownloadDir(dir string) Option {
+	return func(o *options) {
+		o.downloadDir = dir
+	}
+}
+
+// WithProgress calls f as the provisioning progresses, from the goroutine calling Install.
+func WithProgress(f func(Progress)) Option {
+	return func(o *options) {
+		o.progress = f
+	}
+}
+
+// WithOptionalChecksum installs images that have no SHA256SUMS file next to them without verifying them.
+// Images whose URL has such a file must still be listed in it with the right checksum.
+func WithOptionalChecksum() Option {
+	return func(o *options) {
+		o.optionalChecksum = true
+	}
+}
+
+// reservedName matches the names of the distros of the Microsoft Store, which must be installed from there,
+// as well as any name with a release number in it.
+var reservedName = regexp.MustCompile(`(?i)^(Ubuntu|Ubuntu-Preview)$|(?i)Ubuntu-[0-9]{2}\.[0-9]{2}`)
+
+// CheckName returns an error if name cannot be used for a distro installed out of an image.
+func CheckName(name string) error {
+	if name == "" {
+		return errors.New("empty distro name")
+	}
+	if reservedName.MatchString(name) {
+		return fmt.Errorf("target distro ID %s is reserved for installation from MS Store", name)
+	}
+	return nil
+}
+
+// Install downloads the image at rootfs, verifies its checksum against the SHA256SUMS file next to it and imports it
+// as a distro with the given name, then waits for cloud-init to be done with its first boot. The image is removed once
+// imported, and so is the install directory if the import fails.
+func Install(ctx context.Context, name string, rootfs *url.URL, args ...Option) (err error) {
+	defer decorate.OnError(&err, "can't install from URL: %q", rootfs)
+
+	opts := options{
+		downloadDir: os.TempDir(),
+		progress:    func(Progress) {},
+	}
+	for _, f := range args {
+		f(&opts)
+	}
+
+	if err := CheckName(name); err != nil {
+		return err
+	}
+
+	if opts.installDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("could not find the home directory: %v", err)
+		}
+		opts.installDir = filepath.Join(home, "WSL", name)
+	}
+
+	distro := gowsl.NewDistro(ctx, name)
+	if registered, err := distro.IsRegistered(); err != nil {
+		return err
+	} else if registered {
+		return errors.New("already installed")
+	}
+
+	tmpDir := filepath.Join(opts.downloadDir, name)
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return err
+	}
+	// Remove tarball once installed
+	defer os.RemoveAll(tmpDir)
+
+	tarball := filepath.Join(tmpDir, name+".tar.gz")
+
+	opts.progress(Progress{Stage: StageDownloading})
+	if err := download(ctx, rootfs, tarball, opts); err != nil {
+		return err
+	}
+
+	// Create the directory that will contain the vhdx
+	if err := os.MkdirAll(opts.installDir, 0700); err != nil {
+		return err
+	}
+
+	opts.progress(Progress{Stage: StageImporting})
+	if _, err := gowsl.Import(ctx, name, tarball, opts.installDir); err != nil {
+		rmErr := os.RemoveAll(opts.installDir)
+		if rmErr != nil {
+			log.Warningf(ctx, "could not cleanup install directory: %v", rmErr)
+		}
+		return err
+	}
+
+	// If import was successful, let's wait for cloud-init to finish:
+	opts.progress(Progress{Stage: StageConfiguring})
+	if err := touchdistro.WaitForCloudInit(ctx, name); err != nil {
+		log.Infof(ctx, "cloud-init failed: %v", err)
+	}
+
+	log.Debugf(ctx, "Distro %s installed successfully", name)
+	opts.progress(Progress{Stage: StageDone})
+	return nil
+}
+
+// download downloads the rootfs from the given URL and writes it to the given destination while verifying its checksum.
+// The checksum is read from the SHA256SUMS file found alongside the rootfs URL, as done in cloud-images.ubuntu.com.
+func download(ctx context.Context, u *url.URL, destination string, opts options) (err error) {
+	defer decorate.OnError(&err, "could not download %q", u)
+
+	checksum, err := wantRootfsChecksum(ctx, u)
+	if err != nil {
+		return err
+	}
+	if checksum == "" && !opts.optionalChecksum {
+		return errors.New("no checksum to verify the image against")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("http request failed with code %d", resp.StatusCode)
+	}
+
+	f, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	body := &progressReader{r: resp.Body, total: max(resp.ContentLength, 0), report: opts.progress}
+	body.step = progressStepUnknown
+	if body.total > 0 {
+		body.step = max(body.total/progressStep, 1)
+	}
+
+	// Verify checksum and write file to disk
+	r := io.TeeReader(body, f)
+	if checksum != "" {
+		match, err := checksumMatches(ctx, r, checksum)
+		if err != nil {
+			return err
+		}
+		if !match {
+			return fmt.Errorf("checksum %s for %s does not match", checksum, u)
+		}
+	} else {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// progressReader reports the progress of the download every step bytes.
+type progressReader struct {
+	r      io.Reader
+	report func(Progress)
+
+	done, total int64
+	step        int64
+	reported    int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+
+	if p.done-p.reported >= p.step || (errors.Is(err, io.EOF) && p.done != p.reported) {
+		p.reported = p.done
+		p.report(Progress{Stage: StageDownloading, Done: p.done, Total: p.total})
+	}
+
+	return n, err
+}
+
+// wantRootfsChecksum fetches the checksum from the SHA256SUMS file if found alongside the rootfs URL matching the rootfs file name.
+// It returns an empty checksum if there is no such file.
+//
+// The SHA256SUMS file is expected to contain multiple lines of the format:
+//
+// SHA256 *filename
+//
+// For example:
+//
+// 03c7f7c75fb450c7dd576a0da20986e62e0d72bd2ccee4c01296bab9f415c7ab *jammy-server-cloudimg-amd64-azure.vhd.tar.gz
+// 0dc4d78f08e871ce6325e027e1b8421fd1cde1e76158644e35343a36d8f67bf4 *jammy-server-cloudimg-amd64-root.tar.xz
+// 103ee8b5693bdb7c23a378453c624d8605445eb07e2e550d3fad831da865f5ea *jammy-server-cloudimg-riscv64.release.20240514.20240601.image_changelog.json
+// 1eaa1df5794122e3419c963d88f043121c164936b9b828adac650c9f5e22c3e6 *jammy-server-cloudimg-amd64.img
+// 1fcd2edf4fda78e0a6f3bc0c3684286c29371e4dd7863a59b39d2cfcff79b5e1 *jammy-server-cloudimg-amd64-root.manifest
+// 1fcd2edf4fda78e0a6f3bc0c3684286c29371e4dd7863a59b39d2cfcff79b5e1 *jammy-server-cloudimg-amd64.squashfs.manifest
+// 2646292d657f4c9ef5dfce804a5a1e66d8c1324c74147b8bc9b1bf154d7feaf8 *jammy-server-cloudimg-arm64-root.tar.xz
+//
+// ...
+func wantRootfsChecksum(ctx context.Context, u *url.URL) (string, error) {
+	imageName := filepath.Base(u.Path)
+	shasRelativeURL, err := url.Parse("SHA256SUMS")
+	if err != nil {
+		return "", fmt.Errorf("could not assemble SHA256SUMS location: %v", err)
+	}
+	checksumsURL := u.ResolveReference(shasRelativeURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checksumsURL.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("could not assemble checksums request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	// Errors here are protocol errors, not 404s. "A non-2xx response doesn't cause an error".
+	if err != nil {
+		return "", fmt.Errorf("could not download checksums file %q: %v", checksumsURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		log.Infof(ctx, "checksums file %q not found", checksumsURL)
+		return "", nil
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		if strings.TrimPrefix(fields[1], "*") == imageName && len(fields[0]) > 0 {
+			return fields[0], nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to parse checksums file, %v", err)
+	}
+
+	// If the checksums file exist, then it must contain the checksum for the rootfs.
+	return "", fmt.Errorf("could not find checksum for %s in %s", imageName, checksumsURL)
+}
+
+func checksumMatches(ctx context.Context, reader io.Reader, wantChecksum string) (match bool, err error) {
+	defer decorate.OnError(&err, "error checking checksum for: %q", reader)
+
+	// Checksum of the rootfs
+	h := sha256.New()
+	if _, err := io.Copy(h, reader); err != nil {
+		return false, err
+	}
+	gotChecksum := fmt.Sprintf("%x", h.Sum(nil))
+	log.Debugf(ctx, "Want checksum: %s, Got checksum: %s", wantChecksum, gotChecksum)
+
+	// Compare checksums
+	return wantChecksum == gotChecksum, nil
+}
@@ -0,0 +1,175 @@
+package provision_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/provision"
+	"github.com/stretchr/testify/require"
+	wsl "github.com/ubuntu/gowsl"
+	wslmock "github.com/ubuntu/gowsl/mock"
+)
+
+func TestInstall(t *testing.T) {
+	if !wsl.MockAvailable() {
+		t.Skip("This test can only run with the mock")
+	}
+	t.Parallel()
+
+	testCases := map[string]struct {
+		image            string
+		reservedName     bool
+		alreadyInstalled bool
+		noChecksums      bool
+		optionalChecksum bool
+		breakInstallDir  bool
+
+		wantErr bool
+	}{
+		"Success installing an image with a checksum":                 {image: "goodfile"},
+		"Success installing an image with no checksums when optional": {image: "goodfile", noChecksums: true, optionalChecksum: true},
+
+		"Error when the distro name is reserved":             {image: "goodfile", reservedName: true, wantErr: true},
+		"Error when the distro is already installed":         {image: "goodfile", alreadyInstalled: true, wantErr: true},
+		"Error when there are no checksums":                  {image: "goodfile", noChecksums: true, wantErr: true},
+		"Error when the checksum does not match":             {image: "badchecksum", wantErr: true},
+		"Error when the image is not in the checksums":       {image: "nochecksum", wantErr: true},
+		"Error when the image does not exist":                {image: "notfound", wantErr: true},
+		"Error when the image cannot be imported":            {image: "badfile", wantErr: true},
+		"Error when the install directory cannot be created": {image: "goodfile", breakInstallDir: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := wsl.WithMock(context.Background(), wslmock.New())
+
+			distroName := wsltestutils.RandomDistroName(t)
+			if tc.reservedName {
+				distroName = "Ubuntu-24.04"
+			}
+			if tc.alreadyInstalled {
+				distroName, _ = wsltestutils.RegisterDistro(t, ctx, false)
+			}
+
+			installDir := filepath.Join(t.TempDir(), distroName)
+			if tc.breakInstallDir {
+				require.NoError(t, os.WriteFile(installDir, nil, 0600), "Setup: could not break the install directory")
+			}
+
+			server := mockImageServer(t, !tc.noChecksums)
+			u, err := url.Parse(fmt.Sprintf("%s/releases/%s", server.URL, tc.image))
+			require.NoError(t, err, "Setup: could not parse the URL of the image")
+
+			var stages []provision.Stage
+			args := []provision.Option{
+				provision.WithInstallDir(installDir),
+				provision.WithDownloadDir(t.TempDir()),
+				provision.WithProgress(func(p provision.Progress) {
+					if len(stages) == 0 || stages[len(stages)-1] != p.Stage {
+						stages = append(stages, p.Stage)
+					}
+				}),
+			}
+			if tc.optionalChecksum {
+				args = append(args, provision.WithOptionalChecksum())
+			}
+
+			err = provision.Install(ctx, distroName, u, args...)
+			if tc.wantErr {
+				require.Error(t, err, "Install should return an error")
+				if !tc.alreadyInstalled {
+					registered, err := wsl.NewDistro(ctx, distroName).IsRegistered()
+					require.NoError(t, err, "IsRegistered should return no error")
+					require.False(t, registered, "The distro should not have been installed")
+				}
+				return
+			}
+			require.NoError(t, err, "Install should return no error")
+
+			registered, err := wsl.NewDistro(ctx, distroName).IsRegistered()
+			require.NoError(t, err, "IsRegistered should return no error")
+			require.True(t, registered, "The distro should have been installed")
+
+			want := []provision.Stage{provision.StageDownloading, provision.StageImporting, provision.StageConfiguring, provision.StageDone}
+			require.Equal(t, want, stages, "Install should have reported every stage, in order")
+		})
+	}
+}
+
+func TestInstallReportsDownloadProgress(t *testing.T) {
+	if !wsl.MockAvailable() {
+		t.Skip("This test can only run with the mock")
+	}
+	t.Parallel()
+
+	ctx := wsl.WithMock(context.Background(), wslmock.New())
+	server := mockImageServer(t, false)
+
+	u, err := url.Parse(server.URL + "/releases/bigfile")
+	require.NoError(t, err, "Setup: could not parse the URL of the image")
+
+	var reports []provision.Progress
+	err = provision.Install(ctx, wsltestutils.RandomDistroName(t), u,
+		provision.WithInstallDir(t.TempDir()),
+		provision.WithDownloadDir(t.TempDir()),
+		provision.WithOptionalChecksum(),
+		provision.WithProgress(func(p provision.Progress) {
+			if p.Stage == provision.StageDownloading && p.Done > 0 {
+				reports = append(reports, p)
+			}
+		}))
+	require.NoError(t, err, "Install should return no error")
+
+	require.NotEmpty(t, reports, "Install should have reported the progress of the download")
+	for i, p := range reports {
+		require.Equal(t, int64(bigFileSize), p.Total, "The size of the image should have been reported")
+		if i > 0 {
+			require.Greater(t, p.Done, reports[i-1].Done, "The progress should only grow")
+		}
+	}
+	require.Equal(t, int64(bigFileSize), reports[len(reports)-1].Done, "The whole image should have been reported as downloaded")
+}
+
+// bigFileSize is the size of the image whose download progress is reported.
+const bigFileSize = 1 << 20
+
+// mockImageServer serves the images of the tests, with a SHA256SUMS file next to them if withChecksums is set.
+func mockImageServer(t *testing.T, withChecksums bool) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /releases/goodfile", func(w http.ResponseWriter, r *http.Request) {})    // Empty file
+	mux.HandleFunc("GET /releases/badchecksum", func(w http.ResponseWriter, r *http.Request) {}) // Empty file
+	mux.HandleFunc("GET /releases/nochecksum", func(w http.ResponseWriter, r *http.Request) {})  // Not in the checksums file
+	mux.HandleFunc("GET /releases/badfile", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "MOCK_ERROR")
+	})
+	mux.HandleFunc("GET /releases/bigfile", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprint(bigFileSize))
+		_, _ = w.Write(make([]byte, bigFileSize))
+	})
+
+	if withChecksums {
+		mux.HandleFunc("GET /releases/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855 *goodfile
+afe55cda4210c2439b47c62c01039027522f7ed4abdb113972b3030b3359532a *badfile
+1234 *badchecksum
+5678 *notfound`)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+)
+
+// schemaVersionKey is the key of the StateBucket where the version of the schema of the store is kept.
+const schemaVersionKey = "schema-version"
+
+// Migration upgrades the contents of the store from one version of its schema to the next.
+type Migration struct {
+	// Description tells what the migration does, for the logs.
+	Description string
+
+	// Run migrates the contents of the store in the transaction.
+	Run func(tx *Tx) error
+}
+
+// SchemaVersion returns the version of the schema of the store, which is the number of migrations it went through.
+func (s *Store) SchemaVersion() (int, error) {
+	var v int
+	err := s.View(func(tx *Tx) (err error) {
+		v, err = schemaVersion(tx)
+		return err
+	})
+	return v, err
+}
+
+// Migrate runs the migrations the store did not go through yet, in order. Each of them is run in its own
+// transaction, alongside the update of the version of the schema, so that a migration that fails is retried the
+// next time. A store with a schema newer than the migrations, written by a later version of the agent, is left
+// untouched.
+func (s *Store) Migrate(ctx context.Context, migrations []Migration) (err error) {
+	defer decorate.OnError(&err, "could not migrate the store")
+
+	for {
+		done, err := s.migrateOnce(ctx, migrations)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// migrateOnce runs the next migration the store did not go through. It returns true if there is none.
+func (s *Store) migrateOnce(ctx context.Context, migrations []Migration) (done bool, err error) {
+	err = s.Update(func(tx *Tx) error {
+		v, err := schemaVersion(tx)
+		if err != nil {
+			return err
+		}
+
+		if v > len(migrations) {
+			log.Warningf(ctx, "Store: the version of its schema (%d) is newer than this version of the agent knows (%d)", v, len(migrations))
+		}
+		if v >= len(migrations) {
+			done = true
+			return nil
+		}
+
+		m := migrations[v]
+		log.Infof(ctx, "Store: migrating to version %d of the schema: %s", v+1, m.Description)
+		if err := m.Run(tx); err != nil {
+			return fmt.Errorf("migration to version %d: %v", v+1, err)
+		}
+
+		return tx.Put(StateBucket, schemaVersionKey, []byte(strconv.Itoa(v+1)))
+	})
+	return done, err
+}
+
+// schemaVersion returns the version of the schema of the store in the transaction: 0 if it was never migrated.
+func schemaVersion(tx *Tx) (int, error) {
+	out, err := tx.Get(StateBucket, schemaVersionKey)
+	if err != nil {
+		return 0, err
+	}
+	if out == nil {
+		return 0, nil
+	}
+
+	v, err := strconv.Atoi(string(out))
+	if err != nil {
+		return 0, fmt.Errorf("invalid version of the schema %q: %v", out, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,390 @@
+// Package store is an embedded key-value store backed by a single bbolt file. The agent keeps its state
+// there (the distro records and their task queues, the journal of events and the configuration), so that
+// related changes can be made in a single transaction and a crash cannot leave them inconsistent with
+// each other.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+	bolt "go.etcd.io/bbolt"
+)
+
+// The buckets of the store.
+const (
+	// DistrosBucket contains the record of every distro in the database, indexed by lowercase name.
+	DistrosBucket = "distros"
+
+	// TasksBucket contains the task queue of every distro, indexed by name.
+	TasksBucket = "tasks"
+
+	// TasksModifiedBucket contains the time every task queue was last written, indexed by distro name.
+	TasksModifiedBucket = "tasks-modified"
+
+	// JournalBucket contains the events of the journal, indexed by their sequence number.
+	JournalBucket = "journal"
+
+	// StateBucket contains the state of the agent that is not specific to any distro, such as its configuration.
+	StateBucket = "state"
+
+	// SecretsBucket contains the secrets of the agent, such as the Ubuntu Pro tokens, encrypted. See package secrets.
+	SecretsBucket = "secrets"
+
+	// OperationsBucket contains the operations submitted to several distros at once, indexed by ID. See package operations.
+	OperationsBucket = "operations"
+)
+
+var buckets = []string{DistrosBucket, TasksBucket, TasksModifiedBucket, JournalBucket, StateBucket, SecretsBucket, OperationsBucket}
+
+// openTimeout is how long to wait for another process to release the store before giving up.
+const openTimeout = 5 * time.Second
+
+// lockedTimeout is how long a snapshot waits for the store to be released before reading the copy
+// that the process that has it open publishes.
+const lockedTimeout = 100 * time.Millisecond
+
+// publishInterval is the minimum time between two consecutive publications of the copy of the store.
+const publishInterval = time.Second
+
+// snapshotSuffix is appended to the path of the store to get the path of the copy it publishes.
+const snapshotSuffix = ".snapshot"
+
+// Store is an embedded key-value store. It is safe for concurrent use.
+type Store struct {
+	db *bolt.DB
+
+	// cleanup removes the copy backing a snapshot.
+	cleanup func()
+
+	// changed signals the publisher that a transaction was committed. Nil for snapshots, which are
+	// never published.
+	changed   chan struct{}
+	stop      chan struct{}
+	published chan struct{}
+	stopOnce  sync.Once
+}
+
+// Open opens the store at path, creating it if it does not exist. Only one process can have a store
+// open at a time: it fails if another one does not release it in a few seconds. A consistent copy of
+// the store is published next to it shortly after every change, for Snapshot to read it while the
+// store is open. Call Close to release it.
+func Open(ctx context.Context, path string) (s *Store, err error) {
+	s, err = open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.changed = make(chan struct{}, 1)
+	s.stop = make(chan struct{})
+	s.published = make(chan struct{})
+	go s.publish(ctx)
+
+	// The copy may be missing or stale if the process that had the store open last did not close it.
+	s.changed <- struct{}{}
+
+	return s, nil
+}
+
+// open opens the store at path, creating it and its buckets if they do not exist.
+func open(path string) (s *Store, err error) {
+	defer decorate.OnError(&err, "could not open store %q", path)
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range buckets {
+			if _, err := tx.CreateBucketIfNotExists([]byte(b)); err != nil {
+				return fmt.Errorf("could not create bucket %q: %v", b, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Snapshot opens a private copy of the store at path as it is right now. If another process has the store
+// open, the copy that process publishes is read instead, which may lag behind by a second or so. It returns
+// nil if there is no store at path. Call Close to release it.
+func Snapshot(path string) (s *Store, err error) {
+	defer decorate.OnError(&err, "could not take a snapshot of store %q", path)
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "ubuntu-pro-store-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+
+	copyPath := filepath.Join(dir, filepath.Base(path))
+	if err := copyStore(path, copyPath); err != nil {
+		return nil, err
+	}
+
+	s, err = open(copyPath)
+	if err != nil {
+		return nil, err
+	}
+	s.cleanup = func() { _ = os.RemoveAll(dir) }
+
+	return s, nil
+}
+
+// copyStore writes a consistent copy of the store at path to dst, from a read transaction if the store
+// is not open in another process, and from the copy that process publishes otherwise.
+func copyStore(path, dst string) error {
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: lockedTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		// The published copy is replaced atomically, so copying its bytes is enough.
+		return copyFile(path+snapshotSuffix, dst)
+	}
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(dst, 0600)
+	})
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.New("the store is in use and no copy of it was published yet")
+	} else if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// publish writes a copy of the store next to it after every change, at most once per publishInterval,
+// until the store is closed.
+func (s *Store) publish(ctx context.Context) {
+	defer crashreport.Recover("store")
+	defer close(s.published)
+
+	path := s.Path() + snapshotSuffix
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.changed:
+		}
+
+		if err := s.Backup(path); err != nil {
+			log.Warningf(ctx, "Store: could not publish a copy: %v", err)
+		}
+
+		select {
+		case <-s.stop:
+			// Changes made since are published one last time before the store is closed.
+			select {
+			case <-s.changed:
+				if err := s.Backup(path); err != nil {
+					log.Warningf(ctx, "Store: could not publish a copy: %v", err)
+				}
+			default:
+			}
+			return
+		case <-time.After(publishInterval):
+		}
+	}
+}
+
+// Close releases the store, publishing the changes not published yet. It is a no-op on a nil store.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	if s.changed != nil {
+		s.stopOnce.Do(func() { close(s.stop) })
+		<-s.published
+	}
+
+	err := s.db.Close()
+	if s.cleanup != nil {
+		s.cleanup()
+	}
+	return err
+}
+
+// Backup writes a consistent copy of the store to path, which can be opened with Open. It can be taken
+// while the store is in use.
+func (s *Store) Backup(path string) (err error) {
+	defer decorate.OnError(&err, "could not back up store to %q", path)
+
+	tmp := path + ".tmp"
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(tmp, 0600)
+	})
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Path returns the path to the file backing the store.
+func (s *Store) Path() string {
+	return s.db.Path()
+}
+
+// View runs f in a read-only transaction.
+func (s *Store) View(f func(tx *Tx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return f(&Tx{tx: tx})
+	})
+}
+
+// Update runs f in a read-write transaction, which is committed if f returns no error and rolled back
+// otherwise.
+func (s *Store) Update(f func(tx *Tx) error) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return f(&Tx{tx: tx})
+	})
+	if err == nil && s.changed != nil {
+		select {
+		case s.changed <- struct{}{}:
+		default:
+			// A publication is pending already.
+		}
+	}
+	return err
+}
+
+// Get returns the value of key in bucket, or nil if there is none.
+func (s *Store) Get(bucket, key string) (value []byte, err error) {
+	err = s.View(func(tx *Tx) error {
+		value, err = tx.Get(bucket, key)
+		return err
+	})
+	return value, err
+}
+
+// Put sets the value of key in bucket.
+func (s *Store) Put(bucket, key string, value []byte) error {
+	return s.Update(func(tx *Tx) error {
+		return tx.Put(bucket, key, value)
+	})
+}
+
+// Tx is a transaction on the store.
+type Tx struct {
+	tx *bolt.Tx
+}
+
+func (t *Tx) bucket(name string) (*bolt.Bucket, error) {
+	b := t.tx.Bucket([]byte(name))
+	if b == nil {
+		return nil, fmt.Errorf("bucket %q does not exist", name)
+	}
+	return b, nil
+}
+
+// Get returns a copy of the value of key in bucket, or nil if there is none.
+func (t *Tx) Get(bucket, key string) ([]byte, error) {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v := b.Get([]byte(key))
+	if v == nil {
+		return nil, nil
+	}
+
+	// Values are only valid for the duration of the transaction.
+	return append([]byte{}, v...), nil
+}
+
+// Put sets the value of key in bucket.
+func (t *Tx) Put(bucket, key string, value []byte) error {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), value)
+}
+
+// Delete removes key from bucket. Deleting a key that does not exist is not an error.
+func (t *Tx) Delete(bucket, key string) error {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	return b.Delete([]byte(key))
+}
+
+// Keys returns the keys in bucket, in byte-sorted order.
+func (t *Tx) Keys(bucket string) ([]string, error) {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	err = b.ForEach(func(k, _ []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	return keys, err
+}
+
+// Rename moves the value of oldKey in bucket to newKey, replacing any value newKey had.
+// It is a no-op if oldKey has no value.
+func (t *Tx) Rename(bucket, oldKey, newKey string) error {
+	if oldKey == newKey {
+		return nil
+	}
+
+	v, err := t.Get(bucket, oldKey)
+	if err != nil || v == nil {
+		return err
+	}
+
+	if err := t.Put(bucket, newKey, v); err != nil {
+		return err
+	}
+	return t.Delete(bucket, oldKey)
+}
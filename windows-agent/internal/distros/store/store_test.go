@@ -0,0 +1,252 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		failUpdate bool
+
+		wantValue string
+	}{
+		"Success committing the transaction": {wantValue: "new"},
+
+		"Error rolls back the whole transaction": {failUpdate: true, wantValue: "old"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := store.Open(context.Background(), filepath.Join(t.TempDir(), "store.db"))
+			require.NoError(t, err, "Setup: Open should return no error")
+			defer s.Close()
+
+			err = s.Put(store.TasksBucket, "distro", []byte("old"))
+			require.NoError(t, err, "Setup: Put should return no error")
+
+			err = s.Update(func(tx *store.Tx) error {
+				if err := tx.Put(store.TasksBucket, "distro", []byte("new")); err != nil {
+					return err
+				}
+				if err := tx.Put(store.DistrosBucket, "distro", []byte("record")); err != nil {
+					return err
+				}
+				if tc.failUpdate {
+					return errors.New("mock error")
+				}
+				return nil
+			})
+			if tc.failUpdate {
+				require.Error(t, err, "Update should return the error of the transaction")
+			} else {
+				require.NoError(t, err, "Update should return no error")
+			}
+
+			got, err := s.Get(store.TasksBucket, "distro")
+			require.NoError(t, err, "Get should return no error")
+			require.Equal(t, tc.wantValue, string(got), "Unexpected value after the transaction")
+
+			record, err := s.Get(store.DistrosBucket, "distro")
+			require.NoError(t, err, "Get should return no error")
+			require.Equal(t, !tc.failUpdate, record != nil, "Every write in the transaction should be applied or none")
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		oldValue string
+		newValue string
+
+		wantValue string
+	}{
+		"Success moving the value":                 {oldValue: "old", wantValue: "old"},
+		"Success replacing the new value":          {oldValue: "old", newValue: "new", wantValue: "old"},
+		"Success with no value to move is a no-op": {newValue: "new", wantValue: "new"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := store.Open(context.Background(), filepath.Join(t.TempDir(), "store.db"))
+			require.NoError(t, err, "Setup: Open should return no error")
+			defer s.Close()
+
+			for k, v := range map[string]string{"oldKey": tc.oldValue, "newKey": tc.newValue} {
+				if v == "" {
+					continue
+				}
+				err = s.Put(store.TasksBucket, k, []byte(v))
+				require.NoError(t, err, "Setup: Put should return no error")
+			}
+
+			err = s.Update(func(tx *store.Tx) error {
+				return tx.Rename(store.TasksBucket, "oldKey", "newKey")
+			})
+			require.NoError(t, err, "Rename should return no error")
+
+			got, err := s.Get(store.TasksBucket, "newKey")
+			require.NoError(t, err, "Get should return no error")
+			require.Equal(t, tc.wantValue, string(got), "Unexpected value of the new key")
+
+			got, err = s.Get(store.TasksBucket, "oldKey")
+			require.NoError(t, err, "Get should return no error")
+			require.Nil(t, got, "The old key should have no value after a rename")
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	snapshot, err := store.Snapshot(path)
+	require.NoError(t, err, "Snapshot should return no error when there is no store")
+	require.Nil(t, snapshot, "Snapshot should return nil when there is no store")
+
+	s, err := store.Open(context.Background(), path)
+	require.NoError(t, err, "Setup: Open should return no error")
+	defer s.Close()
+
+	err = s.Put(store.DistrosBucket, "distro", []byte("record"))
+	require.NoError(t, err, "Setup: Put should return no error")
+
+	// The store is still open, so the snapshot is taken from the copy it publishes shortly after every change.
+	require.Eventually(t, func() bool {
+		snapshot, err := store.Snapshot(path)
+		if err != nil {
+			return false
+		}
+		defer snapshot.Close()
+
+		got, err := snapshot.Get(store.DistrosBucket, "distro")
+		return err == nil && string(got) == "record"
+	}, 10*time.Second, 100*time.Millisecond, "A snapshot taken while the store is open should eventually have its contents")
+
+	err = s.Put(store.DistrosBucket, "distro", []byte("newer record"))
+	require.NoError(t, err, "Setup: Put should return no error")
+	require.NoError(t, s.Close(), "Setup: Close should return no error")
+
+	// Once closed, the snapshot is taken from the store itself.
+	snapshot, err = store.Snapshot(path)
+	require.NoError(t, err, "Snapshot should return no error once the store is closed")
+	defer snapshot.Close()
+
+	got, err := snapshot.Get(store.DistrosBucket, "distro")
+	require.NoError(t, err, "Get should return no error on a snapshot")
+	require.Equal(t, "newer record", string(got), "The snapshot should have the latest contents of the store")
+}
+
+func TestBackup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	s, err := store.Open(context.Background(), filepath.Join(dir, "store.db"))
+	require.NoError(t, err, "Setup: Open should return no error")
+	defer s.Close()
+
+	err = s.Put(store.DistrosBucket, "distro", []byte("record"))
+	require.NoError(t, err, "Setup: Put should return no error")
+
+	// The store is still open, which must not prevent backing it up.
+	path := filepath.Join(dir, "backup.db")
+	err = s.Backup(path)
+	require.NoError(t, err, "Backup should return no error while the store is open")
+	require.NoFileExists(t, path+".tmp", "The temporary file of the backup should have been renamed")
+
+	err = s.Put(store.DistrosBucket, "distro", []byte("newer record"))
+	require.NoError(t, err, "Setup: Put should return no error")
+
+	backup, err := store.Open(context.Background(), path)
+	require.NoError(t, err, "The backup should be a valid store")
+	defer backup.Close()
+
+	got, err := backup.Get(store.DistrosBucket, "distro")
+	require.NoError(t, err, "Get should return no error on a backup")
+	require.Equal(t, "record", string(got), "The backup should have the contents of the store at the time it was taken")
+
+	err = s.Backup(filepath.Join(dir, "does-not-exist", "backup.db"))
+	require.Error(t, err, "Backup should fail when the backup cannot be written")
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		initialVersion string
+		failMigration  int
+
+		wantVersion int
+		wantRuns    []int
+		wantErr     bool
+	}{
+		"Success migrating a new store":                      {wantVersion: 3, wantRuns: []int{1, 2, 3}},
+		"Success running the migrations not run yet":         {initialVersion: "1", wantVersion: 3, wantRuns: []int{2, 3}},
+		"Success leaving an up to date store untouched":      {initialVersion: "3", wantVersion: 3},
+		"Success leaving a store with a newer schema intact": {initialVersion: "5", wantVersion: 5},
+
+		"Error when a migration fails, keeping the previous ones": {failMigration: 2, wantVersion: 1, wantRuns: []int{1}, wantErr: true},
+		"Error when the version of the schema is invalid":         {initialVersion: "not a number", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := store.Open(context.Background(), filepath.Join(t.TempDir(), "store.db"))
+			require.NoError(t, err, "Setup: Open should return no error")
+			defer s.Close()
+
+			if tc.initialVersion != "" {
+				err = s.Put(store.StateBucket, "schema-version", []byte(tc.initialVersion))
+				require.NoError(t, err, "Setup: Put should return no error")
+			}
+
+			var runs []int
+			var migrations []store.Migration
+			for i := 1; i <= 3; i++ {
+				migrations = append(migrations, store.Migration{
+					Description: "mock migration",
+					Run: func(tx *store.Tx) error {
+						if i == tc.failMigration {
+							return errors.New("mock error")
+						}
+						runs = append(runs, i)
+						return nil
+					},
+				})
+			}
+
+			err = s.Migrate(context.Background(), migrations)
+			if tc.wantErr {
+				require.Error(t, err, "Migrate should have failed")
+			} else {
+				require.NoError(t, err, "Migrate should return no error")
+			}
+			require.Equal(t, tc.wantRuns, runs, "Unexpected migrations run")
+
+			if tc.initialVersion == "not a number" {
+				return
+			}
+			v, err := s.SchemaVersion()
+			require.NoError(t, err, "SchemaVersion should return no error")
+			require.Equal(t, tc.wantVersion, v, "Unexpected version of the schema after migrating")
+		})
+	}
+}
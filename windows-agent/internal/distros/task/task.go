@@ -3,7 +3,14 @@ package task
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
 )
 
 // Connection is a connection to the WSL-Pro-Service that allows for
@@ -11,6 +18,7 @@ import (
 type Connection interface {
 	SendProAttachment(proToken string) error
 	SendLandscapeConfig(lpeConfig string) error
+	SendCommand(cmd *agentapi.Command) (output []byte, err error)
 }
 
 // Task represents a given task that is ging to be executed by a distro.
@@ -26,15 +34,420 @@ type taskWithIs interface {
 
 // Is compares to tasks to determine if they match.
 //
-// A task is considered to match a target if it is equal to that target or if
-// it implements a method Is(Task) bool such that Is(target) returns true.
+// A task is considered to match a target if it is equal to that target, if
+// it implements a method Is(Task) bool such that Is(target) returns true, or
+// else if both are of the same type and have the same idempotency key.
 func Is(t, target Task) bool {
+	t, target = unwrap(t), unwrap(target)
 	if T, ok := t.(taskWithIs); ok {
 		return T.Is(target)
 	}
+	if reflect.TypeOf(t) == reflect.TypeOf(target) && SameKey(t, target) {
+		return true
+	}
 	return t == target
 }
 
+// taskWithKey are tasks that implement the IdempotencyKey method to declare when they are redundant.
+type taskWithKey interface {
+	Task
+	IdempotencyKey() string
+}
+
+// KeyOf returns the idempotency key of a task, and false if it has none. Tasks with the same key do the same
+// thing: once one of them is queued or in progress, submitting another changes nothing, so it is dropped.
+func KeyOf(t Task) (string, bool) {
+	if T, ok := unwrap(t).(taskWithKey); ok && T.IdempotencyKey() != "" {
+		return T.IdempotencyKey(), true
+	}
+	return "", false
+}
+
+// SameKey returns true if both tasks have an idempotency key and it is the same.
+func SameKey(t, other Task) bool {
+	key, ok := KeyOf(t)
+	if !ok {
+		return false
+	}
+	otherKey, ok := KeyOf(other)
+	return ok && key == otherKey
+}
+
+// Priority determines the order in which tasks are executed, and whether they can preempt
+// the task in progress. Tasks with the same priority run in order of submission.
+type Priority int
+
+const (
+	// PriorityNormal is the priority of tasks that do not declare one, e.g. background provisioning work.
+	PriorityNormal Priority = iota
+	// PriorityUser tasks are actions the user asked for, e.g. from the GUI. They run before normal ones,
+	// and preempt them if they are in progress, so that the user does not wait for background work.
+	PriorityUser
+	// PriorityHigh tasks run before any other, and preempt them if they are in progress.
+	PriorityHigh
+)
+
+// taskWithPriority are tasks that implement the Priority method to declare their priority.
+type taskWithPriority interface {
+	Task
+	Priority() Priority
+}
+
+// PriorityOf returns the priority of a task: the one returned by its method Priority() Priority
+// if it implements it, and PriorityNormal otherwise.
+func PriorityOf(t Task) Priority {
+	if T, ok := unwrap(t).(taskWithPriority); ok {
+		return T.Priority()
+	}
+	return PriorityNormal
+}
+
+// Lane is a class of tasks that must run one at a time. Tasks in different lanes are independent
+// and run concurrently, while tasks in the same lane run in order of priority and submission.
+type Lane string
+
+// LaneDefault is the lane of tasks that do not declare one.
+const LaneDefault Lane = "default"
+
+// taskWithLane are tasks that implement the Lane method to declare their lane.
+type taskWithLane interface {
+	Task
+	Lane() Lane
+}
+
+// LaneOf returns the lane of a task: the one returned by its method Lane() Lane if it
+// implements it, and LaneDefault otherwise.
+func LaneOf(t Task) Lane {
+	if T, ok := unwrap(t).(taskWithLane); ok && T.Lane() != "" {
+		return T.Lane()
+	}
+	return LaneDefault
+}
+
+// taskWithWake are tasks that implement the WakesDistro method to declare whether they wake their distro up.
+type taskWithWake interface {
+	Task
+	WakesDistro() bool
+}
+
+// WakesDistro returns whether a task wakes its distro up if it is stopped: the value returned by its method
+// WakesDistro() bool if it implements it, and true otherwise. Tasks that do not wake their distro up wait
+// until it connects.
+func WakesDistro(t Task) bool {
+	if T, ok := unwrap(t).(taskWithWake); ok {
+		return T.WakesDistro()
+	}
+	return true
+}
+
+// taskWithProClient are tasks that implement the NeedsProClient method to declare whether they run the
+// Ubuntu Pro client in their distro.
+type taskWithProClient interface {
+	Task
+	NeedsProClient() bool
+}
+
+// NeedsProClient returns whether a task runs the Ubuntu Pro client in its distro: the value returned by its
+// method NeedsProClient() bool if it implements it, and false otherwise. Such tasks are skipped in distros
+// the client cannot run in.
+func NeedsProClient(t Task) bool {
+	if T, ok := unwrap(t).(taskWithProClient); ok {
+		return T.NeedsProClient()
+	}
+	return false
+}
+
+// taskWithWSL2 are tasks that implement the NeedsWSL2 method to declare whether they need WSL 2.
+type taskWithWSL2 interface {
+	Task
+	NeedsWSL2() bool
+}
+
+// NeedsWSL2 returns whether a task needs its distro to run on WSL 2, e.g. because it manages systemd services or
+// the virtual disk of the distro: the value returned by its method NeedsWSL2() bool if it implements it, and false
+// otherwise. Such tasks are skipped in distros that run on WSL 1.
+func NeedsWSL2(t Task) bool {
+	if T, ok := unwrap(t).(taskWithWSL2); ok {
+		return T.NeedsWSL2()
+	}
+	return false
+}
+
+// taskOnHost are tasks that implement the RunsOnHost method to declare whether they run on the Windows host.
+type taskOnHost interface {
+	Task
+	RunsOnHost() bool
+}
+
+// RunsOnHost returns whether a task runs on the Windows host rather than in its distro: the value returned by its
+// method RunsOnHost() bool if it implements it, and false otherwise. Such tasks operate on the distro as a whole,
+// e.g. on its VHD, so they wait until it is stopped and are executed without a connection.
+func RunsOnHost(t Task) bool {
+	if T, ok := unwrap(t).(taskOnHost); ok {
+		return T.RunsOnHost()
+	}
+	return false
+}
+
+// taskWithConsent are tasks that implement the ConsentPrompt method to require user confirmation.
+type taskWithConsent interface {
+	Task
+	ConsentPrompt() string
+}
+
+// ConsentPromptOf returns the prompt shown to the user to confirm the task, and whether the task needs
+// confirmation at all: only tasks that implement the method ConsentPrompt() string and return a non-empty
+// prompt do.
+func ConsentPromptOf(t Task) (prompt string, ok bool) {
+	if T, ok := unwrap(t).(taskWithConsent); ok && T.ConsentPrompt() != "" {
+		return T.ConsentPrompt(), true
+	}
+	return "", false
+}
+
+// taskWithTimeout are tasks that implement the Timeout method to declare how long they can run.
+type taskWithTimeout interface {
+	Task
+	Timeout() time.Duration
+}
+
+// TimeoutOf returns how long each execution of a task can run before its context is cancelled: the value
+// returned by its method Timeout() time.Duration if it implements it, and 0, meaning no limit, otherwise.
+// Tasks that time out are retried according to their retry policy.
+func TimeoutOf(t Task) time.Duration {
+	if T, ok := unwrap(t).(taskWithTimeout); ok {
+		return max(T.Timeout(), 0)
+	}
+	return 0
+}
+
+// RetryPolicy is how a task that fails transiently, with a NeedsRetryError or by timing out, is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times the task is executed at most, including the first one.
+	MaxAttempts int
+	// Backoff is how long to wait before the first retry. It doubles with each retry, up to MaxBackoff if set.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Delay returns how long to wait before the given retry, 1 being the first one.
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	d := p.Backoff
+	for range retry - 1 {
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff || d > time.Duration(math.MaxInt64/2) {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		return min(d, p.MaxBackoff)
+	}
+	return d
+}
+
+// taskWithRetryPolicy are tasks that implement the RetryPolicy method to declare how they are retried.
+type taskWithRetryPolicy interface {
+	Task
+	RetryPolicy() RetryPolicy
+}
+
+// RetryPolicyOf returns the retry policy of a task, and whether it declares one at all: only tasks that implement
+// the method RetryPolicy() RetryPolicy do. Those that do not are deferred until their distro connects again when
+// they return a NeedsRetryError, and are not retried when they time out.
+func RetryPolicyOf(t Task) (policy RetryPolicy, ok bool) {
+	if T, ok := unwrap(t).(taskWithRetryPolicy); ok {
+		return T.RetryPolicy(), true
+	}
+	return RetryPolicy{}, false
+}
+
+// distroNameKey is the context key under which the name of the distro of the task in progress is stored.
+type distroNameKey struct{}
+
+// WithDistroName returns a context for executing a task in the distro with the given name.
+func WithDistroName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, distroNameKey{}, name)
+}
+
+// DistroName returns the name of the distro a task is executed in, so that the tasks that run on the host
+// know which distro to operate on. It is empty if the context was not created with WithDistroName.
+func DistroName(ctx context.Context) string {
+	name, _ := ctx.Value(distroNameKey{}).(string)
+	return name
+}
+
+// userInitiatedKey is the context key under which whether the tasks are submitted on behalf of the user is stored.
+type userInitiatedKey struct{}
+
+// WithUserInitiated returns a context for submitting tasks on behalf of the user, e.g. from the GUI, so that
+// the code building them down the line knows to give them PriorityUser.
+func WithUserInitiated(ctx context.Context) context.Context {
+	return context.WithValue(ctx, userInitiatedKey{}, true)
+}
+
+// UserInitiated returns true if the context was created with WithUserInitiated.
+func UserInitiated(ctx context.Context) bool {
+	user, _ := ctx.Value(userInitiatedKey{}).(bool)
+	return user
+}
+
+// progressReporterKey is the context key under which the progress reporter of the task in progress is stored.
+type progressReporterKey struct{}
+
+// WithProgressReporter returns a context for executing a task, such that calls to ReportProgress
+// and ReportStepProgress with it are forwarded to report.
+func WithProgressReporter(ctx context.Context, report func(percent uint32, step string)) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, report)
+}
+
+// ReportProgress lets long-running tasks report their percentage of completion. It does nothing
+// if the context was not created with WithProgressReporter.
+func ReportProgress(ctx context.Context, percent uint32) {
+	ReportStepProgress(ctx, percent, "")
+}
+
+// ReportStepProgress is like ReportProgress, also telling what the task is doing, e.g. the step
+// a distro reported for the command it is running.
+func ReportStepProgress(ctx context.Context, percent uint32, step string) {
+	report, ok := ctx.Value(progressReporterKey{}).(func(uint32, string))
+	if !ok {
+		return
+	}
+	report(min(percent, 100), step)
+}
+
+// TransferMeter accounts for the data a task transfers to and from its distro. See bandwidth.Meter.
+type TransferMeter interface {
+	ReserveTransfer(kind bandwidth.Kind, n uint64) error
+	RecordTransfer(kind bandwidth.Kind, n uint64)
+}
+
+// transferMeterKey is the context key under which the transfer meter of the task in progress is stored.
+type transferMeterKey struct{}
+
+// WithTransferMeter returns a context for executing a task, such that calls to ReserveTransfer and
+// RecordTransfer with it are forwarded to m.
+func WithTransferMeter(ctx context.Context, m TransferMeter) context.Context {
+	return context.WithValue(ctx, transferMeterKey{}, m)
+}
+
+// ReserveTransfer lets tasks account for the n bytes they are about to transfer to or from their distro. The
+// error is a bandwidth.CapReachedError if the transfer would exceed the daily cap of the distro: the task must
+// not go ahead with it, and is retried once the cap resets if it fails with it. It does nothing if the context
+// was not created with WithTransferMeter.
+func ReserveTransfer(ctx context.Context, kind bandwidth.Kind, n uint64) error {
+	m, ok := ctx.Value(transferMeterKey{}).(TransferMeter)
+	if !ok {
+		return nil
+	}
+	return m.ReserveTransfer(kind, n)
+}
+
+// RecordTransfer lets tasks account for the n bytes they transferred to or from their distro, when their size
+// could not be known beforehand. It does nothing if the context was not created with WithTransferMeter.
+func RecordTransfer(ctx context.Context, kind bandwidth.Kind, n uint64) {
+	m, ok := ctx.Value(transferMeterKey{}).(TransferMeter)
+	if !ok {
+		return
+	}
+	m.RecordTransfer(kind, n)
+}
+
+// StepStatus is the outcome of a step of a multi-step task.
+type StepStatus int
+
+const (
+	// StepSucceeded is the status of steps that ran without errors.
+	StepSucceeded StepStatus = iota
+	// StepFailed is the status of steps that ran and returned an error.
+	StepFailed
+	// StepSkipped is the status of steps that did not run, e.g. because an earlier step they depend on failed.
+	StepSkipped
+)
+
+func (s StepStatus) String() string {
+	switch s {
+	case StepSucceeded:
+		return "succeeded"
+	case StepFailed:
+		return "failed"
+	case StepSkipped:
+		return "skipped"
+	}
+	return fmt.Sprintf("unknown step status %d", int(s))
+}
+
+// Step is the outcome of a step of a multi-step task.
+type Step struct {
+	Name   string     `json:"name" yaml:"name"`
+	Status StepStatus `json:"status" yaml:"status"`
+
+	// Message is why the step failed or was skipped.
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// stepRecorderKey is the context key under which the step recorder of the task in progress is stored.
+type stepRecorderKey struct{}
+
+// WithStepRecorder returns a context for executing a task, such that the outcome of the steps
+// run with RunStep or skipped with SkipStep are forwarded to record.
+func WithStepRecorder(ctx context.Context, record func(Step)) context.Context {
+	return context.WithValue(ctx, stepRecorderKey{}, record)
+}
+
+// RunStep runs one step of a multi-step task and records its outcome, so that a partial success
+// can be told apart from a task that failed altogether. It returns the error of the step.
+func RunStep(ctx context.Context, name string, step func() error) error {
+	err := step()
+
+	s := Step{Name: name, Status: StepSucceeded}
+	if err != nil {
+		s.Status = StepFailed
+		s.Message = err.Error()
+	}
+	recordStep(ctx, s)
+
+	return err
+}
+
+// SkipStep records that a step of a multi-step task did not run, and why.
+func SkipStep(ctx context.Context, name, reason string) {
+	recordStep(ctx, Step{Name: name, Status: StepSkipped, Message: reason})
+}
+
+// recordStep forwards the outcome of a step to the recorder of the context. It does nothing
+// if the context was not created with WithStepRecorder.
+func recordStep(ctx context.Context, s Step) {
+	record, ok := ctx.Value(stepRecorderKey{}).(func(Step))
+	if !ok {
+		return
+	}
+	record(s)
+}
+
+// ErrPreempted is the error returned by tasks that stopped at a safe point because a task with
+// higher priority was submitted. They are resumed by executing them again.
+var ErrPreempted = errors.New("preempted by a higher priority task")
+
+// ErrInterrupted is the error returned by tasks whose distro disconnected while they were in progress, e.g.
+// because it was terminated. The worker resumes them by executing them again once the distro connects again.
+var ErrInterrupted = errors.New("interrupted by the distro disconnecting")
+
+// ErrCancelled is the error of tasks that were cancelled while queued or in progress (see Worker.Cancel). They are
+// not retried.
+var ErrCancelled = errors.New("cancelled")
+
+// ErrConsentDenied is the error of tasks that did not run because the user did not confirm them.
+var ErrConsentDenied = errors.New("the user did not consent to the task")
+
+// ErrUnsupported is the error of tasks that did not run because they need the Ubuntu Pro client, which cannot
+// run in their distro. They are not retried.
+var ErrUnsupported = errors.New("the Ubuntu Pro client cannot run in the distro")
+
+// ErrNeedsWSL2 is the error of tasks that did not run because they need WSL 2 and their distro runs on WSL 1. They
+// are not retried.
+var ErrNeedsWSL2 = errors.New("the task needs WSL 2 but the distro runs on WSL 1")
+
 // NeedsRetryError is an error that should be emitted by tasks that, in case of failure,
 // should be retried at the next startup sequence.
 type NeedsRetryError struct {
@@ -44,3 +457,7 @@ type NeedsRetryError struct {
 func (e NeedsRetryError) Error() string {
 	return fmt.Sprintf("failed but will be retried: %v", e.SourceErr)
 }
+
+func (e NeedsRetryError) Unwrap() error {
+	return e.SourceErr
+}
@@ -2,10 +2,13 @@ package task_test
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/stretchr/testify/require"
 )
@@ -141,6 +144,512 @@ func TestMarsallUnmarshall(t *testing.T) {
 	}
 }
 
+//nolint:tparallel // Cannot make test parallel because of BackupRegistry.
+func TestRetainUnknownFields(t *testing.T) {
+	task.BackupRegistry(t)
+	task.Register[testTask]()
+
+	testCases := map[string]struct {
+		want task.Task
+	}{
+		"Task with no unknown fields":              {want: testTask{Message: "Hello, world!", Number: 42}},
+		"Task with unknown fields":                 {want: testTask{Message: "Hello, world!", Number: 42}},
+		"Task with unknown fields of complex type": {want: testTask{Message: "Hello, world!", Number: 42}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(testutils.TestFixturePath(t))
+			require.NoError(t, err, "Setup: could not find fixture")
+
+			tasks, err := task.UnmarshalYAML(data)
+			require.NoError(t, err, "Task with unknown fields should not fail to unmarshal")
+			require.Len(t, tasks, 1, "One and only one task was expected")
+			require.True(t, task.Is(tasks[0], tc.want), "Task was not properly unmarshaled")
+			require.True(t, task.Is(tc.want, tasks[0]), "Task comparison should ignore unknown fields")
+
+			got, err := task.MarshalYAML(tasks)
+			require.NoError(t, err, "Task with unknown fields should marshal with no errors")
+
+			want := testutils.LoadWithUpdateFromGolden(t, string(got))
+			require.Equal(t, want, string(got), "Unknown fields should have been retained")
+		})
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	t.Parallel()
+
+	type progress struct {
+		percent uint32
+		step    string
+	}
+
+	testCases := map[string]struct {
+		withoutReporter bool
+		percent         uint32
+		step            string
+
+		want []progress
+	}{
+		"Progress is forwarded to the reporter": {percent: 42, want: []progress{{percent: 42}}},
+		"Progress is capped at 100%":            {percent: 250, want: []progress{{percent: 100}}},
+		"Step is forwarded to the reporter":     {percent: 42, step: "Installing", want: []progress{{percent: 42, step: "Installing"}}},
+
+		"No-op without a reporter": {withoutReporter: true, percent: 42, step: "Installing"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []progress
+			ctx := context.Background()
+			if !tc.withoutReporter {
+				ctx = task.WithProgressReporter(ctx, func(p uint32, s string) { got = append(got, progress{percent: p, step: s}) })
+			}
+
+			if tc.step == "" {
+				task.ReportProgress(ctx, tc.percent)
+			} else {
+				task.ReportStepProgress(ctx, tc.percent, tc.step)
+			}
+			require.Equal(t, tc.want, got, "Unexpected progress reported")
+		})
+	}
+}
+
+func TestTransfers(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		withoutMeter bool
+		capReached   bool
+
+		wantReserved []uint64
+		wantRecorded []uint64
+		wantErr      bool
+	}{
+		"Transfers are forwarded to the meter": {wantReserved: []uint64{42}, wantRecorded: []uint64{7}},
+
+		"No-op without a meter":                   {withoutMeter: true},
+		"Error when the meter refuses a transfer": {capReached: true, wantRecorded: []uint64{7}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := &testMeter{capReached: tc.capReached}
+			ctx := context.Background()
+			if !tc.withoutMeter {
+				ctx = task.WithTransferMeter(ctx, m)
+			}
+
+			err := task.ReserveTransfer(ctx, bandwidth.KindDebs, 42)
+			task.RecordTransfer(ctx, bandwidth.KindFiles, 7)
+
+			if tc.wantErr {
+				require.ErrorIs(t, err, bandwidth.ErrCapReached, "ReserveTransfer should return the error of the meter")
+			} else {
+				require.NoError(t, err, "ReserveTransfer should return no error")
+			}
+			require.Equal(t, tc.wantReserved, m.reserved, "Unexpected transfers reserved")
+			require.Equal(t, tc.wantRecorded, m.recorded, "Unexpected transfers recorded")
+		})
+	}
+}
+
+// testMeter is a task.TransferMeter that keeps the transfers it is told about.
+type testMeter struct {
+	capReached bool
+
+	reserved []uint64
+	recorded []uint64
+}
+
+func (m *testMeter) ReserveTransfer(kind bandwidth.Kind, n uint64) error {
+	if m.capReached {
+		return bandwidth.ErrCapReached
+	}
+	m.reserved = append(m.reserved, n)
+	return nil
+}
+
+func (m *testMeter) RecordTransfer(kind bandwidth.Kind, n uint64) {
+	m.recorded = append(m.recorded, n)
+}
+
+func TestSteps(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		withoutRecorder bool
+		stepErr         error
+		skip            bool
+
+		wantErr bool
+		want    []task.Step
+	}{
+		"Successful steps are recorded": {want: []task.Step{{Name: "step", Status: task.StepSucceeded}}},
+		"Failed steps are recorded with their error": {stepErr: errors.New("mock error"), wantErr: true,
+			want: []task.Step{{Name: "step", Status: task.StepFailed, Message: "mock error"}}},
+		"Skipped steps are recorded with the reason": {skip: true,
+			want: []task.Step{{Name: "step", Status: task.StepSkipped, Message: "mock reason"}}},
+
+		"Steps still run without a recorder":        {withoutRecorder: true},
+		"Errors are returned even without recorder": {withoutRecorder: true, stepErr: errors.New("mock error"), wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []task.Step
+			ctx := context.Background()
+			if !tc.withoutRecorder {
+				ctx = task.WithStepRecorder(ctx, func(s task.Step) { got = append(got, s) })
+			}
+
+			if tc.skip {
+				task.SkipStep(ctx, "step", "mock reason")
+				require.Equal(t, tc.want, got, "Unexpected steps recorded")
+				return
+			}
+
+			var ran bool
+			err := task.RunStep(ctx, "step", func() error {
+				ran = true
+				return tc.stepErr
+			})
+			require.True(t, ran, "The step should have run")
+			if tc.wantErr {
+				require.ErrorIs(t, err, tc.stepErr, "RunStep should return the error of the step")
+			} else {
+				require.NoError(t, err, "RunStep should not return an error")
+			}
+			require.Equal(t, tc.want, got, "Unexpected steps recorded")
+		})
+	}
+}
+
+func TestLaneOf(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		task task.Task
+
+		want task.Lane
+	}{
+		"Tasks declaring a lane run in it":                 {task: lanedTask{lane: "mock lane"}, want: "mock lane"},
+		"Tasks not declaring a lane run in the default":    {task: emptyTask{}, want: task.LaneDefault},
+		"Tasks declaring an empty lane run in the default": {task: lanedTask{}, want: task.LaneDefault},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.want, task.LaneOf(tc.task), "Unexpected lane")
+		})
+	}
+}
+
+func TestConsentPromptOf(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		task task.Task
+
+		wantPrompt string
+		wantOk     bool
+	}{
+		"Tasks declaring a prompt need consent":               {task: consentTask{prompt: "mock prompt"}, wantPrompt: "mock prompt", wantOk: true},
+		"Tasks not declaring a prompt do not need consent":    {task: emptyTask{}},
+		"Tasks declaring an empty prompt do not need consent": {task: consentTask{}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			prompt, ok := task.ConsentPromptOf(tc.task)
+			require.Equal(t, tc.wantOk, ok, "Unexpected need for consent")
+			require.Equal(t, tc.wantPrompt, prompt, "Unexpected prompt")
+		})
+	}
+}
+
+func TestWakesDistro(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		task task.Task
+
+		want bool
+	}{
+		"Tasks declaring they wake the distro wake it":        {task: wakingTask{wakes: true}, want: true},
+		"Tasks declaring they do not wake the distro wait":    {task: wakingTask{}},
+		"Tasks not declaring whether they wake the distro do": {task: emptyTask{}, want: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.want, task.WakesDistro(tc.task), "Unexpected waking of the distro")
+		})
+	}
+}
+
+func TestRunsOnHost(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		task task.Task
+
+		want bool
+	}{
+		"Tasks declaring they run on the host do":              {task: hostTask{onHost: true}, want: true},
+		"Tasks declaring they do not run on the host do not":   {task: hostTask{}},
+		"Tasks not declaring where they run run in the distro": {task: emptyTask{}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.want, task.RunsOnHost(tc.task), "Unexpected place the task runs in")
+		})
+	}
+}
+
+func TestNeedsWSL2(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		task task.Task
+
+		want bool
+	}{
+		"Tasks declaring they need WSL 2 do":            {task: wsl2Task{needsWSL2: true}, want: true},
+		"Tasks declaring they do not need WSL 2 do not": {task: wsl2Task{}},
+		"Tasks not declaring it do not need WSL 2":      {task: emptyTask{}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.want, task.NeedsWSL2(tc.task), "Unexpected need for WSL 2")
+		})
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		task  task.Task
+		other task.Task
+
+		wantKey     string
+		wantSameKey bool
+		wantIs      bool
+	}{
+		"Tasks with the same key are the same":                  {task: keyedTask{key: "a"}, other: keyedTask{key: "a"}, wantKey: "a", wantSameKey: true, wantIs: true},
+		"Tasks with the same key and other fields are the same": {task: keyedTask{key: "a", Value: 1}, other: keyedTask{key: "a", Value: 2}, wantKey: "a", wantSameKey: true, wantIs: true},
+		"Tasks of other types with the same key are not equal":  {task: keyedTask{key: "a"}, other: otherKeyedTask{keyedTask{key: "a"}}, wantKey: "a", wantSameKey: true},
+
+		"Tasks with different keys are not the same":         {task: keyedTask{key: "a"}, other: keyedTask{key: "b"}, wantKey: "a"},
+		"Tasks with an empty key have none":                  {task: keyedTask{}, other: keyedTask{}, wantIs: true},
+		"Tasks not declaring a key have none":                {task: emptyTask{}, other: emptyTask{}, wantIs: true},
+		"Tasks with a key are not the same as those without": {task: keyedTask{key: "a"}, other: emptyTask{}, wantKey: "a"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			key, ok := task.KeyOf(tc.task)
+			require.Equal(t, tc.wantKey != "", ok, "Unexpected presence of the key")
+			require.Equal(t, tc.wantKey, key, "Unexpected key")
+
+			require.Equal(t, tc.wantSameKey, task.SameKey(tc.task, tc.other), "Unexpected key comparison")
+			require.Equal(t, tc.wantSameKey, task.SameKey(tc.other, tc.task), "Key comparison should be symmetric")
+			require.Equal(t, tc.wantIs, task.Is(tc.task, tc.other), "Unexpected task comparison")
+		})
+	}
+}
+
+func TestTimeoutOf(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		task task.Task
+
+		want time.Duration
+	}{
+		"Tasks declaring a timeout have it":            {task: retriedTask{timeout: time.Minute}, want: time.Minute},
+		"Tasks declaring a negative timeout have none": {task: retriedTask{timeout: -time.Minute}},
+		"Tasks not declaring a timeout have none":      {task: emptyTask{}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.want, task.TimeoutOf(tc.task), "Unexpected timeout of the task")
+		})
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		task task.Task
+
+		wantPolicy bool
+		wantDelays []time.Duration
+	}{
+		"Tasks declaring a retry policy back off exponentially": {
+			task:       retriedTask{policy: task.RetryPolicy{MaxAttempts: 5, Backoff: time.Second}},
+			wantPolicy: true,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		"Tasks declaring a maximum backoff do not wait longer": {
+			task:       retriedTask{policy: task.RetryPolicy{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 3 * time.Second}},
+			wantPolicy: true,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		"Tasks declaring a huge backoff do not overflow": {
+			task:       retriedTask{policy: task.RetryPolicy{MaxAttempts: 100, Backoff: time.Hour}},
+			wantPolicy: true,
+		},
+		"Tasks not declaring a retry policy have none": {task: emptyTask{}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, ok := task.RetryPolicyOf(tc.task)
+			require.Equal(t, tc.wantPolicy, ok, "Unexpected presence of a retry policy")
+			if !ok {
+				return
+			}
+
+			for i, want := range tc.wantDelays {
+				require.Equal(t, want, policy.Delay(i+1), "Unexpected delay before retry %d", i+1)
+			}
+			for retry := range policy.MaxAttempts {
+				require.Positive(t, policy.Delay(retry+1), "Delays should never overflow")
+			}
+		})
+	}
+}
+
+func TestDistroName(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, task.DistroName(context.Background()), "DistroName should be empty without WithDistroName")
+
+	ctx := task.WithDistroName(context.Background(), "Ubuntu")
+	require.Equal(t, "Ubuntu", task.DistroName(ctx), "DistroName should return the name set with WithDistroName")
+}
+
+func TestUserInitiated(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, task.UserInitiated(context.Background()), "UserInitiated should be false without WithUserInitiated")
+
+	ctx := task.WithUserInitiated(context.Background())
+	require.True(t, task.UserInitiated(ctx), "UserInitiated should be true with WithUserInitiated")
+}
+
+type consentTask struct {
+	prompt string
+
+	DummyImplementer `yaml:"-"`
+}
+
+func (t consentTask) ConsentPrompt() string {
+	return t.prompt
+}
+
+type wakingTask struct {
+	wakes bool
+
+	DummyImplementer `yaml:"-"`
+}
+
+func (t wakingTask) WakesDistro() bool {
+	return t.wakes
+}
+
+type hostTask struct {
+	onHost bool
+
+	DummyImplementer `yaml:"-"`
+}
+
+func (t hostTask) RunsOnHost() bool {
+	return t.onHost
+}
+
+type wsl2Task struct {
+	needsWSL2 bool
+
+	DummyImplementer `yaml:"-"`
+}
+
+func (t wsl2Task) NeedsWSL2() bool {
+	return t.needsWSL2
+}
+
+type retriedTask struct {
+	timeout time.Duration
+	policy  task.RetryPolicy
+
+	DummyImplementer `yaml:"-"`
+}
+
+func (t retriedTask) Timeout() time.Duration {
+	return t.timeout
+}
+
+func (t retriedTask) RetryPolicy() task.RetryPolicy {
+	return t.policy
+}
+
+type keyedTask struct {
+	Value int
+	key   string
+
+	DummyImplementer `yaml:"-"`
+}
+
+func (t keyedTask) IdempotencyKey() string {
+	return t.key
+}
+
+type otherKeyedTask struct {
+	keyedTask
+}
+
+type lanedTask struct {
+	lane task.Lane
+
+	DummyImplementer `yaml:"-"`
+}
+
+func (t lanedTask) Lane() task.Lane {
+	return t.lane
+}
+
 type testTask struct {
 	Message string
 	Number  uint64
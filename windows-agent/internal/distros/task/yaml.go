@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/unknownfields"
 	"github.com/ubuntu/decorate"
 	"gopkg.in/yaml.v3"
 )
@@ -19,11 +20,39 @@ func Register[T Task]() {
 	typename := reflect.TypeOf((*T)(nil)).Elem().String()
 	registry[typename] = func(node *yaml.Node) (Task, error) {
 		var t T
-		err := node.Decode(&t)
-		return t, err
+		unknown, err := unknownfields.Decode(node, &t)
+		if err != nil || len(unknown) == 0 {
+			return t, err
+		}
+		return withUnknownFields{Task: t, unknown: unknown}, nil
 	}
 }
 
+// withUnknownFields wraps tasks that were stored by a newer version of the agent with
+// fields unknown to this one, so that they are not lost when the task is stored again.
+type withUnknownFields struct {
+	Task
+	unknown unknownfields.Fields
+}
+
+// String returns the description of the wrapped task.
+func (t withUnknownFields) String() string {
+	return fmt.Sprint(t.Task)
+}
+
+// MarshalYAML marshals the wrapped task along with its unknown fields.
+func (t withUnknownFields) MarshalYAML() (any, error) {
+	return unknownfields.Encode(t.Task, t.unknown)
+}
+
+// unwrap returns the task stripped of its unknown fields.
+func unwrap(t Task) Task {
+	if w, ok := t.(withUnknownFields); ok {
+		return w.Task
+	}
+	return t
+}
+
 type yamlTaskHelper struct {
 	Task Task
 	Type string
@@ -35,7 +64,7 @@ func MarshalYAML(tasks []Task) (out []byte, err error) {
 	for i := range tasks {
 		t := tasks[i]
 		tmp = append(tmp, yamlTaskHelper{
-			Type: reflect.TypeOf(t).String(),
+			Type: reflect.TypeOf(unwrap(t)).String(),
 			Task: t,
 		})
 	}
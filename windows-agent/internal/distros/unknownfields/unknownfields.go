@@ -0,0 +1,120 @@
+// Package unknownfields retains the YAML fields that a struct does not know about, so that data written
+// by newer versions of the agent survives being loaded and saved again by older ones.
+package unknownfields
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fields are the key-value pairs of a YAML mapping that did not match any field of a struct.
+// They are stored as a flat list of alternating key and value nodes.
+type Fields []*yaml.Node
+
+// Decode decodes the mapping node into out and returns the fields of the mapping that out does not know
+// about. Only structs can have unknown fields: for any other type, the returned fields are always empty.
+//
+// The type of out must not implement yaml.Unmarshaler by calling Decode, or it would recurse forever: use a
+// type definition without methods instead.
+func Decode(node *yaml.Node, out any) (Fields, error) {
+	if err := node.Decode(out); err != nil {
+		return nil, err
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	known, ok := knownKeys(reflect.TypeOf(out))
+	if !ok {
+		return nil, nil
+	}
+
+	var unknown Fields
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if _, ok := known[node.Content[i].Value]; ok {
+			continue
+		}
+		unknown = append(unknown, node.Content[i], node.Content[i+1])
+	}
+
+	return unknown, nil
+}
+
+// Encode encodes in into a mapping node, and appends the unknown fields to it. Fields known to in take
+// precedence over unknown fields with the same key.
+//
+// The type of in must not implement yaml.Marshaler by calling Encode, or it would recurse forever: use a
+// type definition without methods instead.
+func Encode(in any, unknown Fields) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(in); err != nil {
+		return nil, err
+	}
+
+	if len(unknown) == 0 {
+		return node, nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("cannot append unknown fields to a YAML node of kind %v", node.Kind)
+	}
+
+	present := make(map[string]struct{}, len(node.Content)/2)
+	for i := 0; i < len(node.Content); i += 2 {
+		present[node.Content[i].Value] = struct{}{}
+	}
+
+	for i := 0; i+1 < len(unknown); i += 2 {
+		if _, ok := present[unknown[i].Value]; ok {
+			continue
+		}
+		node.Content = append(node.Content, unknown[i], unknown[i+1])
+	}
+
+	return node, nil
+}
+
+// knownKeys returns the set of YAML keys that the struct (or pointer to struct) type t knows about.
+// It returns false if t is not a struct, or if it accepts any key via an inlined map.
+func knownKeys(t reflect.Type) (map[string]struct{}, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	keys := make(map[string]struct{})
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, flags, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+		// Unexported fields are ignored, unless they are inlined embedded structs.
+		if !field.IsExported() && !field.Anonymous || name == "-" {
+			continue
+		}
+
+		if strings.Contains(flags, "inline") {
+			inlined, ok := knownKeys(field.Type)
+			if !ok {
+				return nil, false
+			}
+			for k := range inlined {
+				keys[k] = struct{}{}
+			}
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		keys[name] = struct{}{}
+	}
+
+	return keys, true
+}
@@ -0,0 +1,129 @@
+package unknownfields_test
+
+import (
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/unknownfields"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type inlined struct {
+	Inlined string
+}
+
+type object struct {
+	Name    string
+	Renamed string `yaml:"other"`
+	Skipped string `yaml:"-"`
+	Empty   string `yaml:",omitempty"`
+	inlined `yaml:",inline"`
+
+	private string
+}
+
+func TestDecodeEncode(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		input string
+
+		want        object
+		wantUnknown []string
+		wantOutput  string
+		wantErr     bool
+	}{
+		"Success with no unknown fields": {
+			input:      "name: hello\nother: world\ninlined: inline\n",
+			want:       object{Name: "hello", Renamed: "world", inlined: inlined{Inlined: "inline"}},
+			wantOutput: "name: hello\nother: world\ninlined: inline\n",
+		},
+		"Success with omitted fields": {
+			input:      "empty: not empty\n",
+			want:       object{Empty: "not empty"},
+			wantOutput: "name: \"\"\nother: \"\"\nempty: not empty\ninlined: \"\"\n",
+		},
+		"Success with unknown fields": {
+			input:       "name: hello\nnew: field\nnested:\n  a: 1\n",
+			want:        object{Name: "hello"},
+			wantUnknown: []string{"new", "nested"},
+			wantOutput:  "name: hello\nother: \"\"\ninlined: \"\"\nnew: field\nnested:\n    a: 1\n",
+		},
+		"Success treating ignored and unexported fields as unknown": {
+			input:       "skipped: a\nprivate: b\n",
+			wantUnknown: []string{"skipped", "private"},
+			wantOutput:  "name: \"\"\nother: \"\"\ninlined: \"\"\nskipped: a\nprivate: b\n",
+		},
+		"Success with a field renamed away": {
+			input:       "renamed: a\n",
+			wantUnknown: []string{"renamed"},
+			wantOutput:  "name: \"\"\nother: \"\"\ninlined: \"\"\nrenamed: a\n",
+		},
+
+		"Error when the YAML does not match the struct": {input: "name: [1, 2]\n", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var node yaml.Node
+			err := yaml.Unmarshal([]byte(tc.input), &node)
+			require.NoError(t, err, "Setup: could not parse input")
+
+			var got object
+			unknown, err := unknownfields.Decode(node.Content[0], &got)
+			if tc.wantErr {
+				require.Error(t, err, "Decode should have returned an error")
+				return
+			}
+			require.NoError(t, err, "Decode should have returned no error")
+			require.Equal(t, tc.want, got, "Mismatch in the decoded object")
+
+			var gotUnknown []string
+			for i := 0; i < len(unknown); i += 2 {
+				gotUnknown = append(gotUnknown, unknown[i].Value)
+			}
+			require.Equal(t, tc.wantUnknown, gotUnknown, "Mismatch in the unknown fields")
+
+			out, err := unknownfields.Encode(got, unknown)
+			require.NoError(t, err, "Encode should have returned no error")
+
+			output, err := yaml.Marshal(out)
+			require.NoError(t, err, "Could not marshal the encoded node")
+			require.Equal(t, tc.wantOutput, string(output), "Mismatch in the encoded YAML")
+		})
+	}
+}
+
+func TestEncodeKnownFieldsTakePrecedence(t *testing.T) {
+	t.Parallel()
+
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte("name: old\n"), &node)
+	require.NoError(t, err, "Setup: could not parse input")
+
+	out, err := unknownfields.Encode(object{Name: "new"}, unknownfields.Fields(node.Content[0].Content))
+	require.NoError(t, err, "Encode should have returned no error")
+
+	output, err := yaml.Marshal(out)
+	require.NoError(t, err, "Could not marshal the encoded node")
+	require.Equal(t, "name: new\nother: \"\"\ninlined: \"\"\n", string(output), "Known fields should not be overridden by unknown ones")
+}
+
+func TestNotStructs(t *testing.T) {
+	t.Parallel()
+
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte("a: 1\nb: 2\n"), &node)
+	require.NoError(t, err, "Setup: could not parse input")
+
+	var got map[string]int
+	unknown, err := unknownfields.Decode(node.Content[0], &got)
+	require.NoError(t, err, "Decode should have returned no error")
+	require.Empty(t, unknown, "Maps should have no unknown fields")
+	require.Equal(t, map[string]int{"a": 1, "b": 2}, got, "Mismatch in the decoded map")
+
+	_, err = unknownfields.Encode("scalar", unknownfields.Fields(node.Content[0].Content))
+	require.Error(t, err, "Encode should refuse to append fields to a scalar")
+}
@@ -0,0 +1,265 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+// EventType is the stage of the task lifecycle an Event is about.
+type EventType int
+
+const (
+	// EventQueued is emitted when a task is submitted, or put back in the queue after being preempted.
+	EventQueued EventType = iota
+	// EventStarted is emitted when a task starts executing.
+	EventStarted
+	// EventProgress is emitted when a task reports its percentage of completion. The percentage never goes back
+	// while the task is in progress.
+	EventProgress
+	// EventCompleted is emitted when a task finishes successfully.
+	EventCompleted
+	// EventFailed is emitted when a task finishes with an error.
+	EventFailed
+	// EventInterrupted is emitted when the distro of a task disconnects while it is in progress. It is followed by
+	// EventQueued, as the task is resumed once the distro connects again.
+	EventInterrupted
+)
+
+func (e EventType) String() string {
+	switch e {
+	case EventQueued:
+		return "queued"
+	case EventStarted:
+		return "started"
+	case EventProgress:
+		return "progress"
+	case EventCompleted:
+		return "completed"
+	case EventFailed:
+		return "failed"
+	case EventInterrupted:
+		return "interrupted"
+	}
+	return fmt.Sprintf("unknown event type %d", int(e))
+}
+
+// Event is a task lifecycle event of a worker.
+type Event struct {
+	Type EventType
+	Task string
+
+	// Progress is the percentage of completion of the task. Only set for EventProgress.
+	Progress uint32
+	// Step is what the task is doing, e.g. the step its distro reported for the command it is running. Only set
+	// for EventProgress, and only by tasks that tell.
+	Step string
+
+	// Reason is why the task failed or was interrupted. Only set for EventFailed and EventInterrupted.
+	Reason string
+
+	// Steps is the outcome of each step of the task, in the order they ran. Only set for EventCompleted
+	// and EventFailed, and only for tasks that report their steps.
+	Steps []task.Step
+}
+
+// TaskRecord is the outcome of a task that finished, as kept in the history of the tasks of its distro, so
+// that one can tell what ran in the distro and why it failed after the fact.
+type TaskRecord struct {
+	// Task is the human-readable description of the task.
+	Task string
+	// Type is the Go type of the task, e.g. *tasks.ProAttachment.
+	Type string
+
+	Started  time.Time
+	Finished time.Time
+
+	// Error is why the task failed, empty if it succeeded.
+	Error string `yaml:",omitempty"`
+
+	// Steps is the outcome of each step of the task, in the order they ran, for tasks that report them.
+	Steps []task.Step `yaml:",omitempty"`
+}
+
+// newTaskRecord creates the record of task t, which started at the given time and just finished with resultErr.
+func newTaskRecord(t task.Task, started time.Time, resultErr error, steps []task.Step) TaskRecord {
+	r := TaskRecord{
+		Task:     fmt.Sprint(t),
+		Type:     fmt.Sprintf("%T", t),
+		Started:  started.UTC().Truncate(time.Second),
+		Finished: time.Now().UTC().Truncate(time.Second),
+		Steps:    steps,
+	}
+	if resultErr != nil {
+		r.Error = resultErr.Error()
+	}
+	return r
+}
+
+// stepRecorder collects the outcome of the steps of the task in progress.
+type stepRecorder struct {
+	steps []task.Step
+	mu    sync.Mutex
+}
+
+func (r *stepRecorder) record(s task.Step) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.steps = append(r.steps, s)
+}
+
+// get returns a copy of the steps recorded so far.
+func (r *stepRecorder) get() []task.Step {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.steps)
+}
+
+// eventBufferSize is the number of events buffered for each watcher. Events for watchers
+// that fall behind are dropped, so that they never stall the processing of tasks.
+const eventBufferSize = 64
+
+// watchers is the set of channels the task lifecycle events are sent to.
+type watchers struct {
+	chans map[chan Event]struct{}
+	mu    sync.Mutex
+
+	// progress is the latest EventProgress of the task in progress in each lane, so that new watchers learn
+	// how far it is without waiting for its next report.
+	progress map[task.Lane]Event
+
+	// stopped is closed when the worker stops, so that no more watchers are added.
+	stopped chan struct{}
+	// wg tracks the goroutines that remove the channels of cancelled watches.
+	wg sync.WaitGroup
+}
+
+// WatchTasks returns a channel that receives the task lifecycle events of this worker.
+// The channel is closed when the context is cancelled or the worker is stopped.
+func (w *Worker) WatchTasks(ctx context.Context) <-chan Event {
+	ch := make(chan Event, eventBufferSize)
+
+	w.watchers.mu.Lock()
+	defer w.watchers.mu.Unlock()
+
+	stopped := w.watchers.stoppedChan()
+	select {
+	case <-stopped:
+		close(ch)
+		return ch
+	default:
+	}
+
+	if w.watchers.chans == nil {
+		w.watchers.chans = make(map[chan Event]struct{})
+	}
+	w.watchers.chans[ch] = struct{}{}
+
+	for _, ev := range w.watchers.progress {
+		ch <- ev
+	}
+
+	w.watchers.wg.Add(1)
+	go func() {
+		defer w.watchers.wg.Done()
+
+		select {
+		case <-ctx.Done():
+			w.watchers.remove(ch)
+		case <-stopped:
+			// The channel was closed by removeAll.
+		}
+	}()
+
+	return ch
+}
+
+// stoppedChan returns the channel that is closed when the worker stops. It must be called with the lock held.
+func (ws *watchers) stoppedChan() chan struct{} {
+	if ws.stopped == nil {
+		ws.stopped = make(chan struct{})
+	}
+	return ws.stopped
+}
+
+// remove stops sending events to the channel and closes it, unless it was removed already.
+func (ws *watchers) remove(ch chan Event) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.chans[ch]; !ok {
+		return
+	}
+
+	delete(ws.chans, ch)
+	close(ch)
+}
+
+// removeAll closes all the channels, and waits for the watches to be done.
+// No more watchers can be added afterwards.
+func (ws *watchers) removeAll() {
+	ws.mu.Lock()
+	for ch := range ws.chans {
+		close(ch)
+	}
+	ws.chans = nil
+	ws.progress = nil
+
+	select {
+	case <-ws.stoppedChan():
+	default:
+		close(ws.stopped)
+	}
+	ws.mu.Unlock()
+
+	ws.wg.Wait()
+}
+
+// emit sends an event about the task to all watchers.
+func (w *Worker) emit(ctx context.Context, t task.Task, ev Event) {
+	ev.Task = fmt.Sprint(t)
+
+	w.watchers.mu.Lock()
+	defer w.watchers.mu.Unlock()
+
+	w.watchers.trackProgress(task.LaneOf(t), &ev)
+
+	for ch := range w.watchers.chans {
+		select {
+		case ch <- ev:
+		default:
+			log.Warningf(ctx, "Distro %q: task %q: dropped %s event for a watcher that is falling behind", w.distro.Name(), t, ev.Type)
+		}
+	}
+}
+
+// trackProgress keeps the latest progress of the task in progress in the lane up to date with the event, which
+// is about a task of that lane. Progress events never lower the percentage of their task: they only update its
+// step. It must be called with the lock held.
+func (ws *watchers) trackProgress(lane task.Lane, ev *Event) {
+	last, ok := ws.progress[lane]
+
+	switch ev.Type {
+	case EventStarted:
+		delete(ws.progress, lane)
+	case EventProgress:
+		if ok && last.Task == ev.Task {
+			ev.Progress = max(ev.Progress, last.Progress)
+		}
+		if ws.progress == nil {
+			ws.progress = make(map[task.Lane]Event)
+		}
+		ws.progress[lane] = *ev
+	default:
+		if ok && last.Task == ev.Task {
+			delete(ws.progress, lane)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package worker
 
 import (
 	"fmt"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 )
 
 // CheckQueuedTaskCount checks that the number of tasks in the queue matches expectations.
@@ -19,3 +21,8 @@ func (w *Worker) CheckTotalTaskCount(want int) error {
 	}
 	return nil
 }
+
+// QueuedTasks returns the tasks in the queue, in the order they are executed.
+func (w *Worker) QueuedTasks() []task.Task {
+	return w.manager.tasks.Data()
+}
@@ -0,0 +1,347 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/ubuntu/decorate"
+)
+
+// taskFileExtension is the extension of the files where each worker stores its task queue.
+const taskFileExtension = ".tasks"
+
+// storagePath returns the path to the file where the tasks of the named distro are stored.
+// It is empty if there is no storage directory.
+func storagePath(storageDir, distroName string) string {
+	if storageDir == "" {
+		return ""
+	}
+	return filepath.Join(storageDir, distroName+taskFileExtension)
+}
+
+// taskStorage is where a worker persists its task queue.
+type taskStorage interface {
+	// read returns the stored task queue, or nil if none was ever stored.
+	read() ([]byte, error)
+
+	// write replaces the stored task queue.
+	write(tasks []byte) error
+}
+
+// fileTaskStorage stores the task queue in a file, whose path it is.
+type fileTaskStorage string
+
+func (path fileTaskStorage) read() ([]byte, error) {
+	out, err := os.ReadFile(string(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return out, err
+}
+
+func (path fileTaskStorage) write(tasks []byte) error {
+	// Writing to a temporary file first ensures a crash never leaves a half-written queue behind.
+	if err := os.WriteFile(string(path)+".new", tasks, 0600); err != nil {
+		return err
+	}
+	return os.Rename(string(path)+".new", string(path))
+}
+
+// storeTaskStorage stores the task queue in the store, under the name of the distro.
+type storeTaskStorage struct {
+	store      *store.Store
+	distroName string
+
+	// owned, if not nil, returns whether the distro still owns its queue in the transaction.
+	owned func(tx *store.Tx) (bool, error)
+}
+
+func (s storeTaskStorage) read() ([]byte, error) {
+	return s.store.Get(store.TasksBucket, s.distroName)
+}
+
+func (s storeTaskStorage) write(tasks []byte) error {
+	return s.store.Update(func(tx *store.Tx) error {
+		if s.owned != nil {
+			ok, err := s.owned(tx)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("distro %q has no record in the store", s.distroName)
+			}
+		}
+		return putTasks(tx, s.distroName, tasks, time.Now())
+	})
+}
+
+// putTasks stores the task queue of the named distro, and when it was modified.
+func putTasks(tx *store.Tx, distroName string, tasks []byte, modified time.Time) error {
+	if err := tx.Put(store.TasksBucket, distroName, tasks); err != nil {
+		return err
+	}
+	return tx.Put(store.TasksModifiedBucket, distroName, []byte(modified.UTC().Format(time.RFC3339Nano)))
+}
+
+// RenameStoredTasks moves the task queue of a distro in the store so that it belongs to its new name,
+// replacing any queue that the new name may have had. The worker of the distro must not be running.
+func RenameStoredTasks(tx *store.Tx, oldName, newName string) (err error) {
+	defer decorate.OnError(&err, "could not move the stored tasks from %q to %q", oldName, newName)
+
+	if err := tx.Rename(store.TasksBucket, oldName, newName); err != nil {
+		return err
+	}
+	return tx.Rename(store.TasksModifiedBucket, oldName, newName)
+}
+
+// ReadTaskFile returns the task queue of the named distro stored in a file inside storageDir, or nil if there
+// is none. The worker of the distro must not be running.
+func ReadTaskFile(storageDir, distroName string) ([]byte, error) {
+	return fileTaskStorage(storagePath(storageDir, distroName)).read()
+}
+
+// PutStoredTasks stores the task queue of the named distro in the store, replacing any it may have had.
+// The worker of the distro must not be running.
+func PutStoredTasks(tx *store.Tx, distroName string, tasks []byte) error {
+	return putTasks(tx, distroName, tasks, time.Now())
+}
+
+// DropStoredTasks removes from the store the task queues of the distros for which drop returns true.
+func DropStoredTasks(tx *store.Tx, drop func(distroName string) bool) (err error) {
+	defer decorate.OnError(&err, "could not drop stored tasks")
+
+	names, err := tx.Keys(store.TasksBucket)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if !drop(name) {
+			continue
+		}
+		if err := tx.Delete(store.TasksBucket, name); err != nil {
+			return err
+		}
+		if err := tx.Delete(store.TasksModifiedBucket, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CleanupStoredTasks is the counterpart of CleanupStorage for task queues kept in the store. The queues
+// that are reclaimed are those of distros for which isKnown returns false, and those that have not been
+// modified for longer than the retention period.
+func CleanupStoredTasks(ctx context.Context, s *store.Store, isKnown func(distroName string) bool, retention time.Duration) (reclaimed int64, err error) {
+	defer decorate.OnError(&err, "could not clean up stored tasks")
+
+	err = s.Update(func(tx *store.Tx) error {
+		names, err := tx.Keys(store.TasksBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			modified, err := tx.Get(store.TasksModifiedBucket, name)
+			if err != nil {
+				return err
+			}
+
+			// Queues with no known modification time are considered fresh.
+			t, err := time.Parse(time.RFC3339Nano, string(modified))
+			stale := err == nil && retention > 0 && time.Since(t) > retention
+
+			if isKnown(name) && !stale {
+				continue
+			}
+
+			tasks, err := tx.Get(store.TasksBucket, name)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Delete(store.TasksBucket, name); err != nil {
+				return err
+			}
+			if err := tx.Delete(store.TasksModifiedBucket, name); err != nil {
+				return err
+			}
+
+			log.Debugf(ctx, "Removed stored tasks of %q (%d bytes)", name, len(tasks))
+			reclaimed += int64(len(tasks))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return reclaimed, nil
+}
+
+// ImportTaskFiles copies the task queues stored in files inside storageDir into the store, keeping
+// the time they were last modified. Queues already in the store are left untouched. It returns the
+// paths of the files that were imported, which are only safe to remove once tx is committed.
+func ImportTaskFiles(tx *store.Tx, storageDir string) (imported []string, err error) {
+	defer decorate.OnError(&err, "could not import task files")
+
+	entries, err := os.ReadDir(storageDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(storageDir, entry.Name())
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		// Leftovers of interrupted writes are not worth importing, but they are not worth keeping either.
+		if strings.HasSuffix(entry.Name(), taskFileExtension+".new") {
+			imported = append(imported, path)
+			continue
+		}
+
+		name, ok := strings.CutSuffix(entry.Name(), taskFileExtension)
+		if !ok || name == "" {
+			continue
+		}
+		imported = append(imported, path)
+
+		current, err := tx.Get(store.TasksBucket, name)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			continue
+		}
+
+		tasks, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := putTasks(tx, name, tasks, info.ModTime()); err != nil {
+			return nil, err
+		}
+	}
+
+	return imported, nil
+}
+
+// CleanupStorage removes the task storage left behind in storageDir that is no longer useful:
+//   - storage of distros for which isKnown returns false.
+//   - storage that has not been modified for longer than the retention period.
+//
+// Leftovers of interrupted writes are subject to the same rules. It returns the number of
+// bytes reclaimed. A non-positive retention disables the age check.
+func CleanupStorage(ctx context.Context, storageDir string, isKnown func(distroName string) bool, retention time.Duration) (reclaimed int64, err error) {
+	defer decorate.OnError(&err, "could not clean up task storage")
+
+	entries, err := os.ReadDir(storageDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var errs error
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".new")
+		if name, ok = strings.CutSuffix(name, taskFileExtension); !ok || name == "" {
+			continue
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed in the meantime.
+			continue
+		} else if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		stale := retention > 0 && time.Since(info.ModTime()) > retention
+		if isKnown(name) && !stale {
+			continue
+		}
+
+		path := filepath.Join(storageDir, entry.Name())
+		size, err := diskUsage(path)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		log.Debugf(ctx, "Removed task storage %q (%d bytes)", path, size)
+		reclaimed += size
+	}
+
+	return reclaimed, errs
+}
+
+// RenameStorage moves the task storage of a distro in storageDir so that it belongs to its new name,
+// replacing any storage that the new name may have had. It is a no-op when there is no storage
+// directory or the distro has no task storage. The worker of the distro must not be running.
+func RenameStorage(storageDir, oldName, newName string) (err error) {
+	defer decorate.OnError(&err, "could not move the task storage from %q to %q", oldName, newName)
+
+	oldPath := storagePath(storageDir, oldName)
+	newPath := storagePath(storageDir, newName)
+	if oldPath == "" || oldPath == newPath {
+		return nil
+	}
+
+	// Leftovers of an interrupted write are of no use to the new name.
+	_ = os.RemoveAll(oldPath + ".new")
+
+	err = os.Rename(oldPath, newPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// diskUsage returns the accumulated size of all the regular files under path.
+func diskUsage(path string) (size int64, err error) {
+	err = filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+
+	return size, err
+}
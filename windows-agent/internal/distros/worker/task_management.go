@@ -4,11 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/fs"
-	"os"
+	"slices"
 	"sync"
+	"time"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/ubuntu/decorate"
 )
@@ -23,18 +24,33 @@ import (
 // which is set to private because it is a freestanding function and we don't
 // want outside packages to be able to use it.
 type taskManager struct {
-	storagePath string
+	// storage is where the tasks are stored. Nil if they are kept in memory only.
+	storage taskStorage
 
 	tasks         *taskQueue
 	deferredTasks *taskQueue
 
 	mu sync.RWMutex
+
+	// retries are the tasks that failed transiently and are queued to be retried according to their retry
+	// policy. They are kept in memory only: after a restart, the tasks are retried right away.
+	retries   []retry
+	retriesMu sync.Mutex
+}
+
+// retry is a task queued to be retried.
+type retry struct {
+	task task.Task
+	// attempts is the number of times the task was executed so far.
+	attempts int
+	// due is when the task can be executed again.
+	due time.Time
 }
 
 // newTaskManager constructs and initializes a TaskManager.
-func newTaskManager(storagePath string) (*taskManager, error) {
+func newTaskManager(storage taskStorage) (*taskManager, error) {
 	tm := taskManager{
-		storagePath:   storagePath,
+		storage:       storage,
 		tasks:         newTaskQueue(),
 		deferredTasks: newTaskQueue(),
 	}
@@ -66,7 +82,14 @@ func (tm *taskManager) TaskLen() int {
 //
 // If deferred is set to true, task execution is deferred until the next load()
 // Otherwise, it is added to the queue immediately.
-func (tm *taskManager) Submit(deferred bool, tasks ...task.Task) error {
+//
+// The tasks are submitted all or nothing: the queues are only changed once the tasks
+// are stored, and then all at once, so that none of them is pulled before the others
+// are queued.
+//
+// Tasks with the same idempotency key as one queued already are dropped, so that the
+// queued one keeps its place and its retries. It returns the tasks that were queued.
+func (tm *taskManager) Submit(deferred bool, tasks ...task.Task) ([]task.Task, error) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
@@ -74,7 +97,7 @@ func (tm *taskManager) Submit(deferred bool, tasks ...task.Task) error {
 }
 
 // submitUnsafe is the thread-unsafe version of Submit.
-func (tm *taskManager) submitUnsafe(deferred bool, tasks ...task.Task) (err error) {
+func (tm *taskManager) submitUnsafe(deferred bool, tasks ...task.Task) (submitted []task.Task, err error) {
 	defer decorate.OnError(&err, "could not submit task")
 
 	thisQueue := &tm.tasks
@@ -83,12 +106,40 @@ func (tm *taskManager) submitUnsafe(deferred bool, tasks ...task.Task) (err erro
 		thisQueue, otherQueue = otherQueue, thisQueue
 	}
 
-	for i := range tasks {
-		(*otherQueue).Remove(tasks[i])
-		(*thisQueue).Push(tasks[i])
+	tasks = slices.DeleteFunc(slices.Clone(tasks), func(t task.Task) bool {
+		if !(*thisQueue).ContainsSame(t) {
+			return false
+		}
+		log.Debugf(context.TODO(), "task %q: dropped, the same task is queued already", t)
+		return true
+	})
+	if len(tasks) == 0 {
+		return nil, nil
 	}
 
-	return tm.save()
+	// Store the queues as they will be, so that a failure leaves them as they were.
+	next, nextOther := (*thisQueue).clone(), (*otherQueue).clone()
+	for _, t := range tasks {
+		nextOther.Remove(t)
+	}
+	next.PushAll(tasks)
+
+	queued, deferredTasks := next, nextOther
+	if deferred {
+		queued, deferredTasks = deferredTasks, queued
+	}
+	if err := tm.store(append(queued.Data(), deferredTasks.Data()...)); err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		(*otherQueue).Remove(t)
+		// Tasks submitted anew start over.
+		tm.forgetRetry(t)
+	}
+	(*thisQueue).PushAll(tasks)
+
+	return tasks, nil
 }
 
 // resubmit submits a task with lowest priority, meaning that it will be overridden
@@ -108,24 +159,94 @@ func (tm *taskManager) resubmit(t task.Task) (err error) {
 	return tm.save()
 }
 
-// NextTask pulls the next task from the queue. If no task is queued, this function blocks until either a task is
-// submitted or the context is cancelled, whichever happens first.
+// Defer puts an interrupted task in the queue of deferred tasks, so that it is resumed once they are enqueued. Like
+// resubmitted tasks, it is dropped if an equivalent task is queued already.
+func (tm *taskManager) Defer(t task.Task) error {
+	return tm.resubmit(t)
+}
+
+// Requeue puts a preempted task back in the queue, so that it is resumed after any task with
+// the same or higher priority. It is dropped if an equivalent task has been submitted meanwhile.
+func (tm *taskManager) Requeue(t task.Task) (err error) {
+	defer decorate.OnError(&err, "could not requeue task %s", t)
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.tasks.PushIfNew(t)
+
+	return tm.save()
+}
+
+// Contains returns true if a task equivalent to t is queued or deferred.
+func (tm *taskManager) Contains(t task.Task) bool {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	return tm.tasks.Contains(t) || tm.deferredTasks.Contains(t)
+}
+
+// Cancel removes the tasks equivalent to t from the queue and from the deferred tasks. It returns false if
+// there was none.
+func (tm *taskManager) Cancel(t task.Task) (removed bool, err error) {
+	defer decorate.OnError(&err, "could not cancel task %s", t)
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	for _, q := range []*taskQueue{tm.tasks, tm.deferredTasks} {
+		if q.Remove(t) {
+			removed = true
+		}
+	}
+	if !removed {
+		return false, nil
+	}
+	tm.forgetRetry(t)
+
+	return true, tm.save()
+}
+
+// NextTask pulls the next task from the queue accepted by the predicate. If no such task is queued, this
+// function blocks until either one is submitted, Wake is called, or the context is cancelled, whichever
+// happens first. The task is pulled in the same critical section as it is accepted, so that there is no
+// moment when it is neither queued nor accepted.
 // The second argument indicates whether a task was pulled or not.
-func (tm *taskManager) NextTask(ctx context.Context) (task.Task, bool) {
-	t := tm.tasks.Pull(ctx)
+// Tasks waiting to be retried are not pulled until they are due.
+func (tm *taskManager) NextTask(ctx context.Context, accept func(task.Task) bool) (task.Task, bool) {
+	t := tm.tasks.Pull(ctx, func(t task.Task) bool { return tm.isDue(t) && accept(t) })
 	return t, t != nil
 }
 
-// TaskDone cleans up after a task is completed, and conditionally re-submits failed ones.
+// Wake makes a pending NextTask check the queue again, e.g. because a lane became available.
+func (tm *taskManager) Wake() {
+	tm.tasks.Notify()
+}
+
+// TaskDone cleans up after a task is completed, and conditionally re-submits failed ones. Tasks refused a transfer
+// because their distro reached its daily cap are queued again to be retried once the cap resets. Tasks with a retry
+// policy that failed transiently are queued again to be retried once it lets them, and the others that need to
+// be retried are deferred.
 func (tm *taskManager) TaskDone(ctx context.Context, t task.Task, taskResult error) (err error) {
 	decorate.OnError(&err, "task %s", t)
 
+	var capErr bandwidth.CapReachedError
+	if errors.As(taskResult, &capErr) {
+		return tm.retryAtReset(ctx, t, taskResult, capErr.Reset)
+	}
+
+	policy, ok := task.RetryPolicyOf(t)
+	if ok && (errors.As(taskResult, &task.NeedsRetryError{}) || errors.Is(taskResult, errTimedOut)) {
+		return tm.retryLater(ctx, t, taskResult, policy)
+	}
+	tm.forgetRetry(t)
+
 	if errors.As(taskResult, &task.NeedsRetryError{}) {
 		log.Errorf(ctx, "%v", taskResult) // Error message already mentions resubmission
 		return tm.resubmit(t)
 	}
 
-	if err := tm.save(); err != nil {
+	if err := tm.saveLocked(); err != nil {
 		return fmt.Errorf("cleanup: could not save task queue: %v", err)
 	}
 
@@ -137,48 +258,165 @@ func (tm *taskManager) TaskDone(ctx context.Context, t task.Task, taskResult err
 	return taskResult
 }
 
+// retryLater queues a task that failed transiently again, so that it is executed once the delay of its retry
+// policy elapses. It is dropped once it used up its attempts.
+func (tm *taskManager) retryLater(ctx context.Context, t task.Task, taskResult error, policy task.RetryPolicy) error {
+	r := tm.retryOf(t)
+	r.task = t
+	r.attempts++
+
+	if r.attempts >= policy.MaxAttempts {
+		tm.forgetRetry(t)
+		if err := tm.saveLocked(); err != nil {
+			return fmt.Errorf("cleanup: could not save task queue: %v", err)
+		}
+
+		log.Errorf(ctx, "failed after %d attempts and will not be retried: %v", r.attempts, taskResult)
+		return taskResult
+	}
+
+	if tm.Contains(t) {
+		// An equivalent task was submitted meanwhile: it runs instead.
+		return nil
+	}
+
+	delay := policy.Delay(r.attempts)
+	log.Warningf(ctx, "failed (attempt %d of %d) and will be retried in %s: %v", r.attempts, policy.MaxAttempts, delay, taskResult)
+
+	return tm.requeueAfter(r, delay)
+}
+
+// retryAtReset queues a task refused a transfer by the daily cap of its distro again, so that it is executed
+// once the cap resets. It does not use up any attempt of its retry policy, as it did not get to do anything.
+func (tm *taskManager) retryAtReset(ctx context.Context, t task.Task, taskResult error, reset time.Time) error {
+	if tm.Contains(t) {
+		// An equivalent task was submitted meanwhile: it runs instead.
+		return nil
+	}
+
+	r := tm.retryOf(t)
+	r.task = t
+
+	log.Warningf(ctx, "will be retried once the daily transfer cap resets at %s: %v", reset.Format(time.DateTime), taskResult)
+
+	return tm.requeueAfter(r, time.Until(reset))
+}
+
+// requeueAfter puts the task of the retry back in the queue, such that it is not pulled before the delay elapses.
+func (tm *taskManager) requeueAfter(r retry, delay time.Duration) error {
+	r.due = time.Now().Add(delay)
+	tm.setRetry(r)
+
+	if err := tm.Requeue(r.task); err != nil {
+		return err
+	}
+
+	time.AfterFunc(delay, tm.Wake)
+	return nil
+}
+
+// retryOf returns the retry of the task equivalent to t, which is the zero value if there is none.
+func (tm *taskManager) retryOf(t task.Task) retry {
+	tm.retriesMu.Lock()
+	defer tm.retriesMu.Unlock()
+
+	if i := tm.retryIndex(t); i >= 0 {
+		return tm.retries[i]
+	}
+	return retry{}
+}
+
+// setRetry records the retry of a task, replacing that of any equivalent task.
+func (tm *taskManager) setRetry(r retry) {
+	tm.retriesMu.Lock()
+	defer tm.retriesMu.Unlock()
+
+	if i := tm.retryIndex(r.task); i >= 0 {
+		tm.retries[i] = r
+		return
+	}
+	tm.retries = append(tm.retries, r)
+}
+
+// forgetRetry drops the retry of the task equivalent to t, if any.
+func (tm *taskManager) forgetRetry(t task.Task) {
+	tm.retriesMu.Lock()
+	defer tm.retriesMu.Unlock()
+
+	tm.retries = slices.DeleteFunc(tm.retries, func(r retry) bool { return task.Is(r.task, t) })
+}
+
+// isDue returns false if t waits to be retried and the delay of its retry policy has not elapsed yet.
+func (tm *taskManager) isDue(t task.Task) bool {
+	tm.retriesMu.Lock()
+	defer tm.retriesMu.Unlock()
+
+	i := tm.retryIndex(t)
+	return i < 0 || !time.Now().Before(tm.retries[i].due)
+}
+
+// retryIndex returns the index of the retry of the task equivalent to t, or -1 if there is none. The mutex
+// must be held.
+func (tm *taskManager) retryIndex(t task.Task) int {
+	return slices.IndexFunc(tm.retries, func(r retry) bool { return task.Is(r.task, t) })
+}
+
 // EnqueueDeferredTasks takes all deferred tasks and promotes them
 // to regular tasks.
 func (tm *taskManager) EnqueueDeferredTasks() {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
 	tm.tasks.Absorb(tm.deferredTasks)
 }
 
-// save writes the current task queue (plus deferred tasks) to file.
-func (tm *taskManager) save() (err error) {
-	defer decorate.OnError(&err, "could not save queued tasks to disk")
+// save writes the current task queue (plus deferred tasks) to storage. The mutex must be held.
+func (tm *taskManager) save() error {
+	return tm.store(append(tm.tasks.Data(), tm.deferredTasks.Data()...))
+}
 
-	tasks := append(tm.tasks.Data(), tm.deferredTasks.Data()...)
+// saveLocked is the thread-safe version of save.
+func (tm *taskManager) saveLocked() error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
-	out, err := task.MarshalYAML(tasks)
-	if err != nil {
-		return err
-	}
+	return tm.save()
+}
 
-	if err = os.WriteFile(tm.storagePath+".new", out, 0600); err != nil {
-		return err
+// store writes the given tasks to storage.
+func (tm *taskManager) store(tasks []task.Task) (err error) {
+	defer decorate.OnError(&err, "could not save queued tasks to disk")
+
+	if tm.storage == nil {
+		return nil
 	}
 
-	if err = os.Rename(tm.storagePath+".new", tm.storagePath); err != nil {
+	out, err := task.MarshalYAML(tasks)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return tm.storage.write(out)
 }
 
-// Load loads tasks from file.
+// Load loads tasks from storage.
 func (tm *taskManager) load() (err error) {
 	defer decorate.OnError(&err, "could not load tasks from disk")
 
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	out, err := os.ReadFile(tm.storagePath)
+	if tm.storage == nil {
+		return nil
+	}
+
+	out, err := tm.storage.read()
 	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return nil
-		}
 		return err
 	}
+	if out == nil {
+		return nil
+	}
 
 	var tasks []task.Task
 	if tasks, err = task.UnmarshalYAML(out); err != nil {
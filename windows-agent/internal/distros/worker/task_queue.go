@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"slices"
 	"sync"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
@@ -9,7 +10,7 @@ import (
 
 // taskQueue is a queue that allows pushing and pulling tasks from a FIFO queue,
 // with the particularity that duplicated elements will be removed in favour of
-// the latest one.
+// the latest one, and that tasks with higher priority jump ahead of the rest.
 //
 // Pulling from an empty queue will wait until it is no longer empty.
 //
@@ -82,7 +83,7 @@ func (q *taskQueue) Data() []task.Task {
 	return append([]task.Task{}, q.data...)
 }
 
-// Push adds a task to the queue. Any existing equivalent tasks are removed.
+// Push adds a task to the queue, behind any task with the same or higher priority. Any existing equivalent tasks are removed.
 func (q *taskQueue) Push(t task.Task) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
@@ -90,8 +91,7 @@ func (q *taskQueue) Push(t task.Task) {
 	// Remove copies of this task
 	q.data = removeIf(q.data, func(queued task.Task) bool { return task.Is(t, queued) })
 
-	// Append task
-	q.data = append(q.data, t)
+	q.insert(t)
 
 	// Notify waiters if there are any
 	select {
@@ -100,6 +100,30 @@ func (q *taskQueue) Push(t task.Task) {
 	}
 }
 
+// PushAll pushes several tasks at once, so that none of them is pulled before the others are queued.
+func (q *taskQueue) PushAll(tasks []task.Task) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, t := range tasks {
+		q.data = removeIf(q.data, func(queued task.Task) bool { return task.Is(t, queued) })
+		q.insert(t)
+	}
+
+	// Notify waiters if there are any
+	select {
+	case q.wait <- struct{}{}:
+	default:
+	}
+}
+
+// clone returns a new queue with the same tasks.
+func (q *taskQueue) clone() *taskQueue {
+	c := newTaskQueue()
+	c.data = q.Data()
+	return c
+}
+
 // Push adds a task to the queue unless an equivalent task is queued already.
 // Useful for re-submitting failed tasks.
 func (q *taskQueue) PushIfNew(t task.Task) {
@@ -113,8 +137,7 @@ func (q *taskQueue) PushIfNew(t task.Task) {
 		}
 	}
 
-	// Append task
-	q.data = append(q.data, t)
+	q.insert(t)
 
 	// Notify waiters if there are any
 	select {
@@ -137,20 +160,50 @@ func (q *taskQueue) Contains(t task.Task) bool {
 	return false
 }
 
-// Remove erases all tasks that are equivalent to "t".
-func (q *taskQueue) Remove(t task.Task) {
+// ContainsSame returns true if a task with the same idempotency key as "t" is queued, with the same or higher
+// priority, so that queueing "t" would change nothing.
+func (q *taskQueue) ContainsSame(t task.Task) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for _, queued := range q.data {
+		if task.SameKey(queued, t) && task.PriorityOf(queued) >= task.PriorityOf(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Remove erases all tasks that are equivalent to "t". It returns false if there was none.
+func (q *taskQueue) Remove(t task.Task) (removed bool) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
+	n := len(q.data)
 	q.data = removeIf(q.data, func(queued task.Task) bool { return task.Is(t, queued) })
+	return len(q.data) < n
+}
+
+// Notify wakes up a pending Pull, so that it checks the queue again. Useful when the
+// tasks accepted by the pull change.
+func (q *taskQueue) Notify() {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	select {
+	case q.wait <- struct{}{}:
+	default:
+	}
 }
 
-// Pull pops the first task in the queue. If the queue is empty, this function
-// blocks until a task is Pushed, Loaded or Absorved.
+// Pull pops the first task in the queue accepted by the predicate, or the first task if the predicate
+// is nil. If there is no such task, this function blocks until a task is Pushed, Loaded or Absorved,
+// or until Notify is called.
 //
 // Concurrent pulls are safe but the order in which they are served in is
 // indeterminate.
-func (q *taskQueue) Pull(ctx context.Context) task.Task {
+func (q *taskQueue) Pull(ctx context.Context, accept func(task.Task) bool) task.Task {
 	// Avoid races if the context is cancelled already
 	select {
 	case <-ctx.Done():
@@ -159,7 +212,7 @@ func (q *taskQueue) Pull(ctx context.Context) task.Task {
 	}
 
 	for {
-		if task, ok := q.tryPopFront(); ok {
+		if task, ok := q.tryPop(accept); ok {
 			return task
 		}
 
@@ -177,7 +230,7 @@ func (q *taskQueue) Pull(ctx context.Context) task.Task {
 			// | only entry in the queue. Or an empty Load could
 			// | leave an empty "data" behind.
 			// ↓
-			if task, ok := q.tryPopFront(); ok {
+			if task, ok := q.tryPop(accept); ok {
 				return task
 			}
 			// Solution to race: just try again
@@ -185,19 +238,34 @@ func (q *taskQueue) Pull(ctx context.Context) task.Task {
 	}
 }
 
-// tryPopFront is a helper function not to be used outside. Equivalent to Pull but without
-// waiting. It returns false if the queue is empty.
-func (q *taskQueue) tryPopFront() (task.Task, bool) {
+// insert is a helper function not to be used outside. It adds the task after the last
+// one with the same or higher priority. The mutex must be held.
+func (q *taskQueue) insert(t task.Task) {
+	p := task.PriorityOf(t)
+
+	i := len(q.data)
+	for i > 0 && task.PriorityOf(q.data[i-1]) < p {
+		i--
+	}
+
+	q.data = slices.Insert(q.data, i, t)
+}
+
+// tryPop is a helper function not to be used outside. Equivalent to Pull but without
+// waiting. It returns false if there is no accepted task in the queue.
+func (q *taskQueue) tryPop(accept func(task.Task) bool) (task.Task, bool) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	if len(q.data) == 0 {
-		return nil, false
+	for i, t := range q.data {
+		if accept != nil && !accept(t) {
+			continue
+		}
+		q.data = slices.Delete(q.data, i, i+1)
+		return t, true
 	}
 
-	r := q.data[0]
-	q.data = q.data[1:]
-	return r, true
+	return nil, false
 }
 
 // removeIf removes all elements that satisfy the predicate from the array.
@@ -5,23 +5,53 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
+	"maps"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/metrics"
 	"github.com/ubuntu/decorate"
+	wsl "github.com/ubuntu/gowsl"
 )
 
+// taskFailures counts the tasks that failed, by distro and type of task.
+var taskFailures = metrics.Default.Counter("task_failures_total", "Number of tasks that failed, by distro and type of task.", "distro", "task")
+
 type distro interface {
 	Name() string
 
 	LockAwake() error
 	ReleaseAwake() error
 
+	// LiveState returns the state of the distro in WSL as last observed, without querying WSL.
+	LiveState() wsl.State
+
 	IsValid() bool
 	Invalidate(context.Context)
+
+	// RecordTaskResult records the outcome of a task: nil if it succeeded.
+	RecordTaskResult(context.Context, error)
+
+	// AppendTaskHistory adds a task that finished to the history of the tasks of the distro.
+	AppendTaskHistory(TaskRecord)
+
+	// RequestConsent asks the user to confirm what the prompt describes.
+	RequestConsent(ctx context.Context, prompt string) (granted bool, err error)
+
+	// TransferMeter accounts for the data the tasks transfer to and from the distro.
+	task.TransferMeter
+
+	// UnsupportedReason returns why the Ubuntu Pro client cannot run in the distro, or an empty string if it can.
+	UnsupportedReason() string
+
+	// WslVersion returns the version of WSL the distro runs on, 1 or 2, or zero if it is unknown.
+	WslVersion() int
 }
 
 // Connection encapsulates the logic behind sending and receiving messages
@@ -29,6 +59,24 @@ type distro interface {
 type Connection interface {
 	SendProAttachment(proToken string) error
 	SendLandscapeConfig(lpeConfig string) error
+	SendCommand(cmd *agentapi.Command) (output []byte, err error)
+
+	// InLane returns the connection as seen by a task of a lane, which tags its commands with it. Once ctx is
+	// done, the task stops waiting for the results of its requests, which tears down the connection.
+	InLane(ctx context.Context, lane task.Lane) task.Connection
+
+	// Preempt asks the command of the lane in progress, if any, to stop at its next safe point. The
+	// commands of the other lanes are not affected.
+	Preempt(lane task.Lane) error
+
+	// TailLog sends the journal lines of the WSL Pro service one at a time as they arrive, independently
+	// of the commands in progress.
+	TailLog(ctx context.Context, cmd *agentapi.TailLogCmd, send func(line string) error) error
+
+	// Ping sends the payload to the WSL Pro service and returns its echo, independently of the commands
+	// in progress.
+	Ping(ctx context.Context, payload []byte) (echo []byte, err error)
+
 	Close()
 }
 
@@ -40,24 +88,82 @@ type Worker struct {
 	cancel     context.CancelFunc
 	processing chan struct{}
 
+	// stopPulling stops starting the queued tasks, leaving those in progress running.
+	stopPulling context.CancelFunc
+	// handingOff is set once the worker hands its tasks off to the next version of the agent (see HandOff).
+	handingOff atomic.Bool
+
+	// running are the tasks in progress, by lane. Each lane runs one task at a time.
+	running   map[task.Lane]task.Task
+	runningMu sync.Mutex
+	// consentCancels stop the requests for consent in progress, by lane. They are protected by runningMu.
+	consentCancels map[task.Lane]context.CancelCauseFunc
+	// taskCancels cancel the contexts of the tasks in progress, by lane. They are protected by runningMu.
+	taskCancels map[task.Lane]context.CancelCauseFunc
+	// cancelTimers cancel the contexts of the tasks in progress that were cancelled (see Cancel), by lane, once
+	// they had the time to reach a safe point. They are protected by runningMu.
+	cancelTimers map[task.Lane]*time.Timer
+
+	// submitted are the tasks submitted with SubmitTask that did not finish yet, by ID. They are protected by
+	// runningMu.
+	submitted  map[TaskID]task.Task
+	lastTaskID TaskID
+
 	conn   Connection
 	connMu sync.RWMutex
+
+	// watchers receive the task lifecycle events (see WatchTasks).
+	watchers watchers
+}
+
+type options struct {
+	store *store.Store
+	owned func(tx *store.Tx) (bool, error)
+}
+
+// Option represents an optional function to override New default values.
+type Option func(*options)
+
+// WithStore stores the task queue in s instead of in a file inside the storage directory. If owned is not
+// nil, the queue is only written in a transaction where it returns true, so that a queue is never stored
+// for a distro the store no longer has a record of.
+func WithStore(s *store.Store, owned func(tx *store.Tx) (bool, error)) Option {
+	return func(o *options) {
+		o.store = s
+		o.owned = owned
+	}
 }
 
 // New creates a new worker and starts it. Call Stop when you're done to avoid leaking the task execution goroutine.
-func New(ctx context.Context, d distro, storageDir string) (w *Worker, err error) {
+// The task queue is stored in storageDir, or kept in memory only if storageDir is empty.
+func New(ctx context.Context, d distro, storageDir string, args ...Option) (w *Worker, err error) {
 	defer decorate.OnError(&err, "distro %q: could not create worker", d.Name())
 
-	storagePath := filepath.Join(storageDir, d.Name()+".tasks")
+	var opts options
+	for _, f := range args {
+		f(&opts)
+	}
+
+	var storage taskStorage
+	if opts.store != nil {
+		storage = storeTaskStorage{store: opts.store, distroName: d.Name(), owned: opts.owned}
+	} else if storageDir != "" {
+		storage = fileTaskStorage(storagePath(storageDir, d.Name()))
+	}
 
-	tm, err := newTaskManager(storagePath)
+	tm, err := newTaskManager(storage)
 	if err != nil {
 		return nil, err
 	}
 
 	w = &Worker{
-		distro:  d,
-		manager: tm,
+		distro:         d,
+		manager:        tm,
+		running:        make(map[task.Lane]task.Task),
+		consentCancels: make(map[task.Lane]context.CancelCauseFunc),
+		taskCancels:    make(map[task.Lane]context.CancelCauseFunc),
+		cancelTimers:   make(map[task.Lane]*time.Timer),
+		submitted:      make(map[TaskID]task.Task),
 	}
 
 	w.start(ctx)
@@ -100,9 +206,11 @@ func (w *Worker) start(ctx context.Context) {
 	log.Debugf(ctx, "Distro %q: starting task processing", w.distro.Name())
 
 	ctx, cancel := context.WithCancel(ctx)
+	pullCtx, stopPulling := context.WithCancel(ctx)
 	w.processing = make(chan struct{})
-	go w.processTasks(ctx)
+	go w.processTasks(ctx, pullCtx)
 	w.cancel = cancel
+	w.stopPulling = stopPulling
 }
 
 // Stop stops the main task processing goroutine and wait for it to be done.
@@ -111,21 +219,308 @@ func (w *Worker) Stop(ctx context.Context) {
 	w.cancel()
 	<-w.processing
 	w.SetConnection(nil)
+	w.watchers.removeAll()
+}
+
+// checkpointTimeout is how long the tasks preempted by a hand-off have to reach their next safe point before they
+// are cancelled.
+const checkpointTimeout = 5 * time.Second
+
+// errHandingOff is returned when submitting tasks to a worker handing off its tasks to the next version of the agent.
+var errHandingOff = errors.New("the agent is shutting down to be updated")
+
+// HandOff stops the task processing ahead of the agent being updated, leaving the queue stored for the next version
+// to resume. No new task is accepted or started from then on. The tasks in progress have the grace period to
+// finish. Past it, they are preempted, and then cancelled if they do not reach a safe point in a few seconds. Either
+// way, they are stored back in the queue. Call Stop afterwards to release the worker.
+func (w *Worker) HandOff(ctx context.Context, grace time.Duration) {
+	log.Debugf(ctx, "Distro %q: handing off the task queue", w.distro.Name())
+
+	w.handingOff.Store(true)
+	w.stopPulling()
+
+	select {
+	case <-w.processing:
+		return
+	case <-time.After(grace):
+	}
+
+	w.preemptRunning(ctx)
+
+	select {
+	case <-w.processing:
+		return
+	case <-time.After(checkpointTimeout):
+	}
+
+	w.cancel()
+	<-w.processing
+}
+
+// preemptRunning preempts the tasks in progress in every lane, including those waiting for the user's consent.
+func (w *Worker) preemptRunning(ctx context.Context) {
+	w.runningMu.Lock()
+	running := maps.Clone(w.running)
+	for _, cancel := range w.consentCancels {
+		cancel(task.ErrPreempted)
+	}
+	w.runningMu.Unlock()
+
+	conn := w.Connection()
+	if conn == nil {
+		return
+	}
+
+	for lane, t := range running {
+		log.Infof(ctx, "Distro %q: preempting task %q to hand it off", w.distro.Name(), t)
+		if err := conn.Preempt(lane); err != nil {
+			log.Warningf(ctx, "Distro %q: could not preempt task %q: %v", w.distro.Name(), t, err)
+		}
+	}
 }
 
 // SubmitTasks enqueues one or more task on our current worker list. The task will wake up
 // the distro and be performed as soon as it reaches the beginning of the queue.
 //
-// It will return an error if the distro has been cleaned up or the task queue is full.
-func (w *Worker) SubmitTasks(tasks ...task.Task) (err error) {
+// Tasks with a higher priority than the one in progress preempt it: it is asked to stop at
+// its next safe point, and resumed after them.
+//
+// It will return an error if the distro has been cleaned up, the task queue is full or the worker
+// is handing its tasks off.
+func (w *Worker) SubmitTasks(tasks ...task.Task) error {
+	return w.SubmitAll(tasks)
+}
+
+// SubmitAll enqueues the tasks like SubmitTasks does, all or nothing: either all of them are
+// queued and stored, or none is. None of them starts before the others are queued, so that a
+// change made of several tasks is never left half queued, not even if the agent stops meanwhile.
+//
+// Tasks with the same idempotency key as one queued or in progress are dropped (see task.KeyOf).
+func (w *Worker) SubmitAll(tasks []task.Task) error {
+	_, err := w.submitAll(tasks)
+	return err
+}
+
+// submitAll is SubmitAll, also returning the tasks that were queued, i.e. without the dropped ones.
+func (w *Worker) submitAll(tasks []task.Task) (submitted []task.Task, err error) {
 	defer decorate.OnError(&err, "distro %q: tasks %q: could not submit", w.distro.Name(), tasks)
 
 	if len(tasks) == 0 {
-		return nil
+		return nil, nil
+	}
+
+	if w.handingOff.Load() {
+		return nil, errHandingOff
 	}
 
 	log.Infof(context.TODO(), "Distro %q: Submitting tasks %q to queue", w.distro.Name(), tasks)
-	return w.manager.Submit(false, tasks...)
+	tasks, err = w.manager.Submit(false, w.dropRedundant(tasks)...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		w.emit(context.TODO(), t, Event{Type: EventQueued})
+	}
+
+	w.preemptIfOutranked(tasks...)
+	return tasks, nil
+}
+
+// TaskID identifies a task submitted with SubmitTask, so that it can be cancelled.
+type TaskID uint64
+
+// ErrTaskNotFound is returned when cancelling a task that is neither queued nor in progress, e.g. because it
+// finished already.
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrTaskDropped is returned when submitting a task with the same idempotency key as one queued or in progress,
+// which would do the same thing again.
+var ErrTaskDropped = errors.New("task dropped: the same task is queued or in progress already")
+
+// SubmitTask enqueues a task like SubmitTasks does, and returns an ID to cancel it with (see Cancel). It returns
+// ErrTaskDropped if the task is not queued because it is redundant.
+func (w *Worker) SubmitTask(t task.Task) (id TaskID, err error) {
+	w.runningMu.Lock()
+	w.lastTaskID++
+	id = w.lastTaskID
+	w.submitted[id] = t
+	w.runningMu.Unlock()
+
+	submitted, err := w.submitAll([]task.Task{t})
+	if err == nil && len(submitted) == 0 {
+		err = ErrTaskDropped
+	}
+	if err != nil {
+		w.runningMu.Lock()
+		delete(w.submitted, id)
+		w.runningMu.Unlock()
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Cancel aborts the task with the given ID, as returned by SubmitTask. A queued task is removed from the
+// queue. A task in progress is preempted, so that the command it runs in the distro stops at its next safe
+// point, and its context is cancelled if it does not stop within a few seconds, which also abandons the
+// request to the distro it may be stuck in. Either way, the task fails with an error wrapping
+// task.ErrCancelled and is not retried.
+//
+// Tasks are told apart as they are deduplicated: cancelling a task cancels the equivalent one that replaced it
+// in the queue, if any. It returns ErrTaskNotFound if the task finished already.
+func (w *Worker) Cancel(id TaskID) (err error) {
+	defer decorate.OnError(&err, "distro %q: could not cancel task %d", w.distro.Name(), id)
+
+	w.runningMu.Lock()
+	t, ok := w.submitted[id]
+	w.runningMu.Unlock()
+	if !ok {
+		return ErrTaskNotFound
+	}
+
+	// Tasks are claimed by their lane before they leave the queue, so the task is found in either of them if it
+	// did not finish.
+	removed, err := w.manager.Cancel(t)
+	if removed {
+		log.Infof(context.TODO(), "Distro %q: task %q: cancelled before it started", w.distro.Name(), t)
+		w.emit(context.TODO(), t, Event{Type: EventFailed, Reason: fmt.Sprintf("distro %q: task %q: %v", w.distro.Name(), t, task.ErrCancelled)})
+		w.forget(t)
+		return err
+	}
+
+	return w.cancelRunning(t)
+}
+
+// cancelRunning cancels the task in progress equivalent to t. It is preempted, and its context is cancelled
+// if it does not reach a safe point within checkpointTimeout, or right away if the distro is not connected.
+func (w *Worker) cancelRunning(t task.Task) error {
+	lane := task.LaneOf(t)
+	conn := w.Connection()
+
+	delay := checkpointTimeout
+	if conn == nil {
+		delay = 0
+	}
+
+	w.runningMu.Lock()
+	running, ok := w.running[lane]
+	if !ok || !task.Is(running, t) {
+		w.runningMu.Unlock()
+		return ErrTaskNotFound
+	}
+	if _, ok := w.cancelTimers[lane]; !ok {
+		var timer *time.Timer
+		timer = time.AfterFunc(delay, func() {
+			w.runningMu.Lock()
+			defer w.runningMu.Unlock()
+
+			// The lane may have moved on to another task meanwhile.
+			if w.cancelTimers[lane] == timer {
+				w.taskCancels[lane](task.ErrCancelled)
+			}
+		})
+		w.cancelTimers[lane] = timer
+	}
+	if cancel, ok := w.consentCancels[lane]; ok {
+		cancel(task.ErrCancelled)
+	}
+	w.runningMu.Unlock()
+
+	log.Infof(context.TODO(), "Distro %q: cancelling task %q", w.distro.Name(), running)
+	if conn == nil {
+		return nil
+	}
+
+	if err := conn.Preempt(lane); err != nil {
+		log.Warningf(context.TODO(), "Distro %q: could not preempt task %q: %v", w.distro.Name(), running, err)
+	}
+	return nil
+}
+
+// dropRedundant returns the tasks without those that have the same idempotency key as a task in progress,
+// which would do the same thing again. Tasks with an equivalent one queued are kept, as the queued one was
+// submitted after the task in progress and may undo it: the task submitted last is the one that must run.
+func (w *Worker) dropRedundant(tasks []task.Task) []task.Task {
+	// Checking the queue first, so that the task manager is not locked with the running tasks.
+	superseding := make([]bool, len(tasks))
+	for i, t := range tasks {
+		superseding[i] = w.manager.Contains(t)
+	}
+
+	w.runningMu.Lock()
+	defer w.runningMu.Unlock()
+
+	var kept []task.Task
+	for i, t := range tasks {
+		if !superseding[i] && w.isRunningSame(t) {
+			log.Debugf(context.TODO(), "Distro %q: task %q: dropped, the same task is in progress", w.distro.Name(), t)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept
+}
+
+// isRunningSame returns true if a task with the same idempotency key as t is in progress. It must be called with
+// runningMu held.
+func (w *Worker) isRunningSame(t task.Task) bool {
+	for _, r := range w.running {
+		if task.SameKey(r, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// forget drops the IDs of the tasks equivalent to t once none is queued anymore, so that they cannot be
+// cancelled after they finished.
+func (w *Worker) forget(t task.Task) {
+	if w.manager.Contains(t) {
+		return
+	}
+
+	w.runningMu.Lock()
+	defer w.runningMu.Unlock()
+
+	maps.DeleteFunc(w.submitted, func(_ TaskID, submitted task.Task) bool { return task.Is(submitted, t) })
+}
+
+// preemptIfOutranked preempts the task in progress in a lane if any of the given tasks of the same
+// lane has a higher priority. Tasks in other lanes do not need to wait for it. Tasks waiting for the
+// user's consent are preempted too, so that nobody answering does not hold the lane back.
+func (w *Worker) preemptIfOutranked(tasks ...task.Task) {
+	w.runningMu.Lock()
+	outranked := make(map[task.Lane]task.Task)
+	for _, t := range tasks {
+		lane := task.LaneOf(t)
+		r, ok := w.running[lane]
+		if ok && task.PriorityOf(t) > task.PriorityOf(r) {
+			outranked[lane] = r
+		}
+	}
+	for lane := range outranked {
+		if cancel, ok := w.consentCancels[lane]; ok {
+			cancel(task.ErrPreempted)
+		}
+	}
+	w.runningMu.Unlock()
+
+	if len(outranked) == 0 {
+		return
+	}
+
+	conn := w.Connection()
+	if conn == nil {
+		return
+	}
+
+	for lane, running := range outranked {
+		log.Infof(context.TODO(), "Distro %q: preempting task %q", w.distro.Name(), running)
+		if err := conn.Preempt(lane); err != nil {
+			log.Warningf(context.TODO(), "Distro %q: could not preempt task %q: %v", w.distro.Name(), running, err)
+		}
+	}
 }
 
 // SubmitDeferredTasks takes one or more tasks into our current worker list.
@@ -133,7 +528,7 @@ func (w *Worker) SubmitTasks(tasks ...task.Task) (err error) {
 // The task(s) won't wake up the distro, instead wait until it is awake. This does
 // NOT necessarily mean it'll run after non-deferred tasks.
 //
-// It will return an error if the distro has been cleaned up.
+// It will return an error if the distro has been cleaned up or the worker is handing its tasks off.
 func (w *Worker) SubmitDeferredTasks(tasks ...task.Task) (err error) {
 	defer decorate.OnError(&err, "distro %q: tasks %q: could not submit", w.distro.Name(), tasks)
 
@@ -141,9 +536,22 @@ func (w *Worker) SubmitDeferredTasks(tasks ...task.Task) (err error) {
 		return nil
 	}
 
+	if w.handingOff.Load() {
+		return errHandingOff
+	}
+
 	log.Infof(context.TODO(), "Distro %q: Submitting tasks %q to queue", w.distro.Name(), tasks)
 
-	return w.manager.Submit(true, tasks...)
+	tasks, err = w.manager.Submit(true, w.dropRedundant(tasks)...)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		w.emit(context.TODO(), t, Event{Type: EventQueued})
+	}
+
+	return nil
 }
 
 // EnqueueDeferredTasks takes all deferred tasks and promotes them
@@ -152,33 +560,200 @@ func (w *Worker) EnqueueDeferredTasks() {
 	w.manager.EnqueueDeferredTasks()
 }
 
-// processTasks is the main loop for the distro, processing any existing tasks while starting and releasing
-// locks to distro,.
-func (w *Worker) processTasks(ctx context.Context) {
+// PendingTasks returns the number of tasks waiting to be processed, including the deferred ones.
+func (w *Worker) PendingTasks() int {
+	return w.manager.TaskLen()
+}
+
+// processTasks is the main loop for the distro, dispatching the queued tasks to their lanes as soon
+// as the lanes are free. Tasks in different lanes run concurrently. No task is started once pullCtx
+// is done, and the tasks in progress are cancelled once ctx is.
+func (w *Worker) processTasks(ctx, pullCtx context.Context) {
+	defer crashreport.Recover("worker")
 	defer close(w.processing)
 
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	for {
-		t, ok := w.manager.NextTask(ctx)
+		var taskCtx context.Context
+		t, ok := w.manager.NextTask(pullCtx, func(t task.Task) (claimed bool) {
+			taskCtx, claimed = w.claimLane(ctx, t)
+			return claimed
+		})
 		if !ok {
 			return
 		}
 
-		resultErr := w.processSingleTask(ctx, t)
+		wg.Add(1)
+		go func() {
+			defer crashreport.Recover("worker")
+			defer wg.Done()
 
-		var target unreachableDistroError
-		if errors.As(resultErr, &target) {
-			log.Errorf(ctx, "Distro %q: task %q: distro not reachable: %v", w.distro.Name(), t, target.sourceErr)
-			w.distro.Invalidate(ctx)
-			continue
+			w.runTask(taskCtx, t)
+
+			w.releaseLane(task.LaneOf(t))
+			w.manager.Wake()
+		}()
+	}
+}
+
+// runTask executes a task while starting and releasing locks to the distro, and handles its outcome.
+func (w *Worker) runTask(ctx context.Context, t task.Task) {
+	defer w.forget(t)
+
+	w.emit(ctx, t, Event{Type: EventStarted})
+	started := time.Now()
+
+	var steps stepRecorder
+	conn, resultErr := w.processSingleTask(task.WithStepRecorder(ctx, steps.record), t)
+
+	// Cancelled tasks fail for good, whatever stopped them.
+	if resultErr != nil && w.isCancelled(task.LaneOf(t)) {
+		resultErr = fmt.Errorf("distro %q: task %q: %w: %v", w.distro.Name(), t, task.ErrCancelled, resultErr)
+	}
+
+	// Tasks cancelled by a hand-off are stored back in the queue for the next version of the agent to resume them.
+	if resultErr != nil && ctx.Err() != nil && w.handingOff.Load() && !errors.Is(resultErr, task.ErrCancelled) {
+		log.Infof(ctx, "Distro %q: task %q: cancelled, it will be resumed after the update", w.distro.Name(), t)
+		if err := w.manager.Requeue(t); err != nil {
+			log.Errorf(ctx, "Distro %q: %v", w.distro.Name(), err)
 		}
+		w.emit(ctx, t, Event{Type: EventQueued})
+		return
+	}
 
-		err := w.manager.TaskDone(ctx, t, resultErr)
-		if err != nil {
+	var target unreachableDistroError
+	if errors.As(resultErr, &target) {
+		log.Errorf(ctx, "Distro %q: task %q: distro not reachable: %v", w.distro.Name(), t, target.sourceErr)
+		w.emit(ctx, t, Event{Type: EventFailed, Reason: resultErr.Error()})
+		taskFailures.Inc(w.distro.Name(), fmt.Sprintf("%T", t))
+		w.distro.AppendTaskHistory(newTaskRecord(t, started, resultErr, nil))
+		w.distro.Invalidate(ctx)
+		return
+	}
+
+	if errors.Is(resultErr, errDistroStopped) {
+		w.deferUntilConnected(ctx, t)
+		return
+	}
+
+	if errors.Is(resultErr, task.ErrPreempted) {
+		log.Infof(ctx, "Distro %q: task %q: preempted, it will be resumed later", w.distro.Name(), t)
+		if err := w.manager.Requeue(t); err != nil {
 			log.Errorf(ctx, "Distro %q: %v", w.distro.Name(), err)
 		}
+		w.emit(ctx, t, Event{Type: EventQueued})
+		return
+	}
+
+	if errors.Is(resultErr, task.ErrInterrupted) {
+		w.resumeOnReconnection(ctx, t, conn, resultErr)
+		return
+	}
+
+	if resultErr != nil {
+		w.emit(ctx, t, Event{Type: EventFailed, Reason: resultErr.Error(), Steps: steps.get()})
+		taskFailures.Inc(w.distro.Name(), fmt.Sprintf("%T", t))
+	} else {
+		w.emit(ctx, t, Event{Type: EventCompleted, Steps: steps.get()})
+	}
+	w.distro.AppendTaskHistory(newTaskRecord(t, started, resultErr, steps.get()))
+
+	// Neither a task the user turned down or cancelled nor one the distro cannot run says anything about its health.
+	if !errors.Is(resultErr, task.ErrConsentDenied) && !errors.Is(resultErr, task.ErrUnsupported) && !errors.Is(resultErr, task.ErrNeedsWSL2) &&
+		!errors.Is(resultErr, task.ErrCancelled) {
+		w.distro.RecordTaskResult(ctx, resultErr)
+	}
+
+	err := w.manager.TaskDone(ctx, t, resultErr)
+	if err != nil {
+		log.Errorf(ctx, "Distro %q: %v", w.distro.Name(), err)
+	}
+}
+
+// resumeOnReconnection defers a task interrupted by its distro disconnecting, so that it is executed again once
+// the distro connects again rather than waking it up right away, as it may have been terminated on purpose. It is
+// dropped if an equivalent task is queued already. conn is the connection the task was interrupted on.
+func (w *Worker) resumeOnReconnection(ctx context.Context, t task.Task, conn Connection, resultErr error) {
+	log.Warningf(ctx, "Distro %q: task %q: %v: it will be resumed once the distro connects again", w.distro.Name(), t, resultErr)
+	w.emit(ctx, t, Event{Type: EventInterrupted, Reason: resultErr.Error()})
+
+	if err := w.manager.Defer(t); err != nil {
+		log.Errorf(ctx, "Distro %q: %v", w.distro.Name(), err)
+	}
+	w.emit(ctx, t, Event{Type: EventQueued})
+
+	// The distro may have connected again already, in which case its deferred tasks were enqueued before this one.
+	if c := w.Connection(); c != nil && c != conn {
+		w.manager.EnqueueDeferredTasks()
+	}
+}
+
+// deferUntilConnected defers a task that does not wake its distro up, so that it is executed once the distro
+// connects. It is dropped if an equivalent task is queued already.
+func (w *Worker) deferUntilConnected(ctx context.Context, t task.Task) {
+	log.Infof(ctx, "Distro %q: task %q: the distro is stopped, the task will run once it connects", w.distro.Name(), t)
+
+	if err := w.manager.Defer(t); err != nil {
+		log.Errorf(ctx, "Distro %q: %v", w.distro.Name(), err)
+	}
+	w.emit(ctx, t, Event{Type: EventQueued})
+
+	// The distro may have connected meanwhile, in which case its deferred tasks were enqueued before this one.
+	if w.Connection() != nil {
+		w.manager.EnqueueDeferredTasks()
 	}
 }
 
+// claimLane makes t the task in progress in its lane, unless a task of the lane is in progress already. It
+// returns the context to execute the task with, which is derived from ctx.
+func (w *Worker) claimLane(ctx context.Context, t task.Task) (context.Context, bool) {
+	w.runningMu.Lock()
+	defer w.runningMu.Unlock()
+
+	lane := task.LaneOf(t)
+	if _, busy := w.running[lane]; busy {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	w.running[lane] = t
+	w.taskCancels[lane] = cancel
+	return ctx, true
+}
+
+// releaseLane frees the lane once its task in progress is done.
+func (w *Worker) releaseLane(lane task.Lane) {
+	w.runningMu.Lock()
+	defer w.runningMu.Unlock()
+
+	if timer, ok := w.cancelTimers[lane]; ok {
+		timer.Stop()
+		delete(w.cancelTimers, lane)
+	}
+	if cancel, ok := w.taskCancels[lane]; ok {
+		cancel(nil)
+		delete(w.taskCancels, lane)
+	}
+	delete(w.running, lane)
+}
+
+// isCancelled returns true if the task in progress in the lane was cancelled.
+func (w *Worker) isCancelled(lane task.Lane) bool {
+	w.runningMu.Lock()
+	defer w.runningMu.Unlock()
+
+	_, ok := w.cancelTimers[lane]
+	return ok
+}
+
+// errTimedOut is the error of the tasks that ran out of the time they declare (see task.TimeoutOf).
+var errTimedOut = errors.New("timed out")
+
+// errDistroStopped is returned for the tasks that do not wake their distro up, when it is stopped.
+var errDistroStopped = errors.New("distro is stopped")
+
 type unreachableDistroError struct {
 	sourceErr error
 }
@@ -196,18 +771,50 @@ func (err unreachableDistroError) Error() string {
 	return fmt.Sprintf("distro cannot be reached: %v", err.sourceErr)
 }
 
-func (w *Worker) processSingleTask(ctx context.Context, t task.Task) error {
+// processSingleTask executes a task, and returns the connection it was executed with, if it got that far.
+func (w *Worker) processSingleTask(ctx context.Context, t task.Task) (Connection, error) {
 	log.Debugf(ctx, "Distro %q: starting task %q", w.distro.Name(), t)
+	ctx = task.WithDistroName(ctx, w.distro.Name())
 
 	if !w.distro.IsValid() {
-		return newUnreachableDistroErr(errors.New("distro marked as invalid"))
+		return nil, newUnreachableDistroErr(errors.New("distro marked as invalid"))
+	}
+
+	// A connected distro is running, whatever its live state says if it was not refreshed since.
+	if !task.WakesDistro(t) && w.Connection() == nil && w.distro.LiveState() == wsl.Stopped {
+		return nil, errDistroStopped
+	}
+
+	if reason := w.distro.UnsupportedReason(); reason != "" && task.NeedsProClient(t) {
+		return nil, fmt.Errorf("distro %q: task %q: %w: %s", w.distro.Name(), t, task.ErrUnsupported, reason)
+	}
+
+	if w.distro.WslVersion() == 1 && task.NeedsWSL2(t) {
+		return nil, fmt.Errorf("distro %q: task %q: %w", w.distro.Name(), t, task.ErrNeedsWSL2)
+	}
+
+	if prompt, ok := task.ConsentPromptOf(t); ok {
+		granted, err := w.requestConsent(ctx, t, prompt)
+		if errors.Is(err, task.ErrPreempted) {
+			return nil, fmt.Errorf("distro %q: task %q: %w while waiting for consent", w.distro.Name(), t, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("distro %q: task %q: %w: %w", w.distro.Name(), t, task.ErrConsentDenied, err)
+		}
+		if !granted {
+			return nil, fmt.Errorf("distro %q: task %q: %w", w.distro.Name(), t, task.ErrConsentDenied)
+		}
+	}
+
+	if task.RunsOnHost(t) {
+		return nil, w.executeOnHost(ctx, t)
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	if err := w.distro.LockAwake(); err != nil {
-		return newUnreachableDistroErr(err)
+		return nil, newUnreachableDistroErr(err)
 	}
 	//nolint:errcheck // Nothing we can do about it
 	defer w.distro.ReleaseAwake()
@@ -216,17 +823,100 @@ func (w *Worker) processSingleTask(ctx context.Context, t task.Task) error {
 
 	client, err := w.waitForActiveConnection(ctx)
 	if err != nil {
-		return fmt.Errorf("task %v: could not start task: %w", t, err)
+		return nil, fmt.Errorf("task %v: could not start task: %w", t, err)
+	}
+
+	if err := w.execute(ctx, t, client); err != nil {
+		return client, err
+	}
+
+	log.Debugf(ctx, "Distro %q: task %q: task completed successfully", w.distro.Name(), t)
+	return client, nil
+}
+
+// execute executes a task with the connection, or without one if it is nil, within the timeout of the task.
+func (w *Worker) execute(ctx context.Context, t task.Task, client Connection) error {
+	timeout := task.TimeoutOf(t)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, errTimedOut)
+		defer cancel()
+	}
+
+	ctx = task.WithProgressReporter(ctx, func(percent uint32, step string) {
+		w.emit(ctx, t, Event{Type: EventProgress, Progress: percent, Step: step})
+	})
+	ctx = task.WithTransferMeter(ctx, w.distro)
+
+	var conn task.Connection
+	if client != nil {
+		conn = client.InLane(ctx, task.LaneOf(t))
+	}
+
+	err := t.Execute(ctx, conn)
+	if err == nil {
+		return nil
+	}
+
+	// Whatever the task returns when it runs out of time, e.g. an interruption as its requests are abandoned, it
+	// is retried according to its retry policy only.
+	if errors.Is(context.Cause(ctx), errTimedOut) {
+		return fmt.Errorf("distro %q: task %q: %w after %s: %v", w.distro.Name(), t, errTimedOut, timeout, err)
+	}
+	return fmt.Errorf("distro %q: task %q failed: %w", w.distro.Name(), t, err)
+}
+
+// timeBetweenStoppedChecks is how often tasks that run on the host check whether their distro stopped.
+const timeBetweenStoppedChecks = time.Second
+
+// executeOnHost executes a task that runs on the host, once its distro is stopped. It does not wake the distro
+// up, nor wait for it to connect: the task is executed without a connection.
+func (w *Worker) executeOnHost(ctx context.Context, t task.Task) error {
+	// A connected distro is running, whatever its live state says if it was not refreshed since.
+	for w.Connection() != nil || w.distro.LiveState() == wsl.Running {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("distro %q: task %q: stopped waiting for the distro to stop: %v", w.distro.Name(), t, ctx.Err())
+		case <-time.After(timeBetweenStoppedChecks):
+		}
 	}
 
-	if err := t.Execute(ctx, client); err != nil {
-		return fmt.Errorf("distro %q: task %q failed: %w", w.distro.Name(), t, err)
+	log.Debugf(ctx, "Distro %q: distro is stopped, running task %q on the host", w.distro.Name(), t)
+
+	if err := w.execute(ctx, t, nil); err != nil {
+		return err
 	}
 
 	log.Debugf(ctx, "Distro %q: task %q: task completed successfully", w.distro.Name(), t)
 	return nil
 }
 
+// requestConsent asks the user to confirm the task. Waiting for the answer is a safe point: the request
+// is withdrawn if a task of higher priority of the same lane is submitted meanwhile, and the error then
+// wraps task.ErrPreempted. It is withdrawn as well if the task is cancelled, and the error then wraps
+// task.ErrCancelled.
+func (w *Worker) requestConsent(ctx context.Context, t task.Task, prompt string) (granted bool, err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	lane := task.LaneOf(t)
+	w.runningMu.Lock()
+	w.consentCancels[lane] = cancel
+	w.runningMu.Unlock()
+
+	defer func() {
+		w.runningMu.Lock()
+		defer w.runningMu.Unlock()
+		delete(w.consentCancels, lane)
+	}()
+
+	granted, err = w.distro.RequestConsent(ctx, prompt)
+	if cause := context.Cause(ctx); err != nil && (errors.Is(cause, task.ErrPreempted) || errors.Is(cause, task.ErrCancelled)) {
+		return false, cause
+	}
+	return granted, err
+}
+
 func (w *Worker) waitForActiveConnection(ctx context.Context) (conn Connection, err error) {
 	log.Debugf(ctx, "Distro %q: ensuring active connection.", w.distro.Name())
 
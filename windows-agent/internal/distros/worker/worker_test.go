@@ -8,23 +8,31 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"text/template"
 	"time"
 
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+	wsl "github.com/ubuntu/gowsl"
 )
 
 func init() {
 	task.Register[emptyTask]()
+	task.Register[keyedTask]()
 }
 
 func TestMain(m *testing.M) {
@@ -282,6 +290,140 @@ func TestSubmitTaskFailsCannotWrite(t *testing.T) {
 	require.Error(t, err, "Submitting a task when the task file is not writable should cause an error")
 }
 
+func TestSubmitAll(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		cannotWrite bool
+
+		wantErr bool
+	}{
+		"Success queueing and storing all tasks": {},
+
+		"Error when the tasks cannot be stored, queueing none": {cannotWrite: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			// We pass a cancelled context so that no tasks are popped
+			// and we can accurately assert on the task queue length.
+			cancel()
+
+			distro := &testDistro{name: wsltestutils.RandomDistroName(t)}
+			distroDir := t.TempDir()
+			taskFile := filepath.Join(distroDir, distro.Name()+".tasks")
+
+			w, err := worker.New(ctx, distro, distroDir)
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			err = w.SubmitTasks(emptyTask{ID: "queued"})
+			require.NoError(t, err, "Setup: could not submit the first task")
+
+			if tc.cannotWrite {
+				require.NoError(t, os.RemoveAll(taskFile), "Setup: could not remove distro task backup file")
+				require.NoError(t, os.MkdirAll(taskFile, 0600), "Setup: could not make dir at distro task file's location")
+			}
+
+			err = w.SubmitAll([]task.Task{emptyTask{ID: "1"}, emptyTask{ID: "2"}, emptyTask{ID: "3"}})
+			if tc.wantErr {
+				require.Error(t, err, "SubmitAll should return an error")
+				require.NoError(t, w.CheckQueuedTaskCount(1), "No task should be queued when they cannot be stored")
+				return
+			}
+			require.NoError(t, err, "SubmitAll should return no error")
+			require.NoError(t, w.CheckQueuedTaskCount(4), "All tasks should be queued")
+
+			w.Stop(ctx)
+			w, err = worker.New(ctx, distro, distroDir)
+			require.NoError(t, err, "Setup: unexpected error creating the worker again")
+			defer w.Stop(ctx)
+
+			require.NoError(t, w.CheckQueuedTaskCount(4), "All tasks should have been stored")
+		})
+	}
+}
+
+func TestTasksInMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// We pass a cancelled context so that no tasks are popped
+	// and we can accurately assert on the task queue length.
+	cancel()
+
+	distro := &testDistro{name: wsltestutils.RandomDistroName(t)}
+
+	w, err := worker.New(ctx, distro, "")
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	err = w.SubmitTasks(&emptyTask{ID: "1"}, &emptyTask{ID: "2"})
+	require.NoError(t, err, "Submitting tasks without storage directory should not fail")
+	require.NoError(t, w.CheckQueuedTaskCount(2), "Wrong number of queued tasks.")
+
+	require.NoFileExists(t, distro.Name()+".tasks", "Tasks should not be written to disk without storage directory")
+}
+
+func TestTasksInStore(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		notOwned bool
+
+		wantErr bool
+	}{
+		"Success": {},
+		"Error when the distro does not own a queue": {notOwned: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			// We pass a cancelled context so that no tasks are popped
+			// and we can accurately assert on the task queue length.
+			cancel()
+
+			distro := &testDistro{name: wsltestutils.RandomDistroName(t)}
+			distroDir := t.TempDir()
+
+			s, err := store.Open(ctx, filepath.Join(distroDir, "store.db"))
+			require.NoError(t, err, "Setup: could not open the store")
+			defer s.Close()
+
+			owned := func(*store.Tx) (bool, error) { return !tc.notOwned, nil }
+
+			w, err := worker.New(ctx, distro, distroDir, worker.WithStore(s, owned))
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+
+			err = w.SubmitTasks(emptyTask{ID: "1"}, emptyTask{ID: "2"})
+			w.Stop(ctx)
+			if tc.wantErr {
+				require.Error(t, err, "Submitting tasks should fail when they cannot be stored")
+			} else {
+				require.NoError(t, err, "Submitting tasks with a store should not fail")
+			}
+
+			require.NoFileExists(t, filepath.Join(distroDir, distro.Name()+".tasks"), "Tasks should not be written to a file when using a store")
+
+			w, err = worker.New(ctx, distro, distroDir, worker.WithStore(s, owned))
+			require.NoError(t, err, "Setup: unexpected error creating the worker again")
+			defer w.Stop(ctx)
+
+			want := 2
+			if tc.wantErr {
+				want = 0
+			}
+			require.NoError(t, w.CheckQueuedTaskCount(want), "Only the stored tasks should have been loaded from the store")
+		})
+	}
+}
+
 func TestSetConnection(t *testing.T) {
 	t.Parallel()
 
@@ -470,6 +612,119 @@ func TestTaskDeferral(t *testing.T) {
 	}
 }
 
+func TestTaskWakesDistro(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stopped   bool
+		connected bool
+		wakes     bool
+
+		wantWake     bool
+		wantDeferred bool
+	}{
+		"Success running a task that does not wake the distro on a running distro": {connected: true},
+		"Success running a task that does not wake the distro on a connected one":  {stopped: true, connected: true},
+		"Success waking a stopped distro up for a task that wakes it":              {stopped: true, wakes: true, wantWake: true},
+		"Success deferring a task that does not wake a stopped distro":             {stopped: true, wantDeferred: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+			d.stopped.Store(tc.stopped)
+
+			w, err := worker.New(ctx, d, "")
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			if tc.connected {
+				w.SetConnection(&mockConnection{})
+			}
+
+			tk := emptyTask{ID: uuid.NewString()}
+			var submitted task.Task = sleepyTask{tk}
+			if tc.wakes {
+				submitted = tk
+			}
+
+			err = w.SubmitTasks(submitted)
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			if tc.wantDeferred {
+				require.Eventually(t, func() bool {
+					return w.CheckQueuedTaskCount(0) == nil && w.CheckTotalTaskCount(1) == nil
+				}, 5*time.Second, 100*time.Millisecond, "The task should have been deferred")
+				require.Never(t, func() bool { return d.state() == "Running" }, time.Second, 100*time.Millisecond, "The distro should not have been woken up")
+				require.False(t, completedEmptyTasks.Has(tk.ID), "The task should not have run before the distro connects")
+
+				w.SetConnection(&mockConnection{})
+				w.EnqueueDeferredTasks()
+			}
+
+			if tc.wantWake {
+				require.Eventually(t, func() bool { return d.state() == "Running" }, 5*time.Second, 100*time.Millisecond, "The distro should have been woken up")
+				w.SetConnection(&mockConnection{})
+			}
+
+			requireEventuallyTaskCompletes(t, tk, "The task should have been completed")
+		})
+	}
+}
+
+func TestTaskRunsOnHost(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		running bool
+
+		wantWait bool
+	}{
+		"Success running a task on the host of a stopped distro":         {},
+		"Success running a task on the host once its distro has stopped": {running: true, wantWait: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+			d.stopped.Store(!tc.running)
+
+			w, err := worker.New(ctx, d, "")
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			if tc.running {
+				w.SetConnection(&mockConnection{})
+			}
+
+			tk := hostTask{emptyTask: emptyTask{ID: uuid.NewString()}, Distro: d.name}
+			err = w.SubmitTasks(tk)
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			if tc.wantWait {
+				require.Never(t, func() bool { return completedEmptyTasks.Has(tk.ID) }, 2*time.Second, 100*time.Millisecond,
+					"The task should not have run while the distro is running")
+
+				w.SetConnection(nil)
+				d.stopped.Store(true)
+			}
+
+			requireEventuallyTaskCompletes(t, tk.emptyTask, "The task should have run on the host, without a connection")
+			require.Equal(t, "Stopped", d.state(), "The distro should not have been woken up")
+		})
+	}
+}
+
 func TestTaskDeduplication(t *testing.T) {
 	t.Parallel()
 
@@ -538,6 +793,7 @@ func TestTaskDeduplication(t *testing.T) {
 			require.NoError(t, err, "SubmitDeferredTasks should return no error")
 			require.NoError(t, w.CheckQueuedTaskCount(1), "Submitting a repeated deferred task should decrease the queue size by one")
 			require.NoError(t, w.CheckTotalTaskCount(2), "Submitting a repeated deferred task should not change the total task count")
+			require.Equal(t, 2, w.PendingTasks(), "Deferred tasks should be reported as pending")
 
 			// Check that re-submitting a deferred task removes the old one
 			// This caused https://warthogs.atlassian.net/browse/UDENG-1848
@@ -549,6 +805,109 @@ func TestTaskDeduplication(t *testing.T) {
 	}
 }
 
+func TestIdempotencyKeys(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{
+		name: wsltestutils.RandomDistroName(t),
+	}
+
+	w, err := worker.New(ctx, d, t.TempDir())
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	w.SetConnection(&mockConnection{})
+
+	blocker := keyedBlockingTask{blockingTask: newBlockingTask(ctx), key: "blocker"}
+	defer blocker.complete()
+
+	err = w.SubmitTasks(blocker)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Eventually(t, blocker.executing.Load, 5*time.Second, 500*time.Millisecond, "Blocker task was never dequeued")
+
+	// The same task as the one in progress is dropped.
+	err = w.SubmitTasks(keyedBlockingTask{blockingTask: newBlockingTask(ctx), key: "blocker"})
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.NoError(t, w.CheckQueuedTaskCount(0), "Submitting the same task as the one in progress should not queue it")
+
+	_, err = w.SubmitTask(keyedBlockingTask{blockingTask: newBlockingTask(ctx), key: "blocker"})
+	require.ErrorIs(t, err, worker.ErrTaskDropped, "SubmitTask should tell that the same task as the one in progress is dropped")
+	require.NoError(t, w.CheckQueuedTaskCount(0), "Submitting the same task as the one in progress should not queue it")
+
+	first := keyedTask{ID: "first", Key: "key"}
+	err = w.SubmitTasks(first)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	err = w.SubmitTasks(keyedTask{ID: "other", Key: "other key"})
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.NoError(t, w.CheckQueuedTaskCount(2), "Submitting tasks with different keys should queue them all")
+
+	// The same task as a queued one is dropped, so that the queued one keeps its place.
+	err = w.SubmitAll([]task.Task{keyedTask{ID: "second", Key: "key"}, keyedTask{ID: "third", Key: "key"}})
+	require.NoError(t, err, "SubmitAll should return no error")
+	require.NoError(t, w.CheckQueuedTaskCount(2), "Submitting the same task as a queued one should not change the queue size")
+	require.Equal(t, first, w.QueuedTasks()[0], "The queued task should keep its place")
+
+	// The same task with higher priority replaces the queued one, so that it runs sooner.
+	urgent := keyedTask{ID: "urgent", Key: "key", Prio: task.PriorityUser}
+	err = w.SubmitTasks(urgent)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.NoError(t, w.CheckQueuedTaskCount(2), "Submitting the same task with higher priority should replace the queued one")
+	require.Equal(t, urgent, w.QueuedTasks()[0], "The task with higher priority should have replaced the queued one")
+
+	blocker.complete()
+	require.Eventually(t, func() bool {
+		return completedEmptyTasks.Has("urgent") && completedEmptyTasks.Has("other")
+	}, 5*time.Second, 100*time.Millisecond, "The queued tasks should have run")
+	for _, id := range []string{"first", "second", "third"} {
+		require.False(t, completedEmptyTasks.Has(id), "Task %q should have been dropped", id)
+	}
+}
+
+func TestIdempotencyKeysWithNewerTask(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{
+		name: wsltestutils.RandomDistroName(t),
+	}
+
+	w, err := worker.New(ctx, d, t.TempDir())
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	w.SetConnection(&mockConnection{})
+
+	attach := attachmentTask{blockingTask: newBlockingTask(ctx), token: "X"}
+	defer attach.complete()
+
+	err = w.SubmitTasks(attach)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Eventually(t, attach.executing.Load, 5*time.Second, 500*time.Millisecond, "Attachment task was never dequeued")
+
+	// A task that undoes the one in progress is queued.
+	detach := attachmentTask{blockingTask: newBlockingTask(ctx)}
+	err = w.SubmitTasks(detach)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.NoError(t, w.CheckQueuedTaskCount(1), "Submitting a task with another key than the one in progress should queue it")
+
+	// The same task as the one in progress replaces the newer one, as it was submitted last.
+	reattach := attachmentTask{blockingTask: newBlockingTask(ctx), token: "X"}
+	_, err = w.SubmitTask(reattach)
+	require.NoError(t, err, "SubmitTask should not drop a task that supersedes a queued one")
+	require.NoError(t, w.CheckQueuedTaskCount(1), "The task should have replaced the equivalent queued one")
+	require.Equal(t, reattach, w.QueuedTasks()[0], "The task submitted last should be the one queued")
+
+	// Once queued, the same task is dropped again.
+	_, err = w.SubmitTask(attachmentTask{blockingTask: newBlockingTask(ctx), token: "X"})
+	require.ErrorIs(t, err, worker.ErrTaskDropped, "SubmitTask should drop the same task as a queued one")
+	require.Equal(t, reattach, w.QueuedTasks()[0], "The queued task should keep its place")
+}
+
 func TestFailedTaskIsDeferred(t *testing.T) {
 	t.Parallel()
 
@@ -583,50 +942,1180 @@ func TestFailedTaskIsDeferred(t *testing.T) {
 	require.NoError(t, w.CheckQueuedTaskCount(0), "Task should not have been submitted into the queue, but rather deferred")
 }
 
-func requireEventuallyTaskCompletes(t *testing.T, task emptyTask, msg string, args ...any) {
-	t.Helper()
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
 
-	require.Eventually(t, func() bool {
-		return completedEmptyTasks.Has(task.ID)
-	}, 5*time.Second, 100*time.Millisecond, msg, args)
-}
+	policy := task.RetryPolicy{MaxAttempts: 3, Backoff: 200 * time.Millisecond}
 
-// completedEmptyTasks tracks which empty tasks have completed. We need to use this global
-// variable because tasks may be written to file and read back, so no callbacks or pointers
-// can be used.
-var completedEmptyTasks = testutils.NewSet[string]()
+	testCases := map[string]struct {
+		failures   int
+		timesOut   bool
+		noPolicy   bool
+		noAttempts bool
 
-type emptyTask struct {
-	ID string
-}
+		wantExecutions int32
+	}{
+		"Success retrying a task until it succeeds":             {failures: 1, wantExecutions: 2},
+		"Success retrying a task that times out":                {failures: 1, timesOut: true, wantExecutions: 2},
+		"Success dropping a task that used up its attempts":     {failures: 5, wantExecutions: 3},
+		"Success dropping a task that may not be retried":       {failures: 5, noAttempts: true, wantExecutions: 1},
+		"Success dropping a task with no policy that times out": {failures: 5, timesOut: true, noPolicy: true, wantExecutions: 1},
+	}
 
-func (t emptyTask) Execute(ctx context.Context, _ task.Connection) error {
-	completedEmptyTasks.Set(t.ID)
-	return nil
-}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-func (t emptyTask) String() string {
-	return "Empty test task"
-}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
 
-type testTask struct {
-	// ExecuteCalls counts the number of times Execute is called
-	ExecuteCalls atomic.Int32
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
 
-	// Delay simulates a processing time for the task
-	Delay time.Duration
+			w, err := worker.New(ctx, d, t.TempDir())
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
 
-	// Returns is the value that Execute will return
-	Returns error
+			w.SetConnection(&mockConnection{})
 
-	// WasCancelled is true if the task Execute context is Done
-	WasCancelled atomic.Bool
+			tsk := &retriedTask{failures: int32(tc.failures), policy: policy}
+			if tc.noAttempts {
+				tsk.policy = task.RetryPolicy{MaxAttempts: 1}
+			}
+			if tc.timesOut {
+				tsk.timeout = 50 * time.Millisecond
+			}
 
-	ID string
+			var submitted task.Task = retriedTaskWithPolicy{tsk}
+			if tc.noPolicy {
+				submitted = tsk
+			}
+
+			err = w.SubmitTasks(submitted)
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			require.Eventually(t, func() bool { return len(d.taskHistory()) == int(tc.wantExecutions) }, 5*time.Second, 10*time.Millisecond, "The task should have been executed %d times", tc.wantExecutions)
+			require.Eventually(t, func() bool { return w.CheckTotalTaskCount(0) == nil }, 5*time.Second, 10*time.Millisecond, "The task should not remain in the queue once it succeeded or was dropped")
+
+			// Give the worker the time to retry too many times.
+			time.Sleep(2 * policy.Delay(policy.MaxAttempts))
+			require.Equal(t, tc.wantExecutions, tsk.executions.Load(), "Unexpected number of executions of the task")
+
+			started := tsk.started()
+			for i := 1; i < len(started); i++ {
+				require.GreaterOrEqual(t, started[i].Sub(started[i-1]), policy.Delay(i), "Retry %d should have waited for the backoff", i)
+			}
+		})
+	}
 }
 
-// MarshalYAML is necessary to avoid races between Execute and Save.
-func (t *testTask) MarshalYAML() (interface{}, error) {
+func TestTaskPreemption(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{
+		name: wsltestutils.RandomDistroName(t),
+	}
+
+	w, err := worker.New(ctx, d, t.TempDir())
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	conn := &mockConnection{}
+	w.SetConnection(conn)
+
+	high := &priorityTask{}
+	long := &preemptibleTask{resumedAfter: high.executed.Load}
+
+	err = w.SubmitTasks(long)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Eventually(t, func() bool { return long.executions.Load() == 1 }, 5*time.Second, 100*time.Millisecond, "Long task was never dequeued")
+
+	// Tasks with the same priority wait for the task in progress
+	err = w.SubmitTasks(emptyTask{ID: "normal priority"})
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Zero(t, conn.preemptCount.Load(), "Tasks with normal priority should not preempt the task in progress")
+
+	// Tasks with higher priority preempt the task in progress, and it is resumed after them
+	err = w.SubmitTasks(high)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Equal(t, int32(1), conn.preemptCount.Load(), "Tasks with high priority should preempt the task in progress")
+
+	require.Eventually(t, long.completed.Load, 5*time.Second, 100*time.Millisecond, "Preempted task should have been resumed")
+	require.True(t, long.resumedAfterHigh.Load(), "Preempted task should have been resumed after the task that preempted it")
+	require.Eventually(t, func() bool { return completedEmptyTasks.Has("normal priority") }, 5*time.Second, 100*time.Millisecond, "Task with normal priority should have been executed")
+
+	require.NoError(t, w.CheckTotalTaskCount(0), "No tasks should remain in storage")
+}
+
+func TestTaskPriorities(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// We pass a cancelled context so that no tasks are popped
+	// and we can accurately assert on the task queue.
+	cancel()
+
+	distro := &testDistro{name: wsltestutils.RandomDistroName(t)}
+
+	w, err := worker.New(ctx, distro, "")
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	newTask := func(p task.Priority) *lanedTask {
+		return &lanedTask{blockingTask: newBlockingTask(ctx), priority: p}
+	}
+	background1, background2 := newTask(task.PriorityNormal), newTask(task.PriorityNormal)
+	user1, user2 := newTask(task.PriorityUser), newTask(task.PriorityUser)
+	high := newTask(task.PriorityHigh)
+
+	for _, tk := range []task.Task{background1, user1, background2, high, user2} {
+		require.NoError(t, w.SubmitTasks(tk), "Setup: could not submit task")
+	}
+
+	want := []task.Task{high, user1, user2, background1, background2}
+	require.Equal(t, want, w.QueuedTasks(), "Tasks should be queued by priority, and in order of submission within a priority")
+}
+
+func TestHandOff(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		finishing     bool
+		notPreemptive bool
+
+		wantStored int
+	}{
+		"Success finishing the task in progress within the grace period": {finishing: true},
+		"Success storing back a task preempted past the grace period":    {wantStored: 1},
+		"Success storing back a task cancelled past the grace period":    {notPreemptive: true, wantStored: 1},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+
+			w, err := worker.New(ctx, d, t.TempDir())
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			w.SetConnection(&mockConnection{})
+
+			var running func() bool
+			switch {
+			case tc.finishing:
+				blocking := newBlockingTask(ctx)
+				time.AfterFunc(100*time.Millisecond, blocking.complete)
+				err = w.SubmitTasks(blocking)
+				running = blocking.executing.Load
+			case tc.notPreemptive:
+				blocking := newBlockingTask(ctx)
+				err = w.SubmitTasks(blocking)
+				running = blocking.executing.Load
+			default:
+				long := &preemptibleTask{resumedAfter: func() bool { return true }}
+				err = w.SubmitTasks(long)
+				running = func() bool { return long.executions.Load() == 1 }
+			}
+			require.NoError(t, err, "Setup: SubmitTasks should return no error")
+			require.Eventually(t, running, 5*time.Second, 10*time.Millisecond, "Setup: task was never dequeued")
+
+			w.HandOff(ctx, time.Second)
+
+			require.NoError(t, w.CheckTotalTaskCount(tc.wantStored), "The tasks not completed should be stored back in the queue")
+			require.Error(t, w.SubmitTasks(emptyTask{ID: "after hand-off"}), "SubmitTasks should fail after the hand-off")
+			require.Error(t, w.SubmitDeferredTasks(emptyTask{ID: "after hand-off"}), "SubmitDeferredTasks should fail after the hand-off")
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		queued        bool
+		notPreemptive bool
+		finished      bool
+		notSubmitted  bool
+
+		wantErr bool
+	}{
+		"Success cancelling a queued task":                                 {queued: true},
+		"Success cancelling a task in progress at its next safe point":     {},
+		"Success cancelling a task in progress that reaches no safe point": {notPreemptive: true},
+
+		"Error when the task finished already":    {finished: true, wantErr: true},
+		"Error when the task was never submitted": {notSubmitted: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+
+			w, err := worker.New(ctx, d, t.TempDir())
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			w.SetConnection(&mockConnection{})
+			events := w.WatchTasks(ctx)
+
+			var tsk task.Task
+			var id worker.TaskID
+			switch {
+			case tc.queued:
+				blocking := newBlockingTask(ctx)
+				defer blocking.complete()
+				require.NoError(t, w.SubmitTasks(blocking), "Setup: SubmitTasks should return no error")
+				require.Eventually(t, blocking.executing.Load, 5*time.Second, 10*time.Millisecond, "Setup: blocking task was never dequeued")
+
+				tsk = emptyTask{ID: t.Name()}
+				id, err = w.SubmitTask(tsk)
+			case tc.notPreemptive:
+				blocking := newBlockingTask(ctx)
+				tsk = blocking
+				id, err = w.SubmitTask(tsk)
+				require.Eventually(t, blocking.executing.Load, 5*time.Second, 10*time.Millisecond, "Setup: task was never dequeued")
+			case tc.finished:
+				tsk = emptyTask{ID: t.Name()}
+				id, err = w.SubmitTask(tsk)
+				require.Eventually(t, func() bool { return completedEmptyTasks.Has(t.Name()) }, 5*time.Second, 10*time.Millisecond, "Setup: task was never executed")
+				require.Eventually(t, func() bool { return len(d.taskHistory()) > 0 }, 5*time.Second, 10*time.Millisecond, "Setup: task never finished")
+			case tc.notSubmitted:
+				id = 42
+			default:
+				long := &preemptibleTask{resumedAfter: func() bool { return true }}
+				tsk = long
+				id, err = w.SubmitTask(tsk)
+				require.Eventually(t, func() bool { return long.executions.Load() == 1 }, 5*time.Second, 10*time.Millisecond, "Setup: task was never dequeued")
+			}
+			require.NoError(t, err, "Setup: SubmitTask should return no error")
+
+			err = w.Cancel(id)
+			if tc.wantErr {
+				require.ErrorIs(t, err, worker.ErrTaskNotFound, "Cancel should return ErrTaskNotFound")
+				return
+			}
+			require.NoError(t, err, "Cancel should return no error")
+
+			// Tasks that do not reach a safe point are cancelled after the checkpoint timeout.
+			deadline := time.After(10 * time.Second)
+			for {
+				var ev worker.Event
+				select {
+				case ev = <-events:
+				case <-deadline:
+					require.Fail(t, "The cancelled task should have failed")
+				}
+				if ev.Task != fmt.Sprint(tsk) || ev.Type != worker.EventFailed {
+					continue
+				}
+				require.Contains(t, ev.Reason, task.ErrCancelled.Error(), "The failure should be due to the cancellation")
+				break
+			}
+
+			require.Eventually(t, func() bool { return w.CheckTotalTaskCount(0) == nil }, 5*time.Second, 10*time.Millisecond, "Cancelled tasks should not be retried")
+			require.ErrorIs(t, w.Cancel(id), worker.ErrTaskNotFound, "Cancelling a task twice should return ErrTaskNotFound")
+
+			if tc.queued {
+				require.False(t, completedEmptyTasks.Has(t.Name()), "Queued tasks should not run once cancelled")
+				require.Empty(t, d.taskHistory(), "Tasks that never started should not be in the history")
+				return
+			}
+			require.Eventually(t, func() bool { return len(d.taskHistory()) == 1 }, 5*time.Second, 10*time.Millisecond, "The cancelled task should be in the history")
+			require.Contains(t, d.taskHistory()[0].Error, task.ErrCancelled.Error(), "The history should record the cancellation")
+		})
+	}
+}
+
+func TestTaskInterruption(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		reconnectMidTask bool
+		submitEquivalent bool
+
+		wantExecutions int32
+	}{
+		"Success resuming an interrupted task once the distro connects again":          {wantExecutions: 2},
+		"Success resuming an interrupted task when the distro connected again already": {reconnectMidTask: true, wantExecutions: 2},
+		"Success dropping an interrupted task when an equivalent one is queued":        {submitEquivalent: true, wantExecutions: 1},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{
+				name: wsltestutils.RandomDistroName(t),
+			}
+
+			w, err := worker.New(ctx, d, t.TempDir())
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			conn := &mockConnection{}
+			w.SetConnection(conn)
+
+			events := w.WatchTasks(ctx)
+
+			interrupted := &interruptibleTask{ID: "interrupted", release: make(chan struct{})}
+			err = w.SubmitTasks(interrupted)
+			require.NoError(t, err, "SubmitTasks should return no error")
+			require.Eventually(t, func() bool { return interrupted.executions.Load() == 1 }, 5*time.Second, 100*time.Millisecond, "Task was never dequeued")
+
+			equivalent := &interruptibleTask{ID: "interrupted"}
+			if tc.submitEquivalent {
+				err = w.SubmitTasks(equivalent)
+				require.NoError(t, err, "SubmitTasks should return no error")
+			}
+
+			// The distro disconnects mid-task.
+			if tc.reconnectMidTask {
+				w.SetConnection(&mockConnection{})
+			} else {
+				w.SetConnection(nil)
+			}
+			close(interrupted.release)
+
+			requireEvent := func(want worker.EventType) {
+				t.Helper()
+				for {
+					select {
+					case ev := <-events:
+						if ev.Task != interrupted.String() || ev.Type != want {
+							continue
+						}
+						if want == worker.EventInterrupted {
+							require.ErrorContains(t, errors.New(ev.Reason), task.ErrInterrupted.Error(), "Interruption should give the reason")
+						}
+						return
+					case <-time.After(10 * time.Second):
+						require.Failf(t, "Timed out waiting for task event", "Event %s never received", want)
+					}
+				}
+			}
+			requireEvent(worker.EventInterrupted)
+
+			if tc.submitEquivalent {
+				w.SetConnection(&mockConnection{})
+				require.Eventually(t, equivalent.completed.Load, 5*time.Second, 100*time.Millisecond, "Equivalent task should have been executed")
+				require.Eventually(t, func() bool { return w.CheckTotalTaskCount(0) == nil }, 5*time.Second, 100*time.Millisecond, "Interrupted task should have been dropped in favour of the equivalent one")
+				require.Equal(t, tc.wantExecutions, interrupted.executions.Load(), "Interrupted task should not have been resumed")
+				return
+			}
+
+			requireEvent(worker.EventQueued)
+
+			if !tc.reconnectMidTask {
+				require.NoError(t, w.CheckQueuedTaskCount(0), "Interrupted task should not wake the distro up")
+				require.NoError(t, w.CheckTotalTaskCount(1), "Interrupted task should have been deferred")
+				time.Sleep(500 * time.Millisecond)
+				require.Equal(t, int32(1), interrupted.executions.Load(), "Interrupted task should not be resumed before the distro connects again")
+
+				// The distro connects again.
+				w.EnqueueDeferredTasks()
+				w.SetConnection(&mockConnection{})
+			}
+
+			require.Eventually(t, interrupted.completed.Load, 5*time.Second, 100*time.Millisecond, "Interrupted task should have been resumed")
+			require.Equal(t, tc.wantExecutions, interrupted.executions.Load(), "Interrupted task should have been executed again")
+			require.Eventually(t, func() bool { return w.CheckTotalTaskCount(0) == nil }, 5*time.Second, 100*time.Millisecond, "No tasks should remain in storage")
+		})
+	}
+}
+
+func TestConsentPreemption(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{
+		name:           wsltestutils.RandomDistroName(t),
+		ConsentGranted: true,
+		ConsentBlocks:  true,
+	}
+
+	w, err := worker.New(ctx, d, t.TempDir())
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	w.SetConnection(&mockConnection{})
+
+	events := w.WatchTasks(ctx)
+
+	consent := &consentTask{prompt: "do something"}
+	err = w.SubmitTasks(consent)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Eventually(t, func() bool { return d.consentAsks.Load() == 1 }, 5*time.Second, 100*time.Millisecond, "Consent was never requested")
+
+	// Nobody answers the request, yet tasks with high priority of the same lane do not wait for it
+	high := &priorityTask{}
+	err = w.SubmitTasks(high)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Eventually(t, high.executed.Load, 5*time.Second, 100*time.Millisecond, "Task with high priority should not wait for the request for consent")
+
+	// The preempted task asks again once it is resumed
+	var got []worker.EventType
+	for len(got) == 0 || (got[len(got)-1] != worker.EventCompleted && got[len(got)-1] != worker.EventFailed) {
+		select {
+		case e := <-events:
+			if e.Task == consent.String() {
+				got = append(got, e.Type)
+			}
+		case <-time.After(10 * time.Second):
+			require.Fail(t, "Timed out waiting for the task to finish")
+		}
+	}
+	require.Equal(t, []worker.EventType{worker.EventQueued, worker.EventStarted, worker.EventQueued, worker.EventStarted, worker.EventProgress, worker.EventCompleted}, got, "Preempted task should have been requeued and completed")
+	require.Equal(t, int32(2), d.consentAsks.Load(), "Consent should have been requested again when the task was resumed")
+}
+
+func TestTaskLanes(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{
+		name: wsltestutils.RandomDistroName(t),
+	}
+
+	w, err := worker.New(ctx, d, t.TempDir())
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	conn := &mockConnection{}
+	w.SetConnection(conn)
+
+	long := newBlockingTask(ctx)
+	defer long.complete()
+	err = w.SubmitTasks(long)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Eventually(t, long.executing.Load, 5*time.Second, 100*time.Millisecond, "Long task was never dequeued")
+
+	// Tasks in the same lane wait for the task in progress
+	sameLane := newBlockingTask(ctx)
+	defer sameLane.complete()
+	err = w.SubmitTasks(sameLane)
+	require.NoError(t, err, "SubmitTasks should return no error")
+
+	// Tasks in other lanes run concurrently, and keep their order within their lane
+	first := &lanedTask{blockingTask: newBlockingTask(ctx), lane: "other"}
+	defer first.complete()
+	second := &lanedTask{blockingTask: newBlockingTask(ctx), lane: "other", priority: task.PriorityHigh}
+	defer second.complete()
+
+	err = w.SubmitTasks(first)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Eventually(t, first.executing.Load, 5*time.Second, 100*time.Millisecond, "Task in another lane should not wait for the task in progress")
+
+	// Tasks with high priority only preempt the task in progress in their lane
+	err = w.SubmitTasks(second)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Equal(t, int32(1), conn.preemptCount.Load(), "Task with high priority should preempt the task in progress in its lane")
+	conn.mu.Lock()
+	require.Equal(t, []task.Lane{"other"}, conn.preempted, "Task with high priority should only preempt its own lane")
+	conn.mu.Unlock()
+
+	first.complete()
+	require.Eventually(t, second.executing.Load, 5*time.Second, 100*time.Millisecond, "Next task in the lane should start when the lane is free")
+	require.False(t, sameLane.executing.Load(), "Task in the same lane as the task in progress should wait for it")
+	second.complete()
+
+	long.complete()
+	require.Eventually(t, sameLane.executing.Load, 5*time.Second, 100*time.Millisecond, "Queued task should start when its lane is free")
+	sameLane.complete()
+
+	require.Eventually(t, func() bool { return w.CheckTotalTaskCount(0) == nil }, 5*time.Second, 100*time.Millisecond, "No tasks should remain in storage")
+}
+
+func TestTaskConsent(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		prompt     string
+		granted    bool
+		consentErr bool
+
+		wantEvent worker.EventType
+	}{
+		"Success running a task that does not need consent": {wantEvent: worker.EventCompleted},
+		"Success running a task the user consents to":       {prompt: "do something", granted: true, wantEvent: worker.EventCompleted},
+
+		"Error when the user does not consent to the task": {prompt: "do something", wantEvent: worker.EventFailed},
+		"Error when consent cannot be requested":           {prompt: "do something", granted: true, consentErr: true, wantEvent: worker.EventFailed},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{
+				name:           wsltestutils.RandomDistroName(t),
+				ConsentGranted: tc.granted,
+			}
+			if tc.consentErr {
+				d.ConsentError = errors.New("mock error")
+			}
+
+			w, err := worker.New(ctx, d, t.TempDir())
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			w.SetConnection(&mockConnection{})
+			events := w.WatchTasks(ctx)
+
+			err = w.SubmitTasks(&consentTask{prompt: tc.prompt})
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			var got worker.Event
+			for got.Type != worker.EventCompleted && got.Type != worker.EventFailed {
+				select {
+				case got = <-events:
+				case <-time.After(10 * time.Second):
+					require.Fail(t, "Timed out waiting for the task to finish")
+				}
+			}
+			require.Equal(t, tc.wantEvent, got.Type, "Unexpected outcome of the task")
+
+			if tc.prompt == "" {
+				require.Nil(t, d.consentPrompt.Load(), "Consent should not be requested for tasks without a prompt")
+				return
+			}
+			require.Equal(t, tc.prompt, d.consentPrompt.Load(), "Consent should be requested with the prompt of the task")
+
+			if tc.wantEvent == worker.EventFailed {
+				require.Contains(t, got.Reason, task.ErrConsentDenied.Error(), "Task should fail because consent was not granted")
+			}
+		})
+	}
+}
+
+func TestTaskUnsupported(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		unsupported    bool
+		wsl1           bool
+		needsProClient bool
+		needsWSL2      bool
+
+		wantEvent worker.EventType
+		wantErr   error
+	}{
+		"Success running a task that needs the Pro client in a supported distro": {needsProClient: true, wantEvent: worker.EventCompleted},
+		"Success running a task that does not need the Pro client in any distro": {unsupported: true, wantEvent: worker.EventCompleted},
+		"Success running a task that needs WSL 2 in a distro on WSL 2":           {needsWSL2: true, wantEvent: worker.EventCompleted},
+		"Success running a task that does not need WSL 2 in a distro on WSL 1":   {wsl1: true, wantEvent: worker.EventCompleted},
+
+		"Error when a task that needs the Pro client runs in an unsupported distro": {unsupported: true, needsProClient: true, wantEvent: worker.EventFailed, wantErr: task.ErrUnsupported},
+		"Error when a task that needs WSL 2 runs in a distro on WSL 1":              {wsl1: true, needsWSL2: true, wantEvent: worker.EventFailed, wantErr: task.ErrNeedsWSL2},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+			if tc.unsupported {
+				d.unsupportedReason = "the Ubuntu Pro client is not installed"
+			}
+			d.wslVersion = 2
+			if tc.wsl1 {
+				d.wslVersion = 1
+			}
+
+			w, err := worker.New(ctx, d, t.TempDir())
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			w.SetConnection(&mockConnection{})
+			events := w.WatchTasks(ctx)
+
+			err = w.SubmitTasks(&proClientTask{needsProClient: tc.needsProClient, needsWSL2: tc.needsWSL2})
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			var got worker.Event
+			for got.Type != worker.EventCompleted && got.Type != worker.EventFailed {
+				select {
+				case got = <-events:
+				case <-time.After(10 * time.Second):
+					require.Fail(t, "Timed out waiting for the task to finish")
+				}
+			}
+			require.Equal(t, tc.wantEvent, got.Type, "Unexpected outcome of the task")
+
+			if tc.wantEvent == worker.EventFailed {
+				require.Contains(t, got.Reason, tc.wantErr.Error(), "Task should fail because the distro is unsupported")
+				require.Contains(t, got.Reason, d.unsupportedReason, "Task should fail with the reason the distro is unsupported")
+				require.Eventually(t, func() bool { return w.CheckTotalTaskCount(0) == nil }, 5*time.Second, 100*time.Millisecond,
+					"Tasks skipped in unsupported distros should not be retried")
+			}
+		})
+	}
+}
+
+func TestWatchTasks(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		taskErr        bool
+		stepped        bool
+		cancelWatch    bool
+		stopWorker     bool
+		watchAfterStop bool
+
+		wantEvents []worker.Event
+	}{
+		"Success watching a task that completes": {wantEvents: []worker.Event{
+			{Type: worker.EventQueued, Task: "Progress task"},
+			{Type: worker.EventStarted, Task: "Progress task"},
+			{Type: worker.EventProgress, Task: "Progress task", Progress: 50},
+			{Type: worker.EventCompleted, Task: "Progress task"},
+		}},
+		"Success watching a task that fails": {taskErr: true, wantEvents: []worker.Event{
+			{Type: worker.EventQueued, Task: "Progress task"},
+			{Type: worker.EventStarted, Task: "Progress task"},
+			{Type: worker.EventProgress, Task: "Progress task", Progress: 50},
+			{Type: worker.EventFailed, Task: "Progress task", Reason: `distro %q: task "Progress task" failed: mock error`},
+		}},
+		"Success watching a multi-step task that completes": {stepped: true, wantEvents: []worker.Event{
+			{Type: worker.EventQueued, Task: "Stepped task"},
+			{Type: worker.EventStarted, Task: "Stepped task"},
+			{Type: worker.EventCompleted, Task: "Stepped task", Steps: []task.Step{
+				{Name: "first", Status: task.StepSucceeded},
+				{Name: "second", Status: task.StepSucceeded},
+				{Name: "third", Status: task.StepSucceeded},
+			}},
+		}},
+		"Success watching a multi-step task that partially fails": {stepped: true, taskErr: true, wantEvents: []worker.Event{
+			{Type: worker.EventQueued, Task: "Stepped task"},
+			{Type: worker.EventStarted, Task: "Stepped task"},
+			{Type: worker.EventFailed, Task: "Stepped task", Reason: `distro %q: task "Stepped task" failed: mock error`, Steps: []task.Step{
+				{Name: "first", Status: task.StepSucceeded},
+				{Name: "second", Status: task.StepFailed, Message: "mock error"},
+				{Name: "third", Status: task.StepSkipped, Message: "the second step failed"},
+			}},
+		}},
+		"Success with no events after the watch is cancelled":   {cancelWatch: true},
+		"Success with no events after the worker is stopped":    {stopWorker: true},
+		"Success with no events when watching a stopped worker": {watchAfterStop: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{
+				name: wsltestutils.RandomDistroName(t),
+			}
+
+			w, err := worker.New(ctx, d, t.TempDir())
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			w.SetConnection(&mockConnection{})
+
+			watchCtx, cancelWatch := context.WithCancel(ctx)
+			defer cancelWatch()
+
+			if tc.watchAfterStop {
+				w.Stop(ctx)
+			}
+
+			events := w.WatchTasks(watchCtx)
+
+			if tc.cancelWatch {
+				cancelWatch()
+			}
+			if tc.stopWorker {
+				// Stop waits for the watches to be done, so it would hang if they outlived the worker.
+				w.Stop(ctx)
+			}
+
+			if tc.cancelWatch || tc.stopWorker || tc.watchAfterStop {
+				require.Eventually(t, func() bool {
+					select {
+					case _, ok := <-events:
+						return !ok
+					default:
+						return false
+					}
+				}, 5*time.Second, 100*time.Millisecond, "Event channel should have been closed")
+				return
+			}
+
+			var taskErr error
+			if tc.taskErr {
+				taskErr = errors.New("mock error")
+			}
+
+			var tsk task.Task = &progressTask{Returns: taskErr}
+			if tc.stepped {
+				tsk = &steppedTask{Returns: taskErr}
+			}
+
+			err = w.SubmitTasks(tsk)
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			for i := range tc.wantEvents {
+				if tc.wantEvents[i].Reason != "" {
+					tc.wantEvents[i].Reason = fmt.Sprintf(tc.wantEvents[i].Reason, d.name)
+				}
+			}
+
+			var got []worker.Event
+			for range tc.wantEvents {
+				select {
+				case ev := <-events:
+					got = append(got, ev)
+				case <-time.After(10 * time.Second):
+					require.Fail(t, "Timed out waiting for task events", "Received so far: %v", got)
+				}
+			}
+
+			require.Equal(t, tc.wantEvents, got, "Unexpected task events")
+		})
+	}
+}
+
+func TestWatchTasksProgress(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{
+		name: wsltestutils.RandomDistroName(t),
+	}
+
+	w, err := worker.New(ctx, d, t.TempDir())
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	w.SetConnection(&mockConnection{})
+
+	receive := func(events <-chan worker.Event, n int) (got []worker.Event) {
+		t.Helper()
+		for range n {
+			select {
+			case ev := <-events:
+				got = append(got, ev)
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "Timed out waiting for task events", "Received so far: %v", got)
+			}
+		}
+		return got
+	}
+
+	events := w.WatchTasks(ctx)
+
+	tsk := &steppedProgressTask{blockingTask: newBlockingTask(ctx)}
+	err = w.SubmitTasks(tsk)
+	require.NoError(t, err, "SubmitTasks should return no error")
+
+	wantProgress := worker.Event{Type: worker.EventProgress, Task: "Stepped progress task", Progress: 50, Step: "second"}
+	require.Equal(t, []worker.Event{
+		{Type: worker.EventQueued, Task: "Stepped progress task"},
+		{Type: worker.EventStarted, Task: "Stepped progress task"},
+		{Type: worker.EventProgress, Task: "Stepped progress task", Progress: 50, Step: "first"},
+		wantProgress,
+	}, receive(events, 4), "Progress should never go back, only the step should change")
+
+	lateEvents := w.WatchTasks(ctx)
+	require.Equal(t, []worker.Event{wantProgress}, receive(lateEvents, 1), "Watchers should learn how far the task in progress is as they start watching")
+
+	tsk.complete()
+	require.Equal(t, []worker.Event{{Type: worker.EventCompleted, Task: "Stepped progress task"}}, receive(events, 1), "Task should have completed")
+
+	select {
+	case ev := <-w.WatchTasks(ctx):
+		require.Fail(t, "Watchers should not learn about the progress of tasks that finished", "Received: %v", ev)
+	default:
+	}
+}
+
+func TestTaskHistory(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		taskErr bool
+
+		wantError string
+		wantSteps []task.Step
+	}{
+		"Success recording a task that completes": {wantSteps: []task.Step{
+			{Name: "first", Status: task.StepSucceeded},
+			{Name: "second", Status: task.StepSucceeded},
+			{Name: "third", Status: task.StepSucceeded},
+		}},
+		"Success recording a task that fails": {taskErr: true, wantError: `distro %q: task "Stepped task" failed: mock error`, wantSteps: []task.Step{
+			{Name: "first", Status: task.StepSucceeded},
+			{Name: "second", Status: task.StepFailed, Message: "mock error"},
+			{Name: "third", Status: task.StepSkipped, Message: "the second step failed"},
+		}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{
+				name: wsltestutils.RandomDistroName(t),
+			}
+
+			w, err := worker.New(ctx, d, t.TempDir())
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			w.SetConnection(&mockConnection{})
+
+			var taskErr error
+			if tc.taskErr {
+				taskErr = errors.New("mock error")
+			}
+
+			before := time.Now().Truncate(time.Second)
+			err = w.SubmitTasks(&steppedTask{Returns: taskErr})
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			require.Eventually(t, func() bool { return len(d.taskHistory()) > 0 }, 5*time.Second, 100*time.Millisecond, "The task should have been added to the history")
+
+			history := d.taskHistory()
+			require.Len(t, history, 1, "Only the task that ran should be in the history")
+
+			got := history[0]
+			require.Equal(t, "Stepped task", got.Task, "Unexpected description of the task in the history")
+			require.Equal(t, "*worker_test.steppedTask", got.Type, "Unexpected type of the task in the history")
+			require.False(t, got.Started.Before(before), "The task should not have started before it was submitted")
+			require.False(t, got.Finished.Before(got.Started), "The task should not have finished before it started")
+			require.Equal(t, tc.wantSteps, got.Steps, "Unexpected steps of the task in the history")
+
+			if tc.wantError != "" {
+				tc.wantError = fmt.Sprintf(tc.wantError, d.name)
+			}
+			require.Equal(t, tc.wantError, got.Error, "Unexpected error of the task in the history")
+		})
+	}
+}
+
+func TestCleanupStorage(t *testing.T) {
+	t.Parallel()
+
+	const retention = time.Hour
+
+	testCases := map[string]struct {
+		files        map[string]string
+		staleFiles   []string
+		retention    time.Duration
+		missingDir   bool
+		breakReadDir bool
+
+		wantRemaining []string
+		wantReclaimed int64
+		wantErr       bool
+	}{
+		"Success with no task storage":             {files: map[string]string{"database.yaml": "hello"}, wantRemaining: []string{"database.yaml"}},
+		"Success with a missing storage directory": {missingDir: true},
+
+		"Keeps storage of known distros":   {files: map[string]string{"known.tasks": "1234"}, wantRemaining: []string{"known.tasks"}},
+		"Keeps storage regardless of case": {files: map[string]string{"KNOWN.tasks": "1234"}, wantRemaining: []string{"KNOWN.tasks"}},
+		"Removes storage of unknown distros": {
+			files:         map[string]string{"known.tasks": "1234", "orphan.tasks": "12345", "orphan.tasks.new": "123", "other.yaml": "1"},
+			wantRemaining: []string{"known.tasks", "other.yaml"},
+			wantReclaimed: 8,
+		},
+		"Removes task storage directories of unknown distros": {
+			files:         map[string]string{"orphan.tasks/a": "12", "orphan.tasks/b/c": "123"},
+			wantReclaimed: 5,
+		},
+		"Removes stale storage of known distros": {
+			files:         map[string]string{"known.tasks": "1234", "known.tasks.new": "12"},
+			staleFiles:    []string{"known.tasks.new"},
+			wantRemaining: []string{"known.tasks"},
+			wantReclaimed: 2,
+		},
+		"Keeps stale storage when retention is disabled": {
+			files:         map[string]string{"known.tasks": "1234"},
+			staleFiles:    []string{"known.tasks"},
+			retention:     -1,
+			wantRemaining: []string{"known.tasks"},
+		},
+
+		"Error when the storage directory cannot be read": {breakReadDir: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			dir := t.TempDir()
+
+			for path, contents := range tc.files {
+				path = filepath.Join(dir, path)
+				err := os.MkdirAll(filepath.Dir(path), 0700)
+				require.NoError(t, err, "Setup: could not create directory")
+				err = os.WriteFile(path, []byte(contents), 0600)
+				require.NoError(t, err, "Setup: could not write file")
+			}
+
+			old := time.Now().Add(-2 * retention)
+			for _, path := range tc.staleFiles {
+				err := os.Chtimes(filepath.Join(dir, path), old, old)
+				require.NoError(t, err, "Setup: could not change file modification time")
+			}
+
+			storageDir := dir
+			if tc.missingDir {
+				storageDir = filepath.Join(dir, "does-not-exist")
+			}
+			if tc.breakReadDir {
+				storageDir = filepath.Join(dir, "not-a-directory")
+				err := os.WriteFile(storageDir, nil, 0600)
+				require.NoError(t, err, "Setup: could not write file")
+			}
+
+			if tc.retention == 0 {
+				tc.retention = retention
+			}
+
+			isKnown := func(name string) bool { return strings.EqualFold(name, "known") }
+
+			reclaimed, err := worker.CleanupStorage(ctx, storageDir, isKnown, tc.retention)
+			if tc.wantErr {
+				require.Error(t, err, "CleanupStorage should have returned an error")
+				return
+			}
+			require.NoError(t, err, "CleanupStorage should have returned no error")
+			require.Equal(t, tc.wantReclaimed, reclaimed, "Mismatch in reclaimed space")
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err, "Could not read the storage directory")
+
+			var remaining []string
+			for _, e := range entries {
+				remaining = append(remaining, e.Name())
+			}
+			require.ElementsMatch(t, tc.wantRemaining, remaining, "Mismatch in the remaining storage")
+		})
+	}
+}
+
+func TestRenameStorage(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		files        map[string]string
+		noStorageDir bool
+
+		wantFiles map[string]string
+		wantErr   bool
+	}{
+		"Success moving the task storage": {
+			files:     map[string]string{"old.tasks": "tasks", "other.tasks": "other"},
+			wantFiles: map[string]string{"new.tasks": "tasks", "other.tasks": "other"},
+		},
+		"Success replacing the storage of the new name": {
+			files:     map[string]string{"old.tasks": "tasks", "new.tasks": "stale"},
+			wantFiles: map[string]string{"new.tasks": "tasks"},
+		},
+		"Success removing leftovers of interrupted writes": {
+			files:     map[string]string{"old.tasks": "tasks", "old.tasks.new": "partial"},
+			wantFiles: map[string]string{"new.tasks": "tasks"},
+		},
+		"Success with no task storage":      {files: map[string]string{"other.tasks": "other"}, wantFiles: map[string]string{"other.tasks": "other"}},
+		"Success with no storage directory": {noStorageDir: true},
+
+		"Error when the storage cannot be moved": {files: map[string]string{"old.tasks": "tasks", "new.tasks/file": "blocking"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			for path, contents := range tc.files {
+				path = filepath.Join(dir, path)
+				err := os.MkdirAll(filepath.Dir(path), 0700)
+				require.NoError(t, err, "Setup: could not create directory")
+				err = os.WriteFile(path, []byte(contents), 0600)
+				require.NoError(t, err, "Setup: could not write file")
+			}
+
+			storageDir := dir
+			if tc.noStorageDir {
+				storageDir = ""
+			}
+
+			err := worker.RenameStorage(storageDir, "old", "new")
+			if tc.wantErr {
+				require.Error(t, err, "RenameStorage should have returned an error")
+				return
+			}
+			require.NoError(t, err, "RenameStorage should have returned no error")
+
+			got := make(map[string]string)
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err, "Could not read the storage directory")
+			for _, e := range entries {
+				out, err := os.ReadFile(filepath.Join(dir, e.Name()))
+				require.NoError(t, err, "Could not read remaining file %q", e.Name())
+				got[e.Name()] = string(out)
+			}
+
+			if tc.wantFiles == nil {
+				tc.wantFiles = map[string]string{}
+			}
+			require.Equal(t, tc.wantFiles, got, "Mismatch in the task storage after renaming")
+		})
+	}
+}
+
+// benchmarkTasks is the number of tasks queued in the benchmarks, as when a fleet-wide operation targets a large fleet.
+const benchmarkTasks = 10000
+
+func TestPerformanceBudgets(t *testing.T) {
+	testCases := map[string]struct {
+		benchmark func(*testing.B)
+		budget    time.Duration
+	}{
+		"Submitting a task to a queue of 10k tasks": {benchmark: BenchmarkSubmitTasks, budget: time.Second},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			testutils.RequirePerformanceBudget(t, tc.budget, tc.benchmark)
+		})
+	}
+}
+
+func BenchmarkSubmitTasks(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// We pass a cancelled context so that no tasks are popped
+	// and the queue keeps the same length.
+	cancel()
+
+	distro := &testDistro{name: wsltestutils.RandomDistroName(b)}
+
+	w, err := worker.New(ctx, distro, b.TempDir())
+	require.NoError(b, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	tasks := make([]task.Task, 0, benchmarkTasks)
+	for i := range benchmarkTasks {
+		tasks = append(tasks, emptyTask{ID: strconv.Itoa(i)})
+	}
+	require.NoError(b, w.SubmitTasks(tasks...), "Setup: could not fill up the queue")
+
+	// Submitting a task equivalent to a queued one replaces it, so the queue does not grow.
+	b.ResetTimer()
+	for i := range b.N {
+		require.NoError(b, w.SubmitTasks(emptyTask{ID: strconv.Itoa(i % benchmarkTasks)}), "SubmitTasks should return no error")
+	}
+	b.StopTimer()
+
+	require.NoError(b, w.CheckQueuedTaskCount(benchmarkTasks), "Submitting the tasks again should not have grown the queue")
+}
+
+func requireEventuallyTaskCompletes(t *testing.T, task emptyTask, msg string, args ...any) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return completedEmptyTasks.Has(task.ID)
+	}, 5*time.Second, 100*time.Millisecond, msg, args)
+}
+
+// completedEmptyTasks tracks which empty tasks have completed. We need to use this global
+// variable because tasks may be written to file and read back, so no callbacks or pointers
+// can be used.
+var completedEmptyTasks = testutils.NewSet[string]()
+
+type emptyTask struct {
+	ID string
+}
+
+func (t emptyTask) Execute(ctx context.Context, _ task.Connection) error {
+	completedEmptyTasks.Set(t.ID)
+	return nil
+}
+
+func (t emptyTask) String() string {
+	return "Empty test task"
+}
+
+// sleepyTask is an empty task that does not wake its distro up.
+type sleepyTask struct {
+	emptyTask
+}
+
+func (t sleepyTask) WakesDistro() bool {
+	return false
+}
+
+// hostTask is an empty task that runs on the host. It only completes if it is executed without a connection,
+// knowing the name of its distro.
+type hostTask struct {
+	emptyTask
+	Distro string
+}
+
+func (t hostTask) Execute(ctx context.Context, conn task.Connection) error {
+	if conn != nil || task.DistroName(ctx) != t.Distro {
+		return errors.New("host task executed with a connection or for the wrong distro")
+	}
+	return t.emptyTask.Execute(ctx, conn)
+}
+
+func (t hostTask) RunsOnHost() bool {
+	return true
+}
+
+type testTask struct {
+	// ExecuteCalls counts the number of times Execute is called
+	ExecuteCalls atomic.Int32
+
+	// Delay simulates a processing time for the task
+	Delay time.Duration
+
+	// Returns is the value that Execute will return
+	Returns error
+
+	// WasCancelled is true if the task Execute context is Done
+	WasCancelled atomic.Bool
+
+	ID string
+}
+
+// MarshalYAML is necessary to avoid races between Execute and Save.
+func (t *testTask) MarshalYAML() (interface{}, error) {
 	return struct {
 		ID      string
 		Delay   time.Duration
@@ -661,6 +2150,410 @@ func (t *testTask) Is(other task.Task) bool {
 	return t.ID == o.ID
 }
 
+// priorityTask is a task with high priority.
+type priorityTask struct {
+	executed atomic.Bool
+}
+
+// MarshalYAML is necessary to avoid races between Execute and Save.
+func (t *priorityTask) MarshalYAML() (interface{}, error) {
+	return struct{}{}, nil
+}
+
+func (t *priorityTask) Execute(ctx context.Context, _ task.Connection) error {
+	t.executed.Store(true)
+	return nil
+}
+
+func (t *priorityTask) Priority() task.Priority {
+	return task.PriorityHigh
+}
+
+func (t *priorityTask) String() string {
+	return "Priority task"
+}
+
+// preemptibleTask is a task that runs until it is preempted the first time, and completes
+// immediately when resumed.
+type preemptibleTask struct {
+	resumedAfter func() bool
+
+	executions       atomic.Int32
+	completed        atomic.Bool
+	resumedAfterHigh atomic.Bool
+}
+
+// MarshalYAML is necessary to avoid races between Execute and Save.
+func (t *preemptibleTask) MarshalYAML() (interface{}, error) {
+	return struct{}{}, nil
+}
+
+func (t *preemptibleTask) Execute(ctx context.Context, c task.Connection) error {
+	if t.executions.Add(1) > 1 {
+		t.resumedAfterHigh.Store(t.resumedAfter())
+		t.completed.Store(true)
+		return nil
+	}
+
+	conn, ok := c.(*mockConnection)
+	if !ok {
+		return fmt.Errorf("unexpected connection type %T", c)
+	}
+
+	// Mock a long task that checks for preemption at its safe points
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		if conn.preemptCount.Load() > 0 {
+			return fmt.Errorf("mock error: %w", task.ErrPreempted)
+		}
+	}
+}
+
+func (t *preemptibleTask) String() string {
+	return "Preemptible task"
+}
+
+// interruptibleTask is a task that is interrupted by its distro disconnecting the first time it is executed, once
+// release is closed, and that completes when resumed. Without release, it completes right away.
+type interruptibleTask struct {
+	ID      string
+	release chan struct{}
+
+	executions atomic.Int32
+	completed  atomic.Bool
+}
+
+// MarshalYAML is necessary to avoid races between Execute and Save.
+func (t *interruptibleTask) MarshalYAML() (interface{}, error) {
+	return struct{ ID string }{ID: t.ID}, nil
+}
+
+func (t *interruptibleTask) Execute(ctx context.Context, _ task.Connection) error {
+	if t.executions.Add(1) > 1 || t.release == nil {
+		t.completed.Store(true)
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.release:
+	}
+	return fmt.Errorf("mock error: %w", task.ErrInterrupted)
+}
+
+func (t *interruptibleTask) String() string {
+	return "Interruptible task"
+}
+
+func (t *interruptibleTask) Is(other task.Task) bool {
+	o, ok := other.(*interruptibleTask)
+	return ok && t.ID == o.ID
+}
+
+func TestTaskTransfers(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		capResetsIn time.Duration
+
+		wantEvents      []worker.EventType
+		wantTransferred uint64
+		wantQueued      bool
+	}{
+		"Success accounting for the transfers of a task":               {wantEvents: []worker.EventType{worker.EventCompleted}, wantTransferred: 1536},
+		"Success retrying a task once the daily transfer cap is reset": {capResetsIn: 500 * time.Millisecond, wantEvents: []worker.EventType{worker.EventFailed, worker.EventCompleted}, wantTransferred: 1536},
+
+		"Error when the distro reached its daily transfer cap": {capResetsIn: time.Hour, wantEvents: []worker.EventType{worker.EventFailed}, wantQueued: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+			if tc.capResetsIn > 0 {
+				d.transferCapReached = true
+				d.transferCapReset = time.Now().Add(tc.capResetsIn)
+			}
+
+			w, err := worker.New(ctx, d, t.TempDir())
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			w.SetConnection(&mockConnection{})
+			events := w.WatchTasks(ctx)
+
+			err = w.SubmitTasks(&transferTask{Reserve: 1024, Record: 512})
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			for _, want := range tc.wantEvents {
+				var got worker.Event
+				for got.Type != worker.EventCompleted && got.Type != worker.EventFailed {
+					select {
+					case got = <-events:
+					case <-time.After(10 * time.Second):
+						require.Fail(t, "Timed out waiting for the task to finish")
+					}
+				}
+				require.Equal(t, want, got.Type, "Unexpected outcome of the task")
+
+				if got.Type == worker.EventFailed {
+					require.Contains(t, got.Reason, bandwidth.ErrCapReached.Error(), "Task should fail because the cap is reached")
+					continue
+				}
+				require.False(t, time.Now().Before(d.transferCapReset), "Task should not be retried before the cap is reset")
+			}
+			require.Equal(t, tc.wantTransferred, d.transferred.Load(), "The transfers of the task should be accounted for by its distro")
+
+			if tc.wantQueued {
+				require.NoError(t, w.CheckTotalTaskCount(1), "The task should remain queued until the cap is reset")
+			}
+		})
+	}
+}
+
+// progressTask is a task that reports being half-way through before returning.
+type progressTask struct {
+	Returns error
+}
+
+// MarshalYAML is necessary to avoid races between Execute and Save.
+func (t *progressTask) MarshalYAML() (interface{}, error) {
+	return struct{}{}, nil
+}
+
+func (t *progressTask) Execute(ctx context.Context, _ task.Connection) error {
+	task.ReportProgress(ctx, 50)
+	return t.Returns
+}
+
+func (t *progressTask) String() string {
+	return "Progress task"
+}
+
+// steppedProgressTask is a blocking task that reports its progress through two steps before blocking, the second
+// one with a lower percentage than the first.
+type steppedProgressTask struct {
+	*blockingTask
+}
+
+func (t *steppedProgressTask) Execute(ctx context.Context, conn task.Connection) error {
+	task.ReportStepProgress(ctx, 50, "first")
+	task.ReportStepProgress(ctx, 30, "second")
+	return t.blockingTask.Execute(ctx, conn)
+}
+
+func (t *steppedProgressTask) String() string {
+	return "Stepped progress task"
+}
+
+// steppedTask is a task with three steps, the second of which returns the specified error.
+type steppedTask struct {
+	Returns error
+}
+
+// MarshalYAML is necessary to avoid races between Execute and Save.
+func (t *steppedTask) MarshalYAML() (interface{}, error) {
+	return struct{}{}, nil
+}
+
+func (t *steppedTask) Execute(ctx context.Context, _ task.Connection) error {
+	_ = task.RunStep(ctx, "first", func() error { return nil })
+	if err := task.RunStep(ctx, "second", func() error { return t.Returns }); err != nil {
+		task.SkipStep(ctx, "third", "the second step failed")
+		return err
+	}
+	return task.RunStep(ctx, "third", func() error { return nil })
+}
+
+func (t *steppedTask) String() string {
+	return "Stepped task"
+}
+
+// transferTask is a task that reserves a transfer of a known size, then records another one of the given size.
+type transferTask struct {
+	Reserve uint64
+	Record  uint64
+}
+
+// MarshalYAML is necessary to avoid races between Execute and Save.
+func (t *transferTask) MarshalYAML() (interface{}, error) {
+	return struct{}{}, nil
+}
+
+func (t *transferTask) Execute(ctx context.Context, _ task.Connection) error {
+	if err := task.ReserveTransfer(ctx, bandwidth.KindDebs, t.Reserve); err != nil {
+		return err
+	}
+	task.RecordTransfer(ctx, bandwidth.KindFiles, t.Record)
+	return nil
+}
+
+func (t *transferTask) String() string {
+	return "Transfer task"
+}
+
+// consentTask is a progress task that requires user confirmation when it has a prompt.
+type consentTask struct {
+	progressTask
+	prompt string
+}
+
+func (t *consentTask) ConsentPrompt() string {
+	return t.prompt
+}
+
+// proClientTask is a progress task that runs the Ubuntu Pro client if needsProClient is true, and needs WSL 2 if
+// needsWSL2 is true.
+type proClientTask struct {
+	progressTask
+	needsProClient bool
+	needsWSL2      bool
+}
+
+func (t *proClientTask) NeedsProClient() bool {
+	return t.needsProClient
+}
+
+func (t *proClientTask) NeedsWSL2() bool {
+	return t.needsWSL2
+}
+
+// lanedTask is a blocking task in a custom lane, with a custom priority.
+type lanedTask struct {
+	*blockingTask
+	lane     task.Lane
+	priority task.Priority
+}
+
+func (t *lanedTask) Lane() task.Lane {
+	return t.lane
+}
+
+func (t *lanedTask) Priority() task.Priority {
+	return t.priority
+}
+
+// retriedTask is a task that fails transiently the given number of times before succeeding, either with a
+// NeedsRetryError or by blocking until it times out. Wrap it in retriedTaskWithPolicy to declare its policy.
+type retriedTask struct {
+	failures int32
+	timeout  time.Duration
+	policy   task.RetryPolicy
+
+	executions atomic.Int32
+	starts     []time.Time
+	mu         sync.Mutex
+}
+
+// MarshalYAML is necessary to avoid races between Execute and Save.
+func (t *retriedTask) MarshalYAML() (interface{}, error) {
+	return struct{}{}, nil
+}
+
+func (t *retriedTask) Execute(ctx context.Context, _ task.Connection) error {
+	t.mu.Lock()
+	t.starts = append(t.starts, time.Now())
+	t.mu.Unlock()
+
+	if t.executions.Add(1) > t.failures {
+		return nil
+	}
+	if t.timeout > 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return task.NeedsRetryError{SourceErr: errors.New("mock error")}
+}
+
+// started returns when each execution started.
+func (t *retriedTask) started() []time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.starts)
+}
+
+func (t *retriedTask) String() string {
+	return "Retried task"
+}
+
+func (t *retriedTask) Timeout() time.Duration {
+	return t.timeout
+}
+
+// retriedTaskWithPolicy is a retriedTask that declares its retry policy.
+type retriedTaskWithPolicy struct {
+	*retriedTask
+}
+
+func (t retriedTaskWithPolicy) RetryPolicy() task.RetryPolicy {
+	return t.policy
+}
+
+// keyedTask is an empty task with an idempotency key and a priority.
+type keyedTask struct {
+	ID   string
+	Key  string
+	Prio task.Priority
+}
+
+func (t keyedTask) Execute(ctx context.Context, _ task.Connection) error {
+	completedEmptyTasks.Set(t.ID)
+	return nil
+}
+
+func (t keyedTask) IdempotencyKey() string {
+	return t.Key
+}
+
+func (t keyedTask) Priority() task.Priority {
+	return t.Prio
+}
+
+func (t keyedTask) String() string {
+	return "Keyed test task " + t.ID
+}
+
+// keyedBlockingTask is a blocking task with an idempotency key.
+type keyedBlockingTask struct {
+	*blockingTask
+	key string
+}
+
+func (t keyedBlockingTask) IdempotencyKey() string {
+	return t.key
+}
+
+// attachmentTask is a blocking task that behaves like tasks.ProAttachment: all of them are equivalent, so that the
+// last one submitted replaces those queued, but only those with the same token do the same thing.
+type attachmentTask struct {
+	*blockingTask
+	token string
+}
+
+func (t attachmentTask) Is(other task.Task) bool {
+	_, ok := other.(attachmentTask)
+	return ok
+}
+
+func (t attachmentTask) IdempotencyKey() string {
+	return "attachment:" + t.token
+}
+
+func (t attachmentTask) String() string {
+	return "Attachment task with token " + t.token
+}
+
 // blockingTask is a task that blocks execution until complete() is called.
 type blockingTask struct {
 	ctx       context.Context
@@ -701,10 +2594,28 @@ type testDistro struct {
 	// Change these freely to modify test behaviour
 	name    string      // The name of the distro
 	invalid atomic.Bool // Whether the distro is valid or not
+	stopped atomic.Bool // Whether LiveState reports the distro as stopped rather than running
 
 	// TODO: Is this used?
 	LockAwakeError error // LockAwake will throw this error (unless it is nil)
 
+	ConsentGranted bool  // RequestConsent will grant consent if true
+	ConsentError   error // RequestConsent will throw this error (unless it is nil)
+	ConsentBlocks  bool  // RequestConsent will wait until its context is done the first time if true
+
+	consentPrompt atomic.Value // The last prompt RequestConsent was called with
+	consentAsks   atomic.Int32 // How many times RequestConsent was called
+
+	unsupportedReason string // Why the Ubuntu Pro client cannot run in the distro, empty if it can
+	wslVersion        int    // The version of WSL the distro runs on, zero if unknown
+
+	transferCapReached bool          // ReserveTransfer will refuse every transfer if true, until transferCapReset
+	transferCapReset   time.Time     // When the daily transfer cap resets
+	transferred        atomic.Uint64 // The bytes ReserveTransfer and RecordTransfer accounted for
+
+	history   []worker.TaskRecord // The tasks AppendTaskHistory was called with
+	historyMu sync.Mutex
+
 	// Do not use directly
 	runningRefCount int
 	runningMu       sync.RWMutex
@@ -763,6 +2674,13 @@ func (d *testDistro) ReleaseAwake() error {
 	return nil
 }
 
+func (d *testDistro) LiveState() wsl.State {
+	if d.stopped.Load() {
+		return wsl.Stopped
+	}
+	return wsl.Running
+}
+
 func (d *testDistro) IsValid() bool {
 	return !d.invalid.Load()
 }
@@ -771,6 +2689,52 @@ func (d *testDistro) Invalidate(ctx context.Context) {
 	d.invalid.Store(true)
 }
 
+func (d *testDistro) RecordTaskResult(ctx context.Context, err error) {}
+
+func (d *testDistro) AppendTaskHistory(r worker.TaskRecord) {
+	d.historyMu.Lock()
+	defer d.historyMu.Unlock()
+
+	d.history = append(d.history, r)
+}
+
+// taskHistory returns a copy of the tasks AppendTaskHistory was called with.
+func (d *testDistro) taskHistory() []worker.TaskRecord {
+	d.historyMu.Lock()
+	defer d.historyMu.Unlock()
+
+	return slices.Clone(d.history)
+}
+
+func (d *testDistro) RequestConsent(ctx context.Context, prompt string) (bool, error) {
+	d.consentPrompt.Store(prompt)
+	if d.consentAsks.Add(1) == 1 && d.ConsentBlocks {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+	return d.ConsentGranted, d.ConsentError
+}
+
+func (d *testDistro) UnsupportedReason() string {
+	return d.unsupportedReason
+}
+
+func (d *testDistro) WslVersion() int {
+	return d.wslVersion
+}
+
+func (d *testDistro) ReserveTransfer(kind bandwidth.Kind, n uint64) error {
+	if d.transferCapReached && time.Now().Before(d.transferCapReset) {
+		return bandwidth.CapReachedError{Kind: kind, Needed: n, Reset: d.transferCapReset}
+	}
+	d.transferred.Add(n)
+	return nil
+}
+
+func (d *testDistro) RecordTransfer(kind bandwidth.Kind, n uint64) {
+	d.transferred.Add(n)
+}
+
 func taskfileFromTemplate[T task.Task](t *testing.T) []byte {
 	t.Helper()
 
@@ -791,7 +2755,13 @@ func taskfileFromTemplate[T task.Task](t *testing.T) []byte {
 type mockConnection struct {
 	proAttachmentCount   atomic.Int32
 	LandscapeConfigCount atomic.Int32
+	commandCount         atomic.Int32
+	preemptCount         atomic.Int32
 	closed               atomic.Bool
+
+	// preempted are the lanes that were preempted, in order.
+	preempted []task.Lane
+	mu        sync.Mutex
 }
 
 func (conn *mockConnection) SendProAttachment(proToken string) error {
@@ -804,6 +2774,33 @@ func (conn *mockConnection) SendLandscapeConfig(lpeConfig string) error {
 	return nil
 }
 
+func (conn *mockConnection) SendCommand(cmd *agentapi.Command) ([]byte, error) {
+	conn.commandCount.Add(1)
+	return nil, nil
+}
+
+func (conn *mockConnection) InLane(context.Context, task.Lane) task.Connection {
+	return conn
+}
+
+func (conn *mockConnection) Preempt(lane task.Lane) error {
+	conn.preemptCount.Add(1)
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.preempted = append(conn.preempted, lane)
+
+	return nil
+}
+
+func (conn *mockConnection) TailLog(ctx context.Context, cmd *agentapi.TailLogCmd, send func(string) error) error {
+	return nil
+}
+
+func (conn *mockConnection) Ping(ctx context.Context, payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
 func (conn *mockConnection) Close() {
 	conn.closed.Store(true)
 }
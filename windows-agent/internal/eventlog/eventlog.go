@@ -0,0 +1,105 @@
+// Package eventlog mirrors the log entries of the agent to the Application log of the Windows Event Log, where
+// administrators expect to find the errors of services.
+//
+// On Linux, where there is no Event Log, the entries are discarded.
+package eventlog
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Source is the name of the event source of the agent in the Event Log.
+	Source = "Ubuntu Pro for WSL"
+
+	// eventID is the ID of all the events of the agent. The source is registered with the message file of
+	// EventCreate, which accepts any ID from 1 to 1000 and displays the messages as they are.
+	eventID = 1
+)
+
+// writer writes events to the Event Log.
+type writer interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Error(eid uint32, msg string) error
+	Close() error
+}
+
+// Hook is a logrus hook mirroring the log entries at or above a level to the Event Log.
+// It is safe for concurrent use.
+type Hook struct {
+	w      writer
+	levels []logrus.Level
+
+	closed bool
+	mu     sync.Mutex
+}
+
+// New registers the event source of the agent, if it is not already, and returns a hook mirroring the entries at
+// or above level to the Event Log.
+func New(ctx context.Context, level logrus.Level) (*Hook, error) {
+	w, err := open(ctx, Source)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the Event Log: %v", err)
+	}
+	return newHook(w, level), nil
+}
+
+func newHook(w writer, level logrus.Level) *Hook {
+	var levels []logrus.Level
+	for _, l := range logrus.AllLevels {
+		if l <= level {
+			levels = append(levels, l)
+		}
+	}
+	return &Hook{w: w, levels: levels}
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook by writing the entry, with its fields, to the Event Log as an event of the type
+// matching its level. It is a no-op once the hook is closed.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	msg := entry.Message
+	for _, k := range slices.Sorted(maps.Keys(entry.Data)) {
+		msg += fmt.Sprintf(" %s=%v", k, entry.Data[k])
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+
+	switch {
+	case entry.Level <= logrus.ErrorLevel:
+		return h.w.Error(eventID, msg)
+	case entry.Level == logrus.WarnLevel:
+		return h.w.Warning(eventID, msg)
+	default:
+		return h.w.Info(eventID, msg)
+	}
+}
+
+// Close stops mirroring the entries and releases the Event Log. Logrus does not remove its hooks, so the hook stays
+// registered but does nothing from then on.
+func (h *Hook) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	return h.w.Close()
+}
@@ -0,0 +1,88 @@
+package eventlog_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/eventlog"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFire(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		level       logrus.Level
+		fields      logrus.Fields
+		closed      bool
+		breakWriter bool
+
+		wantEvent string
+		wantErr   bool
+	}{
+		"Success writing errors as errors":     {level: logrus.ErrorLevel, wantEvent: "error: something happened"},
+		"Success writing warnings as warnings": {level: logrus.WarnLevel, wantEvent: "warning: something happened"},
+		"Success writing infos as infos":       {level: logrus.InfoLevel, wantEvent: "info: something happened"},
+		"Success writing the fields sorted": {
+			level:     logrus.WarnLevel,
+			fields:    logrus.Fields{"b": 2, "a": "one"},
+			wantEvent: "warning: something happened a=one b=2",
+		},
+		"Success discarding entries once closed": {level: logrus.ErrorLevel, closed: true},
+
+		"Error when the Event Log cannot be written": {level: logrus.ErrorLevel, breakWriter: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &mockWriter{broken: tc.breakWriter}
+			h := eventlog.NewWithWriter(w, logrus.InfoLevel)
+			if tc.closed {
+				require.NoError(t, h.Close(), "Setup: Close should not fail")
+			}
+
+			err := h.Fire(&logrus.Entry{Level: tc.level, Message: "something happened", Data: tc.fields})
+			if tc.wantErr {
+				require.Error(t, err, "Fire should have failed")
+				return
+			}
+			require.NoError(t, err, "Fire should not fail")
+
+			if tc.wantEvent == "" {
+				require.Empty(t, w.events, "No event should have been written")
+				return
+			}
+			require.Equal(t, []string{tc.wantEvent}, w.events, "The event should have been written with the type of the level")
+		})
+	}
+}
+
+func TestLevels(t *testing.T) {
+	t.Parallel()
+
+	h := eventlog.NewWithWriter(&mockWriter{}, logrus.WarnLevel)
+	require.ElementsMatch(t, []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel}, h.Levels(),
+		"Only the entries at or above the level should be mirrored")
+}
+
+type mockWriter struct {
+	events []string
+	broken bool
+}
+
+func (m *mockWriter) write(kind, msg string) error {
+	if m.broken {
+		return errors.New("mock error")
+	}
+	m.events = append(m.events, fmt.Sprintf("%s: %s", kind, msg))
+	return nil
+}
+
+func (m *mockWriter) Info(_ uint32, msg string) error    { return m.write("info", msg) }
+func (m *mockWriter) Warning(_ uint32, msg string) error { return m.write("warning", msg) }
+func (m *mockWriter) Error(_ uint32, msg string) error   { return m.write("error", msg) }
+func (m *mockWriter) Close() error                       { return nil }
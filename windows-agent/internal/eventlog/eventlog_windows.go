@@ -0,0 +1,33 @@
+package eventlog
+
+import (
+	"context"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// sourcesKey is the registry key, in HKEY_LOCAL_MACHINE, where the event sources of the Application log are registered.
+const sourcesKey = `SYSTEM\CurrentControlSet\Services\EventLog\Application\`
+
+// open registers source if it is not already and opens the Event Log for it.
+func open(ctx context.Context, source string) (writer, error) {
+	// Registering requires administrator rights, which the agent usually does not have. Unregistered sources can still
+	// write events, which the Event Viewer then prefixes with a notice that their description is missing.
+	if err := register(source); err != nil {
+		log.Infof(ctx, "Event Log: could not register the event source %q: %v", source, err)
+	}
+
+	return eventlog.Open(source)
+}
+
+// register registers source with the message file of EventCreate, unless it is already registered.
+func register(source string) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, sourcesKey+source, registry.QUERY_VALUE)
+	if err == nil {
+		return k.Close()
+	}
+
+	return eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+}
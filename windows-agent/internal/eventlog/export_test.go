@@ -0,0 +1,11 @@
+package eventlog
+
+import "github.com/sirupsen/logrus"
+
+// Writer is the interface of the Event Log, exported for testing purposes.
+type Writer = writer
+
+// NewWithWriter returns a hook writing to w instead of the Event Log.
+func NewWithWriter(w Writer, level logrus.Level) *Hook {
+	return newHook(w, level)
+}
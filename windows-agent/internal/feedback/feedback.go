@@ -0,0 +1,247 @@
+// Package feedback packages the diagnostics of the agent into a bundle that can be attached to a
+// Feedback Hub report, and opens Feedback Hub so that users can file one.
+package feedback
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/journal"
+	"github.com/ubuntu/decorate"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// MetadataFileName is the name of the file in the bundle that describes its contents.
+	MetadataFileName = "metadata.json"
+
+	// DistrosFileName is the name of the file in the bundle with the properties of the distros in the database.
+	DistrosFileName = "distros.yaml"
+)
+
+// Metadata describes a bundle, so that reports can be triaged without opening every file.
+type Metadata struct {
+	Product  string    `json:"product"`
+	Version  string    `json:"version"`
+	Platform string    `json:"platform"`
+	Created  time.Time `json:"created"`
+
+	// Files are the paths of the diagnostics in the bundle. It is filled in by WriteBundle.
+	Files []string `json:"files"`
+}
+
+// diagnostics are the paths, relative to the public and private directories, that go in the bundle.
+// Anything that may hold secrets, such as the configuration, the task queues, the certificates or
+// the cloud-init data, is deliberately left out. The database and the journal share their store with
+// the task queues and the configuration, so only the properties of the distros and the events are bundled.
+var diagnostics = []struct {
+	inPublicDir bool
+	path        string
+}{
+	{inPublicDir: true, path: consts.LogFileName},
+	{inPublicDir: true, path: consts.LogFileName + ".old"},
+	{path: consts.ErrorRecordsDir},
+}
+
+// WriteBundle writes a zip archive with the diagnostics found in the public and private directories
+// into w, along with the distros in the database and its metadata. Diagnostics that do not exist are skipped.
+func WriteBundle(ctx context.Context, w io.Writer, publicDir, privateDir string, meta Metadata) (err error) {
+	defer decorate.OnError(&err, "could not write feedback bundle")
+
+	z := zip.NewWriter(w)
+
+	meta.Files = nil
+	for _, d := range diagnostics {
+		root := privateDir
+		if d.inPublicDir {
+			root = publicDir
+		}
+
+		files, err := addToBundle(z, root, d.path)
+		if err != nil {
+			return err
+		}
+		meta.Files = append(meta.Files, files...)
+	}
+
+	added, err := addDistros(z, privateDir)
+	if err != nil {
+		return err
+	}
+	if added {
+		meta.Files = append(meta.Files, DistrosFileName)
+	}
+
+	files, err := addJournal(z, privateDir)
+	if err != nil {
+		return err
+	}
+	meta.Files = append(meta.Files, files...)
+
+	log.Debugf(ctx, "Feedback: bundled %d files", len(meta.Files))
+
+	f, err := z.Create(MetadataFileName)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(meta); err != nil {
+		return err
+	}
+
+	return z.Close()
+}
+
+// addToBundle copies the file or directory at root/path into the archive, and returns the paths
+// of the files it added. A missing path is not an error.
+func addToBundle(z *zip.Writer, root, path string) (added []string, err error) {
+	err = filepath.WalkDir(filepath.Join(root, path), func(p string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		// Zip archives always use forward slashes.
+		rel = filepath.ToSlash(rel)
+
+		if err := copyToBundle(z, p, rel); err != nil {
+			return err
+		}
+		added = append(added, rel)
+		return nil
+	})
+
+	return added, err
+}
+
+// addDistros writes the properties of the distros in the database stored in privateDir into the archive,
+// and returns whether there were any. It reads a snapshot of the database, so the agent may be running.
+func addDistros(z *zip.Writer, privateDir string) (added bool, err error) {
+	props, err := database.ReadProperties(privateDir)
+	if err != nil {
+		return false, err
+	}
+	if len(props) == 0 {
+		return false, nil
+	}
+
+	out, err := yaml.Marshal(props)
+	if err != nil {
+		return false, err
+	}
+
+	f, err := z.Create(DistrosFileName)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := f.Write(out); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// addJournal writes the events of the journal into the archive, one JSON object per line, and returns
+// the paths of the files it added. It reads a snapshot of the store, so the agent may be running. The
+// journal file of older versions is bundled instead if the events were never migrated into the store.
+func addJournal(z *zip.Writer, privateDir string) (added []string, err error) {
+	snapshot, err := store.Snapshot(filepath.Join(privateDir, consts.StoreFileName))
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return addToBundle(z, privateDir, consts.JournalFileName)
+	}
+	defer snapshot.Close()
+
+	events, err := journal.Stored(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return addToBundle(z, privateDir, consts.JournalFileName)
+	}
+
+	f, err := z.Create(consts.JournalFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := json.NewEncoder(f)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return nil, err
+		}
+	}
+
+	return []string{consts.JournalFileName}, nil
+}
+
+// copyToBundle copies the file at path into the archive under the name dest.
+func copyToBundle(z *zip.Writer, path, dest string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = dest
+	header.Method = zip.Deflate
+
+	f, err := z.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, src)
+	return err
+}
+
+// URI returns the URI that opens Feedback Hub on a new problem report.
+func URI() string {
+	v := url.Values{}
+	v.Set("referrer", "UbuntuProForWSL")
+	v.Set("tabid", "2")
+	v.Set("newFeedback", "true")
+	v.Set("feedbackType", "2")
+
+	return "feedback-hub:?" + v.Encode()
+}
+
+// Open launches Feedback Hub on a new problem report.
+func Open(ctx context.Context) error {
+	return openURI(ctx, URI())
+}
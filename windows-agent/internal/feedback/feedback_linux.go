@@ -0,0 +1,13 @@
+package feedback
+
+import (
+	"context"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// openURI on Linux only logs the URI, as there is no Feedback Hub to open it.
+func openURI(ctx context.Context, uri string) error {
+	log.Infof(ctx, "Feedback: would open %s", uri)
+	return nil
+}
@@ -0,0 +1,170 @@
+package feedback_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/feedback"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/journal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBundle(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		publicFiles  []string
+		privateFiles []string
+		withDistros  bool
+		withJournal  bool
+		breakFile    string
+
+		wantFiles []string
+		wantErr   bool
+	}{
+		"Success with no diagnostics": {},
+		"Success with every diagnostic": {
+			publicFiles:  []string{consts.LogFileName, consts.LogFileName + ".old"},
+			privateFiles: []string{consts.JournalFileName, filepath.Join(consts.ErrorRecordsDir, "crash-1.txt")},
+			withDistros:  true,
+			wantFiles:    []string{consts.LogFileName, consts.LogFileName + ".old", feedback.DistrosFileName, consts.JournalFileName, consts.ErrorRecordsDir + "/crash-1.txt"},
+		},
+		"Success bundling the journal in the store": {
+			withJournal: true,
+			wantFiles:   []string{consts.JournalFileName},
+		},
+		"Success leaving out files that may hold secrets": {
+			publicFiles:  []string{consts.LogFileName, filepath.Join(".cloud-init", "agent.yaml"), filepath.Join("certs", "client_key.pem")},
+			privateFiles: []string{"config", "Ubuntu.tasks"},
+			wantFiles:    []string{consts.LogFileName},
+		},
+
+		"Error when a diagnostic cannot be read": {publicFiles: []string{consts.LogFileName}, breakFile: consts.LogFileName, wantErr: true},
+		"Error when the database cannot be read": {privateFiles: []string{consts.DatabaseFileName}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			publicDir := t.TempDir()
+			privateDir := t.TempDir()
+
+			for _, f := range tc.publicFiles {
+				writeFile(t, filepath.Join(publicDir, f), "public "+f)
+			}
+			for _, f := range tc.privateFiles {
+				writeFile(t, filepath.Join(privateDir, f), "private "+f)
+			}
+			if tc.withDistros {
+				writeFile(t, filepath.Join(privateDir, consts.DatabaseFileName), databaseFile)
+			}
+			if tc.withJournal {
+				s, err := store.Open(ctx, filepath.Join(privateDir, consts.StoreFileName))
+				require.NoError(t, err, "Setup: could not open the store")
+				j, err := journal.New(ctx, filepath.Join(privateDir, consts.JournalFileName), journal.WithStore(s))
+				require.NoError(t, err, "Setup: could not create the journal")
+				j.Record(ctx, journal.DistroAdded, "FeedbackDistro", "")
+				j.Close()
+				require.NoError(t, s.Close(), "Setup: could not close the store")
+			}
+			if tc.breakFile != "" {
+				path := filepath.Join(publicDir, tc.breakFile)
+				require.NoError(t, os.Chmod(path, 0), "Setup: could not make file unreadable")
+				if f, err := os.Open(path); err == nil {
+					f.Close()
+					t.Skip("This test cannot run with permissions to read any file")
+				}
+			}
+
+			meta := feedback.Metadata{
+				Product: "Ubuntu Pro for WSL",
+				Version: "1.2.3",
+				Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			}
+
+			var buf bytes.Buffer
+			err := feedback.WriteBundle(ctx, &buf, publicDir, privateDir, meta)
+			if tc.wantErr {
+				require.Error(t, err, "WriteBundle should have returned an error")
+				return
+			}
+			require.NoError(t, err, "WriteBundle should have returned no error")
+
+			z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			require.NoError(t, err, "WriteBundle should have written a zip archive")
+
+			contents := make(map[string]string)
+			for _, f := range z.File {
+				r, err := f.Open()
+				require.NoError(t, err, "Could not open %q in the bundle", f.Name)
+				out, err := io.ReadAll(r)
+				r.Close()
+				require.NoError(t, err, "Could not read %q in the bundle", f.Name)
+				contents[f.Name] = string(out)
+			}
+
+			var gotMeta feedback.Metadata
+			err = json.Unmarshal([]byte(contents[feedback.MetadataFileName]), &gotMeta)
+			require.NoError(t, err, "Bundle should contain valid metadata")
+			delete(contents, feedback.MetadataFileName)
+
+			require.ElementsMatch(t, tc.wantFiles, gotMeta.Files, "Metadata should list the bundled diagnostics")
+			gotMeta.Files = nil
+			require.Equal(t, meta, gotMeta, "Mismatched metadata in the bundle")
+
+			var gotFiles []string
+			for name, c := range contents {
+				gotFiles = append(gotFiles, name)
+				if name == feedback.DistrosFileName {
+					require.Contains(t, c, "FeedbackMachine", "The bundle should contain the properties of the distros")
+					continue
+				}
+				if tc.withJournal && name == consts.JournalFileName {
+					require.Contains(t, c, "FeedbackDistro", "The bundle should contain the events in the store")
+					continue
+				}
+				require.True(t, strings.HasSuffix(c, filepath.FromSlash(name)), "Mismatched contents of %q in the bundle", name)
+			}
+			require.ElementsMatch(t, tc.wantFiles, gotFiles, "Mismatched diagnostics in the bundle")
+		})
+	}
+}
+
+func TestURI(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse(feedback.URI())
+	require.NoError(t, err, "URI should be valid")
+	require.Equal(t, "feedback-hub", u.Scheme, "URI should open Feedback Hub")
+
+	q := u.Query()
+	require.Equal(t, "true", q.Get("newFeedback"), "URI should open a new report")
+	require.NotEmpty(t, q.Get("referrer"), "URI should identify the product")
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700), "Setup: could not create directory")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0600), "Setup: could not write file")
+}
+
+const databaseFile = `- name: Ubuntu
+  guid: '{12345678-1234-1234-1234-123456789ABC}'
+  properties:
+    distroid: Ubuntu
+    hostname: FeedbackMachine
+`
@@ -0,0 +1,17 @@
+package feedback
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// openURI asks Windows to open the URI with the application registered for its scheme.
+func openURI(ctx context.Context, uri string) error {
+	out, err := exec.CommandContext(ctx, "rundll32.exe", "url.dll,FileProtocolHandler", uri).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not open %s: %v. %s", uri, err, out)
+	}
+
+	return nil
+}
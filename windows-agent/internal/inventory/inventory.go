@@ -0,0 +1,124 @@
+// Package inventory exports the distros of the machine as normalized records, in the CSV and JSON
+// formats expected by asset management tools.
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+)
+
+// Host identifies the Windows machine the distros belong to.
+type Host struct {
+	// Machine is the hostname of the Windows machine.
+	Machine string
+
+	// LandscapeID is the UID Landscape assigned to the machine, empty if it is not registered.
+	LandscapeID string
+}
+
+// Record is the inventory record of a distro.
+type Record struct {
+	Machine       string   `json:"machine"`
+	Distro        string   `json:"distro"`
+	Hostname      string   `json:"hostname"`
+	DistroID      string   `json:"distro_id"`
+	UbuntuVersion string   `json:"ubuntu_version"`
+	PrettyName    string   `json:"pretty_name"`
+	ProAttached   bool     `json:"pro_attached"`
+	ProServices   []string `json:"pro_services"`
+	ProExpires    string   `json:"pro_expires"`
+
+	// LastSeen is the RFC3339 time the WSL Pro service of the distro was last heard from, empty if never.
+	LastSeen string `json:"last_seen"`
+
+	LandscapeID      string `json:"landscape_id"`
+	LandscapeManaged bool   `json:"landscape_managed"`
+
+	// UnsupportedReason is why the Ubuntu Pro client cannot run in the distro, empty if it can.
+	UnsupportedReason string `json:"unsupported_reason"`
+}
+
+// NewRecords returns the inventory records of the distros with the given properties, indexed by name, sorted
+// by name.
+func NewRecords(h Host, props map[string]distro.Properties) []Record {
+	records := make([]Record, 0, len(props))
+	for name, p := range props {
+		r := Record{
+			Machine:          h.Machine,
+			Distro:           name,
+			Hostname:         p.Hostname,
+			DistroID:         p.DistroID,
+			UbuntuVersion:    p.VersionID,
+			PrettyName:       p.PrettyName,
+			ProAttached:      p.ProAttached,
+			ProServices:      p.ProServices,
+			ProExpires:       p.ProExpires,
+			LandscapeID:      h.LandscapeID,
+			LandscapeManaged: p.LandscapeManaged,
+
+			UnsupportedReason: p.UnsupportedReason,
+		}
+		if r.ProServices == nil {
+			r.ProServices = []string{}
+		}
+		if !p.LastSeen.IsZero() {
+			r.LastSeen = p.LastSeen.UTC().Format(time.RFC3339)
+		}
+
+		records = append(records, r)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return strings.ToLower(records[i].Distro) < strings.ToLower(records[j].Distro)
+	})
+
+	return records
+}
+
+// csvHeader are the columns of the CSV export, in the order of the fields of Record.
+var csvHeader = []string{
+	"machine", "distro", "hostname", "distro_id", "ubuntu_version", "pretty_name",
+	"pro_attached", "pro_services", "pro_expires", "last_seen", "landscape_id", "landscape_managed",
+	"unsupported_reason",
+}
+
+// WriteCSV writes the records into w as CSV, with a header. The Pro services are separated by semicolons.
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("could not write CSV header: %v", err)
+	}
+
+	for _, r := range records {
+		row := []string{
+			r.Machine, r.Distro, r.Hostname, r.DistroID, r.UbuntuVersion, r.PrettyName,
+			strconv.FormatBool(r.ProAttached), strings.Join(r.ProServices, ";"), r.ProExpires, r.LastSeen,
+			r.LandscapeID, strconv.FormatBool(r.LandscapeManaged), r.UnsupportedReason,
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("could not write CSV record of %q: %v", r.Distro, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the records into w as an indented JSON array.
+func WriteJSON(w io.Writer, records []Record) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(records); err != nil {
+		return fmt.Errorf("could not write JSON records: %v", err)
+	}
+	return nil
+}
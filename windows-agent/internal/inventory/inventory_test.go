@@ -0,0 +1,106 @@
+package inventory_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/inventory"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	attached = distro.Properties{
+		DistroID:         "ubuntu",
+		VersionID:        "24.04",
+		PrettyName:       "Ubuntu 24.04 LTS",
+		Hostname:         "attachedMachine",
+		ProAttached:      true,
+		ProServices:      []string{"esm-apps", "esm-infra"},
+		ProExpires:       "2030-01-01T00:00:00Z",
+		LandscapeManaged: true,
+		LastSeen:         time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	detached = distro.Properties{
+		DistroID:   "ubuntu",
+		VersionID:  "22.04",
+		PrettyName: "Ubuntu 22.04.5 LTS, with a comma",
+		Hostname:   "detachedMachine",
+	}
+	unsupported = distro.Properties{
+		DistroID:          "ubuntu",
+		VersionID:         "24.04",
+		PrettyName:        "Ubuntu 24.04 LTS",
+		Hostname:          "minimalMachine",
+		UnsupportedReason: "the Ubuntu Pro client is not installed",
+	}
+)
+
+func TestNewRecords(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		props map[string]distro.Properties
+
+		wantNames []string
+	}{
+		"Empty inventory with no distros": {wantNames: []string{}},
+		"Records sorted by name":          {props: map[string]distro.Properties{"b": detached, "Attached": attached, "c": detached}, wantNames: []string{"Attached", "b", "c"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := inventory.Host{Machine: "WINDOWS-PC", LandscapeID: "landscape-uid"}
+			got := inventory.NewRecords(h, tc.props)
+
+			names := make([]string, 0, len(got))
+			for _, r := range got {
+				names = append(names, r.Distro)
+				require.Equal(t, h.Machine, r.Machine, "Every record should carry the name of the machine")
+				require.Equal(t, h.LandscapeID, r.LandscapeID, "Every record should carry the Landscape ID of the machine")
+				require.NotNil(t, r.ProServices, "Records should have an empty list of Pro services rather than none")
+			}
+			require.Equal(t, tc.wantNames, names, "Records should be sorted by name")
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		json    bool
+		noProps bool
+	}{
+		"CSV":                  {},
+		"CSV with no distros":  {noProps: true},
+		"JSON":                 {json: true},
+		"JSON with no distros": {json: true, noProps: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			props := map[string]distro.Properties{"Ubuntu": attached, "Ubuntu-22.04": detached, "Ubuntu-Minimal": unsupported}
+			if tc.noProps {
+				props = nil
+			}
+			records := inventory.NewRecords(inventory.Host{Machine: "WINDOWS-PC", LandscapeID: "landscape-uid"}, props)
+
+			var out bytes.Buffer
+			write := inventory.WriteCSV
+			if tc.json {
+				write = inventory.WriteJSON
+			}
+			require.NoError(t, write(&out, records), "Writing the records should not fail")
+
+			want := testutils.LoadWithUpdateFromGolden(t, out.String())
+			require.Equal(t, want, out.String(), "Records should match the golden file")
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package journal
+
+// Token returns the token that points to the event with the given sequence number.
+func (j *Journal) Token(seq uint64) string {
+	return j.token(seq)
+}
@@ -0,0 +1,55 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+)
+
+// Distro is a distro whose events can be recorded in the journal.
+type Distro interface {
+	Name() string
+	WatchLifecycle(ctx context.Context) <-chan distro.LifecycleEvent
+	DegradedReason() string
+	WatchTasks(ctx context.Context) (<-chan worker.Event, error)
+}
+
+// Follow records the lifecycle changes, the task failures and the completion of the tasks that report
+// their steps of the distro, until the context is cancelled or the distro is cleaned up. It does not block.
+func (j *Journal) Follow(ctx context.Context, d Distro) {
+	stages := d.WatchLifecycle(ctx)
+	go func() {
+		defer crashreport.Recover("journal")
+		for ev := range stages {
+			msg := ev.To.String()
+			if reason := d.DegradedReason(); ev.To == distro.Degraded && reason != "" {
+				msg = fmt.Sprintf("%s: %s", msg, reason)
+			}
+			j.Record(ctx, DistroStageChanged, d.Name(), msg)
+		}
+	}()
+
+	tasks, err := d.WatchTasks(ctx)
+	if err != nil {
+		log.Warningf(ctx, "Journal: could not follow the tasks of distro %q: %v", d.Name(), err)
+		return
+	}
+	go func() {
+		defer crashreport.Recover("journal")
+		for ev := range tasks {
+			switch ev.Type {
+			case worker.EventFailed:
+				j.RecordTaskFailure(ctx, d.Name(), fmt.Sprintf("%s: %s", ev.Task, ev.Reason), ev.Steps)
+			case worker.EventCompleted:
+				if len(ev.Steps) == 0 {
+					continue
+				}
+				j.RecordTaskCompletion(ctx, d.Name(), ev.Task, ev.Steps)
+			}
+		}
+	}()
+}
@@ -0,0 +1,563 @@
+// Package journal keeps a compact record of what happened in the agent (distros added and removed,
+// distros being attached or configured, tasks failing...) and persists it on disk, either in a file or
+// in the embedded store, so that clients that were not listening when the events happened can catch up
+// on them later.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/google/uuid"
+	"github.com/ubuntu/decorate"
+)
+
+// EventType is the kind of thing that happened.
+type EventType string
+
+const (
+	// DistroAdded is recorded when a distro is added to the database.
+	DistroAdded EventType = "distro-added"
+	// DistroRemoved is recorded when a distro is removed from the database.
+	DistroRemoved EventType = "distro-removed"
+	// DistroStageChanged is recorded when a distro moves to a different stage of its lifecycle.
+	DistroStageChanged EventType = "distro-stage-changed"
+	// DistroRenamed is recorded under the new name of a distro when it is renamed. Its message is the previous name.
+	DistroRenamed EventType = "distro-renamed"
+	// TaskFailed is recorded when a task of a distro fails.
+	TaskFailed EventType = "task-failed"
+	// TaskCompleted is recorded when a task of a distro that reports its steps completes. Other tasks
+	// completing are not recorded, so that they do not push the failures out of the journal.
+	TaskCompleted EventType = "task-completed"
+)
+
+// Event is an entry of the journal.
+type Event struct {
+	// Seq is the position of the event in the journal. It is strictly increasing.
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	Type    EventType `json:"type"`
+	Distro  string    `json:"distro"`
+	Message string    `json:"message,omitempty"`
+
+	// Steps is the outcome of each step of the task. Only set for TaskFailed and TaskCompleted,
+	// and only for tasks that report their steps.
+	Steps []task.Step `json:"steps,omitempty"`
+}
+
+// header is the first line of the journal file.
+type header struct {
+	// Epoch identifies the journal file, so that the tokens handed out for a journal that was lost are
+	// not mistaken for tokens of the one that replaced it, whose sequence numbers start over.
+	Epoch string `json:"epoch"`
+}
+
+// DefaultCapacity is the number of events kept in the journal by default.
+const DefaultCapacity = 1000
+
+// epochKey is the key of the epoch of the journal in the state bucket of the store.
+const epochKey = "journal-epoch"
+
+// Journal is a thread-safe, bounded log of events backed by a file or by the embedded store. Only
+// the latest events are kept: older ones are discarded as new ones are recorded.
+type Journal struct {
+	path     string
+	capacity int
+	epoch    string
+
+	// store is where the events are kept if the journal is created WithStore, instead of the file at path.
+	store *store.Store
+
+	events []Event
+	// lastSeq is the sequence number of the latest event recorded, even if it was discarded since.
+	lastSeq uint64
+	// pending are the events recorded but not written to the file yet.
+	pending []Event
+	mu      sync.Mutex
+
+	// lines is the number of events in the file. Above twice the capacity, the file is compacted.
+	// It is only used by the writer.
+	lines int
+
+	// wake signals the writer that there are pending events.
+	wake chan struct{}
+	// stop asks the writer to write the pending events and return, closing done once it has.
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+type options struct {
+	capacity int
+	store    *store.Store
+}
+
+// Option is an optional argument for New.
+type Option func(*options)
+
+// WithCapacity sets the number of events kept in the journal.
+func WithCapacity(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.capacity = n
+		}
+	}
+}
+
+// WithStore keeps the events in the embedded store s instead of in the file at the path given to New.
+// The events that older versions left in that file are migrated into the store, and the file is removed.
+func WithStore(s *store.Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
+}
+
+// New creates a journal and loads the events persisted in the file at path, if any.
+// New events are appended to the same file in the background until the journal is closed.
+func New(ctx context.Context, path string, args ...Option) (j *Journal, err error) {
+	defer decorate.OnError(&err, "could not initialize event journal")
+
+	opts := options{capacity: DefaultCapacity}
+	for _, f := range args {
+		f(&opts)
+	}
+
+	j = &Journal{
+		path:     path,
+		capacity: opts.capacity,
+		store:    opts.store,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if err := j.load(ctx); err != nil {
+		return nil, err
+	}
+
+	// New files, and those written before epochs existed, start a new epoch.
+	if j.epoch == "" {
+		j.epoch = uuid.NewString()
+		if err := j.compact(j.events); err != nil {
+			return nil, err
+		}
+	}
+
+	go j.write(ctx)
+
+	return j, nil
+}
+
+// load reads the persisted events.
+func (j *Journal) load(ctx context.Context) error {
+	if j.store == nil {
+		return j.loadFile(ctx)
+	}
+
+	if err := j.loadStore(ctx); err != nil {
+		return err
+	}
+	if j.epoch != "" {
+		return nil
+	}
+
+	// Nothing in the store yet: the file of older versions is migrated, if there is one.
+	if err := j.loadFile(ctx); err != nil {
+		return err
+	}
+	if j.epoch == "" && len(j.events) == 0 {
+		return nil
+	}
+	if j.epoch == "" {
+		j.epoch = uuid.NewString()
+	}
+	if err := j.compact(j.events); err != nil {
+		return err
+	}
+
+	if err := os.Remove(j.path); err != nil {
+		log.Warningf(ctx, "Journal: could not remove %q after migrating it into the store: %v", j.path, err)
+	}
+	return nil
+}
+
+// loadStore reads the epoch and the events kept in the store. Malformed events are skipped.
+func (j *Journal) loadStore(ctx context.Context) error {
+	return j.store.View(func(tx *store.Tx) error {
+		epoch, err := tx.Get(store.StateBucket, epochKey)
+		if err != nil {
+			return err
+		}
+		j.epoch = string(epoch)
+
+		events, err := storedEvents(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, ev := range events {
+			if ev.Seq <= j.lastSeq {
+				log.Warningf(ctx, "Journal: skipping malformed event %d", ev.Seq)
+				continue
+			}
+			j.lastSeq = ev.Seq
+			j.events = append(j.events, ev)
+		}
+
+		j.trim()
+		return nil
+	})
+}
+
+// Stored returns the events kept in the store s, oldest first.
+func Stored(s *store.Store) (events []Event, err error) {
+	defer decorate.OnError(&err, "could not read the events in the store")
+
+	err = s.View(func(tx *store.Tx) error {
+		events, err = storedEvents(tx)
+		return err
+	})
+	return events, err
+}
+
+// storedEvents returns the events in the journal bucket of the store, in order. Those that cannot be
+// parsed are skipped.
+func storedEvents(tx *store.Tx) ([]Event, error) {
+	keys, err := tx.Keys(store.JournalBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(keys))
+	for _, k := range keys {
+		out, err := tx.Get(store.JournalBucket, k)
+		if err != nil {
+			return nil, err
+		}
+
+		var ev Event
+		if err := json.Unmarshal(out, &ev); err != nil {
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
+
+// eventKey returns the key of the event in the journal bucket of the store. Keys sort like sequence numbers.
+func eventKey(seq uint64) string {
+	return string(binary.BigEndian.AppendUint64(nil, seq))
+}
+
+// loadFile reads the events in the journal file. Malformed lines, such as those left behind by a
+// write that was interrupted, are skipped.
+func (j *Journal) loadFile(ctx context.Context) error {
+	out, err := os.ReadFile(j.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for line := 1; sc.Scan(); line++ {
+		if line == 1 {
+			var h header
+			if err := json.Unmarshal(sc.Bytes(), &h); err == nil && h.Epoch != "" {
+				j.epoch = h.Epoch
+				continue
+			}
+		}
+
+		j.lines++
+
+		var ev Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil || ev.Seq <= j.lastSeq {
+			log.Warningf(ctx, "Journal: skipping malformed event in line %d", line)
+			continue
+		}
+
+		j.lastSeq = ev.Seq
+		j.events = append(j.events, ev)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("could not read %q: %v", j.path, err)
+	}
+
+	j.trim()
+	return nil
+}
+
+// Record adds an event to the journal. The journal is best-effort: failing to persist
+// the event is logged, but the event is still served from memory.
+func (j *Journal) Record(ctx context.Context, t EventType, distro, message string) {
+	j.record(ctx, Event{Type: t, Distro: distro, Message: message})
+}
+
+// RecordTaskFailure adds a TaskFailed event to the journal, along with the outcome of
+// each step of the task, so that partial successes can be told apart.
+func (j *Journal) RecordTaskFailure(ctx context.Context, distro, message string, steps []task.Step) {
+	j.record(ctx, Event{Type: TaskFailed, Distro: distro, Message: message, Steps: steps})
+}
+
+// RecordTaskCompletion adds a TaskCompleted event to the journal, along with the outcome of
+// each step of the task.
+func (j *Journal) RecordTaskCompletion(ctx context.Context, distro, message string, steps []task.Step) {
+	j.record(ctx, Event{Type: TaskCompleted, Distro: distro, Message: message, Steps: steps})
+}
+
+// record stamps the event with its sequence number and time, and adds it to the journal. It does
+// no I/O, so that it can be called with other locks held: the event is written by the writer.
+func (j *Journal) record(ctx context.Context, ev Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.lastSeq++
+	ev.Seq = j.lastSeq
+	ev.Time = time.Now()
+
+	j.events = append(j.events, ev)
+	j.trim()
+
+	j.pending = append(j.pending, ev)
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// write persists the pending events as they are recorded, until the journal is closed or the
+// context is cancelled.
+func (j *Journal) write(ctx context.Context) {
+	defer crashreport.Recover("journal")
+	defer close(j.done)
+
+	for {
+		select {
+		case <-j.wake:
+			j.flush(ctx)
+		case <-j.stop:
+			j.flush(ctx)
+			return
+		case <-ctx.Done():
+			j.flush(ctx)
+			return
+		}
+	}
+}
+
+// Close writes the events that are still pending, and stops persisting new ones.
+func (j *Journal) Close() {
+	j.stopOnce.Do(func() { close(j.stop) })
+	<-j.done
+}
+
+// flush writes the pending events to the file, compacting it if it grew too large. The journal is
+// best-effort: failing to persist events is logged, but they are still served from memory.
+func (j *Journal) flush(ctx context.Context) {
+	j.mu.Lock()
+	pending := j.pending
+	j.pending = nil
+	var events []Event
+	if j.lines+len(pending) > 2*j.capacity {
+		events = append([]Event{}, j.events...)
+	}
+	j.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	var err error
+	if events != nil {
+		err = j.compact(events)
+	} else {
+		err = j.persist(pending)
+	}
+	if err != nil {
+		log.Warningf(ctx, "Journal: could not persist events: %v", err)
+	}
+}
+
+// trim discards the oldest events above the capacity. The caller must hold the lock.
+func (j *Journal) trim() {
+	if excess := len(j.events) - j.capacity; excess > 0 {
+		j.events = append([]Event{}, j.events[excess:]...)
+	}
+}
+
+// persist appends the events to the file. In the store, the events above the capacity are discarded
+// in the same transaction instead, as there is no file to compact.
+func (j *Journal) persist(events []Event) error {
+	if j.store != nil {
+		return j.store.Update(func(tx *store.Tx) error {
+			if err := putEvents(tx, events); err != nil {
+				return err
+			}
+
+			keys, err := tx.Keys(store.JournalBucket)
+			if err != nil {
+				return err
+			}
+			for _, k := range keys[:max(len(keys)-j.capacity, 0)] {
+				if err := tx.Delete(store.JournalBucket, k); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	var buf bytes.Buffer
+	for _, ev := range events {
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	j.lines += len(events)
+	return nil
+}
+
+// compact rewrites the file with the header and the events given, which must be those kept in memory.
+func (j *Journal) compact(events []Event) error {
+	if j.store != nil {
+		return j.store.Update(func(tx *store.Tx) error {
+			if err := tx.Put(store.StateBucket, epochKey, []byte(j.epoch)); err != nil {
+				return err
+			}
+
+			keys, err := tx.Keys(store.JournalBucket)
+			if err != nil {
+				return err
+			}
+			for _, k := range keys {
+				if err := tx.Delete(store.JournalBucket, k); err != nil {
+					return err
+				}
+			}
+			return putEvents(tx, events)
+		})
+	}
+
+	var buf bytes.Buffer
+
+	h, err := json.Marshal(header{Epoch: j.epoch})
+	if err != nil {
+		return err
+	}
+	buf.Write(append(h, '\n'))
+
+	for _, ev := range events {
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	tmp := j.path + ".new"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return err
+	}
+
+	j.lines = len(events)
+	return nil
+}
+
+// putEvents writes the events into the journal bucket of the store.
+func putEvents(tx *store.Tx, events []Event) error {
+	for _, ev := range events {
+		out, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		if err := tx.Put(store.JournalBucket, eventKey(ev.Seq), out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// token returns the token that points to the event with the given sequence number.
+func (j *Journal) token(seq uint64) string {
+	return fmt.Sprintf("%s:%d", j.epoch, seq)
+}
+
+// Since returns the events recorded after the one the token points to, oldest first, alongside the
+// token that points to the latest event. An empty token returns all the events in the journal.
+//
+// Truncated is true when some of the events after the token were discarded already, in which case
+// the caller cannot rely on the events alone to catch up. That includes tokens of a journal that
+// was lost since they were handed out.
+func (j *Journal) Since(token string) (events []Event, next string, truncated bool, err error) {
+	var since uint64
+	var epoch string
+	if token != "" {
+		// Tokens handed out before epochs existed have none.
+		i := strings.LastIndex(token, ":")
+		epoch = token[:max(i, 0)]
+		since, err = strconv.ParseUint(token[i+1:], 10, 64)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("invalid token %q", token)
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	next = j.token(j.lastSeq)
+
+	if token != "" && (epoch != j.epoch || since > j.lastSeq) {
+		// The token comes from a journal that no longer exists.
+		since = 0
+		truncated = true
+	}
+
+	var oldest uint64 = j.lastSeq + 1
+	if len(j.events) > 0 {
+		oldest = j.events[0].Seq
+	}
+	if oldest > since+1 {
+		truncated = true
+	}
+
+	for _, ev := range j.events {
+		if ev.Seq > since {
+			events = append(events, ev)
+		}
+	}
+
+	return events, next, truncated, nil
+}
@@ -0,0 +1,267 @@
+package journal_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/journal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSince(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		recorded int
+		capacity int
+		// since is the sequence number the token points to. Negative means an empty token.
+		since int
+		// token overrides the token pointing to since.
+		token string
+
+		wantSeqs      []uint64
+		wantNext      uint64
+		wantTruncated bool
+		wantErr       bool
+	}{
+		"Success with an empty journal":                   {since: -1},
+		"Success getting every event with an empty token": {recorded: 3, since: -1, wantSeqs: []uint64{1, 2, 3}, wantNext: 3},
+		"Success getting the events after the token":      {recorded: 3, since: 1, wantSeqs: []uint64{2, 3}, wantNext: 3},
+		"Success with an up to date token":                {recorded: 3, since: 3, wantNext: 3},
+		"Success with a token within the capacity":        {recorded: 5, capacity: 3, since: 2, wantSeqs: []uint64{3, 4, 5}, wantNext: 5},
+
+		"Truncated with a token older than the journal":          {recorded: 5, capacity: 3, since: 1, wantSeqs: []uint64{3, 4, 5}, wantNext: 5, wantTruncated: true},
+		"Truncated with an empty token after discarding":         {recorded: 5, capacity: 3, since: -1, wantSeqs: []uint64{3, 4, 5}, wantNext: 5, wantTruncated: true},
+		"Truncated with a token ahead of the journal":            {recorded: 2, since: 7, wantSeqs: []uint64{1, 2}, wantNext: 2, wantTruncated: true},
+		"Truncated with a token from a journal that was lost":    {recorded: 3, token: "another-epoch:1", wantSeqs: []uint64{1, 2, 3}, wantNext: 3, wantTruncated: true},
+		"Truncated with a token from before epochs were tracked": {recorded: 3, token: "1", wantSeqs: []uint64{1, 2, 3}, wantNext: 3, wantTruncated: true},
+
+		"Error with a malformed token": {recorded: 2, token: "not-a-number", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			j, err := journal.New(ctx, filepath.Join(t.TempDir(), "events.journal"), journal.WithCapacity(tc.capacity))
+			require.NoError(t, err, "Setup: New should return no error")
+			defer j.Close()
+
+			for i := range tc.recorded {
+				j.Record(ctx, journal.DistroAdded, fmt.Sprintf("distro-%d", i), "")
+			}
+
+			token := tc.token
+			if token == "" && tc.since >= 0 {
+				token = j.Token(uint64(tc.since))
+			}
+
+			events, next, truncated, err := j.Since(token)
+			if tc.wantErr {
+				require.Error(t, err, "Since should return an error")
+				return
+			}
+			require.NoError(t, err, "Since should return no error")
+
+			var gotSeqs []uint64
+			for _, ev := range events {
+				gotSeqs = append(gotSeqs, ev.Seq)
+			}
+
+			require.Equal(t, tc.wantSeqs, gotSeqs, "Mismatched events")
+			require.Equal(t, j.Token(tc.wantNext), next, "Mismatched next token")
+			require.Equal(t, tc.wantTruncated, truncated, "Mismatched truncation")
+		})
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		recorded    int
+		capacity    int
+		corruptTail bool
+		noHeader    bool
+		badFile     bool
+
+		wantSeqs []uint64
+		// wantLines is the number of lines in the file, including the header. Zero means that the file was
+		// compacted: what was written in each write depends on timing, so only the limit is checked.
+		wantLines int
+		wantErr   bool
+	}{
+		"Success reloading the events":                      {recorded: 3, wantSeqs: []uint64{1, 2, 3}, wantLines: 4},
+		"Success reloading only the latest events":          {recorded: 5, capacity: 3, wantSeqs: []uint64{3, 4, 5}, wantLines: 6},
+		"Success compacting the file when it grows":         {recorded: 8, capacity: 3, wantSeqs: []uint64{6, 7, 8}},
+		"Success skipping an event that was half-written":   {recorded: 3, corruptTail: true, wantSeqs: []uint64{1, 2, 3}, wantLines: 5},
+		"Success reloading a file written without an epoch": {recorded: 3, noHeader: true, wantSeqs: []uint64{1, 2, 3}, wantLines: 3},
+
+		"Error when the file cannot be read": {badFile: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			path := filepath.Join(t.TempDir(), "events.journal")
+			if tc.badFile {
+				require.NoError(t, os.MkdirAll(path, 0700), "Setup: could not create a directory in place of the journal")
+			}
+
+			j, err := journal.New(ctx, path, journal.WithCapacity(tc.capacity))
+			if tc.wantErr {
+				require.Error(t, err, "New should return an error")
+				return
+			}
+			require.NoError(t, err, "Setup: New should return no error")
+
+			for i := range tc.recorded {
+				j.RecordTaskFailure(ctx, "distro", fmt.Sprintf("failure %d", i), failedSteps(i))
+			}
+			_, oldToken, _, err := j.Since("")
+			require.NoError(t, err, "Setup: Since should return no error")
+			j.Close()
+
+			if tc.noHeader {
+				out, err := os.ReadFile(path)
+				require.NoError(t, err, "Setup: could not read the journal")
+				_, events, _ := strings.Cut(string(out), "\n")
+				require.NoError(t, os.WriteFile(path, []byte(events), 0600), "Setup: could not remove the header of the journal")
+			}
+
+			if tc.corruptTail {
+				f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+				require.NoError(t, err, "Setup: could not open the journal")
+				_, err = f.WriteString(`{"seq":4,"ty`)
+				require.NoError(t, err, "Setup: could not corrupt the journal")
+				f.Close()
+			}
+
+			out, err := os.ReadFile(path)
+			require.NoError(t, err, "Setup: could not read the journal")
+			lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+			if tc.wantLines == 0 {
+				require.LessOrEqual(t, len(lines), 2*tc.capacity+1, "The journal file should have been compacted")
+			} else {
+				require.Len(t, lines, tc.wantLines, "Mismatched number of lines in the journal file")
+			}
+
+			j, err = journal.New(ctx, path, journal.WithCapacity(tc.capacity))
+			require.NoError(t, err, "New should return no error when reloading the journal")
+			defer j.Close()
+
+			events, _, _, err := j.Since("")
+			require.NoError(t, err, "Since should return no error")
+
+			var gotSeqs []uint64
+			for _, ev := range events {
+				require.Equal(t, journal.TaskFailed, ev.Type, "Reloaded event should keep its type")
+				require.Equal(t, fmt.Sprintf("failure %d", ev.Seq-1), ev.Message, "Reloaded event should keep its message")
+				require.Equal(t, failedSteps(int(ev.Seq-1)), ev.Steps, "Reloaded event should keep its steps")
+				gotSeqs = append(gotSeqs, ev.Seq)
+			}
+			require.Equal(t, tc.wantSeqs, gotSeqs, "Mismatched reloaded events")
+
+			_, _, truncated, err := j.Since(oldToken)
+			require.NoError(t, err, "Since should return no error with the token handed out before reloading")
+			require.Equal(t, tc.noHeader, truncated, "Tokens should only outlive reloading the journal they were handed out by")
+
+			// Sequence numbers carry on after reloading.
+			j.Record(ctx, journal.TaskFailed, "distro", "")
+			_, next, _, err := j.Since("")
+			require.NoError(t, err, "Since should return no error")
+			require.Equal(t, j.Token(tc.wantSeqs[len(tc.wantSeqs)-1]+1), next, "New events should follow the reloaded ones")
+		})
+	}
+}
+
+func TestStorePersistence(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		recorded     int
+		capacity     int
+		inLegacyFile bool
+
+		wantSeqs []uint64
+	}{
+		"Success reloading the events":             {recorded: 3, wantSeqs: []uint64{1, 2, 3}},
+		"Success reloading only the latest events": {recorded: 8, capacity: 3, wantSeqs: []uint64{6, 7, 8}},
+		"Success migrating the journal file":       {recorded: 3, inLegacyFile: true, wantSeqs: []uint64{1, 2, 3}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "events.journal")
+			s, err := store.Open(ctx, filepath.Join(dir, "store.db"))
+			require.NoError(t, err, "Setup: could not open the store")
+			defer s.Close()
+
+			opts := []journal.Option{journal.WithCapacity(tc.capacity)}
+			if !tc.inLegacyFile {
+				opts = append(opts, journal.WithStore(s))
+			}
+
+			j, err := journal.New(ctx, path, opts...)
+			require.NoError(t, err, "Setup: New should return no error")
+			for i := range tc.recorded {
+				j.RecordTaskFailure(ctx, "distro", fmt.Sprintf("failure %d", i), failedSteps(i))
+			}
+			_, oldToken, _, err := j.Since("")
+			require.NoError(t, err, "Setup: Since should return no error")
+			j.Close()
+
+			if tc.inLegacyFile {
+				require.FileExists(t, path, "Setup: the journal should have been written to a file")
+			}
+
+			j, err = journal.New(ctx, path, journal.WithCapacity(tc.capacity), journal.WithStore(s))
+			require.NoError(t, err, "New should return no error when reloading the journal")
+			defer j.Close()
+
+			require.NoFileExists(t, path, "No journal file should be left when using the store")
+
+			events, _, _, err := j.Since("")
+			require.NoError(t, err, "Since should return no error")
+			stored, err := journal.Stored(s)
+			require.NoError(t, err, "Stored should return no error")
+
+			var gotSeqs, storedSeqs []uint64
+			for _, ev := range events {
+				require.Equal(t, fmt.Sprintf("failure %d", ev.Seq-1), ev.Message, "Reloaded event should keep its message")
+				require.Equal(t, failedSteps(int(ev.Seq-1)), ev.Steps, "Reloaded event should keep its steps")
+				gotSeqs = append(gotSeqs, ev.Seq)
+			}
+			for _, ev := range stored {
+				storedSeqs = append(storedSeqs, ev.Seq)
+			}
+			require.Equal(t, tc.wantSeqs, gotSeqs, "Mismatched reloaded events")
+			require.Equal(t, tc.wantSeqs, storedSeqs, "Only the latest events should be kept in the store")
+
+			_, _, truncated, err := j.Since(oldToken)
+			require.NoError(t, err, "Since should return no error with the token handed out before reloading")
+			require.False(t, truncated, "Tokens should outlive reloading the journal from the store")
+		})
+	}
+}
+
+// failedSteps returns the steps of the i-th failed task of the tests.
+func failedSteps(i int) []task.Step {
+	return []task.Step{
+		{Name: "first", Status: task.StepSucceeded},
+		{Name: "second", Status: task.StepFailed, Message: fmt.Sprintf("mock error %d", i)},
+	}
+}
@@ -0,0 +1,34 @@
+// Package latency publishes the round-trip times measured to the WSL Pro service of each distro as
+// performance counters.
+package latency
+
+import (
+	"math"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+)
+
+// Identifiers of the counters in the counter set. They must match those in latency.man.
+const (
+	counterLast uint32 = iota + 1
+	counterMean
+	counterMax
+	counterFailures
+)
+
+// counterValues returns the value of every counter for the latency of a distro, indexed by counter identifier.
+// Round-trip times are in microseconds.
+func counterValues(l distro.Latency) map[uint32]uint32 {
+	return map[uint32]uint32{
+		counterLast:     microseconds(l.Last),
+		counterMean:     microseconds(l.Mean),
+		counterMax:      microseconds(l.Max),
+		counterFailures: uint32(min(l.Failures, math.MaxUint32)),
+	}
+}
+
+// microseconds returns the duration in whole microseconds, saturating instead of overflowing.
+func microseconds(d time.Duration) uint32 {
+	return uint32(min(d.Microseconds(), math.MaxUint32))
+}
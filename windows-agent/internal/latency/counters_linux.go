@@ -0,0 +1,20 @@
+package latency
+
+import "github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+
+// Counters publishes the latency of every distro as performance counters. There are no performance
+// counters outside of Windows, so publishing does nothing.
+type Counters struct{}
+
+// NewCounters returns a publisher of performance counters.
+func NewCounters() (*Counters, error) {
+	return &Counters{}, nil
+}
+
+// Publish sets the counters of every distro to its latency.
+func (c *Counters) Publish(map[string]distro.Latency) error {
+	return nil
+}
+
+// Close stops publishing the counters.
+func (c *Counters) Close() {}
@@ -0,0 +1,40 @@
+package latency_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/latency"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterValues(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		latency distro.Latency
+
+		want map[uint32]uint32
+	}{
+		"Never pinged": {want: map[uint32]uint32{1: 0, 2: 0, 3: 0, 4: 0}},
+		"Pinged": {
+			latency: distro.Latency{Last: 1500 * time.Microsecond, Mean: time.Millisecond, Max: 2 * time.Millisecond, Samples: 3, Failures: 2},
+			want:    map[uint32]uint32{1: 1500, 2: 1000, 3: 2000, 4: 2},
+		},
+		"Saturated round-trip times": {
+			latency: distro.Latency{Last: 2 * time.Hour, Mean: 2 * time.Hour, Max: 2 * time.Hour, Samples: 1},
+			want:    map[uint32]uint32{1: math.MaxUint32, 2: math.MaxUint32, 3: math.MaxUint32, 4: 0},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// The identifiers are those of the counters in latency.man.
+			require.Equal(t, tc.want, latency.CounterValues(tc.latency), "Mismatched performance counter values")
+		})
+	}
+}
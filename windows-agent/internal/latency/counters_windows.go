@@ -0,0 +1,179 @@
+package latency
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/ubuntu/decorate"
+	"golang.org/x/sys/windows"
+)
+
+// The counter set is published through the Performance Counters (PerfLib V2) API, with one instance per distro.
+// Consumers such as Performance Monitor only find it once latency.man is registered with `lodctr /m:latency.man`.
+// The MSIX package cannot register it, so that is a manual step (see docs/reference/performance_counters.md).
+var (
+	advapi32 = windows.NewLazySystemDLL("advapi32.dll")
+
+	perfStartProvider      = advapi32.NewProc("PerfStartProvider")
+	perfStopProvider       = advapi32.NewProc("PerfStopProvider")
+	perfSetCounterSetInfo  = advapi32.NewProc("PerfSetCounterSetInfo")
+	perfCreateInstance     = advapi32.NewProc("PerfCreateInstance")
+	perfDeleteInstance     = advapi32.NewProc("PerfDeleteInstance")
+	perfSetULongCounterVal = advapi32.NewProc("PerfSetULongCounterValue")
+)
+
+var (
+	// providerGUID and counterSetGUID must match those in latency.man.
+	providerGUID   = windows.GUID{Data1: 0x5c3c7a3e, Data2: 0x4f7b, Data3: 0x4d4e, Data4: [8]byte{0x9b, 0x8f, 0x1f, 0x6a, 0x2c, 0x9d, 0x7e, 0x03}}
+	counterSetGUID = windows.GUID{Data1: 0x5c3c7a3e, Data2: 0x4f7b, Data3: 0x4d4e, Data4: [8]byte{0x9b, 0x8f, 0x1f, 0x6a, 0x2c, 0x9d, 0x7e, 0x04}}
+)
+
+const (
+	numCounters = 4
+
+	// counterSize is the size in bytes of every counter. It is that of the values returned by counterValues, so
+	// that any value passed to PerfSetULongCounterValue fits in its counter without being truncated.
+	counterSize = uint32(unsafe.Sizeof(uint32(0)))
+
+	perfCounterSetMultiInstances = 2          // PERF_COUNTERSET_MULTI_INSTANCES
+	perfCounterRawCount          = 0x00010000 // PERF_COUNTER_RAWCOUNT
+	perfDetailNovice             = 100        // PERF_DETAIL_NOVICE
+)
+
+// perfCounterSetTemplate mirrors a PERF_COUNTERSET_INFO followed by its PERF_COUNTER_INFO array.
+type perfCounterSetTemplate struct {
+	counterSetGUID windows.GUID
+	providerGUID   windows.GUID
+	numCounters    uint32
+	instanceType   uint32
+
+	counters [numCounters]perfCounterInfo
+}
+
+// perfCounterInfo mirrors PERF_COUNTER_INFO.
+type perfCounterInfo struct {
+	counterID   uint32
+	counterType uint32
+	attrib      uint64
+	size        uint32
+	detailLevel uint32
+	scale       int32
+	offset      uint32
+}
+
+// Counters publishes the latency of every distro as Windows performance counters.
+type Counters struct {
+	provider windows.Handle
+
+	// instances are the instances of the counter set, by distro name.
+	instances map[string]uintptr
+	// lastID is the identifier of the latest instance created, as each must have its own.
+	lastID uint32
+}
+
+// NewCounters registers the counter set of the latencies. Call Close to release resources.
+func NewCounters() (c *Counters, err error) {
+	defer decorate.OnError(&err, "could not publish performance counters")
+
+	c = &Counters{instances: make(map[string]uintptr)}
+
+	//nolint:gosec // No other way of calling a DLL proc.
+	if r, _, _ := perfStartProvider.Call(uintptr(unsafe.Pointer(&providerGUID)), 0, uintptr(unsafe.Pointer(&c.provider))); r != 0 {
+		return nil, fmt.Errorf("PerfStartProvider: %w", syscall.Errno(r))
+	}
+	defer func() {
+		if err != nil {
+			c.Close()
+		}
+	}()
+
+	template := perfCounterSetTemplate{
+		counterSetGUID: counterSetGUID,
+		providerGUID:   providerGUID,
+		numCounters:    numCounters,
+		instanceType:   perfCounterSetMultiInstances,
+	}
+	for i := range template.counters {
+		template.counters[i] = perfCounterInfo{
+			counterID:   uint32(i) + counterLast,
+			counterType: perfCounterRawCount,
+			size:        counterSize,
+			detailLevel: perfDetailNovice,
+			offset:      uint32(i) * counterSize,
+		}
+	}
+
+	//nolint:gosec // No other way of calling a DLL proc.
+	if r, _, _ := perfSetCounterSetInfo.Call(uintptr(c.provider), uintptr(unsafe.Pointer(&template)), unsafe.Sizeof(template)); r != 0 {
+		return nil, fmt.Errorf("PerfSetCounterSetInfo: %w", syscall.Errno(r))
+	}
+
+	return c, nil
+}
+
+// Publish sets the counters of every distro to its latency. Distros that are gone are no longer published.
+func (c *Counters) Publish(latencies map[string]distro.Latency) (err error) {
+	defer decorate.OnError(&err, "could not update performance counters")
+
+	for name, instance := range c.instances {
+		if _, ok := latencies[name]; ok {
+			continue
+		}
+		_, _, _ = perfDeleteInstance.Call(uintptr(c.provider), instance)
+		delete(c.instances, name)
+	}
+
+	for name, l := range latencies {
+		instance, ok := c.instances[name]
+		if !ok {
+			var e error
+			if instance, e = c.createInstance(name); e != nil {
+				err = errors.Join(err, fmt.Errorf("distro %q: %v", name, e))
+				continue
+			}
+		}
+
+		for id, value := range counterValues(l) {
+			// The value is an uint32, so it always fits in the 32 bits of the counter (see counterSize).
+			if r, _, _ := perfSetULongCounterVal.Call(uintptr(c.provider), instance, uintptr(id), uintptr(value)); r != 0 {
+				err = errors.Join(err, fmt.Errorf("distro %q: counter %d: %w", name, id, syscall.Errno(r)))
+			}
+		}
+	}
+
+	return err
+}
+
+// createInstance creates the instance of the counter set of a distro.
+func (c *Counters) createInstance(name string) (uintptr, error) {
+	n, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	c.lastID++
+
+	//nolint:gosec // No other way of calling a DLL proc.
+	instance, _, e := perfCreateInstance.Call(uintptr(c.provider), uintptr(unsafe.Pointer(&counterSetGUID)), uintptr(unsafe.Pointer(n)), uintptr(c.lastID))
+	if instance == 0 {
+		return 0, fmt.Errorf("PerfCreateInstance: %w", e)
+	}
+
+	c.instances[name] = instance
+	return instance, nil
+}
+
+// Close stops publishing the counters.
+func (c *Counters) Close() {
+	for name, instance := range c.instances {
+		_, _, _ = perfDeleteInstance.Call(uintptr(c.provider), instance)
+		delete(c.instances, name)
+	}
+	if c.provider != 0 {
+		_, _, _ = perfStopProvider.Call(uintptr(c.provider))
+		c.provider = 0
+	}
+}
@@ -0,0 +1,4 @@
+package latency
+
+// CounterValues returns the value of every performance counter for the latency of a distro, indexed by counter identifier.
+var CounterValues = counterValues
@@ -0,0 +1,6 @@
+package maintenance
+
+// SystemActiveHours returns the active hours of the user. There are no active hours outside of Windows.
+func SystemActiveHours() (ActiveHours, error) {
+	return ActiveHours{}, nil
+}
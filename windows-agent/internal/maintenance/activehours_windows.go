@@ -0,0 +1,42 @@
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// activeHoursKey is the registry key under HKEY_LOCAL_MACHINE where Windows Update keeps the active hours.
+const activeHoursKey = `SOFTWARE\Microsoft\WindowsUpdate\UX\Settings`
+
+// SystemActiveHours returns the active hours set in the Windows Update settings, either by the user or
+// detected by Windows from their activity. No active hours are returned if they have never been set.
+func SystemActiveHours() (ActiveHours, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, activeHoursKey, registry.QUERY_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return ActiveHours{}, nil
+	}
+	if err != nil {
+		return ActiveHours{}, fmt.Errorf("could not open registry key HKLM\\%s: %v", activeHoursKey, err)
+	}
+	defer k.Close()
+
+	start, _, err := k.GetIntegerValue("ActiveHoursStart")
+	if errors.Is(err, registry.ErrNotExist) {
+		return ActiveHours{}, nil
+	}
+	if err != nil {
+		return ActiveHours{}, fmt.Errorf("could not read the start of the active hours: %v", err)
+	}
+
+	end, _, err := k.GetIntegerValue("ActiveHoursEnd")
+	if errors.Is(err, registry.ErrNotExist) {
+		return ActiveHours{}, nil
+	}
+	if err != nil {
+		return ActiveHours{}, fmt.Errorf("could not read the end of the active hours: %v", err)
+	}
+
+	return ActiveHours{Start: int(start), End: int(end)}, nil
+}
@@ -0,0 +1,321 @@
+// Package maintenance decides when automated, disruptive work (such as upgrading wsl-pro-service in
+// every distro) may start: during the maintenance windows chosen by the organization or, when there
+// are none, at any time outside of the user's active hours.
+//
+// Windows and active hours are expressed in local time, and they open and close according to the wall
+// clock, so they may be an hour longer or shorter on the days daylight saving time starts or ends.
+// Times skipped when the clocks go forwards are shifted forwards by the skipped hour.
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// Window is a recurring period of local time, such as "Sat,Sun 02:00-06:00". Windows that end
+// before they start span midnight: "Mon-Fri 22:00-05:00" opens on weekday evenings.
+type Window struct {
+	// days are the days of the week on which the window opens, indexed by time.Weekday.
+	days [7]bool
+
+	// start and end are the wall-clock times at which the window opens and closes, as offsets from
+	// midnight. The window closes on the next day if end is not after start.
+	start, end time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWindows parses a list of windows separated by semicolons or new lines. Each window has the form
+// "DAYS HH:MM-HH:MM", where DAYS is "daily" or a comma-separated list of days and ranges of days,
+// e.g. "Mon-Fri" or "Sat,Sun". An end time of 24:00 closes the window at midnight.
+func ParseWindows(spec string) ([]Window, error) {
+	var windows []Window
+
+	for _, s := range strings.FieldsFunc(spec, func(r rune) bool { return r == ';' || r == '\n' }) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		w, err := parseWindow(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %v", s, err)
+		}
+		windows = append(windows, w)
+	}
+
+	return windows, nil
+}
+
+func parseWindow(s string) (w Window, err error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return w, errors.New("expected days and time range, e.g. Mon-Fri 22:00-05:00")
+	}
+
+	if w.days, err = parseDays(fields[0]); err != nil {
+		return w, err
+	}
+
+	from, to, found := strings.Cut(fields[1], "-")
+	if !found {
+		return w, errors.New("expected a time range, e.g. 22:00-05:00")
+	}
+
+	start, err := parseClock(from)
+	if err != nil {
+		return w, err
+	}
+	if start == 24*time.Hour {
+		return w, errors.New("a window cannot open at 24:00")
+	}
+
+	end, err := parseClock(to)
+	if err != nil {
+		return w, err
+	}
+
+	w.start, w.end = start, end
+	return w, nil
+}
+
+func parseDays(s string) (days [7]bool, err error) {
+	if strings.EqualFold(s, "daily") {
+		return [7]bool{true, true, true, true, true, true, true}, nil
+	}
+
+	for _, r := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(r, "-")
+		if !isRange {
+			to = from
+		}
+
+		first, ok := weekdays[strings.ToLower(from)]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", from)
+		}
+		last, ok := weekdays[strings.ToLower(to)]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", to)
+		}
+
+		// Ranges may wrap around the end of the week, e.g. Fri-Mon.
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// parseClock parses a wall-clock time HH:MM into an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ActiveHours is the part of the day during which the user is usually working, in whole hours of
+// local time. They may span midnight. Equal Start and End mean there are no active hours.
+type ActiveHours struct {
+	Start int
+	End   int
+}
+
+// window returns the active hours as a window that opens every day.
+func (a ActiveHours) window() (w Window, ok bool) {
+	if a.Start == a.End || a.Start < 0 || a.Start > 23 || a.End < 0 || a.End > 24 {
+		return w, false
+	}
+
+	w.days = [7]bool{true, true, true, true, true, true, true}
+	w.start = time.Duration(a.Start) * time.Hour
+	w.end = time.Duration(a.End) * time.Hour
+
+	return w, true
+}
+
+// Schedule states when disruptive work may start.
+type Schedule struct {
+	// Windows are the maintenance windows. Work may start only while one is open. When there are
+	// none, work may start at any time outside of the active hours.
+	Windows []Window
+
+	// ActiveHours are the hours during which the user is working. They are ignored when there are
+	// maintenance windows: those are a deliberate choice of the organization.
+	ActiveHours ActiveHours
+
+	// Location is the time zone of the windows and active hours. Nil means local time.
+	Location *time.Location
+}
+
+// interval is a period of time between two instants, excluding the end.
+type interval struct {
+	start, end time.Time
+}
+
+func (i interval) contains(t time.Time) bool {
+	return !t.Before(i.start) && t.Before(i.end)
+}
+
+// occurrence returns the period of time during which the window is open if it opens on the given day.
+func (w Window) occurrence(day time.Time) (interval, bool) {
+	if !w.days[day.Weekday()] {
+		return interval{}, false
+	}
+
+	// Using the wall clock rather than adding the offsets to midnight, so that the window opens and
+	// closes at its local times on DST transition days. Skipped times are shifted forwards.
+	wallClock := func(days int, offset time.Duration) time.Time {
+		y, m, d := day.Date()
+		h, minute := int(offset/time.Hour), int(offset%time.Hour/time.Minute)
+		return time.Date(y, m, d+days, h, minute, 0, 0, day.Location())
+	}
+
+	i := interval{start: wallClock(0, w.start), end: wallClock(0, w.end)}
+	if w.end <= w.start {
+		i.end = wallClock(1, w.end)
+	}
+
+	return i, true
+}
+
+// occurrences returns the periods of time during which the window is open, starting on the days
+// from the day before t to the given number of days after it.
+func (w Window) occurrences(t time.Time, days int) []interval {
+	y, m, d := t.Date()
+
+	var out []interval
+	for i := -1; i <= days; i++ {
+		day := time.Date(y, m, d+i, 12, 0, 0, 0, t.Location())
+		if occ, ok := w.occurrence(day); ok {
+			out = append(out, occ)
+		}
+	}
+
+	return out
+}
+
+func (s Schedule) location() *time.Location {
+	if s.Location == nil {
+		return time.Local
+	}
+	return s.Location
+}
+
+// IsOpen returns true if disruptive work may start at the given time.
+func (s Schedule) IsOpen(t time.Time) bool {
+	t = t.In(s.location())
+
+	if len(s.Windows) > 0 {
+		for _, w := range s.Windows {
+			for _, occ := range w.occurrences(t, 0) {
+				if occ.contains(t) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	active, ok := s.ActiveHours.window()
+	if !ok {
+		return true
+	}
+
+	for _, occ := range active.occurrences(t, 0) {
+		if occ.contains(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Next returns the earliest time, starting from t, at which disruptive work may start.
+// It returns false if the schedule does not open within the next week.
+func (s Schedule) Next(t time.Time) (time.Time, bool) {
+	if s.IsOpen(t) {
+		return t, true
+	}
+
+	t = t.In(s.location())
+
+	// The schedule can only open when a window opens or the active hours end.
+	var candidates []time.Time
+	for _, w := range s.Windows {
+		for _, occ := range w.occurrences(t, 7) {
+			candidates = append(candidates, occ.start)
+		}
+	}
+	if active, ok := s.ActiveHours.window(); ok && len(s.Windows) == 0 {
+		for _, occ := range active.occurrences(t, 7) {
+			candidates = append(candidates, occ.end)
+		}
+	}
+
+	var next time.Time
+	for _, c := range candidates {
+		if !c.After(t) || !s.IsOpen(c) {
+			continue
+		}
+		if next.IsZero() || c.Before(next) {
+			next = c
+		}
+	}
+
+	return next, !next.IsZero()
+}
+
+// recheckInterval is the longest time Wait sleeps before evaluating the schedule again, so that changes
+// to the schedule, the clock or the time zone are taken into account.
+const recheckInterval = 15 * time.Minute
+
+// Wait blocks until the schedule returned by the function is open, or the context is cancelled.
+func Wait(ctx context.Context, schedule func() Schedule) error {
+	for {
+		now := time.Now()
+		s := schedule()
+
+		next, ok := s.Next(now)
+		if ok && !next.After(now) {
+			return nil
+		}
+
+		sleep := recheckInterval
+		if ok {
+			sleep = min(next.Sub(now), recheckInterval)
+			log.Debugf(ctx, "Maintenance: waiting until %s for the next maintenance window", next.Format(time.RFC1123))
+		} else {
+			log.Warningf(ctx, "Maintenance: the schedule does not open within the next week")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package maintenance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	_ "time/tzdata" // So that the time zones are available on every platform.
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/maintenance"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindows(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		spec string
+
+		wantCount int
+		wantErr   bool
+	}{
+		"Success with an empty spec":                    {spec: "", wantCount: 0},
+		"Success with a single window":                  {spec: "Sat,Sun 02:00-06:00", wantCount: 1},
+		"Success with a window every day":               {spec: "daily 01:00-02:00", wantCount: 1},
+		"Success with windows separated by semicolons":  {spec: "Mon-Fri 22:00-05:00; Sat 10:00-12:00", wantCount: 2},
+		"Success with windows separated by new lines":   {spec: "Mon-Fri 22:00-05:00\nSat 10:00-12:00\n", wantCount: 2},
+		"Success with a range wrapping around the week": {spec: "Fri-Mon 22:00-24:00", wantCount: 1},
+		"Success with case-insensitive days":            {spec: "mon,TUE 22:00-23:00", wantCount: 1},
+
+		"Error with a missing time range":      {spec: "Mon-Fri", wantErr: true},
+		"Error with an unknown day":            {spec: "Monday 22:00-23:00", wantErr: true},
+		"Error with an unknown day in a range": {spec: "Mon-Funday 22:00-23:00", wantErr: true},
+		"Error with a time that is not a time": {spec: "Mon 22:00-25:00", wantErr: true},
+		"Error with a single time":             {spec: "Mon 22:00", wantErr: true},
+		"Error with a window opening at 24:00": {spec: "Mon 24:00-02:00", wantErr: true},
+		"Error if any window is invalid":       {spec: "Mon 22:00-23:00; Tue", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			windows, err := maintenance.ParseWindows(tc.spec)
+			if tc.wantErr {
+				require.Error(t, err, "ParseWindows should return an error")
+				return
+			}
+			require.NoError(t, err, "ParseWindows should return no error")
+			require.Len(t, windows, tc.wantCount, "Mismatched number of windows")
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	loc, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err, "Setup: could not load time zone")
+
+	// In 2026, the clocks go forwards on Sunday 29th of March at 02:00 and backwards on Sunday 25th of October at 03:00.
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, loc)
+	}
+
+	testCases := map[string]struct {
+		windows     string
+		activeHours maintenance.ActiveHours
+		now         time.Time
+
+		want    time.Time
+		wantErr bool
+	}{
+		"Open at any time without windows nor active hours": {now: at(time.March, 25, 10, 0), want: at(time.March, 25, 10, 0)},
+
+		// Maintenance windows
+		"Open inside a window":                            {windows: "Mon-Fri 22:00-05:00", now: at(time.March, 25, 23, 0), want: at(time.March, 25, 23, 0)},
+		"Open after midnight in a window spanning it":     {windows: "Mon-Fri 22:00-05:00", now: at(time.March, 26, 4, 59), want: at(time.March, 26, 4, 59)},
+		"Open at the start of a window":                   {windows: "Mon-Fri 22:00-05:00", now: at(time.March, 25, 22, 0), want: at(time.March, 25, 22, 0)},
+		"Next window at the end of a window":              {windows: "Mon-Fri 22:00-05:00", now: at(time.March, 26, 5, 0), want: at(time.March, 26, 22, 0)},
+		"Next window later in the week":                   {windows: "Sat,Sun 02:00-06:00", now: at(time.March, 25, 10, 0), want: at(time.March, 28, 2, 0)},
+		"Next window on the following week":               {windows: "Mon 02:00-06:00", now: at(time.March, 23, 10, 0), want: at(time.March, 30, 2, 0)},
+		"Next window with a range wrapping the week":      {windows: "Fri-Mon 22:00-23:00", now: at(time.March, 24, 10, 0), want: at(time.March, 27, 22, 0)},
+		"Earliest of several windows":                     {windows: "Sat 10:00-12:00; Thu 03:00-04:00", now: at(time.March, 25, 10, 0), want: at(time.March, 26, 3, 0)},
+		"Active hours are ignored when there are windows": {windows: "daily 09:00-10:00", activeHours: maintenance.ActiveHours{Start: 8, End: 17}, now: at(time.March, 25, 9, 30), want: at(time.March, 25, 9, 30)},
+
+		// Active hours
+		"Open outside of the active hours":                  {activeHours: maintenance.ActiveHours{Start: 8, End: 17}, now: at(time.March, 25, 18, 0), want: at(time.March, 25, 18, 0)},
+		"Next at the end of the active hours":               {activeHours: maintenance.ActiveHours{Start: 8, End: 17}, now: at(time.March, 25, 10, 0), want: at(time.March, 25, 17, 0)},
+		"Next at the end of active hours spanning midnight": {activeHours: maintenance.ActiveHours{Start: 22, End: 6}, now: at(time.March, 26, 2, 0), want: at(time.March, 26, 6, 0)},
+		"Open at any time with empty active hours":          {activeHours: maintenance.ActiveHours{Start: 8, End: 8}, now: at(time.March, 25, 10, 0), want: at(time.March, 25, 10, 0)},
+
+		// Daylight saving time
+		"Window in local time after the clocks go forwards":  {windows: "daily 10:00-11:00", now: at(time.March, 28, 12, 0), want: at(time.March, 29, 10, 0)},
+		"Window in local time after the clocks go backwards": {windows: "daily 10:00-11:00", now: at(time.October, 24, 12, 0), want: at(time.October, 25, 10, 0)},
+		"Window starting in the skipped hour is shifted":     {windows: "Sun 02:30-04:00", now: at(time.March, 28, 12, 0), want: at(time.March, 29, 3, 30)},
+		"Active hours in local time when the clocks change":  {activeHours: maintenance.ActiveHours{Start: 0, End: 9}, now: at(time.March, 29, 1, 0), want: at(time.March, 29, 9, 0)},
+
+		"Error when the active hours last all day": {activeHours: maintenance.ActiveHours{Start: 0, End: 24}, now: at(time.March, 25, 10, 0), wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			windows, err := maintenance.ParseWindows(tc.windows)
+			require.NoError(t, err, "Setup: could not parse maintenance windows")
+
+			s := maintenance.Schedule{Windows: windows, ActiveHours: tc.activeHours, Location: loc}
+
+			got, ok := s.Next(tc.now)
+			if tc.wantErr {
+				require.False(t, ok, "Next should not find any time at which the schedule is open")
+				return
+			}
+			require.True(t, ok, "Next should find a time at which the schedule is open")
+			require.True(t, tc.want.Equal(got), "Mismatched next time: want %s, got %s", tc.want, got.In(loc))
+			require.True(t, s.IsOpen(got), "The schedule should be open at the time returned by Next")
+			require.Equal(t, tc.want.Equal(tc.now), s.IsOpen(tc.now), "IsOpen should agree with Next")
+		})
+	}
+}
+
+func TestIsOpenAcrossDST(t *testing.T) {
+	t.Parallel()
+
+	loc, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err, "Setup: could not load time zone")
+
+	windows, err := maintenance.ParseWindows("Sun 01:30-03:30")
+	require.NoError(t, err, "Setup: could not parse maintenance windows")
+	s := maintenance.Schedule{Windows: windows, Location: loc}
+
+	// The clocks go backwards at 03:00 CEST to 02:00 CET: the window closes at 03:30 CET, three hours after it opens.
+	opening := time.Date(2026, time.October, 25, 1, 30, 0, 0, loc)
+
+	require.False(t, s.IsOpen(opening.Add(-time.Minute)), "The window should be closed before it opens")
+	require.True(t, s.IsOpen(opening.Add(2*time.Hour)), "The window should still be open when the wall clock goes back to 02:30")
+	require.True(t, s.IsOpen(opening.Add(3*time.Hour-time.Minute)), "The window should be open until the wall clock reaches 03:30")
+	require.False(t, s.IsOpen(opening.Add(3*time.Hour)), "The window should be closed when the wall clock reaches 03:30")
+}
+
+func TestWait(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		closed bool
+
+		wantErr bool
+	}{
+		"Success when the schedule is open": {},
+
+		"Error when the context is cancelled before the schedule opens": {closed: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			var s maintenance.Schedule
+			if tc.closed {
+				s.ActiveHours = maintenance.ActiveHours{Start: 0, End: 24}
+			}
+
+			err := maintenance.Wait(ctx, func() maintenance.Schedule { return s })
+			if tc.wantErr {
+				require.ErrorIs(t, err, context.DeadlineExceeded, "Wait should return the context error")
+				return
+			}
+			require.NoError(t, err, "Wait should return no error")
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// Package metrics exposes the health of the agent in the text format of Prometheus, so that fleet operators
+// can scrape it over HTTP.
+//
+// The metrics are registered with the Default registry by the components they are about, and only served if
+// the agent is configured to listen for scrapes.
+package metrics
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Namespace prefixes the names of all the metrics of the agent.
+const Namespace = "ubuntu_pro_for_wsl_agent_"
+
+// Default is the registry the components of the agent register their metrics with.
+var Default = NewRegistry()
+
+// Registry is a set of metrics, written in the text format of Prometheus. It is safe for concurrent use.
+type Registry struct {
+	families map[string]family
+	mu       sync.Mutex
+}
+
+// family is a metric with all its samples.
+type family interface {
+	help() string
+	kind() string
+	samples() []Sample
+}
+
+// Sample is a value of a metric, for a given set of label values. Suffix is appended to the name of the metric,
+// for those exposing several series.
+type Sample struct {
+	Suffix string
+	Labels []string
+	Value  float64
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+// register adds the family under name, replacing any previous one with the same name.
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.families[Namespace+name] = f
+}
+
+// Counter registers a counter with the given label names and returns it.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec: newVec(help, labels)}
+	r.register(name, c)
+	return c
+}
+
+// Summary registers a summary, keeping the sum and count of its observations, with the given label names and
+// returns it.
+func (r *Registry) Summary(name, help string, labels ...string) *Summary {
+	s := &Summary{sums: newVec(help, labels), counts: newVec(help, labels)}
+	r.register(name, s)
+	return s
+}
+
+// GaugeFunc registers a gauge whose samples are collected when the metrics are written, so that they are
+// never stale. Registering a gauge with the same name again replaces it.
+func (r *Registry) GaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(name, &gaugeFunc{helpText: help, labels: labels, collect: collect})
+}
+
+// WriteTo writes all the metrics in the text format of Prometheus, sorted by name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := maps.Clone(r.families)
+	r.mu.Unlock()
+
+	names := slices.Sorted(maps.Keys(families))
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	for _, name := range names {
+		f := families[name]
+		labels := labelsOf(f)
+
+		fmt.Fprintf(cw, "# HELP %s %s\n", name, escapeHelp(f.help()))
+		fmt.Fprintf(cw, "# TYPE %s %s\n", name, f.kind())
+
+		samples := f.samples()
+		slices.SortFunc(samples, func(a, b Sample) int {
+			return cmp.Or(slices.Compare(a.Labels, b.Labels), cmp.Compare(a.Suffix, b.Suffix))
+		})
+
+		for _, s := range samples {
+			fmt.Fprintf(cw, "%s%s%s %s\n", name, s.Suffix, formatLabels(labels, s.Labels), formatValue(s.Value))
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, nil
+}
+
+// ServeHTTP implements http.Handler by writing all the metrics.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+// Counter is a value that only goes up, with one series per set of label values.
+type Counter struct {
+	vec *vec
+}
+
+// Inc increments the counter for the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.vec.add(1, labelValues)
+}
+
+// Add adds delta, which must not be negative, to the counter for the given label values.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.vec.add(delta, labelValues)
+}
+
+func (c *Counter) help() string      { return c.vec.helpText }
+func (c *Counter) kind() string      { return "counter" }
+func (c *Counter) samples() []Sample { return c.vec.samples("") }
+
+// Summary keeps the sum and the count of observations, with one series per set of label values.
+type Summary struct {
+	sums   *vec
+	counts *vec
+}
+
+// Observe records a value for the given label values.
+func (s *Summary) Observe(value float64, labelValues ...string) {
+	s.sums.add(value, labelValues)
+	s.counts.add(1, labelValues)
+}
+
+func (s *Summary) help() string { return s.sums.helpText }
+func (s *Summary) kind() string { return "summary" }
+func (s *Summary) samples() []Sample {
+	return append(s.sums.samples("_sum"), s.counts.samples("_count")...)
+}
+
+// gaugeFunc is a gauge whose samples are collected when the metrics are written.
+type gaugeFunc struct {
+	helpText string
+	labels   []string
+	collect  func() []Sample
+}
+
+func (g *gaugeFunc) help() string      { return g.helpText }
+func (g *gaugeFunc) kind() string      { return "gauge" }
+func (g *gaugeFunc) samples() []Sample { return g.collect() }
+
+// vec holds one value per set of label values.
+type vec struct {
+	helpText string
+	labels   []string
+
+	values map[string]*Sample
+	mu     sync.Mutex
+}
+
+func newVec(help string, labels []string) *vec {
+	return &vec{helpText: help, labels: labels, values: make(map[string]*Sample)}
+}
+
+// add adds delta to the value for the label values. Missing label values are empty, and extra ones are ignored.
+func (v *vec) add(delta float64, labelValues []string) {
+	values := make([]string, len(v.labels))
+	copy(values, labelValues)
+	// Label values cannot contain a NUL byte, which makes them safe to join.
+	key := strings.Join(values, "\x00")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, ok := v.values[key]
+	if !ok {
+		s = &Sample{Labels: values}
+		v.values[key] = s
+	}
+	s.Value += delta
+}
+
+// samples returns a copy of all the values, with the given suffix.
+func (v *vec) samples(suffix string) []Sample {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	samples := make([]Sample, 0, len(v.values))
+	for _, s := range v.values {
+		samples = append(samples, Sample{Suffix: suffix, Labels: s.Labels, Value: s.Value})
+	}
+	return samples
+}
+
+// labelsOf returns the label names of a family.
+func labelsOf(f family) []string {
+	switch f := f.(type) {
+	case *Counter:
+		return f.vec.labels
+	case *Summary:
+		return f.sums.labels
+	case *gaugeFunc:
+		return f.labels
+	}
+	return nil
+}
+
+// formatLabels returns the label pairs of a sample between braces, or nothing if it has no labels.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escapeLabelValue escapes the characters the text format does not allow in label values.
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp escapes the characters the text format does not allow in help texts.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// formatValue formats a value the way Prometheus parses it.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,207 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTo(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		register func(r *metrics.Registry)
+
+		want string
+	}{
+		"Success writing nothing without metrics": {register: func(*metrics.Registry) {}},
+		"Success writing a counter without samples": {
+			register: func(r *metrics.Registry) { r.Counter("failures_total", "Failures.", "distro") },
+			want: `# HELP ubuntu_pro_for_wsl_agent_failures_total Failures.
+# TYPE ubuntu_pro_for_wsl_agent_failures_total counter
+`,
+		},
+		"Success writing a counter per label values sorted": {
+			register: func(r *metrics.Registry) {
+				c := r.Counter("failures_total", "Failures.", "distro", "task")
+				c.Inc("Ubuntu", "tasks.ProAttachment")
+				c.Inc("Debian", "tasks.ProAttachment")
+				c.Inc("Ubuntu", "tasks.ProAttachment")
+			},
+			want: `# HELP ubuntu_pro_for_wsl_agent_failures_total Failures.
+# TYPE ubuntu_pro_for_wsl_agent_failures_total counter
+ubuntu_pro_for_wsl_agent_failures_total{distro="Debian",task="tasks.ProAttachment"} 1
+ubuntu_pro_for_wsl_agent_failures_total{distro="Ubuntu",task="tasks.ProAttachment"} 2
+`,
+		},
+		"Success adding to a counter and ignoring negative deltas": {
+			register: func(r *metrics.Registry) {
+				c := r.Counter("bytes_total", "Bytes.", "distro")
+				c.Add(512, "Ubuntu")
+				c.Add(1024, "Ubuntu")
+				c.Add(-256, "Ubuntu")
+			},
+			want: `# HELP ubuntu_pro_for_wsl_agent_bytes_total Bytes.
+# TYPE ubuntu_pro_for_wsl_agent_bytes_total counter
+ubuntu_pro_for_wsl_agent_bytes_total{distro="Ubuntu"} 1536
+`,
+		},
+		"Success writing the missing label values as empty": {
+			register: func(r *metrics.Registry) { r.Counter("failures_total", "Failures.", "distro", "task").Inc("Ubuntu") },
+			want: `# HELP ubuntu_pro_for_wsl_agent_failures_total Failures.
+# TYPE ubuntu_pro_for_wsl_agent_failures_total counter
+ubuntu_pro_for_wsl_agent_failures_total{distro="Ubuntu",task=""} 1
+`,
+		},
+		"Success writing a summary as its sum and count": {
+			register: func(r *metrics.Registry) {
+				s := r.Summary("latency_seconds", "Latency.", "path")
+				s.Observe(0.25, "/v1/token")
+				s.Observe(1.5, "/v1/token")
+			},
+			want: `# HELP ubuntu_pro_for_wsl_agent_latency_seconds Latency.
+# TYPE ubuntu_pro_for_wsl_agent_latency_seconds summary
+ubuntu_pro_for_wsl_agent_latency_seconds_count{path="/v1/token"} 2
+ubuntu_pro_for_wsl_agent_latency_seconds_sum{path="/v1/token"} 1.75
+`,
+		},
+		"Success writing a gauge collected when written": {
+			register: func(r *metrics.Registry) {
+				r.GaugeFunc("connected", "Connected.", nil, func() []metrics.Sample {
+					return []metrics.Sample{{Value: 3}}
+				})
+			},
+			want: `# HELP ubuntu_pro_for_wsl_agent_connected Connected.
+# TYPE ubuntu_pro_for_wsl_agent_connected gauge
+ubuntu_pro_for_wsl_agent_connected 3
+`,
+		},
+		"Success writing the metrics sorted by name": {
+			register: func(r *metrics.Registry) {
+				r.GaugeFunc("b", "B.", nil, func() []metrics.Sample { return []metrics.Sample{{Value: 2}} })
+				r.GaugeFunc("a", "A.", nil, func() []metrics.Sample { return []metrics.Sample{{Value: 1}} })
+			},
+			want: `# HELP ubuntu_pro_for_wsl_agent_a A.
+# TYPE ubuntu_pro_for_wsl_agent_a gauge
+ubuntu_pro_for_wsl_agent_a 1
+# HELP ubuntu_pro_for_wsl_agent_b B.
+# TYPE ubuntu_pro_for_wsl_agent_b gauge
+ubuntu_pro_for_wsl_agent_b 2
+`,
+		},
+		"Success replacing a metric registered again": {
+			register: func(r *metrics.Registry) {
+				r.GaugeFunc("a", "Old.", nil, func() []metrics.Sample { return []metrics.Sample{{Value: 1}} })
+				r.GaugeFunc("a", "New.", nil, func() []metrics.Sample { return []metrics.Sample{{Value: 2}} })
+			},
+			want: `# HELP ubuntu_pro_for_wsl_agent_a New.
+# TYPE ubuntu_pro_for_wsl_agent_a gauge
+ubuntu_pro_for_wsl_agent_a 2
+`,
+		},
+		"Success escaping the label values and help": {
+			register: func(r *metrics.Registry) {
+				r.Counter("a", "Line\nback\\slash", "distro").Inc("\"quoted\"\nback\\slash")
+			},
+			want: `# HELP ubuntu_pro_for_wsl_agent_a Line\nback\\slash
+# TYPE ubuntu_pro_for_wsl_agent_a counter
+ubuntu_pro_for_wsl_agent_a{distro="\"quoted\"\nback\\slash"} 1
+`,
+		},
+		"Success writing special values": {
+			register: func(r *metrics.Registry) {
+				r.GaugeFunc("a", "A.", []string{"v"}, func() []metrics.Sample {
+					return []metrics.Sample{
+						{Labels: []string{"inf"}, Value: math.Inf(1)},
+						{Labels: []string{"nan"}, Value: math.NaN()},
+						{Labels: []string{"small"}, Value: 1e-9},
+					}
+				})
+			},
+			want: `# HELP ubuntu_pro_for_wsl_agent_a A.
+# TYPE ubuntu_pro_for_wsl_agent_a gauge
+ubuntu_pro_for_wsl_agent_a{v="inf"} +Inf
+ubuntu_pro_for_wsl_agent_a{v="nan"} NaN
+ubuntu_pro_for_wsl_agent_a{v="small"} 1e-09
+`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := metrics.NewRegistry()
+			tc.register(r)
+
+			var out strings.Builder
+			n, err := r.WriteTo(&out)
+			require.NoError(t, err, "WriteTo should not fail")
+			require.Equal(t, tc.want, out.String(), "WriteTo should have written the metrics in the text format")
+			require.Equal(t, int64(out.Len()), n, "WriteTo should return how many bytes it wrote")
+		})
+	}
+}
+
+func TestListen(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		addressInUse bool
+
+		wantErr bool
+	}{
+		"Success serving the metrics": {},
+
+		"Error when the address is in use": {addressInUse: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			r := metrics.NewRegistry()
+			r.Counter("failures_total", "Failures.").Inc()
+
+			address := "127.0.0.1:0"
+			if tc.addressInUse {
+				other, err := metrics.Listen(ctx, address, r)
+				require.NoError(t, err, "Setup: Listen should not fail")
+				defer other.Close()
+				address = other.Addr().String()
+			}
+
+			s, err := metrics.Listen(ctx, address, r)
+			if tc.wantErr {
+				require.Error(t, err, "Listen should have failed")
+				return
+			}
+			require.NoError(t, err, "Listen should not fail")
+			defer s.Close()
+
+			//nolint:noctx // The request is local and the test times out anyway.
+			res, err := http.Get("http://" + s.Addr().String() + metrics.Path)
+			require.NoError(t, err, "Scraping the metrics should not fail")
+			defer res.Body.Close()
+
+			require.Equal(t, http.StatusOK, res.StatusCode, "Scraping the metrics should succeed")
+			require.Contains(t, res.Header.Get("Content-Type"), "text/plain", "The metrics should be served as plain text")
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err, "Reading the metrics should not fail")
+			require.Contains(t, string(body), "ubuntu_pro_for_wsl_agent_failures_total 1", "The metrics of the registry should be served")
+
+			require.NoError(t, s.Close(), "Close should not fail")
+			//nolint:noctx // The request is local and the test times out anyway.
+			_, err = http.Get("http://" + s.Addr().String() + metrics.Path)
+			require.Error(t, err, "The metrics should not be served once closed")
+		})
+	}
+}
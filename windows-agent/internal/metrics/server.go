@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// Path is where the metrics are served.
+const Path = "/metrics"
+
+// Server serves the metrics of a registry over HTTP.
+type Server struct {
+	server *http.Server
+	addr   net.Addr
+}
+
+// Listen starts serving the metrics of the registry at Path on the given address, such as "127.0.0.1:9464".
+// The metrics tell which distros are installed, so the address should not be reachable from other machines
+// unless the fleet operators' scrapers need it to be.
+func Listen(ctx context.Context, address string, r *Registry) (*Server, error) {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen for metrics scrapes: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(Path, r)
+
+	s := &Server{
+		server: &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		addr:   lis.Addr(),
+	}
+
+	go func() {
+		defer crashreport.Recover("metrics")
+		if err := s.server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warningf(ctx, "Metrics: stopped serving: %v", err)
+		}
+	}()
+
+	log.Infof(ctx, "Metrics: serving on http://%s%s", s.addr, Path)
+	return s, nil
+}
+
+// Addr returns the address the metrics are served on.
+func (s *Server) Addr() net.Addr {
+	return s.addr
+}
+
+// Close stops serving the metrics.
+func (s *Server) Close() error {
+	return s.server.Close()
+}
@@ -0,0 +1,5 @@
+// Package namedpipe serves and dials the named pipes the command line clients of the agent reach it through, rather
+// than going through loopback TCP. Only the user running the agent and the administrators can connect to them.
+//
+// On Linux, where there are no named pipes, Unix sockets stand in for them for testing purposes.
+package namedpipe
@@ -0,0 +1,24 @@
+package namedpipe
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// Path returns the path of the named pipe with the given name.
+func Path(name string) string {
+	return filepath.Join(os.TempDir(), name)
+}
+
+// Listen creates the named pipe at path and returns a listener accepting its clients.
+func Listen(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
+
+// Dial connects to the named pipe at path.
+func Dial(ctx context.Context, path string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", path)
+}
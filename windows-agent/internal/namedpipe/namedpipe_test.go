@@ -0,0 +1,63 @@
+package namedpipe_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/namedpipe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenDial(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	path := namedpipe.Path(fmt.Sprintf("up4w-test-%d", rand.Uint64()))
+
+	lis, err := namedpipe.Listen(path)
+	require.NoError(t, err, "Listen should create the named pipe")
+	defer lis.Close()
+
+	_, err = namedpipe.Listen(path)
+	require.Error(t, err, "Listen should fail when the named pipe already exists")
+
+	accepted := make(chan error)
+	go func() {
+		defer close(accepted)
+
+		conn, err := lis.Accept()
+		if err != nil {
+			accepted <- err
+			return
+		}
+		defer conn.Close()
+
+		// Echoes what the client sends until it closes its end.
+		_, err = io.Copy(conn, conn)
+		accepted <- err
+	}()
+
+	conn, err := namedpipe.Dial(ctx, path)
+	require.NoError(t, err, "Dial should connect to the named pipe")
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err, "Write should not fail")
+
+	got := make([]byte, len("hello"))
+	_, err = io.ReadFull(conn, got)
+	require.NoError(t, err, "Read should not fail")
+	require.Equal(t, "hello", string(got), "The server should have echoed what the client sent")
+
+	require.NoError(t, conn.Close(), "Close should not fail")
+	require.NoError(t, <-accepted, "The server should see the client leaving as the end of the stream")
+
+	require.NoError(t, lis.Close(), "Closing the listener should not fail")
+	_, err = namedpipe.Dial(ctx, path)
+	require.Error(t, err, "Dial should fail once the listener is closed")
+}
@@ -0,0 +1,42 @@
+package namedpipe
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+)
+
+// bufferSize is the size of the input and output buffers of the pipe instances.
+const bufferSize = 64 * 1024
+
+// Path returns the path of the named pipe with the given name.
+func Path(name string) string {
+	return `\\.\pipe\` + name
+}
+
+// Listen creates the named pipe at path and returns a listener accepting its clients. The pipe is only reachable
+// by the user running this process, by the administrators and by the system, and rejects remote clients. It fails
+// if there is already a pipe at path, so that the pipe of another process cannot be hijacked.
+func Listen(path string) (net.Listener, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the current user: %v", err)
+	}
+
+	// Full access for the system, the administrators and the current user, and nobody else. Administrators are only
+	// granted access from elevated processes. The DACL is protected from inheritance.
+	// Remote clients are always rejected by winio, and creating the first instance fails if the pipe exists.
+	return winio.ListenPipe(path, &winio.PipeConfig{
+		SecurityDescriptor: fmt.Sprintf("D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GA;;;%s)", user.User.Sid),
+		InputBufferSize:    bufferSize,
+		OutputBufferSize:   bufferSize,
+	})
+}
+
+// Dial connects to the named pipe at path, waiting for an instance of the pipe to be available if they are all busy.
+func Dial(ctx context.Context, path string) (net.Conn, error) {
+	return winio.DialPipeContext(ctx, path)
+}
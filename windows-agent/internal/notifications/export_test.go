@@ -0,0 +1,10 @@
+package notifications
+
+import "time"
+
+// WithInterval overrides the period between summaries for the given frequency.
+func WithInterval(f Frequency, d time.Duration) Option {
+	return func(o *options) {
+		o.intervals[f] = d
+	}
+}
@@ -0,0 +1,257 @@
+// Package notifications shows toast notifications to the user. Low-priority events are batched
+// into a periodic summary toast, while critical events are shown immediately.
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// Priority indicates how urgently an event must be brought to the user's attention.
+type Priority int
+
+const (
+	// Low priority events are batched into a periodic summary.
+	Low Priority = iota
+
+	// Critical events are shown immediately, regardless of the frequency settings.
+	Critical
+)
+
+// Category groups low priority events in the summary, e.g. "3 distros updated".
+type Category struct {
+	// One is the text shown when a single event of this category happened.
+	One string
+	// Many is the text shown when several events of this category happened.
+	Many string
+}
+
+// Categories of the events emitted by the agent.
+var (
+	CategoryAttachPending   = Category{One: "attach pending", Many: "attaches pending"}
+	CategoryAttached        = Category{One: "distro attached", Many: "distros attached"}
+	CategoryUpgradePending  = Category{One: "distro upgrade pending", Many: "distro upgrades pending"}
+	CategoryRenewalReminder = Category{One: "subscription renewal reminder", Many: "subscription renewal reminders"}
+)
+
+// Event is something the user should be notified about.
+type Event struct {
+	Priority Priority
+	Category Category
+	Message  string
+}
+
+// Frequency is how often the summary of low priority events is shown.
+type Frequency string
+
+const (
+	// FrequencyImmediate shows every low priority event as it happens.
+	FrequencyImmediate Frequency = "immediate"
+	// FrequencyHourly shows a summary of low priority events at most once an hour.
+	FrequencyHourly Frequency = "hourly"
+	// FrequencyDaily shows a summary of low priority events at most once a day.
+	FrequencyDaily Frequency = "daily"
+	// FrequencyNever discards low priority events. Critical events are still shown.
+	FrequencyNever Frequency = "never"
+
+	// DefaultFrequency is the frequency used when the user did not choose one.
+	DefaultFrequency = FrequencyHourly
+)
+
+// ParseFrequency validates a frequency. The empty string is parsed as the default frequency.
+func ParseFrequency(s string) (Frequency, error) {
+	switch f := Frequency(s); f {
+	case "":
+		return DefaultFrequency, nil
+	case FrequencyImmediate, FrequencyHourly, FrequencyDaily, FrequencyNever:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown notification frequency %q", s)
+	}
+}
+
+// title is the title of every toast shown by the agent.
+const title = "Ubuntu Pro for WSL"
+
+// Toaster shows a toast notification to the user.
+type Toaster interface {
+	Toast(ctx context.Context, title, body string) error
+}
+
+// Digest batches low priority events into periodic summaries and lets critical events through.
+type Digest struct {
+	toaster   Toaster
+	intervals map[Frequency]time.Duration
+
+	frequency Frequency
+	// pending counts the low priority events of each category since the last summary,
+	// and order lists these categories in the order they first appeared.
+	pending map[Category]int
+	order   []Category
+	mu      sync.Mutex
+
+	// reset is used to restart the summary timer after the frequency changes.
+	reset  chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type options struct {
+	toaster   Toaster
+	intervals map[Frequency]time.Duration
+}
+
+// Option is an optional argument for New.
+type Option func(*options)
+
+// WithToaster overrides the platform's toast notifications. Useful for testing.
+func WithToaster(t Toaster) Option {
+	return func(o *options) {
+		o.toaster = t
+	}
+}
+
+// New creates a Digest and starts summarizing low priority events at the given frequency.
+// Call Stop to release its resources.
+func New(ctx context.Context, frequency Frequency, args ...Option) *Digest {
+	opts := options{
+		toaster: defaultToaster{},
+		intervals: map[Frequency]time.Duration{
+			FrequencyHourly: time.Hour,
+			FrequencyDaily:  24 * time.Hour,
+		},
+	}
+
+	for _, f := range args {
+		f(&opts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	d := &Digest{
+		toaster:   opts.toaster,
+		intervals: opts.intervals,
+		frequency: frequency,
+		pending:   make(map[Category]int),
+		reset:     make(chan struct{}, 1),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	go d.run(ctx)
+
+	return d
+}
+
+// Stop stops summarizing events. Pending low priority events are discarded.
+func (d *Digest) Stop() {
+	d.cancel()
+	<-d.done
+}
+
+// SetFrequency changes how often the summary is shown. The next summary is shown one full period
+// after this call.
+func (d *Digest) SetFrequency(f Frequency) {
+	d.mu.Lock()
+	d.frequency = f
+	d.mu.Unlock()
+
+	select {
+	case d.reset <- struct{}{}:
+	default:
+		// A reset is already pending.
+	}
+}
+
+// Notify shows critical events immediately, and stores low priority events for the next summary.
+func (d *Digest) Notify(ctx context.Context, e Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e.Priority >= Critical || d.frequency == FrequencyImmediate {
+		d.toast(ctx, e.Message)
+		return
+	}
+
+	if d.frequency == FrequencyNever {
+		log.Debugf(ctx, "Notifications: discarding event: %s", e.Message)
+		return
+	}
+
+	if _, ok := d.pending[e.Category]; !ok {
+		d.order = append(d.order, e.Category)
+	}
+	d.pending[e.Category]++
+}
+
+// Flush shows the summary of the pending low priority events, if any.
+func (d *Digest) Flush(ctx context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.order) == 0 {
+		return
+	}
+
+	parts := make([]string, 0, len(d.order))
+	for _, c := range d.order {
+		n := d.pending[c]
+		text := c.Many
+		if n == 1 {
+			text = c.One
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, text))
+	}
+
+	d.pending = make(map[Category]int)
+	d.order = nil
+
+	d.toast(ctx, strings.Join(parts, ", "))
+}
+
+// toast shows a toast, logging any error. Notifications are best-effort.
+func (d *Digest) toast(ctx context.Context, body string) {
+	if err := d.toaster.Toast(ctx, title, body); err != nil {
+		log.Warningf(ctx, "Notifications: could not show notification %q: %v", body, err)
+	}
+}
+
+// run shows the summary periodically until the context is cancelled.
+func (d *Digest) run(ctx context.Context) {
+	defer crashreport.Recover("notifications")
+	defer close(d.done)
+
+	for {
+		d.mu.Lock()
+		interval, ok := d.intervals[d.frequency]
+		d.mu.Unlock()
+
+		if !ok {
+			// Events are not summarized at this frequency: show those received before the change.
+			d.Flush(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.reset:
+			}
+			continue
+		}
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-d.reset:
+			t.Stop()
+		case <-t.C:
+			d.Flush(ctx)
+		}
+	}
+}
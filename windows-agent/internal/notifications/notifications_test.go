@@ -0,0 +1,175 @@
+package notifications_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/notifications"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrequency(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		input string
+
+		want    notifications.Frequency
+		wantErr bool
+	}{
+		"Success with an empty frequency": {input: "", want: notifications.DefaultFrequency},
+		"Success with immediate":          {input: "immediate", want: notifications.FrequencyImmediate},
+		"Success with hourly":             {input: "hourly", want: notifications.FrequencyHourly},
+		"Success with daily":              {input: "daily", want: notifications.FrequencyDaily},
+		"Success with never":              {input: "never", want: notifications.FrequencyNever},
+
+		"Error with an unknown frequency": {input: "weekly", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := notifications.ParseFrequency(tc.input)
+			if tc.wantErr {
+				require.Error(t, err, "ParseFrequency should have returned an error")
+				return
+			}
+			require.NoError(t, err, "ParseFrequency should have returned no error")
+			require.Equal(t, tc.want, got, "Mismatched frequency")
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+
+	updated := notifications.Category{One: "distro updated", Many: "distros updated"}
+	pending := notifications.Category{One: "attach pending", Many: "attaches pending"}
+
+	lowUpdated := notifications.Event{Priority: notifications.Low, Category: updated, Message: "Ubuntu updated"}
+	lowPending := notifications.Event{Priority: notifications.Low, Category: pending, Message: "Ubuntu attach pending"}
+	critical := notifications.Event{Priority: notifications.Critical, Message: "Subscription expired"}
+
+	testCases := map[string]struct {
+		frequency  notifications.Frequency
+		events     []notifications.Event
+		toasterErr bool
+
+		wantBeforeFlush []string
+		wantAfterFlush  []string
+	}{
+		"Low priority events are summarized": {
+			frequency:      notifications.FrequencyDaily,
+			events:         []notifications.Event{lowUpdated, lowPending, lowUpdated, lowUpdated},
+			wantAfterFlush: []string{"3 distros updated, 1 attach pending"},
+		},
+		"Critical events are shown immediately": {
+			frequency:       notifications.FrequencyDaily,
+			events:          []notifications.Event{lowUpdated, critical},
+			wantBeforeFlush: []string{"Subscription expired"},
+			wantAfterFlush:  []string{"Subscription expired", "1 distro updated"},
+		},
+		"Low priority events are shown immediately with the immediate frequency": {
+			frequency:       notifications.FrequencyImmediate,
+			events:          []notifications.Event{lowUpdated, lowPending},
+			wantBeforeFlush: []string{"Ubuntu updated", "Ubuntu attach pending"},
+			wantAfterFlush:  []string{"Ubuntu updated", "Ubuntu attach pending"},
+		},
+		"Low priority events are discarded with the never frequency": {
+			frequency:       notifications.FrequencyNever,
+			events:          []notifications.Event{lowUpdated, critical},
+			wantBeforeFlush: []string{"Subscription expired"},
+			wantAfterFlush:  []string{"Subscription expired"},
+		},
+		"No summary when there are no events": {
+			frequency: notifications.FrequencyDaily,
+		},
+
+		"Toaster errors are ignored": {
+			frequency:  notifications.FrequencyDaily,
+			events:     []notifications.Event{lowUpdated},
+			toasterErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			toaster := &mockToaster{err: tc.toasterErr}
+			d := notifications.New(ctx, tc.frequency, notifications.WithToaster(toaster))
+			defer d.Stop()
+
+			for _, e := range tc.events {
+				d.Notify(ctx, e)
+			}
+			require.Equal(t, tc.wantBeforeFlush, toaster.bodies(), "Mismatched notifications before the summary")
+
+			d.Flush(ctx)
+			require.Equal(t, tc.wantAfterFlush, toaster.bodies(), "Mismatched notifications after the summary")
+
+			d.Flush(ctx)
+			require.Equal(t, tc.wantAfterFlush, toaster.bodies(), "Flushing twice should not show the summary again")
+		})
+	}
+}
+
+func TestPeriodicSummary(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	updated := notifications.Category{One: "distro updated", Many: "distros updated"}
+
+	toaster := &mockToaster{}
+	d := notifications.New(ctx, notifications.FrequencyDaily,
+		notifications.WithToaster(toaster),
+		notifications.WithInterval(notifications.FrequencyHourly, 100*time.Millisecond),
+	)
+	defer d.Stop()
+
+	d.Notify(ctx, notifications.Event{Category: updated})
+	time.Sleep(300 * time.Millisecond)
+	require.Empty(t, toaster.bodies(), "No summary should be shown before the daily period")
+
+	d.SetFrequency(notifications.FrequencyHourly)
+	require.Eventually(t, func() bool { return len(toaster.bodies()) == 1 }, time.Second, 10*time.Millisecond,
+		"The summary should have been shown after changing the frequency")
+	require.Equal(t, []string{"1 distro updated"}, toaster.bodies(), "Mismatched summary")
+
+	d.Notify(ctx, notifications.Event{Category: updated})
+	d.SetFrequency(notifications.FrequencyNever)
+	require.Eventually(t, func() bool { return len(toaster.bodies()) == 2 }, time.Second, 10*time.Millisecond,
+		"Pending events should have been shown after disabling the summary")
+}
+
+type mockToaster struct {
+	err bool
+
+	got []string
+	mu  sync.Mutex
+}
+
+func (m *mockToaster) Toast(ctx context.Context, title, body string) error {
+	if m.err {
+		return errors.New("mock error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.got = append(m.got, body)
+	return nil
+}
+
+func (m *mockToaster) bodies() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.got == nil {
+		return nil
+	}
+	return append([]string{}, m.got...)
+}
@@ -0,0 +1,16 @@
+package notifications
+
+import (
+	"context"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// defaultToaster on Linux only logs the notifications, as there are no toasts to show.
+type defaultToaster struct{}
+
+// Toast logs the notification.
+func (defaultToaster) Toast(ctx context.Context, title, body string) error {
+	log.Infof(ctx, "Notification: %s: %s", title, body)
+	return nil
+}
@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// createNoWindow prevents PowerShell from flashing a console window.
+// See https://learn.microsoft.com/en-us/windows/win32/procthread/process-creation-flags
+const createNoWindow = 0x08000000
+
+// powershellAppID is the Application User Model ID of PowerShell, which is allowed to show toasts
+// without registering a shortcut in the Start menu.
+const powershellAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// showToastScript shows the toast in $env:UP4W_TOAST_XML. The XML is passed via the environment
+// so that no user-provided text is ever interpreted as PowerShell code.
+const showToastScript = `
+$ErrorActionPreference = 'Stop'
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] > $null
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml($env:UP4W_TOAST_XML)
+$toast = New-Object Windows.UI.Notifications.ToastNotification $xml
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($env:UP4W_TOAST_APPID).Show($toast)
+`
+
+// defaultToaster shows toasts via the Windows Runtime notification API.
+type defaultToaster struct{}
+
+// Toast shows a toast notification with the given title and body.
+func (defaultToaster) Toast(ctx context.Context, title, body string) error {
+	var t, b strings.Builder
+	if err := xml.EscapeText(&t, []byte(title)); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(&b, []byte(body)); err != nil {
+		return err
+	}
+
+	toastXML := fmt.Sprintf(`<toast><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual></toast>`, t.String(), b.String())
+
+	cmd := exec.CommandContext(ctx, "powershell.exe", "-NoLogo", "-NoProfile", "-NonInteractive", "-Command", showToastScript)
+	cmd.Env = append(os.Environ(), "UP4W_TOAST_XML="+toastXML, "UP4W_TOAST_APPID="+powershellAppID)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: createNoWindow,
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package operations
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// csvHeader are the columns of the CSV export. Every row is the outcome of the operation in a distro.
+var csvHeader = []string{"operation_id", "kind", "description", "started", "distro", "status", "reason", "finished"}
+
+// WriteCSV writes the outcomes of the operation into w as CSV, with a header.
+func WriteCSV(w io.Writer, op Operation) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("could not write CSV header: %v", err)
+	}
+
+	for _, o := range op.Outcomes {
+		row := []string{op.ID, op.Kind, op.Description, op.Started, o.Distro, string(o.Status), o.Reason, o.Finished}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("could not write CSV record of %q: %v", o.Distro, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the operation into w as an indented JSON object.
+func WriteJSON(w io.Writer, op Operation) error {
+	if op.Outcomes == nil {
+		op.Outcomes = []Outcome{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(op); err != nil {
+		return fmt.Errorf("could not write JSON operation: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+// Package operations records the outcome in every distro of the operations that submit the same task to
+// several of them at once, such as attaching them all to Ubuntu Pro. The operations are kept in the embedded
+// store, so that admins can later export them as evidence of fleet-wide changes.
+package operations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/ubuntu/decorate"
+)
+
+// Status is where the task of an operation stands in a distro.
+type Status string
+
+const (
+	// StatusPending is the status of a task that did not finish yet.
+	StatusPending Status = "pending"
+	// StatusSucceeded is the status of a task that completed.
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed is the status of a task that failed, or could not be submitted.
+	StatusFailed Status = "failed"
+	// StatusUnknown is the status of a task that was still pending when the agent stopped following it, e.g.
+	// because the distro was removed or the agent stopped.
+	StatusUnknown Status = "unknown"
+)
+
+// Operation is a task submitted to several distros at once, along with its outcome in each of them.
+type Operation struct {
+	ID string `json:"id"`
+
+	// Kind is what the operation does, e.g. pro-attach.
+	Kind string `json:"kind"`
+
+	// Description tells the operation apart from others of its kind, e.g. the service it enabled.
+	Description string `json:"description,omitempty"`
+
+	// Started is the RFC3339 time the operation was submitted.
+	Started string `json:"started"`
+
+	Outcomes []Outcome `json:"outcomes"`
+}
+
+// Outcome is the outcome of an operation in a distro.
+type Outcome struct {
+	Distro string `json:"distro"`
+	Status Status `json:"status"`
+
+	// Reason is why the task failed. Only set for StatusFailed and StatusUnknown.
+	Reason string `json:"reason,omitempty"`
+
+	// Finished is the RFC3339 time the task finished, empty if it did not.
+	Finished string `json:"finished,omitempty"`
+}
+
+// Count returns how many distros the task of the operation has the given status in.
+func (o Operation) Count(s Status) (n int) {
+	for _, out := range o.Outcomes {
+		if out.Status == s {
+			n++
+		}
+	}
+	return n
+}
+
+// Distro is a distro operations can submit tasks to.
+type Distro interface {
+	Name() string
+	SubmitTasks(tasks ...task.Task) error
+	WatchTasks(ctx context.Context) (<-chan worker.Event, error)
+}
+
+// DefaultCapacity is the number of operations kept by default. Older ones are discarded as new ones start.
+const DefaultCapacity = 200
+
+// Tracker submits the tasks of operations and records their outcome in the embedded store. It is safe for
+// concurrent use. A nil Tracker submits the tasks without recording anything.
+type Tracker struct {
+	store    *store.Store
+	capacity int
+
+	// mu serializes the updates of the operations, which are read, modified and written back.
+	mu sync.Mutex
+
+	// wg tracks the goroutines that follow the tasks of the operations.
+	wg sync.WaitGroup
+	// ctx is cancelled when the tracker is closed, to stop following the tasks.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type options struct {
+	capacity int
+}
+
+// Option is an optional argument for New.
+type Option func(*options)
+
+// WithCapacity sets how many operations are kept. Older ones are discarded as new ones start.
+func WithCapacity(n int) Option {
+	return func(o *options) {
+		o.capacity = n
+	}
+}
+
+// New creates a tracker that keeps the operations in the embedded store s.
+func New(ctx context.Context, s *store.Store, args ...Option) *Tracker {
+	opts := options{capacity: DefaultCapacity}
+	for _, f := range args {
+		f(&opts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &Tracker{
+		store:    s,
+		capacity: max(1, opts.capacity),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Close stops following the tasks of the operations, recording those still pending as unknown. It is a no-op
+// on a nil tracker.
+func (t *Tracker) Close() {
+	if t == nil {
+		return
+	}
+	t.cancel()
+	t.wg.Wait()
+}
+
+// Submit submits the task taskFor returns to each of the distros, as a single operation of the given kind, and
+// follows the tasks to record their outcome. It returns the ID of the operation, which is empty on a nil
+// tracker, along with the errors of the distros the task could not be submitted to.
+func Submit[D Distro](ctx context.Context, t *Tracker, kind, description string, distros []D, taskFor func(D) task.Task) (id string, err error) {
+	if t == nil {
+		for _, d := range distros {
+			if e := d.SubmitTasks(taskFor(d)); e != nil {
+				err = errors.Join(err, fmt.Errorf("could not submit task to distro %q: %v", d.Name(), e))
+			}
+		}
+		return "", err
+	}
+
+	op := Operation{
+		ID:          newID(),
+		Kind:        kind,
+		Description: description,
+		Started:     time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, d := range distros {
+		op.Outcomes = append(op.Outcomes, Outcome{Distro: d.Name(), Status: StatusPending})
+	}
+
+	if e := t.start(op); e != nil {
+		// Recording the operation is secondary to carrying it out.
+		log.Warningf(ctx, "Operations: %v", e)
+	}
+
+	for _, d := range distros {
+		tk := taskFor(d)
+		if e := t.follow(op.ID, d, tk); e != nil {
+			err = errors.Join(err, fmt.Errorf("could not submit task to distro %q: %v", d.Name(), e))
+			t.record(ctx, op.ID, d.Name(), StatusFailed, e.Error())
+		}
+	}
+
+	log.Infof(ctx, "Operations: started %s operation %s on %d distros", kind, op.ID, len(distros))
+	return op.ID, err
+}
+
+// follow submits the task to the distro, and records its outcome once it finishes.
+func (t *Tracker) follow(id string, d Distro, tk task.Task) error {
+	// The watch must start before the task is submitted, so that no event is missed.
+	ctx, cancel := context.WithCancel(t.ctx)
+	events, err := d.WatchTasks(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if err := d.SubmitTasks(tk); err != nil {
+		cancel()
+		return err
+	}
+
+	name, want := d.Name(), fmt.Sprint(tk)
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer cancel()
+		defer crashreport.Recover("operations")
+
+		for ev := range events {
+			if ev.Task != want {
+				continue
+			}
+			switch ev.Type {
+			case worker.EventCompleted:
+				t.record(t.ctx, id, name, StatusSucceeded, "")
+				return
+			case worker.EventFailed:
+				t.record(t.ctx, id, name, StatusFailed, ev.Reason)
+				return
+			}
+		}
+
+		t.record(context.Background(), id, name, StatusUnknown, "the agent stopped following the task before it finished")
+	}()
+
+	return nil
+}
+
+// start records the operation, discarding the oldest ones above the capacity.
+func (t *Tracker) start(op Operation) (err error) {
+	defer decorate.OnError(&err, "could not record operation %s", op.ID)
+
+	data, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.store.Update(func(tx *store.Tx) error {
+		if err := tx.Put(store.OperationsBucket, op.ID, data); err != nil {
+			return err
+		}
+
+		keys, err := tx.Keys(store.OperationsBucket)
+		if err != nil {
+			return err
+		}
+		for len(keys) > t.capacity {
+			if err := tx.Delete(store.OperationsBucket, keys[0]); err != nil {
+				return err
+			}
+			keys = keys[1:]
+		}
+
+		return nil
+	})
+}
+
+// record sets the outcome of the operation in the distro. Operations discarded in the meantime are left alone.
+func (t *Tracker) record(ctx context.Context, id, distro string, status Status, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.store.Update(func(tx *store.Tx) error {
+		op, err := get(tx, id)
+		if err != nil || op == nil {
+			return err
+		}
+
+		for i := range op.Outcomes {
+			if op.Outcomes[i].Distro != distro || op.Outcomes[i].Status != StatusPending {
+				continue
+			}
+			op.Outcomes[i].Status = status
+			op.Outcomes[i].Reason = reason
+			if status != StatusUnknown {
+				op.Outcomes[i].Finished = time.Now().UTC().Format(time.RFC3339)
+			}
+		}
+
+		data, err := json.Marshal(op)
+		if err != nil {
+			return err
+		}
+		return tx.Put(store.OperationsBucket, id, data)
+	})
+	if err != nil {
+		log.Warningf(ctx, "Operations: could not record the outcome of operation %s in distro %q: %v", id, distro, err)
+	}
+}
+
+// Stored returns the operations kept in the store s, oldest first.
+func Stored(s *store.Store) (ops []Operation, err error) {
+	defer decorate.OnError(&err, "could not read the operations in the store")
+
+	err = s.View(func(tx *store.Tx) error {
+		keys, err := tx.Keys(store.OperationsBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			op, err := get(tx, k)
+			if err != nil {
+				return err
+			}
+			ops = append(ops, *op)
+		}
+		return nil
+	})
+
+	return ops, err
+}
+
+// Get returns the operation with the given ID kept in the store s.
+func Get(s *store.Store, id string) (op Operation, err error) {
+	defer decorate.OnError(&err, "could not read operation %s", id)
+
+	err = s.View(func(tx *store.Tx) error {
+		found, err := get(tx, id)
+		if err != nil {
+			return err
+		}
+		if found == nil {
+			return errors.New("no such operation")
+		}
+		op = *found
+		return nil
+	})
+
+	return op, err
+}
+
+// get returns the operation with the given ID, or nil if there is none.
+func get(tx *store.Tx, id string) (*Operation, error) {
+	data, err := tx.Get(store.OperationsBucket, id)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var op Operation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, fmt.Errorf("malformed operation %s: %v", id, err)
+	}
+	return &op, nil
+}
+
+// newID returns a new operation ID. IDs sort like the time the operations started.
+func newID() string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(b))
+}
@@ -0,0 +1,293 @@
+package operations_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/operations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmit(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		distros    []*distroMock
+		nilTracker bool
+		closeEarly bool
+
+		wantStatuses map[string]operations.Status
+		wantErr      bool
+	}{
+		"Success recording the outcome in every distro": {
+			distros:      []*distroMock{{name: "ok", outcome: worker.EventCompleted}, {name: "ko", outcome: worker.EventFailed}},
+			wantStatuses: map[string]operations.Status{"ok": operations.StatusSucceeded, "ko": operations.StatusFailed},
+		},
+		"Success with no distros": {wantStatuses: map[string]operations.Status{}},
+		"Success submitting the tasks with a nil tracker": {
+			distros:    []*distroMock{{name: "ok", outcome: worker.EventCompleted}},
+			nilTracker: true,
+		},
+		"Success recording the tasks that did not finish when the tracker closes": {
+			distros:      []*distroMock{{name: "ok", outcome: worker.EventCompleted}, {name: "stuck", outcome: worker.EventQueued}},
+			closeEarly:   true,
+			wantStatuses: map[string]operations.Status{"ok": operations.StatusSucceeded, "stuck": operations.StatusUnknown},
+		},
+
+		"Error when the task cannot be submitted to a distro": {
+			distros:      []*distroMock{{name: "ok", outcome: worker.EventCompleted}, {name: "broken", submitErr: true}},
+			wantStatuses: map[string]operations.Status{"ok": operations.StatusSucceeded, "broken": operations.StatusFailed},
+			wantErr:      true,
+		},
+		"Error when the tasks of a distro cannot be watched": {
+			distros:      []*distroMock{{name: "broken", watchErr: true}},
+			wantStatuses: map[string]operations.Status{"broken": operations.StatusFailed},
+			wantErr:      true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			s := openStore(t)
+
+			var tracker *operations.Tracker
+			if !tc.nilTracker {
+				tracker = operations.New(ctx, s)
+				defer tracker.Close()
+			}
+
+			id, err := operations.Submit(ctx, tracker, "test-kind", "test description", tc.distros, func(d *distroMock) task.Task {
+				return testTask{Distro: d.name}
+			})
+			if tc.wantErr {
+				require.Error(t, err, "Submit should return an error")
+			} else {
+				require.NoError(t, err, "Submit should return no error")
+			}
+
+			for _, d := range tc.distros {
+				if d.submitErr || d.watchErr {
+					continue
+				}
+				require.Equal(t, []task.Task{testTask{Distro: d.name}}, d.submitted, "The task should have been submitted to distro %q", d.name)
+			}
+
+			if tc.nilTracker {
+				require.Empty(t, id, "Submit should return no ID with a nil tracker")
+				ops, err := operations.Stored(s)
+				require.NoError(t, err, "Stored should return no error")
+				require.Empty(t, ops, "No operation should have been recorded with a nil tracker")
+				return
+			}
+
+			if tc.closeEarly {
+				require.Eventually(t, func() bool {
+					op, err := operations.Get(s, id)
+					return err == nil && op.Count(operations.StatusSucceeded) == 1
+				}, 5*time.Second, 10*time.Millisecond, "The completed task should have been recorded")
+				tracker.Close()
+			}
+
+			var op operations.Operation
+			require.Eventually(t, func() bool {
+				op, err = operations.Get(s, id)
+				require.NoError(t, err, "Get should return no error")
+				return op.Count(operations.StatusPending) == 0
+			}, 5*time.Second, 10*time.Millisecond, "The outcome of the operation in every distro should have been recorded")
+
+			require.Equal(t, id, op.ID, "The operation should have the ID Submit returned")
+			require.Equal(t, "test-kind", op.Kind, "The operation should have the kind it was submitted with")
+			require.Equal(t, "test description", op.Description, "The operation should have the description it was submitted with")
+			require.NotEmpty(t, op.Started, "The operation should have a start time")
+
+			got := make(map[string]operations.Status)
+			for _, o := range op.Outcomes {
+				got[o.Distro] = o.Status
+				if o.Status == operations.StatusSucceeded || o.Status == operations.StatusFailed {
+					require.NotEmpty(t, o.Finished, "The outcome in distro %q should have a finish time", o.Distro)
+				}
+				if o.Status == operations.StatusFailed || o.Status == operations.StatusUnknown {
+					require.NotEmpty(t, o.Reason, "The outcome in distro %q should have a reason", o.Distro)
+				}
+			}
+			require.Equal(t, tc.wantStatuses, got, "The outcome of the operation in every distro should have been recorded")
+		})
+	}
+}
+
+func TestStored(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		submitted int
+		capacity  int
+
+		wantStored int
+	}{
+		"Success with no operations":                 {},
+		"Success with operations within capacity":    {submitted: 3, capacity: 5, wantStored: 3},
+		"Success discarding the operations above it": {submitted: 5, capacity: 3, wantStored: 3},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			s := openStore(t)
+			tracker := operations.New(ctx, s, operations.WithCapacity(tc.capacity))
+			defer tracker.Close()
+
+			var ids []string
+			for i := range tc.submitted {
+				id, err := operations.Submit(ctx, tracker, "test-kind", fmt.Sprint(i), []*distroMock{}, func(*distroMock) task.Task { return nil })
+				require.NoError(t, err, "Setup: Submit should return no error")
+				ids = append(ids, id)
+			}
+
+			ops, err := operations.Stored(s)
+			require.NoError(t, err, "Stored should return no error")
+			require.Len(t, ops, tc.wantStored, "Stored should return the operations within capacity")
+
+			for i, op := range ops {
+				require.Equal(t, fmt.Sprint(tc.submitted-tc.wantStored+i), op.Description, "Stored should return the newest operations, oldest first")
+			}
+
+			if tc.submitted > tc.wantStored {
+				_, err := operations.Get(s, ids[0])
+				require.Error(t, err, "Get should return an error for a discarded operation")
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	op := operations.Operation{
+		ID:          "20240501T120000-a1b2c3",
+		Kind:        "pro-service",
+		Description: "enable esm-apps",
+		Started:     "2024-05-01T12:00:00Z",
+		Outcomes: []operations.Outcome{
+			{Distro: "Ubuntu-22.04", Status: operations.StatusSucceeded, Finished: "2024-05-01T12:01:00Z"},
+			{Distro: "Ubuntu-24.04", Status: operations.StatusFailed, Reason: "could not enable esm-apps, \"quoted\"", Finished: "2024-05-01T12:02:00Z"},
+			{Distro: "Ubuntu", Status: operations.StatusPending},
+		},
+	}
+
+	testCases := map[string]struct {
+		op operations.Operation
+
+		write func(*bytes.Buffer, operations.Operation) error
+	}{
+		"Success exporting as CSV":              {op: op, write: func(b *bytes.Buffer, op operations.Operation) error { return operations.WriteCSV(b, op) }},
+		"Success exporting as JSON":             {op: op, write: func(b *bytes.Buffer, op operations.Operation) error { return operations.WriteJSON(b, op) }},
+		"Success exporting no outcomes as CSV":  {op: operations.Operation{ID: "empty"}, write: func(b *bytes.Buffer, op operations.Operation) error { return operations.WriteCSV(b, op) }},
+		"Success exporting no outcomes as JSON": {op: operations.Operation{ID: "empty"}, write: func(b *bytes.Buffer, op operations.Operation) error { return operations.WriteJSON(b, op) }},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			require.NoError(t, tc.write(&b, tc.op), "Export should return no error")
+
+			want := testutils.LoadWithUpdateFromGolden(t, b.String())
+			require.Equal(t, want, b.String(), "Export should match the golden file")
+		})
+	}
+}
+
+// openStore opens a store in a temporary directory, which is closed at the end of the test.
+func openStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	s, err := store.Open(context.Background(), filepath.Join(t.TempDir(), "store.db"))
+	require.NoError(t, err, "Setup: could not open the store")
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+// testTask is a task that is never executed: the mock distros report its outcome right away.
+type testTask struct {
+	Distro string
+}
+
+func (testTask) Execute(context.Context, task.Connection) error {
+	return errors.New("test tasks are not meant to be executed")
+}
+
+// distroMock is a distro that reports the outcome of the tasks submitted to it right away.
+type distroMock struct {
+	name string
+
+	// outcome is the event reported for the tasks. Events other than EventCompleted and EventFailed leave them pending.
+	outcome   worker.EventType
+	submitErr bool
+	watchErr  bool
+
+	submitted []task.Task
+	events    chan worker.Event
+	closed    bool
+	mu        sync.Mutex
+}
+
+func (d *distroMock) Name() string {
+	return d.name
+}
+
+func (d *distroMock) SubmitTasks(tasks ...task.Task) error {
+	if d.submitErr {
+		return errors.New("mock error")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.submitted = append(d.submitted, tasks...)
+	if d.events == nil || d.closed {
+		return nil
+	}
+
+	for _, t := range tasks {
+		d.events <- worker.Event{Type: worker.EventStarted, Task: "some other task"}
+		d.events <- worker.Event{Type: d.outcome, Task: fmt.Sprint(t), Reason: "mock reason"}
+	}
+	return nil
+}
+
+func (d *distroMock) WatchTasks(ctx context.Context) (<-chan worker.Event, error) {
+	if d.watchErr {
+		return nil, errors.New("mock error")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.events = make(chan worker.Event, 10)
+	go func() {
+		<-ctx.Done()
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.closed = true
+		close(d.events)
+	}()
+
+	return d.events, nil
+}
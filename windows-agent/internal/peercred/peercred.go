@@ -0,0 +1,94 @@
+// Package peercred identifies the user running the process at the other end of a local connection to the agent,
+// so that the agent can tell its own user and the administrators apart from the other users of the machine.
+//
+// TCP clients are looked up in the table of connections of the system. Named pipe clients are identified when
+// they connect, which requires the listener to be wrapped with WrapListener.
+//
+// On Linux, where it is used for testing purposes, users are identified by their user ID and root is the only
+// administrator. Unix sockets stand in for the named pipes.
+package peercred
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/peer"
+)
+
+// Identity is the user running a process.
+type Identity struct {
+	// User is the security identifier of the user.
+	User string
+
+	// Admin tells whether the process runs with administrator rights.
+	Admin bool
+}
+
+// Current returns the identity of the current process.
+func Current() (Identity, error) {
+	return current()
+}
+
+// FromPeer returns the identity of the process at the other end of a gRPC connection.
+func FromPeer(p *peer.Peer) (Identity, error) {
+	if a, ok := p.Addr.(*Addr); ok {
+		return a.identity, a.err
+	}
+
+	remote, okRemote := p.Addr.(*net.TCPAddr)
+	local, okLocal := p.LocalAddr.(*net.TCPAddr)
+	if !okRemote || !okLocal {
+		return Identity{}, fmt.Errorf("cannot identify the clients connected from %v", p.Addr)
+	}
+
+	// The socket of the client is the one whose local end is the remote end of ours.
+	id, err := tcpOwner(remote, local)
+	if err != nil {
+		return Identity{}, fmt.Errorf("could not identify the client connected from %s: %v", remote, err)
+	}
+	return id, nil
+}
+
+// WrapListener returns a listener identifying the clients of lis, which must be a named pipe listener, as soon as
+// they connect. Their identity is then available through FromPeer.
+func WrapListener(lis net.Listener) net.Listener {
+	return listener{lis}
+}
+
+type listener struct {
+	net.Listener
+}
+
+func (l listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := connOwner(c)
+	if err != nil {
+		err = fmt.Errorf("could not identify the client: %v", err)
+	}
+	return conn{Conn: c, addr: &Addr{Addr: c.RemoteAddr(), identity: id, err: err}}, nil
+}
+
+// conn is a connection whose remote address carries the identity of the client.
+type conn struct {
+	net.Conn
+	addr *Addr
+}
+
+func (c conn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// Addr is the address of a client identified when it connected.
+type Addr struct {
+	net.Addr
+	identity Identity
+	err      error
+}
+
+// errUnsupportedConn is returned when identifying clients connected through anything but named pipes.
+var errUnsupportedConn = errors.New("only named pipe clients can be identified when they connect")
@@ -0,0 +1,126 @@
+package peercred
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// current returns the identity of the user running the current process.
+func current() (Identity, error) {
+	return uidIdentity(os.Getuid()), nil
+}
+
+// tcpOwner returns the identity of the user owning the TCP socket bound to local and connected to remote, as listed
+// in /proc/net. IPv4 connections to IPv6 sockets are listed with the IPv6 ones.
+func tcpOwner(local, remote *net.TCPAddr) (Identity, error) {
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		id, found, err := tcpOwnerIn(table, local, remote)
+		if err != nil {
+			return Identity{}, err
+		}
+		if found {
+			return id, nil
+		}
+	}
+
+	return Identity{}, errors.New("no process owns the connection")
+}
+
+// tcpOwnerIn looks for the socket bound to local and connected to remote in a table of /proc/net.
+func tcpOwnerIn(table string, local, remote *net.TCPAddr) (id Identity, found bool, err error) {
+	f, err := os.Open(table)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Identity{}, false, nil
+	} else if err != nil {
+		return Identity{}, false, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	// The first line is the header.
+	sc.Scan()
+	for sc.Scan() {
+		// Fields: sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid ...
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		if !endpointIs(fields[1], local) || !endpointIs(fields[2], remote) {
+			continue
+		}
+
+		uid, err := strconv.Atoi(fields[7])
+		if err != nil {
+			return Identity{}, false, fmt.Errorf("invalid user ID %q in %s", fields[7], table)
+		}
+		return uidIdentity(uid), true, nil
+	}
+
+	return Identity{}, false, sc.Err()
+}
+
+// endpointIs returns true if the endpoint of /proc/net, an address written as 32-bit words in host byte order
+// followed by a port, is the same as addr.
+func endpointIs(endpoint string, addr *net.TCPAddr) bool {
+	hexIP, hexPort, ok := strings.Cut(endpoint, ":")
+	if !ok {
+		return false
+	}
+
+	words, err := hex.DecodeString(hexIP)
+	if err != nil || len(words)%4 != 0 {
+		return false
+	}
+	ip := make(net.IP, 0, len(words))
+	for i := 0; i < len(words); i += 4 {
+		ip = binary.NativeEndian.AppendUint32(ip, binary.BigEndian.Uint32(words[i:i+4]))
+	}
+
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return false
+	}
+
+	return ip.Equal(addr.IP) && int(port) == addr.Port
+}
+
+// connOwner returns the identity of the user connected to a Unix socket.
+func connOwner(c net.Conn) (Identity, error) {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return Identity{}, errUnsupportedConn
+	}
+
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return Identity{}, err
+	}
+
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return Identity{}, err
+	}
+	if credErr != nil {
+		return Identity{}, fmt.Errorf("could not get the credentials of the client: %v", credErr)
+	}
+
+	return uidIdentity(int(cred.Uid)), nil
+}
+
+// uidIdentity returns the identity of the user with the given ID. Root is the only administrator.
+func uidIdentity(uid int) Identity {
+	return Identity{User: strconv.Itoa(uid), Admin: uid == 0}
+}
@@ -0,0 +1,72 @@
+package peercred_test
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/peercred"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/peer"
+)
+
+func TestFromPeer(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		network string
+		wrap    bool
+		noLocal bool
+
+		wantErr bool
+	}{
+		"Success identifying TCP clients":        {network: "tcp"},
+		"Success identifying named pipe clients": {network: "unix", wrap: true},
+
+		"Error when the named pipe listener is not wrapped": {network: "unix", wantErr: true},
+		"Error when wrapping a TCP listener":                {network: "tcp", wrap: true, wantErr: true},
+		"Error when the local address is unknown":           {network: "tcp", noLocal: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			address := "127.0.0.1:0"
+			if tc.network == "unix" {
+				address = filepath.Join(t.TempDir(), "socket")
+			}
+
+			lis, err := net.Listen(tc.network, address)
+			require.NoError(t, err, "Setup: Listen should not fail")
+			if tc.wrap {
+				lis = peercred.WrapListener(lis)
+			}
+			defer lis.Close()
+
+			client, err := net.Dial(tc.network, lis.Addr().String())
+			require.NoError(t, err, "Setup: Dial should not fail")
+			defer client.Close()
+
+			server, err := lis.Accept()
+			require.NoError(t, err, "Setup: Accept should not fail")
+			defer server.Close()
+
+			p := &peer.Peer{Addr: server.RemoteAddr(), LocalAddr: server.LocalAddr()}
+			if tc.noLocal {
+				p.LocalAddr = nil
+			}
+
+			got, err := peercred.FromPeer(p)
+			if tc.wantErr {
+				require.Error(t, err, "FromPeer should have failed")
+				return
+			}
+			require.NoError(t, err, "FromPeer should not fail")
+
+			want, err := peercred.Current()
+			require.NoError(t, err, "Setup: Current should not fail")
+			require.Equal(t, want, got, "FromPeer should identify the user running the client")
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package peercred
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	iphlpapi                = windows.NewLazySystemDLL("iphlpapi.dll")
+	procGetExtendedTCPTable = iphlpapi.NewProc("GetExtendedTcpTable")
+)
+
+// tcpTableOwnerPIDConnections is the TCP_TABLE_OWNER_PID_CONNECTIONS class of GetExtendedTcpTable: the established
+// connections with the process owning them.
+const tcpTableOwnerPIDConnections = 4
+
+// tcpRowOwnerPID is a MIB_TCPROW_OWNER_PID. Addresses and ports are in network byte order.
+type tcpRowOwnerPID struct {
+	State      uint32
+	LocalAddr  uint32
+	LocalPort  uint32
+	RemoteAddr uint32
+	RemotePort uint32
+	OwningPID  uint32
+}
+
+// current returns the identity of the token of the current process.
+func current() (Identity, error) {
+	return tokenIdentity(windows.GetCurrentProcessToken())
+}
+
+// tcpOwner returns the identity of the process owning the IPv4 TCP socket bound to local and connected to remote.
+func tcpOwner(local, remote *net.TCPAddr) (Identity, error) {
+	localIP, remoteIP := local.IP.To4(), remote.IP.To4()
+	if localIP == nil || remoteIP == nil {
+		return Identity{}, errors.New("only IPv4 connections can be identified")
+	}
+
+	var buf []byte
+	var size uint32
+	for {
+		var p unsafe.Pointer
+		if len(buf) != 0 {
+			p = unsafe.Pointer(&buf[0])
+		}
+
+		r, _, _ := procGetExtendedTCPTable.Call(uintptr(p), uintptr(unsafe.Pointer(&size)), 0, windows.AF_INET, tcpTableOwnerPIDConnections, 0)
+		if r == 0 && len(buf) != 0 {
+			break
+		}
+		// The table may grow between the calls.
+		if r != 0 && windows.Errno(r) != windows.ERROR_INSUFFICIENT_BUFFER {
+			return Identity{}, fmt.Errorf("could not list the TCP connections: %v", windows.Errno(r))
+		}
+		buf = make([]byte, size)
+	}
+
+	n := *(*uint32)(unsafe.Pointer(&buf[0]))
+	rows := unsafe.Slice((*tcpRowOwnerPID)(unsafe.Pointer(&buf[unsafe.Sizeof(n)])), n)
+	for _, row := range rows {
+		if endpointIs(row.LocalAddr, row.LocalPort, localIP, local.Port) && endpointIs(row.RemoteAddr, row.RemotePort, remoteIP, remote.Port) {
+			return processIdentity(row.OwningPID)
+		}
+	}
+
+	return Identity{}, errors.New("no process owns the connection")
+}
+
+// endpointIs returns true if the address and port of a row of the TCP table, in network byte order, are ip and port.
+func endpointIs(rowAddr, rowPort uint32, ip net.IP, port int) bool {
+	var addr [4]byte
+	binary.LittleEndian.PutUint32(addr[:], rowAddr)
+
+	// The port is in the first two bytes of the field.
+	var p [4]byte
+	binary.LittleEndian.PutUint32(p[:], rowPort)
+
+	return net.IP(addr[:]).Equal(ip) && int(binary.BigEndian.Uint16(p[:2])) == port
+}
+
+// connOwner returns the identity of the client of a named pipe.
+func connOwner(c net.Conn) (Identity, error) {
+	pipe, ok := c.(interface{ Fd() uintptr })
+	if !ok {
+		return Identity{}, errUnsupportedConn
+	}
+
+	var pid uint32
+	if err := windows.GetNamedPipeClientProcessId(windows.Handle(pipe.Fd()), &pid); err != nil {
+		return Identity{}, fmt.Errorf("could not get the process of the client: %v", err)
+	}
+	return processIdentity(pid)
+}
+
+// processIdentity returns the identity of the token of the process with the given ID.
+func processIdentity(pid uint32) (Identity, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return Identity{}, fmt.Errorf("could not open process %d: %v", pid, err)
+	}
+	defer windows.CloseHandle(h) //nolint:errcheck // Nothing to do if it cannot be closed.
+
+	var token windows.Token
+	if err := windows.OpenProcessToken(h, windows.TOKEN_QUERY, &token); err != nil {
+		return Identity{}, fmt.Errorf("could not open the token of process %d: %v", pid, err)
+	}
+	defer token.Close()
+
+	return tokenIdentity(token)
+}
+
+// tokenIdentity returns the user of the token, which is an administrator if the token is elevated.
+func tokenIdentity(token windows.Token) (Identity, error) {
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return Identity{}, fmt.Errorf("could not get the user of the token: %v", err)
+	}
+	return Identity{User: user.User.Sid.String(), Admin: token.IsElevated()}, nil
+}
@@ -0,0 +1,76 @@
+package proservices
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	agent_api "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/peercred"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ownerAccess restricts the methods of the UI service that change the state of the agent, which are all those out
+// of the status API, to the user running the agent and to the administrators of the machine. The GUI certificate
+// alone is not enough, as it can be copied out of the profile of the user.
+type ownerAccess struct {
+	owner peercred.Identity
+
+	// identify returns the identity of the process at the other end of a connection.
+	identify func(*peer.Peer) (peercred.Identity, error)
+}
+
+// newOwnerAccess returns an ownerAccess restricting the methods to the user running the current process.
+func newOwnerAccess() (ownerAccess, error) {
+	owner, err := peercred.Current()
+	if err != nil {
+		return ownerAccess{}, err
+	}
+	return ownerAccess{owner: owner, identify: peercred.FromPeer}, nil
+}
+
+// unaryInterceptor denies the unary calls to restricted methods from other users than the owner.
+func (a ownerAccess) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := a.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor denies the streaming calls to restricted methods from other users than the owner.
+func (a ownerAccess) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize returns a PermissionDenied error if method is restricted and the caller is neither the owner nor an
+// administrator, or cannot be identified.
+func (a ownerAccess) authorize(ctx context.Context, method string) error {
+	if !strings.HasPrefix(method, "/"+agent_api.UI_ServiceDesc.ServiceName+"/") || slices.Contains(statusMethods, method) {
+		return nil
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "could not identify the client")
+	}
+
+	id, err := a.identify(p)
+	if err != nil {
+		log.Warningf(ctx, "Denied access to %s: %v", method, err)
+		return status.Errorf(codes.PermissionDenied, "access denied to %s: %v", method, err)
+	}
+
+	if id.User != a.owner.User && !id.Admin {
+		log.Warningf(ctx, "Denied access to %s to user %s", method, id.User)
+		return status.Errorf(codes.PermissionDenied, "access denied to %s: only the user running the agent or an administrator can call it", method)
+	}
+
+	return nil
+}
@@ -1,19 +1,35 @@
 package proservices
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"math/big"
+	"strings"
 
+	agent_api "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/certs"
 	"github.com/ubuntu/decorate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
-// newTLSCertificates creates a self-signed root CA, agent and clients certificates and writes them into destDir.
-func newTLSCertificates(destDir string) (c agentCerts, err error) {
+// serviceClients maps each gRPC service of the agent to the prefix of the only client certificate allowed to call it,
+// so that the certificate read by the WSL Pro services cannot drive the GUI service and vice versa.
+var serviceClients = map[string]string{
+	agent_api.UI_ServiceDesc.ServiceName:          common.ClientsCertFilePrefix,
+	agent_api.WSLInstance_ServiceDesc.ServiceName: common.WSLProServiceCertFilePrefix,
+}
+
+// newTLSCertificates creates a self-signed root CA, the agent certificate and one client certificate per prefix in
+// clients, and writes them into destDir.
+func newTLSCertificates(destDir string, clients ...string) (c agentCerts, err error) {
 	decorate.OnError(&err, "could not create TLS credentials:")
 
 	// generates a pseudo-random serial number for the root CA certificate.
@@ -28,18 +44,23 @@ func newTLSCertificates(destDir string) (c agentCerts, err error) {
 	}
 
 	// Create and write the agent and clients certificates signed by the root certificate created above.
-	agentCert, err := certs.CreateTLSCertificateSignedBy(common.AgentCertFilePrefix, common.GRPCServerNameOverride, serial.Rsh(serial, 2), rootCert, rootKey, destDir)
+	agentCert, err := certs.CreateTLSCertificateSignedBy(common.AgentCertFilePrefix, common.GRPCServerNameOverride, new(big.Int).Rsh(serial, 2), rootCert, rootKey, destDir)
 	if err != nil {
 		return agentCerts{}, err
 	}
-	// We won't store the TLS client certificate, because only the agent should access this function and it's not interested in the client TLS certificate.
+
+	// We only keep the public part of the client certificates, to recognise the clients that present them.
 	// But we still need to write them to disk, so clients can construct their TLS configs from there.
-	_, err = certs.CreateTLSCertificateSignedBy(common.ClientsCertFilePrefix, common.GRPCServerNameOverride, serial.Lsh(serial, 3), rootCert, rootKey, destDir)
-	if err != nil {
-		return agentCerts{}, err
+	c = agentCerts{rootCA: rootCert, agentCert: *agentCert, clients: make(map[string]*x509.Certificate)}
+	for i, name := range clients {
+		clientCert, err := certs.CreateTLSCertificateSignedBy(name, common.GRPCServerNameOverride, new(big.Int).Lsh(serial, uint(3+i)), rootCert, rootKey, destDir)
+		if err != nil {
+			return agentCerts{}, err
+		}
+		c.clients[name] = clientCert.Leaf
 	}
 
-	return agentCerts{rootCA: rootCert, agentCert: *agentCert}, nil
+	return c, nil
 }
 
 // agentTLSConfig returns a TLS config for the agent that require and verify client certificates.
@@ -54,8 +75,51 @@ func (c agentCerts) agentTLSConfig() *tls.Config {
 	}
 }
 
-// agentCerts conveniently holds the root CA and the agent certificates to make it easy to create a TLS config.
+// agentCerts conveniently holds the root CA and the agent certificates to make it easy to create a TLS config,
+// and the client certificates to recognise who is calling.
 type agentCerts struct {
 	rootCA    *x509.Certificate
 	agentCert tls.Certificate
+	clients   map[string]*x509.Certificate
+}
+
+// unaryInterceptor denies the unary calls from clients that are not allowed to use the service of the method.
+func (c agentCerts) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := c.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor denies the streaming calls from clients that are not allowed to use the service of the method.
+func (c agentCerts) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := c.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize returns a PermissionDenied error if the certificate the peer authenticated with is not the one
+// allowed to call the service of method.
+func (c agentCerts) authorize(ctx context.Context, method string) error {
+	// Full method names are formatted as "/service/method".
+	service, _, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	want, ok := c.clients[serviceClients[service]]
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "no client is allowed to call %s", method)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "could not identify the client")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return status.Error(codes.Unauthenticated, "the client did not present a verified certificate")
+	}
+
+	if !tlsInfo.State.VerifiedChains[0][0].Equal(want) {
+		return status.Errorf(codes.PermissionDenied, "the certificate of the client is not allowed to call %s", method)
+	}
+	return nil
 }
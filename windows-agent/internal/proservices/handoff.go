@@ -0,0 +1,118 @@
+package proservices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/ubuntu/decorate"
+)
+
+// handOffGrace is how long the tasks in progress have to finish when the agent is about to be updated.
+const handOffGrace = 20 * time.Second
+
+// storeMigrations are the migrations of the schema of the store, in order. The first one marks the stores written
+// before the schema had a version.
+var storeMigrations = []store.Migration{
+	{Description: "start versioning the schema", Run: func(*store.Tx) error { return nil }},
+}
+
+// handOffMarker is what the agent leaves behind once it handed its tasks off to the next version.
+type handOffMarker struct {
+	// Version is the version of the agent that handed off.
+	Version string `json:"version"`
+
+	// Time is when it handed off.
+	Time time.Time `json:"time"`
+
+	// PendingTasks is the number of tasks it left in the queues.
+	PendingTasks int `json:"pending_tasks"`
+}
+
+// HandOff prepares the agent to be stopped and replaced by a new version, as MSIX updates kill its process: no new
+// task is accepted anymore, and the tasks in progress are given a grace period to finish before they are stored back
+// in the queues. A marker is then written to tell the next version that it starts after an update. Stop must still
+// be called afterwards.
+func (m Manager) HandOff(ctx context.Context) (err error) {
+	defer decorate.OnError(&err, "could not hand off to the next version of the agent")
+
+	if m.db == nil {
+		return errors.New("the services are not running")
+	}
+
+	log.Info(ctx, "Handing off the tasks to the next version of the agent")
+	pending := m.db.HandOff(ctx, handOffGrace)
+
+	out, err := json.Marshal(handOffMarker{Version: consts.Version, Time: time.Now(), PendingTasks: pending})
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(m.privateDir, consts.HandOffFileName)
+	// Writing to a temporary file first ensures being killed never leaves a half-written marker behind.
+	if err := os.WriteFile(path+".new", out, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".new", path); err != nil {
+		return err
+	}
+
+	log.Infof(ctx, "Handed off %d pending tasks", pending)
+	return nil
+}
+
+// prepareStore migrates the schema of the store before anything reads it. When the agent starts after an update,
+// as told by the marker the previous version left, the store is backed up first so that the data can be recovered
+// if the migrations go wrong.
+func prepareStore(ctx context.Context, st *store.Store, privateDir string) (err error) {
+	defer decorate.OnError(&err, "could not prepare the store")
+
+	marker, ok := takeHandOffMarker(ctx, privateDir)
+	if ok && marker.Version != consts.Version {
+		log.Infof(ctx, "Starting after the update from version %s, which handed off %d pending tasks on %s", marker.Version, marker.PendingTasks, marker.Time.Format(time.RFC3339))
+
+		dir := filepath.Join(privateDir, consts.DatabaseBackupsDir)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("distros-%s.db", time.Now().UTC().Format("20060102T150405Z")))
+		if err := st.Backup(path); err != nil {
+			return err
+		}
+		log.Infof(ctx, "Backed up the store of version %s to %s", marker.Version, path)
+	}
+
+	return st.Migrate(ctx, storeMigrations)
+}
+
+// takeHandOffMarker reads and removes the marker the previous run of the agent left after handing off, if any.
+func takeHandOffMarker(ctx context.Context, privateDir string) (marker handOffMarker, ok bool) {
+	path := filepath.Join(privateDir, consts.HandOffFileName)
+
+	out, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return handOffMarker{}, false
+	} else if err != nil {
+		log.Warningf(ctx, "Could not read the hand-off marker: %v", err)
+		return handOffMarker{}, false
+	}
+
+	if err := os.Remove(path); err != nil {
+		log.Warningf(ctx, "Could not remove the hand-off marker: %v", err)
+	}
+
+	if err := json.Unmarshal(out, &marker); err != nil {
+		log.Warningf(ctx, "Ignoring invalid hand-off marker: %v", err)
+		return handOffMarker{}, false
+	}
+
+	return marker, true
+}
@@ -1,12 +1,32 @@
 package proservices
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	agent_api "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/notifications"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/peercred"
 	"github.com/stretchr/testify/require"
+	wsl "github.com/ubuntu/gowsl"
+	wslmock "github.com/ubuntu/gowsl/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewTLSCertificates(t *testing.T) {
@@ -19,9 +39,10 @@ func TestNewTLSCertificates(t *testing.T) {
 	}{
 		"Success": {},
 
-		"Error when the destination directory does not exist":  {inexistentDestDir: true, wantErr: true},
-		"Error when the agent private key cannot be written":   {breakKeyFile: common.AgentCertFilePrefix + common.KeySuffix, wantErr: true},
-		"Error when the clients private key cannot be written": {breakKeyFile: common.ClientsCertFilePrefix + common.KeySuffix, wantErr: true},
+		"Error when the destination directory does not exist":          {inexistentDestDir: true, wantErr: true},
+		"Error when the agent private key cannot be written":           {breakKeyFile: common.AgentCertFilePrefix + common.KeySuffix, wantErr: true},
+		"Error when the clients private key cannot be written":         {breakKeyFile: common.ClientsCertFilePrefix + common.KeySuffix, wantErr: true},
+		"Error when the WSL Pro service private key cannot be written": {breakKeyFile: common.WSLProServiceCertFilePrefix + common.KeySuffix, wantErr: true},
 	}
 
 	for name, tc := range testcases {
@@ -38,13 +59,564 @@ func TestNewTLSCertificates(t *testing.T) {
 				require.NoError(t, err, "Setup: could not write directory that should break %s", tc.breakKeyFile)
 			}
 
-			c, err := newTLSCertificates(dir)
+			c, err := newTLSCertificates(dir, common.ClientsCertFilePrefix, common.WSLProServiceCertFilePrefix)
 			if tc.wantErr {
 				require.Error(t, err, "NewTLSCertificates should have failed")
 				return
 			}
 			require.NoError(t, err, "NewTLSCertificates failed")
 			require.NotEmpty(t, c, "NewTLSCertificates should have returned a non-empty value")
+			require.Len(t, c.clients, 2, "NewTLSCertificates should have kept one certificate per client")
 		})
 	}
 }
+
+func TestOwnerAccess(t *testing.T) {
+	t.Parallel()
+
+	owner := peercred.Identity{User: "S-1-5-21-1000"}
+	testcases := map[string]struct {
+		method  string
+		caller  peercred.Identity
+		noPeer  bool
+		unknown bool
+
+		wantCode codes.Code
+	}{
+		"Success calling a restricted method as the owner":        {method: agent_api.UI_ApplyProToken_FullMethodName, caller: owner},
+		"Success calling a restricted method as an administrator": {method: agent_api.UI_ApplyProToken_FullMethodName, caller: peercred.Identity{User: "S-1-5-21-1001", Admin: true}},
+		"Success calling a status method as another user":         {method: agent_api.UI_GetSummary_FullMethodName, caller: peercred.Identity{User: "S-1-5-21-1001"}},
+		"Success calling a status method from an unknown client":  {method: agent_api.UI_GetSummary_FullMethodName, unknown: true},
+		"Success calling another service as another user":         {method: agent_api.WSLInstance_Session_FullMethodName, caller: peercred.Identity{User: "S-1-5-21-1001"}},
+
+		"Error when calling a restricted method as another user":        {method: agent_api.UI_ApplyProToken_FullMethodName, caller: peercred.Identity{User: "S-1-5-21-1001"}, wantCode: codes.PermissionDenied},
+		"Error when streaming a restricted method as another user":      {method: agent_api.UI_WatchConsent_FullMethodName, caller: peercred.Identity{User: "S-1-5-21-1001"}, wantCode: codes.PermissionDenied},
+		"Error when calling a restricted method from an unknown client": {method: agent_api.UI_ManageUser_FullMethodName, unknown: true, wantCode: codes.PermissionDenied},
+		"Error when calling a restricted method without a peer":         {method: agent_api.UI_ManageUser_FullMethodName, noPeer: true, wantCode: codes.Unauthenticated},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			a := ownerAccess{owner: owner, identify: func(*peer.Peer) (peercred.Identity, error) {
+				if tc.unknown {
+					return peercred.Identity{}, errors.New("mock error")
+				}
+				return tc.caller, nil
+			}}
+
+			ctx := context.Background()
+			if !tc.noPeer {
+				ctx = peer.NewContext(ctx, &peer.Peer{})
+			}
+
+			err := a.authorize(ctx, tc.method)
+			require.Equal(t, tc.wantCode, status.Code(err), "authorize should return the expected code: %v", err)
+		})
+	}
+}
+
+func TestPrepareStore(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		marker string
+
+		wantBackup bool
+	}{
+		"Success migrating the store without hand-off marker":             {},
+		"Success backing up the store after an update":                    {marker: `{"version":"0.0.1","pending_tasks":2}`, wantBackup: true},
+		"Success not backing up the store after a restart of the version": {marker: fmt.Sprintf(`{"version":%q}`, consts.Version)},
+		"Success ignoring an invalid hand-off marker":                     {marker: "not JSON"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			privateDir := t.TempDir()
+
+			st, err := store.Open(ctx, filepath.Join(privateDir, consts.StoreFileName))
+			require.NoError(t, err, "Setup: could not open the store")
+			defer st.Close()
+
+			marker := filepath.Join(privateDir, consts.HandOffFileName)
+			if tc.marker != "" {
+				require.NoError(t, os.WriteFile(marker, []byte(tc.marker), 0600), "Setup: could not write the hand-off marker")
+			}
+
+			err = prepareStore(ctx, st, privateDir)
+			require.NoError(t, err, "prepareStore should return no error")
+
+			v, err := st.SchemaVersion()
+			require.NoError(t, err, "SchemaVersion should return no error")
+			require.Equal(t, len(storeMigrations), v, "The store should have gone through all the migrations")
+
+			require.NoFileExists(t, marker, "The hand-off marker should have been removed")
+
+			backups, err := filepath.Glob(filepath.Join(privateDir, consts.DatabaseBackupsDir, "*.db"))
+			require.NoError(t, err, "Setup: could not list the backups")
+			require.Equal(t, tc.wantBackup, len(backups) == 1, "The store should be backed up only after an update")
+		})
+	}
+}
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestDistributePatching(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testcases := map[string]struct {
+		level      string
+		groupLevel string
+		newDistro  bool
+
+		wantTask  bool
+		wantLevel string
+	}{
+		"Success submitting the global level":          {level: "security-only", wantTask: true, wantLevel: "security-only"},
+		"Success submitting the level of the group":    {level: "security-only", groupLevel: "all", wantTask: true, wantLevel: "all"},
+		"Success submitting the level to a new distro": {level: "security+updates", newDistro: true, wantTask: true, wantLevel: "security+updates"},
+		"Success unmanaging distros without a level":   {wantTask: true},
+
+		"No task is submitted to a new distro without a level": {newDistro: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			conf := config.New(ctx, t.TempDir())
+			data := config.RegistryData{PatchingLevel: tc.level}
+			if tc.groupLevel != "" {
+				data.DistroGroups = fmt.Sprintf("- name: team\n  distros: [%s]\n  patching_level: %s", distroName, tc.groupLevel)
+			}
+			require.NoError(t, conf.UpdateRegistryData(ctx, data, nil), "Setup: could not set the registry data")
+
+			if tc.newDistro {
+				require.NoError(t, submitPatching(ctx, conf, d, false), "submitPatching should not fail")
+			} else {
+				distributePatching(ctx, conf, db)
+			}
+
+			out, err := os.ReadFile(filepath.Join(dir, distroName+".tasks"))
+			if !tc.wantTask {
+				require.NotContains(t, string(out), "Patching", "No patching task should have been submitted")
+				return
+			}
+			require.NoError(t, err, "Could not read the task file")
+			require.Contains(t, string(out), "Patching", "A patching task should have been submitted")
+			if tc.wantLevel != "" {
+				require.Contains(t, string(out), tc.wantLevel, "The patching task should have the level of the distro")
+			}
+		})
+	}
+}
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestDistributeProxy(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testcases := map[string]struct {
+		proxy     string
+		newDistro bool
+
+		wantTask bool
+	}{
+		"Success submitting the proxy":                 {proxy: "http://proxy.example.com:3128", wantTask: true},
+		"Success submitting the proxy to a new distro": {proxy: "http://proxy.example.com:3128", newDistro: true, wantTask: true},
+		"Success unmanaging distros without a proxy":   {wantTask: true},
+
+		"No task is submitted to a new distro without a proxy": {newDistro: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			conf := config.New(ctx, t.TempDir())
+			data := config.RegistryData{HTTPProxy: tc.proxy, HTTPSProxy: tc.proxy}
+			require.NoError(t, conf.UpdateRegistryData(ctx, data, nil), "Setup: could not set the registry data")
+
+			if tc.newDistro {
+				require.NoError(t, submitProxy(ctx, conf, d, false), "submitProxy should not fail")
+			} else {
+				distributeProxy(ctx, conf, db)
+			}
+
+			out, err := os.ReadFile(filepath.Join(dir, distroName+".tasks"))
+			if !tc.wantTask {
+				require.NotContains(t, string(out), "Proxy", "No proxy task should have been submitted")
+				return
+			}
+			require.NoError(t, err, "Could not read the task file")
+			require.Contains(t, string(out), "Proxy", "A proxy task should have been submitted")
+			require.Contains(t, string(out), tc.proxy, "The proxy task should have the proxy settings")
+		})
+	}
+}
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestDistributeSnapd(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testcases := map[string]struct {
+		proxy      string
+		storeProxy string
+		newDistro  bool
+
+		wantTask bool
+	}{
+		"Success submitting the proxy":                    {proxy: "http://proxy.example.com:3128", wantTask: true},
+		"Success submitting the snap store proxy":         {storeProxy: "http://snaps.example.com", wantTask: true},
+		"Success submitting the settings to a new distro": {proxy: "http://proxy.example.com:3128", storeProxy: "http://snaps.example.com", newDistro: true, wantTask: true},
+		"Success unmanaging distros without any settings": {wantTask: true},
+
+		"No task is submitted to a new distro without any settings": {newDistro: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			conf := config.New(ctx, t.TempDir())
+			data := config.RegistryData{HTTPProxy: tc.proxy, SnapStoreProxy: tc.storeProxy}
+			if tc.storeProxy != "" {
+				data.SnapStoreID = "mock-store-id"
+			}
+			require.NoError(t, conf.UpdateRegistryData(ctx, data, nil), "Setup: could not set the registry data")
+
+			if tc.newDistro {
+				require.NoError(t, submitSnapd(ctx, conf, d, false), "submitSnapd should not fail")
+			} else {
+				distributeSnapd(ctx, conf, db)
+			}
+
+			out, err := os.ReadFile(filepath.Join(dir, distroName+".tasks"))
+			if !tc.wantTask {
+				require.NotContains(t, string(out), "Snapd", "No snapd task should have been submitted")
+				return
+			}
+			require.NoError(t, err, "Could not read the task file")
+			require.Contains(t, string(out), "Snapd", "A snapd task should have been submitted")
+			require.Contains(t, string(out), tc.proxy, "The snapd task should have the proxy")
+			require.Contains(t, string(out), tc.storeProxy, "The snapd task should have the snap store proxy")
+		})
+	}
+}
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestDistributeDNS(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testcases := map[string]struct {
+		nameservers string
+		hostEntries string
+		newDistro   bool
+
+		wantTask bool
+	}{
+		"Success submitting the nameservers":              {nameservers: "10.0.0.53", wantTask: true},
+		"Success submitting the host entries":             {hostEntries: "10.0.0.1 intranet", wantTask: true},
+		"Success submitting the settings to a new distro": {nameservers: "10.0.0.53", hostEntries: "10.0.0.1 intranet", newDistro: true, wantTask: true},
+		"Success unmanaging distros without any settings": {wantTask: true},
+
+		"No task is submitted to a new distro without any settings": {newDistro: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			conf := config.New(ctx, t.TempDir())
+			data := config.RegistryData{Nameservers: tc.nameservers, HostEntries: tc.hostEntries}
+			require.NoError(t, conf.UpdateRegistryData(ctx, data, nil), "Setup: could not set the registry data")
+
+			if tc.newDistro {
+				require.NoError(t, submitDNS(ctx, conf, d, false), "submitDNS should not fail")
+			} else {
+				distributeDNS(ctx, conf, db)
+			}
+
+			out, err := os.ReadFile(filepath.Join(dir, distroName+".tasks"))
+			if !tc.wantTask {
+				require.NotContains(t, string(out), "tasks.DNS", "No DNS task should have been submitted")
+				return
+			}
+			require.NoError(t, err, "Could not read the task file")
+			require.Contains(t, string(out), "tasks.DNS", "A DNS task should have been submitted")
+			require.Contains(t, string(out), tc.nameservers, "The DNS task should have the nameservers")
+			require.Contains(t, string(out), tc.hostEntries, "The DNS task should have the host entries")
+		})
+	}
+}
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestDistributeServiceUpgrade(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testcases := map[string]struct {
+		channel config.UpdateChannel
+
+		wantTask bool
+	}{
+		"Success submitting an upgrade from a channel": {channel: config.UpdateChannel{Channel: config.ChannelStable}, wantTask: true},
+		"No upgrade is submitted without a channel":    {},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			toaster := &mockToaster{}
+			notifier := notifications.New(ctx, notifications.FrequencyImmediate, notifications.WithToaster(toaster))
+			defer notifier.Stop()
+
+			distributeServiceUpgrade(ctx, db, notifier, tc.channel)
+
+			out, err := os.ReadFile(filepath.Join(dir, distroName+".tasks"))
+			if !tc.wantTask {
+				require.NotContains(t, string(out), "ServiceUpgrade", "No upgrade task should have been submitted")
+				require.Zero(t, toaster.count.Load(), "No notification should have been shown")
+				return
+			}
+			require.Equal(t, int32(1), toaster.count.Load(), "The pending upgrade should have been notified")
+			require.NoError(t, err, "Could not read the task file")
+			require.Contains(t, string(out), "ServiceUpgrade", "An upgrade task should have been submitted")
+		})
+	}
+}
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestUpgradeScheduler(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	// A window that opens in a couple of hours, so that it is closed during the test.
+	opening := time.Now().Add(2 * time.Hour)
+	closedWindow := fmt.Sprintf("%s %s-%s", opening.Format("Mon"), opening.Format("15:04"), opening.Add(time.Minute).Format("15:04"))
+
+	testcases := map[string]struct {
+		windows  string
+		channels []config.UpdateChannel
+
+		wantTask bool
+	}{
+		"Success upgrading right away without maintenance windows": {channels: []config.UpdateChannel{{Channel: config.ChannelStable}}, wantTask: true},
+		"Success upgrading right away during a maintenance window": {windows: "daily 00:00-24:00", channels: []config.UpdateChannel{{Channel: config.ChannelStable}}, wantTask: true},
+
+		"Upgrade is held back until the maintenance window":    {windows: closedWindow, channels: []config.UpdateChannel{{Channel: config.ChannelStable}}},
+		"Pending upgrade is dropped when the channel is unset": {channels: []config.UpdateChannel{{Channel: config.ChannelStable}, {}}, windows: closedWindow},
+		"No upgrade is submitted without a channel":            {channels: []config.UpdateChannel{{}}},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			conf := config.New(ctx, dir)
+			err = conf.UpdateRegistryData(ctx, config.RegistryData{MaintenanceWindows: tc.windows}, nil)
+			require.NoError(t, err, "Setup: could not set the maintenance windows")
+
+			notifier := notifications.New(ctx, notifications.FrequencyImmediate, notifications.WithToaster(&mockToaster{}))
+			defer notifier.Stop()
+
+			u := newUpgradeScheduler(ctx, conf, db, notifier)
+			for _, channel := range tc.channels {
+				u.schedule(channel)
+			}
+
+			submitted := func() bool {
+				out, err := os.ReadFile(filepath.Join(dir, distroName+".tasks"))
+				return err == nil && strings.Contains(string(out), "ServiceUpgrade")
+			}
+
+			if !tc.wantTask {
+				require.Never(t, submitted, time.Second, 100*time.Millisecond, "No upgrade task should have been submitted")
+				return
+			}
+			require.Eventually(t, submitted, 5*time.Second, 100*time.Millisecond, "An upgrade task should have been submitted")
+		})
+	}
+}
+
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestUpgradeSchedulerResume(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	// A window that opens in a couple of hours, so that it is closed during the test.
+	opening := time.Now().Add(2 * time.Hour)
+	closedWindow := fmt.Sprintf("%s %s-%s", opening.Format("Mon"), opening.Format("15:04"), opening.Add(time.Minute).Format("15:04"))
+
+	testcases := map[string]struct {
+		distributedBefore bool
+
+		wantTask bool
+	}{
+		"Success resuming an upgrade held back before restarting": {wantTask: true},
+		"No upgrade is resumed once it was distributed":           {distributedBefore: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			notifier := notifications.New(ctx, notifications.FrequencyImmediate, notifications.WithToaster(&mockToaster{}))
+			defer notifier.Stop()
+
+			channel := config.UpdateChannel{Channel: config.ChannelStable}
+
+			// Before restarting, the upgrade is held back until a window that never opens.
+			conf := config.New(ctx, dir)
+			err = conf.UpdateRegistryData(ctx, config.RegistryData{UpdateChannel: channel, MaintenanceWindows: closedWindow}, nil)
+			require.NoError(t, err, "Setup: could not set the update channel")
+			if tc.distributedBefore {
+				require.NoError(t, conf.SetServiceUpgradeDistributed(channel), "Setup: could not record the upgrade as distributed")
+			}
+
+			// After restarting, the channel did not change but the window is now open.
+			conf = config.New(ctx, dir)
+			err = conf.UpdateRegistryData(ctx, config.RegistryData{UpdateChannel: channel, MaintenanceWindows: "daily 00:00-24:00"}, nil)
+			require.NoError(t, err, "Setup: could not set the update channel")
+
+			u := newUpgradeScheduler(ctx, conf, db, notifier)
+			u.resume()
+
+			submitted := func() bool {
+				out, err := os.ReadFile(filepath.Join(dir, distroName+".tasks"))
+				return err == nil && strings.Contains(string(out), "ServiceUpgrade")
+			}
+
+			if !tc.wantTask {
+				require.Never(t, submitted, time.Second, 100*time.Millisecond, "No upgrade task should have been submitted")
+				return
+			}
+			require.Eventually(t, submitted, 5*time.Second, 100*time.Millisecond, "The pending upgrade should have been submitted")
+
+			require.Eventually(t, func() bool {
+				pending, err := conf.PendingServiceUpgrade()
+				return err == nil && pending.Channel == ""
+			}, 5*time.Second, 100*time.Millisecond, "The upgrade should no longer be pending once distributed")
+		})
+	}
+}
+
+type mockToaster struct {
+	count atomic.Int32
+}
+
+func (m *mockToaster) Toast(ctx context.Context, title, body string) error {
+	m.count.Add(1)
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 	landscapeapi "github.com/canonical/landscape-hostagent-api"
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/ubuntu/decorate"
 	"google.golang.org/grpc"
@@ -88,6 +89,7 @@ func newConnection(ctx context.Context, d serviceData) (conn *connection, err er
 	// Get ready to receive commands
 	conn.receivingCommands.Add(1)
 	go func() {
+		defer crashreport.Recover("landscape")
 		defer conn.disconnect()
 		defer conn.receivingCommands.Done()
 
@@ -836,6 +836,7 @@ func TestNotifyConfigUpdate(t *testing.T) {
 	testcases := map[string]struct {
 		emptyDB   bool
 		conf, uid string
+		groupConf string
 
 		want        string
 		wantNoTasks bool
@@ -845,6 +846,8 @@ func TestNotifyConfigUpdate(t *testing.T) {
 		"Task contains empty client conf when UID is empty despite submitted conf is not empty": {uid: "-"},
 		"Task contains empty client conf when both are empty":                                   {conf: "-", uid: "-"},
 		"Task doesn't contain [host] section":                                                   {conf: "[host]\nurl=localhost\n[client]\nurl=another\n", want: "[client]\nurl=another\n"},
+		"Task contains the client conf of the distro group":                                     {groupConf: "[host]\nurl=localhost\n[client]\nteam=A\n", want: "[client]\nteam=A\n"},
+		"Task contains default client conf when the distro group conf is invalid":               {groupConf: "INVALID INI SYNTAX", want: "[client]\nhello=world"},
 
 		"Tasks are skipped when database is empty":                       {emptyDB: true, wantNoTasks: true},
 		"Tasks are skipped when config is invalid INI syntax":            {conf: "INVALID INI SYNTAX", wantNoTasks: true},
@@ -864,7 +867,9 @@ func TestNotifyConfigUpdate(t *testing.T) {
 			switch tc.conf {
 			case "":
 				tc.conf = "[client]\nhello=world"
-				tc.want = tc.conf
+				if tc.want == "" {
+					tc.want = tc.conf
+				}
 			case "-":
 				tc.conf = ""
 				tc.want = tc.conf
@@ -892,7 +897,7 @@ func TestNotifyConfigUpdate(t *testing.T) {
 			}
 
 			var cloudInit mockCloudInit
-			service, err := landscape.New(ctx, &mockConfig{}, db, &cloudInit, landscape.WithHomeDir(t.TempDir()))
+			service, err := landscape.New(ctx, &mockConfig{distroGroupConfig: tc.groupConf}, db, &cloudInit, landscape.WithHomeDir(t.TempDir()))
 			require.NoError(t, err, "Setup: New should not return an error")
 
 			service.NotifyConfigUpdate(ctx, tc.conf, tc.uid)
@@ -1089,6 +1094,7 @@ type mockConfig struct {
 	proToken              string
 	landscapeClientConfig string
 	landscapeAgentUID     string
+	distroGroupConfig     string
 
 	proTokenErr        bool
 	landscapeConfigErr bool
@@ -1108,6 +1114,17 @@ func (m *mockConfig) LandscapeClientConfig() (string, config.Source, error) {
 	return m.landscapeClientConfig, config.SourceUser, nil
 }
 
+func (m *mockConfig) LandscapeClientConfigFor(distroName string) (string, config.Source, error) {
+	m.mu.Lock()
+	groupConf := m.distroGroupConfig
+	m.mu.Unlock()
+
+	if groupConf != "" {
+		return groupConf, config.SourceDistroGroup, nil
+	}
+	return m.LandscapeClientConfig()
+}
+
 func (m *mockConfig) Subscription() (string, config.Source, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	landscapeapi "github.com/canonical/landscape-hostagent-api"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
@@ -52,6 +53,7 @@ type Service struct {
 // Config is a configuration provider for ProToken and the Landscape URL.
 type Config interface {
 	LandscapeClientConfig() (string, config.Source, error)
+	LandscapeClientConfigFor(distroName string) (string, config.Source, error)
 
 	Subscription() (string, config.Source, error)
 
@@ -151,6 +153,7 @@ func (s *Service) keepConnected() error {
 	started := make(chan error)
 
 	go func() {
+		defer crashreport.Recover("landscape")
 		defer close(s.running)
 
 		defer s.disconnect()
@@ -273,6 +276,7 @@ func (s *Service) connectOnce(ctx context.Context) (<-chan struct{}, error) {
 
 	connectionDone := make(chan struct{})
 	go func() {
+		defer crashreport.Recover("landscape")
 		defer close(connectionDone)
 
 		status := connectivity.Ready // Don't do GetState() just in case we already failed.
@@ -333,10 +337,37 @@ func (s *Service) NotifyConfigUpdate(ctx context.Context, landscapeConf, agentUI
 		}
 	}
 
-	distributeConfig(ctx, s.db, landscapeConf)
+	distributeConfig(ctx, s.db, func(distroName string) string {
+		if agentUID == "" {
+			return ""
+		}
+		return s.clientConfigFor(ctx, distroName, landscapeConf)
+	})
 	s.reconnectIfNewSettings(ctx)
 }
 
+// clientConfigFor returns the Landscape client configuration of the distro group the distro belongs to,
+// if any, or the default configuration otherwise.
+func (s *Service) clientConfigFor(ctx context.Context, distroName, defaultConf string) string {
+	conf, src, err := s.conf.LandscapeClientConfigFor(distroName)
+	if err != nil {
+		log.Warningf(ctx, "Landscape: %v", err)
+		return defaultConf
+	}
+
+	if src != config.SourceDistroGroup {
+		return defaultConf
+	}
+
+	conf, err = filterClientSection(conf)
+	if err != nil {
+		log.Warningf(ctx, "Landscape: could not use the configuration of the distro group of %q: %v", distroName, err)
+		return defaultConf
+	}
+
+	return conf
+}
+
 func (s *Service) reconnectIfNewSettings(ctx context.Context) {
 	oldSettings := func() connectionSettings {
 		s.connMu.RLock()
@@ -271,11 +271,11 @@ func tryDistroState(d *distro.Distro, interval, timeout time.Duration) (state go
 	return state, nil
 }
 
-func distributeConfig(ctx context.Context, db *database.DistroDB, landscapeConf string) {
+func distributeConfig(ctx context.Context, db *database.DistroDB, configFor func(distroName string) string) {
 	var err error
 	for _, distro := range db.GetAll() {
 		t := tasks.LandscapeConfigure{
-			Config: landscapeConf,
+			Config: configFor(distro.Name()),
 		}
 		err = errors.Join(err, distro.SubmitTasks(t))
 	}
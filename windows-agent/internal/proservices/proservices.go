@@ -3,23 +3,45 @@ package proservices
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	agent_api "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/grpc/interceptorschain"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logconnections"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/cloudinit"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/compliance"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consent"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/journal"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/latency"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/maintenance"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/metrics"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/notifications"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/operations"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/landscape"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/registrywatcher"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/ui"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/wslinstance"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/retention"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/secrets"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslconfig"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslversion"
 	"github.com/sirupsen/logrus"
 	wsl "github.com/ubuntu/gowsl"
 	"google.golang.org/grpc"
@@ -32,14 +54,39 @@ type Manager struct {
 	wslInstanceService *wslinstance.Service
 	landscapeService   *landscape.Service
 	registryWatcher    *registrywatcher.Service
+	notifier           *notifications.Digest
 	db                 *database.DistroDB
-
-	creds credentials.TransportCredentials
+	events             *journal.Journal
+	operations         *operations.Tracker
+	store              *store.Store
+
+	stopOfflineTokenWatch context.CancelFunc
+	stopLatencyProbe      context.CancelFunc
+	stopCounters          context.CancelFunc
+	stopJanitor           context.CancelFunc
+	stopServiceUpgrades   context.CancelFunc
+
+	certs       agentCerts
+	access      ownerAccess
+	creds       credentials.TransportCredentials
+	statusCreds credentials.TransportCredentials
+	statusDir   string
+
+	// privateDir is where the agent keeps its private data, such as the hand-off marker.
+	privateDir string
 }
 
 // options are the configurable functional options for the daemon.
 type options struct {
-	registry registrywatcher.Registry
+	registry  registrywatcher.Registry
+	retention retention.Policy
+	consent   consent.Policy
+	outbound  contracts.Outbound
+	wslInfo   wslversion.Info
+	wslConfig string
+	statusDir string
+
+	notifyProxy config.ProxyNotifier
 }
 
 // Option is the function signature we are passing to tweak the daemon creation.
@@ -52,6 +99,61 @@ func WithRegistry(registry registrywatcher.Registry) func(o *options) {
 	}
 }
 
+// WithRetention sets the policy on how much of the agent's data is kept on disk.
+// Unset values default to those of retention.DefaultPolicy.
+func WithRetention(policy retention.Policy) func(o *options) {
+	return func(o *options) {
+		o.retention = policy
+	}
+}
+
+// WithConsentPolicy sets what happens to the requests of tasks for user confirmation that nobody answers.
+// Unset values default to those of consent.DefaultPolicy.
+func WithConsentPolicy(policy consent.Policy) func(o *options) {
+	return func(o *options) {
+		o.consent = policy
+	}
+}
+
+// WithOutbound makes the calls to the contract server and to the Microsoft Store go through o, such as a
+// sandboxed process, instead of being performed by the agent itself.
+func WithOutbound(o contracts.Outbound) func(o *options) {
+	return func(opts *options) {
+		opts.outbound = o
+	}
+}
+
+// WithWslInfo sets the information about the host WSL reported to the GUI.
+func WithWslInfo(info wslversion.Info) func(o *options) {
+	return func(o *options) {
+		o.wslInfo = info
+	}
+}
+
+// WithWslConfig sets the path of the global WSL configuration file the GUI can manage.
+// It defaults to wslconfig.FileName in the profile directory of the user.
+func WithWslConfig(path string) func(o *options) {
+	return func(o *options) {
+		o.wslConfig = path
+	}
+}
+
+// WithStatusDir sets the directory where the certificates of the read-only status API are written.
+// It defaults to common.StatusDir next to the public directory.
+func WithStatusDir(dir string) func(o *options) {
+	return func(o *options) {
+		o.statusDir = dir
+	}
+}
+
+// WithProxyNotifier sets the function called with the proxy settings of the registry once they are read, and then
+// whenever they change, so that the agent can reach the network through the same proxy as the distros.
+func WithProxyNotifier(notify config.ProxyNotifier) func(o *options) {
+	return func(o *options) {
+		o.notifyProxy = notify
+	}
+}
+
 // New returns a new GRPC services manager.
 // It instantiates both ui and wsl instance services.
 //
@@ -72,37 +174,163 @@ func New(ctx context.Context, publicDir, privateDir string, args ...Option) (s M
 		f(&opts)
 	}
 
+	if opts.statusDir == "" {
+		opts.statusDir = common.StatusPublicDir(filepath.Dir(publicDir))
+	}
+
 	// Ugly trick to prevent WSL error 0x80070005 due bad interaction with the Store API.
 	// See more in:
 	//[Jira](https://warthogs.atlassian.net/browse/UDENG-1810)
 	//[GitHub](https://github.com/canonical/ubuntu-pro-for-wsl/pull/438)
 	InitWSLAPI()
 
-	conf := config.New(ctx, privateDir)
+	// The database, the task queues, the journal, the configuration and its secrets are all kept in the same store.
+	st, err := store.Open(ctx, filepath.Join(privateDir, consts.StoreFileName))
+	if err != nil {
+		return s, err
+	}
+	s.store = st
+	s.privateDir = privateDir
+
+	// The store must be up to date before anything reads it.
+	if err := prepareStore(ctx, st, privateDir); err != nil {
+		return s, err
+	}
+
+	conf := config.New(ctx, privateDir, config.WithStore(st), config.WithSecrets(secrets.New(st)))
+
+	// Operations are recorded in the store too, so that the outcome of fleet-wide changes can be exported later.
+	s.operations = operations.New(ctx, st)
 
 	cloudInit, err := cloudinit.New(ctx, conf, publicDir)
 	if err != nil {
 		return s, err
 	}
 
+	events, err := journal.New(ctx, filepath.Join(privateDir, consts.JournalFileName), journal.WithStore(st))
+	if err != nil {
+		return s, err
+	}
+	s.events = events
+
+	// Distros find the broker in their context to ask for consent.
+	broker := consent.New(opts.consent)
+	ctx = consent.WithBroker(ctx, broker)
+
+	// Distros find the meter in their context to account for the data transferred to and from them.
+	meter := bandwidth.New()
+	ctx = bandwidth.WithMeter(ctx, meter)
+
 	db, err := database.New(
 		ctx, privateDir,
-		func(d string) {
+		database.WithStore(st),
+		database.WithDiscovery(),
+		database.WithCleanup(func(d string) {
 			err = cloudInit.RemoveDistroData(d)
 			if err != nil {
 				log.Warningf(ctx, "Could not remove leftover distro data: %v", err)
 			}
-		},
+		}),
+		database.WithCleanup(func(d string) {
+			events.Record(ctx, journal.DistroRemoved, d, "")
+		}),
+		database.WithCleanup(meter.Forget),
+		database.WithRename(func(ctx context.Context, d *distro.Distro, oldName string) {
+			events.Record(ctx, journal.DistroRenamed, d.Name(), oldName)
+			events.Follow(ctx, d)
+			if err := cloudInit.RenameDistroData(oldName, d.Name()); err != nil {
+				log.Warningf(ctx, "Could not rename distro data: %v", err)
+			}
+			if s.landscapeService == nil {
+				return
+			}
+			// Sending the info may block while reconnecting, holding up the distro that reported the rename.
+			go func() {
+				defer crashreport.Recover("proservices")
+				if err := s.landscapeService.Controller().SendUpdatedInfo(ctx); err != nil {
+					log.Warningf(ctx, "Could not notify Landscape of the renamed distro %q: %v", d.Name(), err)
+				}
+			}()
+		}),
 	)
 	if err != nil {
 		return s, err
 	}
 	s.db = db
 
+	// Distros loaded from disk are not new, but their events are journaled too.
+	for _, d := range s.db.GetAll() {
+		events.Follow(ctx, d)
+	}
+	s.db.SetDistroAddedNotifier(func(ctx context.Context, d *distro.Distro) {
+		events.Record(ctx, journal.DistroAdded, d.Name(), "")
+		events.Follow(ctx, d)
+		if err := submitPatching(ctx, conf, d, false); err != nil {
+			log.Warningf(ctx, "Distro %q: could not submit patching level: %v", d.Name(), err)
+		}
+		if err := submitProxy(ctx, conf, d, false); err != nil {
+			log.Warningf(ctx, "Distro %q: could not submit proxy settings: %v", d.Name(), err)
+		}
+		if err := submitSnapd(ctx, conf, d, false); err != nil {
+			log.Warningf(ctx, "Distro %q: could not submit snapd settings: %v", d.Name(), err)
+		}
+		if err := submitDNS(ctx, conf, d, false); err != nil {
+			log.Warningf(ctx, "Distro %q: could not submit DNS settings: %v", d.Name(), err)
+		}
+	})
+
+	s.notifier = notifications.New(ctx, notificationFrequency(ctx, conf))
+
+	// The subsystems interested in the properties that the distros report are told when they change.
+	s.db.OnPropertyChange(database.ProAttached, func(ctx context.Context, c database.PropertyChange) {
+		if !c.New.ProAttached {
+			return
+		}
+		s.notifier.Notify(ctx, notifications.Event{
+			Priority: notifications.Low,
+			Category: notifications.CategoryAttached,
+			Message:  fmt.Sprintf("%s is attached to Ubuntu Pro", c.Distro.Name()),
+		})
+	})
+	s.db.OnPropertyChange(database.Used, func(ctx context.Context, c database.PropertyChange) {
+		// The attach policy may have held back the attachment of the distro until it was launched by the user.
+		ubuntupro.AttachOnFirstUse(ctx, s.operations, conf, c.Distro)
+	})
+	s.db.OnPropertyChange(database.VersionID, func(ctx context.Context, c database.PropertyChange) {
+		// The first report of the release is no upgrade: the distro got its settings when it was added.
+		if c.Old.VersionID == "" {
+			return
+		}
+		// A release upgrade may replace the configuration of unattended-upgrades.
+		log.Infof(ctx, "Distro %q: upgraded from %s to %s", c.Distro.Name(), c.Old.VersionID, c.New.VersionID)
+		if err := submitPatching(ctx, conf, c.Distro, false); err != nil {
+			log.Warningf(ctx, "Distro %q: could not submit patching level: %v", c.Distro.Name(), err)
+		}
+	})
+
 	w := registrywatcher.New(ctx, conf, s.db, registrywatcher.WithRegistry(opts.registry))
 	s.registryWatcher = &w
 
-	s.uiService = ui.New(ctx, conf, s.db)
+	var contractsArgs []contracts.Option
+	if opts.outbound != nil {
+		contractsArgs = append(contractsArgs, contracts.WithOutbound(opts.outbound))
+	}
+
+	health, err := contracts.OpenHealth(filepath.Join(privateDir, consts.ContractHealthFileName))
+	if err != nil {
+		log.Warningf(ctx, "Could not load the health of the contract server synchronisation: %v", err)
+	}
+	contractsArgs = append(contractsArgs, contracts.WithHealth(health))
+
+	if opts.wslConfig == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return s, fmt.Errorf("could not locate the user home dir: %v", err)
+		}
+		opts.wslConfig = filepath.Join(homeDir, wslconfig.FileName)
+	}
+
+	s.uiService = ui.New(ctx, conf, s.db, events, broker, s.operations, filepath.Join(privateDir, consts.UsgReportsDir), opts.wslConfig, opts.wslInfo, contractsArgs...)
 
 	landscape, err := landscape.New(ctx, conf, s.db, cloudInit)
 	if err != nil {
@@ -110,12 +338,28 @@ func New(ctx context.Context, publicDir, privateDir string, args ...Option) (s M
 	}
 	s.landscapeService = landscape
 
-	s.wslInstanceService = wslinstance.New(ctx, s.db, s.landscapeService.Controller())
+	upgradesCtx, cancel := context.WithCancel(ctx)
+	s.stopServiceUpgrades = cancel
+	upgrades := newUpgradeScheduler(upgradesCtx, conf, s.db, s.notifier)
+
+	s.wslInstanceService = wslinstance.New(ctx, s.db, s.landscapeService.Controller(), conf, wslinstance.WithServiceUpgrader(upgrades))
 
 	conf.SetUbuntuProNotifier(func(ctx context.Context, token string) {
-		ubuntupro.Distribute(ctx, s.db, token)
+		ubuntupro.Distribute(ctx, s.db, s.operations, conf, token)
 		landscape.NotifyUbuntuProUpdate(ctx, token)
 		cloudInit.Update(ctx)
+		s.uiService.NotifySubscriptionChanged()
+
+		if token == "" {
+			return
+		}
+		for _, d := range s.db.GetAll() {
+			s.notifier.Notify(ctx, notifications.Event{
+				Priority: notifications.Low,
+				Category: notifications.CategoryAttachPending,
+				Message:  fmt.Sprintf("Ubuntu Pro will be attached to %s", d.Name()),
+			})
+		}
 	})
 
 	conf.SetLandscapeNotifier(func(ctx context.Context, conf, uid string) {
@@ -123,10 +367,77 @@ func New(ctx context.Context, publicDir, privateDir string, args ...Option) (s M
 		cloudInit.Update(ctx)
 	})
 
+	conf.SetUpdateChannelNotifier(func(ctx context.Context, channel config.UpdateChannel) {
+		upgrades.schedule(channel)
+	})
+
+	conf.SetMinimumServiceVersionNotifier(func(ctx context.Context, _ string) {
+		s.wslInstanceService.RecheckServiceVersions(ctx)
+	})
+
+	conf.SetNotificationsNotifier(func(ctx context.Context, _ string) {
+		s.notifier.SetFrequency(notificationFrequency(ctx, conf))
+	})
+
+	conf.SetPatchingNotifier(func(ctx context.Context, _ config.PatchingLevel) {
+		distributePatching(ctx, conf, s.db)
+	})
+
+	conf.SetProxyNotifier(func(ctx context.Context, proxy config.ProxySettings) {
+		distributeProxy(ctx, conf, s.db)
+		distributeSnapd(ctx, conf, s.db)
+		if opts.notifyProxy != nil {
+			opts.notifyProxy(ctx, proxy)
+		}
+	})
+
+	conf.SetSnapNotifier(func(ctx context.Context, _ config.SnapSettings) {
+		distributeSnapd(ctx, conf, s.db)
+	})
+
+	conf.SetDNSNotifier(func(ctx context.Context, _ config.DNSSettings) {
+		distributeDNS(ctx, conf, s.db)
+	})
+
+	conf.SetTransferCapNotifier(func(ctx context.Context, bytes uint64) {
+		meter.SetDailyCap(bytes)
+	})
+
 	// All notifications have been set up: starting the registry watcher before any services.
 	s.registryWatcher.Start()
 
-	if err := ubuntupro.FetchFromMicrosoftStore(ctx, conf, s.db); err != nil {
+	// The proxy notifier is only called on changes: the registry may have the same settings as on the last start.
+	if opts.notifyProxy != nil {
+		if proxy, _, err := conf.ProxySettings(); err != nil {
+			log.Warningf(ctx, "Could not read the proxy settings: %v", err)
+		} else {
+			opts.notifyProxy(ctx, proxy)
+		}
+	}
+
+	// The registry has been read: upgrades that were held back before the last stop can be resumed.
+	upgrades.resume()
+
+	offlineCtx, cancel := context.WithCancel(ctx)
+	s.stopOfflineTokenWatch = cancel
+	go watchOfflineToken(offlineCtx, conf, s.notifier)
+
+	probeCtx, cancel := context.WithCancel(ctx)
+	s.stopLatencyProbe = cancel
+	go probeLatencies(probeCtx, s.db)
+
+	countersCtx, cancel := context.WithCancel(ctx)
+	s.stopCounters = cancel
+	go publishComplianceCounters(countersCtx, s.db)
+
+	registerDistroMetrics(s.db)
+
+	janitorCtx, cancel := context.WithCancel(ctx)
+	s.stopJanitor = cancel
+	janitorSchedule := retention.WithSchedule(func() maintenance.Schedule { return maintenanceSchedule(janitorCtx, conf) })
+	go retention.New(opts.retention, s.db, publicDir, privateDir, janitorSchedule).Run(janitorCtx)
+
+	if err := ubuntupro.FetchFromMicrosoftStore(ctx, conf, s.db, contractsArgs...); err != nil {
 		log.Warningf(ctx, "%v", err)
 	}
 
@@ -134,19 +445,374 @@ func New(ctx context.Context, publicDir, privateDir string, args ...Option) (s M
 		log.Warning(ctx, err.Error())
 	}
 
-	destDir := filepath.Join(publicDir, common.CertificatesDir)
+	destDir := common.CertificatesPath(publicDir)
 	if err := os.MkdirAll(destDir, 0700); err != nil {
 		return s, fmt.Errorf("failed to create certificates directory: %s", err)
 	}
-	certs, err := newTLSCertificates(destDir)
+	certs, err := newTLSCertificates(destDir, common.ClientsCertFilePrefix, common.WSLProServiceCertFilePrefix)
 	if err != nil {
 		return s, fmt.Errorf("failed to create certificates: %s", err)
 	}
 
+	s.certs = certs
 	s.creds = credentials.NewTLS(certs.agentTLSConfig())
+
+	access, err := newOwnerAccess()
+	if err != nil {
+		return s, fmt.Errorf("failed to identify the user running the agent: %s", err)
+	}
+	s.access = access
+
+	// The status API has certificates of its own, out of the public directory and with an access control list of
+	// their own, so that its clients cannot use the main socket.
+	if err := restrictAccess(opts.statusDir); err != nil {
+		return s, fmt.Errorf("failed to create status directory: %s", err)
+	}
+	statusCertsDir := common.CertificatesPath(opts.statusDir)
+	if err := os.MkdirAll(statusCertsDir, 0700); err != nil {
+		return s, fmt.Errorf("failed to create status certificates directory: %s", err)
+	}
+	statusCerts, err := newTLSCertificates(statusCertsDir, common.ClientsCertFilePrefix)
+	if err != nil {
+		return s, fmt.Errorf("failed to create status certificates: %s", err)
+	}
+
+	s.statusCreds = credentials.NewTLS(statusCerts.agentTLSConfig())
+	s.statusDir = opts.statusDir
 	return s, nil
 }
 
+// StatusDir returns the directory where the address and certificates of the read-only status API are written.
+func (m Manager) StatusDir() string {
+	return m.statusDir
+}
+
+// SetDefaultSettings sets the settings applied when the registry leaves them empty, such as those of the
+// configuration file of the agent.
+func (m Manager) SetDefaultSettings(data config.RegistryData) {
+	if m.registryWatcher == nil {
+		return
+	}
+	m.registryWatcher.SetDefaults(data)
+}
+
+// distributeServiceUpgrade submits a task to all distros to upgrade wsl-pro-service from the new channel.
+// Unsetting the channel does not downgrade the distros.
+func distributeServiceUpgrade(ctx context.Context, db *database.DistroDB, notifier *notifications.Digest, channel config.UpdateChannel) {
+	if channel.Channel == "" {
+		return
+	}
+
+	task := tasks.ServiceUpgrade{
+		Channel:  channel.Channel,
+		Source:   channel.Source,
+		Checksum: channel.Checksum,
+	}
+
+	var err error
+	for _, d := range db.GetAll() {
+		if e := d.SubmitTasks(task); e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+
+		notifier.Notify(ctx, notifications.Event{
+			Priority: notifications.Low,
+			Category: notifications.CategoryUpgradePending,
+			Message:  fmt.Sprintf("Ubuntu Pro for WSL will be upgraded in %s", d.Name()),
+		})
+	}
+
+	if err != nil {
+		log.Warningf(ctx, "could not submit service upgrade to all distros: %v", err)
+		notifier.Notify(ctx, notifications.Event{
+			Priority: notifications.Critical,
+			Message:  "Ubuntu Pro for WSL could not be upgraded in some distros. Check the logs for more details.",
+		})
+	}
+}
+
+// distributePatching submits a task to all distros to configure unattended-upgrades with their patching level.
+// Distros left without a patching level stop being managed.
+func distributePatching(ctx context.Context, conf *config.Config, db *database.DistroDB) {
+	var err error
+	for _, d := range db.GetAll() {
+		err = errors.Join(err, submitPatching(ctx, conf, d, true))
+	}
+
+	if err != nil {
+		log.Warningf(ctx, "could not submit patching level to all distros: %v", err)
+	}
+}
+
+// submitPatching submits a task to the distro to configure unattended-upgrades with its patching level.
+// Distros without a patching level are only sent a task to stop managing it if unmanage is true.
+func submitPatching(ctx context.Context, conf *config.Config, d *distro.Distro, unmanage bool) error {
+	level, _, err := conf.PatchingLevelFor(d.Name())
+	if err != nil {
+		log.Warningf(ctx, "Distro %q: %v", d.Name(), err)
+		return nil
+	}
+
+	if level == "" && !unmanage {
+		return nil
+	}
+
+	return d.SubmitTasks(tasks.Patching{Level: string(level)})
+}
+
+// distributeProxy submits a task to all distros to configure their proxy. If the proxy settings were
+// removed, the distros stop being managed.
+func distributeProxy(ctx context.Context, conf *config.Config, db *database.DistroDB) {
+	var err error
+	for _, d := range db.GetAll() {
+		err = errors.Join(err, submitProxy(ctx, conf, d, true))
+	}
+
+	if err != nil {
+		log.Warningf(ctx, "could not submit proxy settings to all distros: %v", err)
+	}
+}
+
+// submitProxy submits a task to the distro to configure its proxy. Without proxy settings, the distro is
+// only sent a task to stop managing it if unmanage is true.
+func submitProxy(ctx context.Context, conf *config.Config, d *distro.Distro, unmanage bool) error {
+	proxy, _, err := conf.ProxySettings()
+	if err != nil {
+		log.Warningf(ctx, "Distro %q: %v", d.Name(), err)
+		return nil
+	}
+
+	if proxy.HTTP == "" && proxy.HTTPS == "" && !unmanage {
+		return nil
+	}
+
+	return d.SubmitTasks(tasks.Proxy{HTTP: proxy.HTTP, HTTPS: proxy.HTTPS, NoProxy: proxy.NoProxy})
+}
+
+// distributeSnapd submits a task to all distros to configure snapd with the proxy and the snap store proxy.
+// If both were removed, the distros stop being managed.
+func distributeSnapd(ctx context.Context, conf *config.Config, db *database.DistroDB) {
+	var err error
+	for _, d := range db.GetAll() {
+		err = errors.Join(err, submitSnapd(ctx, conf, d, true))
+	}
+
+	if err != nil {
+		log.Warningf(ctx, "could not submit snapd settings to all distros: %v", err)
+	}
+}
+
+// submitSnapd submits a task to the distro to configure snapd. snapd ignores the proxy of the environment,
+// so it is sent the same proxy as the rest of the distro. Without any settings, the distro is only sent a
+// task to stop managing snapd if unmanage is true.
+func submitSnapd(ctx context.Context, conf *config.Config, d *distro.Distro, unmanage bool) error {
+	proxy, _, err := conf.ProxySettings()
+	if err != nil {
+		log.Warningf(ctx, "Distro %q: %v", d.Name(), err)
+		return nil
+	}
+
+	snap, _, err := conf.SnapSettings()
+	if err != nil {
+		log.Warningf(ctx, "Distro %q: %v", d.Name(), err)
+		return nil
+	}
+
+	t := tasks.Snapd{HTTP: proxy.HTTP, HTTPS: proxy.HTTPS, StoreProxy: snap.StoreProxy, StoreID: snap.StoreID}
+	if t == (tasks.Snapd{}) && !unmanage {
+		return nil
+	}
+
+	return d.SubmitTasks(t)
+}
+
+// distributeDNS submits a task to all distros to configure their nameservers, search domains and host entries.
+// If they were all removed, the distros stop being managed.
+func distributeDNS(ctx context.Context, conf *config.Config, db *database.DistroDB) {
+	var err error
+	for _, d := range db.GetAll() {
+		err = errors.Join(err, submitDNS(ctx, conf, d, true))
+	}
+
+	if err != nil {
+		log.Warningf(ctx, "could not submit DNS settings to all distros: %v", err)
+	}
+}
+
+// submitDNS submits a task to the distro to configure its DNS. Without DNS settings, the distro is only sent a
+// task to stop managing it if unmanage is true.
+func submitDNS(ctx context.Context, conf *config.Config, d *distro.Distro, unmanage bool) error {
+	dns, src, err := conf.DNSSettings()
+	if err != nil {
+		log.Warningf(ctx, "Distro %q: %v", d.Name(), err)
+		return nil
+	}
+
+	if src == config.SourceNone && !unmanage {
+		return nil
+	}
+
+	return d.SubmitTasks(tasks.DNS{Nameservers: dns.Nameservers, SearchDomains: dns.SearchDomains, Hosts: dns.Hosts})
+}
+
+// offlineTokenCheckInterval is how often the offline Ubuntu Pro token file is read again.
+const offlineTokenCheckInterval = 24 * time.Hour
+
+// watchOfflineToken periodically reads the offline Ubuntu Pro token file, if any, to apply renewed tokens
+// and remind the user about its expiration, until the context is cancelled.
+func watchOfflineToken(ctx context.Context, conf *config.Config, notifier *notifications.Digest) {
+	defer crashreport.Recover("proservices")
+	for {
+		ubuntupro.CheckOfflineToken(ctx, conf, notifier)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(offlineTokenCheckInterval):
+		}
+	}
+}
+
+// registerDistroMetrics exposes how many distros are connected and how many tasks each of them has queued.
+// Both are collected from the database when the metrics are scraped.
+func registerDistroMetrics(db *database.DistroDB) {
+	metrics.Default.GaugeFunc("connected_distros", "Number of distros whose WSL Pro service is connected.", nil, func() []metrics.Sample {
+		var connected int
+		for _, d := range db.GetAll() {
+			if active, err := d.IsActive(); err == nil && active {
+				connected++
+			}
+		}
+		return []metrics.Sample{{Value: float64(connected)}}
+	})
+
+	metrics.Default.GaugeFunc("queued_tasks", "Number of tasks waiting to be processed, by distro.", []string{"distro"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		for _, d := range db.GetAll() {
+			samples = append(samples, metrics.Sample{Labels: []string{d.Name()}, Value: float64(d.PendingTasks())})
+		}
+		return samples
+	})
+}
+
+const (
+	// latencyProbeInterval is how often the connected distros are pinged to measure their latency.
+	latencyProbeInterval = 5 * time.Minute
+
+	// latencyProbeTimeout is how long a distro has to answer a ping before it is recorded as a failure.
+	latencyProbeTimeout = 10 * time.Second
+)
+
+// probeLatencies periodically pings all connected distros at once to keep track of their round-trip times,
+// and publishes them as performance counters, until the context is cancelled.
+func probeLatencies(ctx context.Context, db *database.DistroDB) {
+	defer crashreport.Recover("proservices")
+	counters, err := latency.NewCounters()
+	if err != nil {
+		log.Warningf(ctx, "Latencies will not be available as performance counters: %v", err)
+	} else {
+		defer counters.Close()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(latencyProbeInterval):
+		}
+
+		distros := db.GetAll()
+
+		var wg sync.WaitGroup
+		for _, d := range distros {
+			if active, err := d.IsActive(); err != nil || !active {
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer crashreport.Recover("proservices")
+				defer wg.Done()
+
+				ctx, cancel := context.WithTimeout(ctx, latencyProbeTimeout)
+				defer cancel()
+
+				rtt, err := d.Ping(ctx)
+				if err != nil {
+					log.Warningf(ctx, "Distro %q: could not measure latency: %v", d.Name(), err)
+					return
+				}
+				log.Debugf(ctx, "Distro %q: round-trip time is %s", d.Name(), rtt)
+			}()
+		}
+		wg.Wait()
+
+		if counters == nil {
+			continue
+		}
+
+		latencies := make(map[string]distro.Latency, len(distros))
+		for _, d := range distros {
+			latencies[d.Name()] = d.Latency()
+		}
+
+		if err := counters.Publish(latencies); err != nil {
+			log.Warningf(ctx, "%v", err)
+		}
+	}
+}
+
+// complianceCountersInterval is how often the compliance performance counters are updated.
+const complianceCountersInterval = time.Minute
+
+// publishComplianceCounters periodically publishes the compliance metrics of all distros as performance
+// counters, until the context is cancelled.
+func publishComplianceCounters(ctx context.Context, db *database.DistroDB) {
+	defer crashreport.Recover("proservices")
+	counters, err := compliance.NewCounters()
+	if err != nil {
+		log.Warningf(ctx, "Compliance metrics will not be available as performance counters: %v", err)
+		return
+	}
+	defer counters.Close()
+
+	for {
+		props := make(map[string]distro.Properties)
+		for _, d := range db.GetAll() {
+			props[d.Name()] = d.Properties()
+		}
+
+		if err := counters.Publish(compliance.NewReport(props)); err != nil {
+			log.Warningf(ctx, "%v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(complianceCountersInterval):
+		}
+	}
+}
+
+// notificationFrequency returns the notification frequency chosen by the user, or the default one if it
+// cannot be read.
+func notificationFrequency(ctx context.Context, conf *config.Config) notifications.Frequency {
+	s, err := conf.NotificationFrequency()
+	if err != nil {
+		log.Warningf(ctx, "could not read notification frequency: %v", err)
+		return notifications.DefaultFrequency
+	}
+
+	f, err := notifications.ParseFrequency(s)
+	if err != nil {
+		log.Warningf(ctx, "ignoring notification frequency: %v", err)
+		return notifications.DefaultFrequency
+	}
+
+	return f
+}
+
 // Stop deallocates resources in the services.
 func (m Manager) Stop(ctx context.Context) {
 	log.Info(ctx, "Stopping GRPC services manager")
@@ -159,9 +825,45 @@ func (m Manager) Stop(ctx context.Context) {
 		m.registryWatcher.Stop()
 	}
 
+	if m.stopOfflineTokenWatch != nil {
+		m.stopOfflineTokenWatch()
+	}
+
+	if m.stopLatencyProbe != nil {
+		m.stopLatencyProbe()
+	}
+
+	if m.stopCounters != nil {
+		m.stopCounters()
+	}
+
+	if m.stopJanitor != nil {
+		m.stopJanitor()
+	}
+
+	if m.stopServiceUpgrades != nil {
+		m.stopServiceUpgrades()
+	}
+
+	if m.notifier != nil {
+		m.notifier.Stop()
+	}
+
 	if m.db != nil {
 		m.db.Close(ctx)
 	}
+
+	if m.events != nil {
+		m.events.Close()
+	}
+
+	m.operations.Close()
+
+	if m.store != nil {
+		if err := m.store.Close(); err != nil {
+			log.Warningf(ctx, "Could not close the store: %v", err)
+		}
+	}
 }
 
 // RegisterGRPCServices returns a new grpc Server with the 2 api services attached to it.
@@ -171,8 +873,14 @@ func (m Manager) RegisterGRPCServices(ctx context.Context, isWslNetAvailable boo
 	log.Debug(ctx, "Registering GRPC services")
 
 	// This is never nil because grpc.NewServer() never returns nil.
-	grpcServer := grpc.NewServer(grpc.StreamInterceptor(
-		interceptorschain.StreamServer(
+	// Each service only accepts the client certificate issued for it: the GUI one for the UI service and the WSL Pro
+	// service one for the WSLInstance service. On top of that, only the user running the agent and the administrators
+	// can change its state through the UI service.
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(m.certs.unaryInterceptor, m.access.unaryInterceptor),
+		grpc.StreamInterceptor(interceptorschain.StreamServer(
+			m.certs.streamInterceptor,
+			m.access.streamInterceptor,
 			log.StreamServerInterceptor(logrus.StandardLogger()),
 			logconnections.StreamServerInterceptor(),
 		)), grpc.Creds(m.creds))
@@ -19,8 +19,10 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 func TestMain(m *testing.M) {
@@ -33,21 +35,23 @@ func TestNew(t *testing.T) {
 	t.Parallel()
 
 	testCases := map[string]struct {
-		breakConfig          bool
-		breakNewDistroDB     bool
-		breakCertificatesDir bool
-		breakCA              bool
-		breakCloudInit       bool
+		breakConfig                bool
+		breakStore                 bool
+		breakCertificatesDir       bool
+		breakStatusCertificatesDir bool
+		breakCA                    bool
+		breakCloudInit             bool
 
 		wantErr bool
 	}{
 		"When the subscription stays empty":               {},
 		"When the config cannot check if it is read-only": {breakConfig: true},
 
-		"Error when database cannot create its dump file":     {breakNewDistroDB: true, wantErr: true},
-		"Error when certificates directory cannot be created": {breakCertificatesDir: true, wantErr: true},
-		"Error when CA certificate cannot be created":         {breakCA: true, wantErr: true},
-		"Error when cloud-init dir cannot be created":         {breakCloudInit: true, wantErr: true},
+		"Error when the store cannot be opened":                      {breakStore: true, wantErr: true},
+		"Error when certificates directory cannot be created":        {breakCertificatesDir: true, wantErr: true},
+		"Error when status certificates directory cannot be created": {breakStatusCertificatesDir: true, wantErr: true},
+		"Error when CA certificate cannot be created":                {breakCA: true, wantErr: true},
+		"Error when cloud-init dir cannot be created":                {breakCloudInit: true, wantErr: true},
 	}
 
 	for name, tc := range testCases {
@@ -57,20 +61,24 @@ func TestNew(t *testing.T) {
 
 			publicDir := t.TempDir()
 			privateDir := t.TempDir()
+			statusDir := t.TempDir()
 
 			reg := registry.NewMock()
 			k, err := reg.HKCUCreateKey("Software/Canonical/UbuntuPro")
 			require.NoError(t, err, "Setup: could not create Ubuntu Pro registry key")
 			defer reg.CloseKey(k)
 
-			if tc.breakNewDistroDB {
-				dbFile := filepath.Join(privateDir, consts.DatabaseFileName)
-				err := os.MkdirAll(dbFile, 0600)
-				require.NoError(t, err, "Setup: could not write directory where database wants to put a file")
+			if tc.breakStore {
+				storeFile := filepath.Join(privateDir, consts.StoreFileName)
+				err := os.MkdirAll(storeFile, 0600)
+				require.NoError(t, err, "Setup: could not write directory where the store wants to put a file")
 			}
 			if tc.breakCertificatesDir {
 				require.NoError(t, os.WriteFile(filepath.Join(publicDir, common.CertificatesDir), []byte{}, 0600), "Setup: could not create the file that should break writing the certificates")
 			}
+			if tc.breakStatusCertificatesDir {
+				require.NoError(t, os.WriteFile(filepath.Join(statusDir, common.CertificatesDir), []byte{}, 0600), "Setup: could not create the file that should break writing the status certificates")
+			}
 			if tc.breakCA {
 				require.NoError(t, os.MkdirAll(filepath.Join(publicDir, common.CertificatesDir, common.RootCACertFileName), 0700), "Setup: could not break the root CA certificate file")
 			}
@@ -81,7 +89,7 @@ func TestNew(t *testing.T) {
 				f.Close()
 			}
 
-			s, err := proservices.New(ctx, publicDir, privateDir, proservices.WithRegistry(reg))
+			s, err := proservices.New(ctx, publicDir, privateDir, proservices.WithRegistry(reg), proservices.WithStatusDir(statusDir))
 			if err == nil {
 				defer s.Stop(ctx)
 			}
@@ -122,8 +130,9 @@ func TestRegisterGRPCServices(t *testing.T) {
 	defaultServices := []string{"agentapi.UI", "agentapi.WSLInstance"}
 
 	testCases := map[string]struct {
-		insecureClient bool
-		withoutWSLNet  bool
+		insecureClient    bool
+		withoutWSLNet     bool
+		wslProServiceCert bool
 
 		wantServices []string
 		wantErr      bool
@@ -131,7 +140,8 @@ func TestRegisterGRPCServices(t *testing.T) {
 		"Success with WSL net adapter":    {wantServices: defaultServices},
 		"Success without WSL net adapter": {withoutWSLNet: true, wantServices: []string{"agentapi.UI"}},
 
-		"Error with insecure requests": {insecureClient: true, wantServices: defaultServices, wantErr: true},
+		"Error with insecure requests":                                   {insecureClient: true, wantServices: defaultServices, wantErr: true},
+		"Error when calling the UI with the WSL Pro service certificate": {wslProServiceCert: true, wantServices: defaultServices, wantErr: true},
 	}
 
 	for name, tc := range testCases {
@@ -180,7 +190,11 @@ func TestRegisterGRPCServices(t *testing.T) {
 			addr := lis.Addr().String()
 			creds := insecure.NewCredentials()
 			if !tc.insecureClient {
-				creds = loadClientCertificates(t, filepath.Join(publicDir, common.CertificatesDir))
+				prefix := common.ClientsCertFilePrefix
+				if tc.wslProServiceCert {
+					prefix = common.WSLProServiceCertFilePrefix
+				}
+				creds = loadClientCertificates(t, filepath.Join(publicDir, common.CertificatesDir), prefix)
 			}
 			conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
 			require.NoError(t, err, "Setup: could not create a client connection")
@@ -202,10 +216,111 @@ func TestRegisterGRPCServices(t *testing.T) {
 	}
 }
 
-func loadClientCertificates(t *testing.T, certsDir string) credentials.TransportCredentials {
+func TestRegisterStatusGRPCServices(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		method         string
+		mainCerts      bool
+		insecureClient bool
+
+		wantCode codes.Code
+	}{
+		"Success calling a read-only method":           {method: "Ping"},
+		"Success calling a read-only streaming method": {method: "WatchSummary"},
+
+		"Error calling a method that changes the agent":   {method: "SetNotificationSettings", wantCode: codes.PermissionDenied},
+		"Error calling a streaming method out of the API": {method: "WatchConsent", wantCode: codes.PermissionDenied},
+		"Error with the certificates of the main API":     {method: "Ping", mainCerts: true, wantCode: codes.Unavailable},
+		"Error with insecure requests":                    {method: "Ping", insecureClient: true, wantCode: codes.Unavailable},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+
+			publicDir := t.TempDir()
+			statusDir := t.TempDir()
+
+			s, err := proservices.New(ctx, publicDir, t.TempDir(), proservices.WithRegistry(registry.NewMock()), proservices.WithStatusDir(statusDir))
+			require.NoError(t, err, "Setup: New should return no error")
+			defer s.Stop(ctx)
+			require.Equal(t, statusDir, s.StatusDir(), "StatusDir should return the directory of the status API")
+
+			server := s.RegisterStatusGRPCServices(context.Background())
+			info := server.GetServiceInfo()
+			require.Len(t, info, 1, "Only the UI service should be registered after calling RegisterStatusGRPCServices")
+			require.Contains(t, info, "agentapi.UI", "The UI service should be registered after calling RegisterStatusGRPCServices")
+
+			var cfg net.ListenConfig
+			lis, err := cfg.Listen(ctx, "tcp", "localhost:0")
+			require.NoError(t, err, "Setup: could not create a listener")
+			defer lis.Close()
+
+			serverDone := make(chan struct{})
+			go func() {
+				defer close(serverDone)
+				if err := server.Serve(lis); err != nil {
+					t.Logf("Serve exited with error: %v", err)
+				}
+			}()
+			t.Cleanup(func() {
+				server.Stop()
+				<-serverDone
+			})
+
+			creds := insecure.NewCredentials()
+			if !tc.insecureClient {
+				certsDir := filepath.Join(statusDir, common.CertificatesDir)
+				if tc.mainCerts {
+					certsDir = filepath.Join(publicDir, common.CertificatesDir)
+				}
+				creds = loadClientCertificates(t, certsDir, common.ClientsCertFilePrefix)
+			}
+			conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(creds))
+			require.NoError(t, err, "Setup: could not create a client connection")
+			defer conn.Close()
+			c := agentapi.NewUIClient(conn)
+
+			ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			switch tc.method {
+			case "Ping":
+				_, err = c.Ping(ctx, &agentapi.Empty{})
+			case "SetNotificationSettings":
+				_, err = c.SetNotificationSettings(ctx, &agentapi.NotificationSettings{})
+			case "WatchSummary":
+				var stream agentapi.UI_WatchSummaryClient
+				if stream, err = c.WatchSummary(ctx, &agentapi.Empty{}); err == nil {
+					_, err = stream.Recv()
+				}
+			case "WatchConsent":
+				var stream agentapi.UI_WatchConsentClient
+				if stream, err = c.WatchConsent(ctx, &agentapi.Empty{}); err == nil {
+					_, err = stream.Recv()
+				}
+			default:
+				require.Failf(t, "Setup: unknown method", "%s", tc.method)
+			}
+
+			if tc.wantCode == codes.OK {
+				require.NoError(t, err, "Clients of the status API should succeed in calling %s", tc.method)
+				return
+			}
+			require.Error(t, err, "Clients of the status API should fail to call %s", tc.method)
+			require.Equal(t, tc.wantCode, status.Code(err), "Unexpected error code: %v", err)
+		})
+	}
+}
+
+func loadClientCertificates(t *testing.T, certsDir, prefix string) credentials.TransportCredentials {
 	t.Helper()
 
-	cert, err := tls.LoadX509KeyPair(filepath.Join(certsDir, common.ClientsCertFilePrefix+common.CertificateSuffix), filepath.Join(certsDir, common.ClientsCertFilePrefix+common.KeySuffix))
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certsDir, prefix+common.CertificateSuffix), filepath.Join(certsDir, prefix+common.KeySuffix))
 	require.NoError(t, err, "failed to load client cert: %v", err)
 
 	ca := x509.NewCertPool()
@@ -0,0 +1,112 @@
+package registrywatcher
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/fsnotify/fsnotify"
+)
+
+// fileWatcher pushes the registry data again every time the file referenced by the registry changes,
+// so that edits to its contents are applied even though the registry itself did not change.
+type fileWatcher struct {
+	// path is the file being watched, empty if none.
+	path string
+	// stop stops watching the file, and done is closed once it is no longer watched.
+	stop func()
+	done chan struct{}
+
+	mu sync.Mutex
+}
+
+// watchLandscapeConfigFile starts watching the Landscape configuration file at path, replacing the file
+// previously watched. Relative paths cannot be told apart from the working directory of the agent, so
+// they are not watched.
+func (s *Service) watchLandscapeConfigFile(path string) {
+	fw := s.fileWatch
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if path == fw.path {
+		return
+	}
+
+	// Not waiting for the previous watch to be done, as it may be the one pushing the registry data.
+	fw.stop()
+	fw.path = path
+	fw.stop = func() {}
+
+	if path == "" {
+		return
+	}
+	if !filepath.IsAbs(path) {
+		log.Warningf(s.ctx, "Registry watcher: not watching Landscape configuration file %q: the path is not absolute", path)
+		return
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warningf(s.ctx, "Registry watcher: could not watch Landscape configuration file %q: %v", path, err)
+		return
+	}
+
+	// Watching the directory rather than the file, as editors often replace the file instead of writing it.
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		log.Warningf(s.ctx, "Registry watcher: could not watch Landscape configuration file %q: %v", path, err)
+		w.Close()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	done := make(chan struct{})
+	fw.stop = cancel
+	fw.done = done
+
+	go func() {
+		defer crashreport.Recover("registrywatcher")
+		defer close(done)
+		defer w.Close()
+
+		log.Debugf(ctx, "Registry watcher: watching Landscape configuration file %q", path)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) != filepath.Clean(path) {
+					continue
+				}
+
+				log.Infof(ctx, "Registry watcher: detected change in Landscape configuration file %q", path)
+				s.readThenPushRegistryData(ctx)
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Warningf(ctx, "Registry watcher: error watching Landscape configuration file %q: %v", path, err)
+			}
+		}
+	}()
+}
+
+// stopWatchingFile stops watching the file, if any, and waits until it is no longer watched.
+func (s *Service) stopWatchingFile() {
+	fw := s.fileWatch
+
+	fw.mu.Lock()
+	fw.stop()
+	done := fw.done
+	fw.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
@@ -28,6 +30,21 @@ type Service struct {
 	registry Registry
 	conf     Config
 	db       *database.DistroDB
+
+	// fileWatch follows the Landscape configuration file referenced by the registry, if any.
+	fileWatch *fileWatcher
+
+	// defaults fill the settings the registry leaves empty.
+	defaults *defaults
+
+	// debounce is how long the registry must stay unchanged before its changes are pushed.
+	debounce time.Duration
+}
+
+// defaults are the settings used when the registry leaves them empty.
+type defaults struct {
+	data config.RegistryData
+	mu   sync.Mutex
 }
 
 // registryPath is the path to the registry key we want to watch.
@@ -59,8 +76,16 @@ type Config interface {
 
 type options struct {
 	registry Registry
+	debounce time.Duration
 }
 
+// defaultDebounce is how long the registry must stay unchanged before its changes are pushed, so that a burst of
+// edits, such as a script or a group policy setting several values, is pushed at once.
+const defaultDebounce = 500 * time.Millisecond
+
+// maxDebounce is the longest the changes are held back when the registry keeps changing.
+const maxDebounce = 10 * time.Second
+
 // Option is an optional argument for the registry watcher.
 type Option = func(*options)
 
@@ -71,9 +96,16 @@ func WithRegistry(r Registry) Option {
 	}
 }
 
+// WithDebounce overrides how long the registry must stay unchanged before its changes are pushed.
+func WithDebounce(d time.Duration) Option {
+	return func(o *options) {
+		o.debounce = d
+	}
+}
+
 // New creates a registry watcher service.
 func New(ctx context.Context, conf Config, database *database.DistroDB, args ...Option) Service {
-	var opts options
+	opts := options{debounce: defaultDebounce}
 
 	for _, f := range args {
 		f(&opts)
@@ -91,9 +123,26 @@ func New(ctx context.Context, conf Config, database *database.DistroDB, args ...
 		ctx:     ctx,
 		stop:    func() {},
 		running: make(chan struct{}),
+
+		fileWatch: &fileWatcher{stop: func() {}},
+		defaults:  &defaults{},
+		debounce:  opts.debounce,
 	}
 }
 
+// SetDefaults sets the settings used when the registry leaves them empty, such as those of the configuration file
+// of the agent, and pushes the registry data again unless the watcher is stopped.
+func (s *Service) SetDefaults(data config.RegistryData) {
+	s.defaults.mu.Lock()
+	s.defaults.data = data
+	s.defaults.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		return
+	}
+	s.readThenPushRegistryData(s.ctx)
+}
+
 // Start starts watching the service. It does a first read of the registry
 // before returning.
 func (s *Service) Start() {
@@ -112,10 +161,12 @@ func (s *Service) Start() {
 func (s *Service) Stop() {
 	s.stop()
 	<-s.running
+	s.stopWatchingFile()
 }
 
 // run is the blocking registry watcher.
 func (s *Service) run() {
+	defer crashreport.Recover("registrywatcher")
 	defer close(s.running)
 	/*
 		When we detect a change we don't immediately read the registry and push
@@ -125,6 +176,10 @@ func (s *Service) run() {
 		In the case we fail to watch, we still push changes just in case. False
 		positives don't matter much because the config will ignore data that are
 		not new.
+
+		Once a change is detected, the data is only pushed after the registry
+		stays unchanged for the debounce period, or after maxDebounce if it keeps
+		changing, so that a burst of changes is pushed once.
 	*/
 
 	// These rates are NOT how often we look at the registry. Registry updates are
@@ -137,6 +192,9 @@ func (s *Service) run() {
 	)
 	retryRate := minRate
 
+	// changedAt is when the first change not pushed yet was detected. Zero if there is none.
+	var changedAt time.Time
+
 	log.Info(s.ctx, "Registry watcher: started watching")
 	defer log.Info(s.ctx, "Registry watcher: stopped watching")
 
@@ -174,20 +232,44 @@ func (s *Service) run() {
 			defer s.registry.CloseEvent(event)
 
 			log.Debugf(ctx, `Registry watcher: watching key HKCU\%s`, path)
+			changed := s.waitForSingleObject(event)
+
+			// Wait for the registry to settle before pushing the changes
+			if !changedAt.IsZero() && time.Since(changedAt) < maxDebounce {
+				select {
+				case <-ctx.Done():
+					return fmt.Errorf(`could not wait for changes to registry key HKCU\%s: %v`, path, ctx.Err())
+				case err := <-changed:
+					if err != nil {
+						return fmt.Errorf(`could not wait for changes to registry key HKCU\%s: %v`, path, err)
+					}
+					log.Debugf(ctx, `Registry watcher: registry key HKCU\%s is still changing`, path)
+					return nil
+				case <-time.After(s.debounce):
+				}
+			}
 
 			// Push update right after having started to watch
+			changedAt = time.Time{}
 			s.readThenPushRegistryData(ctx)
 
 			// Wait until the key is modified or the context is cancelled, whichever one happens first
-			if err := s.waitForSingleObject(ctx, event); err != nil {
-				return fmt.Errorf(`could not wait for changes to registry key HKCU\%s: %v`, path, err)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf(`could not wait for changes to registry key HKCU\%s: %v`, path, ctx.Err())
+			case err := <-changed:
+				if err != nil {
+					return fmt.Errorf(`could not wait for changes to registry key HKCU\%s: %v`, path, err)
+				}
 			}
 			log.Infof(ctx, `Registry watcher: detected change in registry key HKCU\%s or one of its children`, path)
+			changedAt = time.Now()
 
 			return nil
 		}()
 
 		if err != nil {
+			changedAt = time.Time{}
 			log.Warningf(s.ctx, "Registry watcher: %v", err)
 			s.readThenPushRegistryData(s.ctx)
 
@@ -205,12 +287,12 @@ func (s *Service) run() {
 	}
 }
 
-// waitForSingleObject is a utility wrapper around Win32's WaitForSingleObject. It allows
-// cancelling the wait with the use of a context.
+// waitForSingleObject is a utility wrapper around Win32's WaitForSingleObject. The returned
+// channel receives the outcome of the wait once the event is set, so that the wait can be
+// combined with a context or a timer.
 //
-// Cancelling the context skips the wait, but does not release resources. These are released
-// once the event is set.
-func (s *Service) waitForSingleObject(ctx context.Context, event registry.Event) error {
+// Giving up on the channel does not release resources. These are released once the event is set.
+func (s *Service) waitForSingleObject(event registry.Event) <-chan error {
 	ch := make(chan error, 1)
 
 	go func() {
@@ -218,12 +300,7 @@ func (s *Service) waitForSingleObject(ctx context.Context, event registry.Event)
 		close(ch)
 	}()
 
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-ch:
-		return err
-	}
+	return ch
 }
 
 // readThenPushRegistryData reads the registry and pushes the read data to the config.
@@ -236,6 +313,17 @@ func (s *Service) readThenPushRegistryData(ctx context.Context) {
 		return
 	}
 
+	s.defaults.mu.Lock()
+	data = data.WithDefaults(s.defaults.data)
+	s.defaults.mu.Unlock()
+
+	// The file is only read when there is no configuration in the registry itself.
+	var landscapeConfigFile string
+	if data.LandscapeConfig == "" {
+		landscapeConfigFile = data.LandscapeConfigFile
+	}
+	s.watchLandscapeConfigFile(landscapeConfigFile)
+
 	if err := s.conf.UpdateRegistryData(ctx, data, s.db); err != nil {
 		log.Warningf(ctx, "Registry watcher: could not push new registry data: %v", err)
 	}
@@ -245,8 +333,69 @@ func (s *Service) readThenPushRegistryData(ctx context.Context) {
 const (
 	ubuntuProTokenField  = "UbuntuProToken"
 	landscapeConfigField = "LandscapeConfig"
+
+	// Fields to select where wsl-pro-service is provisioned from. They are optional, so they are not
+	// created by default.
+	updateChannelField  = "WslProServiceChannel"
+	updateSourceField   = "WslProServiceSource"
+	updateChecksumField = "WslProServiceChecksum"
+
+	// Oldest version of wsl-pro-service the agent manages. It is optional, so it is not created by default.
+	minimumVersionField = "WslProServiceMinimumVersion"
+
+	// Maintenance windows during which wsl-pro-service may be upgraded, e.g. "Sat,Sun 02:00-06:00". It
+	// is optional, so it is not created by default.
+	maintenanceWindowsField = "MaintenanceWindows"
+
+	// Path to an offline Ubuntu Pro token file, for air-gapped machines. It is optional, so it is not
+	// created by default.
+	ubuntuProTokenFileField = "UbuntuProTokenFile"
+
+	// Path to a Landscape client configuration file, for configurations too long or complex to be deployed
+	// as a registry value. It is optional, so it is not created by default.
+	landscapeConfigFileField = "LandscapeConfigFile"
+
+	// Which pockets unattended-upgrades installs updates from in the distros: security-only, security+updates
+	// or all. It is optional, so it is not created by default.
+	patchingLevelField = "PatchingLevel"
+
+	// When the distros are attached to Ubuntu Pro: immediately, or on first use to hold back the attachment of
+	// the distros that are never launched. It is optional, so it is not created by default.
+	attachPolicyField = "AttachPolicy"
+
+	// Proxies the distros reach the network through, e.g. http://proxy.example.com:3128, and comma-separated
+	// list of hosts and domains they reach directly. They are optional, so they are not created by default.
+	httpProxyField  = "HTTPProxy"
+	httpsProxyField = "HTTPSProxy"
+	noProxyField    = "NoProxy"
+
+	// URL of the enterprise snap store proxy of the distros, e.g. http://snaps.example.com, and ID of its store.
+	// They are optional, so they are not created by default.
+	snapStoreProxyField = "SnapStoreProxy"
+	snapStoreIDField    = "SnapStoreID"
+
+	// Comma-separated lists of the nameservers and search domains of the distros, which replace the ones WSL
+	// generates in resolv.conf, and lines to add to their /etc/hosts. They are optional, so they are not created
+	// by default.
+	nameserversField   = "Nameservers"
+	searchDomainsField = "SearchDomains"
+	hostEntriesField   = "HostEntries"
+
+	// YAML list of distro groups, each with its own Ubuntu Pro token, Landscape configuration and patching
+	// level. It is optional, so it is not created by default.
+	distroGroupsField = "DistroGroups"
+
+	// How much data the agent may transfer to and from each distro per day, e.g. "500MB". It is optional, so it
+	// is not created by default.
+	dailyTransferCapField = "DailyTransferCap"
 )
 
+// ReadRegistry returns the data of the Ubuntu Pro registry key as it is right now, without the defaults that fill
+// the settings it leaves empty. A missing key has no data.
+func ReadRegistry(reg Registry) (config.RegistryData, error) {
+	return loadRegistry(reg)
+}
+
 func loadRegistry(reg Registry) (data config.RegistryData, err error) {
 	defer decorate.OnError(&err, "could not read registry")
 
@@ -270,9 +419,109 @@ func loadRegistry(reg Registry) (data config.RegistryData, err error) {
 		return data, err
 	}
 
+	tokenFile, err := readFromRegistry(reg, k, ubuntuProTokenFileField)
+	if err != nil {
+		return data, err
+	}
+
+	confFile, err := readFromRegistry(reg, k, landscapeConfigFileField)
+	if err != nil {
+		return data, err
+	}
+
+	patching, err := readFromRegistry(reg, k, patchingLevelField)
+	if err != nil {
+		return data, err
+	}
+
+	attach, err := readFromRegistry(reg, k, attachPolicyField)
+	if err != nil {
+		return data, err
+	}
+
+	groups, err := readFromRegistry(reg, k, distroGroupsField)
+	if err != nil {
+		return data, err
+	}
+
+	minVersion, err := readFromRegistry(reg, k, minimumVersionField)
+	if err != nil {
+		return data, err
+	}
+
+	windows, err := readFromRegistry(reg, k, maintenanceWindowsField)
+	if err != nil {
+		return data, err
+	}
+
+	transferCap, err := readFromRegistry(reg, k, dailyTransferCapField)
+	if err != nil {
+		return data, err
+	}
+
+	var proxy config.ProxySettings
+	for field, dest := range map[string]*string{
+		httpProxyField:  &proxy.HTTP,
+		httpsProxyField: &proxy.HTTPS,
+		noProxyField:    &proxy.NoProxy,
+	} {
+		if *dest, err = readFromRegistry(reg, k, field); err != nil {
+			return data, err
+		}
+	}
+
+	var snap config.SnapSettings
+	for field, dest := range map[string]*string{
+		snapStoreProxyField: &snap.StoreProxy,
+		snapStoreIDField:    &snap.StoreID,
+	} {
+		if *dest, err = readFromRegistry(reg, k, field); err != nil {
+			return data, err
+		}
+	}
+
+	var nameservers, searchDomains, hostEntries string
+	for field, dest := range map[string]*string{
+		nameserversField:   &nameservers,
+		searchDomainsField: &searchDomains,
+		hostEntriesField:   &hostEntries,
+	} {
+		if *dest, err = readFromRegistry(reg, k, field); err != nil {
+			return data, err
+		}
+	}
+
+	var channel config.UpdateChannel
+	for field, dest := range map[string]*string{
+		updateChannelField:  &channel.Channel,
+		updateSourceField:   &channel.Source,
+		updateChecksumField: &channel.Checksum,
+	} {
+		if *dest, err = readFromRegistry(reg, k, field); err != nil {
+			return data, err
+		}
+	}
+
 	return config.RegistryData{
-		UbuntuProToken:  proToken,
-		LandscapeConfig: conf,
+		UbuntuProToken:        proToken,
+		UbuntuProTokenFile:    tokenFile,
+		LandscapeConfig:       conf,
+		LandscapeConfigFile:   confFile,
+		UpdateChannel:         channel,
+		MinimumServiceVersion: minVersion,
+		MaintenanceWindows:    windows,
+		PatchingLevel:         patching,
+		AttachPolicy:          attach,
+		HTTPProxy:             proxy.HTTP,
+		HTTPSProxy:            proxy.HTTPS,
+		NoProxy:               proxy.NoProxy,
+		SnapStoreProxy:        snap.StoreProxy,
+		SnapStoreID:           snap.StoreID,
+		Nameservers:           nameservers,
+		SearchDomains:         searchDomains,
+		HostEntries:           hostEntries,
+		DistroGroups:          groups,
+		DailyTransferCap:      transferCap,
 	}, nil
 }
 
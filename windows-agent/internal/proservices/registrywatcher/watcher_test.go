@@ -3,6 +3,9 @@ package registrywatcher_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -25,6 +28,8 @@ func TestRegistryWatcher(t *testing.T) {
 
 		newProToken        = "NewProToken"
 		newLandscapeConfig = "NewLandscapeConfig"
+
+		distroGroups = "- name: team-a\n  pattern: Ubuntu-TeamA-*\n  ubuntu_pro_token: TeamAProToken\n"
 	)
 
 	const maxUpdateTime = 5 * time.Second
@@ -165,6 +170,291 @@ func TestRegistryWatcher(t *testing.T) {
 				maxUpdateTime, 100*time.Millisecond, "Registry watcher should have updated the config after changing the registry")
 			require.Equal(t, newProToken, conf.LatestReceived().UbuntuProToken, "Ubuntu Pro token config should have contained the new registry value")
 			require.Equal(t, newLandscapeConfig, conf.LatestReceived().LandscapeConfig, "Landscape config should have contained the new registry value")
+
+			err = reg.WriteValue(k, "WslProServiceChannel", "beta", false)
+			require.NoError(t, err, "Setup: could not write WslProServiceChannel into the registry")
+			err = reg.WriteValue(k, "WslProServiceSource", "ppa:owner/name", false)
+			require.NoError(t, err, "Setup: could not write WslProServiceSource into the registry")
+			err = reg.WriteValue(k, "WslProServiceMinimumVersion", "1.2.3", false)
+			require.NoError(t, err, "Setup: could not write WslProServiceMinimumVersion into the registry")
+			err = reg.WriteValue(k, "MaintenanceWindows", "Sat,Sun 02:00-06:00", false)
+			require.NoError(t, err, "Setup: could not write MaintenanceWindows into the registry")
+			err = reg.WriteValue(k, "UbuntuProTokenFile", `C:\ubuntu-pro\token.yaml`, false)
+			require.NoError(t, err, "Setup: could not write UbuntuProTokenFile into the registry")
+			err = reg.WriteValue(k, "LandscapeConfigFile", `C:\ubuntu-pro\client.conf`, false)
+			require.NoError(t, err, "Setup: could not write LandscapeConfigFile into the registry")
+			err = reg.WriteValue(k, "PatchingLevel", "security-only", false)
+			require.NoError(t, err, "Setup: could not write PatchingLevel into the registry")
+			err = reg.WriteValue(k, "AttachPolicy", "first-use", false)
+			require.NoError(t, err, "Setup: could not write AttachPolicy into the registry")
+			err = reg.WriteValue(k, "HTTPProxy", "http://proxy.example.com:3128", false)
+			require.NoError(t, err, "Setup: could not write HTTPProxy into the registry")
+			err = reg.WriteValue(k, "NoProxy", "localhost", false)
+			require.NoError(t, err, "Setup: could not write NoProxy into the registry")
+			err = reg.WriteValue(k, "SnapStoreProxy", "http://snaps.example.com", false)
+			require.NoError(t, err, "Setup: could not write SnapStoreProxy into the registry")
+			err = reg.WriteValue(k, "SnapStoreID", "mock-store-id", false)
+			require.NoError(t, err, "Setup: could not write SnapStoreID into the registry")
+			err = reg.WriteValue(k, "Nameservers", "10.0.0.53", false)
+			require.NoError(t, err, "Setup: could not write Nameservers into the registry")
+			err = reg.WriteValue(k, "SearchDomains", "corp.example.com", false)
+			require.NoError(t, err, "Setup: could not write SearchDomains into the registry")
+			err = reg.WriteValue(k, "HostEntries", "10.0.0.1 intranet", true)
+			require.NoError(t, err, "Setup: could not write HostEntries into the registry")
+			err = reg.WriteValue(k, "DistroGroups", distroGroups, true)
+			require.NoError(t, err, "Setup: could not write DistroGroups into the registry")
+			err = reg.WriteValue(k, "DailyTransferCap", "500MB", false)
+			require.NoError(t, err, "Setup: could not write DailyTransferCap into the registry")
+
+			require.Eventually(t, func() bool {
+				data := conf.LatestReceived()
+				return data.UpdateChannel.Source != "" && data.MinimumServiceVersion != "" && data.MaintenanceWindows != "" && data.UbuntuProTokenFile != "" && data.LandscapeConfigFile != "" && data.PatchingLevel != "" && data.AttachPolicy != "" && data.HTTPProxy != "" && data.NoProxy != "" && data.SnapStoreProxy != "" && data.SnapStoreID != "" && data.Nameservers != "" && data.SearchDomains != "" && data.HostEntries != "" && data.DistroGroups != "" && data.DailyTransferCap != ""
+			},
+				maxUpdateTime, 100*time.Millisecond, "Registry watcher should have updated the config after changing the registry")
+			require.Equal(t, config.UpdateChannel{Channel: "beta", Source: "ppa:owner/name"}, conf.LatestReceived().UpdateChannel, "Update channel should have contained the new registry values")
+			require.Equal(t, "1.2.3", conf.LatestReceived().MinimumServiceVersion, "Minimum service version should have contained the new registry value")
+			require.Equal(t, "Sat,Sun 02:00-06:00", conf.LatestReceived().MaintenanceWindows, "Maintenance windows should have contained the new registry value")
+			require.Equal(t, `C:\ubuntu-pro\token.yaml`, conf.LatestReceived().UbuntuProTokenFile, "Ubuntu Pro token file should have contained the new registry value")
+			require.Equal(t, `C:\ubuntu-pro\client.conf`, conf.LatestReceived().LandscapeConfigFile, "Landscape config file should have contained the new registry value")
+			require.Equal(t, "security-only", conf.LatestReceived().PatchingLevel, "Patching level should have contained the new registry value")
+			require.Equal(t, "first-use", conf.LatestReceived().AttachPolicy, "Attach policy should have contained the new registry value")
+			require.Equal(t, "http://proxy.example.com:3128", conf.LatestReceived().HTTPProxy, "HTTP proxy should have contained the new registry value")
+			require.Equal(t, "localhost", conf.LatestReceived().NoProxy, "Proxy exceptions should have contained the new registry value")
+			require.Equal(t, "http://snaps.example.com", conf.LatestReceived().SnapStoreProxy, "Snap store proxy should have contained the new registry value")
+			require.Equal(t, "mock-store-id", conf.LatestReceived().SnapStoreID, "Snap store ID should have contained the new registry value")
+			require.Equal(t, "10.0.0.53", conf.LatestReceived().Nameservers, "Nameservers should have contained the new registry value")
+			require.Equal(t, "corp.example.com", conf.LatestReceived().SearchDomains, "Search domains should have contained the new registry value")
+			require.Equal(t, "10.0.0.1 intranet", conf.LatestReceived().HostEntries, "Host entries should have contained the new registry value")
+			require.Equal(t, distroGroups, conf.LatestReceived().DistroGroups, "Distro groups should have contained the new registry value")
+			require.Equal(t, "500MB", conf.LatestReceived().DailyTransferCap, "Daily transfer cap should have contained the new registry value")
+			require.Equal(t, newProToken, conf.LatestReceived().UbuntuProToken, "Ubuntu Pro token config should not have changed")
+		})
+	}
+}
+
+func TestLandscapeConfigFileWatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		inlineConfig  bool
+		replaceFile   bool
+		relativePath  bool
+		noFileInitial bool
+
+		wantPush bool
+	}{
+		"Success pushing the data when the file is written":  {wantPush: true},
+		"Success pushing the data when the file is replaced": {replaceFile: true, wantPush: true},
+		"Success pushing the data when the file is created":  {noFileInitial: true, wantPush: true},
+
+		"No push when the registry has its own config": {inlineConfig: true},
+		"No push when the path is not absolute":        {relativePath: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			conf := &mockConfig{}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: could not create empty DB")
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "client.conf")
+			if !tc.noFileInitial {
+				require.NoError(t, os.WriteFile(path, []byte("[host]\nurl = old.example.com\n"), 0600), "Setup: could not write Landscape config file")
+			}
+
+			reg := registry.NewMock()
+			defer reg.RequireNoLeaks(t)
+
+			func() {
+				k, err := reg.HKCUCreateKey("Software/Canonical/UbuntuPro")
+				require.NoError(t, err, "Setup: could not create key")
+				defer reg.CloseKey(k)
+
+				regPath := path
+				if tc.relativePath {
+					regPath = "client.conf"
+				}
+				err = reg.WriteValue(k, "LandscapeConfigFile", regPath, false)
+				require.NoError(t, err, "Setup: could not write LandscapeConfigFile into the registry")
+
+				if tc.inlineConfig {
+					err = reg.WriteValue(k, "LandscapeConfig", "[host]\nurl = inline.example.com\n", true)
+					require.NoError(t, err, "Setup: could not write LandscapeConfig into the registry")
+				}
+			}()
+
+			w := registrywatcher.New(ctx, conf, db, registrywatcher.WithRegistry(reg))
+			w.Start()
+			defer w.Stop()
+
+			// Letting the watcher settle after its initial pushes.
+			require.Eventually(t, func() bool { return conf.ReceivedLen() >= 2 }, time.Minute, 100*time.Millisecond, "Setup: registry watcher should have started watching")
+			time.Sleep(500 * time.Millisecond)
+			pushes := conf.ReceivedLen()
+
+			newContents := []byte("[host]\nurl = new.example.com\n")
+			if tc.replaceFile {
+				tmp := filepath.Join(dir, "client.conf.tmp")
+				require.NoError(t, os.WriteFile(tmp, newContents, 0600), "Setup: could not write new Landscape config file")
+				require.NoError(t, os.Rename(tmp, path), "Setup: could not replace Landscape config file")
+			} else {
+				require.NoError(t, os.WriteFile(path, newContents, 0600), "Setup: could not write Landscape config file")
+			}
+
+			pushed := func() bool { return conf.ReceivedLen() > pushes }
+			if !tc.wantPush {
+				require.Never(t, pushed, 2*time.Second, 100*time.Millisecond, "Registry watcher should not have pushed the data after the file changed")
+				return
+			}
+			require.Eventually(t, pushed, 5*time.Second, 100*time.Millisecond, "Registry watcher should have pushed the data after the file changed")
+			require.Equal(t, path, conf.LatestReceived().LandscapeConfigFile, "Landscape config file should have contained the registry value")
+
+			// Once stopped, changes to the file are ignored.
+			w.Stop()
+			pushes = conf.ReceivedLen()
+			require.NoError(t, os.WriteFile(path, []byte("[host]\nurl = newer.example.com\n"), 0600), "Setup: could not write Landscape config file")
+			require.Never(t, pushed, time.Second, 100*time.Millisecond, "Registry watcher should not push data after being stopped")
+		})
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		registryProxy string
+		stopped       bool
+
+		wantProxy string
+		wantToken string
+		wantPush  bool
+	}{
+		"Success pushing the defaults":                       {wantProxy: "http://file.example.com:3128", wantToken: "RegistryProToken", wantPush: true},
+		"Success keeping the settings of the registry first": {registryProxy: "http://registry.example.com:3128", wantProxy: "http://registry.example.com:3128", wantToken: "RegistryProToken", wantPush: true},
+
+		"No push when the watcher is stopped": {stopped: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			conf := &mockConfig{}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: could not create empty DB")
+
+			reg := registry.NewMock()
+			defer reg.RequireNoLeaks(t)
+
+			func() {
+				k, err := reg.HKCUCreateKey("Software/Canonical/UbuntuPro")
+				require.NoError(t, err, "Setup: could not create key")
+				defer reg.CloseKey(k)
+
+				err = reg.WriteValue(k, "UbuntuProToken", "RegistryProToken", false)
+				require.NoError(t, err, "Setup: could not write UbuntuProToken into the registry")
+
+				if tc.registryProxy != "" {
+					err = reg.WriteValue(k, "HTTPProxy", tc.registryProxy, false)
+					require.NoError(t, err, "Setup: could not write HTTPProxy into the registry")
+				}
+			}()
+
+			w := registrywatcher.New(ctx, conf, db, registrywatcher.WithRegistry(reg))
+			w.Start()
+			defer w.Stop()
+
+			require.Eventually(t, func() bool { return conf.ReceivedLen() >= 1 }, time.Minute, 100*time.Millisecond, "Setup: registry watcher should have pushed the registry data")
+			if tc.stopped {
+				w.Stop()
+			}
+			pushes := conf.ReceivedLen()
+
+			w.SetDefaults(config.RegistryData{UbuntuProToken: "FileProToken", HTTPProxy: "http://file.example.com:3128"})
+
+			if !tc.wantPush {
+				require.Equal(t, pushes, conf.ReceivedLen(), "SetDefaults should not push the data once the watcher is stopped")
+				return
+			}
+			require.Greater(t, conf.ReceivedLen(), pushes, "SetDefaults should have pushed the data again")
+
+			got := conf.LatestReceived()
+			require.Equal(t, tc.wantToken, got.UbuntuProToken, "The Ubuntu Pro token of the registry should take precedence")
+			require.Equal(t, tc.wantProxy, got.HTTPProxy, "The proxy should be that of the registry, or the default one")
+		})
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	t.Parallel()
+
+	const debounce = time.Second
+
+	testCases := map[string]struct {
+		writes   int
+		interval time.Duration
+
+		wantPushes int
+	}{
+		"Success pushing a single change once":               {writes: 1, wantPushes: 1},
+		"Success pushing a burst of changes once":            {writes: 10, interval: 10 * time.Millisecond, wantPushes: 1},
+		"Success pushing changes far apart from one another": {writes: 2, interval: 3 * debounce, wantPushes: 2},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			conf := &mockConfig{}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: could not create empty DB")
+
+			reg := registry.NewMock()
+			defer reg.RequireNoLeaks(t)
+
+			w := registrywatcher.New(ctx, conf, db, registrywatcher.WithRegistry(reg), registrywatcher.WithDebounce(debounce))
+			w.Start()
+			defer w.Stop()
+
+			// The watcher pushes the data when it starts, and again when it starts watching.
+			require.Eventually(t, func() bool { return conf.ReceivedLen() >= 2 }, 5*time.Second, 10*time.Millisecond, "Setup: registry watcher should have started watching")
+			pushes := conf.ReceivedLen()
+
+			k, err := reg.HKCUCreateKey("Software/Canonical/UbuntuPro")
+			require.NoError(t, err, "Setup: could not create key")
+			defer reg.CloseKey(k)
+
+			var want string
+			for i := range tc.writes {
+				want = fmt.Sprintf("ProToken%d", i)
+				err = reg.WriteValue(k, "UbuntuProToken", want, false)
+				require.NoError(t, err, "Setup: could not write UbuntuProToken into the registry")
+				time.Sleep(tc.interval)
+			}
+
+			require.Eventually(t, func() bool { return conf.LatestReceived().UbuntuProToken == want }, 5*time.Second, 10*time.Millisecond, "Registry watcher should have pushed the last change")
+
+			// Leave time for any extra push.
+			time.Sleep(2 * debounce)
+			require.Equal(t, pushes+tc.wantPushes, conf.ReceivedLen(), "Registry watcher should push once per burst of changes")
 		})
 	}
 }
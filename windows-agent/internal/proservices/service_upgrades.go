@@ -0,0 +1,177 @@
+package proservices
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/maintenance"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/notifications"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+)
+
+// upgradeScheduler holds back the upgrades of wsl-pro-service from the update channel until the
+// schedule is open: during the maintenance windows or, if there are none, outside of the active hours
+// of the user.
+type upgradeScheduler struct {
+	ctx      context.Context
+	conf     *config.Config
+	db       *database.DistroDB
+	notifier *notifications.Digest
+
+	// cancel stops waiting for the pending upgrade, if any.
+	cancel context.CancelFunc
+	// distroCancels stop waiting for the pending upgrades of single distros, keyed by distro name.
+	distroCancels map[string]context.CancelFunc
+	mu            sync.Mutex
+}
+
+func newUpgradeScheduler(ctx context.Context, conf *config.Config, db *database.DistroDB, notifier *notifications.Digest) *upgradeScheduler {
+	return &upgradeScheduler{
+		ctx:      ctx,
+		conf:     conf,
+		db:       db,
+		notifier: notifier,
+
+		distroCancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// schedule distributes the upgrade from the channel once the schedule is open. It replaces any pending
+// upgrade, as only the latest channel matters.
+func (u *upgradeScheduler) schedule(channel config.UpdateChannel) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.cancel != nil {
+		u.cancel()
+		u.cancel = nil
+	}
+
+	if channel.Channel == "" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(u.ctx)
+	u.cancel = cancel
+
+	go func() {
+		defer crashreport.Recover("proservices")
+		defer cancel()
+
+		if err := maintenance.Wait(ctx, u.maintenanceSchedule); err != nil {
+			log.Debugf(ctx, "Service upgrade from channel %q was not distributed: %v", channel.Channel, err)
+			return
+		}
+
+		distributeServiceUpgrade(ctx, u.db, u.notifier, channel)
+		if err := u.conf.SetServiceUpgradeDistributed(channel); err != nil {
+			log.Warningf(ctx, "Service upgrades: %v", err)
+		}
+	}()
+}
+
+// resume schedules the upgrade that was still pending when the agent last stopped, if any. It does
+// nothing if an upgrade was scheduled since starting, as that one is more recent.
+func (u *upgradeScheduler) resume() {
+	u.mu.Lock()
+	scheduled := u.cancel != nil
+	u.mu.Unlock()
+
+	if scheduled {
+		return
+	}
+
+	channel, err := u.conf.PendingServiceUpgrade()
+	if err != nil {
+		log.Warningf(u.ctx, "Service upgrades: %v", err)
+		return
+	}
+
+	if channel.Channel == "" {
+		return
+	}
+
+	log.Infof(u.ctx, "Service upgrades: resuming the pending upgrade from channel %q", channel.Channel)
+	u.schedule(channel)
+}
+
+// UpgradeDistro upgrades the WSL Pro service of a single distro from the channel once the schedule is open.
+// It replaces any upgrade pending for that same distro.
+func (u *upgradeScheduler) UpgradeDistro(d *distro.Distro, channel config.UpdateChannel) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	name := d.Name()
+	if cancel, ok := u.distroCancels[name]; ok {
+		cancel()
+		delete(u.distroCancels, name)
+	}
+
+	ctx, cancel := context.WithCancel(u.ctx)
+	u.distroCancels[name] = cancel
+
+	go func() {
+		defer crashreport.Recover("proservices")
+		defer func() {
+			u.mu.Lock()
+			defer u.mu.Unlock()
+
+			// A newer upgrade replacing this one cancels it first: only clean up after ourselves.
+			if ctx.Err() == nil {
+				delete(u.distroCancels, name)
+			}
+			cancel()
+		}()
+
+		if err := maintenance.Wait(ctx, u.maintenanceSchedule); err != nil {
+			log.Debugf(ctx, "Distro %q: service upgrade from channel %q was not submitted: %v", name, channel.Channel, err)
+			return
+		}
+
+		if err := d.SubmitTasks(tasks.ServiceUpgrade{
+			Channel:  channel.Channel,
+			Source:   channel.Source,
+			Checksum: channel.Checksum,
+		}); err != nil {
+			log.Warningf(ctx, "Distro %q: could not submit service upgrade: %v", name, err)
+			return
+		}
+
+		u.notifier.Notify(ctx, notifications.Event{
+			Priority: notifications.Low,
+			Category: notifications.CategoryUpgradePending,
+			Message:  fmt.Sprintf("Ubuntu Pro for WSL will be upgraded in %s", name),
+		})
+	}()
+}
+
+// maintenanceSchedule returns the current schedule, so that changes to the maintenance windows and
+// the active hours are taken into account while waiting.
+func (u *upgradeScheduler) maintenanceSchedule() maintenance.Schedule {
+	return maintenanceSchedule(u.ctx, u.conf)
+}
+
+// maintenanceSchedule returns the schedule configured by the organization, or the one derived from the
+// active hours of the user if there are no maintenance windows.
+func maintenanceSchedule(ctx context.Context, conf *config.Config) maintenance.Schedule {
+	windows, err := conf.MaintenanceWindows()
+	if err != nil {
+		log.Warningf(ctx, "Maintenance: %v", err)
+	}
+
+	activeHours, err := maintenance.SystemActiveHours()
+	if err != nil {
+		log.Warningf(ctx, "Maintenance: could not detect the active hours: %v", err)
+	}
+
+	return maintenance.Schedule{
+		Windows:     windows,
+		ActiveHours: activeHours,
+	}
+}
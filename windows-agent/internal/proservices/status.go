@@ -0,0 +1,75 @@
+package proservices
+
+import (
+	"context"
+	"slices"
+
+	agent_api "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/grpc/interceptorschain"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logconnections"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusMethods are the methods of the UI service the status API serves. They only read the state of the agent,
+// so that status widgets and third-party tools can be granted access to them without getting control of the agent.
+// Any other method, including those added in the future, is denied.
+var statusMethods = []string{
+	agent_api.UI_Ping_FullMethodName,
+	agent_api.UI_GetConfigSources_FullMethodName,
+	agent_api.UI_GetComplianceReport_FullMethodName,
+	agent_api.UI_GetNotificationSettings_FullMethodName,
+	agent_api.UI_GetLatencies_FullMethodName,
+	agent_api.UI_GetBandwidth_FullMethodName,
+	agent_api.UI_GetSubscriptionDetails_FullMethodName,
+	agent_api.UI_WatchTasks_FullMethodName,
+	agent_api.UI_GetEvents_FullMethodName,
+	agent_api.UI_GetSummary_FullMethodName,
+	agent_api.UI_WatchSummary_FullMethodName,
+	agent_api.UI_GetInventory_FullMethodName,
+	agent_api.UI_GetWslConfig_FullMethodName,
+}
+
+// RegisterStatusGRPCServices returns a new grpc Server serving the read-only status API: the UI service restricted
+// to the methods that do not change the state of the agent, with credentials of its own.
+func (m Manager) RegisterStatusGRPCServices(ctx context.Context) *grpc.Server {
+	log.Debug(ctx, "Registering status GRPC services")
+
+	// Unlike the GUI, third-party clients do not stream the logs of the agent, so the logstreamer interceptor,
+	// which requires its client counterpart, is left out.
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(statusUnaryInterceptor),
+		grpc.StreamInterceptor(interceptorschain.StreamServer(
+			statusStreamInterceptor,
+			logconnections.StreamServerInterceptor(),
+		)), grpc.Creds(m.statusCreds))
+	agent_api.RegisterUIServer(grpcServer, &m.uiService)
+
+	return grpcServer
+}
+
+// statusUnaryInterceptor denies the unary calls that are not part of the status API.
+func statusUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := allowedInStatusAPI(info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// statusStreamInterceptor denies the streaming calls that are not part of the status API.
+func statusStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := allowedInStatusAPI(info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// allowedInStatusAPI returns a PermissionDenied error if method is not part of the status API.
+func allowedInStatusAPI(method string) error {
+	if slices.Contains(statusMethods, method) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "%s is not part of the status API", method)
+}
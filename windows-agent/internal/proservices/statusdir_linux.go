@@ -0,0 +1,11 @@
+package proservices
+
+import "os"
+
+// restrictAccess creates dir if needed, and makes it only accessible by the current user.
+func restrictAccess(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return os.Chmod(dir, 0700)
+}
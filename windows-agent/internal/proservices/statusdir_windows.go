@@ -0,0 +1,41 @@
+package proservices
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// restrictAccess creates dir if needed, and replaces its access control list with one granting full access to the
+// system and the current user only. It is protected from inheritance, so that the access granted to the user
+// profile does not leak into it: administrators can grant read access to the tools consuming the status API without
+// granting access to the rest of the profile.
+func restrictAccess(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return fmt.Errorf("could not get the current user: %v", err)
+	}
+
+	// Inherited by files and directories.
+	sd, err := windows.SecurityDescriptorFromString(fmt.Sprintf("D:P(A;OICI;FA;;;SY)(A;OICI;FA;;;%s)", user.User.Sid))
+	if err != nil {
+		return fmt.Errorf("could not create the security descriptor of %q: %v", dir, err)
+	}
+
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return fmt.Errorf("could not read the access control list of %q: %v", dir, err)
+	}
+
+	info := windows.SECURITY_INFORMATION(windows.DACL_SECURITY_INFORMATION | windows.PROTECTED_DACL_SECURITY_INFORMATION)
+	if err := windows.SetNamedSecurityInfo(dir, windows.SE_FILE_OBJECT, info, nil, nil, dacl, nil); err != nil {
+		return fmt.Errorf("could not set the access control list of %q: %v", dir, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
+)
+
+// GetBandwidth handles the gRPC call to report the data transferred to and from every distro.
+func (s *Service) GetBandwidth(ctx context.Context, _ *agentapi.Empty) (*agentapi.Bandwidth, error) {
+	log.Info(ctx, "UI service: received GetBandwidth message")
+
+	distros := s.db.GetAll()
+	sort.Slice(distros, func(i, j int) bool {
+		return strings.ToLower(distros[i].Name()) < strings.ToLower(distros[j].Name())
+	})
+
+	out := &agentapi.Bandwidth{Distros: make([]*agentapi.DistroBandwidth, 0, len(distros))}
+	for _, d := range distros {
+		out.Distros = append(out.Distros, bandwidthToAPI(d.Name(), d.Bandwidth()))
+	}
+
+	return out, nil
+}
+
+// bandwidthToAPI converts the data transferred by a distro into its gRPC counterpart, with one entry per kind.
+func bandwidthToAPI(name string, u bandwidth.Usage) *agentapi.DistroBandwidth {
+	out := &agentapi.DistroBandwidth{
+		Distro:        name,
+		Transfers:     make([]*agentapi.TransferUsage, 0, len(bandwidth.Kinds)),
+		TodayBytes:    u.TodayTotal(),
+		DailyCapBytes: u.DailyCap,
+	}
+
+	for _, k := range bandwidth.Kinds {
+		out.Transfers = append(out.Transfers, &agentapi.TransferUsage{
+			Kind:       string(k),
+			TodayBytes: u.Today[k],
+			TotalBytes: u.Total[k],
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"context"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/compliance"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+)
+
+// GetComplianceReport handles the gRPC call to aggregate the security status of all distros.
+func (s *Service) GetComplianceReport(ctx context.Context, _ *agentapi.Empty) (*agentapi.ComplianceReport, error) {
+	log.Info(ctx, "UI service: received GetComplianceReport message")
+
+	props := make(map[string]distro.Properties)
+	for _, d := range s.db.GetAll() {
+		props[d.Name()] = d.Properties()
+	}
+
+	return complianceReportToAPI(compliance.NewReport(props)), nil
+}
+
+// complianceReportToAPI converts a compliance report into its gRPC counterpart.
+func complianceReportToAPI(r compliance.Report) *agentapi.ComplianceReport {
+	out := &agentapi.ComplianceReport{
+		Total:           int32(r.Total),
+		FullyPatched:    int32(r.FullyPatched),
+		PendingSecurity: int32(r.PendingSecurity),
+		PendingEsm:      int32(r.PendingESM),
+		Unknown:         int32(r.Unknown),
+		Distros:         make([]*agentapi.DistroCompliance, 0, len(r.Distros)),
+	}
+
+	for _, d := range r.Distros {
+		dc := &agentapi.DistroCompliance{Distro: d.Name}
+		if d.Security.Known {
+			dc.Status = &agentapi.SecurityStatus{
+				StandardUpdates: int32(d.Security.StandardUpdates),
+				EsmUpdates:      int32(d.Security.ESMUpdates),
+			}
+		}
+		out.Distros = append(out.Distros, dc)
+	}
+
+	return out
+}
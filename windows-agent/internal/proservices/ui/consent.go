@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consent"
+	"github.com/ubuntu/decorate"
+)
+
+// Consent is the broker of the requests of tasks, and of the changes to the host, for user confirmation.
+type Consent interface {
+	Ask(ctx context.Context, distro, prompt string) (bool, error)
+	Watch(ctx context.Context) <-chan consent.Request
+	Answer(id string, granted bool) error
+}
+
+// WatchConsent handles the gRPC call to stream the pending requests for consent, and the new ones as
+// tasks make them, until the client disconnects.
+func (s *Service) WatchConsent(_ *agentapi.Empty, stream agentapi.UI_WatchConsentServer) (err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: WatchConsent")
+
+	ctx := stream.Context()
+	log.Info(ctx, "UI service: received request to watch requests for consent")
+
+	if s.consent == nil {
+		return errors.New("no consent broker available")
+	}
+
+	for req := range s.consent.Watch(ctx) {
+		if err := stream.Send(&agentapi.ConsentRequest{
+			Id:       req.ID,
+			Distro:   req.Distro,
+			Prompt:   req.Prompt,
+			Deadline: req.Deadline.Format(time.RFC3339),
+		}); err != nil {
+			return fmt.Errorf("could not send request for consent: %v", err)
+		}
+	}
+
+	log.Debug(ctx, "UI service: stopped watching requests for consent")
+	return nil
+}
+
+// AnswerConsent handles the gRPC call to grant or deny a pending request for consent.
+func (s *Service) AnswerConsent(ctx context.Context, answer *agentapi.ConsentAnswer) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: AnswerConsent")
+
+	log.Infof(ctx, "UI service: received answer to request for consent %q (granted: %t)", answer.GetId(), answer.GetGranted())
+
+	if s.consent == nil {
+		return nil, errors.New("no consent broker available")
+	}
+
+	if err := s.consent.Answer(answer.GetId(), answer.GetGranted()); err != nil {
+		return nil, err
+	}
+
+	return &agentapi.Empty{}, nil
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/journal"
+	"github.com/ubuntu/decorate"
+)
+
+// Journal is a provider for the events that happened in the agent.
+type Journal interface {
+	Since(token string) (events []journal.Event, next string, truncated bool, err error)
+}
+
+// GetEvents handles the gRPC call to return the events that happened since the given token,
+// so that clients can catch up without fetching the full state again.
+func (s *Service) GetEvents(ctx context.Context, req *agentapi.GetEventsRequest) (_ *agentapi.Events, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetEvents")
+
+	log.Debugf(ctx, "UI service: received request for events since %q", req.GetSinceToken())
+
+	if s.journal == nil {
+		return nil, errors.New("no event journal available")
+	}
+
+	events, next, truncated, err := s.journal.Since(req.GetSinceToken())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &agentapi.Events{
+		NextToken: next,
+		Truncated: truncated,
+	}
+
+	for _, ev := range events {
+		resp.Events = append(resp.Events, &agentapi.AgentEvent{
+			Type:    agentEventType(ev.Type),
+			Time:    ev.Time.Format(time.RFC3339),
+			Distro:  ev.Distro,
+			Message: ev.Message,
+			Result:  taskResult(ev.Steps),
+		})
+	}
+
+	return resp, nil
+}
+
+// agentEventType converts the journal event types into their gRPC counterparts.
+func agentEventType(t journal.EventType) agentapi.AgentEventType {
+	switch t {
+	case journal.DistroAdded:
+		return agentapi.AgentEventType_AGENT_EVENT_DISTRO_ADDED
+	case journal.DistroRemoved:
+		return agentapi.AgentEventType_AGENT_EVENT_DISTRO_REMOVED
+	case journal.DistroStageChanged:
+		return agentapi.AgentEventType_AGENT_EVENT_DISTRO_STAGE_CHANGED
+	case journal.DistroRenamed:
+		return agentapi.AgentEventType_AGENT_EVENT_DISTRO_RENAMED
+	case journal.TaskFailed:
+		return agentapi.AgentEventType_AGENT_EVENT_TASK_FAILED
+	case journal.TaskCompleted:
+		return agentapi.AgentEventType_AGENT_EVENT_TASK_COMPLETED
+	}
+	return agentapi.AgentEventType_AGENT_EVENT_UNSPECIFIED
+}
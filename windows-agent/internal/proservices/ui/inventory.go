@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/inventory"
+	"github.com/ubuntu/decorate"
+	wsl "github.com/ubuntu/gowsl"
+)
+
+// GetInventory handles the gRPC call to return the inventory records of the distros, for asset management tools.
+// Distros whose WSL Pro service is connected are reported as seen right now. Unlike the exported inventory, the
+// records carry the number of tasks queued for each distro and whether it is running.
+func (s *Service) GetInventory(ctx context.Context, _ *agentapi.Empty) (_ *agentapi.Inventory, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetInventory")
+
+	log.Debug(ctx, "UI service: received GetInventory message")
+
+	machine, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the hostname: %v", err)
+	}
+
+	landscapeID, err := s.config.LandscapeAgentUID()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the Landscape UID: %v", err)
+	}
+
+	now := time.Now()
+	props := make(map[string]distro.Properties)
+	pending := make(map[string]int)
+	running := make(map[string]bool)
+	for _, d := range s.db.GetAll() {
+		p := d.Properties()
+		switch d.Lifecycle() {
+		case distro.Connected, distro.Provisioned, distro.Managed, distro.Degraded:
+			p.LastSeen = now
+		}
+		props[d.Name()] = p
+		pending[d.Name()] = d.PendingTasks()
+		running[d.Name()] = d.LiveState() == wsl.Running
+	}
+
+	records := inventory.NewRecords(inventory.Host{Machine: machine, LandscapeID: landscapeID}, props)
+
+	out := &agentapi.Inventory{Records: make([]*agentapi.InventoryRecord, 0, len(records))}
+	for _, r := range records {
+		out.Records = append(out.Records, &agentapi.InventoryRecord{
+			Machine:          r.Machine,
+			Distro:           r.Distro,
+			Hostname:         r.Hostname,
+			DistroId:         r.DistroID,
+			UbuntuVersion:    r.UbuntuVersion,
+			PrettyName:       r.PrettyName,
+			ProAttached:      r.ProAttached,
+			ProServices:      r.ProServices,
+			ProExpires:       r.ProExpires,
+			LastSeen:         r.LastSeen,
+			LandscapeId:      r.LandscapeID,
+			LandscapeManaged: r.LandscapeManaged,
+			PendingTasks:     int32(pending[r.Distro]),
+			Running:          running[r.Distro],
+
+			UnsupportedReason: r.UnsupportedReason,
+		})
+	}
+
+	return out, nil
+}
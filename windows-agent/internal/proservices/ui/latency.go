@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+)
+
+// GetLatencies handles the gRPC call to report the round-trip times measured to every distro.
+func (s *Service) GetLatencies(ctx context.Context, _ *agentapi.Empty) (*agentapi.Latencies, error) {
+	log.Info(ctx, "UI service: received GetLatencies message")
+
+	distros := s.db.GetAll()
+	sort.Slice(distros, func(i, j int) bool {
+		return strings.ToLower(distros[i].Name()) < strings.ToLower(distros[j].Name())
+	})
+
+	out := &agentapi.Latencies{Distros: make([]*agentapi.DistroLatency, 0, len(distros))}
+	for _, d := range distros {
+		out.Distros = append(out.Distros, latencyToAPI(d.Name(), d.Latency()))
+	}
+
+	return out, nil
+}
+
+// latencyToAPI converts the latency of a distro into its gRPC counterpart.
+func latencyToAPI(name string, l distro.Latency) *agentapi.DistroLatency {
+	out := &agentapi.DistroLatency{
+		Distro:   name,
+		LastMs:   milliseconds(l.Last),
+		MinMs:    milliseconds(l.Min),
+		MaxMs:    milliseconds(l.Max),
+		MeanMs:   milliseconds(l.Mean),
+		Samples:  int32(l.Samples),
+		Failures: int32(l.Failures),
+	}
+
+	if !l.LastProbe.IsZero() {
+		out.LastProbe = l.LastProbe.Format(time.RFC3339)
+	}
+	if l.LastErr != nil {
+		out.LastError = l.LastErr.Error()
+	}
+
+	return out
+}
+
+// milliseconds returns the duration as a fractional number of milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
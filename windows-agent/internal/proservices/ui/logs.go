@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	// defaultTailLines is the number of log lines returned when the request does not specify it.
+	defaultTailLines = 100
+
+	// maxTailLines caps the number of log lines that can be requested, to keep the messages small.
+	maxTailLines = 5000
+)
+
+// TailLog handles the gRPC call to stream the recent journal lines of wsl-pro-service in a distro, and
+// optionally the new ones until the call is cancelled. The distro must be running and connected to the agent.
+// The lines count towards the daily transfer cap of the distro: the stream stops once it is reached.
+func (s *Service) TailLog(req *agentapi.TailLogRequest, stream agentapi.UI_TailLogServer) (err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: TailLog")
+
+	ctx := stream.Context()
+	log.Infof(ctx, "UI service: received request to tail the logs of distro %q", req.GetDistro())
+
+	lines := req.GetLines()
+	if lines == 0 {
+		lines = defaultTailLines
+	}
+	if lines < 0 || lines > maxTailLines {
+		return fmt.Errorf("invalid number of lines %d: must be between 1 and %d", lines, maxTailLines)
+	}
+
+	d, ok := s.db.Get(req.GetDistro())
+	if !ok {
+		return fmt.Errorf("distro %q not found", req.GetDistro())
+	}
+
+	conn, err := d.Connection()
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return errors.New("distro is not connected: start it and try again")
+	}
+
+	if err := d.ReserveTransfer(bandwidth.KindLogs, 0); err != nil {
+		return err
+	}
+
+	err = conn.TailLog(ctx, &agentapi.TailLogCmd{
+		Lines:            lines,
+		Priority:         req.GetPriority(),
+		IncludeProClient: req.GetIncludeProClient(),
+		Follow:           req.GetFollow(),
+	}, func(line string) error {
+		if err := d.ReserveTransfer(bandwidth.KindLogs, uint64(len(line))); err != nil {
+			return err
+		}
+		if err := stream.Send(&agentapi.LogLine{Line: line}); err != nil {
+			return fmt.Errorf("could not send log line: %v", err)
+		}
+		return nil
+	})
+
+	// Followed logs are only stopped by cancelling the call.
+	if req.GetFollow() && ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/operations"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslversion"
+	"github.com/ubuntu/decorate"
+)
+
+// distroOperationFeatures are the features of WSL the operations on the distros need. Those that are not listed
+// are supported by any WSL.
+var distroOperationFeatures = map[agentapi.DistroOperation]wslversion.Feature{
+	agentapi.DistroOperation_DISTRO_OPERATION_SET_SPARSE: wslversion.SparseVHD,
+	agentapi.DistroOperation_DISTRO_OPERATION_MOVE:       wslversion.MoveDistro,
+}
+
+// supportsOperation returns nil if the host WSL supports the operation on the distros, and why not otherwise.
+func supportsOperation(info wslversion.Info, op agentapi.DistroOperation) error {
+	f, ok := distroOperationFeatures[op]
+	switch {
+	case info.Channel == "":
+		return errors.New("WSL could not be found")
+	case !ok || info.Supports(f):
+		return nil
+	case !info.Known():
+		return fmt.Errorf("%s requires WSL %s or newer and the WSL version is unknown", f, f.MinVersion())
+	}
+	return fmt.Errorf("%s requires WSL %s or newer but WSL %s is installed", f, f.MinVersion(), info.Version)
+}
+
+// distroOperations returns the operations on the distros the host WSL supports.
+func distroOperations(info wslversion.Info) (ops []agentapi.DistroOperation) {
+	for _, op := range []agentapi.DistroOperation{
+		agentapi.DistroOperation_DISTRO_OPERATION_SET_SPARSE,
+		agentapi.DistroOperation_DISTRO_OPERATION_MOVE,
+		agentapi.DistroOperation_DISTRO_OPERATION_SET_VERSION,
+	} {
+		if supportsOperation(info, op) == nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// ManageDistro handles the gRPC call to operate on the selected distros as a whole, such as making their VHD sparse
+// to reclaim disk space. The operations run on the host once each distro is stopped. The host WSL must support the
+// operation, every distro must be known and run on WSL 2 if the operation needs it: otherwise nothing is submitted.
+func (s *Service) ManageDistro(ctx context.Context, req *agentapi.ManageDistroRequest) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: ManageDistro")
+
+	var op agentapi.DistroOperation
+	var t task.Task
+	var description string
+
+	switch o := req.GetOperation().(type) {
+	case *agentapi.ManageDistroRequest_SetSparse:
+		op, t = agentapi.DistroOperation_DISTRO_OPERATION_SET_SPARSE, tasks.SetSparse{Sparse: o.SetSparse}
+		description = fmt.Sprintf("set sparse %t", o.SetSparse)
+	case *agentapi.ManageDistroRequest_Move:
+		if o.Move == "" {
+			return nil, errors.New("no location to move the distro into")
+		}
+		if len(req.GetDistros()) > 1 {
+			return nil, errors.New("only one distro can be moved at a time")
+		}
+		op, t = agentapi.DistroOperation_DISTRO_OPERATION_MOVE, tasks.MoveDistro{Location: o.Move}
+		description = "move to " + o.Move
+	case *agentapi.ManageDistroRequest_SetVersion:
+		if o.SetVersion != 1 && o.SetVersion != 2 {
+			return nil, fmt.Errorf("invalid WSL version %d: expected 1 or 2", o.SetVersion)
+		}
+		op, t = agentapi.DistroOperation_DISTRO_OPERATION_SET_VERSION, tasks.SetWslVersion{Version: int(o.SetVersion)}
+		description = fmt.Sprintf("set WSL version %d", o.SetVersion)
+	default:
+		return nil, errors.New("no operation requested")
+	}
+
+	log.Infof(ctx, "UI service: received request to %s on %d distros", description, len(req.GetDistros()))
+
+	if err := supportsOperation(s.wslInfo, op); err != nil {
+		return nil, err
+	}
+
+	distros, err := s.lookUpDistros(req.GetDistros())
+	if err != nil {
+		return nil, err
+	}
+
+	if task.NeedsWSL2(t) {
+		for _, d := range distros {
+			if d.WslVersion() == 1 {
+				return nil, fmt.Errorf("distro %q: %v", d.Name(), task.ErrNeedsWSL2)
+			}
+		}
+	}
+
+	if _, err := operations.Submit(ctx, s.operations, "manage-distro", description, distros, func(*distro.Distro) task.Task { return t }); err != nil {
+		return nil, err
+	}
+
+	return &agentapi.Empty{}, nil
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"context"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/notifications"
+	"github.com/ubuntu/decorate"
+)
+
+// GetNotificationSettings handles the gRPC call to return the user's notification preferences.
+func (s *Service) GetNotificationSettings(ctx context.Context, _ *agentapi.Empty) (_ *agentapi.NotificationSettings, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetNotificationSettings")
+
+	frequency, err := s.config.NotificationFrequency()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := notifications.ParseFrequency(frequency)
+	if err != nil {
+		log.Warningf(ctx, "UI service: %v: reporting the default one", err)
+		f = notifications.DefaultFrequency
+	}
+
+	return &agentapi.NotificationSettings{Frequency: string(f)}, nil
+}
+
+// SetNotificationSettings handles the gRPC call to store the user's notification preferences.
+func (s *Service) SetNotificationSettings(ctx context.Context, settings *agentapi.NotificationSettings) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: SetNotificationSettings")
+
+	log.Infof(ctx, "UI service: received notification frequency %q", settings.GetFrequency())
+
+	f, err := notifications.ParseFrequency(settings.GetFrequency())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.config.SetNotificationFrequency(ctx, string(f)); err != nil {
+		return nil, err
+	}
+
+	return &agentapi.Empty{}, nil
+}
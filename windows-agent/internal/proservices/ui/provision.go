@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/provision"
+	"github.com/ubuntu/decorate"
+)
+
+// provisionStages maps the stages of the provisioning to their API counterparts.
+var provisionStages = map[provision.Stage]agentapi.ProvisionStage{
+	provision.StageDownloading: agentapi.ProvisionStage_PROVISION_STAGE_DOWNLOADING,
+	provision.StageImporting:   agentapi.ProvisionStage_PROVISION_STAGE_IMPORTING,
+	provision.StageConfiguring: agentapi.ProvisionStage_PROVISION_STAGE_CONFIGURING,
+	provision.StageDone:        agentapi.ProvisionStage_PROVISION_STAGE_DONE,
+}
+
+// ProvisionDistro handles the gRPC call to install a distro out of an image downloaded from the web, streaming the
+// progress of the download and of the import. The image must be verified by a SHA256SUMS file next to it. Once
+// installed, the distro goes through the same configuration as any other: it is added to the database so that it
+// gets Pro-attached and registered to Landscape when its WSL Pro service connects.
+func (s *Service) ProvisionDistro(req *agentapi.ProvisionRequest, stream agentapi.UI_ProvisionDistroServer) (err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: ProvisionDistro")
+
+	ctx := stream.Context()
+	log.Infof(ctx, "UI service: received request to provision distro %q from %q", req.GetDistroName(), req.GetRootfsUrl())
+
+	if err := provision.CheckName(req.GetDistroName()); err != nil {
+		return err
+	}
+
+	rootfs, err := url.Parse(req.GetRootfsUrl())
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %v", req.GetRootfsUrl(), err)
+	}
+	if rootfs.Scheme != "https" && rootfs.Scheme != "http" {
+		return fmt.Errorf("invalid URL %q: only HTTP and HTTPS are supported", req.GetRootfsUrl())
+	}
+
+	args := []provision.Option{
+		provision.WithProgress(func(p provision.Progress) {
+			msg := &agentapi.ProvisionProgress{Stage: provisionStages[p.Stage], Done: p.Done, Total: p.Total}
+			if err := stream.Send(msg); err != nil {
+				log.Warningf(ctx, "UI service: could not send the progress of the provisioning of %q: %v", req.GetDistroName(), err)
+			}
+		}),
+	}
+	if req.GetInstallDir() != "" {
+		args = append(args, provision.WithInstallDir(req.GetInstallDir()))
+	}
+
+	if err := provision.Install(ctx, req.GetDistroName(), rootfs, args...); err != nil {
+		return err
+	}
+
+	if _, err := s.db.GetDistroAndUpdateProperties(ctx, req.GetDistroName(), distro.Properties{}); err != nil {
+		return fmt.Errorf("distro %q was installed but could not be added to the database: %v", req.GetDistroName(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/contractsapi"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/ubuntu/decorate"
+)
+
+// supportEntitlement is the entitlement whose affordances contain the support level.
+const supportEntitlement = "support"
+
+// subscriptionDetailsCache holds the details of the contract of the latest Pro token, so that
+// the contract server is only queried again after the token changes.
+type subscriptionDetailsCache struct {
+	token   string
+	details *agentapi.SubscriptionDetails
+	mu      sync.Mutex
+}
+
+// GetSubscriptionDetails handles the gRPC call to report the entitlements of the current subscription.
+//
+// They are those of the contract of the Pro token, as the contract server describes it. Only when the contract
+// server cannot be reached (e.g. for offline tokens) are they those the Ubuntu Pro client reports in the distros
+// attached with the token, so that the GUI has something to show.
+func (s *Service) GetSubscriptionDetails(ctx context.Context, _ *agentapi.Empty) (_ *agentapi.SubscriptionDetails, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetSubscriptionDetails")
+
+	log.Info(ctx, "UI service: received GetSubscriptionDetails message")
+
+	token, _, err := s.config.Subscription()
+	if err != nil {
+		return nil, err
+	}
+
+	if token == "" {
+		return &agentapi.SubscriptionDetails{}, nil
+	}
+
+	cache := s.subscriptionDetails
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.details != nil && cache.token == token {
+		return cache.details, nil
+	}
+
+	contract, err := contracts.Contract(ctx, token, s.contractsArgs...)
+	if err != nil {
+		log.Warningf(ctx, "UI service: could not query the contract server, reporting the subscription details of the attached distros: %v", err)
+		return s.attachedSubscriptionDetails(token)
+	}
+
+	cache.token = token
+	cache.details = contractToAPI(contract)
+
+	return cache.details, nil
+}
+
+// contractToAPI converts a contract into its gRPC representation.
+func contractToAPI(contract contractsapi.ContractInfo) *agentapi.SubscriptionDetails {
+	details := &agentapi.SubscriptionDetails{
+		Entitlements: make([]*agentapi.Entitlement, 0, len(contract.ResourceEntitlements)),
+	}
+
+	if !contract.EffectiveTo.IsZero() {
+		details.Expires = contract.EffectiveTo.UTC().Format(time.RFC3339)
+	}
+
+	for _, e := range contract.ResourceEntitlements {
+		if e.Type == supportEntitlement {
+			if e.Entitled {
+				details.SupportLevel = e.Affordances.SupportLevel
+			}
+			continue
+		}
+
+		details.Entitlements = append(details.Entitlements, &agentapi.Entitlement{
+			Name:     e.Type,
+			Entitled: e.Entitled,
+		})
+	}
+
+	return details
+}
+
+// attachedSubscriptionDetails gathers the subscription details that the Ubuntu Pro client reports in the distros
+// attached with the token. They are not cached, as they change as distros attach and refresh their status.
+func (s *Service) attachedSubscriptionDetails(token string) (*agentapi.SubscriptionDetails, error) {
+	details := &agentapi.SubscriptionDetails{}
+
+	var entitled []string
+	for _, d := range s.db.Lookup(database.ProAttached, true) {
+		props := d.Properties()
+
+		// Distros of a distro group with its own subscription are attached to another contract.
+		t, _, err := s.config.SubscriptionFor(d.Name())
+		if err != nil {
+			return nil, err
+		}
+		if t != token {
+			continue
+		}
+
+		for _, e := range props.ProServices {
+			if !slices.Contains(entitled, e) {
+				entitled = append(entitled, e)
+			}
+		}
+
+		// Distros that have not refreshed their status since the contract was renewed report an earlier
+		// expiry, so the latest one is the current one.
+		if props.ProExpires > details.Expires {
+			details.Expires = props.ProExpires
+			details.SupportLevel = props.ProSupportLevel
+		}
+	}
+
+	slices.Sort(entitled)
+	for _, e := range entitled {
+		details.Entitlements = append(details.Entitlements, &agentapi.Entitlement{Name: e, Entitled: true})
+	}
+
+	return details, nil
+}
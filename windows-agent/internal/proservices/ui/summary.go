@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/ubuntu/decorate"
+	"google.golang.org/protobuf/proto"
+)
+
+// GetSummary handles the gRPC call to return an aggregated view of the state of the agent and of the WSL
+// installed on the host, so that the GUI does not need to fetch and aggregate the full list of distros.
+func (s *Service) GetSummary(ctx context.Context, _ *agentapi.Empty) (_ *agentapi.Summary, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetSummary")
+
+	log.Debug(ctx, "UI service: received GetSummary message")
+
+	return s.summary()
+}
+
+// WatchSummary handles the gRPC call to stream the aggregated view of the state of the agent. The
+// current summary is sent right away, and a new one every time it changes, until the client disconnects.
+// The summary is only computed again when the distros or the subscription change.
+func (s *Service) WatchSummary(_ *agentapi.Empty, stream agentapi.UI_WatchSummaryServer) (err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: WatchSummary")
+
+	ctx := stream.Context()
+	log.Info(ctx, "UI service: received request to watch the summary")
+
+	// Watching before computing the first summary, so that no change is missed in between.
+	distroChanges := s.db.Watch(ctx)
+	subscriptionChanges := s.subscriptionChanges.watch(ctx)
+
+	var last *agentapi.Summary
+	for {
+		summary, err := s.summary()
+		if err != nil {
+			return err
+		}
+
+		if !proto.Equal(summary, last) {
+			if err := stream.Send(summary); err != nil {
+				return fmt.Errorf("could not send summary: %v", err)
+			}
+			last = summary
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Debug(ctx, "UI service: stopped watching the summary")
+			return nil
+		case <-distroChanges:
+		case <-subscriptionChanges:
+		}
+	}
+}
+
+// NotifySubscriptionChanged tells the summary watchers that the subscription changed, so that they are sent
+// the new summary.
+func (s *Service) NotifySubscriptionChanged() {
+	s.subscriptionChanges.notify()
+}
+
+// changes signals its watchers every time something changes.
+type changes struct {
+	watchers map[chan struct{}]struct{}
+	mu       sync.Mutex
+}
+
+// watch returns a channel that receives a value after every change. Changes that happen before the
+// previous one is received are coalesced. The channel is closed when the context is cancelled.
+func (c *changes) watch(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	c.mu.Lock()
+	c.watchers[ch] = struct{}{}
+	c.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		delete(c.watchers, ch)
+		close(ch)
+	}()
+
+	return ch
+}
+
+// notify signals every watcher without blocking.
+func (c *changes) notify() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for ch := range c.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// summary aggregates the subscription and the state of the distros.
+func (s *Service) summary() (*agentapi.Summary, error) {
+	subs, err := s.getSubscriptionSource()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &agentapi.Summary{
+		Subscription: subs,
+		Wsl: &agentapi.WslInfo{
+			Version:       s.wslInfo.Version,
+			KernelVersion: s.wslInfo.KernelVersion,
+			Channel:       s.wslInfo.Channel,
+			Degraded:      s.wslInfo.Degraded(),
+			Operations:    distroOperations(s.wslInfo),
+		},
+		Contracts: contractsHealth(contracts.HealthOf(s.contractsArgs...).State()),
+	}
+
+	for _, d := range s.db.GetAll() {
+		summary.Distros++
+
+		switch d.Lifecycle() {
+		case distro.Connected, distro.Provisioned, distro.Managed:
+			summary.Connected++
+		case distro.Degraded:
+			summary.Connected++
+			summary.Errors++
+		}
+
+		if sec := d.Properties().Security; sec.StandardUpdates > 0 || sec.ESMUpdates > 0 {
+			summary.PendingUpdates++
+		}
+	}
+
+	return summary, nil
+}
+
+// contractsHealth converts the health of the synchronisation with the contract server to its API
+// representation. Times are in RFC 3339 format, and empty if the interaction never happened.
+func contractsHealth(state contracts.HealthState) *agentapi.ContractsHealth {
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	return &agentapi.ContractsHealth{
+		LastContact:         format(state.LastContact),
+		LastTokenRefresh:    format(state.LastTokenRefresh),
+		LastEntitlementSync: format(state.LastEntitlementSync),
+		LastError:           state.LastError,
+		LastErrorTime:       format(state.LastErrorTime),
+	}
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/ubuntu/decorate"
+)
+
+// WatchTasks handles the gRPC call to stream the lifecycle events of the tasks of a distro.
+// It streams until the client cancels the call or the distro is removed.
+func (s *Service) WatchTasks(req *agentapi.WatchTasksRequest, stream agentapi.UI_WatchTasksServer) (err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: WatchTasks")
+
+	ctx := stream.Context()
+	log.Infof(ctx, "UI service: received request to watch the tasks of distro %q", req.GetDistro())
+
+	d, ok := s.db.Get(req.GetDistro())
+	if !ok {
+		return fmt.Errorf("distro %q not found", req.GetDistro())
+	}
+
+	events, err := d.WatchTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	for ev := range events {
+		if err := stream.Send(&agentapi.TaskEvent{
+			Type:     taskEventType(ev.Type),
+			Task:     ev.Task,
+			Progress: ev.Progress,
+			Step:     ev.Step,
+			Reason:   ev.Reason,
+			Result:   taskResult(ev.Steps),
+		}); err != nil {
+			return fmt.Errorf("could not send task event: %v", err)
+		}
+	}
+
+	log.Debugf(ctx, "UI service: stopped watching the tasks of distro %q", req.GetDistro())
+	return nil
+}
+
+// taskEventType converts the worker event types into their gRPC counterparts.
+func taskEventType(t worker.EventType) agentapi.TaskEventType {
+	switch t {
+	case worker.EventQueued:
+		return agentapi.TaskEventType_TASK_EVENT_QUEUED
+	case worker.EventStarted:
+		return agentapi.TaskEventType_TASK_EVENT_STARTED
+	case worker.EventProgress:
+		return agentapi.TaskEventType_TASK_EVENT_PROGRESS
+	case worker.EventCompleted:
+		return agentapi.TaskEventType_TASK_EVENT_COMPLETED
+	case worker.EventFailed:
+		return agentapi.TaskEventType_TASK_EVENT_FAILED
+	case worker.EventInterrupted:
+		return agentapi.TaskEventType_TASK_EVENT_INTERRUPTED
+	}
+	return agentapi.TaskEventType_TASK_EVENT_UNSPECIFIED
+}
+
+// taskResult converts the outcome of the steps of a task into its gRPC counterpart.
+// Tasks that do not report their steps have no result.
+func taskResult(steps []task.Step) *agentapi.TaskResult {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	r := &agentapi.TaskResult{}
+	for _, s := range steps {
+		r.Steps = append(r.Steps, &agentapi.TaskStep{
+			Name:    s.Name,
+			Status:  taskStepStatus(s.Status),
+			Message: s.Message,
+		})
+	}
+	return r
+}
+
+// taskStepStatus converts the task step statuses into their gRPC counterparts.
+func taskStepStatus(s task.StepStatus) agentapi.TaskStepStatus {
+	switch s {
+	case task.StepSucceeded:
+		return agentapi.TaskStepStatus_TASK_STEP_SUCCEEDED
+	case task.StepFailed:
+		return agentapi.TaskStepStatus_TASK_STEP_FAILED
+	case task.StepSkipped:
+		return agentapi.TaskStepStatus_TASK_STEP_SKIPPED
+	}
+	return agentapi.TaskStepStatus_TASK_STEP_UNSPECIFIED
+}
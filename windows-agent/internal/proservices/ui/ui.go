@@ -5,14 +5,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/operations"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslversion"
 	"github.com/ubuntu/decorate"
 )
 
@@ -21,30 +27,74 @@ type Config interface {
 	SetUserSubscription(ctx context.Context, token string) error
 	SetStoreSubscription(ctx context.Context, token string) error
 	Subscription() (string, config.Source, error)
+	SubscriptionFor(distroName string) (string, config.Source, error)
+	OfflineSubscription() (bool, error)
 	SetUserLandscapeConfig(ctx context.Context, token string) error
 	LandscapeClientConfig() (string, config.Source, error)
+	NotificationFrequency() (string, error)
+	SetNotificationFrequency(ctx context.Context, frequency string) error
+	LandscapeAgentUID() (string, error)
 }
 
 // Service it the UI GRPC service implementation.
 type Service struct {
-	db     *database.DistroDB
-	config Config
+	db      *database.DistroDB
+	config  Config
+	journal Journal
+	consent Consent
+
+	// operations records the outcome of the tasks submitted to several distros at once.
+	operations *operations.Tracker
+
+	// usgReportsDir is the directory where USG audit reports are stored.
+	usgReportsDir string
+
+	// wslConfig is the path of the global WSL configuration file.
+	wslConfig string
+
+	// wslInfo describes the WSL installed on the host.
+	wslInfo wslversion.Info
 
 	// contractsArgs allows for overriding the contract server's behaviour.
 	contractsArgs []contracts.Option
 
+	// subscriptionDetails caches the response of the contract server.
+	subscriptionDetails *subscriptionDetailsCache
+
+	// subscriptionChanges signals the summary watchers that the subscription changed.
+	subscriptionChanges *changes
+
 	agentapi.UnimplementedUIServer
 }
 
-// New returns a new service handling the UI API.
-func New(ctx context.Context, config Config, db *database.DistroDB, args ...contracts.Option) (s Service) {
+// New returns a new service handling the UI API. The events are served from the journal, the requests
+// for consent are relayed to and from the consent broker, the outcome of the tasks submitted to several distros
+// at once is recorded by ops, USG audit reports are stored in usgReportsDir, the global WSL configuration file
+// is the one at wslConfig, and wslInfo is reported as the WSL installed on the host.
+func New(ctx context.Context, config Config, db *database.DistroDB, journal Journal, consent Consent, ops *operations.Tracker, usgReportsDir, wslConfig string, wslInfo wslversion.Info, args ...contracts.Option) (s Service) {
 	log.Debug(ctx, "Building gRPC UI service")
 
-	return Service{
+	s = Service{
 		db:            db,
 		config:        config,
+		journal:       journal,
+		consent:       consent,
+		operations:    ops,
+		usgReportsDir: usgReportsDir,
+		wslConfig:     wslConfig,
+		wslInfo:       wslInfo,
 		contractsArgs: args,
+
+		subscriptionDetails: &subscriptionDetailsCache{},
+		subscriptionChanges: &changes{watchers: make(map[chan struct{}]struct{})},
 	}
+
+	// The summary reports the health of the synchronisation with the contract server.
+	if h := contracts.HealthOf(args...); h != nil {
+		h.OnChange(s.subscriptionChanges.notify)
+	}
+
+	return s
 }
 
 // ApplyProToken handles the gRPC call to pro attach all distros using a token provided by the GUI.
@@ -55,6 +105,8 @@ func (s *Service) ApplyProToken(ctx context.Context, info *agentapi.ProAttachInf
 	token := info.GetToken()
 	log.Infof(ctx, "UI service: received token %s", common.Obfuscate(token))
 
+	// The attachment jumps ahead of the background work queued in the distros.
+	ctx = task.WithUserInitiated(ctx)
 	if err := s.config.SetUserSubscription(ctx, token); err != nil {
 		return nil, err
 	}
@@ -172,6 +224,7 @@ func (s *Service) getLandscapeConfigSource() (*agentapi.LandscapeSource, error)
 func (s *Service) NotifyPurchase(ctx context.Context, empty *agentapi.Empty) (info *agentapi.SubscriptionInfo, errs error) {
 	log.Info(ctx, "UI service: received NotifyPurchase message")
 
+	ctx = task.WithUserInitiated(ctx)
 	if err := ubuntupro.FetchFromMicrosoftStore(ctx, s.config, s.db, s.contractsArgs...); err != nil {
 		log.Warningf(ctx, "UI service: NotifyPurchase: %v", err)
 		errs = errors.Join(errs, err)
@@ -186,3 +239,81 @@ func (s *Service) NotifyPurchase(ctx context.Context, empty *agentapi.Empty) (in
 	log.Debugf(ctx, "UI service: responding NotifyPurchase with info: %v", info)
 	return info, errs
 }
+
+// ApplyProService handles the gRPC call to enable or disable a single Ubuntu Pro service on the
+// selected distros. Every distro must report the service as available: otherwise nothing is submitted.
+func (s *Service) ApplyProService(ctx context.Context, info *agentapi.ProServiceInfo) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: ApplyProService")
+
+	service := info.GetService()
+	log.Infof(ctx, "UI service: received request to set service %q enabled=%t on %d distros", service, info.GetEnable(), len(info.GetDistros()))
+
+	if service == "" {
+		return nil, errors.New("no service provided")
+	}
+
+	distros, err := s.distrosWithService(info.GetDistros(), service)
+	if err != nil {
+		return nil, err
+	}
+
+	action := "disable"
+	if info.GetEnable() {
+		action = "enable"
+	}
+
+	t := tasks.ProService{Service: service, Enable: info.GetEnable()}
+	if _, err := operations.Submit(ctx, s.operations, "pro-service", action+" "+service, distros, func(*distro.Distro) task.Task { return t }); err != nil {
+		return nil, err
+	}
+
+	return &agentapi.Empty{}, nil
+}
+
+// distrosWithService looks up the named distros in the database, and checks that all of them
+// report the specified Ubuntu Pro service as available.
+func (s *Service) distrosWithService(names []string, service string) (distros []*distro.Distro, err error) {
+	all, err := s.lookUpDistros(names)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range all {
+		if !slices.Contains(d.Properties().ProServices, service) {
+			err = errors.Join(err, fmt.Errorf("service %q is not available in distro %q", service, d.Name()))
+			continue
+		}
+
+		distros = append(distros, d)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return distros, nil
+}
+
+// lookUpDistros looks up the named distros in the database. It fails if any of them is not found.
+func (s *Service) lookUpDistros(names []string) (distros []*distro.Distro, err error) {
+	if len(names) == 0 {
+		return nil, errors.New("no distros provided")
+	}
+
+	for _, name := range names {
+		d, ok := s.db.Get(name)
+		if !ok {
+			err = errors.Join(err, fmt.Errorf("distro %q not found", name))
+			continue
+		}
+
+		distros = append(distros, d)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return distros, nil
+}
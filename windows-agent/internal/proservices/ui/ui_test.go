@@ -4,23 +4,35 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/mocks/contractserver/contractsmockserver"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consent"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/journal"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/ui"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslversion"
 	"github.com/stretchr/testify/require"
 	wsl "github.com/ubuntu/gowsl"
 	wslmock "github.com/ubuntu/gowsl/mock"
+	"google.golang.org/grpc"
 )
 
 func TestNew(t *testing.T) {
@@ -34,7 +46,7 @@ func TestNew(t *testing.T) {
 
 	conf := config.New(ctx, dir)
 
-	_ = ui.New(context.Background(), conf, db)
+	_ = ui.New(context.Background(), conf, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
 }
 
 // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
@@ -102,7 +114,7 @@ func TestAttachPro(t *testing.T) {
 				require.NoError(t, err, "Setup: could not make registry read registry settings")
 			}
 
-			serv := ui.New(context.Background(), conf, db)
+			serv := ui.New(context.Background(), conf, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
 
 			info := agentapi.ProAttachInfo{Token: tc.token}
 			_, err = serv.ApplyProToken(context.Background(), &info)
@@ -167,7 +179,7 @@ func TestGetConfigSources(t *testing.T) {
 			db, err := database.New(ctx, dir)
 			require.NoError(t, err, "Setup: empty database New() should return no error")
 			config := tc.config
-			service := ui.New(ctx, &config, db)
+			service := ui.New(ctx, &config, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
 
 			src, err := service.GetConfigSources(ctx, &agentapi.Empty{})
 			if tc.wantErr {
@@ -225,7 +237,7 @@ func TestNotifyPurchase(t *testing.T) {
 				conf.proSource = config.SourceUser
 			}
 
-			service := ui.New(ctx, conf, db, opts...)
+			service := ui.New(ctx, conf, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{}, opts...)
 			info, err := service.NotifyPurchase(ctx, &agentapi.Empty{})
 			if tc.wantErr {
 				require.Error(t, err, "NotifyPurchase should return an error")
@@ -275,7 +287,7 @@ func TestApplyLandscapeConfig(t *testing.T) {
 				returnBadSource:           tc.returnBadSource,
 			}
 
-			uiService := ui.New(context.Background(), conf, db)
+			uiService := ui.New(context.Background(), conf, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
 
 			msg := &agentapi.LandscapeConfig{
 				Config: landscapeConfig,
@@ -294,18 +306,1966 @@ func TestApplyLandscapeConfig(t *testing.T) {
 	}
 }
 
+// Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+//
+//nolint:tparallel
+func TestApplyProService(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	entitled, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	notEntitled, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		distros []string
+		service string
+
+		wantErr bool
+	}{
+		"Success enabling a service on an entitled distro": {distros: []string{entitled}, service: "esm-apps"},
+
+		"Error when no service is provided":             {distros: []string{entitled}, wantErr: true},
+		"Error when no distros are provided":            {service: "esm-apps", wantErr: true},
+		"Error when the distro is not in the database":  {distros: []string{"NotInDatabase"}, service: "esm-apps", wantErr: true},
+		"Error when the service is not available":       {distros: []string{entitled}, service: "realtime-kernel", wantErr: true},
+		"Error when any of the distros is not entitled": {distros: []string{entitled, notEntitled}, service: "esm-apps", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, entitled, distro.Properties{ProAttached: true, ProServices: []string{"esm-apps", "usg"}})
+			require.NoError(t, err, "Setup: could not add %q to database", entitled)
+			defer d.Cleanup(ctx)
+
+			d, err = db.GetDistroAndUpdateProperties(ctx, notEntitled, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", notEntitled)
+			defer d.Cleanup(ctx)
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			_, err = service.ApplyProService(ctx, &agentapi.ProServiceInfo{
+				Distros: tc.distros,
+				Service: tc.service,
+				Enable:  true,
+			})
+
+			if tc.wantErr {
+				require.Error(t, err, "ApplyProService should return an error")
+
+				for _, name := range []string{entitled, notEntitled} {
+					out, err := os.ReadFile(filepath.Join(dir, name+".tasks"))
+					if err == nil {
+						require.NotContains(t, string(out), "ProService", "No task should have been submitted to %q", name)
+					}
+				}
+				return
+			}
+			require.NoError(t, err, "ApplyProService should return no errors")
+
+			for _, name := range tc.distros {
+				out, err := os.ReadFile(filepath.Join(dir, name+".tasks"))
+				require.NoError(t, err, "Could not read the task file of %q", name)
+				require.Contains(t, string(out), "ProService", "The task should have been submitted to %q", name)
+			}
+		})
+	}
+}
+
+// Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+//
+//nolint:tparallel
+func TestApplyUsgProfile(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	withUsg, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	withoutUsg, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		distros []string
+		profile string
+
+		wantErr bool
+	}{
+		"Success applying a profile on a distro with USG": {distros: []string{withUsg}, profile: "cis_level1_server"},
+
+		"Error when the profile is empty":                 {distros: []string{withUsg}, wantErr: true},
+		"Error when the profile is not a valid name":      {distros: []string{withUsg}, profile: "../../evil", wantErr: true},
+		"Error when no distros are provided":              {profile: "cis_level1_server", wantErr: true},
+		"Error when the distro is not in the database":    {distros: []string{"NotInDatabase"}, profile: "cis_level1_server", wantErr: true},
+		"Error when any of the distros does not have USG": {distros: []string{withUsg, withoutUsg}, profile: "cis_level1_server", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, withUsg, distro.Properties{ProAttached: true, ProServices: []string{"esm-apps", "usg"}})
+			require.NoError(t, err, "Setup: could not add %q to database", withUsg)
+			defer d.Cleanup(ctx)
+
+			d, err = db.GetDistroAndUpdateProperties(ctx, withoutUsg, distro.Properties{ProAttached: true, ProServices: []string{"esm-apps"}})
+			require.NoError(t, err, "Setup: could not add %q to database", withoutUsg)
+			defer d.Cleanup(ctx)
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			_, err = service.ApplyUsgProfile(ctx, &agentapi.UsgProfileInfo{
+				Distros: tc.distros,
+				Profile: tc.profile,
+				Fix:     true,
+			})
+			if tc.wantErr {
+				require.Error(t, err, "ApplyUsgProfile should return an error")
+				return
+			}
+			require.NoError(t, err, "ApplyUsgProfile should return no errors")
+
+			for _, name := range tc.distros {
+				out, err := os.ReadFile(filepath.Join(dir, name+".tasks"))
+				require.NoError(t, err, "Could not read the task file of %q", name)
+				require.Contains(t, string(out), "UsgProfile", "The task should have been submitted to %q", name)
+				require.Contains(t, string(out), tc.profile, "The task should contain the profile")
+			}
+		})
+	}
+}
+
+// Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+//
+//nolint:tparallel
+func TestGetUsgReport(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		distro      string
+		profile     string
+		noReport    bool
+		largeReport bool
+		sendErr     bool
+
+		wantChunks int
+		wantErr    bool
+	}{
+		"Success retrieving a report":                     {wantChunks: 1},
+		"Success retrieving a report in multiple chunks":  {largeReport: true, wantChunks: 3},
+		"Error when the report cannot be sent to the GUI": {sendErr: true, wantErr: true},
+
+		"Error when the distro is not in the database": {distro: "NotInDatabase", wantErr: true},
+		"Error when the profile is not a valid name":   {profile: "../evil", wantErr: true},
+		"Error when there is no report":                {noReport: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.distro == "" {
+				tc.distro = distroName
+			}
+			if tc.profile == "" {
+				tc.profile = "cis_level1_server"
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			report := []byte("<html>report</html>")
+			if tc.largeReport {
+				report = []byte("<html>" + strings.Repeat("report", 500*1024) + "</html>")
+			}
+
+			reportsDir := t.TempDir()
+			if !tc.noReport {
+				err := os.MkdirAll(filepath.Join(reportsDir, distroName), 0700)
+				require.NoError(t, err, "Setup: could not create the report directory")
+				err = os.WriteFile(filepath.Join(reportsDir, distroName, "cis_level1_server.html"), report, 0600)
+				require.NoError(t, err, "Setup: could not write the report")
+			}
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, reportsDir, "", wslversion.Info{})
+
+			stream := &mockUsgReportStream{ctx: ctx, err: tc.sendErr}
+			err = service.GetUsgReport(&agentapi.UsgReportRequest{Distro: tc.distro, Profile: tc.profile}, stream)
+			if tc.wantErr {
+				require.Error(t, err, "GetUsgReport should return an error")
+				return
+			}
+			require.NoError(t, err, "GetUsgReport should return no errors")
+			require.Len(t, stream.chunks, tc.wantChunks, "Mismatched number of chunks")
+
+			var got []byte
+			for _, chunk := range stream.chunks {
+				require.Equal(t, distroName, chunk.GetDistro(), "Mismatched distro name")
+				require.Equal(t, tc.profile, chunk.GetProfile(), "Mismatched profile")
+
+				_, err = time.Parse(time.RFC3339, chunk.GetTimestamp())
+				require.NoError(t, err, "Timestamp should be in RFC3339 format")
+
+				got = append(got, chunk.GetReport()...)
+			}
+			require.Equal(t, report, got, "Mismatched report contents")
+		})
+	}
+}
+
+// Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+//
+//nolint:tparallel
+func TestManageUser(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distro1, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	distro2, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		distros []string
+		user    string
+		groups  []string
+
+		wantErr bool
+	}{
+		"Success managing a user in a distro":         {distros: []string{distro1}, user: "ubuntu"},
+		"Success managing a user in multiple distros": {distros: []string{distro1, distro2}, user: "ubuntu", groups: []string{"sudo", "docker"}},
+
+		"Error when the user name is empty":            {distros: []string{distro1}, wantErr: true},
+		"Error when the user name is not valid":        {distros: []string{distro1}, user: "Bad User", wantErr: true},
+		"Error when a group name is not valid":         {distros: []string{distro1}, user: "ubuntu", groups: []string{"sudo", "../evil"}, wantErr: true},
+		"Error when no distros are provided":           {user: "ubuntu", wantErr: true},
+		"Error when the distro is not in the database": {distros: []string{distro1, "NotInDatabase"}, user: "ubuntu", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			for _, n := range []string{distro1, distro2} {
+				d, err := db.GetDistroAndUpdateProperties(ctx, n, distro.Properties{})
+				require.NoError(t, err, "Setup: could not add %q to database", n)
+				defer d.Cleanup(ctx)
+			}
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			_, err = service.ManageUser(ctx, &agentapi.ManageUserInfo{
+				Distros:    tc.distros,
+				Name:       tc.user,
+				Groups:     tc.groups,
+				SetDefault: true,
+			})
+			if tc.wantErr {
+				require.Error(t, err, "ManageUser should return an error")
+				for _, n := range []string{distro1, distro2} {
+					out, _ := os.ReadFile(filepath.Join(dir, n+".tasks"))
+					require.NotContains(t, string(out), "ManageUser", "No task should have been submitted to %q", n)
+				}
+				return
+			}
+			require.NoError(t, err, "ManageUser should return no errors")
+
+			for _, n := range tc.distros {
+				out, err := os.ReadFile(filepath.Join(dir, n+".tasks"))
+				require.NoError(t, err, "Could not read the task file of %q", n)
+				require.Contains(t, string(out), "ManageUser", "The task should have been submitted to %q", n)
+				require.Contains(t, string(out), tc.user, "The task should contain the user name")
+			}
+		})
+	}
+}
+
+// Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+//
+//nolint:tparallel
+func TestManageDistro(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distro1, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	distro2, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	recentWsl := wslversion.Info{Version: "2.3.24.0", Channel: wslversion.ChannelStore}
+
+	testCases := map[string]struct {
+		distros []string
+		req     *agentapi.ManageDistroRequest
+		wslInfo *wslversion.Info
+		wsl1    bool
+
+		wantTask string
+		wantErr  bool
+	}{
+		"Success setting the VHD of multiple distros sparse": {distros: []string{distro1, distro2}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_SetSparse{SetSparse: true}}, wantTask: "SetSparse"},
+		"Success moving a distro":                            {distros: []string{distro1}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_Move{Move: `D:\WSL`}}, wantTask: "MoveDistro"},
+		"Success setting the WSL version with an old WSL":    {distros: []string{distro1}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_SetVersion{SetVersion: 2}}, wslInfo: &wslversion.Info{Channel: wslversion.ChannelInbox}, wantTask: "SetWslVersion"},
+		"Success converting a distro on WSL 1":               {distros: []string{distro1}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_SetVersion{SetVersion: 2}}, wsl1: true, wantTask: "SetWslVersion"},
+
+		"Error when no operation is requested":                    {distros: []string{distro1}, req: &agentapi.ManageDistroRequest{}, wantErr: true},
+		"Error when no distros are provided":                      {req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_SetSparse{SetSparse: true}}, wantErr: true},
+		"Error when the distro is not in the database":            {distros: []string{distro1, "NotInDatabase"}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_SetSparse{SetSparse: true}}, wantErr: true},
+		"Error when moving multiple distros":                      {distros: []string{distro1, distro2}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_Move{Move: `D:\WSL`}}, wantErr: true},
+		"Error when moving a distro nowhere":                      {distros: []string{distro1}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_Move{}}, wantErr: true},
+		"Error when the WSL version is not valid":                 {distros: []string{distro1}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_SetVersion{SetVersion: 3}}, wantErr: true},
+		"Error when WSL is too old for the operation":             {distros: []string{distro1}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_Move{Move: `D:\WSL`}}, wslInfo: &wslversion.Info{Version: "2.2.4.0", Channel: wslversion.ChannelStore}, wantErr: true},
+		"Error when the WSL version is unknown for the operation": {distros: []string{distro1}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_SetSparse{SetSparse: true}}, wslInfo: &wslversion.Info{Channel: wslversion.ChannelInbox}, wantErr: true},
+		"Error when WSL could not be found":                       {distros: []string{distro1}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_SetVersion{SetVersion: 2}}, wslInfo: &wslversion.Info{}, wantErr: true},
+		"Error when a distro runs on WSL 1 for the operation":     {distros: []string{distro1, distro2}, req: &agentapi.ManageDistroRequest{Operation: &agentapi.ManageDistroRequest_SetSparse{SetSparse: true}}, wsl1: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			for _, n := range []string{distro1, distro2} {
+				props := distro.Properties{}
+				if tc.wsl1 && n == distro1 {
+					props.WslVersion = 1
+				}
+				d, err := db.GetDistroAndUpdateProperties(ctx, n, props)
+				require.NoError(t, err, "Setup: could not add %q to database", n)
+				defer d.Cleanup(ctx)
+			}
+
+			wslInfo := recentWsl
+			if tc.wslInfo != nil {
+				wslInfo = *tc.wslInfo
+			}
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslInfo)
+
+			tc.req.Distros = tc.distros
+			_, err = service.ManageDistro(ctx, tc.req)
+			if tc.wantErr {
+				require.Error(t, err, "ManageDistro should return an error")
+				for _, n := range []string{distro1, distro2} {
+					out, _ := os.ReadFile(filepath.Join(dir, n+".tasks"))
+					for _, task := range []string{"SetSparse", "MoveDistro", "SetWslVersion"} {
+						require.NotContains(t, string(out), task, "No task should have been submitted to %q", n)
+					}
+				}
+				return
+			}
+			require.NoError(t, err, "ManageDistro should return no errors")
+
+			// The distros are stopped, so the tasks run on the host right away and end up in their history.
+			for _, n := range tc.distros {
+				d, ok := db.Get(n)
+				require.True(t, ok, "Distro %q should be in the database", n)
+				require.Eventually(t, func() bool {
+					return slices.ContainsFunc(d.TaskHistory(), func(r worker.TaskRecord) bool {
+						return strings.HasSuffix(r.Type, "."+tc.wantTask)
+					})
+				}, 10*time.Second, 100*time.Millisecond, "The task should have run for %q", n)
+			}
+		})
+	}
+}
+
+// Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+//
+//nolint:tparallel
+func TestGetComplianceReport(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	patched, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	pending, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	unknown, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		noDistros bool
+
+		want *agentapi.ComplianceReport
+	}{
+		"Success with no distros": {noDistros: true, want: &agentapi.ComplianceReport{}},
+		"Success aggregating distros": {want: &agentapi.ComplianceReport{
+			Total: 3, FullyPatched: 1, PendingSecurity: 1, PendingEsm: 1, Unknown: 1,
+		}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			if !tc.noDistros {
+				for n, sec := range map[string]distro.SecurityStatus{
+					patched: {Known: true},
+					pending: {Known: true, StandardUpdates: 4, ESMUpdates: 2},
+					unknown: {},
+				} {
+					d, err := db.GetDistroAndUpdateProperties(ctx, n, distro.Properties{Security: sec})
+					require.NoError(t, err, "Setup: could not add %q to database", n)
+					defer d.Cleanup(ctx)
+				}
+			}
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			got, err := service.GetComplianceReport(ctx, &agentapi.Empty{})
+			require.NoError(t, err, "GetComplianceReport should return no errors")
+
+			require.Equal(t, tc.want.GetTotal(), got.GetTotal(), "Mismatched total")
+			require.Equal(t, tc.want.GetFullyPatched(), got.GetFullyPatched(), "Mismatched count of fully patched distros")
+			require.Equal(t, tc.want.GetPendingSecurity(), got.GetPendingSecurity(), "Mismatched count of distros with pending security updates")
+			require.Equal(t, tc.want.GetPendingEsm(), got.GetPendingEsm(), "Mismatched count of distros with pending ESM updates")
+			require.Equal(t, tc.want.GetUnknown(), got.GetUnknown(), "Mismatched count of distros with unknown status")
+			require.Len(t, got.GetDistros(), int(tc.want.GetTotal()), "Every distro should be present in the report")
+
+			for _, d := range got.GetDistros() {
+				switch d.GetDistro() {
+				case patched:
+					require.NotNil(t, d.GetStatus(), "Status should be set for distros that reported it")
+				case pending:
+					require.Equal(t, int32(4), d.GetStatus().GetStandardUpdates(), "Mismatched standard updates")
+					require.Equal(t, int32(2), d.GetStatus().GetEsmUpdates(), "Mismatched ESM updates")
+				case unknown:
+					require.Nil(t, d.GetStatus(), "Status should be unset for distros that never reported it")
+				}
+			}
+		})
+	}
+}
+
+func TestTailLog(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		distro       string
+		lines        int32
+		follow       bool
+		noConnection bool
+		connErr      bool
+		sendErr      bool
+		dailyCap     uint64
+
+		wantLines int32
+		wantErr   bool
+	}{
+		"Success with the default number of lines": {wantLines: 100},
+		"Success with a custom number of lines":    {lines: 3, wantLines: 3},
+		"Success following until cancelled":        {follow: true, wantLines: 100},
+
+		"Error when the distro is not in the database": {distro: "NotInDatabase", wantErr: true},
+		"Error when the number of lines is negative":   {lines: -1, wantErr: true},
+		"Error when the number of lines is too large":  {lines: 1000000, wantErr: true},
+		"Error when the distro is not connected":       {noConnection: true, wantErr: true},
+		"Error when the command fails":                 {connErr: true, wantErr: true},
+		"Error when the lines cannot be sent":          {sendErr: true, wantErr: true},
+		"Error when the daily transfer cap is reached": {dailyCap: 8, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.distro == "" {
+				tc.distro = distroName
+			}
+
+			meter := bandwidth.New()
+			meter.SetDailyCap(tc.dailyCap)
+
+			db, err := database.New(bandwidth.WithMeter(ctx, meter), t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			conn := &mockConnection{err: tc.connErr}
+			if !tc.noConnection {
+				err = d.SetConnection(conn)
+				require.NoError(t, err, "Setup: could not set the distro connection")
+			}
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			streamCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			stream := &mockTailLogStream{ctx: streamCtx, err: tc.sendErr}
+			if tc.follow {
+				// The call is cancelled once the lines before following are sent.
+				stream.onLine = func(lines []string) {
+					if len(lines) == 2 {
+						cancel()
+					}
+				}
+			}
+
+			err = service.TailLog(&agentapi.TailLogRequest{Distro: tc.distro, Lines: tc.lines, Priority: "err", Follow: tc.follow}, stream)
+			if tc.dailyCap > 0 {
+				require.ErrorIs(t, err, bandwidth.ErrCapReached, "TailLog should stop once the cap is reached")
+				require.Equal(t, []string{"line 1"}, stream.lines, "TailLog should only send the lines within the cap")
+			}
+			if tc.wantErr {
+				require.Error(t, err, "TailLog should return an error")
+				return
+			}
+			require.NoError(t, err, "TailLog should return no errors")
+
+			require.Equal(t, tc.wantLines, conn.gotTail.GetLines(), "Mismatched number of lines requested to the distro")
+			require.Equal(t, "err", conn.gotTail.GetPriority(), "Mismatched priority requested to the distro")
+			require.Equal(t, tc.follow, conn.gotTail.GetFollow(), "Mismatched following requested to the distro")
+			require.Equal(t, []string{"line 1", "line 2"}, stream.lines, "Mismatched log lines")
+			require.Equal(t, uint64(len("line 1")+len("line 2")), meter.Usage(distroName).Today[bandwidth.KindLogs], "The lines streamed should be accounted for")
+		})
+	}
+}
+
+func TestWatchTasks(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		distro        string
+		taskErr       bool
+		sendErr       bool
+		cleanupDistro bool
+
+		wantEvents []agentapi.TaskEventType
+		wantErr    bool
+	}{
+		"Success watching a task that completes": {wantEvents: []agentapi.TaskEventType{
+			agentapi.TaskEventType_TASK_EVENT_QUEUED,
+			agentapi.TaskEventType_TASK_EVENT_STARTED,
+			agentapi.TaskEventType_TASK_EVENT_COMPLETED,
+		}},
+		"Success watching a task that fails": {taskErr: true, wantEvents: []agentapi.TaskEventType{
+			agentapi.TaskEventType_TASK_EVENT_QUEUED,
+			agentapi.TaskEventType_TASK_EVENT_STARTED,
+			agentapi.TaskEventType_TASK_EVENT_FAILED,
+		}},
+		"Success stops streaming when the distro is cleaned up": {cleanupDistro: true},
+
+		"Error when the distro is not in the database": {distro: "NotInDatabase", wantErr: true},
+		"Error when the events cannot be sent":         {sendErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.distro == "" {
+				tc.distro = distroName
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to database", distroName)
+			defer d.Cleanup(ctx)
+
+			err = d.SetConnection(&mockConnection{})
+			require.NoError(t, err, "Setup: could not set the distro connection")
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			streamCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			stream := &mockWatchTasksStream{ctx: streamCtx, err: tc.sendErr, events: make(chan *agentapi.TaskEvent, 10)}
+			done := make(chan error)
+			go func() {
+				done <- service.WatchTasks(&agentapi.WatchTasksRequest{Distro: tc.distro}, stream)
+			}()
+
+			// Give time for the watch to start before generating events.
+			time.Sleep(500 * time.Millisecond)
+
+			if tc.cleanupDistro {
+				d.Cleanup(ctx)
+			} else if tc.distro == distroName {
+				err = d.SubmitTasks(&mockTask{err: tc.taskErr})
+				require.NoError(t, err, "Setup: could not submit task")
+			}
+
+			var got []agentapi.TaskEventType
+			for range tc.wantEvents {
+				select {
+				case ev := <-stream.events:
+					require.Equal(t, "Mock task", ev.GetTask(), "Mismatched task description")
+					got = append(got, ev.GetType())
+				case <-time.After(20 * time.Second):
+					require.Fail(t, "Timed out waiting for task events", "Received so far: %v", got)
+				}
+			}
+			require.Equal(t, tc.wantEvents, got, "Mismatched task events")
+
+			cancel()
+
+			select {
+			case err = <-done:
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "WatchTasks should have returned after the stream was cancelled")
+			}
+
+			if tc.wantErr {
+				require.Error(t, err, "WatchTasks should return an error")
+				return
+			}
+			require.NoError(t, err, "WatchTasks should return no errors")
+		})
+	}
+}
+
+func TestGetLatencies(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	pinged, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	failing, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	neverPinged, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		noDistros bool
+	}{
+		"Success with no distros":        {noDistros: true},
+		"Success reporting every distro": {},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			if !tc.noDistros {
+				for n, conn := range map[string]*mockConnection{
+					pinged:      {},
+					failing:     {err: true},
+					neverPinged: nil,
+				} {
+					d, err := db.GetDistroAndUpdateProperties(ctx, n, distro.Properties{})
+					require.NoError(t, err, "Setup: could not add %q to database", n)
+					defer d.Cleanup(ctx)
+
+					if conn == nil {
+						continue
+					}
+
+					err = d.SetConnection(conn)
+					require.NoError(t, err, "Setup: could not set the distro connection")
+					_, err = d.Ping(ctx)
+					require.Equal(t, conn.err, err != nil, "Setup: unexpected result pinging the distro")
+				}
+			}
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			got, err := service.GetLatencies(ctx, &agentapi.Empty{})
+			require.NoError(t, err, "GetLatencies should return no errors")
+
+			if tc.noDistros {
+				require.Empty(t, got.GetDistros(), "No latencies should be reported without distros")
+				return
+			}
+			require.Len(t, got.GetDistros(), 3, "Every distro should be present in the report")
+
+			for _, l := range got.GetDistros() {
+				switch l.GetDistro() {
+				case pinged:
+					require.Equal(t, int32(1), l.GetSamples(), "Mismatched number of samples")
+					require.Zero(t, l.GetFailures(), "Mismatched number of failures")
+					require.Equal(t, l.GetLastMs(), l.GetMeanMs(), "Mean should match the only sample")
+					require.NotEmpty(t, l.GetLastProbe(), "The time of the last probe should be reported")
+					require.Empty(t, l.GetLastError(), "No error should be reported")
+				case failing:
+					require.Zero(t, l.GetSamples(), "Mismatched number of samples")
+					require.Equal(t, int32(1), l.GetFailures(), "Mismatched number of failures")
+					require.NotEmpty(t, l.GetLastProbe(), "The time of the last probe should be reported")
+					require.NotEmpty(t, l.GetLastError(), "The error of the last probe should be reported")
+				case neverPinged:
+					require.Zero(t, l.GetSamples(), "Mismatched number of samples")
+					require.Empty(t, l.GetLastProbe(), "The time of the last probe should be unset")
+				default:
+					require.Fail(t, "Unexpected distro in the report", l.GetDistro())
+				}
+			}
+		})
+	}
+}
+
+func TestGetBandwidth(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	busy, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	idle, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	testCases := map[string]struct {
+		noDistros bool
+		noMeter   bool
+	}{
+		"Success with no distros":                  {noDistros: true},
+		"Success reporting every distro":           {},
+		"Success reporting no usage without meter": {noMeter: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := ctx
+			if !tc.noMeter {
+				meter := bandwidth.New()
+				meter.SetDailyCap(1000)
+				ctx = bandwidth.WithMeter(ctx, meter)
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			if !tc.noDistros {
+				for _, n := range []string{busy, idle} {
+					d, err := db.GetDistroAndUpdateProperties(ctx, n, distro.Properties{})
+					require.NoError(t, err, "Setup: could not add %q to database", n)
+					defer d.Cleanup(ctx)
+
+					if n == busy {
+						d.RecordTransfer(bandwidth.KindLogs, 100)
+						require.NoError(t, d.ReserveTransfer(bandwidth.KindDebs, 200), "Setup: could not reserve a transfer")
+					}
+				}
+			}
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			got, err := service.GetBandwidth(ctx, &agentapi.Empty{})
+			require.NoError(t, err, "GetBandwidth should return no errors")
+
+			if tc.noDistros {
+				require.Empty(t, got.GetDistros(), "No usage should be reported without distros")
+				return
+			}
+			require.Len(t, got.GetDistros(), 2, "Every distro should be present in the report")
+
+			for _, b := range got.GetDistros() {
+				require.Len(t, b.GetTransfers(), len(bandwidth.Kinds), "Every kind of transfer should be reported")
+
+				var want map[string]uint64
+				switch b.GetDistro() {
+				case busy:
+					if !tc.noMeter {
+						want = map[string]uint64{"logs": 100, "debs": 200}
+					}
+				case idle:
+				default:
+					require.Fail(t, "Unexpected distro in the report", b.GetDistro())
+				}
+
+				var today uint64
+				for _, u := range b.GetTransfers() {
+					require.Equal(t, want[u.GetKind()], u.GetTodayBytes(), "Mismatched bytes transferred today for %s", u.GetKind())
+					require.Equal(t, want[u.GetKind()], u.GetTotalBytes(), "Mismatched bytes transferred in total for %s", u.GetKind())
+					today += u.GetTodayBytes()
+				}
+				require.Equal(t, today, b.GetTodayBytes(), "The bytes transferred today should add up every kind")
+
+				if tc.noMeter {
+					require.Zero(t, b.GetDailyCapBytes(), "No cap should be reported without meter")
+				} else {
+					require.Equal(t, uint64(1000), b.GetDailyCapBytes(), "Mismatched daily cap")
+				}
+			}
+		})
+	}
+}
+
+func TestGetInventory(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	connected, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	disconnected, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	lastSeen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := map[string]struct {
+		noDistros       bool
+		landscapeUIDErr bool
+
+		wantErr bool
+	}{
+		"Success with no distros":        {noDistros: true},
+		"Success reporting every distro": {},
+
+		"Error when the Landscape UID cannot be read": {landscapeUIDErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			if !tc.noDistros {
+				for _, n := range []string{connected, disconnected} {
+					d, err := db.GetDistroAndUpdateProperties(ctx, n, distro.Properties{VersionID: "24.04", ProAttached: true})
+					require.NoError(t, err, "Setup: could not add %q to database", n)
+					defer d.Cleanup(ctx)
+					d.SetLastSeen(lastSeen)
+
+					if n == connected {
+						err = d.SetConnection(&mockConnection{})
+						require.NoError(t, err, "Setup: could not set the distro connection")
+						continue
+					}
+
+					// Deferred tasks wait for the distro to connect.
+					err = d.SubmitDeferredTasks(&mockTask{})
+					require.NoError(t, err, "Setup: could not submit a task to the distro")
+				}
+			}
+
+			conf := &mockConfig{landscapeUID: "landscape-uid", landscapeUIDErr: tc.landscapeUIDErr}
+			service := ui.New(ctx, conf, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			got, err := service.GetInventory(ctx, &agentapi.Empty{})
+			if tc.wantErr {
+				require.Error(t, err, "GetInventory should return an error")
+				return
+			}
+			require.NoError(t, err, "GetInventory should return no errors")
+
+			if tc.noDistros {
+				require.Empty(t, got.GetRecords(), "No records should be reported without distros")
+				return
+			}
+			require.Len(t, got.GetRecords(), 2, "Every distro should be present in the inventory")
+
+			hostname, err := os.Hostname()
+			require.NoError(t, err, "Setup: could not get the hostname")
+
+			for _, r := range got.GetRecords() {
+				require.Equal(t, hostname, r.GetMachine(), "Records should carry the hostname of the machine")
+				require.Equal(t, "landscape-uid", r.GetLandscapeId(), "Records should carry the Landscape UID of the machine")
+				require.Equal(t, "24.04", r.GetUbuntuVersion(), "Records should carry the Ubuntu version of the distro")
+				require.True(t, r.GetProAttached(), "Records should carry the Pro status of the distro")
+
+				seen, err := time.Parse(time.RFC3339, r.GetLastSeen())
+				require.NoError(t, err, "The time the distro was last seen should be in RFC3339 format")
+
+				switch r.GetDistro() {
+				case connected:
+					require.WithinDuration(t, time.Now(), seen, time.Minute, "Connected distros should be reported as seen right now")
+					require.Zero(t, r.GetPendingTasks(), "Records should carry the number of tasks queued for the distro")
+					require.True(t, r.GetRunning(), "Connected distros should be reported as running")
+				case disconnected:
+					require.Equal(t, lastSeen, seen, "Disconnected distros should be reported as last seen when they were last heard from")
+					require.EqualValues(t, 1, r.GetPendingTasks(), "Records should carry the number of tasks queued for the distro")
+					require.False(t, r.GetRunning(), "Stopped distros should not be reported as running")
+				default:
+					require.Fail(t, "Unexpected distro in the inventory", r.GetDistro())
+				}
+			}
+		})
+	}
+}
+
+// Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+//
+//nolint:tparallel
+func TestGetSubscriptionDetails(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	const token = "CHx_ProToken"
+
+	// Distros attached with the subscription, one of which has not refreshed its status since the contract was renewed.
+	renewed, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	notRefreshed, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	// A distro that is not attached.
+	notAttached, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	// A distro attached with the subscription of its distro group.
+	grouped, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	distros := map[string]distro.Properties{
+		renewed:      {ProAttached: true, ProServices: []string{"esm-infra", "esm-apps"}, ProExpires: "2030-01-01T00:00:00Z", ProSupportLevel: "essential"},
+		notRefreshed: {ProAttached: true, ProServices: []string{"esm-infra", "livepatch"}, ProExpires: "2029-01-01T00:00:00Z"},
+		notAttached:  {ProServices: []string{"fips"}},
+		grouped:      {ProAttached: true, ProServices: []string{"realtime-kernel"}, ProExpires: "2031-01-01T00:00:00Z", ProSupportLevel: "advanced"},
+	}
+
+	testCases := map[string]struct {
+		token                   string
+		breakConfig             bool
+		unreachableContractsSrv bool
+
+		wantEntitlements map[string]bool
+		wantSupportLevel string
+		wantExpires      string
+		wantErr          bool
+	}{
+		"Success": {
+			token:            token,
+			wantEntitlements: map[string]bool{"esm-infra": true, "esm-apps": true, "livepatch": true, "fips": false},
+			wantSupportLevel: contractsmockserver.DefaultSupportLevel,
+			wantExpires:      contractsmockserver.DefaultContractExpiry.Format(time.RFC3339),
+		},
+		"Success with no subscription": {},
+		"Success falling back on the attached distros when the contract server cannot be reached": {
+			token:                   token,
+			unreachableContractsSrv: true,
+			wantEntitlements:        map[string]bool{"esm-apps": true, "esm-infra": true, "livepatch": true},
+			wantSupportLevel:        "essential",
+			wantExpires:             "2030-01-01T00:00:00Z",
+		},
+
+		"Error when the subscription cannot be read": {token: token, breakConfig: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			for name, props := range distros {
+				d, err := db.GetDistroAndUpdateProperties(ctx, name, props)
+				require.NoError(t, err, "Setup: could not add %q to database", name)
+				defer d.Cleanup(ctx)
+			}
+
+			conf := &mockConfig{
+				token:           tc.token,
+				proSource:       config.SourceUser,
+				subscriptionErr: tc.breakConfig,
+				groupTokens:     map[string]string{grouped: "GROUP_TOKEN"},
+			}
+
+			settings := contractsmockserver.DefaultSettings()
+			settings.Contract.Disabled = tc.unreachableContractsSrv
+			server := contractsmockserver.NewServer(settings)
+			err = server.Serve(ctx, "localhost:0")
+			require.NoError(t, err, "Setup: Server should return no error")
+			//nolint:errcheck // Nothing we can do about it
+			defer server.Stop()
+
+			u, err := url.Parse(fmt.Sprintf("http://%s", server.Address()))
+			require.NoError(t, err, "Setup: Server URL should have been parsed with no issues")
+
+			service := ui.New(ctx, conf, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{}, contracts.WithProURL(u))
+			got, err := service.GetSubscriptionDetails(ctx, &agentapi.Empty{})
+			if tc.wantErr {
+				require.Error(t, err, "GetSubscriptionDetails should return an error")
+				return
+			}
+			require.NoError(t, err, "GetSubscriptionDetails should return no errors")
+
+			var entitlements map[string]bool
+			for _, e := range got.GetEntitlements() {
+				if entitlements == nil {
+					entitlements = make(map[string]bool)
+				}
+				entitlements[e.GetName()] = e.GetEntitled()
+			}
+			require.Equal(t, tc.wantEntitlements, entitlements, "Mismatched entitlements")
+			require.Equal(t, tc.wantSupportLevel, got.GetSupportLevel(), "Mismatched support level")
+			require.Equal(t, tc.wantExpires, got.GetExpires(), "Mismatched expiry")
+		})
+	}
+}
+
+func TestNotificationSettings(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stored    string
+		frequency string
+		getErr    bool
+		setErr    bool
+
+		wantBefore string
+		wantStored string
+		wantGetErr bool
+		wantSetErr bool
+	}{
+		"Success setting a frequency":                 {frequency: "daily", wantBefore: "hourly", wantStored: "daily"},
+		"Success setting an empty frequency":          {stored: "never", wantBefore: "never", wantStored: "hourly"},
+		"Success reporting the default for bad value": {stored: "weekly", frequency: "immediate", wantBefore: "hourly", wantStored: "immediate"},
+
+		"Error getting the frequency":         {getErr: true, frequency: "daily", wantGetErr: true, wantStored: "daily"},
+		"Error setting an unknown frequency":  {frequency: "weekly", wantBefore: "hourly", wantSetErr: true},
+		"Error when the config cannot be set": {frequency: "daily", setErr: true, wantBefore: "hourly", wantSetErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			conf := &mockConfig{
+				notificationFrequency:       tc.stored,
+				notificationFrequencyErr:    tc.getErr,
+				setNotificationFrequencyErr: tc.setErr,
+			}
+			service := ui.New(ctx, conf, nil, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			got, err := service.GetNotificationSettings(ctx, &agentapi.Empty{})
+			if tc.wantGetErr {
+				require.Error(t, err, "GetNotificationSettings should return an error")
+			} else {
+				require.NoError(t, err, "GetNotificationSettings should return no error")
+				require.Equal(t, tc.wantBefore, got.GetFrequency(), "Mismatched notification frequency")
+			}
+
+			_, err = service.SetNotificationSettings(ctx, &agentapi.NotificationSettings{Frequency: tc.frequency})
+			if tc.wantSetErr {
+				require.Error(t, err, "SetNotificationSettings should return an error")
+				return
+			}
+			require.NoError(t, err, "SetNotificationSettings should return no error")
+			require.Equal(t, tc.wantStored, conf.notificationFrequency, "Mismatched stored notification frequency")
+		})
+	}
+}
+
+func TestGetEvents(t *testing.T) {
+	t.Parallel()
+
+	// The tokens handed out by the journal are opaque: these stand for the token pointing to the
+	// second event and to the latest one.
+	const (
+		secondToken = "SECOND"
+		latestToken = "LATEST"
+	)
+
+	testCases := map[string]struct {
+		token     string
+		noJournal bool
+
+		wantTypes     []agentapi.AgentEventType
+		wantTruncated bool
+		wantErr       bool
+	}{
+		"Success getting every event": {wantTypes: []agentapi.AgentEventType{
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_ADDED,
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_STAGE_CHANGED,
+			agentapi.AgentEventType_AGENT_EVENT_TASK_FAILED,
+			agentapi.AgentEventType_AGENT_EVENT_TASK_COMPLETED,
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_RENAMED,
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_REMOVED,
+		}},
+		"Success getting the events since a token": {token: secondToken, wantTypes: []agentapi.AgentEventType{
+			agentapi.AgentEventType_AGENT_EVENT_TASK_FAILED,
+			agentapi.AgentEventType_AGENT_EVENT_TASK_COMPLETED,
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_RENAMED,
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_REMOVED,
+		}},
+		"Success with an up to date token": {token: latestToken},
+		"Success with a token from a lost journal": {token: "lost-journal:2", wantTypes: []agentapi.AgentEventType{
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_ADDED,
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_STAGE_CHANGED,
+			agentapi.AgentEventType_AGENT_EVENT_TASK_FAILED,
+			agentapi.AgentEventType_AGENT_EVENT_TASK_COMPLETED,
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_RENAMED,
+			agentapi.AgentEventType_AGENT_EVENT_DISTRO_REMOVED,
+		}, wantTruncated: true},
+
+		"Error with a malformed token": {token: "not-a-token", wantErr: true},
+		"Error without a journal":      {noJournal: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			events, err := journal.New(ctx, filepath.Join(t.TempDir(), "events.journal"))
+			require.NoError(t, err, "Setup: could not create the journal")
+			defer events.Close()
+
+			events.Record(ctx, journal.DistroAdded, "Ubuntu", "")
+			events.Record(ctx, journal.DistroStageChanged, "Ubuntu", "provisioned")
+			_, second, _, err := events.Since("")
+			require.NoError(t, err, "Setup: could not get the token of the second event")
+			events.RecordTaskFailure(ctx, "Ubuntu", "mock task: mock error", []task.Step{
+				{Name: "first", Status: task.StepSucceeded},
+				{Name: "second", Status: task.StepFailed, Message: "mock error"},
+			})
+			events.RecordTaskCompletion(ctx, "Ubuntu", "mock task", []task.Step{
+				{Name: "first", Status: task.StepSucceeded},
+				{Name: "second", Status: task.StepSucceeded},
+			})
+			events.Record(ctx, journal.DistroRenamed, "Ubuntu", "Ubuntu-Old")
+			events.Record(ctx, journal.DistroRemoved, "Ubuntu", "")
+			_, latest, _, err := events.Since("")
+			require.NoError(t, err, "Setup: could not get the token of the latest event")
+
+			token := tc.token
+			switch token {
+			case secondToken:
+				token = second
+			case latestToken:
+				token = latest
+			}
+
+			var j ui.Journal = events
+			if tc.noJournal {
+				j = nil
+			}
+			service := ui.New(ctx, &mockConfig{}, nil, j, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			got, err := service.GetEvents(ctx, &agentapi.GetEventsRequest{SinceToken: token})
+			if tc.wantErr {
+				require.Error(t, err, "GetEvents should return an error")
+				return
+			}
+			require.NoError(t, err, "GetEvents should return no error")
+
+			var gotTypes []agentapi.AgentEventType
+			for _, ev := range got.GetEvents() {
+				require.Equal(t, "Ubuntu", ev.GetDistro(), "Mismatched distro of the event")
+				_, err := time.Parse(time.RFC3339, ev.GetTime())
+				require.NoError(t, err, "Event time should be in RFC3339 format")
+				gotTypes = append(gotTypes, ev.GetType())
+
+				var wantSteps []agentapi.TaskStepStatus
+				switch ev.GetType() {
+				case agentapi.AgentEventType_AGENT_EVENT_TASK_FAILED:
+					wantSteps = []agentapi.TaskStepStatus{agentapi.TaskStepStatus_TASK_STEP_SUCCEEDED, agentapi.TaskStepStatus_TASK_STEP_FAILED}
+				case agentapi.AgentEventType_AGENT_EVENT_TASK_COMPLETED:
+					wantSteps = []agentapi.TaskStepStatus{agentapi.TaskStepStatus_TASK_STEP_SUCCEEDED, agentapi.TaskStepStatus_TASK_STEP_SUCCEEDED}
+				default:
+					require.Nil(t, ev.GetResult(), "Only finished tasks should have a result")
+					continue
+				}
+
+				var gotSteps []agentapi.TaskStepStatus
+				for _, s := range ev.GetResult().GetSteps() {
+					gotSteps = append(gotSteps, s.GetStatus())
+				}
+				require.Equal(t, wantSteps, gotSteps, "Mismatched outcome of the steps of the %s event", ev.GetType())
+			}
+
+			require.Equal(t, tc.wantTypes, gotTypes, "Mismatched events")
+			require.Equal(t, latest, got.GetNextToken(), "Next token should point to the latest event")
+			require.Equal(t, tc.wantTruncated, got.GetTruncated(), "Mismatched truncation")
+		})
+	}
+}
+
+func TestConsent(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		grant    bool
+		badID    bool
+		sendErr  bool
+		noBroker bool
+
+		wantWatchErr  bool
+		wantAnswerErr bool
+	}{
+		"Success granting a request for consent": {grant: true},
+		"Success denying a request for consent":  {},
+
+		"Error answering a request that is not pending": {badID: true, wantAnswerErr: true},
+		"Error when the requests cannot be sent":        {sendErr: true, wantWatchErr: true},
+		"Error without a consent broker":                {noBroker: true, wantWatchErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			broker := consent.New(consent.Policy{Timeout: time.Minute})
+			var c ui.Consent = broker
+			if tc.noBroker {
+				c = nil
+			}
+			service := ui.New(ctx, &mockConfig{}, nil, nil, c, nil, t.TempDir(), "", wslversion.Info{})
+
+			type result struct {
+				granted bool
+				err     error
+			}
+			asked := make(chan result, 1)
+			go func() {
+				granted, err := broker.Ask(ctx, "Ubuntu", "mock prompt")
+				asked <- result{granted, err}
+			}()
+
+			stream := &mockWatchConsentStream{ctx: ctx, err: tc.sendErr, requests: make(chan *agentapi.ConsentRequest, 10)}
+			done := make(chan error, 1)
+			go func() {
+				done <- service.WatchConsent(&agentapi.Empty{}, stream)
+			}()
+
+			if tc.wantWatchErr {
+				select {
+				case err := <-done:
+					require.Error(t, err, "WatchConsent should return an error")
+				case <-time.After(10 * time.Second):
+					require.Fail(t, "WatchConsent should have returned an error")
+				}
+
+				if tc.noBroker {
+					_, err := service.AnswerConsent(ctx, &agentapi.ConsentAnswer{Id: "any-request"})
+					require.Error(t, err, "AnswerConsent should return an error")
+				}
+				return
+			}
+
+			var req *agentapi.ConsentRequest
+			select {
+			case req = <-stream.requests:
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "Timed out waiting for the request for consent")
+			}
+			require.Equal(t, "Ubuntu", req.GetDistro(), "Mismatched distro of the request")
+			require.Equal(t, "mock prompt", req.GetPrompt(), "Mismatched prompt of the request")
+			_, err := time.Parse(time.RFC3339, req.GetDeadline())
+			require.NoError(t, err, "Deadline should be in RFC3339 format")
+
+			id := req.GetId()
+			if tc.badID {
+				id = "not-a-request"
+			}
+
+			_, err = service.AnswerConsent(ctx, &agentapi.ConsentAnswer{Id: id, Granted: tc.grant})
+			if tc.wantAnswerErr {
+				require.Error(t, err, "AnswerConsent should return an error")
+				return
+			}
+			require.NoError(t, err, "AnswerConsent should return no error")
+
+			select {
+			case got := <-asked:
+				require.NoError(t, got.err, "Ask should return no error")
+				require.Equal(t, tc.grant, got.granted, "The answer should be relayed to the task asking for consent")
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "The answer should have been relayed to the task asking for consent")
+			}
+
+			cancel()
+			select {
+			case err := <-done:
+				require.NoError(t, err, "WatchConsent should return no error")
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "WatchConsent should have returned after the stream was cancelled")
+			}
+		})
+	}
+}
+
+// Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+//
+//nolint:tparallel
+func TestSummary(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	connected, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	pending, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	degraded, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	recentWsl := wslversion.Info{Version: "2.3.24.0", KernelVersion: "5.15.153.1-2", Channel: wslversion.ChannelStore}
+
+	testCases := map[string]struct {
+		noDistros       bool
+		wslInfo         wslversion.Info
+		subscriptionErr bool
+		sendErr         bool
+
+		want         *agentapi.Summary
+		wantDegraded bool
+		wantErr      bool
+	}{
+		"Success with no distros":               {noDistros: true, wslInfo: recentWsl, want: &agentapi.Summary{}},
+		"Success aggregating distros":           {wslInfo: recentWsl, want: &agentapi.Summary{Distros: 3, Connected: 2, PendingUpdates: 1, Errors: 1}},
+		"Success with a WSL too old":            {noDistros: true, wslInfo: wslversion.Info{Version: "1.2.5.0", Channel: wslversion.ChannelStore}, want: &agentapi.Summary{}, wantDegraded: true},
+		"Success with a WSL of unknown version": {noDistros: true, wslInfo: wslversion.Info{Channel: wslversion.ChannelInbox}, want: &agentapi.Summary{}, wantDegraded: true},
+
+		"Error when the subscription cannot be read": {subscriptionErr: true, wantErr: true},
+		"Error when the summary cannot be sent":      {sendErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			var distros []*distro.Distro
+			if !tc.noDistros {
+				for _, n := range []string{connected, pending, degraded} {
+					var props distro.Properties
+					if n == pending {
+						props.Security = distro.SecurityStatus{Known: true, ESMUpdates: 3}
+					}
+
+					d, err := db.GetDistroAndUpdateProperties(ctx, n, props)
+					require.NoError(t, err, "Setup: could not add %q to database", n)
+					defer d.Cleanup(ctx)
+					distros = append(distros, d)
+
+					if n == pending {
+						continue
+					}
+					err = d.SetConnection(&mockConnection{})
+					require.NoError(t, err, "Setup: could not set connection for %q", n)
+					if n == degraded {
+						d.SetDegraded(ctx, errors.New("mock error"))
+					}
+				}
+			}
+
+			conf := &mockSummaryConfig{mockConfig: &mockConfig{subscriptionErr: tc.subscriptionErr}}
+			health := contracts.NewHealth()
+			service := ui.New(ctx, conf, db, nil, nil, nil, t.TempDir(), "", tc.wslInfo, contracts.WithHealth(health))
+
+			watchCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			stream := &mockWatchSummaryStream{ctx: watchCtx, err: tc.sendErr, summaries: make(chan *agentapi.Summary, 10)}
+			done, stopped := make(chan error, 1), make(chan struct{})
+			go func() {
+				defer close(stopped)
+				done <- service.WatchSummary(&agentapi.Empty{}, stream)
+			}()
+			// The watcher must stop before the database is closed.
+			defer func() {
+				cancel()
+				<-stopped
+			}()
+
+			got, err := service.GetSummary(ctx, &agentapi.Empty{})
+			if tc.wantErr {
+				if !tc.sendErr {
+					require.Error(t, err, "GetSummary should return an error")
+				}
+				select {
+				case err := <-done:
+					require.Error(t, err, "WatchSummary should return an error")
+				case <-time.After(10 * time.Second):
+					require.Fail(t, "WatchSummary should have returned an error")
+				}
+				return
+			}
+			require.NoError(t, err, "GetSummary should return no error")
+
+			wantSubs := any(subsNone)
+			requireSummary := func(want, got *agentapi.Summary) {
+				t.Helper()
+				require.IsType(t, wantSubs, got.GetSubscription().GetSubscriptionType(), "Mismatched subscription types")
+				require.Equal(t, want.GetDistros(), got.GetDistros(), "Mismatched count of distros")
+				require.Equal(t, want.GetConnected(), got.GetConnected(), "Mismatched count of connected distros")
+				require.Equal(t, want.GetPendingUpdates(), got.GetPendingUpdates(), "Mismatched count of distros with pending updates")
+				require.Equal(t, want.GetErrors(), got.GetErrors(), "Mismatched count of distros with errors")
+				require.Equal(t, tc.wslInfo.Version, got.GetWsl().GetVersion(), "Mismatched WSL version")
+				require.Equal(t, tc.wslInfo.KernelVersion, got.GetWsl().GetKernelVersion(), "Mismatched kernel version")
+				require.Equal(t, tc.wslInfo.Channel, got.GetWsl().GetChannel(), "Mismatched WSL release channel")
+				require.Equal(t, tc.wantDegraded, len(got.GetWsl().GetDegraded()) != 0, "Degraded features should be reported only when WSL is too old or unknown")
+				require.Contains(t, got.GetWsl().GetOperations(), agentapi.DistroOperation_DISTRO_OPERATION_SET_VERSION, "Converting distros should be supported by any WSL")
+			}
+			requireSummary(tc.want, got)
+
+			select {
+			case got = <-stream.summaries:
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "WatchSummary should have sent the current summary")
+			}
+			requireSummary(tc.want, got)
+
+			if !tc.noDistros {
+				// A change in any distro must be sent to the watchers.
+				distros[0].SetProperties(distro.Properties{Security: distro.SecurityStatus{Known: true, StandardUpdates: 1}})
+				tc.want.PendingUpdates++
+
+				select {
+				case got = <-stream.summaries:
+				case <-time.After(10 * time.Second):
+					require.Fail(t, "WatchSummary should have sent the new summary")
+				}
+				requireSummary(tc.want, got)
+			}
+
+			// Unchanged summaries must not be sent again, and the summary is not computed again until notified of a change.
+			conf.setSource(config.SourceUser)
+			require.Never(t, func() bool { return len(stream.summaries) > 0 }, 2*time.Second, 100*time.Millisecond, "WatchSummary should only send a summary when notified of a change")
+
+			service.NotifySubscriptionChanged()
+			wantSubs = subsUser
+			select {
+			case got = <-stream.summaries:
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "WatchSummary should have sent the summary with the new subscription")
+			}
+			requireSummary(tc.want, got)
+			require.Empty(t, got.GetContracts().GetLastContact(), "No contact with the contract server should be reported yet")
+			require.Empty(t, got.GetContracts().GetLastError(), "No error with the contract server should be reported yet")
+
+			// Interactions with the contract server must be sent to the watchers.
+			//nolint:gosec // This is not a real token
+			_, err = contracts.Contract(ctx, "TOKEN", contracts.WithProURL(&url.URL{Scheme: "http", Host: "127.0.0.1:1"}), contracts.WithHealth(health))
+			require.Error(t, err, "Setup: Contract should fail when the contract server is unreachable")
+			select {
+			case got = <-stream.summaries:
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "WatchSummary should have sent the summary with the health of the contract server synchronisation")
+			}
+			requireSummary(tc.want, got)
+			require.Empty(t, got.GetContracts().GetLastEntitlementSync(), "A failed entitlement sync should not be reported as successful")
+			require.NotEmpty(t, got.GetContracts().GetLastError(), "The error with the contract server should be reported")
+			require.NotEmpty(t, got.GetContracts().GetLastErrorTime(), "The time of the error with the contract server should be reported")
+
+			cancel()
+			select {
+			case err := <-done:
+				require.NoError(t, err, "WatchSummary should return no error")
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "WatchSummary should have returned after the stream was cancelled")
+			}
+		})
+	}
+}
+
+func TestProvisionDistro(t *testing.T) {
+	if !wsl.MockAvailable() {
+		t.Skip("This test can only run with the mock")
+	}
+	t.Parallel()
+
+	testCases := map[string]struct {
+		image        string
+		reservedName bool
+		badURL       bool
+		sendErr      bool
+
+		wantErr bool
+	}{
+		"Success provisioning a distro":               {image: "goodfile"},
+		"Success even if the progress cannot be sent": {image: "goodfile", sendErr: true},
+
+		"Error when the distro name is reserved":  {image: "goodfile", reservedName: true, wantErr: true},
+		"Error when the URL is not HTTP":          {image: "goodfile", badURL: true, wantErr: true},
+		"Error when the image cannot be verified": {image: "nochecksum", wantErr: true},
+		"Error when the image cannot be imported": {image: "badfile", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := wsl.WithMock(context.Background(), wslmock.New())
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /releases/goodfile", func(w http.ResponseWriter, r *http.Request) {}) // Empty file
+			mux.HandleFunc("GET /releases/nochecksum", func(w http.ResponseWriter, r *http.Request) {})
+			mux.HandleFunc("GET /releases/badfile", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "MOCK_ERROR") })
+			mux.HandleFunc("GET /releases/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855 *goodfile
+afe55cda4210c2439b47c62c01039027522f7ed4abdb113972b3030b3359532a *badfile`)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			distroName := wsltestutils.RandomDistroName(t)
+			if tc.reservedName {
+				distroName = "Ubuntu"
+			}
+
+			rootfsURL := fmt.Sprintf("%s/releases/%s", server.URL, tc.image)
+			if tc.badURL {
+				rootfsURL = "file:///releases/" + tc.image
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			service := ui.New(ctx, &mockConfig{}, db, nil, nil, nil, t.TempDir(), "", wslversion.Info{})
+
+			stream := &mockProvisionStream{ctx: ctx, err: tc.sendErr}
+			err = service.ProvisionDistro(&agentapi.ProvisionRequest{
+				DistroName: distroName,
+				RootfsUrl:  rootfsURL,
+				InstallDir: filepath.Join(t.TempDir(), distroName),
+			}, stream)
+			if tc.wantErr {
+				require.Error(t, err, "ProvisionDistro should return an error")
+				_, ok := db.Get(distroName)
+				require.False(t, ok, "The distro should not have been added to the database")
+				return
+			}
+			require.NoError(t, err, "ProvisionDistro should return no error")
+
+			d, ok := db.Get(distroName)
+			require.True(t, ok, "The distro should have been added to the database")
+			defer d.Cleanup(ctx)
+
+			if tc.sendErr {
+				return
+			}
+
+			var stages []agentapi.ProvisionStage
+			for _, p := range stream.progress {
+				if len(stages) == 0 || stages[len(stages)-1] != p.GetStage() {
+					stages = append(stages, p.GetStage())
+				}
+			}
+			want := []agentapi.ProvisionStage{
+				agentapi.ProvisionStage_PROVISION_STAGE_DOWNLOADING,
+				agentapi.ProvisionStage_PROVISION_STAGE_IMPORTING,
+				agentapi.ProvisionStage_PROVISION_STAGE_CONFIGURING,
+				agentapi.ProvisionStage_PROVISION_STAGE_DONE,
+			}
+			require.Equal(t, want, stages, "ProvisionDistro should have streamed every stage, in order")
+		})
+	}
+}
+
+func TestGetWslConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		file      string
+		breakFile bool
+
+		want    *agentapi.WslConfig
+		wantErr bool
+	}{
+		"Success reading the settings": {
+			file: "[wsl2]\nmemory=8GB\nnetworkingMode=mirrored\nvmIdleTimeout=-1\n",
+			want: &agentapi.WslConfig{Memory: "8GB", NetworkingMode: "mirrored", VmIdleTimeout: -1},
+		},
+		"Success reporting the settings that fall short of requirements": {
+			file: "[wsl2]\nmemory=1GB\n",
+			want: &agentapi.WslConfig{Memory: "1GB", Problems: []string{"memory 1GB is below the 2GB Pro workloads need"}},
+		},
+		"Success with no file": {want: &agentapi.WslConfig{}},
+
+		"Error when the file cannot be read": {breakFile: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			path := filepath.Join(t.TempDir(), ".wslconfig")
+			if tc.breakFile {
+				require.NoError(t, os.MkdirAll(path, 0700), "Setup: could not create a directory in place of the file")
+			} else if tc.file != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tc.file), 0600), "Setup: could not write the configuration file")
+			}
+
+			service := ui.New(ctx, &mockConfig{}, nil, nil, nil, nil, t.TempDir(), path, wslversion.Info{})
+
+			got, err := service.GetWslConfig(ctx, &agentapi.Empty{})
+			if tc.wantErr {
+				require.Error(t, err, "GetWslConfig should return an error")
+				return
+			}
+			require.NoError(t, err, "GetWslConfig should return no error")
+
+			require.Equal(t, tc.want.GetMemory(), got.GetMemory(), "Mismatched memory")
+			require.Equal(t, tc.want.GetNetworkingMode(), got.GetNetworkingMode(), "Mismatched networking mode")
+			require.Equal(t, tc.want.GetVmIdleTimeout(), got.GetVmIdleTimeout(), "Mismatched VM idle timeout")
+			require.Equal(t, tc.want.GetProblems(), got.GetProblems(), "Mismatched problems")
+		})
+	}
+}
+
+func TestSetWslConfig(t *testing.T) {
+	t.Parallel()
+
+	const file = "[wsl2]\nmemory=4GB\nprocessors=2\n"
+
+	testCases := map[string]struct {
+		settings  *agentapi.WslConfig
+		deny      bool
+		noBroker  bool
+		breakFile bool
+
+		wantAsked bool
+		wantErr   bool
+	}{
+		"Success changing the settings once granted":  {settings: &agentapi.WslConfig{Memory: "8GB", NetworkingMode: "mirrored"}, wantAsked: true},
+		"Success without asking when nothing changes": {settings: &agentapi.WslConfig{Memory: "4gb"}, noBroker: true},
+
+		"Error when the user denies the changes":             {settings: &agentapi.WslConfig{Memory: "8GB"}, deny: true, wantAsked: true, wantErr: true},
+		"Error when the settings fall short of requirements": {settings: &agentapi.WslConfig{Memory: "1GB"}, wantErr: true},
+		"Error when the settings are invalid":                {settings: &agentapi.WslConfig{NetworkingMode: "carrier pigeon"}, wantErr: true},
+		"Error without a consent broker":                     {settings: &agentapi.WslConfig{Memory: "8GB"}, noBroker: true, wantErr: true},
+		"Error when the file cannot be read":                 {settings: &agentapi.WslConfig{Memory: "8GB"}, breakFile: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			path := filepath.Join(t.TempDir(), ".wslconfig")
+			if tc.breakFile {
+				require.NoError(t, os.MkdirAll(path, 0700), "Setup: could not create a directory in place of the file")
+			} else {
+				require.NoError(t, os.WriteFile(path, []byte(file), 0600), "Setup: could not write the configuration file")
+			}
+
+			broker := consent.New(consent.Policy{Timeout: time.Minute})
+			var c ui.Consent = broker
+			if tc.noBroker {
+				c = nil
+			}
+			service := ui.New(ctx, &mockConfig{}, nil, nil, c, nil, t.TempDir(), path, wslversion.Info{})
+
+			asked := make(chan consent.Request, 1)
+			requests := broker.Watch(ctx)
+			go func() {
+				for req := range requests {
+					asked <- req
+					_ = broker.Answer(req.ID, !tc.deny)
+				}
+			}()
+
+			got, err := service.SetWslConfig(ctx, tc.settings)
+
+			select {
+			case req := <-asked:
+				require.True(t, tc.wantAsked, "SetWslConfig should not have asked for consent")
+				require.Empty(t, req.Distro, "The request for consent should not be about a distro")
+			default:
+				require.False(t, tc.wantAsked, "SetWslConfig should have asked for consent")
+			}
+
+			if tc.wantErr {
+				require.Error(t, err, "SetWslConfig should return an error")
+				if !tc.breakFile {
+					contents, err := os.ReadFile(path)
+					require.NoError(t, err, "Setup: could not read the configuration file")
+					require.Equal(t, file, string(contents), "SetWslConfig should not have changed the file")
+				}
+				return
+			}
+			require.NoError(t, err, "SetWslConfig should return no error")
+			require.True(t, strings.EqualFold(tc.settings.GetMemory(), got.GetMemory()), "SetWslConfig should return the new memory")
+			require.Equal(t, tc.settings.GetNetworkingMode(), got.GetNetworkingMode(), "SetWslConfig should return the new networking mode")
+			require.Empty(t, got.GetProblems(), "The new settings should have no problems")
+
+			if !tc.wantAsked {
+				return
+			}
+
+			contents, err := os.ReadFile(path)
+			require.NoError(t, err, "Setup: could not read the configuration file")
+			require.Contains(t, string(contents), "processors=2", "SetWslConfig should have kept the other settings")
+
+			backup, err := os.ReadFile(path + ".bak")
+			require.NoError(t, err, "SetWslConfig should have backed up the previous file")
+			require.Equal(t, file, string(backup), "The backup should be the previous file")
+		})
+	}
+}
+
+// mockSummaryConfig is a mockConfig whose subscription source can be changed while it is in use.
+type mockSummaryConfig struct {
+	*mockConfig
+
+	source config.Source
+	mu     sync.Mutex
+}
+
+func (m *mockSummaryConfig) Subscription() (string, config.Source, error) {
+	if m.subscriptionErr {
+		return "", config.SourceNone, errors.New("Subscription error")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return "", m.source, nil
+}
+
+func (m *mockSummaryConfig) setSource(source config.Source) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.source = source
+}
+
+type mockConnection struct {
+	err bool
+	got *agentapi.Command
+
+	gotTail *agentapi.TailLogCmd
+}
+
+func (c *mockConnection) SendProAttachment(proToken string) error           { return nil }
+func (c *mockConnection) SendLandscapeConfig(lpeConfig string) error        { return nil }
+func (c *mockConnection) InLane(context.Context, task.Lane) task.Connection { return c }
+func (c *mockConnection) Preempt(task.Lane) error                           { return nil }
+func (c *mockConnection) Close()                                            {}
+func (c *mockConnection) SendCommand(cmd *agentapi.Command) ([]byte, error) {
+	if c.err {
+		return nil, errors.New("mock error")
+	}
+	c.got = cmd
+	return nil, nil
+}
+
+func (c *mockConnection) Ping(ctx context.Context, payload []byte) ([]byte, error) {
+	if c.err {
+		return nil, errors.New("mock error")
+	}
+	return payload, nil
+}
+
+func (c *mockConnection) TailLog(ctx context.Context, cmd *agentapi.TailLogCmd, send func(string) error) error {
+	if c.err {
+		return errors.New("mock error")
+	}
+	c.gotTail = cmd
+	for _, line := range []string{"line 1", "line 2"} {
+		if err := send(line); err != nil {
+			return err
+		}
+	}
+	if cmd.GetFollow() {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+type mockTailLogStream struct {
+	grpc.ServerStream
+
+	ctx   context.Context
+	err   bool
+	lines []string
+
+	// onLine is called with the lines sent so far after every line.
+	onLine func(lines []string)
+}
+
+func (s *mockTailLogStream) Context() context.Context { return s.ctx }
+func (s *mockTailLogStream) Send(l *agentapi.LogLine) error {
+	if s.err {
+		return errors.New("mock error")
+	}
+	s.lines = append(s.lines, l.GetLine())
+	if s.onLine != nil {
+		s.onLine(s.lines)
+	}
+	return nil
+}
+
+type mockUsgReportStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	err    bool
+	chunks []*agentapi.UsgReport
+}
+
+func (s *mockUsgReportStream) Context() context.Context { return s.ctx }
+func (s *mockUsgReportStream) Send(r *agentapi.UsgReport) error {
+	if s.err {
+		return errors.New("mock error")
+	}
+	// The service reuses the buffer of the report across chunks.
+	r.Report = slices.Clone(r.GetReport())
+	s.chunks = append(s.chunks, r)
+	return nil
+}
+
+type mockWatchTasksStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	err    bool
+	events chan *agentapi.TaskEvent
+}
+
+func (s *mockWatchTasksStream) Context() context.Context { return s.ctx }
+func (s *mockWatchTasksStream) Send(ev *agentapi.TaskEvent) error {
+	if s.err {
+		return errors.New("mock error")
+	}
+	s.events <- ev
+	return nil
+}
+
+type mockWatchConsentStream struct {
+	grpc.ServerStream
+
+	ctx      context.Context
+	err      bool
+	requests chan *agentapi.ConsentRequest
+}
+
+func (s *mockWatchConsentStream) Context() context.Context { return s.ctx }
+func (s *mockWatchConsentStream) Send(req *agentapi.ConsentRequest) error {
+	if s.err {
+		return errors.New("mock error")
+	}
+	s.requests <- req
+	return nil
+}
+
+type mockWatchSummaryStream struct {
+	grpc.ServerStream
+
+	ctx       context.Context
+	err       bool
+	summaries chan *agentapi.Summary
+}
+
+func (s *mockWatchSummaryStream) Context() context.Context { return s.ctx }
+func (s *mockWatchSummaryStream) Send(summary *agentapi.Summary) error {
+	if s.err {
+		return errors.New("mock error")
+	}
+	s.summaries <- summary
+	return nil
+}
+
+type mockProvisionStream struct {
+	grpc.ServerStream
+
+	ctx      context.Context
+	err      bool
+	progress []*agentapi.ProvisionProgress
+}
+
+func (s *mockProvisionStream) Context() context.Context { return s.ctx }
+func (s *mockProvisionStream) Send(p *agentapi.ProvisionProgress) error {
+	if s.err {
+		return errors.New("mock error")
+	}
+	s.progress = append(s.progress, p)
+	return nil
+}
+
+type mockTask struct {
+	err bool
+}
+
+func (t *mockTask) Execute(context.Context, task.Connection) error {
+	if t.err {
+		return errors.New("mock error")
+	}
+	return nil
+}
+
+func (t *mockTask) String() string { return "Mock task" }
+
 type mockConfig struct {
 	setUserSubscriptionErr    bool // Config errors out in SetUserSubscription function
 	subscriptionErr           bool // Config errors out in Subscription function
 	setUserLandscapeConfigErr bool // Config errors out in SetUserLandscapeConfig function
 	landscapeErr              bool // Config errors out in LandscapeClientConfig function
 
-	token           string        // stores the configured Pro token
-	proSource       config.Source // stores the configured subscription source.
-	landscapeSource config.Source // stores the configured landscape source.
+	token           string            // stores the configured Pro token
+	groupTokens     map[string]string // stores the Pro tokens of the distro groups, by distro name
+	proSource       config.Source     // stores the configured subscription source.
+	landscapeSource config.Source     // stores the configured landscape source.
 
 	returnBadSource    bool
 	gotLandscapeConfig string
+
+	notificationFrequency       string // stores the configured notification frequency
+	notificationFrequencyErr    bool   // Config errors out in NotificationFrequency function
+	setNotificationFrequencyErr bool   // Config errors out in SetNotificationFrequency function
+
+	landscapeUID    string // stores the UID Landscape assigned to the machine
+	landscapeUIDErr bool   // Config errors out in LandscapeAgentUID function
 }
 
 func (m *mockConfig) SetUserSubscription(ctx context.Context, token string) error {
@@ -317,6 +2277,10 @@ func (m *mockConfig) SetUserSubscription(ctx context.Context, token string) erro
 	return nil
 }
 
+func (m *mockConfig) OfflineSubscription() (bool, error) {
+	return false, nil
+}
+
 func (m *mockConfig) SetStoreSubscription(ctx context.Context, token string) error {
 	m.token = token
 	m.proSource = config.SourceMicrosoftStore
@@ -348,6 +2312,13 @@ func (m mockConfig) Subscription() (string, config.Source, error) {
 	return m.token, m.proSource, nil
 }
 
+func (m mockConfig) SubscriptionFor(distroName string) (string, config.Source, error) {
+	if token, ok := m.groupTokens[distroName]; ok {
+		return token, config.SourceDistroGroup, nil
+	}
+	return m.Subscription()
+}
+
 func (m mockConfig) LandscapeClientConfig() (string, config.Source, error) {
 	if m.landscapeErr {
 		return "", config.SourceNone, errors.New("LandscapeClientConfig error")
@@ -358,6 +2329,28 @@ func (m mockConfig) LandscapeClientConfig() (string, config.Source, error) {
 	return "[host]", m.landscapeSource, nil
 }
 
+func (m mockConfig) NotificationFrequency() (string, error) {
+	if m.notificationFrequencyErr {
+		return "", errors.New("NotificationFrequency error")
+	}
+	return m.notificationFrequency, nil
+}
+
+func (m *mockConfig) SetNotificationFrequency(ctx context.Context, frequency string) error {
+	if m.setNotificationFrequencyErr {
+		return errors.New("SetNotificationFrequency error")
+	}
+	m.notificationFrequency = frequency
+	return nil
+}
+
+func (m mockConfig) LandscapeAgentUID() (string, error) {
+	if m.landscapeUIDErr {
+		return "", errors.New("LandscapeAgentUID error")
+	}
+	return m.landscapeUID, nil
+}
+
 //nolint:revive // Testing t comes before the context.
 func setupMockContracts(t *testing.T, ctx context.Context) (opts []contracts.Option, stop func()) {
 	t.Helper()
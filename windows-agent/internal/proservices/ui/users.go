@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/operations"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+	"github.com/ubuntu/decorate"
+)
+
+// userNameRegex matches the user and group names accepted by useradd on Ubuntu.
+var userNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// ManageUser handles the gRPC call to create a user in the selected distros, add it to groups,
+// and optionally make it their default user. Every distro must be known: otherwise nothing is submitted.
+func (s *Service) ManageUser(ctx context.Context, info *agentapi.ManageUserInfo) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: ManageUser")
+
+	name := info.GetName()
+	log.Infof(ctx, "UI service: received request to manage user %q on %d distros", name, len(info.GetDistros()))
+
+	if !userNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid user name %q", name)
+	}
+
+	for _, g := range info.GetGroups() {
+		if !userNameRegex.MatchString(g) {
+			return nil, fmt.Errorf("invalid group name %q", g)
+		}
+	}
+
+	distros, err := s.lookUpDistros(info.GetDistros())
+	if err != nil {
+		return nil, err
+	}
+
+	t := tasks.ManageUser{
+		Name:       name,
+		Groups:     info.GetGroups(),
+		SetDefault: info.GetSetDefault(),
+	}
+
+	if _, err := operations.Submit(ctx, s.operations, "manage-user", name, distros, func(*distro.Distro) task.Task { return t }); err != nil {
+		return nil, err
+	}
+
+	return &agentapi.Empty{}, nil
+}
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/operations"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+	"github.com/ubuntu/decorate"
+)
+
+// usgService is the Ubuntu Pro service that must be available in a distro to apply USG profiles.
+const usgService = "usg"
+
+// usgProfileRegex matches valid USG profile names. It also prevents profiles from escaping the reports directory.
+var usgProfileRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// ApplyUsgProfile handles the gRPC call to audit (and optionally fix) the selected distros against a USG profile.
+// Every distro must have the USG service available: otherwise nothing is submitted.
+func (s *Service) ApplyUsgProfile(ctx context.Context, info *agentapi.UsgProfileInfo) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: ApplyUsgProfile")
+
+	profile := info.GetProfile()
+	log.Infof(ctx, "UI service: received request to apply USG profile %q (fix: %t) on %d distros", profile, info.GetFix(), len(info.GetDistros()))
+
+	if !usgProfileRegex.MatchString(profile) {
+		return nil, fmt.Errorf("invalid USG profile %q", profile)
+	}
+
+	distros, err := s.distrosWithService(info.GetDistros(), usgService)
+	if err != nil {
+		return nil, err
+	}
+
+	action := "audit"
+	if info.GetFix() {
+		action = "fix"
+	}
+
+	_, err = operations.Submit(ctx, s.operations, "usg-profile", action+" "+profile, distros, func(d *distro.Distro) task.Task {
+		return tasks.UsgProfile{
+			Profile:    profile,
+			Fix:        info.GetFix(),
+			ReportPath: s.usgReportPath(d.Name(), profile),
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &agentapi.Empty{}, nil
+}
+
+// usgReportChunkSize is the largest chunk of a USG report sent in a single message, well below the
+// 4MiB that gRPC accepts by default. Reports of real profiles easily exceed that.
+const usgReportChunkSize = 1 << 20
+
+// GetUsgReport handles the gRPC call to stream the latest USG audit report of a distro for a given profile.
+// The report is sent in chunks, the concatenation of which is the whole report.
+func (s *Service) GetUsgReport(req *agentapi.UsgReportRequest, stream agentapi.UI_GetUsgReportServer) (err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetUsgReport")
+
+	ctx := stream.Context()
+	log.Infof(ctx, "UI service: received request for the USG report of distro %q for profile %q", req.GetDistro(), req.GetProfile())
+
+	if !usgProfileRegex.MatchString(req.GetProfile()) {
+		return fmt.Errorf("invalid USG profile %q", req.GetProfile())
+	}
+
+	d, ok := s.db.Get(req.GetDistro())
+	if !ok {
+		return fmt.Errorf("distro %q not found", req.GetDistro())
+	}
+
+	f, err := os.Open(s.usgReportPath(d.Name(), req.GetProfile()))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no report available for profile %q", req.GetProfile())
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Reports are replaced atomically, so the open file is a consistent report even if a new one arrives.
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	buff := make([]byte, usgReportChunkSize)
+	for sent := false; ; sent = true {
+		n, err := io.ReadFull(f, buff)
+		if errors.Is(err, io.EOF) && sent {
+			return nil
+		} else if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		if err := stream.Send(&agentapi.UsgReport{
+			Distro:    d.Name(),
+			Profile:   req.GetProfile(),
+			Timestamp: stat.ModTime().UTC().Format(time.RFC3339),
+			Report:    buff[:n],
+		}); err != nil {
+			return fmt.Errorf("could not send report: %v", err)
+		}
+
+		if n < len(buff) {
+			return nil
+		}
+	}
+}
+
+// usgReportPath returns the path where the USG report of a distro for a given profile is stored.
+func (s *Service) usgReportPath(distroName, profile string) string {
+	return filepath.Join(s.usgReportsDir, distroName, profile+".html")
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslconfig"
+	"github.com/ubuntu/decorate"
+)
+
+// GetWslConfig handles the gRPC call to report the settings of the global WSL configuration file relevant to Pro
+// workloads, along with why they fall short of what these workloads need, if they do.
+func (s *Service) GetWslConfig(ctx context.Context, _ *agentapi.Empty) (_ *agentapi.WslConfig, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetWslConfig")
+
+	log.Info(ctx, "UI service: received GetWslConfig message")
+
+	settings, err := wslconfig.Read(s.wslConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return wslConfigMessage(settings), nil
+}
+
+// SetWslConfig handles the gRPC call to change the settings of the global WSL configuration file relevant to Pro
+// workloads. As they apply to all the distros, including those the agent does not manage, the user is asked for
+// consent through the consent broker before the file is written. The previous file is backed up next to it.
+func (s *Service) SetWslConfig(ctx context.Context, msg *agentapi.WslConfig) (_ *agentapi.WslConfig, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: SetWslConfig")
+
+	log.Infof(ctx, "UI service: received request to set the WSL configuration (memory: %q, networking mode: %q, VM idle timeout: %d)",
+		msg.GetMemory(), msg.GetNetworkingMode(), msg.GetVmIdleTimeout())
+
+	want := wslconfig.Settings{
+		Memory:         msg.GetMemory(),
+		NetworkingMode: msg.GetNetworkingMode(),
+		VMIdleTimeout:  msg.GetVmIdleTimeout(),
+	}
+	if err := want.Validate(); err != nil {
+		return nil, err
+	}
+
+	current, err := wslconfig.Read(s.wslConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := wslConfigChanges(current, want)
+	if len(changes) == 0 {
+		return wslConfigMessage(current), nil
+	}
+
+	if s.consent == nil {
+		return nil, errors.New("no consent broker available")
+	}
+
+	prompt := fmt.Sprintf("change the WSL settings shared by all the distros (%s), which apply once WSL restarts", strings.Join(changes, ", "))
+	granted, err := s.consent.Ask(ctx, "", prompt)
+	if err != nil {
+		return nil, fmt.Errorf("could not get consent: %v", err)
+	}
+	if !granted {
+		return nil, errors.New("the user did not consent to the changes")
+	}
+
+	if err := wslconfig.Write(s.wslConfig, want); err != nil {
+		return nil, err
+	}
+
+	written, err := wslconfig.Read(s.wslConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return wslConfigMessage(written), nil
+}
+
+// wslConfigMessage converts the settings of the global WSL configuration file into their API counterpart.
+func wslConfigMessage(settings wslconfig.Settings) *agentapi.WslConfig {
+	return &agentapi.WslConfig{
+		Memory:         settings.Memory,
+		NetworkingMode: settings.NetworkingMode,
+		VmIdleTimeout:  settings.VMIdleTimeout,
+		Problems:       settings.Problems(),
+	}
+}
+
+// wslConfigChanges describes how the settings change from current to want.
+func wslConfigChanges(current, want wslconfig.Settings) (changes []string) {
+	describe := func(name, from, to string) {
+		if strings.EqualFold(from, to) {
+			return
+		}
+		if from == "" {
+			from = "default"
+		}
+		if to == "" {
+			to = "default"
+		}
+		changes = append(changes, fmt.Sprintf("%s from %s to %s", name, from, to))
+	}
+
+	timeout := func(t int64) string {
+		if t == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%dms", t)
+	}
+
+	describe("memory", current.Memory, want.Memory)
+	describe("networking mode", current.NetworkingMode, want.NetworkingMode)
+	describe("VM idle timeout", timeout(current.VMIdleTimeout), timeout(want.VMIdleTimeout))
+
+	return changes
+}
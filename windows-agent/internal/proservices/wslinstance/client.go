@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/watchdog"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/ubuntu/decorate"
 )
 
@@ -16,8 +21,16 @@ type client struct {
 	ctx     context.Context
 	cancel  context.CancelFunc
 
-	connStream agentapi.WSLInstance_ConnectedServer
+	connStream agentapi.WSLInstance_SessionServer
 	connReady  chan struct{}
+	// capabilities are the features negotiated in the handshake of the Session stream.
+	capabilities []agentapi.Capability
+	// serviceVersion is the version of the WSL Pro service reported in the handshake of the Session stream.
+	serviceVersion string
+	// tooOld is whether the service was found older than the minimum version when it was last checked, and
+	// versionChecked whether it was checked at all. Both are protected by mu.
+	tooOld         bool
+	versionChecked bool
 
 	proStream agentapi.WSLInstance_ProAttachmentCommandsServer
 	proReady  chan struct{}
@@ -25,6 +38,43 @@ type client struct {
 	lpeStream agentapi.WSLInstance_LandscapeConfigCommandsServer
 	lpeReady  chan struct{}
 
+	cmdStream agentapi.WSLInstance_CommandsServer
+	cmdReady  chan struct{}
+	// cmdMu serializes commands, so that each result is matched with the command that caused it.
+	cmdMu sync.Mutex
+	// cmdSendMu serializes sending, as preemptions are sent while a command is in progress.
+	cmdSendMu sync.Mutex
+	// laneCmds are the commands of each lane, whether in progress or waiting for their turn. Preemptions
+	// target the command of a lane, so that they cannot stop the commands of other lanes, and by id, so that
+	// a preemption that arrives late cannot stop the next command of the lane.
+	laneCmds   map[task.Lane]*laneCmd
+	laneCmdsMu sync.Mutex
+	lastCmdID  atomic.Uint32
+
+	// logStream is only opened by the WSL Pro services with CAPABILITY_LOGS, so WaitReady does not wait for it.
+	logStream agentapi.WSLInstance_TailLogServer
+	logReady  chan struct{}
+	// logSendMu serializes sending, as several TailLog requests are served at once.
+	logSendMu sync.Mutex
+	// tails are the TailLog requests in progress, by id.
+	tails      map[uint32]*tail
+	tailsMu    sync.Mutex
+	lastTailID atomic.Uint32
+
+	// pingStream is only opened by the WSL Pro services with CAPABILITY_PING, so WaitReady does not wait for it.
+	pingStream agentapi.WSLInstance_PingServer
+	pingReady  chan struct{}
+	// pingSendMu serializes sending, as several pings can be in flight at once.
+	pingSendMu sync.Mutex
+	// pings are the channels the replies to the pings in flight are sent to, by id.
+	pings      map[uint32]chan *agentapi.PingReply
+	pingsMu    sync.Mutex
+	lastPingID atomic.Uint32
+
+	// watchdog detects the DistroInfo loop of the Session stream getting stuck, so that the client is closed
+	// instead of lingering without serving anything. The WSL Pro service then connects again.
+	watchdog *watchdog.Watchdog
+
 	mu sync.RWMutex
 }
 
@@ -49,17 +99,52 @@ func (s *Service) client(ctx context.Context, name string) *client {
 		connReady: make(chan struct{}),
 		proReady:  make(chan struct{}),
 		lpeReady:  make(chan struct{}),
+		cmdReady:  make(chan struct{}),
+		logReady:  make(chan struct{}),
+		pingReady: make(chan struct{}),
+
+		laneCmds: make(map[task.Lane]*laneCmd),
+		tails:    make(map[uint32]*tail),
+		pings:    make(map[uint32]chan *agentapi.PingReply),
+
+		watchdog: watchdog.New(s.stallTimeout),
 	}
+	go c.supervise()
 
 	s.clients[name] = c
 	return c
 }
 
-// WaitReady waits for all three streams to be connected.
+// supervise closes the client as soon as the DistroInfo loop gets stuck, logging the diagnostics. It
+// returns once the client is closed.
+func (c *client) supervise() {
+	defer crashreport.Recover("wslinstance")
+	err := c.watchdog.Run(c.ctx)
+	if err == nil {
+		return
+	}
+
+	var stall watchdog.StallError
+	if errors.As(err, &stall) {
+		log.Errorf(c.ctx, "Distro %q: tearing down the connection: %v. Goroutines:\n%s", c.name, stall, stall.Goroutines)
+	}
+
+	c.Close()
+}
+
+// watched runs f as some work of the heart.
+func (c *client) watched(h *watchdog.Heart, f func() error) error {
+	h.Start()
+	defer h.Done()
+
+	return f()
+}
+
+// WaitReady waits for all streams to be connected.
 func (c *client) WaitReady(ctx context.Context) (err error) {
 	defer decorate.OnError(&err, "could not wait for all streams to connect")
 
-	for _, ready := range []chan struct{}{c.connReady, c.proReady, c.lpeReady} {
+	for _, ready := range []chan struct{}{c.connReady, c.proReady, c.lpeReady, c.cmdReady} {
 		select {
 		case <-ready:
 		case <-c.ctx.Done():
@@ -92,6 +177,19 @@ func (c *client) Close() {
 	delete(c.service.clients, c.name)
 }
 
+// taskContext returns a context that is done once either the client is closed or taskCtx is done, to wait
+// for the result of a request of a task with. A task that is cancelled abandons the result, which closes the
+// client like any other error receiving it, as the stream would otherwise deliver it as the result of the next
+// request. The WSL Pro service then stops serving the request along with the stream, and connects again.
+func (c *client) taskContext(taskCtx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(taskCtx)
+	stop := context.AfterFunc(c.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
 // msgToError translates a result received via gRPC into an error.
 // If there is a problem translating, an error will be returned and the first return value
 // will be false.
@@ -112,9 +210,9 @@ func msgToError(message *agentapi.MSG) (bool, error) {
 	return true, nil
 }
 
-// SetConnectedStream sets the Connected stream for the client.
-// This step is necessary for WaitReady to return.
-func (c *client) SetConnectedStream(stream agentapi.WSLInstance_ConnectedServer) error {
+// SetSessionStream sets the Session stream for the client, alongside the capabilities and the service version
+// reported in its handshake. This step is necessary for WaitReady to return.
+func (c *client) SetSessionStream(stream agentapi.WSLInstance_SessionServer, caps []agentapi.Capability, serviceVersion string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -123,6 +221,25 @@ func (c *client) SetConnectedStream(stream agentapi.WSLInstance_ConnectedServer)
 	}
 
 	c.connStream = stream
+	c.capabilities = caps
+	c.serviceVersion = serviceVersion
 	close(c.connReady)
 	return nil
 }
+
+// setTooOld records whether the service was found older than the minimum version.
+func (c *client) setTooOld(tooOld bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tooOld = tooOld
+	c.versionChecked = true
+}
+
+// getTooOld returns whether the service was found older than the minimum version, and whether it was checked at all.
+func (c *client) getTooOld() (tooOld, checked bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.tooOld, c.versionChecked
+}
@@ -0,0 +1,255 @@
+package wslinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/ubuntu/decorate"
+	"google.golang.org/protobuf/proto"
+)
+
+// Commands serves the homonymous stream.
+func (s *Service) Commands(stream agentapi.WSLInstance_CommandsServer) (err error) {
+	defer decorate.OnError(&err, "WslInstance: could not handle commands")
+	ctx := stream.Context()
+
+	client, err := commandHandshake(ctx, s, stream.Recv)
+	if err != nil {
+		return err
+	}
+	if err := client.SetCommandsStream(stream); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.WaitReady(ctx); err != nil {
+		return err
+	}
+
+	// Block until the connection drops
+	client.WaitDone(ctx)
+	return nil
+}
+
+// SetCommandsStream sets the generic commands stream for the client.
+// This step is necessary for WaitReady to return.
+func (c *client) SetCommandsStream(stream agentapi.WSLInstance_CommandsServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cmdStream != nil {
+		return errors.New("stream already connected")
+	}
+
+	c.cmdStream = stream
+	close(c.cmdReady)
+	return nil
+}
+
+// laneCmd is the command of a lane.
+type laneCmd struct {
+	// id is the id of the command once it is sent, and 0 while it waits for its turn.
+	id uint32
+	// preempted is set if the command is preempted while waiting for its turn.
+	preempted bool
+}
+
+// laneClient is the client as seen by a task of a lane.
+type laneClient struct {
+	*client
+	lane task.Lane
+	// taskCtx is the context of the task. See client.taskContext.
+	taskCtx context.Context
+}
+
+// InLane returns the client as seen by a task of a lane: its commands can only be preempted
+// by preempting that lane, and it stops waiting for the results of its requests once ctx is done.
+func (c *client) InLane(ctx context.Context, lane task.Lane) task.Connection {
+	return laneClient{client: c, lane: lane, taskCtx: ctx}
+}
+
+// SendCommand sends a command of the lane of the client. See client.SendCommand.
+func (c laneClient) SendCommand(cmd *agentapi.Command) ([]byte, error) {
+	return c.client.sendCommand(c.taskCtx, c.lane, cmd)
+}
+
+// SendProAttachment sends a pro attachment token on behalf of the task. See client.SendProAttachment.
+func (c laneClient) SendProAttachment(proToken string) error {
+	return c.client.sendProAttachment(c.taskCtx, proToken)
+}
+
+// SendLandscapeConfig sends a Landscape config on behalf of the task. See client.SendLandscapeConfig.
+func (c laneClient) SendLandscapeConfig(config string) error {
+	return c.client.sendLandscapeConfig(c.taskCtx, config)
+}
+
+// SendCommand sends a command to the client and waits for its result, returning the
+// command-specific output, if any. The command belongs to the default lane.
+// Concurrent commands are sent one at a time, each with its own id.
+// Do not use before the client is ready.
+func (c *client) SendCommand(cmd *agentapi.Command) ([]byte, error) {
+	return c.sendCommand(context.Background(), task.LaneDefault, cmd)
+}
+
+// sendCommand sends a command of the given lane to the client on behalf of the task with the given
+// context, and waits for its result.
+func (c *client) sendCommand(taskCtx context.Context, lane task.Lane, cmd *agentapi.Command) ([]byte, error) {
+	lc := &laneCmd{}
+	c.setLaneCmd(lane, lc)
+	defer c.removeLaneCmd(lane, lc)
+
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	select {
+	case <-c.ctx.Done():
+		return nil, fmt.Errorf("client closed: %w", task.ErrInterrupted)
+	default:
+	}
+
+	if c.cmdStream == nil {
+		return nil, errors.New("no commands stream")
+	}
+
+	// Not modifying the caller's command, as it may be sent again (e.g. to another distro).
+	cmd = proto.Clone(cmd).(*agentapi.Command)
+	cmd.Id = c.lastCmdID.Add(1)
+
+	c.laneCmdsMu.Lock()
+	lc.id = cmd.GetId()
+	preempted := lc.preempted
+	c.laneCmdsMu.Unlock()
+
+	if preempted {
+		// Waiting for the commands of other lanes is as safe a point as any.
+		return nil, fmt.Errorf("%w: before the command was sent", task.ErrPreempted)
+	}
+
+	err := c.send(cmd)
+	if err != nil {
+		c.Close()
+		log.Warningf(c.cmdStream.Context(), "Commands stream could not send: %v", err)
+		return nil, fmt.Errorf("could not send command: %w", task.ErrInterrupted)
+	}
+
+	ctx, cancel := c.taskContext(taskCtx)
+	defer cancel()
+
+	// Large outputs are split across several messages, the last of which carries the result.
+	var output []byte
+	var result *agentapi.MSG
+	for {
+		result, err = recvResult(ctx, taskCtx, c.cmdStream.Recv)
+		if err != nil {
+			c.Close()
+			log.Warningf(c.cmdStream.Context(), "Commands stream could not receive: %v", err)
+			return nil, fmt.Errorf("could not receive command result: %w", task.ErrInterrupted)
+		}
+
+		output = append(output, result.GetOutput()...)
+		if !result.GetMore() {
+			break
+		}
+	}
+
+	ok, err := msgToError(result)
+	if !ok {
+		return nil, fmt.Errorf("did not receive command result: %v", err)
+	}
+	if result.GetPreempted() {
+		return output, fmt.Errorf("%w: %v", task.ErrPreempted, err)
+	}
+	return output, err
+}
+
+// recvResult is like recvContext for the messages answering a command, except that it reports the progress the
+// distro sends ahead of them to the task with the given context, instead of returning it.
+func recvResult(ctx, taskCtx context.Context, recv func() (*agentapi.MSG, error)) (*agentapi.MSG, error) {
+	for {
+		msg, err := recvContext(ctx, recv)
+		if err != nil {
+			return nil, err
+		}
+
+		p := msg.GetProgress()
+		if p == nil {
+			return msg, nil
+		}
+		task.ReportStepProgress(taskCtx, p.GetPercent(), p.GetStep())
+	}
+}
+
+// setLaneCmd makes lc the command of the lane.
+func (c *client) setLaneCmd(lane task.Lane, lc *laneCmd) {
+	c.laneCmdsMu.Lock()
+	defer c.laneCmdsMu.Unlock()
+
+	c.laneCmds[lane] = lc
+}
+
+// removeLaneCmd removes lc from the lane, unless another command of the lane replaced it.
+func (c *client) removeLaneCmd(lane task.Lane, lc *laneCmd) {
+	c.laneCmdsMu.Lock()
+	defer c.laneCmdsMu.Unlock()
+
+	if c.laneCmds[lane] == lc {
+		delete(c.laneCmds, lane)
+	}
+}
+
+// Preempt asks the command of the lane, if any, to stop at its next safe point. SendCommand then
+// returns an error wrapping task.ErrPreempted. A command still waiting for the commands of other
+// lanes is not sent at all. The preemption targets the command by id, so it is ignored if that command
+// finished in the meantime, without affecting the next one, nor the commands of other lanes.
+// Do not use before the client is ready.
+func (c *client) Preempt(lane task.Lane) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	select {
+	case <-c.ctx.Done():
+		return errors.New("client closed")
+	default:
+	}
+
+	if c.cmdStream == nil {
+		return errors.New("no commands stream")
+	}
+
+	c.laneCmdsMu.Lock()
+	var id uint32
+	if lc, ok := c.laneCmds[lane]; ok {
+		id = lc.id
+		lc.preempted = true
+	}
+	c.laneCmdsMu.Unlock()
+
+	if id == 0 {
+		// Nothing in progress to preempt.
+		return nil
+	}
+
+	err := c.send(&agentapi.Command{
+		Cmd: &agentapi.Command_Preempt{Preempt: &agentapi.PreemptCmd{Id: id}},
+	})
+	if err != nil {
+		return fmt.Errorf("could not send preemption: %v", err)
+	}
+
+	return nil
+}
+
+// send sends a message through the commands stream.
+func (c *client) send(cmd *agentapi.Command) error {
+	c.cmdSendMu.Lock()
+	defer c.cmdSendMu.Unlock()
+
+	return c.cmdStream.Send(cmd)
+}
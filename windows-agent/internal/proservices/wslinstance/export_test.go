@@ -1,3 +1,19 @@
 package wslinstance
 
+import (
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+)
+
 var PropsFromInfo = propsFromInfo
+
+// LaneHasCommand returns true if a command of the lane is in progress or waiting for its turn.
+func LaneHasCommand(conn worker.Connection, lane task.Lane) bool {
+	c := conn.(*client)
+
+	c.laneCmdsMu.Lock()
+	defer c.laneCmdsMu.Unlock()
+
+	_, ok := c.laneCmds[lane]
+	return ok
+}
@@ -0,0 +1,94 @@
+package wslinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	// The compressor must be registered for the streams of the distros that negotiated CAPABILITY_COMPRESSION.
+	_ "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/compression"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// errLegacyHandshake is the reason WSL Pro services that predate the handshake are rejected.
+var errLegacyHandshake = errors.New("the WSL Pro service predates the handshake and is too old for this agent: upgrade it")
+
+// supportedCapabilities are the features of the WSL Pro service that the agent knows how to use.
+var supportedCapabilities = []agentapi.Capability{
+	agentapi.Capability_CAPABILITY_LOGS,
+	agentapi.Capability_CAPABILITY_INFO_ACK,
+	agentapi.Capability_CAPABILITY_PING,
+	agentapi.Capability_CAPABILITY_COMPRESSION,
+	agentapi.Capability_CAPABILITY_PROGRESS,
+}
+
+// mainHandshake receives the Handshake from the session stream, answers it with the capabilities both
+// ends support, and receives the first DistroInfo. It also returns the version the WSL Pro service reported.
+func mainHandshake(ctx context.Context, s *Service, stream agentapi.WSLInstance_SessionServer) (c *client, caps []agentapi.Capability, serviceVersion string, info *agentapi.DistroInfo, err error) {
+	recvCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	msg, err := recvContext(recvCtx, stream.Recv)
+	if err != nil {
+		return nil, nil, "", nil, fmt.Errorf("could not start handshake: did not receive: %v", err)
+	}
+
+	h := msg.GetHandshake()
+	if h == nil {
+		return nil, nil, "", nil, errors.New("could not start handshake: the first message was not a Handshake")
+	}
+
+	if v := h.GetProtocolVersion(); v != common.WSLInstanceProtocolVersion {
+		return nil, nil, "", nil, fmt.Errorf("could not complete handshake: the WSL Pro service speaks protocol version %d, but the agent speaks version %d: upgrade the oldest of them", v, common.WSLInstanceProtocolVersion)
+	}
+
+	if h.GetWslName() == "" {
+		return nil, nil, "", nil, errors.New("could not complete handshake: no WSL name provided")
+	}
+
+	caps = negotiateCapabilities(h.GetCapabilities())
+	if err := stream.Send(&agentapi.HandshakeAck{
+		ProtocolVersion:    common.WSLInstanceProtocolVersion,
+		Capabilities:       caps,
+		AgentTimeUnixMilli: time.Now().UnixMilli(),
+	}); err != nil {
+		return nil, nil, "", nil, fmt.Errorf("could not complete handshake: could not send acknowledgement: %v", err)
+	}
+
+	log.Debugf(ctx, "WSL instance %q: handshake completed with WSL Pro service version %q and capabilities: %s", h.GetWslName(), h.GetServiceVersion(), capabilitiesString(caps))
+
+	info, err = recvInfo(recvCtx, stream.Recv)
+	if err != nil {
+		return nil, nil, "", nil, fmt.Errorf("could not complete handshake: %v", err)
+	}
+
+	return s.client(ctx, h.GetWslName()), caps, h.GetServiceVersion(), info, nil
+}
+
+// negotiateCapabilities returns the capabilities offered by the WSL Pro service that the agent supports.
+func negotiateCapabilities(offered []agentapi.Capability) []agentapi.Capability {
+	var caps []agentapi.Capability
+	for _, c := range supportedCapabilities {
+		if slices.Contains(offered, c) {
+			caps = append(caps, c)
+		}
+	}
+	return caps
+}
+
+func capabilitiesString(caps []agentapi.Capability) string {
+	if len(caps) == 0 {
+		return "none"
+	}
+
+	names := make([]string, 0, len(caps))
+	for _, c := range caps {
+		names = append(names, c.String())
+	}
+	return strings.Join(names, ", ")
+}
@@ -1,11 +1,13 @@
 package wslinstance
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/ubuntu/decorate"
 )
 
@@ -49,15 +51,20 @@ func (c *client) SetLandscapeConfigStream(stream agentapi.WSLInstance_LandscapeC
 
 // SendLandscapeConfig sends a Landscape config to the client.
 // Do not use before the client is ready.
+func (c *client) SendLandscapeConfig(config string) error {
+	return c.sendLandscapeConfig(context.Background(), config)
+}
+
+// sendLandscapeConfig sends a Landscape config to the client on behalf of the task with the given context.
 //
 //nolint:dupl // The structure of this function is similar, but the contents are not identical, between tasks.
-func (c *client) SendLandscapeConfig(config string) error {
+func (c *client) sendLandscapeConfig(taskCtx context.Context, config string) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	select {
 	case <-c.ctx.Done():
-		return errors.New("client closed")
+		return fmt.Errorf("client closed: %w", task.ErrInterrupted)
 	default:
 	}
 
@@ -71,19 +78,31 @@ func (c *client) SendLandscapeConfig(config string) error {
 	if err != nil {
 		c.Close()
 		log.Warningf(c.lpeStream.Context(), "LandscapeConfig stream could not send: %v", err)
-		return errors.New("could not send landscape config: disconnected")
+		return fmt.Errorf("could not send landscape config: %w", task.ErrInterrupted)
 	}
 
-	result, err := recvContext(c.ctx, c.lpeStream.Recv)
+	ctx, cancel := c.taskContext(taskCtx)
+	defer cancel()
+
+	result, err := recvResult(ctx, taskCtx, c.lpeStream.Recv)
 	if err != nil {
 		c.Close()
 		log.Warningf(c.lpeStream.Context(), "LandscapeConfig stream could not receive: %v", err)
-		return errors.New("could not receive landscape config result: disconnected")
+		return fmt.Errorf("could not receive landscape config result: %w", task.ErrInterrupted)
 	}
 
 	ok, err := msgToError(result)
 	if !ok {
 		return fmt.Errorf("did not receive landscape config result: %v", err)
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	if d, ok := c.service.db.Get(c.name); ok && d.SetManaged(c.ctx, config != "") {
+		if err := c.service.db.Dump(); err != nil {
+			log.Warningf(c.ctx, "Distro %q: could not store that it is managed by Landscape: %v", c.name, err)
+		}
+	}
+	return nil
 }
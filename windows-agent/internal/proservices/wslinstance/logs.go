@@ -0,0 +1,189 @@
+package wslinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+)
+
+// tail is a TailLog request in progress.
+type tail struct {
+	// messages are the LogMessages that answer the request.
+	messages chan *agentapi.LogMessage
+	// done is closed once the request is abandoned, so that its messages are dropped.
+	done chan struct{}
+}
+
+// TailLog serves the homonymous stream. Only the WSL Pro services with CAPABILITY_LOGS open it.
+func (s *Service) TailLog(stream agentapi.WSLInstance_TailLogServer) (err error) {
+	defer decorate.OnError(&err, "WslInstance: could not handle TailLog requests")
+	ctx := stream.Context()
+
+	recvCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	msg, err := recvContext(recvCtx, stream.Recv)
+	if err != nil {
+		return fmt.Errorf("could not start handshake: did not receive: %v", err)
+	}
+
+	name := msg.GetWslName()
+	if name == "" {
+		return errors.New("could not complete handshake: no WSL name received")
+	}
+
+	client := s.client(ctx, name)
+	if err := client.SetLogStream(stream); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Block until the connection drops
+	return client.dispatchLogs()
+}
+
+// SetLogStream sets the TailLog stream for the client.
+func (c *client) SetLogStream(stream agentapi.WSLInstance_TailLogServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.logStream != nil {
+		return errors.New("stream already connected")
+	}
+
+	c.logStream = stream
+	close(c.logReady)
+	return nil
+}
+
+// dispatchLogs forwards every LogMessage received to the TailLog request it answers, until the stream
+// breaks or the client is closed.
+func (c *client) dispatchLogs() error {
+	for {
+		msg, err := recvContext(c.ctx, c.logStream.Recv)
+		if c.ctx.Err() != nil || errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("could not receive: %v", err)
+		}
+
+		c.tailsMu.Lock()
+		t, ok := c.tails[msg.GetId()]
+		c.tailsMu.Unlock()
+
+		if !ok {
+			// The request was abandoned.
+			continue
+		}
+
+		select {
+		case t.messages <- msg:
+		case <-t.done:
+		case <-c.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// TailLog asks the WSL Pro service for the journal lines of the request, and calls send with each of them
+// as they arrive. Followed requests go on until the context is cancelled. Several requests are served at
+// once, independently of the commands in progress.
+func (c *client) TailLog(ctx context.Context, cmd *agentapi.TailLogCmd, send func(line string) error) (err error) {
+	defer decorate.OnError(&err, "could not tail the log of the WSL Pro service")
+
+	c.mu.RLock()
+	supported := slices.Contains(c.capabilities, agentapi.Capability_CAPABILITY_LOGS)
+	c.mu.RUnlock()
+
+	if !supported {
+		return fmt.Errorf("the WSL Pro service of the distro does not support %s: upgrade it to use this feature", agentapi.Capability_CAPABILITY_LOGS)
+	}
+
+	// The TailLog stream is opened after the handshake, so it may not be ready yet.
+	select {
+	case <-c.logReady:
+	case <-c.ctx.Done():
+		return errors.New("client closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(30 * time.Second):
+		return errors.New("timed out waiting for the TailLog stream")
+	}
+
+	id := c.lastTailID.Add(1)
+	t := &tail{
+		messages: make(chan *agentapi.LogMessage, 64),
+		done:     make(chan struct{}),
+	}
+
+	c.tailsMu.Lock()
+	c.tails[id] = t
+	c.tailsMu.Unlock()
+
+	defer func() {
+		c.tailsMu.Lock()
+		delete(c.tails, id)
+		c.tailsMu.Unlock()
+		close(t.done)
+	}()
+
+	err = c.sendLogRequest(&agentapi.TailLogCmd{
+		Id:               id,
+		Lines:            cmd.GetLines(),
+		Priority:         cmd.GetPriority(),
+		IncludeProClient: cmd.GetIncludeProClient(),
+		Follow:           cmd.GetFollow(),
+	})
+	if err != nil {
+		c.Close()
+		log.Warningf(ctx, "TailLog stream could not send: %v", err)
+		return errors.New("could not send request: disconnected")
+	}
+
+	for {
+		var msg *agentapi.LogMessage
+		select {
+		case <-ctx.Done():
+			c.cancelLogRequest(ctx, id)
+			return ctx.Err()
+		case <-c.ctx.Done():
+			return errors.New("could not receive lines: disconnected")
+		case msg = <-t.messages:
+		}
+
+		if msg.GetDone() {
+			if msg.GetError() != "" {
+				return errors.New(msg.GetError())
+			}
+			return nil
+		}
+
+		if err := send(msg.GetLine()); err != nil {
+			c.cancelLogRequest(ctx, id)
+			return err
+		}
+	}
+}
+
+// cancelLogRequest tells the WSL Pro service to stop sending the lines of the request.
+func (c *client) cancelLogRequest(ctx context.Context, id uint32) {
+	// The request is abandoned either way, so its remaining lines are dropped.
+	if err := c.sendLogRequest(&agentapi.TailLogCmd{Id: id, Cancel: true}); err != nil {
+		log.Warningf(ctx, "TailLog stream could not cancel request %d: %v", id, err)
+	}
+}
+
+// sendLogRequest sends a message through the TailLog stream.
+func (c *client) sendLogRequest(cmd *agentapi.TailLogCmd) error {
+	c.logSendMu.Lock()
+	defer c.logSendMu.Unlock()
+
+	return c.logStream.Send(cmd)
+}
@@ -0,0 +1,135 @@
+package wslinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/ubuntu/decorate"
+)
+
+// Ping serves the homonymous stream. Only the WSL Pro services with CAPABILITY_PING open it.
+func (s *Service) Ping(stream agentapi.WSLInstance_PingServer) (err error) {
+	defer decorate.OnError(&err, "WslInstance: could not handle pings")
+	ctx := stream.Context()
+
+	recvCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	msg, err := recvContext(recvCtx, stream.Recv)
+	if err != nil {
+		return fmt.Errorf("could not start handshake: did not receive: %v", err)
+	}
+
+	name := msg.GetWslName()
+	if name == "" {
+		return errors.New("could not complete handshake: no WSL name received")
+	}
+
+	client := s.client(ctx, name)
+	if err := client.SetPingStream(stream); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Block until the connection drops
+	return client.dispatchPings()
+}
+
+// SetPingStream sets the Ping stream for the client.
+func (c *client) SetPingStream(stream agentapi.WSLInstance_PingServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pingStream != nil {
+		return errors.New("stream already connected")
+	}
+
+	c.pingStream = stream
+	close(c.pingReady)
+	return nil
+}
+
+// dispatchPings forwards every PingReply received to the ping it answers, until the stream breaks or the
+// client is closed.
+func (c *client) dispatchPings() error {
+	for {
+		msg, err := recvContext(c.ctx, c.pingStream.Recv)
+		if c.ctx.Err() != nil || errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("could not receive: %v", err)
+		}
+
+		c.pingsMu.Lock()
+		reply, ok := c.pings[msg.GetId()]
+		delete(c.pings, msg.GetId())
+		c.pingsMu.Unlock()
+
+		if !ok {
+			// The ping was abandoned.
+			continue
+		}
+
+		// The channel is buffered and only ever receives this reply.
+		reply <- msg
+	}
+}
+
+// Ping sends the payload to the WSL Pro service, and returns what it echoes back. Pings go through their
+// own stream, so they are answered right away regardless of the commands in progress.
+func (c *client) Ping(ctx context.Context, payload []byte) (echo []byte, err error) {
+	defer decorate.OnError(&err, "could not ping the WSL Pro service")
+
+	c.mu.RLock()
+	supported := slices.Contains(c.capabilities, agentapi.Capability_CAPABILITY_PING)
+	c.mu.RUnlock()
+
+	if !supported {
+		return nil, fmt.Errorf("the WSL Pro service of the distro does not support %s: upgrade it to use this feature", agentapi.Capability_CAPABILITY_PING)
+	}
+
+	// The Ping stream is opened after the handshake, so it may not be ready yet.
+	select {
+	case <-c.pingReady:
+	case <-c.ctx.Done():
+		return nil, errors.New("client closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	id := c.lastPingID.Add(1)
+	reply := make(chan *agentapi.PingReply, 1)
+
+	c.pingsMu.Lock()
+	c.pings[id] = reply
+	c.pingsMu.Unlock()
+
+	defer func() {
+		c.pingsMu.Lock()
+		delete(c.pings, id)
+		c.pingsMu.Unlock()
+	}()
+
+	c.pingSendMu.Lock()
+	err = c.pingStream.Send(&agentapi.PingCmd{Id: id, Payload: payload})
+	c.pingSendMu.Unlock()
+
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("could not send ping: disconnected: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.ctx.Done():
+		return nil, errors.New("could not receive reply: disconnected")
+	case msg := <-reply:
+		return msg.GetPayload(), nil
+	}
+}
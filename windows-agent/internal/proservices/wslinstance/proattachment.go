@@ -1,11 +1,13 @@
 package wslinstance
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/ubuntu/decorate"
 )
 
@@ -34,15 +36,20 @@ func (s *Service) ProAttachmentCommands(stream agentapi.WSLInstance_ProAttachmen
 
 // SendProAttachment sends a pro attachment token to the client.
 // Do not use before the client is ready.
+func (c *client) SendProAttachment(proToken string) error {
+	return c.sendProAttachment(context.Background(), proToken)
+}
+
+// sendProAttachment sends a pro attachment token to the client on behalf of the task with the given context.
 //
 //nolint:dupl // The structure of this function is similar, but the contents are not identical, between tasks.
-func (c *client) SendProAttachment(proToken string) error {
+func (c *client) sendProAttachment(taskCtx context.Context, proToken string) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	select {
 	case <-c.ctx.Done():
-		return errors.New("client closed")
+		return fmt.Errorf("client closed: %w", task.ErrInterrupted)
 	default:
 	}
 
@@ -56,14 +63,17 @@ func (c *client) SendProAttachment(proToken string) error {
 	if err != nil {
 		c.Close()
 		log.Warningf(c.proStream.Context(), "ProAttachmentCommands stream could not send: %v", err)
-		return errors.New("could not send pro attachment: disconnected")
+		return fmt.Errorf("could not send pro attachment: %w", task.ErrInterrupted)
 	}
 
-	msg, err := recvContext(c.ctx, c.proStream.Recv)
+	ctx, cancel := c.taskContext(taskCtx)
+	defer cancel()
+
+	msg, err := recvResult(ctx, taskCtx, c.proStream.Recv)
 	if err != nil {
 		c.Close()
 		log.Warningf(c.proStream.Context(), "ProAttachmentCommands stream could not receive: %v", err)
-		return errors.New("could not receive pro attachment result: disconnected")
+		return fmt.Errorf("could not receive pro attachment result: %w", task.ErrInterrupted)
 	}
 
 	ok, err := msgToError(msg)
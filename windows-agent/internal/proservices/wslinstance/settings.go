@@ -0,0 +1,73 @@
+package wslinstance
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+)
+
+// infoRefreshInterval is how often the agent wants a new DistroInfo from the WSL Pro services, so that
+// the security status of the distros does not go stale when no commands are sent to them.
+const infoRefreshInterval = time.Hour
+
+// acknowledgeInfo answers a DistroInfo with the settings of the distro, if the WSL Pro service negotiated
+// that capability in the handshake.
+func (s *Service) acknowledgeInfo(ctx context.Context, client *client, caps []agentapi.Capability, d *distro.Distro) error {
+	if !slices.Contains(caps, agentapi.Capability_CAPABILITY_INFO_ACK) {
+		return nil
+	}
+
+	ack := &agentapi.HandshakeAck{
+		ProtocolVersion:    common.WSLInstanceProtocolVersion,
+		Settings:           s.distroSettings(ctx, d),
+		AgentTimeUnixMilli: time.Now().UnixMilli(),
+	}
+	err := client.connStream.Send(ack)
+	if err != nil {
+		return fmt.Errorf("could not acknowledge DistroInfo: %v", err)
+	}
+
+	return nil
+}
+
+// distroSettings returns the settings the agent propagates to the distro.
+func (s *Service) distroSettings(ctx context.Context, d *distro.Distro) *agentapi.DistroSettings {
+	return &agentapi.DistroSettings{
+		ConfigHash:             s.configHash(ctx, d.Name()),
+		PendingTasks:           int32(d.PendingTasks()),
+		RefreshIntervalSeconds: uint32(infoRefreshInterval / time.Second),
+	}
+}
+
+// configHash returns a digest of the configuration the agent wants the distro to have, i.e. the Pro token
+// and the Landscape client configuration of its distro group, if any. It is empty if the configuration
+// cannot be read.
+func (s *Service) configHash(ctx context.Context, distroName string) string {
+	token, _, err := s.config.SubscriptionFor(distroName)
+	if err != nil {
+		log.Warningf(ctx, "Could not compute the configuration hash: %v", err)
+		return ""
+	}
+
+	landscape, _, err := s.config.LandscapeClientConfigFor(distroName)
+	if err != nil {
+		log.Warningf(ctx, "Could not compute the configuration hash: %v", err)
+		return ""
+	}
+
+	h := sha256.New()
+	h.Write([]byte(token))
+	// The separator prevents different configurations from concatenating into the same input.
+	h.Write([]byte{0})
+	h.Write([]byte(landscape))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -9,10 +9,17 @@ import (
 	"time"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/debversion"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/metrics"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/ubuntu/decorate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // LandscapeController is the  controller for the Landscape client proservice.
@@ -20,52 +27,161 @@ type LandscapeController interface {
 	SendUpdatedInfo(context.Context) error
 }
 
+// Config is the configuration that decides which versions of the WSL Pro service are managed,
+// and where to upgrade the older ones from. The subscription and Landscape configuration are
+// only used to tell the distros when the configuration they should have changes.
+type Config interface {
+	MinimumServiceVersion() (string, error)
+	UpdateChannel() (config.UpdateChannel, error)
+	SubscriptionFor(distroName string) (string, config.Source, error)
+	LandscapeClientConfigFor(distroName string) (string, config.Source, error)
+}
+
+// ServiceUpgrader upgrades the WSL Pro service of a distro from an update channel when the maintenance
+// schedule allows it.
+type ServiceUpgrader interface {
+	UpgradeDistro(d *distro.Distro, channel config.UpdateChannel)
+}
+
+// streamReconnects counts the sessions opened by distros that had opened one already since the agent started.
+var streamReconnects = metrics.Default.Counter("stream_reconnects_total", "Number of times the WSL Pro service of a distro connected again since the agent started, by distro.", "distro")
+
+// defaultStallTimeout is how long the DistroInfo loop of a distro can be busy without making progress before
+// its connection is considered a zombie and torn down.
+const defaultStallTimeout = 5 * time.Minute
+
 // Service is the WSL Instance GRPC service implementation.
 type Service struct {
 	agentapi.UnimplementedWSLInstanceServer
 
 	db        *database.DistroDB
 	landscape LandscapeController
+	config    Config
+	upgrader  ServiceUpgrader
 
 	clients   map[string]*client
 	clientsMu sync.Mutex
+
+	// sessions tells which distros have opened a session, so that the reconnections can be counted.
+	// It is guarded by clientsMu.
+	sessions map[string]bool
+
+	// stallTimeout is the timeout of the watchdog of every client.
+	stallTimeout time.Duration
+}
+
+type options struct {
+	upgrader     ServiceUpgrader
+	stallTimeout time.Duration
+}
+
+// Option is an optional argument for New.
+type Option func(*options)
+
+// WithServiceUpgrader makes the upgrades of the WSL Pro services that are too old go through u, so
+// that they wait for the maintenance schedule. They are submitted right away otherwise.
+func WithServiceUpgrader(u ServiceUpgrader) Option {
+	return func(o *options) {
+		o.upgrader = u
+	}
+}
+
+// WithStallTimeout sets how long the DistroInfo loop of a distro can be busy without making progress before
+// its connection is torn down. It defaults to defaultStallTimeout.
+func WithStallTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.stallTimeout = timeout
+	}
 }
 
 // New returns a new service handling WSL Instance API.
-func New(ctx context.Context, db *database.DistroDB, landscape LandscapeController) (s *Service) {
+func New(ctx context.Context, db *database.DistroDB, landscape LandscapeController, conf Config, args ...Option) (s *Service) {
 	log.Debug(ctx, "Building new GRPC WSLInstance server")
+
+	opts := options{
+		upgrader:     immediateUpgrader{},
+		stallTimeout: defaultStallTimeout,
+	}
+	for _, f := range args {
+		f(&opts)
+	}
+
 	return &Service{
 		db:        db,
 		landscape: landscape,
+		config:    conf,
+		upgrader:  opts.upgrader,
 		clients:   make(map[string]*client),
+		sessions:  make(map[string]bool),
+
+		stallTimeout: opts.stallTimeout,
 	}
 }
 
-// Connected establishes a connection with a WSL instance and keeps its properties
+// immediateUpgrader submits the upgrades right away.
+type immediateUpgrader struct{}
+
+func (immediateUpgrader) UpgradeDistro(d *distro.Distro, channel config.UpdateChannel) {
+	err := d.SubmitTasks(tasks.ServiceUpgrade{
+		Channel:  channel.Channel,
+		Source:   channel.Source,
+		Checksum: channel.Checksum,
+	})
+	if err != nil {
+		log.Warningf(context.Background(), "Distro %q: could not submit service upgrade: %v", d.Name(), err)
+	}
+}
+
+// Connected is the stream opened by the WSL Pro services that predate the handshake. They are rejected,
+// as the agent cannot negotiate what they support: only the service knows which distro it runs in.
+func (s *Service) Connected(stream agentapi.WSLInstance_ConnectedServer) error {
+	ctx, cancel := context.WithTimeout(stream.Context(), 20*time.Second)
+	defer cancel()
+
+	// Their first message tells which distro must be upgraded.
+	name := "unknown"
+	if info, err := recvContext(ctx, stream.Recv); err == nil {
+		name = info.GetWslName()
+	}
+
+	log.Warningf(ctx, "WSL instance %q: %v", name, errLegacyHandshake)
+	return status.Error(codes.FailedPrecondition, errLegacyHandshake.Error())
+}
+
+// Session establishes a connection with a WSL instance and keeps its properties
 // in the database up-to-date.
-func (s *Service) Connected(stream agentapi.WSLInstance_ConnectedServer) (err error) {
+func (s *Service) Session(stream agentapi.WSLInstance_SessionServer) (err error) {
 	ctx := stream.Context()
 
-	client, info, err := mainHandshake(ctx, s, stream.Recv)
+	client, caps, serviceVersion, info, err := mainHandshake(ctx, s, stream)
 	if err != nil {
 		return err
 	}
 
-	if err := client.SetConnectedStream(stream); err != nil {
+	if err := client.SetSessionStream(stream, caps, serviceVersion); err != nil {
 		return err
 	}
 	defer client.Close()
 
+	s.countSession(client.name)
+
 	props, err := propsFromInfo(info)
 	if err != nil {
 		return fmt.Errorf("invalid DistroInfo: %v", err)
 	}
+	props.ServiceVersion = serviceVersion
 
 	d, err := s.db.GetDistroAndUpdateProperties(ctx, client.name, props)
 	if err != nil {
 		return err
 	}
 
+	// The distro is seen when it connects and until it disconnects. The database may be closing by the time
+	// it disconnects, so the time is only stored with the next dump, at the latest when the database closes.
+	s.seen(ctx, d)
+	defer d.SetLastSeen(time.Now())
+	s.used(ctx, d)
+
 	// Update landscape host agent when connecting and disconnecting.
 	s.landscapeHostagentSendUpdatedInfo(ctx)
 	defer s.landscapeHostagentSendUpdatedInfo(ctx)
@@ -78,7 +194,11 @@ func (s *Service) Connected(stream agentapi.WSLInstance_ConnectedServer) (err er
 	// Load deferred tasks
 	d.EnqueueDeferredTasks()
 
-	if err := d.SetConnection(client); err != nil {
+	if err := s.acknowledgeInfo(ctx, client, caps, d); err != nil {
+		return err
+	}
+
+	if err := s.manage(ctx, d, client, serviceVersion); err != nil {
 		return err
 	}
 
@@ -88,25 +208,164 @@ func (s *Service) Connected(stream agentapi.WSLInstance_ConnectedServer) (err er
 	log.Debug(ctx, "connection to Linux-side WSL service established")
 
 	// Blocking connection for the lifetime of the WSL service.
+	dispatcher := client.watchdog.Heart("DistroInfo dispatcher")
 	for {
-		info, err := recvContext(client.ctx, stream.Recv)
+		info, err := recvInfo(client.ctx, stream.Recv)
 		if err != nil {
-			return fmt.Errorf("could not receive info: %v", err)
+			return err
 		}
 
-		props, err = propsFromInfo(info)
-		if err != nil {
-			return fmt.Errorf("invalid DistroInfo: %v", err)
+		if err := client.watched(dispatcher, func() error { return s.updateInfo(ctx, client, d, info, caps) }); err != nil {
+			return err
 		}
+	}
+}
 
-		if d.SetProperties(props) {
-			if err := s.db.Dump(); err != nil {
-				log.Warningf(ctx, "updating properties: %v", err)
-			}
+// countSession records that the distro opened a session, counting it as a reconnection if it had opened one already.
+func (s *Service) countSession(name string) {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	if s.sessions[name] {
+		streamReconnects.Inc(name)
+	}
+	s.sessions[name] = true
+}
+
+// updateInfo stores the properties of the distro received in a DistroInfo, and acknowledges it.
+func (s *Service) updateInfo(ctx context.Context, client *client, d *distro.Distro, info *agentapi.DistroInfo, caps []agentapi.Capability) error {
+	props, err := propsFromInfo(info)
+	if err != nil {
+		return fmt.Errorf("invalid DistroInfo: %v", err)
+	}
+	props.ServiceVersion = client.serviceVersion
+
+	changed := d.SetProperties(props)
+	if d.SetLastSeen(time.Now()) || changed {
+		if err := s.db.Dump(); err != nil {
+			log.Warningf(ctx, "updating properties: %v", err)
 		}
+	}
 
-		s.landscapeHostagentSendUpdatedInfo(ctx)
+	if err := s.acknowledgeInfo(ctx, client, caps, d); err != nil {
+		return err
 	}
+
+	s.landscapeHostagentSendUpdatedInfo(ctx)
+	return nil
+}
+
+// seen records that the WSL Pro service of the distro was just heard from.
+func (s *Service) seen(ctx context.Context, d *distro.Distro) {
+	if !d.SetLastSeen(time.Now()) {
+		return
+	}
+	if err := s.db.Dump(); err != nil {
+		log.Warningf(ctx, "Distro %q: could not store the last time it was seen: %v", d.Name(), err)
+	}
+}
+
+// used records the first time the distro connects without the agent keeping it awake, as it was then launched
+// by the user rather than woken up by the agent to run its tasks.
+func (s *Service) used(ctx context.Context, d *distro.Distro) {
+	if d.KeptAwake() {
+		return
+	}
+	if !d.SetFirstUsed(time.Now()) {
+		return
+	}
+	log.Infof(ctx, "Distro %q: launched by the user for the first time", d.Name())
+	if err := s.db.Dump(); err != nil {
+		log.Warningf(ctx, "Distro %q: could not store the first time it was used: %v", d.Name(), err)
+	}
+}
+
+// manage hands the connection over to the distro, so that it starts processing its tasks. WSL Pro services
+// older than the minimum version are upgraded during the next maintenance window if there is an update channel
+// to upgrade them from, and refused otherwise. Distros that run on WSL 1 are managed as usual: their WSL version
+// is a property of the distro, and the tasks that need WSL 2 are refused for them when submitted.
+func (s *Service) manage(ctx context.Context, d *distro.Distro, client *client, serviceVersion string) error {
+	tooOld := s.checkServiceVersion(ctx, serviceVersion)
+	client.setTooOld(tooOld != nil)
+	if tooOld == nil {
+		if err := d.SetConnection(client); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	channel, err := s.config.UpdateChannel()
+	if err != nil {
+		log.Warningf(ctx, "Distro %q: %v", d.Name(), err)
+	}
+	if channel.Channel == "" {
+		return d.Refuse(ctx, fmt.Errorf("%v: configure an update channel or upgrade it manually", tooOld))
+	}
+
+	log.Infof(ctx, "Distro %q: %v: upgrading it from channel %q", d.Name(), tooOld, channel.Channel)
+
+	// The distro remains degraded until the upgraded service connects again.
+	d.SetDegraded(ctx, tooOld)
+	if err := d.SetConnection(client); err != nil {
+		return err
+	}
+
+	s.upgrader.UpgradeDistro(d, channel)
+	return nil
+}
+
+// RecheckServiceVersions checks the version of the connected WSL Pro services again, e.g. because the minimum
+// version changed. Those that are now too old, or no longer are, are disconnected: they connect again on their
+// own and are then managed, upgraded or refused like any new connection.
+func (s *Service) RecheckServiceVersions(ctx context.Context) {
+	s.clientsMu.Lock()
+	clients := make([]*client, 0, len(s.clients))
+	for _, c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.clientsMu.Unlock()
+
+	for _, c := range clients {
+		tooOld, checked := c.getTooOld()
+		if !checked {
+			// Not managed yet: the version will be checked then.
+			continue
+		}
+		if (s.checkServiceVersion(ctx, c.serviceVersion) != nil) == tooOld {
+			continue
+		}
+
+		log.Infof(ctx, "Distro %q: the minimum version of the WSL Pro service changed: reconnecting it", c.name)
+		c.Close()
+	}
+}
+
+// checkServiceVersion returns an error if the version of the WSL Pro service is older than the minimum
+// version configured. Versions that cannot be compared, such as those of development builds, are accepted.
+func (s *Service) checkServiceVersion(ctx context.Context, serviceVersion string) error {
+	minVersion, err := s.config.MinimumServiceVersion()
+	if err != nil {
+		log.Warningf(ctx, "could not check the version of the WSL Pro service: %v", err)
+		return nil
+	}
+	if minVersion == "" {
+		return nil
+	}
+
+	if serviceVersion == "" {
+		return fmt.Errorf("the WSL Pro service is too old to report its version, and version %s or newer is required", minVersion)
+	}
+
+	cmp, err := debversion.Compare(serviceVersion, minVersion)
+	if err != nil {
+		log.Warningf(ctx, "could not compare the version of the WSL Pro service %q with the minimum version %q: %v", serviceVersion, minVersion, err)
+		return nil
+	}
+	if cmp < 0 {
+		return fmt.Errorf("the WSL Pro service version %s is older than the minimum version %s", serviceVersion, minVersion)
+	}
+
+	return nil
 }
 
 func propsFromInfo(info *agentapi.DistroInfo) (props distro.Properties, err error) {
@@ -116,30 +375,45 @@ func propsFromInfo(info *agentapi.DistroInfo) (props distro.Properties, err erro
 		return props, errors.New("no WSL name provided in DistroInfo message")
 	}
 
-	return distro.Properties{
+	props = distro.Properties{
 		DistroID:    info.GetId(),
 		VersionID:   info.GetVersionId(),
 		PrettyName:  info.GetPrettyName(),
 		ProAttached: info.GetProAttached(),
 		Hostname:    info.GetHostname(),
-	}, nil
-}
+		ProServices: info.GetProServices(),
 
-// mainHandshake receives the first message from the main stream and attaches the stream to the client.
-func mainHandshake(ctx context.Context, s *Service, recv func() (*agentapi.DistroInfo, error)) (c *client, m *agentapi.DistroInfo, err error) {
-	recvCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
-	defer cancel()
+		ProExpires:      info.GetProExpires(),
+		ProSupportLevel: info.GetProSupportLevel(),
 
-	msg, err := recvContext(recvCtx, recv)
+		UnsupportedReason: info.GetUnsupportedReason(),
+		WslVersion:        int(info.GetWslVersion()),
+	}
+
+	if sec := info.GetSecurityStatus(); sec != nil {
+		props.Security = distro.SecurityStatus{
+			Known:           true,
+			StandardUpdates: int(sec.GetStandardUpdates()),
+			ESMUpdates:      int(sec.GetEsmUpdates()),
+		}
+	}
+
+	return props, nil
+}
+
+// recvInfo receives a DistroInfo message from the main stream.
+func recvInfo(ctx context.Context, recv func() (*agentapi.DistroMessage, error)) (*agentapi.DistroInfo, error) {
+	msg, err := recvContext(ctx, recv)
 	if err != nil {
-		return nil, m, fmt.Errorf("could not start handshake: did not receive: %v", err)
+		return nil, fmt.Errorf("could not receive info: %v", err)
 	}
 
-	if msg.GetWslName() == "" {
-		return nil, msg, errors.New("could not complete handshake: no WSL name provided")
+	info := msg.GetInfo()
+	if info == nil {
+		return nil, fmt.Errorf("could not receive info: unexpected message %T", msg.GetData())
 	}
 
-	return s.client(ctx, msg.GetWslName()), msg, err
+	return info, nil
 }
 
 // commandHandshake receives the first message from a command-sending stream and attaches the stream to the client.
@@ -188,6 +462,7 @@ func recvContext[MessageT any](ctx context.Context, recv func() (*MessageT, erro
 // log in the case error.
 func (s *Service) landscapeHostagentSendUpdatedInfo(ctx context.Context) {
 	go func() {
+		defer crashreport.Recover("wslinstance")
 		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 		defer cancel()
 
@@ -4,24 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/grpc/compression"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/wslinstance"
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	wsl "github.com/ubuntu/gowsl"
 	wslmock "github.com/ubuntu/gowsl/mock"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 func TestMain(m *testing.M) {
@@ -42,11 +52,15 @@ func TestServe(t *testing.T) {
 		dontSendDistroName bool
 
 		skipConnectedHandshake bool
+		legacyHandshake        bool
+		wrongProtocolVersion   bool
 		skipProHandshake       bool
 		skipLandscapeHandshake bool
+		skipCommandsHandshake  bool
 
 		duplicateStream bool
 
+		wantHandshakeErr            string
 		wantNeverInDatabase         bool
 		wantConnectionNeverAttached bool
 	}{
@@ -56,13 +70,16 @@ func TestServe(t *testing.T) {
 		"Error when two streams connect under the same name": {duplicateStream: true},
 
 		// Early failure: before/during add to database
-		"Error when the distro name is not sent":            {dontSendDistroName: true, wantNeverInDatabase: true},
-		"Error when the distro does not exist":              {dontRegister: true, wantNeverInDatabase: true},
-		"Error when Connected never performs the handshake": {skipConnectedHandshake: true, wantNeverInDatabase: true},
+		"Error when the distro name is not sent":                 {dontSendDistroName: true, wantNeverInDatabase: true},
+		"Error when the distro does not exist":                   {dontRegister: true, wantNeverInDatabase: true},
+		"Error when Session never performs the handshake":        {skipConnectedHandshake: true, wantNeverInDatabase: true},
+		"Error when a legacy service opens the Connected stream": {legacyHandshake: true, wantHandshakeErr: "predates the handshake", wantNeverInDatabase: true},
+		"Error when the protocol version does not match":         {wrongProtocolVersion: true, wantHandshakeErr: "protocol version", wantNeverInDatabase: true},
 
 		// Late failure: during wait for other streams
 		"Error when Pro never performs the handshake":       {skipProHandshake: true, wantConnectionNeverAttached: true},
 		"Error when Landscape never performs the handshake": {skipLandscapeHandshake: true, wantConnectionNeverAttached: true},
+		"Error when Commands never performs the handshake":  {skipCommandsHandshake: true, wantConnectionNeverAttached: true},
 	}
 
 	for name, tc := range testCases {
@@ -80,7 +97,7 @@ func TestServe(t *testing.T) {
 
 			landscape := &landscapeCtlMock{}
 
-			service := wslinstance.New(ctx, db, landscape)
+			service := wslinstance.New(ctx, db, landscape, &mockConfig{})
 			server := grpc.NewServer()
 			agentapi.RegisterWSLInstanceServer(server, service)
 
@@ -110,16 +127,31 @@ func TestServe(t *testing.T) {
 				sendName = ""
 			}
 
+			var protocolVersion uint32
+			if tc.wrongProtocolVersion {
+				protocolVersion = common.WSLInstanceProtocolVersion + 1
+			}
+
 			wps := newMockWSLProService(t, ctx, mockWslProServiceOptions{
-				address:    lis.Addr().String(),
-				distroName: sendName,
+				address:         lis.Addr().String(),
+				distroName:      sendName,
+				protocolVersion: protocolVersion,
+				legacyHandshake: tc.legacyHandshake,
 
 				noHandshakeConnected:         tc.skipConnectedHandshake,
 				noHandshakeProCommands:       tc.skipProHandshake,
 				noHandshakeLandscapeCommands: tc.skipLandscapeHandshake,
+				noHandshakeCommands:          tc.skipCommandsHandshake,
 			})
 			defer wps.Stop()
 
+			if tc.wantHandshakeErr != "" {
+				require.ErrorContains(t, wps.handshakeErr, tc.wantHandshakeErr, "Handshake should have been rejected with a meaningful error")
+			}
+			if tc.legacyHandshake {
+				require.Equal(t, codes.FailedPrecondition, status.Code(wps.handshakeErr), "Legacy services should be told they must be upgraded")
+			}
+
 			timeout := time.Minute
 			if tc.wantNeverInDatabase {
 				wps.requireDone(t, timeout, "did not disconnect before adding the distro to the database")
@@ -140,7 +172,7 @@ func TestServe(t *testing.T) {
 
 				time.Sleep(time.Second)
 
-				err := wps2.connStream.Send(&agentapi.DistroInfo{WslName: distroName})
+				err := wps2.connStream.Send(&agentapi.DistroMessage{Data: &agentapi.DistroMessage_Info{Info: &agentapi.DistroInfo{WslName: distroName}}})
 				require.Error(t, err, "Second stream should have errored")
 
 				err = wps2.proStream.Send(&agentapi.MSG{Data: &agentapi.MSG_WslName{WslName: distroName}})
@@ -171,6 +203,9 @@ func TestServe(t *testing.T) {
 				return conn != nil
 			}, timeout, time.Second, "Distro never got assigned a connection")
 
+			require.Equal(t, uint32(common.WSLInstanceProtocolVersion), wps.ack.GetProtocolVersion(), "Handshake should have been acknowledged with the protocol version of the agent")
+			require.Equal(t, []agentapi.Capability{agentapi.Capability_CAPABILITY_LOGS, agentapi.Capability_CAPABILITY_PING}, wps.ack.GetCapabilities(), "Handshake should have been acknowledged with the capabilities supported by both ends")
+
 			wps.sendInfo(t, &agentapi.DistroInfo{
 				WslName:     distroName,
 				Id:          "TEST_ID",
@@ -178,6 +213,11 @@ func TestServe(t *testing.T) {
 				PrettyName:  "TEST_PRETTY_NAME",
 				ProAttached: true,
 				Hostname:    "TEST_HOSTNAME",
+				ProServices: []string{"esm-apps", "usg"},
+				SecurityStatus: &agentapi.SecurityStatus{
+					StandardUpdates: 2,
+					EsmUpdates:      5,
+				},
 			})
 
 			require.Eventually(t, func() bool {
@@ -191,6 +231,10 @@ func TestServe(t *testing.T) {
 			require.Equal(t, "TEST_PRETTY_NAME", props.PrettyName, "Mismatch between sent and stored properties")
 			require.True(t, props.ProAttached, "Mismatch between sent and stored properties")
 			require.Equal(t, "TEST_HOSTNAME", props.Hostname, "Mismatch between sent and stored properties")
+			require.Equal(t, []string{"esm-apps", "usg"}, props.ProServices, "Mismatch between sent and stored properties")
+			require.Equal(t, distro.SecurityStatus{Known: true, StandardUpdates: 2, ESMUpdates: 5}, props.Security, "Mismatch between sent and stored properties")
+			require.WithinDuration(t, time.Now(), props.LastSeen, time.Minute, "The distro should have been seen when reporting its info")
+			require.WithinDuration(t, time.Now(), props.FirstUsed, time.Minute, "The distro should have been used, as it connected without the agent keeping it awake")
 		})
 	}
 }
@@ -209,7 +253,7 @@ func TestSendCommands(t *testing.T) {
 
 	landscape := &landscapeCtlMock{}
 
-	service := wslinstance.New(ctx, db, landscape)
+	service := wslinstance.New(ctx, db, landscape, &mockConfig{})
 	server := grpc.NewServer()
 	agentapi.RegisterWSLInstanceServer(server, service)
 
@@ -270,211 +314,1571 @@ func TestSendCommands(t *testing.T) {
 	err = conn.SendLandscapeConfig("MOCK_ERROR")
 	require.Error(t, err, "SendLandscapeConfig should have returned an error")
 
-	wps.Stop()
+	out, err := conn.SendCommand(proServiceCmd("esm-apps"))
+	require.NoError(t, err, "SendCommand should return no error")
+	require.Empty(t, out, "SendCommand should return no output for pro service commands")
 
-	err = conn.SendProAttachment("hello123")
-	require.Error(t, err, "SendProAttachment should return an error after disconnecting")
+	out, err = conn.SendCommand(&agentapi.Command{Cmd: &agentapi.Command_Usg{Usg: &agentapi.UsgCmd{Profile: "cis_level1_server"}}})
+	require.NoError(t, err, "SendCommand should return no error")
+	require.Equal(t, "<html>cis_level1_server</html>", string(out), "SendCommand should return the command output")
 
-	err = conn.SendLandscapeConfig("hello123")
-	require.Error(t, err, "SendLandscapeConfig should return an error after disconnecting")
-}
+	out, err = conn.SendCommand(&agentapi.Command{Cmd: &agentapi.Command_Usg{Usg: &agentapi.UsgCmd{Profile: "chunked"}}})
+	require.NoError(t, err, "SendCommand should return no error")
+	require.Equal(t, "<html>chunked</html>", string(out), "SendCommand should return the command output split across messages")
 
-// landscapeCtlMock mocks the landscape client.
-//
-// disconnected and err are inputs to manipulate mock behaviour.
-// updateCount is used to assert that the SendUpdatedInfo function has been called.
-type landscapeCtlMock struct {
-	disconnected bool
-	err          bool
+	// The progress sent ahead of the result is reported to the task of the command.
+	var progress []string
+	progressCtx := task.WithProgressReporter(ctx, func(percent uint32, step string) {
+		progress = append(progress, fmt.Sprintf("%d%% %s", percent, step))
+	})
+	out, err = conn.InLane(progressCtx, task.LaneDefault).SendCommand(&agentapi.Command{Cmd: &agentapi.Command_Usg{Usg: &agentapi.UsgCmd{Profile: "progress"}}})
+	require.NoError(t, err, "SendCommand should return no error")
+	require.Equal(t, "<html>progress</html>", string(out), "SendCommand should return the command output after its progress")
+	require.Equal(t, []string{"30% Auditing", "60% Reporting"}, progress, "SendCommand should report the progress of the command to its task")
 
-	updateCount atomic.Int32
-}
+	progress = nil
+	err = conn.InLane(progressCtx, task.LaneDefault).SendLandscapeConfig("PROGRESS")
+	require.NoError(t, err, "SendLandscapeConfig should return no error")
+	require.Equal(t, []string{"50% Registering with Landscape"}, progress, "SendLandscapeConfig should report the progress of the enrollment to its task")
 
-func (c *landscapeCtlMock) SendUpdatedInfo(ctx context.Context) error {
-	c.updateCount.Add(1)
+	var lines []string
+	err = conn.TailLog(ctx, &agentapi.TailLogCmd{Lines: 10}, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err, "TailLog should return no error")
+	require.Equal(t, []string{"mock log line"}, lines, "TailLog should send the lines received")
 
-	if c.disconnected {
-		return errors.New("Sending updated info to disconnected landscape")
-	}
+	err = conn.TailLog(ctx, &agentapi.TailLogCmd{Lines: -1}, func(string) error { return nil })
+	require.Error(t, err, "TailLog should return the error of the WSL Pro service")
 
-	if c.err {
-		return errors.New("mock error")
-	}
-	return nil
-}
+	// Followed logs do not hold back commands, and stop once cancelled.
+	followCtx, cancelFollow := context.WithCancel(ctx)
+	defer cancelFollow()
+	followed := make(chan struct{})
+	followErr := make(chan error)
+	go func() {
+		followErr <- conn.TailLog(followCtx, &agentapi.TailLogCmd{Lines: 10, Follow: true}, func(string) error {
+			close(followed)
+			return nil
+		})
+	}()
 
-// mockWSLProService mocks the actions performed by the Linux-side client and services.
-type mockWSLProService struct {
-	connStream agentapi.WSLInstance_ConnectedClient
-	proStream  agentapi.WSLInstance_ProAttachmentCommandsClient
-	lpeStream  agentapi.WSLInstance_LandscapeConfigCommandsClient
+	select {
+	case <-followed:
+	case <-time.After(timeout):
+		require.Fail(t, "TailLog should send the lines as they arrive")
+	}
 
-	cancel  func()
-	conn    *grpc.ClientConn
-	running sync.WaitGroup
-}
+	_, err = conn.SendCommand(proServiceCmd("esm-apps"))
+	require.NoError(t, err, "SendCommand should return no error while following the logs")
 
-type mockWslProServiceOptions struct {
-	address    string
-	distroName string
+	cancelFollow()
+	select {
+	case err = <-followErr:
+		require.ErrorIs(t, err, context.Canceled, "TailLog should stop following once cancelled")
+	case <-time.After(timeout):
+		require.Fail(t, "TailLog should stop following once cancelled")
+	}
 
-	noHandshakeConnected         bool
-	noHandshakeProCommands       bool
-	noHandshakeLandscapeCommands bool
-}
+	_, err = conn.SendCommand(proServiceCmd("MOCK_ERROR"))
+	require.Error(t, err, "SendCommand should have returned an error")
+	require.NotErrorIs(t, err, task.ErrPreempted, "SendCommand should only return ErrPreempted for preempted commands")
 
-// newMockWSLProService creates a wslDistroMock, establishing a connection to the control stream.
-//
-//nolint:revive // testing.T should go before context, regardless of what these linters say.
-func newMockWSLProService(t *testing.T, ctx context.Context, opt mockWslProServiceOptions) (mock *mockWSLProService) {
-	t.Helper()
+	// Preempting with no command in progress has no effect
+	err = conn.Preempt(task.LaneDefault)
+	require.NoError(t, err, "Preempt should return no error")
 
-	mock = &mockWSLProService{}
+	_, err = conn.SendCommand(proServiceCmd("esm-apps"))
+	require.NoError(t, err, "SendCommand should return no error after a preemption with no command in progress")
 
-	conn, err := grpc.NewClient(opt.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	require.NoError(t, err, "wslDistroMock: could not setup a control address client")
+	// Preempting a command in progress
+	const upgradeLane, proLane task.Lane = "upgrade", "pro"
+	upgrades := wps.upgrades.Load()
+	errCh := make(chan error)
+	go func() {
+		_, err := conn.InLane(ctx, upgradeLane).SendCommand(&agentapi.Command{Cmd: &agentapi.Command_ServiceUpgrade{ServiceUpgrade: &agentapi.ServiceUpgradeCmd{Channel: "PREEMPTIBLE"}}})
+		errCh <- err
+	}()
+	require.Eventually(t, func() bool { return wps.upgrades.Load() > upgrades }, timeout, 10*time.Millisecond, "The long command should have been sent")
 
-	ctx, cancel := context.WithCancel(ctx)
-	t.Cleanup(cancel)
+	// Commands of other lanes wait for their turn, and are not sent at all if their lane is preempted meanwhile
+	proErrCh := make(chan error)
+	go func() {
+		_, err := conn.InLane(ctx, proLane).SendCommand(proServiceCmd("esm-apps"))
+		proErrCh <- err
+	}()
+	require.Eventually(t, func() bool { return wslinstance.LaneHasCommand(conn, proLane) }, timeout, 10*time.Millisecond, "The command of the other lane should be waiting for its turn")
+	require.NoError(t, conn.Preempt(proLane), "Preempt should return no error")
 
-	mock.conn = conn
-	mock.cancel = cancel
+	// Preempting other lanes does not affect the command in progress
+	require.NoError(t, conn.Preempt(task.LaneDefault), "Preempt should return no error")
+	select {
+	case <-errCh:
+		require.Fail(t, "Preempting a lane should not stop the commands of other lanes")
+	case <-time.After(500 * time.Millisecond):
+	}
 
-	c := agentapi.NewWSLInstanceClient(conn)
+	// Pings are answered while a command is in progress, and each gets its own echo.
+	var pings sync.WaitGroup
+	for i := range 3 {
+		pings.Add(1)
+		go func() {
+			defer pings.Done()
+			payload := fmt.Sprintf("payload %d", i)
+			echo, err := conn.Ping(ctx, []byte(payload))
+			assert.NoError(t, err, "Ping should return no error while a command is in progress")
+			assert.Equal(t, payload, string(echo), "Ping should return the payload echoed")
+		}()
+	}
+	pings.Wait()
 
-	mock.connStream, err = c.Connected(ctx)
-	require.NoError(t, err, "wslDistroMock: could not connect to Connected stream")
+	pingCtx, cancelPing := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancelPing()
+	_, err = conn.Ping(pingCtx, []byte("NO_REPLY"))
+	require.ErrorIs(t, err, context.DeadlineExceeded, "Ping should stop waiting for the reply once the context is done")
 
-	if !opt.noHandshakeConnected {
-		err = mock.connStream.Send(&agentapi.DistroInfo{WslName: opt.distroName})
-		require.NoError(t, err, "wslDistroMock: could not send info via Connected stream")
+	// Preemptions sent before the command are ignored, so keep trying until it is preempted
+	deadline := time.After(timeout)
+preempting:
+	for {
+		select {
+		case err = <-errCh:
+			break preempting
+		case <-time.After(100 * time.Millisecond):
+			require.NoError(t, conn.Preempt(upgradeLane), "Preempt should return no error")
+		case <-deadline:
+			require.Fail(t, "SendCommand should have been preempted")
+		}
 	}
+	require.ErrorIs(t, err, task.ErrPreempted, "SendCommand should return ErrPreempted for preempted commands")
 
-	mock.proStream, err = c.ProAttachmentCommands(ctx)
-	require.NoError(t, err, "wslDistroMock: could not connect to ProAttachmentCommands stream")
-	if !opt.noHandshakeProCommands {
-		err = sendWslName(mock.proStream.Send, opt.distroName)
-		require.NoError(t, err, "wslDistroMock: could not send wsl name via ProAttachmentCommands stream")
+	select {
+	case err = <-proErrCh:
+		require.ErrorIs(t, err, task.ErrPreempted, "SendCommand should return ErrPreempted for commands preempted while waiting for their turn")
+	case <-time.After(timeout):
+		require.Fail(t, "The command of the other lane should have returned once its turn came")
 	}
 
-	mock.lpeStream, err = c.LandscapeConfigCommands(ctx)
-	require.NoError(t, err, "wslDistroMock: could not connect to LandscapeConfigCommands stream")
-	if !opt.noHandshakeLandscapeCommands {
-		err = sendWslName(mock.lpeStream.Send, opt.distroName)
-		require.NoError(t, err, "wslDistroMock: could not send wsl name via LandscapeConfigCommands stream")
+	// Tasks stop waiting for the results of their commands once cancelled, which tears the connection down.
+	taskCtx, cancelTask := context.WithCancel(ctx)
+	defer cancelTask()
+	upgrades = wps.upgrades.Load()
+	go func() {
+		_, err := conn.InLane(taskCtx, upgradeLane).SendCommand(&agentapi.Command{Cmd: &agentapi.Command_ServiceUpgrade{ServiceUpgrade: &agentapi.ServiceUpgradeCmd{Channel: "PREEMPTIBLE"}}})
+		errCh <- err
+	}()
+	require.Eventually(t, func() bool { return wps.upgrades.Load() > upgrades }, timeout, 10*time.Millisecond, "The long command should have been sent")
+
+	cancelTask()
+	select {
+	case err = <-errCh:
+		require.ErrorIs(t, err, task.ErrInterrupted, "SendCommand should stop waiting for the result once the task is cancelled")
+	case <-time.After(timeout):
+		require.Fail(t, "SendCommand should stop waiting for the result once the task is cancelled")
 	}
 
-	mock.running.Add(2)
-	go mock.replyProAttachmentCommands(t)
-	go mock.replyLandscapeConfigCommands(t)
+	_, err = conn.SendCommand(proServiceCmd("esm-apps"))
+	require.Error(t, err, "SendCommand should return an error once a cancelled task tore the connection down")
 
-	return mock
-}
+	wps.Stop()
 
-func sendWslName(send func(*agentapi.MSG) error, wslName string) error {
-	return send(&agentapi.MSG{
-		Data: &agentapi.MSG_WslName{
-			WslName: wslName,
-		},
-	})
-}
+	err = conn.SendProAttachment("hello123")
+	require.Error(t, err, "SendProAttachment should return an error after disconnecting")
 
-func sendResult(send func(*agentapi.MSG) error, result error) error {
-	var errMsg string
-	if result != nil {
-		errMsg = result.Error()
-	}
+	err = conn.SendLandscapeConfig("hello123")
+	require.Error(t, err, "SendLandscapeConfig should return an error after disconnecting")
 
-	return send(&agentapi.MSG{
-		Data: &agentapi.MSG_Result{
-			Result: errMsg,
-		},
-	})
-}
+	_, err = conn.SendCommand(proServiceCmd("esm-apps"))
+	require.Error(t, err, "SendCommand should return an error after disconnecting")
 
-// Stop stops the Linux-side service.
-func (m *mockWSLProService) Stop() {
-	m.cancel()
-	m.conn.Close()
-	m.running.Wait()
+	err = conn.Preempt(task.LaneDefault)
+	require.Error(t, err, "Preempt should return an error after disconnecting")
+
+	_, err = conn.Ping(ctx, []byte("payload"))
+	require.Error(t, err, "Ping should return an error after disconnecting")
 }
 
-func (m *mockWSLProService) requireDone(t *testing.T, timeout time.Duration, msg string, args ...any) {
-	t.Helper()
+func TestCapabilities(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
 
-	ch := make(chan struct{})
-	go func() {
-		m.running.Wait()
-		close(ch)
-	}()
+	testCases := map[string]struct {
+		capabilities []agentapi.Capability
 
-	select {
-	case <-ch:
-		return
-	case <-time.After(timeout):
+		wantCapabilities []agentapi.Capability
+		wantErr          bool
+	}{
+		"Success with the capabilities supported by the agent": {capabilities: []agentapi.Capability{agentapi.Capability_CAPABILITY_LOGS}, wantCapabilities: []agentapi.Capability{agentapi.Capability_CAPABILITY_LOGS}},
+		"Success with compressed streams":                      {capabilities: []agentapi.Capability{agentapi.Capability_CAPABILITY_LOGS, agentapi.Capability_CAPABILITY_COMPRESSION}, wantCapabilities: []agentapi.Capability{agentapi.Capability_CAPABILITY_LOGS, agentapi.Capability_CAPABILITY_COMPRESSION}},
+		"Success with progress reports":                        {capabilities: []agentapi.Capability{agentapi.Capability_CAPABILITY_LOGS, agentapi.Capability_CAPABILITY_PROGRESS}, wantCapabilities: []agentapi.Capability{agentapi.Capability_CAPABILITY_LOGS, agentapi.Capability_CAPABILITY_PROGRESS}},
+
+		"Error when the WSL Pro service has no capabilities":               {capabilities: []agentapi.Capability{}, wantErr: true},
+		"Error when the WSL Pro service lacks the capability of a command": {capabilities: []agentapi.Capability{agentapi.Capability_CAPABILITY_EXEC}, wantErr: true},
 	}
 
-	require.Failf(t, "WSL Pro service was not done", msg, args...)
-}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
 
-func (m *mockWSLProService) replyProAttachmentCommands(t *testing.T) {
-	t.Helper()
-	defer m.running.Done()
-	defer m.cancel()
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
 
-	for {
-		msg, err := m.proStream.Recv()
-		if err != nil {
-			log.Warningf("%s: Could not receive pro command: %v", t.Name(), err)
-			return
-		}
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: could not create empty database")
 
-		var send error
-		if msg.GetToken() == "MOCK_ERROR" {
-			send = errors.New("mock error")
-		}
+			service := wslinstance.New(ctx, db, &landscapeCtlMock{}, &mockConfig{})
+			server := grpc.NewServer()
+			agentapi.RegisterWSLInstanceServer(server, service)
 
-		err = sendResult(m.proStream.Send, send)
-		if err != nil {
-			log.Warningf("%s: Could not send pro command result: %v", t.Name(), err)
-			m.Stop()
-			return
-		}
+			lis, err := (&net.ListenConfig{}).Listen(ctx, "tcp4", "127.0.0.1:0")
+			require.NoError(t, err, "Setup: could not listen to dynamically-allocated port")
+			defer lis.Close()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			defer wg.Wait()
+			go func() {
+				defer wg.Done()
+				err := server.Serve(lis)
+				if err != nil {
+					t.Logf("Serve exited with error: %v", err)
+				}
+			}()
+			defer server.Stop()
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+			wps := newMockWSLProService(t, ctx, mockWslProServiceOptions{
+				address:      lis.Addr().String(),
+				distroName:   distroName,
+				capabilities: tc.capabilities,
+			})
+			defer wps.Stop()
+
+			require.Eventually(t, func() bool {
+				d, ok := db.Get(distroName)
+				if !ok {
+					return false
+				}
+				conn, err := d.Connection()
+				return err == nil && conn != nil
+			}, time.Minute, time.Second, "Distro never got assigned a connection")
+
+			d, _ := db.Get(distroName)
+			conn, err := d.Connection()
+			require.NoError(t, err, "distro.Connection should return no error")
+
+			require.Equal(t, tc.wantCapabilities, wps.ack.GetCapabilities(), "Handshake should have been acknowledged with the capabilities supported by both ends")
+
+			err = conn.TailLog(ctx, &agentapi.TailLogCmd{Lines: 10}, func(string) error { return nil })
+			if tc.wantErr {
+				require.Error(t, err, "TailLog should fail when the WSL Pro service lacks the capability")
+				return
+			}
+			require.NoError(t, err, "TailLog should return no error")
+		})
 	}
 }
 
-func (m *mockWSLProService) replyLandscapeConfigCommands(t *testing.T) {
-	t.Helper()
-	defer m.running.Done()
-	defer m.cancel()
+func TestWatchdog(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
 
-	for {
-		msg, err := m.lpeStream.Recv()
-		if err != nil {
-			log.Warningf("%s: Could not receive Landscape command: %v", t.Name(), err)
-			return
-		}
+	const stallTimeout = 200 * time.Millisecond
 
-		var send error
-		if msg.GetConfig() == "MOCK_ERROR" {
-			send = errors.New("mock error")
-		}
+	testCases := map[string]struct {
+		stall bool
 
-		err = sendResult(m.lpeStream.Send, send)
-		if err != nil {
-			log.Warningf("%s: Could not send Landscape command result: %v", t.Name(), err)
-			m.Stop()
-			return
-		}
+		wantDisconnected bool
+	}{
+		"Success keeping idle connections":                                      {},
+		"Success tearing down the connection when the DistroInfo loop is stuck": {stall: true, wantDisconnected: true},
 	}
-}
 
-// sendInfo sends the specified info from the Linux-side client to the wslinstance service.
-func (m *mockWSLProService) sendInfo(t *testing.T, info *agentapi.DistroInfo) {
-	t.Helper()
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
 
-	err := m.connStream.Send(info)
-	require.NoError(t, err, "wslDistroMock SendInfo expected no errors")
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: could not create empty database")
+
+			conf := &mockConfig{release: make(chan struct{})}
+			service := wslinstance.New(ctx, db, &landscapeCtlMock{}, conf, wslinstance.WithStallTimeout(stallTimeout))
+			server := grpc.NewServer()
+			agentapi.RegisterWSLInstanceServer(server, service)
+
+			lis, err := (&net.ListenConfig{}).Listen(ctx, "tcp4", "127.0.0.1:0")
+			require.NoError(t, err, "Setup: could not listen to dynamically-allocated port")
+			defer lis.Close()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			defer wg.Wait()
+			go func() {
+				defer wg.Done()
+				err := server.Serve(lis)
+				if err != nil {
+					t.Logf("Serve exited with error: %v", err)
+				}
+			}()
+			defer server.Stop()
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+			wps := newMockWSLProService(t, ctx, mockWslProServiceOptions{
+				address:    lis.Addr().String(),
+				distroName: distroName,
+				capabilities: []agentapi.Capability{
+					agentapi.Capability_CAPABILITY_EXEC,
+					agentapi.Capability_CAPABILITY_FILE_PUSH,
+					agentapi.Capability_CAPABILITY_INFO_ACK,
+				},
+			})
+			defer wps.Stop()
+			// The stuck DistroInfo loop must be released before the server can stop.
+			defer close(conf.release)
+
+			require.Eventually(t, func() bool {
+				d, ok := db.Get(distroName)
+				if !ok {
+					return false
+				}
+				conn, err := d.Connection()
+				return err == nil && conn != nil
+			}, time.Minute, 100*time.Millisecond, "Distro never got assigned a connection")
+
+			if tc.stall {
+				// Acknowledging the DistroInfo reads the configuration, which now blocks.
+				conf.stalled.Store(true)
+				wps.sendInfo(t, &agentapi.DistroInfo{WslName: distroName})
+			}
+
+			if tc.wantDisconnected {
+				wps.requireDone(t, 4*stallTimeout, "the connection should have been torn down")
+				return
+			}
+
+			time.Sleep(3 * stallTimeout)
+
+			d, _ := db.Get(distroName)
+			conn, err := d.Connection()
+			require.NoError(t, err, "distro.Connection should return no error")
+			require.NotNil(t, conn, "Idle connections should not be torn down")
+		})
+	}
+}
+
+func TestServiceVersion(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	testCases := map[string]struct {
+		serviceVersion string
+		minVersion     string
+		channel        string
+		configErr      bool
+		withUpgrader   bool
+		// newMinVersion is the minimum version the config changes to once the distro is managed.
+		newMinVersion string
+
+		wantRefused   bool
+		wantUpgraded  bool
+		wantScheduled bool
+		// wantReconnect is whether the new minimum version changes the verdict, so that the service is disconnected.
+		wantReconnect bool
+	}{
+		"Success with no minimum version":                 {serviceVersion: "1.0"},
+		"Success with the minimum version":                {serviceVersion: "1.2.3", minVersion: "1.2.3"},
+		"Success with a version newer than the minimum":   {serviceVersion: "1.10", minVersion: "1.9"},
+		"Success with a development build":                {serviceVersion: "Dev", minVersion: "1.2.3"},
+		"Success when the config cannot be read":          {serviceVersion: "1.0", minVersion: "1.2.3", configErr: true},
+		"Success upgrading a version older than minimum":  {serviceVersion: "1.0", minVersion: "1.2.3", channel: "stable", wantUpgraded: true},
+		"Success upgrading a service with no version":     {minVersion: "1.2.3", channel: "stable", wantUpgraded: true},
+		"Refused with a version older than the minimum":   {serviceVersion: "1.0", minVersion: "1.2.3", wantRefused: true},
+		"Refused with a service that reports no version":  {minVersion: "1.2.3", wantRefused: true},
+		"Refused with a tilde version before the minimum": {serviceVersion: "1.2.3~rc1", minVersion: "1.2.3", wantRefused: true},
+
+		"Success scheduling the upgrade with the upgrader":       {serviceVersion: "1.0", minVersion: "1.2.3", channel: "stable", withUpgrader: true, wantScheduled: true},
+		"Success keeping the service if the minimum still holds": {serviceVersion: "1.10", minVersion: "1.9", newMinVersion: "1.10"},
+		"Success managing a refused service once it is allowed":  {serviceVersion: "1.0", minVersion: "1.2.3", newMinVersion: "0.9", wantRefused: true, wantReconnect: true},
+		"Refused once the minimum version is raised":             {serviceVersion: "1.0", newMinVersion: "1.2.3", wantReconnect: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: could not create empty database")
+
+			conf := &mockConfig{
+				minVersion: tc.minVersion,
+				channel:    config.UpdateChannel{Channel: tc.channel},
+				err:        tc.configErr,
+			}
+
+			var args []wslinstance.Option
+			upgrader := &mockUpgrader{}
+			if tc.withUpgrader {
+				args = append(args, wslinstance.WithServiceUpgrader(upgrader))
+			}
+
+			service := wslinstance.New(ctx, db, &landscapeCtlMock{}, conf, args...)
+			server := grpc.NewServer()
+			agentapi.RegisterWSLInstanceServer(server, service)
+
+			lis, err := (&net.ListenConfig{}).Listen(ctx, "tcp4", "127.0.0.1:0")
+			require.NoError(t, err, "Setup: could not listen to dynamically-allocated port")
+			defer lis.Close()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			defer wg.Wait()
+			go func() {
+				defer wg.Done()
+				err := server.Serve(lis)
+				if err != nil {
+					t.Logf("Serve exited with error: %v", err)
+				}
+			}()
+			defer server.Stop()
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+			wps := newMockWSLProService(t, ctx, mockWslProServiceOptions{
+				address:        lis.Addr().String(),
+				distroName:     distroName,
+				serviceVersion: tc.serviceVersion,
+			})
+			defer wps.Stop()
+
+			var d *distro.Distro
+			require.Eventually(t, func() bool {
+				var ok bool
+				d, ok = db.Get(distroName)
+				return ok && d.Lifecycle() != distro.Registered
+			}, time.Minute, time.Second, "Distro never got connected")
+
+			require.Equal(t, tc.serviceVersion, d.Properties().ServiceVersion, "Service version should have been stored in the properties")
+
+			if tc.newMinVersion != "" {
+				requireServiceVersionVerdict(t, d, tc.wantRefused)
+
+				conf.setMinimumServiceVersion(tc.newMinVersion)
+				service.RecheckServiceVersions(ctx)
+
+				if !tc.wantReconnect {
+					require.Never(t, func() bool {
+						conn, err := d.Connection()
+						return err != nil || conn == nil
+					}, 2*time.Second, 100*time.Millisecond, "Service should not have been disconnected when the verdict did not change")
+					require.Equal(t, distro.Connected, d.Lifecycle(), "Distro should remain connected")
+					return
+				}
+
+				wps.requireDone(t, time.Minute, "Service should have been disconnected when the verdict changed")
+				wps.Stop()
+
+				// The service connects again on its own.
+				wps = newMockWSLProService(t, ctx, mockWslProServiceOptions{
+					address:        lis.Addr().String(),
+					distroName:     distroName,
+					serviceVersion: tc.serviceVersion,
+				})
+				defer wps.Stop()
+
+				if tc.wantRefused {
+					require.Eventually(t, func() bool { return d.Lifecycle() == distro.Connected },
+						time.Minute, 100*time.Millisecond, "Distro should be managed once the minimum version allows it")
+					return
+				}
+
+				require.Eventually(t, func() bool { return d.Lifecycle() == distro.Degraded },
+					time.Minute, 100*time.Millisecond, "Distro should be refused once the minimum version is raised")
+				requireServiceVersionVerdict(t, d, true)
+				return
+			}
+
+			if tc.wantScheduled {
+				require.Eventually(t, func() bool {
+					conn, err := d.Connection()
+					return err == nil && conn != nil
+				}, time.Minute, time.Second, "Distro never got assigned a connection")
+				require.Equal(t, []string{tc.channel}, upgrader.upgrades(), "The upgrade should have been handed to the upgrader")
+				require.Zero(t, wps.upgrades.Load(), "Service should not have been upgraded before the upgrader submits it")
+				require.Equal(t, distro.Degraded, d.Lifecycle(), "Distro should be degraded while the upgrade is pending")
+				return
+			}
+
+			if tc.wantRefused {
+				require.Equal(t, distro.Degraded, d.Lifecycle(), "Distro with a service that is too old should be degraded")
+				require.Contains(t, d.DegradedReason(), "1.2.3", "Degraded reason should mention the minimum version")
+
+				conn, err := d.Connection()
+				require.NoError(t, err, "Connection should return no error")
+				require.Nil(t, conn, "Distro with a service that is too old should not be given the connection")
+				return
+			}
+
+			require.Eventually(t, func() bool {
+				conn, err := d.Connection()
+				return err == nil && conn != nil
+			}, time.Minute, time.Second, "Distro never got assigned a connection")
+
+			if tc.wantUpgraded {
+				require.Eventually(t, func() bool { return wps.upgrades.Load() > 0 },
+					time.Minute, 100*time.Millisecond, "Service should have been upgraded")
+				require.Equal(t, distro.Degraded, d.Lifecycle(), "Distro should remain degraded until the upgraded service reconnects")
+
+				// The upgraded service restarts, and connects again with the new version.
+				wps.Stop()
+				wps = newMockWSLProService(t, ctx, mockWslProServiceOptions{
+					address:        lis.Addr().String(),
+					distroName:     distroName,
+					serviceVersion: tc.minVersion,
+				})
+				defer wps.Stop()
+
+				require.Eventually(t, func() bool { return d.Lifecycle() == distro.Connected },
+					time.Minute, 100*time.Millisecond, "Distro should recover from degraded once the upgraded service reconnects")
+				return
+			}
+
+			require.Equal(t, distro.Connected, d.Lifecycle(), "Distro should be connected")
+			require.Zero(t, wps.upgrades.Load(), "Service should not have been upgraded")
+		})
+	}
+}
+
+// requireServiceVersionVerdict checks that the distro was refused for its WSL Pro service being too old, or that it was given the connection.
+func requireServiceVersionVerdict(t *testing.T, d *distro.Distro, refused bool) {
+	t.Helper()
+
+	conn, err := d.Connection()
+	require.NoError(t, err, "Connection should return no error")
+
+	if refused {
+		require.Equal(t, distro.Degraded, d.Lifecycle(), "Distro with a service that is too old should be degraded")
+		require.Nil(t, conn, "Distro with a service that is too old should not be given the connection")
+		return
+	}
+
+	require.Equal(t, distro.Connected, d.Lifecycle(), "Distro should be connected")
+	require.NotNil(t, conn, "Distro should have been given the connection")
+}
+
+func TestInfoAcknowledgement(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	testCases := map[string]struct {
+		inDistroGroup bool
+		configErr     bool
+
+		wantSameHash bool
+		wantNoHash   bool
+	}{
+		"Success acknowledging every DistroInfo":              {},
+		"Success with the config hash of the distro group":    {inDistroGroup: true, wantSameHash: true},
+		"Success with no config hash if config is unreadable": {configErr: true, wantNoHash: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: could not create empty database")
+
+			conf := &mockConfig{token: "TOKEN_1", landscape: "[client]\nhello=world", err: tc.configErr}
+			service := wslinstance.New(ctx, db, &landscapeCtlMock{}, conf)
+			server := grpc.NewServer()
+			agentapi.RegisterWSLInstanceServer(server, service)
+
+			lis, err := (&net.ListenConfig{}).Listen(ctx, "tcp4", "127.0.0.1:0")
+			require.NoError(t, err, "Setup: could not listen to dynamically-allocated port")
+			defer lis.Close()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			defer wg.Wait()
+			go func() {
+				defer wg.Done()
+				err := server.Serve(lis)
+				if err != nil {
+					t.Logf("Serve exited with error: %v", err)
+				}
+			}()
+			defer server.Stop()
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			if tc.inDistroGroup {
+				conf.groupTokens = map[string]string{distroName: "GROUP_TOKEN"}
+			}
+
+			wps := newMockWSLProService(t, ctx, mockWslProServiceOptions{
+				address:      lis.Addr().String(),
+				distroName:   distroName,
+				capabilities: []agentapi.Capability{agentapi.Capability_CAPABILITY_LOGS, agentapi.Capability_CAPABILITY_INFO_ACK},
+			})
+			defer wps.Stop()
+
+			require.Contains(t, wps.ack.GetCapabilities(), agentapi.Capability_CAPABILITY_INFO_ACK, "Handshake should have negotiated the acknowledgement of DistroInfo")
+			require.Nil(t, wps.ack.GetSettings(), "Handshake acknowledgement should carry no settings")
+
+			first, err := wps.connStream.Recv()
+			require.NoError(t, err, "The first DistroInfo should be acknowledged")
+			settings := first.GetSettings()
+			require.NotNil(t, settings, "DistroInfo acknowledgement should carry the distro settings")
+			require.Equal(t, uint32(time.Hour/time.Second), settings.GetRefreshIntervalSeconds(), "Unexpected refresh interval")
+			require.Zero(t, settings.GetPendingTasks(), "No tasks should be pending")
+
+			conf.setToken("TOKEN_2")
+			err = wps.connStream.Send(&agentapi.DistroMessage{Data: &agentapi.DistroMessage_Info{Info: &agentapi.DistroInfo{WslName: distroName}}})
+			require.NoError(t, err, "Setup: could not send a second DistroInfo")
+
+			second, err := wps.connStream.Recv()
+			require.NoError(t, err, "The second DistroInfo should be acknowledged")
+
+			if tc.wantNoHash {
+				require.Empty(t, settings.GetConfigHash(), "Config hash should be empty when the config cannot be read")
+				require.Empty(t, second.GetSettings().GetConfigHash(), "Config hash should be empty when the config cannot be read")
+				return
+			}
+			require.NotEmpty(t, settings.GetConfigHash(), "Config hash should be set")
+			if tc.wantSameHash {
+				require.Equal(t, settings.GetConfigHash(), second.GetSettings().GetConfigHash(), "Config hash should not change with the global configuration the distro does not use")
+				return
+			}
+			require.NotEqual(t, settings.GetConfigHash(), second.GetSettings().GetConfigHash(), "Config hash should change with the configuration")
+		})
+	}
+}
+
+func TestWslVersion(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	testCases := map[string]struct {
+		wslVersion int32
+	}{
+		"Success managing a distro on WSL 2":                {wslVersion: 2},
+		"Success managing a distro on WSL 1":                {wslVersion: 1},
+		"Success managing a distro that reports no version": {},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: could not create empty database")
+
+			service := wslinstance.New(ctx, db, &landscapeCtlMock{}, &mockConfig{})
+			server := grpc.NewServer()
+			agentapi.RegisterWSLInstanceServer(server, service)
+
+			lis, err := (&net.ListenConfig{}).Listen(ctx, "tcp4", "127.0.0.1:0")
+			require.NoError(t, err, "Setup: could not listen to dynamically-allocated port")
+			defer lis.Close()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			defer wg.Wait()
+			go func() {
+				defer wg.Done()
+				err := server.Serve(lis)
+				if err != nil {
+					t.Logf("Serve exited with error: %v", err)
+				}
+			}()
+			defer server.Stop()
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+			wps := newMockWSLProService(t, ctx, mockWslProServiceOptions{
+				address:    lis.Addr().String(),
+				distroName: distroName,
+				wslVersion: tc.wslVersion,
+			})
+			defer wps.Stop()
+
+			var d *distro.Distro
+			require.Eventually(t, func() bool {
+				var ok bool
+				d, ok = db.Get(distroName)
+				return ok && d.Lifecycle() != distro.Registered
+			}, time.Minute, time.Second, "Distro never got connected")
+
+			require.Equal(t, int(tc.wslVersion), d.WslVersion(), "WSL version should have been stored in the properties")
+
+			require.Equal(t, distro.Connected, d.Lifecycle(), "Distro should be connected, whatever its WSL version")
+			require.Never(t, func() bool { return d.Lifecycle() == distro.Degraded },
+				time.Second, 100*time.Millisecond, "Distro should not be degraded because of its WSL version")
+		})
+	}
+}
+
+func TestPerformanceBudgets(t *testing.T) {
+	testCases := map[string]struct {
+		benchmark func(*testing.B)
+		budget    time.Duration
+	}{
+		"Processing a DistroInfo update": {benchmark: BenchmarkInfoUpdates, budget: 20 * time.Millisecond},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			testutils.RequirePerformanceBudget(t, tc.budget, tc.benchmark)
+		})
+	}
+}
+
+// BenchmarkInfoUpdates measures the time between the WSL Pro service sending new properties and
+// the agent acknowledging them, once they are stored.
+func BenchmarkInfoUpdates(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if wsl.MockAvailable() {
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	db, err := database.New(ctx, b.TempDir())
+	require.NoError(b, err, "Setup: could not create empty database")
+	defer db.Close(ctx)
+
+	service := wslinstance.New(ctx, db, &landscapeCtlMock{}, &mockConfig{})
+	server := grpc.NewServer()
+	agentapi.RegisterWSLInstanceServer(server, service)
+
+	lis, err := (&net.ListenConfig{}).Listen(ctx, "tcp4", "127.0.0.1:0")
+	require.NoError(b, err, "Setup: could not listen to dynamically-allocated port")
+	defer lis.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Wait()
+	go func() {
+		defer wg.Done()
+		err := server.Serve(lis)
+		if err != nil {
+			b.Logf("Serve exited with error: %v", err)
+		}
+	}()
+	defer server.Stop()
+
+	distroName, _ := wsltestutils.RegisterDistro(b, ctx, false)
+
+	wps := newMockWSLProService(b, ctx, mockWslProServiceOptions{
+		address:      lis.Addr().String(),
+		distroName:   distroName,
+		capabilities: []agentapi.Capability{agentapi.Capability_CAPABILITY_INFO_ACK},
+	})
+	defer wps.Stop()
+
+	_, err = wps.connStream.Recv()
+	require.NoError(b, err, "Setup: the first DistroInfo should be acknowledged")
+
+	b.ResetTimer()
+	for i := range b.N {
+		// A new hostname every time, so that every update is stored.
+		info := &agentapi.DistroInfo{WslName: distroName, Id: "ubuntu", VersionId: "24.04", Hostname: fmt.Sprintf("host%d", i)}
+		err := wps.connStream.Send(&agentapi.DistroMessage{Data: &agentapi.DistroMessage_Info{Info: info}})
+		require.NoError(b, err, "Could not send a DistroInfo")
+
+		_, err = wps.connStream.Recv()
+		require.NoError(b, err, "The DistroInfo should be acknowledged")
+	}
+	b.StopTimer()
+}
+
+// TestChaos subjects the connection between the WSL Pro service and the agent to a random sequence of
+// network faults, reconnecting whenever the connection is lost, and then checks that the agent converges
+// to a consistent view of the distro once the network heals. Each case is a different seed, so that
+// failures can be reproduced.
+func TestChaos(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	testCases := map[string]struct {
+		seed int64
+	}{
+		"Converges after the fault sequence of seed 1": {seed: 1},
+		"Converges after the fault sequence of seed 2": {seed: 2},
+		"Converges after the fault sequence of seed 3": {seed: 3},
+		"Converges after the fault sequence of seed 4": {seed: 4},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: could not create empty database")
+
+			service := wslinstance.New(ctx, db, &landscapeCtlMock{}, &mockConfig{})
+			server := grpc.NewServer()
+			agentapi.RegisterWSLInstanceServer(server, service)
+
+			lis, err := (&net.ListenConfig{}).Listen(ctx, "tcp4", "127.0.0.1:0")
+			require.NoError(t, err, "Setup: could not listen to dynamically-allocated port")
+			defer lis.Close()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			defer wg.Wait()
+			go func() {
+				defer wg.Done()
+				err := server.Serve(lis)
+				if err != nil {
+					t.Logf("Serve exited with error: %v", err)
+				}
+			}()
+			defer server.Stop()
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+			chaos := &testutils.Chaos{}
+			opts := mockWslProServiceOptions{
+				address:    lis.Addr().String(),
+				distroName: distroName,
+				chaos:      chaos,
+			}
+
+			// Like the WSL Pro service, a new connection is only attempted once the previous one is gone.
+			var services []*mockWSLProService
+			reconnect := func() {
+				if len(services) > 0 && services[len(services)-1].ctx.Err() == nil {
+					return
+				}
+				require.Eventually(t, func() bool {
+					d, ok := db.Get(distroName)
+					if !ok {
+						return true
+					}
+					conn, err := d.Connection()
+					return err == nil && conn == nil
+				}, time.Minute, 100*time.Millisecond, "The agent should forget the lost connection")
+
+				wps := newMockWSLProService(t, ctx, opts)
+				t.Cleanup(wps.Stop)
+				services = append(services, wps)
+			}
+			reconnect()
+
+			rng := rand.New(rand.NewSource(tc.seed))
+			// The handshake sends no hostname.
+			sent := map[string]bool{"": true}
+
+			const steps = 4
+			partitioned := false
+			for i := range steps {
+				switch rng.Intn(4) {
+				case 0:
+					t.Logf("Step %d: resetting the connection", i)
+					chaos.Reset()
+				case 1:
+					n := rng.Intn(64)
+					t.Logf("Step %d: truncating the connection after %d bytes", i, n)
+					chaos.Truncate(n)
+				case 2:
+					delay := time.Duration(rng.Intn(500)) * time.Millisecond
+					t.Logf("Step %d: delaying messages by %s", i, delay)
+					chaos.Delay(delay)
+				case 3:
+					t.Logf("Step %d: partitioning the network", i)
+					chaos.Partition()
+					partitioned = true
+				}
+
+				// The info may or may not make it through: what matters is that it is either applied whole or not at all.
+				hostname := fmt.Sprintf("host-%d", i)
+				sent[hostname] = true
+				_ = services[len(services)-1].connStream.Send(&agentapi.DistroMessage{Data: &agentapi.DistroMessage_Info{Info: &agentapi.DistroInfo{WslName: distroName, Hostname: hostname}}})
+
+				time.Sleep(time.Duration(rng.Intn(1000)) * time.Millisecond)
+
+				if rng.Intn(2) == 0 {
+					t.Logf("Step %d: healing the network", i)
+					chaos.Heal()
+					partitioned = false
+				}
+				if !partitioned {
+					reconnect()
+				}
+			}
+
+			chaos.Heal()
+
+			// Convergence: the WSL Pro service reconnects and re-sends its info until the agent applies it.
+			const final = "final-host"
+			sent[final] = true
+			var conn worker.Connection
+			for deadline := time.Now().Add(2 * time.Minute); ; time.Sleep(time.Second) {
+				require.True(t, time.Now().Before(deadline), "The agent should have reconnected and applied the final info")
+
+				reconnect()
+				_ = services[len(services)-1].connStream.Send(&agentapi.DistroMessage{Data: &agentapi.DistroMessage_Info{Info: &agentapi.DistroInfo{WslName: distroName, Hostname: final}}})
+
+				d, ok := db.Get(distroName)
+				if !ok {
+					continue
+				}
+				require.True(t, sent[d.Properties().Hostname], "The agent stored a hostname that was never sent whole: %q", d.Properties().Hostname)
+
+				c, err := d.Connection()
+				if err == nil && c != nil && d.Properties().Hostname == final {
+					conn = c
+					break
+				}
+			}
+			wps := services[len(services)-1]
+
+			require.Len(t, db.GetAll(), 1, "The agent should know the distro only once")
+			d, _ := db.Get(distroName)
+
+			require.NoError(t, conn.SendProAttachment("final-token"), "Commands should reach the WSL Pro service after the network heals")
+			require.Equal(t, int32(1), wps.attachments.Load(), "The command should have reached the last connection")
+			for _, old := range services[:len(services)-1] {
+				require.Zero(t, old.attachments.Load(), "The command should not have reached a lost connection")
+			}
+
+			echo, err := conn.Ping(ctx, []byte("payload"))
+			require.NoError(t, err, "Pings should be answered after the network heals")
+			require.Equal(t, "payload", string(echo), "Ping should return the payload echoed")
+
+			wps.Stop()
+			require.Eventually(t, func() bool {
+				c, err := d.Connection()
+				return err == nil && c == nil
+			}, time.Minute, 100*time.Millisecond, "The agent should forget the connection once it is closed")
+		})
+	}
+}
+
+func proServiceCmd(service string) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_ProService{
+			ProService: &agentapi.ProServiceCmd{Service: service, Enable: true},
+		},
+	}
+}
+
+// landscapeCtlMock mocks the landscape client.
+//
+// disconnected and err are inputs to manipulate mock behaviour.
+// updateCount is used to assert that the SendUpdatedInfo function has been called.
+type landscapeCtlMock struct {
+	disconnected bool
+	err          bool
+
+	updateCount atomic.Int32
+}
+
+func (c *landscapeCtlMock) SendUpdatedInfo(ctx context.Context) error {
+	c.updateCount.Add(1)
+
+	if c.disconnected {
+		return errors.New("Sending updated info to disconnected landscape")
+	}
+
+	if c.err {
+		return errors.New("mock error")
+	}
+	return nil
+}
+
+// mockWSLProService mocks the actions performed by the Linux-side client and services.
+type mockWSLProService struct {
+	connStream agentapi.WSLInstance_SessionClient
+	proStream  agentapi.WSLInstance_ProAttachmentCommandsClient
+	lpeStream  agentapi.WSLInstance_LandscapeConfigCommandsClient
+	cmdStream  agentapi.WSLInstance_CommandsClient
+	logStream  agentapi.WSLInstance_TailLogClient
+	pingStream agentapi.WSLInstance_PingClient
+
+	// ack is the answer of the agent to the handshake, if any.
+	ack *agentapi.HandshakeAck
+	// handshakeErr is the error the agent rejected the handshake with, if any.
+	handshakeErr error
+
+	// upgrades is the number of service upgrade commands received.
+	upgrades atomic.Int32
+	// attachments is the number of pro attachment commands received.
+	attachments atomic.Int32
+
+	// ctx is done once the mock stops serving.
+	ctx     context.Context
+	cancel  func()
+	conn    *grpc.ClientConn
+	running sync.WaitGroup
+}
+
+type mockWslProServiceOptions struct {
+	address    string
+	distroName string
+
+	// protocolVersion defaults to the one of the agent.
+	protocolVersion uint32
+	// capabilities default to all of them.
+	capabilities []agentapi.Capability
+	// legacyHandshake opens the Connected stream and sends a bare DistroInfo, as done before the Handshake existed.
+	legacyHandshake bool
+	// serviceVersion is the version of the WSL Pro service reported in the handshake.
+	serviceVersion string
+	// wslVersion is the version of WSL reported in the first DistroInfo.
+	wslVersion int32
+	// chaos, if set, injects network faults into what is sent to the agent.
+	chaos *testutils.Chaos
+
+	noHandshakeConnected         bool
+	noHandshakeProCommands       bool
+	noHandshakeLandscapeCommands bool
+	noHandshakeCommands          bool
+}
+
+// newMockWSLProService creates a wslDistroMock, establishing a connection to the control stream.
+//
+//nolint:revive // testing.T should go before context, regardless of what these linters say.
+func newMockWSLProService(t testing.TB, ctx context.Context, opt mockWslProServiceOptions) (mock *mockWSLProService) {
+	t.Helper()
+
+	mock = &mockWSLProService{}
+
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if opt.chaos != nil {
+		dialOpts = append(dialOpts, grpc.WithContextDialer(opt.chaos.Dial))
+	}
+
+	conn, err := grpc.NewClient(opt.address, dialOpts...)
+	require.NoError(t, err, "wslDistroMock: could not setup a control address client")
+
+	ctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	mock.conn = conn
+	mock.ctx = ctx
+	mock.cancel = cancel
+
+	c := agentapi.NewWSLInstanceClient(conn)
+
+	if opt.legacyHandshake {
+		mock.legacyHandshake(t, ctx, c, opt.distroName)
+	} else {
+		mock.connStream, err = c.Session(ctx)
+		require.NoError(t, err, "wslDistroMock: could not connect to Session stream")
+	}
+
+	if !opt.noHandshakeConnected && !opt.legacyHandshake {
+		mock.handshake(t, opt)
+	}
+
+	mock.proStream, err = c.ProAttachmentCommands(ctx)
+	require.NoError(t, err, "wslDistroMock: could not connect to ProAttachmentCommands stream")
+	if !opt.noHandshakeProCommands {
+		err = sendWslName(mock.proStream.Send, opt.distroName)
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via ProAttachmentCommands stream")
+	}
+
+	mock.lpeStream, err = c.LandscapeConfigCommands(ctx)
+	require.NoError(t, err, "wslDistroMock: could not connect to LandscapeConfigCommands stream")
+	if !opt.noHandshakeLandscapeCommands {
+		err = sendWslName(mock.lpeStream.Send, opt.distroName)
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via LandscapeConfigCommands stream")
+	}
+
+	// Like the WSL Pro service, the streams opened after the handshake are compressed once it is negotiated.
+	var callOpts []grpc.CallOption
+	if slices.Contains(mock.ack.GetCapabilities(), agentapi.Capability_CAPABILITY_COMPRESSION) {
+		callOpts = append(callOpts, grpc.UseCompressor(compression.Name))
+	}
+
+	mock.cmdStream, err = c.Commands(ctx, callOpts...)
+	require.NoError(t, err, "wslDistroMock: could not connect to Commands stream")
+	if !opt.noHandshakeCommands {
+		err = sendWslName(mock.cmdStream.Send, opt.distroName)
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via Commands stream")
+	}
+
+	mock.running.Add(3)
+	go mock.replyProAttachmentCommands(t)
+	go mock.replyLandscapeConfigCommands(t)
+	go mock.replyCommands(t)
+
+	// Like the WSL Pro service, the TailLog stream is only opened once the capability is negotiated.
+	if slices.Contains(mock.ack.GetCapabilities(), agentapi.Capability_CAPABILITY_LOGS) {
+		mock.logStream, err = c.TailLog(ctx, callOpts...)
+		require.NoError(t, err, "wslDistroMock: could not connect to TailLog stream")
+		err = mock.logStream.Send(&agentapi.LogMessage{WslName: opt.distroName})
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via TailLog stream")
+
+		mock.running.Add(1)
+		go mock.replyTailLog(t)
+	}
+
+	if slices.Contains(mock.ack.GetCapabilities(), agentapi.Capability_CAPABILITY_PING) {
+		mock.pingStream, err = c.Ping(ctx, callOpts...)
+		require.NoError(t, err, "wslDistroMock: could not connect to Ping stream")
+		err = mock.pingStream.Send(&agentapi.PingReply{WslName: opt.distroName})
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via Ping stream")
+
+		mock.running.Add(1)
+		go mock.replyPings(t)
+	}
+
+	return mock
+}
+
+// legacyHandshake connects like the WSL Pro services that predate the handshake: via the Connected stream,
+// starting with a bare DistroInfo.
+//
+//nolint:revive // testing.T should go before context, regardless of what these linters say.
+func (m *mockWSLProService) legacyHandshake(t testing.TB, ctx context.Context, c agentapi.WSLInstanceClient, distroName string) {
+	t.Helper()
+
+	stream, err := c.Connected(ctx)
+	require.NoError(t, err, "wslDistroMock: could not connect to Connected stream")
+
+	err = stream.Send(&agentapi.DistroInfo{
+		WslName:     distroName,
+		Id:          "ubuntu",
+		VersionId:   "22.04",
+		PrettyName:  "Ubuntu 22.04.1 LTS",
+		ProAttached: true,
+		Hostname:    "testMachine",
+	})
+	require.NoError(t, err, "wslDistroMock: could not send legacy info via Connected stream")
+
+	// The agent may reject the stream before it is closed, so only its answer matters.
+	_, m.handshakeErr = stream.CloseAndRecv()
+}
+
+// handshake performs the handshake of the Session stream, and sends the first DistroInfo.
+func (m *mockWSLProService) handshake(t testing.TB, opt mockWslProServiceOptions) {
+	t.Helper()
+
+	info := &agentapi.DistroMessage{Data: &agentapi.DistroMessage_Info{Info: &agentapi.DistroInfo{WslName: opt.distroName, WslVersion: opt.wslVersion}}}
+
+	version := opt.protocolVersion
+	if version == 0 {
+		version = common.WSLInstanceProtocolVersion
+	}
+
+	caps := opt.capabilities
+	if caps == nil {
+		caps = []agentapi.Capability{agentapi.Capability_CAPABILITY_EXEC, agentapi.Capability_CAPABILITY_FILE_PUSH, agentapi.Capability_CAPABILITY_LOGS, agentapi.Capability_CAPABILITY_PING}
+	}
+
+	err := m.connStream.Send(&agentapi.DistroMessage{Data: &agentapi.DistroMessage_Handshake{Handshake: &agentapi.Handshake{
+		ProtocolVersion: version,
+		WslName:         opt.distroName,
+		Capabilities:    caps,
+		ServiceVersion:  opt.serviceVersion,
+	}}})
+	require.NoError(t, err, "wslDistroMock: could not send handshake via Session stream")
+
+	// The agent may reject the handshake, which is checked by the tests.
+	m.ack, m.handshakeErr = m.connStream.Recv()
+
+	// The stream may be closed already if the handshake was rejected.
+	_ = m.connStream.Send(info)
+}
+
+func sendWslName(send func(*agentapi.MSG) error, wslName string) error {
+	return send(&agentapi.MSG{
+		Data: &agentapi.MSG_WslName{
+			WslName: wslName,
+		},
+	})
+}
+
+func sendResult(send func(*agentapi.MSG) error, result error) error {
+	var errMsg string
+	if result != nil {
+		errMsg = result.Error()
+	}
+
+	return send(&agentapi.MSG{
+		Data: &agentapi.MSG_Result{
+			Result: errMsg,
+		},
+	})
+}
+
+// Stop stops the Linux-side service.
+func (m *mockWSLProService) Stop() {
+	m.cancel()
+	m.conn.Close()
+	m.running.Wait()
+}
+
+func (m *mockWSLProService) requireDone(t *testing.T, timeout time.Duration, msg string, args ...any) {
+	t.Helper()
+
+	ch := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(ch)
+	}()
+
+	select {
+	case <-ch:
+		return
+	case <-time.After(timeout):
+	}
+
+	require.Failf(t, "WSL Pro service was not done", msg, args...)
+}
+
+func (m *mockWSLProService) replyProAttachmentCommands(t testing.TB) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		msg, err := m.proStream.Recv()
+		if err != nil {
+			log.Warningf("%s: Could not receive pro command: %v", t.Name(), err)
+			return
+		}
+
+		m.attachments.Add(1)
+
+		var send error
+		if msg.GetToken() == "MOCK_ERROR" {
+			send = errors.New("mock error")
+		}
+
+		err = sendResult(m.proStream.Send, send)
+		if err != nil {
+			log.Warningf("%s: Could not send pro command result: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
+func (m *mockWSLProService) replyLandscapeConfigCommands(t testing.TB) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		msg, err := m.lpeStream.Recv()
+		if err != nil {
+			log.Warningf("%s: Could not receive Landscape command: %v", t.Name(), err)
+			return
+		}
+
+		var send error
+		if msg.GetConfig() == "MOCK_ERROR" {
+			send = errors.New("mock error")
+		}
+
+		if msg.GetConfig() == "PROGRESS" {
+			err = m.lpeStream.Send(&agentapi.MSG{Data: &agentapi.MSG_Progress{Progress: &agentapi.TaskProgress{Percent: 50, Step: "Registering with Landscape"}}})
+			if err != nil {
+				log.Warningf("%s: Could not send Landscape command progress: %v", t.Name(), err)
+				m.Stop()
+				return
+			}
+		}
+
+		err = sendResult(m.lpeStream.Send, send)
+		if err != nil {
+			log.Warningf("%s: Could not send Landscape command result: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
+func (m *mockWSLProService) replyCommands(t testing.TB) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		msg, err := m.cmdStream.Recv()
+		if err != nil {
+			log.Warningf("%s: Could not receive command: %v", t.Name(), err)
+			return
+		}
+
+		if msg.GetPreempt() != nil {
+			// No command in progress: nothing to preempt
+			continue
+		}
+
+		var send error
+		if msg.GetId() == 0 {
+			send = errors.New("mock error: command without id")
+		}
+
+		if msg.GetServiceUpgrade() != nil {
+			m.upgrades.Add(1)
+		}
+
+		if msg.GetProService().GetService() == "MOCK_ERROR" {
+			send = errors.New("mock error")
+		}
+
+		reply := &agentapi.MSG{}
+		if msg.GetServiceUpgrade().GetChannel() == "PREEMPTIBLE" {
+			// Mock a long command that runs until it is preempted. Only the preemptions of this command count.
+			for next := msg; next.GetPreempt() == nil || next.GetPreempt().GetId() != msg.GetId(); {
+				if next, err = m.cmdStream.Recv(); err != nil {
+					log.Warningf("%s: Could not receive preemption: %v", t.Name(), err)
+					return
+				}
+			}
+			send = errors.New("mock error: preempted")
+			reply.Preempted = true
+		}
+		if send != nil {
+			reply.Data = &agentapi.MSG_Result{Result: send.Error()}
+		} else {
+			reply.Data = &agentapi.MSG_Result{}
+		}
+		if profile := msg.GetUsg().GetProfile(); profile != "" {
+			reply.Output = []byte("<html>" + profile + "</html>")
+		}
+		if msg.GetUsg().GetProfile() == "chunked" {
+			// Mock an output too large for a single message
+			for _, chunk := range []string{"<html>", "chunked"} {
+				if err := m.cmdStream.Send(&agentapi.MSG{Output: []byte(chunk), More: true}); err != nil {
+					log.Warningf("%s: Could not send command output: %v", t.Name(), err)
+					m.Stop()
+					return
+				}
+			}
+			reply.Output = []byte("</html>")
+		}
+		if msg.GetUsg().GetProfile() == "progress" {
+			// Mock a long command that tells how far it is
+			for _, p := range []*agentapi.TaskProgress{{Percent: 30, Step: "Auditing"}, {Percent: 60, Step: "Reporting"}} {
+				if err := m.cmdStream.Send(&agentapi.MSG{Data: &agentapi.MSG_Progress{Progress: p}}); err != nil {
+					log.Warningf("%s: Could not send command progress: %v", t.Name(), err)
+					m.Stop()
+					return
+				}
+			}
+		}
+
+		err = m.cmdStream.Send(reply)
+		if err != nil {
+			log.Warningf("%s: Could not send command result: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
+// replyTailLog answers every TailLog request with a single line. Followed requests are only
+// finished once cancelled.
+func (m *mockWSLProService) replyTailLog(t testing.TB) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		msg, err := m.logStream.Recv()
+		if err != nil {
+			log.Warningf("%s: Could not receive TailLog request: %v", t.Name(), err)
+			return
+		}
+
+		var replies []*agentapi.LogMessage
+		switch {
+		case msg.GetCancel():
+			replies = append(replies, &agentapi.LogMessage{Id: msg.GetId(), Done: true})
+		case msg.GetLines() < 0:
+			replies = append(replies, &agentapi.LogMessage{Id: msg.GetId(), Done: true, Error: "mock error"})
+		default:
+			replies = append(replies, &agentapi.LogMessage{Id: msg.GetId(), Line: "mock log line"})
+			if !msg.GetFollow() {
+				replies = append(replies, &agentapi.LogMessage{Id: msg.GetId(), Done: true})
+			}
+		}
+
+		for _, r := range replies {
+			if err := m.logStream.Send(r); err != nil {
+				log.Warningf("%s: Could not send TailLog reply: %v", t.Name(), err)
+				m.Stop()
+				return
+			}
+		}
+	}
+}
+
+// replyPings echoes every ping, except those whose payload asks for no reply.
+func (m *mockWSLProService) replyPings(t testing.TB) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		msg, err := m.pingStream.Recv()
+		if err != nil {
+			log.Warningf("%s: Could not receive ping: %v", t.Name(), err)
+			return
+		}
+
+		if string(msg.GetPayload()) == "NO_REPLY" {
+			continue
+		}
+
+		if err := m.pingStream.Send(&agentapi.PingReply{Id: msg.GetId(), Payload: msg.GetPayload()}); err != nil {
+			log.Warningf("%s: Could not send ping reply: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
+// sendInfo sends the specified info from the Linux-side client to the wslinstance service.
+func (m *mockWSLProService) sendInfo(t *testing.T, info *agentapi.DistroInfo) {
+	t.Helper()
+
+	err := m.connStream.Send(&agentapi.DistroMessage{Data: &agentapi.DistroMessage_Info{Info: info}})
+	require.NoError(t, err, "wslDistroMock SendInfo expected no errors")
+}
+
+type mockConfig struct {
+	minVersion   string
+	minVersionMu sync.Mutex
+	channel      config.UpdateChannel
+	landscape    string
+	err          bool
+
+	token   string
+	tokenMu sync.Mutex
+
+	// groupTokens are the tokens of the distros in a distro group, by distro name.
+	groupTokens map[string]string
+
+	// stalled makes SubscriptionFor block until release is closed, like a configuration that got stuck.
+	stalled atomic.Bool
+	release chan struct{}
+}
+
+func (c *mockConfig) MinimumServiceVersion() (string, error) {
+	if c.err {
+		return "", errors.New("mock error")
+	}
+
+	c.minVersionMu.Lock()
+	defer c.minVersionMu.Unlock()
+
+	return c.minVersion, nil
+}
+
+func (c *mockConfig) setMinimumServiceVersion(v string) {
+	c.minVersionMu.Lock()
+	defer c.minVersionMu.Unlock()
+
+	c.minVersion = v
+}
+
+// mockUpgrader records the upgrades instead of submitting them.
+type mockUpgrader struct {
+	channels []string
+	mu       sync.Mutex
+}
+
+func (u *mockUpgrader) UpgradeDistro(_ *distro.Distro, channel config.UpdateChannel) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.channels = append(u.channels, channel.Channel)
+}
+
+func (u *mockUpgrader) upgrades() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return append([]string{}, u.channels...)
+}
+
+func (c *mockConfig) UpdateChannel() (config.UpdateChannel, error) {
+	if c.err {
+		return config.UpdateChannel{}, errors.New("mock error")
+	}
+	return c.channel, nil
+}
+
+func (c *mockConfig) SubscriptionFor(distroName string) (string, config.Source, error) {
+	if c.stalled.Load() {
+		<-c.release
+	}
+
+	if c.err {
+		return "", config.SourceNone, errors.New("mock error")
+	}
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if t, ok := c.groupTokens[distroName]; ok {
+		return t, config.SourceDistroGroup, nil
+	}
+
+	return c.token, config.SourceUser, nil
+}
+
+func (c *mockConfig) setToken(token string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	c.token = token
+}
+
+func (c *mockConfig) LandscapeClientConfigFor(string) (string, config.Source, error) {
+	if c.err {
+		return "", config.SourceNone, errors.New("mock error")
+	}
+	return c.landscape, config.SourceUser, nil
 }
 
 type testTask struct {
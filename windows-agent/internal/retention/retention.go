@@ -0,0 +1,295 @@
+// Package retention keeps the data the agent stores on disk from growing unbounded. A janitor
+// periodically enforces a retention policy on the task storage, the log file, the database
+// backups it takes and the error records (e.g. the crash records of the sandboxed process).
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/maintenance"
+	"github.com/ubuntu/decorate"
+)
+
+// Policy states how much of each kind of data is kept. Zero values are replaced by the defaults,
+// and negative values disable the corresponding limit.
+type Policy struct {
+	// TaskHistoryDays is how many days the task storage of a distro can go unused before it is removed.
+	TaskHistoryDays int `mapstructure:"task_history_days"`
+
+	// MaxLogSizeMB is the size in megabytes above which the log file is archived and started afresh.
+	MaxLogSizeMB int `mapstructure:"max_log_size_mb"`
+
+	// MaxErrorRecords is how many error records (e.g. crash reports) are kept.
+	MaxErrorRecords int `mapstructure:"max_error_records"`
+
+	// MaxBackups is how many daily database backups are kept. Disabling it disables the backups.
+	MaxBackups int `mapstructure:"max_backups"`
+}
+
+// DefaultPolicy is the policy used when none is configured.
+var DefaultPolicy = Policy{
+	TaskHistoryDays: 90,
+	MaxLogSizeMB:    10,
+	MaxErrorRecords: 20,
+	MaxBackups:      5,
+}
+
+// withDefaults returns a copy of the policy with the unset values replaced by the default ones.
+func (p Policy) withDefaults() Policy {
+	def := func(v *int, d int) {
+		if *v == 0 {
+			*v = d
+		}
+	}
+
+	def(&p.TaskHistoryDays, DefaultPolicy.TaskHistoryDays)
+	def(&p.MaxLogSizeMB, DefaultPolicy.MaxLogSizeMB)
+	def(&p.MaxErrorRecords, DefaultPolicy.MaxErrorRecords)
+	def(&p.MaxBackups, DefaultPolicy.MaxBackups)
+
+	return p
+}
+
+// Database is the distro database, where the task queues of the distros are stored as well.
+type Database interface {
+	CleanupTaskStorage(ctx context.Context, retention time.Duration) (reclaimed int64, err error)
+	Backup(path string) error
+}
+
+// Janitor enforces a retention policy.
+type Janitor struct {
+	policy Policy
+	db     Database
+
+	logFile         string
+	backupsDir      string
+	errorRecordsDir string
+
+	// schedule, if set, returns the maintenance schedule the policy is enforced within.
+	schedule func() maintenance.Schedule
+}
+
+// Option is an optional argument for New.
+type Option func(*Janitor)
+
+// WithSchedule makes the janitor enforce the policy only while the maintenance schedule returned by
+// the function is open, as backing up the database and archiving the log are best kept out of the
+// user's way.
+func WithSchedule(schedule func() maintenance.Schedule) Option {
+	return func(j *Janitor) {
+		j.schedule = schedule
+	}
+}
+
+// interval is the time between consecutive enforcements of the policy.
+const interval = time.Hour
+
+// backupInterval is the minimum time between consecutive backups of the database.
+const backupInterval = 24 * time.Hour
+
+// New creates a janitor that enforces the policy on the data in the agent's public and private directories.
+func New(policy Policy, db Database, publicDir, privateDir string, args ...Option) *Janitor {
+	j := &Janitor{
+		policy:          policy.withDefaults(),
+		db:              db,
+		logFile:         filepath.Join(publicDir, consts.LogFileName),
+		backupsDir:      filepath.Join(privateDir, consts.DatabaseBackupsDir),
+		errorRecordsDir: filepath.Join(privateDir, consts.ErrorRecordsDir),
+	}
+
+	for _, f := range args {
+		f(j)
+	}
+
+	return j
+}
+
+// Run enforces the policy right away, and then periodically until the context is cancelled. With a
+// maintenance schedule, each enforcement waits for the schedule to be open.
+func (j *Janitor) Run(ctx context.Context) {
+	defer crashreport.Recover("retention")
+	for {
+		if j.schedule != nil {
+			if err := maintenance.Wait(ctx, j.schedule); err != nil {
+				return
+			}
+		}
+
+		if err := j.Clean(ctx); err != nil {
+			log.Warningf(ctx, "Retention: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Clean enforces the policy once.
+func (j *Janitor) Clean(ctx context.Context) (err error) {
+	defer decorate.OnError(&err, "could not enforce the retention policy")
+
+	var errs error
+
+	if days := j.policy.TaskHistoryDays; days > 0 {
+		if _, err := j.db.CleanupTaskStorage(ctx, time.Duration(days)*24*time.Hour); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	if mb := j.policy.MaxLogSizeMB; mb > 0 {
+		if err := archiveLog(ctx, j.logFile, int64(mb)<<20); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	if n := j.policy.MaxErrorRecords; n > 0 {
+		if err := keepNewest(ctx, j.errorRecordsDir, n); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	if n := j.policy.MaxBackups; n > 0 {
+		if err := j.backup(ctx); err != nil {
+			errs = errors.Join(errs, err)
+		}
+		if err := keepNewest(ctx, j.backupsDir, n); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// backup takes a backup of the database, unless the newest one is recent enough.
+func (j *Janitor) backup(ctx context.Context) (err error) {
+	defer decorate.OnError(&err, "could not back up the database")
+
+	entries, err := os.ReadDir(j.backupsDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) < backupInterval {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(j.backupsDir, 0700); err != nil {
+		return err
+	}
+
+	path := filepath.Join(j.backupsDir, fmt.Sprintf("distros-%s.db", time.Now().UTC().Format("20060102T150405Z")))
+	if err := j.db.Backup(path); err != nil {
+		return err
+	}
+
+	log.Debugf(ctx, "Retention: backed up the database to %s", path)
+	return nil
+}
+
+// archiveLog moves the contents of the log file into its archive (the same file with the .old
+// extension) once it grows larger than maxSize. The log file is truncated rather than replaced,
+// as the logger keeps it open.
+func archiveLog(ctx context.Context, path string, maxSize int64) (err error) {
+	defer decorate.OnError(&err, "could not archive log file")
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if info.Size() <= maxSize {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".old", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		return err
+	}
+
+	log.Infof(ctx, "Retention: log file grew to %d bytes: archived it", info.Size())
+	return nil
+}
+
+// keepNewest removes all but the n most recently modified entries in dir.
+func keepNewest(ctx context.Context, dir string, n int) (err error) {
+	defer decorate.OnError(&err, "could not prune %s", dir)
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if len(entries) <= n {
+		return nil
+	}
+
+	type entry struct {
+		name    string
+		modTime time.Time
+	}
+
+	var all []entry
+	for _, e := range entries {
+		info, err := e.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed in the meantime.
+			continue
+		} else if err != nil {
+			return err
+		}
+		all = append(all, entry{name: e.Name(), modTime: info.ModTime()})
+	}
+
+	// Newest first.
+	slices.SortFunc(all, func(a, b entry) int { return b.modTime.Compare(a.modTime) })
+
+	var errs error
+	for _, e := range all[min(n, len(all)):] {
+		if err := os.RemoveAll(filepath.Join(dir, e.name)); err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		log.Debugf(ctx, "Retention: removed %s", filepath.Join(dir, e.name))
+	}
+
+	return errs
+}
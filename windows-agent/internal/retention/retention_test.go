@@ -0,0 +1,269 @@
+package retention_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/maintenance"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/retention"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClean(t *testing.T) {
+	t.Parallel()
+
+	const mb = 1 << 20
+
+	testCases := map[string]struct {
+		policy       retention.Policy
+		logSize      int
+		errorRecords int
+		backups      int
+		oldBackups   bool
+		taskErr      bool
+		backupErr    bool
+
+		wantTaskRetention time.Duration
+		wantLogArchived   bool
+		wantErrorRecords  int
+		wantBackups       int
+		wantNewBackup     bool
+		wantErr           bool
+	}{
+		"Success with nothing to clean up": {wantTaskRetention: 90 * 24 * time.Hour, wantBackups: 1, wantNewBackup: true},
+		"Success enforcing the default policy": {
+			logSize: 11 * mb, errorRecords: 25, backups: 7,
+			wantTaskRetention: 90 * 24 * time.Hour, wantLogArchived: true, wantErrorRecords: 20, wantBackups: 5,
+		},
+		"Success with data within the default policy": {
+			logSize: 9 * mb, errorRecords: 20, backups: 5,
+			wantTaskRetention: 90 * 24 * time.Hour, wantErrorRecords: 20, wantBackups: 5,
+		},
+		"Success enforcing a custom policy": {
+			policy:  retention.Policy{TaskHistoryDays: 7, MaxLogSizeMB: 1, MaxErrorRecords: 2, MaxBackups: 1},
+			logSize: 2 * mb, errorRecords: 5, backups: 3,
+			wantTaskRetention: 7 * 24 * time.Hour, wantLogArchived: true, wantErrorRecords: 2, wantBackups: 1,
+		},
+		"Success taking a backup when the newest one is a day old": {
+			backups: 5, oldBackups: true,
+			wantTaskRetention: 90 * 24 * time.Hour, wantBackups: 5, wantNewBackup: true,
+		},
+		"Success with every limit disabled": {
+			policy:  retention.Policy{TaskHistoryDays: -1, MaxLogSizeMB: -1, MaxErrorRecords: -1, MaxBackups: -1},
+			logSize: 11 * mb, errorRecords: 25, backups: 7, oldBackups: true,
+			wantErrorRecords: 25, wantBackups: 7,
+		},
+
+		"Error when the task storage cannot be cleaned up": {
+			taskErr: true, backups: 7,
+			wantTaskRetention: 90 * 24 * time.Hour, wantBackups: 5, wantErr: true,
+		},
+		"Error when the database cannot be backed up": {
+			backupErr: true, backups: 7, oldBackups: true,
+			wantTaskRetention: 90 * 24 * time.Hour, wantBackups: 5, wantErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			publicDir := t.TempDir()
+			privateDir := t.TempDir()
+
+			logFile := filepath.Join(publicDir, consts.LogFileName)
+			if tc.logSize > 0 {
+				err := os.WriteFile(logFile, make([]byte, tc.logSize), 0600)
+				require.NoError(t, err, "Setup: could not write log file")
+			}
+
+			errorRecordsDir := filepath.Join(privateDir, consts.ErrorRecordsDir)
+			newest := writeEntries(t, errorRecordsDir, tc.errorRecords, time.Hour)
+
+			backupAge := time.Hour
+			if tc.oldBackups {
+				backupAge = 25 * time.Hour
+			}
+			backupsDir := filepath.Join(privateDir, consts.DatabaseBackupsDir)
+			writeEntries(t, backupsDir, tc.backups, backupAge)
+
+			db := &mockDatabase{taskErr: tc.taskErr, backupErr: tc.backupErr}
+			j := retention.New(tc.policy, db, publicDir, privateDir)
+
+			err := j.Clean(context.Background())
+			if tc.wantErr {
+				require.Error(t, err, "Clean should return an error")
+			} else {
+				require.NoError(t, err, "Clean should return no error")
+			}
+
+			require.Equal(t, tc.wantTaskRetention, time.Duration(db.gotRetention.Load()), "Mismatched task storage retention")
+
+			if tc.wantLogArchived {
+				require.FileExists(t, logFile, "Log file should not be removed, as the logger keeps it open")
+				requireFileSize(t, logFile, 0, "Log file should have been truncated")
+				requireFileSize(t, logFile+".old", tc.logSize, "Log file contents should have been archived")
+			} else {
+				require.NoFileExists(t, logFile+".old", "Log file should not have been archived")
+			}
+
+			requireEntries(t, errorRecordsDir, tc.wantErrorRecords)
+			requireEntries(t, backupsDir, tc.wantBackups)
+
+			backups, _ := filepath.Glob(filepath.Join(backupsDir, "distros-*.db"))
+			if tc.wantNewBackup {
+				require.Len(t, backups, 1, "A backup of the database should have been taken")
+				out, err := os.ReadFile(backups[0])
+				require.NoError(t, err, "Could not read the backup")
+				require.Equal(t, "backup", string(out), "The backup should have been written by the database")
+			} else {
+				require.Empty(t, backups, "No backup of the database should have been taken")
+			}
+
+			if tc.wantErrorRecords > 0 {
+				require.FileExists(t, filepath.Join(errorRecordsDir, newest), "The newest error record should have been kept")
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	// A window that opens in a couple of hours, so that it is closed during the test.
+	opening := time.Now().Add(2 * time.Hour)
+	closed, err := maintenance.ParseWindows(fmt.Sprintf("%s %s-%s", opening.Format("Mon"), opening.Format("15:04"), opening.Add(time.Minute).Format("15:04")))
+	require.NoError(t, err, "Setup: could not parse the closed window")
+
+	open, err := maintenance.ParseWindows("daily 00:00-24:00")
+	require.NoError(t, err, "Setup: could not parse the open window")
+
+	testCases := map[string]struct {
+		windows     []maintenance.Window
+		noSchedule  bool
+		wantCleaned bool
+	}{
+		"Success enforcing the policy right away without a schedule": {noSchedule: true, wantCleaned: true},
+		"Success enforcing the policy while the schedule is open":    {windows: open, wantCleaned: true},
+
+		"Policy is not enforced until the schedule opens": {windows: closed},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+
+			var args []retention.Option
+			if !tc.noSchedule {
+				args = append(args, retention.WithSchedule(func() maintenance.Schedule {
+					return maintenance.Schedule{Windows: tc.windows}
+				}))
+			}
+
+			db := &mockDatabase{}
+			j := retention.New(retention.Policy{}, db, t.TempDir(), t.TempDir(), args...)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				j.Run(ctx)
+			}()
+
+			if tc.wantCleaned {
+				require.Eventually(t, func() bool { return db.called() }, 5*time.Second, 100*time.Millisecond,
+					"Run should enforce the policy right away")
+			} else {
+				require.Never(t, func() bool { return db.called() }, time.Second, 100*time.Millisecond,
+					"Run should not enforce the policy while the schedule is closed")
+			}
+
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "Run should return after the context is cancelled")
+			}
+		})
+	}
+}
+
+// writeEntries creates n files in dir, each one an hour more recent than the previous one, the newest
+// one being as old as age, and returns its name.
+func writeEntries(t *testing.T, dir string, n int, age time.Duration) (newest string) {
+	t.Helper()
+
+	if n == 0 {
+		return ""
+	}
+
+	require.NoError(t, os.MkdirAll(dir, 0700), "Setup: could not create directory")
+
+	start := time.Now().Add(-age - time.Duration(n-1)*time.Hour)
+	for i := range n {
+		newest = fmt.Sprintf("entry-%02d", i)
+		path := filepath.Join(dir, newest)
+
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0600), "Setup: could not write file")
+
+		modTime := start.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(path, modTime, modTime), "Setup: could not set file modification time")
+	}
+
+	return newest
+}
+
+func requireEntries(t *testing.T, dir string, want int) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if want == 0 {
+		require.True(t, err != nil || len(entries) == 0, "Directory %s should be empty or missing", dir)
+		return
+	}
+
+	require.NoError(t, err, "Could not read directory %s", dir)
+	require.Len(t, entries, want, "Mismatched number of entries in %s", dir)
+}
+
+func requireFileSize(t *testing.T, path string, want int, msg string) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err, "Could not stat %s", path)
+	require.EqualValues(t, want, info.Size(), msg)
+}
+
+type mockDatabase struct {
+	taskErr   bool
+	backupErr bool
+
+	gotRetention atomic.Int64
+}
+
+func (m *mockDatabase) CleanupTaskStorage(_ context.Context, retention time.Duration) (int64, error) {
+	m.gotRetention.Store(int64(retention))
+	if m.taskErr {
+		return 0, errors.New("mock error")
+	}
+	return 0, nil
+}
+
+func (m *mockDatabase) Backup(path string) error {
+	if m.backupErr {
+		return errors.New("mock error")
+	}
+	return os.WriteFile(path, []byte("backup"), 0600)
+}
+
+func (m *mockDatabase) called() bool {
+	return m.gotRetention.Load() != 0
+}
@@ -0,0 +1,86 @@
+// Package secrets keeps the secrets of the agent, such as the Ubuntu Pro tokens, encrypted at rest. On Windows
+// they are encrypted with the Data Protection API (DPAPI), so that only the user running the agent can read them
+// back, and no plaintext copy of them ends up on disk.
+package secrets
+
+import (
+	"fmt"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/ubuntu/decorate"
+)
+
+// The names of the secrets.
+const (
+	// UserProToken is the Ubuntu Pro token the user entered in the GUI.
+	UserProToken = "ubuntu-pro-token/user"
+
+	// StoreProToken is the Ubuntu Pro token the Microsoft Store subscription was exchanged for.
+	StoreProToken = "ubuntu-pro-token/store"
+)
+
+// Secrets is the set of secrets of the agent, kept encrypted in the embedded store. It is safe for concurrent use.
+type Secrets struct {
+	store *store.Store
+}
+
+// New returns the secrets kept in the store s.
+func New(s *store.Store) *Secrets {
+	return &Secrets{store: s}
+}
+
+// Get returns the secret with the given name, or an empty string if there is none.
+func (s *Secrets) Get(name string) (secret string, err error) {
+	defer decorate.OnError(&err, "could not get secret %q", name)
+
+	protected, err := s.store.Get(store.SecretsBucket, name)
+	if err != nil {
+		return "", err
+	}
+	if protected == nil {
+		return "", nil
+	}
+
+	out, err := Unprotect(protected)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// Set stores the secret with the given name, replacing its previous value. Setting an empty secret removes it.
+func (s *Secrets) Set(name, secret string) (err error) {
+	defer decorate.OnError(&err, "could not set secret %q", name)
+
+	if secret == "" {
+		return s.store.Update(func(tx *store.Tx) error {
+			return tx.Delete(store.SecretsBucket, name)
+		})
+	}
+
+	protected, err := Protect([]byte(secret))
+	if err != nil {
+		return err
+	}
+
+	return s.store.Put(store.SecretsBucket, name, protected)
+}
+
+// Protect encrypts data so that only the current user can decrypt it with Unprotect.
+func Protect(data []byte) (out []byte, err error) {
+	out, err = protect(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt data: %v", err)
+	}
+	return out, nil
+}
+
+// Unprotect decrypts data encrypted with Protect.
+func Unprotect(data []byte) (out []byte, err error) {
+	out, err = unprotect(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt data: %v", err)
+	}
+	return out, nil
+}
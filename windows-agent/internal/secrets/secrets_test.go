@@ -0,0 +1,78 @@
+package secrets_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/store"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/secrets"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecrets(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		existing string
+		set      string
+
+		want string
+	}{
+		"Success getting a missing secret": {},
+		"Success setting a secret":         {set: "SECRET", want: "SECRET"},
+		"Success replacing a secret":       {existing: "OLD_SECRET", set: "SECRET", want: "SECRET"},
+		"Success removing a secret":        {existing: "OLD_SECRET"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			path := filepath.Join(t.TempDir(), "store.db")
+			s, err := store.Open(ctx, path)
+			require.NoError(t, err, "Setup: could not open the store")
+
+			sec := secrets.New(s)
+			if tc.existing != "" {
+				err := sec.Set(secrets.UserProToken, tc.existing)
+				require.NoError(t, err, "Setup: could not set the existing secret")
+			}
+
+			err = sec.Set(secrets.UserProToken, tc.set)
+			require.NoError(t, err, "Set should return no error")
+
+			got, err := sec.Get(secrets.UserProToken)
+			require.NoError(t, err, "Get should return no error")
+			require.Equal(t, tc.want, got, "Get should return the secret that was set")
+
+			got, err = sec.Get(secrets.StoreProToken)
+			require.NoError(t, err, "Get should return no error")
+			require.Empty(t, got, "Other secrets should not have been set")
+
+			// The secrets must outlive the process.
+			require.NoError(t, s.Close(), "Setup: could not close the store")
+			s, err = store.Open(ctx, path)
+			require.NoError(t, err, "Setup: could not reopen the store")
+			defer s.Close()
+
+			got, err = secrets.New(s).Get(secrets.UserProToken)
+			require.NoError(t, err, "Get should return no error")
+			require.Equal(t, tc.want, got, "Get should return the secret that was set after reopening the store")
+		})
+	}
+}
+
+func TestProtect(t *testing.T) {
+	t.Parallel()
+
+	for _, data := range []string{"", "SECRET"} {
+		protected, err := secrets.Protect([]byte(data))
+		require.NoError(t, err, "Protect should return no error")
+
+		got, err := secrets.Unprotect(protected)
+		require.NoError(t, err, "Unprotect should return no error")
+		require.Equal(t, data, string(got), "Unprotect should return the data that was protected")
+	}
+}
@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// entropy is mixed into the encryption, so that other applications of the user cannot decrypt the secrets of the
+// agent by merely asking DPAPI to.
+var entropy = []byte("ubuntu-pro-for-wsl")
+
+// protect encrypts data with CryptProtectData, under the key of the current user.
+func protect(data []byte) ([]byte, error) {
+	var out windows.DataBlob
+	err := windows.CryptProtectData(blob(data), nil, blob(entropy), 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	if err != nil {
+		return nil, err
+	}
+	return release(out), nil
+}
+
+// unprotect decrypts data with CryptUnprotectData.
+func unprotect(data []byte) ([]byte, error) {
+	var out windows.DataBlob
+	err := windows.CryptUnprotectData(blob(data), nil, blob(entropy), 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	if err != nil {
+		return nil, err
+	}
+	return release(out), nil
+}
+
+// blob wraps data into the structure DPAPI takes.
+func blob(data []byte) *windows.DataBlob {
+	if len(data) == 0 {
+		return &windows.DataBlob{}
+	}
+	return &windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+}
+
+// release copies the data that DPAPI allocated and frees it.
+func release(b windows.DataBlob) []byte {
+	if b.Data == nil {
+		return []byte{}
+	}
+	//nolint:gosec // No other way of freeing the memory DPAPI allocated.
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(b.Data))) //nolint:errcheck // Nothing we can do about it.
+
+	return append([]byte{}, unsafe.Slice(b.Data, b.Size)...)
+}
@@ -0,0 +1,521 @@
+// Package simulator impersonates the WSL Pro service of a distro, so that the agent and the GUI can be
+// tested on Windows without installing a real distro. What the simulated distro reports and how it answers
+// the commands of the agent is described by a Script.
+package simulator
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/namedpipe"
+	"github.com/ubuntu/decorate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// KindProAttach is the kind of the commands sent through the ProAttachmentCommands stream.
+	KindProAttach = "pro_attach"
+	// KindLandscapeConfig is the kind of the commands sent through the LandscapeConfigCommands stream.
+	KindLandscapeConfig = "landscape_config"
+)
+
+// Script describes the simulated distro.
+type Script struct {
+	// Info is what the distro reports about itself. Its name is always the one of the simulator.
+	Info Info `yaml:"info"`
+
+	// ServiceVersion is the version of the WSL Pro service reported in the handshake. Empty reports a
+	// version that predates the field.
+	ServiceVersion string `yaml:"service_version"`
+
+	// Commands are the behaviors of the distro by kind of command: pro_attach, landscape_config, or the name
+	// of the command in the Command message of the agent API, such as pro_service or usg. The commands without
+	// a behavior succeed right away.
+	Commands map[string]Behavior `yaml:"commands"`
+}
+
+// Info is the information reported by the simulated distro.
+type Info struct {
+	ID          string   `yaml:"id"`
+	VersionID   string   `yaml:"version_id"`
+	PrettyName  string   `yaml:"pretty_name"`
+	Hostname    string   `yaml:"hostname"`
+	ProAttached bool     `yaml:"pro_attached"`
+	ProServices []string `yaml:"pro_services"`
+
+	// SecurityUpdates and ESMUpdates are the pending security updates. The security status is only reported
+	// if any of them is set.
+	SecurityUpdates *int32 `yaml:"security_updates"`
+	ESMUpdates      *int32 `yaml:"esm_updates"`
+}
+
+// Behavior is how the simulated distro answers a kind of command.
+type Behavior struct {
+	// Delay is how long the command takes. Commands can be preempted while they wait.
+	Delay time.Duration `yaml:"delay"`
+	// Error is the error the command fails with. Empty means success.
+	Error string `yaml:"error"`
+	// Output is the output of the command, such as the report of an audit.
+	Output string `yaml:"output"`
+}
+
+// DefaultScript is the script of an Ubuntu distro, not attached to Ubuntu Pro, that succeeds at everything.
+func DefaultScript() Script {
+	return Script{
+		Info: Info{
+			ID:         "ubuntu",
+			VersionID:  "24.04",
+			PrettyName: "Ubuntu 24.04 LTS (simulated)",
+			Hostname:   "simulated",
+		},
+	}
+}
+
+// LoadScript reads a YAML script, whose unset fields default to the ones of DefaultScript.
+func LoadScript(path string) (s Script, err error) {
+	defer decorate.OnError(&err, "could not load simulation script")
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+
+	s = DefaultScript()
+	if err := yaml.Unmarshal(out, &s); err != nil {
+		return s, err
+	}
+
+	kinds := commandKinds()
+	for kind := range s.Commands {
+		if !slices.Contains(kinds, kind) {
+			return s, fmt.Errorf("unknown kind of command %q: expected one of %s", kind, strings.Join(kinds, ", "))
+		}
+	}
+
+	return s, nil
+}
+
+// commandKinds returns the kinds of commands a script can describe a behavior for.
+func commandKinds() []string {
+	kinds := []string{KindProAttach, KindLandscapeConfig}
+
+	fields := (&agentapi.Command{}).ProtoReflect().Descriptor().Oneofs().ByName("cmd").Fields()
+	for i := range fields.Len() {
+		if name := string(fields.Get(i).Name()); name != "preempt" {
+			kinds = append(kinds, name)
+		}
+	}
+
+	return kinds
+}
+
+// kindOf returns the kind of a command of the Commands stream.
+func kindOf(cmd *agentapi.Command) string {
+	m := cmd.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName("cmd"))
+	if field == nil {
+		return ""
+	}
+	return string(field.Name())
+}
+
+// Simulator impersonates the WSL Pro service of a distro.
+type Simulator struct {
+	name   string
+	script Script
+
+	// attached is whether the distro is attached to Ubuntu Pro, as changed by the pro_attach commands.
+	attached bool
+	mu       sync.Mutex
+}
+
+// New creates a simulator of a distro with the given name and script.
+func New(name string, script Script) *Simulator {
+	return &Simulator{
+		name:     name,
+		script:   script,
+		attached: script.Info.ProAttached,
+	}
+}
+
+// Dial connects to the agent whose address and certificates are published in publicDir, like the WSL Pro
+// service does from the distros, presenting the client certificate whose file name starts with certPrefix.
+// Being local, it connects through the named pipe of the agent if it serves one.
+func Dial(publicDir, certPrefix string) (conn *grpc.ClientConn, err error) {
+	defer decorate.OnError(&err, "could not connect to the agent")
+
+	certsDir := common.CertificatesPath(publicDir)
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certsDir, certPrefix+common.CertificateSuffix), filepath.Join(certsDir, certPrefix+common.KeySuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	caBytes, err := os.ReadFile(filepath.Join(certsDir, common.RootCACertFileName))
+	if err != nil {
+		return nil, err
+	}
+	ca := x509.NewCertPool()
+	if ok := ca.AppendCertsFromPEM(caBytes); !ok {
+		return nil, fmt.Errorf("failed to parse %q", common.RootCACertFileName)
+	}
+
+	creds := grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		ServerName:   common.GRPCServerNameOverride,
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      ca,
+		MinVersion:   tls.VersionTLS13,
+	}))
+
+	if pipe, err := os.ReadFile(filepath.Join(publicDir, common.NamedPipeFileName)); err == nil {
+		path := strings.TrimSpace(string(pipe))
+		// The target only names the server: the dialer connects to the pipe whatever the target.
+		return grpc.NewClient("passthrough:///"+common.GRPCServerNameOverride, creds,
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return namedpipe.Dial(ctx, path)
+			}))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not read the named pipe of the agent: %v", err)
+	}
+
+	addr, err := os.ReadFile(common.AddressFile(publicDir))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the address of the agent: %v", err)
+	}
+	if _, _, err := net.SplitHostPort(strings.TrimSpace(string(addr))); err != nil {
+		return nil, fmt.Errorf("could not parse the address of the agent: %v", err)
+	}
+
+	return grpc.NewClient(strings.TrimSpace(string(addr)), creds)
+}
+
+// Run serves the agent through conn until the context is cancelled, in which case it returns nil, or the
+// connection is lost.
+func (s *Simulator) Run(ctx context.Context, conn *grpc.ClientConn) (err error) {
+	defer decorate.OnError(&err, "simulated distro %q", s.name)
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c := agentapi.NewWSLInstanceClient(conn)
+
+	connStream, err := c.Session(ctx)
+	if err != nil {
+		return fmt.Errorf("could not open the Session stream: %v", err)
+	}
+
+	caps, err := s.handshake(connStream)
+	if err != nil {
+		return err
+	}
+	log.Infof(ctx, "Simulator: %s connected to the agent", s.name)
+
+	// Sends on the Session stream come from the handlers of the attachments too.
+	var connMu sync.Mutex
+	sendInfo := func() error {
+		connMu.Lock()
+		defer connMu.Unlock()
+		return connStream.Send(&agentapi.DistroMessage{Data: &agentapi.DistroMessage_Info{Info: s.info()}})
+	}
+
+	if err := sendInfo(); err != nil {
+		return fmt.Errorf("could not send the distro info: %v", err)
+	}
+
+	proStream, err := c.ProAttachmentCommands(ctx)
+	if err != nil {
+		return fmt.Errorf("could not open the ProAttachmentCommands stream: %v", err)
+	}
+	lpeStream, err := c.LandscapeConfigCommands(ctx)
+	if err != nil {
+		return fmt.Errorf("could not open the LandscapeConfigCommands stream: %v", err)
+	}
+	cmdStream, err := c.Commands(ctx)
+	if err != nil {
+		return fmt.Errorf("could not open the Commands stream: %v", err)
+	}
+	for _, send := range []func(*agentapi.MSG) error{proStream.Send, lpeStream.Send, cmdStream.Send} {
+		if err := send(&agentapi.MSG{Data: &agentapi.MSG_WslName{WslName: s.name}}); err != nil {
+			return fmt.Errorf("could not identify a command stream: %v", err)
+		}
+	}
+
+	loops := []func() error{
+		func() error { return s.receiveAcks(ctx, connStream) },
+		func() error { return s.serveProAttachments(ctx, proStream, sendInfo) },
+		func() error { return s.serveLandscapeConfigs(ctx, lpeStream) },
+		func() error { return s.serveCommands(ctx, cmdStream) },
+	}
+
+	if slices.Contains(caps, agentapi.Capability_CAPABILITY_PING) {
+		pingStream, err := c.Ping(ctx)
+		if err != nil {
+			return fmt.Errorf("could not open the Ping stream: %v", err)
+		}
+		if err := pingStream.Send(&agentapi.PingReply{WslName: s.name}); err != nil {
+			return fmt.Errorf("could not identify the Ping stream: %v", err)
+		}
+		loops = append(loops, func() error { return servePings(pingStream) })
+	}
+
+	errs := make(chan error, len(loops))
+	for _, loop := range loops {
+		go func() { errs <- loop() }()
+	}
+
+	// The first loop to stop brings the others down with it.
+	err = <-errs
+	cancel()
+	for range len(loops) - 1 {
+		<-errs
+	}
+
+	if parent.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// handshake negotiates the protocol with the agent, and returns the capabilities both ends support.
+func (s *Simulator) handshake(connStream agentapi.WSLInstance_SessionClient) ([]agentapi.Capability, error) {
+	err := connStream.Send(&agentapi.DistroMessage{Data: &agentapi.DistroMessage_Handshake{Handshake: &agentapi.Handshake{
+		ProtocolVersion: common.WSLInstanceProtocolVersion,
+		WslName:         s.name,
+		Capabilities:    []agentapi.Capability{agentapi.Capability_CAPABILITY_INFO_ACK, agentapi.Capability_CAPABILITY_PING},
+		ServiceVersion:  s.script.ServiceVersion,
+	}}})
+	if err != nil {
+		return nil, fmt.Errorf("could not send the handshake: %v", err)
+	}
+
+	ack, err := connStream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("the agent rejected the handshake: %v", err)
+	}
+
+	return ack.GetCapabilities(), nil
+}
+
+// info returns the DistroInfo of the simulated distro.
+func (s *Simulator) info() *agentapi.DistroInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.script.Info
+	info := &agentapi.DistroInfo{
+		WslName:     s.name,
+		Id:          i.ID,
+		VersionId:   i.VersionID,
+		PrettyName:  i.PrettyName,
+		ProAttached: s.attached,
+		Hostname:    i.Hostname,
+	}
+
+	if s.attached {
+		info.ProServices = i.ProServices
+	}
+
+	if i.SecurityUpdates != nil || i.ESMUpdates != nil {
+		info.SecurityStatus = &agentapi.SecurityStatus{}
+		if i.SecurityUpdates != nil {
+			info.SecurityStatus.StandardUpdates = *i.SecurityUpdates
+		}
+		if i.ESMUpdates != nil {
+			info.SecurityStatus.EsmUpdates = *i.ESMUpdates
+		}
+	}
+
+	return info
+}
+
+// receiveAcks logs the settings the agent acknowledges the DistroInfo messages with.
+func (s *Simulator) receiveAcks(ctx context.Context, connStream agentapi.WSLInstance_SessionClient) error {
+	for {
+		ack, err := connStream.Recv()
+		if err != nil {
+			return fmt.Errorf("connection to the agent lost: %v", err)
+		}
+
+		settings := ack.GetSettings()
+		log.Infof(ctx, "Simulator: agent acknowledged the distro info: config hash %q, %d pending tasks", settings.GetConfigHash(), settings.GetPendingTasks())
+	}
+}
+
+// serveProAttachments answers the attachments, sending the new DistroInfo once attached or detached.
+func (s *Simulator) serveProAttachments(ctx context.Context, stream agentapi.WSLInstance_ProAttachmentCommandsClient, sendInfo func() error) error {
+	for {
+		cmd, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("could not receive attachment: %v", err)
+		}
+
+		log.Infof(ctx, "Simulator: received %s command", KindProAttach)
+		b := s.script.Commands[KindProAttach]
+		if err := wait(ctx, b.Delay); err != nil {
+			return err
+		}
+
+		if b.Error == "" {
+			s.mu.Lock()
+			s.attached = cmd.GetToken() != ""
+			s.mu.Unlock()
+		}
+
+		if err := stream.Send(result(b)); err != nil {
+			return fmt.Errorf("could not send attachment result: %v", err)
+		}
+
+		if b.Error == "" {
+			if err := sendInfo(); err != nil {
+				return fmt.Errorf("could not send the distro info: %v", err)
+			}
+		}
+	}
+}
+
+// serveLandscapeConfigs answers the Landscape configurations.
+func (s *Simulator) serveLandscapeConfigs(ctx context.Context, stream agentapi.WSLInstance_LandscapeConfigCommandsClient) error {
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return fmt.Errorf("could not receive Landscape configuration: %v", err)
+		}
+
+		log.Infof(ctx, "Simulator: received %s command", KindLandscapeConfig)
+		b := s.script.Commands[KindLandscapeConfig]
+		if err := wait(ctx, b.Delay); err != nil {
+			return err
+		}
+
+		if err := stream.Send(result(b)); err != nil {
+			return fmt.Errorf("could not send Landscape configuration result: %v", err)
+		}
+	}
+}
+
+// serveCommands answers the commands one at a time, as the WSL Pro service does. The command in progress
+// stops as soon as it is preempted, while the other preemptions are ignored.
+func (s *Simulator) serveCommands(ctx context.Context, stream agentapi.WSLInstance_CommandsClient) error {
+	type received struct {
+		cmd *agentapi.Command
+		err error
+	}
+
+	messages := make(chan received)
+	go func() {
+		for {
+			cmd, err := stream.Recv()
+			select {
+			case messages <- received{cmd, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var queue []*agentapi.Command
+	for {
+		var cmd *agentapi.Command
+		if len(queue) > 0 {
+			cmd, queue = queue[0], queue[1:]
+		} else {
+			select {
+			case <-ctx.Done():
+				return nil
+			case msg := <-messages:
+				if msg.err != nil {
+					return fmt.Errorf("could not receive command: %v", msg.err)
+				}
+				cmd = msg.cmd
+			}
+		}
+
+		if cmd.GetPreempt() != nil {
+			// No command in progress: nothing to preempt.
+			continue
+		}
+
+		kind := kindOf(cmd)
+		log.Infof(ctx, "Simulator: received %s command #%d", kind, cmd.GetId())
+		b := s.script.Commands[kind]
+		reply := result(b)
+
+		timer := time.NewTimer(b.Delay)
+	running:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+				break running
+			case msg := <-messages:
+				if msg.err != nil {
+					timer.Stop()
+					return fmt.Errorf("could not receive command: %v", msg.err)
+				}
+				if p := msg.cmd.GetPreempt(); p != nil && p.GetId() == cmd.GetId() {
+					timer.Stop()
+					log.Infof(ctx, "Simulator: %s command #%d preempted", kind, cmd.GetId())
+					reply = &agentapi.MSG{Data: &agentapi.MSG_Result{Result: "preempted"}, Preempted: true}
+					break running
+				}
+				queue = append(queue, msg.cmd)
+			}
+		}
+
+		if err := stream.Send(reply); err != nil {
+			return fmt.Errorf("could not send command result: %v", err)
+		}
+	}
+}
+
+// servePings echoes the pings of the agent.
+func servePings(stream agentapi.WSLInstance_PingClient) error {
+	for {
+		ping, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("could not receive ping: %v", err)
+		}
+
+		if err := stream.Send(&agentapi.PingReply{Id: ping.GetId(), Payload: ping.GetPayload()}); err != nil {
+			return fmt.Errorf("could not send ping reply: %v", err)
+		}
+	}
+}
+
+// result returns the message answering a command with the given behavior.
+func result(b Behavior) *agentapi.MSG {
+	msg := &agentapi.MSG{Data: &agentapi.MSG_Result{Result: b.Error}}
+	if b.Output != "" {
+		msg.Output = []byte(b.Output)
+	}
+	return msg
+}
+
+// wait waits for the delay of a command, or returns the error of the context if it is cancelled first.
+func wait(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
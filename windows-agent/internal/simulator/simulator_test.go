@@ -0,0 +1,390 @@
+package simulator_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/simulator"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestLoadScript(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		script   string
+		noScript bool
+
+		wantInfo     simulator.Info
+		wantCommands map[string]simulator.Behavior
+		wantErr      bool
+	}{
+		"Success loading a script": {
+			script: `
+info:
+  id: ubuntu
+  version_id: "22.04"
+  pretty_name: Ubuntu 22.04 LTS
+  hostname: testMachine
+  pro_attached: true
+commands:
+  pro_attach:
+    delay: 5s
+    error: "mock error"
+  usg:
+    output: "<html></html>"
+`,
+			wantInfo: simulator.Info{ID: "ubuntu", VersionID: "22.04", PrettyName: "Ubuntu 22.04 LTS", Hostname: "testMachine", ProAttached: true},
+			wantCommands: map[string]simulator.Behavior{
+				simulator.KindProAttach: {Delay: 5 * time.Second, Error: "mock error"},
+				"usg":                   {Output: "<html></html>"},
+			},
+		},
+		"Success defaulting the unset fields": {
+			script:   "service_version: 1.0.0\n",
+			wantInfo: simulator.DefaultScript().Info,
+		},
+
+		"Error when the script does not exist":    {noScript: true, wantErr: true},
+		"Error when the script is not YAML":       {script: "info: [", wantErr: true},
+		"Error when a kind of command is unknown": {script: "commands:\n  reboot:\n    delay: 1s\n", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "script.yaml")
+			if !tc.noScript {
+				require.NoError(t, os.WriteFile(path, []byte(tc.script), 0600), "Setup: could not write script")
+			}
+
+			got, err := simulator.LoadScript(path)
+			if tc.wantErr {
+				require.Error(t, err, "LoadScript should have returned an error")
+				return
+			}
+			require.NoError(t, err, "LoadScript should not return an error")
+
+			require.Equal(t, tc.wantInfo, got.Info, "Unexpected distro info")
+			require.Equal(t, tc.wantCommands, got.Commands, "Unexpected command behaviors")
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		attached bool
+		commands map[string]simulator.Behavior
+		send     func(t *testing.T, a *fakeAgent) *agentapi.MSG
+
+		wantResult    string
+		wantOutput    string
+		wantPreempted bool
+		// wantAttached is the attachment status reported after the command, if it reports any.
+		wantAttached *bool
+	}{
+		"Success attaching the distro": {
+			send:         attach("TOKEN"),
+			wantAttached: ptr(true),
+		},
+		"Success detaching the distro": {
+			attached:     true,
+			send:         attach(""),
+			wantAttached: ptr(false),
+		},
+		"Success configuring Landscape": {send: configureLandscape},
+		"Success running a command": {
+			commands:   map[string]simulator.Behavior{"usg": {Output: "<html>report</html>"}},
+			send:       runCommand(&agentapi.Command{Id: 1, Cmd: &agentapi.Command_Usg{Usg: &agentapi.UsgCmd{Profile: "cis_level1_workstation"}}}),
+			wantOutput: "<html>report</html>",
+		},
+		"Success preempting a command in progress": {
+			commands:      map[string]simulator.Behavior{"service_upgrade": {Delay: time.Hour}},
+			send:          preemptCommand,
+			wantResult:    "preempted",
+			wantPreempted: true,
+		},
+
+		"Error when the script fails attachments": {
+			commands:   map[string]simulator.Behavior{simulator.KindProAttach: {Error: "mock error"}},
+			send:       attach("TOKEN"),
+			wantResult: "mock error",
+		},
+		"Error when the script fails Landscape configurations": {
+			commands:   map[string]simulator.Behavior{simulator.KindLandscapeConfig: {Error: "mock error"}},
+			send:       configureLandscape,
+			wantResult: "mock error",
+		},
+		"Error when the script fails commands": {
+			commands:   map[string]simulator.Behavior{"pro_service": {Delay: 10 * time.Millisecond, Error: "mock error"}},
+			send:       runCommand(&agentapi.Command{Id: 1, Cmd: &agentapi.Command_ProService{ProService: &agentapi.ProServiceCmd{Service: "esm-infra"}}}),
+			wantResult: "mock error",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			script := simulator.DefaultScript()
+			script.ServiceVersion = "1.0.0"
+			script.Info.ProAttached = tc.attached
+			script.Commands = tc.commands
+
+			a, conn := newFakeAgent(t)
+			ranCtx, stop := context.WithCancel(ctx)
+			ran := make(chan error)
+			go func() { ran <- simulator.New("testDistro", script).Run(ranCtx, conn) }()
+
+			h := a.nextHandshake(t)
+			require.Equal(t, "testDistro", h.GetWslName(), "The handshake should carry the name of the simulated distro")
+			require.Equal(t, "1.0.0", h.GetServiceVersion(), "The handshake should carry the service version of the script")
+
+			info := a.nextInfo(t)
+			require.Equal(t, "testDistro", info.GetWslName(), "The distro info should carry the name of the simulated distro")
+			require.Equal(t, tc.attached, info.GetProAttached(), "The distro info should report the attachment status of the script")
+
+			got := tc.send(t, a)
+			require.Equal(t, tc.wantResult, got.GetResult(), "Unexpected result of the command")
+			require.Equal(t, tc.wantOutput, string(got.GetOutput()), "Unexpected output of the command")
+			require.Equal(t, tc.wantPreempted, got.GetPreempted(), "Unexpected preemption of the command")
+
+			if tc.wantAttached != nil {
+				info = a.nextInfo(t)
+				require.Equal(t, *tc.wantAttached, info.GetProAttached(), "The distro info should report the new attachment status")
+			} else {
+				select {
+				case info := <-a.infos:
+					require.Failf(t, "No distro info should be sent", "Got %v", info)
+				case <-time.After(100 * time.Millisecond):
+				}
+			}
+
+			stop()
+			select {
+			case err := <-ran:
+				require.NoError(t, err, "Run should return no error when its context is cancelled")
+			case <-ctx.Done():
+				require.Fail(t, "Run should return once its context is cancelled")
+			}
+		})
+	}
+}
+
+func TestRunConnectionLost(t *testing.T) {
+	t.Parallel()
+
+	a, conn := newFakeAgent(t)
+	ran := make(chan error)
+	go func() { ran <- simulator.New("testDistro", simulator.DefaultScript()).Run(context.Background(), conn) }()
+
+	a.nextInfo(t)
+	a.server.Stop()
+
+	select {
+	case err := <-ran:
+		require.Error(t, err, "Run should return an error when the connection to the agent is lost")
+	case <-time.After(30 * time.Second):
+		require.Fail(t, "Run should return once the connection to the agent is lost")
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func attach(token string) func(*testing.T, *fakeAgent) *agentapi.MSG {
+	return func(t *testing.T, a *fakeAgent) *agentapi.MSG {
+		t.Helper()
+
+		stream := <-a.pro
+		require.NoError(t, stream.Send(&agentapi.ProAttachCmd{Token: token}), "Setup: could not send attachment")
+		msg, err := stream.Recv()
+		require.NoError(t, err, "Could not receive attachment result")
+		return msg
+	}
+}
+
+func configureLandscape(t *testing.T, a *fakeAgent) *agentapi.MSG {
+	t.Helper()
+
+	stream := <-a.lpe
+	require.NoError(t, stream.Send(&agentapi.LandscapeConfigCmd{Config: "[client]"}), "Setup: could not send Landscape configuration")
+	msg, err := stream.Recv()
+	require.NoError(t, err, "Could not receive Landscape configuration result")
+	return msg
+}
+
+func runCommand(cmd *agentapi.Command) func(*testing.T, *fakeAgent) *agentapi.MSG {
+	return func(t *testing.T, a *fakeAgent) *agentapi.MSG {
+		t.Helper()
+
+		stream := <-a.cmds
+		require.NoError(t, stream.Send(cmd), "Setup: could not send command")
+		msg, err := stream.Recv()
+		require.NoError(t, err, "Could not receive command result")
+		return msg
+	}
+}
+
+// preemptCommand sends a command, a preemption of another one, which is ignored, and then its own preemption.
+func preemptCommand(t *testing.T, a *fakeAgent) *agentapi.MSG {
+	t.Helper()
+
+	stream := <-a.cmds
+	cmd := &agentapi.Command{Id: 2, Cmd: &agentapi.Command_ServiceUpgrade{ServiceUpgrade: &agentapi.ServiceUpgradeCmd{Channel: "stable"}}}
+	for _, c := range []*agentapi.Command{
+		cmd,
+		{Cmd: &agentapi.Command_Preempt{Preempt: &agentapi.PreemptCmd{Id: 1}}},
+		{Cmd: &agentapi.Command_Preempt{Preempt: &agentapi.PreemptCmd{Id: 2}}},
+	} {
+		require.NoError(t, stream.Send(c), "Setup: could not send command")
+	}
+
+	msg, err := stream.Recv()
+	require.NoError(t, err, "Could not receive command result")
+	return msg
+}
+
+// fakeAgent serves the WSLInstance service, handing the command streams over to the tests.
+type fakeAgent struct {
+	agentapi.UnimplementedWSLInstanceServer
+
+	server *grpc.Server
+
+	handshakes chan *agentapi.Handshake
+	infos      chan *agentapi.DistroInfo
+
+	pro  chan grpc.BidiStreamingServer[agentapi.MSG, agentapi.ProAttachCmd]
+	lpe  chan grpc.BidiStreamingServer[agentapi.MSG, agentapi.LandscapeConfigCmd]
+	cmds chan grpc.BidiStreamingServer[agentapi.MSG, agentapi.Command]
+}
+
+func newFakeAgent(t *testing.T) (*fakeAgent, *grpc.ClientConn) {
+	t.Helper()
+
+	a := &fakeAgent{
+		server:     grpc.NewServer(),
+		handshakes: make(chan *agentapi.Handshake, 1),
+		infos:      make(chan *agentapi.DistroInfo, 10),
+		pro:        make(chan grpc.BidiStreamingServer[agentapi.MSG, agentapi.ProAttachCmd], 1),
+		lpe:        make(chan grpc.BidiStreamingServer[agentapi.MSG, agentapi.LandscapeConfigCmd], 1),
+		cmds:       make(chan grpc.BidiStreamingServer[agentapi.MSG, agentapi.Command], 1),
+	}
+	agentapi.RegisterWSLInstanceServer(a.server, a)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err, "Setup: could not listen")
+
+	go func() { _ = a.server.Serve(lis) }()
+	t.Cleanup(a.server.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "Setup: could not create a client")
+	t.Cleanup(func() { conn.Close() })
+
+	return a, conn
+}
+
+func (a *fakeAgent) nextHandshake(t *testing.T) *agentapi.Handshake {
+	t.Helper()
+
+	select {
+	case h := <-a.handshakes:
+		return h
+	case <-time.After(30 * time.Second):
+		require.Fail(t, "The simulator should have sent a handshake")
+		return nil
+	}
+}
+
+func (a *fakeAgent) nextInfo(t *testing.T) *agentapi.DistroInfo {
+	t.Helper()
+
+	select {
+	case info := <-a.infos:
+		return info
+	case <-time.After(30 * time.Second):
+		require.Fail(t, "The simulator should have sent its distro info")
+		return nil
+	}
+}
+
+func (a *fakeAgent) Session(stream grpc.BidiStreamingServer[agentapi.DistroMessage, agentapi.HandshakeAck]) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	a.handshakes <- msg.GetHandshake()
+
+	caps := []agentapi.Capability{agentapi.Capability_CAPABILITY_INFO_ACK, agentapi.Capability_CAPABILITY_PING}
+	if err := stream.Send(&agentapi.HandshakeAck{ProtocolVersion: msg.GetHandshake().GetProtocolVersion(), Capabilities: caps}); err != nil {
+		return err
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		a.infos <- msg.GetInfo()
+
+		if err := stream.Send(&agentapi.HandshakeAck{Settings: &agentapi.DistroSettings{ConfigHash: "hash"}}); err != nil {
+			return err
+		}
+	}
+}
+
+func (a *fakeAgent) ProAttachmentCommands(stream grpc.BidiStreamingServer[agentapi.MSG, agentapi.ProAttachCmd]) error {
+	return handOver(stream, a.pro)
+}
+
+func (a *fakeAgent) LandscapeConfigCommands(stream grpc.BidiStreamingServer[agentapi.MSG, agentapi.LandscapeConfigCmd]) error {
+	return handOver(stream, a.lpe)
+}
+
+func (a *fakeAgent) Commands(stream grpc.BidiStreamingServer[agentapi.MSG, agentapi.Command]) error {
+	return handOver(stream, a.cmds)
+}
+
+func (a *fakeAgent) Ping(stream grpc.BidiStreamingServer[agentapi.PingReply, agentapi.PingCmd]) error {
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+	if err := stream.Send(&agentapi.PingCmd{Id: 1, Payload: []byte("ping")}); err != nil {
+		return err
+	}
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+// handOver hands an identified command stream over to the test, and keeps it open until the client leaves.
+func handOver[Command any](stream grpc.BidiStreamingServer[agentapi.MSG, Command], ch chan<- grpc.BidiStreamingServer[agentapi.MSG, Command]) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if msg.GetWslName() == "" {
+		return nil
+	}
+
+	ch <- stream
+	<-stream.Context().Done()
+	return nil
+}
@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[DNS]()
+}
+
+// DNS is a task that configures how a distro resolves host names: the nameservers and search domains that replace
+// the resolv.conf WSL generates, and the entries added to /etc/hosts, typically to reach the hosts of a corporate
+// VPN. Empty settings stop managing them, so that WSL generates both files again.
+type DNS struct {
+	Nameservers   []string
+	SearchDomains []string
+	Hosts         []string
+}
+
+// Execute sends the DNS settings to the target WSL-Pro-Service.
+func (t DNS) Execute(ctx context.Context, conn task.Connection) error {
+	_, err := conn.SendCommand(&agentapi.Command{
+		Cmd: &agentapi.Command_Dns{
+			Dns: &agentapi.DnsCmd{
+				Nameservers:   t.Nameservers,
+				SearchDomains: t.SearchDomains,
+				Hosts:         t.Hosts,
+			},
+		},
+	})
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+	return nil
+}
+
+// String is needed to fulfil Task.
+func (t DNS) String() string {
+	if t.empty() {
+		return fmt.Sprintf("%T task to stop managing the DNS", t)
+	}
+	return fmt.Sprintf("%T task with nameservers %q, search domains %q and %d host entries", t, t.Nameservers, t.SearchDomains, len(t.Hosts))
+}
+
+// Is is a custom comparator. All DNS tasks are considered equivalent: only the latest settings matter.
+func (t DNS) Is(other task.Task) bool {
+	_, ok := other.(DNS)
+	return ok
+}
+
+// Lane is a custom lane, shared with the other configuration tasks: the distro must resolve the hosts of the VPN
+// before the upgrades that may need them.
+func (t DNS) Lane() task.Lane {
+	return laneConfiguration
+}
+
+// empty returns true if the task stops managing the DNS.
+func (t DNS) empty() bool {
+	return len(t.Nameservers) == 0 && len(t.SearchDomains) == 0 && len(t.Hosts) == 0
+}
@@ -2,8 +2,12 @@ package tasks
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"gopkg.in/ini.v1"
 )
 
 func init() {
@@ -17,11 +21,34 @@ type LandscapeConfigure struct {
 	Config string
 }
 
-// Execute sends the config to the target WSL-Pro-Service so that the distro can be
-// registered in Landscape.
+// Execute checks the config and sends it to the target WSL-Pro-Service, which installs it in the distro
+// and registers it in Landscape. Each of the two is reported as a step, so that a config that can never
+// be installed is told apart from a registration that failed. Half of the progress is reported once the
+// config is checked. Disabling Landscape is a single step.
 func (t LandscapeConfigure) Execute(ctx context.Context, client task.Connection) error {
-	// First value is a dummy message, we ignore it. We only care about success/failure.
-	err := client.SendLandscapeConfig(t.Config)
+	if t.Config == "" {
+		err := task.RunStep(ctx, "disable", func() error {
+			return client.SendLandscapeConfig("")
+		})
+		if err != nil {
+			return task.NeedsRetryError{SourceErr: err}
+		}
+		return nil
+	}
+
+	err := task.RunStep(ctx, "check config", func() error {
+		return checkLandscapeConfig(t.Config)
+	})
+	if err != nil {
+		// Retrying would not fix the config: a new one must be submitted.
+		task.SkipStep(ctx, "install and register", "the config is not valid")
+		return err
+	}
+	task.ReportProgress(ctx, 50)
+
+	err = task.RunStep(ctx, "install and register", func() error {
+		return client.SendLandscapeConfig(t.Config)
+	})
 	if err != nil {
 		return task.NeedsRetryError{SourceErr: err}
 	}
@@ -29,11 +56,41 @@ func (t LandscapeConfigure) Execute(ctx context.Context, client task.Connection)
 	return nil
 }
 
+// checkLandscapeConfig returns an error if the config cannot be installed in a distro.
+func checkLandscapeConfig(config string) error {
+	f, err := ini.Load(strings.NewReader(config))
+	if err != nil {
+		return fmt.Errorf("could not parse Landscape config: %v", err)
+	}
+
+	if !f.HasSection("client") {
+		return errors.New("missing [client] section in Landscape config")
+	}
+
+	return nil
+}
+
 // String returns the name of the task.
 func (t LandscapeConfigure) String() string {
 	return "LandscapeConfigure"
 }
 
+// Lane is a custom lane. The config is sent through its own stream, so there is no need to wait
+// behind long-running commands such as upgrades.
+func (t LandscapeConfigure) Lane() task.Lane {
+	return laneLandscape
+}
+
+// NeedsWSL2 is true: the Landscape client runs as a systemd service, which WSL 1 lacks.
+func (t LandscapeConfigure) NeedsWSL2() bool {
+	return true
+}
+
+// IdempotencyKey is a custom idempotency key. Sending the same config again is redundant.
+func (t LandscapeConfigure) IdempotencyKey() string {
+	return "LandscapeConfigure:" + t.Config
+}
+
 // Is is a custom comparator. All LandscapeConfigure tasks are considered equivalent. In other words: newer
 // instructions to configure will override old ones.
 func (t LandscapeConfigure) Is(other task.Task) bool {
@@ -0,0 +1,23 @@
+package tasks
+
+import "github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+
+// The lanes of the tasks of this package. Tasks that depend on each other share a lane, so that they run in order,
+// while independent ones run concurrently and can only be preempted by tasks of their own lane.
+const (
+	// laneUbuntuPro is the lane of the tasks that attach the distro and use its subscription, which must run after
+	// the attachment they depend on.
+	laneUbuntuPro task.Lane = "ubuntu-pro"
+
+	// laneLandscape is the lane of the tasks that register the distro in Landscape.
+	laneLandscape task.Lane = "landscape"
+
+	// laneConfiguration is the lane of the tasks that configure the distro, which are short.
+	laneConfiguration task.Lane = "configuration"
+
+	// laneUpgrade is the lane of the upgrades of the WSL Pro service, which are long-running.
+	laneUpgrade task.Lane = "upgrade"
+
+	// laneHost is the lane of the tasks that run on the host while the distro is stopped, such as moving its VHD.
+	laneHost task.Lane = "host"
+)
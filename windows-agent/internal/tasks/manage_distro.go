@@ -0,0 +1,121 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslmanage"
+)
+
+func init() {
+	task.Register[SetSparse]()
+	task.Register[MoveDistro]()
+	task.Register[SetWslVersion]()
+}
+
+// SetSparse is a task that makes the VHD of a distro sparse or not, so that WSL gives back to the host the disk
+// space the distro frees. It runs on the host once the distro is stopped.
+type SetSparse struct {
+	Sparse bool
+}
+
+// Execute runs `wsl --manage --set-sparse` on the distro.
+func (t SetSparse) Execute(ctx context.Context, _ task.Connection) error {
+	return wslmanage.SetSparse(ctx, task.DistroName(ctx), t.Sparse)
+}
+
+// String is needed to fulfil Task.
+func (t SetSparse) String() string {
+	return fmt.Sprintf("%T task (sparse: %t)", t, t.Sparse)
+}
+
+// Is is a custom comparator. All SetSparse tasks are considered equivalent: only the latest one matters.
+func (t SetSparse) Is(other task.Task) bool {
+	_, ok := other.(SetSparse)
+	return ok
+}
+
+// Lane is a custom lane, as the task waits for the distro to stop.
+func (t SetSparse) Lane() task.Lane {
+	return laneHost
+}
+
+// RunsOnHost is true: the VHD of the distro is managed with wsl.exe.
+func (t SetSparse) RunsOnHost() bool {
+	return true
+}
+
+// NeedsWSL2 is true: distros on WSL 1 have no VHD.
+func (t SetSparse) NeedsWSL2() bool {
+	return true
+}
+
+// MoveDistro is a task that moves the VHD of a distro into another directory of the host, e.g. on a larger drive.
+// It runs on the host once the distro is stopped.
+type MoveDistro struct {
+	Location string
+}
+
+// Execute runs `wsl --manage --move` on the distro.
+func (t MoveDistro) Execute(ctx context.Context, _ task.Connection) error {
+	return wslmanage.Move(ctx, task.DistroName(ctx), t.Location)
+}
+
+// String is needed to fulfil Task.
+func (t MoveDistro) String() string {
+	return fmt.Sprintf("%T task to %q", t, t.Location)
+}
+
+// Is is a custom comparator. All MoveDistro tasks are considered equivalent: only the latest location matters.
+func (t MoveDistro) Is(other task.Task) bool {
+	_, ok := other.(MoveDistro)
+	return ok
+}
+
+// Lane is a custom lane, as the task waits for the distro to stop.
+func (t MoveDistro) Lane() task.Lane {
+	return laneHost
+}
+
+// RunsOnHost is true: the VHD of the distro is managed with wsl.exe.
+func (t MoveDistro) RunsOnHost() bool {
+	return true
+}
+
+// NeedsWSL2 is true: distros on WSL 1 have no VHD.
+func (t MoveDistro) NeedsWSL2() bool {
+	return true
+}
+
+// SetWslVersion is a task that converts a distro to run with WSL 1 or WSL 2. It runs on the host once the distro
+// is stopped.
+type SetWslVersion struct {
+	Version int
+}
+
+// Execute runs `wsl --set-version` on the distro.
+func (t SetWslVersion) Execute(ctx context.Context, _ task.Connection) error {
+	return wslmanage.SetVersion(ctx, task.DistroName(ctx), t.Version)
+}
+
+// String is needed to fulfil Task.
+func (t SetWslVersion) String() string {
+	return fmt.Sprintf("%T task to WSL %d", t, t.Version)
+}
+
+// Is is a custom comparator. All SetWslVersion tasks are considered equivalent: only the latest version matters.
+func (t SetWslVersion) Is(other task.Task) bool {
+	_, ok := other.(SetWslVersion)
+	return ok
+}
+
+// Lane is a custom lane, as the task waits for the distro to stop.
+func (t SetWslVersion) Lane() task.Lane {
+	return laneHost
+}
+
+// RunsOnHost is true: the distro is converted with wsl.exe.
+func (t SetWslVersion) RunsOnHost() bool {
+	return true
+}
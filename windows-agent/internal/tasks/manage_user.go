@@ -0,0 +1,56 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[ManageUser]()
+}
+
+// ManageUser is a task that creates a user in a distro if it does not exist yet, adds it to the
+// supplementary groups and, optionally, sets it as the default user of the distro.
+type ManageUser struct {
+	Name       string
+	Groups     []string
+	SetDefault bool
+}
+
+// Execute sends the command to the target WSL-Pro-Service so that the user is set up.
+func (t ManageUser) Execute(ctx context.Context, conn task.Connection) error {
+	_, err := conn.SendCommand(&agentapi.Command{
+		Cmd: &agentapi.Command_ManageUser{
+			ManageUser: &agentapi.ManageUserCmd{
+				Name:       t.Name,
+				Groups:     t.Groups,
+				SetDefault: t.SetDefault,
+			},
+		},
+	})
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+	return nil
+}
+
+// String is needed to fulfil Task.
+func (t ManageUser) String() string {
+	return fmt.Sprintf("%T task for user %q (groups: %q, default: %t)", t, t.Name, t.Groups, t.SetDefault)
+}
+
+// Is is a custom comparator, needed because the task is not comparable. Only identical
+// ManageUser tasks are considered equivalent, as they do not override one another.
+func (t ManageUser) Is(other task.Task) bool {
+	o, ok := other.(ManageUser)
+	return ok && o.Name == t.Name && o.SetDefault == t.SetDefault && slices.Equal(o.Groups, t.Groups)
+}
+
+// Lane is a custom lane. Creating a user is quick, so it does not wait behind upgrades or audits.
+func (t ManageUser) Lane() task.Lane {
+	return laneConfiguration
+}
@@ -0,0 +1,53 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[Patching]()
+}
+
+// Patching is a task that configures which package pockets unattended-upgrades installs updates from in
+// a distro. An empty level stops managing unattended-upgrades, restoring the distro's own configuration.
+type Patching struct {
+	Level string
+}
+
+// Execute sends the patching level to the target WSL-Pro-Service.
+func (t Patching) Execute(ctx context.Context, conn task.Connection) error {
+	_, err := conn.SendCommand(&agentapi.Command{
+		Cmd: &agentapi.Command_Patching{
+			Patching: &agentapi.PatchingCmd{
+				Level: t.Level,
+			},
+		},
+	})
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+	return nil
+}
+
+// String is needed to fulfil Task.
+func (t Patching) String() string {
+	if t.Level == "" {
+		return fmt.Sprintf("%T task to stop managing unattended-upgrades", t)
+	}
+	return fmt.Sprintf("%T task with level %q", t, t.Level)
+}
+
+// Is is a custom comparator. All Patching tasks are considered equivalent: only the latest level matters.
+func (t Patching) Is(other task.Task) bool {
+	_, ok := other.(Patching)
+	return ok
+}
+
+// Lane is a custom lane. It only writes the configuration of unattended-upgrades, so it does not wait behind upgrades or audits.
+func (t Patching) Lane() task.Lane {
+	return laneConfiguration
+}
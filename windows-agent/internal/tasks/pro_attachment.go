@@ -4,7 +4,9 @@ package tasks
 import (
 	"context"
 	"fmt"
+	"time"
 
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 )
@@ -18,11 +20,40 @@ func init() {
 // - to detach: send an empty token.
 type ProAttachment struct {
 	Token string
+
+	// UserInitiated is true if the user asked for the attachment, e.g. from the GUI, rather than it being
+	// part of the provisioning of the distro.
+	UserInitiated bool `yaml:",omitempty"`
 }
 
-// Execute is needed to fulfil Task.
+// Execute sends the token to the target WSL-Pro-Service, which attaches the distro and enables the
+// services of the subscription, and then checks that the distro is attached. Detaching is checked
+// the same way. Each of the two is reported as a step, so that a distro left detached by an attachment
+// that seemed to succeed is told apart from one that failed to attach. Half of the progress is reported
+// once the token is applied.
 func (t ProAttachment) Execute(ctx context.Context, conn task.Connection) error {
-	err := conn.SendProAttachment(t.Token)
+	step := "attach and enable services"
+	if t.Token == "" {
+		step = "detach"
+	}
+
+	err := task.RunStep(ctx, step, func() error {
+		return conn.SendProAttachment(t.Token)
+	})
+	if err != nil {
+		task.SkipStep(ctx, "verify", fmt.Sprintf("the %s step failed", step))
+		return task.NeedsRetryError{SourceErr: err}
+	}
+	task.ReportProgress(ctx, 50)
+
+	err = task.RunStep(ctx, "verify", func() error {
+		_, err := conn.SendCommand(&agentapi.Command{
+			Cmd: &agentapi.Command_ProStatus{
+				ProStatus: &agentapi.ProStatusCmd{Attached: t.Token != ""},
+			},
+		})
+		return err
+	})
 	if err != nil {
 		return task.NeedsRetryError{SourceErr: err}
 	}
@@ -34,8 +65,49 @@ func (t ProAttachment) String() string {
 	return fmt.Sprintf("%T task with token: %s", t, common.Obfuscate(t.Token))
 }
 
+// Priority is a custom priority. Detaching has high priority so that it is not held back by
+// long-running tasks, e.g. when the subscription has been revoked by policy. Attaching on behalf
+// of the user jumps ahead of background work.
+func (t ProAttachment) Priority() task.Priority {
+	if t.Token == "" {
+		return task.PriorityHigh
+	}
+	if t.UserInitiated {
+		return task.PriorityUser
+	}
+	return task.PriorityNormal
+}
+
 // Is is a custom comparator. All ProAttachment tasks are considered equivalent.
 func (t ProAttachment) Is(other task.Task) bool {
 	_, ok := other.(ProAttachment)
 	return ok
 }
+
+// IdempotencyKey is a custom idempotency key. Applying the same token again is redundant, so that registry
+// changes and reconnections that submit it once more do not pile up attachments.
+func (t ProAttachment) IdempotencyKey() string {
+	return "ProAttachment:" + t.Token
+}
+
+// Lane is a custom lane. The services and audits that need the subscription run after it.
+func (t ProAttachment) Lane() task.Lane {
+	return laneUbuntuPro
+}
+
+// Timeout is a custom timeout. Attaching gives up on a contracts server that does not answer, rather than
+// holding the lane back.
+func (t ProAttachment) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+// RetryPolicy is a custom retry policy. The contracts server may be unreachable for a while, so attaching is
+// retried a few times, waiting longer each time.
+func (t ProAttachment) RetryPolicy() task.RetryPolicy {
+	return task.RetryPolicy{MaxAttempts: 5, Backoff: time.Minute, MaxBackoff: 30 * time.Minute}
+}
+
+// NeedsProClient is true: attaching and detaching run the Ubuntu Pro client.
+func (t ProAttachment) NeedsProClient() bool {
+	return true
+}
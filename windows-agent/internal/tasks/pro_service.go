@@ -0,0 +1,62 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[ProService]()
+}
+
+// ProService is a task that enables or disables a single Ubuntu Pro service (e.g. esm-apps, usg)
+// in a distro that is already attached.
+type ProService struct {
+	Service string
+	Enable  bool
+}
+
+// Execute sends the command to the target WSL-Pro-Service so that the service is enabled or disabled.
+func (t ProService) Execute(ctx context.Context, conn task.Connection) error {
+	_, err := conn.SendCommand(&agentapi.Command{
+		Cmd: &agentapi.Command_ProService{
+			ProService: &agentapi.ProServiceCmd{
+				Service: t.Service,
+				Enable:  t.Enable,
+			},
+		},
+	})
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+	return nil
+}
+
+// String is needed to fulfil Task.
+func (t ProService) String() string {
+	verb := "disable"
+	if t.Enable {
+		verb = "enable"
+	}
+	return fmt.Sprintf("%T task to %s service %q", t, verb, t.Service)
+}
+
+// Is is a custom comparator. ProService tasks acting on the same service are considered equivalent.
+// In other words: newer instructions to enable or disable a service override old ones.
+func (t ProService) Is(other task.Task) bool {
+	o, ok := other.(ProService)
+	return ok && o.Service == t.Service
+}
+
+// Lane is a custom lane. It needs the distro to be attached first.
+func (t ProService) Lane() task.Lane {
+	return laneUbuntuPro
+}
+
+// NeedsProClient is true: enabling and disabling services run the Ubuntu Pro client.
+func (t ProService) NeedsProClient() bool {
+	return true
+}
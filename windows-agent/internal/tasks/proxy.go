@@ -0,0 +1,60 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[Proxy]()
+}
+
+// Proxy is a task that configures the proxy of apt, of login sessions (in /etc/environment) and of systemd
+// services in a distro, as the Pro client and snapd need it too. Without HTTP nor HTTPS proxy, it stops
+// managing the proxy, restoring the distro's own configuration.
+type Proxy struct {
+	HTTP    string
+	HTTPS   string
+	NoProxy string
+}
+
+// Execute sends the proxy settings to the target WSL-Pro-Service.
+func (t Proxy) Execute(ctx context.Context, conn task.Connection) error {
+	_, err := conn.SendCommand(&agentapi.Command{
+		Cmd: &agentapi.Command_Proxy{
+			Proxy: &agentapi.ProxyCmd{
+				Http:    t.HTTP,
+				Https:   t.HTTPS,
+				NoProxy: t.NoProxy,
+			},
+		},
+	})
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+	return nil
+}
+
+// String is needed to fulfil Task. The credentials of the proxies are hidden, as the string ends up in logs.
+func (t Proxy) String() string {
+	if t.HTTP == "" && t.HTTPS == "" {
+		return fmt.Sprintf("%T task to stop managing the proxy", t)
+	}
+	return fmt.Sprintf("%T task with HTTP proxy %q and HTTPS proxy %q", t, common.RedactURL(t.HTTP), common.RedactURL(t.HTTPS))
+}
+
+// Is is a custom comparator. All Proxy tasks are considered equivalent: only the latest settings matter.
+func (t Proxy) Is(other task.Task) bool {
+	_, ok := other.(Proxy)
+	return ok
+}
+
+// Lane is a custom lane, shared with the other configuration tasks: the proxy must not wait behind the
+// upgrades that may need it.
+func (t Proxy) Lane() task.Lane {
+	return laneConfiguration
+}
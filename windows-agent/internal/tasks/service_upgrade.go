@@ -0,0 +1,90 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[ServiceUpgrade]()
+}
+
+// ServiceUpgrade is a task that installs or upgrades wsl-pro-service in a distro from the
+// configured update channel.
+//
+// Upgrading the service restarts it, so the result of the command may never be received. The
+// task is then retried, which is harmless: re-installing the same version is a no-op.
+type ServiceUpgrade struct {
+	Channel  string
+	Source   string
+	Checksum string
+}
+
+// Execute sends the command to the target WSL-Pro-Service so that it upgrades itself. The deb of the local channel
+// is copied from Windows into the distro, so it counts towards the daily transfer cap of the distro: once the cap is
+// reached, the upgrade waits for it to reset.
+func (t ServiceUpgrade) Execute(ctx context.Context, conn task.Connection) error {
+	if t.Channel == config.ChannelLocal {
+		if err := task.ReserveTransfer(ctx, bandwidth.KindDebs, localDebSize(t.Source)); err != nil {
+			return task.NeedsRetryError{SourceErr: err}
+		}
+	}
+
+	_, err := conn.SendCommand(&agentapi.Command{
+		Cmd: &agentapi.Command_ServiceUpgrade{
+			ServiceUpgrade: &agentapi.ServiceUpgradeCmd{
+				Channel:  t.Channel,
+				Source:   t.Source,
+				Checksum: t.Checksum,
+			},
+		},
+	})
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+	return nil
+}
+
+// localDebSize returns the size of the deb at path, along with that of its detached signature. Files that cannot be
+// found count as empty: the distro reports them missing.
+func localDebSize(path string) uint64 {
+	var size uint64
+	for _, p := range []string{path, path + ".sig"} {
+		if info, err := os.Stat(p); err == nil {
+			size += uint64(info.Size())
+		}
+	}
+	return size
+}
+
+// String is needed to fulfil Task.
+func (t ServiceUpgrade) String() string {
+	if t.Source == "" {
+		return fmt.Sprintf("%T task from channel %q", t, t.Channel)
+	}
+	return fmt.Sprintf("%T task from channel %q (%s)", t, t.Channel, t.Source)
+}
+
+// Is is a custom comparator. All ServiceUpgrade tasks are considered equivalent: only the
+// latest channel matters.
+func (t ServiceUpgrade) Is(other task.Task) bool {
+	_, ok := other.(ServiceUpgrade)
+	return ok
+}
+
+// Lane is a custom lane. It is long-running, so the other tasks do not wait behind it.
+func (t ServiceUpgrade) Lane() task.Lane {
+	return laneUpgrade
+}
+
+// WakesDistro is false: stopped distros are upgraded once they run again rather than woken up all at once for it,
+// as the service is only needed while they run.
+func (t ServiceUpgrade) WakesDistro() bool {
+	return false
+}
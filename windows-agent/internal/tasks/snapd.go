@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[Snapd]()
+}
+
+// Snapd is a task that configures snapd in a distro: the proxy it reaches the snap store through, and the
+// enterprise snap store proxy it installs snaps from instead. snapd ignores the proxy variables of the
+// environment, so it needs its own configuration. Empty settings stop managing them, restoring snapd's defaults.
+type Snapd struct {
+	HTTP  string
+	HTTPS string
+
+	StoreProxy string
+	StoreID    string
+}
+
+// Execute sends the snapd settings to the target WSL-Pro-Service.
+func (t Snapd) Execute(ctx context.Context, conn task.Connection) error {
+	_, err := conn.SendCommand(&agentapi.Command{
+		Cmd: &agentapi.Command_Snapd{
+			Snapd: &agentapi.SnapdCmd{
+				Http:       t.HTTP,
+				Https:      t.HTTPS,
+				StoreProxy: t.StoreProxy,
+				StoreId:    t.StoreID,
+			},
+		},
+	})
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+	return nil
+}
+
+// String is needed to fulfil Task. The credentials of the proxies are hidden, as the string ends up in logs.
+func (t Snapd) String() string {
+	if t == (Snapd{}) {
+		return fmt.Sprintf("%T task to stop managing snapd", t)
+	}
+	return fmt.Sprintf("%T task with HTTP proxy %q, HTTPS proxy %q and store %q at %q", t, common.RedactURL(t.HTTP), common.RedactURL(t.HTTPS), t.StoreID, common.RedactURL(t.StoreProxy))
+}
+
+// Is is a custom comparator. All Snapd tasks are considered equivalent: only the latest settings matter.
+func (t Snapd) Is(other task.Task) bool {
+	_, ok := other.(Snapd)
+	return ok
+}
+
+// Lane is a custom lane, shared with the other configuration tasks: snaps must not wait behind the
+// upgrades that may need them.
+func (t Snapd) Lane() task.Lane {
+	return laneConfiguration
+}
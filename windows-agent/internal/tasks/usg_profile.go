@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/bandwidth"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/ubuntu/decorate"
+)
+
+func init() {
+	task.Register[UsgProfile]()
+}
+
+// UsgProfile is a task that audits a distro against a USG profile (e.g. cis_level1_server),
+// optionally remediating it with `usg fix` first. The audit report is stored at ReportPath.
+type UsgProfile struct {
+	Profile    string
+	Fix        bool
+	ReportPath string
+}
+
+// Execute sends the USG command to the target WSL-Pro-Service and stores the report it sends back.
+// Each of the two is reported as a step, so that a report that could not be stored is not mistaken
+// for a failed audit. Half of the progress is reported once the command is done. The report counts towards the
+// daily transfer cap of the distro, so the audit does not run once the cap is reached: it waits for it to reset.
+func (t UsgProfile) Execute(ctx context.Context, conn task.Connection) (err error) {
+	if err := task.ReserveTransfer(ctx, bandwidth.KindFiles, 0); err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+
+	step := "audit"
+	if t.Fix {
+		step = "fix and audit"
+	}
+
+	var report []byte
+	err = task.RunStep(ctx, step, func() (err error) {
+		report, err = conn.SendCommand(&agentapi.Command{
+			Cmd: &agentapi.Command_Usg{
+				Usg: &agentapi.UsgCmd{
+					Profile: t.Profile,
+					Fix:     t.Fix,
+				},
+			},
+		})
+		return err
+	})
+	task.RecordTransfer(ctx, bandwidth.KindFiles, uint64(len(report)))
+	if err != nil {
+		task.SkipStep(ctx, "store report", fmt.Sprintf("no report was produced by the %s step", step))
+		return task.NeedsRetryError{SourceErr: err}
+	}
+	task.ReportProgress(ctx, 50)
+
+	return task.RunStep(ctx, "store report", func() error {
+		return writeReport(t.ReportPath, report)
+	})
+}
+
+// writeReport atomically writes the report to the specified path.
+func writeReport(path string, report []byte) (err error) {
+	defer decorate.OnError(&err, "could not store report")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".new"
+	if err := os.WriteFile(tmp, report, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// String is needed to fulfil Task.
+func (t UsgProfile) String() string {
+	return fmt.Sprintf("%T task with profile %q (fix: %t)", t, t.Profile, t.Fix)
+}
+
+// ConsentPrompt makes remediation, which changes the configuration of the distro, require user confirmation.
+func (t UsgProfile) ConsentPrompt() string {
+	if !t.Fix {
+		return ""
+	}
+	return fmt.Sprintf("remediate the distro against USG profile %q, changing its configuration", t.Profile)
+}
+
+// Is is a custom comparator. UsgProfile tasks acting on the same profile are considered equivalent,
+// as long as they agree on whether to fix it: an audit must not deduplicate a remediation, nor vice versa.
+func (t UsgProfile) Is(other task.Task) bool {
+	o, ok := other.(UsgProfile)
+	return ok && o.Profile == t.Profile && o.Fix == t.Fix
+}
+
+// Lane is a custom lane. It needs the usg service to be enabled first.
+func (t UsgProfile) Lane() task.Lane {
+	return laneUbuntuPro
+}
@@ -0,0 +1,363 @@
+package contractclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/secrets"
+)
+
+// maxCachedSize is the largest response body that is cached.
+const maxCachedSize = 1 << 20
+
+// maxCacheEntries is how many responses the cache holds. The oldest ones are evicted first.
+const maxCacheEntries = 32
+
+// exchangeKeyPrefix tells the cached exchanges of user JWTs apart from the cached responses to GET requests.
+const exchangeKeyPrefix = "exchange "
+
+// Cache is an HTTP cache for the GET requests to the contract server. It honours the Cache-Control
+// directives of the responses, and revalidates stale responses with their ETag, so that repeated
+// requests (e.g. all distros reconciling after the machine resumes) do not download the same data
+// over and over. It also caches the Pro tokens user JWTs are exchanged for, see exchange.
+// It is safe to share a Cache between clients.
+type Cache struct {
+	entries map[string]cacheEntry
+	mu      sync.Mutex
+
+	// inflight are the exchanges being performed, by key, for concurrent exchanges to share their result.
+	inflight map[string]*exchangeCall
+
+	// path is the file the entries are persisted to. They are kept in memory only if empty.
+	path string
+
+	// now is overridable for testing.
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	ETag    string      `json:"etag,omitempty"`
+	Body    []byte      `json:"body"`
+	Header  http.Header `json:"header"`
+	Expires time.Time   `json:"expires"`
+
+	// Stored is when the entry was last stored, to evict the oldest ones first.
+	Stored time.Time `json:"stored"`
+}
+
+// NewCache creates an empty cache that is kept in memory only.
+func NewCache() *Cache {
+	return &Cache{
+		entries:  make(map[string]cacheEntry),
+		inflight: make(map[string]*exchangeCall),
+		now:      time.Now,
+	}
+}
+
+// OpenCache creates a cache persisted to the file at path, so that it outlives the process, and loads the entries
+// already in it. The file is encrypted, as it holds Pro tokens. The cache being an optimisation, a missing or
+// unreadable file gives an empty cache.
+func OpenCache(path string) (*Cache, error) {
+	c := NewCache()
+	c.path = path
+
+	out, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return c, fmt.Errorf("could not read cache file: %v", err)
+	}
+
+	out, err = secrets.Unprotect(out)
+	if err != nil {
+		return c, fmt.Errorf("could not decrypt cache file, starting afresh: %v", err)
+	}
+
+	if err := json.Unmarshal(out, &c.entries); err != nil {
+		c.entries = make(map[string]cacheEntry)
+		return c, fmt.Errorf("could not parse cache file, starting afresh: %v", err)
+	}
+
+	return c, nil
+}
+
+// do sends the request through the cache. Fresh responses are served without contacting the server,
+// and stale ones are revalidated. Only successful responses are cached.
+func (c *Cache) do(doer HTTPDoer, req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return doer.Do(req)
+	}
+
+	key := cacheKey(req)
+
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	c.mu.Unlock()
+
+	if found && c.now().Before(entry.Expires) {
+		return entry.response(req), nil
+	}
+
+	if found && entry.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	res, err := doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		res.Body.Close()
+		if !found {
+			// We did not ask for revalidation: nothing to serve.
+			return res, nil
+		}
+		entry.Expires = expiry(c.now(), res.Header)
+		c.store(key, entry)
+		return entry.response(req), nil
+	case http.StatusOK:
+	default:
+		// The cached response is no longer valid, e.g. the token was revoked.
+		c.forget(key)
+		return res, nil
+	}
+
+	directives := cacheControl(res.Header)
+	if _, noStore := directives["no-store"]; noStore || res.ContentLength < 0 || res.ContentLength > maxCachedSize {
+		c.forget(key)
+		return res, nil
+	}
+
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxCachedSize))
+	if err != nil {
+		return nil, err
+	}
+
+	entry = cacheEntry{
+		ETag:    res.Header.Get("ETag"),
+		Body:    body,
+		Header:  res.Header.Clone(),
+		Expires: expiry(c.now(), res.Header),
+	}
+
+	if entry.ETag != "" || c.now().Before(entry.Expires) {
+		c.store(key, entry)
+	} else {
+		c.forget(key)
+	}
+
+	return entry.response(req), nil
+}
+
+// exchangeCall is an exchange of a user JWT in progress.
+type exchangeCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// exchange returns the Pro token the contract server at u exchanges the user JWT for, calling post only if it
+// is not cached. The Microsoft Store issues a new JWT every time, so exchanges are keyed by the claims of the
+// JWT that identify the user rather than by the JWT itself, and cached until the JWT expires. Concurrent
+// exchanges of the same claims are coalesced into a single request, and failed ones are not cached.
+// JWTs that cannot be parsed or that do not expire are exchanged every time.
+func (c *Cache) exchange(ctx context.Context, u, userJWT string, post func() (string, error)) (string, error) {
+	claims, expires, err := parseJWT(userJWT)
+	if err != nil {
+		return post()
+	}
+	key := exchangeKeyPrefix + u + " " + claims
+
+	c.mu.Lock()
+	if entry, found := c.entries[key]; found && c.now().Before(entry.Expires) {
+		c.mu.Unlock()
+		return string(entry.Body), nil
+	}
+	if call, found := c.inflight[key]; found {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.token, call.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	call := &exchangeCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.token, call.err = post()
+	if call.err == nil && c.now().Before(expires) {
+		c.store(key, cacheEntry{Body: []byte(call.token), Expires: expires})
+	}
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.token, call.err
+}
+
+// store adds the entry to the cache, evicting the oldest entries if it is full.
+func (c *Cache) store(key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.Stored = c.now()
+	c.entries[key] = entry
+
+	for len(c.entries) > maxCacheEntries {
+		oldest := key
+		for k, e := range c.entries {
+			if e.Stored.Before(c.entries[oldest].Stored) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+
+	c.persist()
+}
+
+func (c *Cache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, found := c.entries[key]; !found {
+		return
+	}
+
+	delete(c.entries, key)
+	c.persist()
+}
+
+// persist writes the entries to the cache file, if any, encrypted. Failing to do so only costs a request
+// to the server after a restart, so errors are ignored.
+func (c *Cache) persist() {
+	if c.path == "" {
+		return
+	}
+
+	out, err := json.Marshal(c.entries)
+	if err != nil {
+		return
+	}
+	out, err = secrets.Protect(out)
+	if err != nil {
+		return
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return
+	}
+	if err := os.WriteFile(tmp, out, 0600); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, c.path)
+}
+
+// response builds a successful response out of the cached entry.
+func (e cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cacheKey identifies the request. Responses depend on the credentials, which are hashed
+// so that they are not kept in memory in plain text.
+func cacheKey(req *http.Request) string {
+	auth := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + " " + hex.EncodeToString(auth[:])
+}
+
+// parseJWT returns a hash of the claims of the JWT, leaving out those that change every time a JWT is issued,
+// and when the JWT expires. The signature is not verified: that is up to the contract server.
+func parseJWT(jwt string) (claims string, expires time.Time, err error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return "", time.Time{}, errors.New("not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not decode JWT payload: %v", err)
+	}
+
+	var c map[string]any
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return "", time.Time{}, fmt.Errorf("could not parse JWT claims: %v", err)
+	}
+
+	exp, ok := c["exp"].(float64)
+	if !ok {
+		return "", time.Time{}, errors.New("JWT does not expire")
+	}
+
+	for _, k := range []string{"exp", "iat", "nbf", "jti"} {
+		delete(c, k)
+	}
+
+	// Maps are marshalled with their keys sorted, so equal claims give the same hash.
+	out, err := json.Marshal(c)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not marshal JWT claims: %v", err)
+	}
+	sum := sha256.Sum256(out)
+
+	return hex.EncodeToString(sum[:]), time.Unix(int64(exp), 0), nil
+}
+
+// expiry returns until when a response received at the given time is fresh, according to its headers.
+// Responses that must be revalidated expire immediately.
+func expiry(now time.Time, h http.Header) time.Time {
+	directives := cacheControl(h)
+	if _, noCache := directives["no-cache"]; noCache {
+		return now
+	}
+
+	maxAge, err := strconv.Atoi(directives["max-age"])
+	if err != nil || maxAge <= 0 {
+		return now
+	}
+
+	return now.Add(time.Duration(maxAge) * time.Second)
+}
+
+// cacheControl parses the Cache-Control header into its directives and their (possibly empty) values.
+func cacheControl(h http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if name == "" {
+			continue
+		}
+		directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+	}
+	return directives
+}
@@ -11,12 +11,17 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	"github.com/canonical/ubuntu-pro-for-wsl/contractsapi"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/metrics"
 	"github.com/ubuntu/decorate"
 )
 
+// requestDuration keeps how long the contract server takes to answer, by path of the request.
+var requestDuration = metrics.Default.Summary("contracts_request_duration_seconds", "Time the contract server took to answer, by path of the request.", "path")
+
 // HTTPDoer is an interface to allow injecting an HTTP Client.
 type HTTPDoer interface {
 	Do(*http.Request) (*http.Response, error)
@@ -26,14 +31,55 @@ type HTTPDoer interface {
 type Client struct {
 	baseURL *url.URL
 	http    HTTPDoer
+	cache   *Cache
+}
+
+type options struct {
+	cache *Cache
+}
+
+// Option is an optional argument for New.
+type Option func(*options)
+
+// WithCache makes the client cache the responses of the contract server in the given cache.
+func WithCache(cache *Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
 }
 
 // New returns a Client instance caching a base URL.
-func New(base *url.URL, doer HTTPDoer) *Client {
+func New(base *url.URL, doer HTTPDoer, args ...Option) *Client {
+	var opts options
+	for _, f := range args {
+		f(&opts)
+	}
+
 	return &Client{
 		baseURL: base,
-		http:    doer,
+		http:    timedDoer{doer},
+		cache:   opts.cache,
+	}
+}
+
+// timedDoer records how long the requests take to be answered, whether they succeed or not.
+type timedDoer struct {
+	HTTPDoer
+}
+
+func (d timedDoer) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	defer func() { requestDuration.Observe(time.Since(start).Seconds(), req.URL.Path) }()
+
+	return d.HTTPDoer.Do(req)
+}
+
+// do sends the request, through the cache if there is one.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.cache == nil {
+		return c.http.Do(req)
 	}
+	return c.cache.do(c.http, req)
 }
 
 // GetServerAccessToken returns a short-lived auth token identifying the Contract Server backend.
@@ -49,12 +95,12 @@ func (c *Client) GetServerAccessToken(ctx context.Context) (token string, err er
 
 	req.Header.Set("Accept", "application/json")
 
-	res, err := c.http.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute the GET request: %v", err)
 	}
 
-	if err := checkLength(res.ContentLength); err != nil {
+	if err := checkLength(res.ContentLength, contractsapi.TokenMaxSize); err != nil {
 		return "", fmt.Errorf("invalid response content length: %v", err)
 	}
 
@@ -84,13 +130,23 @@ func (c *Client) GetServerAccessToken(ctx context.Context) (token string, err er
 func (c *Client) GetProToken(ctx context.Context, userJWT string) (token string, err error) {
 	defer decorate.OnError(&err, "couldn't download an Ubuntu Pro Token from the contract server")
 
-	if err := checkLength(int64(len(userJWT))); err != nil {
+	if err := checkLength(int64(len(userJWT)), contractsapi.TokenMaxSize); err != nil {
 		return "", fmt.Errorf("invalid user JWT: %v", err)
 	}
 
 	// baseurl/v1/subscription.
 	u := c.baseURL.JoinPath(contractsapi.Version, contractsapi.SubscriptionPath)
 
+	if c.cache == nil {
+		return c.exchangeProToken(ctx, u, userJWT)
+	}
+	return c.cache.exchange(ctx, u.String(), userJWT, func() (string, error) {
+		return c.exchangeProToken(ctx, u, userJWT)
+	})
+}
+
+// exchangeProToken POSTs the user JWT to the contract server at u, and returns the Pro token it is exchanged for.
+func (c *Client) exchangeProToken(ctx context.Context, u *url.URL, userJWT string) (string, error) {
 	jsonData, err := json.Marshal(contractsapi.SubscriptionRequest{
 		MSStoreIDKey: userJWT,
 	})
@@ -103,12 +159,12 @@ func (c *Client) GetProToken(ctx context.Context, userJWT string) (token string,
 		return "", fmt.Errorf("could not create a POST request: %v", err)
 	}
 
-	res, err := c.http.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute the POST request: %v", err)
 	}
 
-	if err := checkLength(res.ContentLength); err != nil {
+	if err := checkLength(res.ContentLength, contractsapi.TokenMaxSize); err != nil {
 		return "", fmt.Errorf("invalid response content length: %v", err)
 	}
 
@@ -148,8 +204,57 @@ func (c *Client) GetProToken(ctx context.Context, userJWT string) (token string,
 	return "", fmt.Errorf("response did not contain any valid subscriptions: %s", res.Body)
 }
 
-// checkLength sanity checks that 0 < length < apiTokenMaxSize.
-func checkLength(length int64) error {
+// GetContract returns the contract of a Pro token, which describes the entitlements it grants. Contracts are
+// not cached, as the response depends on the token and not only on the URL.
+func (c *Client) GetContract(ctx context.Context, proToken string) (contract contractsapi.ContractInfo, err error) {
+	defer decorate.OnError(&err, "couldn't download the contract from the contract server")
+
+	if err := checkLength(int64(len(proToken)), contractsapi.TokenMaxSize); err != nil {
+		return contract, fmt.Errorf("invalid Pro token: %v", err)
+	}
+
+	// baseurl/v1/contract.
+	u := c.baseURL.JoinPath(contractsapi.Version, contractsapi.ContractPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return contract, fmt.Errorf("could not create a GET request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+proToken)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return contract, fmt.Errorf("failed to execute the GET request: %v", err)
+	}
+
+	if err := checkLength(res.ContentLength, contractsapi.ContractMaxSize); err != nil {
+		return contract, fmt.Errorf("invalid response content length: %v", err)
+	}
+
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return contract, fmt.Errorf("bad Pro token: %s", common.Obfuscate(proToken))
+	default:
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return contract, fmt.Errorf("unknown error from the contracts server: Code %d, %v", res.StatusCode, err)
+		}
+		return contract, fmt.Errorf("unknown error from the contracts server: Code %d, %s", res.StatusCode, body)
+	case http.StatusOK:
+	}
+
+	var resp contractsapi.ContractResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return contract, fmt.Errorf("could not decode the response: %v", err)
+	}
+
+	return resp.ContractInfo, nil
+}
+
+// checkLength sanity checks that 0 < length < limit.
+func checkLength(length, limit int64) error {
 	if length < 0 {
 		return errors.New("negative length")
 	}
@@ -158,8 +263,8 @@ func checkLength(length int64) error {
 		return errors.New("empty")
 	}
 
-	if length > contractsapi.TokenMaxSize {
-		return fmt.Errorf("too big: %d bytes, limit is %d", length, contractsapi.TokenMaxSize)
+	if length > limit {
+		return fmt.Errorf("too big: %d bytes, limit is %d", length, limit)
 	}
 
 	return nil
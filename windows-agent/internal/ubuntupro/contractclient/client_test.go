@@ -3,12 +3,15 @@ package contractclient_test
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -183,6 +186,89 @@ func TestGetProToken(t *testing.T) {
 	}
 }
 
+func TestGetContract(t *testing.T) {
+	t.Parallel()
+
+	expiry := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	goodContract, err := json.Marshal(contractsapi.ContractResponse{
+		ContractInfo: contractsapi.ContractInfo{
+			EffectiveTo: expiry,
+			ResourceEntitlements: []contractsapi.Entitlement{
+				{Type: "esm-infra", Entitled: true},
+				{Type: "support", Entitled: true, Affordances: contractsapi.Affordances{SupportLevel: "standard"}},
+			},
+		},
+	})
+	require.NoError(t, err, "Setup: unexpected error when marshalling the good contract")
+
+	testCases := map[string]struct {
+		token string
+
+		errorOnDo            bool
+		responseContent      []byte
+		unknownContentLength bool
+		statusCode           int
+
+		wantErr bool
+	}{
+		"Success": {},
+
+		"Error with a too big token":         {token: strings.Repeat("REPEAT_TOO_BIG_TOKEN", 230), wantErr: true},
+		"Error with empty token":             {token: "-", wantErr: true},
+		"Error with unauthorized token":      {statusCode: 401, responseContent: []byte("UNAUTHORIZED"), wantErr: true},
+		"Error with unexpected status code":  {statusCode: 500, responseContent: []byte("UNKNOWN SERVER ERROR"), wantErr: true},
+		"Error on http.Do":                   {errorOnDo: true, wantErr: true},
+		"Error with invalid JSON":            {responseContent: []byte("invalid JSON"), wantErr: true},
+		"Error with empty response body":     {responseContent: []byte(""), wantErr: true},
+		"Error with unknown response length": {unknownContentLength: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if len(tc.token) == 0 { // we want a simple default.
+				tc.token = "TOKEN"
+			} else if tc.token == "-" { // we want to exercise the case of the empty string.
+				tc.token = ""
+			}
+
+			if tc.statusCode == 0 {
+				tc.statusCode = http.StatusOK
+			}
+
+			if tc.responseContent == nil {
+				tc.responseContent = goodContract
+			}
+
+			l := int64(len(tc.responseContent))
+			if tc.unknownContentLength {
+				l = -1
+			}
+			h := HTTPMock{
+				errorOnDo: tc.errorOnDo,
+				response:  http.Response{Body: io.NopCloser(bytes.NewReader(tc.responseContent)), StatusCode: tc.statusCode, ContentLength: l},
+			}
+			u, err := url.Parse("https://localhost:1234")
+			require.NoError(t, err, "Setup: URL parsing should not fail")
+
+			client := contractclient.New(u, h)
+
+			got, err := client.GetContract(context.Background(), tc.token)
+			if tc.wantErr {
+				require.Errorf(t, err, "Got contract %v when failure was expected", got)
+				return
+			}
+			require.NoError(t, err, "GetContract should return no errors")
+
+			require.True(t, expiry.Equal(got.EffectiveTo), "Mismatched contract expiry")
+			require.Len(t, got.ResourceEntitlements, 2, "Mismatched number of entitlements")
+			require.Equal(t, "standard", got.ResourceEntitlements[1].Affordances.SupportLevel, "Mismatched support level")
+		})
+	}
+}
+
 func TestGetServerAccessTokenNet(t *testing.T) {
 	t.Parallel()
 
@@ -343,6 +429,346 @@ func TestGetProTokenNet(t *testing.T) {
 	}
 }
 
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	const requests = 3
+
+	testCases := map[string]struct {
+		noCache      bool
+		cacheControl string
+		// elapsed is the time between consecutive requests.
+		elapsed     time.Duration
+		serverError bool
+
+		wantStatuses []int
+		wantErr      bool
+	}{
+		"Success revalidating with the ETag":                   {wantStatuses: []int{http.StatusOK, http.StatusNotModified, http.StatusNotModified}},
+		"Success serving fresh responses from the cache":       {cacheControl: "max-age=60", wantStatuses: []int{http.StatusOK}},
+		"Success revalidating stale responses":                 {cacheControl: "max-age=60", elapsed: 45 * time.Second, wantStatuses: []int{http.StatusOK, http.StatusNotModified}},
+		"Success revalidating when told to":                    {cacheControl: "no-cache, max-age=60", wantStatuses: []int{http.StatusOK, http.StatusNotModified, http.StatusNotModified}},
+		"Success not caching when told not to":                 {cacheControl: "no-store", wantStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+		"Success reaching the server every time with no cache": {noCache: true, cacheControl: "max-age=60", wantStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+
+		"Error responses are not cached": {serverError: true, cacheControl: "max-age=60", wantStatuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			settings := contractsmockserver.DefaultSettings()
+			settings.CacheControl = tc.cacheControl
+			if tc.serverError {
+				settings.Token.OnSuccess.Status = http.StatusInternalServerError
+			}
+
+			s := contractsmockserver.NewServer(settings)
+			err := s.Serve(ctx, "localhost:0")
+			require.NoError(t, err, "Setup: Server should return no error")
+			//nolint:errcheck // Nothing we can do about it
+			defer s.Stop()
+
+			u, err := url.Parse(fmt.Sprintf("http://%s", s.Address()))
+			require.NoError(t, err, "Setup: URL parsing should not fail")
+
+			now := time.Now()
+			cache := contractclient.NewCache()
+			cache.SetNow(func() time.Time { return now })
+
+			var opts []contractclient.Option
+			if !tc.noCache {
+				opts = append(opts, contractclient.WithCache(cache))
+			}
+
+			doer := &recordingDoer{doer: &http.Client{Timeout: 3 * time.Second}}
+
+			for i := range requests {
+				// A new client every time, as the agent does: the cache is what outlives them.
+				client := contractclient.New(u, doer, opts...)
+
+				got, err := client.GetServerAccessToken(ctx)
+				if tc.wantErr {
+					require.Error(t, err, "GetServerAccessToken should return an error")
+				} else {
+					require.NoError(t, err, "GetServerAccessToken should return no error in request %d", i)
+					require.Equal(t, contractsmockserver.DefaultADToken, got, "Mismatched token in request %d", i)
+				}
+
+				now = now.Add(tc.elapsed)
+			}
+
+			require.Equal(t, tc.wantStatuses, doer.statuses, "Mismatched responses from the server")
+		})
+	}
+}
+
+func TestCachePersistence(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		corruptFile bool
+		noFile      bool
+
+		wantStatuses []int
+		wantOpenErr  bool
+	}{
+		"Success serving responses cached before a restart":      {wantStatuses: []int{http.StatusOK}},
+		"Success reaching the server with an in-memory cache":    {noFile: true, wantStatuses: []int{http.StatusOK, http.StatusOK}},
+		"Success starting afresh when the cache file is corrupt": {corruptFile: true, wantStatuses: []int{http.StatusOK}, wantOpenErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			settings := contractsmockserver.DefaultSettings()
+			settings.CacheControl = "max-age=60"
+
+			s := contractsmockserver.NewServer(settings)
+			err := s.Serve(ctx, "localhost:0")
+			require.NoError(t, err, "Setup: Server should return no error")
+			//nolint:errcheck // Nothing we can do about it
+			defer s.Stop()
+
+			u, err := url.Parse(fmt.Sprintf("http://%s", s.Address()))
+			require.NoError(t, err, "Setup: URL parsing should not fail")
+
+			path := filepath.Join(t.TempDir(), "sandbox", "cache.json")
+			if tc.corruptFile {
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700), "Setup: could not create the cache directory")
+				require.NoError(t, os.WriteFile(path, []byte("{corrupt"), 0600), "Setup: could not write the corrupt cache file")
+			}
+
+			doer := &recordingDoer{doer: &http.Client{Timeout: 3 * time.Second}}
+
+			// Each iteration stands for a new process, with a new cache.
+			for i := range 2 {
+				cache := contractclient.NewCache()
+				if !tc.noFile {
+					cache, err = contractclient.OpenCache(path)
+					if tc.wantOpenErr && i == 0 {
+						require.Error(t, err, "OpenCache should return an error")
+					} else {
+						require.NoError(t, err, "OpenCache should return no error in process %d", i)
+					}
+				}
+
+				client := contractclient.New(u, doer, contractclient.WithCache(cache))
+				got, err := client.GetServerAccessToken(ctx)
+				require.NoError(t, err, "GetServerAccessToken should return no error in process %d", i)
+				require.Equal(t, contractsmockserver.DefaultADToken, got, "Mismatched token in process %d", i)
+			}
+
+			require.Equal(t, tc.wantStatuses, doer.statuses, "Mismatched responses from the server")
+		})
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	cache := contractclient.NewCache()
+	cache.SetNow(func() time.Time { return now })
+
+	doer := &freshDoer{}
+	get := func(i int) {
+		u, err := url.Parse(fmt.Sprintf("http://localhost/%d", i))
+		require.NoError(t, err, "Setup: URL parsing should not fail")
+
+		_, err = contractclient.New(u, doer, contractclient.WithCache(cache)).GetServerAccessToken(context.Background())
+		require.NoError(t, err, "GetServerAccessToken should return no error")
+	}
+
+	const extra = 5
+	for i := range contractclient.MaxCacheEntries + extra {
+		get(i)
+		now = now.Add(time.Second)
+	}
+
+	require.Equal(t, contractclient.MaxCacheEntries, cache.Len(), "The cache should not grow past its limit")
+	require.Equal(t, contractclient.MaxCacheEntries+extra, doer.requests, "Every first request should reach the server")
+
+	get(contractclient.MaxCacheEntries + extra - 1)
+	require.Equal(t, contractclient.MaxCacheEntries+extra, doer.requests, "The newest response should still be cached")
+
+	get(0)
+	require.Equal(t, contractclient.MaxCacheEntries+extra+1, doer.requests, "The oldest response should have been evicted")
+}
+
+func TestExchangeCache(t *testing.T) {
+	t.Parallel()
+
+	const exchanges = 3
+
+	testCases := map[string]struct {
+		noCache     bool
+		restart     bool
+		otherUsers  bool
+		invalidJWT  bool
+		serverError bool
+		// elapsed is the time between consecutive exchanges.
+		elapsed time.Duration
+
+		wantExchanges int
+		wantErr       bool
+	}{
+		"Success exchanging JWTs of the same user once":     {wantExchanges: 1},
+		"Success serving exchanges cached before a restart": {restart: true, wantExchanges: 1},
+		"Success exchanging again once the JWT expires":     {elapsed: 45 * time.Minute, wantExchanges: 2},
+		"Success exchanging JWTs of different users":        {otherUsers: true, wantExchanges: exchanges},
+		"Success exchanging JWTs that cannot be parsed":     {invalidJWT: true, wantExchanges: exchanges},
+		"Success exchanging every time with no cache":       {noCache: true, wantExchanges: exchanges},
+
+		"Error exchanges are not cached": {serverError: true, wantExchanges: exchanges, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			settings := contractsmockserver.DefaultSettings()
+			if tc.serverError {
+				settings.Subscription.OnSuccess.Status = http.StatusInternalServerError
+			}
+
+			s := contractsmockserver.NewServer(settings)
+			err := s.Serve(ctx, "localhost:0")
+			require.NoError(t, err, "Setup: Server should return no error")
+			//nolint:errcheck // Nothing we can do about it
+			defer s.Stop()
+
+			u, err := url.Parse(fmt.Sprintf("http://%s", s.Address()))
+			require.NoError(t, err, "Setup: URL parsing should not fail")
+
+			path := filepath.Join(t.TempDir(), "cache.json")
+			now := time.Now()
+
+			var opts []contractclient.Option
+			for i := range exchanges {
+				// Opening the cache anew stands for a restart.
+				if !tc.noCache && (i == 0 || tc.restart) {
+					cache, err := contractclient.OpenCache(path)
+					require.NoError(t, err, "Setup: OpenCache should return no error")
+					cache.SetNow(func() time.Time { return now })
+					opts = []contractclient.Option{contractclient.WithCache(cache)}
+				}
+
+				// The Microsoft Store issues a new JWT every time.
+				user := "user"
+				if tc.otherUsers {
+					user = fmt.Sprintf("user%d", i)
+				}
+				jwt := newJWT(t, user, now, now.Add(time.Hour))
+				if tc.invalidJWT {
+					jwt = "JWT"
+				}
+
+				client := contractclient.New(u, &http.Client{Timeout: 3 * time.Second}, opts...)
+				got, err := client.GetProToken(ctx, jwt)
+				if tc.wantErr {
+					require.Error(t, err, "GetProToken should return an error")
+				} else {
+					require.NoError(t, err, "GetProToken should return no error in exchange %d", i)
+					require.Equal(t, contractsmockserver.DefaultProToken, got, "Mismatched token in exchange %d", i)
+				}
+
+				now = now.Add(tc.elapsed)
+			}
+
+			require.Equal(t, tc.wantExchanges, s.Exchanges(), "Mismatched number of exchanges reaching the server")
+		})
+	}
+}
+
+func TestConcurrentExchanges(t *testing.T) {
+	t.Parallel()
+
+	const exchanges = 10
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := contractsmockserver.NewServer(contractsmockserver.DefaultSettings())
+	err := s.Serve(ctx, "localhost:0")
+	require.NoError(t, err, "Setup: Server should return no error")
+	//nolint:errcheck // Nothing we can do about it
+	defer s.Stop()
+
+	u, err := url.Parse(fmt.Sprintf("http://%s", s.Address()))
+	require.NoError(t, err, "Setup: URL parsing should not fail")
+
+	cache := contractclient.NewCache()
+	errs := make(chan error, exchanges)
+	for i := range exchanges {
+		jwt := newJWT(t, "user", time.Now().Add(time.Duration(i)*time.Second), time.Now().Add(time.Hour))
+		go func() {
+			_, err := contractclient.New(u, &http.Client{Timeout: 3 * time.Second}, contractclient.WithCache(cache)).GetProToken(ctx, jwt)
+			errs <- err
+		}()
+	}
+
+	for range exchanges {
+		require.NoError(t, <-errs, "GetProToken should return no error")
+	}
+	require.Equal(t, 1, s.Exchanges(), "Concurrent exchanges of the same user should reach the server once")
+}
+
+// freshDoer answers every request with a token that stays fresh for a minute.
+type freshDoer struct {
+	requests int
+}
+
+func (d *freshDoer) Do(req *http.Request) (*http.Response, error) {
+	d.requests++
+
+	body := fmt.Sprintf(`{%q: %q}`, contractsapi.ADTokenKey, contractsmockserver.DefaultADToken)
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Cache-Control": []string{"max-age=60"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// recordingDoer records the status codes of the responses of the server.
+type recordingDoer struct {
+	doer     contractclient.HTTPDoer
+	statuses []int
+}
+
+func (d *recordingDoer) Do(req *http.Request) (*http.Response, error) {
+	res, err := d.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	d.statuses = append(d.statuses, res.StatusCode)
+	return res, nil
+}
+
+// newJWT returns an unsigned JWT of the user, issued and expiring at the given times.
+func newJWT(t *testing.T, user string, issued, expires time.Time) string {
+	t.Helper()
+
+	claims, err := json.Marshal(map[string]any{"sub": user, "iat": issued.Unix(), "exp": expires.Unix()})
+	require.NoError(t, err, "Setup: could not marshal the claims of the JWT")
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString(claims) + ".signature"
+}
+
 type HTTPMock struct {
 	errorOnDo bool
 	response  http.Response
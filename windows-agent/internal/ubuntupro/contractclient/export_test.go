@@ -0,0 +1,19 @@
+package contractclient
+
+import "time"
+
+// SetNow overrides the clock of the cache.
+func (c *Cache) SetNow(now func() time.Time) {
+	c.now = now
+}
+
+// Len returns how many responses are cached.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// MaxCacheEntries is how many responses the cache holds.
+const MaxCacheEntries = maxCacheEntries
@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/contractsapi"
 	"github.com/canonical/ubuntu-pro-for-wsl/storeapi/go-wrapper/microsoftstore"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contractclient"
 	"github.com/ubuntu/decorate"
@@ -17,6 +18,10 @@ import (
 type options struct {
 	proURL         *url.URL
 	microsoftStore MicrosoftStore
+	outbound       Outbound
+	cache          *contractclient.Cache
+	proxy          Proxy
+	health         *Health
 }
 
 // Option is an optional argument for ProToken.
@@ -36,6 +41,54 @@ func WithMockMicrosoftStore(store MicrosoftStore) Option {
 	}
 }
 
+// WithCache makes the contract clients share the given cache, so that responses outlive the clients
+// that fetched them. Responses are not cached otherwise.
+func WithCache(cache *contractclient.Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// WithProxy makes the requests to the contract server go through the proxy, instead of that of the environment
+// variables or of the system.
+func WithProxy(proxy Proxy) Option {
+	return func(o *options) {
+		o.proxy = proxy
+	}
+}
+
+// WithOutbound delegates the operations to another implementation, such as one running in a separate
+// process. The other options are ignored: they are up to the delegate.
+func WithOutbound(o Outbound) Option {
+	return func(opts *options) {
+		opts.outbound = o
+	}
+}
+
+// WithHealth records the outcome of the interactions with the contract server in h, whether they are
+// delegated to an Outbound or not.
+func WithHealth(h *Health) Option {
+	return func(o *options) {
+		o.health = h
+	}
+}
+
+// HealthOf returns the health tracker set by WithHealth among args, if any.
+func HealthOf(args ...Option) *Health {
+	var opts options
+	for _, f := range args {
+		f(&opts)
+	}
+	return opts.health
+}
+
+// Outbound performs the network-facing operations of this package.
+type Outbound interface {
+	ValidSubscription() (bool, error)
+	NewProToken(ctx context.Context) (string, error)
+	Contract(ctx context.Context, proToken string) (contractsapi.ContractInfo, error)
+}
+
 // MicrosoftStore is an interface to the Microsoft store API.
 type MicrosoftStore interface {
 	GenerateUserJWT(azureADToken string) (jwt string, err error)
@@ -63,6 +116,10 @@ func ValidSubscription(args ...Option) (bool, error) {
 		f(&opts)
 	}
 
+	if opts.outbound != nil {
+		return opts.outbound.ValidSubscription()
+	}
+
 	expiration, err := opts.microsoftStore.GetSubscriptionExpirationDate()
 	if err != nil {
 		var target microsoftstore.StoreAPIError
@@ -97,15 +154,16 @@ func NewProToken(ctx context.Context, args ...Option) (token string, err error)
 		f(&opts)
 	}
 
-	if opts.proURL == nil {
-		url, err := defaultProBackendURL()
-		if err != nil {
-			return "", fmt.Errorf("could not parse contract server URL: %v", err)
-		}
-		opts.proURL = url
+	defer func() { opts.health.recordTokenRefresh(err) }()
+
+	if opts.outbound != nil {
+		return opts.outbound.NewProToken(ctx)
 	}
 
-	contractClient := contractclient.New(opts.proURL, &http.Client{Timeout: 30 * time.Second})
+	contractClient, err := opts.contractClient()
+	if err != nil {
+		return "", err
+	}
 	msftStore := opts.microsoftStore
 
 	adToken, err := contractClient.GetServerAccessToken(ctx)
@@ -125,3 +183,49 @@ func NewProToken(ctx context.Context, args ...Option) (token string, err error)
 
 	return proToken, nil
 }
+
+// Contract queries the contract server for the contract of the Pro token, which describes the
+// entitlements it grants and when it expires.
+func Contract(ctx context.Context, proToken string, args ...Option) (contract contractsapi.ContractInfo, err error) {
+	defer decorate.OnError(&err, "couldn't get the contract of the Ubuntu Pro token")
+
+	opts := options{}
+	for _, f := range args {
+		f(&opts)
+	}
+
+	defer func() { opts.health.recordEntitlementSync(err) }()
+
+	if opts.outbound != nil {
+		return opts.outbound.Contract(ctx, proToken)
+	}
+
+	contractClient, err := opts.contractClient()
+	if err != nil {
+		return contract, err
+	}
+
+	return contractClient.GetContract(ctx, proToken)
+}
+
+// contractClient returns a client to the contract server at the configured URL.
+func (o options) contractClient() (*contractclient.Client, error) {
+	proURL := o.proURL
+	if proURL == nil {
+		url, err := defaultProBackendURL()
+		if err != nil {
+			return nil, fmt.Errorf("could not parse contract server URL: %v", err)
+		}
+		proURL = url
+	}
+
+	var args []contractclient.Option
+	if o.cache != nil {
+		args = append(args, contractclient.WithCache(o.cache))
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = o.proxyFunc()
+
+	return contractclient.New(proURL, &http.Client{Timeout: 30 * time.Second, Transport: transport}, args...), nil
+}
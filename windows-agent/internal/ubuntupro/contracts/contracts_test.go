@@ -4,10 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http/httptest"
+	"net/http/httputil"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/contractsapi"
 	"github.com/canonical/ubuntu-pro-for-wsl/mocks/contractserver/contractsmockserver"
 	"github.com/canonical/ubuntu-pro-for-wsl/storeapi/go-wrapper/microsoftstore"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
@@ -138,6 +145,296 @@ func TestValidSubscription(t *testing.T) {
 	}
 }
 
+func TestContract(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		token            string
+		disabledEndpoint bool
+
+		wantErr bool
+	}{
+		"Success": {token: contractsmockserver.DefaultProToken},
+
+		"Error when the token is unknown to the contract server": {token: "UNKNOWN_TOKEN", wantErr: true},
+		"Error when the token is empty":                          {wantErr: true},
+		"Error when the contract server's GetContract fails":     {token: contractsmockserver.DefaultProToken, disabledEndpoint: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			settings := contractsmockserver.DefaultSettings()
+			settings.Contract.Disabled = tc.disabledEndpoint
+
+			server := contractsmockserver.NewServer(settings)
+			err := server.Serve(ctx, "localhost:0")
+			require.NoError(t, err, "Setup: Server should return no error")
+			//nolint:errcheck // Nothing we can do about it
+			defer server.Stop()
+
+			url, err := url.Parse(fmt.Sprintf("http://%s", server.Address()))
+			require.NoError(t, err, "Setup: Server URL should have been parsed with no issues")
+
+			contract, err := contracts.Contract(ctx, tc.token, contracts.WithProURL(url))
+			if tc.wantErr {
+				require.Error(t, err, "Contract should return an error")
+				return
+			}
+			require.NoError(t, err, "Contract should return no error")
+
+			require.True(t, contract.EffectiveTo.Equal(contractsmockserver.DefaultContractExpiry), "Unexpected contract expiry")
+			require.NotEmpty(t, contract.ResourceEntitlements, "Contract should list its entitlements")
+		})
+	}
+}
+
+func TestWithOutbound(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// Nothing listens here: every operation must be delegated.
+	url, err := url.Parse("http://127.0.0.1:1")
+	require.NoError(t, err, "Setup: URL should have been parsed with no issues")
+
+	o := &mockOutbound{}
+	args := []contracts.Option{contracts.WithProURL(url), contracts.WithOutbound(o)}
+
+	valid, err := contracts.ValidSubscription(args...)
+	require.NoError(t, err, "ValidSubscription should return no error")
+	require.True(t, valid, "ValidSubscription should return the delegate's answer")
+
+	token, err := contracts.NewProToken(ctx, args...)
+	require.NoError(t, err, "NewProToken should return no error")
+	require.Equal(t, "OUTBOUND_TOKEN", token, "NewProToken should return the delegate's token")
+
+	contract, err := contracts.Contract(ctx, "OUTBOUND_TOKEN", args...)
+	require.NoError(t, err, "Contract should return no error")
+	require.Equal(t, "OUTBOUND_CONTRACT", contract.ID, "Contract should return the delegate's contract")
+
+	require.Equal(t, []string{"ValidSubscription", "NewProToken", "Contract"}, o.calls, "Every operation should have been delegated")
+}
+
+func TestHealth(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		previousState string
+		failToken     bool
+		failContract  bool
+
+		wantTokenRefresh    bool
+		wantEntitlementSync bool
+		wantErr             bool
+		wantOpenErr         bool
+	}{
+		"Success recording the token refresh and the entitlement sync": {wantTokenRefresh: true, wantEntitlementSync: true},
+		"Success recording the entitlement sync after a failed token refresh": {
+			failToken: true, wantEntitlementSync: true,
+		},
+		"Success recording a failed entitlement sync":          {failContract: true, wantTokenRefresh: true, wantErr: true},
+		"Success recording failures without any prior contact": {failToken: true, failContract: true, wantErr: true},
+		"Success keeping the previous state":                   {previousState: "previous", failToken: true, failContract: true, wantTokenRefresh: true, wantEntitlementSync: true, wantErr: true},
+
+		"Error when the health file is corrupt": {previousState: "corrupt", wantTokenRefresh: true, wantEntitlementSync: true, wantOpenErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "health.json")
+
+			switch tc.previousState {
+			case "previous":
+				h, err := contracts.OpenHealth(path)
+				require.NoError(t, err, "Setup: OpenHealth should return no error")
+				_, err = contracts.NewProToken(ctx, contracts.WithOutbound(&mockOutbound{}), contracts.WithHealth(h))
+				require.NoError(t, err, "Setup: NewProToken should return no error")
+				_, err = contracts.Contract(ctx, "OUTBOUND_TOKEN", contracts.WithOutbound(&mockOutbound{}), contracts.WithHealth(h))
+				require.NoError(t, err, "Setup: Contract should return no error")
+			case "corrupt":
+				err := os.WriteFile(path, []byte("not json"), 0600)
+				require.NoError(t, err, "Setup: could not write the corrupt health file")
+			}
+
+			h, err := contracts.OpenHealth(path)
+			if tc.wantOpenErr {
+				require.Error(t, err, "OpenHealth should return an error")
+			} else {
+				require.NoError(t, err, "OpenHealth should return no error")
+			}
+			previous := h.State()
+
+			var changes atomic.Int32
+			h.OnChange(func() { changes.Add(1) })
+
+			before := time.Now()
+
+			tokenErr, contractErr := errors.New("token error"), errors.New("contract error")
+			if !tc.failToken {
+				tokenErr = nil
+			}
+			if !tc.failContract {
+				contractErr = nil
+			}
+
+			_, err = contracts.NewProToken(ctx, contracts.WithOutbound(&mockOutbound{err: tokenErr}), contracts.WithHealth(h))
+			require.Equal(t, tc.failToken, err != nil, "Unexpected outcome of NewProToken")
+			_, err = contracts.Contract(ctx, "OUTBOUND_TOKEN", contracts.WithOutbound(&mockOutbound{err: contractErr}), contracts.WithHealth(h))
+			require.Equal(t, tc.failContract, err != nil, "Unexpected outcome of Contract")
+
+			require.EqualValues(t, 2, changes.Load(), "OnChange callbacks should have been called after every interaction")
+
+			// The state must have been persisted.
+			reopened, err := contracts.OpenHealth(path)
+			require.NoError(t, err, "OpenHealth should return no error when reopening the health file")
+			state := h.State()
+			require.Equal(t, state.LastError, reopened.State().LastError, "The state should have been persisted")
+			require.True(t, state.LastContact.Equal(reopened.State().LastContact), "The state should have been persisted")
+
+			requireRecorded := func(got, previous time.Time, want bool, what string) {
+				t.Helper()
+				if !want {
+					require.True(t, got.IsZero(), "%s should not have been recorded", what)
+					return
+				}
+				require.True(t, got.Equal(previous) || !got.Before(before), "%s should have been recorded", what)
+				require.False(t, got.IsZero(), "%s should have been recorded", what)
+			}
+			requireRecorded(state.LastTokenRefresh, previous.LastTokenRefresh, tc.wantTokenRefresh, "The token refresh")
+			requireRecorded(state.LastEntitlementSync, previous.LastEntitlementSync, tc.wantEntitlementSync, "The entitlement sync")
+			requireRecorded(state.LastContact, previous.LastContact, tc.wantTokenRefresh || tc.wantEntitlementSync, "The last contact")
+
+			if !tc.wantErr {
+				require.Empty(t, state.LastError, "No error should be reported after a successful interaction")
+				require.True(t, state.LastErrorTime.IsZero(), "No error time should be reported after a successful interaction")
+				return
+			}
+			require.Equal(t, "contract error", state.LastError, "The last error should be reported")
+			require.False(t, state.LastErrorTime.Before(before), "The time of the last error should be reported")
+		})
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		proxy contracts.Proxy
+
+		wantProxied bool
+		wantErr     bool
+	}{
+		"Success through the HTTP proxy":            {proxy: contracts.Proxy{HTTP: "{{PROXY}}"}, wantProxied: true},
+		"Success through the proxy of every scheme": {proxy: contracts.Proxy{HTTP: "{{PROXY}}", HTTPS: "{{PROXY}}"}, wantProxied: true},
+
+		"Error when the proxy is only for HTTPS":     {proxy: contracts.Proxy{HTTPS: "{{PROXY}}"}, wantErr: true},
+		"Error when the contract server is bypassed": {proxy: contracts.Proxy{HTTP: "{{PROXY}}", NoProxy: "contracts.invalid"}, wantErr: true},
+		"Error when the proxy is unreachable":        {proxy: contracts.Proxy{HTTP: "http://127.0.0.1:1"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			server := contractsmockserver.NewServer(contractsmockserver.DefaultSettings())
+			err := server.Serve(ctx, "localhost:0")
+			require.NoError(t, err, "Setup: Server should return no error")
+			//nolint:errcheck // Nothing we can do about it
+			defer server.Stop()
+
+			// The proxy forwards every request to the contract server, which can only be reached through it.
+			var proxied atomic.Int32
+			proxy := httptest.NewServer(&httputil.ReverseProxy{Rewrite: func(r *httputil.ProxyRequest) {
+				proxied.Add(1)
+				r.Out.URL.Scheme = "http"
+				r.Out.URL.Host = server.Address()
+			}})
+			defer proxy.Close()
+
+			tc.proxy.HTTP = strings.ReplaceAll(tc.proxy.HTTP, "{{PROXY}}", proxy.URL)
+			tc.proxy.HTTPS = strings.ReplaceAll(tc.proxy.HTTPS, "{{PROXY}}", proxy.URL)
+
+			url, err := url.Parse("http://contracts.invalid")
+			require.NoError(t, err, "Setup: URL should have been parsed with no issues")
+
+			_, err = contracts.Contract(ctx, contractsmockserver.DefaultProToken, contracts.WithProURL(url), contracts.WithProxy(tc.proxy))
+			if tc.wantErr {
+				require.Error(t, err, "Contract should return an error")
+			} else {
+				require.NoError(t, err, "Contract should return no error")
+			}
+
+			require.Equal(t, tc.wantProxied, proxied.Load() > 0, "The request should only go through the proxy if it is used")
+		})
+	}
+}
+
+func TestParseWindowsProxy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		list, bypass string
+
+		want            contracts.Proxy
+		wantBypassLocal bool
+	}{
+		"Success with a proxy for every scheme":      {list: "proxy:3128", want: contracts.Proxy{HTTP: "http://proxy:3128", HTTPS: "http://proxy:3128"}},
+		"Success with a proxy for each scheme":       {list: "http=proxy:80;https=secure:443", want: contracts.Proxy{HTTP: "http://proxy:80", HTTPS: "http://secure:443"}},
+		"Success with a proxy for a single scheme":   {list: "https=secure:443", want: contracts.Proxy{HTTPS: "http://secure:443"}},
+		"Success with a proxy with a scheme":         {list: "https://proxy:3128", want: contracts.Proxy{HTTP: "https://proxy:3128", HTTPS: "https://proxy:3128"}},
+		"Success with a proxy overridden per scheme": {list: "http=proxy:80 secure:443", want: contracts.Proxy{HTTP: "http://proxy:80", HTTPS: "http://secure:443"}},
+		"Success ignoring other schemes":             {list: "ftp=ftp:21;socks=socks:1080"},
+		"Success with hosts to bypass":               {list: "proxy:3128", bypass: "*.example.com; 10.0.0.1", want: contracts.Proxy{HTTP: "http://proxy:3128", HTTPS: "http://proxy:3128", NoProxy: "*.example.com,10.0.0.1"}},
+		"Success bypassing local hosts":              {list: "proxy:3128", bypass: "<local>;intranet", want: contracts.Proxy{HTTP: "http://proxy:3128", HTTPS: "http://proxy:3128", NoProxy: "intranet"}, wantBypassLocal: true},
+		"Success with no proxy":                      {},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, bypassLocal := contracts.ParseWindowsProxy(tc.list, tc.bypass)
+			require.Equal(t, tc.want, got, "Unexpected proxy")
+			require.Equal(t, tc.wantBypassLocal, bypassLocal, "Unexpected bypass of local hosts")
+		})
+	}
+}
+
+type mockOutbound struct {
+	calls []string
+	err   error
+}
+
+func (o *mockOutbound) ValidSubscription() (bool, error) {
+	o.calls = append(o.calls, "ValidSubscription")
+	return true, nil
+}
+
+func (o *mockOutbound) NewProToken(context.Context) (string, error) {
+	o.calls = append(o.calls, "NewProToken")
+	if o.err != nil {
+		return "", o.err
+	}
+	return "OUTBOUND_TOKEN", nil
+}
+
+func (o *mockOutbound) Contract(context.Context, string) (contractsapi.ContractInfo, error) {
+	o.calls = append(o.calls, "Contract")
+	if o.err != nil {
+		return contractsapi.ContractInfo{}, o.err
+	}
+	return contractsapi.ContractInfo{ID: "OUTBOUND_CONTRACT"}, nil
+}
+
 type mockMSStore struct {
 	jwt            string
 	jwtWantADToken string
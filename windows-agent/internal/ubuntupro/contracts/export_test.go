@@ -0,0 +1,4 @@
+package contracts
+
+// ParseWindowsProxy exposes parseWindowsProxy for testing.
+var ParseWindowsProxy = parseWindowsProxy
@@ -0,0 +1,151 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Health keeps track of the interactions with the contract server, so that one can tell whether the
+// subscription is syncing, and since when it is not. It is safe for concurrent use, and a nil Health
+// records nothing.
+type Health struct {
+	state HealthState
+	mu    sync.Mutex
+
+	// path is the file the state is persisted to. It is kept in memory only if empty.
+	path string
+
+	// onChange are called after every interaction.
+	onChange []func()
+
+	// now is overridable for testing.
+	now func() time.Time
+}
+
+// HealthState is a snapshot of the interactions with the contract server. Zero times mean that the
+// interaction never happened.
+type HealthState struct {
+	// LastContact is the time of the last successful interaction of any kind.
+	LastContact time.Time `json:"last_contact"`
+	// LastTokenRefresh is the time a Pro token was last obtained out of the Microsoft Store entitlement.
+	LastTokenRefresh time.Time `json:"last_token_refresh"`
+	// LastEntitlementSync is the time the contract of the Pro token was last fetched.
+	LastEntitlementSync time.Time `json:"last_entitlement_sync"`
+
+	// LastError is the error of the last interaction that failed since the last successful one, if any.
+	LastError string `json:"last_error,omitempty"`
+	// LastErrorTime is the time of that failed interaction.
+	LastErrorTime time.Time `json:"last_error_time"`
+}
+
+// NewHealth creates a health tracker that is kept in memory only.
+func NewHealth() *Health {
+	return &Health{now: time.Now}
+}
+
+// OpenHealth creates a health tracker persisted to the file at path, so that it outlives the process, and
+// loads the state already in it. A missing or unreadable file gives a tracker with no interaction yet.
+func OpenHealth(path string) (*Health, error) {
+	h := NewHealth()
+	h.path = path
+
+	out, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	} else if err != nil {
+		return h, fmt.Errorf("could not read contract health file: %v", err)
+	}
+
+	if err := json.Unmarshal(out, &h.state); err != nil {
+		h.state = HealthState{}
+		return h, fmt.Errorf("could not parse contract health file, starting afresh: %v", err)
+	}
+
+	return h, nil
+}
+
+// State returns a snapshot of the interactions with the contract server.
+func (h *Health) State() HealthState {
+	if h == nil {
+		return HealthState{}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.state
+}
+
+// OnChange registers f to be called after every interaction with the contract server.
+func (h *Health) OnChange(f func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.onChange = append(h.onChange, f)
+}
+
+// recordTokenRefresh records the outcome of obtaining a Pro token.
+func (h *Health) recordTokenRefresh(err error) {
+	h.record(err, func(s *HealthState, now time.Time) { s.LastTokenRefresh = now })
+}
+
+// recordEntitlementSync records the outcome of fetching the contract of a Pro token.
+func (h *Health) recordEntitlementSync(err error) {
+	h.record(err, func(s *HealthState, now time.Time) { s.LastEntitlementSync = now })
+}
+
+// record updates the state with the outcome of an interaction, calling onSuccess if it succeeded, and
+// notifies the callbacks.
+func (h *Health) record(err error, onSuccess func(s *HealthState, now time.Time)) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	now := h.now()
+	if err != nil {
+		h.state.LastError = err.Error()
+		h.state.LastErrorTime = now
+	} else {
+		h.state.LastContact = now
+		h.state.LastError = ""
+		h.state.LastErrorTime = time.Time{}
+		onSuccess(&h.state, now)
+	}
+	h.persist()
+	callbacks := h.onChange
+	h.mu.Unlock()
+
+	// Called without the lock, so that the callbacks may query the state.
+	for _, f := range callbacks {
+		f()
+	}
+}
+
+// persist writes the state to the health file, if any. Failing to do so only loses the history across
+// a restart, so errors are ignored.
+func (h *Health) persist() {
+	if h.path == "" {
+		return
+	}
+
+	out, err := json.Marshal(h.state)
+	if err != nil {
+		return
+	}
+
+	tmp := h.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return
+	}
+	if err := os.WriteFile(tmp, out, 0600); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, h.path)
+}
@@ -0,0 +1,125 @@
+package contracts
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Proxy is the proxy the contract server is reached through.
+type Proxy struct {
+	// HTTP is the URL of the proxy for HTTP requests, e.g. http://proxy.example.com:3128.
+	HTTP string
+
+	// HTTPS is the URL of the proxy for HTTPS requests.
+	HTTPS string
+
+	// NoProxy is a comma-separated list of hosts and domains reached without proxy.
+	NoProxy string
+}
+
+// proxyFunc returns the function that picks the proxy of each request, in the format of http.Transport.
+func (p Proxy) proxyFunc() func(*http.Request) (*url.URL, error) {
+	conf := httpproxy.Config{HTTPProxy: p.HTTP, HTTPSProxy: p.HTTPS, NoProxy: p.NoProxy}
+	f := conf.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return f(req.URL)
+	}
+}
+
+// proxyFunc returns the function that picks the proxy of each request. The proxy of the options comes first,
+// then the one of the environment variables (HTTP_PROXY, HTTPS_PROXY and NO_PROXY) and finally the one of the system,
+// which on Windows is that of the Internet Options of the user or, failing that, of WinHTTP.
+func (o options) proxyFunc() func(*http.Request) (*url.URL, error) {
+	if o.proxy != (Proxy{}) {
+		return o.proxy.proxyFunc()
+	}
+
+	if env := httpproxy.FromEnvironment(); env.HTTPProxy != "" || env.HTTPSProxy != "" {
+		return http.ProxyFromEnvironment
+	}
+
+	if f := systemProxy(); f != nil {
+		return f
+	}
+
+	return noProxy
+}
+
+// noProxy reaches every host directly.
+func noProxy(*http.Request) (*url.URL, error) {
+	return nil, nil
+}
+
+// parseWindowsProxy converts proxy settings in the format of Windows into a Proxy. The list is made of either a
+// single proxy for every scheme, or of proxies for each of them, such as "http=proxy:80;https=proxy:443". The bypass
+// list contains the hosts reached without proxy, with <local> standing for the host names without a dot.
+func parseWindowsProxy(list, bypass string) (p Proxy, bypassLocal bool) {
+	for _, entry := range splitWindowsList(list) {
+		scheme, addr, found := strings.Cut(entry, "=")
+		if !found {
+			// A proxy without scheme is the one for every scheme, unless it was already set.
+			addr = entry
+			if p.HTTP == "" {
+				p.HTTP = proxyURL(addr)
+			}
+			if p.HTTPS == "" {
+				p.HTTPS = proxyURL(addr)
+			}
+			continue
+		}
+
+		switch strings.ToLower(scheme) {
+		case "http":
+			p.HTTP = proxyURL(addr)
+		case "https":
+			p.HTTPS = proxyURL(addr)
+		}
+	}
+
+	var noProxy []string
+	for _, host := range splitWindowsList(bypass) {
+		if strings.EqualFold(host, "<local>") {
+			bypassLocal = true
+			continue
+		}
+		noProxy = append(noProxy, host)
+	}
+	p.NoProxy = strings.Join(noProxy, ",")
+
+	return p, bypassLocal
+}
+
+// windowsProxyFunc returns the function that picks the proxy of each request out of proxy settings in the format of
+// Windows.
+func windowsProxyFunc(list, bypass string) func(*http.Request) (*url.URL, error) {
+	p, bypassLocal := parseWindowsProxy(list, bypass)
+	if p.HTTP == "" && p.HTTPS == "" {
+		return noProxy
+	}
+
+	f := p.proxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassLocal && !strings.Contains(req.URL.Hostname(), ".") {
+			return nil, nil
+		}
+		return f(req)
+	}
+}
+
+// splitWindowsList splits a list of Windows settings, which are separated by semicolons or white space.
+func splitWindowsList(list string) []string {
+	return strings.FieldsFunc(list, func(r rune) bool {
+		return r == ';' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	})
+}
+
+// proxyURL returns the URL of the proxy at addr, which Windows usually gives without scheme.
+func proxyURL(addr string) string {
+	if strings.Contains(addr, "://") {
+		return addr
+	}
+	return "http://" + addr
+}
@@ -0,0 +1,153 @@
+package contracts
+
+import (
+	"net/http"
+	"net/url"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// The proxy of the system is read through WinHTTP, which knows both the settings of the Internet Options of the user
+// and those set with `netsh winhttp set proxy`, and runs the automatic detection (WPAD) and configuration scripts.
+var (
+	winhttp = windows.NewLazySystemDLL("winhttp.dll")
+
+	winHTTPGetIEProxyConfigForCurrentUser = winhttp.NewProc("WinHttpGetIEProxyConfigForCurrentUser")
+	winHTTPGetDefaultProxyConfiguration   = winhttp.NewProc("WinHttpGetDefaultProxyConfiguration")
+	winHTTPOpen                           = winhttp.NewProc("WinHttpOpen")
+	winHTTPGetProxyForURL                 = winhttp.NewProc("WinHttpGetProxyForUrl")
+	winHTTPCloseHandle                    = winhttp.NewProc("WinHttpCloseHandle")
+
+	kernel32   = windows.NewLazySystemDLL("kernel32.dll")
+	globalFree = kernel32.NewProc("GlobalFree")
+)
+
+const (
+	winHTTPAccessTypeNoProxy    = 1 // WINHTTP_ACCESS_TYPE_NO_PROXY
+	winHTTPAccessTypeNamedProxy = 3 // WINHTTP_ACCESS_TYPE_NAMED_PROXY
+
+	winHTTPAutoProxyAutoDetect = 0x1 // WINHTTP_AUTOPROXY_AUTO_DETECT
+	winHTTPAutoProxyConfigURL  = 0x2 // WINHTTP_AUTOPROXY_CONFIG_URL
+
+	winHTTPAutoDetectTypeDHCP = 0x1 // WINHTTP_AUTO_DETECT_TYPE_DHCP
+	winHTTPAutoDetectTypeDNSA = 0x2 // WINHTTP_AUTO_DETECT_TYPE_DNS_A
+)
+
+// winHTTPCurrentUserIEProxyConfig mirrors WINHTTP_CURRENT_USER_IE_PROXY_CONFIG.
+type winHTTPCurrentUserIEProxyConfig struct {
+	autoDetect    int32
+	autoConfigURL *uint16
+	proxy         *uint16
+	proxyBypass   *uint16
+}
+
+// winHTTPProxyInfo mirrors WINHTTP_PROXY_INFO.
+type winHTTPProxyInfo struct {
+	accessType  uint32
+	proxy       *uint16
+	proxyBypass *uint16
+}
+
+// winHTTPAutoProxyOptions mirrors WINHTTP_AUTOPROXY_OPTIONS.
+type winHTTPAutoProxyOptions struct {
+	flags                 uint32
+	autoDetectFlags       uint32
+	autoConfigURL         *uint16
+	reserved1             uintptr
+	reserved2             uint32
+	autoLogonIfChallenged int32
+}
+
+// systemProxy returns the function that picks the proxy of each request out of the Internet Options of the user or,
+// if they have none, out of the settings of WinHTTP. It returns nil if neither has a proxy.
+func systemProxy() func(*http.Request) (*url.URL, error) {
+	var ie winHTTPCurrentUserIEProxyConfig
+	//nolint:gosec // No other way of calling a DLL proc.
+	if r, _, _ := winHTTPGetIEProxyConfigForCurrentUser.Call(uintptr(unsafe.Pointer(&ie))); r != 0 {
+		defer freeStrings(ie.autoConfigURL, ie.proxy, ie.proxyBypass)
+
+		var static func(*http.Request) (*url.URL, error)
+		if ie.proxy != nil {
+			static = windowsProxyFunc(windows.UTF16PtrToString(ie.proxy), windows.UTF16PtrToString(ie.proxyBypass))
+		}
+
+		if ie.autoDetect != 0 || ie.autoConfigURL != nil {
+			return autoProxy(ie.autoDetect != 0, windows.UTF16PtrToString(ie.autoConfigURL), static)
+		}
+		if static != nil {
+			return static
+		}
+	}
+
+	var info winHTTPProxyInfo
+	//nolint:gosec // No other way of calling a DLL proc.
+	if r, _, _ := winHTTPGetDefaultProxyConfiguration.Call(uintptr(unsafe.Pointer(&info))); r != 0 {
+		defer freeStrings(info.proxy, info.proxyBypass)
+
+		if info.accessType == winHTTPAccessTypeNamedProxy {
+			return windowsProxyFunc(windows.UTF16PtrToString(info.proxy), windows.UTF16PtrToString(info.proxyBypass))
+		}
+	}
+
+	return nil
+}
+
+// autoProxy returns the function that picks the proxy of each request by automatic detection, with a configuration
+// script at configURL or both. The static proxy is used when they find none, unless it is nil.
+func autoProxy(detect bool, configURL string, static func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	if static == nil {
+		static = noProxy
+	}
+
+	opts := winHTTPAutoProxyOptions{autoLogonIfChallenged: 1}
+	if detect {
+		opts.flags |= winHTTPAutoProxyAutoDetect
+		opts.autoDetectFlags = winHTTPAutoDetectTypeDHCP | winHTTPAutoDetectTypeDNSA
+	}
+	if configURL != "" {
+		u, err := windows.UTF16PtrFromString(configURL)
+		if err == nil {
+			opts.flags |= winHTTPAutoProxyConfigURL
+			opts.autoConfigURL = u
+		}
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		target, err := windows.UTF16PtrFromString(req.URL.String())
+		if err != nil {
+			return static(req)
+		}
+
+		session, _, _ := winHTTPOpen.Call(0, winHTTPAccessTypeNoProxy, 0, 0, 0)
+		if session == 0 {
+			return static(req)
+		}
+		defer winHTTPCloseHandle.Call(session) //nolint:errcheck // Nothing we can do about it.
+
+		var info winHTTPProxyInfo
+		//nolint:gosec // No other way of calling a DLL proc.
+		r, _, _ := winHTTPGetProxyForURL.Call(session, uintptr(unsafe.Pointer(target)), uintptr(unsafe.Pointer(&opts)), uintptr(unsafe.Pointer(&info)))
+		if r == 0 {
+			// No proxy was detected and the script could not be run: WinHTTP leaves the choice to the caller.
+			return static(req)
+		}
+		defer freeStrings(info.proxy, info.proxyBypass)
+
+		if info.accessType != winHTTPAccessTypeNamedProxy {
+			return nil, nil
+		}
+		return windowsProxyFunc(windows.UTF16PtrToString(info.proxy), windows.UTF16PtrToString(info.proxyBypass))(req)
+	}
+}
+
+// freeStrings releases the strings that WinHTTP allocated.
+func freeStrings(strs ...*uint16) {
+	for _, s := range strs {
+		if s == nil {
+			continue
+		}
+		//nolint:gosec // No other way of calling a DLL proc.
+		_, _, _ = globalFree.Call(uintptr(unsafe.Pointer(s)))
+	}
+}
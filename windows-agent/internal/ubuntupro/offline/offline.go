@@ -0,0 +1,60 @@
+// Package offline reads the Ubuntu Pro token files used to attach air-gapped machines, where the
+// contracts server cannot be reached.
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ubuntu/decorate"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalid is returned when the token file could be read but is not valid, unlike the errors reading it,
+// which may be transient (e.g. the network share it is on being unreachable).
+var ErrInvalid = errors.New("invalid offline Ubuntu Pro token file")
+
+// Token is an offline Ubuntu Pro token. Its file looks like:
+//
+//	token: C1234567890
+//	expires: 2025-12-31T00:00:00Z
+type Token struct {
+	Token   string    `yaml:"token"`
+	Expires time.Time `yaml:"expires"`
+}
+
+// Read parses the offline token file at path.
+func Read(path string) (t Token, err error) {
+	defer decorate.OnError(&err, "could not read offline Ubuntu Pro token file %q", path)
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		return t, err
+	}
+
+	if err := yaml.Unmarshal(out, &t); err != nil {
+		return t, fmt.Errorf("%w: could not parse file: %v", ErrInvalid, err)
+	}
+
+	if t.Token == "" {
+		return t, fmt.Errorf("%w: missing token", ErrInvalid)
+	}
+
+	if t.Expires.IsZero() {
+		return t, fmt.Errorf("%w: missing expiration date", ErrInvalid)
+	}
+
+	return t, nil
+}
+
+// Remaining returns how long the token is still valid for. It is negative once the token has expired.
+func (t Token) Remaining(now time.Time) time.Duration {
+	return t.Expires.Sub(now)
+}
+
+// Expired returns true if the token is no longer valid.
+func (t Token) Expired(now time.Time) bool {
+	return t.Remaining(now) <= 0
+}
@@ -0,0 +1,59 @@
+package offline_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/offline"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		file string
+
+		wantErr        bool
+		wantErrInvalid bool
+	}{
+		"Success": {file: "valid.yaml"},
+
+		"Error when the file does not exist":        {file: "does_not_exist.yaml", wantErr: true},
+		"Error when the file is not valid YAML":     {file: "bad_yaml.yaml", wantErr: true, wantErrInvalid: true},
+		"Error when the token is missing":           {file: "no_token.yaml", wantErr: true, wantErrInvalid: true},
+		"Error when the expiration date is missing": {file: "no_expiry.yaml", wantErr: true, wantErrInvalid: true},
+		"Error when the expiration date is invalid": {file: "bad_expiry.yaml", wantErr: true, wantErrInvalid: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := offline.Read(filepath.Join("testdata", "TestRead", tc.file))
+			if tc.wantErr {
+				require.Error(t, err, "Read should have returned an error")
+				require.Equal(t, tc.wantErrInvalid, errors.Is(err, offline.ErrInvalid), "Only invalid files should be reported as ErrInvalid")
+				return
+			}
+			require.NoError(t, err, "Read should have returned no error")
+
+			require.Equal(t, "C1234567890", got.Token, "Mismatched token")
+			require.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), got.Expires.UTC(), "Mismatched expiration date")
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	tok := offline.Token{Token: "C1234567890", Expires: expires}
+
+	require.False(t, tok.Expired(expires.Add(-time.Hour)), "Token should not have expired before its expiration date")
+	require.Equal(t, time.Hour, tok.Remaining(expires.Add(-time.Hour)), "Mismatched remaining validity")
+	require.True(t, tok.Expired(expires), "Token should have expired on its expiration date")
+	require.True(t, tok.Expired(expires.Add(time.Hour)), "Token should have expired after its expiration date")
+}
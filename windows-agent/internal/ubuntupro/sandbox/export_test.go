@@ -0,0 +1,8 @@
+package sandbox
+
+import "context"
+
+// CallOnce performs the operation in the sandboxed process as if it were not idempotent.
+func CallOnce[T any](ctx context.Context, s *Supervisor, method string) (T, error) {
+	return call[T](ctx, s, method, Empty{}, notIdempotent)
+}
@@ -0,0 +1,7 @@
+package sandbox
+
+// LowIntegrityToken exposes lowIntegrityToken to the tests.
+var LowIntegrityToken = lowIntegrityToken
+
+// AllowWrites exposes allowWrites to the tests.
+var AllowWrites = allowWrites
@@ -0,0 +1,105 @@
+// Package sandbox runs the network-facing operations of the agent, i.e. the calls to the contract server
+// and to the Microsoft Store, in a separate lower-privilege process. The agent talks to that process
+// through a narrow RPC boundary over its standard input and output, so that a compromised TLS stack is
+// contained in it, and so that it can crash without taking the agent down.
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/contractsapi"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+)
+
+// serviceName is the name the operations are served under.
+const serviceName = "Outbound"
+
+// callTimeout is how long the sandboxed process may take to perform an operation.
+const callTimeout = 2 * time.Minute
+
+// Empty is the argument or reply of the operations that need none.
+type Empty struct{}
+
+// ContractArgs are the arguments of Service.Contract.
+type ContractArgs struct {
+	ProToken string
+}
+
+// Service is served by the sandboxed process. Its methods follow the conventions of net/rpc, and
+// perform the operations of package contracts.
+type Service struct {
+	args []contracts.Option
+}
+
+// ValidSubscription calls contracts.ValidSubscription.
+func (s Service) ValidSubscription(_ Empty, reply *bool) (err error) {
+	*reply, err = contracts.ValidSubscription(s.args...)
+	return err
+}
+
+// NewProToken calls contracts.NewProToken.
+func (s Service) NewProToken(_ Empty, reply *string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	*reply, err = contracts.NewProToken(ctx, s.args...)
+	return err
+}
+
+// Contract calls contracts.Contract.
+func (s Service) Contract(args ContractArgs, reply *contractsapi.ContractInfo) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	*reply, err = contracts.Contract(ctx, args.ProToken, s.args...)
+	return err
+}
+
+// Serve serves the sandboxed operations over conn until it is closed. It is meant to be called by the
+// sandboxed process, with Stdio as the connection. It drops the privileges of the process first.
+func Serve(ctx context.Context, conn io.ReadWriteCloser, args ...contracts.Option) error {
+	if err := dropPrivileges(); err != nil {
+		log.Warningf(ctx, "Sandbox: could not drop privileges: %v", err)
+	}
+
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(serviceName, Service{args: args}); err != nil {
+		return err
+	}
+
+	log.Debug(ctx, "Sandbox: serving outbound operations")
+	srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+	log.Debug(ctx, "Sandbox: connection to the agent closed")
+
+	return nil
+}
+
+// Stdio returns the connection of the sandboxed process to the agent: its standard input and output.
+func Stdio() io.ReadWriteCloser {
+	return pipes{r: os.Stdin, w: os.Stdout}
+}
+
+// pipes joins a reader and a writer into a connection.
+type pipes struct {
+	r io.ReadCloser
+	w io.WriteCloser
+}
+
+func (p pipes) Read(b []byte) (int, error) {
+	return p.r.Read(b)
+}
+
+func (p pipes) Write(b []byte) (int, error) {
+	return p.w.Write(b)
+}
+
+func (p pipes) Close() error {
+	return errors.Join(p.w.Close(), p.r.Close())
+}
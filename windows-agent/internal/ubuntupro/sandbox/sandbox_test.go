@@ -0,0 +1,256 @@
+package sandbox_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/mocks/contractserver/contractsmockserver"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/sandbox"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:gosec // These are not real tokens
+const (
+	azureADToken   = "AZURE_AD_TOKEN"
+	ubuntuProToken = "UBUNTU_PRO_TOKEN"
+)
+
+var (
+	childProURL      = flag.String("sandbox-child", "", "act as the sandboxed process, with the contract server at this URL")
+	childCrashMarker = flag.String("sandbox-crash-marker", "", "crash on the first operation, unless this file exists")
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if *childProURL != "" {
+		os.Exit(runChild(*childProURL, *childCrashMarker))
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestOperations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newContractServer(t)
+
+	s := sandbox.New(ctx, os.Args[0], childArgs(t, server, "")...)
+	defer s.Stop()
+
+	valid, err := s.ValidSubscription()
+	require.NoError(t, err, "ValidSubscription should return no error")
+	require.True(t, valid, "ValidSubscription should find the mock subscription valid")
+
+	token, err := s.NewProToken(ctx)
+	require.NoError(t, err, "NewProToken should return no error")
+	require.Equal(t, ubuntuProToken, token, "NewProToken should return the token of the contract server")
+
+	contract, err := s.Contract(ctx, contractsmockserver.DefaultProToken)
+	require.NoError(t, err, "Contract should return no error")
+	require.True(t, contract.EffectiveTo.Equal(contractsmockserver.DefaultContractExpiry), "Unexpected contract expiry")
+
+	_, err = s.Contract(ctx, "UNKNOWN_TOKEN")
+	require.Error(t, err, "Contract should forward the errors of the sandboxed process")
+
+	var _ contracts.Outbound = s
+}
+
+func TestRestartAfterCrash(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newContractServer(t)
+	marker := filepath.Join(t.TempDir(), "crashed")
+
+	errorRecordsDir := filepath.Join(t.TempDir(), "errors")
+
+	s := sandbox.New(ctx, os.Args[0], childArgs(t, server, marker)...)
+	s.RecordCrashesIn(errorRecordsDir)
+	// The sandboxed process leaves its crash marker there.
+	s.SetWritableDir(filepath.Dir(marker))
+	defer s.Stop()
+
+	token, err := s.NewProToken(ctx)
+	require.NoError(t, err, "NewProToken should succeed after restarting the crashed sandboxed process")
+	require.Equal(t, ubuntuProToken, token, "NewProToken should return the token of the contract server")
+	require.FileExists(t, marker, "Setup: the sandboxed process should have crashed once")
+
+	var records []string
+	require.Eventually(t, func() bool {
+		records, _ = filepath.Glob(filepath.Join(errorRecordsDir, "sandbox-*.txt"))
+		return len(records) > 0
+	}, 5*time.Second, 100*time.Millisecond, "The crash should have been recorded")
+	require.Len(t, records, 1, "Only the crash should have been recorded")
+
+	out, err := os.ReadFile(records[0])
+	require.NoError(t, err, "Could not read the crash record")
+	require.Contains(t, string(out), "exit status 3", "The crash record should state how the process exited")
+}
+
+func TestNoRetryAfterCrash(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newContractServer(t)
+	marker := filepath.Join(t.TempDir(), "crashed")
+
+	s := sandbox.New(ctx, os.Args[0], childArgs(t, server, marker)...)
+	s.SetWritableDir(filepath.Dir(marker))
+	defer s.Stop()
+
+	_, err := sandbox.CallOnce[string](ctx, s, "NewProToken")
+	require.Error(t, err, "Operations that are not idempotent should not be retried after a crash")
+	require.FileExists(t, marker, "Setup: the sandboxed process should have crashed once")
+
+	token, err := s.NewProToken(ctx)
+	require.NoError(t, err, "NewProToken should succeed in a new sandboxed process")
+	require.Equal(t, ubuntuProToken, token, "NewProToken should return the token of the contract server")
+}
+
+func TestStop(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newContractServer(t)
+
+	s := sandbox.New(ctx, os.Args[0], childArgs(t, server, "")...)
+
+	_, err := s.NewProToken(ctx)
+	require.NoError(t, err, "Setup: NewProToken should return no error")
+
+	s.Stop()
+	s.Stop() // Stopping twice is fine.
+
+	_, err = s.NewProToken(ctx)
+	require.Error(t, err, "NewProToken should return an error after Stop")
+}
+
+func TestSetArgs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	oldServer := newContractServer(t)
+	newServer := newContractServer(t)
+
+	s := sandbox.New(ctx, os.Args[0], childArgs(t, oldServer, "")...)
+	defer s.Stop()
+
+	_, err := s.NewProToken(ctx)
+	require.NoError(t, err, "Setup: NewProToken should return no error")
+
+	s.SetArgs(childArgs(t, newServer, "")...)
+	require.NoError(t, oldServer.Stop(), "Setup: could not stop the old contract server")
+
+	token, err := s.NewProToken(ctx)
+	require.NoError(t, err, "NewProToken should reach the contract server of the new arguments")
+	require.Equal(t, ubuntuProToken, token, "NewProToken should return the token of the contract server")
+}
+
+func TestErrorStarting(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(context.Background(), filepath.Join(t.TempDir(), "does-not-exist"))
+	defer s.Stop()
+
+	_, err := s.ValidSubscription()
+	require.Error(t, err, "ValidSubscription should return an error when the sandboxed process cannot start")
+}
+
+func TestCancelledCall(t *testing.T) {
+	t.Parallel()
+
+	server := newContractServer(t)
+
+	s := sandbox.New(context.Background(), os.Args[0], childArgs(t, server, "")...)
+	defer s.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.NewProToken(ctx)
+	require.ErrorIs(t, err, context.Canceled, "NewProToken should return when its context is cancelled")
+}
+
+// newContractServer starts a mock contract server for the duration of the test.
+func newContractServer(t *testing.T) *contractsmockserver.Server {
+	t.Helper()
+
+	settings := contractsmockserver.DefaultSettings()
+	settings.Token.OnSuccess.Value = azureADToken
+	settings.Subscription.OnSuccess.Value = ubuntuProToken
+
+	server := contractsmockserver.NewServer(settings)
+	err := server.Serve(context.Background(), "localhost:0")
+	require.NoError(t, err, "Setup: Server should return no error")
+	//nolint:errcheck // Nothing we can do about it
+	t.Cleanup(func() { server.Stop() })
+
+	return server
+}
+
+// childArgs are the arguments that make the test binary act as the sandboxed process.
+func childArgs(t *testing.T, server *contractsmockserver.Server, crashMarker string) []string {
+	t.Helper()
+
+	return []string{
+		fmt.Sprintf("-sandbox-child=http://%s", server.Address()),
+		fmt.Sprintf("-sandbox-crash-marker=%s", crashMarker),
+	}
+}
+
+// runChild serves the sandboxed operations over the standard input and output.
+func runChild(proURL, crashMarker string) int {
+	u, err := url.Parse(proURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not parse contract server URL: %v\n", err)
+		return 1
+	}
+
+	var conn io.ReadWriteCloser = sandbox.Stdio()
+	if crashMarker != "" {
+		conn = crashingConn{ReadWriteCloser: conn, marker: crashMarker}
+	}
+
+	err = sandbox.Serve(context.Background(), conn, contracts.WithProURL(u), contracts.WithMockMicrosoftStore(mockMSStore{}))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// crashingConn makes the process exit as soon as it receives its first operation, unless the marker
+// file exists. It creates the marker before exiting, so that the next process does not crash.
+type crashingConn struct {
+	io.ReadWriteCloser
+	marker string
+}
+
+func (c crashingConn) Read(b []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(b)
+	if _, statErr := os.Stat(c.marker); errors.Is(statErr, os.ErrNotExist) {
+		_ = os.WriteFile(c.marker, nil, 0600)
+		os.Exit(3)
+	}
+	return n, err
+}
+
+type mockMSStore struct{}
+
+func (mockMSStore) GenerateUserJWT(azureADToken string) (string, error) {
+	return "JWT_123", nil
+}
+
+func (mockMSStore) GetSubscriptionExpirationDate() (time.Time, error) {
+	return time.Now().Add(24 * 365 * time.Hour), nil
+}
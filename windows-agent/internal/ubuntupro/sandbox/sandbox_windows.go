@@ -0,0 +1,186 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// confine prepares the command of the sandboxed process so that it runs at low integrity level and
+// without a console window. At low integrity, the process can still reach the network and read most
+// files, but it cannot write anywhere but in writableDir, nor open the processes of the agent.
+//
+// The returned function must be called once the process is started.
+func confine(cmd *exec.Cmd, writableDir string) (started func(), err error) {
+	if writableDir != "" {
+		if err := allowWrites(writableDir); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := lowIntegrityToken()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: windows.CREATE_NO_WINDOW,
+		Token:         syscall.Token(token),
+	}
+
+	return func() { _ = token.Close() }, nil
+}
+
+// lowIntegrityToken returns a primary token like the one of the current process, at low integrity level.
+func lowIntegrityToken() (low windows.Token, err error) {
+	var token windows.Token
+	if err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_DUPLICATE|windows.TOKEN_QUERY|windows.TOKEN_ASSIGN_PRIMARY|windows.TOKEN_ADJUST_DEFAULT, &token); err != nil {
+		return 0, fmt.Errorf("could not open process token: %v", err)
+	}
+	defer token.Close()
+
+	if err := windows.DuplicateTokenEx(token, windows.MAXIMUM_ALLOWED, nil, windows.SecurityImpersonation, windows.TokenPrimary, &low); err != nil {
+		return 0, fmt.Errorf("could not duplicate process token: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = low.Close()
+		}
+	}()
+
+	sid, err := windows.CreateWellKnownSid(windows.WinLowLabelSid)
+	if err != nil {
+		return 0, fmt.Errorf("could not create low integrity SID: %v", err)
+	}
+
+	label := windows.Tokenmandatorylabel{
+		Label: windows.SIDAndAttributes{Sid: sid, Attributes: windows.SE_GROUP_INTEGRITY},
+	}
+	if err := windows.SetTokenInformation(low, windows.TokenIntegrityLevel, (*byte)(unsafe.Pointer(&label)), label.Size()); err != nil {
+		return 0, fmt.Errorf("could not lower token integrity level: %v", err)
+	}
+
+	return low, nil
+}
+
+// allowWrites creates dir if needed and labels it, and everything in it, with the low integrity level,
+// so that the sandboxed process can write in it.
+func allowWrites(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("could not create sandbox directory: %v", err)
+	}
+
+	// No write up from below low integrity, inherited by files and directories.
+	sd, err := windows.SecurityDescriptorFromString("S:(ML;OICI;NW;;;LW)")
+	if err != nil {
+		return fmt.Errorf("could not create low integrity label: %v", err)
+	}
+
+	sacl, _, err := sd.SACL()
+	if err != nil {
+		return fmt.Errorf("could not read low integrity label: %v", err)
+	}
+
+	if err := windows.SetNamedSecurityInfo(dir, windows.SE_FILE_OBJECT, windows.LABEL_SECURITY_INFORMATION, nil, nil, nil, sacl); err != nil {
+		return fmt.Errorf("could not label sandbox directory %q with low integrity: %v", dir, err)
+	}
+
+	return nil
+}
+
+// contain places the sandboxed process in a job object, so that:
+//   - it is killed when the agent exits, even if the agent crashes.
+//   - it cannot start processes of its own.
+//   - its crashes do not show error dialogs.
+//
+// The returned function releases the job object, which kills the process if it is still running.
+func contain(p *os.Process) (release func(), err error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create job object: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = windows.CloseHandle(job)
+		}
+	}()
+
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE |
+				windows.JOB_OBJECT_LIMIT_ACTIVE_PROCESS |
+				windows.JOB_OBJECT_LIMIT_DIE_ON_UNHANDLED_EXCEPTION,
+			ActiveProcessLimit: 1,
+		},
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		return nil, fmt.Errorf("could not set job object limits: %v", err)
+	}
+
+	h, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(p.Pid))
+	if err != nil {
+		return nil, fmt.Errorf("could not open sandboxed process: %v", err)
+	}
+	defer windows.CloseHandle(h)
+
+	if err := windows.AssignProcessToJobObject(job, h); err != nil {
+		return nil, fmt.Errorf("could not assign sandboxed process to job object: %v", err)
+	}
+
+	return func() { _ = windows.CloseHandle(job) }, nil
+}
+
+// dropPrivileges removes every privilege from the token of the current process, except for the one
+// needed to traverse directories. Removed privileges cannot be enabled again. Being at low integrity
+// already keeps most of them disabled, but some could still be enabled.
+func dropPrivileges() error {
+	var token windows.Token
+	if err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_QUERY|windows.TOKEN_ADJUST_PRIVILEGES, &token); err != nil {
+		return fmt.Errorf("could not open process token: %v", err)
+	}
+	defer token.Close()
+
+	var n uint32
+	_ = windows.GetTokenInformation(token, windows.TokenPrivileges, nil, 0, &n)
+	if n == 0 {
+		return nil
+	}
+
+	buf := make([]byte, n)
+	if err := windows.GetTokenInformation(token, windows.TokenPrivileges, &buf[0], n, &n); err != nil {
+		return fmt.Errorf("could not read token privileges: %v", err)
+	}
+	privs := (*windows.Tokenprivileges)(unsafe.Pointer(&buf[0]))
+	all := unsafe.Slice(&privs.Privileges[0], privs.PrivilegeCount)
+
+	var traverse windows.LUID
+	if err := windows.LookupPrivilegeValue(nil, windows.StringToUTF16Ptr("SeChangeNotifyPrivilege"), &traverse); err != nil {
+		return fmt.Errorf("could not look up privilege: %v", err)
+	}
+
+	// Keep only the privileges to remove.
+	removed := all[:0]
+	for _, p := range all {
+		if p.Luid == traverse {
+			continue
+		}
+		p.Attributes = windows.SE_PRIVILEGE_REMOVED
+		removed = append(removed, p)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	privs.PrivilegeCount = uint32(len(removed))
+
+	if err := windows.AdjustTokenPrivileges(token, false, privs, 0, nil, nil); err != nil {
+		return fmt.Errorf("could not remove privileges: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package sandbox_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/sandbox"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows"
+)
+
+func TestLowIntegrityToken(t *testing.T) {
+	t.Parallel()
+
+	token, err := sandbox.LowIntegrityToken()
+	require.NoError(t, err, "LowIntegrityToken should return no error")
+	defer token.Close()
+
+	var n uint32
+	_ = windows.GetTokenInformation(token, windows.TokenIntegrityLevel, nil, 0, &n)
+	require.NotZero(t, n, "Could not get the size of the token integrity level")
+
+	buf := make([]byte, n)
+	err = windows.GetTokenInformation(token, windows.TokenIntegrityLevel, &buf[0], n, &n)
+	require.NoError(t, err, "Could not read the token integrity level")
+
+	label := (*windows.Tokenmandatorylabel)(unsafe.Pointer(&buf[0]))
+	low, err := windows.CreateWellKnownSid(windows.WinLowLabelSid)
+	require.NoError(t, err, "Setup: could not create low integrity SID")
+	require.True(t, label.Label.Sid.Equals(low), "The token should be at low integrity level, got %s", label.Label.Sid)
+}
+
+func TestAllowWrites(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "sandbox")
+	existing := filepath.Join(dir, "existing")
+	require.NoError(t, os.MkdirAll(dir, 0700), "Setup: could not create directory")
+	require.NoError(t, os.WriteFile(existing, nil, 0600), "Setup: could not write file")
+
+	err := sandbox.AllowWrites(dir)
+	require.NoError(t, err, "AllowWrites should return no error")
+
+	for _, path := range []string{dir, existing} {
+		sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.LABEL_SECURITY_INFORMATION)
+		require.NoError(t, err, "Could not read the label of %q", path)
+		// LW is the low integrity level SID, NW the no write up policy.
+		require.True(t, strings.Contains(sd.String(), ";NW;;;LW)"), "%q should be labelled with low integrity, got %s", path, sd)
+	}
+}
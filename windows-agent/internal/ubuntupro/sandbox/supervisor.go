@@ -0,0 +1,344 @@
+package sandbox
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/contractsapi"
+	"github.com/ubuntu/decorate"
+)
+
+// stopTimeout is how long the sandboxed process has to exit after its connection is closed before
+// it is killed.
+const stopTimeout = 5 * time.Second
+
+// crashRecordLines is how many of the last lines logged by the sandboxed process go into its crash records.
+const crashRecordLines = 50
+
+// Supervisor starts the sandboxed process when it is first needed, and starts it again after it
+// exits or crashes. It implements contracts.Outbound by forwarding the operations to it.
+type Supervisor struct {
+	ctx  context.Context
+	name string
+	args []string
+
+	mu      sync.Mutex
+	child   *child
+	stopped bool
+
+	// errorRecordsDir is where the crash records are written. Crashes are only logged if empty.
+	errorRecordsDir string
+
+	// writableDir is the only directory the sandboxed process can write to.
+	writableDir string
+}
+
+// child is a running instance of the sandboxed process.
+type child struct {
+	cmd    *exec.Cmd
+	client *rpc.Client
+	stdin  io.Closer
+
+	// release frees the resources used to contain the process.
+	release func()
+
+	// done is closed when the process exits.
+	done chan struct{}
+}
+
+// New creates a supervisor of the sandboxed process, which is started with the given command. That
+// command must call Serve with Stdio. Nothing is started until the first operation.
+//
+// You must call Stop to deallocate resources.
+func New(ctx context.Context, name string, args ...string) *Supervisor {
+	return &Supervisor{
+		ctx:  ctx,
+		name: name,
+		args: args,
+	}
+}
+
+// RecordCrashesIn makes the supervisor write a record of every crash of the sandboxed process in dir,
+// with the last lines it logged, so that they outlive the log file.
+func (s *Supervisor) RecordCrashesIn(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errorRecordsDir = dir
+}
+
+// SetWritableDir sets the directory the sandboxed process can write to. On Windows, it runs at low
+// integrity level, so it cannot write anywhere else. It applies to the processes started afterwards.
+func (s *Supervisor) SetWritableDir(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writableDir = dir
+}
+
+// SetArgs changes the arguments the sandboxed process is started with. The running process, if any, is stopped,
+// so that the next operation starts one with the new arguments. The operations it was performing are retried then.
+// Nothing happens if the arguments did not change.
+func (s *Supervisor) SetArgs(args ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.Equal(s.args, args) {
+		return
+	}
+
+	s.args = args
+	if s.child != nil {
+		go s.child.stop()
+		s.child = nil
+	}
+}
+
+// ValidSubscription implements contracts.Outbound.
+func (s *Supervisor) ValidSubscription() (bool, error) {
+	// The interface gives no context to this operation, so it gets the same time as in the sandboxed process.
+	ctx, cancel := context.WithTimeout(s.ctx, callTimeout)
+	defer cancel()
+
+	return call[bool](ctx, s, "ValidSubscription", Empty{}, idempotent)
+}
+
+// NewProToken implements contracts.Outbound.
+func (s *Supervisor) NewProToken(ctx context.Context) (string, error) {
+	return call[string](ctx, s, "NewProToken", Empty{}, idempotent)
+}
+
+// Contract implements contracts.Outbound.
+func (s *Supervisor) Contract(ctx context.Context, proToken string) (contractsapi.ContractInfo, error) {
+	return call[contractsapi.ContractInfo](ctx, s, "Contract", ContractArgs{ProToken: proToken}, idempotent)
+}
+
+// retryPolicy is whether an operation can be performed again when the sandboxed process exits during it.
+type retryPolicy bool
+
+const (
+	// idempotent operations are retried, as performing them twice has the same effect as once.
+	idempotent retryPolicy = true
+
+	// notIdempotent operations are not retried, as they may have been performed before the process exited.
+	notIdempotent retryPolicy = false
+)
+
+// call performs the operation in the sandboxed process, starting it if needed. If the process exits
+// during an idempotent operation, it is started again and the operation is retried once.
+func call[T any](ctx context.Context, s *Supervisor, method string, args any, retry retryPolicy) (reply T, err error) {
+	defer decorate.OnError(&err, "sandbox: %s", method)
+
+	for attempt := 0; ; attempt++ {
+		c, err := s.start(ctx)
+		if err != nil {
+			return reply, err
+		}
+
+		// A cancelled call may still be answered later, so every call gets its own reply.
+		r := new(T)
+		call := c.client.Go(serviceName+"."+method, args, r, make(chan *rpc.Call, 1))
+		select {
+		case <-ctx.Done():
+			return reply, ctx.Err()
+		case <-call.Done:
+		}
+
+		if !isDisconnection(call.Error) || retry == notIdempotent || attempt > 0 {
+			if isDisconnection(call.Error) {
+				s.forget(c)
+			}
+			return *r, call.Error
+		}
+
+		log.Warningf(ctx, "Sandbox: process exited during %s: starting it again", method)
+		s.forget(c)
+	}
+}
+
+// isDisconnection returns true if the error is due to the sandboxed process having gone away.
+// Its pipes are closed once it exits, which may happen before the client reads the end of them.
+func isDisconnection(err error) bool {
+	return errors.Is(err, rpc.ErrShutdown) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, os.ErrClosed)
+}
+
+// start returns the running sandboxed process, starting it if there is none.
+func (s *Supervisor) start(ctx context.Context) (c *child, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return nil, errors.New("sandbox stopped")
+	}
+
+	if s.child != nil {
+		select {
+		case <-s.child.done:
+			// Exited since last time: start it again.
+		default:
+			return s.child, nil
+		}
+	}
+
+	defer decorate.OnError(&err, "could not start sandboxed process")
+
+	//nolint:gosec // The command is set by the agent, not by user input.
+	cmd := exec.Command(s.name, s.args...)
+	started, err := confine(cmd, s.writableDir)
+	if err != nil {
+		return nil, err
+	}
+	defer started()
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	release, err := contain(cmd.Process)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+
+	c = &child{
+		cmd:     cmd,
+		client:  rpc.NewClientWithCodec(jsonrpc.NewClientCodec(pipes{r: stdout, w: stdin})),
+		stdin:   stdin,
+		release: release,
+		done:    make(chan struct{}),
+	}
+
+	// The process logs to its standard error. The last lines are kept for its crash record.
+	var lastLines []string
+	logsDone := make(chan struct{})
+	go func() {
+		defer crashreport.Recover("sandbox")
+		defer close(logsDone)
+		sc := bufio.NewScanner(stderr)
+		for sc.Scan() {
+			log.Infof(s.ctx, "Sandbox: %s", sc.Text())
+			lastLines = append(lastLines, sc.Text())
+			if len(lastLines) > crashRecordLines {
+				lastLines = lastLines[1:]
+			}
+		}
+	}()
+
+	errorRecordsDir := s.errorRecordsDir
+	go func() {
+		defer crashreport.Recover("sandbox")
+		defer close(c.done)
+		<-logsDone
+		err := cmd.Wait()
+		c.client.Close()
+		c.release()
+		if err != nil {
+			log.Warningf(s.ctx, "Sandbox: process %d exited: %v", cmd.Process.Pid, err)
+			if err := recordCrash(errorRecordsDir, cmd.Process.Pid, err, lastLines); err != nil {
+				log.Warningf(s.ctx, "Sandbox: %v", err)
+			}
+			return
+		}
+		log.Debugf(s.ctx, "Sandbox: process %d exited", cmd.Process.Pid)
+	}()
+
+	log.Debugf(ctx, "Sandbox: started process %d", cmd.Process.Pid)
+
+	s.child = c
+	return c, nil
+}
+
+// forget makes the next operation start a new sandboxed process, if c is the current one.
+func (s *Supervisor) forget(c *child) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.child == c {
+		s.child = nil
+	}
+	go c.stop()
+}
+
+// Stop stops the sandboxed process, if any. No operation can be performed afterwards.
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	if s.child != nil {
+		s.child.stop()
+		s.child = nil
+	}
+}
+
+// stop closes the connection to the process, which makes it exit, and kills it if it does not.
+func (c *child) stop() {
+	_ = c.stdin.Close()
+
+	select {
+	case <-c.done:
+		return
+	case <-time.After(stopTimeout):
+	}
+
+	if err := c.cmd.Process.Kill(); err != nil {
+		log.Warningf(context.Background(), "Sandbox: could not kill process %d: %v", c.cmd.Process.Pid, err)
+	}
+	<-c.done
+}
+
+// recordCrash writes a record of the crash of the sandboxed process into dir, with the last lines it logged.
+// Nothing is written if dir is empty.
+func recordCrash(dir string, pid int, exitErr error, lastLines []string) (err error) {
+	if dir == "" {
+		return nil
+	}
+
+	defer decorate.OnError(&err, "could not record the crash of process %d", pid)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Time: %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Process: %d\n", pid)
+	fmt.Fprintf(&b, "Exit: %v\n", exitErr)
+	fmt.Fprintf(&b, "Last lines logged:\n")
+	for _, l := range lastLines {
+		fmt.Fprintf(&b, "  %s\n", l)
+	}
+
+	name := fmt.Sprintf("sandbox-%s-%d.txt", now.Format("20060102T150405Z"), pid)
+	return os.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0600)
+}
@@ -3,37 +3,126 @@ package ubuntupro
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/notifications"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/operations"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/offline"
 	"github.com/ubuntu/decorate"
 )
 
-// Distribute sends the current subscription token to all distros.
-func Distribute(ctx context.Context, db *database.DistroDB, ubuntuProToken string) {
-	task := tasks.ProAttachment{
-		Token: ubuntuProToken,
+// DistroConfig provides the Ubuntu Pro token for each distro, and when to attach them.
+type DistroConfig interface {
+	SubscriptionFor(distroName string) (string, config.Source, error)
+	AttachPolicy() (config.AttachPolicy, error)
+}
+
+// Distribute sends the subscription token to all distros, as an operation recorded by ops. Distros in a distro
+// group with its own token are sent that one instead. The attachment has PriorityUser if the context was created
+// with task.WithUserInitiated. If the attach policy is to attach on first use, the distros
+// that were never launched by the user are not attached until AttachOnFirstUse is called for them. Distros the
+// Ubuntu Pro client cannot run in are skipped.
+func Distribute(ctx context.Context, db *database.DistroDB, ops *operations.Tracker, conf DistroConfig, ubuntuProToken string) {
+	kind := "pro-attach"
+	if ubuntuProToken == "" {
+		kind = "pro-detach"
 	}
 
-	var err error
-	for _, distro := range db.GetAll() {
-		err = errors.Join(err, distro.SubmitTasks(task))
+	distros := slices.DeleteFunc(db.GetAll(), func(d *distro.Distro) bool {
+		reason := d.UnsupportedReason()
+		if reason == "" {
+			return false
+		}
+		log.Infof(ctx, "Distro %q: skipping the Ubuntu Pro attachment: %s", d.Name(), reason)
+		return true
+	})
+	if ubuntuProToken != "" && attachOnFirstUse(ctx, conf) {
+		distros = slices.DeleteFunc(distros, func(d *distro.Distro) bool {
+			props := d.Properties()
+			if props.ProAttached || !props.FirstUsed.IsZero() {
+				return false
+			}
+			log.Infof(ctx, "Distro %q: holding back the Ubuntu Pro attachment until the distro is first launched", d.Name())
+			return true
+		})
 	}
 
+	_, err := operations.Submit(ctx, ops, kind, "", distros, func(d *distro.Distro) task.Task {
+		return tasks.ProAttachment{
+			Token:         tokenFor(ctx, conf, d.Name(), ubuntuProToken),
+			UserInitiated: task.UserInitiated(ctx),
+		}
+	})
 	if err != nil {
 		log.Warningf(ctx, "could not submit tasks to all distros: %v", err)
 	}
 }
 
+// AttachOnFirstUse attaches the distro to Ubuntu Pro, as an operation recorded by ops, if the attach policy held it
+// back until it was first launched by the user. It must be called when the distro is first used.
+func AttachOnFirstUse(ctx context.Context, ops *operations.Tracker, conf DistroConfig, d *distro.Distro) {
+	if !attachOnFirstUse(ctx, conf) || d.Properties().ProAttached || d.UnsupportedReason() != "" {
+		return
+	}
+
+	token, _, err := conf.SubscriptionFor(d.Name())
+	if err != nil {
+		log.Warningf(ctx, "Distro %q: could not attach to Ubuntu Pro on first use: %v", d.Name(), err)
+		return
+	}
+	if token == "" {
+		return
+	}
+
+	log.Infof(ctx, "Distro %q: attaching to Ubuntu Pro on first use", d.Name())
+	_, err = operations.Submit(ctx, ops, "pro-attach", "first use", []*distro.Distro{d}, func(*distro.Distro) task.Task {
+		return tasks.ProAttachment{Token: token}
+	})
+	if err != nil {
+		log.Warningf(ctx, "Distro %q: %v", d.Name(), err)
+	}
+}
+
+// attachOnFirstUse returns true if the attach policy holds back the attachment of the distros until they are
+// first used. They are attached right away if the policy cannot be read.
+func attachOnFirstUse(ctx context.Context, conf DistroConfig) bool {
+	policy, err := conf.AttachPolicy()
+	if err != nil {
+		log.Warningf(ctx, "%v", err)
+		return false
+	}
+	return policy == config.AttachOnFirstUse
+}
+
+// tokenFor returns the token of the distro group the distro belongs to, if any, or the default token otherwise.
+func tokenFor(ctx context.Context, conf DistroConfig, distroName, defaultToken string) string {
+	token, src, err := conf.SubscriptionFor(distroName)
+	if err != nil {
+		log.Warningf(ctx, "%v", err)
+		return defaultToken
+	}
+
+	if src != config.SourceDistroGroup {
+		return defaultToken
+	}
+
+	return token
+}
+
 // Config is a configuration manager for the Windows Agent.
 type Config interface {
 	Subscription() (string, config.Source, error)
+	OfflineSubscription() (bool, error)
 	SetStoreSubscription(context.Context, string) error
 }
 
@@ -47,6 +136,18 @@ func FetchFromMicrosoftStore(ctx context.Context, conf Config, db *database.Dist
 		return fmt.Errorf("could not get current subscription status: %v", err)
 	}
 
+	// On air-gapped machines, the subscription comes from an offline token file and the contract server
+	// must not be contacted.
+	isOffline, err := conf.OfflineSubscription()
+	if err != nil {
+		return fmt.Errorf("could not get current subscription status: %v", err)
+	}
+
+	if src == config.SourceRegistry && isOffline {
+		log.Debug(ctx, "Config: offline subscription active: skipping the Microsoft Store")
+		return nil
+	}
+
 	// Shortcut to avoid spamming the contract server
 	// We don't need to request a new token if we have a non-expired one
 	if src == config.SourceMicrosoftStore {
@@ -82,3 +183,57 @@ func FetchFromMicrosoftStore(ctx context.Context, conf Config, db *database.Dist
 
 	return nil
 }
+
+const (
+	// renewalReminder is how long before expiration the user starts being reminded to renew an offline token.
+	renewalReminder = 30 * 24 * time.Hour
+
+	// renewalUrgent is how long before expiration the renewal reminders become critical.
+	renewalUrgent = 7 * 24 * time.Hour
+)
+
+// OfflineConfig is a configuration manager that provides offline Ubuntu Pro tokens.
+type OfflineConfig interface {
+	RefreshOfflineToken(ctx context.Context) (offline.Token, error)
+}
+
+// CheckOfflineToken reads the offline Ubuntu Pro token file again, so that renewed tokens are applied,
+// and reminds the user to renew it when it is about to expire.
+func CheckOfflineToken(ctx context.Context, conf OfflineConfig, notifier *notifications.Digest) {
+	tok, err := conf.RefreshOfflineToken(ctx)
+	if err != nil {
+		log.Warningf(ctx, "%v", err)
+		notifier.Notify(ctx, notifications.Event{
+			Priority: notifications.Critical,
+			Message:  "The offline Ubuntu Pro token could not be read. Check the logs for more details.",
+		})
+		return
+	}
+
+	if tok.Token == "" {
+		// No offline token configured
+		return
+	}
+
+	expires := tok.Expires.Format(time.DateOnly)
+	remaining := tok.Remaining(time.Now())
+
+	switch {
+	case remaining <= 0:
+		notifier.Notify(ctx, notifications.Event{
+			Priority: notifications.Critical,
+			Message:  fmt.Sprintf("The offline Ubuntu Pro token expired on %s. Contact your administrator to renew it.", expires),
+		})
+	case remaining <= renewalUrgent:
+		notifier.Notify(ctx, notifications.Event{
+			Priority: notifications.Critical,
+			Message:  fmt.Sprintf("The offline Ubuntu Pro token expires on %s. Contact your administrator to renew it.", expires),
+		})
+	case remaining <= renewalReminder:
+		notifier.Notify(ctx, notifications.Event{
+			Priority: notifications.Low,
+			Category: notifications.CategoryRenewalReminder,
+			Message:  fmt.Sprintf("The offline Ubuntu Pro token expires on %s.", expires),
+		})
+	}
+}
@@ -2,19 +2,25 @@ package ubuntupro_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"testing"
 	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/mocks/contractserver/contractsmockserver"
+	"github.com/canonical/ubuntu-pro-for-wsl/mocks/storeserver/storemockserver"
+	"github.com/canonical/ubuntu-pro-for-wsl/storeapi/go-wrapper/microsoftstore"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/notifications"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/offline"
 	"github.com/stretchr/testify/require"
 	wsl "github.com/ubuntu/gowsl"
 	wslmock "github.com/ubuntu/gowsl/mock"
@@ -26,13 +32,27 @@ func TestDistribute(t *testing.T) {
 	}
 
 	testCases := map[string]struct {
-		distroIsDead bool
-		breakConfig  bool
-
-		wantErr bool
+		distroIsDead   bool
+		groupProToken  string
+		breakConfig    bool
+		attachPolicy   config.AttachPolicy
+		distroProps    distro.Properties
+		breakPolicy    bool
+		noToken        bool
+		wantNoAttached bool
 	}{
-		"Success": {},
-		"Success when a task cannot be submitted": {distroIsDead: true},
+		"Success":                                      {},
+		"Success with a distro group token":            {groupProToken: "team_token"},
+		"Success when a task cannot be submitted":      {distroIsDead: true, wantNoAttached: true},
+		"Success when the distro group cannot be read": {breakConfig: true},
+
+		"Success attaching used distros on first use":             {attachPolicy: config.AttachOnFirstUse, distroProps: distro.Properties{FirstUsed: time.Now()}},
+		"Success attaching attached distros again on first use":   {attachPolicy: config.AttachOnFirstUse, distroProps: distro.Properties{ProAttached: true}},
+		"Success detaching distros never used on first use":       {attachPolicy: config.AttachOnFirstUse, noToken: true},
+		"Success attaching when the attach policy cannot be read": {attachPolicy: config.AttachOnFirstUse, breakPolicy: true},
+
+		"Holds back distros never used on first use": {attachPolicy: config.AttachOnFirstUse, wantNoAttached: true},
+		"Skips distros the Pro client cannot run in": {distroProps: distro.Properties{UnsupportedReason: "the Ubuntu Pro client is not installed"}, wantNoAttached: true},
 	}
 
 	for name, tc := range testCases {
@@ -43,20 +63,87 @@ func TestDistribute(t *testing.T) {
 				ctx = wsl.WithMock(ctx, wslmock.New())
 			}
 
-			db, err := database.New(ctx, t.TempDir())
+			db, err := database.New(ctx, "", database.WithMemoryStorage())
 			require.NoError(t, err, "Setup: Database creation should return no error")
 
 			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
 
-			dist, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			dist, err := db.GetDistroAndUpdateProperties(ctx, distroName, tc.distroProps)
 			require.NoError(t, err, "Setup: GetDistroAndUpdateProperties should return no error")
 			defer dist.Cleanup(ctx)
+			dist.SetFirstUsed(tc.distroProps.FirstUsed)
 
 			if tc.distroIsDead {
 				dist.Invalidate(ctx)
 			}
 
-			ubuntupro.Distribute(ctx, db, "super_token")
+			token := "super_token"
+			if tc.noToken {
+				token = ""
+			}
+
+			conf := &mockConfig{groupProToken: tc.groupProToken, subscriptionErr: tc.breakConfig, attachPolicy: tc.attachPolicy, attachPolicyErr: tc.breakPolicy}
+			ubuntupro.Distribute(ctx, db, nil, conf, token)
+
+			// The distro is not connected: the task stays in its queue.
+			if tc.wantNoAttached {
+				require.Zero(t, dist.PendingTasks(), "The distro should not have been sent the token")
+				return
+			}
+			require.Equal(t, 1, dist.PendingTasks(), "The distro should have been sent the token")
+		})
+	}
+}
+
+func TestAttachOnFirstUse(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	testCases := map[string]struct {
+		attachPolicy config.AttachPolicy
+		attached     bool
+		noToken      bool
+		breakConfig  bool
+		breakPolicy  bool
+
+		wantAttach bool
+	}{
+		"Success attaching a distro held back": {attachPolicy: config.AttachOnFirstUse, wantAttach: true},
+
+		"No attachment when distros are attached immediately": {attachPolicy: config.AttachImmediately},
+		"No attachment when the distro is attached already":   {attachPolicy: config.AttachOnFirstUse, attached: true},
+		"No attachment when there is no subscription":         {attachPolicy: config.AttachOnFirstUse, noToken: true},
+		"No attachment when the attach policy cannot be read": {attachPolicy: config.AttachOnFirstUse, breakPolicy: true},
+		"No attachment when the subscription cannot be read":  {attachPolicy: config.AttachOnFirstUse, breakConfig: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			db, err := database.New(ctx, "", database.WithMemoryStorage())
+			require.NoError(t, err, "Setup: Database creation should return no error")
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+			dist, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{ProAttached: tc.attached})
+			require.NoError(t, err, "Setup: GetDistroAndUpdateProperties should return no error")
+			defer dist.Cleanup(ctx)
+
+			conf := &mockConfig{orgProToken: "org_token", subscriptionErr: tc.breakConfig, attachPolicy: tc.attachPolicy, attachPolicyErr: tc.breakPolicy, noProToken: tc.noToken}
+			ubuntupro.AttachOnFirstUse(ctx, nil, conf, dist)
+
+			// The distro is not connected: the task stays in its queue.
+			if !tc.wantAttach {
+				require.Zero(t, dist.PendingTasks(), "The distro should not have been sent the token")
+				return
+			}
+			require.Equal(t, 1, dist.PendingTasks(), "The distro should have been sent the token")
 		})
 	}
 }
@@ -69,6 +156,7 @@ func TestFetchFromMicrosoftStore(t *testing.T) {
 	//nolint:gosec // These are not real credentials
 	const (
 		oldProToken  = "OLD_UBUNTU_PRO_TOKEN"
+		orgProToken  = "ORGANIZATION_UBUNTU_PRO_TOKEN"
 		proToken     = "UBUNTU_PRO_TOKEN_456"
 		azureADToken = "AZURE_AD_TOKEN_789"
 	)
@@ -79,6 +167,8 @@ func TestFetchFromMicrosoftStore(t *testing.T) {
 
 		alreadyHaveToken    bool
 		subscriptionExpired bool
+		organizationToken   bool
+		offlineToken        bool
 
 		msStoreJWTErr        bool
 		msStoreExpirationErr bool
@@ -87,16 +177,19 @@ func TestFetchFromMicrosoftStore(t *testing.T) {
 		wantErr   bool
 	}{
 		"Success": {wantToken: proToken},
-		"Success when there is a store token already":  {alreadyHaveToken: true, wantToken: oldProToken},
-		"Success when there is an expired store token": {alreadyHaveToken: true, subscriptionExpired: true, wantToken: proToken},
+		"Success when there is a store token already":            {alreadyHaveToken: true, wantToken: oldProToken},
+		"Success when there is an expired store token":           {alreadyHaveToken: true, subscriptionExpired: true, wantToken: proToken},
+		"Success skipping the store with an offline token":       {organizationToken: true, offlineToken: true, msStoreJWTErr: true, wantToken: orgProToken},
+		"Success fetching the store token with a registry token": {organizationToken: true, wantToken: orgProToken},
 
 		// Config errors
 		"Error when the current subscription cannot be obtained": {breakSubscription: true, wantErr: true},
 		"Error when the new subscription cannot be set":          {breakSetStoreProToken: true, wantErr: true},
 
 		// Contract server errors
-		"Error when the Microsoft Store cannot provide the JWT":             {msStoreJWTErr: true, wantErr: true},
-		"Error when the Microsoft Store cannot provide the expiration date": {alreadyHaveToken: true, msStoreExpirationErr: true, wantErr: true},
+		"Error when the Microsoft Store cannot provide the JWT":                       {msStoreJWTErr: true, wantErr: true},
+		"Error when the Microsoft Store cannot provide the JWT with a registry token": {organizationToken: true, msStoreJWTErr: true, wantErr: true},
+		"Error when the Microsoft Store cannot provide the expiration date":           {alreadyHaveToken: true, msStoreExpirationErr: true, wantErr: true},
 	}
 
 	for name, tc := range testCases {
@@ -112,6 +205,11 @@ func TestFetchFromMicrosoftStore(t *testing.T) {
 				setStoreProTokenErr: tc.breakSetStoreProToken,
 			}
 
+			if tc.organizationToken {
+				conf.orgProToken = orgProToken
+				conf.offlineProToken = tc.offlineToken
+			}
+
 			if tc.alreadyHaveToken {
 				conf.storeProToken = oldProToken
 			}
@@ -156,6 +254,171 @@ func TestFetchFromMicrosoftStore(t *testing.T) {
 	}
 }
 
+func TestFetchFromMicrosoftStoreLicenses(t *testing.T) {
+	t.Parallel()
+
+	//nolint:gosec // These are not real credentials
+	const (
+		oldProToken  = "OLD_UBUNTU_PRO_TOKEN"
+		proToken     = "UBUNTU_PRO_TOKEN_456"
+		azureADToken = "AZURE_AD_TOKEN_789"
+		productID    = "9P25B50XMKXT"
+	)
+
+	testCases := map[string]struct {
+		license   storemockserver.LicenseState
+		noProduct bool
+
+		// purchase is the status the purchase of the product is expected to end with, if it is purchased at all.
+		purchase string
+		// thenLicense is the license the Store grants afterwards, if it changes.
+		thenLicense storemockserver.LicenseState
+
+		wantValid     bool
+		wantValidThen bool
+		wantErr       bool
+	}{
+		"Success with an active subscription":             {license: storemockserver.LicenseActive, wantValid: true},
+		"Success with no subscription":                    {license: storemockserver.LicenseNotPurchased},
+		"Success purchasing a subscription":               {license: storemockserver.LicenseNotPurchased, purchase: storemockserver.SucceededResult, wantValid: true},
+		"Success purchasing a subscription owned already": {license: storemockserver.LicenseActive, purchase: storemockserver.AlreadyPurchasedResult, wantValid: true},
+		"Success renewing an expired subscription":        {license: storemockserver.LicenseExpired, purchase: storemockserver.SucceededResult, wantValid: true},
+		"Success when the subscription expires":           {license: storemockserver.LicenseActive, thenLicense: storemockserver.LicenseExpired, wantValid: true},
+		"Success when a pending purchase is granted":      {license: storemockserver.LicensePending, purchase: storemockserver.SucceededResult, thenLicense: storemockserver.LicenseActive, wantValidThen: true},
+		"Success when an expired subscription is renewed": {license: storemockserver.LicenseExpired, thenLicense: storemockserver.LicenseActive, wantValidThen: true},
+
+		"Error when the Microsoft Store does not sell the subscription": {noProduct: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			// Set up the mock Microsoft Store
+			storeSettings := storemockserver.DefaultSettings()
+			storeSettings.AllProducts = nil
+			if !tc.noProduct {
+				storeSettings.AllProducts = []storemockserver.Product{{StoreID: productID, Title: "Annual Subscription", ProductKind: "Durable", License: tc.license}}
+			}
+			storeServer := storemockserver.NewServer(storeSettings)
+			err := storeServer.Serve(ctx, "localhost:0")
+			require.NoError(t, err, "Setup: Store server should return no error")
+			//nolint:errcheck // Nothing we can do about it
+			defer storeServer.Stop()
+
+			store := storeMockClient{address: storeServer.Address(), productID: productID}
+
+			// Set up the mock contract server
+			csSettings := contractsmockserver.DefaultSettings()
+			csSettings.Token.OnSuccess.Value = azureADToken
+			csSettings.Subscription.OnSuccess.Value = proToken
+			csServer := contractsmockserver.NewServer(csSettings)
+			err = csServer.Serve(ctx, "localhost:0")
+			require.NoError(t, err, "Setup: Contract server should return no error")
+			//nolint:errcheck // Nothing we can do about it
+			defer csServer.Stop()
+
+			csAddr, err := url.Parse(fmt.Sprintf("http://%s", csServer.Address()))
+			require.NoError(t, err, "Setup: Server URL should have been parsed with no issues")
+
+			// requireFetched checks that a store token is kept as long as the subscription is valid, and replaced otherwise.
+			requireFetched := func(wantValid bool, msg string) {
+				t.Helper()
+
+				conf := &mockConfig{storeProToken: oldProToken}
+				err := ubuntupro.FetchFromMicrosoftStore(ctx, conf, nil, contracts.WithProURL(csAddr), contracts.WithMockMicrosoftStore(store))
+				if tc.wantErr {
+					require.Error(t, err, "FetchFromMicrosoftStore should return an error")
+					return
+				}
+				require.NoError(t, err, "FetchFromMicrosoftStore should return no errors")
+
+				want := proToken
+				if wantValid {
+					want = oldProToken
+				}
+				require.Equal(t, want, conf.storeProToken, msg)
+			}
+
+			if tc.purchase != "" {
+				require.Equal(t, tc.purchase, store.purchase(t), "Unexpected status of the purchase")
+			}
+			requireFetched(tc.wantValid, "The store token should only be replaced without a valid subscription")
+
+			if tc.thenLicense == "" {
+				return
+			}
+			err = storeServer.SetLicense(productID, tc.thenLicense, time.Time{})
+			require.NoError(t, err, "Setup: SetLicense should return no error")
+
+			requireFetched(tc.wantValidThen, "The store token should follow the changes of the license")
+		})
+	}
+}
+
+func TestCheckOfflineToken(t *testing.T) {
+	t.Parallel()
+
+	day := 24 * time.Hour
+
+	testCases := map[string]struct {
+		noToken    bool
+		expiresIn  time.Duration
+		refreshErr bool
+
+		wantNotifications int
+	}{
+		"No reminder without an offline token":            {noToken: true},
+		"No reminder when the token is far from expiring": {expiresIn: 365 * day},
+		"Reminder when the token expires soon":            {expiresIn: 20 * day, wantNotifications: 1},
+		"Reminder when the token expires very soon":       {expiresIn: 3 * day, wantNotifications: 1},
+		"Reminder when the token has expired":             {expiresIn: -day, wantNotifications: 1},
+
+		"Notification when the token cannot be read": {refreshErr: true, wantNotifications: 1},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			conf := &mockOfflineConfig{err: tc.refreshErr}
+			if !tc.noToken {
+				conf.token = offline.Token{Token: "OFFLINE_TOKEN", Expires: time.Now().Add(tc.expiresIn)}
+			}
+
+			toaster := &mockToaster{}
+			notifier := notifications.New(ctx, notifications.FrequencyImmediate, notifications.WithToaster(toaster))
+			defer notifier.Stop()
+
+			ubuntupro.CheckOfflineToken(ctx, conf, notifier)
+			require.Len(t, toaster.bodies, tc.wantNotifications, "Mismatched number of notifications")
+		})
+	}
+}
+
+type mockOfflineConfig struct {
+	token offline.Token
+	err   bool
+}
+
+func (c *mockOfflineConfig) RefreshOfflineToken(ctx context.Context) (offline.Token, error) {
+	if c.err {
+		return offline.Token{}, errors.New("mock error")
+	}
+	return c.token, nil
+}
+
+type mockToaster struct {
+	bodies []string
+}
+
+func (m *mockToaster) Toast(ctx context.Context, title, body string) error {
+	m.bodies = append(m.bodies, body)
+	return nil
+}
+
 type mockMSStore struct {
 	jwt    string
 	jwtErr bool
@@ -180,11 +443,113 @@ func (s mockMSStore) GetSubscriptionExpirationDate() (tm time.Time, err error) {
 	return s.expirationDate, nil
 }
 
+// storeMockClient is a Microsoft Store backed by the Store mock server, which it queries the way the storeapi DLL
+// does when it is built for tests.
+type storeMockClient struct {
+	address   string
+	productID string
+}
+
+func (s storeMockClient) GenerateUserJWT(azureADToken string) (jwt string, err error) {
+	var users struct {
+		Users []string `json:"users"`
+	}
+	if err := s.get(storemockserver.AllAuthenticatedUsersPath, nil, &users); err != nil {
+		return "", err
+	}
+	switch len(users.Users) {
+	case 0:
+		return "", microsoftstore.ErrNoLocalUser
+	case 1:
+	default:
+		return "", microsoftstore.ErrTooManyLocalUsers
+	}
+
+	var resp struct {
+		JWT string `json:"jwt"`
+	}
+	q := url.Values{storemockserver.ServiceTicketParam: {azureADToken}, storemockserver.PublisherUserIDParam: {users.Users[0]}}
+	if err := s.get(storemockserver.GenerateUserJWTPath, q, &resp); err != nil {
+		return "", err
+	}
+	if resp.JWT == "" {
+		return "", microsoftstore.ErrEmptyJwt
+	}
+
+	return resp.JWT, nil
+}
+
+func (s storeMockClient) GetSubscriptionExpirationDate() (tm time.Time, err error) {
+	var resp struct {
+		Products []storemockserver.Product `json:"products"`
+	}
+	q := url.Values{storemockserver.ProductKindsParam: {"Durable"}, storemockserver.ProductIDsParam: {s.productID}}
+	if err := s.get(storemockserver.ProductPath, q, &resp); err != nil {
+		return time.Time{}, err
+	}
+
+	switch len(resp.Products) {
+	case 0:
+		return time.Time{}, microsoftstore.ErrNoProductsFound
+	case 1:
+	default:
+		return time.Time{}, microsoftstore.ErrTooManyProductsFound
+	}
+
+	if !resp.Products[0].IsInUserCollection {
+		return time.Time{}, microsoftstore.ErrNotSubscribed
+	}
+
+	return resp.Products[0].ExpirationDate, nil
+}
+
+// purchase buys the subscription product, and returns the status of the purchase.
+func (s storeMockClient) purchase(t *testing.T) string {
+	t.Helper()
+
+	var resp struct {
+		Status string `json:"status"`
+	}
+	err := s.get(storemockserver.PurchasePath, url.Values{storemockserver.ProductIDParam: {s.productID}}, &resp)
+	require.NoError(t, err, "Setup: could not purchase the product")
+
+	return resp.Status
+}
+
+// get sends a GET request to the endpoint of the Store mock server, and decodes its JSON response into out.
+func (s storeMockClient) get(path string, query url.Values, out any) error {
+	u := url.URL{Scheme: "http", Host: s.address, Path: path, RawQuery: query.Encode()}
+
+	//nolint:noctx // The mock server is local: there is no need for a context.
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return fmt.Errorf("%w: %v", microsoftstore.ErrStoreAPI, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s: %s", microsoftstore.ErrStoreAPI, path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
 type mockConfig struct {
 	storeProToken string
+	orgProToken   string
+	groupProToken string
+
+	// offlineProToken is true if orgProToken comes from an offline token file.
+	offlineProToken bool
+
+	// noProToken is true if there is no subscription at all.
+	noProToken bool
+
+	attachPolicy config.AttachPolicy
 
 	subscriptionErr     bool
 	setStoreProTokenErr bool
+	attachPolicyErr     bool
 }
 
 func (c mockConfig) Subscription() (string, config.Source, error) {
@@ -192,6 +557,14 @@ func (c mockConfig) Subscription() (string, config.Source, error) {
 		return "", config.SourceNone, errors.New("mock config Subscription: mock error")
 	}
 
+	if c.noProToken {
+		return "", config.SourceNone, nil
+	}
+
+	if c.orgProToken != "" {
+		return c.orgProToken, config.SourceRegistry, nil
+	}
+
 	if c.storeProToken != "" {
 		return c.storeProToken, config.SourceMicrosoftStore, nil
 	}
@@ -199,6 +572,33 @@ func (c mockConfig) Subscription() (string, config.Source, error) {
 	return "USER_PRO_TOKEN", config.SourceUser, nil
 }
 
+func (c mockConfig) SubscriptionFor(distroName string) (string, config.Source, error) {
+	if c.groupProToken != "" && !c.subscriptionErr {
+		return c.groupProToken, config.SourceDistroGroup, nil
+	}
+
+	return c.Subscription()
+}
+
+func (c mockConfig) AttachPolicy() (config.AttachPolicy, error) {
+	if c.attachPolicyErr {
+		return "", errors.New("mock config AttachPolicy: mock error")
+	}
+
+	if c.attachPolicy == "" {
+		return config.AttachImmediately, nil
+	}
+	return c.attachPolicy, nil
+}
+
+func (c mockConfig) OfflineSubscription() (bool, error) {
+	if c.subscriptionErr {
+		return false, errors.New("mock config OfflineSubscription: mock error")
+	}
+
+	return c.offlineProToken, nil
+}
+
 func (c *mockConfig) SetStoreSubscription(ctx context.Context, token string) error {
 	if c.setStoreProTokenErr {
 		return errors.New("mock config SetStoreSubscription: mock error")
@@ -0,0 +1,310 @@
+// Package wslconfig reads and edits the settings of the global WSL configuration file, %USERPROFILE%\.wslconfig,
+// that matter to the workloads the agent manages: they are shared by all the distros, as they run in the same VM.
+package wslconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ubuntu/decorate"
+)
+
+// FileName is the name of the global WSL configuration file, found in the profile directory of the user.
+const FileName = ".wslconfig"
+
+// Settings are the settings of .wslconfig relevant to Pro workloads. Their zero values stand for the defaults of WSL.
+type Settings struct {
+	// Memory is how much memory the WSL VM can use, e.g. 8GB. Empty means half of the memory of the host.
+	Memory string
+
+	// NetworkingMode is how the WSL VM is networked: NAT, mirrored, virtioproxy, bridged or none. Empty means NAT.
+	NetworkingMode string
+
+	// VMIdleTimeout is how long the WSL VM stays up once idle, in milliseconds. -1 keeps it up, and zero means
+	// the default of one minute.
+	VMIdleTimeout int64
+}
+
+const (
+	// minMemory is the memory Pro workloads need, e.g. to run USG audits alongside the workloads of the user.
+	minMemory = 2 << 30
+
+	// minVMIdleTimeout is how long the WSL VM must stay up once idle, in milliseconds, so that distros started to
+	// get their queued tasks stay up long enough for their WSL Pro service to connect to the agent.
+	minVMIdleTimeout = 15_000
+)
+
+// networkingModes are the networking modes WSL supports, as spelt in the documentation of WSL.
+var networkingModes = []string{"NAT", "mirrored", "virtioproxy", "bridged", "none"}
+
+// memorySize matches the sizes WSL accepts, such as 512MB or 8GB.
+var memorySize = regexp.MustCompile(`(?i)^([0-9]+)\s*(B|KB|MB|GB|TB)$`)
+
+// Problems returns why the settings are invalid or fall short of what Pro workloads need, if they do.
+func (s Settings) Problems() (problems []string) {
+	if s.Memory != "" {
+		size, err := parseMemory(s.Memory)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if size < minMemory {
+			problems = append(problems, fmt.Sprintf("memory %s is below the 2GB Pro workloads need", s.Memory))
+		}
+	}
+
+	if s.NetworkingMode != "" {
+		mode, ok := canonicalNetworkingMode(s.NetworkingMode)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown networking mode %q: use one of %s", s.NetworkingMode, strings.Join(networkingModes, ", ")))
+		} else if mode == "none" {
+			problems = append(problems, "networking mode none cuts the distros off from the agent and the Ubuntu Pro servers")
+		}
+	}
+
+	if s.VMIdleTimeout < -1 {
+		problems = append(problems, fmt.Sprintf("invalid VM idle timeout %d: use -1 to keep the VM up", s.VMIdleTimeout))
+	} else if s.VMIdleTimeout > 0 && s.VMIdleTimeout < minVMIdleTimeout {
+		problems = append(problems, fmt.Sprintf("VM idle timeout %dms is below the %dms distros need to get their tasks", s.VMIdleTimeout, minVMIdleTimeout))
+	}
+
+	return problems
+}
+
+// Validate returns an error if the settings are invalid or fall short of what Pro workloads need.
+func (s Settings) Validate() error {
+	problems := s.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// parseMemory returns the size in bytes of a memory setting.
+func parseMemory(memory string) (int64, error) {
+	m := memorySize.FindStringSubmatch(strings.TrimSpace(memory))
+	if m == nil {
+		return 0, fmt.Errorf("invalid memory %q: use a size such as 8GB", memory)
+	}
+
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory %q: %v", memory, err)
+	}
+
+	shift := map[string]int{"B": 0, "KB": 10, "MB": 20, "GB": 30, "TB": 40}[strings.ToUpper(m[2])]
+	return n << shift, nil
+}
+
+// canonicalNetworkingMode returns the networking mode as spelt in the documentation of WSL, and whether it is one.
+func canonicalNetworkingMode(mode string) (string, bool) {
+	for _, m := range networkingModes {
+		if strings.EqualFold(m, mode) {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// The keys of the settings, and the sections they are found in. The networking mode used to be experimental,
+// and is still honoured by WSL in the [experimental] section if missing from the [wsl2] one.
+const (
+	sectionWSL2         = "wsl2"
+	sectionExperimental = "experimental"
+
+	keyMemory         = "memory"
+	keyNetworkingMode = "networkingMode"
+	keyVMIdleTimeout  = "vmIdleTimeout"
+)
+
+// entry is a line of the configuration file, along with the section it belongs to and, for settings, its key and value.
+type entry struct {
+	line    string
+	section string
+	key     string
+	value   string
+}
+
+// parse splits the configuration file into entries. Section names are lowercased, as WSL ignores their case.
+func parse(data string) (entries []entry, eol string) {
+	eol = "\n"
+	if strings.Contains(data, "\r\n") {
+		eol = "\r\n"
+	}
+
+	var section string
+	for _, line := range strings.Split(strings.TrimSuffix(strings.ReplaceAll(data, "\r\n", "\n"), "\n"), "\n") {
+		e := entry{line: line, section: section}
+
+		l := strings.TrimSpace(line)
+		switch {
+		case l == "", strings.HasPrefix(l, "#"), strings.HasPrefix(l, ";"):
+		case strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]"):
+			section = strings.ToLower(strings.TrimSpace(l[1 : len(l)-1]))
+			e.section = section
+		default:
+			if k, v, ok := strings.Cut(l, "="); ok {
+				e.key = strings.TrimSpace(k)
+				e.value = strings.Trim(strings.TrimSpace(v), `"`)
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, eol
+}
+
+// is returns whether the entry is the setting with the given key in the given section.
+func (e entry) is(section, key string) bool {
+	return e.section == section && strings.EqualFold(e.key, key)
+}
+
+// Read returns the settings of the configuration file at path. A missing file has the default settings.
+func Read(path string) (s Settings, err error) {
+	defer decorate.OnError(&err, "could not read WSL configuration %s", path)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return s, err
+	}
+
+	entries, _ := parse(string(data))
+
+	var legacyNetworkingMode string
+	for _, e := range entries {
+		switch {
+		case e.is(sectionWSL2, keyMemory):
+			s.Memory = e.value
+		case e.is(sectionWSL2, keyNetworkingMode):
+			s.NetworkingMode = e.value
+		case e.is(sectionExperimental, keyNetworkingMode):
+			legacyNetworkingMode = e.value
+		case e.is(sectionWSL2, keyVMIdleTimeout):
+			t, err := strconv.ParseInt(e.value, 10, 64)
+			if err != nil {
+				return s, fmt.Errorf("invalid %s %q: %v", keyVMIdleTimeout, e.value, err)
+			}
+			s.VMIdleTimeout = t
+		}
+	}
+
+	if s.NetworkingMode == "" {
+		s.NetworkingMode = legacyNetworkingMode
+	}
+
+	return s, nil
+}
+
+// Write sets the settings in the configuration file at path, leaving the rest of the file untouched. Settings with
+// their zero value are removed so that WSL uses its defaults. The previous file is backed up next to it with a .bak
+// extension. The changes only apply once the WSL VM restarts, e.g. after `wsl --shutdown`.
+func Write(path string, s Settings) (err error) {
+	defer decorate.OnError(&err, "could not write WSL configuration %s", path)
+
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	if s.NetworkingMode != "" {
+		s.NetworkingMode, _ = canonicalNetworkingMode(s.NetworkingMode)
+	}
+
+	values := map[string]string{
+		keyMemory:         strings.TrimSpace(s.Memory),
+		keyNetworkingMode: s.NetworkingMode,
+		keyVMIdleTimeout:  "",
+	}
+	if s.VMIdleTimeout != 0 {
+		values[keyVMIdleTimeout] = strconv.FormatInt(s.VMIdleTimeout, 10)
+	}
+	keys := []string{keyMemory, keyNetworkingMode, keyVMIdleTimeout}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err == nil {
+		if err := os.WriteFile(path+".bak", data, 0600); err != nil {
+			return fmt.Errorf("could not back up the previous configuration: %v", err)
+		}
+	}
+
+	entries, eol := parse(string(data))
+	if len(data) == 0 {
+		entries = nil
+	}
+
+	var out []string
+	written := make(map[string]bool)
+
+	// sectionEnd is where the missing settings go: after the last non-blank line of the [wsl2] section.
+	sectionEnd := -1
+
+	for _, e := range entries {
+		if e.is(sectionExperimental, keyNetworkingMode) {
+			// Superseded by the setting in the [wsl2] section.
+			continue
+		}
+
+		if e.section == sectionWSL2 && e.key != "" {
+			if k := settingKey(keys, e.key); k != "" {
+				if written[k] || values[k] == "" {
+					continue
+				}
+				e.line = fmt.Sprintf("%s=%s", k, values[k])
+				written[k] = true
+			}
+		}
+
+		out = append(out, e.line)
+		if e.section == sectionWSL2 && strings.TrimSpace(e.line) != "" {
+			sectionEnd = len(out)
+		}
+	}
+
+	var missing []string
+	for _, k := range keys {
+		if !written[k] && values[k] != "" {
+			missing = append(missing, fmt.Sprintf("%s=%s", k, values[k]))
+		}
+	}
+
+	if len(missing) > 0 {
+		if sectionEnd < 0 {
+			if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+				out = append(out, "")
+			}
+			out = append(out, "[wsl2]")
+			sectionEnd = len(out)
+		}
+		out = append(out[:sectionEnd], append(missing, out[sectionEnd:]...)...)
+	}
+
+	contents := strings.Join(out, eol)
+	if len(out) > 0 {
+		contents += eol
+	}
+
+	if err := os.WriteFile(path+".new", []byte(contents), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".new", path)
+}
+
+// settingKey returns the key of the setting matching key regardless of its case, or an empty string if none does.
+func settingKey(keys []string, key string) string {
+	for _, k := range keys {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package wslconfig_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslconfig"
+	"github.com/stretchr/testify/require"
+)
+
+// fullConfig is a configuration file with all the settings of interest, along with others and comments.
+const fullConfig = `# Settings applying to all the distros
+[wsl2]
+Memory=4GB
+processors=2
+vmIdleTimeout=120000
+networkingMode=mirrored
+
+[experimental]
+autoMemoryReclaim=gradual
+`
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		file      string
+		noFile    bool
+		breakFile bool
+
+		want    wslconfig.Settings
+		wantErr bool
+	}{
+		"Success reading all the settings": {file: fullConfig, want: wslconfig.Settings{Memory: "4GB", NetworkingMode: "mirrored", VMIdleTimeout: 120000}},
+		"Success reading a file with CRLF line endings": {
+			file: "[wsl2]\r\nmemory=4GB\r\n",
+			want: wslconfig.Settings{Memory: "4GB"},
+		},
+		"Success reading the networking mode from the experimental section": {
+			file: "[wsl2]\nmemory=4GB\n[Experimental]\nnetworkingMode=mirrored\n",
+			want: wslconfig.Settings{Memory: "4GB", NetworkingMode: "mirrored"},
+		},
+		"Success preferring the networking mode of the wsl2 section": {
+			file: "[experimental]\nnetworkingMode=mirrored\n[wsl2]\nnetworkingMode=NAT\n",
+			want: wslconfig.Settings{NetworkingMode: "NAT"},
+		},
+		"Success ignoring the settings of other sections": {file: "[boot]\nmemory=4GB\n"},
+		"Success with an empty file":                      {file: ""},
+		"Success with no file":                            {noFile: true},
+
+		"Error when the VM idle timeout is not a number": {file: "[wsl2]\nvmIdleTimeout=forever\n", wantErr: true},
+		"Error when the file cannot be read":             {breakFile: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), wslconfig.FileName)
+			switch {
+			case tc.breakFile:
+				require.NoError(t, os.MkdirAll(path, 0700), "Setup: could not create a directory in place of the file")
+			case !tc.noFile:
+				require.NoError(t, os.WriteFile(path, []byte(tc.file), 0600), "Setup: could not write the configuration file")
+			}
+
+			got, err := wslconfig.Read(path)
+			if tc.wantErr {
+				require.Error(t, err, "Read should return an error")
+				return
+			}
+			require.NoError(t, err, "Read should return no error")
+			require.Equal(t, tc.want, got, "Read should return the settings of the file")
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		file      string
+		noFile    bool
+		breakFile bool
+		settings  wslconfig.Settings
+
+		wantCRLF bool
+		wantErr  bool
+	}{
+		"Success replacing the settings":                    {file: fullConfig, settings: wslconfig.Settings{Memory: "8GB", NetworkingMode: "nat", VMIdleTimeout: -1}},
+		"Success removing the settings with default values": {file: fullConfig},
+		"Success adding the settings to the wsl2 section": {
+			file:     "[wsl2]\nprocessors=2\n\n[experimental]\nautoMemoryReclaim=gradual\n",
+			settings: wslconfig.Settings{Memory: "8GB", VMIdleTimeout: 60000},
+		},
+		"Success adding the wsl2 section":                            {file: "[experimental]\nautoMemoryReclaim=gradual\n", settings: wslconfig.Settings{Memory: "8GB"}},
+		"Success moving the networking mode out of the experimental": {file: "[experimental]\nnetworkingMode=NAT\n", settings: wslconfig.Settings{NetworkingMode: "mirrored"}},
+		"Success keeping CRLF line endings":                          {file: "[wsl2]\r\nprocessors=2\r\n", settings: wslconfig.Settings{Memory: "8GB"}, wantCRLF: true},
+		"Success creating the file":                                  {noFile: true, settings: wslconfig.Settings{Memory: "8GB", NetworkingMode: "mirrored"}},
+
+		"Error when the settings are invalid":                {file: fullConfig, settings: wslconfig.Settings{Memory: "lots"}, wantErr: true},
+		"Error when the settings fall short of requirements": {file: fullConfig, settings: wslconfig.Settings{Memory: "1GB"}, wantErr: true},
+		"Error when the file cannot be read":                 {breakFile: true, settings: wslconfig.Settings{Memory: "8GB"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), wslconfig.FileName)
+			switch {
+			case tc.breakFile:
+				require.NoError(t, os.MkdirAll(path, 0700), "Setup: could not create a directory in place of the file")
+			case !tc.noFile:
+				require.NoError(t, os.WriteFile(path, []byte(tc.file), 0600), "Setup: could not write the configuration file")
+			}
+
+			err := wslconfig.Write(path, tc.settings)
+			if tc.wantErr {
+				require.Error(t, err, "Write should return an error")
+				if !tc.breakFile {
+					got, err := os.ReadFile(path)
+					require.NoError(t, err, "Setup: could not read the configuration file")
+					require.Equal(t, tc.file, string(got), "Write should not have changed the file")
+				}
+				return
+			}
+			require.NoError(t, err, "Write should return no error")
+
+			out, err := os.ReadFile(path)
+			require.NoError(t, err, "Setup: could not read the configuration file")
+
+			got := string(out)
+			if tc.wantCRLF {
+				require.Equal(t, strings.Count(got, "\n"), strings.Count(got, "\r\n"), "Write should have kept the CRLF line endings")
+				// Golden files are checked out with the line endings of the platform.
+				got = strings.ReplaceAll(got, "\r\n", "\n")
+			}
+			want := testutils.LoadWithUpdateFromGolden(t, got)
+			require.Equal(t, want, got, "Write should have set the settings and left the rest of the file untouched")
+
+			read, err := wslconfig.Read(path)
+			require.NoError(t, err, "Read should return no error")
+			require.Equal(t, tc.settings.Memory, read.Memory, "The memory should have been written")
+			require.Equal(t, tc.settings.VMIdleTimeout, read.VMIdleTimeout, "The VM idle timeout should have been written")
+			require.True(t, strings.EqualFold(tc.settings.NetworkingMode, read.NetworkingMode), "The networking mode should have been written")
+
+			backup, err := os.ReadFile(path + ".bak")
+			if tc.noFile {
+				require.ErrorIs(t, err, os.ErrNotExist, "There should be no backup of a missing file")
+				return
+			}
+			require.NoError(t, err, "The previous file should have been backed up")
+			require.Equal(t, tc.file, string(backup), "The backup should be the previous file")
+		})
+	}
+}
+
+func TestProblems(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings wslconfig.Settings
+
+		wantProblems int
+	}{
+		"Success with the default settings":    {},
+		"Success with settings meeting needs":  {settings: wslconfig.Settings{Memory: "2GB", NetworkingMode: "Mirrored", VMIdleTimeout: 15000}},
+		"Success keeping the VM up":            {settings: wslconfig.Settings{VMIdleTimeout: -1}},
+		"Success with a memory size in bytes":  {settings: wslconfig.Settings{Memory: "4294967296B"}},
+		"Success with a memory size in lower":  {settings: wslconfig.Settings{Memory: "4gb"}},
+		"Problem when the memory is not valid": {settings: wslconfig.Settings{Memory: "4 gigs"}, wantProblems: 1},
+		"Problem when the memory is too low":   {settings: wslconfig.Settings{Memory: "1536MB"}, wantProblems: 1},
+		"Problem when the mode is unknown":     {settings: wslconfig.Settings{NetworkingMode: "carrier pigeon"}, wantProblems: 1},
+		"Problem when the network is disabled": {settings: wslconfig.Settings{NetworkingMode: "none"}, wantProblems: 1},
+		"Problem when the timeout is invalid":  {settings: wslconfig.Settings{VMIdleTimeout: -2}, wantProblems: 1},
+		"Problem when the timeout is too low":  {settings: wslconfig.Settings{VMIdleTimeout: 1000}, wantProblems: 1},
+		"Problems with every setting":          {settings: wslconfig.Settings{Memory: "1GB", NetworkingMode: "none", VMIdleTimeout: 1000}, wantProblems: 3},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			problems := tc.settings.Problems()
+			require.Len(t, problems, tc.wantProblems, "Problems should report every problem of the settings: %v", problems)
+
+			if tc.wantProblems == 0 {
+				require.NoError(t, tc.settings.Validate(), "Validate should return no error")
+				return
+			}
+			require.Error(t, tc.settings.Validate(), "Validate should return an error")
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Package wslmanage runs the wsl.exe operations that manage the distros as a whole from the host, such as making
+// their VHD sparse or moving it, which are not part of the WSL API. The distros must be stopped.
+package wslmanage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ubuntu/decorate"
+)
+
+// SetSparse makes the VHD of the distro sparse or not. A sparse VHD gives back to the host the disk space that
+// the distro frees, rather than only growing.
+func SetSparse(ctx context.Context, distroName string, sparse bool) (err error) {
+	defer decorate.OnError(&err, "could not set the VHD of distro %q sparse", distroName)
+
+	return manage(ctx, distroName, "--manage", distroName, "--set-sparse", strconv.FormatBool(sparse))
+}
+
+// Move moves the VHD of the distro into the directory dir of the host.
+func Move(ctx context.Context, distroName, dir string) (err error) {
+	defer decorate.OnError(&err, "could not move distro %q to %q", distroName, dir)
+
+	return manage(ctx, distroName, "--manage", distroName, "--move", dir)
+}
+
+// SetVersion converts the distro to run with the version of WSL given, 1 or 2.
+func SetVersion(ctx context.Context, distroName string, version int) (err error) {
+	defer decorate.OnError(&err, "could not set the WSL version of distro %q", distroName)
+
+	if version != 1 && version != 2 {
+		return fmt.Errorf("invalid WSL version %d: expected 1 or 2", version)
+	}
+
+	return manage(ctx, distroName, "--set-version", distroName, strconv.Itoa(version))
+}
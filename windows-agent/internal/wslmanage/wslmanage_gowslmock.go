@@ -0,0 +1,33 @@
+//go:build gowslmock
+
+package wslmanage
+
+import (
+	"context"
+	"errors"
+
+	wsl "github.com/ubuntu/gowsl"
+)
+
+// manage pretends to run wsl.exe, failing as it would if the distro is not registered or is running.
+func manage(ctx context.Context, distroName string, _ ...string) error {
+	d := wsl.NewDistro(ctx, distroName)
+
+	registered, err := d.IsRegistered()
+	if err != nil {
+		return err
+	}
+	if !registered {
+		return errors.New("the distro is not registered")
+	}
+
+	state, err := d.State()
+	if err != nil {
+		return err
+	}
+	if state == wsl.Running {
+		return errors.New("the distro is running")
+	}
+
+	return nil
+}
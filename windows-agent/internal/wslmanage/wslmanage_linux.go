@@ -0,0 +1,13 @@
+//go:build !gowslmock
+
+package wslmanage
+
+import (
+	"context"
+	"errors"
+)
+
+// manage returns an error, as there is no wsl.exe on Linux. Use the gowslmock in order to use it in Linux.
+func manage(context.Context, string, ...string) error {
+	return errors.New("managing distros is only supported on Windows")
+}
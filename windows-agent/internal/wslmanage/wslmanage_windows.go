@@ -0,0 +1,42 @@
+//go:build !gowslmock
+
+package wslmanage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// manage runs wsl.exe with the arguments given.
+func manage(ctx context.Context, _ string, args ...string) error {
+	// https://learn.microsoft.com/en-us/windows/win32/procthread/process-creation-flags
+	//
+	// CREATE_NO_WINDOW:
+	// The process is a console application that is being run without
+	// a console window. Therefore, the console handle for the
+	// application is not set.
+	const createNoWindow = 0x08000000
+
+	cmd := exec.CommandContext(ctx, "wsl.exe", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: createNoWindow,
+	}
+	// wsl.exe prints UTF-16 unless told otherwise.
+	cmd.Env = append(os.Environ(), "WSL_UTF8=1")
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("wsl.exe %s: %v. Output: %s", strings.Join(args, " "), err, strings.TrimSpace(out.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package wslversion
+
+import "os"
+
+// WithWslVersionOutput makes the mocked `wsl --version` command print the given output,
+// fail if the output is "error", or not be found at all if the output is "missing".
+func WithWslVersionOutput(output string) Option {
+	return func(o *options) {
+		if output == "missing" {
+			o.wslCmd = []string{"this-wsl-does-not-exist.exe"}
+			return
+		}
+
+		o.wslCmd = []string{
+			os.Args[0],
+			"-test.run",
+			"TestWithWslVersionMock",
+			"--",
+			output,
+		}
+		o.wslCmdEnv = []string{"GO_WANT_HELPER_PROCESS=1"}
+	}
+}
@@ -0,0 +1,222 @@
+// Package wslversion detects the version of WSL installed on the host and which of the features the agent
+// relies on it supports, so that the agent can degrade gracefully when the host WSL is too old.
+package wslversion
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/debversion"
+)
+
+// Info describes the WSL installed on the host.
+type Info struct {
+	// Version is the version of the WSL package, e.g. 2.3.24.0. It is empty if it could not be determined,
+	// which is always the case with the inbox WSL shipped with Windows, as it has no --version flag.
+	Version string
+
+	// KernelVersion is the version of the WSL kernel, empty if unknown.
+	KernelVersion string
+
+	// Channel is the release channel WSL is delivered through (see ChannelStore and ChannelInbox),
+	// empty if wsl.exe could not be run at all.
+	Channel string
+}
+
+const (
+	// ChannelStore is the WSL package from the Microsoft Store, updated with `wsl --update`.
+	ChannelStore = "Store"
+
+	// ChannelInbox is the legacy WSL component shipped with Windows, which only gets Windows updates.
+	ChannelInbox = "Inbox"
+)
+
+// Feature is a behaviour of WSL the agent depends on that is not available in every WSL version.
+type Feature int
+
+const (
+	// MirroredNetworking is the mirrored networking mode and the wslinfo command to query it.
+	MirroredNetworking Feature = iota
+
+	// SparseVHD is `wsl --manage --set-sparse`, which makes the VHD of a distro give back the disk space it frees.
+	SparseVHD
+
+	// MoveDistro is `wsl --manage --move`, which moves the VHD of a distro to another directory.
+	MoveDistro
+)
+
+// feature describes the minimum WSL version supporting a Feature and what happens without it, be it
+// because the host WSL is too old or because its version is unknown.
+type feature struct {
+	name            string
+	minVersion      string
+	fallback        string
+	unknownFallback string
+}
+
+var features = map[Feature]feature{
+	MirroredNetworking: {
+		name:            "mirrored networking",
+		minVersion:      "2.0.0",
+		fallback:        "the agent will assume NAT networking",
+		unknownFallback: "the agent will query the networking mode and assume NAT if that fails",
+	},
+	SparseVHD: {
+		name:       "sparse VHDs",
+		minVersion: "2.0.0",
+	},
+	MoveDistro: {
+		name:       "moving distros",
+		minVersion: "2.3.11",
+	},
+}
+
+// degradable are the features whose absence the agent copes with, and reports as degraded.
+// The others are operations the agent only offers if WSL supports them.
+var degradable = []Feature{MirroredNetworking}
+
+// String returns the human readable name of the feature.
+func (f Feature) String() string {
+	if ft, ok := features[f]; ok {
+		return ft.name
+	}
+	return fmt.Sprintf("Feature(%d)", int(f))
+}
+
+// MinVersion returns the minimum version of WSL supporting the feature.
+func (f Feature) MinVersion() string {
+	return features[f].minVersion
+}
+
+// Known returns true if the WSL version could be determined.
+func (i Info) Known() bool {
+	return i.Version != ""
+}
+
+// Supports returns true if the host WSL is known to support the feature.
+// A WSL of unknown version is not known to support any of them: callers decide whether to try anyway.
+func (i Info) Supports(f Feature) bool {
+	ft, ok := features[f]
+	if !ok || !i.Known() {
+		return false
+	}
+
+	cmp, err := debversion.Compare(i.Version, ft.minVersion)
+	if err != nil {
+		return false
+	}
+	return cmp >= 0
+}
+
+// Degraded returns a message for every feature the host WSL does not support, explaining how the agent
+// behaves without it. It returns nil if the host WSL supports all of them.
+func (i Info) Degraded() (msgs []string) {
+	for _, f := range degradable {
+		if i.Supports(f) {
+			continue
+		}
+
+		ft := features[f]
+		if !i.Known() {
+			msgs = append(msgs, fmt.Sprintf("%s requires WSL %s or newer and the WSL version is unknown: %s", ft.name, ft.minVersion, ft.unknownFallback))
+			continue
+		}
+		msgs = append(msgs, fmt.Sprintf("%s requires WSL %s or newer but WSL %s is installed: %s", ft.name, ft.minVersion, i.Version, ft.fallback))
+	}
+
+	return msgs
+}
+
+type options struct {
+	wslCmd    []string
+	wslCmdEnv []string
+}
+
+var defaultOptions = options{
+	wslCmd: []string{"wsl.exe"},
+}
+
+// Option represents an optional function to override Detect default values.
+type Option func(*options)
+
+// Detect returns the information about the WSL installed on the host. Failing to detect any of it is not
+// an error: the missing fields are left empty and the reasons are logged.
+func Detect(ctx context.Context, args ...Option) (info Info) {
+	opts := defaultOptions
+	for _, f := range args {
+		f(&opts)
+	}
+
+	out, err := wslVersion(ctx, opts)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		info.Version, info.KernelVersion = parseVersion(out)
+		info.Channel = ChannelStore
+	case errors.As(err, &exitErr):
+		// Only the inbox WSL runs but rejects the --version flag.
+		log.Warningf(ctx, "Could not determine the WSL version, assuming the inbox WSL: %v", err)
+		info.Channel = ChannelInbox
+	default:
+		log.Warningf(ctx, "Could not determine the WSL version: %v", err)
+	}
+
+	return info
+}
+
+// wslVersion returns the output of `wsl --version`.
+func wslVersion(ctx context.Context, opts options) ([]byte, error) {
+	name := opts.wslCmd[0]
+	args := append(opts.wslCmd[1:], "--version")
+	//nolint:gosec //Subprocess is launched from a variable to be testable.
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// wsl.exe prints UTF-16 unless told otherwise.
+	cmd.Env = append(append(os.Environ(), "WSL_UTF8=1"), opts.wslCmdEnv...)
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w. Stderr: %s", cmd.Path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+var versionRegex = regexp.MustCompile(`\d+(\.\d+)+\S*`)
+
+// parseVersion returns the WSL and kernel versions out of the output of `wsl --version`.
+// The labels are localized, so the versions are found by position: the WSL version is on the first line,
+// and the kernel version on the second one.
+func parseVersion(out []byte) (version, kernel string) {
+	// Older versions ignore WSL_UTF8 and still print UTF-16.
+	out = bytes.ReplaceAll(out, []byte{0}, nil)
+
+	var found []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		found = append(found, versionRegex.FindString(line))
+		if len(found) == 2 {
+			break
+		}
+	}
+
+	if len(found) > 0 {
+		version = found[0]
+	}
+	if len(found) > 1 {
+		kernel = found[1]
+	}
+	return version, kernel
+}
@@ -0,0 +1,150 @@
+package wslversion_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/wslversion"
+	"github.com/stretchr/testify/require"
+)
+
+const wslVersionOutput = `WSL version: 2.3.24.0
+Kernel version: 5.15.153.1-2
+WSLg version: 1.0.65
+MSRDC version: 1.2.5620
+Direct3D version: 1.611.1-81528511
+DXCore version: 10.0.26100.1-240331-1435.ge-release
+Windows version: 10.0.22631.4317
+`
+
+const wslVersionOutputLocalized = `Versión de WSL: 2.4.4.0
+Versión de kernel: 5.15.167.4-1
+Versión de WSLg: 1.0.65
+`
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		output string
+
+		wantVersion string
+		wantKernel  string
+		wantChannel string
+	}{
+		"Success":                           {output: wslVersionOutput, wantVersion: "2.3.24.0", wantKernel: "5.15.153.1-2", wantChannel: wslversion.ChannelStore},
+		"Success with localized output":     {output: wslVersionOutputLocalized, wantVersion: "2.4.4.0", wantKernel: "5.15.167.4-1", wantChannel: wslversion.ChannelStore},
+		"Success with UTF-16 output":        {output: "utf16:" + wslVersionOutput, wantVersion: "2.3.24.0", wantKernel: "5.15.153.1-2", wantChannel: wslversion.ChannelStore},
+		"Success with only the WSL version": {output: "WSL version: 1.2.5.0\n", wantVersion: "1.2.5.0", wantChannel: wslversion.ChannelStore},
+
+		"Unknown version with no output":                  {output: "", wantChannel: wslversion.ChannelStore},
+		"Unknown version with the inbox WSL":              {output: "error", wantChannel: wslversion.ChannelInbox},
+		"Unknown version and channel when WSL cannot run": {output: "missing"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			info := wslversion.Detect(context.Background(), wslversion.WithWslVersionOutput(tc.output))
+
+			require.Equal(t, tc.wantVersion, info.Version, "Unexpected WSL version")
+			require.Equal(t, tc.wantKernel, info.KernelVersion, "Unexpected kernel version")
+			require.Equal(t, tc.wantChannel, info.Channel, "Unexpected WSL release channel")
+			require.Equal(t, tc.wantVersion != "", info.Known(), "Known should match whether the version was detected")
+		})
+	}
+}
+
+func TestSupports(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		version string
+		feature wslversion.Feature
+
+		want bool
+	}{
+		"Mirrored networking is supported on the minimum version": {version: "2.0.0", feature: wslversion.MirroredNetworking, want: true},
+		"Mirrored networking is supported on newer versions":      {version: "2.3.24.0", feature: wslversion.MirroredNetworking, want: true},
+		"Sparse VHDs are supported on the minimum version":        {version: "2.0.0", feature: wslversion.SparseVHD, want: true},
+		"Moving distros is supported on newer versions":           {version: "2.4.13.0", feature: wslversion.MoveDistro, want: true},
+
+		"Mirrored networking is not supported on older versions": {version: "1.2.5.0", feature: wslversion.MirroredNetworking},
+		"Moving distros is not supported on older versions":      {version: "2.2.4.0", feature: wslversion.MoveDistro},
+		"Nothing is supported on unknown versions":               {version: "", feature: wslversion.MirroredNetworking},
+		"Nothing is supported on invalid versions":               {version: "not-a-version", feature: wslversion.MirroredNetworking},
+		"Unknown features are not supported":                     {version: "2.3.24.0", feature: wslversion.Feature(42)},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			info := wslversion.Info{Version: tc.version}
+			require.Equal(t, tc.want, info.Supports(tc.feature), "Unexpected support for %s on WSL %q", tc.feature, tc.version)
+		})
+	}
+}
+
+func TestDegraded(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		version string
+
+		wantMessages int
+		wantContains string
+	}{
+		"No degradation on a recent WSL":       {version: "2.3.24.0"},
+		"Degraded on an old WSL":               {version: "1.2.5.0", wantMessages: 1, wantContains: "WSL 1.2.5.0 is installed: the agent will assume NAT"},
+		"Degraded on a WSL of unknown version": {version: "", wantMessages: 1, wantContains: "the WSL version is unknown: the agent will query"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			msgs := wslversion.Info{Version: tc.version}.Degraded()
+			require.Len(t, msgs, tc.wantMessages, "Unexpected number of degraded mode messages")
+			for _, m := range msgs {
+				require.Contains(t, m, tc.wantContains, "Degraded mode message should explain why")
+			}
+		})
+	}
+}
+
+//nolint:thelper // This is a faux test used to mock `wsl --version`
+func TestWithWslVersionMock(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") == "" {
+		t.Skip("Skipped because it is not a real test, but rather a mocked executable")
+	}
+
+	begin := slices.Index(os.Args, "--")
+	if begin == -1 || len(os.Args) != begin+3 || os.Args[begin+2] != "--version" {
+		fmt.Fprintf(os.Stderr, "Invalid arguments: [%v]\n", os.Args)
+		os.Exit(1)
+	}
+
+	output := os.Args[begin+1]
+	if output == "error" {
+		fmt.Fprintln(os.Stderr, "Invalid command line option: --version")
+		os.Exit(1)
+	}
+
+	// Older versions of wsl.exe print UTF-16LE regardless of WSL_UTF8.
+	if ascii, found := strings.CutPrefix(output, "utf16:"); found {
+		out := make([]byte, 0, 2*len(ascii))
+		for _, b := range []byte(ascii) {
+			out = append(out, b, 0)
+		}
+		output = string(out)
+	}
+
+	fmt.Fprint(os.Stdout, output)
+	os.Exit(0)
+}
@@ -9,6 +9,7 @@ import (
 	"syscall"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/coverage"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/cmd/wsl-pro-service/service"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/consts"
@@ -19,8 +20,14 @@ import (
 
 func main() {
 	i18n.InitI18nDomain(common.TEXTDOMAIN)
+	stopCoverage := coverage.Start(context.Background())
+
 	a := service.New()
-	os.Exit(run(a))
+	code := run(a)
+
+	// os.Exit skips deferred calls.
+	stopCoverage()
+	os.Exit(code)
 }
 
 type app interface {
@@ -74,6 +74,22 @@ func installConfigFlag(cmd *cobra.Command) *string {
 	return cmd.PersistentFlags().StringP("config", "c", "", i18n.G("configuration file path"))
 }
 
+// installForegroundFlag adds the --foreground flag to run the service outside systemd, i.e. for debugging.
+func installForegroundFlag(cmd *cobra.Command, viper *viper.Viper) *bool {
+	r := cmd.PersistentFlags().Bool("foreground", false, i18n.G("run outside systemd, without service notifications and with console-friendly logging"))
+	if err := viper.BindPFlag("foreground", cmd.PersistentFlags().Lookup("foreground")); err != nil {
+		log.Warning(context.Background(), err)
+	}
+	return r
+}
+
+// setForegroundMode switches the logs to a console-friendly format with full timestamps.
+func setForegroundMode() {
+	logrus.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+}
+
 // SetVerboseMode change ErrorFormat and logs between very, middly and non verbose.
 func setVerboseMode(level int) {
 	var reportCaller bool
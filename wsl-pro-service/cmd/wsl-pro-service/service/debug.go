@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"os"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) installDebug(args ...option) {
+	cmd := &cobra.Command{
+		Use:   "debug COMMAND",
+		Short: i18n.G("Diagnose issues with wsl-pro-service"),
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "connection",
+		Short: i18n.G("Diagnose the connection to the Windows Agent"),
+		Long: i18n.G(`Diagnose the connection to the Windows Agent.
+
+Prints every address the Windows host may be reached at, the result of dialing each of them and of the TLS
+handshake, and the endpoint the service connects to. Exits with an error if that endpoint is not reachable.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opt := newOptions(args...)
+			return daemon.DiagnoseConnection(context.Background(), opt.system, os.Stdout)
+		},
+	})
+
+	a.rootCmd.AddCommand(cmd)
+}
@@ -3,11 +3,15 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/grpc/compression"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/commandservice"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/daemon"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
 	"github.com/spf13/cobra"
@@ -29,7 +33,15 @@ type App struct {
 }
 
 type daemonConfig struct {
-	Verbosity int
+	Verbosity  int
+	Foreground bool
+
+	// CrashReportsURL opts in to uploading the crash reports there. They are only written to disk if it is empty.
+	CrashReportsURL string
+
+	// CompressionThreshold is the size in bytes of the smallest message compressed on the streams with the agent,
+	// when it supports compression. Zero keeps the default.
+	CompressionThreshold int
 }
 
 type options struct {
@@ -38,6 +50,17 @@ type options struct {
 
 type option func(*options)
 
+// newOptions returns the default options with the given args applied.
+func newOptions(args ...option) options {
+	opt := options{
+		system: system.New(),
+	}
+	for _, f := range args {
+		f(&opt)
+	}
+	return opt
+}
+
 // New registers commands and return a new App.
 func New(o ...option) *App {
 	a := App{ready: make(chan struct{})}
@@ -61,6 +84,9 @@ func New(o ...option) *App {
 			}
 
 			setVerboseMode(a.config.Verbosity)
+			if a.config.Foreground {
+				setForegroundMode()
+			}
 			log.Debug(context.Background(), "Debug mode is enabled")
 
 			return nil
@@ -75,9 +101,11 @@ func New(o ...option) *App {
 
 	installVerbosityFlag(&a.rootCmd, a.viper)
 	installConfigFlag(&a.rootCmd)
+	installForegroundFlag(&a.rootCmd, a.viper)
 
 	// subcommands
 	a.installVersion()
+	a.installDebug(o...)
 
 	return &a
 }
@@ -86,15 +114,31 @@ func New(o ...option) *App {
 func (a *App) serve(args ...option) (err error) {
 	ctx := context.Background()
 
-	opt := options{
-		system: system.New(),
+	opt := newOptions(args...)
+
+	crashreport.Install(crashreport.New(consts.CrashReportsDir, consts.Version, crashreport.WithUploadURL(a.config.CrashReportsURL)))
+	compression.SetThreshold(a.config.CompressionThreshold)
+
+	// A broken package signing key is a broken build, which must not wait for an upgrade to be noticed.
+	if err := opt.system.CheckPackageSigningKey(); errors.Is(err, system.ErrNoPackageSigningKey) {
+		log.Warningf(ctx, "%v", err)
+	} else if err != nil {
+		close(a.ready)
+		return err
 	}
-	for _, f := range args {
-		f(&opt)
+
+	// WSL regenerates /etc/hosts when the distro boots, dropping the host entries managed by the agent.
+	if err := opt.system.RestoreHostEntries(ctx); err != nil {
+		log.Warningf(ctx, "%v", err)
+	}
+
+	var daemonOpts []daemon.Option
+	if a.config.Foreground {
+		daemonOpts = append(daemonOpts, daemon.WithoutSystemd())
 	}
 
 	// Connect with the agent.
-	a.daemon, err = daemon.New(ctx, opt.system)
+	a.daemon, err = daemon.New(ctx, opt.system, daemonOpts...)
 	if err != nil {
 		close(a.ready)
 		return fmt.Errorf("could not create daemon: %v", err)
@@ -122,10 +166,13 @@ func (a *App) Quit() {
 	a.daemon.Quit(context.Background(), false)
 }
 
-// WaitReady signals when the daemon is ready
+// WaitReady signals when the daemon is ready, which is once it serves or failed to start.
 // Note: we need to use a pointer to not copy the App object before the daemon is ready, and thus, creates a data race.
 func (a *App) WaitReady() {
 	<-a.ready
+	if a.daemon != nil {
+		<-a.daemon.Ready()
+	}
 }
 
 // RootCmd returns a copy of the root command for the app. Shouldn't be in general necessary apart when running generators.
@@ -9,8 +9,8 @@ import (
 	"runtime"
 	"strings"
 	"testing"
-	"time"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/cmd/wsl-pro-service/service"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
@@ -71,6 +71,48 @@ func TestVersion(t *testing.T) {
 	require.Equal(t, consts.Version, fields[1], "Wrong version")
 }
 
+func TestDebugConnection(t *testing.T) {
+	testCases := map[string]struct {
+		dontServe bool
+
+		wantErr bool
+	}{
+		"Success": {},
+
+		"Error when the agent is not reachable": {dontServe: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sys, mock := testutils.MockSystem(t)
+
+			agent := testutils.NewMockWindowsAgent(t, context.Background(), mock.DefaultPublicDir())
+			defer agent.Stop()
+
+			if tc.dontServe {
+				addr := agent.Listener.Addr().String()
+				agent.Stop()
+				require.NoError(t, os.WriteFile(filepath.Join(mock.DefaultPublicDir(), common.ListeningPortFileName), []byte(addr), 0600), "Setup: could not overwrite port file")
+			}
+
+			a := service.New(service.WithSystem(sys))
+			a.SetArgs("debug", "connection")
+
+			getStdout := captureStdout(t)
+
+			err := a.Run()
+			out := getStdout()
+			require.Contains(t, out, "Endpoint: "+agent.Listener.Addr().String(), "The endpoint should be reported")
+
+			if tc.wantErr {
+				require.Error(t, err, "Run should return an error when the agent is not reachable")
+				return
+			}
+			require.NoError(t, err, "Run should not return an error. Stdout: %v", out)
+		})
+	}
+}
+
 func TestConfigBadArg(t *testing.T) {
 	getStdout := captureStdout(t)
 
@@ -103,6 +145,19 @@ func TestConfigArg(t *testing.T) {
 	require.Equal(t, 1, a.Config().Verbosity)
 }
 
+func TestForegroundArg(t *testing.T) {
+	getStdout := captureStdout(t)
+
+	sys, _ := testutils.MockSystem(t)
+	a := service.New(service.WithSystem(sys))
+	a.SetArgs("version", "--foreground")
+
+	err := a.Run()
+	out := getStdout()
+	require.NoError(t, err, "Run should not return an error, stdout: %v", out)
+	require.True(t, a.Config().Foreground, "Foreground mode should be enabled")
+}
+
 func TestConfigAutoDetect(t *testing.T) {
 	getStdout := captureStdout(t)
 
@@ -160,7 +215,8 @@ func TestCanQuitWhenExecute(t *testing.T) {
 	a, wait := startDaemon(t, system)
 	defer wait()
 
-	time.Sleep(time.Second)
+	err := agent.WaitForConnection(ctx)
+	require.NoError(t, err, "Setup: the service should have connected to the agent")
 	agent.Stop()
 
 	a.Quit()
@@ -263,7 +319,6 @@ func startDaemon(t *testing.T, s *system.System) (app *service.App, done func())
 	t.Cleanup(a.Quit)
 
 	a.WaitReady()
-	time.Sleep(50 * time.Millisecond)
 
 	return a, func() {
 		require.NoError(t, <-ch, "Run should exit without any error")
@@ -19,4 +19,21 @@ if [ -n "${is_source_build}" ]; then
 fi
 
 version=$(cat ${VERSION_FILE})
-echo -ldflags=-X=github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/consts.Version=${version}
+
+# Embed the public key the debs of the local update channel are signed with, recording where it comes from.
+# Without it, the service refuses to install debs from the local update channel.
+if [ -n "${is_source_build}" ] && [ -n "${UP4W_PACKAGE_SIGNING_KEY:-}" ]; then
+    {
+        echo "# Public half of the release key pair that signs the debs of the local update channel, base64-encoded."
+        echo "# Origin: ${UP4W_PACKAGE_SIGNING_KEY_ORIGIN:-UP4W_PACKAGE_SIGNING_KEY}, embedded on $(date -u +%Y-%m-%d) for version ${version}."
+        echo "${UP4W_PACKAGE_SIGNING_KEY}"
+    } > ./internal/system/package-signing-key.pub
+fi
+flags="-ldflags=-X=github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/consts.Version=${version}"
+
+# Instrument the binary to write coverage profiles into $GOCOVERDIR (used by the end-to-end tests).
+if [ -n "${UP4W_COVERAGE:-}" ]; then
+    flags="${flags} -cover -covermode=atomic -coverpkg=github.com/canonical/ubuntu-pro-for-wsl/..."
+fi
+
+echo ${flags}
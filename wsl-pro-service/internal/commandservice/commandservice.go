@@ -3,6 +3,8 @@ package commandservice
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
@@ -63,3 +65,149 @@ func (s Service) ApplyLandscapeConfig(ctx context.Context, msg *agentapi.Landsca
 
 	return nil
 }
+
+// ApplyCommand serves the generic commands sent by the agent, returning their output, if any.
+// Commands preempted before they start do nothing; the long ones can also be preempted between
+// their steps (see system.WithPreemption).
+func (s Service) ApplyCommand(ctx context.Context, msg *agentapi.Command) (output []byte, err error) {
+	if err := system.SafePoint(ctx); err != nil {
+		return nil, err
+	}
+
+	switch cmd := msg.GetCmd().(type) {
+	case *agentapi.Command_ProService:
+		return nil, s.applyProService(ctx, cmd.ProService)
+	case *agentapi.Command_Usg:
+		return s.applyUsg(ctx, cmd.Usg)
+	case *agentapi.Command_ServiceUpgrade:
+		return nil, s.applyServiceUpgrade(ctx, cmd.ServiceUpgrade)
+	case *agentapi.Command_ManageUser:
+		return nil, s.applyManageUser(ctx, cmd.ManageUser)
+	case *agentapi.Command_Patching:
+		return nil, s.applyPatching(ctx, cmd.Patching)
+	case *agentapi.Command_Proxy:
+		return nil, s.applyProxy(ctx, cmd.Proxy)
+	case *agentapi.Command_ProStatus:
+		return nil, s.applyProStatus(ctx, cmd.ProStatus)
+	case *agentapi.Command_Snapd:
+		return nil, s.applySnapd(ctx, cmd.Snapd)
+	case *agentapi.Command_Dns:
+		return nil, s.applyDNS(ctx, cmd.Dns)
+	default:
+		return nil, fmt.Errorf("ApplyCommand: unknown command type %T", cmd)
+	}
+}
+
+// TailLog serves the TailLog requests sent by the agent, sending the journal lines of wsl-pro-service
+// one at a time as they are read. Followed requests stop when the context is cancelled.
+func (s Service) TailLog(ctx context.Context, cmd *agentapi.TailLogCmd, send func(line string) error) error {
+	log.Debugf(ctx, "TailLog: reading the last %d journal lines (priority: %q, follow: %t)", cmd.GetLines(), cmd.GetPriority(), cmd.GetFollow())
+	return s.system.JournalTail(ctx, int(cmd.GetLines()), cmd.GetPriority(), cmd.GetIncludeProClient(), cmd.GetFollow(), send)
+}
+
+// applyProService enables or disables a single Ubuntu Pro service.
+func (s Service) applyProService(ctx context.Context, cmd *agentapi.ProServiceCmd) error {
+	service := cmd.GetService()
+	if service == "" {
+		return errors.New("ApplyCommand: received empty Pro service")
+	}
+
+	if cmd.GetEnable() {
+		log.Infof(ctx, "ApplyCommand: enabling Pro service %q", service)
+		return s.system.ProEnableService(ctx, service)
+	}
+
+	log.Infof(ctx, "ApplyCommand: disabling Pro service %q", service)
+	return s.system.ProDisableService(ctx, service)
+}
+
+// applyUsg optionally applies the remediations of an Ubuntu Security Guide profile,
+// and then audits the distro against it, returning the HTML report.
+func (s Service) applyUsg(ctx context.Context, cmd *agentapi.UsgCmd) ([]byte, error) {
+	profile := cmd.GetProfile()
+	if profile == "" {
+		return nil, errors.New("ApplyCommand: received empty USG profile")
+	}
+
+	var progress uint32
+	if cmd.GetFix() {
+		log.Infof(ctx, "ApplyCommand: applying USG profile %q", profile)
+		system.ReportProgress(ctx, 0, fmt.Sprintf("Applying USG profile %q", profile))
+		if err := s.system.UsgFix(ctx, profile); err != nil {
+			return nil, err
+		}
+
+		// The audit takes as long as the fix, and can be repeated once the fix is applied.
+		if err := system.SafePoint(ctx); err != nil {
+			return nil, err
+		}
+		progress = 50
+	}
+
+	log.Infof(ctx, "ApplyCommand: auditing USG profile %q", profile)
+	system.ReportProgress(ctx, progress, fmt.Sprintf("Auditing USG profile %q", profile))
+	return s.system.UsgAudit(ctx, profile)
+}
+
+// applyServiceUpgrade installs the wsl-pro-service package from the requested update channel.
+func (s Service) applyServiceUpgrade(ctx context.Context, cmd *agentapi.ServiceUpgradeCmd) error {
+	channel := cmd.GetChannel()
+	if channel == "" {
+		return errors.New("ApplyCommand: received empty update channel")
+	}
+
+	log.Infof(ctx, "ApplyCommand: upgrading wsl-pro-service from the %q channel", channel)
+	return s.system.UpgradeService(ctx, channel, cmd.GetSource(), cmd.GetChecksum())
+}
+
+// applyManageUser creates a user if needed, adds it to groups and optionally makes it the default user.
+func (s Service) applyManageUser(ctx context.Context, cmd *agentapi.ManageUserCmd) error {
+	name := cmd.GetName()
+	if name == "" {
+		return errors.New("ApplyCommand: received empty user name")
+	}
+
+	log.Infof(ctx, "ApplyCommand: managing user %q (groups: %q, default: %t)", name, cmd.GetGroups(), cmd.GetSetDefault())
+	return s.system.ManageUser(ctx, name, cmd.GetGroups(), cmd.GetSetDefault())
+}
+
+// applyPatching configures which pockets unattended-upgrades installs updates from. An empty level
+// stops managing unattended-upgrades.
+func (s Service) applyPatching(ctx context.Context, cmd *agentapi.PatchingCmd) error {
+	log.Infof(ctx, "ApplyCommand: setting patching level %q", cmd.GetLevel())
+	return s.system.ConfigurePatching(ctx, cmd.GetLevel())
+}
+
+// applyProxy configures the proxy of apt, login sessions and systemd services. Without proxies, it stops
+// managing them.
+func (s Service) applyProxy(ctx context.Context, cmd *agentapi.ProxyCmd) error {
+	log.Infof(ctx, "ApplyCommand: setting proxy (http: %q, https: %q, no_proxy: %q)", common.RedactURL(cmd.GetHttp()), common.RedactURL(cmd.GetHttps()), cmd.GetNoProxy())
+	return s.system.ConfigureProxy(ctx, cmd.GetHttp(), cmd.GetHttps(), cmd.GetNoProxy())
+}
+
+// applySnapd configures the proxy and the snap store proxy of snapd. Without them, it stops managing them.
+func (s Service) applySnapd(ctx context.Context, cmd *agentapi.SnapdCmd) error {
+	log.Infof(ctx, "ApplyCommand: configuring snapd (store: %q at %q)", cmd.GetStoreId(), common.RedactURL(cmd.GetStoreProxy()))
+	return s.system.ConfigureSnapd(ctx, cmd.GetHttp(), cmd.GetHttps(), cmd.GetStoreProxy(), cmd.GetStoreId())
+}
+
+// applyDNS configures the nameservers, search domains and host entries. Without them, it stops managing them.
+func (s Service) applyDNS(ctx context.Context, cmd *agentapi.DnsCmd) error {
+	log.Infof(ctx, "ApplyCommand: configuring DNS (nameservers: %q, %d host entries)", cmd.GetNameservers(), len(cmd.GetHosts()))
+	return s.system.ConfigureDNS(ctx, cmd.GetNameservers(), cmd.GetSearchDomains(), cmd.GetHosts())
+}
+
+// applyProStatus checks that the distro is attached to Ubuntu Pro, or detached from it, as the agent expects.
+func (s Service) applyProStatus(ctx context.Context, cmd *agentapi.ProStatusCmd) error {
+	attached, err := s.system.ProStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if attached != cmd.GetAttached() {
+		return fmt.Errorf("ApplyCommand: expected the distro to be attached: %t, but it is: %t", cmd.GetAttached(), attached)
+	}
+
+	log.Debugf(ctx, "ApplyCommand: the distro is attached as expected: %t", attached)
+	return nil
+}
@@ -2,10 +2,13 @@ package commandservice_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/commandservice"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/testutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -148,8 +151,268 @@ func TestApplyLandscapeConfig(t *testing.T) {
 	}
 }
 
+func TestApplyCommand(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		cmd *agentapi.Command
+
+		breakProEnable  bool
+		breakProDisable bool
+		breakUsgFix     bool
+		breakUsgAudit   bool
+		breakAptInstall bool
+		breakUseradd    bool
+		breakSnap       bool
+		breakProStatus  bool
+		proAttached     bool
+		preempt         bool
+
+		wantFile     string
+		wantNoFile   string
+		wantOutput   string
+		wantProgress []string
+		wantErr      bool
+	}{
+		"Success enabling a Pro service":          {cmd: proServiceCmd("esm-apps", true), wantFile: "/.pro-enabled-esm-apps"},
+		"Success disabling a Pro service":         {cmd: proServiceCmd("esm-apps", false), wantFile: "/.pro-disabled-esm-apps"},
+		"Success fixing and auditing with USG":    {cmd: usgCmd("cis_level1_server", true), wantFile: "/.usg-fixed-cis_level1_server", wantOutput: "<html>cis_level1_server</html>", wantProgress: []string{`0% Applying USG profile "cis_level1_server"`, `50% Auditing USG profile "cis_level1_server"`}},
+		"Success only auditing with USG":          {cmd: usgCmd("cis_level1_server", false), wantNoFile: "/.usg-fixed-cis_level1_server", wantOutput: "<html>cis_level1_server</html>", wantProgress: []string{`0% Auditing USG profile "cis_level1_server"`}},
+		"Success upgrading the service":           {cmd: serviceUpgradeCmd("stable"), wantFile: "/.apt-installed", wantProgress: []string{"10% Refreshing the package index", "50% Installing wsl-pro-service"}},
+		"Success managing a user":                 {cmd: manageUserCmd("ubuntu"), wantFile: "/.useradd-ubuntu"},
+		"Success setting the patching level":      {cmd: patchingCmd("security-only"), wantFile: system.PatchingConfigPath},
+		"Success unsetting the patching level":    {cmd: patchingCmd(""), wantNoFile: system.PatchingConfigPath},
+		"Success setting the proxy":               {cmd: proxyCmd("http://proxy.example.com:3128"), wantFile: system.ProxySystemdConfigPath},
+		"Success unsetting the proxy":             {cmd: proxyCmd(""), wantNoFile: system.ProxySystemdConfigPath},
+		"Success configuring snapd":               {cmd: snapdCmd("http://proxy.example.com:3128"), wantFile: "/.snap-set"},
+		"Success setting the nameservers":         {cmd: dnsCmd("10.0.0.53"), wantFile: system.ResolvConfPath},
+		"Success unsetting the nameservers":       {cmd: dnsCmd(""), wantNoFile: system.ResolvConfPath},
+		"Success checking the distro is attached": {cmd: proStatusCmd(true), proAttached: true},
+		"Success checking the distro is detached": {cmd: proStatusCmd(false)},
+
+		"Error when the command is empty":          {cmd: &agentapi.Command{}, wantErr: true},
+		"Error when the Pro service is empty":      {cmd: proServiceCmd("", true), wantErr: true},
+		"Error calling pro enable":                 {cmd: proServiceCmd("esm-apps", true), breakProEnable: true, wantErr: true},
+		"Error calling pro disable":                {cmd: proServiceCmd("esm-apps", false), breakProDisable: true, wantErr: true},
+		"Error when the USG profile is empty":      {cmd: usgCmd("", true), wantErr: true},
+		"Error calling usg fix":                    {cmd: usgCmd("cis_level1_server", true), breakUsgFix: true, wantErr: true},
+		"Error calling usg audit":                  {cmd: usgCmd("cis_level1_server", false), breakUsgAudit: true, wantErr: true},
+		"Error when the update channel is empty":   {cmd: serviceUpgradeCmd(""), wantErr: true},
+		"Error calling apt-get install":            {cmd: serviceUpgradeCmd("stable"), breakAptInstall: true, wantErr: true},
+		"Error when the user name is empty":        {cmd: manageUserCmd(""), wantErr: true},
+		"Error calling useradd":                    {cmd: manageUserCmd("ubuntu"), breakUseradd: true, wantErr: true},
+		"Error when the patching level is unknown": {cmd: patchingCmd("everything"), wantErr: true},
+		"Error when the proxy is malformed":        {cmd: proxyCmd("http://proxy\n"), wantErr: true},
+		"Error calling snap":                       {cmd: snapdCmd("http://proxy.example.com:3128"), breakSnap: true, wantErr: true},
+		"Error when the nameserver is malformed":   {cmd: dnsCmd("dns.example.com"), wantErr: true},
+		"Error when the distro is not attached":    {cmd: proStatusCmd(true), wantErr: true},
+		"Error when the distro is still attached":  {cmd: proStatusCmd(false), proAttached: true, wantErr: true},
+		"Error calling pro status":                 {cmd: proStatusCmd(true), breakProStatus: true, wantErr: true},
+		"Error when preempted before starting":     {cmd: proServiceCmd("esm-apps", true), preempt: true, wantNoFile: "/.pro-enabled-esm-apps", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sys, mock := testutils.MockSystem(t)
+
+			if tc.breakProEnable {
+				mock.SetControlArg(testutils.ProEnableErr)
+			}
+
+			if tc.breakProDisable {
+				mock.SetControlArg(testutils.ProDisableErr)
+			}
+
+			if tc.breakUsgFix {
+				mock.SetControlArg(testutils.UsgFixErr)
+			}
+
+			if tc.breakUsgAudit {
+				mock.SetControlArg(testutils.UsgAuditErr)
+			}
+
+			if tc.breakAptInstall {
+				mock.SetControlArg(testutils.AptGetInstallErr)
+			}
+
+			if tc.breakUseradd {
+				mock.SetControlArg(testutils.UseraddErr)
+			}
+
+			if tc.breakSnap {
+				mock.SetControlArg(testutils.SnapErr)
+			}
+
+			if tc.breakProStatus {
+				mock.SetControlArg(testutils.ProStatusErr)
+			}
+
+			if tc.proAttached {
+				mock.SetControlArg(testutils.ProStatusAttached)
+			}
+
+			svc := commandservice.New(sys)
+
+			ctx, preempt := system.WithPreemption(context.Background())
+			if tc.preempt {
+				preempt()
+			}
+
+			var progress []string
+			ctx = system.WithProgress(ctx, func(percent uint32, step string) {
+				progress = append(progress, fmt.Sprintf("%d%% %s", percent, step))
+			})
+
+			out, err := svc.ApplyCommand(ctx, tc.cmd)
+			if tc.wantErr {
+				require.Error(t, err, "ApplyCommand call should return an error")
+				require.Equal(t, tc.preempt, errors.Is(err, system.ErrPreempted), "Only preempted commands should return ErrPreempted")
+				if tc.wantNoFile != "" {
+					assert.NoFileExists(t, mock.Path(tc.wantNoFile), "Executable should not have been called")
+				}
+				return
+			}
+			require.NoError(t, err, "ApplyCommand call should return no error")
+			require.Equal(t, tc.wantOutput, string(out), "Mismatched command output")
+			require.Equal(t, tc.wantProgress, progress, "Mismatched progress of the command")
+
+			if tc.wantFile != "" {
+				assert.FileExists(t, mock.Path(tc.wantFile), "Executable should have been called")
+			}
+			if tc.wantNoFile != "" {
+				assert.NoFileExists(t, mock.Path(tc.wantNoFile), "Executable should not have been called")
+			}
+		})
+	}
+}
+
+func TestTailLog(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		lines            int32
+		includeProClient bool
+
+		want    []string
+		wantErr bool
+	}{
+		"Success": {lines: 10, want: []string{"wsl-pro-service.service: mock line (lines: 10, priority: all)"}},
+		"Success including the Pro client": {lines: 10, includeProClient: true, want: []string{
+			"wsl-pro-service.service: mock line (lines: 10, priority: all)",
+			"ubuntu-advantage.service: mock line (lines: 10, priority: all)",
+		}},
+
+		"Error when tailing no lines": {lines: 0, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sys, _ := testutils.MockSystem(t)
+			svc := commandservice.New(sys)
+
+			var got []string
+			err := svc.TailLog(context.Background(), &agentapi.TailLogCmd{Lines: tc.lines, IncludeProClient: tc.includeProClient}, func(line string) error {
+				got = append(got, line)
+				return nil
+			})
+			if tc.wantErr {
+				require.Error(t, err, "TailLog call should return an error")
+				return
+			}
+			require.NoError(t, err, "TailLog call should return no error")
+			require.Equal(t, tc.want, got, "Mismatched journal lines")
+		})
+	}
+}
+
+func proServiceCmd(service string, enable bool) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_ProService{
+			ProService: &agentapi.ProServiceCmd{Service: service, Enable: enable},
+		},
+	}
+}
+
+func usgCmd(profile string, fix bool) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_Usg{
+			Usg: &agentapi.UsgCmd{Profile: profile, Fix: fix},
+		},
+	}
+}
+
+func serviceUpgradeCmd(channel string) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_ServiceUpgrade{
+			ServiceUpgrade: &agentapi.ServiceUpgradeCmd{Channel: channel},
+		},
+	}
+}
+
+func patchingCmd(level string) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_Patching{
+			Patching: &agentapi.PatchingCmd{Level: level},
+		},
+	}
+}
+
+func proStatusCmd(attached bool) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_ProStatus{
+			ProStatus: &agentapi.ProStatusCmd{Attached: attached},
+		},
+	}
+}
+
+func proxyCmd(proxy string) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_Proxy{
+			Proxy: &agentapi.ProxyCmd{Http: proxy, Https: proxy},
+		},
+	}
+}
+
+func snapdCmd(proxy string) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_Snapd{
+			Snapd: &agentapi.SnapdCmd{Http: proxy, Https: proxy},
+		},
+	}
+}
+
+func dnsCmd(nameserver string) *agentapi.Command {
+	var nameservers []string
+	if nameserver != "" {
+		nameservers = append(nameservers, nameserver)
+	}
+
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_Dns{
+			Dns: &agentapi.DnsCmd{Nameservers: nameservers},
+		},
+	}
+}
+
+func manageUserCmd(name string) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_ManageUser{
+			ManageUser: &agentapi.ManageUserCmd{Name: name},
+		},
+	}
+}
+
 func TestWithProMock(t *testing.T)             { testutils.ProMock(t) }
 func TestWithLandscapeConfigMock(t *testing.T) { testutils.LandscapeConfigMock(t) }
 func TestWithWslPathMock(t *testing.T)         { testutils.WslPathMock(t) }
 func TestWithWslInfoMock(t *testing.T)         { testutils.WslInfoMock(t) }
 func TestWithCmdExeMock(t *testing.T)          { testutils.CmdExeMock(t) }
+func TestWithUsgMock(t *testing.T)             { testutils.UsgMock(t) }
+func TestWithAptGetMock(t *testing.T)          { testutils.AptGetMock(t) }
+func TestWithJournalctlMock(t *testing.T)      { testutils.JournalctlMock(t) }
+func TestWithUseraddMock(t *testing.T)         { testutils.UseraddMock(t) }
+func TestWithSnapMock(t *testing.T)            { testutils.SnapMock(t) }
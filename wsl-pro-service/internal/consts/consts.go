@@ -8,4 +8,7 @@ import (
 const (
 	// DefaultLogLevel is the default logging level selected without any option.
 	DefaultLogLevel = log.WarnLevel
+
+	// CrashReportsDir is the directory where the crash reports of the service are written.
+	CrashReportsDir = "/var/cache/wsl-pro-service/crashes"
 )
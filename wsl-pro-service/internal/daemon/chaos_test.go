@@ -0,0 +1,155 @@
+package daemon_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/daemon"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChaos subjects the connection between the daemon and the agent to a random sequence of
+// network faults, and then checks that both sides converge to a consistent state once the network
+// heals. Each case is a different seed, so that failures can be reproduced.
+func TestChaos(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		seed int64
+	}{
+		"Converges after the fault sequence of seed 1": {seed: 1},
+		"Converges after the fault sequence of seed 2": {seed: 2},
+		"Converges after the fault sequence of seed 3": {seed: 3},
+		"Converges after the fault sequence of seed 4": {seed: 4},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			system, mock := testutils.MockSystem(t)
+			systemd := &SystemdSdNotifierMock{returns: true}
+
+			d, err := daemon.New(ctx, system, daemon.WithSystemdNotifier(systemd.notify))
+			require.NoError(t, err, "Setup: New should return no error")
+			defer d.Quit(ctx, true)
+
+			agent := testutils.NewMockWindowsAgent(t, ctx, mock.DefaultPublicDir())
+			defer agent.Stop()
+
+			service := &chaosService{}
+
+			//nolint:errcheck // The daemon is force-quit at the end of the test.
+			go d.Serve(service)
+
+			require.Eventually(t, agent.Service.AllConnected, 20*time.Second, 100*time.Millisecond, "Setup: daemon never connected to the agent")
+
+			rng := rand.New(rand.NewSource(tc.seed))
+			sent := map[string]bool{}
+
+			const steps = 4
+			for i := range steps {
+				switch rng.Intn(4) {
+				case 0:
+					t.Logf("Step %d: resetting the connection", i)
+					agent.Chaos.Reset()
+				case 1:
+					n := rng.Intn(64)
+					t.Logf("Step %d: truncating the connection after %d bytes", i, n)
+					agent.Chaos.Truncate(n)
+				case 2:
+					delay := time.Duration(rng.Intn(500)) * time.Millisecond
+					t.Logf("Step %d: delaying messages by %s", i, delay)
+					agent.Chaos.Delay(delay)
+				case 3:
+					t.Logf("Step %d: partitioning the network", i)
+					agent.Chaos.Partition()
+				}
+
+				// The command may or may not make it through: what matters is that it is either applied whole or not at all.
+				token := fmt.Sprintf("token-%d", i)
+				sent[token] = true
+				_ = agent.Service.ProAttachment.Send(&agentapi.ProAttachCmd{Token: token})
+
+				time.Sleep(time.Duration(rng.Intn(1000)) * time.Millisecond)
+
+				if rng.Intn(2) == 0 {
+					t.Logf("Step %d: healing the network", i)
+					agent.Chaos.Heal()
+				}
+			}
+
+			agent.Chaos.Heal()
+
+			// Convergence: the agent re-sends its desired state until the daemon applies it.
+			const final = "final-token"
+			sent[final] = true
+			require.Eventually(t, func() bool {
+				if !agent.Service.AllConnected() {
+					return false
+				}
+				if service.last() == final {
+					return true
+				}
+				_ = agent.Service.ProAttachment.Send(&agentapi.ProAttachCmd{Token: final})
+				return false
+			}, 2*time.Minute, time.Second, "The daemon should have reconnected and applied the final state")
+
+			for _, token := range service.all() {
+				require.True(t, sent[token], "The daemon applied a token that was never sent whole: %q", token)
+			}
+
+			require.Eventually(t, func() bool {
+				return systemd.gotState.Load() == "STATUS=Connected"
+			}, 10*time.Second, 100*time.Millisecond, "The daemon should report that it is connected")
+
+			history := agent.Service.Connect.History()
+			require.NotEmpty(t, history, "The agent should have received messages from the daemon")
+			last := history[len(history)-1]
+			gotName := last.GetHandshake().GetWslName() + last.GetInfo().GetWslName()
+			require.Equal(t, mock.WslDistroName, gotName, "The agent should know which distro is connected")
+		})
+	}
+}
+
+// chaosService records the Pro tokens it applies.
+type chaosService struct {
+	mockService
+
+	tokens []string
+	mu     sync.Mutex
+}
+
+func (s *chaosService) ApplyProToken(ctx context.Context, msg *agentapi.ProAttachCmd) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = append(s.tokens, msg.GetToken())
+	return nil
+}
+
+func (s *chaosService) last() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.tokens) == 0 {
+		return ""
+	}
+	return s.tokens[len(s.tokens)-1]
+}
+
+func (s *chaosService) all() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.tokens...)
+}
@@ -31,6 +31,11 @@ import (
 type Daemon struct {
 	addressPath, certsPath string
 
+	// fingerprintsPath pins the certificates of the agents the service accepts, if it exists. It must be owned
+	// by fingerprintsOwner.
+	fingerprintsPath  string
+	fingerprintsOwner uint32
+
 	// Interface to the WSL distro
 	system *system.System
 
@@ -40,6 +45,7 @@ type Daemon struct {
 	// Channels for internal messaging.
 	started atomic.Bool
 	running chan struct{}
+	ready   chan struct{}
 
 	// This context is used to interrupt any action.
 	// It must be the parent of gracefulCtx.
@@ -56,11 +62,21 @@ const (
 	serviceStatusWaiting    = "Not connected: waiting to retry"
 	serviceStatusConnecting = "Connecting"
 	serviceStatusConnected  = "Connected"
+	serviceStatusSkewed     = "Connected: warning: the clock is %s"
 	serviceStatusStopped    = "Stopped"
 )
 
+// readyAttempts is how many times the daemon tries to notify systemd about its readiness before giving up.
+const readyAttempts = 3
+
 type options struct {
 	systemdSdNotifier systemdSdNotifier
+
+	// notifySocket is the socket systemd listens to for notifications. Empty when not running under systemd.
+	notifySocket string
+
+	// fingerprintsOwner is the user that must own the file pinning the agent certificates.
+	fingerprintsOwner uint32
 }
 
 type systemdSdNotifier func(unsetEnvironment bool, state string) (bool, error)
@@ -68,6 +84,13 @@ type systemdSdNotifier func(unsetEnvironment bool, state string) (bool, error)
 // Option is the function signature used to tweak the daemon creation.
 type Option func(*options)
 
+// WithoutSystemd disables the notifications to systemd, as when the daemon runs in the foreground.
+func WithoutSystemd() Option {
+	return func(o *options) {
+		o.notifySocket = ""
+	}
+}
+
 // New returns an new, initialized daemon server, which handles systemd activation.
 // If systemd activation is used, it will override any socket passed here.
 func New(ctx context.Context, s *system.System, args ...Option) (*Daemon, error) {
@@ -76,6 +99,7 @@ func New(ctx context.Context, s *system.System, args ...Option) (*Daemon, error)
 	// Set default options.
 	opts := options{
 		systemdSdNotifier: daemon.SdNotify,
+		notifySocket:      os.Getenv("NOTIFY_SOCKET"),
 	}
 
 	// Apply given args.
@@ -83,6 +107,11 @@ func New(ctx context.Context, s *system.System, args ...Option) (*Daemon, error)
 		f(&opts)
 	}
 
+	if opts.notifySocket == "" {
+		log.Info(ctx, "Daemon: not running under systemd: skipping systemd notifications")
+		opts.systemdSdNotifier = func(bool, string) (bool, error) { return false, nil }
+	}
+
 	home, err := s.UserProfileDir(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not find address file: could not find $env:UserProfile: %v", err)
@@ -94,8 +123,12 @@ func New(ctx context.Context, s *system.System, args ...Option) (*Daemon, error)
 	return &Daemon{
 		systemdSdNotifier: opts.systemdSdNotifier,
 		system:            s,
-		addressPath:       filepath.Join(home, common.UserProfileDir, common.ListeningPortFileName),
-		certsPath:         filepath.Join(home, common.UserProfileDir, common.CertificatesDir),
+		addressPath:       common.AddressFile(common.PublicDir(home)),
+		certsPath:         common.CertificatesPath(common.PublicDir(home)),
+		fingerprintsPath:  s.Path(agentFingerprintsPath),
+		fingerprintsOwner: opts.fingerprintsOwner,
+
+		ready: make(chan struct{}),
 
 		ctx:    ctx,
 		cancel: cancel,
@@ -115,6 +148,7 @@ func (d *Daemon) Serve(service streams.CommandService) error {
 	defer close(d.running)
 
 	d.started.Store(true)
+	close(d.ready)
 
 	select {
 	case <-d.gracefulCtx.Done():
@@ -216,6 +250,12 @@ func (d *Daemon) Serve(service streams.CommandService) error {
 	}
 }
 
+// Ready returns a channel that is closed once Serve is running, so that it can be quit.
+// It is never closed if Serve is not called.
+func (d *Daemon) Ready() <-chan struct{} {
+	return d.ready
+}
+
 // Quit gracefully quits listening loop and stops the grpc server.
 // It can drop any existing connection if force is set to true.
 func (d *Daemon) Quit(ctx context.Context, force bool) {
@@ -239,18 +279,38 @@ func (d *Daemon) Quit(ctx context.Context, force bool) {
 	log.Debug(ctx, i18n.G("All connections have now ended."))
 }
 
-func (d *Daemon) systemdNotifyReady(ctx context.Context) error {
-	sent, err := d.systemdSdNotifier(false, "READY=1")
-	if err != nil {
-		return fmt.Errorf(i18n.G("couldn't send ready notification to systemd: %v"), err)
-	}
-	if sent {
-		log.Debug(ctx, i18n.G("Ready state sent to systemd"))
-		if err := os.Unsetenv("NOTIFY_SOCKET"); err != nil {
-			log.Warningf(ctx, "couldn't unset NOTIFY_SOCKET for subprocesses: %v", err)
+// systemdNotifyReady notifies systemd about the readiness of the daemon, retrying with exponential
+// back-off in case of failure.
+func (d *Daemon) systemdNotifyReady(ctx context.Context) (err error) {
+	wait := 100 * time.Millisecond
+
+	for attempt := 1; attempt <= readyAttempts; attempt++ {
+		var sent bool
+		sent, err = d.systemdSdNotifier(false, "READY=1")
+		if err == nil {
+			// NOTIFY_SOCKET is kept, as the status is updated for as long as the daemon runs. Subprocesses
+			// inheriting it are harmless: systemd only accepts notifications from the main process.
+			if sent {
+				log.Debug(ctx, i18n.G("Ready state sent to systemd"))
+			}
+			return nil
+		}
+
+		if attempt == readyAttempts {
+			break
 		}
+
+		log.Warningf(ctx, "Daemon: couldn't send ready notification to systemd (attempt %d/%d): %v", attempt, readyAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
 	}
-	return nil
+
+	return fmt.Errorf(i18n.G("couldn't send ready notification to systemd: %v"), err)
 }
 
 func (d *Daemon) systemdNotifyStatus(ctx context.Context, status string) {
@@ -295,7 +355,13 @@ func (d *Daemon) connect(ctx context.Context) (server *streams.Server, err error
 
 	log.Infof(ctx, "Daemon: starting connection to Windows Agent via %s", addr)
 
-	tlsConfig, err := newTLSConfigFromDir(d.certsPath)
+	// The pinned certificates are read on every connection, so that changes apply without restarting the service.
+	pins, err := loadAgentFingerprints(d.fingerprintsPath, d.fingerprintsOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfigFromDir(d.certsPath, pins)
 	if err != nil {
 		return nil, err
 	}
@@ -307,14 +373,27 @@ func (d *Daemon) connect(ctx context.Context) (server *streams.Server, err error
 		return nil, fmt.Errorf("could not create a gRPC client: %v", err)
 	}
 
-	return streams.NewServer(ctx, d.system, conn), nil
+	return streams.NewServer(ctx, d.system, conn, streams.WithClockSkewHandler(d.clockSkewed)), nil
+}
+
+// clockSkewed exposes in the systemd status that the clocks of Windows and the distro drifted apart, as it is
+// the likely cause of the TLS and token errors that may ensue.
+func (d *Daemon) clockSkewed(ctx context.Context, skew time.Duration) {
+	if !streams.ClockSkewed(skew) {
+		d.systemdNotifyStatus(ctx, serviceStatusConnected)
+		return
+	}
+	d.systemdNotifyStatus(ctx, fmt.Sprintf(serviceStatusSkewed, streams.DescribeClockSkew(skew)))
 }
 
 // newTLSConfigFromDir loads certificates from the provided certs path and returns a matching tls.Config.
-func newTLSConfigFromDir(certsPath string) (conf *tls.Config, err error) {
+// The service presents the certificate the agent issued for the WSL Pro services, which is the only one
+// the agent accepts on the WSLInstance service. If pins is not nil, only the pinned agent certificates
+// are accepted.
+func newTLSConfigFromDir(certsPath string, pins *agentFingerprints) (conf *tls.Config, err error) {
 	decorate.OnError(&err, "could not load TLS config")
 
-	cert, err := tls.LoadX509KeyPair(filepath.Join(certsPath, common.ClientsCertFilePrefix+common.CertificateSuffix), filepath.Join(certsPath, common.ClientsCertFilePrefix+common.KeySuffix))
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certsPath, common.WSLProServiceCertFilePrefix+common.CertificateSuffix), filepath.Join(certsPath, common.WSLProServiceCertFilePrefix+common.KeySuffix))
 	if err != nil {
 		return nil, err
 	}
@@ -329,12 +408,17 @@ func newTLSConfigFromDir(certsPath string) (conf *tls.Config, err error) {
 		return nil, fmt.Errorf("failed to parse %q", caFilePath)
 	}
 
-	return &tls.Config{
+	conf = &tls.Config{
 		ServerName:   common.GRPCServerNameOverride,
 		Certificates: []tls.Certificate{cert},
 		RootCAs:      ca,
 		MinVersion:   tls.VersionTLS13,
-	}, nil
+	}
+	if pins != nil {
+		conf.VerifyConnection = pins.verify
+	}
+
+	return conf, nil
 }
 
 // address fetches the address of the control stream from the Windows filesystem.
@@ -2,7 +2,11 @@ package daemon_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
 	"errors"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -71,6 +75,11 @@ func TestServe(t *testing.T) {
 		missingCaCert           bool
 		breakLandscapeConf      bool
 
+		// Pin the agent certificates
+		pinAgentCert    bool
+		pinOtherCert    bool
+		breakPinnedFile bool
+
 		// Break the port file in various ways
 		breakPortFile         bool
 		portFileEmpty         bool
@@ -81,14 +90,20 @@ func TestServe(t *testing.T) {
 		// Return values for the mock SystemdSdNotifier
 		notifierReturn bool
 		notifierErr    bool
+		readyFailures  int32
+		noSystemd      bool
 
-		wantSystemdNotReady bool
-		wantConnected       bool
-		wantErr             bool
+		wantSystemdNotReady    bool
+		wantReadyNotifications int32
+		wantConnected          bool
+		wantErr                bool
 	}{
 		"Success": {wantConnected: true},
-		"Success with systemd notifier returning true": {notifierReturn: true, wantConnected: true},
-		"Success with a broken Landscape config":       {breakLandscapeConf: true, wantConnected: true},
+		"Success with systemd notifier returning true":          {notifierReturn: true, wantConnected: true},
+		"Success when not running under systemd":                {noSystemd: true, wantSystemdNotReady: true, wantConnected: true},
+		"Success when the ready notification fails transiently": {readyFailures: 2, wantReadyNotifications: 3, wantConnected: true},
+		"Success with a broken Landscape config":                {breakLandscapeConf: true, wantConnected: true},
+		"Success with the agent certificate pinned":             {pinAgentCert: true, wantConnected: true},
 
 		// No connection:
 		// These problems do not cause the agent to return error because it
@@ -103,10 +118,12 @@ func TestServe(t *testing.T) {
 		"No connection because there is no server":                   {dontServe: true},
 		"No connection because there are no certificates":            {missingCertsDir: true, wantConnected: false},
 		"No connection because cannot read root CA certificate file": {missingCaCert: true, wantConnected: false},
+		"No connection because the agent certificate is not pinned":  {pinOtherCert: true, wantConnected: false},
+		"No connection because the pinned certificates are invalid":  {breakPinnedFile: true, wantConnected: false},
 
 		// Errors
 		"Error because the context is pre-cancelled":        {precancelContext: true, wantSystemdNotReady: true, wantErr: true},
-		"Error because the notifier returns an error":       {notifierErr: true, wantErr: true},
+		"Error because the notifier returns an error":       {notifierErr: true, wantReadyNotifications: 3, wantErr: true},
 		"Error because WindowsHostAddress returns an error": {breakWindowsHostAddress: true, wantErr: true},
 	}
 
@@ -144,6 +161,22 @@ func TestServe(t *testing.T) {
 				mock.SetControlArg(testutils.WslInfoErr)
 			}
 
+			pinnedFile := system.Path("/etc/wsl-pro-service/agent-fingerprints")
+			var pins string
+			if tc.pinAgentCert {
+				pins = "# The mock agent\n" + agentCertFingerprint(t, publicDir) + "\n"
+			}
+			if tc.pinOtherCert {
+				pins = "SHA256 Fingerprint=" + strings.Repeat("AB:", 31) + "AB\n"
+			}
+			if tc.breakPinnedFile {
+				pins = "not a fingerprint\n"
+			}
+			if pins != "" {
+				require.NoError(t, os.MkdirAll(filepath.Dir(pinnedFile), 0750), "Setup: could not create the directory of the pinned certificates")
+				require.NoError(t, os.WriteFile(pinnedFile, []byte(pins), 0600), "Setup: could not write the pinned certificates")
+			}
+
 			portFile := filepath.Join(publicDir, common.ListeningPortFileName)
 			if tc.portFileEmpty {
 				require.NoError(t, os.WriteFile(portFile, []byte{}, 0600), "Setup: could not overwrite port file")
@@ -164,11 +197,20 @@ func TestServe(t *testing.T) {
 			}
 
 			systemd := &SystemdSdNotifierMock{
-				returns:   tc.notifierReturn,
-				returnErr: tc.notifierErr,
+				returns:       tc.notifierReturn,
+				returnErr:     tc.notifierErr,
+				readyFailures: tc.readyFailures,
 			}
 
-			d, err := daemon.New(ctx, system, daemon.WithSystemdNotifier(systemd.notify))
+			opts := []daemon.Option{
+				daemon.WithSystemdNotifier(systemd.notify),
+				daemon.WithAgentFingerprintsOwner(uint32(os.Getuid())),
+			}
+			if tc.noSystemd {
+				opts = append(opts, daemon.WithoutSystemd())
+			}
+
+			d, err := daemon.New(ctx, system, opts...)
 			require.NoError(t, err, "New should return no error")
 
 			if tc.precancelContext {
@@ -182,9 +224,11 @@ func TestServe(t *testing.T) {
 			}()
 
 			if tc.wantConnected {
-				require.Eventually(t, func() bool {
-					return systemd.gotState.Load() == "STATUS=Connected"
-				}, 30*time.Second, time.Second, "Systemd never switched states to 'Connected'")
+				if !tc.noSystemd {
+					require.Eventually(t, func() bool {
+						return systemd.gotState.Load() == "STATUS=Connected"
+					}, 30*time.Second, time.Second, "Systemd never switched states to 'Connected'")
+				}
 
 				require.Eventually(t, agent.Service.AllConnected, 30*time.Second, time.Second, "The daemon should have connected to the Windows Agent")
 
@@ -219,12 +263,19 @@ func TestServe(t *testing.T) {
 				}
 			}
 
-			if tc.wantSystemdNotReady {
+			switch {
+			case tc.wantSystemdNotReady:
 				require.Zero(t, systemd.readyNotifications.Load(), "daemon should not have notified systemd")
-			} else {
+			case tc.wantReadyNotifications != 0:
+				require.Equal(t, tc.wantReadyNotifications, systemd.readyNotifications.Load(), "daemon should have retried notifying systemd until it succeeded or gave up")
+			default:
 				require.EqualValues(t, 1, systemd.readyNotifications.Load(), "daemon should have notified systemd once")
 			}
 
+			if tc.noSystemd {
+				require.Empty(t, systemd.gotState.Load(), "daemon should not have sent any status to systemd")
+			}
+
 			if tc.dontServe {
 				return // Nothing to assert server-side
 			}
@@ -401,10 +452,157 @@ func TestReconnection(t *testing.T) {
 	}
 }
 
+func TestClockSkewStatus(t *testing.T) {
+	// Not parallel because we modify the environment.
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The real notifier is used, so that the status must reach the socket of systemd after the ready notification.
+	socket := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err, "Setup: could not listen on the mock systemd notification socket")
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	notifications := make(chan string, 100)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			notifications <- string(buf[:n])
+		}
+	}()
+
+	system, mock := testutils.MockSystem(t)
+	agent := testutils.NewMockWindowsAgent(t, ctx, mock.DefaultPublicDir())
+	defer agent.Stop()
+
+	d, err := daemon.New(ctx, system)
+	require.NoError(t, err, "New should return no error")
+	defer d.Quit(ctx, true)
+
+	//nolint:errcheck // We don't really care
+	go d.Serve(&mockService{})
+
+	requireNotified := func(want string) {
+		t.Helper()
+		timeout := time.After(30 * time.Second)
+		for {
+			select {
+			case got := <-notifications:
+				if strings.HasPrefix(got, want) {
+					return
+				}
+			case <-timeout:
+				require.Failf(t, "Systemd was not notified", "Want a notification starting with %q", want)
+			}
+		}
+	}
+
+	requireNotified("READY=1")
+	requireNotified("STATUS=Connected")
+	require.Eventually(t, agent.Service.AllConnected, 20*time.Second, 500*time.Millisecond, "Daemon never connected to agent's service")
+
+	err = agent.Service.Connect.Send(&agentapi.HandshakeAck{AgentTimeUnixMilli: time.Now().Add(10 * time.Minute).UnixMilli()})
+	require.NoError(t, err, "Send should return no error")
+	requireNotified("STATUS=Connected: warning: the clock is")
+}
+
+func TestDiagnoseConnection(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		breakWslPath    bool
+		breakWslInfo    bool
+		breakPortFile   bool
+		missingCertsDir bool
+		dontServe       bool
+
+		wantOutput []string
+		wantErr    bool
+	}{
+		"Success": {wantOutput: []string{"Networking mode: other", "localhost: 127.0.0.1:", "TLS handshake succeeded (TLS 1.3)"}},
+
+		"Error when the user profile cannot be found": {breakWslPath: true, wantOutput: []string{"Windows user profile: could not find it"}, wantErr: true},
+		"Error when the networking mode is unknown":   {breakWslInfo: true, wantOutput: []string{"Networking mode: could not find it", "Endpoint: could not resolve"}, wantErr: true},
+		"Error when the port file does not exist":     {breakPortFile: true, wantOutput: []string{"could not read it", "not dialed: port unknown", "Endpoint: 127.0.0.1 (port unknown)"}, wantErr: true},
+		"Error when there are no certificates":        {missingCertsDir: true, wantOutput: []string{"could not load them", "TLS handshake skipped: no certificates"}, wantErr: true},
+		"Error when there is no server":               {dontServe: true, wantOutput: []string{"Endpoint: 127.0.0.1:", "dial failed"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			system, mock := testutils.MockSystem(t)
+
+			publicDir := mock.DefaultPublicDir()
+			agent := testutils.NewMockWindowsAgent(t, ctx, publicDir)
+			defer agent.Stop()
+
+			if tc.breakWslPath {
+				mock.SetControlArg(testutils.WslpathErr)
+			}
+			if tc.breakWslInfo {
+				mock.SetControlArg(testutils.WslInfoErr)
+			}
+			if tc.breakPortFile {
+				require.NoError(t, os.RemoveAll(filepath.Join(publicDir, common.ListeningPortFileName)), "Setup: could not remove port file")
+			}
+			if tc.missingCertsDir {
+				require.NoError(t, os.RemoveAll(filepath.Join(publicDir, common.CertificatesDir)), "Setup: could not remove certificates")
+			}
+			if tc.dontServe {
+				addr := agent.Listener.Addr().String()
+				agent.Stop()
+				require.NoError(t, os.WriteFile(filepath.Join(publicDir, common.ListeningPortFileName), []byte(addr), 0600), "Setup: could not overwrite port file")
+			}
+
+			var out strings.Builder
+			err := daemon.DiagnoseConnection(ctx, system, &out)
+			t.Log(out.String())
+
+			for _, want := range tc.wantOutput {
+				require.Contains(t, out.String(), want, "Missing line in the diagnostics output")
+			}
+
+			if tc.wantErr {
+				require.Error(t, err, "DiagnoseConnection should return an error")
+				return
+			}
+			require.NoError(t, err, "DiagnoseConnection should return no error")
+		})
+	}
+}
+
+// agentCertFingerprint returns the SHA-256 fingerprint of the certificate of the mock agent.
+func agentCertFingerprint(t *testing.T, publicDir string) string {
+	t.Helper()
+
+	out, err := os.ReadFile(filepath.Join(publicDir, common.CertificatesDir, "server"+common.CertificateSuffix))
+	require.NoError(t, err, "Setup: could not read the certificate of the agent")
+
+	block, _ := pem.Decode(out)
+	require.NotNil(t, block, "Setup: the certificate of the agent is not PEM encoded")
+
+	sum := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(sum[:])
+}
+
 type SystemdSdNotifierMock struct {
 	returns   bool
 	returnErr bool
 
+	// readyFailures is the number of ready notifications that fail before succeeding.
+	readyFailures int32
+
 	gotUnsetEnvironment atomic.Bool
 	gotState            atomicString
 	readyNotifications  atomic.Int32
@@ -415,7 +613,9 @@ func (s *SystemdSdNotifierMock) notify(unsetEnvironment bool, state string) (boo
 	s.gotState.Store(state)
 
 	if strings.Contains(state, "READY=1") {
-		s.readyNotifications.Add(1)
+		if n := s.readyNotifications.Add(1); n <= s.readyFailures {
+			return false, errors.New("mock error")
+		}
 	}
 
 	if s.returnErr {
@@ -450,6 +650,14 @@ func (s *mockService) ApplyLandscapeConfig(ctx context.Context, msg *agentapi.La
 	return nil
 }
 
+func (s *mockService) ApplyCommand(ctx context.Context, msg *agentapi.Command) ([]byte, error) {
+	return nil, nil
+}
+
+func (s *mockService) TailLog(ctx context.Context, msg *agentapi.TailLogCmd, send func(string) error) error {
+	return nil
+}
+
 func TestWithProMock(t *testing.T)     { testutils.ProMock(t) }
 func TestWithWslPathMock(t *testing.T) { testutils.WslPathMock(t) }
 func TestWithWslInfoMock(t *testing.T) { testutils.WslInfoMock(t) }
@@ -0,0 +1,194 @@
+package daemon
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
+	"github.com/ubuntu/decorate"
+)
+
+// diagnosticsTimeout is how long each dial and handshake may take while diagnosing the connection.
+const diagnosticsTimeout = 5 * time.Second
+
+// probeResult is the outcome of trying to reach the agent at one address.
+type probeResult struct {
+	dialErr      error
+	dialDuration time.Duration
+
+	// handshakeErr is nil when the handshake succeeded or was not attempted.
+	handshakeErr error
+	tlsVersion   string
+}
+
+// DiagnoseConnection tries every way the service may find and reach the Windows Agent, and writes a
+// human-readable report of each step to w. It returns an error if the endpoint the service would
+// connect to is not reachable.
+func DiagnoseConnection(ctx context.Context, s *system.System, w io.Writer) (err error) {
+	defer decorate.OnError(&err, "the Windows Agent is not reachable")
+
+	home, err := s.UserProfileDir(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "Windows user profile: could not find it: %v\n", err)
+		return fmt.Errorf("could not find $env:UserProfile: %v", err)
+	}
+
+	addressPath := common.AddressFile(common.PublicDir(home))
+	certsPath := common.CertificatesPath(common.PublicDir(home))
+
+	// Port
+	fmt.Fprintf(w, "Agent port file: %s\n", addressPath)
+	var port int
+	var portErr error
+	if addr, err := os.ReadFile(addressPath); err != nil {
+		portErr = err
+		fmt.Fprintf(w, "  could not read it: %v\n", err)
+	} else if port, err = splitPort(string(addr)); err != nil {
+		portErr = err
+		fmt.Fprintf(w, "  contents: %q\n  %v\n", string(addr), err)
+	} else {
+		fmt.Fprintf(w, "  contents: %q\n  port: %d\n", string(addr), port)
+	}
+
+	// Pinned agent certificates
+	fingerprintsPath := s.Path(agentFingerprintsPath)
+	fmt.Fprintf(w, "Pinned agent certificates: %s\n", fingerprintsPath)
+	pins, certErr := loadAgentFingerprints(fingerprintsPath, 0)
+	switch {
+	case certErr != nil:
+		fmt.Fprintf(w, "  %v\n", certErr)
+	case pins == nil:
+		fmt.Fprintln(w, "  none: any certificate of the agent is accepted")
+	default:
+		fmt.Fprintf(w, "  %d pinned\n", len(pins.allowed))
+	}
+
+	// Certificates
+	fmt.Fprintf(w, "Certificates: %s\n", certsPath)
+	var tlsConfig *tls.Config
+	if certErr == nil {
+		tlsConfig, certErr = newTLSConfigFromDir(certsPath, pins)
+	}
+	if certErr != nil {
+		fmt.Fprintf(w, "  could not load them: %v\n", certErr)
+	} else {
+		fmt.Fprintln(w, "  loaded")
+	}
+
+	// Networking mode
+	if mode, err := s.NetworkingMode(ctx); err != nil {
+		fmt.Fprintf(w, "Networking mode: could not find it: %v\n", err)
+	} else {
+		fmt.Fprintf(w, "Networking mode: %s\n", mode)
+	}
+
+	// Candidate addresses
+	results := make(map[string]probeResult)
+	fmt.Fprintln(w, "Address resolution strategies:")
+	for _, c := range s.WindowsHostAddressCandidates() {
+		if c.Err != nil {
+			fmt.Fprintf(w, "  %s: could not resolve: %v\n", c.Strategy, c.Err)
+			continue
+		}
+
+		if portErr != nil {
+			fmt.Fprintf(w, "  %s: %s (not dialed: port unknown)\n", c.Strategy, c.IP)
+			continue
+		}
+
+		addr := net.JoinHostPort(c.IP.String(), fmt.Sprint(port))
+		r, ok := results[addr]
+		if !ok {
+			r = probe(ctx, addr, tlsConfig)
+			results[addr] = r
+		}
+		fmt.Fprintf(w, "  %s: %s: %s\n", c.Strategy, addr, r)
+	}
+
+	// Endpoint the service would use
+	ip, err := s.WindowsHostAddress(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "Endpoint: could not resolve: %v\n", err)
+		return err
+	}
+
+	if portErr != nil {
+		fmt.Fprintf(w, "Endpoint: %s (port unknown)\n", ip)
+		return fmt.Errorf("could not find the agent port: %v", portErr)
+	}
+
+	addr := net.JoinHostPort(ip.String(), fmt.Sprint(port))
+	r, ok := results[addr]
+	if !ok {
+		r = probe(ctx, addr, tlsConfig)
+	}
+	fmt.Fprintf(w, "Endpoint: %s: %s\n", addr, r)
+
+	switch {
+	case r.dialErr != nil:
+		return fmt.Errorf("could not dial %s: %v", addr, r.dialErr)
+	case certErr != nil:
+		return fmt.Errorf("could not load certificates: %v", certErr)
+	case r.handshakeErr != nil:
+		return fmt.Errorf("could not complete the TLS handshake with %s: %v", addr, r.handshakeErr)
+	}
+
+	return nil
+}
+
+// probe dials the address and, if tlsConfig is not nil, performs a TLS handshake over the connection.
+func probe(ctx context.Context, addr string, tlsConfig *tls.Config) (r probeResult) {
+	ctx, cancel := context.WithTimeout(ctx, diagnosticsTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	r.dialDuration = time.Since(start)
+	if err != nil {
+		r.dialErr = err
+		return r
+	}
+	defer conn.Close()
+
+	if tlsConfig == nil {
+		return r
+	}
+
+	// The agent is a gRPC server, which requires HTTP/2 to be negotiated.
+	conf := tlsConfig.Clone()
+	conf.NextProtos = []string{"h2"}
+
+	tlsConn := tls.Client(conn, conf)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		r.handshakeErr = err
+		return r
+	}
+
+	r.tlsVersion = tls.VersionName(tlsConn.ConnectionState().Version)
+	return r
+}
+
+// String returns a one-line summary of the probe.
+func (r probeResult) String() string {
+	if r.dialErr != nil {
+		return fmt.Sprintf("dial failed after %s: %v", r.dialDuration.Round(time.Millisecond), r.dialErr)
+	}
+
+	dial := fmt.Sprintf("dial succeeded in %s", r.dialDuration.Round(time.Millisecond))
+
+	switch {
+	case r.handshakeErr != nil:
+		return fmt.Sprintf("%s, TLS handshake failed: %v", dial, r.handshakeErr)
+	case r.tlsVersion == "":
+		return fmt.Sprintf("%s, TLS handshake skipped: no certificates", dial)
+	}
+
+	return fmt.Sprintf("%s, TLS handshake succeeded (%s)", dial, r.tlsVersion)
+}
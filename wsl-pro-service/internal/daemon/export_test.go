@@ -5,5 +5,14 @@ type SystemdSdNotifier = systemdSdNotifier
 func WithSystemdNotifier(notifier SystemdSdNotifier) Option {
 	return func(o *options) {
 		o.systemdSdNotifier = notifier
+		o.notifySocket = "@mock-notify-socket"
+	}
+}
+
+// WithAgentFingerprintsOwner sets the user that must own the file pinning the agent certificates, so that tests
+// need not run as root.
+func WithAgentFingerprintsOwner(uid uint32) Option {
+	return func(o *options) {
+		o.fingerprintsOwner = uid
 	}
 }
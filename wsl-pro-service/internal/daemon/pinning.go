@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/ubuntu/decorate"
+)
+
+// agentFingerprintsPath is the file in the distro that pins the certificates of the agents the service accepts,
+// so that only one of the agents running on the machine, e.g. a stable build and a development one, connects
+// to it. It lists their SHA-256 fingerprints, one per line, as printed by openssl x509 -fingerprint -sha256.
+// Without it, any certificate issued by the root CA of the agent is accepted.
+const agentFingerprintsPath = "/etc/wsl-pro-service/agent-fingerprints"
+
+// agentFingerprints are the fingerprints of the certificates of the agents the service accepts.
+type agentFingerprints struct {
+	path    string
+	allowed map[string]bool
+}
+
+// loadAgentFingerprints reads the fingerprints of the agent certificates pinned in path. As the file decides
+// which agent the distro trusts, it must be owned by owner (root, but for tests) and not be writable by anybody
+// else. It returns nil if the file does not exist, so that no certificate is pinned.
+func loadAgentFingerprints(path string, owner uint32) (pins *agentFingerprints, err error) {
+	defer decorate.OnError(&err, "could not load the pinned agent certificates")
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || stat.Uid != owner {
+		return nil, fmt.Errorf("%s must be owned by root", path)
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return nil, fmt.Errorf("%s must not be writable by other users than root", path)
+	}
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pins = &agentFingerprints{path: path, allowed: make(map[string]bool)}
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for n := 1; sc.Scan(); n++ {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// openssl prefixes the fingerprint with the name of the digest.
+		if _, digest, found := strings.Cut(line, "="); found {
+			line = digest
+		}
+
+		fp := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(line), ":", ""))
+		if b, err := hex.DecodeString(fp); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("%s: line %d: %q is not a SHA-256 fingerprint", path, n, line)
+		}
+		pins.allowed[fp] = true
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(pins.allowed) == 0 {
+		return nil, fmt.Errorf("%s pins no certificate", path)
+	}
+
+	return pins, nil
+}
+
+// verify rejects the connection unless the agent presented one of the pinned certificates. It complements the
+// verification of the certificate chain, which has already succeeded when it is called.
+func (pins *agentFingerprints) verify(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("the agent presented no certificate")
+	}
+
+	sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+	fp := hex.EncodeToString(sum[:])
+	if !pins.allowed[fp] {
+		return fmt.Errorf("the agent certificate with fingerprint %s is not pinned in %s", fp, pins.path)
+	}
+
+	return nil
+}
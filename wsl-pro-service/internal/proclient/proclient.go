@@ -0,0 +1,280 @@
+// Package proclient parses the JSON output of the Ubuntu Pro client, pro, as shipped by ubuntu-advantage-tools
+// from Ubuntu 20.04 to the development release. The schema of that output changed over the years: fields were
+// renamed, moved or changed type, and new ones keep being added. Parsing is tolerant to all of that, so that a
+// new version of the client does not break the status reported to the agent.
+package proclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status is what the agent needs of the output of `pro status --format=json`.
+type Status struct {
+	// SchemaVersion is the version of the schema of the output, empty with the clients that did not report it.
+	SchemaVersion string
+
+	Attached bool
+
+	// Expires is when the contract expires. It is zero if the distro is not attached or the date is unknown.
+	Expires time.Time
+
+	// SupportLevel is the level of technical support of the contract, e.g. essential. It is empty if the
+	// distro is not attached or the contract does not include support.
+	SupportLevel string
+
+	Services []Service
+}
+
+// Service is an Ubuntu Pro service, as reported by `pro status`.
+type Service struct {
+	Name     string
+	Entitled bool
+
+	// Status is whether the service is enabled, disabled, or n/a if it is not available on this distro.
+	Status string
+}
+
+// EntitledServices returns the names of the services the distro is entitled to.
+func (s Status) EntitledServices() []string {
+	var services []string
+	for _, svc := range s.Services {
+		if svc.Entitled {
+			services = append(services, svc.Name)
+		}
+	}
+	return services
+}
+
+// SecurityStatus is what the agent needs of the output of `pro security-status --format=json`:
+// the number of pending security updates, by origin.
+type SecurityStatus struct {
+	StandardUpdates int32
+	ESMInfraUpdates int32
+	ESMAppsUpdates  int32
+	SchemaVersion   string
+}
+
+// statusOutput is the union of the schemas of the output of `pro status` that matter to the agent.
+type statusOutput struct {
+	SchemaVersion string    `json:"_schema_version"`
+	Attached      *flexBool `json:"attached"`
+	Expires       any       `json:"expires"`
+
+	// Contract holds the support level since ubuntu-advantage-tools 27.
+	Contract *struct {
+		TechSupportLevel string `json:"tech_support_level"`
+	} `json:"contract"`
+
+	// TechSupportLevel is where older clients reported the support level.
+	TechSupportLevel string `json:"techSupportLevel"`
+
+	Services []struct {
+		Name     string   `json:"name"`
+		Entitled flexBool `json:"entitled"`
+		Status   string   `json:"status"`
+	} `json:"services"`
+
+	errorsOutput
+}
+
+// securityStatusOutput is the union of the schemas of the output of `pro security-status` that matter to the agent.
+type securityStatusOutput struct {
+	SchemaVersion string `json:"_schema_version"`
+	Summary       *struct {
+		NumStandardSecurityUpdates flexInt `json:"num_standard_security_updates"`
+		NumEsmInfraUpdates         flexInt `json:"num_esm_infra_updates"`
+		NumEsmAppsUpdates          flexInt `json:"num_esm_apps_updates"`
+	} `json:"summary"`
+
+	errorsOutput
+}
+
+// errorsOutput is how the client reports that it failed, in the same output as the result it could not produce.
+type errorsOutput struct {
+	Errors []struct {
+		Message     string `json:"message"`
+		MessageCode string `json:"message_code"`
+	} `json:"errors"`
+}
+
+// err returns the first error the client reported, if any.
+func (o errorsOutput) err() error {
+	if len(o.Errors) == 0 {
+		return nil
+	}
+	if o.Errors[0].MessageCode == "" {
+		return fmt.Errorf("pro reported an error: %s", o.Errors[0].Message)
+	}
+	return fmt.Errorf("pro reported an error: %s: %s", o.Errors[0].MessageCode, o.Errors[0].Message)
+}
+
+// ParseStatus parses the output of `pro status --format=json`.
+func ParseStatus(out []byte) (status Status, err error) {
+	var parsed statusOutput
+	if err := decode(out, &parsed); err != nil {
+		return status, err
+	}
+
+	if parsed.Attached == nil {
+		if err := parsed.err(); err != nil {
+			return status, err
+		}
+		return status, errors.New("no attachment status in the output of pro status")
+	}
+
+	status.SchemaVersion = parsed.SchemaVersion
+	status.Attached = bool(*parsed.Attached)
+
+	for _, s := range parsed.Services {
+		if s.Name == "" {
+			continue
+		}
+		status.Services = append(status.Services, Service{Name: s.Name, Entitled: bool(s.Entitled), Status: s.Status})
+	}
+
+	if !status.Attached {
+		return status, nil
+	}
+
+	status.Expires = parseTime(parsed.Expires)
+
+	level := parsed.TechSupportLevel
+	if parsed.Contract != nil && parsed.Contract.TechSupportLevel != "" {
+		level = parsed.Contract.TechSupportLevel
+	}
+	if !isUnset(level) {
+		status.SupportLevel = level
+	}
+
+	return status, nil
+}
+
+// ParseSecurityStatus parses the output of `pro security-status --format=json`.
+func ParseSecurityStatus(out []byte) (status SecurityStatus, err error) {
+	var parsed securityStatusOutput
+	if err := decode(out, &parsed); err != nil {
+		return status, err
+	}
+
+	if parsed.Summary == nil {
+		if err := parsed.err(); err != nil {
+			return status, err
+		}
+		return status, errors.New("no summary in the output of pro security-status")
+	}
+
+	return SecurityStatus{
+		SchemaVersion:   parsed.SchemaVersion,
+		StandardUpdates: int32(parsed.Summary.NumStandardSecurityUpdates),
+		ESMInfraUpdates: int32(parsed.Summary.NumEsmInfraUpdates),
+		ESMAppsUpdates:  int32(parsed.Summary.NumEsmAppsUpdates),
+	}, nil
+}
+
+// decode parses the JSON object in out into v. Some versions of the client print warnings before the object,
+// such as "Unable to determine current instance-id", or messages after it, so anything around it is ignored.
+func decode(out []byte, v any) error {
+	start := bytes.IndexByte(out, '{')
+	if start < 0 {
+		return fmt.Errorf("no JSON object in output: %q", out)
+	}
+
+	if err := json.NewDecoder(bytes.NewReader(out[start:])).Decode(v); err != nil {
+		return fmt.Errorf("could not parse output: %v. Output: %s", err, out)
+	}
+
+	return nil
+}
+
+// timeLayouts are the formats the client reported dates in, from the RFC3339 of recent versions to the Python
+// string representation of datetimes of older ones.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// parseTime returns the time in v, or the zero time if there is none, such as with "n/a" or null.
+func parseTime(v any) time.Time {
+	s, ok := v.(string)
+	if !ok || isUnset(s) {
+		return time.Time{}
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t.UTC()
+		}
+	}
+
+	return time.Time{}
+}
+
+// isUnset returns true for the values the client uses for fields with no value.
+func isUnset(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "n/a", "none", "null", "unknown":
+		return true
+	}
+	return false
+}
+
+// flexBool is a boolean reported as a JSON boolean by some versions of the client, and as a string such as
+// "yes" by others.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "yes", "true", "enabled":
+			*b = true
+		default:
+			*b = false
+		}
+	default:
+		*b = false
+	}
+
+	return nil
+}
+
+// flexInt is a count reported as a JSON number by the client, that is tolerated as a string or null as well.
+type flexInt int32
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case float64:
+		*n = flexInt(max(0, min(v, math.MaxInt32)))
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid count %q: %v", v, err)
+		}
+		*n = flexInt(max(0, i))
+	default:
+		*n = 0
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package proclient_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/proclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		output string
+
+		wantErr bool
+	}{
+		"Success with an attached distro on 20.04":              {output: "focal_attached.json"},
+		"Success with an unattached distro on 20.04":            {output: "focal_unattached.json"},
+		"Success with an attached distro on 24.04":              {output: "noble_attached.json"},
+		"Success with the schema of the legacy ua client":       {output: "legacy_ua_client.json"},
+		"Success with unknown fields of the development client": {output: "devel_unknown_fields.json"},
+		"Success with partial data":                             {output: "partial.json"},
+		"Success with messages around the JSON output":          {output: "noisy.json"},
+
+		"Error when pro reports an error":    {output: "errors.json", wantErr: true},
+		"Error when the output is not JSON":  {output: "not_json.json", wantErr: true},
+		"Error when the output is truncated": {output: "truncated.json", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := os.ReadFile(filepath.Join(testutils.TestFamilyPath(t), tc.output))
+			require.NoError(t, err, "Setup: could not read the output of pro status")
+
+			got, err := proclient.ParseStatus(out)
+			if tc.wantErr {
+				require.Error(t, err, "ParseStatus should return an error")
+				return
+			}
+			require.NoError(t, err, "ParseStatus should return no error")
+
+			want := testutils.LoadWithUpdateFromGoldenYAML(t, got)
+			require.Equal(t, want, got, "ParseStatus should return the status in the output")
+		})
+	}
+}
+
+func TestParseSecurityStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		output string
+
+		wantErr bool
+	}{
+		"Success with the output of 20.04":                      {output: "focal.json"},
+		"Success with unknown fields of the development client": {output: "devel_unknown_fields.json"},
+		"Success with messages around the JSON output":          {output: "noisy.json"},
+
+		"Error when pro reports an error":    {output: "errors.json", wantErr: true},
+		"Error when a count is not a number": {output: "bad_count.json", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := os.ReadFile(filepath.Join(testutils.TestFamilyPath(t), tc.output))
+			require.NoError(t, err, "Setup: could not read the output of pro security-status")
+
+			got, err := proclient.ParseSecurityStatus(out)
+			if tc.wantErr {
+				require.Error(t, err, "ParseSecurityStatus should return an error")
+				return
+			}
+			require.NoError(t, err, "ParseSecurityStatus should return no error")
+
+			want := testutils.LoadWithUpdateFromGoldenYAML(t, got)
+			require.Equal(t, want, got, "ParseSecurityStatus should return the counts of updates in the output")
+		})
+	}
+}
+
+func FuzzParseStatus(f *testing.F) {
+	f.Add([]byte(`{"attached": true, "expires": "2030-01-01T00:00:00+00:00", "services": [{"name": "esm-infra", "entitled": "yes"}]}`))
+	f.Add([]byte(`{"attached": "yes", "expires": "2030-01-01 00:00:00", "techSupportLevel": "standard"}`))
+	f.Add([]byte(`warning {"attached": false, "contract": null, "services": null}`))
+	f.Add([]byte(`{"errors": [{"message": "oops"}]}`))
+	f.Add([]byte(``))
+
+	f.Fuzz(func(t *testing.T, out []byte) {
+		got, err := proclient.ParseStatus(out)
+		if err != nil {
+			require.Zero(t, got, "ParseStatus should return no status along with an error")
+			return
+		}
+
+		if !got.Attached {
+			require.Zero(t, got.Expires, "ParseStatus should return no expiration date for an unattached distro")
+			require.Empty(t, got.SupportLevel, "ParseStatus should return no support level for an unattached distro")
+		}
+		for _, s := range got.Services {
+			require.NotEmpty(t, s.Name, "ParseStatus should only return services with a name")
+		}
+	})
+}
+
+func FuzzParseSecurityStatus(f *testing.F) {
+	f.Add([]byte(`{"summary": {"num_standard_security_updates": 1, "num_esm_infra_updates": 2, "num_esm_apps_updates": 3}}`))
+	f.Add([]byte(`{"summary": {"num_standard_security_updates": "1", "num_esm_infra_updates": null, "num_esm_apps_updates": -5}}`))
+	f.Add([]byte(`{"summary": {"num_standard_security_updates": 1e100}}`))
+	f.Add([]byte(`{"errors": [{"message": "oops"}]}`))
+	f.Add([]byte(``))
+
+	f.Fuzz(func(t *testing.T, out []byte) {
+		got, err := proclient.ParseSecurityStatus(out)
+		if err != nil {
+			require.Zero(t, got, "ParseSecurityStatus should return no counts along with an error")
+			return
+		}
+
+		require.GreaterOrEqual(t, got.StandardUpdates, int32(0), "ParseSecurityStatus should return no negative count")
+		require.GreaterOrEqual(t, got.ESMInfraUpdates, int32(0), "ParseSecurityStatus should return no negative count")
+		require.GreaterOrEqual(t, got.ESMAppsUpdates, int32(0), "ParseSecurityStatus should return no negative count")
+	})
+}
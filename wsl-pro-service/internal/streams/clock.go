@@ -0,0 +1,87 @@
+package streams
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// MaxClockSkew is how far apart the clocks of Windows and the distro can be before it is warned about. The clock
+// of WSL is known to drift after the host sleeps, and beyond this the certificates and tokens checked on either
+// end may be rejected as not valid yet or expired.
+//
+// The timeouts and expiries of the protocol itself are all relative, measured with the monotonic clock of each
+// end, so that they are not affected by the skew.
+const MaxClockSkew = time.Minute
+
+// ClockSkewHandler is called every time the clocks of Windows and the distro drift apart by more than
+// MaxClockSkew, and every time they are back in sync, with how far behind the clock of Windows the distro's is.
+type ClockSkewHandler func(ctx context.Context, skew time.Duration)
+
+// ServerOption tweaks the creation of a Server.
+type ServerOption func(*Server)
+
+// WithClockSkewHandler sets the handler called when the clocks of Windows and the distro drift apart or are back
+// in sync. The skew is logged either way.
+func WithClockSkewHandler(handler ClockSkewHandler) ServerOption {
+	return func(s *Server) {
+		s.clock.handler = handler
+	}
+}
+
+// ClockSkewed returns true if the skew is beyond MaxClockSkew, whichever clock is ahead.
+func ClockSkewed(skew time.Duration) bool {
+	return skew > MaxClockSkew || skew < -MaxClockSkew
+}
+
+// DescribeClockSkew describes how far behind or ahead of the clock of Windows the distro's is.
+func DescribeClockSkew(skew time.Duration) string {
+	if skew < 0 {
+		return fmt.Sprintf("%s ahead of Windows", (-skew).Round(time.Second))
+	}
+	return fmt.Sprintf("%s behind Windows", skew.Round(time.Second))
+}
+
+// clockWatch compares the clock of the distro with the time the agent stamps its acknowledgements with.
+type clockWatch struct {
+	handler ClockSkewHandler
+
+	skewed bool
+	mu     sync.Mutex
+}
+
+// check measures the skew with an acknowledgement received at the given time, and warns about it if the clocks
+// just drifted apart. The delay between the agent stamping the acknowledgement and the distro receiving it is
+// negligible compared to MaxClockSkew. Acknowledgements of agents that do not stamp them are ignored.
+func (w *clockWatch) check(ctx context.Context, ack *agentapi.HandshakeAck, received time.Time) {
+	ms := ack.GetAgentTimeUnixMilli()
+	if ms == 0 {
+		return
+	}
+
+	// The wall clocks are compared on purpose: the monotonic reading of received must not be used.
+	skew := time.UnixMilli(ms).Sub(received.Round(0))
+	skewed := ClockSkewed(skew)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if skewed == w.skewed {
+		return
+	}
+	w.skewed = skewed
+
+	if skewed {
+		log.Warningf(ctx, "Server: the clock of this distro is %s: certificates and tokens may be rejected. Run `sudo hwclock -s` to resync it", DescribeClockSkew(skew))
+	} else {
+		log.Infof(ctx, "Server: the clock of this distro is back in sync with Windows (%s)", DescribeClockSkew(skew))
+	}
+
+	if w.handler != nil {
+		w.handler(ctx, skew)
+	}
+}
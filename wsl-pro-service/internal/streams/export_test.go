@@ -2,6 +2,9 @@ package streams
 
 import (
 	"context"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/watchdog"
 
 	"google.golang.org/grpc"
 )
@@ -11,7 +14,18 @@ import (
 // It only provides communication primitives, it does not handle the logic of the messages themselves.
 type MultiClient = multiClient
 
-// connect connects to the three streams. Call Close to release resources.
+// connect connects to all the streams. Call Close to release resources.
 func Connect(ctx context.Context, conn *grpc.ClientConn) (c *MultiClient, err error) {
 	return connect(ctx, conn)
 }
+
+// SetStallTimeout replaces the watchdog of the server with one that considers the goroutines serving the
+// streams stuck after timeout.
+func (s *Server) SetStallTimeout(timeout time.Duration) {
+	s.watchdog = watchdog.New(timeout)
+}
+
+// Stall makes the watchdog of the server find a goroutine serving the streams stuck.
+func (s *Server) Stall() {
+	s.watchdog.Heart("stuck goroutine").Start()
+}
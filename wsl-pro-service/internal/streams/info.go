@@ -0,0 +1,92 @@
+package streams
+
+import (
+	"fmt"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// infoHandler receives the settings the agent sends in answer to every DistroInfo, and sends a new
+// DistroInfo as often as the agent requests, and right away when the agent configuration for the distro
+// changes so that the agent can tell whether the distro already complies with it.
+type infoHandler struct{}
+
+// newInfoHandler creates a handler for the acknowledgements of the DistroInfo messages.
+func newInfoHandler() handler {
+	return &infoHandler{}
+}
+
+func (h *infoHandler) run(s *Server, client *multiClient) error {
+	ctx := s.ctx
+
+	// Every acknowledgement is stamped by the agent, so the clock skew is measured again as it is received.
+	recv := func() (*agentapi.HandshakeAck, error) {
+		ack, err := client.RecvAck()
+		if err == nil {
+			s.clock.check(ctx, ack, time.Now())
+		}
+		return ack, err
+	}
+	acks := receiveAll(ctx, recv, s.watchdog)
+	dispatcher := s.watchdog.Heart("DistroInfo dispatcher")
+
+	log.Debug(ctx, "Started serving DistroInfo acknowledgements")
+
+	var refresh <-chan time.Time
+	var interval time.Duration
+	var configHash string
+
+	for {
+		select {
+		case <-s.gracefulCtx.Done():
+			log.Debug(ctx, "Stopping serving DistroInfo acknowledgements")
+			return stopGracefully(ctx, acks)
+		case in, ok := <-acks:
+			if over, err := endOfStream(ctx, in, ok); over {
+				return err
+			}
+
+			settings := in.msg.GetSettings()
+			changed := configHash != "" && settings.GetConfigHash() != configHash
+			configHash = settings.GetConfigHash()
+
+			if d := time.Duration(settings.GetRefreshIntervalSeconds()) * time.Second; d != interval {
+				log.Debugf(ctx, "Server: the agent requested a DistroInfo every %s", d)
+				interval = d
+				refresh = nil
+				if interval > 0 {
+					refresh = time.After(interval)
+				}
+			}
+
+			if !changed {
+				continue
+			}
+			log.Debugf(ctx, "Server: the agent configuration for this distro changed (%d tasks pending)", settings.GetPendingTasks())
+		case <-refresh:
+			refresh = time.After(interval)
+		}
+
+		if err := watched(dispatcher, func() error { return h.sendInfo(s, client) }); err != nil {
+			return err
+		}
+	}
+}
+
+// sendInfo sends up-to-date info to the agent. Failing to gather it is not fatal, as the agent still has
+// the previous one, but failing to send it means that the connection is broken.
+func (h *infoHandler) sendInfo(s *Server, client *multiClient) error {
+	info, err := s.system.Info(s.ctx)
+	if err != nil {
+		log.Warningf(s.ctx, "Server: could not gather info to refresh the agent: %v", err)
+		return nil
+	}
+
+	if err := client.SendInfo(info); err != nil {
+		return fmt.Errorf("could not refresh the agent with new info: %v", err)
+	}
+
+	return nil
+}
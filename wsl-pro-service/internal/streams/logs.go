@@ -0,0 +1,135 @@
+package streams
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// logsHandler serves the TailLog stream. Unlike the other handlers, it serves several requests at once:
+// the journal lines of each request are sent as they are read, until it completes or is cancelled.
+type logsHandler struct {
+	stream agentapi.WSLInstance_TailLogClient
+	tail   func(ctx context.Context, cmd *agentapi.TailLogCmd, send func(line string) error) error
+
+	// sendMu serializes sending, as the lines of every request are sent concurrently.
+	sendMu sync.Mutex
+
+	// client is the client the stream belongs to, set once the handler runs.
+	client *multiClient
+}
+
+// newLogsHandler creates a handler for the TailLog stream, which tails the journal with the callback.
+func newLogsHandler(stream agentapi.WSLInstance_TailLogClient, tail func(context.Context, *agentapi.TailLogCmd, func(string) error) error) handler {
+	return &logsHandler{
+		stream: stream,
+		tail:   tail,
+	}
+}
+
+func (h *logsHandler) run(s *Server, client *multiClient) error {
+	ctx := h.stream.Context()
+	requests := receiveAll(ctx, h.stream.Recv, s.watchdog)
+	h.client = client
+
+	// inProgress are the cancel functions of the requests in progress, by id.
+	inProgress := make(map[uint32]context.CancelFunc)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	defer func() {
+		mu.Lock()
+		for _, cancel := range inProgress {
+			cancel()
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+
+	log.Debug(ctx, "Started serving TailLog requests")
+
+	for {
+		var in received[agentapi.TailLogCmd]
+		var ok bool
+		select {
+		case <-s.gracefulCtx.Done():
+			// Followed requests would never finish on their own, so they are cancelled rather than waited for.
+			log.Debug(ctx, "Stopping serving TailLog requests")
+			return stopGracefully(ctx, requests)
+		case in, ok = <-requests:
+		}
+
+		if over, err := endOfStream(ctx, in, ok); over {
+			return err
+		}
+
+		cmd := in.msg
+		id := cmd.GetId()
+
+		mu.Lock()
+		cancel, duplicate := inProgress[id]
+		if cmd.GetCancel() {
+			if duplicate {
+				log.Debugf(ctx, "Cancelling TailLog request %d", id)
+				cancel()
+			}
+			mu.Unlock()
+			continue
+		}
+		if duplicate {
+			mu.Unlock()
+			h.sendDone(ctx, id, fmt.Errorf("request %d is already in progress", id))
+			continue
+		}
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		inProgress[id] = cancel
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer crashreport.Recover("streams")
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(inProgress, id)
+				mu.Unlock()
+				cancel()
+			}()
+
+			err := h.tail(reqCtx, cmd, func(line string) error {
+				return h.send(&agentapi.LogMessage{Id: id, Line: line})
+			})
+
+			// Requests that were cancelled are done without error.
+			if reqCtx.Err() != nil {
+				err = nil
+			}
+			h.sendDone(ctx, id, err)
+		}()
+	}
+}
+
+// sendDone tells the agent that no more lines will be sent for the request, and why, if it failed.
+func (h *logsHandler) sendDone(ctx context.Context, id uint32, err error) {
+	msg := &agentapi.LogMessage{Id: id, Done: true}
+	if err != nil {
+		msg.Error = err.Error()
+	}
+
+	if err := h.send(msg); err != nil {
+		// The broken stream is reported by the receiving loop.
+		log.Warningf(ctx, "Could not finish TailLog request %d: %v", id, err)
+	}
+}
+
+func (h *logsHandler) send(msg *agentapi.LogMessage) error {
+	h.sendMu.Lock()
+	defer h.sendMu.Unlock()
+
+	return h.client.write(func() error { return h.stream.Send(msg) })
+}
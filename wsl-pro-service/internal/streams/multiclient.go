@@ -2,26 +2,63 @@ package streams
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/grpc/compression"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/watchdog"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // multiClient represents a connected multiClient to the Windows Agent.
 // It abstracts away the multiple streams into a single object.
 // It only provides communication primitives, it does not handle the logic of the messages themselves.
 type multiClient struct {
-	mainStream agentapi.WSLInstance_ConnectedClient
+	api agentapi.WSLInstanceClient
+
+	mainStream agentapi.WSLInstance_SessionClient
 	proStream  agentapi.WSLInstance_ProAttachmentCommandsClient
 	lpeStream  agentapi.WSLInstance_LandscapeConfigCommandsClient
+
+	// cmdStream is opened after the handshake, see ConnectCommands.
+	cmdStream agentapi.WSLInstance_CommandsClient
+
+	// logStream is only opened once CAPABILITY_LOGS is negotiated, see ConnectLogs.
+	logStream agentapi.WSLInstance_TailLogClient
+
+	// pingStream is only opened once CAPABILITY_PING is negotiated, see ConnectPing.
+	pingStream agentapi.WSLInstance_PingClient
+
+	// compressed is whether the streams opened after the handshake compress their large messages,
+	// as negotiated with CAPABILITY_COMPRESSION.
+	compressed bool
+
+	// progress is whether the progress of long commands is sent ahead of their result, as negotiated with
+	// CAPABILITY_PROGRESS. It is atomic because some streams are handled before the handshake.
+	progress atomic.Bool
+
+	// mainSendMu serializes sending DistroInfo, as it is sent after every command and periodically.
+	mainSendMu sync.Mutex
+
+	// writer is the heart of the goroutines sending through the streams, see write.
+	writer *watchdog.Heart
 }
 
-// connect connects to the three streams. Call Close to release resources.
+// connect connects to all the streams. Call Close to release resources.
 func connect(ctx context.Context, conn *grpc.ClientConn) (c *multiClient, err error) {
 	client := agentapi.NewWSLInstanceClient(conn)
 
-	mainStream, err := client.Connected(ctx)
+	mainStream, err := client.Session(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not connect to GRPC service: %v", err)
 	}
@@ -40,6 +77,7 @@ func connect(ctx context.Context, conn *grpc.ClientConn) (c *multiClient, err er
 	defer closeOnError(&err, lpeStream)
 
 	return &multiClient{
+		api:        client,
 		mainStream: mainStream,
 		proStream:  proStream,
 		lpeStream:  lpeStream,
@@ -52,15 +90,149 @@ func closeOnError(err *error, closer interface{ CloseSend() error }) {
 	}
 }
 
-// SendInfo sends the distro info via the connected stream.
+// capabilities are the features this WSL Pro service offers to the agent in the handshake.
+var capabilities = []agentapi.Capability{
+	agentapi.Capability_CAPABILITY_LOGS,
+	agentapi.Capability_CAPABILITY_INFO_ACK,
+	agentapi.Capability_CAPABILITY_PING,
+	agentapi.Capability_CAPABILITY_COMPRESSION,
+	agentapi.Capability_CAPABILITY_PROGRESS,
+}
+
+// errLegacyAgent is the reason the handshake fails with agents that predate it.
+var errLegacyAgent = errors.New("the agent predates the handshake and is too old for this WSL Pro service: upgrade Ubuntu Pro for WSL")
+
+// Handshake opens the session stream, and returns the acknowledgement of the agent, with the capabilities
+// supported by both ends. It must be called before SendInfo and before opening the other streams.
+func (s *multiClient) Handshake(wslName string) (ack *agentapi.HandshakeAck, err error) {
+	err = s.write(func() error {
+		return s.mainStream.Send(&agentapi.DistroMessage{
+			Data: &agentapi.DistroMessage_Handshake{
+				Handshake: &agentapi.Handshake{
+					ProtocolVersion: common.WSLInstanceProtocolVersion,
+					WslName:         wslName,
+					Capabilities:    capabilities,
+					ServiceVersion:  consts.Version,
+				},
+			},
+		})
+	})
+	// The stream is closed if the agent already rejected it, which is only told by Recv.
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not send handshake: %v", err)
+	}
+
+	ack, err = s.mainStream.Recv()
+	if status.Code(err) == codes.Unimplemented {
+		return nil, fmt.Errorf("handshake rejected: %v", errLegacyAgent)
+	} else if err != nil {
+		return nil, fmt.Errorf("handshake rejected: %v", err)
+	}
+
+	if v := ack.GetProtocolVersion(); v != common.WSLInstanceProtocolVersion {
+		return nil, fmt.Errorf("handshake rejected: the agent speaks protocol version %d, but this service speaks version %d", v, common.WSLInstanceProtocolVersion)
+	}
+
+	s.compressed = slices.Contains(ack.GetCapabilities(), agentapi.Capability_CAPABILITY_COMPRESSION)
+	s.progress.Store(slices.Contains(ack.GetCapabilities(), agentapi.Capability_CAPABILITY_PROGRESS))
+
+	return ack, nil
+}
+
+// SendInfo sends the distro info via the session stream.
 func (s *multiClient) SendInfo(info *agentapi.DistroInfo) error {
-	return s.mainStream.Send(info)
+	s.mainSendMu.Lock()
+	defer s.mainSendMu.Unlock()
+
+	return s.write(func() error {
+		return s.mainStream.Send(&agentapi.DistroMessage{
+			Data: &agentapi.DistroMessage_Info{
+				Info: info,
+			},
+		})
+	})
+}
+
+// write sends a message through a stream with send. Sending blocks while the agent does not read the
+// stream, so it is watched by the writer heart: a send that never completes means the connection is a zombie.
+func (s *multiClient) write(send func() error) error {
+	return watched(s.writer, send)
+}
+
+// watched runs f as some work of the heart.
+func watched(h *watchdog.Heart, f func() error) error {
+	h.Start()
+	defer h.Done()
+
+	return f()
+}
+
+// RecvAck receives the acknowledgement of a DistroInfo sent via the session stream. It must only be
+// called after a handshake that negotiated CAPABILITY_INFO_ACK.
+func (s *multiClient) RecvAck() (*agentapi.HandshakeAck, error) {
+	return s.mainStream.Recv()
+}
+
+// callOptions are the options of the streams opened after the handshake.
+func (s *multiClient) callOptions() []grpc.CallOption {
+	if !s.compressed {
+		return nil
+	}
+	return []grpc.CallOption{grpc.UseCompressor(compression.Name)}
+}
+
+// ConnectCommands opens the generic Command stream. Unlike the other streams, which agents of any version
+// serve, it is opened after the handshake so that it can compress the large outputs of the commands.
+func (s *multiClient) ConnectCommands(ctx context.Context) error {
+	cmdStream, err := s.api.Commands(ctx, s.callOptions()...)
+	if err != nil {
+		return fmt.Errorf("could not connect to commands stream: %v", err)
+	}
+
+	s.cmdStream = cmdStream
+	return nil
+}
+
+// ConnectLogs opens the TailLog stream. It must only be called after a handshake that negotiated CAPABILITY_LOGS,
+// as older agents do not serve it.
+func (s *multiClient) ConnectLogs(ctx context.Context) error {
+	logStream, err := s.api.TailLog(ctx, s.callOptions()...)
+	if err != nil {
+		return fmt.Errorf("could not connect to TailLog stream: %v", err)
+	}
+
+	s.logStream = logStream
+	return nil
+}
+
+// LogStream is a getter for the TailLog stream. It is nil unless ConnectLogs succeeded.
+func (s *multiClient) LogStream() agentapi.WSLInstance_TailLogClient {
+	return s.logStream
+}
+
+// ConnectPing opens the Ping stream. It must only be called after a handshake that negotiated CAPABILITY_PING,
+// as older agents do not serve it.
+func (s *multiClient) ConnectPing(ctx context.Context) error {
+	pingStream, err := s.api.Ping(ctx, s.callOptions()...)
+	if err != nil {
+		return fmt.Errorf("could not connect to Ping stream: %v", err)
+	}
+
+	s.pingStream = pingStream
+	return nil
+}
+
+// PingStream is a getter for the Ping stream. It is nil unless ConnectPing succeeded.
+func (s *multiClient) PingStream() agentapi.WSLInstance_PingClient {
+	return s.pingStream
 }
 
 // ProAttachStream is a getter for the ProAttachmentCmd stream.
 func (s *multiClient) ProAttachStream() stream[agentapi.ProAttachCmd] {
 	return stream[agentapi.ProAttachCmd]{
 		grpcStream: s.proStream,
+		writer:     s.writer,
+		progress:   &s.progress,
 	}
 }
 
@@ -68,6 +240,17 @@ func (s *multiClient) ProAttachStream() stream[agentapi.ProAttachCmd] {
 func (s *multiClient) LandscapeConfigStream() stream[agentapi.LandscapeConfigCmd] {
 	return stream[agentapi.LandscapeConfigCmd]{
 		grpcStream: s.lpeStream,
+		writer:     s.writer,
+		progress:   &s.progress,
+	}
+}
+
+// CommandStream is a getter for the generic Command stream. It must only be used once ConnectCommands succeeded.
+func (s *multiClient) CommandStream() stream[agentapi.Command] {
+	return stream[agentapi.Command]{
+		grpcStream: s.cmdStream,
+		writer:     s.writer,
+		progress:   &s.progress,
 	}
 }
 
@@ -80,25 +263,78 @@ type grpcStream[Command any] interface {
 // stream provides a restricted interface for sending and receiving messages.
 type stream[Command any] struct {
 	grpcStream[Command]
+	writer *watchdog.Heart
+
+	// progress is whether SendProgress sends anything, see multiClient.progress.
+	progress *atomic.Bool
 }
 
 func (s stream[Command]) SendResult(err error) error {
+	return s.SendResultWithOutput(nil, err)
+}
+
+// maxOutputChunkSize is the largest command output sent in a single message, well below the
+// 4MiB that gRPC accepts by default.
+const maxOutputChunkSize = 1 << 20
+
+// SendResultWithOutput sends the result of a command alongside its command-specific output.
+// Large outputs are split across several messages, only the last of which carries the result.
+// Commands that stopped with system.ErrPreempted are reported as preempted.
+func (s stream[Command]) SendResultWithOutput(output []byte, err error) error {
+	for len(output) > maxOutputChunkSize {
+		chunk := output[:maxOutputChunkSize]
+		if err := watched(s.writer, func() error {
+			return s.grpcStream.Send(&agentapi.MSG{
+				Output: chunk,
+				More:   true,
+			})
+		}); err != nil {
+			return err
+		}
+		output = output[maxOutputChunkSize:]
+	}
+
 	var errMsg string
 	if err != nil {
 		errMsg = err.Error()
 	}
 
-	return s.grpcStream.Send(&agentapi.MSG{
-		Data: &agentapi.MSG_Result{
-			Result: errMsg,
-		},
+	return watched(s.writer, func() error {
+		return s.grpcStream.Send(&agentapi.MSG{
+			Data: &agentapi.MSG_Result{
+				Result: errMsg,
+			},
+			Output:    output,
+			Preempted: errors.Is(err, system.ErrPreempted),
+		})
+	})
+}
+
+// SendProgress tells the agent how far the command in progress is, ahead of its result. It does nothing
+// unless CAPABILITY_PROGRESS was negotiated, as other agents take any message for the result.
+func (s stream[Command]) SendProgress(percent uint32, step string) error {
+	if s.progress == nil || !s.progress.Load() {
+		return nil
+	}
+
+	return watched(s.writer, func() error {
+		return s.grpcStream.Send(&agentapi.MSG{
+			Data: &agentapi.MSG_Progress{
+				Progress: &agentapi.TaskProgress{
+					Percent: percent,
+					Step:    step,
+				},
+			},
+		})
 	})
 }
 
 func (s stream[Command]) SendWslName(wslName string) error {
-	return s.grpcStream.Send(&agentapi.MSG{
-		Data: &agentapi.MSG_WslName{
-			WslName: wslName,
-		},
+	return watched(s.writer, func() error {
+		return s.grpcStream.Send(&agentapi.MSG{
+			Data: &agentapi.MSG_WslName{
+				WslName: wslName,
+			},
+		})
 	})
 }
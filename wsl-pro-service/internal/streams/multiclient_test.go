@@ -1,6 +1,7 @@
 package streams_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"net"
@@ -69,7 +70,7 @@ func TestConnect(t *testing.T) {
 
 			// Connection is immediate but updating the counts is not: hence the waits
 			require.Eventually(t, func() bool { return service.connected.callCount.Load() >= 1 },
-				5*time.Second, 100*time.Millisecond, "Should have connected to the Connected stream")
+				5*time.Second, 100*time.Millisecond, "Should have connected to the Session stream")
 
 			require.Eventually(t, func() bool { return service.proattachment.callCount.Load() >= 1 },
 				5*time.Second, 100*time.Millisecond, "Should have connected to the Pro attachment stream")
@@ -79,6 +80,17 @@ func TestConnect(t *testing.T) {
 
 			require.NotNil(t, client.ProAttachStream(), "ProAttachStream should not return nil")
 			require.NotNil(t, client.LandscapeConfigStream(), "LandscapeConfigStream should not return nil")
+
+			// The commands stream is only opened after the handshake.
+			_, err = client.Handshake("TestDistro")
+			require.NoError(t, err, "Handshake should not return an error")
+			err = client.ConnectCommands(ctx)
+			require.NoError(t, err, "ConnectCommands should not return an error")
+
+			require.Eventually(t, func() bool { return service.commands.callCount.Load() >= 1 },
+				5*time.Second, 100*time.Millisecond, "Should have connected to the commands stream")
+
+			require.NotNil(t, client.CommandStream(), "CommandStream should not return nil")
 		})
 	}
 }
@@ -113,11 +125,25 @@ func TestSendAndRecv(t *testing.T) {
 	client, err := streams.Connect(ctx, conn)
 	require.NoError(t, err, "Setup: Connect should not return an error")
 
+	ack, err := client.Handshake("TestDistro")
+	require.NoError(t, err, "Handshake should not return error")
+	require.Equal(t, []agentapi.Capability{
+		agentapi.Capability_CAPABILITY_LOGS,
+		agentapi.Capability_CAPABILITY_INFO_ACK,
+		agentapi.Capability_CAPABILITY_PING,
+		agentapi.Capability_CAPABILITY_COMPRESSION,
+		agentapi.Capability_CAPABILITY_PROGRESS,
+	}, ack.GetCapabilities(), "Handshake should return the capabilities acknowledged by the agent")
+
+	err = client.ConnectCommands(ctx)
+	require.NoError(t, err, "Setup: ConnectCommands should not return an error")
+
 	require.Eventually(t, func() bool {
 		connReady := service.connected.callCount.Load() > 0
 		proReady := service.proattachment.callCount.Load() > 0
 		lpeReady := service.landscapeConfig.callCount.Load() > 0
-		return connReady && proReady && lpeReady
+		cmdReady := service.commands.callCount.Load() > 0
+		return connReady && proReady && lpeReady && cmdReady
 	}, 10*time.Second, 100*time.Millisecond, "Setup: streams never connected")
 
 	// Test sending messages Server->Client
@@ -135,6 +161,13 @@ func TestSendAndRecv(t *testing.T) {
 	require.NoError(t, err, "LandscapeConfigStream.Recv should not return error")
 	require.Equal(t, "[client]\nhello=world", lpeMsg.GetConfig(), "Mismatch between sent and received Landscape config")
 
+	err = service.SendCommand(&agentapi.Command{Cmd: &agentapi.Command_ProService{ProService: &agentapi.ProServiceCmd{Service: "esm-apps"}}})
+	require.NoError(t, err, "Sending commands should not fail")
+
+	cmdMsg, err := client.CommandStream().Recv()
+	require.NoError(t, err, "CommandStream.Recv should not return error")
+	require.Equal(t, "esm-apps", cmdMsg.GetProService().GetService(), "Mismatch between sent and received command")
+
 	// Test sending messages Client->Server
 	err = client.SendInfo(&agentapi.DistroInfo{})
 	require.NoError(t, err, "SendInfo should not return error")
@@ -151,6 +184,19 @@ func TestSendAndRecv(t *testing.T) {
 	require.Eventually(t, func() bool { return service.landscapeConfig.recvCount.Load() >= 1 },
 		5*time.Second, 100*time.Millisecond, "The server should have received a result message via the Landscape stream")
 
+	err = client.CommandStream().SendResult(nil)
+	require.NoError(t, err, "CommandStream.SendResult should not return error")
+	require.Eventually(t, func() bool { return service.commands.recvCount.Load() >= 1 },
+		5*time.Second, 100*time.Millisecond, "The server should have received a result message via the commands stream")
+
+	// The commands stream compresses large outputs, as negotiated in the handshake.
+	output := bytes.Repeat([]byte("a large and repetitive command output\n"), 1<<14)
+	err = client.CommandStream().SendResultWithOutput(output, nil)
+	require.NoError(t, err, "CommandStream.SendResultWithOutput should not return error")
+	require.Eventually(t, func() bool { return service.commands.recvCount.Load() >= 2 },
+		5*time.Second, 100*time.Millisecond, "The server should have received a large output via the commands stream")
+	require.Equal(t, output, service.lastOutput.Load(), "Mismatch between sent and received command output")
+
 	// Disconnect to exercise error cases
 	conn.Close()
 
@@ -158,6 +204,9 @@ func TestSendAndRecv(t *testing.T) {
 	require.Error(t, err, "Connect should return an error when using a closed connection")
 
 	// Test sending messages after disconnecting
+	_, err = client.Handshake("TestDistro")
+	require.Error(t, err, "Handshake should return an error after disconnecting")
+
 	err = client.SendInfo(&agentapi.DistroInfo{})
 	require.Error(t, err, "SendInfo should return an error after disconnecting")
 
@@ -167,6 +216,9 @@ func TestSendAndRecv(t *testing.T) {
 	err = client.LandscapeConfigStream().SendResult(nil)
 	require.Error(t, err, "LandscapeConfigStream.SendResult should return an error after disconnecting")
 
+	err = client.CommandStream().SendResult(nil)
+	require.Error(t, err, "CommandStream.SendResult should return an error after disconnecting")
+
 	// Test receiving messages after disconnecting
 	_, err = client.ProAttachStream().Recv()
 	require.Error(t, err, "ProAttachStream.Recv should return an error after disconnecting")
@@ -175,12 +227,55 @@ func TestSendAndRecv(t *testing.T) {
 	require.Error(t, err, "SendResult.Recv should return an error after disconnecting")
 }
 
+func TestHandshakeWithLegacyAgent(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lc net.ListenConfig
+	lis, err := lc.Listen(ctx, "tcp", "localhost:0")
+	require.NoError(t, err, "Setup: could not listen")
+	defer lis.Close()
+
+	// Agents that predate the handshake do not serve the Session stream.
+	s := grpc.NewServer()
+	agentapi.RegisterWSLInstanceServer(s, &legacyAgentAPIServer{})
+	go func() {
+		err := s.Serve(lis)
+		if err != nil {
+			log.Warningf(ctx, "Serve error: %v", err)
+		}
+	}()
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err, "Setup: Creating a client should have succeeded")
+	defer conn.Close()
+
+	client, err := streams.Connect(ctx, conn)
+	require.NoError(t, err, "Setup: Connect should not return an error")
+
+	_, err = client.Handshake("TestDistro")
+	require.ErrorContains(t, err, "agent predates the handshake", "Handshake should tell the agent is too old")
+}
+
+type legacyAgentAPIServer struct {
+	agentapi.UnimplementedWSLInstanceServer
+}
+
 type agentAPIServer struct {
 	agentapi.UnimplementedWSLInstanceServer
 
 	connected       stream
 	proattachment   stream
 	landscapeConfig stream
+	commands        stream
+
+	// lastOutput is the output of the last result received via the commands stream.
+	lastOutput atomic.Value
 }
 
 type stream struct {
@@ -189,17 +284,24 @@ type stream struct {
 	stream    atomic.Value
 }
 
-func (s *agentAPIServer) Connected(stream agentapi.WSLInstance_ConnectedServer) error {
+func (s *agentAPIServer) Session(stream agentapi.WSLInstance_SessionServer) error {
 	s.connected.callCount.Add(1)
 	s.connected.stream.Store(stream)
 
 	for {
-		_, err := stream.Recv()
+		msg, err := stream.Recv()
 		if err != nil {
 			return nil
 		}
 
 		s.connected.recvCount.Add(1)
+
+		if h := msg.GetHandshake(); h != nil {
+			err := stream.Send(&agentapi.HandshakeAck{ProtocolVersion: h.GetProtocolVersion(), Capabilities: h.GetCapabilities()})
+			if err != nil {
+				return nil
+			}
+		}
 	}
 }
 
@@ -252,3 +354,28 @@ func (s *agentAPIServer) SendLandscapeConfig(config string) error {
 		Config: config,
 	})
 }
+
+func (s *agentAPIServer) Commands(stream agentapi.WSLInstance_CommandsServer) error {
+	s.commands.callCount.Add(1)
+	s.commands.stream.Store(stream)
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		s.lastOutput.Store(msg.GetOutput())
+		s.commands.recvCount.Add(1)
+	}
+}
+
+func (s *agentAPIServer) SendCommand(cmd *agentapi.Command) error {
+	stream := s.commands.stream.Load()
+	if stream == nil {
+		return errors.New("stream not connected")
+	}
+
+	//nolint:forcetypeassert // This value is always this type (or nil, which we checked already)
+	return stream.(agentapi.WSLInstance_CommandsServer).Send(cmd)
+}
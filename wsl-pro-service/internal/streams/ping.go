@@ -0,0 +1,47 @@
+package streams
+
+import (
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+)
+
+// pingHandler serves the Ping stream, echoing every ping right away so that the round-trip time the agent
+// measures does not depend on the commands in progress.
+type pingHandler struct {
+	stream agentapi.WSLInstance_PingClient
+}
+
+// newPingHandler creates a handler for the Ping stream.
+func newPingHandler(stream agentapi.WSLInstance_PingClient) handler {
+	return &pingHandler{stream: stream}
+}
+
+func (h *pingHandler) run(s *Server, client *multiClient) error {
+	ctx := h.stream.Context()
+	pings := receiveAll(ctx, h.stream.Recv, s.watchdog)
+
+	log.Debug(ctx, "Started serving pings")
+
+	for {
+		var in received[agentapi.PingCmd]
+		var ok bool
+		select {
+		case <-s.gracefulCtx.Done():
+			log.Debug(ctx, "Stopping serving pings")
+			return stopGracefully(ctx, pings)
+		case in, ok = <-pings:
+		}
+
+		if over, err := endOfStream(ctx, in, ok); over {
+			return err
+		}
+
+		if err := client.write(func() error {
+			return h.stream.Send(&agentapi.PingReply{Id: in.msg.GetId(), Payload: in.msg.GetPayload()})
+		}); err != nil {
+			return fmt.Errorf("could not reply to ping: %v", err)
+		}
+	}
+}
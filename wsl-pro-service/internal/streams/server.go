@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"slices"
 	"sync"
+	"time"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/crashreport"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/watchdog"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
 	"google.golang.org/grpc"
 )
@@ -19,8 +23,14 @@ import (
 type CommandService interface {
 	ApplyProToken(ctx context.Context, msg *agentapi.ProAttachCmd) error
 	ApplyLandscapeConfig(ctx context.Context, msg *agentapi.LandscapeConfigCmd) error
+	ApplyCommand(ctx context.Context, msg *agentapi.Command) (output []byte, err error)
+	TailLog(ctx context.Context, msg *agentapi.TailLogCmd, send func(line string) error) error
 }
 
+// stallTimeout is how long the goroutines serving the streams can be busy without making progress before the
+// connection is considered a zombie and torn down. It is generous, as gathering the distro info can take a while.
+const stallTimeout = 5 * time.Minute
+
 // Server is a struct that mimics a unary call server. It is backed by a bi-directional gRPC stream.
 //
 // It is used to make unary calls from the real gRPC server (Windows Agent) to the real client (this faux server).
@@ -30,6 +40,13 @@ type Server struct {
 
 	done chan struct{}
 
+	// watchdog detects the goroutines serving the streams that get stuck, so that the connection is torn down
+	// instead of lingering without serving anything.
+	watchdog *watchdog.Watchdog
+
+	// clock warns when the clocks of Windows and the distro drift apart.
+	clock clockWatch
+
 	// This context will be the parent of the streams's context
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -61,7 +78,7 @@ func (err SystemError) Is(e error) bool {
 }
 
 // NewServer creates a new Server.
-func NewServer(ctx context.Context, sys *system.System, conn *grpc.ClientConn) *Server {
+func NewServer(ctx context.Context, sys *system.System, conn *grpc.ClientConn, opts ...ServerOption) *Server {
 	fCtx, cancel := context.WithCancel(ctx)
 	gCtx, gCancel := context.WithCancel(ctx)
 
@@ -70,6 +87,8 @@ func NewServer(ctx context.Context, sys *system.System, conn *grpc.ClientConn) *
 		system: sys,
 		done:   make(chan struct{}),
 
+		watchdog: watchdog.New(stallTimeout),
+
 		// the stream context will be a child of forcequit context and will thus be cancelled with it.
 		ctx:    fCtx,
 		cancel: cancel,
@@ -78,6 +97,10 @@ func NewServer(ctx context.Context, sys *system.System, conn *grpc.ClientConn) *
 		gracefulCancel: gCancel,
 	}
 
+	for _, f := range opts {
+		f(s)
+	}
+
 	return s
 }
 
@@ -98,8 +121,9 @@ func (s *Server) GracefulStop() {
 }
 
 // Serve starts receiving commands from the control stream and forwards them to the provided service.
-// It blocks until stops serving.
-func (s *Server) Serve(service CommandService) error {
+// It blocks until stops serving. If any of the goroutines serving the streams gets stuck, the connection
+// is torn down and Serve returns an error wrapping a watchdog.StallError, so that it can be rebuilt.
+func (s *Server) Serve(service CommandService) (err error) {
 	defer s.cancel()
 	defer close(s.done)
 
@@ -107,16 +131,37 @@ func (s *Server) Serve(service CommandService) error {
 	if err != nil {
 		return fmt.Errorf("could not start serving: could not connect: %v", err)
 	}
+	client.writer = s.watchdog.Heart("stream writer")
+
+	stalled := make(chan error, 1)
+	go func() {
+		defer crashreport.Recover("streams")
+		err := s.watchdog.Run(s.ctx)
+		// The stall is reported before tearing down, which breaks the streams, so that it takes precedence.
+		stalled <- err
+		if err != nil {
+			s.tearDown(err)
+		}
+	}()
+
+	// Tearing down the connection breaks whatever was in progress: the stall is what is worth reporting.
+	defer func() {
+		select {
+		case stall := <-stalled:
+			if stall != nil {
+				err = fmt.Errorf("serve error: %w", stall)
+			}
+		default:
+		}
+	}()
 
 	ch := make(chan error)
 	var wg sync.WaitGroup
 
-	for _, h := range []handler{
-		newHandler(client.ProAttachStream(), service.ApplyProToken),
-		newHandler(client.LandscapeConfigStream(), service.ApplyLandscapeConfig),
-	} {
+	start := func(h handler) {
 		wg.Add(1)
 		go func() {
+			defer crashreport.Recover("streams")
 			defer wg.Done()
 			ch <- h.run(s, client)
 
@@ -125,14 +170,35 @@ func (s *Server) Serve(service CommandService) error {
 		}()
 	}
 
+	start(newHandler(client.ProAttachStream(), withoutOutput(service.ApplyProToken)))
+	start(newHandler(client.LandscapeConfigStream(), withoutOutput(service.ApplyLandscapeConfig)))
+
 	// Notify Agent that we are ready
 	info, err := s.system.Info(s.ctx)
 	if err != nil {
 		return NewSystemError("could not serve: %v", err)
 	}
 
+	ack, err := client.Handshake(info.GetWslName())
+	if err != nil {
+		return fmt.Errorf("could not serve: %v", err)
+	}
+	s.clock.check(s.ctx, ack, time.Now())
+
+	caps := ack.GetCapabilities()
+	log.Debugf(s.ctx, "Server: handshake completed with capabilities: %v", caps)
+
+	if err := client.ConnectCommands(s.ctx); err != nil {
+		return fmt.Errorf("could not serve: %v", err)
+	}
+	start(newPreemptibleHandler(client.CommandStream(), service.ApplyCommand, isPreemption, preempts))
+
+	if slices.Contains(caps, agentapi.Capability_CAPABILITY_INFO_ACK) {
+		start(newInfoHandler())
+	}
+
 	if err := client.SendInfo(info); err != nil {
-		return fmt.Errorf("could not serve: could not send first Connnected message: %v", err)
+		return fmt.Errorf("could not serve: could not send first DistroInfo message: %v", err)
 	}
 
 	if err := client.ProAttachStream().SendWslName(info.GetWslName()); err != nil {
@@ -143,6 +209,34 @@ func (s *Server) Serve(service CommandService) error {
 		return fmt.Errorf("could not serve: could not send first LandscapeConfigCmd message: %v", err)
 	}
 
+	if err := client.CommandStream().SendWslName(info.GetWslName()); err != nil {
+		return fmt.Errorf("could not serve: could not send first Command message: %v", err)
+	}
+
+	if slices.Contains(caps, agentapi.Capability_CAPABILITY_LOGS) {
+		if err := client.ConnectLogs(s.ctx); err != nil {
+			return fmt.Errorf("could not serve: %v", err)
+		}
+
+		if err := client.write(func() error { return client.LogStream().Send(&agentapi.LogMessage{WslName: info.GetWslName()}) }); err != nil {
+			return fmt.Errorf("could not serve: could not send first LogMessage message: %v", err)
+		}
+
+		start(newLogsHandler(client.LogStream(), service.TailLog))
+	}
+
+	if slices.Contains(caps, agentapi.Capability_CAPABILITY_PING) {
+		if err := client.ConnectPing(s.ctx); err != nil {
+			return fmt.Errorf("could not serve: %v", err)
+		}
+
+		if err := client.write(func() error { return client.PingStream().Send(&agentapi.PingReply{WslName: info.GetWslName()}) }); err != nil {
+			return fmt.Errorf("could not serve: could not send first PingReply message: %v", err)
+		}
+
+		start(newPingHandler(client.PingStream()))
+	}
+
 	log.Debug(s.ctx, "Server: sent preface messages to all streams")
 
 	go func() {
@@ -150,10 +244,26 @@ func (s *Server) Serve(service CommandService) error {
 		close(ch)
 	}()
 
-	err = nil
-	for msg := range ch {
-		err = errors.Join(err, msg)
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		for msg := range ch {
+			err = errors.Join(err, msg)
+		}
+		done <- err
+	}()
+
+	select {
+	case err = <-done:
+	case stall := <-stalled:
+		if stall != nil {
+			// Stuck goroutines may never return: they are abandoned along with the connection.
+			return fmt.Errorf("serve error: %w", stall)
+		}
+		// The server was stopped.
+		err = <-done
 	}
+
 	if err != nil {
 		return fmt.Errorf("serve error: %w", err)
 	}
@@ -161,6 +271,20 @@ func (s *Server) Serve(service CommandService) error {
 	return nil
 }
 
+// tearDown closes the connection after some of the goroutines serving the streams got stuck, logging the
+// diagnostics of the stall.
+func (s *Server) tearDown(stall error) {
+	var diag watchdog.StallError
+	if errors.As(stall, &diag) {
+		log.Errorf(s.ctx, "Server: tearing down the connection: %v. Goroutines:\n%s", diag, diag.Goroutines)
+	}
+
+	s.cancel()
+	if err := s.conn.Close(); err != nil {
+		log.Warningf(s.ctx, "Server: could not close the connection: %v", err)
+	}
+}
+
 // handler interface for type erasure: it allows for having all handlerImpl in the same slice.
 type handler interface {
 	run(s *Server, client *multiClient) error
@@ -168,89 +292,257 @@ type handler interface {
 
 // newHandler takes the ingredients for a handler and hides their type under the type-erased handler.
 // This is essentially a handler factory.
-func newHandler[Command any](stream stream[Command], callback func(context.Context, *Command) error) handler {
+func newHandler[Command any](stream stream[Command], callback func(context.Context, *Command) ([]byte, error)) handler {
 	return &handlingLoop[Command]{
 		stream:   stream,
 		callback: callback,
 	}
 }
 
+// newPreemptibleHandler is like newHandler, but the commands for which isPreemption returns true are not
+// passed to the callback: instead, they preempt the command in progress if preempts says they target it
+// (see system.WithPreemption). Other commands received while one is in progress are queued.
+func newPreemptibleHandler[Command any](stream stream[Command], callback func(context.Context, *Command) ([]byte, error), isPreemption func(*Command) bool, preempts func(preemption, cmd *Command) bool) handler {
+	return &handlingLoop[Command]{
+		stream:       stream,
+		callback:     callback,
+		isPreemption: isPreemption,
+		preempts:     preempts,
+	}
+}
+
+// withoutOutput adapts a callback that produces no output to the signature expected by newHandler.
+func withoutOutput[Command any](callback func(context.Context, *Command) error) func(context.Context, *Command) ([]byte, error) {
+	return func(ctx context.Context, msg *Command) ([]byte, error) {
+		return nil, callback(ctx, msg)
+	}
+}
+
+// isPreemption returns true for the commands that preempt the generic command in progress.
+func isPreemption(cmd *agentapi.Command) bool {
+	return cmd.GetPreempt() != nil
+}
+
+// preempts returns true if the preemption targets the command. Agents that do not identify their
+// commands send preemptions without id, which target any command in progress.
+func preempts(preemption, cmd *agentapi.Command) bool {
+	id := preemption.GetPreempt().GetId()
+	return id == 0 || id == cmd.GetId()
+}
+
 // handlingLoop implements the logic of the request handling loop.
 type handlingLoop[Command any] struct {
-	stream   stream[Command]
-	callback func(context.Context, *Command) error
+	stream       stream[Command]
+	callback     func(context.Context, *Command) ([]byte, error)
+	isPreemption func(*Command) bool
+	preempts     func(preemption, cmd *Command) bool
+
+	// pending are the commands received while another one was in progress, in order of arrival.
+	pending []*Command
+}
+
+// received is a message received from the stream, or the error that prevented receiving it.
+type received[Command any] struct {
+	msg *Command
+	err error
 }
 
 func (h *handlingLoop[Command]) run(s *Server, client *multiClient) error {
 	// We deliberately use the stream's context for logging, running the handler callback and acquiring system info.
 	ctx := h.stream.Context()
+
+	// A single receiver for the lifetime of the loop, so that messages received while
+	// a command is in progress (i.e. preemptions) are not lost.
+	messages := receiveAll(ctx, h.stream.Recv, s.watchdog)
+	dispatcher := s.watchdog.Heart(fmt.Sprintf("%s dispatcher", reflect.TypeFor[Command]()))
+
 	for {
 		// Graceful stop
 		select {
 		case <-s.gracefulCtx.Done():
 			log.Debugf(ctx, "Stopping serving %s requests", reflect.TypeFor[Command]())
-			return nil
+			return stopGracefully(ctx, messages)
 		default:
 		}
 
 		log.Debugf(ctx, "Started serving %s requests", reflect.TypeFor[Command]())
 
-		// Handle a single command responsive to the cancellation of s.gracefulCtx.
-		msg, ok, err := receiveWithContext(s.gracefulCtx, h.stream.Recv)
-		if err != nil {
-			return fmt.Errorf("could not receive ProAttachCmd: %w", err)
-		} else if !ok {
-			// Non-erroneous exit. Probably a graceful stop.
-			return nil
+		// Commands queued while another one was in progress go first.
+		var msg *Command
+		if len(h.pending) > 0 {
+			msg, h.pending = h.pending[0], h.pending[1:]
+		} else {
+			// Handle a single command responsive to the cancellation of s.gracefulCtx.
+			var in received[Command]
+			var ok bool
+			select {
+			case <-s.gracefulCtx.Done():
+				// Probably a graceful stop.
+				return stopGracefully(ctx, messages)
+			case in, ok = <-messages:
+			}
+
+			if over, err := endOfStream(ctx, in, ok); over {
+				return err
+			}
+			msg = in.msg
 		}
 
-		result := h.callback(ctx, msg)
+		if h.isPreemption != nil && h.isPreemption(msg) {
+			log.Debugf(ctx, "Ignoring preemption of %s: no command in progress", reflect.TypeFor[Command]())
+			continue
+		}
 
-		if err := h.stream.SendResult(result); err != nil {
-			return fmt.Errorf("could not send ProAttachCmd result: %w", err)
+		if err := h.process(ctx, s, client, msg, messages, dispatcher); err != nil {
+			return err
 		}
+	}
+}
 
-		// Send back updated info after command completion
-		info, err := s.system.Info(ctx)
-		if err != nil {
-			log.Warningf(ctx, "Streamserver: could not gather info after command completion: %v", err)
+// process runs the command and sends its result back, followed by the updated info. The dispatcher heart
+// is busy all along, except while the command runs, as commands have timeouts of their own.
+func (h *handlingLoop[Command]) process(ctx context.Context, s *Server, client *multiClient, msg *Command, messages <-chan received[Command], dispatcher *watchdog.Heart) error {
+	output, result, recvErr := h.handle(ctx, msg, messages)
+
+	dispatcher.Start()
+	defer dispatcher.Done()
+
+	if err := h.stream.SendResultWithOutput(output, result); err != nil {
+		return fmt.Errorf("could not send %s result: %w", reflect.TypeFor[Command](), err)
+	}
+
+	if recvErr != nil {
+		return recvErr
+	}
+
+	// Send back updated info after command completion
+	info, err := s.system.Info(ctx)
+	if err != nil {
+		log.Warningf(ctx, "Streamserver: could not gather info after command completion: %v", err)
+	}
+
+	if err = client.SendInfo(info); err != nil {
+		log.Warningf(ctx, "Streamserver: could not stream back info after command completion: %v", err)
+	}
+
+	return nil
+}
+
+// handle runs the callback on the message. It keeps listening to the stream in the meantime, so that
+// the stream is never left unread however long the command takes: the commands received are queued
+// and, for preemptible handlers, preemptions stop the command in progress. Any error receiving from
+// the stream is returned once the callback finishes. The progress the callback reports is sent ahead
+// of the result (see system.WithProgress).
+func (h *handlingLoop[Command]) handle(ctx context.Context, msg *Command, messages <-chan received[Command]) (output []byte, result error, recvErr error) {
+	preempt := func() {}
+	if h.isPreemption != nil {
+		ctx, preempt = system.WithPreemption(ctx)
+	}
+
+	ctx = system.WithProgress(ctx, func(percent uint32, step string) {
+		if err := h.stream.SendProgress(percent, step); err != nil {
+			log.Warningf(ctx, "Could not send the progress of %s: %v", reflect.TypeFor[Command](), err)
 		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer crashreport.Recover("streams")
+		defer close(done)
+		output, result = h.callback(ctx, msg)
+	}()
 
-		if err = client.SendInfo(info); err != nil {
-			log.Warningf(ctx, "Streamserver: could not stream back info after command completion: %v", err)
+	for {
+		select {
+		case <-done:
+			return output, result, recvErr
+		case in, ok := <-messages:
+			if !ok || errors.Is(in.err, io.EOF) {
+				// Stop listening, but let the callback finish.
+				messages = nil
+				continue
+			} else if in.err != nil {
+				recvErr = fmt.Errorf("could not receive %s: %w", reflect.TypeFor[Command](), in.err)
+				messages = nil
+				continue
+			}
+
+			if h.isPreemption == nil || !h.isPreemption(in.msg) {
+				log.Infof(ctx, "Queueing %s received while another one is in progress", reflect.TypeFor[Command]())
+				h.pending = append(h.pending, in.msg)
+				continue
+			}
+
+			if !h.preempts(in.msg, msg) {
+				log.Debugf(ctx, "Ignoring preemption of a %s no longer in progress", reflect.TypeFor[Command]())
+				continue
+			}
+
+			log.Infof(ctx, "Preempting %s in progress", reflect.TypeFor[Command]())
+			preempt()
 		}
 	}
 }
 
-// Receive with context calls the recv receiver asyncronously.
-// Returns (message, message error) if recv returned.
-// Returns (nil, context error) if the context was cancelled.
-func receiveWithContext[MessageT any](ctx context.Context, recv func() (*MessageT, error)) (*MessageT, bool, error) {
+// endOfStream returns true if what was received means that the stream is over, along with the error
+// that broke it, if any.
+func endOfStream[Command any](ctx context.Context, in received[Command], ok bool) (over bool, err error) {
+	if !ok && ctx.Err() != nil {
+		// The server was stopped without waiting for the commands in progress.
+		return true, fmt.Errorf("could not receive %s: %w", reflect.TypeFor[Command](), ctx.Err())
+	} else if !ok || errors.Is(in.err, io.EOF) {
+		return true, nil
+	} else if in.err != nil {
+		return true, fmt.Errorf("could not receive %s: %w", reflect.TypeFor[Command](), in.err)
+	}
+	return false, nil
+}
+
+// stopGracefully returns the error that broke the stream, if any, when the server is stopped gracefully.
+// Stopping the server breaks the stream and stops the other handlers gracefully at once, so the broken
+// stream takes precedence rather than being masked by whichever of the two was noticed first.
+func stopGracefully[Command any](ctx context.Context, messages <-chan received[Command]) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("could not receive %s: %w", reflect.TypeFor[Command](), ctx.Err())
+	}
+
 	select {
-	case <-ctx.Done():
-		return nil, false, ctx.Err()
+	case in, ok := <-messages:
+		_, err := endOfStream(ctx, in, ok)
+		return err
 	default:
+		return nil
 	}
+}
 
-	type retval struct {
-		t   *MessageT
-		err error
-	}
-	ch := make(chan retval)
+// receiveAll calls recv in a loop and forwards what it returns to the returned channel,
+// until recv returns an error or the context is cancelled. The handlers keep reading the channel
+// even while they run commands, so forwarding a message is watched by a reader heart of the
+// watchdog: waiting for the next message is not.
+func receiveAll[MessageT any](ctx context.Context, recv func() (*MessageT, error), w *watchdog.Watchdog) <-chan received[MessageT] {
+	ch := make(chan received[MessageT])
+	reader := w.Heart(fmt.Sprintf("%s reader", reflect.TypeFor[MessageT]()))
 
 	go func() {
+		defer crashreport.Recover("streams")
 		defer close(ch)
-		t, err := recv()
-		ch <- retval{t, err}
+		for {
+			msg, err := recv()
+
+			reader.Start()
+			var sent bool
+			select {
+			case <-ctx.Done():
+			case ch <- received[MessageT]{msg, err}:
+				sent = true
+			}
+			reader.Done()
+
+			if !sent || err != nil {
+				return
+			}
+		}
 	}()
 
-	select {
-	case <-ctx.Done():
-		return nil, false, nil
-	case msg := <-ch:
-		if errors.Is(msg.err, io.EOF) {
-			return nil, false, nil
-		}
-		return msg.t, true, msg.err
-	}
+	return ch
 }
@@ -1,14 +1,19 @@
 package streams_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/watchdog"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/streams"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/testutils"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
@@ -38,7 +43,9 @@ func TestServe(t *testing.T) {
 	}()
 
 	// Test handshake
-	require.Eventually(t, agent.Service.AllConnected, 20*time.Second, 500*time.Millisecond, "Setup: Agent service never became ready")
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Second)
+	defer waitCancel()
+	require.NoError(t, agent.WaitForConnection(waitCtx), "Setup: Agent service never became ready")
 
 	// Test receiving a pro token and returning success
 	err = agent.Service.ProAttachment.Send(&agentapi.ProAttachCmd{Token: "token345"})
@@ -76,6 +83,53 @@ func TestServe(t *testing.T) {
 	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the Pro attach command")
 	require.NotEmpty(t, agent.Service.LandscapeConfig.History()[2].GetResult(), "LandscapeConfig should return an error result")
 
+	// Test receiving a generic command and returning success
+	err = agent.Service.Command.Send(proServiceCmd("esm-apps"))
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Command.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the generic command")
+	require.Empty(t, agent.Service.Command.History()[1].GetResult(), "Commands should return a successful result")
+
+	// Test receiving a generic command and returning error
+	err = agent.Service.Command.Send(proServiceCmd("HARDCODED_FAILURE"))
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Command.History()) > 2
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the generic command")
+	require.NotEmpty(t, agent.Service.Command.History()[2].GetResult(), "Commands should return an error result")
+
+	// Test receiving a generic command that produces some output
+	err = agent.Service.Command.Send(&agentapi.Command{Cmd: &agentapi.Command_Usg{Usg: &agentapi.UsgCmd{Profile: "cis_level1_server"}}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Command.History()) > 3
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the generic command")
+	require.Empty(t, agent.Service.Command.History()[3].GetResult(), "Commands should return a successful result")
+	require.Equal(t, "<html>cis_level1_server</html>", string(agent.Service.Command.History()[3].GetOutput()), "Commands should return the command output")
+
+	// Test receiving a generic command that produces an output too large for a single message
+	err = agent.Service.Command.Send(&agentapi.Command{Cmd: &agentapi.Command_Usg{Usg: &agentapi.UsgCmd{Profile: "LARGE_REPORT"}}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Command.History()) > 6
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the generic command")
+
+	var output []byte
+	for i, msg := range agent.Service.Command.History()[4:7] {
+		last := i == 2
+		require.Equal(t, !last, msg.GetMore(), "Only the last message of the output should have no more output")
+		_, isResult := msg.GetData().(*agentapi.MSG_Result)
+		require.Equal(t, last, isResult, "Only the last message of the output should carry the result")
+		require.LessOrEqual(t, len(msg.GetOutput()), 1<<20, "Output chunks should not exceed 1MiB")
+		output = append(output, msg.GetOutput()...)
+	}
+	require.Equal(t, largeReport(), output, "Commands should return the whole command output across messages")
+
 	server.GracefulStop()
 	select {
 	case err := <-errCh:
@@ -108,7 +162,9 @@ func TestStop(t *testing.T) {
 		close(errCh)
 	}()
 
-	require.Eventually(t, agent.Service.AllConnected, 20*time.Second, 500*time.Millisecond, "Setup: Agent service never became ready")
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Second)
+	defer waitCancel()
+	require.NoError(t, agent.WaitForConnection(waitCtx), "Setup: Agent service never became ready")
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -121,8 +177,9 @@ func TestStop(t *testing.T) {
 	err = agent.Service.LandscapeConfig.Send(&agentapi.LandscapeConfigCmd{})
 	require.NoError(t, err, "mock agent could not send a landscape-config command")
 
-	// Wait for unary calls to be made
-	time.Sleep(10 * time.Second)
+	require.Eventually(t, func() bool {
+		return service.inProgress.Load() == 2
+	}, 20*time.Second, 100*time.Millisecond, "Setup: the unary calls never started")
 
 	server.Stop()
 	select {
@@ -133,11 +190,492 @@ func TestStop(t *testing.T) {
 	}
 }
 
+func TestWatchdog(t *testing.T) {
+	t.Parallel()
+
+	const stallTimeout = 4 * time.Second
+
+	testCases := map[string]struct {
+		stall bool
+
+		wantErr bool
+	}{
+		"Success serving commands that take longer than the stall timeout": {},
+
+		"Error when a goroutine serving the streams gets stuck": {stall: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			sys, _ := testutils.MockSystem(t)
+
+			agent := testutils.NewMockWindowsAgent(t, ctx, t.TempDir())
+			defer agent.Stop()
+
+			conn, err := grpc.NewClient(agent.Listener.Addr().String(),
+				grpc.WithTransportCredentials(agent.ClientCredentials))
+			require.NoError(t, err, "Setup: could not create a client to the mock windows agent")
+			defer conn.Close()
+
+			server := streams.NewServer(ctx, sys, conn)
+			server.SetStallTimeout(stallTimeout)
+			defer server.Stop()
+
+			service := &mockService{}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- server.Serve(service)
+				close(errCh)
+			}()
+
+			waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Second)
+			defer waitCancel()
+			require.NoError(t, agent.WaitForConnection(waitCtx), "Setup: Agent service never became ready")
+
+			if tc.stall {
+				server.Stall()
+
+				select {
+				case err := <-errCh:
+					var stall watchdog.StallError
+					require.ErrorAs(t, err, &stall, "Serve should return the diagnostics of the stall")
+					require.Contains(t, stall.Stalled[0], "stuck goroutine", "The diagnostics should name the stuck goroutine")
+				case <-time.After(4 * stallTimeout):
+					require.Fail(t, "Serve should tear down the connection when a goroutine gets stuck")
+				}
+				return
+			}
+
+			blockCtx, unblock := context.WithCancel(ctx)
+			defer unblock()
+			service.setBlocking(blockCtx)
+
+			// The second command is received while the first one is in progress.
+			for range 2 {
+				err = agent.Service.ProAttachment.Send(&agentapi.ProAttachCmd{})
+				require.NoError(t, err, "mock agent could not send a pro-attach command")
+			}
+
+			select {
+			case err := <-errCh:
+				require.Failf(t, "Serve should not stop while a command is in progress", "Serve returned: %v", err)
+			case <-time.After(3 * stallTimeout):
+			}
+
+			unblock()
+			require.Eventually(t, func() bool {
+				return len(agent.Service.ProAttachment.History()) > 2
+			}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to both commands")
+		})
+	}
+}
+
+func TestPreemption(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	sys, _ := testutils.MockSystem(t)
+
+	agent := testutils.NewMockWindowsAgent(t, ctx, t.TempDir())
+	defer agent.Stop()
+
+	conn, err := grpc.NewClient(agent.Listener.Addr().String(),
+		grpc.WithTransportCredentials(agent.ClientCredentials))
+	require.NoError(t, err, "Setup: could not create a client to the mock windows agent")
+	defer conn.Close()
+
+	server := streams.NewServer(ctx, sys, conn)
+	defer server.Stop()
+
+	go func() { _ = server.Serve(&mockService{}) }()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Second)
+	defer waitCancel()
+	require.NoError(t, agent.WaitForConnection(waitCtx), "Setup: Agent service never became ready")
+
+	preempt := &agentapi.Command{Cmd: &agentapi.Command_Preempt{Preempt: &agentapi.PreemptCmd{}}}
+
+	// Preempting with no command in progress does nothing
+	err = agent.Service.Command.Send(preempt)
+	require.NoError(t, err, "Send should return no error")
+
+	err = agent.Service.Command.Send(proServiceCmd("esm-apps"))
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Command.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the generic command")
+	require.Len(t, agent.Service.Command.History(), 2, "Preemptions should not get a response")
+	require.Empty(t, agent.Service.Command.History()[1].GetResult(), "Commands should return a successful result")
+	require.False(t, agent.Service.Command.History()[1].GetPreempted(), "Commands should not be preempted by a previous preemption")
+
+	// Preempting a command in progress stops it at its next safe point
+	err = agent.Service.Command.Send(preemptibleCmd())
+	require.NoError(t, err, "Send should return no error")
+
+	err = agent.Service.Command.Send(preempt)
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Command.History()) > 2
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the preempted command")
+	require.NotEmpty(t, agent.Service.Command.History()[2].GetResult(), "Preempted commands should return an error result")
+	require.True(t, agent.Service.Command.History()[2].GetPreempted(), "Preempted commands should be reported as such")
+
+	// Commands are served normally after a preemption
+	err = agent.Service.Command.Send(proServiceCmd("esm-apps"))
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Command.History()) > 3
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the generic command")
+	require.Empty(t, agent.Service.Command.History()[3].GetResult(), "Commands should return a successful result")
+	require.False(t, agent.Service.Command.History()[3].GetPreempted(), "Commands should not be preempted by a previous preemption")
+
+	// Preemptions only stop the command they target, and commands received meanwhile are queued
+	cmd := preemptibleCmd()
+	cmd.Id = 7
+	err = agent.Service.Command.Send(cmd)
+	require.NoError(t, err, "Send should return no error")
+
+	err = agent.Service.Command.Send(&agentapi.Command{Cmd: &agentapi.Command_Preempt{Preempt: &agentapi.PreemptCmd{Id: 6}}})
+	require.NoError(t, err, "Send should return no error")
+
+	queued := proServiceCmd("esm-apps")
+	queued.Id = 8
+	err = agent.Service.Command.Send(queued)
+	require.NoError(t, err, "Send should return no error")
+
+	require.Never(t, func() bool {
+		return len(agent.Service.Command.History()) > 4
+	}, time.Second, 100*time.Millisecond, "Commands should neither be preempted by preemptions of other commands nor be answered before the one in progress")
+
+	err = agent.Service.Command.Send(&agentapi.Command{Cmd: &agentapi.Command_Preempt{Preempt: &agentapi.PreemptCmd{Id: 7}}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Command.History()) > 5
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the preempted and the queued commands")
+	require.True(t, agent.Service.Command.History()[4].GetPreempted(), "Commands should be preempted by the preemptions that target them")
+	require.Empty(t, agent.Service.Command.History()[5].GetResult(), "Commands received while another one is in progress should be served afterwards")
+	require.False(t, agent.Service.Command.History()[5].GetPreempted(), "Queued commands should not be preempted by the preemption of the previous command")
+}
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	sys, _ := testutils.MockSystem(t)
+
+	agent := testutils.NewMockWindowsAgent(t, ctx, t.TempDir())
+	defer agent.Stop()
+
+	conn, err := grpc.NewClient(agent.Listener.Addr().String(),
+		grpc.WithTransportCredentials(agent.ClientCredentials))
+	require.NoError(t, err, "Setup: could not create a client to the mock windows agent")
+	defer conn.Close()
+
+	server := streams.NewServer(ctx, sys, conn)
+	defer server.Stop()
+
+	go func() { _ = server.Serve(&mockService{}) }()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Second)
+	defer waitCancel()
+	require.NoError(t, agent.WaitForConnection(waitCtx), "Setup: Agent service never became ready")
+
+	// The progress of a command is sent ahead of its result
+	err = agent.Service.Command.Send(&agentapi.Command{Cmd: &agentapi.Command_Usg{Usg: &agentapi.UsgCmd{Profile: "PROGRESS"}}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Command.History()) > 3
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the generic command")
+
+	history := agent.Service.Command.History()
+	requireProgress(t, history[1], 30, "Auditing")
+	requireProgress(t, history[2], 100, "Reporting")
+	require.Empty(t, history[3].GetResult(), "Commands should return a successful result after their progress")
+	require.Equal(t, "<html>PROGRESS</html>", string(history[3].GetOutput()), "Commands should return the command output after their progress")
+
+	// So is the progress of Landscape enrollments
+	err = agent.Service.LandscapeConfig.Send(&agentapi.LandscapeConfigCmd{Config: "PROGRESS"})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.LandscapeConfig.History()) > 2
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the Landscape config")
+
+	history = agent.Service.LandscapeConfig.History()
+	requireProgress(t, history[1], 40, "Registering with Landscape")
+	require.Empty(t, history[2].GetResult(), "LandscapeConfig should return a successful result after its progress")
+}
+
+// requireProgress checks that the message reports the given progress, and nothing else.
+func requireProgress(t *testing.T, msg *agentapi.MSG, percent uint32, step string) {
+	t.Helper()
+
+	p := msg.GetProgress()
+	require.NotNil(t, p, "Message should report progress, got %v", msg)
+	require.Equal(t, percent, p.GetPercent(), "Unexpected percentage of completion (capped at 100%)")
+	require.Equal(t, step, p.GetStep(), "Unexpected step in progress")
+	require.Empty(t, msg.GetOutput(), "Progress should not carry any output")
+}
+
+func TestTailLog(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	sys, _ := testutils.MockSystem(t)
+
+	agent := testutils.NewMockWindowsAgent(t, ctx, t.TempDir())
+	defer agent.Stop()
+
+	conn, err := grpc.NewClient(agent.Listener.Addr().String(),
+		grpc.WithTransportCredentials(agent.ClientCredentials))
+	require.NoError(t, err, "Setup: could not create a client to the mock windows agent")
+	defer conn.Close()
+
+	server := streams.NewServer(ctx, sys, conn)
+	defer server.Stop()
+
+	go func() { _ = server.Serve(&mockService{}) }()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Second)
+	defer waitCancel()
+	require.NoError(t, agent.WaitForConnection(waitCtx), "Setup: Agent service never became ready")
+	require.NotEmpty(t, agent.Service.Logs.History()[0].GetWslName(), "The TailLog stream should start with the WSL name")
+
+	// byID returns the lines received for the request, and whether the request is done.
+	byID := func(id uint32) (lines []string, done bool, errMsg string) {
+		for _, msg := range agent.Service.Logs.History() {
+			if msg.GetWslName() != "" || msg.GetId() != id {
+				continue
+			}
+			if msg.GetDone() {
+				return lines, true, msg.GetError()
+			}
+			lines = append(lines, msg.GetLine())
+		}
+		return lines, false, ""
+	}
+
+	// Followed requests stream lines until cancelled, without blocking other requests.
+	err = agent.Service.Logs.Send(&agentapi.TailLogCmd{Id: 1, Lines: 2, Follow: true})
+	require.NoError(t, err, "Send should return no error")
+
+	err = agent.Service.Logs.Send(&agentapi.TailLogCmd{Id: 2, Lines: 3})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		_, done, _ := byID(2)
+		return done
+	}, 20*time.Second, 100*time.Millisecond, "Server did not finish the request while another one is followed")
+	lines, _, errMsg := byID(2)
+	require.Equal(t, []string{"line 0", "line 1", "line 2"}, lines, "Server should send every line of the request")
+	require.Empty(t, errMsg, "Successful requests should finish without error")
+
+	lines, done, _ := byID(1)
+	require.Equal(t, []string{"line 0", "line 1"}, lines, "Server should send the lines of the followed request as they are read")
+	require.False(t, done, "Followed requests should not finish until cancelled")
+
+	err = agent.Service.Logs.Send(&agentapi.TailLogCmd{Id: 1, Cancel: true})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		_, done, _ := byID(1)
+		return done
+	}, 20*time.Second, 100*time.Millisecond, "Server did not finish the cancelled request")
+	_, _, errMsg = byID(1)
+	require.Empty(t, errMsg, "Cancelled requests should finish without error")
+
+	// Failed requests are finished with the error.
+	err = agent.Service.Logs.Send(&agentapi.TailLogCmd{Id: 3})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		_, done, _ := byID(3)
+		return done
+	}, 20*time.Second, 100*time.Millisecond, "Server did not finish the failed request")
+	_, _, errMsg = byID(3)
+	require.NotEmpty(t, errMsg, "Failed requests should finish with the error")
+}
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	sys, _ := testutils.MockSystem(t)
+
+	agent := testutils.NewMockWindowsAgent(t, ctx, t.TempDir())
+	defer agent.Stop()
+
+	conn, err := grpc.NewClient(agent.Listener.Addr().String(),
+		grpc.WithTransportCredentials(agent.ClientCredentials))
+	require.NoError(t, err, "Setup: could not create a client to the mock windows agent")
+	defer conn.Close()
+
+	server := streams.NewServer(ctx, sys, conn)
+	defer server.Stop()
+
+	go func() { _ = server.Serve(&mockService{}) }()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Second)
+	defer waitCancel()
+	require.NoError(t, agent.WaitForConnection(waitCtx), "Setup: Agent service never became ready")
+	require.NotEmpty(t, agent.Service.Pings.History()[0].GetWslName(), "The Ping stream should start with the WSL name")
+
+	// Pings are echoed even while a command is in progress.
+	err = agent.Service.Command.Send(preemptibleCmd())
+	require.NoError(t, err, "Send should return no error")
+
+	for id := uint32(1); id <= 2; id++ {
+		err = agent.Service.Pings.Send(&agentapi.PingCmd{Id: id, Payload: []byte(fmt.Sprintf("payload %d", id))})
+		require.NoError(t, err, "Send should return no error")
+	}
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Pings.History()) > 2
+	}, 20*time.Second, 100*time.Millisecond, "Server did not echo the pings")
+
+	for i, reply := range agent.Service.Pings.History()[1:] {
+		id := uint32(i) + 1
+		require.Equal(t, id, reply.GetId(), "Server should reply to the pings in order, with their id")
+		require.Equal(t, fmt.Sprintf("payload %d", id), string(reply.GetPayload()), "Server should echo the payload of the ping")
+	}
+	require.Len(t, agent.Service.Command.History(), 1, "The command in progress should not have finished")
+
+	err = agent.Service.Command.Send(&agentapi.Command{Cmd: &agentapi.Command_Preempt{Preempt: &agentapi.PreemptCmd{}}})
+	require.NoError(t, err, "Send should return no error")
+}
+
+func TestClockSkew(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	sys, _ := testutils.MockSystem(t)
+
+	agent := testutils.NewMockWindowsAgent(t, ctx, t.TempDir())
+	defer agent.Stop()
+
+	conn, err := grpc.NewClient(agent.Listener.Addr().String(),
+		grpc.WithTransportCredentials(agent.ClientCredentials))
+	require.NoError(t, err, "Setup: could not create a client to the mock windows agent")
+	defer conn.Close()
+
+	skews := make(chan time.Duration, 10)
+	server := streams.NewServer(ctx, sys, conn, streams.WithClockSkewHandler(func(_ context.Context, skew time.Duration) {
+		skews <- skew
+	}))
+	defer server.Stop()
+
+	go func() { _ = server.Serve(&mockService{}) }()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Second)
+	defer waitCancel()
+	require.NoError(t, agent.WaitForConnection(waitCtx), "Setup: Agent service never became ready")
+
+	stamped := func(offset time.Duration) *agentapi.HandshakeAck {
+		return &agentapi.HandshakeAck{AgentTimeUnixMilli: time.Now().Add(offset).UnixMilli()}
+	}
+	requireSkew := func(want time.Duration, msg string) {
+		t.Helper()
+		select {
+		case got := <-skews:
+			require.InDelta(t, want, got, float64(10*time.Second), msg)
+		case <-time.After(20 * time.Second):
+			require.Fail(t, msg)
+		}
+	}
+
+	// The mock agent does not stamp its handshake acknowledgement, like agents that predate clock skew detection.
+	require.Empty(t, skews, "The handler should not be called without a stamped acknowledgement")
+
+	require.NoError(t, agent.Service.Connect.Send(stamped(10*time.Second)), "Send should return no error")
+	require.NoError(t, agent.Service.Connect.Send(stamped(10*time.Minute)), "Send should return no error")
+	requireSkew(10*time.Minute, "The handler should be called when the distro clock falls behind Windows")
+
+	require.NoError(t, agent.Service.Connect.Send(stamped(11*time.Minute)), "Send should return no error")
+	require.NoError(t, agent.Service.Connect.Send(stamped(-5*time.Second)), "Send should return no error")
+	requireSkew(-5*time.Second, "The handler should be called once when the clocks are back in sync")
+
+	require.NoError(t, agent.Service.Connect.Send(stamped(-2*time.Hour)), "Send should return no error")
+	requireSkew(-2*time.Hour, "The handler should be called when the distro clock gets ahead of Windows")
+
+	time.Sleep(500 * time.Millisecond)
+	require.Empty(t, skews, "The handler should only be called when the clocks drift apart or are back in sync")
+}
+
+func TestInfoRefresh(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	sys, _ := testutils.MockSystem(t)
+
+	agent := testutils.NewMockWindowsAgent(t, ctx, t.TempDir())
+	defer agent.Stop()
+
+	conn, err := grpc.NewClient(agent.Listener.Addr().String(),
+		grpc.WithTransportCredentials(agent.ClientCredentials))
+	require.NoError(t, err, "Setup: could not create a client to the mock windows agent")
+	defer conn.Close()
+
+	server := streams.NewServer(ctx, sys, conn)
+	defer server.Stop()
+
+	serveDone := make(chan struct{})
+	go func() {
+		defer close(serveDone)
+		_ = server.Serve(&mockService{})
+	}()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 20*time.Second)
+	defer waitCancel()
+	require.NoError(t, agent.WaitForConnection(waitCtx), "Setup: Agent service never became ready")
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Connect.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send the first DistroInfo")
+
+	// Without a refresh interval, a DistroInfo is only sent when the agent configuration changes.
+	err = agent.Service.Connect.Send(&agentapi.HandshakeAck{Settings: &agentapi.DistroSettings{ConfigHash: "1234"}})
+	require.NoError(t, err, "Send should return no error")
+	err = agent.Service.Connect.Send(&agentapi.HandshakeAck{Settings: &agentapi.DistroSettings{ConfigHash: "5678"}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Connect.History()) > 2
+	}, 20*time.Second, 100*time.Millisecond, "Server should send a DistroInfo when the agent configuration changes")
+	require.Len(t, agent.Service.Connect.History(), 3, "Server should only send a DistroInfo for the configuration change")
+	require.NotNil(t, agent.Service.Connect.History()[2].GetInfo(), "Server should refresh the agent with a DistroInfo")
+
+	err = agent.Service.Connect.Send(&agentapi.HandshakeAck{Settings: &agentapi.DistroSettings{ConfigHash: "5678", RefreshIntervalSeconds: 1}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Connect.History()) > 4
+	}, 20*time.Second, 100*time.Millisecond, "Server should send a DistroInfo as often as the agent requests")
+	require.NotNil(t, agent.Service.Connect.History()[4].GetInfo(), "Server should refresh the agent with a DistroInfo")
+
+	// The server must stop its handlers, the acknowledgements one included, before returning.
+	server.Stop()
+	select {
+	case <-serveDone:
+	case <-time.After(20 * time.Second):
+		require.Fail(t, "Serve should return once the server is stopped")
+	}
+}
+
 type mockService struct {
 	blockingCalls bool
 	mu            sync.RWMutex
 
 	ctx context.Context
+
+	// inProgress is the number of blocking calls in progress.
+	inProgress atomic.Int32
 }
 
 func (s *mockService) setBlocking(ctx context.Context) {
@@ -158,6 +696,9 @@ func (s *mockService) ApplyProToken(ctx context.Context, msg *agentapi.ProAttach
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if s.blockingCalls {
+		s.inProgress.Add(1)
+		defer s.inProgress.Add(-1)
+
 		select {
 		case <-ctx.Done():
 			// Mock task interrupted
@@ -175,11 +716,18 @@ func (s *mockService) ApplyLandscapeConfig(ctx context.Context, msg *agentapi.La
 		return errors.New("mock error")
 	}
 
+	if msg.GetConfig() == "PROGRESS" {
+		system.ReportProgress(ctx, 40, "Registering with Landscape")
+	}
+
 	// Mock a slow task that can be cancelled
 	// Using a mutex because those calls can race with s.setBlocking.
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if s.blockingCalls {
+		s.inProgress.Add(1)
+		defer s.inProgress.Add(-1)
+
 		select {
 		case <-ctx.Done():
 			// Mock task interrupted
@@ -192,7 +740,84 @@ func (s *mockService) ApplyLandscapeConfig(ctx context.Context, msg *agentapi.La
 	return nil
 }
 
+func (s *mockService) ApplyCommand(ctx context.Context, msg *agentapi.Command) ([]byte, error) {
+	if msg.GetProService().GetService() == "HARDCODED_FAILURE" {
+		return nil, errors.New("mock error")
+	}
+
+	if msg.GetServiceUpgrade().GetChannel() == "PREEMPTIBLE" {
+		// Mock a slow task that reaches a safe point every so often
+		for {
+			if err := system.SafePoint(ctx); err != nil {
+				return nil, err
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	if msg.GetUsg().GetProfile() == "LARGE_REPORT" {
+		return largeReport(), nil
+	}
+
+	if msg.GetUsg().GetProfile() == "PROGRESS" {
+		// Mock a long task that tells how far it is, overshooting at the end
+		system.ReportProgress(ctx, 30, "Auditing")
+		system.ReportProgress(ctx, 250, "Reporting")
+	}
+
+	if profile := msg.GetUsg().GetProfile(); profile != "" {
+		return []byte("<html>" + profile + "</html>"), nil
+	}
+
+	return nil, nil
+}
+
+func (s *mockService) TailLog(ctx context.Context, msg *agentapi.TailLogCmd, send func(string) error) error {
+	if msg.GetLines() <= 0 {
+		return errors.New("mock error")
+	}
+
+	for i := range msg.GetLines() {
+		if err := send(fmt.Sprintf("line %d", i)); err != nil {
+			return err
+		}
+	}
+
+	if msg.GetFollow() {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// largeReport returns an output that needs three messages to be sent.
+func largeReport() []byte {
+	return bytes.Repeat([]byte("0123456789"), 250*1024)
+}
+
+func proServiceCmd(service string) *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_ProService{
+			ProService: &agentapi.ProServiceCmd{Service: service, Enable: true},
+		},
+	}
+}
+
 func TestWithProMock(t *testing.T)     { testutils.ProMock(t) }
 func TestWithWslPathMock(t *testing.T) { testutils.WslPathMock(t) }
 func TestWithWslInfoMock(t *testing.T) { testutils.WslInfoMock(t) }
 func TestWithCmdExeMock(t *testing.T)  { testutils.CmdExeMock(t) }
+
+func preemptibleCmd() *agentapi.Command {
+	return &agentapi.Command{
+		Cmd: &agentapi.Command_ServiceUpgrade{
+			ServiceUpgrade: &agentapi.ServiceUpgradeCmd{Channel: "PREEMPTIBLE"},
+		},
+	}
+}
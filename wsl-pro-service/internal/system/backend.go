@@ -43,6 +43,43 @@ func (b realBackend) WslinfoExecutable(ctx context.Context, args ...string) *exe
 	return exec.CommandContext(ctx, "wslinfo", args...)
 }
 
+// UsgExecutable returns the full command to run the usg executable with the provided arguments.
+func (b realBackend) UsgExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "usg", args...)
+}
+
+// AptGetExecutable returns the full command to run apt-get non-interactively with the provided arguments.
+func (b realBackend) AptGetExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "apt-get", args...)
+	cmd.Env = append(os.Environ(), "DEBIAN_FRONTEND=noninteractive")
+	return cmd
+}
+
+// AddAptRepositoryExecutable returns the full command to run add-apt-repository with the provided arguments.
+func (b realBackend) AddAptRepositoryExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "add-apt-repository", args...)
+}
+
+// JournalctlExecutable returns the full command to run journalctl with the provided arguments.
+func (b realBackend) JournalctlExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "journalctl", args...)
+}
+
+// UseraddExecutable returns the full command to run useradd with the provided arguments.
+func (b realBackend) UseraddExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "useradd", args...)
+}
+
+// UsermodExecutable returns the full command to run usermod with the provided arguments.
+func (b realBackend) UsermodExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "usermod", args...)
+}
+
+// SnapExecutable returns the full command to run snap with the provided arguments.
+func (b realBackend) SnapExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "snap", args...)
+}
+
 func (b realBackend) CmdExe(ctx context.Context, path string, args ...string) *exec.Cmd {
 	cmd := exec.CommandContext(ctx, path, args...)
 
@@ -55,3 +92,7 @@ func (b realBackend) CmdExe(ctx context.Context, path string, args ...string) *e
 func (b realBackend) LookupGroup(name string) (*user.Group, error) {
 	return user.LookupGroup("landscape")
 }
+
+func (b realBackend) LookupUser(name string) (*user.User, error) {
+	return user.Lookup(name)
+}
@@ -0,0 +1,245 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"strings"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+	"gopkg.in/ini.v1"
+)
+
+const (
+	// ResolvConfPath is the resolver configuration of the distro, which WSL generates when the distro boots
+	// unless told otherwise in /etc/wsl.conf.
+	ResolvConfPath = "/etc/resolv.conf"
+
+	// ResolvConfBackupPath is where the resolv.conf WSL generated, usually a symlink, is kept while the nameservers
+	// are managed, so that it can be restored when they stop being managed.
+	ResolvConfBackupPath = "/etc/resolv.conf.wsl"
+
+	// HostsPath is the static table of host names of the distro. The managed entries are kept in a block between
+	// markers, so that they can be updated and removed without touching the rest of it.
+	HostsPath = "/etc/hosts"
+
+	// hostEntriesStatePath keeps the managed host entries, so that they can be added again after WSL regenerates
+	// /etc/hosts when the distro boots. Only root can access it.
+	hostEntriesStatePath = upgradeStateDir + "/hosts"
+)
+
+const (
+	hostsBeginMarker = "# BEGIN Ubuntu Pro for WSL host entries. Local changes in this block will be overwritten."
+	hostsEndMarker   = "# END Ubuntu Pro for WSL host entries"
+
+	// managedResolvConfHeader is the first line of the resolv.conf written by the service.
+	managedResolvConfHeader = "# This file is managed by Ubuntu Pro for WSL. Local changes will be overwritten."
+)
+
+// ConfigureDNS sets the nameservers and search domains of resolv.conf, instead of those WSL generates, and adds the
+// host entries to /etc/hosts. Without nameservers, the resolv.conf generated by WSL is restored. Without host
+// entries, the managed ones are removed from /etc/hosts.
+func (s *System) ConfigureDNS(ctx context.Context, nameservers, searchDomains, hosts []string) (err error) {
+	defer decorate.OnError(&err, "could not configure DNS")
+
+	if err := validateDNS(nameservers, searchDomains, hosts); err != nil {
+		return err
+	}
+
+	if err := s.configureResolvConf(ctx, nameservers, searchDomains); err != nil {
+		return err
+	}
+
+	return s.configureHostEntries(ctx, hosts)
+}
+
+// RestoreHostEntries adds the managed host entries back to /etc/hosts, which WSL regenerates when the distro boots.
+func (s *System) RestoreHostEntries(ctx context.Context) (err error) {
+	defer decorate.OnError(&err, "could not restore the managed host entries")
+
+	out, err := os.ReadFile(s.backend.Path(hostEntriesStatePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	hosts := strings.FieldsFunc(string(out), func(r rune) bool { return r == '\n' })
+	return s.writeHostEntries(ctx, hosts)
+}
+
+// validateDNS checks that the nameservers and the host entries start with IP addresses, and that no setting
+// contains characters that resolv.conf and /etc/hosts would misread.
+func validateDNS(nameservers, searchDomains, hosts []string) error {
+	for _, ns := range nameservers {
+		if _, err := netip.ParseAddr(ns); err != nil {
+			return fmt.Errorf("invalid nameserver %q: expected an IP address", ns)
+		}
+	}
+
+	if len(searchDomains) != 0 && len(nameservers) == 0 {
+		return errors.New("search domains require nameservers")
+	}
+	for _, domain := range searchDomains {
+		if domain == "" || strings.ContainsAny(domain, "# \t\r\n") {
+			return fmt.Errorf("invalid search domain %q", domain)
+		}
+	}
+
+	for _, entry := range hosts {
+		fields := strings.Fields(entry)
+		if len(fields) < 2 || strings.ContainsAny(entry, "#\r\n") {
+			return fmt.Errorf("invalid host entry %q: expected an IP address followed by host names", entry)
+		}
+		if _, err := netip.ParseAddr(fields[0]); err != nil {
+			return fmt.Errorf("invalid host entry %q: %q is not an IP address", entry, fields[0])
+		}
+	}
+
+	return nil
+}
+
+// configureResolvConf writes resolv.conf with the nameservers and search domains, and stops WSL from generating it
+// when the distro boots. Without nameservers, it restores the resolv.conf generated by WSL, if it was managed.
+func (s *System) configureResolvConf(ctx context.Context, nameservers, searchDomains []string) error {
+	path := s.backend.Path(ResolvConfPath)
+	backup := s.backend.Path(ResolvConfBackupPath)
+
+	managed, err := isManagedResolvConf(path)
+	if err != nil {
+		return err
+	}
+
+	if len(nameservers) == 0 {
+		if !managed {
+			return nil
+		}
+
+		log.Info(ctx, "DNS: restoring the resolv.conf generated by WSL")
+		if err := s.editWSLConf(func(conf *ini.File) {
+			sec, err := conf.GetSection("network")
+			if err != nil {
+				return
+			}
+			sec.DeleteKey("generateResolvConf")
+			if len(sec.Keys()) == 0 {
+				conf.DeleteSection("network")
+			}
+		}); err != nil {
+			return fmt.Errorf("could not update %s: %v", wslConfPath, err)
+		}
+
+		// Without a backup, WSL generates resolv.conf again the next time the distro boots.
+		err = os.Rename(backup, path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return removeIfExists(path)
+		}
+		return err
+	}
+
+	log.Infof(ctx, "DNS: using nameservers %q and search domains %q", nameservers, searchDomains)
+
+	// WSL would otherwise overwrite resolv.conf the next time the distro boots.
+	if err := s.editWSLConf(func(conf *ini.File) {
+		conf.Section("network").Key("generateResolvConf").SetValue("false")
+	}); err != nil {
+		return fmt.Errorf("could not update %s: %v", wslConfPath, err)
+	}
+
+	if !managed {
+		// Renaming keeps the symlink WSL usually generates as it is.
+		if err := os.Rename(path, backup); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	var conf strings.Builder
+	fmt.Fprintln(&conf, managedResolvConfHeader)
+	for _, ns := range nameservers {
+		fmt.Fprintf(&conf, "nameserver %s\n", ns)
+	}
+	if len(searchDomains) != 0 {
+		fmt.Fprintf(&conf, "search %s\n", strings.Join(searchDomains, " "))
+	}
+
+	return writeConfigFile(path, []byte(conf.String()))
+}
+
+// isManagedResolvConf returns true if the resolv.conf at path was written by the service.
+func isManagedResolvConf(path string) (bool, error) {
+	out, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	firstLine, _, _ := bytes.Cut(out, []byte("\n"))
+	return string(firstLine) == managedResolvConfHeader, nil
+}
+
+// configureHostEntries keeps the host entries, so that they can be restored when the distro boots, and writes
+// them to /etc/hosts. Without entries, both are removed.
+func (s *System) configureHostEntries(ctx context.Context, hosts []string) error {
+	state := s.backend.Path(hostEntriesStatePath)
+
+	if len(hosts) == 0 {
+		if err := removeIfExists(state); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(s.backend.Path(upgradeStateDir), 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(state, []byte(strings.Join(hosts, "\n")+"\n"), 0600); err != nil {
+			return fmt.Errorf("could not record the host entries: %v", err)
+		}
+	}
+
+	return s.writeHostEntries(ctx, hosts)
+}
+
+// writeHostEntries replaces the block of managed entries in /etc/hosts, or removes it if there are no entries.
+// The rest of the file is left untouched. So is the whole file if the block has lost its end marker, as there is no
+// telling where it ended.
+func (s *System) writeHostEntries(ctx context.Context, hosts []string) error {
+	path := s.backend.Path(HostsPath)
+
+	out, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	contents, ok := removeMarkedBlock(out, hostsBeginMarker, hostsEndMarker)
+	if !ok {
+		log.Warningf(ctx, "DNS: leaving %s untouched: the block of host entries has no end marker", HostsPath)
+		return nil
+	}
+
+	if len(hosts) != 0 {
+		log.Infof(ctx, "DNS: adding %d entries to %s", len(hosts), HostsPath)
+
+		var block strings.Builder
+		fmt.Fprintln(&block, hostsBeginMarker)
+		for _, entry := range hosts {
+			fmt.Fprintln(&block, entry)
+		}
+		fmt.Fprintln(&block, hostsEndMarker)
+
+		if len(contents) != 0 && !bytes.HasSuffix(contents, []byte("\n")) {
+			contents = append(contents, '\n')
+		}
+		contents = append(contents, block.String()...)
+	}
+
+	if bytes.Equal(contents, out) {
+		return nil
+	}
+
+	return writeConfigFile(path, contents)
+}
@@ -1,5 +1,7 @@
 package system
 
+import "context"
+
 const LandscapeConfigPath = landscapeConfigPath
 
 func (s *System) CmdExeCache() *string {
@@ -7,3 +9,9 @@ func (s *System) CmdExeCache() *string {
 }
 
 type RealBackend = realBackend
+
+var ParsePackageSigningKey = parsePackageSigningKey
+
+func (s *System) LocalDeb(ctx context.Context, pathWindows, checksum string) (string, func(), error) {
+	return s.localDeb(ctx, pathWindows, checksum)
+}
@@ -0,0 +1,122 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ubuntu/decorate"
+)
+
+// journalPriorities are the priority names accepted by journalctl, from most to least severe.
+var journalPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// JournalTail sends the most recent lines of the journal of wsl-pro-service, and optionally of the
+// Ubuntu Pro client, filtered by priority, one at a time as journalctl prints them. An empty priority
+// sends lines of all priorities. When following, new lines keep being sent until the context is cancelled.
+//
+// It stops as soon as send returns an error, and returns that error.
+func (s *System) JournalTail(ctx context.Context, lines int, priority string, includeProClient, follow bool, send func(line string) error) (err error) {
+	defer decorate.OnError(&err, "could not read the journal")
+
+	if lines <= 0 {
+		return fmt.Errorf("invalid number of lines %d", lines)
+	}
+
+	args := []string{"--no-pager", "--output=short-iso", "--lines=" + strconv.Itoa(lines), "--unit=wsl-pro-service.service"}
+	if includeProClient {
+		args = append(args, "--unit=ubuntu-advantage.service")
+	}
+
+	if priority != "" {
+		if !slices.Contains(journalPriorities, priority) {
+			return fmt.Errorf("unknown priority %q", priority)
+		}
+		args = append(args, "--priority="+priority)
+	}
+
+	if follow {
+		args = append(args, "--follow")
+	}
+
+	// Stopping journalctl as soon as the lines cannot be sent, as it may never exit on its own when following.
+	cmdCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	out := &lineWriter{send: func(line string) error {
+		if err := send(line); err != nil {
+			cancel()
+			return err
+		}
+		return nil
+	}}
+
+	var stderr bytes.Buffer
+	cmd := s.backend.JournalctlExecutable(cmdCtx, args...)
+	cmd.Stdout = out
+	cmd.Stderr = &stderr
+	cmd.Env = append(cmd.Env, "LC_ALL=C") // Ensure that the output is in English
+	// Killing journalctl does not close its output if it was inherited by other processes.
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if out.err != nil {
+		return out.err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		return fmt.Errorf("%s: error: %v.\n    Stderr: %s", cmd.Path, err, stderr.String())
+	}
+
+	return out.flush()
+}
+
+// lineWriter is a writer that sends what is written to it line by line.
+type lineWriter struct {
+	send func(line string) error
+	buf  []byte
+
+	// err is the first error returned by send, after which nothing else is sent.
+	err error
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+
+		line := string(w.buf[:i])
+		w.buf = w.buf[i+1:]
+
+		if w.err = w.send(line); w.err != nil {
+			return 0, w.err
+		}
+	}
+
+	return len(p), nil
+}
+
+// flush sends the last line, if it was not terminated by a newline.
+func (w *lineWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	line := string(w.buf)
+	w.buf = nil
+	return w.send(line)
+}
@@ -78,10 +78,12 @@ func (s *System) fixAndEnableLandscapeFromConfig(ctx context.Context, landscapeC
 		return nil
 	}
 
+	ReportProgress(ctx, 20, "Writing the Landscape configuration")
 	if err := s.writeConfig(modifiedLandscapeConfig); err != nil {
 		return err
 	}
 
+	ReportProgress(ctx, 40, "Registering with Landscape")
 	// TODO: check foreground/background
 	cmd := s.backend.LandscapeConfigExecutable(ctx, "--config", landscapeConfigPath, "--silent", "--register-if-needed")
 	if _, err := runCommand(cmd); err != nil {
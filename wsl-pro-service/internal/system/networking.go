@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -17,7 +18,12 @@ import (
 func (s *System) WindowsHostAddress(ctx context.Context) (ip net.IP, err error) {
 	defer decorate.OnError(&err, "coud not find address mapping to the Windows host")
 
-	mode, err := s.networkingMode(ctx)
+	// WSL 1 distros share the network of the host, and have no networking mode.
+	if version, err := s.WslVersion(); err == nil && version == 1 {
+		return net.IPv4(127, 0, 0, 1), nil
+	}
+
+	mode, err := s.NetworkingMode(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not ascertain the network mode: %v", err)
 	}
@@ -29,7 +35,8 @@ func (s *System) WindowsHostAddress(ctx context.Context) (ip net.IP, err error)
 	return s.defaultGateway()
 }
 
-func (s *System) networkingMode(ctx context.Context) (string, error) {
+// NetworkingMode returns the networking mode WSL runs the distro with, as reported by wslinfo (e.g. "nat" or "mirrored").
+func (s *System) NetworkingMode(ctx context.Context) (string, error) {
 	cmd := s.backend.WslinfoExecutable(ctx, "--networking-mode", "-n")
 
 	out, err := runCommand(cmd)
@@ -40,6 +47,68 @@ func (s *System) networkingMode(ctx context.Context) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// HostAddressCandidate is an address that may map to the Windows host, and the strategy used to find it.
+type HostAddressCandidate struct {
+	// Strategy is a human-readable name of the way the address was found.
+	Strategy string
+
+	// IP is the address found. It is nil if the strategy failed.
+	IP net.IP
+
+	// Err is the reason the strategy failed, if it did.
+	Err error
+}
+
+// WindowsHostAddressCandidates returns the addresses that may map to the Windows host, one per strategy,
+// whether or not the strategy applies to the current networking mode. It is meant for diagnostics: use
+// WindowsHostAddress to find the address the service actually connects to.
+func (s *System) WindowsHostAddressCandidates() []HostAddressCandidate {
+	var candidates []HostAddressCandidate
+
+	ip, err := s.resolvConfNameserver()
+	candidates = append(candidates, HostAddressCandidate{Strategy: "resolv.conf nameserver", IP: ip, Err: err})
+
+	ip, err = s.defaultGateway()
+	candidates = append(candidates, HostAddressCandidate{Strategy: "default gateway", IP: ip, Err: err})
+
+	candidates = append(candidates, HostAddressCandidate{Strategy: "localhost", IP: net.IPv4(127, 0, 0, 1)})
+
+	return candidates
+}
+
+// resolvConfNameserver returns the first nameserver in /etc/resolv.conf. When WSL generates this file,
+// it points to the Windows host.
+func (s *System) resolvConfNameserver() (ip net.IP, err error) {
+	const fileName = "/etc/resolv.conf"
+	defer decorate.OnError(&err, "could not parse %s", fileName)
+
+	f, err := os.Open(s.Path(fileName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		ip := net.ParseIP(fields[1])
+		if ip == nil {
+			return nil, fmt.Errorf("could not parse nameserver address %q", fields[1])
+		}
+		return ip, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan: %v", err)
+	}
+
+	return nil, errors.New("no nameserver found")
+}
+
 // defaultGateway returns the default gateway of the machine.
 func (s *System) defaultGateway() (ip net.IP, err error) {
 	/*
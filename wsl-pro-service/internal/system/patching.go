@@ -0,0 +1,101 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+)
+
+// PatchingConfigPath is the apt configuration file that sets which pockets unattended-upgrades installs updates
+// from. It sorts after 50unattended-upgrades so that it overrides the origins allowed there.
+const PatchingConfigPath = "/etc/apt/apt.conf.d/52ubuntu-pro-for-wsl-patching"
+
+// patchingOrigins are the origins that unattended-upgrades is allowed to install updates from at each patching
+// level. The ESM origins are those of Ubuntu Pro, and are only used when the distro is attached.
+var patchingOrigins = map[string][]string{
+	"security-only": {
+		"${distro_id}:${distro_codename}-security",
+		"${distro_id}ESMApps:${distro_codename}-apps-security",
+		"${distro_id}ESM:${distro_codename}-infra-security",
+	},
+	"security+updates": {
+		"${distro_id}:${distro_codename}-security",
+		"${distro_id}ESMApps:${distro_codename}-apps-security",
+		"${distro_id}ESM:${distro_codename}-infra-security",
+		"${distro_id}:${distro_codename}-updates",
+		"${distro_id}ESMApps:${distro_codename}-apps-updates",
+		"${distro_id}ESM:${distro_codename}-infra-updates",
+	},
+	"all": {
+		"${distro_id}:${distro_codename}",
+		"${distro_id}:${distro_codename}-security",
+		"${distro_id}ESMApps:${distro_codename}-apps-security",
+		"${distro_id}ESM:${distro_codename}-infra-security",
+		"${distro_id}:${distro_codename}-updates",
+		"${distro_id}ESMApps:${distro_codename}-apps-updates",
+		"${distro_id}ESM:${distro_codename}-infra-updates",
+		"${distro_id}:${distro_codename}-backports",
+	},
+}
+
+// ConfigurePatching enables unattended-upgrades and restricts the pockets it installs updates from to
+// those of the patching level: security-only, security+updates or all. An empty level removes the
+// configuration, so that the distro's own takes over again.
+func (s *System) ConfigurePatching(ctx context.Context, level string) (err error) {
+	defer decorate.OnError(&err, "could not configure patching level %q", level)
+
+	path := s.backend.Path(PatchingConfigPath)
+
+	if level == "" {
+		log.Info(ctx, "Patching: removing unattended-upgrades configuration")
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	origins, ok := patchingOrigins[level]
+	if !ok {
+		return errors.New("unknown patching level")
+	}
+
+	log.Infof(ctx, "Patching: configuring unattended-upgrades with level %q", level)
+
+	var conf strings.Builder
+	fmt.Fprintln(&conf, "// This file is managed by Ubuntu Pro for WSL. Local changes will be overwritten.")
+	fmt.Fprintf(&conf, "// Patching level: %s\n\n", level)
+	fmt.Fprintln(&conf, `APT::Periodic::Update-Package-Lists "1";`)
+	fmt.Fprintf(&conf, "APT::Periodic::Unattended-Upgrade \"1\";\n\n")
+	fmt.Fprintln(&conf, "#clear Unattended-Upgrade::Origins-Pattern;")
+	fmt.Fprintln(&conf, "#clear Unattended-Upgrade::Allowed-Origins;")
+	fmt.Fprintln(&conf, "Unattended-Upgrade::Allowed-Origins {")
+	for _, o := range origins {
+		fmt.Fprintf(&conf, "\t%q;\n", o)
+	}
+	fmt.Fprintln(&conf, "};")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".new"
+	// apt configuration is world-readable by convention.
+	//nolint:gosec // See above.
+	if err := os.WriteFile(tmp, []byte(conf.String()), 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+
+	return nil
+}
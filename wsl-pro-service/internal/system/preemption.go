@@ -0,0 +1,41 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPreempted is returned by long-running operations that stopped at a safe point because
+// they were preempted. Every step before the safe point is idempotent, so the operation can
+// be resumed by running it again.
+var ErrPreempted = errors.New("preempted by a higher priority command")
+
+type preemptionKey struct{}
+
+// WithPreemption returns a context that lets long-running operations be preempted. Calling
+// preempt asks them to stop at their next safe point, without interrupting the step in progress.
+func WithPreemption(ctx context.Context) (_ context.Context, preempt func()) {
+	ch := make(chan struct{})
+	var once sync.Once
+
+	return context.WithValue(ctx, preemptionKey{}, ch), func() {
+		once.Do(func() { close(ch) })
+	}
+}
+
+// SafePoint returns ErrPreempted if the operation running with this context has been preempted.
+// Long-running operations call it between steps, where they can stop without harm.
+func SafePoint(ctx context.Context) error {
+	ch, ok := ctx.Value(preemptionKey{}).(chan struct{})
+	if !ok {
+		return nil
+	}
+
+	select {
+	case <-ch:
+		return ErrPreempted
+	default:
+		return nil
+	}
+}
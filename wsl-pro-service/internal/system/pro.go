@@ -3,29 +3,142 @@ package system
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
 
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/proclient"
 	"github.com/ubuntu/decorate"
 )
 
 // ProStatus returns whether this distro is pro-attached.
 func (s System) ProStatus(ctx context.Context) (attached bool, err error) {
+	status, err := s.proStatus(ctx)
+	if err != nil {
+		return false, err
+	}
+	return status.Attached, nil
+}
+
+// formatExpires returns the expiry date of the contract in RFC3339 format, or an empty string if it is not known.
+func formatExpires(status proclient.Status) string {
+	if status.Expires.IsZero() {
+		return ""
+	}
+	return status.Expires.UTC().Format(time.RFC3339)
+}
+
+// exitCommandNotFound is the exit code of shells and wrapper scripts when a command they run cannot be found.
+const exitCommandNotFound = 127
+
+// proClientUnavailable returns why the Ubuntu Pro client cannot run, judging by the error running it, or an
+// empty string if it ran, be it successfully or not. It cannot run on minimal images and containers imported
+// as distros, which lack ubuntu-advantage-tools or the Python interpreter it needs.
+func proClientUnavailable(err error) string {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, exec.ErrNotFound):
+		return "the Ubuntu Pro client is not installed"
+	case errors.Is(err, fs.ErrNotExist):
+		// Executing a script whose interpreter is missing fails as if the script itself did not exist.
+		return "the Ubuntu Pro client cannot run: its interpreter is missing"
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == exitCommandNotFound:
+		return "the Ubuntu Pro client cannot run: a command it needs is missing"
+	}
+	return ""
+}
+
+// proStatus runs `pro status` and parses its output.
+func (s System) proStatus(ctx context.Context) (status proclient.Status, err error) {
 	defer decorate.OnError(&err, "pro status")
 
 	cmd := s.backend.ProExecutable(ctx, "status", "--format=json")
 	out, err := runCommand(cmd)
 	if err != nil {
-		return false, err
+		return status, err
+	}
+
+	return proclient.ParseStatus(out)
+}
+
+// securityStatusTTL is the longest the security status is cached for. It is refreshed earlier
+// if the installed packages or the package lists change.
+const securityStatusTTL = time.Hour
+
+// securityStatusCache keeps the last security status, as `pro security-status` takes seconds to run
+// and Info is called after every command.
+type securityStatusCache struct {
+	status      *agentapi.SecurityStatus
+	fingerprint string
+	expiration  time.Time
+	mu          sync.Mutex
+}
+
+// securityStatus returns the summary of the pending security updates, running `pro security-status`
+// only if the cached one is outdated.
+func (s System) securityStatus(ctx context.Context) (*agentapi.SecurityStatus, error) {
+	s.securityCache.mu.Lock()
+	defer s.securityCache.mu.Unlock()
+
+	fingerprint := s.packagesFingerprint()
+	if s.securityCache.status != nil && s.securityCache.fingerprint == fingerprint && time.Now().Before(s.securityCache.expiration) {
+		return s.securityCache.status, nil
+	}
+
+	status, err := s.runSecurityStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.securityCache.status = status
+	s.securityCache.fingerprint = fingerprint
+	s.securityCache.expiration = time.Now().Add(securityStatusTTL)
+
+	return status, nil
+}
+
+// packagesFingerprint returns a string that changes whenever packages are installed or removed, or the
+// package lists are updated, which is when the security status changes.
+func (s System) packagesFingerprint() string {
+	var fingerprint strings.Builder
+	for _, path := range []string{"/var/lib/dpkg/status", "/var/lib/apt/lists"} {
+		// A missing file has a fingerprint of its own.
+		if stat, err := os.Stat(s.backend.Path(path)); err == nil {
+			fmt.Fprintf(&fingerprint, "%d-%d;", stat.ModTime().UnixNano(), stat.Size())
+		} else {
+			fmt.Fprint(&fingerprint, "-;")
+		}
 	}
+	return fingerprint.String()
+}
+
+// runSecurityStatus runs `pro security-status` and summarises the pending security updates.
+func (s System) runSecurityStatus(ctx context.Context) (status *agentapi.SecurityStatus, err error) {
+	defer decorate.OnError(&err, "pro security-status")
 
-	var attachedStatus struct {
-		Attached bool
+	cmd := s.backend.ProExecutable(ctx, "security-status", "--format=json")
+	out, err := runCommand(cmd)
+	if err != nil {
+		return nil, err
 	}
-	if err = json.Unmarshal(out, &attachedStatus); err != nil {
-		return false, fmt.Errorf("could not parse output: %v. Output: %s", err, string(out))
+
+	parsed, err := proclient.ParseSecurityStatus(out)
+	if err != nil {
+		return nil, err
 	}
 
-	return attachedStatus.Attached, nil
+	return &agentapi.SecurityStatus{
+		StandardUpdates: parsed.StandardUpdates,
+		EsmUpdates:      parsed.ESMInfraUpdates + parsed.ESMAppsUpdates,
+	}, nil
 }
 
 // ProAttach attaches the current distro to Ubuntu Pro.
@@ -78,3 +191,27 @@ func (s *System) ProDetach(ctx context.Context) (err error) {
 	}
 	return nil
 }
+
+// ProEnableService enables a single Ubuntu Pro service (e.g. esm-apps, usg) in the current distro.
+func (s *System) ProEnableService(ctx context.Context, service string) (err error) {
+	defer decorate.OnError(&err, "pro enable %s", service)
+
+	cmd := s.backend.ProExecutable(ctx, "enable", service, "--assume-yes", "--format=json")
+	if _, err := runCommand(cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ProDisableService disables a single Ubuntu Pro service (e.g. esm-apps, usg) in the current distro.
+func (s *System) ProDisableService(ctx context.Context, service string) (err error) {
+	defer decorate.OnError(&err, "pro disable %s", service)
+
+	cmd := s.backend.ProExecutable(ctx, "disable", service, "--assume-yes", "--format=json")
+	if _, err := runCommand(cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package system
+
+import "context"
+
+type progressKey struct{}
+
+// WithProgress returns a context that lets long-running operations tell how far they are. Calling
+// ReportProgress with it forwards the percentage of completion and the step in progress to report.
+func WithProgress(ctx context.Context, report func(percent uint32, step string)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// ReportProgress tells how far the operation running with this context is, out of 100, and what it is
+// doing. It does nothing if nobody listens, i.e. the context was not created with WithProgress.
+func ReportProgress(ctx context.Context, percent uint32, step string) {
+	report, ok := ctx.Value(progressKey{}).(func(uint32, string))
+	if !ok {
+		return
+	}
+	report(min(percent, 100), step)
+}
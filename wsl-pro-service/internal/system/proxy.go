@@ -0,0 +1,213 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+)
+
+const (
+	// ProxyAptConfigPath is the apt configuration file that sets the proxy apt downloads packages through.
+	ProxyAptConfigPath = "/etc/apt/apt.conf.d/51ubuntu-pro-for-wsl-proxy"
+
+	// EnvironmentPath is the file with the environment of login sessions. The proxy variables are kept in
+	// a block between markers, so that they can be updated and removed without touching the rest of it.
+	EnvironmentPath = "/etc/environment"
+
+	// ProxySystemdConfigPath is the systemd drop-in that sets the proxy variables in the default environment
+	// of services, such as those of snapd and the Pro client. It applies to the services started after the
+	// distro restarts.
+	ProxySystemdConfigPath = "/etc/systemd/system.conf.d/ubuntu-pro-for-wsl-proxy.conf"
+)
+
+const (
+	proxyBeginMarker = "# BEGIN Ubuntu Pro for WSL proxy settings. Local changes in this block will be overwritten."
+	proxyEndMarker   = "# END Ubuntu Pro for WSL proxy settings"
+)
+
+// ConfigureProxy sets the proxy of apt, of login sessions and of systemd services. Without HTTP nor
+// HTTPS proxy, the configuration is removed, so that the distro's own takes over again.
+func (s *System) ConfigureProxy(ctx context.Context, httpProxy, httpsProxy, noProxy string) (err error) {
+	defer decorate.OnError(&err, "could not configure proxy")
+
+	for _, v := range []string{httpProxy, httpsProxy, noProxy} {
+		if strings.ContainsAny(v, "\"'\\ \t\r\n") {
+			return fmt.Errorf("invalid proxy setting %q: quotes, backslashes and whitespace are not allowed", v)
+		}
+	}
+
+	var vars [][2]string
+	if httpProxy != "" || httpsProxy != "" {
+		log.Infof(ctx, "Proxy: configuring proxy (http: %q, https: %q, no_proxy: %q)", common.RedactURL(httpProxy), common.RedactURL(httpsProxy), noProxy)
+		vars = proxyVariables(httpProxy, httpsProxy, noProxy)
+	} else {
+		log.Info(ctx, "Proxy: removing proxy configuration")
+	}
+
+	if err := s.configureAptProxy(httpProxy, httpsProxy); err != nil {
+		return err
+	}
+
+	if err := s.configureEnvironmentProxy(ctx, vars); err != nil {
+		return err
+	}
+
+	return s.configureSystemdProxy(vars)
+}
+
+// proxyVariables returns the environment variables of the proxy settings, in both lower and upper case
+// as tools disagree on which one they read.
+func proxyVariables(httpProxy, httpsProxy, noProxy string) (vars [][2]string) {
+	for _, v := range [][2]string{{"http_proxy", httpProxy}, {"https_proxy", httpsProxy}, {"no_proxy", noProxy}} {
+		if v[1] == "" {
+			continue
+		}
+		vars = append(vars, v, [2]string{strings.ToUpper(v[0]), v[1]})
+	}
+	return vars
+}
+
+// configureAptProxy writes the apt proxy configuration, or removes it if there are no proxies.
+func (s *System) configureAptProxy(httpProxy, httpsProxy string) error {
+	path := s.backend.Path(ProxyAptConfigPath)
+
+	if httpProxy == "" && httpsProxy == "" {
+		return removeIfExists(path)
+	}
+
+	var conf strings.Builder
+	fmt.Fprintln(&conf, "// This file is managed by Ubuntu Pro for WSL. Local changes will be overwritten.")
+	if httpProxy != "" {
+		fmt.Fprintf(&conf, "Acquire::http::Proxy %q;\n", httpProxy)
+	}
+	if httpsProxy != "" {
+		fmt.Fprintf(&conf, "Acquire::https::Proxy %q;\n", httpsProxy)
+	}
+
+	return writeConfigFile(path, []byte(conf.String()))
+}
+
+// configureEnvironmentProxy replaces the block of proxy variables in /etc/environment, or removes it
+// if there are no variables. The rest of the file is left untouched. So is the whole file if the block
+// has lost its end marker, as there is no telling where it ended.
+func (s *System) configureEnvironmentProxy(ctx context.Context, vars [][2]string) error {
+	path := s.backend.Path(EnvironmentPath)
+
+	out, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	env, ok := removeMarkedBlock(out, proxyBeginMarker, proxyEndMarker)
+	if !ok {
+		log.Warningf(ctx, "Proxy: leaving %s untouched: the proxy block has no end marker", EnvironmentPath)
+		return nil
+	}
+
+	if len(vars) != 0 {
+		var block strings.Builder
+		fmt.Fprintln(&block, proxyBeginMarker)
+		for _, v := range vars {
+			fmt.Fprintf(&block, "%s=%q\n", v[0], v[1])
+		}
+		fmt.Fprintln(&block, proxyEndMarker)
+
+		if len(env) != 0 && !bytes.HasSuffix(env, []byte("\n")) {
+			env = append(env, '\n')
+		}
+		env = append(env, block.String()...)
+	}
+
+	if bytes.Equal(env, out) {
+		return nil
+	}
+
+	return writeConfigFile(path, env)
+}
+
+// configureSystemdProxy writes the systemd drop-in with the proxy variables, or removes it if there
+// are no variables.
+func (s *System) configureSystemdProxy(vars [][2]string) error {
+	path := s.backend.Path(ProxySystemdConfigPath)
+
+	if len(vars) == 0 {
+		return removeIfExists(path)
+	}
+
+	var conf strings.Builder
+	fmt.Fprintln(&conf, "# This file is managed by Ubuntu Pro for WSL. Local changes will be overwritten.")
+	fmt.Fprintln(&conf, "[Manager]")
+	fmt.Fprint(&conf, "DefaultEnvironment=")
+	for i, v := range vars {
+		if i != 0 {
+			fmt.Fprint(&conf, " ")
+		}
+		fmt.Fprintf(&conf, "\"%s=%s\"", v[0], v[1])
+	}
+	fmt.Fprintln(&conf)
+
+	return writeConfigFile(path, []byte(conf.String()))
+}
+
+// removeMarkedBlock returns the contents without the lines between the begin and end markers, markers
+// included. It returns false if a block is not terminated.
+func removeMarkedBlock(contents []byte, begin, end string) (_ []byte, ok bool) {
+	var out []byte
+	var inBlock bool
+
+	for _, line := range bytes.SplitAfter(contents, []byte("\n")) {
+		trimmed := string(bytes.TrimSpace(line))
+		switch {
+		case !inBlock && trimmed == begin:
+			inBlock = true
+		case inBlock && trimmed == end:
+			inBlock = false
+		case !inBlock:
+			out = append(out, line...)
+		}
+	}
+
+	if inBlock {
+		return nil, false
+	}
+
+	return out, true
+}
+
+// writeConfigFile atomically writes a world-readable configuration file, creating its directory if needed.
+func writeConfigFile(path string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".new"
+	// System configuration is world-readable by convention.
+	//nolint:gosec // See above.
+	if err := os.WriteFile(tmp, contents, 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// removeIfExists removes the file at path, if there is one.
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ubuntu/decorate"
+)
+
+// servicePackage is the name of the deb package that ships this service.
+const servicePackage = "wsl-pro-service"
+
+// upgradeStateDir is where the state of the upgrades is kept. Only root can access it.
+const upgradeStateDir = "/var/lib/wsl-pro-service"
+
+// upgradeCopyDir is where local debs are copied to be verified and installed, so that the deb that is
+// installed is the one that was verified, regardless of what happens to the original on the Windows side.
+const upgradeCopyDir = upgradeStateDir + "/upgrade"
+
+// betaPPAStatePath keeps the PPA of the beta channel added to the apt sources, so that it can be removed
+// when the distro leaves the beta channel.
+const betaPPAStatePath = upgradeStateDir + "/beta-ppa"
+
+// signatureExt is the extension of the detached signature that must sit next to a local deb.
+// It contains the base64-encoded Ed25519 signature of the SHA-256 digest of the deb.
+const signatureExt = ".sig"
+
+var (
+	// packageSigningKeyFile holds the base64-encoded Ed25519 public key of the release key pair, whose private
+	// half is kept out of the repository by the release managers. The file is empty in the repository: the key is
+	// provided with UP4W_PACKAGE_SIGNING_KEY when building the source package, and debian/prepare-source.sh embeds
+	// it along with a comment recording where it comes from. The debs published for the local channel are signed
+	// with it, and the key pair is generated, as follows:
+	//
+	//	openssl genpkey -algorithm ed25519 -out release.pem
+	//	openssl pkey -in release.pem -pubout -outform DER | tail -c 32 | base64 > package-signing-key.pub
+	//	openssl dgst -sha256 -binary wsl-pro-service.deb > digest
+	//	openssl pkeyutl -sign -rawin -inkey release.pem -in digest | base64 -w0 > wsl-pro-service.deb.sig
+	//
+	// Rotating it requires re-signing the published debs, as each service only trusts the key it was built with.
+	//
+	//go:embed package-signing-key.pub
+	packageSigningKeyFile []byte
+
+	// packageSigningKey is the public key that the debs installed from the local channel must be signed with,
+	// and errPackageSigningKey the reason it could not be read. See CheckPackageSigningKey.
+	packageSigningKey, errPackageSigningKey = parsePackageSigningKey(packageSigningKeyFile)
+)
+
+// ErrNoPackageSigningKey is returned when the service was built without a package signing key, in which case
+// no deb can be installed from the local update channel.
+var ErrNoPackageSigningKey = errors.New("no package signing key was embedded in this build: debs from the local update channel cannot be verified")
+
+// PackageVerificationError is returned when a deb cannot be proven to be authentic, in which case it is not installed.
+type PackageVerificationError struct {
+	Path   string
+	Reason string
+}
+
+func (e PackageVerificationError) Error() string {
+	return fmt.Sprintf("could not verify package %q: %s", e.Path, e.Reason)
+}
+
+// parsePackageSigningKey decodes the base64-encoded public key in contents, ignoring empty lines and comments
+// starting with #. It returns a nil key and no error if there is no key.
+func parsePackageSigningKey(contents []byte) (ed25519.PublicKey, error) {
+	var b64 string
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		b64 += line
+	}
+
+	if b64 == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid package signing key: %v", err)
+	}
+
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid package signing key: it is %d bytes long instead of %d", len(key), ed25519.PublicKeySize)
+	}
+
+	return key, nil
+}
+
+// CheckPackageSigningKey returns an error if the embedded package signing key is invalid, or ErrNoPackageSigningKey
+// if there is none. It is meant to be called at start-up, so that broken builds are noticed before any upgrade.
+func (s *System) CheckPackageSigningKey() error {
+	if s.packageSigningKeyErr != nil {
+		return s.packageSigningKeyErr
+	}
+
+	if len(s.packageSigningKey) == 0 {
+		return ErrNoPackageSigningKey
+	}
+
+	return nil
+}
+
+// UpgradeService installs the latest wsl-pro-service package available in the given update channel:
+//   - stable: the archive configured in the distro.
+//   - beta: the PPA in source, which is added to the distro's apt sources.
+//   - local: the deb at the Windows path in source, whose SHA-256 checksum must match, and whose
+//     detached signature (the same path with the .sig extension) must be valid. The checksum is supplied
+//     by the agent along with the deb, so it only guards against a corrupted copy: the signature is what
+//     tells an authentic package apart.
+//
+// The PPA of the beta channel is removed from the apt sources when upgrading from any other channel
+// or PPA. Note that installing the package restarts this service. The upgrade can be preempted
+// (see WithPreemption) at the safe points before installing the package, and reports its progress
+// (see WithProgress) as it goes.
+func (s *System) UpgradeService(ctx context.Context, channel, source, checksum string) (err error) {
+	defer decorate.OnError(&err, "could not upgrade %s from the %q channel", servicePackage, channel)
+
+	switch channel {
+	case "stable":
+		if err := s.removeBetaPPA(ctx, ""); err != nil {
+			return err
+		}
+		return s.aptInstall(ctx, servicePackage)
+	case "beta":
+		if source == "" {
+			return fmt.Errorf("missing PPA")
+		}
+		if err := s.removeBetaPPA(ctx, source); err != nil {
+			return err
+		}
+		ReportProgress(ctx, 5, "Adding the beta PPA")
+		if err := s.addBetaPPA(ctx, source); err != nil {
+			return err
+		}
+		if err := SafePoint(ctx); err != nil {
+			return err
+		}
+		return s.aptInstall(ctx, servicePackage)
+	case "local":
+		if err := s.removeBetaPPA(ctx, ""); err != nil {
+			return err
+		}
+		ReportProgress(ctx, 5, "Verifying the package")
+		deb, cleanup, err := s.localDeb(ctx, source, checksum)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		return s.aptInstall(ctx, deb)
+	default:
+		return fmt.Errorf("unknown channel")
+	}
+}
+
+// addBetaPPA adds the PPA to the apt sources, and remembers it so that it can be removed later.
+func (s *System) addBetaPPA(ctx context.Context, ppa string) error {
+	// Remembering it first, so that the PPA is not left behind if it is added but not recorded.
+	if err := os.MkdirAll(s.Path(upgradeStateDir), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path(betaPPAStatePath), []byte(ppa), 0600); err != nil {
+		return fmt.Errorf("could not record the beta PPA: %v", err)
+	}
+
+	cmd := s.backend.AddAptRepositoryExecutable(ctx, "-y", ppa)
+	if _, err := runCommand(cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// removeBetaPPA removes the beta PPA added previously from the apt sources, unless it is the one to keep.
+func (s *System) removeBetaPPA(ctx context.Context, keep string) error {
+	out, err := os.ReadFile(s.Path(betaPPAStatePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not read the beta PPA: %v", err)
+	}
+
+	ppa := strings.TrimSpace(string(out))
+	if ppa == keep {
+		return nil
+	}
+
+	if ppa != "" {
+		cmd := s.backend.AddAptRepositoryExecutable(ctx, "-y", "--remove", ppa)
+		if _, err := runCommand(cmd); err != nil {
+			return fmt.Errorf("could not remove the beta PPA: %v", err)
+		}
+	}
+
+	return removeIfExists(s.Path(betaPPAStatePath))
+}
+
+// aptInstall refreshes the package index and installs the package, which can be a name or a path to a deb.
+// Packages are always authenticated by apt.
+func (s *System) aptInstall(ctx context.Context, pkg string) error {
+	ReportProgress(ctx, 10, "Refreshing the package index")
+	cmd := s.backend.AptGetExecutable(ctx, "update")
+	if _, err := runCommand(cmd); err != nil {
+		return err
+	}
+
+	// Refreshing the index can take a while and is safe to repeat, installing is not.
+	if err := SafePoint(ctx); err != nil {
+		return err
+	}
+
+	ReportProgress(ctx, 50, "Installing "+filepath.Base(pkg))
+	cmd = s.backend.AptGetExecutable(ctx, "install", "-y", pkg)
+	if _, err := runCommand(cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// localDeb translates the Windows path to a deb into a Linux path, and copies the deb and its signature into a
+// directory only root can access. It then checks that the contents of the copy match the expected SHA-256
+// checksum and verifies that the copy of the signature is valid for them, so that neither can be replaced between
+// their verification and the installation. Failures of either are reported as PackageVerificationError.
+//
+// The checksum comes from the agent, which also supplies the deb: matching it only proves that the deb was not
+// corrupted on its way into the distro, not that it is authentic. Only the signature proves the latter.
+//
+// It returns the path to the copy, which is to be removed with cleanup once installed.
+func (s *System) localDeb(ctx context.Context, pathWindows, checksum string) (deb string, cleanup func(), err error) {
+	if pathWindows == "" {
+		return "", nil, fmt.Errorf("missing path to the deb")
+	}
+
+	if err := s.CheckPackageSigningKey(); err != nil {
+		return "", nil, PackageVerificationError{Path: pathWindows, Reason: err.Error()}
+	}
+
+	cmd := s.backend.WslpathExecutable(ctx, "-ua", pathWindows)
+	out, err := runCommand(cmd)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not translate path %q to a WSL path: %v", pathWindows, err)
+	}
+
+	pathLinux := s.Path(strings.TrimSpace(string(out)))
+
+	// Leftovers of an upgrade interrupted by the restart of this service are removed as well.
+	dir := s.Path(upgradeCopyDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return "", nil, fmt.Errorf("could not clean up previous upgrades: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", nil, fmt.Errorf("could not create directory to copy the deb into: %v", err)
+	}
+	removeCopy := func() { _ = os.RemoveAll(dir) }
+	defer func() {
+		if err != nil {
+			removeCopy()
+		}
+	}()
+
+	deb = filepath.Join(dir, filepath.Base(pathLinux))
+	digest, err := copyAndHash(pathLinux, deb)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if got := hex.EncodeToString(digest); !strings.EqualFold(got, checksum) {
+		return "", nil, PackageVerificationError{Path: pathLinux, Reason: fmt.Sprintf("checksum mismatch: expected %q, got %q", checksum, got)}
+	}
+
+	sig := deb + signatureExt
+	if _, err := copyAndHash(pathLinux+signatureExt, sig); errors.Is(err, fs.ErrNotExist) {
+		return "", nil, PackageVerificationError{Path: pathLinux, Reason: "missing signature"}
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.verifySignature(pathLinux, sig, digest); err != nil {
+		return "", nil, err
+	}
+
+	return deb, removeCopy, nil
+}
+
+// copyAndHash copies the file at src into dst, and returns the SHA-256 digest of what was copied.
+func copyAndHash(src, dst string) (digest []byte, err error) {
+	defer decorate.OnError(&err, "could not copy %q", src)
+
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+		return nil, err
+	}
+
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// verifySignature checks the detached signature of the deb at path, read from sigPath, against its SHA-256 digest.
+func (s *System) verifySignature(path, sigPath string, digest []byte) error {
+	sigB64, err := os.ReadFile(sigPath)
+	if err != nil {
+		return fmt.Errorf("could not read signature of %q: %v", path, err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sigB64)))
+	if err != nil {
+		return PackageVerificationError{Path: path, Reason: fmt.Sprintf("malformed signature: %v", err)}
+	}
+
+	if !ed25519.Verify(s.packageSigningKey, digest, sig) {
+		return PackageVerificationError{Path: path, Reason: "invalid signature"}
+	}
+
+	return nil
+}
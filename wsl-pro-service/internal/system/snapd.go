@@ -0,0 +1,129 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+)
+
+// snapStoreAssertionsTimeout is how long the snap store proxy has to serve its assertions.
+const snapStoreAssertionsTimeout = 30 * time.Second
+
+// maxSnapStoreAssertionsSize is the largest response accepted from the snap store proxy.
+const maxSnapStoreAssertionsSize = 1 << 20
+
+// ConfigureSnapd sets the proxy snapd reaches the snap store through and the enterprise snap store proxy
+// it installs snaps from, as snapd does not read the proxy of the environment. Empty settings are unset,
+// restoring snapd's defaults. Distros without snapd are left untouched.
+func (s *System) ConfigureSnapd(ctx context.Context, httpProxy, httpsProxy, storeProxy, storeID string) (err error) {
+	defer decorate.OnError(&err, "could not configure snapd")
+
+	var set, unset []string
+	for _, v := range [][2]string{{"proxy.http", httpProxy}, {"proxy.https", httpsProxy}} {
+		if v[1] == "" {
+			unset = append(unset, v[0])
+			continue
+		}
+		set = append(set, v[0]+"="+v[1])
+	}
+
+	log.Infof(ctx, "Snapd: configuring proxy (http: %q, https: %q)", common.RedactURL(httpProxy), common.RedactURL(httpsProxy))
+	if err := s.snapConfigure(ctx, set, unset); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			log.Info(ctx, "Snapd: skipping configuration: snap is not installed")
+			return nil
+		}
+		return err
+	}
+
+	if storeProxy == "" {
+		log.Info(ctx, "Snapd: removing the snap store proxy")
+		return s.snapConfigure(ctx, nil, []string{"proxy.store"})
+	}
+
+	log.Infof(ctx, "Snapd: using snap store %q at %q", storeID, common.RedactURL(storeProxy))
+	if err := s.acknowledgeSnapStore(ctx, storeProxy); err != nil {
+		return err
+	}
+
+	return s.snapConfigure(ctx, []string{"proxy.store=" + storeID}, nil)
+}
+
+// snapConfigure sets and unsets system options of snapd.
+func (s *System) snapConfigure(ctx context.Context, set, unset []string) error {
+	if len(set) != 0 {
+		cmd := s.backend.SnapExecutable(ctx, append([]string{"set", "system"}, set...)...)
+		if _, err := runCommand(cmd); err != nil {
+			return err
+		}
+	}
+
+	if len(unset) != 0 {
+		cmd := s.backend.SnapExecutable(ctx, append([]string{"unset", "system"}, unset...)...)
+		if _, err := runCommand(cmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// acknowledgeSnapStore adds the assertions of the snap store proxy to snapd, so that it trusts the store.
+// The store proxy is reached directly, as it is usually deployed on premises.
+func (s *System) acknowledgeSnapStore(ctx context.Context, storeProxy string) (err error) {
+	defer decorate.OnError(&err, "could not acknowledge the snap store proxy")
+
+	u, err := url.JoinPath(strings.TrimSuffix(storeProxy, "/"), "v2/auth/store/assertions")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{
+		Timeout:   snapStoreAssertionsTimeout,
+		Transport: &http.Transport{},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q", resp.Status)
+	}
+
+	f, err := os.CreateTemp("", "snap-store-assertions-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, io.LimitReader(resp.Body, maxSnapStoreAssertionsSize))
+	if err = errors.Join(err, f.Close()); err != nil {
+		return err
+	}
+
+	cmd := s.backend.SnapExecutable(ctx, "ack", f.Name())
+	if _, err := runCommand(cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
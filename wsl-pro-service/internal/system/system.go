@@ -6,6 +6,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"strings"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/ubuntu/decorate"
 	"gopkg.in/ini.v1"
 )
@@ -29,6 +31,14 @@ type System struct {
 	cmdExe  string  // Linux path to cmd.exe
 
 	wslDistroNameCache string
+
+	// packageSigningKey verifies the signature of the debs installed from the local update channel.
+	packageSigningKey ed25519.PublicKey
+	// packageSigningKeyErr is the reason the package signing key could not be read, if any.
+	packageSigningKeyErr error
+
+	// securityCache avoids running `pro security-status` on every call to Info.
+	securityCache *securityStatusCache
 }
 
 // Backend is the engine behind the System object, and defines the interactions
@@ -38,17 +48,27 @@ type Backend interface {
 	Hostname() (string, error)
 	GetenvWslDistroName() string
 	LookupGroup(string) (*user.Group, error)
+	LookupUser(string) (*user.User, error)
 
 	ProExecutable(ctx context.Context, args ...string) *exec.Cmd
 	LandscapeConfigExecutable(ctx context.Context, args ...string) *exec.Cmd
 	WslpathExecutable(ctx context.Context, args ...string) *exec.Cmd
 	WslinfoExecutable(ctx context.Context, args ...string) *exec.Cmd
+	UsgExecutable(ctx context.Context, args ...string) *exec.Cmd
+	AptGetExecutable(ctx context.Context, args ...string) *exec.Cmd
+	AddAptRepositoryExecutable(ctx context.Context, args ...string) *exec.Cmd
+	JournalctlExecutable(ctx context.Context, args ...string) *exec.Cmd
+	UseraddExecutable(ctx context.Context, args ...string) *exec.Cmd
+	UsermodExecutable(ctx context.Context, args ...string) *exec.Cmd
+	SnapExecutable(ctx context.Context, args ...string) *exec.Cmd
 
 	CmdExe(ctx context.Context, path string, args ...string) *exec.Cmd
 }
 
 type options struct {
-	backend Backend
+	backend              Backend
+	packageSigningKey    ed25519.PublicKey
+	packageSigningKeyErr error
 }
 
 // Option is an optional argument for New.
@@ -62,16 +82,32 @@ func WithTestBackend(b Backend) Option {
 	}
 }
 
+// WithPackageSigningKey is an optional argument for New that replaces the key embedded at build time,
+// which is used to verify the debs installed from the local update channel. A nil key trusts no deb.
+func WithPackageSigningKey(key ed25519.PublicKey) Option {
+	return func(o *options) {
+		o.packageSigningKey = key
+		o.packageSigningKeyErr = nil
+	}
+}
+
 // New instantiates a stateless object that mediates interactions with the filesystem
 // as well as a few key executables.
 func New(args ...Option) *System {
-	opts := options{backend: realBackend{}}
+	opts := options{
+		backend:              realBackend{},
+		packageSigningKey:    packageSigningKey,
+		packageSigningKeyErr: errPackageSigningKey,
+	}
 	for _, f := range args {
 		f(&opts)
 	}
 
 	s := &System{
-		backend: opts.backend,
+		backend:              opts.backend,
+		packageSigningKey:    opts.packageSigningKey,
+		packageSigningKeyErr: opts.packageSigningKeyErr,
+		securityCache:        &securityStatusCache{},
 	}
 
 	return s
@@ -85,29 +121,66 @@ func (s System) Info(ctx context.Context) (*agentapi.DistroInfo, error) {
 		return nil, err
 	}
 
-	pro, err := s.ProStatus(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("could not obtain pro status: %v", err)
-	}
-
 	hostname, err := s.backend.Hostname()
 	if err != nil {
 		return nil, fmt.Errorf("could not obtain hostname: %v", err)
 	}
 
 	info := &agentapi.DistroInfo{
-		WslName:     distroName,
-		ProAttached: pro,
-		Hostname:    hostname,
+		WslName:  distroName,
+		Hostname: hostname,
 	}
 
 	if err := s.fillOsRelease(info); err != nil {
 		return nil, err
 	}
 
+	// The agent only needs the version to tell which features apply: failing to detect it must not prevent the
+	// distro from connecting.
+	if version, err := s.WslVersion(); err != nil {
+		log.Warningf(ctx, "could not detect the WSL version: %v", err)
+	} else {
+		info.WslVersion = int32(version)
+	}
+
+	pro, err := s.proStatus(ctx)
+	if reason := proClientUnavailable(err); reason != "" {
+		// Minimal images and containers imported as distros can still be managed, only not attached.
+		info.UnsupportedReason = reason
+		return info, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not obtain pro status: %v", err)
+	}
+
+	info.ProAttached = pro.Attached
+	info.ProServices = pro.EntitledServices()
+	info.ProExpires = formatExpires(pro)
+	info.ProSupportLevel = pro.SupportLevel
+
+	// The security status is informative only: failing to obtain it must not prevent the distro from connecting.
+	if info.SecurityStatus, err = s.securityStatus(ctx); err != nil {
+		log.Warningf(ctx, "could not obtain security status: %v", err)
+	}
+
 	return info, nil
 }
 
+// WslVersion returns the version of WSL the distro runs on: 1 or 2. WSL 1 has no Linux kernel, so the kernel
+// release it reports is that of the Windows build, ending in "-Microsoft", unlike the kernels of WSL 2.
+func (s System) WslVersion() (version int, err error) {
+	const fileName = "/proc/sys/kernel/osrelease"
+
+	out, err := os.ReadFile(s.backend.Path(fileName))
+	if err != nil {
+		return 0, fmt.Errorf("could not read %s: %v", fileName, err)
+	}
+
+	if strings.HasSuffix(strings.TrimSpace(string(out)), "-Microsoft") {
+		return 1, nil
+	}
+	return 2, nil
+}
+
 // fillOSRelease fills the info with os-release file content.
 func (s System) fillOsRelease(info *agentapi.DistroInfo) error {
 	const fileName = "/etc/os-release"
@@ -226,7 +299,7 @@ func runCommand(cmd *exec.Cmd) ([]byte, error) {
 	err := cmd.Run()
 	out := bytes.TrimSpace(stdout.Bytes())
 	if err != nil {
-		return out, fmt.Errorf("%s: error: %v.\n    Stdout: %s\n    Stderr: %s", cmd.Path, err, out, stderr.String())
+		return out, fmt.Errorf("%s: error: %w.\n    Stdout: %s\n    Stderr: %s", cmd.Path, err, out, stderr.String())
 	}
 
 	return out, nil
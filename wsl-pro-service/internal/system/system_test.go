@@ -1,11 +1,22 @@
 package system_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	commontestutils "github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
@@ -66,11 +77,19 @@ func TestInfo(t *testing.T) {
 		proStatusCommand mockBehaviour
 		osRelease        mockBehaviour
 
-		hostnameErr bool
+		hostnameErr       bool
+		securityStatusErr bool
+		proNotInstalled   bool
+		wsl1              bool
+		noKernelRelease   bool
 
 		wantErr bool
 	}{
-		"Success": {},
+		"Success":                                      {},
+		"Success when the distro runs on WSL 1":        {wsl1: true},
+		"Success when the WSL version cannot be found": {noKernelRelease: true},
+		"Success when pro security-status fails":       {securityStatusErr: true},
+		"Success when the pro client is not installed": {proNotInstalled: true},
 
 		"Error when WslDistroName fails": {badWslDistroName: true, wantErr: true},
 
@@ -100,6 +119,22 @@ func TestInfo(t *testing.T) {
 				mock.DistroHostname = nil
 			}
 
+			if tc.securityStatusErr {
+				mock.SetControlArg(testutils.ProSecurityStatusErr)
+			}
+
+			if tc.proNotInstalled {
+				mock.SetControlArg(testutils.ProNotInstalled)
+			}
+
+			if tc.wsl1 {
+				err := os.WriteFile(mock.Path("/proc/sys/kernel/osrelease"), []byte("4.4.0-19041-Microsoft\n"), 0600)
+				require.NoError(t, err, "Setup: could not overwrite /proc/sys/kernel/osrelease")
+			}
+			if tc.noKernelRelease {
+				require.NoError(t, os.Remove(mock.Path("/proc/sys/kernel/osrelease")), "Setup: could not remove /proc/sys/kernel/osrelease")
+			}
+
 			switch tc.proStatusCommand {
 			case mockOK:
 			case mockError:
@@ -133,7 +168,89 @@ func TestInfo(t *testing.T) {
 			assert.Equal(t, "22.04", info.GetVersionId(), "VersionId does not match expected value")
 			assert.Equal(t, "Ubuntu 22.04.1 LTS", info.GetPrettyName(), "PrettyName does not match expected value")
 			assert.Equal(t, "TEST_DISTRO_HOSTNAME", info.GetHostname(), "Hostname does not match expected value")
+
+			wantWslVersion := int32(2)
+			if tc.wsl1 {
+				wantWslVersion = 1
+			} else if tc.noKernelRelease {
+				wantWslVersion = 0
+			}
+			assert.Equal(t, wantWslVersion, info.GetWslVersion(), "WslVersion does not match expected value")
+
+			if tc.proNotInstalled {
+				assert.NotEmpty(t, info.GetUnsupportedReason(), "UnsupportedReason should be set when the pro client is not installed")
+				assert.False(t, info.GetProAttached(), "ProAttached should be unset when the pro client is not installed")
+				assert.Nil(t, info.GetSecurityStatus(), "SecurityStatus should be unset when the pro client is not installed")
+				return
+			}
+			assert.Empty(t, info.GetUnsupportedReason(), "UnsupportedReason should be unset when the pro client can run")
 			assert.True(t, info.GetProAttached(), "ProAttached does not match expected value")
+			assert.Equal(t, []string{"esm-apps"}, info.GetProServices(), "ProServices does not match expected value")
+			assert.Equal(t, "2030-01-01T00:00:00Z", info.GetProExpires(), "ProExpires does not match expected value")
+			assert.Equal(t, "essential", info.GetProSupportLevel(), "ProSupportLevel does not match expected value")
+
+			if tc.securityStatusErr {
+				assert.Nil(t, info.GetSecurityStatus(), "SecurityStatus should be unset when it cannot be obtained")
+				return
+			}
+			assert.Equal(t, int32(2), info.GetSecurityStatus().GetStandardUpdates(), "StandardUpdates does not match expected value")
+			assert.Equal(t, int32(4), info.GetSecurityStatus().GetEsmUpdates(), "EsmUpdates does not match expected value")
+		})
+	}
+}
+
+func TestInfoCachesSecurityStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		changePackages bool
+		changeLists    bool
+
+		wantCached bool
+	}{
+		"Success reusing the security status when nothing changed": {wantCached: true},
+
+		"Success refreshing the security status when packages change":      {changePackages: true},
+		"Success refreshing the security status when package lists change": {changeLists: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			system, mock := testutils.MockSystem(t)
+
+			dpkgStatus := mock.Path("/var/lib/dpkg/status")
+			aptLists := mock.Path("/var/lib/apt/lists")
+			require.NoError(t, os.MkdirAll(filepath.Dir(dpkgStatus), 0700), "Setup: could not create dpkg directory")
+			require.NoError(t, os.WriteFile(dpkgStatus, []byte("Package: wsl-pro-service\n"), 0600), "Setup: could not write dpkg status")
+			require.NoError(t, os.MkdirAll(aptLists, 0700), "Setup: could not create apt lists directory")
+
+			info, err := system.Info(ctx)
+			require.NoError(t, err, "Setup: Info should return no errors")
+			require.NotNil(t, info.GetSecurityStatus(), "Setup: Info should return the security status")
+
+			// Further calls to pro security-status fail, so only a cached status can be returned.
+			mock.SetControlArg(testutils.ProSecurityStatusErr)
+
+			later := time.Now().Add(time.Minute)
+			if tc.changePackages {
+				require.NoError(t, os.Chtimes(dpkgStatus, later, later), "Setup: could not touch dpkg status")
+			}
+			if tc.changeLists {
+				require.NoError(t, os.Chtimes(aptLists, later, later), "Setup: could not touch apt lists")
+			}
+
+			info, err = system.Info(ctx)
+			require.NoError(t, err, "Info should return no errors")
+
+			if !tc.wantCached {
+				require.Nil(t, info.GetSecurityStatus(), "Info should have run pro security-status again")
+				return
+			}
+			require.Equal(t, int32(2), info.GetSecurityStatus().GetStandardUpdates(), "Info should return the cached security status")
+			require.Equal(t, int32(4), info.GetSecurityStatus().GetEsmUpdates(), "Info should return the cached security status")
 		})
 	}
 }
@@ -426,6 +543,531 @@ func TestProDetach(t *testing.T) {
 	}
 }
 
+func TestProEnableDisableService(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		disable bool
+		proErr  bool
+
+		wantErr bool
+	}{
+		"Success enabling a service":  {},
+		"Success disabling a service": {disable: true},
+
+		"Error on 'pro enable' error":  {proErr: true, wantErr: true},
+		"Error on 'pro disable' error": {disable: true, proErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			system, mock := testutils.MockSystem(t)
+
+			verb := "enable"
+			f := system.ProEnableService
+			if tc.disable {
+				verb = "disable"
+				f = system.ProDisableService
+			}
+
+			if tc.proErr && tc.disable {
+				mock.SetControlArg(testutils.ProDisableErr)
+			} else if tc.proErr {
+				mock.SetControlArg(testutils.ProEnableErr)
+			}
+
+			err := f(context.Background(), "esm-apps")
+			if tc.wantErr {
+				require.Errorf(t, err, "Expected pro %s to return an error", verb)
+				return
+			}
+			require.NoErrorf(t, err, "Expected pro %s to return no errors", verb)
+
+			require.FileExists(t, filepath.Join(mock.FsRoot, fmt.Sprintf(".pro-%sd-esm-apps", verb)), "The pro executable should have been called")
+		})
+	}
+}
+
+func TestUsgFix(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		usgErr bool
+
+		wantErr bool
+	}{
+		"Success": {},
+
+		"Error on 'usg fix' error": {usgErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			system, mock := testutils.MockSystem(t)
+			if tc.usgErr {
+				mock.SetControlArg(testutils.UsgFixErr)
+			}
+
+			err := system.UsgFix(context.Background(), "cis_level1_server")
+			if tc.wantErr {
+				require.Error(t, err, "Expected usg fix to return an error")
+				return
+			}
+			require.NoError(t, err, "Expected usg fix to return no errors")
+
+			require.FileExists(t, filepath.Join(mock.FsRoot, ".usg-fixed-cis_level1_server"), "The usg executable should have been called")
+		})
+	}
+}
+
+func TestUsgAudit(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		usgErr bool
+
+		wantErr bool
+	}{
+		"Success": {},
+
+		"Error on 'usg audit' error": {usgErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			system, mock := testutils.MockSystem(t)
+			if tc.usgErr {
+				mock.SetControlArg(testutils.UsgAuditErr)
+			}
+
+			report, err := system.UsgAudit(context.Background(), "cis_level1_server")
+			if tc.wantErr {
+				require.Error(t, err, "Expected usg audit to return an error")
+				return
+			}
+			require.NoError(t, err, "Expected usg audit to return no errors")
+
+			require.Equal(t, "<html>cis_level1_server</html>", string(report), "Mismatched audit report")
+		})
+	}
+}
+
+func TestManageUser(t *testing.T) {
+	t.Parallel()
+
+	const existingWslConf = "[boot]\nsystemd = true\n\n[user]\ndefault = olduser\n"
+
+	testCases := map[string]struct {
+		name       string
+		groups     []string
+		setDefault bool
+
+		userExists   bool
+		wslConf      string
+		breakWslConf bool
+		useraddErr   bool
+		usermodErr   bool
+		preempt      bool
+
+		wantUseradd bool
+		wantUsermod string
+		wantErr     bool
+	}{
+		"Success creating a user":                      {wantUseradd: true},
+		"Success with a user that already exists":      {userExists: true},
+		"Success adding the user to groups":            {groups: []string{"sudo", "docker"}, wantUseradd: true, wantUsermod: "sudo,docker"},
+		"Success setting the default user":             {setDefault: true, wantUseradd: true},
+		"Success setting the default user in wsl.conf": {setDefault: true, userExists: true, wslConf: existingWslConf},
+
+		"Error when the user name is invalid":          {name: "Bad User", wantErr: true},
+		"Error when a group name is invalid":           {groups: []string{"sudo", "../evil"}, wantErr: true},
+		"Error when useradd fails":                     {useraddErr: true, wantErr: true},
+		"Error when usermod fails":                     {groups: []string{"sudo"}, usermodErr: true, wantErr: true},
+		"Error when wsl.conf cannot be parsed":         {setDefault: true, wslConf: "[unclosed\n", wantErr: true},
+		"Error when wsl.conf cannot be written":        {setDefault: true, breakWslConf: true, wantErr: true},
+		"Error when preempted after creating the user": {groups: []string{"sudo"}, setDefault: true, preempt: true, wantUseradd: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.name == "" {
+				tc.name = "ubuntu"
+			}
+
+			sys, mock := testutils.MockSystem(t)
+			if tc.userExists {
+				mock.Users = []string{tc.name}
+			}
+			if tc.useraddErr {
+				mock.SetControlArg(testutils.UseraddErr)
+			}
+			if tc.usermodErr {
+				mock.SetControlArg(testutils.UsermodErr)
+			}
+
+			wslConf := mock.Path("/etc/wsl.conf")
+			if tc.wslConf != "" {
+				err := os.WriteFile(wslConf, []byte(tc.wslConf), 0600)
+				require.NoError(t, err, "Setup: could not write wsl.conf")
+			}
+			if tc.breakWslConf {
+				commontestutils.ReplaceFileWithDir(t, wslConf+".new", "Setup: could not create directory to interfere with wsl.conf")
+			}
+
+			ctx, preempt := system.WithPreemption(context.Background())
+			if tc.preempt {
+				preempt()
+			}
+
+			err := sys.ManageUser(ctx, tc.name, tc.groups, tc.setDefault)
+			if tc.wantErr {
+				require.Error(t, err, "ManageUser should return an error")
+				require.Equal(t, tc.preempt, errors.Is(err, system.ErrPreempted), "Only preempted calls should return ErrPreempted")
+				if tc.preempt {
+					require.FileExists(t, mock.Path(".useradd-"+tc.name), "useradd should have been called before the first safe point")
+					require.NoFileExists(t, mock.Path(".usermod-"+tc.name), "usermod should not have been called once preempted")
+					require.NoFileExists(t, wslConf, "wsl.conf should not have been written once preempted")
+				}
+				return
+			}
+			require.NoError(t, err, "ManageUser should return no errors")
+
+			if tc.wantUseradd {
+				require.FileExists(t, mock.Path(".useradd-"+tc.name), "useradd should have been called")
+			} else {
+				require.NoFileExists(t, mock.Path(".useradd-"+tc.name), "useradd should not have been called")
+			}
+
+			if tc.wantUsermod != "" {
+				groups, err := os.ReadFile(mock.Path(".usermod-" + tc.name))
+				require.NoError(t, err, "usermod should have been called")
+				require.Equal(t, tc.wantUsermod, string(groups), "usermod should have been called with the requested groups")
+			} else {
+				require.NoFileExists(t, mock.Path(".usermod-"+tc.name), "usermod should not have been called")
+			}
+
+			if !tc.setDefault {
+				require.NoFileExists(t, wslConf, "wsl.conf should not have been written")
+				return
+			}
+
+			got, err := os.ReadFile(wslConf)
+			require.NoError(t, err, "wsl.conf should have been written")
+
+			want := commontestutils.LoadWithUpdateFromGolden(t, string(got))
+			require.Equal(t, want, string(got), "Mismatched wsl.conf contents")
+		})
+	}
+}
+
+func TestUpgradeService(t *testing.T) {
+	t.Parallel()
+
+	const (
+		ppa      = "ppa:ubuntu-wsl-dev/ppa"
+		debPath  = `D:\Users\TestUser\wsl-pro-service.deb`
+		debLinux = "mnt/d/Users/TestUser/wsl-pro-service.deb"
+		debCopy  = "var/lib/wsl-pro-service/upgrade/wsl-pro-service.deb"
+		ppaState = "var/lib/wsl-pro-service/beta-ppa"
+	)
+
+	debContents := []byte("deb contents")
+	sum := sha256.Sum256(debContents)
+	checksum := hex.EncodeToString(sum[:])
+
+	testCases := map[string]struct {
+		channel  string
+		source   string
+		checksum string
+
+		noDeb                 bool
+		noSigningKey          bool
+		signature             string
+		previousPPA           string
+		breakAptGetUpdate     bool
+		breakAptGetInstall    bool
+		breakAddAptRepository bool
+		breakWslpath          bool
+		preempt               bool
+
+		wantInstalled         string
+		wantRepository        string
+		wantRemovedRepository string
+		wantVerificationErr   bool
+		wantErr               bool
+	}{
+		"Success with the stable channel":                    {channel: "stable", wantInstalled: "wsl-pro-service"},
+		"Success with the beta channel":                      {channel: "beta", source: ppa, wantInstalled: "wsl-pro-service", wantRepository: ppa},
+		"Success with the local channel":                     {channel: "local", source: debPath, checksum: checksum, wantInstalled: debCopy},
+		"Success with the local channel and upper case hash": {channel: "local", source: debPath, checksum: strings.ToUpper(checksum), wantInstalled: debCopy},
+
+		"Success removing the beta PPA when moving to the stable channel": {channel: "stable", previousPPA: ppa, wantInstalled: "wsl-pro-service", wantRemovedRepository: ppa},
+		"Success removing the beta PPA when moving to the local channel":  {channel: "local", source: debPath, checksum: checksum, previousPPA: ppa, wantInstalled: debCopy, wantRemovedRepository: ppa},
+		"Success replacing the beta PPA when it changes":                  {channel: "beta", source: ppa, previousPPA: "ppa:ubuntu-wsl-dev/old", wantInstalled: "wsl-pro-service", wantRepository: ppa, wantRemovedRepository: "ppa:ubuntu-wsl-dev/old"},
+		"Success keeping the beta PPA when it does not change":            {channel: "beta", source: ppa, previousPPA: ppa, wantInstalled: "wsl-pro-service", wantRepository: ppa},
+
+		"Error with an unknown channel":                                 {channel: "nightly", wantErr: true},
+		"Error with the beta channel and no PPA":                        {channel: "beta", wantErr: true},
+		"Error with the local channel and no path":                      {channel: "local", checksum: checksum, wantErr: true},
+		"Error with the local channel and a wrong hash":                 {channel: "local", source: debPath, checksum: strings.Repeat("0", 64), wantVerificationErr: true, wantErr: true},
+		"Error with the local channel and no signature":                 {channel: "local", source: debPath, checksum: checksum, signature: "-", wantVerificationErr: true, wantErr: true},
+		"Error with the local channel and a malformed signature":        {channel: "local", source: debPath, checksum: checksum, signature: "not base64!", wantVerificationErr: true, wantErr: true},
+		"Error with the local channel and a signature from another deb": {channel: "local", source: debPath, checksum: checksum, signature: string(testutils.SignPackage([]byte("other contents"))), wantVerificationErr: true, wantErr: true},
+		"Error with the local channel and no deb":                       {channel: "local", source: debPath, checksum: checksum, noDeb: true, wantErr: true},
+		"Error with the local channel and no signing key":               {channel: "local", source: debPath, checksum: checksum, noSigningKey: true, wantVerificationErr: true, wantErr: true},
+		"Error when wslpath fails":                                      {channel: "local", source: debPath, checksum: checksum, breakWslpath: true, wantErr: true},
+		"Error when apt-get update fails":                               {channel: "stable", breakAptGetUpdate: true, wantErr: true},
+		"Error when apt-get install fails":                              {channel: "stable", breakAptGetInstall: true, wantErr: true},
+		"Error when add-apt-repository fails":                           {channel: "beta", source: ppa, breakAddAptRepository: true, wantErr: true},
+		"Error when preempted":                                          {channel: "stable", preempt: true, wantErr: true},
+		"Error when preempted with the beta channel":                    {channel: "beta", source: ppa, preempt: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var opts []system.Option
+			if tc.noSigningKey {
+				opts = append(opts, system.WithPackageSigningKey(nil))
+			}
+
+			sys, mock := testutils.MockSystem(t, opts...)
+			if tc.breakAptGetUpdate {
+				mock.SetControlArg(testutils.AptGetUpdateErr)
+			}
+			if tc.breakAptGetInstall {
+				mock.SetControlArg(testutils.AptGetInstallErr)
+			}
+			if tc.breakAddAptRepository {
+				mock.SetControlArg(testutils.AddAptRepositoryErr)
+			}
+			if tc.breakWslpath {
+				mock.SetControlArg(testutils.WslpathErr)
+			}
+
+			if !tc.noDeb {
+				p := filepath.Join(mock.FsRoot, debLinux)
+				require.NoError(t, os.MkdirAll(filepath.Dir(p), 0750), "Setup: could not create directory for the deb")
+				require.NoError(t, os.WriteFile(p, debContents, 0600), "Setup: could not write deb")
+
+				sig := testutils.SignPackage(debContents)
+				if tc.signature != "" {
+					sig = []byte(tc.signature)
+				}
+				if tc.signature != "-" {
+					require.NoError(t, os.WriteFile(p+".sig", sig, 0600), "Setup: could not write signature")
+				}
+			}
+
+			if tc.previousPPA != "" {
+				p := filepath.Join(mock.FsRoot, ppaState)
+				require.NoError(t, os.MkdirAll(filepath.Dir(p), 0700), "Setup: could not create directory for the beta PPA")
+				require.NoError(t, os.WriteFile(p, []byte(tc.previousPPA), 0600), "Setup: could not write beta PPA")
+			}
+
+			ctx, preempt := system.WithPreemption(context.Background())
+			if tc.preempt {
+				preempt()
+			}
+
+			err := sys.UpgradeService(ctx, tc.channel, tc.source, tc.checksum)
+			if tc.wantErr {
+				require.Error(t, err, "Expected UpgradeService to return an error")
+				require.Equal(t, tc.preempt, errors.Is(err, system.ErrPreempted), "Only preempted upgrades should return ErrPreempted")
+				var verificationErr system.PackageVerificationError
+				require.Equal(t, tc.wantVerificationErr, errors.As(err, &verificationErr), "Only unverifiable debs should return PackageVerificationError")
+				require.NoFileExists(t, filepath.Join(mock.FsRoot, ".apt-installed"), "No package should have been installed")
+				return
+			}
+			require.NoError(t, err, "Expected UpgradeService to return no errors")
+
+			want := tc.wantInstalled
+			if want != "wsl-pro-service" {
+				want = filepath.Join(mock.FsRoot, want)
+			}
+			got, err := os.ReadFile(filepath.Join(mock.FsRoot, ".apt-installed"))
+			require.NoError(t, err, "apt-get install should have been called")
+			require.Equal(t, want, string(got), "apt-get install was called with the wrong package")
+			require.NoDirExists(t, filepath.Join(mock.FsRoot, filepath.Dir(debCopy)), "The copy of the deb should have been removed after installing it")
+
+			if tc.wantRemovedRepository == "" {
+				require.NoFileExists(t, filepath.Join(mock.FsRoot, ".apt-repository-removed"), "No repository should have been removed")
+			} else {
+				got, err = os.ReadFile(filepath.Join(mock.FsRoot, ".apt-repository-removed"))
+				require.NoError(t, err, "add-apt-repository --remove should have been called")
+				require.Equal(t, tc.wantRemovedRepository, string(got), "add-apt-repository --remove was called with the wrong repository")
+			}
+
+			if tc.wantRepository == "" {
+				require.NoFileExists(t, filepath.Join(mock.FsRoot, ppaState), "No beta PPA should be left recorded")
+				require.NoFileExists(t, filepath.Join(mock.FsRoot, ".apt-repository"), "add-apt-repository should not have been called")
+				return
+			}
+			got, err = os.ReadFile(filepath.Join(mock.FsRoot, ".apt-repository"))
+			require.NoError(t, err, "add-apt-repository should have been called")
+			require.Equal(t, tc.wantRepository, string(got), "add-apt-repository was called with the wrong repository")
+
+			got, err = os.ReadFile(filepath.Join(mock.FsRoot, ppaState))
+			require.NoError(t, err, "The beta PPA should have been recorded")
+			require.Equal(t, tc.wantRepository, string(got), "The wrong beta PPA was recorded")
+		})
+	}
+}
+
+func TestParsePackageSigningKey(t *testing.T) {
+	t.Parallel()
+
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, ed25519.PublicKeySize))
+
+	testCases := map[string]struct {
+		contents string
+
+		wantKey bool
+		wantErr bool
+	}{
+		"Success with a key":                    {contents: key + "\n", wantKey: true},
+		"Success with a key and its origin":     {contents: "# Origin: release managers\n\n" + key + "\n", wantKey: true},
+		"Success with no key":                   {},
+		"Success with only comments":            {contents: "# No key was provided\n"},
+		"Error with a key that is not base64":   {contents: "not base64!", wantErr: true},
+		"Error with a key of the wrong size":    {contents: base64.StdEncoding.EncodeToString([]byte("too short")), wantErr: true},
+		"Error with a key that is split in two": {contents: key + "\n" + key, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := system.ParsePackageSigningKey([]byte(tc.contents))
+			if tc.wantErr {
+				require.Error(t, err, "ParsePackageSigningKey should have returned an error")
+				return
+			}
+			require.NoError(t, err, "ParsePackageSigningKey should not have returned an error")
+
+			if !tc.wantKey {
+				require.Nil(t, got, "ParsePackageSigningKey should not have returned a key")
+				return
+			}
+			require.Equal(t, ed25519.PublicKey(bytes.Repeat([]byte{1}, ed25519.PublicKeySize)), got, "ParsePackageSigningKey returned the wrong key")
+		})
+	}
+}
+
+func TestLocalDebSignatureCopy(t *testing.T) {
+	t.Parallel()
+
+	const (
+		debPath  = `D:\Users\TestUser\wsl-pro-service.deb`
+		debLinux = "mnt/d/Users/TestUser/wsl-pro-service.deb"
+	)
+
+	debContents := []byte("deb contents")
+	sum := sha256.Sum256(debContents)
+	sig := testutils.SignPackage(debContents)
+
+	sys, mock := testutils.MockSystem(t)
+
+	p := filepath.Join(mock.FsRoot, debLinux)
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0750), "Setup: could not create directory for the deb")
+	require.NoError(t, os.WriteFile(p, debContents, 0600), "Setup: could not write deb")
+	require.NoError(t, os.WriteFile(p+".sig", sig, 0600), "Setup: could not write signature")
+
+	deb, cleanup, err := sys.LocalDeb(context.Background(), debPath, hex.EncodeToString(sum[:]))
+	require.NoError(t, err, "LocalDeb should verify the deb")
+
+	// Replacing the signature on the Windows side after the verification must not affect the verified copy.
+	require.NoError(t, os.WriteFile(p+".sig", []byte("tampered"), 0600), "Setup: could not replace signature")
+
+	got, err := os.ReadFile(deb + ".sig")
+	require.NoError(t, err, "The signature should have been copied next to the deb")
+	require.Equal(t, string(sig), string(got), "The copy of the signature should be the one that was verified")
+
+	cleanup()
+	require.NoDirExists(t, filepath.Dir(deb), "Cleanup should remove the copies of the deb and its signature")
+}
+
+func TestJournalTail(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		lines            int
+		priority         string
+		includeProClient bool
+		follow           bool
+		journalctlErr    bool
+		breakSend        bool
+
+		want    []string
+		wantErr bool
+	}{
+		"Success":                       {lines: 100, want: []string{"wsl-pro-service.service: mock line (lines: 100, priority: all)"}},
+		"Success filtering by priority": {lines: 5, priority: "err", want: []string{"wsl-pro-service.service: mock line (lines: 5, priority: err)"}},
+		"Success including the Pro client": {lines: 5, includeProClient: true, want: []string{
+			"wsl-pro-service.service: mock line (lines: 5, priority: all)",
+			"ubuntu-advantage.service: mock line (lines: 5, priority: all)",
+		}},
+		"Success following until cancelled": {lines: 5, follow: true, want: []string{
+			"wsl-pro-service.service: mock line (lines: 5, priority: all)",
+			"mock followed line 0",
+			"mock followed line 1",
+		}},
+
+		"Error with no lines":                 {lines: 0, wantErr: true},
+		"Error with an unknown priority":      {lines: 5, priority: "loud", wantErr: true},
+		"Error when journalctl fails":         {lines: 5, journalctlErr: true, wantErr: true},
+		"Error when the lines cannot be sent": {lines: 5, follow: true, breakSend: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			system, mock := testutils.MockSystem(t)
+			if tc.journalctlErr {
+				mock.SetControlArg(testutils.JournalctlErr)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			var got []string
+			err := system.JournalTail(ctx, tc.lines, tc.priority, tc.includeProClient, tc.follow, func(line string) error {
+				if tc.breakSend {
+					return errors.New("mock error")
+				}
+				got = append(got, line)
+				if tc.follow && len(got) == len(tc.want) {
+					cancel()
+				}
+				return nil
+			})
+
+			if tc.wantErr {
+				require.Error(t, err, "Expected JournalTail to return an error")
+				return
+			}
+			if tc.follow {
+				require.ErrorIs(t, err, context.Canceled, "Following should only stop when cancelled")
+				// Lines may be read after cancelling and before journalctl is stopped.
+				got = got[:len(tc.want)]
+			} else {
+				require.NoError(t, err, "Expected JournalTail to return no errors")
+			}
+			require.Equal(t, tc.want, got, "Mismatched journal lines")
+		})
+	}
+}
+
 func TestLandscapeEnable(t *testing.T) {
 	t.Parallel()
 
@@ -529,6 +1171,398 @@ func TestLandscapeEnable(t *testing.T) {
 	}
 }
 
+func TestConfigurePatching(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		level          string
+		previousConfig bool
+		breakAptConfD  bool
+
+		wantNoConfig bool
+		wantErr      bool
+	}{
+		"Success with security-only":                   {level: "security-only"},
+		"Success with security+updates":                {level: "security+updates"},
+		"Success with all":                             {level: "all"},
+		"Success overwriting a previous configuration": {level: "all", previousConfig: true},
+		"Success removing the configuration":           {previousConfig: true, wantNoConfig: true},
+		"Success removing a missing configuration":     {wantNoConfig: true},
+
+		"Error with an unknown level":             {level: "everything", wantErr: true},
+		"Error when apt.conf.d cannot be created": {level: "all", breakAptConfD: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			s, mock := testutils.MockSystem(t)
+			path := mock.Path(system.PatchingConfigPath)
+
+			if tc.previousConfig {
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700), "Setup: could not create apt.conf.d")
+				require.NoError(t, os.WriteFile(path, []byte("previous config"), 0600), "Setup: could not write previous config")
+			}
+			if tc.breakAptConfD {
+				require.NoError(t, os.MkdirAll(mock.Path("/etc/apt"), 0700), "Setup: could not create /etc/apt")
+				require.NoError(t, os.WriteFile(filepath.Dir(path), nil, 0600), "Setup: could not break apt.conf.d")
+			}
+
+			err := s.ConfigurePatching(ctx, tc.level)
+			if tc.wantErr {
+				require.Error(t, err, "ConfigurePatching should have returned an error")
+				return
+			}
+			require.NoError(t, err, "ConfigurePatching should have succeeded")
+
+			if tc.wantNoConfig {
+				require.NoFileExists(t, path, "The patching configuration should have been removed")
+				return
+			}
+
+			got, err := os.ReadFile(path)
+			require.NoError(t, err, "Could not read the patching configuration")
+
+			want := commontestutils.LoadWithUpdateFromGolden(t, string(got))
+			require.Equal(t, want, string(got), "Unexpected patching configuration")
+		})
+	}
+}
+
+func TestConfigureProxy(t *testing.T) {
+	t.Parallel()
+
+	const previousEnvironment = `PATH="/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+# BEGIN Ubuntu Pro for WSL proxy settings. Local changes in this block will be overwritten.
+http_proxy="http://old.example.com:3128"
+# END Ubuntu Pro for WSL proxy settings
+LANG="C.UTF-8"
+`
+
+	testCases := map[string]struct {
+		http    string
+		https   string
+		noProxy string
+
+		environment      string
+		previousConfig   bool
+		breakAptConfD    bool
+		breakEnvironment bool
+		breakSystemConfD bool
+
+		wantNoConfig bool
+		wantErr      bool
+	}{
+		"Success with HTTP and HTTPS proxies":                                 {http: "http://proxy.example.com:3128", https: "http://proxy.example.com:3129"},
+		"Success with an HTTPS proxy and exceptions":                          {https: "http://proxy.example.com:3128", noProxy: "localhost,127.0.0.1,.internal"},
+		"Success keeping the rest of the environment":                         {http: "http://proxy.example.com:3128", environment: "PATH=\"/usr/bin:/bin\""},
+		"Success replacing a previous configuration":                          {http: "http://proxy.example.com:3128", environment: previousEnvironment, previousConfig: true},
+		"Success removing the configuration":                                  {environment: previousEnvironment, previousConfig: true, wantNoConfig: true},
+		"Success removing a missing configuration":                            {wantNoConfig: true},
+		"Success leaving an environment with an unterminated block untouched": {http: "http://proxy.example.com:3128", environment: "PATH=\"/usr/bin:/bin\"\n# BEGIN Ubuntu Pro for WSL proxy settings. Local changes in this block will be overwritten.\nLANG=\"C.UTF-8\"\n"},
+		"Success ignoring exceptions without a proxy":                         {noProxy: "localhost", wantNoConfig: true},
+
+		"Error with whitespace in a proxy":           {http: "http://proxy.example.com:3128 -o", wantErr: true},
+		"Error with quotes in the exceptions":        {http: "http://proxy.example.com:3128", noProxy: `localhost"`, wantErr: true},
+		"Error when apt.conf.d cannot be created":    {http: "http://proxy.example.com:3128", breakAptConfD: true, wantErr: true},
+		"Error when /etc/environment cannot be read": {http: "http://proxy.example.com:3128", breakEnvironment: true, wantErr: true},
+		"Error when system.conf.d cannot be created": {http: "http://proxy.example.com:3128", breakSystemConfD: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			s, mock := testutils.MockSystem(t)
+			aptPath := mock.Path(system.ProxyAptConfigPath)
+			envPath := mock.Path(system.EnvironmentPath)
+			systemdPath := mock.Path(system.ProxySystemdConfigPath)
+
+			require.NoError(t, os.MkdirAll(mock.Path("/etc"), 0700), "Setup: could not create /etc")
+			if tc.environment != "" {
+				require.NoError(t, os.WriteFile(envPath, []byte(tc.environment), 0600), "Setup: could not write /etc/environment")
+			}
+			if tc.previousConfig {
+				for _, path := range []string{aptPath, systemdPath} {
+					require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700), "Setup: could not create configuration directory")
+					require.NoError(t, os.WriteFile(path, []byte("previous config"), 0600), "Setup: could not write previous config")
+				}
+			}
+			if tc.breakAptConfD {
+				require.NoError(t, os.MkdirAll(mock.Path("/etc/apt"), 0700), "Setup: could not create /etc/apt")
+				require.NoError(t, os.WriteFile(filepath.Dir(aptPath), nil, 0600), "Setup: could not break apt.conf.d")
+			}
+			if tc.breakEnvironment {
+				require.NoError(t, os.MkdirAll(envPath, 0700), "Setup: could not break /etc/environment")
+			}
+			if tc.breakSystemConfD {
+				require.NoError(t, os.MkdirAll(mock.Path("/etc/systemd"), 0700), "Setup: could not create /etc/systemd")
+				require.NoError(t, os.WriteFile(filepath.Dir(systemdPath), nil, 0600), "Setup: could not break system.conf.d")
+			}
+
+			err := s.ConfigureProxy(ctx, tc.http, tc.https, tc.noProxy)
+			if tc.wantErr {
+				require.Error(t, err, "ConfigureProxy should have returned an error")
+				return
+			}
+			require.NoError(t, err, "ConfigureProxy should have succeeded")
+
+			if tc.wantNoConfig {
+				require.NoFileExists(t, aptPath, "The apt proxy configuration should have been removed")
+				require.NoFileExists(t, systemdPath, "The systemd proxy configuration should have been removed")
+			}
+
+			for file, path := range map[string]string{"apt": aptPath, "environment": envPath, "systemd": systemdPath} {
+				got, err := os.ReadFile(path)
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				require.NoError(t, err, "Could not read the %s configuration", file)
+
+				want := commontestutils.LoadWithUpdateFromGolden(t, string(got), commontestutils.WithGoldenPath(filepath.Join(commontestutils.Path(t), file)))
+				require.Equal(t, want, string(got), "Unexpected %s configuration", file)
+			}
+		})
+	}
+}
+
+func TestConfigureSnapd(t *testing.T) {
+	t.Parallel()
+
+	const assertions = "type: account-key\nmock assertions\n"
+
+	testCases := map[string]struct {
+		http       string
+		https      string
+		storeProxy string
+
+		breakStoreProxy bool
+		breakSnap       bool
+
+		wantSet   string
+		wantUnset string
+		wantAck   bool
+		wantErr   bool
+	}{
+		"Success with HTTP and HTTPS proxies": {http: "http://proxy.example.com:3128", https: "http://proxy.example.com:3129", wantSet: "proxy.http=http://proxy.example.com:3128\nproxy.https=http://proxy.example.com:3129\n", wantUnset: "proxy.store\n"},
+		"Success with an HTTPS proxy":         {https: "http://proxy.example.com:3128", wantSet: "proxy.https=http://proxy.example.com:3128\n", wantUnset: "proxy.http\nproxy.store\n"},
+		"Success with a snap store proxy":     {storeProxy: "STORE_PROXY", wantSet: "proxy.store=mock-store-id\n", wantUnset: "proxy.http\nproxy.https\n", wantAck: true},
+		"Success removing the configuration":  {wantUnset: "proxy.http\nproxy.https\nproxy.store\n"},
+
+		"Error when the snap store proxy cannot be reached": {storeProxy: "STORE_PROXY", breakStoreProxy: true, wantErr: true},
+		"Error when snap fails":                             {http: "http://proxy.example.com:3128", breakSnap: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			s, mock := testutils.MockSystem(t)
+			if tc.breakSnap {
+				mock.SetControlArg(testutils.SnapErr)
+			}
+
+			storeID := ""
+			if tc.storeProxy != "" {
+				storeID = "mock-store-id"
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if tc.breakStoreProxy || r.URL.Path != "/v2/auth/store/assertions" {
+						http.NotFound(w, r)
+						return
+					}
+					fmt.Fprint(w, assertions)
+				}))
+				defer server.Close()
+				tc.storeProxy = server.URL
+			}
+
+			err := s.ConfigureSnapd(ctx, tc.http, tc.https, tc.storeProxy, storeID)
+			if tc.wantErr {
+				require.Error(t, err, "ConfigureSnapd should have returned an error")
+				return
+			}
+			require.NoError(t, err, "ConfigureSnapd should have succeeded")
+
+			for marker, want := range map[string]string{".snap-set": tc.wantSet, ".snap-unset": tc.wantUnset} {
+				got, err := os.ReadFile(mock.Path(marker))
+				if want == "" {
+					require.ErrorIs(t, err, fs.ErrNotExist, "snap should not have been called to %s anything", strings.TrimPrefix(marker, ".snap-"))
+					continue
+				}
+				require.NoError(t, err, "snap should have been called to %s options", strings.TrimPrefix(marker, ".snap-"))
+				require.Equal(t, want, string(got), "Mismatched options passed to snap %s", strings.TrimPrefix(marker, ".snap-"))
+			}
+
+			got, err := os.ReadFile(mock.Path(".snap-ack"))
+			if !tc.wantAck {
+				require.ErrorIs(t, err, fs.ErrNotExist, "snap ack should not have been called")
+				return
+			}
+			require.NoError(t, err, "snap ack should have been called")
+			require.Equal(t, assertions, string(got), "snap ack should have been passed the assertions of the snap store proxy")
+		})
+	}
+}
+
+func TestConfigureDNS(t *testing.T) {
+	t.Parallel()
+
+	const (
+		wslTarget     = "/mnt/wsl/resolv.conf"
+		managedHeader = "# This file is managed by Ubuntu Pro for WSL. Local changes will be overwritten.\n"
+		hostsBegin    = "# BEGIN Ubuntu Pro for WSL host entries. Local changes in this block will be overwritten.\n"
+		hostsEnd      = "# END Ubuntu Pro for WSL host entries\n"
+		wslHosts      = "127.0.0.1\tlocalhost\n"
+	)
+
+	testCases := map[string]struct {
+		nameservers   []string
+		searchDomains []string
+		hosts         []string
+
+		managedResolvConf bool
+		hostsContents     string
+		breakWslConf      bool
+		breakHostsState   bool
+
+		wantResolvConf string
+		wantHosts      string
+		wantErr        bool
+	}{
+		"Success with nameservers":                           {nameservers: []string{"10.0.0.53", "fd00::53"}, wantResolvConf: managedHeader + "nameserver 10.0.0.53\nnameserver fd00::53\n"},
+		"Success with nameservers and search domains":        {nameservers: []string{"10.0.0.53"}, searchDomains: []string{"corp.example.com", "example.com"}, wantResolvConf: managedHeader + "nameserver 10.0.0.53\nsearch corp.example.com example.com\n"},
+		"Success replacing managed nameservers":              {nameservers: []string{"10.0.0.54"}, managedResolvConf: true, wantResolvConf: managedHeader + "nameserver 10.0.0.54\n"},
+		"Success restoring the resolv.conf generated by WSL": {managedResolvConf: true},
+		"Success leaving an unmanaged resolv.conf untouched": {},
+		"Success adding host entries":                        {hosts: []string{"10.0.0.1 intranet.corp.example.com intranet"}, hostsContents: wslHosts, wantHosts: wslHosts + hostsBegin + "10.0.0.1 intranet.corp.example.com intranet\n" + hostsEnd},
+		"Success replacing host entries":                     {hosts: []string{"10.0.0.2 build"}, hostsContents: wslHosts + hostsBegin + "10.0.0.1 intranet\n" + hostsEnd, wantHosts: wslHosts + hostsBegin + "10.0.0.2 build\n" + hostsEnd},
+		"Success removing host entries":                      {hostsContents: wslHosts + hostsBegin + "10.0.0.1 intranet\n" + hostsEnd, wantHosts: wslHosts},
+		"Success leaving hosts with an unterminated block":   {hosts: []string{"10.0.0.2 build"}, hostsContents: wslHosts + hostsBegin, wantHosts: wslHosts + hostsBegin},
+
+		"Error with a nameserver that is not an IP address": {nameservers: []string{"dns.example.com"}, wantErr: true},
+		"Error with search domains without nameservers":     {searchDomains: []string{"corp.example.com"}, wantErr: true},
+		"Error with a host entry without host names":        {hosts: []string{"10.0.0.1"}, wantErr: true},
+		"Error with a host entry without IP address":        {hosts: []string{"intranet intranet.corp.example.com"}, wantErr: true},
+		"Error when wsl.conf cannot be written":             {nameservers: []string{"10.0.0.53"}, breakWslConf: true, wantErr: true},
+		"Error when the host entries cannot be recorded":    {hosts: []string{"10.0.0.1 intranet"}, breakHostsState: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			s, mock := testutils.MockSystem(t)
+			resolvConf := mock.Path(system.ResolvConfPath)
+			backup := mock.Path(system.ResolvConfBackupPath)
+			hosts := mock.Path(system.HostsPath)
+			wslConf := mock.Path("/etc/wsl.conf")
+
+			// WSL generates resolv.conf as a symlink to a file of its own.
+			require.NoError(t, os.MkdirAll(mock.Path("/etc"), 0700), "Setup: could not create /etc")
+			require.NoError(t, os.WriteFile(wslConf, []byte("[boot]\nsystemd = true\n"), 0600), "Setup: could not write wsl.conf")
+			if tc.managedResolvConf {
+				require.NoError(t, os.Symlink(wslTarget, backup), "Setup: could not create the backup of resolv.conf")
+				require.NoError(t, os.WriteFile(resolvConf, []byte(managedHeader+"nameserver 10.0.0.53\n"), 0600), "Setup: could not write resolv.conf")
+				require.NoError(t, os.WriteFile(wslConf, []byte("[boot]\nsystemd = true\n\n[network]\ngenerateResolvConf = false\n"), 0600), "Setup: could not write wsl.conf")
+			} else {
+				require.NoError(t, os.Symlink(wslTarget, resolvConf), "Setup: could not create resolv.conf")
+			}
+			if tc.hostsContents != "" {
+				require.NoError(t, os.WriteFile(hosts, []byte(tc.hostsContents), 0600), "Setup: could not write /etc/hosts")
+			}
+			if tc.breakWslConf {
+				commontestutils.ReplaceFileWithDir(t, wslConf+".new", "Setup: could not create directory to interfere with wsl.conf")
+			}
+			if tc.breakHostsState {
+				require.NoError(t, os.MkdirAll(mock.Path("/var/lib"), 0700), "Setup: could not create /var/lib")
+				require.NoError(t, os.WriteFile(mock.Path("/var/lib/wsl-pro-service"), nil, 0600), "Setup: could not break the state directory")
+			}
+
+			err := s.ConfigureDNS(ctx, tc.nameservers, tc.searchDomains, tc.hosts)
+			if tc.wantErr {
+				require.Error(t, err, "ConfigureDNS should have returned an error")
+				return
+			}
+			require.NoError(t, err, "ConfigureDNS should have succeeded")
+
+			conf, err := os.ReadFile(wslConf)
+			require.NoError(t, err, "Could not read wsl.conf")
+			require.Contains(t, string(conf), "systemd = true", "The rest of wsl.conf should have been preserved")
+
+			if tc.wantResolvConf != "" {
+				got, err := os.ReadFile(resolvConf)
+				require.NoError(t, err, "Could not read resolv.conf")
+				require.Equal(t, tc.wantResolvConf, string(got), "Mismatched resolv.conf contents")
+				require.Contains(t, string(conf), "generateResolvConf = false", "WSL should have been told not to generate resolv.conf")
+
+				target, err := os.Readlink(backup)
+				require.NoError(t, err, "The resolv.conf generated by WSL should have been kept")
+				require.Equal(t, wslTarget, target, "The resolv.conf generated by WSL should have been kept as it was")
+			} else {
+				target, err := os.Readlink(resolvConf)
+				require.NoError(t, err, "resolv.conf should be the one generated by WSL")
+				require.Equal(t, wslTarget, target, "resolv.conf should be the one generated by WSL")
+				require.NotContains(t, string(conf), "generateResolvConf", "WSL should generate resolv.conf again")
+				require.NotContains(t, string(conf), "[network]", "Empty sections should not be left behind in wsl.conf")
+				require.NoFileExists(t, backup, "The backup of resolv.conf should have been restored")
+			}
+
+			got, err := os.ReadFile(hosts)
+			if tc.wantHosts == "" {
+				require.ErrorIs(t, err, fs.ErrNotExist, "/etc/hosts should not have been written")
+				return
+			}
+			require.NoError(t, err, "Could not read /etc/hosts")
+			require.Equal(t, tc.wantHosts, string(got), "Mismatched /etc/hosts contents")
+		})
+	}
+}
+
+func TestRestoreHostEntries(t *testing.T) {
+	t.Parallel()
+
+	const wslHosts = "127.0.0.1\tlocalhost\n"
+
+	testCases := map[string]struct {
+		hosts []string
+
+		wantHosts string
+	}{
+		"Success adding the managed host entries back": {hosts: []string{"10.0.0.1 intranet", "10.0.0.2 build"}, wantHosts: wslHosts + "# BEGIN Ubuntu Pro for WSL host entries. Local changes in this block will be overwritten.\n10.0.0.1 intranet\n10.0.0.2 build\n# END Ubuntu Pro for WSL host entries\n"},
+		"Success without managed host entries":         {wantHosts: wslHosts},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			s, mock := testutils.MockSystem(t)
+			hosts := mock.Path(system.HostsPath)
+			require.NoError(t, os.MkdirAll(mock.Path("/etc"), 0700), "Setup: could not create /etc")
+
+			if len(tc.hosts) != 0 {
+				require.NoError(t, s.ConfigureDNS(ctx, nil, nil, tc.hosts), "Setup: could not configure the host entries")
+			}
+
+			// WSL regenerates /etc/hosts when the distro boots.
+			require.NoError(t, os.WriteFile(hosts, []byte(wslHosts), 0600), "Setup: could not write /etc/hosts")
+
+			err := s.RestoreHostEntries(ctx)
+			require.NoError(t, err, "RestoreHostEntries should have succeeded")
+
+			got, err := os.ReadFile(hosts)
+			require.NoError(t, err, "Could not read /etc/hosts")
+			require.Equal(t, tc.wantHosts, string(got), "Mismatched /etc/hosts contents")
+		})
+	}
+}
+
 func TestWindowsHostAddress(t *testing.T) {
 	t.Parallel()
 
@@ -574,6 +1608,7 @@ func TestWindowsHostAddress(t *testing.T) {
 	testCases := map[string]struct {
 		networkNotNAT bool
 		breakWslInfo  bool
+		wsl1          bool
 
 		procNetRoute fileState
 
@@ -582,6 +1617,7 @@ func TestWindowsHostAddress(t *testing.T) {
 	}{
 		"Without NAT": {networkNotNAT: true, want: localhost},
 		"With NAT":    {want: defaultGway},
+		"On WSL 1":    {wsl1: true, breakWslInfo: true, want: localhost},
 
 		// WSL info errors
 		"Error when wslinfo returns an error": {breakWslInfo: true, wantErr: true},
@@ -605,6 +1641,10 @@ func TestWindowsHostAddress(t *testing.T) {
 			if !tc.networkNotNAT {
 				mock.SetControlArg(testutils.WslInfoIsNAT)
 			}
+			if tc.wsl1 {
+				err := os.WriteFile(mock.Path("/proc/sys/kernel/osrelease"), []byte("4.4.0-19041-Microsoft\n"), 0600)
+				require.NoError(t, err, "Setup: could not overwrite /proc/sys/kernel/osrelease")
+			}
 
 			copyFile(t, tc.procNetRoute, filepath.Join(commontestutils.TestFamilyPath(t), "proc-net-route"), mock.Path("/proc/net/route"))
 
@@ -619,6 +1659,61 @@ func TestWindowsHostAddress(t *testing.T) {
 	}
 }
 
+func TestWindowsHostAddressCandidates(t *testing.T) {
+	t.Parallel()
+
+	const (
+		nameserver  = "172.25.32.1"
+		defaultGway = "172.25.32.1"
+		localhost   = "127.0.0.1"
+	)
+
+	testCases := map[string]struct {
+		resolvConf        string
+		breakProcNetRoute bool
+		noResolvConf      bool
+
+		wantResolvConf     string
+		wantDefaultGateway string
+	}{
+		"Success": {resolvConf: "# Generated by WSL\nnameserver 172.25.32.1\n", wantResolvConf: nameserver, wantDefaultGateway: defaultGway},
+		"Success with a search domain before the nameserver": {resolvConf: "search example.com\nnameserver 172.25.32.1\nnameserver 8.8.8.8\n", wantResolvConf: nameserver, wantDefaultGateway: defaultGway},
+
+		"Error in resolv.conf strategy when the file does not exist":     {noResolvConf: true, wantDefaultGateway: defaultGway},
+		"Error in resolv.conf strategy when there is no nameserver":      {resolvConf: "search example.com\n", wantDefaultGateway: defaultGway},
+		"Error in resolv.conf strategy when the nameserver is not an IP": {resolvConf: "nameserver localhost\n", wantDefaultGateway: defaultGway},
+		"Error in gateway strategy when /proc/net/route does not exist":  {resolvConf: "nameserver 172.25.32.1\n", breakProcNetRoute: true, wantResolvConf: nameserver},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sys, mock := testutils.MockSystem(t)
+
+			if !tc.noResolvConf {
+				require.NoError(t, os.WriteFile(mock.Path("/etc/resolv.conf"), []byte(tc.resolvConf), 0600), "Setup: could not write resolv.conf")
+			}
+			if tc.breakProcNetRoute {
+				require.NoError(t, os.RemoveAll(mock.Path("/proc/net/route")), "Setup: could not remove /proc/net/route")
+			}
+
+			got := sys.WindowsHostAddressCandidates()
+			require.Len(t, got, 3, "There should be one candidate per strategy")
+
+			for i, want := range []string{tc.wantResolvConf, tc.wantDefaultGateway, localhost} {
+				if want == "" {
+					require.Errorf(t, got[i].Err, "Strategy %q should have failed", got[i].Strategy)
+					require.Nil(t, got[i].IP, "Strategy %q should not return an address", got[i].Strategy)
+					continue
+				}
+				require.NoErrorf(t, got[i].Err, "Strategy %q should not have failed", got[i].Strategy)
+				require.Equal(t, want, got[i].IP.String(), "Wrong address for strategy %q", got[i].Strategy)
+			}
+		})
+	}
+}
+
 func TestLandscapeDisable(t *testing.T) {
 	t.Parallel()
 
@@ -811,8 +1906,15 @@ func assertBasePath(t *testing.T, wantBase, got, msg string) {
 	assert.Equalf(t, wantBase, base, "Mismatch in base path.\n%s", msg)
 }
 
-func TestWithProMock(t *testing.T)             { testutils.ProMock(t) }
-func TestWithLandscapeConfigMock(t *testing.T) { testutils.LandscapeConfigMock(t) }
-func TestWithWslPathMock(t *testing.T)         { testutils.WslPathMock(t) }
-func TestWithWslInfoMock(t *testing.T)         { testutils.WslInfoMock(t) }
-func TestWithCmdExeMock(t *testing.T)          { testutils.CmdExeMock(t) }
+func TestWithProMock(t *testing.T)              { testutils.ProMock(t) }
+func TestWithLandscapeConfigMock(t *testing.T)  { testutils.LandscapeConfigMock(t) }
+func TestWithWslPathMock(t *testing.T)          { testutils.WslPathMock(t) }
+func TestWithWslInfoMock(t *testing.T)          { testutils.WslInfoMock(t) }
+func TestWithCmdExeMock(t *testing.T)           { testutils.CmdExeMock(t) }
+func TestWithUsgMock(t *testing.T)              { testutils.UsgMock(t) }
+func TestWithAptGetMock(t *testing.T)           { testutils.AptGetMock(t) }
+func TestWithAddAptRepositoryMock(t *testing.T) { testutils.AddAptRepositoryMock(t) }
+func TestWithJournalctlMock(t *testing.T)       { testutils.JournalctlMock(t) }
+func TestWithUseraddMock(t *testing.T)          { testutils.UseraddMock(t) }
+func TestWithUsermodMock(t *testing.T)          { testutils.UsermodMock(t) }
+func TestWithSnapMock(t *testing.T)             { testutils.SnapMock(t) }
@@ -0,0 +1,119 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+	"gopkg.in/ini.v1"
+)
+
+// wslConfPath is the path to the per-distro WSL configuration file.
+const wslConfPath = "/etc/wsl.conf"
+
+// userNameRegex matches the user and group names accepted by useradd on Ubuntu.
+var userNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// ManageUser creates the user if it does not exist yet, adds it to the supplementary groups
+// and, if requested, sets it as the default user of the distro in /etc/wsl.conf. The new
+// default user is used the next time the distro starts. Every step is safe to repeat, so it
+// can be preempted (see WithPreemption) in between.
+func (s *System) ManageUser(ctx context.Context, name string, groups []string, setDefault bool) (err error) {
+	defer decorate.OnError(&err, "could not manage user %q", name)
+
+	if !userNameRegex.MatchString(name) {
+		return errors.New("invalid user name")
+	}
+
+	for _, g := range groups {
+		if !userNameRegex.MatchString(g) {
+			return fmt.Errorf("invalid group name %q", g)
+		}
+	}
+
+	_, err = s.backend.LookupUser(name)
+	var unknown user.UnknownUserError
+	if errors.As(err, &unknown) {
+		log.Infof(ctx, "Creating user %q", name)
+		cmd := s.backend.UseraddExecutable(ctx, "--create-home", "--shell", "/bin/bash", "--user-group", name)
+		if _, err := runCommand(cmd); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return fmt.Errorf("could not look up user: %v", err)
+	}
+
+	if err := SafePoint(ctx); err != nil {
+		return err
+	}
+
+	if len(groups) > 0 {
+		log.Infof(ctx, "Adding user %q to groups %s", name, strings.Join(groups, ", "))
+		cmd := s.backend.UsermodExecutable(ctx, "--append", "--groups", strings.Join(groups, ","), name)
+		if _, err := runCommand(cmd); err != nil {
+			return err
+		}
+	}
+
+	if !setDefault {
+		return nil
+	}
+
+	if err := SafePoint(ctx); err != nil {
+		return err
+	}
+
+	log.Infof(ctx, "Setting %q as the default user", name)
+	return s.setDefaultUser(name)
+}
+
+// setDefaultUser sets the default user in /etc/wsl.conf, preserving the rest of its contents.
+func (s *System) setDefaultUser(name string) (err error) {
+	defer decorate.OnError(&err, "could not set the default user in %s", wslConfPath)
+
+	return s.editWSLConf(func(conf *ini.File) {
+		conf.Section("user").Key("default").SetValue(name)
+	})
+}
+
+// editWSLConf applies edit to /etc/wsl.conf, preserving the rest of its contents. The file is created if needed.
+func (s *System) editWSLConf(edit func(conf *ini.File)) error {
+	path := s.backend.Path(wslConfPath)
+
+	conf, err := ini.LooseLoad(path)
+	if err != nil {
+		return fmt.Errorf("could not parse: %v", err)
+	}
+
+	edit(conf)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".new"
+	if err := conf.SaveTo(tmp); err != nil {
+		return err
+	}
+
+	// wsl.conf is read by WSL as root, but it is world-readable by convention.
+	//nolint:gosec // See above.
+	if err := os.Chmod(tmp, 0644); err != nil {
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+
+	return nil
+}
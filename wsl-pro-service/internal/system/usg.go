@@ -0,0 +1,50 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ubuntu/decorate"
+)
+
+// UsgFix applies the remediations of the given Ubuntu Security Guide profile to the current distro.
+func (s *System) UsgFix(ctx context.Context, profile string) (err error) {
+	defer decorate.OnError(&err, "usg fix %s", profile)
+
+	cmd := s.backend.UsgExecutable(ctx, "fix", profile)
+	if _, err := runCommand(cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UsgAudit audits the current distro against the given Ubuntu Security Guide profile
+// and returns the resulting HTML report.
+func (s *System) UsgAudit(ctx context.Context, profile string) (report []byte, err error) {
+	defer decorate.OnError(&err, "usg audit %s", profile)
+
+	dir, err := os.MkdirTemp("", "usg-audit-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	reportPath := filepath.Join(dir, "report.html")
+
+	// usg audit exits with non-zero status when the distro is not compliant, but still writes the report.
+	cmd := s.backend.UsgExecutable(ctx, "audit", profile, "--html-file", reportPath)
+	_, auditErr := runCommand(cmd)
+
+	report, err = os.ReadFile(reportPath)
+	if err != nil {
+		if auditErr != nil {
+			return nil, auditErr
+		}
+		return nil, fmt.Errorf("could not read report: %v", err)
+	}
+
+	return report, nil
+}
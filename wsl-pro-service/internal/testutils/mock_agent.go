@@ -11,7 +11,6 @@ import (
 	"math/big"
 	"net"
 	"os"
-	"path/filepath"
 	"sync"
 	"testing"
 
@@ -19,6 +18,7 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/certs"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	commontestutils "github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
 	"github.com/stretchr/testify/require"
 	"github.com/ubuntu/decorate"
 	"google.golang.org/grpc"
@@ -33,6 +33,9 @@ type MockWindowsAgent struct {
 
 	ClientCredentials credentials.TransportCredentials
 
+	// Chaos injects network faults into the connections to the agent.
+	Chaos *commontestutils.Chaos
+
 	Started chan struct{}
 	Stopped chan struct{}
 }
@@ -51,10 +54,12 @@ func NewMockWindowsAgent(t *testing.T, ctx context.Context, publicDir string) *M
 	lis, err := cfg.Listen(ctx, "tcp4", "localhost:0")
 	require.NoError(t, err, "Setup: could not listen to agent address")
 
-	clientCreds, serverCreds := agentTLSCreds(t, filepath.Join(publicDir, common.CertificatesDir))
+	clientCreds, serverCreds := agentTLSCreds(t, common.CertificatesPath(publicDir))
 
+	chaos := &commontestutils.Chaos{}
 	m := MockWindowsAgent{
-		Listener:          lis,
+		Listener:          chaos.Listen(lis),
+		Chaos:             chaos,
 		Server:            grpc.NewServer(grpc.Creds(serverCreds)),
 		Service:           &mockWSLInstanceService{},
 		ClientCredentials: clientCreds,
@@ -64,7 +69,7 @@ func NewMockWindowsAgent(t *testing.T, ctx context.Context, publicDir string) *M
 	agentapi.RegisterWSLInstanceServer(m.Server, m.Service)
 	t.Cleanup(m.Stop)
 
-	addrFile := filepath.Join(publicDir, common.ListeningPortFileName)
+	addrFile := common.AddressFile(publicDir)
 	err = os.WriteFile(addrFile, []byte(lis.Addr().String()), 0600)
 	if err != nil {
 		close(m.Started)
@@ -78,7 +83,7 @@ func NewMockWindowsAgent(t *testing.T, ctx context.Context, publicDir string) *M
 		close(m.Started)
 		defer close(m.Stopped)
 
-		if err := m.Server.Serve(lis); err != nil {
+		if err := m.Server.Serve(m.Listener); err != nil {
 			log.Infof(ctx, "MockWindowsAgent: Serve returned an error: %v", err)
 		}
 
@@ -107,7 +112,7 @@ func agentTLSCreds(t *testing.T, destDir string) (wslProService, agentCreds cred
 	// Create and write the server and client certificates signed by the root certificate created above.
 	agentCert, err := certs.CreateTLSCertificateSignedBy("server", common.GRPCServerNameOverride, serial.Rsh(serial, 2), rootCert, rootKey, destDir)
 	require.NoError(t, err, "failed to create agent certificate", err)
-	wslProServiceCert, err := certs.CreateTLSCertificateSignedBy("client", "wsl-pro-service-test", serial.Lsh(serial, 3), rootCert, rootKey, destDir)
+	wslProServiceCert, err := certs.CreateTLSCertificateSignedBy(common.WSLProServiceCertFilePrefix, "wsl-pro-service-test", serial.Lsh(serial, 3), rootCert, rootKey, destDir)
 	require.NoError(t, err, "failed to create WSL Pro service certificate", err)
 
 	ca := x509.NewCertPool()
@@ -143,20 +148,66 @@ func (m *MockWindowsAgent) Stop() {
 	<-m.Stopped
 }
 
+// WaitForConnection blocks until the WSL Pro service is connected to every stream of the agent,
+// or the context is done.
+func (m *MockWindowsAgent) WaitForConnection(ctx context.Context) error {
+	for {
+		changed := m.Service.connectionChanged()
+		if m.Service.AllConnected() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("MockWindowsAgent: WSL Pro service did not connect: %v", ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
 type mockWSLInstanceService struct {
 	agentapi.UnimplementedWSLInstanceServer
 
-	Connect         channel[agentapi.DistroInfo, int, agentapi.WSLInstance_ConnectedServer]
+	// changed is closed and replaced every time a stream connects.
+	changedMu sync.Mutex
+	changed   chan struct{}
+
+	Connect         channel[agentapi.DistroMessage, agentapi.HandshakeAck, agentapi.WSLInstance_SessionServer]
 	ProAttachment   channel[agentapi.MSG, agentapi.ProAttachCmd, agentapi.WSLInstance_ProAttachmentCommandsServer]
 	LandscapeConfig channel[agentapi.MSG, agentapi.LandscapeConfigCmd, agentapi.WSLInstance_LandscapeConfigCommandsServer]
+	Command         channel[agentapi.MSG, agentapi.Command, agentapi.WSLInstance_CommandsServer]
+	Logs            channel[agentapi.LogMessage, agentapi.TailLogCmd, agentapi.WSLInstance_TailLogServer]
+	Pings           channel[agentapi.PingReply, agentapi.PingCmd, agentapi.WSLInstance_PingServer]
 }
 
 func (s *mockWSLInstanceService) AllConnected() bool {
-	return s.Connect.connected() && s.ProAttachment.connected() && s.LandscapeConfig.connected()
+	return s.Connect.connected() && s.ProAttachment.connected() && s.LandscapeConfig.connected() && s.Command.connected() && s.Logs.connected() && s.Pings.connected()
 }
 
 func (s *mockWSLInstanceService) AnyConnected() bool {
-	return s.Connect.connected() || s.ProAttachment.connected() || s.LandscapeConfig.connected()
+	return s.Connect.connected() || s.ProAttachment.connected() || s.LandscapeConfig.connected() || s.Command.connected() || s.Logs.connected() || s.Pings.connected()
+}
+
+// connectionChanged returns a channel that is closed the next time a stream connects.
+func (s *mockWSLInstanceService) connectionChanged() <-chan struct{} {
+	s.changedMu.Lock()
+	defer s.changedMu.Unlock()
+
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return s.changed
+}
+
+// notifyConnection wakes up those waiting for a stream to connect.
+func (s *mockWSLInstanceService) notifyConnection() {
+	s.changedMu.Lock()
+	defer s.changedMu.Unlock()
+
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
 }
 
 type receiver[Recv any] interface {
@@ -217,16 +268,10 @@ func (ch *channel[Recv, Send, Stream]) Send(msg *Send) error {
 	return snd.Send(msg)
 }
 
-func (ch *channel[Recv, Send, Stream]) recv() (*Recv, error) {
-	ch.mu.Lock()
-	tmp := ch.stream
-	ch.mu.Unlock()
-
-	if tmp == nil {
-		return nil, errors.New("MockWindowsAgent: not connected")
-	}
-
-	r, ok := any(*tmp).(receiver[Recv])
+// recv receives a message from the stream, which is the one the caller is handling rather than the
+// latest one: after a reconnection, the handler of the old stream must not steal messages from the new one.
+func (ch *channel[Recv, Send, Stream]) recv(s Stream) (*Recv, error) {
+	r, ok := any(s).(receiver[Recv])
 	if !ok {
 		panic("MockWindowsAgent: this channel cannot receive")
 	}
@@ -252,34 +297,48 @@ func (ch *channel[Recv, Send, Stream]) set(s Stream, helloMsg *Recv) {
 	ch.recvHistory = append(ch.recvHistory, *helloMsg)
 }
 
-func (ch *channel[Recv, Send, Stream]) reset() {
+// reset forgets the stream, unless it has already been replaced by a newer one.
+func (ch *channel[Recv, Send, Stream]) reset(s Stream) {
 	ch.mu.Lock()
 	defer ch.mu.Unlock()
 
+	if ch.stream == nil || any(*ch.stream) != any(s) {
+		return
+	}
 	ch.stream = nil
 }
 
-func (s *mockWSLInstanceService) Connected(stream agentapi.WSLInstance_ConnectedServer) (err error) {
+func (s *mockWSLInstanceService) Session(stream agentapi.WSLInstance_SessionServer) (err error) {
 	defer decorate.LogOnError(&err)
 
 	msg, err := stream.Recv()
 	if err != nil {
 		return err
-	} else if msg.GetWslName() == "" {
+	}
+
+	h := msg.GetHandshake()
+	if h == nil {
+		return errors.New("MockWindowsAgent: the first message is not a handshake")
+	} else if h.GetWslName() == "" {
 		return errors.New("MockWindowsAgent: WSL name not provided")
 	}
 
+	if err := stream.Send(&agentapi.HandshakeAck{ProtocolVersion: h.GetProtocolVersion(), Capabilities: h.GetCapabilities()}); err != nil {
+		return err
+	}
+
 	s.Connect.set(stream, msg)
-	defer s.Connect.reset()
+	defer s.Connect.reset(stream)
+	s.notifyConnection()
 
-	log.Info(stream.Context(), "MockWindowsAgent: Connected ready")
+	log.Info(stream.Context(), "MockWindowsAgent: Session ready")
 
 	for {
-		_, err := s.Connect.recv()
+		_, err := s.Connect.recv(stream)
 		if errors.Is(err, io.EOF) {
 			return nil
 		} else if err != nil {
-			return fmt.Errorf("MockWindowsAgent: Connected stopped: %v", err)
+			return fmt.Errorf("MockWindowsAgent: Session stopped: %v", err)
 		}
 	}
 }
@@ -295,12 +354,13 @@ func (s *mockWSLInstanceService) ProAttachmentCommands(stream agentapi.WSLInstan
 	}
 
 	s.ProAttachment.set(stream, msg)
-	defer s.ProAttachment.reset()
+	defer s.ProAttachment.reset(stream)
+	s.notifyConnection()
 
 	log.Info(stream.Context(), "MockWindowsAgent: ProAttachmentCommands ready")
 
 	for {
-		_, err := s.ProAttachment.recv()
+		_, err := s.ProAttachment.recv(stream)
 		if errors.Is(err, io.EOF) {
 			log.Info(stream.Context(), "MockWindowsAgent: ProAttachmentCommands finished")
 			return nil
@@ -321,12 +381,13 @@ func (s *mockWSLInstanceService) LandscapeConfigCommands(stream agentapi.WSLInst
 	}
 
 	s.LandscapeConfig.set(stream, msg)
-	defer s.LandscapeConfig.reset()
+	defer s.LandscapeConfig.reset(stream)
+	s.notifyConnection()
 
 	log.Info(stream.Context(), "MockWindowsAgent: LandscapeConfigCommands ready")
 
 	for {
-		_, err := s.LandscapeConfig.recv()
+		_, err := s.LandscapeConfig.recv(stream)
 		if errors.Is(err, io.EOF) {
 			log.Info(stream.Context(), "MockWindowsAgent: LandscapeConfigCommands finished")
 			return nil
@@ -335,3 +396,84 @@ func (s *mockWSLInstanceService) LandscapeConfigCommands(stream agentapi.WSLInst
 		}
 	}
 }
+
+func (s *mockWSLInstanceService) Commands(stream agentapi.WSLInstance_CommandsServer) (err error) {
+	defer decorate.LogOnError(&err)
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if msg.GetWslName() == "" {
+		return errors.New("MockWindowsAgent: WSL name not provided")
+	}
+
+	s.Command.set(stream, msg)
+	defer s.Command.reset(stream)
+	s.notifyConnection()
+
+	log.Info(stream.Context(), "MockWindowsAgent: Commands ready")
+
+	for {
+		_, err := s.Command.recv(stream)
+		if errors.Is(err, io.EOF) {
+			log.Info(stream.Context(), "MockWindowsAgent: Commands finished")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("MockWindowsAgent: Commands stopped: %v", err)
+		}
+	}
+}
+
+func (s *mockWSLInstanceService) TailLog(stream agentapi.WSLInstance_TailLogServer) (err error) {
+	defer decorate.LogOnError(&err)
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if msg.GetWslName() == "" {
+		return errors.New("MockWindowsAgent: WSL name not provided")
+	}
+
+	s.Logs.set(stream, msg)
+	defer s.Logs.reset(stream)
+	s.notifyConnection()
+
+	log.Info(stream.Context(), "MockWindowsAgent: TailLog ready")
+
+	for {
+		_, err := s.Logs.recv(stream)
+		if errors.Is(err, io.EOF) {
+			log.Info(stream.Context(), "MockWindowsAgent: TailLog finished")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("MockWindowsAgent: TailLog stopped: %v", err)
+		}
+	}
+}
+
+func (s *mockWSLInstanceService) Ping(stream agentapi.WSLInstance_PingServer) (err error) {
+	defer decorate.LogOnError(&err)
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if msg.GetWslName() == "" {
+		return errors.New("MockWindowsAgent: WSL name not provided")
+	}
+
+	s.Pings.set(stream, msg)
+	defer s.Pings.reset(stream)
+	s.notifyConnection()
+
+	log.Info(stream.Context(), "MockWindowsAgent: Ping ready")
+
+	for {
+		_, err := s.Pings.recv(stream)
+		if errors.Is(err, io.EOF) {
+			log.Info(stream.Context(), "MockWindowsAgent: Ping finished")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("MockWindowsAgent: Ping stopped: %v", err)
+		}
+	}
+}
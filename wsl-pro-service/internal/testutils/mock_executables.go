@@ -3,8 +3,12 @@
 package testutils
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"os"
@@ -14,6 +18,7 @@ import (
 	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
@@ -40,6 +45,9 @@ type SystemMock struct {
 	// LookupGroupError makes the LookupGroup function fail.
 	LandscapeGroupGID string
 
+	// Users are the names of the users that exist in the distro.
+	Users []string
+
 	// extraEnv are extra environment variables that will be passed to mocked executables
 	extraEnv []string
 }
@@ -53,7 +61,10 @@ var (
 	linuxUserProfileDir   = filepath.Join(defaultWindowsMount, "Users/TestUser/")
 
 	// defaultPublicDir is the default path used in tests to store the address of the Windows Agent service.
-	defaultPublicDir = filepath.Join(linuxUserProfileDir, common.UserProfileDir)
+	defaultPublicDir = common.PublicDir(linuxUserProfileDir)
+
+	// packageSigningKey signs the debs of the mocked local update channel. See SignPackage.
+	packageSigningKey = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{42}, ed25519.SeedSize))
 
 	//go:embed filesystem_defaults/os-release
 	defaultOsReleaseContents []byte
@@ -63,6 +74,9 @@ var (
 
 	//go:embed filesystem_defaults/proc.net.route
 	defaultProcNetRouteContents []byte
+
+	//go:embed filesystem_defaults/proc.sys.kernel.osrelease
+	defaultKernelReleaseContents []byte
 )
 
 // controlArg Mock-controlling constants.
@@ -75,6 +89,9 @@ const (
 	ProStatusBadJSON  = "UP4W_PRO_STATUS_BAD_JSON"
 	ProStatusAttached = "UP4W_PRO_STATUS_ATTACHED"
 
+	// ProNotInstalled makes pro missing from the PATH, as on minimal images.
+	ProNotInstalled = "UP4W_PRO_NOT_INSTALLED"
+
 	ProAttachErr = "UP4W_PRO_ATTACH_ERR"
 
 	ProDetachBadJSON = "UP4W_PRO_DETACH_BAD_JSON"
@@ -83,6 +100,11 @@ const (
 	ProDetachErrGeneric         = "UP4W_PRO_DETACH_ERR_GENERIC"
 	ProDetachErrNoReason        = "UP4W_PRO_DETACH_ERR_UNKNOWN"
 
+	ProEnableErr  = "UP4W_PRO_ENABLE_ERR"
+	ProDisableErr = "UP4W_PRO_DISABLE_ERR"
+
+	ProSecurityStatusErr = "UP4W_PRO_SECURITY_STATUS_ERR"
+
 	LandscapeEnableErr  = "UP4W_LANDSCAPE_ENABLE_ERR"
 	LandscapeDisableErr = "UP4W_LANDSCAPE_DISABLE_ERR"
 
@@ -95,6 +117,20 @@ const (
 	WslInfoErr   = "UP4W_WSLINFO_ERR"
 	WslInfoIsNAT = "UP4W_WSLINFO_IS_NAT"
 
+	UsgFixErr   = "UP4W_USG_FIX_ERR"
+	UsgAuditErr = "UP4W_USG_AUDIT_ERR"
+
+	AptGetUpdateErr     = "UP4W_APT_GET_UPDATE_ERR"
+	AptGetInstallErr    = "UP4W_APT_GET_INSTALL_ERR"
+	AddAptRepositoryErr = "UP4W_ADD_APT_REPOSITORY_ERR"
+
+	JournalctlErr = "UP4W_JOURNALCTL_ERR"
+
+	UseraddErr = "UP4W_USERADD_ERR"
+	UsermodErr = "UP4W_USERMOD_ERR"
+
+	SnapErr = "UP4W_SNAP_ERR"
+
 	// FileSystemRoot contains the path to the mocked filesystem root.
 	FileSystemRoot = "UP4W_FILE_SYSTEM_ROOT"
 )
@@ -113,7 +149,7 @@ const (
 
 // MockSystem sets up a few mocks:
 // - filesystem and mock executables for wslpath, pro.
-func MockSystem(t *testing.T) (*system.System, *SystemMock) {
+func MockSystem(t *testing.T, args ...system.Option) (*system.System, *SystemMock) {
 	t.Helper()
 
 	u, err := user.Current()
@@ -128,7 +164,20 @@ func MockSystem(t *testing.T) (*system.System, *SystemMock) {
 		LandscapeGroupGID:       u.Gid,
 	}
 
-	return system.New(system.WithTestBackend(mock)), mock
+	opts := []system.Option{
+		system.WithTestBackend(mock),
+		system.WithPackageSigningKey(packageSigningKey.Public().(ed25519.PublicKey)),
+	}
+
+	return system.New(append(opts, args...)...), mock
+}
+
+// SignPackage returns the detached signature of a deb with the contents provided, as
+// expected by a mocked system next to the debs of the local update channel.
+func SignPackage(contents []byte) []byte {
+	digest := sha256.Sum256(contents)
+	sig := ed25519.Sign(packageSigningKey, digest[:])
+	return []byte(base64.StdEncoding.EncodeToString(sig))
 }
 
 // DefaultPublicDir is the location where a mocked system will expect the addr file to be located,
@@ -186,6 +235,18 @@ func (m *SystemMock) LookupGroup(name string) (*user.Group, error) {
 	}, nil
 }
 
+// LookupUser mocks the user.Lookup function.
+func (m *SystemMock) LookupUser(name string) (*user.User, error) {
+	if !slices.Contains(m.Users, name) {
+		return nil, user.UnknownUserError(name)
+	}
+
+	return &user.User{
+		Username: name,
+		HomeDir:  filepath.Join("/home", name),
+	}, nil
+}
+
 // mockExec generates a command of the form `bash -ec <SCRIPT>` that will call an alternate binary
 // to the one we are mocking.
 //
@@ -237,6 +298,10 @@ func (m *SystemMock) mockExec(ctx context.Context, fauxTestName string, argv ...
 
 // ProExecutable mocks `pro $args...`.
 func (m *SystemMock) ProExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	if slices.Contains(m.extraEnv, fmt.Sprintf("%s=1", ProNotInstalled)) {
+		//nolint:gosec // This is test code
+		return exec.CommandContext(ctx, "up4w-mock-pro-not-installed", args...)
+	}
 	return m.mockExec(ctx, "TestWithProMock", args...)
 }
 
@@ -255,6 +320,41 @@ func (m *SystemMock) WslinfoExecutable(ctx context.Context, args ...string) *exe
 	return m.mockExec(ctx, "TestWithWslInfoMock", args...)
 }
 
+// UsgExecutable mocks `usg $args...`.
+func (m *SystemMock) UsgExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithUsgMock", args...)
+}
+
+// AptGetExecutable mocks `apt-get $args...`.
+func (m *SystemMock) AptGetExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithAptGetMock", args...)
+}
+
+// AddAptRepositoryExecutable mocks `add-apt-repository $args...`.
+func (m *SystemMock) AddAptRepositoryExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithAddAptRepositoryMock", args...)
+}
+
+// JournalctlExecutable mocks `journalctl $args...`.
+func (m *SystemMock) JournalctlExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithJournalctlMock", args...)
+}
+
+// UseraddExecutable mocks `useradd $args...`.
+func (m *SystemMock) UseraddExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithUseraddMock", args...)
+}
+
+// UsermodExecutable mocks `usermod $args...`.
+func (m *SystemMock) UsermodExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithUsermodMock", args...)
+}
+
+// SnapExecutable mocks `snap $args...`.
+func (m *SystemMock) SnapExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithSnapMock", args...)
+}
+
 // CmdExe mocks `cmd.exe $args...`.
 func (m *SystemMock) CmdExe(ctx context.Context, path string, args ...string) *exec.Cmd {
 	return m.mockExec(ctx, "TestWithCmdExeMock", args...)
@@ -296,7 +396,46 @@ func ProMock(t *testing.T) {
 				return exitOk
 			}
 
-			fmt.Fprintf(os.Stdout, `{"attached": %t, "anotherfield": "potato"}%s`, envExists(ProStatusAttached), "\n")
+			attached := envExists(ProStatusAttached)
+			entitled, expires, support := "no", "n/a", "n/a"
+			if attached {
+				entitled, expires, support = "yes", "2030-01-01T00:00:00+00:00", "essential"
+			}
+
+			fmt.Fprintf(os.Stdout, `{"attached": %t, "anotherfield": "potato", "expires": %q, "contract": {"tech_support_level": %q}, "services": [{"name": "esm-apps", "entitled": %q, "status": "enabled"}, {"name": "realtime-kernel", "entitled": "no", "status": "n/a"}]}%s`, attached, expires, support, entitled, "\n")
+			return exitOk
+
+		case "security-status":
+			if envExists(ProSecurityStatusErr) {
+				return exitError
+			}
+
+			fmt.Fprintln(os.Stdout, `{"_schema_version": "0.1", "packages": [], "summary": {"num_installed_packages": 500, "num_standard_security_updates": 2, "num_esm_infra_updates": 3, "num_esm_apps_updates": 1}}`)
+			return exitOk
+
+		case "enable", "disable":
+			if len(argv) < 2 {
+				fmt.Fprintf(os.Stderr, "Pro %s expects a service\n", argv[0])
+				return exitBadUsage
+			}
+
+			if (argv[0] == "enable" && envExists(ProEnableErr)) || (argv[0] == "disable" && envExists(ProDisableErr)) {
+				fmt.Fprintf(os.Stdout, `{"errors": [{"message": "This error is produced by a mock instructed to fail on pro %s", "message_code": "mock_error"}]}%s`, argv[0], "\n")
+				return exitError
+			}
+
+			// Proving that this executable has run
+			root := os.Getenv(FileSystemRoot)
+			if root == "" {
+				fmt.Fprintf(os.Stderr, "Missing environment variable %s\n", FileSystemRoot)
+				return exitBadUsage
+			}
+
+			p := filepath.Join(root, fmt.Sprintf(".pro-%sd-%s", argv[0], argv[1]))
+			if err := os.WriteFile(p, []byte{}, 0600); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not write file: %v", err)
+			}
+
 			return exitOk
 
 		case "attach":
@@ -527,6 +666,7 @@ func WslPathMock(t *testing.T) {
 				windowsUserProfileDir:                   linuxUserProfileDir,
 				`D:\Users\TestUser\certificate`:         filepath.Join(defaultWindowsMount, "Users/TestUser/certificate"),
 				"D:/Users/TestUser/certificate":         filepath.Join(defaultWindowsMount, "Users/TestUser/certificate"),
+				`D:\Users\TestUser\wsl-pro-service.deb`: filepath.Join(defaultWindowsMount, "Users/TestUser/wsl-pro-service.deb"),
 				"/idempotent/path/to/linux/certificate": "/idempotent/path/to/linux/certificate",
 				"":                                      cwd,
 			}[argv[1]]
@@ -632,6 +772,375 @@ func CmdExeMock(t *testing.T) {
 	})
 }
 
+// UsgMock mocks the executable for `usg`.
+// Add it to your package_test with:
+//
+//	func TestWithUsgMock(t *testing.T) { testutils.UsgMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `usg`
+func UsgMock(t *testing.T) {
+	if t.Name() != "TestWithUsgMock" {
+		panic("The UsgMock faux test must be named TestWithUsgMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		if len(argv) < 2 {
+			fmt.Fprintln(os.Stderr, "Mock expected a verb and a profile")
+			return exitBadUsage
+		}
+
+		switch argv[0] {
+		case "fix":
+			// usg fix PROFILE
+			if len(argv) != 2 {
+				fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+				return exitBadUsage
+			}
+
+			if envExists(UsgFixErr) {
+				fmt.Fprintln(os.Stderr, "Fix: Mock error")
+				return exitError
+			}
+
+			root := os.Getenv(FileSystemRoot)
+			if root == "" {
+				fmt.Fprintf(os.Stderr, "Missing environment variable %s\n", FileSystemRoot)
+				return exitBadUsage
+			}
+
+			// Proving that this executable has run
+			p := filepath.Join(root, ".usg-fixed-"+argv[1])
+			if err := os.WriteFile(p, []byte{}, 0600); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not write file: %v", err)
+			}
+
+			return exitOk
+		case "audit":
+			// usg audit PROFILE --html-file PATH
+			if len(argv) != 4 || argv[2] != "--html-file" {
+				fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+				return exitBadUsage
+			}
+
+			if envExists(UsgAuditErr) {
+				fmt.Fprintln(os.Stderr, "Audit: Mock error")
+				return exitError
+			}
+
+			report := fmt.Sprintf("<html>%s</html>", argv[1])
+			if err := os.WriteFile(argv[3], []byte(report), 0600); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not write report: %v", err)
+				return exitError
+			}
+
+			return exitOk
+		default:
+			fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+			return exitBadUsage
+		}
+	})
+}
+
+// AptGetMock mocks the executable for `apt-get`.
+// Add it to your package_test with:
+//
+//	func TestWithAptGetMock(t *testing.T) { testutils.AptGetMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `apt-get`
+func AptGetMock(t *testing.T) {
+	if t.Name() != "TestWithAptGetMock" {
+		panic("The AptGetMock faux test must be named TestWithAptGetMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		if len(argv) == 0 {
+			fmt.Fprintln(os.Stderr, "Mock expected a verb")
+			return exitBadUsage
+		}
+
+		switch argv[0] {
+		case "update":
+			// apt-get update
+			if len(argv) != 1 {
+				fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+				return exitBadUsage
+			}
+
+			if envExists(AptGetUpdateErr) {
+				fmt.Fprintln(os.Stderr, "Update: Mock error")
+				return exitError
+			}
+
+			return exitOk
+		case "install":
+			// apt-get install -y PACKAGE
+			if len(argv) != 3 || argv[1] != "-y" {
+				fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+				return exitBadUsage
+			}
+
+			if envExists(AptGetInstallErr) {
+				fmt.Fprintln(os.Stderr, "Install: Mock error")
+				return exitError
+			}
+
+			root := os.Getenv(FileSystemRoot)
+			if root == "" {
+				fmt.Fprintf(os.Stderr, "Missing environment variable %s\n", FileSystemRoot)
+				return exitBadUsage
+			}
+
+			// Proving that this executable has run, and with what package
+			p := filepath.Join(root, ".apt-installed")
+			if err := os.WriteFile(p, []byte(argv[2]), 0600); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not write file: %v", err)
+			}
+
+			return exitOk
+		default:
+			fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+			return exitBadUsage
+		}
+	})
+}
+
+// AddAptRepositoryMock mocks the executable for `add-apt-repository`.
+// Add it to your package_test with:
+//
+//	func TestWithAddAptRepositoryMock(t *testing.T) { testutils.AddAptRepositoryMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `add-apt-repository`
+func AddAptRepositoryMock(t *testing.T) {
+	if t.Name() != "TestWithAddAptRepositoryMock" {
+		panic("The AddAptRepositoryMock faux test must be named TestWithAddAptRepositoryMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		// add-apt-repository -y [--remove] REPOSITORY
+		proof := ".apt-repository"
+		if len(argv) == 3 && argv[1] == "--remove" {
+			proof = ".apt-repository-removed"
+			argv = []string{argv[0], argv[2]}
+		}
+
+		if len(argv) != 2 || argv[0] != "-y" {
+			fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+			return exitBadUsage
+		}
+
+		if envExists(AddAptRepositoryErr) {
+			fmt.Fprintln(os.Stderr, "Mock error")
+			return exitError
+		}
+
+		root := os.Getenv(FileSystemRoot)
+		if root == "" {
+			fmt.Fprintf(os.Stderr, "Missing environment variable %s\n", FileSystemRoot)
+			return exitBadUsage
+		}
+
+		// Proving that this executable has run, and with what repository
+		p := filepath.Join(root, proof)
+		if err := os.WriteFile(p, []byte(argv[1]), 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write file: %v", err)
+		}
+
+		return exitOk
+	})
+}
+
+// JournalctlMock mocks the executable for `journalctl`.
+// It prints one line per requested unit, mentioning the requested priority and number of lines.
+// When following, it then prints a new line every few milliseconds until killed.
+// Add it to your package_test with:
+//
+//	func TestWithJournalctlMock(t *testing.T) { testutils.JournalctlMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `journalctl`
+func JournalctlMock(t *testing.T) {
+	if t.Name() != "TestWithJournalctlMock" {
+		panic("The JournalctlMock faux test must be named TestWithJournalctlMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		if envExists(JournalctlErr) {
+			fmt.Fprintln(os.Stderr, "Mock error")
+			return exitError
+		}
+
+		lines, priority := "all", "all"
+		var units []string
+		var follow bool
+		for _, arg := range argv {
+			switch {
+			case arg == "--no-pager", arg == "--output=short-iso":
+			case arg == "--follow":
+				follow = true
+			case strings.HasPrefix(arg, "--lines="):
+				lines = strings.TrimPrefix(arg, "--lines=")
+			case strings.HasPrefix(arg, "--priority="):
+				priority = strings.TrimPrefix(arg, "--priority=")
+			case strings.HasPrefix(arg, "--unit="):
+				units = append(units, strings.TrimPrefix(arg, "--unit="))
+			default:
+				fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+				return exitBadUsage
+			}
+		}
+
+		for _, u := range units {
+			fmt.Fprintf(os.Stdout, "%s: mock line (lines: %s, priority: %s)\n", u, lines, priority)
+		}
+
+		for i := 0; follow; i++ {
+			fmt.Fprintf(os.Stdout, "mock followed line %d\n", i)
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		return exitOk
+	})
+}
+
+// UseraddMock mocks the executable for `useradd`.
+// Add it to your package_test with:
+//
+//	func TestWithUseraddMock(t *testing.T) { testutils.UseraddMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `useradd`
+func UseraddMock(t *testing.T) {
+	if t.Name() != "TestWithUseraddMock" {
+		panic("The UseraddMock faux test must be named TestWithUseraddMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		// useradd --create-home --shell SHELL --user-group USER
+		if len(argv) != 5 || argv[0] != "--create-home" || argv[1] != "--shell" || argv[3] != "--user-group" {
+			fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+			return exitBadUsage
+		}
+
+		if envExists(UseraddErr) {
+			fmt.Fprintln(os.Stderr, "Mock error")
+			return exitError
+		}
+
+		root := os.Getenv(FileSystemRoot)
+		if root == "" {
+			fmt.Fprintf(os.Stderr, "Missing environment variable %s\n", FileSystemRoot)
+			return exitBadUsage
+		}
+
+		// Proving that this executable has run, and with what shell
+		p := filepath.Join(root, ".useradd-"+argv[4])
+		if err := os.WriteFile(p, []byte(argv[2]), 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write file: %v", err)
+		}
+
+		return exitOk
+	})
+}
+
+// UsermodMock mocks the executable for `usermod`.
+// Add it to your package_test with:
+//
+//	func TestWithUsermodMock(t *testing.T) { testutils.UsermodMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `usermod`
+func UsermodMock(t *testing.T) {
+	if t.Name() != "TestWithUsermodMock" {
+		panic("The UsermodMock faux test must be named TestWithUsermodMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		// usermod --append --groups GROUP[,GROUP...] USER
+		if len(argv) != 4 || argv[0] != "--append" || argv[1] != "--groups" {
+			fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+			return exitBadUsage
+		}
+
+		if envExists(UsermodErr) {
+			fmt.Fprintln(os.Stderr, "Mock error")
+			return exitError
+		}
+
+		root := os.Getenv(FileSystemRoot)
+		if root == "" {
+			fmt.Fprintf(os.Stderr, "Missing environment variable %s\n", FileSystemRoot)
+			return exitBadUsage
+		}
+
+		// Proving that this executable has run, and with what groups
+		p := filepath.Join(root, ".usermod-"+argv[3])
+		if err := os.WriteFile(p, []byte(argv[2]), 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write file: %v", err)
+		}
+
+		return exitOk
+	})
+}
+
+// SnapMock mocks the executable for `snap`.
+// Add it to your package_test with:
+//
+//	func TestWithSnapMock(t *testing.T) { testutils.SnapMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `snap`
+func SnapMock(t *testing.T) {
+	if t.Name() != "TestWithSnapMock" {
+		panic("The SnapMock faux test must be named TestWithSnapMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		if envExists(SnapErr) {
+			fmt.Fprintln(os.Stderr, "Mock error")
+			return exitError
+		}
+
+		root := os.Getenv(FileSystemRoot)
+		if root == "" {
+			fmt.Fprintf(os.Stderr, "Missing environment variable %s\n", FileSystemRoot)
+			return exitBadUsage
+		}
+
+		// Proving that this executable has run, and with what arguments or input
+		var marker string
+		var out []byte
+		switch {
+		case len(argv) > 2 && argv[0] == "set" && argv[1] == "system":
+			// snap set system KEY=VALUE...
+			marker, out = ".snap-set", []byte(strings.Join(argv[2:], "\n")+"\n")
+		case len(argv) > 2 && argv[0] == "unset" && argv[1] == "system":
+			// snap unset system KEY...
+			marker, out = ".snap-unset", []byte(strings.Join(argv[2:], "\n")+"\n")
+		case len(argv) == 2 && argv[0] == "ack":
+			// snap ack FILE
+			var err error
+			if out, err = os.ReadFile(argv[1]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not read assertions: %v", err)
+				return exitError
+			}
+			marker = ".snap-ack"
+		default:
+			fmt.Fprintf(os.Stderr, "Mock not implemented for args %q\n", argv)
+			return exitBadUsage
+		}
+
+		f, err := os.OpenFile(filepath.Join(root, marker), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not open file: %v", err)
+			return exitError
+		}
+		defer f.Close()
+
+		if _, err := f.Write(out); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not write file: %v", err)
+			return exitError
+		}
+
+		return exitOk
+	})
+}
+
 func envExists(arg controlArg) bool {
 	return os.Getenv(string(arg)) != ""
 }
@@ -692,6 +1201,12 @@ func MockFilesystemRoot(t *testing.T) (rootDir string) {
 	err = os.WriteFile(filepath.Join(rootDir, "/proc/net/route"), defaultProcNetRouteContents, 0600)
 	require.NoError(t, err, "Setup: could not write mock /proc/mounts")
 
+	err = os.MkdirAll(filepath.Join(rootDir, "/proc/sys/kernel"), 0750)
+	require.NoError(t, err, "Setup: could not create mock /proc/sys/kernel/")
+
+	err = os.WriteFile(filepath.Join(rootDir, "/proc/sys/kernel/osrelease"), defaultKernelReleaseContents, 0600)
+	require.NoError(t, err, "Setup: could not write mock /proc/sys/kernel/osrelease")
+
 	// Mock Windows FS
 	publicDir := filepath.Join(rootDir, defaultPublicDir)
 	err = os.MkdirAll(publicDir, 0750)